@@ -0,0 +1,52 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package main is the opi-evpn-cni plugin, installed in the CNI bin directory of the node
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"time"
+
+	pe "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+	"github.com/opiproject/opi-evpn-bridge/pkg/cni"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+func main() {
+	args := &cni.Args{
+		Command:     os.Getenv("CNI_COMMAND"),
+		ContainerID: os.Getenv("CNI_CONTAINERID"),
+		Netns:       os.Getenv("CNI_NETNS"),
+		IfName:      os.Getenv("CNI_IFNAME"),
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// the configuration is read twice, for the gateway address and by the plugin
+	stdin, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		os.Exit(1)
+	}
+	server := "localhost:50151"
+	if conf, err := cni.ParseNetConf(stdin); err == nil {
+		server = conf.Server
+	}
+	conn, err := grpc.Dial(server, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		os.Exit(1)
+	}
+	defer conn.Close()
+
+	plugin := cni.NewPlugin(pe.NewBridgePortServiceClient(conn), cni.VethLinks{})
+	if err := plugin.Run(ctx, args, bytes.NewReader(stdin), os.Stdout); err != nil {
+		conn.Close()
+		cancel()
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,57 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package main is the evpn-bench tool, loading a gateway with many objects created concurrently
+// and checking the state it ends up with
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/bench"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+func main() {
+	o := bench.DefaultOptions()
+
+	var server string
+	flag.StringVar(&server, "server", "localhost:50151", "gRPC address of the gateway.")
+	flag.IntVar(&o.Vrfs, "vrfs", o.Vrfs, "Number of Vrfs created.")
+	flag.IntVar(&o.Bridges, "bridges", o.Bridges, "Number of LogicalBridges created, on vlans from 100.")
+	flag.IntVar(&o.Ports, "ports", o.Ports, "Number of BridgePorts created, the interfaces named by --port_prefix followed by 1 to --ports have to exist on the gateway.")
+	flag.IntVar(&o.VlansPerPort, "vlans_per_port", o.VlansPerPort, "Number of LogicalBridges each BridgePort is a trunk of, access ports when 1.")
+	flag.IntVar(&o.Concurrency, "concurrency", o.Concurrency, "Number of calls in flight.")
+	flag.StringVar(&o.PortPrefix, "port_prefix", o.PortPrefix, "Prefix of the interfaces of the BridgePorts, e.g. eth for eth1, eth2...")
+	flag.BoolVar(&o.Async, "async", o.Async, "Ask the gateway to program the Vrfs in the background, the programming time is then longer than the calls.")
+	flag.DurationVar(&o.Timeout, "timeout", o.Timeout, "How long to wait for all the objects to be programmed.")
+	flag.BoolVar(&o.Cleanup, "cleanup", o.Cleanup, "Delete the objects at the end and check none is left.")
+	flag.Parse()
+
+	if err := o.Validate(); err != nil {
+		log.Fatal(err)
+	}
+	conn, err := grpc.Dial(server, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		log.Fatalf("did not connect: %v", err)
+	}
+	defer conn.Close()
+
+	report, err := bench.Run(context.Background(), bench.NewClients(conn), o)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := report.Write(os.Stdout); err != nil {
+		log.Fatal(err)
+	}
+	// a run with failed calls or an inconsistent state fails, e.g. in CI
+	if report.Failed() {
+		conn.Close()
+		os.Exit(1)
+	}
+}
@@ -7,29 +7,58 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"flag"
-	"fmt"
+	"io"
 	"log"
+	"math"
 	"net"
 	"net/http"
+	"net/http/pprof"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
 	"time"
 
 	pc "github.com/opiproject/opi-api/inventory/v1/gen/go"
 	pe "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+	"github.com/opiproject/opi-evpn-bridge/pkg/bluefield"
+	"github.com/opiproject/opi-evpn-bridge/pkg/ebpf"
 	"github.com/opiproject/opi-evpn-bridge/pkg/evpn"
+	"github.com/opiproject/opi-evpn-bridge/pkg/fake"
+	"github.com/opiproject/opi-evpn-bridge/pkg/gnmi"
+	"github.com/opiproject/opi-evpn-bridge/pkg/ipu"
+	"github.com/opiproject/opi-evpn-bridge/pkg/k8s"
+	"github.com/opiproject/opi-evpn-bridge/pkg/octeon"
+	"github.com/opiproject/opi-evpn-bridge/pkg/ovs"
+	"github.com/opiproject/opi-evpn-bridge/pkg/p4rt"
+	"github.com/opiproject/opi-evpn-bridge/pkg/sonic"
 	"github.com/opiproject/opi-evpn-bridge/pkg/utils"
 	"github.com/opiproject/opi-smbios-bridge/pkg/inventory"
 
+	"cloud.google.com/go/longrunning/autogen/longrunningpb"
 	"github.com/philippgille/gokv"
+	"github.com/philippgille/gokv/gomap"
 	"github.com/philippgille/gokv/redis"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
 
 	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/logging"
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/recovery"
 	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
 	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 )
 
@@ -40,15 +69,236 @@ func main() {
 	var httpPort int
 	flag.IntVar(&httpPort, "http_port", 8082, "The HTTP server port")
 
+	var httpAddress string
+	flag.StringVar(&httpAddress, "http_address", "", "Address the HTTP server listens on, every address with --tls, its clients then needing a client certificate as the gRPC ones, only localhost without.")
+
+	var gnmiPort int
+	flag.IntVar(&gnmiPort, "gnmi_port", 0, "The gNMI server port serving the interface counters, the BGP state and the objects to telemetry collectors (e.g.: 9339), disabled when 0.")
+
+	var pprofPort int
+	flag.IntVar(&pprofPort, "pprof_port", 0, "The port of the pprof profiles of the server (e.g.: 6060), only listening on localhost, disabled when 0.")
+
 	var tlsFiles string
 	flag.StringVar(&tlsFiles, "tls", "", "TLS files in server_cert:server_key:ca_cert format.")
 
+	var adopt bool
+	flag.BoolVar(&adopt, "adopt", false, "Adopt pre-existing bridges, vrfs, vxlan and vlan interfaces found in the kernel.")
+
+	var teardownOnExit bool
+	flag.BoolVar(&teardownOnExit, "teardown-on-exit", false, "Delete all managed kernel and FRR state on shutdown instead of leaving it in place.")
+
+	var maxConcurrent string
+	flag.StringVar(&maxConcurrent, "max_concurrent", "", "Max concurrent programming operations per object type in ObjectType=N,ObjectType=N format (e.g.: LogicalBridge=2).")
+
+	var ha bool
+	flag.BoolVar(&ha, "ha", false, "Run as one instance of an active/standby pair sharing the Redis store.")
+
+	hostname, _ := os.Hostname()
+	var haID string
+	flag.StringVar(&haID, "ha_id", hostname, "Unique ID of this instance in the HA pair.")
+
+	var configFile string
+	flag.StringVar(&configFile, "config", "", "YAML file of the settings reloaded on SIGHUP without restarting, on top of the command line: logLevel, frrAddress, liveRead, rejectDefaultVlan, hwOffload, pageTokenTtl and the faults of --fault_injection.")
+
+	var faultInjection bool
+	flag.BoolVar(&faultInjection, "fault_injection", false, "Test only: fail, delay or fake the output of the netlink and FRR calls matching the faults of the --config file.")
+
+	var logLevel string
+	flag.StringVar(&logLevel, "log_level", "debug", "Minimum level of the call logs: debug, info, warn or error.")
+
+	var frrAddress string
+	flag.StringVar(&frrAddress, "frr_address", utils.FrrAddress(), "Host the FRR daemons are reached at.")
+
+	var liveRead bool
+	flag.BoolVar(&liveRead, "live_read", false, "Check kernel devices on Get/List and return the broken objects as degraded instead of failing.")
+
+	var statusMonitor bool
+	flag.BoolVar(&statusMonitor, "status_monitor", false, "Keep the status of the objects up to date from the kernel link, neighbor and route notifications instead of looking their devices up on every Get/List.")
+
+	var vtepProbeInterval time.Duration
+	flag.DurationVar(&vtepProbeInterval, "vtep_probe_interval", 0, "Probe the remote VTEPs of the LogicalBridges, learned from the EVPN type-3 routes, with echo requests every interval and report them in the TunnelsReachable condition of the LogicalBridges, disabled when 0.")
+
+	var countersInterval time.Duration
+	flag.DurationVar(&countersInterval, "counters_interval", 0, "Refresh every interval the route counts of the Vrfs and the MAC and EVPN prefix counts of the Vrfs and LogicalBridges, returned in the x-opi-counters header of Get/List, disabled when 0.")
+
+	var orphanPolicy string
+	flag.StringVar(&orphanPolicy, "orphan_policy", "off", "What to do with the vni, br, vlan and Vrf devices and the bridge vlans no Vrf, LogicalBridge or Svi is backed by, left over by a crash or a manual experiment: off, report (log them every --orphan_sweep_interval) or remove (delete those found by two sweeps in a row).")
+
+	var orphanSweepInterval time.Duration
+	flag.DurationVar(&orphanSweepInterval, "orphan_sweep_interval", 5*time.Minute, "Interval the orphaned devices and bridge vlans are looked for at, see --orphan_policy.")
+
+	var frrMonitorInterval time.Duration
+	flag.DurationVar(&frrMonitorInterval, "frr_monitor_interval", 10*time.Second, "Check zebra and bgpd every interval and replay the FRR configuration of the Vrfs and Svis when one of them restarted, disabled when 0.")
+
+	var hwOffload bool
+	flag.BoolVar(&hwOffload, "hw_offload", false, "Report in the HwOffloaded condition of the BridgePorts whether the switchdev driver of their port offloads their forwarding, for --dataplane=linux.")
+
+	var requireEtag bool
+	flag.BoolVar(&requireEtag, "require_etag", false, "Reject the Update and Delete calls of Vrfs, LogicalBridges, BridgePorts and Svis not sending the etag of the object in x-opi-if-match.")
+
+	var rejectDefaultVlan bool
+	flag.BoolVar(&rejectDefaultVlan, "reject_default_vlan", false, "Reject vlan 1 in LogicalBridges and VrfLiteHandoffs, vlans 0 and 4095 are always rejected.")
+
+	var quotas string
+	flag.StringVar(&quotas, "quotas", "", "Max number of objects of the whole server in Limit=N,Limit=N format, for LogicalBridge, Vrf, BridgePortsPerLogicalBridge and Vni (e.g.: LogicalBridge=1000,Vni=1024).")
+
+	var tenancy bool
+	flag.BoolVar(&tenancy, "tenancy", false, "Scope the calls to the tenant named by the common name of their client certificate, requires --tls.")
+
+	var tenantAdmins string
+	flag.StringVar(&tenantAdmins, "tenant_admins", "", "Comma separated common names of the client certificates not scoped to a tenant with --tenancy (e.g.: operator).")
+
+	var tenantQuotas string
+	flag.StringVar(&tenantQuotas, "tenant_quotas", "", "Max number of objects each tenant can create per object type in ObjectType=N,ObjectType=N format, for LogicalBridge and Vrf (e.g.: LogicalBridge=10,Vrf=2).")
+
+	vxlan := evpn.DefaultVxlanOptions()
+	flag.IntVar(&vxlan.Port, "vxlan_port", vxlan.Port, "UDP destination port of the VXLAN tunnels, 4789 or 8472 for older peers.")
+	flag.IntVar(&vxlan.TTL, "vxlan_ttl", vxlan.TTL, "TTL of the outer IP header of the VXLAN tunnels, 0 leaves the default of the dataplane.")
+	flag.IntVar(&vxlan.TOS, "vxlan_tos", vxlan.TOS, "TOS, DSCP included, of the outer IP header of the VXLAN tunnels, 1 inherits it from the inner packet.")
+	flag.BoolVar(&vxlan.Learning, "vxlan_learning", vxlan.Learning, "Learn remote MAC addresses from the VXLAN data plane too, instead of only from EVPN.")
+
+	pim := evpn.DefaultPimOptions()
+	var pimInterfaces string
+	flag.StringVar(&pimInterfaces, "pim_interfaces", strings.Join(pim.Interfaces, ","), "Comma separated interfaces running PIM for the LogicalBridges flooding to a multicast group, the VTEP loopback and the underlay uplinks.")
+	flag.StringVar(&pim.RP, "pim_rp", pim.RP, "Rendezvous point address of the multicast groups of the LogicalBridges, none for SSM groups.")
+
+	srv6 := evpn.DefaultSrv6Options()
+	flag.StringVar(&srv6.Prefix, "srv6_locator", srv6.Prefix, "IPv6 prefix of the SRv6 locator the SIDs of the Vrfs created with the srv6 overlay are allocated from, the overlay is disabled when empty.")
+	flag.StringVar(&srv6.Locator, "srv6_locator_name", srv6.Locator, "Name of the SRv6 locator in FRR.")
+
+	macMobility := evpn.DefaultMacMobilityOptions()
+	flag.IntVar(&macMobility.MaxMoves, "dad_max_moves", macMobility.MaxMoves, "Number of moves within --dad_time making a MAC address a duplicate in the EVPN duplicate address detection.")
+	flag.DurationVar(&macMobility.Time, "dad_time", macMobility.Time, "Window the moves of a MAC address are counted in by the EVPN duplicate address detection.")
+	flag.DurationVar(&macMobility.FreezeTime, "dad_freeze", macMobility.FreezeTime, "How long a duplicate MAC address is frozen at its last location, not frozen when 0.")
+	flag.BoolVar(&macMobility.FreezePermanent, "dad_freeze_permanent", macMobility.FreezePermanent, "Keep the duplicate MAC addresses frozen until they are cleared in FRR.")
+
+	gateway := evpn.DefaultGatewayConfig()
+	var localAs uint64
+	flag.Uint64Var(&localAs, "local_as", uint64(gateway.LocalAs), "AS number of the BGP instances of the gateway, the default one and those of the Vrfs.")
+	flag.StringVar(&gateway.RouterID, "router_id", gateway.RouterID, "BGP router-id of the gateway, the Vrfs with a loopback_ip_prefix use their loopback instead.")
+	flag.StringVar(&gateway.VtepIP, "vtep_ip", gateway.VtepIP, "VTEP loopback address, the source of the VXLAN tunnels of the LogicalBridges and Vrfs created without a vtep_ip_prefix.")
+
+	var pageTokenTTL time.Duration
+	var macAgeing time.Duration
+	flag.DurationVar(&macAgeing, "mac_ageing_time", 0, "How long the MAC addresses learned on the LogicalBridges are kept, e.g. longer for silent hosts, the dataplane default (300s in Linux) when 0.")
+
+	flag.DurationVar(&pageTokenTTL, "page_token_ttl", time.Hour, "How long the NextPageToken returned by List calls can be used, expired tokens fail with InvalidArgument.")
+
+	var programmingTimeout time.Duration
+	flag.DurationVar(&programmingTimeout, "programming_timeout", 30*time.Second, "How long the netlink and FRR programming of one Vrf, LogicalBridge, BridgePort or Svi may take, on top of the deadline of the call, before it is aborted and a create rolled back, not bounded when 0.")
+
+	var netnsName string
+	flag.StringVar(&netnsName, "netns", "", "Network namespace the netlink calls program instead of the one of the daemon, by name as in /var/run/netns or by path, e.g. /proc/<pid>/ns/net, for --dataplane=linux and those on top of it but ovs and bluefield.")
+
+	var vrfIsolation string
+	flag.StringVar(&vrfIsolation, "vrf_isolation", "vrf", "How the Vrfs are isolated in the kernel: vrf (a vrf device and routing table each) or netns (a network namespace each, for kernels without the vrf module, zebra running with -n), for --dataplane=linux.")
+
+	var auditSink string
+	flag.StringVar(&auditSink, "audit", "", "Append an audit record of every mutating call to file:<path> or syslog.")
+
+	var siemTarget string
+	flag.StringVar(&siemTarget, "siem", "", "Forward audit records to a SIEM at cef+udp://host:port, cef+tcp://host:port or an http(s):// URL.")
+
+	var siemSpool string
+	flag.StringVar(&siemSpool, "siem_spool", "", "Spool file keeping the audit records the SIEM could not receive until it is reachable again.")
+
+	var eventBus string
+	flag.StringVar(&eventBus, "event_bus", "", "Publish resource, link and BGP peer events to nats://host:port or kafka://broker[,broker...].")
+
+	var eventBusPrefix string
+	flag.StringVar(&eventBusPrefix, "event_bus_prefix", "opi.evpn", "Prefix of the NATS subjects or Kafka topics the events are published to.")
+
+	var eventPollInterval time.Duration
+	flag.DurationVar(&eventPollInterval, "event_poll_interval", 10*time.Second, "Interval the link and BGP peer states are polled at for the event bus and the event history.")
+
+	dampening := evpn.DefaultDampeningOptions()
+	flag.DurationVar(&dampening.HalfLife, "dampening_half_life", dampening.HalfLife, "Half-life of the penalty of the link and BGP peer flaps, their events are not dampened when 0.")
+	flag.IntVar(&dampening.Suppress, "dampening_suppress", dampening.Suppress, "Penalty the events of a flapping link or BGP peer are suppressed at, every transition adding 1000.")
+	flag.IntVar(&dampening.Reuse, "dampening_reuse", dampening.Reuse, "Penalty the events of a suppressed link or BGP peer are published again under.")
+
+	var k8sNamespace string
+	flag.StringVar(&k8sNamespace, "k8s_namespace", "", "Reconcile the LogicalBridge, Vrf, Svi and BridgePort custom resources of this Kubernetes namespace, using the in-cluster service account.")
+
+	var dataplane string
+	flag.StringVar(&dataplane, "dataplane", "linux", "Dataplane the objects are programmed into: linux, ipu (Intel IPU P4 pipeline, on top of linux), p4rt (P4 pipeline implementing EVPN IRB, on top of linux), ovs (Open vSwitch bridge instead of the kernel bridge), bluefield (ovs with hw-offload on NVIDIA BlueField), octeon (Marvell OCTEON SDK, on top of linux), ebpf (experimental XDP fast path, on top of linux), sonic (SONiC CONFIG_DB, instead of linux) or fake (in-memory kernel and FRR, for tests and development without root).")
+
+	var p4rtAddress string
+	flag.StringVar(&p4rtAddress, "p4rt", "localhost:9559", "Address of the P4Runtime server, for --dataplane=ipu or p4rt.")
+
+	var p4infoFile string
+	flag.StringVar(&p4infoFile, "p4info", "", "P4Info file, in text format, of the EVPN program loaded on the device, for --dataplane=ipu or p4rt.")
+
+	var p4DeviceID uint64
+	flag.Uint64Var(&p4DeviceID, "p4_device_id", 1, "P4Runtime device ID, for --dataplane=ipu or p4rt.")
+
+	var p4Pipeline string
+	flag.StringVar(&p4Pipeline, "p4_pipeline", "", "JSON file naming the tables and numbering the ports of the EVPN IRB program, for --dataplane=p4rt.")
+
+	var ovsdbEndpoint string
+	flag.StringVar(&ovsdbEndpoint, "ovsdb", "unix:/var/run/openvswitch/db.sock", "Endpoint of ovsdb-server, for --dataplane=ovs or bluefield.")
+
+	var octeonAgent string
+	flag.StringVar(&octeonAgent, "octeon_agent", "/var/run/octeon-sdk.sock", "Unix socket of the OCTEON SDK agent, for --dataplane=octeon.")
+
+	var ebpfObject string
+	flag.StringVar(&ebpfObject, "ebpf_object", "/usr/share/opi/evpn.bpf.o", "Object file of the XDP bridge program, for --dataplane=ebpf.")
+
+	var ebpfPinDir string
+	flag.StringVar(&ebpfPinDir, "ebpf_pin_dir", "/sys/fs/bpf/opi-evpn", "Directory of the bpf filesystem the XDP programs and their maps are pinned in, for --dataplane=ebpf.")
+
+	var sonicRedis string
+	flag.StringVar(&sonicRedis, "sonic_redis", "127.0.0.1:6379", "Address of the redis instance of SONiC holding CONFIG_DB and APPL_DB, for --dataplane=sonic.")
+
+	var fakeInterfaces string
+	flag.StringVar(&fakeInterfaces, "fake_interfaces", "eth0,eth1,eth2,eth3", "Comma separated interfaces of the simulated kernel, e.g. the ports of the BridgePorts, for --dataplane=fake.")
+
 	flag.Parse()
 
+	limits, err := utils.ParseConcurrencyLimits(maxConcurrent)
+	if err != nil {
+		log.Panic(err)
+	}
+	limiter := utils.NewConcurrencyLimiter(limits)
+
+	if tenancy && tlsFiles == "" {
+		log.Panic("--tenancy requires --tls, the tenants are the client certificates")
+	}
+	tenants := utils.NewTenancy(tenancy, tenantAdmins)
+
+	audit := utils.DefaultAuditLog()
+	if auditSink != "" {
+		sink, err := utils.OpenAuditSink(auditSink)
+		if err != nil {
+			log.Panic(err)
+		}
+		defer func(sink io.Closer) {
+			if err := sink.Close(); err != nil {
+				log.Printf("Failed to close audit sink: %v", err)
+			}
+		}(sink)
+		audit.SetSink(sink)
+	}
+	if siemTarget != "" {
+		siem, err := utils.NewSiemForwarder(siemTarget, siemSpool)
+		if err != nil {
+			log.Panic(err)
+		}
+		defer func(siem io.Closer) {
+			if err := siem.Close(); err != nil {
+				log.Printf("Failed to close siem forwarder: %v", err)
+			}
+		}(siem)
+		audit.AddForwarder(siem)
+	}
+
 	// Create KV store for persistence
 	options := redis.DefaultOptions
-	store, err := redis.NewClient(options)
-	if err != nil {
+	options.Codec = utils.ProtoCodec{}
+	var store gokv.Store
+	if dataplane == "fake" {
+		// the simulated kernel and FRR start empty on every run, so do the objects
+		store = gomap.NewStore(gomap.Options{Codec: utils.ProtoCodec{}})
+	} else if store, err = redis.NewClient(options); err != nil {
 		log.Panic(err)
 	}
 	defer func(store gokv.Store) {
@@ -58,11 +308,351 @@ func main() {
 		}
 	}(store)
 
-	go runGatewayServer(grpcPort, httpPort)
-	runGrpcServer(grpcPort, tlsFiles, store)
+	// the fake dataplane is the linux one programming a simulated kernel and FRR
+	var opi *evpn.Server
+	if dataplane == "fake" {
+		opi = evpn.NewServerWithArgs(fake.NewNetlink(strings.Split(fakeInterfaces, ",")...), fake.NewFrr(), store)
+	} else if netnsName != "" {
+		nLink, err := utils.NewNetlinkWrapperAt(netnsName)
+		if err != nil {
+			log.Panic(err)
+		}
+		opi = evpn.NewServerWithArgs(nLink, utils.NewFrrWrapper(), store)
+	} else {
+		opi = evpn.NewServer(store)
+	}
+	if faultInjection {
+		opi.EnableFaultInjection()
+	}
+	opi.LiveRead = liveRead
+	opi.RejectDefaultVlan = rejectDefaultVlan
+	opi.RequireEtag = requireEtag
+	opi.HwOffload = hwOffload
+	opi.PageTokenTTL = pageTokenTTL
+	opi.ProgrammingTimeout = programmingTimeout
+	if opi.VrfIsolation, err = evpn.ParseVrfIsolation(vrfIsolation); err != nil {
+		log.Panic(err)
+	}
+	// the notifications are subscribed to in the host only
+	if statusMonitor && opi.VrfIsolation == evpn.VrfIsolationNetns {
+		log.Panic("--status_monitor cannot see the devices of the Vrfs isolated in network namespaces, use --vrf_isolation=vrf")
+	}
+	level, err := utils.ParseLogLevel(logLevel)
+	if err != nil {
+		log.Panic(err)
+	}
+	utils.SetLogLevel(level)
+	utils.SetFrrAddress(frrAddress)
+	if err := opi.SetConfigFile(configFile); err != nil {
+		log.Panic(err)
+	}
+	if err := vxlan.Validate(); err != nil {
+		log.Panic(err)
+	}
+	opi.Vxlan = vxlan
+	if pimInterfaces != "" {
+		pim.Interfaces = strings.Split(pimInterfaces, ",")
+	}
+	opi.Pim = pim
+	if err := srv6.Validate(); err != nil {
+		log.Panic(err)
+	}
+	opi.Srv6 = srv6
+	if err := macMobility.Validate(); err != nil {
+		log.Panic(err)
+	}
+	opi.MacMobility = macMobility
+	if err := dampening.Validate(); err != nil {
+		log.Panic(err)
+	}
+	opi.Dampening = dampening
+	if localAs > math.MaxUint32 {
+		log.Panicf("invalid local AS %d, has to be between 1 and %d", localAs, uint32(math.MaxUint32))
+	}
+	gateway.LocalAs = uint32(localAs)
+	if err := gateway.Validate(); err != nil {
+		log.Panic(err)
+	}
+	opi.Gateway = gateway
+	opi.Quotas, err = evpn.ParseQuotas(quotas)
+	if err != nil {
+		log.Panic(err)
+	}
+	opi.TenantQuotas, err = evpn.ParseTenantQuotas(tenantQuotas)
+	if err != nil {
+		log.Panic(err)
+	}
+	audit.SetObjectLookup(opi.LookupObject)
+	if adopt {
+		if err := opi.AdoptExisting(context.Background()); err != nil {
+			log.Panicf("Failed to adopt existing kernel state: %v", err)
+		}
+	}
+
+	// stop accepting new requests on SIGINT/SIGTERM and drain the in-flight ones
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// reload the config file on SIGHUP, the programmed objects are left untouched
+	if configFile != "" {
+		hup := make(chan os.Signal, 1)
+		signal.Notify(hup, syscall.SIGHUP)
+		go func() {
+			defer signal.Stop(hup)
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-hup:
+					if _, err := opi.ReloadConfig(ctx, &evpn.ReloadConfigRequest{}); err != nil {
+						log.Printf("Failed to reload the config: %v", err)
+					}
+				}
+			}
+		}()
+	}
+
+	switch dataplane {
+	case "linux", "fake":
+	case "ipu", "p4rt":
+		conn, err := grpc.Dial(p4rtAddress, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			log.Panicf("Failed to connect to P4Runtime: %v", err)
+		}
+		defer conn.Close()
+		info, err := p4rt.LoadP4Info(p4infoFile)
+		if err != nil {
+			log.Panic(err)
+		}
+		tables, err := p4rt.NewClient(ctx, conn, p4DeviceID, info)
+		if err != nil {
+			log.Panicf("Failed to become the P4Runtime controller: %v", err)
+		}
+		if dataplane == "ipu" {
+			opi.SetDataplane(ipu.NewDataplane(opi, tables))
+			break
+		}
+		pipeline := &p4rt.DefaultPipeline
+		if p4Pipeline != "" {
+			if pipeline, err = p4rt.LoadPipeline(p4Pipeline); err != nil {
+				log.Panic(err)
+			}
+		}
+		opi.SetDataplane(p4rt.NewDataplane(opi, p4rt.IRB{Pipeline: pipeline}, tables))
+	case "ovs", "bluefield":
+		sw, err := ovs.DialOvsdb(ctx, ovsdbEndpoint)
+		if err != nil {
+			log.Panicf("Failed to connect to OVSDB: %v", err)
+		}
+		defer sw.Close()
+		if dataplane == "ovs" {
+			opi.SetDataplane(ovs.NewDataplane(opi, sw, utils.NewNetlinkWrapper(), utils.NewFrrWrapper()))
+			break
+		}
+		offloaded, err := bluefield.NewDataplane(ctx, opi, sw, utils.NewNetlinkWrapper(), utils.NewFrrWrapper())
+		if err != nil {
+			log.Panic(err)
+		}
+		opi.SetDataplane(offloaded)
+	case "octeon":
+		sdk, err := octeon.DialAgent(octeonAgent)
+		if err != nil {
+			log.Panicf("Failed to connect to the OCTEON SDK agent: %v", err)
+		}
+		defer sdk.Close()
+		opi.SetDataplane(octeon.NewDataplane(opi, sdk))
+	case "ebpf":
+		maps, err := ebpf.LoadBpftool(ctx, ebpfObject, ebpfPinDir)
+		if err != nil {
+			log.Panicf("Failed to load the XDP programs: %v", err)
+		}
+		opi.SetDataplane(ebpf.NewDataplane(opi, maps))
+	case "sonic":
+		config := sonic.NewRedisDB(sonicRedis, sonic.ConfigDB)
+		defer config.Close()
+		appl := sonic.NewRedisDB(sonicRedis, sonic.ApplDB)
+		defer appl.Close()
+		opi.SetDataplane(sonic.NewDataplane(opi, config, appl))
+	default:
+		log.Panicf("Unknown dataplane %s", dataplane)
+	}
+	opi.DataplaneName = dataplane
+
+	opi.MacAgeing = macAgeing
+	if err := opi.ApplyMacAgeing(ctx); err != nil {
+		log.Panicf("Failed to set the MAC ageing time: %v", err)
+	}
+
+	opi.ApplyMacMobility(ctx)
+
+	if statusMonitor {
+		if err := opi.StartStatusMonitor(ctx); err != nil {
+			log.Panicf("Failed to subscribe to kernel notifications: %v", err)
+		}
+	}
+
+	if vtepProbeInterval > 0 {
+		opi.StartVtepProbes(ctx, vtepProbeInterval)
+	}
+
+	if frrMonitorInterval > 0 {
+		opi.StartFrrMonitor(ctx, frrMonitorInterval)
+	}
+
+	if countersInterval > 0 {
+		opi.StartCounters(ctx, countersInterval)
+	}
+
+	policy, err := evpn.ParseOrphanPolicy(orphanPolicy)
+	if err != nil {
+		log.Panic(err)
+	}
+	opi.StartOrphanSweeper(ctx, orphanSweepInterval, policy)
+
+	if ha {
+		ttl := 10 * time.Second
+		lease := utils.NewRedisLease(options.Address, "opi-evpn-bridge/leader", haID, ttl)
+		opi.StartHA(ctx, lease, ttl, 3*time.Second)
+	}
+
+	// the link and BGP peer transitions are recorded for ListEvents even without a bus
+	var pub utils.EventPublisher
+	if eventBus != "" {
+		bus, err := utils.NewEventBus(eventBus, eventBusPrefix)
+		if err != nil {
+			log.Panicf("Failed to connect to the event bus: %v", err)
+		}
+		defer func(bus io.Closer) {
+			if err := bus.Close(); err != nil {
+				log.Printf("Failed to close event bus: %v", err)
+			}
+		}(bus)
+		pub = bus
+	}
+	opi.StartEventPublisher(ctx, pub, eventPollInterval)
+
+	if k8sNamespace != "" {
+		client, err := k8s.NewInClusterClient()
+		if err != nil {
+			log.Panicf("Failed to create kubernetes client: %v", err)
+		}
+		go func() {
+			if err := k8s.NewController(client, opi, k8sNamespace).Run(ctx); err != nil && ctx.Err() == nil {
+				log.Printf("Kubernetes controller stopped: %v", err)
+			}
+		}()
+	}
+
+	// use the sockets of systemd when it socket activated the server, so it starts on demand
+	// and the clients never see the ports closed across restarts
+	listeners, err := utils.SdListeners("grpc", "http", "gnmi")
+	if err != nil {
+		log.Panicf("Failed to use the sockets of systemd: %v", err)
+	}
+	grpcListener, err := listen(listeners, "grpc", "", grpcPort)
+	if err != nil {
+		log.Panicf("failed to listen: %v", err)
+	}
+	if httpAddress == "" && tlsFiles == "" {
+		// the calls of the HTTP server are not authenticated without TLS
+		httpAddress = "127.0.0.1"
+	}
+	httpListener, err := listen(listeners, "http", httpAddress, httpPort)
+	if err != nil {
+		log.Panicf("failed to listen: %v", err)
+	}
+
+	// the calls of the HTTP server run through the interceptors of the gRPC calls, their
+	// payloads not being protos are not logged
+	gatewayInterceptors := unaryInterceptors(opi, limiter, audit, tenants, logging.StartCall, logging.FinishCall)
+	go runGatewayServer(ctx, grpcListener.Addr().String(), httpListener, tlsFiles, opi, tenants, gatewayInterceptors)
+
+	if _, ok := listeners["gnmi"]; ok || gnmiPort != 0 {
+		gnmiListener, err := listen(listeners, "gnmi", "", gnmiPort)
+		if err != nil {
+			log.Panicf("failed to listen: %v", err)
+		}
+		go runGnmiServer(ctx, gnmiListener, tlsFiles, opi, tenants)
+	}
+
+	if pprofPort != 0 {
+		go runPprofServer(ctx, pprofPort)
+	}
+
+	// the adoption, the dataplane setup and the replay are done and the sockets are open
+	if _, err := utils.SdNotify("READY=1"); err != nil {
+		log.Printf("Failed to notify systemd: %v", err)
+	}
+	watchdog, err := utils.SdWatchdogInterval()
+	if err != nil {
+		log.Printf("Failed to read the systemd watchdog interval: %v", err)
+	}
+	if watchdog > 0 {
+		go utils.RunSdWatchdog(ctx, watchdog, opi.CheckLiveness)
+	}
+	go func() {
+		<-ctx.Done()
+		if _, err := utils.SdNotify("STOPPING=1"); err != nil {
+			log.Printf("Failed to notify systemd: %v", err)
+		}
+	}()
+
+	runGrpcServer(ctx, grpcListener, tlsFiles, opi, limiter, audit, tenants)
+
+	if teardownOnExit {
+		if err := opi.Teardown(context.Background()); err != nil {
+			log.Printf("Failed to tear down state on exit: %v", err)
+		}
+	}
+	log.Println("Shutdown complete")
+}
+
+// listen returns the socket of systemd of that name, or listens on the port of address without
+// one, of every address when empty
+func listen(listeners map[string]net.Listener, name string, address string, port int) (net.Listener, error) {
+	if lis, ok := listeners[name]; ok {
+		log.Printf("Using the %s socket of systemd at %v", name, lis.Addr())
+		return lis, nil
+	}
+	return net.Listen("tcp", net.JoinHostPort(address, strconv.Itoa(port)))
+}
+
+// unaryInterceptors are the interceptors of the gRPC calls and of the calls of the HTTP server,
+// logging the payloads of the calls on events
+func unaryInterceptors(opi *evpn.Server, limiter *utils.ConcurrencyLimiter, audit *utils.AuditLog, tenants *utils.Tenancy, events ...logging.LoggableEvent) []grpc.UnaryServerInterceptor {
+	return []grpc.UnaryServerInterceptor{
+		utils.RequestIDInterceptor(),
+		recovery.UnaryServerInterceptor(recovery.WithRecoveryHandlerContext(utils.RecoverPanic)),
+		tenants.UnaryServerInterceptor(),
+		logging.UnaryServerInterceptor(utils.InterceptorLogger(log.Default()),
+			logging.WithLogOnEvents(events...),
+			logging.WithFieldsFromContext(utils.RequestIDFields),
+		),
+		audit.UnaryServerInterceptor(),
+		utils.StandbyInterceptor(opi.IsStandby),
+		limiter.UnaryServerInterceptor(),
+		opi.EtagInterceptor(),
+	}
+}
+
+// streamInterceptors are the interceptors of the gRPC streams, the streaming lists included,
+// matching unaryInterceptors
+func streamInterceptors(opi *evpn.Server, limiter *utils.ConcurrencyLimiter, audit *utils.AuditLog, tenants *utils.Tenancy, events ...logging.LoggableEvent) []grpc.StreamServerInterceptor {
+	return []grpc.StreamServerInterceptor{
+		utils.RequestIDStreamInterceptor(),
+		recovery.StreamServerInterceptor(recovery.WithRecoveryHandlerContext(utils.RecoverPanic)),
+		tenants.StreamServerInterceptor(),
+		logging.StreamServerInterceptor(utils.InterceptorLogger(log.Default()),
+			logging.WithLogOnEvents(events...),
+			logging.WithFieldsFromContext(utils.RequestIDFields),
+		),
+		audit.StreamServerInterceptor(),
+		utils.StandbyStreamInterceptor(opi.IsStandby),
+		limiter.StreamServerInterceptor(),
+	}
 }
 
-func runGrpcServer(grpcPort int, tlsFiles string, store gokv.Store) {
+func runGrpcServer(ctx context.Context, lis net.Listener, tlsFiles string, opi *evpn.Server, limiter *utils.ConcurrencyLimiter, audit *utils.AuditLog, tenants *utils.Tenancy) {
 	tp := utils.InitTracerProvider("opi-evpn-bridge")
 	defer func() {
 		if err := tp.Shutdown(context.Background()); err != nil {
@@ -70,11 +660,6 @@ func runGrpcServer(grpcPort int, tlsFiles string, store gokv.Store) {
 		}
 	}()
 
-	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", grpcPort))
-	if err != nil {
-		log.Panicf("failed to listen: %v", err)
-	}
-
 	var serverOptions []grpc.ServerOption
 	if tlsFiles == "" {
 		log.Println("TLS files are not specified. Use insecure connection.")
@@ -91,37 +676,111 @@ func runGrpcServer(grpcPort int, tlsFiles string, store gokv.Store) {
 		}
 		serverOptions = append(serverOptions, option)
 	}
-	serverOptions = append(serverOptions, grpc.ChainUnaryInterceptor(
-		otelgrpc.UnaryServerInterceptor(),
-		logging.UnaryServerInterceptor(utils.InterceptorLogger(log.Default()),
-			logging.WithLogOnEvents(
-				logging.StartCall,
-				logging.FinishCall,
-				logging.PayloadReceived,
-				logging.PayloadSent,
-			),
-		)),
+	interceptors := unaryInterceptors(opi, limiter, audit, tenants,
+		logging.StartCall,
+		logging.FinishCall,
+		logging.PayloadReceived,
+		logging.PayloadSent,
+	)
+	// the payloads of the streaming lists are the chunks of a full resync, not logged
+	streams := streamInterceptors(opi, limiter, audit, tenants, logging.StartCall, logging.FinishCall)
+	serverOptions = append(serverOptions,
+		grpc.ChainUnaryInterceptor(append([]grpc.UnaryServerInterceptor{otelgrpc.UnaryServerInterceptor()}, interceptors...)...),
+		grpc.ChainStreamInterceptor(append([]grpc.StreamServerInterceptor{otelgrpc.StreamServerInterceptor()}, streams...)...),
 	)
 	s := grpc.NewServer(serverOptions...)
 
-	opi := evpn.NewServer(store)
-
 	pe.RegisterLogicalBridgeServiceServer(s, opi)
 	pe.RegisterBridgePortServiceServer(s, opi)
 	pe.RegisterVrfServiceServer(s, opi)
 	pe.RegisterSviServiceServer(s, opi)
+	evpn.RegisterListStreamServer(s, opi)
+	longrunningpb.RegisterOperationsServer(s, opi)
 	pc.RegisterInventorySvcServer(s, &inventory.Server{})
 
 	reflection.Register(s)
 
+	go func() {
+		<-ctx.Done()
+		log.Println("Shutting down gRPC server, draining in-flight requests")
+		s.GracefulStop()
+	}()
+
 	log.Printf("gRPC server listening at %v", lis.Addr())
 	if err := s.Serve(lis); err != nil {
 		log.Panicf("failed to serve: %v", err)
 	}
 }
 
-func runGatewayServer(grpcPort int, httpPort int) {
-	ctx := context.Background()
+func runGnmiServer(ctx context.Context, lis net.Listener, tlsFiles string, opi *evpn.Server, tenants *utils.Tenancy) {
+	serverOptions := gnmi.ServerOptions()
+	if tlsFiles != "" {
+		config, err := utils.ParseTLSFiles(tlsFiles)
+		if err != nil {
+			log.Panic("Failed to parse string with tls paths:", err)
+		}
+		option, err := utils.SetupTLSCredentials(config)
+		if err != nil {
+			log.Panic("Failed to setup TLS:", err)
+		}
+		serverOptions = append(serverOptions, option)
+	}
+	serverOptions = append(serverOptions,
+		grpc.ChainUnaryInterceptor(
+			recovery.UnaryServerInterceptor(recovery.WithRecoveryHandlerContext(utils.RecoverPanic)),
+			tenants.UnaryServerInterceptor(),
+		),
+		grpc.ChainStreamInterceptor(
+			recovery.StreamServerInterceptor(recovery.WithRecoveryHandlerContext(utils.RecoverPanic)),
+			tenants.StreamServerInterceptor(),
+		),
+	)
+	s := grpc.NewServer(serverOptions...)
+	gnmi.NewServer(opi).Register(s)
+
+	go func() {
+		<-ctx.Done()
+		// the subscriptions never end by themselves, there is nothing to drain
+		log.Println("Shutting down gNMI server")
+		s.Stop()
+	}()
+
+	log.Printf("gNMI server listening at %v", lis.Addr())
+	if err := s.Serve(lis); err != nil {
+		log.Panicf("failed to serve: %v", err)
+	}
+}
+
+// runPprofServer serves the profiles of net/http/pprof on localhost only, they expose the
+// internals of the server and profiling slows it down
+func runPprofServer(ctx context.Context, port int) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	server := &http.Server{
+		Addr:              net.JoinHostPort("127.0.0.1", strconv.Itoa(port)),
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		log.Println("Shutting down pprof server")
+		if err := server.Close(); err != nil {
+			log.Printf("Failed to close pprof server: %v", err)
+		}
+	}()
+
+	log.Printf("pprof server listening at %v", server.Addr)
+	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		log.Printf("pprof server stopped: %v", err)
+	}
+}
+
+func runGatewayServer(ctx context.Context, grpcEndpoint string, lis net.Listener, tlsFiles string, opi *evpn.Server, tenants *utils.Tenancy, interceptors []grpc.UnaryServerInterceptor) {
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
@@ -131,21 +790,1175 @@ func runGatewayServer(grpcPort int, httpPort int) {
 	opts := []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}
 
 	// TODO: add/replace with more/less registrations, once opi-api compiler fixed
-	err := pc.RegisterInventorySvcHandlerFromEndpoint(ctx, mux, fmt.Sprintf(":%d", grpcPort), opts)
+	err := pc.RegisterInventorySvcHandlerFromEndpoint(ctx, mux, grpcEndpoint, opts)
 	if err != nil {
 		log.Panic("cannot register handler server")
 	}
 
-	// Start HTTP server (and proxy calls to gRPC server endpoint)
-	log.Printf("HTTP Server listening at %v", httpPort)
-	server := &http.Server{
-		Addr:         fmt.Sprintf(":%d", httpPort),
-		Handler:      mux,
-		ReadTimeout:  5 * time.Second,
-		WriteTimeout: 10 * time.Second,
+	// Register metrics and SLO report endpoints
+	registry := prometheus.NewRegistry()
+	utils.MustRegisterSloMetrics(registry)
+	utils.MustRegisterLimiterMetrics(registry)
+	utils.MustRegisterWatchMetrics(registry)
+	utils.MustRegisterRetryMetrics(registry)
+	utils.MustRegisterFrrMetrics(registry)
+	metricsHandler := promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+	err = mux.HandlePath("GET", "/metrics", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		metricsHandler.ServeHTTP(w, r)
+	})
+	if err != nil {
+		log.Panic("cannot register metrics handler")
 	}
-	err = server.ListenAndServe()
+	err = mux.HandlePath("GET", "/v1/sloReport", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		serveSloReport(w, r, opi)
+	})
 	if err != nil {
-		log.Panic("cannot start HTTP gateway server")
+		log.Panic("cannot register SLO report handler")
+	}
+	err = mux.HandlePath("GET", "/v1/kernelNames", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(opi.GetKernelNames(r.Context())); err != nil {
+			log.Printf("Failed to encode kernel names: %v", err)
+		}
+	})
+	if err != nil {
+		log.Panic("cannot register kernel names handler")
+	}
+	err = mux.HandlePath("GET", "/v1/liveStatus", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(opi.GetLiveStatuses(r.Context())); err != nil {
+			log.Printf("Failed to encode live status: %v", err)
+		}
+	})
+	if err != nil {
+		log.Panic("cannot register live status handler")
+	}
+	err = mux.HandlePath("GET", "/v1/conditions", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		response, err := opi.GetConditions(r.Context(), &evpn.GetConditionsRequest{Name: r.URL.Query().Get("name")})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			log.Printf("Failed to encode conditions: %v", err)
+		}
+	})
+	if err != nil {
+		log.Panic("cannot register conditions handler")
+	}
+	err = mux.HandlePath("GET", "/v1/lifecycles", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		response, err := opi.GetLifecycles(r.Context(), &evpn.GetLifecyclesRequest{Name: r.URL.Query().Get("name")})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			log.Printf("Failed to encode lifecycles: %v", err)
+		}
+	})
+	if err != nil {
+		log.Panic("cannot register lifecycles handler")
+	}
+	err = mux.HandlePath("GET", "/v1/labels", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		response, err := opi.GetLabels(r.Context(), &evpn.GetLabelsRequest{Name: r.URL.Query().Get("name")})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			log.Printf("Failed to encode labels: %v", err)
+		}
+	})
+	if err != nil {
+		log.Panic("cannot register labels handler")
+	}
+	err = mux.HandlePath("GET", "/v1/gatewayConfig", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		response, err := opi.GetGatewayConfig(r.Context(), &evpn.GetGatewayConfigRequest{})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			log.Printf("Failed to encode gateway config: %v", err)
+		}
+	})
+	if err != nil {
+		log.Panic("cannot register gateway config handler")
+	}
+	err = mux.HandlePath("GET", "/v1/version", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		in := &evpn.GetVersionRequest{}
+		serveAdminCall(w, r, "GetVersion", in, func(ctx context.Context) (interface{}, error) {
+			return opi.GetVersion(ctx, in)
+		})
+	})
+	if err != nil {
+		log.Panic("cannot register version handler")
+	}
+	err = mux.HandlePath("GET", "/v1/capabilities", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		in := &evpn.GetCapabilitiesRequest{}
+		serveAdminCall(w, r, "GetCapabilities", in, func(ctx context.Context) (interface{}, error) {
+			return opi.GetCapabilities(ctx, in)
+		})
+	})
+	if err != nil {
+		log.Panic("cannot register capabilities handler")
+	}
+	err = mux.HandlePath("GET", "/v1/runtimeConfig", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		in := &evpn.GetRuntimeConfigRequest{}
+		serveAdminCall(w, r, "GetRuntimeConfig", in, func(ctx context.Context) (interface{}, error) {
+			return opi.GetRuntimeConfig(ctx, in)
+		})
+	})
+	if err != nil {
+		log.Panic("cannot register runtime config handler")
+	}
+	err = mux.HandlePath("POST", "/v1/runtimeConfig:reload", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		in := &evpn.ReloadConfigRequest{}
+		serveAdminCall(w, r, "ReloadConfig", in, func(ctx context.Context) (interface{}, error) {
+			return opi.ReloadConfig(ctx, in)
+		})
+	})
+	if err != nil {
+		log.Panic("cannot register config reload handler")
+	}
+	err = mux.HandlePath("POST", "/v1/runtimeConfig:setLogLevel", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		in := &evpn.SetLogLevelRequest{}
+		if err := json.NewDecoder(r.Body).Decode(in); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		serveAdminCall(w, r, "SetLogLevel", in, func(ctx context.Context) (interface{}, error) {
+			return opi.SetLogLevel(ctx, in)
+		})
+	})
+	if err != nil {
+		log.Panic("cannot register log level handler")
+	}
+	err = mux.HandlePath("GET", "/v1/evpnRoutes", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		serveEvpnRoutes(w, r, opi)
+	})
+	if err != nil {
+		log.Panic("cannot register EVPN routes handler")
+	}
+	err = mux.HandlePath("GET", "/v1/portNeighbors", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		servePortNeighbors(w, r, opi)
+	})
+	if err != nil {
+		log.Panic("cannot register port neighbors handler")
+	}
+	err = mux.HandlePath("GET", "/v1/hostInterfaces", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		serveHostInterfaces(w, r, opi)
+	})
+	if err != nil {
+		log.Panic("cannot register host interfaces handler")
+	}
+	err = mux.HandlePath("GET", "/v1/offloadCounters", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		serveOffloadCounters(w, r, opi)
+	})
+	if err != nil {
+		log.Panic("cannot register offload counters handler")
+	}
+	err = mux.HandlePath("GET", "/v1/macsecCounters", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		serveMacsecCounters(w, r, opi)
+	})
+	if err != nil {
+		log.Panic("cannot register MACsec counters handler")
+	}
+	err = mux.HandlePath("GET", "/v1/vrfLoopbacks", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		serveVrfLoopbacks(w, r, opi)
+	})
+	if err != nil {
+		log.Panic("cannot register Vrf loopbacks handler")
+	}
+	err = mux.HandlePath("GET", "/v1/counters", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		in := &evpn.GetCountersRequest{}
+		serveAdminCall(w, r, "GetCounters", in, func(ctx context.Context) (interface{}, error) {
+			return opi.GetCounters(ctx, in)
+		})
+	})
+	if err != nil {
+		log.Panic("cannot register counters handler")
+	}
+	err = mux.HandlePath("GET", "/v1/macMobilityEvents", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		serveMacMobilityEvents(w, r, opi)
+	})
+	if err != nil {
+		log.Panic("cannot register MAC mobility events handler")
+	}
+	err = mux.HandlePath("GET", "/v1/events", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		in := &evpn.ListEventsRequest{Resource: r.URL.Query().Get("resource")}
+		serveAdminCall(w, r, "ListEvents", in, func(ctx context.Context) (interface{}, error) {
+			return opi.ListEvents(ctx, in)
+		})
+	})
+	if err != nil {
+		log.Panic("cannot register events handler")
+	}
+	err = mux.HandlePath("GET", "/v1/remoteVteps", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		serveRemoteVteps(w, r, opi)
+	})
+	if err != nil {
+		log.Panic("cannot register remote VTEPs handler")
+	}
+	err = mux.HandlePath("POST", "/v1/diagnostics:ping", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		in := &evpn.PingRequest{}
+		serveDiagnostic(w, r, in, func(ctx context.Context, send func(*evpn.DiagnosticOutput)) error {
+			return opi.Ping(ctx, in, send)
+		})
+	})
+	if err != nil {
+		log.Panic("cannot register ping handler")
+	}
+	err = mux.HandlePath("POST", "/v1/diagnostics:traceroute", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		in := &evpn.TracerouteRequest{}
+		serveDiagnostic(w, r, in, func(ctx context.Context, send func(*evpn.DiagnosticOutput)) error {
+			return opi.Traceroute(ctx, in, send)
+		})
+	})
+	if err != nil {
+		log.Panic("cannot register traceroute handler")
+	}
+	err = mux.HandlePath("POST", "/v1/diagnostics:capture", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		serveCapturePackets(w, r, opi)
+	})
+	if err != nil {
+		log.Panic("cannot register packet capture handler")
+	}
+	err = mux.HandlePath("GET", "/v1/frrRetries", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(opi.GetFrrRetries()); err != nil {
+			log.Printf("Failed to encode FRR retries: %v", err)
+		}
+	})
+	if err != nil {
+		log.Panic("cannot register FRR retries handler")
+	}
+	err = mux.HandlePath("GET", "/v1/auditEvents", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		serveAuditEvents(w, r, opi)
+	})
+	if err != nil {
+		log.Panic("cannot register audit events handler")
+	}
+	err = mux.HandlePath("POST", "/v1/demo", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		serveDemoTopology(w, r, opi)
+	})
+	if err != nil {
+		log.Panic("cannot register demo topology handler")
+	}
+	err = mux.HandlePath("DELETE", "/v1/demo", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		serveDemoTopology(w, r, opi)
+	})
+	if err != nil {
+		log.Panic("cannot register demo topology handler")
+	}
+	err = mux.HandlePath("POST", "/v1/bridges:batchCreate", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		in := &evpn.BatchCreateLogicalBridgesRequest{}
+		serveBatch(w, r, "LogicalBridgeService/BatchCreateLogicalBridges", in, func(ctx context.Context) (interface{}, error) {
+			return opi.BatchCreateLogicalBridges(ctx, in)
+		})
+	})
+	if err != nil {
+		log.Panic("cannot register logical bridges batch create handler")
+	}
+	err = mux.HandlePath("POST", "/v1/bridges:batchDelete", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		in := &evpn.BatchDeleteLogicalBridgesRequest{}
+		serveBatch(w, r, "LogicalBridgeService/BatchDeleteLogicalBridges", in, func(ctx context.Context) (interface{}, error) {
+			return opi.BatchDeleteLogicalBridges(ctx, in)
+		})
+	})
+	if err != nil {
+		log.Panic("cannot register logical bridges batch delete handler")
+	}
+	err = mux.HandlePath("POST", "/v1/bridges:flushMacs", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		in := &evpn.FlushBridgeMacsRequest{}
+		serveBatch(w, r, "LogicalBridgeService/FlushBridgeMacs", in, func(ctx context.Context) (interface{}, error) {
+			return opi.FlushBridgeMacs(ctx, in)
+		})
+	})
+	if err != nil {
+		log.Panic("cannot register logical bridges MAC flush handler")
+	}
+	err = mux.HandlePath("POST", "/v1/ports:batchCreate", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		in := &evpn.BatchCreateBridgePortsRequest{}
+		serveBatch(w, r, "BridgePortService/BatchCreateBridgePorts", in, func(ctx context.Context) (interface{}, error) {
+			return opi.BatchCreateBridgePorts(ctx, in)
+		})
+	})
+	if err != nil {
+		log.Panic("cannot register bridge ports batch create handler")
+	}
+	err = mux.HandlePath("POST", "/v1/ports:batchDelete", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		in := &evpn.BatchDeleteBridgePortsRequest{}
+		serveBatch(w, r, "BridgePortService/BatchDeleteBridgePorts", in, func(ctx context.Context) (interface{}, error) {
+			return opi.BatchDeleteBridgePorts(ctx, in)
+		})
+	})
+	if err != nil {
+		log.Panic("cannot register bridge ports batch delete handler")
+	}
+	err = mux.HandlePath("POST", "/v1/resync", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		serveResync(w, r, opi)
+	})
+	if err != nil {
+		log.Panic("cannot register resync handler")
+	}
+	err = mux.HandlePath("GET", "/v1/drift", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		in := &evpn.GetDriftRequest{}
+		serveAdminCall(w, r, "GetDrift", in, func(ctx context.Context) (interface{}, error) {
+			return opi.GetDrift(ctx, in)
+		})
+	})
+	if err != nil {
+		log.Panic("cannot register drift handler")
+	}
+	err = mux.HandlePath("GET", "/v1/orphans", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		in := &evpn.GetOrphansRequest{}
+		serveAdminCall(w, r, "GetOrphans", in, func(ctx context.Context) (interface{}, error) {
+			return opi.GetOrphans(ctx, in)
+		})
+	})
+	if err != nil {
+		log.Panic("cannot register orphans handler")
+	}
+	err = mux.HandlePath("GET", "/v1/debug/state", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		in := &evpn.DumpStateRequest{}
+		serveAdminCall(w, r, "DumpState", in, func(ctx context.Context) (interface{}, error) {
+			return opi.DumpState(ctx, in)
+		})
+	})
+	if err != nil {
+		log.Panic("cannot register state dump handler")
+	}
+	err = mux.HandlePath("GET", "/v1/config", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		serveExportConfig(w, r, opi)
+	})
+	if err != nil {
+		log.Panic("cannot register config export handler")
+	}
+	err = mux.HandlePath("POST", "/v1/config", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		serveImportConfig(w, r, opi)
+	})
+	if err != nil {
+		log.Panic("cannot register config import handler")
+	}
+	err = mux.HandlePath("POST", "/v1/config:apply", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		serveApplyConfig(w, r, opi)
+	})
+	if err != nil {
+		log.Panic("cannot register config apply handler")
+	}
+	registerResourceHandlers(mux, opi)
+
+	// Start HTTP server (and proxy calls to gRPC server endpoint)
+	log.Printf("HTTP Server listening at %v", lis.Addr())
+	server := &http.Server{
+		// scoped after the metadata of the headers is added, a tenant sent by the client is dropped
+		Handler:      withInterceptors(interceptors, withTenancy(tenants, mux)),
+		ReadTimeout:  5 * time.Second,
+		WriteTimeout: 10 * time.Second,
+		// the streaming handlers extend the write deadline of their connection
+		ConnContext: withConn,
+	}
+	go func() {
+		<-ctx.Done()
+		log.Println("Shutting down HTTP server")
+		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer shutdownCancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			log.Printf("HTTP server shutdown: %v", err)
+		}
+	}()
+	if tlsFiles == "" {
+		err = server.Serve(lis)
+	} else {
+		config, terr := utils.ParseTLSFiles(tlsFiles)
+		if terr != nil {
+			log.Panic("Failed to parse string with tls paths:", terr)
+		}
+		if server.TLSConfig, terr = utils.ServerTLSConfig(config); terr != nil {
+			log.Panic("Failed to setup TLS:", terr)
+		}
+		err = server.ServeTLS(lis, "", "")
+	}
+	if err != nil && err != http.ErrServerClosed {
+		log.Panic("cannot start HTTP gateway server")
+	}
+}
+
+// withTenancy scopes the requests to the tenant of their client certificate as the grpc calls
+// are, most handlers of the gateway calling the server directly
+func withTenancy(tenants *utils.Tenancy, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, err := tenants.Scope(r.Context(), utils.HTTPIdentity(r))
+		if err != nil {
+			http.Error(w, err.Error(), runtime.HTTPStatusFromCode(status.Code(err)))
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// apiPackage is the package of the gRPC services the calls of the HTTP gateway are named in
+const apiPackage = "opi_api.network.evpn_gw.v1alpha1"
+
+// interceptedKey is the context key of the interceptors of a request of the HTTP gateway
+type interceptedKey struct{}
+
+// intercepted are the interceptors of a request of the HTTP gateway and the headers of its response
+type intercepted struct {
+	interceptor grpc.UnaryServerInterceptor
+	header      http.Header
+}
+
+// withInterceptors lets the handlers of next run their calls through the interceptors with
+// intercept, the client of the request being the peer of the calls and the X- and
+// Grpc-Metadata- headers of the request their metadata, e.g. x-request-id and x-opi-if-match
+func withInterceptors(interceptors []grpc.UnaryServerInterceptor, next http.Handler) http.Handler {
+	interceptor := chainInterceptors(interceptors)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p := &peer.Peer{Addr: httpAddr(r.RemoteAddr)}
+		if r.TLS != nil {
+			p.AuthInfo = credentials.TLSInfo{State: *r.TLS}
+		}
+		md := metadata.MD{}
+		for key, values := range r.Header {
+			if name := strings.TrimPrefix(key, runtime.MetadataHeaderPrefix); name != key || strings.HasPrefix(key, "X-") {
+				md.Append(name, values...)
+			}
+		}
+		ctx := peer.NewContext(r.Context(), p)
+		ctx = metadata.NewIncomingContext(ctx, md)
+		ctx = context.WithValue(ctx, interceptedKey{}, intercepted{interceptor: interceptor, header: w.Header()})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// intercept runs call through the interceptors of the request of ctx as method, e.g.
+// RouteService/CreateRoute, so the calls of the HTTP gateway are recovered, scoped, logged,
+// audited, refused by a standby and limited as the gRPC calls are, the headers the
+// interceptors send, e.g. the request ID, being Grpc-Metadata- headers of the response
+func intercept(ctx context.Context, method string, req interface{}, call func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+	in, ok := ctx.Value(interceptedKey{}).(intercepted)
+	if !ok {
+		return call(ctx)
+	}
+	info := &grpc.UnaryServerInfo{FullMethod: "/" + apiPackage + "." + method}
+	ctx = grpc.NewContextWithServerTransportStream(ctx, &headerStream{method: info.FullMethod, header: in.header})
+	return in.interceptor(ctx, req, info, func(ctx context.Context, _ interface{}) (interface{}, error) {
+		return call(ctx)
+	})
+}
+
+// chainInterceptors runs the interceptors in order, the last one calling the handler
+func chainInterceptors(interceptors []grpc.UnaryServerInterceptor) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		next := handler
+		for i := len(interceptors) - 1; i >= 0; i-- {
+			interceptor, inner := interceptors[i], next
+			next = func(ctx context.Context, req interface{}) (interface{}, error) {
+				return interceptor(ctx, req, info, inner)
+			}
+		}
+		return next(ctx, req)
+	}
+}
+
+// httpAddr is the address of the client of a request of the HTTP gateway
+type httpAddr string
+
+func (a httpAddr) Network() string { return "tcp" }
+func (a httpAddr) String() string  { return string(a) }
+
+// headerStream sends the headers of an intercepted call as the Grpc-Metadata- headers of the
+// response, as the grpc-gateway does for the calls it proxies
+type headerStream struct {
+	method string
+	header http.Header
+}
+
+func (s *headerStream) Method() string {
+	return s.method
+}
+
+func (s *headerStream) SetHeader(md metadata.MD) error {
+	for key, values := range md {
+		for _, value := range values {
+			s.header.Add(runtime.MetadataHeaderPrefix+key, value)
+		}
+	}
+	return nil
+}
+
+func (s *headerStream) SendHeader(md metadata.MD) error {
+	return s.SetHeader(md)
+}
+
+func (s *headerStream) SetTrailer(_ metadata.MD) error {
+	return nil
+}
+
+// streamWriteTimeout is how long the streaming handlers write for, longer than the longest
+// packet capture and diagnostic
+const streamWriteTimeout = 6 * time.Minute
+
+// connKey is the context key of the connection of a request of the HTTP gateway
+type connKey struct{}
+
+// withConn adds the connection of the requests to their context, for extendWriteDeadline
+func withConn(ctx context.Context, conn net.Conn) context.Context {
+	return context.WithValue(ctx, connKey{}, conn)
+}
+
+// extendWriteDeadline lets the handler of r write until timeout from now instead of the
+// WriteTimeout of the HTTP gateway, the connection being reset at its next request
+func extendWriteDeadline(r *http.Request, timeout time.Duration) {
+	conn, ok := r.Context().Value(connKey{}).(net.Conn)
+	if !ok {
+		return
+	}
+	if err := conn.SetWriteDeadline(time.Now().Add(timeout)); err != nil {
+		log.Printf("Failed to extend the write deadline: %v", err)
+	}
+}
+
+func serveSloReport(w http.ResponseWriter, r *http.Request, opi *evpn.Server) {
+	var window time.Duration
+	if value := r.URL.Query().Get("window"); value != "" {
+		var err error
+		if window, err = time.ParseDuration(value); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	report, err := opi.GetSloReport(r.Context(), &evpn.GetSloReportRequest{Window: window})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		log.Printf("Failed to encode SLO report: %v", err)
+	}
+}
+
+func serveDemoTopology(w http.ResponseWriter, r *http.Request, opi *evpn.Server) {
+	var topology interface{}
+	var err error
+	if r.Method == http.MethodDelete {
+		topology, err = intercept(r.Context(), "DemoService/DeleteDemoTopology", nil, func(ctx context.Context) (interface{}, error) {
+			return opi.DeleteDemoTopology(ctx)
+		})
+	} else {
+		in := &evpn.CreateDemoTopologyRequest{}
+		if value := r.URL.Query().Get("ports"); value != "" {
+			in.Ports = strings.Split(value, ",")
+		}
+		topology, err = intercept(r.Context(), "DemoService/CreateDemoTopology", in, func(ctx context.Context) (interface{}, error) {
+			return opi.CreateDemoTopology(ctx, in)
+		})
+	}
+	if err != nil {
+		http.Error(w, err.Error(), runtime.HTTPStatusFromCode(status.Code(err)))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(topology); err != nil {
+		log.Printf("Failed to encode demo topology: %v", err)
+	}
+}
+
+func serveExportConfig(w http.ResponseWriter, r *http.Request, opi *evpn.Server) {
+	format := r.URL.Query().Get("format")
+	response, err := opi.ExportConfig(r.Context(), &evpn.ExportConfigRequest{Format: format})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if format == "yaml" {
+		w.Header().Set("Content-Type", "application/yaml")
+	} else {
+		w.Header().Set("Content-Type", "application/json")
+	}
+	if _, err := w.Write(response.Document); err != nil {
+		log.Printf("Failed to write config document: %v", err)
+	}
+}
+
+func serveImportConfig(w http.ResponseWriter, r *http.Request, opi *evpn.Server) {
+	document, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	in := &evpn.ImportConfigRequest{Document: document}
+	response, err := intercept(r.Context(), "ConfigService/ImportConfig", in, func(ctx context.Context) (interface{}, error) {
+		return opi.ImportConfig(ctx, in)
+	})
+	if err != nil {
+		http.Error(w, err.Error(), runtime.HTTPStatusFromCode(status.Code(err)))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("Failed to encode import result: %v", err)
+	}
+}
+
+func serveApplyConfig(w http.ResponseWriter, r *http.Request, opi *evpn.Server) {
+	dryRun := r.URL.Query().Get("dry_run") == "true"
+	// a dry run only reads, a standby answers it
+	method := "ConfigService/ApplyConfiguration"
+	if dryRun {
+		method = "ConfigService/DryRunConfiguration"
+	}
+	document, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	in := &evpn.ApplyConfigurationRequest{Document: document, DryRun: dryRun}
+	response, err := intercept(r.Context(), method, in, func(ctx context.Context) (interface{}, error) {
+		return opi.ApplyConfiguration(ctx, in)
+	})
+	if err != nil {
+		http.Error(w, err.Error(), runtime.HTTPStatusFromCode(status.Code(err)))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("Failed to encode apply results: %v", err)
+	}
+}
+
+func serveResync(w http.ResponseWriter, r *http.Request, opi *evpn.Server) {
+	in := &evpn.ResyncRequest{}
+	response, err := intercept(r.Context(), "AdminService/Resync", in, func(ctx context.Context) (interface{}, error) {
+		return opi.Resync(ctx, in)
+	})
+	if err != nil {
+		http.Error(w, err.Error(), runtime.HTTPStatusFromCode(status.Code(err)))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("Failed to encode resync results: %v", err)
+	}
+}
+
+// serveBatch decodes the body into in and answers with the per-request results of call, the
+// method of the service, e.g. LogicalBridgeService/BatchCreateLogicalBridges
+func serveBatch(w http.ResponseWriter, r *http.Request, method string, in interface{}, call func(ctx context.Context) (interface{}, error)) {
+	if err := json.NewDecoder(r.Body).Decode(in); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	response, err := intercept(r.Context(), method, in, call)
+	if err != nil {
+		http.Error(w, err.Error(), runtime.HTTPStatusFromCode(status.Code(err)))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("Failed to encode batch results: %v", err)
+	}
+}
+
+func serveAuditEvents(w http.ResponseWriter, r *http.Request, opi *evpn.Server) {
+	in := &evpn.ListAuditEventsRequest{PageToken: r.URL.Query().Get("page_token")}
+	if value := r.URL.Query().Get("page_size"); value != "" {
+		size, err := strconv.ParseInt(value, 10, 32)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		in.PageSize = int32(size)
+	}
+	response, err := opi.ListAuditEvents(r.Context(), in)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("Failed to encode audit events: %v", err)
+	}
+}
+
+func serveEvpnRoutes(w http.ResponseWriter, r *http.Request, opi *evpn.Server) {
+	in := &evpn.GetEvpnRoutesRequest{Vrf: r.URL.Query().Get("vrf")}
+	if value := r.URL.Query().Get("vni"); value != "" {
+		vni, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		in.Vni = uint32(vni)
+	}
+	response, err := opi.GetEvpnRoutes(r.Context(), in)
+	if err != nil {
+		http.Error(w, err.Error(), runtime.HTTPStatusFromCode(status.Code(err)))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("Failed to encode EVPN routes: %v", err)
+	}
+}
+
+func servePortNeighbors(w http.ResponseWriter, r *http.Request, opi *evpn.Server) {
+	in := &evpn.GetPortNeighborsRequest{
+		BridgePort: r.URL.Query().Get("bridgePort"),
+		Interface:  r.URL.Query().Get("interface"),
+	}
+	response, err := opi.GetPortNeighbors(r.Context(), in)
+	if err != nil {
+		http.Error(w, err.Error(), runtime.HTTPStatusFromCode(status.Code(err)))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("Failed to encode port neighbors: %v", err)
+	}
+}
+
+func serveHostInterfaces(w http.ResponseWriter, r *http.Request, opi *evpn.Server) {
+	response, err := opi.ListHostInterfaces(r.Context(), &evpn.ListHostInterfacesRequest{})
+	if err != nil {
+		http.Error(w, err.Error(), runtime.HTTPStatusFromCode(status.Code(err)))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("Failed to encode host interfaces: %v", err)
+	}
+}
+
+func serveOffloadCounters(w http.ResponseWriter, r *http.Request, opi *evpn.Server) {
+	in := &evpn.GetOffloadCountersRequest{LogicalBridge: r.URL.Query().Get("logicalBridge")}
+	response, err := opi.GetOffloadCounters(r.Context(), in)
+	if err != nil {
+		http.Error(w, err.Error(), runtime.HTTPStatusFromCode(status.Code(err)))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("Failed to encode offload counters: %v", err)
+	}
+}
+
+func serveMacsecCounters(w http.ResponseWriter, r *http.Request, opi *evpn.Server) {
+	in := &evpn.GetMacsecCountersRequest{BridgePort: r.URL.Query().Get("bridgePort")}
+	response, err := opi.GetMacsecCounters(r.Context(), in)
+	if err != nil {
+		http.Error(w, err.Error(), runtime.HTTPStatusFromCode(status.Code(err)))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("Failed to encode MACsec counters: %v", err)
+	}
+}
+
+func serveVrfLoopbacks(w http.ResponseWriter, r *http.Request, opi *evpn.Server) {
+	in := &evpn.GetVrfLoopbacksRequest{Vrf: r.URL.Query().Get("vrf")}
+	response, err := opi.GetVrfLoopbacks(r.Context(), in)
+	if err != nil {
+		http.Error(w, err.Error(), runtime.HTTPStatusFromCode(status.Code(err)))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("Failed to encode Vrf loopbacks: %v", err)
+	}
+}
+
+func serveMacMobilityEvents(w http.ResponseWriter, r *http.Request, opi *evpn.Server) {
+	in := &evpn.ListMacMobilityEventsRequest{LogicalBridge: r.URL.Query().Get("logicalBridge")}
+	if value := r.URL.Query().Get("duplicates"); value != "" {
+		duplicates, err := strconv.ParseBool(value)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		in.DuplicatesOnly = duplicates
+	}
+	response, err := opi.ListMacMobilityEvents(r.Context(), in)
+	if err != nil {
+		http.Error(w, err.Error(), runtime.HTTPStatusFromCode(status.Code(err)))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("Failed to encode MAC mobility events: %v", err)
+	}
+}
+
+func serveRemoteVteps(w http.ResponseWriter, r *http.Request, opi *evpn.Server) {
+	in := &evpn.GetRemoteVtepsRequest{LogicalBridge: r.URL.Query().Get("logicalBridge")}
+	response, err := opi.GetRemoteVteps(r.Context(), in)
+	if err != nil {
+		http.Error(w, err.Error(), runtime.HTTPStatusFromCode(status.Code(err)))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("Failed to encode remote VTEPs: %v", err)
+	}
+}
+
+// serveAdminCall runs a call of AdminService, e.g. on the runtime config or the version of the
+// server, these calls apply to this instance only, the standby of an HA pair included
+func serveAdminCall(w http.ResponseWriter, r *http.Request, method string, in interface{}, call func(ctx context.Context) (interface{}, error)) {
+	response, err := intercept(r.Context(), "AdminService/"+method, in, call)
+	if err != nil {
+		http.Error(w, err.Error(), runtime.HTTPStatusFromCode(status.Code(err)))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("Failed to encode response: %v", err)
+	}
+}
+
+// registerResourceHandlers serves the resources the opi-api has no service for yet
+func registerResourceHandlers(mux *runtime.ServeMux, opi *evpn.Server) {
+	handleResource(mux, "handoffs", resourceCalls{
+		kind:   "VrfLiteHandoff",
+		kinds:  "VrfLiteHandoffs",
+		create: bodyCall(opi.CreateVrfLiteHandoff),
+		get: func(ctx context.Context, name string) (interface{}, error) {
+			return opi.GetVrfLiteHandoff(ctx, &evpn.GetVrfLiteHandoffRequest{Name: name})
+		},
+		list: func(ctx context.Context, in listParams) (interface{}, error) {
+			return opi.ListVrfLiteHandoffs(ctx, &evpn.ListVrfLiteHandoffsRequest{PageSize: in.pageSize, PageToken: in.pageToken})
+		},
+		delete: func(ctx context.Context, name string, allowMissing bool) (interface{}, error) {
+			return opi.DeleteVrfLiteHandoff(ctx, &evpn.DeleteVrfLiteHandoffRequest{Name: name, AllowMissing: allowMissing})
+		},
+	})
+	handleResource(mux, "routes", resourceCalls{
+		kind:   "Route",
+		kinds:  "Routes",
+		create: bodyCall(opi.CreateRoute),
+		list: func(ctx context.Context, in listParams) (interface{}, error) {
+			return opi.ListRoutes(ctx, &evpn.ListRoutesRequest{Parent: in.parent, PageSize: in.pageSize, PageToken: in.pageToken})
+		},
+		delete: func(ctx context.Context, name string, allowMissing bool) (interface{}, error) {
+			return opi.DeleteRoute(ctx, &evpn.DeleteRouteRequest{Name: name, AllowMissing: allowMissing})
+		},
+	})
+	handleResource(mux, "routeLeaks", resourceCalls{
+		kind:   "RouteLeak",
+		kinds:  "RouteLeaks",
+		create: bodyCall(opi.CreateRouteLeak),
+		get: func(ctx context.Context, name string) (interface{}, error) {
+			return opi.GetRouteLeak(ctx, &evpn.GetRouteLeakRequest{Name: name})
+		},
+		list: func(ctx context.Context, in listParams) (interface{}, error) {
+			return opi.ListRouteLeaks(ctx, &evpn.ListRouteLeaksRequest{PageSize: in.pageSize, PageToken: in.pageToken})
+		},
+		delete: func(ctx context.Context, name string, allowMissing bool) (interface{}, error) {
+			return opi.DeleteRouteLeak(ctx, &evpn.DeleteRouteLeakRequest{Name: name, AllowMissing: allowMissing})
+		},
+	})
+	handleResource(mux, "bgpPeers", resourceCalls{
+		kind:   "BgpPeer",
+		kinds:  "BgpPeers",
+		create: bodyCall(opi.CreateBgpPeer),
+		get: func(ctx context.Context, name string) (interface{}, error) {
+			return opi.GetBgpPeer(ctx, &evpn.GetBgpPeerRequest{Name: name})
+		},
+		list: func(ctx context.Context, in listParams) (interface{}, error) {
+			return opi.ListBgpPeers(ctx, &evpn.ListBgpPeersRequest{PageSize: in.pageSize, PageToken: in.pageToken})
+		},
+		delete: func(ctx context.Context, name string, allowMissing bool) (interface{}, error) {
+			return opi.DeleteBgpPeer(ctx, &evpn.DeleteBgpPeerRequest{Name: name, AllowMissing: allowMissing})
+		},
+	})
+	handleResource(mux, "staticFdbEntries", resourceCalls{
+		kind:   "StaticFdbEntry",
+		kinds:  "StaticFdbEntries",
+		create: bodyCall(opi.CreateStaticFdbEntry),
+		list: func(ctx context.Context, in listParams) (interface{}, error) {
+			return opi.ListStaticFdbEntries(ctx, &evpn.ListStaticFdbEntriesRequest{Parent: in.parent, PageSize: in.pageSize, PageToken: in.pageToken})
+		},
+		delete: func(ctx context.Context, name string, allowMissing bool) (interface{}, error) {
+			return opi.DeleteStaticFdbEntry(ctx, &evpn.DeleteStaticFdbEntryRequest{Name: name, AllowMissing: allowMissing})
+		},
+	})
+	handleResource(mux, "securityPolicies", resourceCalls{
+		kind:   "SecurityPolicy",
+		kinds:  "SecurityPolicies",
+		create: bodyCall(opi.CreateSecurityPolicy),
+		update: bodyCall(opi.UpdateSecurityPolicy),
+		list: func(ctx context.Context, in listParams) (interface{}, error) {
+			return opi.ListSecurityPolicies(ctx, &evpn.ListSecurityPoliciesRequest{Parent: in.parent, PageSize: in.pageSize, PageToken: in.pageToken})
+		},
+		delete: func(ctx context.Context, name string, allowMissing bool) (interface{}, error) {
+			return opi.DeleteSecurityPolicy(ctx, &evpn.DeleteSecurityPolicyRequest{Name: name, AllowMissing: allowMissing})
+		},
+	})
+	handleResource(mux, "natRules", resourceCalls{
+		kind:   "NatRule",
+		kinds:  "NatRules",
+		create: bodyCall(opi.CreateNatRule),
+		list: func(ctx context.Context, in listParams) (interface{}, error) {
+			return opi.ListNatRules(ctx, &evpn.ListNatRulesRequest{Parent: in.parent, PageSize: in.pageSize, PageToken: in.pageToken})
+		},
+		delete: func(ctx context.Context, name string, allowMissing bool) (interface{}, error) {
+			return opi.DeleteNatRule(ctx, &evpn.DeleteNatRuleRequest{Name: name, AllowMissing: allowMissing})
+		},
+	})
+	handleResource(mux, "pbrRules", resourceCalls{
+		kind:   "PbrRule",
+		kinds:  "PbrRules",
+		create: bodyCall(opi.CreatePbrRule),
+		list: func(ctx context.Context, in listParams) (interface{}, error) {
+			return opi.ListPbrRules(ctx, &evpn.ListPbrRulesRequest{Parent: in.parent, PageSize: in.pageSize, PageToken: in.pageToken})
+		},
+		delete: func(ctx context.Context, name string, allowMissing bool) (interface{}, error) {
+			return opi.DeletePbrRule(ctx, &evpn.DeletePbrRuleRequest{Name: name, AllowMissing: allowMissing})
+		},
+	})
+	handleResource(mux, "prefixLists", resourceCalls{
+		kind:   "PrefixList",
+		kinds:  "PrefixLists",
+		create: bodyCall(opi.CreatePrefixList),
+		update: bodyCall(opi.UpdatePrefixList),
+		list: func(ctx context.Context, in listParams) (interface{}, error) {
+			return opi.ListPrefixLists(ctx, &evpn.ListPrefixListsRequest{PageSize: in.pageSize, PageToken: in.pageToken})
+		},
+		delete: func(ctx context.Context, name string, allowMissing bool) (interface{}, error) {
+			return opi.DeletePrefixList(ctx, &evpn.DeletePrefixListRequest{Name: name, AllowMissing: allowMissing})
+		},
+	})
+	handleResource(mux, "routeMaps", resourceCalls{
+		kind:   "RouteMap",
+		kinds:  "RouteMaps",
+		create: bodyCall(opi.CreateRouteMap),
+		update: bodyCall(opi.UpdateRouteMap),
+		list: func(ctx context.Context, in listParams) (interface{}, error) {
+			return opi.ListRouteMaps(ctx, &evpn.ListRouteMapsRequest{PageSize: in.pageSize, PageToken: in.pageToken})
+		},
+		delete: func(ctx context.Context, name string, allowMissing bool) (interface{}, error) {
+			return opi.DeleteRouteMap(ctx, &evpn.DeleteRouteMapRequest{Name: name, AllowMissing: allowMissing})
+		},
+	})
+	handleResource(mux, "tunnelSecurities", resourceCalls{
+		kind:   "TunnelSecurity",
+		kinds:  "TunnelSecurities",
+		create: bodyCall(opi.CreateTunnelSecurity),
+		list: func(ctx context.Context, in listParams) (interface{}, error) {
+			return opi.ListTunnelSecurities(ctx, &evpn.ListTunnelSecuritiesRequest{PageSize: in.pageSize, PageToken: in.pageToken})
+		},
+		delete: func(ctx context.Context, name string, allowMissing bool) (interface{}, error) {
+			return opi.DeleteTunnelSecurity(ctx, &evpn.DeleteTunnelSecurityRequest{Name: name, AllowMissing: allowMissing})
+		},
+	})
+	handleResource(mux, "underlayInterfaces", resourceCalls{
+		kind:   "UnderlayInterface",
+		kinds:  "UnderlayInterfaces",
+		create: bodyCall(opi.CreateUnderlayInterface),
+		list: func(ctx context.Context, in listParams) (interface{}, error) {
+			return opi.ListUnderlayInterfaces(ctx, &evpn.ListUnderlayInterfacesRequest{PageSize: in.pageSize, PageToken: in.pageToken})
+		},
+		delete: func(ctx context.Context, name string, allowMissing bool) (interface{}, error) {
+			return opi.DeleteUnderlayInterface(ctx, &evpn.DeleteUnderlayInterfaceRequest{Name: name, AllowMissing: allowMissing})
+		},
+	})
+}
+
+// resourceCalls are the calls of a resource served under /v1/<collection>, the bodies being
+// the json of the requests of package evpn
+type resourceCalls struct {
+	// kind and kinds name the service and the methods of the calls, e.g. CreateRoute and
+	// ListRoutes of RouteService
+	kind   string
+	kinds  string
+	create func(ctx context.Context, body io.Reader) (interface{}, error)
+	// update is nil for the resources replaced by a delete and a create
+	update func(ctx context.Context, body io.Reader) (interface{}, error)
+	// get is nil for the resources only listed
+	get    func(ctx context.Context, name string) (interface{}, error)
+	list   func(ctx context.Context, in listParams) (interface{}, error)
+	delete func(ctx context.Context, name string, allowMissing bool) (interface{}, error)
+}
+
+// listParams are the query parameters of a list, parent only applying to the resources of a parent
+type listParams struct {
+	parent    string
+	pageSize  int32
+	pageToken string
+}
+
+// handleResource registers the calls of a resource: POST creates, PATCH updates, GET gets the
+// object of the name parameter or lists them, DELETE deletes the object of the name parameter
+func handleResource(mux *runtime.ServeMux, collection string, calls resourceCalls) {
+	path := "/v1/" + collection
+	service := calls.kind + "Service/"
+	err := mux.HandlePath("POST", path, func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		serveResourceCall(w, r, service+"Create"+calls.kind, func(ctx context.Context) (interface{}, error) {
+			return calls.create(ctx, r.Body)
+		})
+	})
+	if err != nil {
+		log.Panicf("cannot register %s create handler", collection)
+	}
+	if calls.update != nil {
+		err = mux.HandlePath("PATCH", path, func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+			serveResourceCall(w, r, service+"Update"+calls.kind, func(ctx context.Context) (interface{}, error) {
+				return calls.update(ctx, r.Body)
+			})
+		})
+		if err != nil {
+			log.Panicf("cannot register %s update handler", collection)
+		}
+	}
+	err = mux.HandlePath("GET", path, func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		method := service + "List" + calls.kinds
+		if r.URL.Query().Get("name") != "" && calls.get != nil {
+			method = service + "Get" + calls.kind
+		}
+		serveResourceCall(w, r, method, func(ctx context.Context) (interface{}, error) {
+			query := r.URL.Query()
+			if name := query.Get("name"); name != "" && calls.get != nil {
+				return calls.get(ctx, name)
+			}
+			in := listParams{parent: query.Get("parent"), pageToken: query.Get("page_token")}
+			if value := query.Get("page_size"); value != "" {
+				size, err := strconv.ParseInt(value, 10, 32)
+				if err != nil {
+					return nil, status.Errorf(codes.InvalidArgument, "invalid page_size %q", value)
+				}
+				in.pageSize = int32(size)
+			}
+			return calls.list(ctx, in)
+		})
+	})
+	if err != nil {
+		log.Panicf("cannot register %s get handler", collection)
+	}
+	err = mux.HandlePath("DELETE", path, func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		serveResourceCall(w, r, service+"Delete"+calls.kind, func(ctx context.Context) (interface{}, error) {
+			query := r.URL.Query()
+			allowMissing := false
+			if value := query.Get("allow_missing"); value != "" {
+				var err error
+				if allowMissing, err = strconv.ParseBool(value); err != nil {
+					return nil, status.Errorf(codes.InvalidArgument, "invalid allow_missing %q", value)
+				}
+			}
+			return calls.delete(ctx, query.Get("name"), allowMissing)
+		})
+	})
+	if err != nil {
+		log.Panicf("cannot register %s delete handler", collection)
+	}
+}
+
+// bodyCall decodes the body into the request of call
+func bodyCall[T any, R any](call func(context.Context, *T) (R, error)) func(context.Context, io.Reader) (interface{}, error) {
+	return func(ctx context.Context, body io.Reader) (interface{}, error) {
+		data, err := io.ReadAll(body)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		in := new(T)
+		if err := utils.UnmarshalJSON(data, in); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid request: %v", err)
+		}
+		return call(ctx, in)
+	}
+}
+
+// serveResourceCall answers with the result of call, run as method, the api messages it holds
+// being encoded as protojson does
+func serveResourceCall(w http.ResponseWriter, r *http.Request, method string, call func(ctx context.Context) (interface{}, error)) {
+	response, err := intercept(r.Context(), method, nil, call)
+	if err != nil {
+		http.Error(w, err.Error(), runtime.HTTPStatusFromCode(status.Code(err)))
+		return
+	}
+	data, err := utils.MarshalJSON(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if _, err := w.Write(data); err != nil {
+		log.Printf("Failed to write response: %v", err)
+	}
+}
+
+// serveDiagnostic decodes the body into in and streams the output of run as json lines,
+// flushed as they are printed, a failure after the first line being the last line
+func serveDiagnostic(w http.ResponseWriter, r *http.Request, in interface{}, run func(ctx context.Context, send func(*evpn.DiagnosticOutput)) error) {
+	if err := json.NewDecoder(r.Body).Decode(in); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	extendWriteDeadline(r, streamWriteTimeout)
+	encoder := json.NewEncoder(w)
+	flusher, _ := w.(http.Flusher)
+	started := false
+	send := func(output *evpn.DiagnosticOutput) {
+		if !started {
+			w.Header().Set("Content-Type", "application/x-ndjson")
+			started = true
+		}
+		if err := encoder.Encode(output); err != nil {
+			log.Printf("Failed to encode diagnostic output: %v", err)
+			return
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+	err := run(r.Context(), send)
+	if err == nil {
+		return
+	}
+	if !started {
+		http.Error(w, err.Error(), runtime.HTTPStatusFromCode(status.Code(err)))
+		return
+	}
+	send(&evpn.DiagnosticOutput{Error: err.Error()})
+}
+
+// serveCapturePackets streams the captured packets as a pcap file, flushed as they are
+// captured, a failure after the first packet only ending the stream
+func serveCapturePackets(w http.ResponseWriter, r *http.Request, opi *evpn.Server) {
+	in := &evpn.CapturePacketsRequest{}
+	if err := json.NewDecoder(r.Body).Decode(in); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	extendWriteDeadline(r, streamWriteTimeout)
+	flusher, _ := w.(http.Flusher)
+	started := false
+	err := opi.CapturePackets(r.Context(), in, func(data []byte) {
+		if !started {
+			w.Header().Set("Content-Type", "application/vnd.tcpdump.pcap")
+			started = true
+		}
+		if _, err := w.Write(data); err != nil {
+			log.Printf("Failed to write captured packets: %v", err)
+			return
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+	})
+	if err == nil {
+		return
+	}
+	if !started {
+		http.Error(w, err.Error(), runtime.HTTPStatusFromCode(status.Code(err)))
+		return
 	}
+	log.Printf("Packet capture ended with: %v", err)
 }
@@ -0,0 +1,307 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Intel Corporation, or its subsidiaries.
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package main is the main package of the application
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/philippgille/gokv/gomap"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+
+	pe "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+	pn "github.com/opiproject/opi-api/network/opinetcommon/v1alpha1/gen/go"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/evpn"
+	"github.com/opiproject/opi-evpn-bridge/pkg/fake"
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils"
+)
+
+func Test_ResourceHandlers(t *testing.T) {
+	ctx := context.Background()
+	opi := evpn.NewServerWithArgs(fake.NewNetlink("eth0"), fake.NewFrr(), gomap.NewStore(gomap.DefaultOptions))
+	opi.Gateway.VtepIP = "10.0.0.1"
+	prefix := &pn.IPPrefix{Addr: &pn.IPAddress{Af: pn.IpAf_IP_AF_INET, V4OrV6: &pn.IPAddress_V4Addr{V4Addr: 0x0a000001}}, Len: 32}
+	vrf, err := opi.CreateVrf(ctx, &pe.CreateVrfRequest{VrfId: "blue", Vrf: &pe.Vrf{Spec: &pe.VrfSpec{Vni: proto.Uint32(1000), LoopbackIpPrefix: prefix, VtepIpPrefix: prefix}}})
+	if err != nil {
+		t.Fatal("CreateVrf: expected", nil, "received", err)
+	}
+	green, err := opi.CreateVrf(ctx, &pe.CreateVrfRequest{VrfId: "green", Vrf: &pe.Vrf{Spec: &pe.VrfSpec{Vni: proto.Uint32(2000), LoopbackIpPrefix: prefix, VtepIpPrefix: prefix}}})
+	if err != nil {
+		t.Fatal("CreateVrf: expected", nil, "received", err)
+	}
+	bridge, err := opi.CreateLogicalBridge(ctx, &pe.CreateLogicalBridgeRequest{LogicalBridgeId: "vlan10", LogicalBridge: &pe.LogicalBridge{Spec: &pe.LogicalBridgeSpec{VlanId: 10, Vni: proto.Uint32(10), VtepIpPrefix: prefix}}})
+	if err != nil {
+		t.Fatal("CreateLogicalBridge: expected", nil, "received", err)
+	}
+	mux := runtime.NewServeMux()
+	registerResourceHandlers(mux, opi)
+	serve := func(method string, target string, body string) (int, string) {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(method, target, strings.NewReader(body)))
+		return w.Code, w.Body.String()
+	}
+
+	// the resources are created in order, the later ones may depend on the earlier ones
+	tests := []struct {
+		collection string
+		body       string
+		// parent is set for the child resources, listed by parent
+		parent string
+		// get is false for the resources only listed
+		get bool
+		// update is the field of the object in the update request, empty for the resources
+		// not updated in place
+		update string
+	}{
+		{
+			collection: "handoffs",
+			get:        true,
+			body: `{"VrfLiteHandoffID": "uplink100", "VrfLiteHandoff": {"Spec": {"Vrf": "` + vrf.Name + `", "Uplink": "eth0", "VlanID": 100,
+				"LocalIPPrefix": {"addr": {"af": "IP_AF_INET", "v4Addr": 167772162}, "len": 30},
+				"PeerIPAddress": {"af": "IP_AF_INET", "v4Addr": 167772161}, "RemoteAs": 65100}}}`,
+		},
+		{
+			collection: "routes",
+			body: `{"Parent": "` + vrf.Name + `", "RouteID": "default-route", "Route": {"Spec": {
+				"Prefix": {"addr": {"af": "IP_AF_INET", "v4Addr": 0}, "len": 0}, "NextHop": {"af": "IP_AF_INET", "v4Addr": 167772161}}}}`,
+			parent: vrf.Name,
+		},
+		{
+			collection: "routeLeaks",
+			body: `{"RouteLeakID": "blue-to-green", "RouteLeak": {"Spec": {"SourceVrf": "` + vrf.Name + `", "DestinationVrf": "` + green.Name + `",
+				"Prefixes": [{"addr": {"af": "IP_AF_INET", "v4Addr": 167772160}, "len": 24}]}}}`,
+			get: true,
+		},
+		{
+			collection: "bgpPeers",
+			body:       `{"BgpPeerID": "spine1", "BgpPeer": {"Spec": {"PeerIPAddress": {"af": "IP_AF_INET", "v4Addr": 167772417}, "RemoteAs": 65000}}}`,
+			get:        true,
+		},
+		{
+			collection: "staticFdbEntries",
+			body:       `{"Parent": "` + bridge.Name + `", "StaticFdbEntryID": "appliance", "StaticFdbEntry": {"Spec": {"MacAddress": "AgAAAAAB", "RemoteVtep": {"af": "IP_AF_INET", "v4Addr": 167772418}}}}`,
+			parent:     bridge.Name,
+		},
+		{
+			collection: "pbrRules",
+			body:       `{"Parent": "` + vrf.Name + `", "PbrRuleID": "scrubbing", "PbrRule": {"Spec": {"Priority": 100, "SourcePrefix": {"addr": {"af": "IP_AF_INET", "v4Addr": 167772160}, "len": 24}}}}`,
+			parent:     vrf.Name,
+		},
+		{
+			collection: "prefixLists",
+			body:       `{"PrefixListID": "loopbacks", "PrefixList": {"Spec": {"Entries": [{"Seq": 10, "Action": "permit", "Prefix": {"addr": {"af": "IP_AF_INET", "v4Addr": 167772160}, "len": 8}, "Le": 32}]}}}`,
+			update:     "PrefixList",
+		},
+		{
+			collection: "routeMaps",
+			body:       `{"RouteMapID": "from-spine", "RouteMap": {"Spec": {"Entries": [{"Seq": 10, "Action": "permit", "MatchPrefixList": "//network.opiproject.org/prefixlists/loopbacks"}]}}}`,
+			update:     "RouteMap",
+		},
+		{
+			collection: "tunnelSecurities",
+			body: `{"TunnelSecurityID": "leaf2", "TunnelSecurity": {"Spec": {"RemoteVtep": {"af": "IP_AF_INET", "v4Addr": 167772418}, "Mode": "static",
+				"OutboundSpi": 256, "InboundSpi": 257, "OutboundKey": "000102030405060708090a0b0c0d0e0f10111213", "InboundKey": "101112131415161718191a1b1c1d1e1f20212223"}}}`,
+		},
+		{
+			collection: "underlayInterfaces",
+			body:       `{"UnderlayInterfaceID": "uplink1", "UnderlayInterface": {"Spec": {"Role": "uplink", "Interface": "eth0", "IPPrefix": {"addr": {"af": "IP_AF_INET", "v4Addr": 167837954}, "len": 31}}}}`,
+		},
+	}
+	names := make([]string, len(tests))
+	for i, tt := range tests {
+		path := "/v1/" + tt.collection
+		code, body := serve("POST", path, tt.body)
+		if code != http.StatusOK {
+			t.Fatal(tt.collection, "create: expected", http.StatusOK, "received", code, body)
+		}
+		var obj struct{ Name string }
+		if err := json.Unmarshal([]byte(body), &obj); err != nil || obj.Name == "" {
+			t.Fatal(tt.collection, "create: expected a named object, received", body, err)
+		}
+		names[i] = obj.Name
+		if code, body := serve("GET", path+"?parent="+url.QueryEscape(tt.parent), ""); code != http.StatusOK || !strings.Contains(body, obj.Name) {
+			t.Error(tt.collection, "list: expected", obj.Name, "received", code, body)
+		}
+		if tt.get {
+			if code, body := serve("GET", path+"?name="+url.QueryEscape(obj.Name), ""); code != http.StatusOK || !strings.Contains(body, obj.Name) {
+				t.Error(tt.collection, "get: expected", obj.Name, "received", code, body)
+			}
+		}
+		if tt.update != "" {
+			if code, body := serve("PATCH", path, `{"`+tt.update+`": `+body+`}`); code != http.StatusOK || !strings.Contains(body, obj.Name) {
+				t.Error(tt.collection, "update: expected", obj.Name, "received", code, body)
+			}
+		}
+		if code, body := serve("GET", path+"?page_size=many", ""); code != http.StatusBadRequest {
+			t.Error(tt.collection, "invalid page size: expected", http.StatusBadRequest, "received", code, body)
+		}
+		if code, body := serve("POST", path, `[]`); code != http.StatusBadRequest {
+			t.Error(tt.collection, "invalid body: expected", http.StatusBadRequest, "received", code, body)
+		}
+	}
+	// deleted in reverse order, the dependents first
+	for i := len(tests) - 1; i >= 0; i-- {
+		path := "/v1/" + tests[i].collection + "?name=" + url.QueryEscape(names[i])
+		if code, body := serve("DELETE", path, ""); code != http.StatusOK {
+			t.Error(tests[i].collection, "delete: expected", http.StatusOK, "received", code, body)
+		}
+		if code, body := serve("DELETE", path, ""); code != http.StatusNotFound {
+			t.Error(tests[i].collection, "delete missing: expected", http.StatusNotFound, "received", code, body)
+		}
+		if code, body := serve("DELETE", path+"&allow_missing=true", ""); code != http.StatusOK {
+			t.Error(tests[i].collection, "delete allowed missing: expected", http.StatusOK, "received", code, body)
+		}
+	}
+
+	// the resources programmed with nft are only checked to be served, the host tools are
+	// not faked
+	for _, tt := range []struct {
+		collection string
+		parent     string
+		update     bool
+	}{
+		{collection: "securityPolicies", parent: vrf.Name, update: true},
+		{collection: "natRules", parent: vrf.Name},
+	} {
+		path := "/v1/" + tt.collection
+		if code, body := serve("GET", path+"?parent="+url.QueryEscape(tt.parent), ""); code != http.StatusOK {
+			t.Error(tt.collection, "list: expected", http.StatusOK, "received", code, body)
+		}
+		if code, body := serve("POST", path, `{"Parent": "`+tt.parent+`"}`); code != http.StatusBadRequest {
+			t.Error(tt.collection, "missing object: expected", http.StatusBadRequest, "received", code, body)
+		}
+		if tt.update {
+			if code, body := serve("PATCH", path, `{}`); code != http.StatusBadRequest {
+				t.Error(tt.collection, "update missing object: expected", http.StatusBadRequest, "received", code, body)
+			}
+		}
+		if code, body := serve("DELETE", path+"?name="+url.QueryEscape(tt.parent+"/missing"), ""); code != http.StatusNotFound {
+			t.Error(tt.collection, "delete missing: expected", http.StatusNotFound, "received", code, body)
+		}
+	}
+}
+
+func Test_ExtendWriteDeadline(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("extend") == "true" {
+			extendWriteDeadline(r, time.Second)
+		}
+		time.Sleep(200 * time.Millisecond)
+		if _, err := w.Write([]byte("done")); err != nil {
+			t.Log("write:", err)
+		}
+	}))
+	server.Config.WriteTimeout = 100 * time.Millisecond
+	server.Config.ConnContext = withConn
+	server.Start()
+	defer server.Close()
+
+	for _, tt := range []struct {
+		extend   bool
+		expected bool
+	}{
+		{extend: false, expected: false},
+		{extend: true, expected: true},
+	} {
+		response, err := http.Get(server.URL + "?extend=" + strconv.FormatBool(tt.extend))
+		received := err == nil
+		if received {
+			_ = response.Body.Close()
+		}
+		if received != tt.expected {
+			t.Error("extend", tt.extend, "response: expected", tt.expected, "received", received, err)
+		}
+	}
+}
+
+func Test_WithTenancy(t *testing.T) {
+	tenant := ""
+	handler := withTenancy(utils.NewTenancy(true, "operator"), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tenant = utils.TenantFromContext(r.Context())
+	}))
+	for _, tt := range []struct {
+		identity string
+		code     int
+		tenant   string
+	}{
+		{identity: "", code: http.StatusUnauthorized, tenant: ""},
+		{identity: "acme", code: http.StatusOK, tenant: "acme"},
+		{identity: "operator", code: http.StatusOK, tenant: ""},
+	} {
+		tenant = ""
+		r := httptest.NewRequest("GET", "/v1/vrfLoopbacks", nil)
+		r.Header.Set("Grpc-Metadata-X-Opi-Tenant", "globex")
+		if tt.identity != "" {
+			r.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{{Subject: pkix.Name{CommonName: tt.identity}}}}
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		if w.Code != tt.code || tenant != tt.tenant {
+			t.Error(tt.identity, "code and tenant: expected", tt.code, tt.tenant, "received", w.Code, tenant)
+		}
+	}
+}
+
+func Test_WithInterceptors(t *testing.T) {
+	opi := evpn.NewServerWithArgs(fake.NewNetlink("eth0"), fake.NewFrr(), gomap.NewStore(gomap.DefaultOptions))
+	standby := true
+	methods := []string{}
+	record := func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		methods = append(methods, info.FullMethod)
+		return handler(ctx, req)
+	}
+	mux := runtime.NewServeMux()
+	registerResourceHandlers(mux, opi)
+	handler := withInterceptors([]grpc.UnaryServerInterceptor{
+		utils.RequestIDInterceptor(),
+		record,
+		utils.StandbyInterceptor(func() bool { return standby }),
+	}, mux)
+	serve := func(method string, target string, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(method, target, strings.NewReader(body))
+		r.Header.Set("X-Request-Id", "req-1")
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	w := serve("POST", "/v1/prefixLists", `{"PrefixListID": "loopbacks", "PrefixList": {"Spec": {"Entries": [{"Seq": 10, "Action": "permit", "Prefix": {"addr": {"af": "IP_AF_INET", "v4Addr": 167772160}, "len": 8}}]}}}`)
+	if w.Code != http.StatusServiceUnavailable {
+		t.Error("create on standby: expected", http.StatusServiceUnavailable, "received", w.Code, w.Body.String())
+	}
+	if id := w.Header().Get(runtime.MetadataHeaderPrefix + utils.RequestIDMetadataKey); id != "req-1" {
+		t.Error("request ID: expected", "req-1", "received", id)
+	}
+	if w := serve("GET", "/v1/prefixLists", ""); w.Code != http.StatusOK {
+		t.Error("list on standby: expected", http.StatusOK, "received", w.Code, w.Body.String())
+	}
+	standby = false
+	if w := serve("POST", "/v1/prefixLists", `{"PrefixListID": "loopbacks", "PrefixList": {"Spec": {"Entries": [{"Seq": 10, "Action": "permit", "Prefix": {"addr": {"af": "IP_AF_INET", "v4Addr": 167772160}, "len": 8}}]}}}`); w.Code != http.StatusOK {
+		t.Error("create on active: expected", http.StatusOK, "received", w.Code, w.Body.String())
+	}
+	expected := []string{
+		"/" + apiPackage + ".PrefixListService/CreatePrefixList",
+		"/" + apiPackage + ".PrefixListService/ListPrefixLists",
+		"/" + apiPackage + ".PrefixListService/CreatePrefixList",
+	}
+	if !reflect.DeepEqual(methods, expected) {
+		t.Error("methods: expected", expected, "received", methods)
+	}
+}
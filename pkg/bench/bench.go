@@ -0,0 +1,483 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package bench loads the gateway through its gRPC API, creating many objects concurrently,
+// measuring the latency of the calls and the time until the objects are programmed, and
+// verifying the state the gateway ends up with
+package bench
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+	pc "github.com/opiproject/opi-api/network/opinetcommon/v1alpha1/gen/go"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils"
+)
+
+// first VNIs and vlan of the objects created, the i-th object gets the next ones
+const (
+	vrfVniBase    = 10000
+	bridgeVniBase = 20000
+	vlanBase      = 100
+	maxVlan       = 4094
+)
+
+// pollInterval is how often the objects are read back while waiting for them to be programmed
+const pollInterval = 50 * time.Millisecond
+
+// Options are the objects created and how
+type Options struct {
+	// Vrfs, Bridges and Ports are the number of Vrfs, LogicalBridges and BridgePorts created
+	Vrfs    int
+	Bridges int
+	Ports   int
+	// VlansPerPort is the number of LogicalBridges each BridgePort is a trunk of, an access
+	// port when 1
+	VlansPerPort int
+	// Concurrency is the number of calls in flight
+	Concurrency int
+	// PortPrefix names the interfaces of the BridgePorts, followed by 1 to Ports, e.g. eth1,
+	// they have to exist on the gateway
+	PortPrefix string
+	// VtepIP is the VTEP address of the Vrfs and LogicalBridges
+	VtepIP uint32
+	// Async asks the gateway to program the Vrfs in the background
+	Async bool
+	// Timeout is how long to wait for the objects to be programmed
+	Timeout time.Duration
+	// Cleanup deletes the objects at the end, and checks none is left
+	Cleanup bool
+}
+
+// DefaultOptions creates a few objects of each kind, and deletes them at the end
+func DefaultOptions() Options {
+	return Options{
+		Vrfs:         4,
+		Bridges:      16,
+		Ports:        4,
+		VlansPerPort: 4,
+		Concurrency:  8,
+		PortPrefix:   "eth",
+		VtepIP:       0x0a000001,
+		Timeout:      time.Minute,
+		Cleanup:      true,
+	}
+}
+
+// Validate checks the objects can be created, e.g. there are enough vlans
+func (o Options) Validate() error {
+	switch {
+	case o.Vrfs < 0 || o.Bridges < 0 || o.Ports < 0:
+		return errors.New("the number of objects has to be positive")
+	case o.Bridges > maxVlan-vlanBase+1:
+		return fmt.Errorf("at most %d LogicalBridges, one per vlan from %d", maxVlan-vlanBase+1, vlanBase)
+	case o.Ports > 0 && (o.VlansPerPort < 1 || o.VlansPerPort > o.Bridges):
+		return fmt.Errorf("each BridgePort needs 1 to %d LogicalBridges, has %d", o.Bridges, o.VlansPerPort)
+	case o.Concurrency < 1:
+		return errors.New("concurrency has to be at least 1")
+	case o.Ports > 0 && len(o.PortPrefix+"1") < 4:
+		return fmt.Errorf("port prefix %q is too short for a BridgePort ID", o.PortPrefix)
+	case o.Timeout <= 0:
+		return errors.New("timeout has to be positive")
+	}
+	return nil
+}
+
+// Clients are the services of the gateway the objects are created with
+type Clients struct {
+	Vrf    pb.VrfServiceClient
+	Bridge pb.LogicalBridgeServiceClient
+	Port   pb.BridgePortServiceClient
+}
+
+// NewClients creates the clients of the services on the connection to the gateway
+func NewClients(conn grpc.ClientConnInterface) Clients {
+	return Clients{
+		Vrf:    pb.NewVrfServiceClient(conn),
+		Bridge: pb.NewLogicalBridgeServiceClient(conn),
+		Port:   pb.NewBridgePortServiceClient(conn),
+	}
+}
+
+// Phase is the latency of the calls of a step, e.g. creating the Vrfs
+type Phase struct {
+	Name   string
+	Calls  int
+	Errors int
+	// Duration is the time the whole step took
+	Duration time.Duration
+	P50      time.Duration
+	P90      time.Duration
+	P99      time.Duration
+	Max      time.Duration
+	// FirstError is the first call that failed, to tell why
+	FirstError string
+}
+
+// Report is the outcome of a run
+type Report struct {
+	Phases []Phase
+	// Programmed is the time from the first create until every object is read back with an UP
+	// oper status, zero when some never were
+	Programmed time.Duration
+	// Inconsistencies are the differences between the objects created and the state of the
+	// gateway, e.g. a missing object or a spec changed, none when consistent
+	Inconsistencies []string
+}
+
+// Write prints the report as a table of the phases followed by the inconsistencies
+func (r *Report) Write(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "phase\tcalls\terrors\tp50\tp90\tp99\tmax\ttotal\t")
+	for _, phase := range r.Phases {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%v\t%v\t%v\t%v\t%v\t\n", phase.Name, phase.Calls, phase.Errors,
+			phase.P50.Round(time.Microsecond), phase.P90.Round(time.Microsecond), phase.P99.Round(time.Microsecond),
+			phase.Max.Round(time.Microsecond), phase.Duration.Round(time.Millisecond))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	for _, phase := range r.Phases {
+		if phase.FirstError != "" {
+			fmt.Fprintf(w, "%s: first error: %s\n", phase.Name, phase.FirstError)
+		}
+	}
+	if r.Programmed != 0 {
+		fmt.Fprintf(w, "programmed in %v\n", r.Programmed.Round(time.Millisecond))
+	} else {
+		fmt.Fprintln(w, "not programmed")
+	}
+	if len(r.Inconsistencies) == 0 {
+		_, err := fmt.Fprintln(w, "consistent")
+		return err
+	}
+	fmt.Fprintf(w, "%d inconsistencies:\n", len(r.Inconsistencies))
+	for _, inconsistency := range r.Inconsistencies {
+		fmt.Fprintf(w, "  %s\n", inconsistency)
+	}
+	return nil
+}
+
+// Failed tells whether a call failed or the state is inconsistent
+func (r *Report) Failed() bool {
+	for _, phase := range r.Phases {
+		if phase.Errors != 0 {
+			return true
+		}
+	}
+	return r.Programmed == 0 || len(r.Inconsistencies) != 0
+}
+
+// run calls fn for 0 to n-1 with concurrency calls in flight, timing each of them
+func run(ctx context.Context, name string, n int, concurrency int, fn func(ctx context.Context, i int) error) Phase {
+	latencies := make([]time.Duration, n)
+	errs := make([]error, n)
+	indexes := make(chan int)
+	wg := sync.WaitGroup{}
+	start := time.Now()
+	for w := 0; w < concurrency && w < n; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				begin := time.Now()
+				errs[i] = fn(ctx, i)
+				latencies[i] = time.Since(begin)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	phase := Phase{Name: name, Calls: n, Duration: time.Since(start)}
+	for i, err := range errs {
+		if err == nil {
+			continue
+		}
+		phase.Errors++
+		if phase.FirstError == "" {
+			phase.FirstError = fmt.Sprintf("#%d: %v", i, err)
+		}
+	}
+	sort.Slice(latencies, func(i int, j int) bool { return latencies[i] < latencies[j] })
+	phase.P50, phase.P90 = percentile(latencies, 0.5), percentile(latencies, 0.9)
+	phase.P99, phase.Max = percentile(latencies, 0.99), percentile(latencies, 1)
+	return phase
+}
+
+// percentile returns the latency p of the calls are under, of the sorted latencies
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	i := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if i < 0 {
+		i = 0
+	}
+	return sorted[i]
+}
+
+func ipv4Prefix(addr uint32, length int32) *pc.IPPrefix {
+	return &pc.IPPrefix{
+		Addr: &pc.IPAddress{Af: pc.IpAf_IP_AF_INET, V4OrV6: &pc.IPAddress_V4Addr{V4Addr: addr}},
+		Len:  length,
+	}
+}
+
+func vrfID(i int) string {
+	return fmt.Sprintf("bench-vrf-%d", i+1)
+}
+
+func bridgeID(i int) string {
+	return fmt.Sprintf("bench-br-%d", i+1)
+}
+
+func (o Options) portID(i int) string {
+	return o.PortPrefix + strconv.Itoa(i+1)
+}
+
+func fullName(collection string, id string) string {
+	return fmt.Sprintf("//network.opiproject.org/%s/%s", collection, id)
+}
+
+func (o Options) vrfSpec(i int) *pb.VrfSpec {
+	return &pb.VrfSpec{
+		Vni:              proto.Uint32(uint32(vrfVniBase + i + 1)),
+		LoopbackIpPrefix: ipv4Prefix(0x0ac80000+uint32(i+1), 32),
+		VtepIpPrefix:     ipv4Prefix(o.VtepIP, 32),
+	}
+}
+
+func (o Options) bridgeSpec(i int) *pb.LogicalBridgeSpec {
+	return &pb.LogicalBridgeSpec{
+		VlanId:       uint32(vlanBase + i),
+		Vni:          proto.Uint32(uint32(bridgeVniBase + i + 1)),
+		VtepIpPrefix: ipv4Prefix(o.VtepIP, 32),
+	}
+}
+
+// portSpec spreads the vlans of the BridgePorts over the LogicalBridges
+func (o Options) portSpec(i int) *pb.BridgePortSpec {
+	spec := &pb.BridgePortSpec{
+		MacAddress: []byte{0x02, 0xbe, 0x00, 0x00, byte((i + 1) >> 8), byte(i + 1)},
+		Ptype:      pb.BridgePortType_ACCESS,
+	}
+	if o.VlansPerPort > 1 {
+		spec.Ptype = pb.BridgePortType_TRUNK
+	}
+	for j := 0; j < o.VlansPerPort; j++ {
+		spec.LogicalBridges = append(spec.LogicalBridges, fullName("bridges", bridgeID((i*o.VlansPerPort+j)%o.Bridges)))
+	}
+	return spec
+}
+
+// object is an object created, read back to check it is programmed as asked
+type object struct {
+	name string
+	spec proto.Message
+	// get reads the spec and whether the oper status is UP
+	get func(ctx context.Context) (proto.Message, bool, error)
+}
+
+// created keeps the objects created, the others are expected to be missing
+type created struct {
+	mu      sync.Mutex
+	objects map[string]object
+}
+
+func (c *created) add(obj object) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.objects[obj.name] = obj
+}
+
+// Run creates the Vrfs, LogicalBridges and BridgePorts, waits for them to be programmed,
+// verifies the gateway state and deletes them when asked, an error is returned only when
+// the run could not complete, the failed calls and inconsistencies are in the report
+func Run(ctx context.Context, clients Clients, o Options) (*Report, error) {
+	if err := o.Validate(); err != nil {
+		return nil, err
+	}
+	report := &Report{}
+	done := &created{objects: map[string]object{}}
+	start := time.Now()
+
+	vrfCtx := ctx
+	if o.Async {
+		vrfCtx = metadata.AppendToOutgoingContext(ctx, utils.AsyncMetadataKey, "true")
+	}
+	report.Phases = append(report.Phases, run(vrfCtx, "create vrfs", o.Vrfs, o.Concurrency, func(ctx context.Context, i int) error {
+		vrf, err := clients.Vrf.CreateVrf(ctx, &pb.CreateVrfRequest{VrfId: vrfID(i), Vrf: &pb.Vrf{Spec: o.vrfSpec(i)}})
+		if err != nil {
+			return err
+		}
+		done.add(object{name: vrf.Name, spec: o.vrfSpec(i), get: func(ctx context.Context) (proto.Message, bool, error) {
+			vrf, err := clients.Vrf.GetVrf(ctx, &pb.GetVrfRequest{Name: vrf.Name})
+			return vrf.GetSpec(), vrf.GetStatus().GetOperStatus() == pb.VRFOperStatus_VRF_OPER_STATUS_UP, err
+		}})
+		return nil
+	}))
+	report.Phases = append(report.Phases, run(ctx, "create bridges", o.Bridges, o.Concurrency, func(ctx context.Context, i int) error {
+		bridge, err := clients.Bridge.CreateLogicalBridge(ctx, &pb.CreateLogicalBridgeRequest{LogicalBridgeId: bridgeID(i), LogicalBridge: &pb.LogicalBridge{Spec: o.bridgeSpec(i)}})
+		if err != nil {
+			return err
+		}
+		done.add(object{name: bridge.Name, spec: o.bridgeSpec(i), get: func(ctx context.Context) (proto.Message, bool, error) {
+			bridge, err := clients.Bridge.GetLogicalBridge(ctx, &pb.GetLogicalBridgeRequest{Name: bridge.Name})
+			return bridge.GetSpec(), bridge.GetStatus().GetOperStatus() == pb.LBOperStatus_LB_OPER_STATUS_UP, err
+		}})
+		return nil
+	}))
+	report.Phases = append(report.Phases, run(ctx, "create ports", o.Ports, o.Concurrency, func(ctx context.Context, i int) error {
+		port, err := clients.Port.CreateBridgePort(ctx, &pb.CreateBridgePortRequest{BridgePortId: o.portID(i), BridgePort: &pb.BridgePort{Spec: o.portSpec(i)}})
+		if err != nil {
+			return err
+		}
+		done.add(object{name: port.Name, spec: o.portSpec(i), get: func(ctx context.Context) (proto.Message, bool, error) {
+			port, err := clients.Port.GetBridgePort(ctx, &pb.GetBridgePortRequest{Name: port.Name})
+			return port.GetSpec(), port.GetStatus().GetOperStatus() == pb.BPOperStatus_BP_OPER_STATUS_UP, err
+		}})
+		return nil
+	}))
+
+	report.Programmed = waitProgrammed(ctx, done, start, o.Timeout)
+	report.Inconsistencies = append(report.Inconsistencies, verify(ctx, clients, o, done)...)
+	if !o.Cleanup {
+		return report, ctx.Err()
+	}
+
+	report.Phases = append(report.Phases, run(ctx, "delete ports", o.Ports, o.Concurrency, func(ctx context.Context, i int) error {
+		_, err := clients.Port.DeleteBridgePort(ctx, &pb.DeleteBridgePortRequest{Name: fullName("ports", o.portID(i)), AllowMissing: true})
+		return err
+	}))
+	report.Phases = append(report.Phases, run(ctx, "delete bridges", o.Bridges, o.Concurrency, func(ctx context.Context, i int) error {
+		_, err := clients.Bridge.DeleteLogicalBridge(ctx, &pb.DeleteLogicalBridgeRequest{Name: fullName("bridges", bridgeID(i)), AllowMissing: true})
+		return err
+	}))
+	report.Phases = append(report.Phases, run(ctx, "delete vrfs", o.Vrfs, o.Concurrency, func(ctx context.Context, i int) error {
+		_, err := clients.Vrf.DeleteVrf(ctx, &pb.DeleteVrfRequest{Name: fullName("vrfs", vrfID(i)), AllowMissing: true})
+		return err
+	}))
+	report.Inconsistencies = append(report.Inconsistencies, verify(ctx, clients, o, &created{objects: map[string]object{}})...)
+	return report, ctx.Err()
+}
+
+// waitProgrammed reads the objects back until they are all UP, and returns how long it took
+// since start, zero when some are still not UP after the timeout
+func waitProgrammed(ctx context.Context, done *created, start time.Time, timeout time.Duration) time.Duration {
+	pending := map[string]object{}
+	for name, obj := range done.objects {
+		pending[name] = obj
+	}
+	deadline := time.Now().Add(timeout)
+	for {
+		for name, obj := range pending {
+			// an object programmed in the background is missing until then
+			if _, up, err := obj.get(ctx); err == nil && up {
+				delete(pending, name)
+			}
+		}
+		if len(pending) == 0 {
+			return time.Since(start)
+		}
+		if time.Now().After(deadline) || ctx.Err() != nil {
+			return 0
+		}
+		time.Sleep(pollInterval)
+	}
+}
+
+// verify compares the objects of the gateway with the ones created, the objects that failed
+// to be created or were deleted are expected to be missing
+func verify(ctx context.Context, clients Clients, o Options, done *created) []string {
+	inconsistencies := []string{}
+	names := map[string]bool{}
+	for i := 0; i < o.Vrfs; i++ {
+		names[fullName("vrfs", vrfID(i))] = true
+	}
+	for i := 0; i < o.Bridges; i++ {
+		names[fullName("bridges", bridgeID(i))] = true
+	}
+	for i := 0; i < o.Ports; i++ {
+		names[fullName("ports", o.portID(i))] = true
+	}
+	listed, err := list(ctx, clients)
+	if err != nil {
+		return append(inconsistencies, fmt.Sprintf("unable to list the objects: %v", err))
+	}
+	for name, obj := range done.objects {
+		spec, ok := listed[name]
+		switch {
+		case !ok:
+			inconsistencies = append(inconsistencies, fmt.Sprintf("%s is missing", name))
+		case !proto.Equal(spec, obj.spec):
+			inconsistencies = append(inconsistencies, fmt.Sprintf("%s has spec %v instead of %v", name, spec, obj.spec))
+		}
+	}
+	for name := range listed {
+		if _, ok := done.objects[name]; names[name] && !ok {
+			inconsistencies = append(inconsistencies, fmt.Sprintf("%s exists, it failed to be created or was deleted", name))
+		}
+	}
+	sort.Strings(inconsistencies)
+	return inconsistencies
+}
+
+// list returns the specs of all the Vrfs, LogicalBridges and BridgePorts by name
+func list(ctx context.Context, clients Clients) (map[string]proto.Message, error) {
+	specs := map[string]proto.Message{}
+	for token := ""; ; {
+		response, err := clients.Vrf.ListVrfs(ctx, &pb.ListVrfsRequest{PageToken: token})
+		if err != nil {
+			return nil, err
+		}
+		for _, vrf := range response.Vrfs {
+			specs[vrf.Name] = vrf.Spec
+		}
+		if token = response.NextPageToken; token == "" {
+			break
+		}
+	}
+	for token := ""; ; {
+		response, err := clients.Bridge.ListLogicalBridges(ctx, &pb.ListLogicalBridgesRequest{PageToken: token})
+		if err != nil {
+			return nil, err
+		}
+		for _, bridge := range response.LogicalBridges {
+			specs[bridge.Name] = bridge.Spec
+		}
+		if token = response.NextPageToken; token == "" {
+			break
+		}
+	}
+	for token := ""; ; {
+		response, err := clients.Port.ListBridgePorts(ctx, &pb.ListBridgePortsRequest{PageToken: token})
+		if err != nil {
+			return nil, err
+		}
+		for _, port := range response.BridgePorts {
+			specs[port.Name] = port.Spec
+		}
+		if token = response.NextPageToken; token == "" {
+			break
+		}
+	}
+	return specs, nil
+}
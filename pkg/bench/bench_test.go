@@ -0,0 +1,132 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package bench loads the gateway through its gRPC API, creating many objects concurrently,
+// measuring the latency of the calls and the time until the objects are programmed, and
+// verifying the state the gateway ends up with
+package bench
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/philippgille/gokv/gomap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/evpn"
+	"github.com/opiproject/opi-evpn-bridge/pkg/fake"
+)
+
+func dial(t *testing.T, opi *evpn.Server) *grpc.ClientConn {
+	listener := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer()
+	pb.RegisterLogicalBridgeServiceServer(server, opi)
+	pb.RegisterBridgePortServiceServer(server, opi)
+	pb.RegisterVrfServiceServer(server, opi)
+	go func() {
+		if err := server.Serve(listener); err != nil {
+			log.Fatal(err)
+		}
+	}()
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.DialContext(context.Background(), "", grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+			return listener.Dial()
+		}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func Test_Run(t *testing.T) {
+	interfaces := []string{}
+	for i := 1; i <= 3; i++ {
+		interfaces = append(interfaces, fmt.Sprintf("eth%d", i))
+	}
+	opi := evpn.NewServerWithArgs(fake.NewNetlink(interfaces...), fake.NewFrr(), gomap.NewStore(gomap.DefaultOptions))
+	clients := NewClients(dial(t, opi))
+
+	o := DefaultOptions()
+	o.Vrfs, o.Bridges, o.Ports, o.VlansPerPort = 2, 4, 3, 2
+	// one call at a time keeps the in-process server deterministic
+	o.Concurrency = 1
+	o.Timeout = time.Second
+	report, err := Run(context.Background(), clients, o)
+	if err != nil {
+		t.Fatal("error: expected", nil, "received", err)
+	}
+	phases := []string{}
+	for _, phase := range report.Phases {
+		phases = append(phases, fmt.Sprintf("%s %d/%d", phase.Name, phase.Calls-phase.Errors, phase.Calls))
+		if phase.Errors != 0 {
+			t.Error(phase.Name, ": expected no errors, received", phase.FirstError)
+		}
+	}
+	expected := "create vrfs 2/2,create bridges 4/4,create ports 3/3,delete ports 3/3,delete bridges 4/4,delete vrfs 2/2"
+	if strings.Join(phases, ",") != expected {
+		t.Error("phases: expected", expected, "received", phases)
+	}
+	if report.Programmed == 0 || len(report.Inconsistencies) != 0 || report.Failed() {
+		t.Error("report: expected programmed and consistent, received", report.Programmed, report.Inconsistencies)
+	}
+	out := &bytes.Buffer{}
+	if err := report.Write(out); err != nil || !strings.HasSuffix(out.String(), "consistent\n") {
+		t.Error("output: expected consistent, received", out.String(), err)
+	}
+
+	// a port whose interface is missing fails, and is expected to be missing, the deleted
+	// BridgePorts took their interfaces with them
+	opi = evpn.NewServerWithArgs(fake.NewNetlink(interfaces...), fake.NewFrr(), gomap.NewStore(gomap.DefaultOptions))
+	o.Ports, o.Cleanup = 4, false
+	report, err = Run(context.Background(), NewClients(dial(t, opi)), o)
+	if err != nil {
+		t.Fatal("error: expected", nil, "received", err)
+	}
+	if ports := report.Phases[2]; ports.Errors != 1 || !report.Failed() {
+		t.Error("create ports: expected 1 error, received", ports.Errors, ports.FirstError)
+	}
+	if len(report.Inconsistencies) != 0 {
+		t.Error("inconsistencies: expected none, received", report.Inconsistencies)
+	}
+}
+
+func Test_OptionsValidate(t *testing.T) {
+	tests := map[string]struct {
+		change func(o *Options)
+		valid  bool
+	}{
+		"default":              {change: func(o *Options) {}, valid: true},
+		"too many bridges":     {change: func(o *Options) { o.Bridges = 4000 }},
+		"more vlans":           {change: func(o *Options) { o.VlansPerPort = o.Bridges + 1 }},
+		"no vlans":             {change: func(o *Options) { o.VlansPerPort = 0 }},
+		"no ports, no vlans":   {change: func(o *Options) { o.Ports, o.VlansPerPort = 0, 0 }, valid: true},
+		"no concurrency":       {change: func(o *Options) { o.Concurrency = 0 }},
+		"short port prefix":    {change: func(o *Options) { o.PortPrefix = "p" }},
+		"negative number":      {change: func(o *Options) { o.Vrfs = -1 }},
+		"no timeout":           {change: func(o *Options) { o.Timeout = 0 }},
+		"no objects at all":    {change: func(o *Options) { o.Vrfs, o.Bridges, o.Ports = 0, 0, 0 }, valid: true},
+		"bridges on all vlans": {change: func(o *Options) { o.Bridges = maxVlan - vlanBase + 1 }, valid: true},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			o := DefaultOptions()
+			tt.change(&o)
+			if err := o.Validate(); (err == nil) != tt.valid {
+				t.Error("valid: expected", tt.valid, "received", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,27 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package bluefield offloads the EVPN forwarding to the NVIDIA BlueField eSwitch through OVS
+package bluefield
+
+import (
+	"context"
+	"errors"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/evpn"
+	"github.com/opiproject/opi-evpn-bridge/pkg/ovs"
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils"
+)
+
+// NewDataplane plugs the objects into the OVS bridge like the ovs dataplane, the flows of the
+// bridge are offloaded to the ConnectX eSwitch (tc/doca-flow) only with other_config:hw-offload=true
+func NewDataplane(ctx context.Context, server *evpn.Server, sw ovs.Switch, nLink utils.Netlink, frr utils.Frr) (*ovs.Dataplane, error) {
+	config, err := sw.OtherConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if config["hw-offload"] != "true" {
+		return nil, errors.New("OVS hw-offload is not enabled, set other_config:hw-offload=true")
+	}
+	return ovs.NewDataplane(server, sw, nLink, frr), nil
+}
@@ -0,0 +1,51 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package bluefield offloads the EVPN forwarding to the NVIDIA BlueField eSwitch through OVS
+package bluefield
+
+import (
+	"context"
+	"testing"
+
+	"github.com/philippgille/gokv/gomap"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/evpn"
+	"github.com/opiproject/opi-evpn-bridge/pkg/ovs"
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils/mocks"
+)
+
+// fakeSwitch only knows the other_config of OVS
+type fakeSwitch struct {
+	ovs.Switch
+	config map[string]string
+}
+
+func (s *fakeSwitch) OtherConfig(_ context.Context) (map[string]string, error) {
+	return s.config, nil
+}
+
+func TestNewDataplane(t *testing.T) {
+	tests := map[string]struct {
+		config  map[string]string
+		wantErr bool
+	}{
+		"hw-offload enabled": {
+			config: map[string]string{"hw-offload": "true"},
+		},
+		"hw-offload disabled": {
+			config:  map[string]string{},
+			wantErr: true,
+		},
+	}
+
+	for testName, tt := range tests {
+		t.Run(testName, func(t *testing.T) {
+			server := evpn.NewServerWithArgs(mocks.NewNetlink(t), mocks.NewFrr(t), gomap.NewStore(gomap.DefaultOptions))
+			_, err := NewDataplane(context.Background(), server, &fakeSwitch{config: tt.config}, mocks.NewNetlink(t), mocks.NewFrr(t))
+			if (err != nil) != tt.wantErr {
+				t.Error("error: expected", tt.wantErr, "received", err)
+			}
+		})
+	}
+}
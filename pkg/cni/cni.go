@@ -0,0 +1,279 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package cni attaches pods to LogicalBridges as a CNI plugin
+package cni
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// supportedVersions are the CNI spec versions the plugin understands
+var supportedVersions = []string{"0.4.0", "1.0.0"}
+
+// CNI error codes, see https://github.com/containernetworking/cni/blob/main/SPEC.md#error
+const (
+	errIncompatibleVersion = 1
+	errInvalidEnvironment  = 4
+	errDecoding            = 6
+	errInvalidConfig       = 7
+	errTryAgain            = 11
+	errGateway             = 100
+)
+
+// NetConf is the network configuration of the plugin, e.g.:
+//
+//	{"cniVersion": "1.0.0", "name": "tenant-blue", "type": "opi-evpn-cni",
+//	 "logicalBridges": ["//network.opiproject.org/bridges/vlan10"]}
+type NetConf struct {
+	CNIVersion string `json:"cniVersion"`
+	Name       string `json:"name"`
+	Type       string `json:"type"`
+	// Server is the gRPC address of the gateway, localhost:50151 when empty
+	Server string `json:"server,omitempty"`
+	// LogicalBridges the pod interface is a BridgePort of
+	LogicalBridges []string `json:"logicalBridges"`
+	// PortType is ACCESS or TRUNK, ACCESS when empty
+	PortType string `json:"portType,omitempty"`
+	MTU      int    `json:"mtu,omitempty"`
+}
+
+// Args are the per invocation parameters passed in the CNI_* environment variables
+type Args struct {
+	Command     string
+	ContainerID string
+	Netns       string
+	IfName      string
+}
+
+// Error is the error result of a CNI invocation
+type Error struct {
+	CNIVersion string `json:"cniVersion,omitempty"`
+	Code       uint   `json:"code"`
+	Msg        string `json:"msg"`
+	Details    string `json:"details,omitempty"`
+}
+
+func (e *Error) Error() string {
+	if e.Details == "" {
+		return e.Msg
+	}
+	return e.Msg + ": " + e.Details
+}
+
+// Interface is an interface of the result of an ADD
+type Interface struct {
+	Name    string `json:"name"`
+	Mac     string `json:"mac,omitempty"`
+	Sandbox string `json:"sandbox,omitempty"`
+}
+
+// Result is the success result of an ADD, see https://github.com/containernetworking/cni/blob/main/SPEC.md#success
+type Result struct {
+	CNIVersion string      `json:"cniVersion"`
+	Interfaces []Interface `json:"interfaces"`
+}
+
+// Links creates the pod interfaces, VethLinks is the implementation used by the plugin
+type Links interface {
+	// AddVeth creates a veth pair with hostName on the host, moves the peer into the
+	// netns as ifName and returns its MAC address
+	AddVeth(hostName string, netns string, ifName string, mtu int) (string, error)
+	// DelVeth deletes the veth pair, a missing one is not an error
+	DelVeth(hostName string) error
+}
+
+// BridgePortClient is the subset of pb.BridgePortServiceClient used by the plugin
+type BridgePortClient interface {
+	CreateBridgePort(ctx context.Context, in *pb.CreateBridgePortRequest, opts ...grpc.CallOption) (*pb.BridgePort, error)
+	DeleteBridgePort(ctx context.Context, in *pb.DeleteBridgePortRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	GetBridgePort(ctx context.Context, in *pb.GetBridgePortRequest, opts ...grpc.CallOption) (*pb.BridgePort, error)
+}
+
+// Plugin handles the CNI commands
+type Plugin struct {
+	client BridgePortClient
+	links  Links
+}
+
+// NewPlugin creates a plugin talking to the gateway through client
+func NewPlugin(client BridgePortClient, links Links) *Plugin {
+	return &Plugin{client: client, links: links}
+}
+
+// PortID is the BridgePort ID, and host interface name, of the pod interface.
+// It is derived from the container and interface so that DEL finds it again
+// and fits the 15 characters of a kernel interface name
+func PortID(containerID string, ifName string) string {
+	sum := sha256.Sum256([]byte(containerID + "/" + ifName))
+	return "cni-" + hex.EncodeToString(sum[:])[:10]
+}
+
+// portName is the resource name the gateway gives to the BridgePort with that ID
+func portName(id string) string {
+	return fmt.Sprintf("//network.opiproject.org/ports/%s", id)
+}
+
+// ParseNetConf decodes and checks the network configuration read from stdin
+func ParseNetConf(data []byte) (*NetConf, error) {
+	conf := &NetConf{}
+	if err := json.Unmarshal(data, conf); err != nil {
+		return nil, &Error{Code: errDecoding, Msg: "failed to decode network configuration", Details: err.Error()}
+	}
+	if len(conf.LogicalBridges) == 0 {
+		return nil, &Error{Code: errInvalidConfig, Msg: "logicalBridges is required"}
+	}
+	if _, err := conf.portType(); err != nil {
+		return nil, err
+	}
+	if conf.Server == "" {
+		conf.Server = "localhost:50151"
+	}
+	return conf, nil
+}
+
+func (conf *NetConf) portType() (pb.BridgePortType, error) {
+	switch strings.ToUpper(conf.PortType) {
+	case "", "ACCESS":
+		if len(conf.LogicalBridges) > 1 {
+			return pb.BridgePortType_UNKNOWN, &Error{Code: errInvalidConfig, Msg: "an ACCESS port has a single logical bridge, use TRUNK"}
+		}
+		return pb.BridgePortType_ACCESS, nil
+	case "TRUNK":
+		return pb.BridgePortType_TRUNK, nil
+	}
+	return pb.BridgePortType_UNKNOWN, &Error{Code: errInvalidConfig, Msg: fmt.Sprintf("portType %s is not ACCESS or TRUNK", conf.PortType)}
+}
+
+// gatewayError maps a gRPC error of the gateway to a CNI error
+func gatewayError(msg string, err error) *Error {
+	code := uint(errGateway)
+	if status.Code(err) == codes.Unavailable {
+		code = errTryAgain
+	}
+	return &Error{Code: code, Msg: msg, Details: status.Convert(err).Message()}
+}
+
+// Add creates the pod interface and its BridgePort
+func (p *Plugin) Add(ctx context.Context, conf *NetConf, args *Args) (*Result, error) {
+	if args.Netns == "" || args.IfName == "" {
+		return nil, &Error{Code: errInvalidEnvironment, Msg: "CNI_NETNS and CNI_IFNAME are required"}
+	}
+	ptype, err := conf.portType()
+	if err != nil {
+		return nil, err
+	}
+	id := PortID(args.ContainerID, args.IfName)
+	mac, err := p.links.AddVeth(id, args.Netns, args.IfName, conf.MTU)
+	if err != nil {
+		return nil, &Error{Code: errGateway, Msg: "failed to create the pod interface", Details: err.Error()}
+	}
+	in := &pb.CreateBridgePortRequest{
+		BridgePortId: id,
+		BridgePort: &pb.BridgePort{
+			Spec: &pb.BridgePortSpec{Ptype: ptype, LogicalBridges: conf.LogicalBridges},
+		},
+	}
+	if _, err := p.client.CreateBridgePort(ctx, in); err != nil {
+		if err := p.links.DelVeth(id); err != nil {
+			// stdout is the CNI result
+			log.Printf("Failed to clean up veth %s: %v", id, err)
+		}
+		return nil, gatewayError("failed to create the bridge port", err)
+	}
+	return &Result{
+		CNIVersion: conf.CNIVersion,
+		Interfaces: []Interface{{Name: id}, {Name: args.IfName, Mac: mac, Sandbox: args.Netns}},
+	}, nil
+}
+
+// Del deletes the BridgePort and the pod interface, both may already be gone
+func (p *Plugin) Del(ctx context.Context, _ *NetConf, args *Args) error {
+	id := PortID(args.ContainerID, args.IfName)
+	if _, err := p.client.DeleteBridgePort(ctx, &pb.DeleteBridgePortRequest{Name: portName(id), AllowMissing: true}); err != nil {
+		return gatewayError("failed to delete the bridge port", err)
+	}
+	if err := p.links.DelVeth(id); err != nil {
+		return &Error{Code: errGateway, Msg: "failed to delete the pod interface", Details: err.Error()}
+	}
+	return nil
+}
+
+// Check verifies the BridgePort of the pod interface still exists
+func (p *Plugin) Check(ctx context.Context, _ *NetConf, args *Args) error {
+	id := PortID(args.ContainerID, args.IfName)
+	if _, err := p.client.GetBridgePort(ctx, &pb.GetBridgePortRequest{Name: portName(id)}); err != nil {
+		return gatewayError("failed to find the bridge port", err)
+	}
+	return nil
+}
+
+// Run executes the CNI command of args with the network configuration read from stdin
+// and writes its result to stdout, the returned error is already written to stdout
+func (p *Plugin) Run(ctx context.Context, args *Args, stdin io.Reader, stdout io.Writer) error {
+	err := p.run(ctx, args, stdin, stdout)
+	if err != nil {
+		cniErr, ok := err.(*Error)
+		if !ok {
+			cniErr = &Error{Code: errGateway, Msg: err.Error()}
+		}
+		_ = json.NewEncoder(stdout).Encode(cniErr)
+	}
+	return err
+}
+
+func (p *Plugin) run(ctx context.Context, args *Args, stdin io.Reader, stdout io.Writer) error {
+	if args.Command == "VERSION" {
+		return json.NewEncoder(stdout).Encode(map[string]interface{}{
+			"cniVersion":        supportedVersions[len(supportedVersions)-1],
+			"supportedVersions": supportedVersions,
+		})
+	}
+	data, err := io.ReadAll(stdin)
+	if err != nil {
+		return &Error{Code: errDecoding, Msg: "failed to read network configuration", Details: err.Error()}
+	}
+	conf, err := ParseNetConf(data)
+	if err != nil {
+		return err
+	}
+	if !supportedVersion(conf.CNIVersion) {
+		return &Error{Code: errIncompatibleVersion, Msg: fmt.Sprintf("cniVersion %s is not supported", conf.CNIVersion)}
+	}
+	switch args.Command {
+	case "ADD":
+		result, err := p.Add(ctx, conf, args)
+		if err != nil {
+			return err
+		}
+		return json.NewEncoder(stdout).Encode(result)
+	case "DEL":
+		return p.Del(ctx, conf, args)
+	case "CHECK":
+		return p.Check(ctx, conf, args)
+	}
+	return &Error{Code: errInvalidEnvironment, Msg: fmt.Sprintf("unknown CNI_COMMAND %s", args.Command)}
+}
+
+func supportedVersion(version string) bool {
+	for _, v := range supportedVersions {
+		if v == version {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,147 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package cni attaches pods to LogicalBridges as a CNI plugin
+package cni
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+type fakeLinks struct {
+	veths map[string]string
+}
+
+func (l *fakeLinks) AddVeth(hostName string, _ string, ifName string, _ int) (string, error) {
+	l.veths[hostName] = ifName
+	return "aa:bb:cc:00:00:41", nil
+}
+
+func (l *fakeLinks) DelVeth(hostName string) error {
+	delete(l.veths, hostName)
+	return nil
+}
+
+type fakeClient struct {
+	ports map[string]*pb.BridgePort
+	err   error
+}
+
+func (c *fakeClient) CreateBridgePort(_ context.Context, in *pb.CreateBridgePortRequest, _ ...grpc.CallOption) (*pb.BridgePort, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	obj := &pb.BridgePort{Name: portName(in.BridgePortId), Spec: in.BridgePort.Spec}
+	c.ports[obj.Name] = obj
+	return obj, nil
+}
+
+func (c *fakeClient) DeleteBridgePort(_ context.Context, in *pb.DeleteBridgePortRequest, _ ...grpc.CallOption) (*emptypb.Empty, error) {
+	delete(c.ports, in.Name)
+	return &emptypb.Empty{}, nil
+}
+
+func (c *fakeClient) GetBridgePort(_ context.Context, in *pb.GetBridgePortRequest, _ ...grpc.CallOption) (*pb.BridgePort, error) {
+	obj, ok := c.ports[in.Name]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "unable to find key %s", in.Name)
+	}
+	return obj, nil
+}
+
+func TestPortID(t *testing.T) {
+	id := PortID("4b8bd53c8b8f", "eth0")
+	if len(id) > 15 || !strings.HasPrefix(id, "cni-") {
+		t.Errorf("PortID() = %v, want a cni- name of at most 15 characters", id)
+	}
+	if other := PortID("4b8bd53c8b8f", "net1"); other == id {
+		t.Errorf("PortID() = %v for both interfaces, want different IDs", id)
+	}
+}
+
+func TestPlugin_Run(t *testing.T) {
+	conf := `{"cniVersion": "1.0.0", "name": "blue", "type": "opi-evpn-cni", "logicalBridges": ["//network.opiproject.org/bridges/vlan10"]}`
+	tests := map[string]struct {
+		command string
+		conf    string
+		err     error
+		code    uint
+		ports   int
+		veths   int
+	}{
+		"add": {
+			command: "ADD",
+			conf:    conf,
+			ports:   1,
+			veths:   1,
+		},
+		"add with gateway down": {
+			command: "ADD",
+			conf:    conf,
+			err:     status.Error(codes.Unavailable, "connection refused"),
+			code:    errTryAgain,
+		},
+		"add access port to several bridges": {
+			command: "ADD",
+			conf:    `{"cniVersion": "1.0.0", "logicalBridges": ["//network.opiproject.org/bridges/a", "//network.opiproject.org/bridges/b"]}`,
+			code:    errInvalidConfig,
+		},
+		"add without bridges": {
+			command: "ADD",
+			conf:    `{"cniVersion": "1.0.0"}`,
+			code:    errInvalidConfig,
+		},
+		"unsupported version": {
+			command: "ADD",
+			conf:    `{"cniVersion": "0.1.0", "logicalBridges": ["//network.opiproject.org/bridges/vlan10"]}`,
+			code:    errIncompatibleVersion,
+		},
+		"del": {
+			command: "DEL",
+			conf:    conf,
+		},
+	}
+	for testName, tt := range tests {
+		t.Run(testName, func(t *testing.T) {
+			client := &fakeClient{ports: map[string]*pb.BridgePort{}, err: tt.err}
+			links := &fakeLinks{veths: map[string]string{}}
+			args := &Args{Command: tt.command, ContainerID: "4b8bd53c8b8f", Netns: "/var/run/netns/pod", IfName: "eth0"}
+			if tt.command == "DEL" {
+				// as left by a previous ADD
+				id := PortID(args.ContainerID, args.IfName)
+				client.ports[portName(id)] = &pb.BridgePort{Name: portName(id)}
+				links.veths[id] = args.IfName
+			}
+			stdout := &bytes.Buffer{}
+			err := NewPlugin(client, links).Run(context.Background(), args, strings.NewReader(tt.conf), stdout)
+			if tt.code != 0 {
+				cniErr := &Error{}
+				if jerr := json.Unmarshal(stdout.Bytes(), cniErr); jerr != nil || cniErr.Code != tt.code || err == nil {
+					t.Errorf("Run() = %v, %s, want code %d", err, stdout, tt.code)
+				}
+			} else if err != nil {
+				t.Errorf("Run() error = %v", err)
+			}
+			if len(client.ports) != tt.ports || len(links.veths) != tt.veths {
+				t.Errorf("ports = %v, veths = %v, want %d and %d", client.ports, links.veths, tt.ports, tt.veths)
+			}
+			if tt.command == "ADD" && tt.code == 0 {
+				result := &Result{}
+				if err := json.Unmarshal(stdout.Bytes(), result); err != nil || len(result.Interfaces) != 2 {
+					t.Errorf("result = %s, want the host and pod interfaces", stdout)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package cni attaches pods to LogicalBridges as a CNI plugin
+package cni
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netns"
+)
+
+// VethLinks creates the pod interfaces as veth pairs with netlink
+type VethLinks struct{}
+
+// AddVeth creates the veth pair, the host end is left down for the gateway to plug
+// into the bridge and a pair left over by a failed ADD is recreated
+func (VethLinks) AddVeth(hostName string, netnsPath string, ifName string, mtu int) (string, error) {
+	ns, err := netns.GetFromPath(netnsPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to open netns %s: %w", netnsPath, err)
+	}
+	defer ns.Close()
+	if err := (VethLinks{}).DelVeth(hostName); err != nil {
+		return "", err
+	}
+	// the peer is renamed once in the netns, where ifName cannot collide with the host
+	peerName := "tmp" + hostName[len("cni"):]
+	veth := &netlink.Veth{LinkAttrs: netlink.LinkAttrs{Name: hostName, MTU: mtu}, PeerName: peerName}
+	if err := netlink.LinkAdd(veth); err != nil {
+		return "", fmt.Errorf("failed to create veth %s: %w", hostName, err)
+	}
+	mac, err := movePeer(ns, peerName, ifName)
+	if err != nil {
+		if err := netlink.LinkDel(veth); err != nil {
+			log.Printf("Failed to delete veth %s: %v", hostName, err)
+		}
+		return "", err
+	}
+	return mac, nil
+}
+
+// movePeer moves the peer into the netns, renames it to ifName and brings it up
+func movePeer(ns netns.NsHandle, peerName string, ifName string) (string, error) {
+	peer, err := netlink.LinkByName(peerName)
+	if err != nil {
+		return "", err
+	}
+	if err := netlink.LinkSetNsFd(peer, int(ns)); err != nil {
+		return "", fmt.Errorf("failed to move %s into the netns: %w", peerName, err)
+	}
+	handle, err := netlink.NewHandleAt(ns)
+	if err != nil {
+		return "", err
+	}
+	defer handle.Delete()
+	if peer, err = handle.LinkByName(peerName); err != nil {
+		return "", err
+	}
+	if err := handle.LinkSetName(peer, ifName); err != nil {
+		return "", fmt.Errorf("failed to rename %s to %s: %w", peerName, ifName, err)
+	}
+	if err := handle.LinkSetUp(peer); err != nil {
+		return "", err
+	}
+	return peer.Attrs().HardwareAddr.String(), nil
+}
+
+// DelVeth deletes the host end, the kernel deletes the peer with it
+func (VethLinks) DelVeth(hostName string) error {
+	link, err := netlink.LinkByName(hostName)
+	if err != nil {
+		var notFound netlink.LinkNotFoundError
+		if errors.As(err, &notFound) {
+			return nil
+		}
+		return err
+	}
+	return netlink.LinkDel(link)
+}
@@ -0,0 +1,198 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package ebpf forwards the LogicalBridges in XDP programs attached to the BridgePorts
+package ebpf
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"log"
+	"net"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+	"github.com/opiproject/opi-evpn-bridge/pkg/evpn"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Dataplane is an experimental fast path for the platforms without switchdev: the XDP program
+// of the BridgePorts bridges the vlans of the LogicalBridges and encapsulates their vni on top
+// of the Linux dataplane, the frames it does not know, e.g. routed by the Svis, are passed to
+// the kernel which stays the control plane view FRR learns from. Translated and service vlans
+// are not supported
+type Dataplane struct {
+	evpn.Dataplane
+	server *evpn.Server
+	maps   Maps
+}
+
+// NewDataplane wraps the current dataplane of the server, to be set with server.SetDataplane
+func NewDataplane(server *evpn.Server, maps Maps) *Dataplane {
+	return &Dataplane{Dataplane: server.Dataplane(), server: server, maps: maps}
+}
+
+func ipv4(addr uint32) net.IP {
+	ip := make(net.IP, 4)
+	binary.BigEndian.PutUint32(ip, addr)
+	return ip
+}
+
+// undo removes again from Linux an object the maps could not be updated for
+func undo(err error, remove func() error) error {
+	fmt.Printf("Failed to program the XDP maps: %v", err)
+	if err := remove(); err != nil {
+		log.Printf("Failed to clean up after the XDP failure: %v", err)
+	}
+	return err
+}
+
+func (d *Dataplane) setTunnel(ctx context.Context, obj *pb.LogicalBridge) error {
+	if obj.Spec.Vni == nil {
+		return nil
+	}
+	return d.maps.SetTunnel(ctx, obj.Spec.VlanId, *obj.Spec.Vni, ipv4(obj.Spec.VtepIpPrefix.GetAddr().GetV4Addr()))
+}
+
+// CreateLogicalBridge programs the LogicalBridge in Linux and maps its vlan to its vni
+func (d *Dataplane) CreateLogicalBridge(ctx context.Context, obj *pb.LogicalBridge) error {
+	if err := d.Dataplane.CreateLogicalBridge(ctx, obj); err != nil {
+		return err
+	}
+	if err := d.setTunnel(ctx, obj); err != nil {
+		return undo(err, func() error { return d.Dataplane.DeleteLogicalBridge(ctx, obj) })
+	}
+	return nil
+}
+
+// DeleteLogicalBridge deletes the tunnel of the vlan before the Linux devices
+func (d *Dataplane) DeleteLogicalBridge(ctx context.Context, obj *pb.LogicalBridge) error {
+	if obj.Spec.Vni != nil {
+		if err := d.maps.DeleteTunnel(ctx, obj.Spec.VlanId); err != nil {
+			fmt.Printf("Failed to delete XDP tunnel: %v", err)
+			return err
+		}
+	}
+	return d.Dataplane.DeleteLogicalBridge(ctx, obj)
+}
+
+// ResyncLogicalBridge re-applies the LogicalBridge to Linux and maps its vlan again
+func (d *Dataplane) ResyncLogicalBridge(ctx context.Context, obj *pb.LogicalBridge) error {
+	if err := d.Dataplane.ResyncLogicalBridge(ctx, obj); err != nil {
+		return err
+	}
+	return d.setTunnel(ctx, obj)
+}
+
+// portVlans returns the vlans of the LogicalBridges of the port, with whether they are tagged
+func (d *Dataplane) portVlans(obj *pb.BridgePort) (map[uint32]bool, error) {
+	trunk := d.server.PortTrunkVlans(obj.Name)
+	vlans := map[uint32]bool{}
+	for _, bridgeRefName := range obj.Spec.LogicalBridges {
+		bridgeObject, ok := d.server.Bridges[bridgeRefName]
+		if !ok {
+			err := status.Errorf(codes.NotFound, "unable to find key %s", bridgeRefName)
+			return nil, err
+		}
+		vlan := bridgeObject.Spec.VlanId
+		vlans[vlan] = obj.Spec.Ptype == pb.BridgePortType_TRUNK && vlan != trunk.Native
+	}
+	return vlans, nil
+}
+
+func (d *Dataplane) setPortVlans(ctx context.Context, obj *pb.BridgePort) error {
+	vlans, err := d.portVlans(obj)
+	if err != nil {
+		return err
+	}
+	port := d.server.PortKernelName(obj.Name)
+	for vlan, tagged := range vlans {
+		if err := d.maps.SetPortVlan(ctx, port, vlan, tagged); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *Dataplane) deletePortVlans(ctx context.Context, obj *pb.BridgePort) error {
+	vlans, err := d.portVlans(obj)
+	if err != nil {
+		return err
+	}
+	port := d.server.PortKernelName(obj.Name)
+	for vlan := range vlans {
+		if err := d.maps.DeletePortVlan(ctx, port, vlan); err != nil {
+			fmt.Printf("Failed to delete XDP port vlan: %v", err)
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *Dataplane) bindPort(ctx context.Context, obj *pb.BridgePort) error {
+	if err := d.setPortVlans(ctx, obj); err != nil {
+		return err
+	}
+	return d.maps.AttachPort(ctx, d.server.PortKernelName(obj.Name))
+}
+
+// BindBridgePort binds the port in Linux, adds it to the vlans of its LogicalBridges and
+// attaches the XDP program to it
+func (d *Dataplane) BindBridgePort(ctx context.Context, obj *pb.BridgePort) error {
+	trunk := d.server.PortTrunkVlans(obj.Name)
+	if len(trunk.Translations) > 0 {
+		msg := fmt.Sprintf("Translating the vlans of BridgePort %s is not supported by the eBPF dataplane", obj.Name)
+		return status.Error(codes.Unimplemented, msg)
+	}
+	if trunk.ServiceVlan != 0 {
+		msg := fmt.Sprintf("Service-tagged BridgePort %s is not supported by the eBPF dataplane", obj.Name)
+		return status.Error(codes.Unimplemented, msg)
+	}
+	if err := d.Dataplane.BindBridgePort(ctx, obj); err != nil {
+		return err
+	}
+	if err := d.bindPort(ctx, obj); err != nil {
+		return undo(err, func() error {
+			if err := d.deletePortVlans(ctx, obj); err != nil {
+				return err
+			}
+			return d.Dataplane.UnbindBridgePort(ctx, obj)
+		})
+	}
+	return nil
+}
+
+// UpdateBridgePort updates the port in Linux and moves it to its new vlans
+func (d *Dataplane) UpdateBridgePort(ctx context.Context, old *pb.BridgePort, obj *pb.BridgePort) error {
+	if err := d.Dataplane.UpdateBridgePort(ctx, old, obj); err != nil {
+		return err
+	}
+	if err := d.deletePortVlans(ctx, old); err != nil {
+		return err
+	}
+	return d.setPortVlans(ctx, obj)
+}
+
+// UnbindBridgePort detaches the XDP program and removes the port from its vlans before
+// unbinding it in Linux
+func (d *Dataplane) UnbindBridgePort(ctx context.Context, obj *pb.BridgePort) error {
+	if err := d.maps.DetachPort(ctx, d.server.PortKernelName(obj.Name)); err != nil {
+		fmt.Printf("Failed to detach XDP program: %v", err)
+		return err
+	}
+	if err := d.deletePortVlans(ctx, obj); err != nil {
+		return err
+	}
+	return d.Dataplane.UnbindBridgePort(ctx, obj)
+}
+
+// ResyncBridgePort re-applies the port to Linux, adds it to its vlans and attaches the XDP
+// program again
+func (d *Dataplane) ResyncBridgePort(ctx context.Context, obj *pb.BridgePort) error {
+	if err := d.Dataplane.ResyncBridgePort(ctx, obj); err != nil {
+		return err
+	}
+	return d.bindPort(ctx, obj)
+}
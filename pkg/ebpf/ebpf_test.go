@@ -0,0 +1,132 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package ebpf forwards the LogicalBridges in XDP programs attached to the BridgePorts
+package ebpf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/philippgille/gokv/gomap"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+	"github.com/opiproject/opi-evpn-bridge/pkg/evpn"
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils/mocks"
+)
+
+// fakeLinux stands for the Linux dataplane the XDP program falls back to
+type fakeLinux struct {
+	evpn.Dataplane
+	ports map[string]bool
+}
+
+func (l *fakeLinux) BindBridgePort(_ context.Context, obj *pb.BridgePort) error {
+	l.ports[obj.Name] = true
+	return nil
+}
+
+func (l *fakeLinux) UnbindBridgePort(_ context.Context, obj *pb.BridgePort) error {
+	delete(l.ports, obj.Name)
+	return nil
+}
+
+// fakeMaps records the updates of the maps
+type fakeMaps struct {
+	Maps
+	updates []string
+	err     error
+}
+
+func (m *fakeMaps) AttachPort(_ context.Context, port string) error {
+	m.updates = append(m.updates, "attach "+port)
+	return m.err
+}
+
+func (m *fakeMaps) SetPortVlan(_ context.Context, port string, vlan uint32, tagged bool) error {
+	m.updates = append(m.updates, fmt.Sprintf("vlan %s %d %v", port, vlan, tagged))
+	return nil
+}
+
+func (m *fakeMaps) DeletePortVlan(_ context.Context, port string, vlan uint32) error {
+	m.updates = append(m.updates, fmt.Sprintf("delete vlan %s %d", port, vlan))
+	return nil
+}
+
+func TestDataplane_BindBridgePort(t *testing.T) {
+	tests := map[string]struct {
+		trunk   evpn.TrunkVlans
+		err     error
+		updates []string
+		ports   int
+		errCode codes.Code
+	}{
+		"native and tagged vlans": {
+			trunk:   evpn.TrunkVlans{Native: 10},
+			updates: []string{"attach eth2", "vlan eth2 10 false", "vlan eth2 20 true"},
+			ports:   1,
+		},
+		"attach failure unbinds the port": {
+			err:     errors.New("no XDP support"),
+			updates: []string{"attach eth2", "delete vlan eth2 10", "delete vlan eth2 20", "vlan eth2 10 true", "vlan eth2 20 true"},
+			errCode: codes.Unknown,
+		},
+		"translated vlans": {
+			trunk:   evpn.TrunkVlans{Translations: map[uint32]uint32{100: 10}},
+			errCode: codes.Unimplemented,
+		},
+	}
+
+	for testName, tt := range tests {
+		t.Run(testName, func(t *testing.T) {
+			maps := &fakeMaps{err: tt.err}
+			server := evpn.NewServerWithArgs(mocks.NewNetlink(t), mocks.NewFrr(t), gomap.NewStore(gomap.DefaultOptions))
+			linux := &fakeLinux{ports: map[string]bool{}}
+			server.SetDataplane(linux)
+			dataplane := NewDataplane(server, maps)
+			for _, vlan := range []uint32{10, 20} {
+				name := fmt.Sprintf("//network.opiproject.org/bridges/vlan%d", vlan)
+				server.Bridges[name] = &pb.LogicalBridge{Name: name, Spec: &pb.LogicalBridgeSpec{VlanId: vlan}}
+			}
+			obj := &pb.BridgePort{
+				Name: "//network.opiproject.org/ports/eth2",
+				Spec: &pb.BridgePortSpec{
+					Ptype:          pb.BridgePortType_TRUNK,
+					LogicalBridges: []string{"//network.opiproject.org/bridges/vlan10", "//network.opiproject.org/bridges/vlan20"},
+				},
+			}
+			server.TrunkVlans[obj.Name] = tt.trunk
+
+			err := dataplane.BindBridgePort(context.Background(), obj)
+			if status.Code(err) != tt.errCode {
+				t.Error("error: expected", tt.errCode, "received", err)
+			}
+			// the vlans are set in map order
+			sort.Strings(maps.updates)
+			if !reflect.DeepEqual(maps.updates, tt.updates) || len(linux.ports) != tt.ports {
+				t.Error("updates: expected", tt.updates, tt.ports, "received", maps.updates, linux.ports)
+			}
+		})
+	}
+}
+
+func Test_encoding(t *testing.T) {
+	key := hex(u32s(4, 10))
+	want := []string{"hex", "04", "00", "00", "00", "0a", "00", "00", "00"}
+	if !reflect.DeepEqual(key, want) {
+		t.Error("key: expected", want, "received", key)
+	}
+	value := hex(append(u32s(1000), net.ParseIP("10.0.0.1").To4()...))
+	want = []string{"hex", "e8", "03", "00", "00", "0a", "00", "00", "01"}
+	if !reflect.DeepEqual(value, want) {
+		t.Error("value: expected", want, "received", value)
+	}
+}
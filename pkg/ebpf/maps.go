@@ -0,0 +1,172 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package ebpf forwards the LogicalBridges in XDP programs attached to the BridgePorts
+package ebpf
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// bpftool loads and attaches the programs and updates their maps
+const bpftool = "bpftool"
+
+// Maps attaches the XDP program to the BridgePorts and fills the maps it forwards with,
+// implemented by Bpftool. Setting an entry which already exists is expected to succeed
+type Maps interface {
+	AttachPort(ctx context.Context, port string) error
+	DetachPort(ctx context.Context, port string) error
+	SetPortVlan(ctx context.Context, port string, vlan uint32, tagged bool) error
+	DeletePortVlan(ctx context.Context, port string, vlan uint32) error
+	SetTunnel(ctx context.Context, vlan uint32, vni uint32, srcIP net.IP) error
+	DeleteTunnel(ctx context.Context, vlan uint32) error
+}
+
+// Bpftool manages the programs of an object file pinned in the bpf filesystem with bpftool,
+// the programs are pinned under progs and their maps under maps of the pin directory:
+//
+//   - xdp_bridge is attached to the BridgePorts, it forwards the frames of the fdb map
+//     entries and passes the others to the kernel bridge
+//   - port_vlans is keyed by ifindex and vlan, both u32, the value is the u32 tagged flag
+//   - tunnels is keyed by the u32 vlan, the value is the u32 vni and the IPv4 source of
+//     the VXLAN encapsulation
+//
+// The keys and values are little endian, as on the x86 and Arm hosts, the addresses in
+// network byte order
+type Bpftool struct {
+	pinDir string
+}
+
+// LoadBpftool loads the programs of the object file and pins them with their maps, the
+// programs already pinned, e.g. by a previous run, are kept
+func LoadBpftool(ctx context.Context, object string, pinDir string) (*Bpftool, error) {
+	b := &Bpftool{pinDir: pinDir}
+	progs := filepath.Join(pinDir, "progs")
+	if _, err := b.run(ctx, "prog", "show", "pinned", filepath.Join(progs, "xdp_bridge")); err == nil {
+		return b, nil
+	}
+	// Example: bpftool prog loadall evpn.bpf.o /sys/fs/bpf/opi-evpn/progs pinmaps /sys/fs/bpf/opi-evpn/maps
+	_, err := b.run(ctx, "prog", "loadall", object, progs, "pinmaps", filepath.Join(pinDir, "maps"))
+	if err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// build time check that struct implements interface
+var _ Maps = (*Bpftool)(nil)
+
+func (b *Bpftool) run(ctx context.Context, args ...string) (string, error) {
+	data, err := exec.CommandContext(ctx, bpftool, args...).Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok && len(exitErr.Stderr) > 0 {
+			return "", fmt.Errorf("%s: %s", err, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", err
+	}
+	return string(data), nil
+}
+
+// hex returns the bytes in the format of the keys and values of bpftool
+func hex(data []byte) []string {
+	bytes := make([]string, 0, len(data))
+	for _, b := range data {
+		bytes = append(bytes, fmt.Sprintf("%02x", b))
+	}
+	return append([]string{"hex"}, bytes...)
+}
+
+func u32s(values ...uint32) []byte {
+	data := make([]byte, 0, 4*len(values))
+	for _, value := range values {
+		data = binary.LittleEndian.AppendUint32(data, value)
+	}
+	return data
+}
+
+func (b *Bpftool) update(ctx context.Context, name string, key []byte, value []byte) error {
+	args := append([]string{"map", "update", "pinned", filepath.Join(b.pinDir, "maps", name), "key"}, hex(key)...)
+	args = append(append(args, "value"), hex(value)...)
+	_, err := b.run(ctx, args...)
+	return err
+}
+
+func (b *Bpftool) delete(ctx context.Context, name string, key []byte) error {
+	args := append([]string{"map", "delete", "pinned", filepath.Join(b.pinDir, "maps", name), "key"}, hex(key)...)
+	_, err := b.run(ctx, args...)
+	if err != nil && strings.Contains(err.Error(), "No such file or directory") {
+		return nil
+	}
+	return err
+}
+
+func ifindex(port string) (uint32, error) {
+	iface, err := net.InterfaceByName(port)
+	if err != nil {
+		return 0, err
+	}
+	return uint32(iface.Index), nil
+}
+
+// AttachPort attaches xdp_bridge to a port, in native mode when its driver supports XDP
+func (b *Bpftool) AttachPort(ctx context.Context, port string) error {
+	prog := filepath.Join(b.pinDir, "progs", "xdp_bridge")
+	// Example: bpftool net attach xdp pinned /sys/fs/bpf/opi-evpn/progs/xdp_bridge dev eth2 overwrite
+	if _, err := b.run(ctx, "net", "attach", "xdp", "pinned", prog, "dev", port, "overwrite"); err == nil {
+		return nil
+	}
+	_, err := b.run(ctx, "net", "attach", "xdpgeneric", "pinned", prog, "dev", port, "overwrite")
+	return err
+}
+
+// DetachPort detaches the program of a port, whatever its mode
+func (b *Bpftool) DetachPort(ctx context.Context, port string) error {
+	for _, mode := range []string{"xdp", "xdpgeneric"} {
+		if _, err := b.run(ctx, "net", "detach", mode, "dev", port); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetPortVlan adds a port to the vlan of a LogicalBridge, tagged for trunks
+func (b *Bpftool) SetPortVlan(ctx context.Context, port string, vlan uint32, tagged bool) error {
+	index, err := ifindex(port)
+	if err != nil {
+		return err
+	}
+	flag := uint32(0)
+	if tagged {
+		flag = 1
+	}
+	return b.update(ctx, "port_vlans", u32s(index, vlan), u32s(flag))
+}
+
+// DeletePortVlan removes a port from the vlan of a LogicalBridge
+func (b *Bpftool) DeletePortVlan(ctx context.Context, port string, vlan uint32) error {
+	index, err := ifindex(port)
+	if err != nil {
+		return err
+	}
+	return b.delete(ctx, "port_vlans", u32s(index, vlan))
+}
+
+// SetTunnel maps the vlan of a LogicalBridge to its vni, encapsulated from the local VTEP
+func (b *Bpftool) SetTunnel(ctx context.Context, vlan uint32, vni uint32, srcIP net.IP) error {
+	src := srcIP.To4()
+	if src == nil {
+		return fmt.Errorf("%v is not an IPv4 address", srcIP)
+	}
+	return b.update(ctx, "tunnels", u32s(vlan), append(u32s(vni), src...))
+}
+
+// DeleteTunnel deletes the vni of the vlan of a LogicalBridge
+func (b *Bpftool) DeleteTunnel(ctx context.Context, vlan uint32) error {
+	return b.delete(ctx, "tunnels", u32s(vlan))
+}
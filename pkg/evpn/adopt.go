@@ -0,0 +1,230 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"log"
+	"net"
+	"strings"
+
+	"github.com/vishvananda/netlink"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+	pc "github.com/opiproject/opi-api/network/opinetcommon/v1alpha1/gen/go"
+)
+
+// kernelLinks indexes the kernel links for lookups while adopting
+type kernelLinks struct {
+	byIndex map[int]netlink.Link
+	byName  map[string]netlink.Link
+}
+
+func (k *kernelLinks) master(link netlink.Link) netlink.Link {
+	if link.Attrs().MasterIndex == 0 {
+		return nil
+	}
+	return k.byIndex[link.Attrs().MasterIndex]
+}
+
+func ipToPrefix(ip net.IP, length int) *pc.IPPrefix {
+	return &pc.IPPrefix{
+		Addr: &pc.IPAddress{
+			Af: pc.IpAf_IP_AF_INET,
+			V4OrV6: &pc.IPAddress_V4Addr{
+				V4Addr: binary.BigEndian.Uint32(ip.To4()),
+			},
+		},
+		Len: int32(length),
+	}
+}
+
+// AdoptExisting discovers bridges, vrfs, vxlan and vlan interfaces already present
+// in the kernel, created by previous tooling, and synthesizes the corresponding
+// API objects so they are managed from now on
+func (s *Server) AdoptExisting(ctx context.Context) error {
+	links, err := s.nLink.LinkList(ctx)
+	if err != nil {
+		fmt.Printf("Failed to list links: %v", err)
+		return err
+	}
+	k := &kernelLinks{byIndex: make(map[int]netlink.Link), byName: make(map[string]netlink.Link)}
+	for _, link := range links {
+		k.byIndex[link.Attrs().Index] = link
+		k.byName[link.Attrs().Name] = link
+	}
+	if err := s.adoptVrfs(ctx, k); err != nil {
+		return err
+	}
+	// logical bridges and svis hang off the tenant bridge
+	if _, ok := k.byName[tenantbridgeName]; !ok {
+		log.Printf("No %s found, skipping adoption of logical bridges and svis", tenantbridgeName)
+		return nil
+	}
+	if err := s.adoptLogicalBridges(ctx, k); err != nil {
+		return err
+	}
+	return s.adoptSvis(ctx, k)
+}
+
+func (s *Server) adoptVrfs(ctx context.Context, k *kernelLinks) error {
+	for _, link := range k.byIndex {
+		vrfdev, ok := link.(*netlink.Vrf)
+		if !ok {
+			continue
+		}
+		if err := validateResourceID(vrfdev.Name, true); err != nil {
+			log.Printf("Skipping VRF %s, not a valid resource ID: %v", vrfdev.Name, err)
+			continue
+		}
+		obj := &pb.Vrf{
+			Name:   resourceIDToFullName("vrfs", vrfdev.Name),
+			Spec:   &pb.VrfSpec{},
+			Status: &pb.VrfStatus{LocalAs: s.Gateway.LocalAs, RoutingTable: vrfdev.Table},
+		}
+		if _, ok := s.Vrfs[obj.Name]; ok {
+			continue
+		}
+		// Example: ip address show dev <vrf-name>-lo, or dev <vrf-name> as set up by older releases
+		var holder netlink.Link = vrfdev
+		if loopback, ok := k.byName[vrfLoopbackKernelName(obj.Name, vrfdev.Name)]; ok && loopback.Attrs().MasterIndex == vrfdev.Index {
+			holder = loopback
+		}
+		addrs, err := s.nLink.AddrList(ctx, holder, netlink.FAMILY_V4)
+		if err != nil {
+			fmt.Printf("Failed to list addresses of VRF link: %v", err)
+			return err
+		}
+		if len(addrs) > 0 {
+			length, _ := addrs[0].Mask.Size()
+			obj.Spec.LoopbackIpPrefix = ipToPrefix(addrs[0].IP, length)
+		}
+		// L3 VNI is a vni<N> vxlan enslaved to a br<N> bridge enslaved to the VRF
+		for _, other := range k.byIndex {
+			vxlan, ok := other.(*netlink.Vxlan)
+			if !ok {
+				continue
+			}
+			bridge := k.master(vxlan)
+			if bridge == nil || bridge.Attrs().Name != fmt.Sprintf("br%d", vxlan.VxlanId) || bridge.Attrs().MasterIndex != vrfdev.Index {
+				continue
+			}
+			vni := uint32(vxlan.VxlanId)
+			obj.Spec.Vni = &vni
+			if vxlan.SrcAddr != nil {
+				obj.Spec.VtepIpPrefix = ipToPrefix(vxlan.SrcAddr, 32)
+			}
+			obj.Status.Rmac = bridge.Attrs().HardwareAddr
+			break
+		}
+		log.Printf("Adopting existing VRF %v", obj.Name)
+		s.putVrf(obj)
+		s.Adopted[obj.Name] = true
+	}
+	return nil
+}
+
+func (s *Server) adoptLogicalBridges(ctx context.Context, k *kernelLinks) error {
+	// Example: bridge vlan show
+	vlans, err := s.nLink.BridgeVlanList(ctx)
+	if err != nil {
+		fmt.Printf("Failed to list bridge vlans: %v", err)
+		return err
+	}
+	for _, link := range k.byIndex {
+		vxlan, ok := link.(*netlink.Vxlan)
+		if !ok {
+			continue
+		}
+		bridge := k.master(vxlan)
+		if bridge == nil || bridge.Attrs().Name != tenantbridgeName {
+			continue
+		}
+		// the vlan of the logical bridge is the pvid of its vxlan port
+		var vlanID uint32
+		for _, info := range vlans[int32(vxlan.Index)] {
+			if info.PortVID() {
+				vlanID = uint32(info.Vid)
+			}
+		}
+		if vlanID == 0 {
+			log.Printf("Skipping Vxlan %s, no pvid found", vxlan.Name)
+			continue
+		}
+		vni := uint32(vxlan.VxlanId)
+		obj := &pb.LogicalBridge{
+			Name:   resourceIDToFullName("bridges", vxlan.Name),
+			Spec:   &pb.LogicalBridgeSpec{Vni: &vni, VlanId: vlanID},
+			Status: &pb.LogicalBridgeStatus{},
+		}
+		if vxlan.SrcAddr != nil {
+			obj.Spec.VtepIpPrefix = ipToPrefix(vxlan.SrcAddr, 32)
+		}
+		if _, ok := s.Bridges[obj.Name]; ok {
+			continue
+		}
+		log.Printf("Adopting existing LogicalBridge %v", obj.Name)
+		s.putLogicalBridge(obj)
+		s.Adopted[obj.Name] = true
+	}
+	return nil
+}
+
+func (s *Server) adoptSvis(ctx context.Context, k *kernelLinks) error {
+	tenant := k.byName[tenantbridgeName]
+	for _, link := range k.byIndex {
+		vlandev, ok := link.(*netlink.Vlan)
+		if !ok || vlandev.ParentIndex != tenant.Attrs().Index || !strings.HasPrefix(vlandev.Name, "vlan") {
+			continue
+		}
+		vrfdev := k.master(vlandev)
+		if vrfdev == nil {
+			continue
+		}
+		vrfName := resourceIDToFullName("vrfs", vrfdev.Attrs().Name)
+		if _, ok := s.Vrfs[vrfName]; !ok {
+			log.Printf("Skipping VLAN %s, VRF %s is not managed", vlandev.Name, vrfName)
+			continue
+		}
+		bridgeName := s.vlanBridge(uint32(vlandev.VlanId))
+		if bridgeName == "" {
+			log.Printf("Skipping VLAN %s, no LogicalBridge with vlan %d", vlandev.Name, vlandev.VlanId)
+			continue
+		}
+		obj := &pb.Svi{
+			Name: resourceIDToFullName("svis", vlandev.Name),
+			Spec: &pb.SviSpec{
+				Vrf:           vrfName,
+				LogicalBridge: bridgeName,
+				MacAddress:    vlandev.HardwareAddr,
+			},
+			Status: &pb.SviStatus{},
+		}
+		if _, ok := s.Svis[obj.Name]; ok {
+			continue
+		}
+		// Example: ip address show dev <link_svi>
+		addrs, err := s.nLink.AddrList(ctx, vlandev, netlink.FAMILY_V4)
+		if err != nil {
+			fmt.Printf("Failed to list addresses of vlan link: %v", err)
+			return err
+		}
+		for _, addr := range addrs {
+			length, _ := addr.Mask.Size()
+			obj.Spec.GwIpPrefix = append(obj.Spec.GwIpPrefix, ipToPrefix(addr.IP, length))
+		}
+		log.Printf("Adopting existing Svi %v", obj.Name)
+		s.putSvi(obj)
+		s.Adopted[obj.Name] = true
+	}
+	return nil
+}
+
+// IsAdopted reports whether the named object was synthesized from pre-existing kernel state
+func (s *Server) IsAdopted(name string) bool {
+	return s.Adopted[name]
+}
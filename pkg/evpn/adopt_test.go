@@ -0,0 +1,132 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/philippgille/gokv/gomap"
+	"github.com/stretchr/testify/mock"
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netlink/nl"
+
+	"google.golang.org/protobuf/proto"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+	pc "github.com/opiproject/opi-api/network/opinetcommon/v1alpha1/gen/go"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils/mocks"
+)
+
+func Test_AdoptExisting(t *testing.T) {
+	rmac := net.HardwareAddr{0xaa, 0xbb, 0xcc, 0x00, 0x00, 0x02}
+	vtep := net.IPv4(10, 0, 0, 4).To4()
+	vrfdev := &netlink.Vrf{LinkAttrs: netlink.LinkAttrs{Index: 10, Name: "blue"}, Table: 1000}
+	l3bridge := &netlink.Bridge{LinkAttrs: netlink.LinkAttrs{Index: 11, Name: "br100", MasterIndex: 10, HardwareAddr: rmac}}
+	l3vxlan := &netlink.Vxlan{LinkAttrs: netlink.LinkAttrs{Index: 12, Name: "vni100", MasterIndex: 11}, VxlanId: 100, SrcAddr: vtep}
+	tenant := &netlink.Bridge{LinkAttrs: netlink.LinkAttrs{Index: 20, Name: tenantbridgeName}}
+	l2vxlan := &netlink.Vxlan{LinkAttrs: netlink.LinkAttrs{Index: 21, Name: "vni200", MasterIndex: 20}, VxlanId: 200, SrcAddr: vtep}
+	vlandev := &netlink.Vlan{LinkAttrs: netlink.LinkAttrs{Index: 22, Name: "vlan20", ParentIndex: 20, MasterIndex: 10, HardwareAddr: rmac}, VlanId: 20}
+	ignored := &netlink.Vrf{LinkAttrs: netlink.LinkAttrs{Index: 30, Name: "Not_Valid"}, Table: 1005}
+	links := []netlink.Link{vrfdev, l3bridge, l3vxlan, tenant, l2vxlan, vlandev, ignored}
+	loopback := netlink.Addr{IPNet: &net.IPNet{IP: net.IPv4(10, 1, 1, 1).To4(), Mask: net.CIDRMask(32, 32)}}
+	gateway := netlink.Addr{IPNet: &net.IPNet{IP: net.IPv4(10, 2, 0, 1).To4(), Mask: net.CIDRMask(24, 32)}}
+	pvid := &nl.BridgeVlanInfo{Vid: 20, Flags: nl.BRIDGE_VLAN_INFO_PVID | nl.BRIDGE_VLAN_INFO_UNTAGGED}
+
+	l3vni := uint32(100)
+	l2vni := uint32(200)
+	wantVrf := &pb.Vrf{
+		Name: resourceIDToFullName("vrfs", "blue"),
+		Spec: &pb.VrfSpec{
+			Vni:              &l3vni,
+			LoopbackIpPrefix: ipToPrefix(loopback.IP, 32),
+			VtepIpPrefix:     ipToPrefix(vtep, 32),
+		},
+		Status: &pb.VrfStatus{LocalAs: 65000, RoutingTable: 1000, Rmac: rmac},
+	}
+	wantBridge := &pb.LogicalBridge{
+		Name:   resourceIDToFullName("bridges", "vni200"),
+		Spec:   &pb.LogicalBridgeSpec{Vni: &l2vni, VlanId: 20, VtepIpPrefix: ipToPrefix(vtep, 32)},
+		Status: &pb.LogicalBridgeStatus{},
+	}
+	wantSvi := &pb.Svi{
+		Name: resourceIDToFullName("svis", "vlan20"),
+		Spec: &pb.SviSpec{
+			Vrf:           wantVrf.Name,
+			LogicalBridge: wantBridge.Name,
+			MacAddress:    rmac,
+			GwIpPrefix:    []*pc.IPPrefix{ipToPrefix(gateway.IP, 24)},
+		},
+		Status: &pb.SviStatus{},
+	}
+
+	t.Run("successful adoption", func(t *testing.T) {
+		ctx := context.Background()
+		mockNetlink := mocks.NewNetlink(t)
+		mockFrr := mocks.NewFrr(t)
+		store := gomap.NewStore(gomap.DefaultOptions)
+		opi := NewServerWithArgs(mockNetlink, mockFrr, store)
+
+		mockNetlink.EXPECT().LinkList(mock.Anything).Return(links, nil).Once()
+		mockNetlink.EXPECT().AddrList(mock.Anything, vrfdev, netlink.FAMILY_V4).Return([]netlink.Addr{loopback}, nil).Once()
+		mockNetlink.EXPECT().BridgeVlanList(mock.Anything).Return(map[int32][]*nl.BridgeVlanInfo{21: {pvid}}, nil).Once()
+		mockNetlink.EXPECT().AddrList(mock.Anything, vlandev, netlink.FAMILY_V4).Return([]netlink.Addr{gateway}, nil).Once()
+
+		if err := opi.AdoptExisting(ctx); err != nil {
+			t.Fatal("unexpected error", err)
+		}
+		if len(opi.Vrfs) != 1 || !proto.Equal(opi.Vrfs[wantVrf.Name], wantVrf) {
+			t.Error("vrfs: expected", wantVrf, "received", opi.Vrfs)
+		}
+		if len(opi.Bridges) != 1 || !proto.Equal(opi.Bridges[wantBridge.Name], wantBridge) {
+			t.Error("bridges: expected", wantBridge, "received", opi.Bridges)
+		}
+		if len(opi.Svis) != 1 || !proto.Equal(opi.Svis[wantSvi.Name], wantSvi) {
+			t.Error("svis: expected", wantSvi, "received", opi.Svis)
+		}
+		for _, name := range []string{wantVrf.Name, wantBridge.Name, wantSvi.Name} {
+			if !opi.IsAdopted(name) {
+				t.Error("expected", name, "to be marked adopted")
+			}
+		}
+	})
+
+	t.Run("already managed objects are kept", func(t *testing.T) {
+		ctx := context.Background()
+		mockNetlink := mocks.NewNetlink(t)
+		mockFrr := mocks.NewFrr(t)
+		store := gomap.NewStore(gomap.DefaultOptions)
+		opi := NewServerWithArgs(mockNetlink, mockFrr, store)
+		existing := protoClone(wantVrf)
+		existing.Status.LocalAs = 77
+		opi.Vrfs[existing.Name] = existing
+
+		mockNetlink.EXPECT().LinkList(mock.Anything).Return([]netlink.Link{vrfdev}, nil).Once()
+
+		if err := opi.AdoptExisting(ctx); err != nil {
+			t.Fatal("unexpected error", err)
+		}
+		if opi.Vrfs[existing.Name] != existing || opi.IsAdopted(existing.Name) {
+			t.Error("expected existing vrf to be left untouched")
+		}
+	})
+
+	t.Run("failed LinkList call", func(t *testing.T) {
+		ctx := context.Background()
+		mockNetlink := mocks.NewNetlink(t)
+		mockFrr := mocks.NewFrr(t)
+		store := gomap.NewStore(gomap.DefaultOptions)
+		opi := NewServerWithArgs(mockNetlink, mockFrr, store)
+
+		mockNetlink.EXPECT().LinkList(mock.Anything).Return(nil, errors.New("Failed to call LinkList")).Once()
+
+		if err := opi.AdoptExisting(ctx); err == nil || err.Error() != "Failed to call LinkList" {
+			t.Error("error: expected Failed to call LinkList received", err)
+		}
+	})
+}
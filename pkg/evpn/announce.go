@@ -0,0 +1,218 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+	pc "github.com/opiproject/opi-api/network/opinetcommon/v1alpha1/gen/go"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils"
+)
+
+const (
+	// sviAnnouncementsKey is the store key of the announcements of the Svis not using the defaults
+	sviAnnouncementsKey = "sviannouncements"
+	// announcementMaxCount is the max number of announcements of each gateway address
+	announcementMaxCount = 10
+	// announcementMinInterval and announcementMaxInterval bound the time between them
+	announcementMinInterval = 100 * time.Millisecond
+	announcementMaxInterval = 10 * time.Second
+)
+
+// SviAnnouncement is how a Svi announces its gateway addresses, with gratuitous ARPs and
+// unsolicited neighbor advertisements, when they are created or moved, so the hosts of its
+// LogicalBridge update their caches at once instead of when their entries expire
+type SviAnnouncement struct {
+	// Count is the number of announcements of each gateway address, none when 0
+	Count int
+	// Interval is the time between them
+	Interval time.Duration
+}
+
+// DefaultSviAnnouncement are 3 announcements, 1 second apart, as arping -U and the kernel send
+func DefaultSviAnnouncement() SviAnnouncement {
+	return SviAnnouncement{Count: 3, Interval: time.Second}
+}
+
+// parseSviAnnouncement parses the count=N,interval=DURATION options of the announcements, the
+// missing ones are the defaults
+func parseSviAnnouncement(options string) (SviAnnouncement, error) {
+	announcement := DefaultSviAnnouncement()
+	for _, option := range strings.Split(options, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(option), "=")
+		if !ok {
+			return announcement, fmt.Errorf("%q has to be in key=value format", option)
+		}
+		switch key {
+		case "count":
+			count, err := strconv.Atoi(value)
+			if err != nil || count < 0 || count > announcementMaxCount {
+				return announcement, fmt.Errorf("count %q has to be between 0 and %d", value, announcementMaxCount)
+			}
+			announcement.Count = count
+		case "interval":
+			interval, err := time.ParseDuration(value)
+			if err != nil || interval < announcementMinInterval || interval > announcementMaxInterval {
+				return announcement, fmt.Errorf("interval %q has to be between %v and %v", value, announcementMinInterval, announcementMaxInterval)
+			}
+			announcement.Interval = interval
+		default:
+			return announcement, fmt.Errorf("unknown option %q, expected count or interval", key)
+		}
+	}
+	return announcement, nil
+}
+
+// sviAnnouncementFor returns the announcements of a new or updated Svi, sent with the call, or
+// kept from a previous incarnation of the same Svi (e.g.: on replay)
+func (s *Server) sviAnnouncementFor(ctx context.Context, obj *pb.Svi) (SviAnnouncement, error) {
+	value, ok := utils.MetadataValue(ctx, utils.GratuitousArpMetadataKey)
+	if !ok {
+		return s.SviAnnouncement(obj.Name), nil
+	}
+	announcement, err := parseSviAnnouncement(value)
+	if err != nil {
+		msg := fmt.Sprintf("invalid gratuitous ARP %v", err)
+		return announcement, badRequest(utils.GratuitousArpMetadataKey, status.Error(codes.InvalidArgument, msg))
+	}
+	return announcement, nil
+}
+
+// SviAnnouncement returns the announcements of the Svi, the defaults unless it was created or
+// updated with others
+func (s *Server) SviAnnouncement(name string) SviAnnouncement {
+	if announcement, ok := s.SviAnnouncements[name]; ok {
+		return announcement
+	}
+	return DefaultSviAnnouncement()
+}
+
+// setSviAnnouncement keeps the announcements of the Svi, only when they are not the defaults,
+// and reports whether they changed
+func (s *Server) setSviAnnouncement(name string, announcement SviAnnouncement) bool {
+	if s.SviAnnouncement(name) == announcement {
+		return false
+	}
+	if announcement == DefaultSviAnnouncement() {
+		delete(s.SviAnnouncements, name)
+	} else {
+		s.SviAnnouncements[name] = announcement
+	}
+	return true
+}
+
+// sviGatewayMoved reports whether the updated Svi answers for other gateway addresses, or from
+// another MAC address, the hosts having to be told
+func sviGatewayMoved(old *pb.Svi, obj *pb.Svi) bool {
+	if !bytes.Equal(old.Spec.MacAddress, obj.Spec.MacAddress) || len(old.Spec.GwIpPrefix) != len(obj.Spec.GwIpPrefix) {
+		return true
+	}
+	for i, prefix := range obj.Spec.GwIpPrefix {
+		if !proto.Equal(old.Spec.GwIpPrefix[i].GetAddr(), prefix.GetAddr()) {
+			return true
+		}
+	}
+	return false
+}
+
+// gatewayIP returns the address of a gateway prefix, an IPv4 one unless it is IPv6 as the
+// vlan devices are programmed, nil when it has none
+func gatewayIP(prefix *pc.IPPrefix) net.IP {
+	switch {
+	case prefix.GetAddr() == nil:
+		return nil
+	case prefix.Addr.GetAf() == pc.IpAf_IP_AF_INET6:
+		if len(prefix.Addr.GetV6Addr()) != net.IPv6len {
+			return nil
+		}
+		return net.IP(prefix.Addr.GetV6Addr())
+	}
+	ip := make(net.IP, 4)
+	binary.BigEndian.PutUint32(ip, prefix.Addr.GetV4Addr())
+	return ip
+}
+
+// announceSvi announces the gateway addresses of the Svi from its vlan device in the
+// background, the call creating or updating it not waiting for the announcements to be sent
+func (s *Server) announceSvi(obj *pb.Svi) {
+	announcement := s.SviAnnouncement(obj.Name)
+	dev := s.sviKernelName(obj)
+	if announcement.Count == 0 || dev == "" {
+		return
+	}
+	for _, prefix := range obj.Spec.GwIpPrefix {
+		ip := gatewayIP(prefix)
+		if ip == nil {
+			continue
+		}
+		s.announcements.Add(1)
+		go func(ip net.IP) {
+			defer s.announcements.Done()
+			if err := s.announcer.Announce(context.Background(), dev, ip, announcement.Count, announcement.Interval); err != nil {
+				fmt.Printf("Failed to announce %v on %s: %v", ip, dev, err)
+			}
+		}(ip)
+	}
+}
+
+func (s *Server) persistSviAnnouncements() {
+	fields := make(map[string]interface{}, len(s.SviAnnouncements))
+	for name, announcement := range s.SviAnnouncements {
+		fields[name] = map[string]interface{}{
+			"count":    float64(announcement.Count),
+			"interval": announcement.Interval.String(),
+		}
+	}
+	msg, err := structpb.NewStruct(fields)
+	if err == nil {
+		err = s.store.Set(sviAnnouncementsKey, msg)
+	}
+	if err != nil {
+		fmt.Printf("Failed to persist %s: %v", sviAnnouncementsKey, err)
+	}
+}
+
+// loadSviAnnouncements restores the announcements, so replayed Svis keep announcing the same way
+func (s *Server) loadSviAnnouncements() error {
+	msg := &structpb.Struct{}
+	found, err := s.store.Get(sviAnnouncementsKey, msg)
+	if err != nil || !found {
+		return err
+	}
+	for name, value := range msg.Fields {
+		fields := value.GetStructValue().GetFields()
+		interval, err := time.ParseDuration(fields["interval"].GetStringValue())
+		if err != nil {
+			return err
+		}
+		s.SviAnnouncements[name] = SviAnnouncement{
+			Count:    int(fields["count"].GetNumberValue()),
+			Interval: interval,
+		}
+	}
+	return nil
+}
+
+// releaseSviAnnouncement forgets the announcements of a deleted Svi
+func (s *Server) releaseSviAnnouncement(name string) {
+	if _, ok := s.SviAnnouncements[name]; ok {
+		delete(s.SviAnnouncements, name)
+		s.persistSviAnnouncements()
+	}
+}
@@ -0,0 +1,160 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/philippgille/gokv/gomap"
+	"github.com/stretchr/testify/mock"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	pc "github.com/opiproject/opi-api/network/opinetcommon/v1alpha1/gen/go"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils"
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils/mocks"
+)
+
+func Test_parseSviAnnouncement(t *testing.T) {
+	tests := map[string]struct {
+		options string
+		out     SviAnnouncement
+		valid   bool
+	}{
+		"count and interval": {
+			options: "count=5,interval=500ms",
+			out:     SviAnnouncement{Count: 5, Interval: 500 * time.Millisecond},
+			valid:   true,
+		},
+		"default interval": {
+			options: "count=1",
+			out:     SviAnnouncement{Count: 1, Interval: time.Second},
+			valid:   true,
+		},
+		"turned off": {
+			options: "count=0",
+			out:     SviAnnouncement{Interval: time.Second},
+			valid:   true,
+		},
+		"too many": {
+			options: "count=11",
+		},
+		"interval too short": {
+			options: "interval=10ms",
+		},
+		"not key=value": {
+			options: "5",
+		},
+		"unknown option": {
+			options: "burst=5",
+		},
+	}
+	for testName, tt := range tests {
+		t.Run(testName, func(t *testing.T) {
+			announcement, err := parseSviAnnouncement(tt.options)
+			if (err == nil) != tt.valid {
+				t.Error("valid: expected", tt.valid, "received", err)
+			}
+			if err == nil && announcement != tt.out {
+				t.Error("announcement: expected", tt.out, "received", announcement)
+			}
+		})
+	}
+}
+
+func Test_sviAnnouncementFor(t *testing.T) {
+	opi := NewServerWithArgs(mocks.NewNetlink(t), mocks.NewFrr(t), gomap.NewStore(gomap.DefaultOptions))
+	obj := protoClone(&testSvi)
+	obj.Name = testSviName
+
+	// the defaults, or the ones kept from a previous incarnation
+	announcement, err := opi.sviAnnouncementFor(context.Background(), obj)
+	if err != nil || announcement != DefaultSviAnnouncement() {
+		t.Error("announcement: expected", DefaultSviAnnouncement(), "received", announcement, err)
+	}
+	kept := SviAnnouncement{Count: 2, Interval: 200 * time.Millisecond}
+	opi.SviAnnouncements[testSviName] = kept
+	if announcement, _ := opi.sviAnnouncementFor(context.Background(), obj); announcement != kept {
+		t.Error("announcement: expected", kept, "received", announcement)
+	}
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(utils.GratuitousArpMetadataKey, "count=20"))
+	_, err = opi.sviAnnouncementFor(ctx, obj)
+	if status.Code(err) != codes.InvalidArgument {
+		t.Error("error: expected", codes.InvalidArgument, "received", err)
+	}
+}
+
+func Test_announceSvi(t *testing.T) {
+	mockAnnouncer := mocks.NewAnnouncer(t)
+	opi := NewServerWithArgs(mocks.NewNetlink(t), mocks.NewFrr(t), gomap.NewStore(gomap.DefaultOptions))
+	opi.announcer = mockAnnouncer
+	opi.Bridges[testLogicalBridgeName] = protoClone(&testLogicalBridge)
+	obj := protoClone(&testSvi)
+	obj.Name = testSviName
+	// the prefixes without an address are skipped
+	obj.Spec.GwIpPrefix = append(obj.Spec.GwIpPrefix, ipToPrefix(net.ParseIP("10.0.0.1"), 24), &pc.IPPrefix{
+		Addr: &pc.IPAddress{Af: pc.IpAf_IP_AF_INET6, V4OrV6: &pc.IPAddress_V6Addr{V6Addr: net.ParseIP("2001:db8::1")}},
+		Len:  64,
+	})
+
+	mockAnnouncer.EXPECT().Announce(mock.Anything, "vlan22", net.ParseIP("10.0.0.1").To4(), 3, time.Second).Return(nil).Once()
+	mockAnnouncer.EXPECT().Announce(mock.Anything, "vlan22", net.ParseIP("2001:db8::1"), 3, time.Second).Return(nil).Once()
+	opi.announceSvi(obj)
+	opi.announcements.Wait()
+
+	// turned off
+	opi.SviAnnouncements[testSviName] = SviAnnouncement{Interval: time.Second}
+	opi.announceSvi(obj)
+	opi.announcements.Wait()
+}
+
+func Test_sviGatewayMoved(t *testing.T) {
+	old := protoClone(&testSvi)
+	if sviGatewayMoved(old, protoClone(&testSvi)) {
+		t.Error("moved: expected", false, "received", true)
+	}
+	moved := protoClone(&testSvi)
+	moved.Spec.GwIpPrefix[0] = ipToPrefix(net.ParseIP("10.0.0.2"), 24)
+	if !sviGatewayMoved(old, moved) {
+		t.Error("moved: expected", true, "received", false)
+	}
+	moved = protoClone(&testSvi)
+	moved.Spec.MacAddress = []byte{0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff}
+	if !sviGatewayMoved(old, moved) {
+		t.Error("moved: expected", true, "received", false)
+	}
+}
+
+func Test_SviAnnouncementsReplay(t *testing.T) {
+	opi := NewServerWithArgs(mocks.NewNetlink(t), mocks.NewFrr(t), gomap.NewStore(gomap.DefaultOptions))
+	announcement := SviAnnouncement{Count: 5, Interval: 500 * time.Millisecond}
+	if !opi.setSviAnnouncement(testSviName, announcement) {
+		t.Error("changed: expected", true, "received", false)
+	}
+	if opi.setSviAnnouncement(testSviName, announcement) {
+		t.Error("changed: expected", false, "received", true)
+	}
+	opi.persistSviAnnouncements()
+	restored := NewServerWithArgs(mocks.NewNetlink(t), mocks.NewFrr(t), opi.store)
+	if err := restored.loadSviAnnouncements(); err != nil {
+		t.Fatal("error: expected", nil, "received", err)
+	}
+	if received := restored.SviAnnouncement(testSviName); received != announcement {
+		t.Error("announcement: expected", announcement, "received", received)
+	}
+
+	// the defaults are not kept
+	restored.setSviAnnouncement(testSviName, DefaultSviAnnouncement())
+	if _, ok := restored.SviAnnouncements[testSviName]; ok {
+		t.Error("announcement: expected the defaults not to be kept")
+	}
+}
@@ -0,0 +1,123 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils"
+)
+
+// anycastGatewaysKey is the store key of the Svis acting as anycast gateways
+const anycastGatewaysKey = "anycastgateways"
+
+// anycastGatewayFor reports whether a new Svi is an anycast gateway, sent with the call, or
+// kept from a previous incarnation of the same Svi (e.g.: on replay)
+func (s *Server) anycastGatewayFor(ctx context.Context, obj *pb.Svi, bridge *pb.LogicalBridge) (bool, error) {
+	value, ok := utils.MetadataValue(ctx, utils.AnycastGatewayMetadataKey)
+	if !ok {
+		return s.AnycastGateways[obj.Name], nil
+	}
+	anycast, err := strconv.ParseBool(value)
+	if err != nil {
+		msg := fmt.Sprintf("invalid anycast gateway %q, expected true or false", value)
+		return false, badRequest(utils.AnycastGatewayMetadataKey, status.Error(codes.InvalidArgument, msg))
+	}
+	if !anycast {
+		return false, nil
+	}
+	// every VTEP answers for the same gateway, so both have to be given instead of generated
+	if len(obj.Spec.MacAddress) != 6 || len(obj.Spec.GwIpPrefix) == 0 {
+		msg := "an anycast gateway requires the virtual mac_address and gw_ip_prefix"
+		return false, badRequest(utils.AnycastGatewayMetadataKey, status.Error(codes.InvalidArgument, msg))
+	}
+	if bridge.Spec.Vni == nil {
+		msg := fmt.Sprintf("an anycast gateway requires a vni on %s", bridge.Name)
+		return false, badRequest(utils.AnycastGatewayMetadataKey, status.Error(codes.InvalidArgument, msg))
+	}
+	return true, nil
+}
+
+// IsAnycastGateway reports whether the Svi is a distributed anycast gateway, for the dataplanes
+func (s *Server) IsAnycastGateway(name string) bool {
+	return s.AnycastGateways[name]
+}
+
+// frrCreateAnycastGateway advertises the gateway MAC and IP of the vni with the default gateway
+// extended community, the remote VTEPs install them as sticky so the shared MAC is never seen
+// moving, and their ARP suppression answers the hosts asking for the gateway
+func (s *Server) frrCreateAnycastGateway(ctx context.Context, bridge *pb.LogicalBridge) error {
+	data, err := s.frr.FrrBgpCmd(ctx, fmt.Sprintf(
+		`configure terminal
+		router bgp %d
+		address-family l2vpn evpn
+		vni %d
+		advertise-default-gw
+		exit-vni
+		exit-address-family
+		exit`, s.Gateway.LocalAs, *bridge.Spec.Vni))
+	fmt.Printf("FrrBgpCmd: %v:%v", data, err)
+	return err
+}
+
+// frrDeleteAnycastGateway stops advertising the gateway of the vni
+func (s *Server) frrDeleteAnycastGateway(ctx context.Context, bridge *pb.LogicalBridge) error {
+	data, err := s.frr.FrrBgpCmd(ctx, fmt.Sprintf(
+		`configure terminal
+		router bgp %d
+		address-family l2vpn evpn
+		vni %d
+		no advertise-default-gw
+		exit-vni
+		exit-address-family
+		exit`, s.Gateway.LocalAs, *bridge.Spec.Vni))
+	fmt.Printf("FrrBgpCmd: %v:%v", data, err)
+	return err
+}
+
+func (s *Server) persistAnycastGateways() {
+	fields := make(map[string]interface{}, len(s.AnycastGateways))
+	for name := range s.AnycastGateways {
+		fields[name] = true
+	}
+	msg, err := structpb.NewStruct(fields)
+	if err == nil {
+		err = s.store.Set(anycastGatewaysKey, msg)
+	}
+	if err != nil {
+		fmt.Printf("Failed to persist %s: %v", anycastGatewaysKey, err)
+	}
+}
+
+// loadAnycastGateways restores the anycast gateways, so replayed Svis keep advertising them
+func (s *Server) loadAnycastGateways() error {
+	msg := &structpb.Struct{}
+	found, err := s.store.Get(anycastGatewaysKey, msg)
+	if err != nil || !found {
+		return err
+	}
+	for name, value := range msg.Fields {
+		if value.GetBoolValue() {
+			s.AnycastGateways[name] = true
+		}
+	}
+	return nil
+}
+
+// releaseAnycastGateway forgets a deleted Svi
+func (s *Server) releaseAnycastGateway(name string) {
+	if s.AnycastGateways[name] {
+		delete(s.AnycastGateways, name)
+		s.persistAnycastGateways()
+	}
+}
@@ -0,0 +1,109 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/philippgille/gokv/gomap"
+	"github.com/stretchr/testify/mock"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils"
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils/mocks"
+)
+
+func Test_anycastGatewayFor(t *testing.T) {
+	tests := map[string]struct {
+		value   string
+		spec    *pb.SviSpec
+		vni     *uint32
+		out     bool
+		errCode codes.Code
+	}{
+		"anycast gateway": {
+			value: "true",
+			spec:  testSvi.Spec,
+			vni:   proto.Uint32(10),
+			out:   true,
+		},
+		"regular gateway": {
+			value: "false",
+			spec:  testSvi.Spec,
+			vni:   proto.Uint32(10),
+			out:   false,
+		},
+		"invalid value": {
+			value:   "maybe",
+			spec:    testSvi.Spec,
+			vni:     proto.Uint32(10),
+			errCode: codes.InvalidArgument,
+		},
+		"no virtual mac": {
+			value:   "true",
+			spec:    &pb.SviSpec{Vrf: testVrfName, LogicalBridge: testLogicalBridgeName, GwIpPrefix: testSvi.Spec.GwIpPrefix},
+			vni:     proto.Uint32(10),
+			errCode: codes.InvalidArgument,
+		},
+		"no vni": {
+			value:   "true",
+			spec:    testSvi.Spec,
+			errCode: codes.InvalidArgument,
+		},
+	}
+	for testName, tt := range tests {
+		t.Run(testName, func(t *testing.T) {
+			opi := NewServerWithArgs(mocks.NewNetlink(t), mocks.NewFrr(t), gomap.NewStore(gomap.DefaultOptions))
+			ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(utils.AnycastGatewayMetadataKey, tt.value))
+			obj := &pb.Svi{Name: testSviName, Spec: tt.spec}
+			bridge := &pb.LogicalBridge{Name: testLogicalBridgeName, Spec: &pb.LogicalBridgeSpec{VlanId: 10, Vni: tt.vni}}
+			anycast, err := opi.anycastGatewayFor(ctx, obj, bridge)
+			if status.Code(err) != tt.errCode {
+				t.Error("error: expected", tt.errCode, "received", err)
+			}
+			if anycast != tt.out {
+				t.Error("anycast: expected", tt.out, "received", anycast)
+			}
+		})
+	}
+}
+
+func Test_frrAnycastGateway(t *testing.T) {
+	mockFrr := mocks.NewFrr(t)
+	opi := NewServerWithArgs(mocks.NewNetlink(t), mockFrr, gomap.NewStore(gomap.DefaultOptions))
+	bridge := &pb.LogicalBridge{Name: testLogicalBridgeName, Spec: &pb.LogicalBridgeSpec{VlanId: 10, Vni: proto.Uint32(10)}}
+
+	mockFrr.EXPECT().FrrBgpCmd(mock.Anything, mock.MatchedBy(func(command string) bool {
+		return strings.Contains(command, "vni 10\n\t\tadvertise-default-gw")
+	})).Return("", nil).Once()
+	if err := opi.frrCreateAnycastGateway(context.Background(), bridge); err != nil {
+		t.Error("error: expected", nil, "received", err)
+	}
+	mockFrr.EXPECT().FrrBgpCmd(mock.Anything, mock.MatchedBy(func(command string) bool {
+		return strings.Contains(command, "no advertise-default-gw")
+	})).Return("", nil).Once()
+	if err := opi.frrDeleteAnycastGateway(context.Background(), bridge); err != nil {
+		t.Error("error: expected", nil, "received", err)
+	}
+
+	// the anycast gateways are kept for the replay
+	opi.AnycastGateways[testSviName] = true
+	opi.persistAnycastGateways()
+	restored := NewServerWithArgs(mocks.NewNetlink(t), mocks.NewFrr(t), opi.store)
+	if err := restored.loadAnycastGateways(); err != nil {
+		t.Fatal("error: expected", nil, "received", err)
+	}
+	if !restored.IsAnycastGateway(testSviName) {
+		t.Error("anycast: expected", true, "received", false)
+	}
+}
@@ -0,0 +1,365 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"path"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils"
+)
+
+// Actions of the changes of an apply
+const (
+	ActionCreate = "create"
+	ActionUpdate = "update"
+	ActionDelete = "delete"
+)
+
+// ApplyConfigurationRequest is the full desired set of LogicalBridges, Vrfs, Svis, BridgePorts
+// and child resources, in the document layout of ExportConfig
+// TODO: move to opi-api once the message is agreed upon
+type ApplyConfigurationRequest struct {
+	// Document is either JSON or YAML
+	Document []byte
+	// DryRun only returns the plan, nothing is changed
+	DryRun bool
+}
+
+// ConfigChange is a create, update or delete of the plan of an apply
+type ConfigChange struct {
+	Name   string `json:"name"`
+	Action string `json:"action"`
+}
+
+// ApplyConfigurationResponse lists the planned changes and, unless a dry run, their results
+// TODO: move to opi-api once the message is agreed upon
+type ApplyConfigurationResponse struct {
+	Plan    []ConfigChange `json:"plan"`
+	Results []BatchResult  `json:"results,omitempty"`
+}
+
+// plannedChange is a change of the plan with the call making it
+type plannedChange struct {
+	ConfigChange
+	run func(ctx context.Context) error
+}
+
+// unmarshalObjects decodes the protojson objects of a document, refusing the duplicated names
+func unmarshalObjects[T proto.Message](raw []json.RawMessage, kind string, newObject func() T, name func(T) string) (map[string]T, error) {
+	objects := map[string]T{}
+	for _, b := range raw {
+		obj := newObject()
+		if err := protojson.Unmarshal(b, obj); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid %s: %v", kind, err)
+		}
+		if _, ok := objects[name(obj)]; ok {
+			msg := fmt.Sprintf("%s %s is listed twice", kind, name(obj))
+			return nil, status.Error(codes.InvalidArgument, msg)
+		}
+		objects[name(obj)] = obj
+	}
+	return objects, nil
+}
+
+// diffObjects returns the names of the objects to create, update and delete to turn the
+// current objects the call sees into the desired ones, sorted, same compares their specs
+func diffObjects[T any](ctx context.Context, current map[string]T, desired map[string]T, same func(T, T) bool) (creates []string, updates []string, deletes []string, err error) {
+	for _, name := range sortedKeys(desired) {
+		if !inTenant(ctx, name) {
+			err := status.Errorf(codes.PermissionDenied, "%s is not an object of the tenant", name)
+			return nil, nil, nil, err
+		}
+		obj, ok := current[name]
+		switch {
+		case !ok:
+			creates = append(creates, name)
+		case !same(obj, desired[name]):
+			updates = append(updates, name)
+		}
+	}
+	for _, name := range sortedKeys(current) {
+		if _, ok := desired[name]; !ok && inTenant(ctx, name) {
+			deletes = append(deletes, name)
+		}
+	}
+	return creates, updates, deletes, nil
+}
+
+// planVrfs returns the deletes and the other changes of the Vrfs
+func (s *Server) planVrfs(ctx context.Context, raw []json.RawMessage) (dels []plannedChange, sets []plannedChange, err error) {
+	desired, err := unmarshalObjects(raw, "Vrf", func() *pb.Vrf { return &pb.Vrf{} }, (*pb.Vrf).GetName)
+	if err != nil {
+		return nil, nil, err
+	}
+	creates, updates, deletes, err := diffObjects(ctx, s.Vrfs, desired, func(a, b *pb.Vrf) bool { return proto.Equal(a.Spec, b.Spec) })
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, name := range deletes {
+		name := name
+		dels = append(dels, plannedChange{ConfigChange{name, ActionDelete}, func(ctx context.Context) error {
+			_, err := s.DeleteVrf(ctx, &pb.DeleteVrfRequest{Name: name})
+			return err
+		}})
+	}
+	for _, name := range creates {
+		name := name
+		spec := desired[name].Spec
+		sets = append(sets, plannedChange{ConfigChange{name, ActionCreate}, func(ctx context.Context) error {
+			in := &pb.CreateVrfRequest{VrfId: path.Base(name), Vrf: &pb.Vrf{Spec: spec}}
+			_, err := s.CreateVrf(withTenantOf(ctx, name), in)
+			return err
+		}})
+	}
+	for _, name := range updates {
+		name := name
+		spec := desired[name].Spec
+		sets = append(sets, plannedChange{ConfigChange{name, ActionUpdate}, func(ctx context.Context) error {
+			_, err := s.UpdateVrf(ctx, &pb.UpdateVrfRequest{Vrf: &pb.Vrf{Name: name, Spec: spec}})
+			return err
+		}})
+	}
+	return dels, sets, nil
+}
+
+// planLogicalBridges returns the deletes and the other changes of the LogicalBridges
+func (s *Server) planLogicalBridges(ctx context.Context, raw []json.RawMessage) (dels []plannedChange, sets []plannedChange, err error) {
+	desired, err := unmarshalObjects(raw, "LogicalBridge", func() *pb.LogicalBridge { return &pb.LogicalBridge{} }, (*pb.LogicalBridge).GetName)
+	if err != nil {
+		return nil, nil, err
+	}
+	creates, updates, deletes, err := diffObjects(ctx, s.Bridges, desired, func(a, b *pb.LogicalBridge) bool { return proto.Equal(a.Spec, b.Spec) })
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, name := range deletes {
+		name := name
+		dels = append(dels, plannedChange{ConfigChange{name, ActionDelete}, func(ctx context.Context) error {
+			_, err := s.DeleteLogicalBridge(ctx, &pb.DeleteLogicalBridgeRequest{Name: name})
+			return err
+		}})
+	}
+	for _, name := range creates {
+		name := name
+		spec := desired[name].Spec
+		sets = append(sets, plannedChange{ConfigChange{name, ActionCreate}, func(ctx context.Context) error {
+			in := &pb.CreateLogicalBridgeRequest{LogicalBridgeId: path.Base(name), LogicalBridge: &pb.LogicalBridge{Spec: spec}}
+			_, err := s.CreateLogicalBridge(withTenantOf(ctx, name), in)
+			return err
+		}})
+	}
+	for _, name := range updates {
+		name := name
+		spec := desired[name].Spec
+		sets = append(sets, plannedChange{ConfigChange{name, ActionUpdate}, func(ctx context.Context) error {
+			_, err := s.UpdateLogicalBridge(ctx, &pb.UpdateLogicalBridgeRequest{LogicalBridge: &pb.LogicalBridge{Name: name, Spec: spec}})
+			return err
+		}})
+	}
+	return dels, sets, nil
+}
+
+// planBridgePorts returns the deletes and the other changes of the BridgePorts
+func (s *Server) planBridgePorts(ctx context.Context, raw []json.RawMessage) (dels []plannedChange, sets []plannedChange, err error) {
+	desired, err := unmarshalObjects(raw, "BridgePort", func() *pb.BridgePort { return &pb.BridgePort{} }, (*pb.BridgePort).GetName)
+	if err != nil {
+		return nil, nil, err
+	}
+	creates, updates, deletes, err := diffObjects(ctx, s.Ports, desired, func(a, b *pb.BridgePort) bool { return proto.Equal(a.Spec, b.Spec) })
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, name := range deletes {
+		name := name
+		dels = append(dels, plannedChange{ConfigChange{name, ActionDelete}, func(ctx context.Context) error {
+			_, err := s.DeleteBridgePort(ctx, &pb.DeleteBridgePortRequest{Name: name})
+			return err
+		}})
+	}
+	for _, name := range creates {
+		name := name
+		spec := desired[name].Spec
+		sets = append(sets, plannedChange{ConfigChange{name, ActionCreate}, func(ctx context.Context) error {
+			in := &pb.CreateBridgePortRequest{BridgePortId: path.Base(name), BridgePort: &pb.BridgePort{Spec: spec}}
+			_, err := s.CreateBridgePort(withTenantOf(ctx, name), in)
+			return err
+		}})
+	}
+	for _, name := range updates {
+		name := name
+		spec := desired[name].Spec
+		sets = append(sets, plannedChange{ConfigChange{name, ActionUpdate}, func(ctx context.Context) error {
+			_, err := s.UpdateBridgePort(ctx, &pb.UpdateBridgePortRequest{BridgePort: &pb.BridgePort{Name: name, Spec: spec}})
+			return err
+		}})
+	}
+	return dels, sets, nil
+}
+
+// planSvis returns the deletes and the other changes of the Svis
+func (s *Server) planSvis(ctx context.Context, raw []json.RawMessage) (dels []plannedChange, sets []plannedChange, err error) {
+	desired, err := unmarshalObjects(raw, "Svi", func() *pb.Svi { return &pb.Svi{} }, (*pb.Svi).GetName)
+	if err != nil {
+		return nil, nil, err
+	}
+	creates, updates, deletes, err := diffObjects(ctx, s.Svis, desired, func(a, b *pb.Svi) bool { return proto.Equal(a.Spec, b.Spec) })
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, name := range deletes {
+		name := name
+		dels = append(dels, plannedChange{ConfigChange{name, ActionDelete}, func(ctx context.Context) error {
+			_, err := s.DeleteSvi(ctx, &pb.DeleteSviRequest{Name: name})
+			return err
+		}})
+	}
+	for _, name := range creates {
+		name := name
+		spec := desired[name].Spec
+		sets = append(sets, plannedChange{ConfigChange{name, ActionCreate}, func(ctx context.Context) error {
+			in := &pb.CreateSviRequest{SviId: path.Base(name), Svi: &pb.Svi{Spec: spec}}
+			_, err := s.CreateSvi(withTenantOf(ctx, name), in)
+			return err
+		}})
+	}
+	for _, name := range updates {
+		name := name
+		spec := desired[name].Spec
+		sets = append(sets, plannedChange{ConfigChange{name, ActionUpdate}, func(ctx context.Context) error {
+			_, err := s.UpdateSvi(ctx, &pb.UpdateSviRequest{Svi: &pb.Svi{Name: name, Spec: spec}})
+			return err
+		}})
+	}
+	return dels, sets, nil
+}
+
+// planObjects returns the deletes and the other changes of the kind
+func (c childCalls[T]) planObjects(ctx context.Context, raw []json.RawMessage) (dels []plannedChange, sets []plannedChange, err error) {
+	desired := map[string]*T{}
+	for _, b := range raw {
+		obj := new(T)
+		if err := utils.UnmarshalJSON(b, obj); err != nil {
+			return nil, nil, status.Errorf(codes.InvalidArgument, "invalid %s: %v", c.kind, err)
+		}
+		if _, ok := desired[c.name(obj)]; ok {
+			msg := fmt.Sprintf("%s %s is listed twice", c.kind, c.name(obj))
+			return nil, nil, status.Error(codes.InvalidArgument, msg)
+		}
+		desired[c.name(obj)] = obj
+	}
+	creates, updates, deletes, err := diffObjects(ctx, c.stored, desired, func(a, b *T) bool {
+		return checkSameChildSpec(c.name(a), c.spec(a), c.spec(b)) == nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, name := range deletes {
+		name := name
+		dels = append(dels, plannedChange{ConfigChange{name, ActionDelete}, func(ctx context.Context) error {
+			return c.del(ctx, name)
+		}})
+	}
+	for _, name := range creates {
+		obj := desired[name]
+		sets = append(sets, plannedChange{ConfigChange{name, ActionCreate}, func(ctx context.Context) error {
+			return c.create(withTenantOf(ctx, c.name(obj)), obj)
+		}})
+	}
+	for _, name := range updates {
+		name := name
+		obj := desired[name]
+		sets = append(sets, plannedChange{ConfigChange{name, ActionUpdate}, func(ctx context.Context) error {
+			if c.update != nil {
+				return c.update(ctx, obj)
+			}
+			if err := c.del(ctx, name); err != nil {
+				return err
+			}
+			return c.create(withTenantOf(ctx, name), obj)
+		}})
+	}
+	return dels, sets, nil
+}
+
+// planConfiguration returns the changes turning the objects the call sees into the ones of
+// the document, with objectsMu held for reading as the calls making them take it
+func (s *Server) planConfiguration(ctx context.Context, snapshot *configSnapshot) ([]plannedChange, error) {
+	s.objectsMu.RLock()
+	defer s.objectsMu.RUnlock()
+	type kindPlan struct {
+		objects []json.RawMessage
+		plan    func(context.Context, []json.RawMessage) ([]plannedChange, []plannedChange, error)
+	}
+	var kinds []kindPlan
+	used, owned := s.childKinds()
+	for _, kind := range used {
+		kinds = append(kinds, kindPlan{*kind.documentObjects(snapshot), kind.planObjects})
+	}
+	kinds = append(kinds,
+		kindPlan{snapshot.Vrfs, s.planVrfs},
+		kindPlan{snapshot.LogicalBridges, s.planLogicalBridges},
+		kindPlan{snapshot.BridgePorts, s.planBridgePorts},
+		kindPlan{snapshot.Svis, s.planSvis},
+	)
+	for _, kind := range owned {
+		kinds = append(kinds, kindPlan{*kind.documentObjects(snapshot), kind.planObjects})
+	}
+	var plan, sets []plannedChange
+	// parents first, the deletes of the children being moved in front of those of their parents
+	for _, kind := range kinds {
+		dels, kindSets, err := kind.plan(ctx, kind.objects)
+		if err != nil {
+			return nil, err
+		}
+		plan = append(dels, plan...)
+		sets = append(sets, kindSets...)
+	}
+	return append(plan, sets...), nil
+}
+
+// ApplyConfiguration computes the changes turning the LogicalBridges, Vrfs, Svis, BridgePorts and
+// child resources the call sees into the ones of the document and makes them: the deletes first,
+// children first, then the creates and updates, parents first. The objects are named as in the
+// exported documents and their specs are compared as they are stored, the defaulted fields
+// included, the child resources without an update call are deleted and created again. The
+// apply stops at the first failed change, the following ones are not attempted
+func (s *Server) ApplyConfiguration(ctx context.Context, in *ApplyConfigurationRequest) (*ApplyConfigurationResponse, error) {
+	snapshot, err := parseConfigSnapshot(in.Document)
+	if err != nil {
+		return nil, err
+	}
+	plan, err := s.planConfiguration(ctx, snapshot)
+	if err != nil {
+		return nil, err
+	}
+	response := &ApplyConfigurationResponse{Plan: []ConfigChange{}}
+	for _, change := range plan {
+		response.Plan = append(response.Plan, change.ConfigChange)
+	}
+	if in.DryRun {
+		return response, nil
+	}
+	for _, change := range plan {
+		log.Printf("Applying configuration: %s %s", change.Action, change.Name)
+		err := change.run(ctx)
+		response.Results = append(response.Results, batchResult(change.Name, err))
+		if err != nil {
+			log.Printf("Failed to %s %s, the following changes are not attempted: %v", change.Action, change.Name, err)
+			break
+		}
+	}
+	return response, nil
+}
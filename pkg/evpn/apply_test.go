@@ -0,0 +1,160 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/philippgille/gokv/gomap"
+	"github.com/stretchr/testify/mock"
+	"github.com/vishvananda/netlink"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/fake"
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils/mocks"
+)
+
+func Test_ApplyConfiguration(t *testing.T) {
+	tests := map[string]struct {
+		document string
+		plan     []ConfigChange
+		errCode  codes.Code
+	}{
+		"deletes children first and creates parents first": {
+			document: `{"version": 1,
+				"vrfs": [{"name": "` + testVrfName + `", "spec": {"loopbackIpPrefix": {"len": 24}}}],
+				"logicalBridges": [{"name": "//network.opiproject.org/bridges/green", "spec": {"vlanId": 20}}]}`,
+			plan: []ConfigChange{
+				{Name: "//network.opiproject.org/ports/blue-port", Action: ActionDelete},
+				{Name: "//network.opiproject.org/bridges/blue", Action: ActionDelete},
+				{Name: testVrfName, Action: ActionCreate},
+				{Name: "//network.opiproject.org/bridges/green", Action: ActionCreate},
+			},
+		},
+		"changed spec": {
+			document: `{"version": 1,
+				"logicalBridges": [{"name": "//network.opiproject.org/bridges/blue", "spec": {"vlanId": 11}}],
+				"bridgePorts": [{"name": "//network.opiproject.org/ports/blue-port", "spec": {"ptype": "ACCESS", "logicalBridges": ["//network.opiproject.org/bridges/blue"]}}]}`,
+			plan: []ConfigChange{
+				{Name: "//network.opiproject.org/bridges/blue", Action: ActionUpdate},
+			},
+		},
+		"listed twice": {
+			document: `{"version": 1, "vrfs": [{"name": "` + testVrfName + `"}, {"name": "` + testVrfName + `"}]}`,
+			errCode:  codes.InvalidArgument,
+		},
+		"unsupported version": {
+			document: `{"version": 0}`,
+			errCode:  codes.InvalidArgument,
+		},
+	}
+
+	// run tests
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			opi := NewServerWithArgs(mocks.NewNetlink(t), mocks.NewFrr(t), gomap.NewStore(gomap.DefaultOptions))
+			opi.Bridges["//network.opiproject.org/bridges/blue"] = &pb.LogicalBridge{
+				Name: "//network.opiproject.org/bridges/blue",
+				Spec: &pb.LogicalBridgeSpec{VlanId: 10},
+			}
+			opi.Ports["//network.opiproject.org/ports/blue-port"] = &pb.BridgePort{
+				Name: "//network.opiproject.org/ports/blue-port",
+				Spec: &pb.BridgePortSpec{Ptype: pb.BridgePortType_ACCESS, LogicalBridges: []string{"//network.opiproject.org/bridges/blue"}},
+			}
+			response, err := opi.ApplyConfiguration(context.Background(), &ApplyConfigurationRequest{Document: []byte(tt.document), DryRun: true})
+			if status.Code(err) != tt.errCode {
+				t.Fatal("error: expected", tt.errCode, "received", err)
+			}
+			if err != nil {
+				return
+			}
+			if !reflect.DeepEqual(response.Plan, tt.plan) {
+				t.Error("plan: expected", tt.plan, "received", response.Plan)
+			}
+			if response.Results != nil {
+				t.Error("results: expected none for a dry run, received", response.Results)
+			}
+		})
+	}
+
+	t.Run("apply", func(t *testing.T) {
+		mockNetlink := mocks.NewNetlink(t)
+		mockFrr := mocks.NewFrr(t)
+		opi := NewServerWithArgs(mockNetlink, mockFrr, gomap.NewStore(gomap.DefaultOptions))
+		vrf := &netlink.Vrf{LinkAttrs: netlink.LinkAttrs{Name: testVrfID, Alias: managedAlias}, Table: 1000}
+		mockNetlink.EXPECT().LinkAdd(mock.Anything, vrf).Return(nil).Once()
+		mockNetlink.EXPECT().LinkSetUp(mock.Anything, vrf).Return(nil).Once()
+		mockFrr.EXPECT().FrrZebraCmd(mock.Anything, "show vrf").Return("", nil).Once()
+
+		request := &ApplyConfigurationRequest{Document: []byte(`{"version": 1, "vrfs": [{"name": "` + testVrfName + `", "spec": {"loopbackIpPrefix": {"len": 24}}}]}`)}
+		response, err := opi.ApplyConfiguration(context.Background(), request)
+		if err != nil {
+			t.Fatal("error: expected", nil, "received", err)
+		}
+		results := []BatchResult{{Name: testVrfName, Code: codes.OK.String()}}
+		if !reflect.DeepEqual(response.Results, results) {
+			t.Error("results: expected", results, "received", response.Results)
+		}
+		if _, ok := opi.Vrfs[testVrfName]; !ok {
+			t.Error("vrf: expected", testVrfName, "to be created")
+		}
+
+		// applying the same document again has nothing to do
+		response, err = opi.ApplyConfiguration(context.Background(), request)
+		if err != nil || len(response.Plan) != 0 {
+			t.Error("plan: expected none, received", response, err)
+		}
+	})
+}
+
+func Test_ApplyConfigurationChildResources(t *testing.T) {
+	ctx := context.Background()
+	desired := NewServerWithArgs(fake.NewNetlink(), fake.NewFrr(), gomap.NewStore(gomap.DefaultOptions))
+	opi := NewServerWithArgs(fake.NewNetlink(), fake.NewFrr(), gomap.NewStore(gomap.DefaultOptions))
+	for _, server := range []*Server{desired, opi} {
+		if _, err := server.CreateVrf(ctx, &pb.CreateVrfRequest{VrfId: testVrfID, Vrf: protoClone(&testVrf)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := opi.CreateRoute(ctx, &CreateRouteRequest{Parent: testVrfName, RouteID: testRouteID, Route: testRoute.clone()}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := opi.CreatePrefixList(ctx, &CreatePrefixListRequest{PrefixListID: testPrefixListID, PrefixList: testPrefixList.clone()}); err != nil {
+		t.Fatal(err)
+	}
+	list := testPrefixList.clone()
+	list.Spec.Entries = list.Spec.Entries[:1]
+	if _, err := desired.CreatePrefixList(ctx, &CreatePrefixListRequest{PrefixListID: testPrefixListID, PrefixList: list}); err != nil {
+		t.Fatal(err)
+	}
+	exported, err := desired.ExportConfig(ctx, &ExportConfigRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response, err := opi.ApplyConfiguration(ctx, &ApplyConfigurationRequest{Document: exported.Document})
+	if err != nil {
+		t.Fatal("error: expected", nil, "received", err)
+	}
+	plan := []ConfigChange{
+		{Name: testRouteName, Action: ActionDelete},
+		{Name: testPrefixListName, Action: ActionUpdate},
+	}
+	if !reflect.DeepEqual(response.Plan, plan) {
+		t.Error("plan: expected", plan, "received", response.Plan)
+	}
+	if _, ok := opi.Routes[testRouteName]; ok {
+		t.Error("route: expected", testRouteName, "to be deleted")
+	}
+	if got := opi.PrefixLists[testPrefixListName]; checkSameChildSpec(got.Name, got.Spec, list.Spec) != nil {
+		t.Error("prefix-list: expected", list.Spec, "received", got.Spec)
+	}
+}
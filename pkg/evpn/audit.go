@@ -0,0 +1,72 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils"
+)
+
+// ListAuditEventsRequest is the request to list the recorded mutating operations
+// TODO: move to opi-api once the message is agreed upon
+type ListAuditEventsRequest struct {
+	PageSize  int32  `json:"pageSize"`
+	PageToken string `json:"pageToken"`
+}
+
+// ListAuditEventsResponse is the response of listing the recorded mutating operations
+// TODO: move to opi-api once the message is agreed upon
+type ListAuditEventsResponse struct {
+	AuditEvents   []utils.AuditEvent `json:"auditEvents"`
+	NextPageToken string             `json:"nextPageToken"`
+}
+
+// ListAuditEvents lists the most recent Create, Update and Delete calls, oldest first
+func (s *Server) ListAuditEvents(ctx context.Context, in *ListAuditEventsRequest) (*ListAuditEventsResponse, error) {
+	// fetch pagination from the database, calculate size and offset
+	size, offset, perr := s.extractPagination(in.PageSize, in.PageToken)
+	if perr != nil {
+		return nil, perr
+	}
+	// already in chronological order, the following pages are cut from the snapshot of the first one
+	Blobarray, token, err := listPage(ctx, s, "auditEvents", "", in.PageToken, offset, size, s.audit.Events)
+	if err != nil {
+		return nil, err
+	}
+	return &ListAuditEventsResponse{AuditEvents: Blobarray, NextPageToken: token}, nil
+}
+
+// LookupObject returns a copy of the stored Vrf, LogicalBridge, BridgePort or Svi by name,
+// nil when not found, so the audit log can record what a call changed. The audit log calls it
+// outside of the handlers, it takes objectsMu itself
+func (s *Server) LookupObject(name string) proto.Message {
+	s.objectsMu.RLock()
+	defer s.objectsMu.RUnlock()
+	if obj := s.lookupObject(name); obj != nil {
+		return proto.Clone(obj)
+	}
+	return nil
+}
+
+// lookupObject returns the stored Vrf, LogicalBridge, BridgePort or Svi by name, nil when not
+// found, with objectsMu held
+func (s *Server) lookupObject(name string) proto.Message {
+	if obj, ok := s.Vrfs[name]; ok {
+		return obj
+	}
+	if obj, ok := s.Bridges[name]; ok {
+		return obj
+	}
+	if obj, ok := s.Ports[name]; ok {
+		return obj
+	}
+	if obj, ok := s.Svis[name]; ok {
+		return obj
+	}
+	return nil
+}
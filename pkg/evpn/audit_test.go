@@ -0,0 +1,52 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/philippgille/gokv/gomap"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/fake"
+)
+
+func Test_LookupObjectConcurrentCalls(t *testing.T) {
+	ctx := context.Background()
+	opi := NewServerWithArgs(fake.NewNetlink(), fake.NewFrr(), gomap.NewStore(gomap.DefaultOptions))
+
+	// the audit log looks the objects up while they are being created
+	const count = 10
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < count; i++ {
+			opi.LookupObject(resourceIDToFullName("bridges", fmt.Sprintf("blue%d", i)))
+		}
+	}()
+	for i := 0; i < count; i++ {
+		request := &pb.CreateLogicalBridgeRequest{LogicalBridgeId: fmt.Sprintf("blue%d", i), LogicalBridge: &pb.LogicalBridge{Spec: &pb.LogicalBridgeSpec{VlanId: uint32(10 + i)}}}
+		if _, err := opi.CreateLogicalBridge(ctx, request); err != nil {
+			t.Fatal("error: expected", nil, "received", err)
+		}
+	}
+	<-done
+
+	// the audit log keeps what it looked up, the later changes do not alter it
+	name := resourceIDToFullName("bridges", "blue0")
+	obj, ok := opi.LookupObject(name).(*pb.LogicalBridge)
+	if !ok {
+		t.Fatal("object: expected", name, "received", obj)
+	}
+	if obj == opi.Bridges[name] {
+		t.Error("object: expected a copy of", name, "received the stored one")
+	}
+	if missing := opi.LookupObject("unknown"); missing != nil {
+		t.Error("unknown object: expected", nil, "received", missing)
+	}
+}
@@ -0,0 +1,383 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// maxBatchSize bounds the number of requests of a single batch call
+const maxBatchSize = 1000
+
+// BatchResult is the outcome for one object of a batch or resync call, in request order
+type BatchResult struct {
+	Name    string `json:"name"`
+	Code    string `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+// BatchCreateLogicalBridgesRequest creates several LogicalBridges in one call (see https://google.aip.dev/233)
+// TODO: move to opi-api once the message is agreed upon
+type BatchCreateLogicalBridgesRequest struct {
+	Requests []*pb.CreateLogicalBridgeRequest
+}
+
+// BatchCreateLogicalBridgesResponse lists the created LogicalBridges and the result of every request
+// TODO: move to opi-api once the message is agreed upon
+type BatchCreateLogicalBridgesResponse struct {
+	LogicalBridges []*pb.LogicalBridge
+	Results        []BatchResult
+}
+
+// BatchCreateBridgePortsRequest creates several BridgePorts in one call (see https://google.aip.dev/233)
+// TODO: move to opi-api once the message is agreed upon
+type BatchCreateBridgePortsRequest struct {
+	Requests []*pb.CreateBridgePortRequest
+}
+
+// BatchCreateBridgePortsResponse lists the created BridgePorts and the result of every request
+// TODO: move to opi-api once the message is agreed upon
+type BatchCreateBridgePortsResponse struct {
+	BridgePorts []*pb.BridgePort
+	Results     []BatchResult
+}
+
+// BatchDeleteLogicalBridgesRequest deletes several LogicalBridges in one call (see https://google.aip.dev/235)
+// TODO: move to opi-api once the message is agreed upon
+type BatchDeleteLogicalBridgesRequest struct {
+	Requests []*pb.DeleteLogicalBridgeRequest
+}
+
+// BatchDeleteBridgePortsRequest deletes several BridgePorts in one call (see https://google.aip.dev/235)
+// TODO: move to opi-api once the message is agreed upon
+type BatchDeleteBridgePortsRequest struct {
+	Requests []*pb.DeleteBridgePortRequest
+}
+
+// BatchDeleteResponse reports the result of every request of a batch delete
+// TODO: move to opi-api once the message is agreed upon
+type BatchDeleteResponse struct {
+	Results []BatchResult `json:"results"`
+}
+
+func batchResult(name string, err error) BatchResult {
+	if err != nil {
+		st := status.Convert(err)
+		return BatchResult{Name: name, Code: st.Code().String(), Message: st.Message()}
+	}
+	return BatchResult{Name: name, Code: codes.OK.String()}
+}
+
+func validateBatchSize(n int) error {
+	if n == 0 || n > maxBatchSize {
+		msg := fmt.Sprintf("batch size (%d) have to be between 1 and %d", n, maxBatchSize)
+		return status.Error(codes.InvalidArgument, msg)
+	}
+	return nil
+}
+
+// batchError prefixes the error of one request with its index, keeping its code
+func batchError(i int, err error) error {
+	st := status.Convert(err)
+	return status.Errorf(st.Code(), "requests[%d]: %s", i, st.Message())
+}
+
+// validateBatchCreateLogicalBridgesRequest validates the batch as a unit: every request on its own,
+// then the IDs, vlans and vnis against each other and against the stored LogicalBridges
+func (s *Server) validateBatchCreateLogicalBridgesRequest(in *BatchCreateLogicalBridgesRequest) error {
+	if err := validateBatchSize(len(in.Requests)); err != nil {
+		return err
+	}
+	ids := map[string]int{}
+	vlans := map[uint32]int{}
+	vnis := map[uint32]int{}
+	for i, req := range in.Requests {
+		if req == nil {
+			return batchError(i, status.Error(codes.InvalidArgument, "missing request"))
+		}
+		if err := s.validateCreateLogicalBridgeRequest(req); err != nil {
+			return batchError(i, err)
+		}
+		if req.LogicalBridgeId != "" {
+			if j, ok := ids[req.LogicalBridgeId]; ok {
+				msg := fmt.Sprintf("LogicalBridgeId %s already used by requests[%d]", req.LogicalBridgeId, j)
+				return batchError(i, status.Error(codes.InvalidArgument, msg))
+			}
+			ids[req.LogicalBridgeId] = i
+			// retries of already created LogicalBridges are checked by the create itself
+			if _, ok := s.Bridges[resourceIDToFullName("bridges", req.LogicalBridgeId)]; ok {
+				continue
+			}
+		}
+		spec := req.LogicalBridge.Spec
+		if j, ok := vlans[spec.VlanId]; ok {
+			msg := fmt.Sprintf("VlanId %d already used by requests[%d]", spec.VlanId, j)
+			return batchError(i, status.Error(codes.InvalidArgument, msg))
+		}
+		vlans[spec.VlanId] = i
+		if other := s.vlanBridge(spec.VlanId); other != "" {
+			msg := fmt.Sprintf("VlanId %d already used by %s", spec.VlanId, other)
+			return batchError(i, status.Error(codes.AlreadyExists, msg))
+		}
+		if spec.Vni != nil {
+			if j, ok := vnis[*spec.Vni]; ok {
+				msg := fmt.Sprintf("vni %d already used by requests[%d]", *spec.Vni, j)
+				return batchError(i, status.Error(codes.InvalidArgument, msg))
+			}
+			vnis[*spec.Vni] = i
+			if err := s.precheckVniFree("", spec.Vni); err != nil {
+				return batchError(i, err)
+			}
+		}
+	}
+	return nil
+}
+
+// validateBatchCreateBridgePortsRequest validates the batch as a unit: every request on its own,
+// then the IDs against each other and the referenced LogicalBridges
+func (s *Server) validateBatchCreateBridgePortsRequest(in *BatchCreateBridgePortsRequest) error {
+	if err := validateBatchSize(len(in.Requests)); err != nil {
+		return err
+	}
+	ids := map[string]int{}
+	for i, req := range in.Requests {
+		if req == nil {
+			return batchError(i, status.Error(codes.InvalidArgument, "missing request"))
+		}
+		if err := s.validateCreateBridgePortRequest(req); err != nil {
+			return batchError(i, err)
+		}
+		if req.BridgePortId != "" {
+			if j, ok := ids[req.BridgePortId]; ok {
+				msg := fmt.Sprintf("BridgePortId %s already used by requests[%d]", req.BridgePortId, j)
+				return batchError(i, status.Error(codes.InvalidArgument, msg))
+			}
+			ids[req.BridgePortId] = i
+		}
+		for _, bridgeRefName := range req.BridgePort.Spec.LogicalBridges {
+			if _, ok := s.Bridges[bridgeRefName]; !ok {
+				err := status.Errorf(codes.NotFound, "unable to find key %s", bridgeRefName)
+				return batchError(i, err)
+			}
+		}
+	}
+	return nil
+}
+
+// prepareBatch lets the dataplane share work, e.g. device lookups, between the requests of a batch
+func (s *Server) prepareBatch(ctx context.Context) (context.Context, error) {
+	p, ok := s.dataplane.(batchDataplane)
+	if !ok {
+		return ctx, nil
+	}
+	return p.PrepareBatch(ctx)
+}
+
+// BatchCreateLogicalBridges validates all the requests before creating any LogicalBridge,
+// then creates them in order, a failed create does not stop the following ones
+func (s *Server) BatchCreateLogicalBridges(ctx context.Context, in *BatchCreateLogicalBridgesRequest) (*BatchCreateLogicalBridgesResponse, error) {
+	s.objectsMu.Lock()
+	defer s.objectsMu.Unlock()
+	// check input correctness
+	if err := s.validateBatchCreateLogicalBridgesRequest(in); err != nil {
+		return nil, err
+	}
+	for _, req := range in.Requests {
+		if req.LogicalBridge.Spec.Vni != nil {
+			batchCtx, err := s.prepareBatch(ctx)
+			if err != nil {
+				return nil, err
+			}
+			ctx = batchCtx
+			break
+		}
+	}
+	response := &BatchCreateLogicalBridgesResponse{}
+	for _, req := range in.Requests {
+		obj, err := s.createLogicalBridge(ctx, req)
+		if err != nil {
+			fmt.Printf("Failed to create %s in batch: %v", req.LogicalBridge.Name, err)
+		} else {
+			response.LogicalBridges = append(response.LogicalBridges, obj)
+		}
+		response.Results = append(response.Results, batchResult(req.LogicalBridge.Name, err))
+	}
+	return response, nil
+}
+
+// BatchCreateBridgePorts validates all the requests before creating any BridgePort,
+// then creates them in order, a failed create does not stop the following ones
+func (s *Server) BatchCreateBridgePorts(ctx context.Context, in *BatchCreateBridgePortsRequest) (*BatchCreateBridgePortsResponse, error) {
+	s.objectsMu.Lock()
+	defer s.objectsMu.Unlock()
+	// check input correctness
+	if err := s.validateBatchCreateBridgePortsRequest(in); err != nil {
+		return nil, err
+	}
+	ctx, err := s.prepareBatch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	response := &BatchCreateBridgePortsResponse{}
+	for _, req := range in.Requests {
+		obj, err := s.createBridgePort(ctx, req)
+		if err != nil {
+			fmt.Printf("Failed to create %s in batch: %v", req.BridgePort.Name, err)
+		} else {
+			response.BridgePorts = append(response.BridgePorts, obj)
+		}
+		response.Results = append(response.Results, batchResult(req.BridgePort.Name, err))
+	}
+	return response, nil
+}
+
+// BatchDeleteLogicalBridges validates all the requests before deleting any LogicalBridge,
+// then deletes them in order, a failed delete does not stop the following ones
+func (s *Server) BatchDeleteLogicalBridges(ctx context.Context, in *BatchDeleteLogicalBridgesRequest) (*BatchDeleteResponse, error) {
+	// check input correctness
+	if err := validateBatchSize(len(in.Requests)); err != nil {
+		return nil, err
+	}
+	for i, req := range in.Requests {
+		if req == nil {
+			return nil, batchError(i, status.Error(codes.InvalidArgument, "missing request"))
+		}
+		if err := s.validateDeleteLogicalBridgeRequest(req); err != nil {
+			return nil, batchError(i, err)
+		}
+	}
+	response := &BatchDeleteResponse{}
+	for _, req := range in.Requests {
+		_, err := s.DeleteLogicalBridge(ctx, req)
+		response.Results = append(response.Results, batchResult(req.Name, err))
+	}
+	return response, nil
+}
+
+// BatchDeleteBridgePorts validates all the requests before deleting any BridgePort,
+// then deletes them in order, a failed delete does not stop the following ones
+func (s *Server) BatchDeleteBridgePorts(ctx context.Context, in *BatchDeleteBridgePortsRequest) (*BatchDeleteResponse, error) {
+	// check input correctness
+	if err := validateBatchSize(len(in.Requests)); err != nil {
+		return nil, err
+	}
+	for i, req := range in.Requests {
+		if req == nil {
+			return nil, batchError(i, status.Error(codes.InvalidArgument, "missing request"))
+		}
+		if err := s.validateDeleteBridgePortRequest(req); err != nil {
+			return nil, batchError(i, err)
+		}
+	}
+	response := &BatchDeleteResponse{}
+	for _, req := range in.Requests {
+		_, err := s.DeleteBridgePort(ctx, req)
+		response.Results = append(response.Results, batchResult(req.Name, err))
+	}
+	return response, nil
+}
+
+// the batch messages embed api messages, which only round trip through protojson
+
+// unmarshalBatch decodes {"requests": [...]} with every request created by newRequest
+func unmarshalBatch(data []byte, newRequest func() proto.Message) error {
+	var raw struct {
+		Requests []json.RawMessage `json:"requests"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	for i, item := range raw.Requests {
+		if err := protojson.Unmarshal(item, newRequest()); err != nil {
+			return fmt.Errorf("requests[%d]: %w", i, err)
+		}
+	}
+	return nil
+}
+
+func marshalObjectList[T proto.Message](objects []T) ([]json.RawMessage, error) {
+	raw := make([]json.RawMessage, 0, len(objects))
+	for _, obj := range objects {
+		b, err := protojson.Marshal(obj)
+		if err != nil {
+			return nil, err
+		}
+		raw = append(raw, b)
+	}
+	return raw, nil
+}
+
+// UnmarshalJSON decodes the requests with protojson
+func (in *BatchCreateLogicalBridgesRequest) UnmarshalJSON(data []byte) error {
+	in.Requests = nil
+	return unmarshalBatch(data, func() proto.Message {
+		req := &pb.CreateLogicalBridgeRequest{}
+		in.Requests = append(in.Requests, req)
+		return req
+	})
+}
+
+// UnmarshalJSON decodes the requests with protojson
+func (in *BatchCreateBridgePortsRequest) UnmarshalJSON(data []byte) error {
+	in.Requests = nil
+	return unmarshalBatch(data, func() proto.Message {
+		req := &pb.CreateBridgePortRequest{}
+		in.Requests = append(in.Requests, req)
+		return req
+	})
+}
+
+// UnmarshalJSON decodes the requests with protojson
+func (in *BatchDeleteLogicalBridgesRequest) UnmarshalJSON(data []byte) error {
+	in.Requests = nil
+	return unmarshalBatch(data, func() proto.Message {
+		req := &pb.DeleteLogicalBridgeRequest{}
+		in.Requests = append(in.Requests, req)
+		return req
+	})
+}
+
+// UnmarshalJSON decodes the requests with protojson
+func (in *BatchDeleteBridgePortsRequest) UnmarshalJSON(data []byte) error {
+	in.Requests = nil
+	return unmarshalBatch(data, func() proto.Message {
+		req := &pb.DeleteBridgePortRequest{}
+		in.Requests = append(in.Requests, req)
+		return req
+	})
+}
+
+// MarshalJSON encodes the LogicalBridges with protojson
+func (out *BatchCreateLogicalBridgesResponse) MarshalJSON() ([]byte, error) {
+	objects, err := marshalObjectList(out.LogicalBridges)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(struct {
+		LogicalBridges []json.RawMessage `json:"logicalBridges"`
+		Results        []BatchResult     `json:"results"`
+	}{objects, out.Results})
+}
+
+// MarshalJSON encodes the BridgePorts with protojson
+func (out *BatchCreateBridgePortsResponse) MarshalJSON() ([]byte, error) {
+	objects, err := marshalObjectList(out.BridgePorts)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(struct {
+		BridgePorts []json.RawMessage `json:"bridgePorts"`
+		Results     []BatchResult     `json:"results"`
+	}{objects, out.Results})
+}
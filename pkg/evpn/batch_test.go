@@ -0,0 +1,131 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/philippgille/gokv/gomap"
+	"github.com/stretchr/testify/mock"
+	"github.com/vishvananda/netlink"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils/mocks"
+)
+
+func testBatchBridge(id string, vlanID uint32) *pb.CreateLogicalBridgeRequest {
+	spec := protoClone(testLogicalBridge.Spec)
+	spec.VlanId = vlanID
+	spec.Vni = proto.Uint32(vlanID)
+	return &pb.CreateLogicalBridgeRequest{LogicalBridgeId: id, LogicalBridge: &pb.LogicalBridge{Spec: spec}}
+}
+
+func Test_BatchCreateLogicalBridges(t *testing.T) {
+	tests := map[string]struct {
+		in          []*pb.CreateLogicalBridgeRequest
+		errCode     codes.Code
+		wantResults []codes.Code
+		on          func(mockNetlink *mocks.Netlink)
+	}{
+		"empty batch": {
+			in:      nil,
+			errCode: codes.InvalidArgument,
+			on:      nil,
+		},
+		"vlan used twice in the batch": {
+			in:      []*pb.CreateLogicalBridgeRequest{testBatchBridge("bridge1", 10), testBatchBridge("bridge2", 10)},
+			errCode: codes.InvalidArgument,
+			on:      nil,
+		},
+		"id used twice in the batch": {
+			in:      []*pb.CreateLogicalBridgeRequest{testBatchBridge("bridge1", 10), testBatchBridge("bridge1", 20)},
+			errCode: codes.InvalidArgument,
+			on:      nil,
+		},
+		"failed tenant bridge lookup": {
+			in:      []*pb.CreateLogicalBridgeRequest{testBatchBridge("bridge1", 10)},
+			errCode: codes.NotFound,
+			on: func(mockNetlink *mocks.Netlink) {
+				mockNetlink.EXPECT().LinkByName(mock.Anything, tenantbridgeName).Return(nil, errors.New("Link not found")).Once()
+			},
+		},
+		"per item results": {
+			in:          []*pb.CreateLogicalBridgeRequest{testBatchBridge("bridge1", 10), testBatchBridge("bridge2", 20), testBatchBridge("bridge3", 30)},
+			errCode:     codes.OK,
+			wantResults: []codes.Code{codes.OK, codes.Unknown, codes.OK},
+			on: func(mockNetlink *mocks.Netlink) {
+				bridge := &netlink.Bridge{LinkAttrs: netlink.LinkAttrs{Name: tenantbridgeName}}
+				// looked up once for the whole batch
+				mockNetlink.EXPECT().LinkByName(mock.Anything, tenantbridgeName).Return(bridge, nil).Once()
+				mockNetlink.EXPECT().LinkAdd(mock.Anything, mock.Anything).RunAndReturn(func(_ context.Context, link netlink.Link) error {
+					if link.Attrs().Name == "vni20" {
+						return errors.New("Failed to call LinkAdd")
+					}
+					return nil
+				}).Times(3)
+				mockNetlink.EXPECT().LinkSetMaster(mock.Anything, mock.Anything, bridge).Return(nil).Twice()
+				mockNetlink.EXPECT().LinkSetUp(mock.Anything, mock.Anything).Return(nil).Twice()
+				mockNetlink.EXPECT().BridgeVlanAdd(mock.Anything, mock.Anything, mock.Anything, true, true, false, false).Return(nil).Twice()
+			},
+		},
+	}
+
+	for testName, tt := range tests {
+		t.Run(testName, func(t *testing.T) {
+			ctx := context.Background()
+			mockNetlink := mocks.NewNetlink(t)
+			mockFrr := mocks.NewFrr(t)
+			opi := NewServerWithArgs(mockNetlink, mockFrr, gomap.NewStore(gomap.DefaultOptions))
+			if tt.on != nil {
+				tt.on(mockNetlink)
+			}
+
+			response, err := opi.BatchCreateLogicalBridges(ctx, &BatchCreateLogicalBridgesRequest{Requests: tt.in})
+			if status.Code(err) != tt.errCode {
+				t.Fatal("error code: expected", tt.errCode, "received", status.Code(err), err)
+			}
+			if err != nil {
+				if len(opi.Bridges) != 0 {
+					t.Error("expected nothing created on a rejected batch, received", opi.Bridges)
+				}
+				return
+			}
+			if len(response.Results) != len(tt.wantResults) {
+				t.Fatal("results: expected", tt.wantResults, "received", response.Results)
+			}
+			for i, want := range tt.wantResults {
+				if response.Results[i].Code != want.String() {
+					t.Error("result", i, ": expected", want, "received", response.Results[i])
+				}
+			}
+			if len(response.LogicalBridges) != len(opi.Bridges) {
+				t.Error("bridges: expected", len(opi.Bridges), "received", response.LogicalBridges)
+			}
+		})
+	}
+}
+
+func Test_BatchCreateBridgePortsRequestJSON(t *testing.T) {
+	document := `{"requests": [{"bridgePortId": "eth1", "bridgePort": {"spec": {"ptype": "ACCESS", "logicalBridges": ["` + testLogicalBridgeName + `"]}}}]}`
+	in := &BatchCreateBridgePortsRequest{}
+	if err := json.Unmarshal([]byte(document), in); err != nil {
+		t.Fatal("unmarshal: expected", nil, "received", err)
+	}
+	if len(in.Requests) != 1 || in.Requests[0].BridgePortId != "eth1" || in.Requests[0].BridgePort.Spec.Ptype != pb.BridgePortType_ACCESS {
+		t.Error("requests: expected the eth1 ACCESS port, received", in.Requests)
+	}
+	out := &BatchCreateBridgePortsResponse{BridgePorts: []*pb.BridgePort{&testBridgePortWithStatus}, Results: []BatchResult{batchResult(testBridgePortName, nil)}}
+	if _, err := json.Marshal(out); err != nil {
+		t.Error("marshal: expected", nil, "received", err)
+	}
+}
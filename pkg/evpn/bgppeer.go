@@ -0,0 +1,317 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"log"
+	"net"
+	"sort"
+	"strconv"
+
+	pc "github.com/opiproject/opi-api/network/opinetcommon/v1alpha1/gen/go"
+
+	"go.einride.tech/aip/resourceid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils"
+)
+
+// BgpAddressFamily is an address family activated on a BGP session, in FRR syntax
+type BgpAddressFamily string
+
+const (
+	// BgpIPv4Unicast carries the underlay routes, e.g.: the VTEP loopbacks
+	BgpIPv4Unicast BgpAddressFamily = "ipv4 unicast"
+	// BgpL2vpnEvpn carries the EVPN overlay routes
+	BgpL2vpnEvpn BgpAddressFamily = "l2vpn evpn"
+	// BgpIPv4Vpn carries the IPv4 L3VPN routes of the SRv6 Vrfs
+	BgpIPv4Vpn BgpAddressFamily = "ipv4 vpn"
+	// BgpIPv6Vpn carries the IPv6 L3VPN routes of the SRv6 Vrfs
+	BgpIPv6Vpn BgpAddressFamily = "ipv6 vpn"
+)
+
+// BgpPeerType is the relation with a peer whose AS number is not pinned, in FRR syntax
+type BgpPeerType string
+
+const (
+	// BgpExternal accepts any AS number but the local one, e.g.: the spines of a fabric
+	// numbering every leaf with its own AS
+	BgpExternal BgpPeerType = "external"
+	// BgpInternal peers in the local AS
+	BgpInternal BgpPeerType = "internal"
+)
+
+// bgpAddressFamilies are the address families a BgpPeer can activate
+var bgpAddressFamilies = []BgpAddressFamily{BgpIPv4Unicast, BgpL2vpnEvpn, BgpIPv4Vpn, BgpIPv6Vpn}
+
+// BgpPeer is a BGP session of the default instance towards an uplink router or a route
+// reflector, carrying the underlay and overlay routes, instead of hand-editing frr.conf
+// TODO: move to opi-api once the message is agreed upon
+type BgpPeer struct {
+	Name   string
+	Spec   *BgpPeerSpec
+	Status *BgpPeerStatus
+}
+
+// BgpPeerSpec is the desired configuration of a BgpPeer
+type BgpPeerSpec struct {
+	// PeerIPAddress is the address of the peer of a numbered session
+	PeerIPAddress *pc.IPAddress
+	// Interface is the kernel name of the uplink of an unnumbered session, instead of PeerIPAddress (e.g.: eth0)
+	Interface string
+	// RemoteAs is the AS number of the peer
+	RemoteAs uint32
+	// PeerType accepts the AS number of the peer as external or internal, instead of RemoteAs
+	PeerType BgpPeerType
+	// AddressFamilies are activated on the session, IPv4 unicast and L2VPN EVPN when empty
+	AddressFamilies []BgpAddressFamily
+	// KeepaliveTime is the BGP keepalive interval in seconds, the FRR default when 0
+	KeepaliveTime uint32
+	// HoldTime is the BGP hold time in seconds, set together with KeepaliveTime
+	HoldTime uint32
+	// ImportRouteMap is the name of the RouteMap filtering the routes received from the peer, in every address family
+	ImportRouteMap string
+	// ExportRouteMap is the name of the RouteMap filtering the routes advertised to the peer, in every address family
+	ExportRouteMap string
+}
+
+// BgpPeerStatus is the observed state of a BgpPeer
+type BgpPeerStatus struct {
+	// State is the BGP state of the session reported by FRR (e.g.: Established), empty when unknown
+	State string
+}
+
+// CreateBgpPeerRequest is the request to create a BgpPeer
+type CreateBgpPeerRequest struct {
+	BgpPeerID string
+	BgpPeer   *BgpPeer
+}
+
+// DeleteBgpPeerRequest is the request to delete a BgpPeer
+type DeleteBgpPeerRequest struct {
+	Name         string
+	AllowMissing bool
+}
+
+// GetBgpPeerRequest is the request to get a BgpPeer
+type GetBgpPeerRequest struct {
+	Name string
+}
+
+// ListBgpPeersRequest is the request to list BgpPeers
+type ListBgpPeersRequest struct {
+	PageSize  int32
+	PageToken string
+}
+
+// ListBgpPeersResponse is the response of listing BgpPeers
+type ListBgpPeersResponse struct {
+	BgpPeers      []*BgpPeer
+	NextPageToken string
+}
+
+func (p *BgpPeer) clone() *BgpPeer {
+	if p == nil {
+		return nil
+	}
+	c := &BgpPeer{Name: p.Name}
+	if p.Spec != nil {
+		spec := *p.Spec
+		if p.Spec.PeerIPAddress != nil {
+			spec.PeerIPAddress = protoClone(p.Spec.PeerIPAddress)
+		}
+		spec.AddressFamilies = append([]BgpAddressFamily(nil), p.Spec.AddressFamilies...)
+		c.Spec = &spec
+	}
+	if p.Status != nil {
+		st := *p.Status
+		c.Status = &st
+	}
+	return c
+}
+
+func sortBgpPeers(peers []*BgpPeer) {
+	sort.Slice(peers, func(i int, j int) bool {
+		return peers[i].Name < peers[j].Name
+	})
+}
+
+// BgpPeerNeighbor returns the FRR neighbor of the session, the peer address or the interface
+// of an unnumbered session
+func BgpPeerNeighbor(spec *BgpPeerSpec) string {
+	if spec.Interface != "" {
+		return spec.Interface
+	}
+	peer := make(net.IP, 4)
+	binary.BigEndian.PutUint32(peer, spec.PeerIPAddress.GetV4Addr())
+	return peer.String()
+}
+
+// bgpPeerRemoteAs returns the remote-as of the session in FRR syntax, its AS number or peer type
+func bgpPeerRemoteAs(spec *BgpPeerSpec) string {
+	if spec.PeerType != "" {
+		return string(spec.PeerType)
+	}
+	return strconv.FormatUint(uint64(spec.RemoteAs), 10)
+}
+
+// BgpPeerAddressFamilies returns the address families activated on the session
+func BgpPeerAddressFamilies(spec *BgpPeerSpec) []BgpAddressFamily {
+	if len(spec.AddressFamilies) == 0 {
+		return []BgpAddressFamily{BgpIPv4Unicast, BgpL2vpnEvpn}
+	}
+	return spec.AddressFamilies
+}
+
+// checkNoTenant rejects the tenants, the underlay sessions are shared by all of them
+func checkNoTenant(ctx context.Context) error {
+	if tenant := utils.TenantFromContext(ctx); tenant != "" {
+		return status.Errorf(codes.PermissionDenied, "tenant %s cannot manage the BGP sessions of the underlay", tenant)
+	}
+	return nil
+}
+
+// CreateBgpPeer executes the creation of the BGP session
+func (s *Server) CreateBgpPeer(ctx context.Context, in *CreateBgpPeerRequest) (*BgpPeer, error) {
+	// check input correctness
+	if err := s.validateCreateBgpPeerRequest(in); err != nil {
+		return nil, err
+	}
+	if err := checkNoTenant(ctx); err != nil {
+		return nil, err
+	}
+	// see https://google.aip.dev/133#user-specified-ids
+	resourceID := resourceid.NewSystemGenerated()
+	if in.BgpPeerID != "" {
+		log.Printf("client provided the ID of a resource %v, ignoring the name field %v", in.BgpPeerID, in.BgpPeer.Name)
+		resourceID = in.BgpPeerID
+	}
+	in.BgpPeer.Name = resourceIDToFullName("bgppeers", resourceID)
+	s.objectsMu.Lock()
+	defer s.objectsMu.Unlock()
+	// idempotent API when called with same key, should return same object
+	obj, ok := s.BgpPeers[in.BgpPeer.Name]
+	if ok {
+		// a different spec under the same key is a conflict, not a retry
+		if err := checkSameChildSpec(obj.Name, obj.Spec, in.BgpPeer.Spec); err != nil {
+			return nil, err
+		}
+		log.Printf("Already existing BgpPeer with id %v", in.BgpPeer.Name)
+		return obj.clone(), nil
+	}
+	// FRR knows a single session per neighbor
+	neighbor := BgpPeerNeighbor(in.BgpPeer.Spec)
+	for _, peer := range s.BgpPeers {
+		if BgpPeerNeighbor(peer.Spec) == neighbor {
+			err := status.Errorf(codes.AlreadyExists, "BGP session with %s already exists as %s", neighbor, peer.Name)
+			return nil, err
+		}
+	}
+	if err := s.checkRouteMaps(in.BgpPeer.Spec.ImportRouteMap, in.BgpPeer.Spec.ExportRouteMap); err != nil {
+		return nil, err
+	}
+	if err := s.dataplane.CreateBgpPeer(ctx, in.BgpPeer); err != nil {
+		s.forgetStatus(in.BgpPeer.Name)
+		return nil, status.Convert(err).Err()
+	}
+	// save object to the database
+	response := in.BgpPeer.clone()
+	response.Status = &BgpPeerStatus{}
+	s.BgpPeers[in.BgpPeer.Name] = response
+	persistObjects(s, "bgppeers", s.BgpPeers)
+	return response.clone(), nil
+}
+
+// DeleteBgpPeer deletes a BGP session
+func (s *Server) DeleteBgpPeer(ctx context.Context, in *DeleteBgpPeerRequest) (*emptypb.Empty, error) {
+	// check input correctness
+	if err := s.validateDeleteBgpPeerRequest(in); err != nil {
+		return nil, err
+	}
+	if err := checkNoTenant(ctx); err != nil {
+		return nil, err
+	}
+	s.objectsMu.Lock()
+	defer s.objectsMu.Unlock()
+	return s.deleteBgpPeer(ctx, in)
+}
+
+// deleteBgpPeer deletes a validated BGP session, with objectsMu held
+func (s *Server) deleteBgpPeer(ctx context.Context, in *DeleteBgpPeerRequest) (*emptypb.Empty, error) {
+	// fetch object from the database
+	obj, ok := s.BgpPeers[in.Name]
+	if !ok {
+		if in.AllowMissing {
+			return &emptypb.Empty{}, nil
+		}
+		err := status.Errorf(codes.NotFound, "unable to find key %s", in.Name)
+		return nil, err
+	}
+	if err := s.dataplane.DeleteBgpPeer(ctx, obj); err != nil {
+		return nil, status.Convert(err).Err()
+	}
+	// remove from the Database
+	delete(s.BgpPeers, obj.Name)
+	persistObjects(s, "bgppeers", s.BgpPeers)
+	s.forgetStatus(obj.Name)
+	return &emptypb.Empty{}, nil
+}
+
+// GetBgpPeer gets a BGP session, with its state reported by FRR
+func (s *Server) GetBgpPeer(ctx context.Context, in *GetBgpPeerRequest) (*BgpPeer, error) {
+	// check input correctness
+	if err := s.validateGetBgpPeerRequest(in); err != nil {
+		return nil, err
+	}
+	// fetch object from the database, FRR is asked for the state without objectsMu held
+	s.objectsMu.RLock()
+	obj, ok := s.BgpPeers[in.Name]
+	if !ok {
+		s.objectsMu.RUnlock()
+		err := status.Errorf(codes.NotFound, "unable to find key %s", in.Name)
+		return nil, err
+	}
+	response := obj.clone()
+	s.objectsMu.RUnlock()
+	response.Status = &BgpPeerStatus{}
+	// the state is only informational, the session is configured even when FRR cannot tell it
+	states, err := s.BgpPeerStates(ctx)
+	if err != nil {
+		fmt.Printf("Failed to get BGP peers: %v", err)
+		return response, nil
+	}
+	response.Status.State = states["default|"+BgpPeerNeighbor(response.Spec)]
+	return response, nil
+}
+
+// ListBgpPeers lists BGP sessions
+func (s *Server) ListBgpPeers(ctx context.Context, in *ListBgpPeersRequest) (*ListBgpPeersResponse, error) {
+	// fetch pagination from the database, calculate size and offset
+	size, offset, perr := s.extractPagination(in.PageSize, in.PageToken)
+	if perr != nil {
+		return nil, perr
+	}
+	s.objectsMu.RLock()
+	defer s.objectsMu.RUnlock()
+	// fetch object from the database, the following pages are cut from the snapshot of the first one
+	Blobarray, token, err := listPage(ctx, s, "bgpPeers", "", in.PageToken, offset, size, func() []*BgpPeer {
+		Blobarray := []*BgpPeer{}
+		for _, peer := range s.BgpPeers {
+			Blobarray = append(Blobarray, peer.clone())
+		}
+		// sort is needed, since MAP is unsorted in golang, and we might get different results
+		sortBgpPeers(Blobarray)
+		return Blobarray
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &ListBgpPeersResponse{BgpPeers: Blobarray, NextPageToken: token}, nil
+}
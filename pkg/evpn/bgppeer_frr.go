@@ -0,0 +1,60 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+func (s *Server) frrCreateBgpPeerRequest(ctx context.Context, obj *BgpPeer) error {
+	neighbor := BgpPeerNeighbor(obj.Spec)
+	// Example: neighbor eth0 interface remote-as external for an unnumbered session, its IPv4
+	// routes have the IPv6 link-local next-hops of the peer (RFC 5549), FRR negotiating the
+	// extended next-hop capability and installing them through the link-local address
+	session := fmt.Sprintf("neighbor %s remote-as %s\n", neighbor, bgpPeerRemoteAs(obj.Spec))
+	if obj.Spec.Interface != "" {
+		session = fmt.Sprintf("neighbor %s interface remote-as %s\n", neighbor, bgpPeerRemoteAs(obj.Spec))
+	}
+	if obj.Spec.HoldTime != 0 {
+		session += fmt.Sprintf("neighbor %s timers %d %d\n", neighbor, obj.Spec.KeepaliveTime, obj.Spec.HoldTime)
+	}
+	if s.Gateway.RouterID != "" {
+		session = fmt.Sprintf("bgp router-id %s\n", s.Gateway.RouterID) + session
+	}
+	var families strings.Builder
+	for _, family := range BgpPeerAddressFamilies(obj.Spec) {
+		// the IPv6 VPN routes of the SRv6 Vrfs have IPv6 next-hops, unnumbered sessions already negotiate it
+		if family == BgpIPv6Vpn && obj.Spec.Interface == "" {
+			session += fmt.Sprintf("neighbor %s capability extended-nexthop\n", neighbor)
+		}
+		fmt.Fprintf(&families, "address-family %s\nneighbor %s activate\n", family, neighbor)
+		families.WriteString(frrNeighborRouteMaps(neighbor, obj.Spec.ImportRouteMap, obj.Spec.ExportRouteMap))
+		families.WriteString("exit-address-family\n")
+	}
+	data, err := s.frr.FrrBgpCmd(ctx, fmt.Sprintf(
+		`configure terminal
+		router bgp %d
+		%s%sexit`, s.Gateway.LocalAs, session, families.String()))
+	fmt.Printf("FrrBgpCmd: %v:%v", data, err)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (s *Server) frrDeleteBgpPeerRequest(ctx context.Context, obj *BgpPeer) error {
+	data, err := s.frr.FrrBgpCmd(ctx, fmt.Sprintf(
+		`configure terminal
+		router bgp %d
+		no neighbor %s
+		exit`, s.Gateway.LocalAs, BgpPeerNeighbor(obj.Spec)))
+	fmt.Printf("FrrBgpCmd: %v:%v", data, err)
+	if err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"github.com/vishvananda/netlink"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// netlinkCreateBgpPeer brings up the uplink of an unnumbered session, whose peer is found and
+// reached through the IPv6 link-local addresses, the numbered sessions need nothing
+func (s *Server) netlinkCreateBgpPeer(ctx context.Context, obj *BgpPeer) error {
+	if obj.Spec.Interface == "" {
+		return nil
+	}
+	link, err := s.nLink.LinkByName(ctx, obj.Spec.Interface)
+	if err != nil {
+		err := status.Errorf(codes.NotFound, "unable to find key %s", obj.Spec.Interface)
+		return err
+	}
+	up := link.Attrs().Flags&net.FlagUp != 0
+	// Example: ip link set eth0 up
+	if err := s.nLink.LinkSetUp(ctx, link); err != nil {
+		fmt.Printf("Failed to up link: %v", err)
+		return err
+	}
+	// the link-local address comes with the link, an up link without one has IPv6 disabled
+	if !up {
+		return nil
+	}
+	addrs, err := s.nLink.AddrList(ctx, link, netlink.FAMILY_V6)
+	if err != nil {
+		fmt.Printf("Failed to list addresses: %v", err)
+		return err
+	}
+	for _, addr := range addrs {
+		if addr.IP.IsLinkLocalUnicast() {
+			return nil
+		}
+	}
+	err = status.Errorf(codes.FailedPrecondition, "interface %s has no IPv6 link-local address for the unnumbered session, see net.ipv6.conf.%s.disable_ipv6", obj.Spec.Interface, obj.Spec.Interface)
+	return err
+}
@@ -0,0 +1,366 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/philippgille/gokv/gomap"
+	"github.com/stretchr/testify/mock"
+	"github.com/vishvananda/netlink"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	pc "github.com/opiproject/opi-api/network/opinetcommon/v1alpha1/gen/go"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils/mocks"
+)
+
+var (
+	testBgpPeerID       = "opi-peer8"
+	testBgpPeerName     = resourceIDToFullName("bgppeers", testBgpPeerID)
+	testBgpPeerAddress  = &pc.IPAddress{Af: pc.IpAf_IP_AF_INET, V4OrV6: &pc.IPAddress_V4Addr{V4Addr: 167772162}}
+	testBgpPeer         = BgpPeer{Spec: &BgpPeerSpec{PeerIPAddress: testBgpPeerAddress, RemoteAs: 65001}}
+	testBgpPeerWithName = BgpPeer{Name: testBgpPeerName, Spec: testBgpPeer.Spec, Status: &BgpPeerStatus{}}
+)
+
+func Test_CreateBgpPeer(t *testing.T) {
+	tests := map[string]struct {
+		id      string
+		in      *BgpPeer
+		out     *BgpPeer
+		errCode codes.Code
+		errMsg  string
+		exist   bool
+		on      func(mockNetlink *mocks.Netlink, mockFrr *mocks.Frr, errMsg string)
+	}{
+		"illegal resource_id": {
+			id:      "CapitalLettersNotAllowed",
+			in:      &testBgpPeer,
+			out:     nil,
+			errCode: codes.InvalidArgument,
+			errMsg:  fmt.Sprintf("user-settable ID must only contain lowercase, numbers and hyphens (%v)", "got: 'C' in position 0"),
+			exist:   false,
+			on:      nil,
+		},
+		"no required remote_as field": {
+			id:      testBgpPeerID,
+			in:      &BgpPeer{Spec: &BgpPeerSpec{PeerIPAddress: testBgpPeerAddress}},
+			out:     nil,
+			errCode: codes.InvalidArgument,
+			errMsg:  "missing required field: bgp_peer.spec.remote_as",
+			exist:   false,
+			on:      nil,
+		},
+		"both peer address and interface": {
+			id:      testBgpPeerID,
+			in:      &BgpPeer{Spec: &BgpPeerSpec{PeerIPAddress: testBgpPeerAddress, Interface: "eth0", RemoteAs: 65001}},
+			out:     nil,
+			errCode: codes.InvalidArgument,
+			errMsg:  "only one of peer_ip_address and interface can be set",
+			exist:   false,
+			on:      nil,
+		},
+		"invalid address family": {
+			id:      testBgpPeerID,
+			in:      &BgpPeer{Spec: &BgpPeerSpec{Interface: "eth0", RemoteAs: 65001, AddressFamilies: []BgpAddressFamily{"ipv6 multicast"}}},
+			out:     nil,
+			errCode: codes.InvalidArgument,
+			errMsg:  "Address family (ipv6 multicast) have to be ipv4 unicast, l2vpn evpn, ipv4 vpn or ipv6 vpn",
+			exist:   false,
+			on:      nil,
+		},
+		"keepalive not below hold time": {
+			id:      testBgpPeerID,
+			in:      &BgpPeer{Spec: &BgpPeerSpec{Interface: "eth0", RemoteAs: 65001, KeepaliveTime: 9, HoldTime: 9}},
+			out:     nil,
+			errCode: codes.InvalidArgument,
+			errMsg:  "Keepalive time (9) have to be between 1 and the hold time (9)",
+			exist:   false,
+			on:      nil,
+		},
+		"both remote_as and peer_type": {
+			id:      testBgpPeerID,
+			in:      &BgpPeer{Spec: &BgpPeerSpec{Interface: "eth0", RemoteAs: 65001, PeerType: BgpExternal}},
+			out:     nil,
+			errCode: codes.InvalidArgument,
+			errMsg:  "only one of remote_as and peer_type can be set",
+			exist:   false,
+			on:      nil,
+		},
+		"invalid peer_type": {
+			id:      testBgpPeerID,
+			in:      &BgpPeer{Spec: &BgpPeerSpec{Interface: "eth0", PeerType: "confederation"}},
+			out:     nil,
+			errCode: codes.InvalidArgument,
+			errMsg:  "Peer type (confederation) have to be external or internal",
+			exist:   false,
+			on:      nil,
+		},
+		"already exists": {
+			id:      testBgpPeerID,
+			in:      &testBgpPeer,
+			out:     &testBgpPeerWithName,
+			errCode: codes.OK,
+			errMsg:  "",
+			exist:   true,
+			on:      nil,
+		},
+		"already exists with a different spec": {
+			id:      testBgpPeerID,
+			in:      &BgpPeer{Spec: &BgpPeerSpec{PeerIPAddress: testBgpPeerAddress, RemoteAs: 65002}},
+			out:     nil,
+			errCode: codes.AlreadyExists,
+			errMsg:  fmt.Sprintf("%s already exists with a different spec", testBgpPeerName),
+			exist:   true,
+			on:      nil,
+		},
+		"same neighbor": {
+			id:      "opi-peer9",
+			in:      &testBgpPeer,
+			out:     nil,
+			errCode: codes.AlreadyExists,
+			errMsg:  fmt.Sprintf("BGP session with 10.0.0.2 already exists as %v", testBgpPeerName),
+			exist:   true,
+			on:      nil,
+		},
+		"failed FrrBgpCmd call": {
+			id:      testBgpPeerID,
+			in:      &testBgpPeer,
+			out:     nil,
+			errCode: codes.Unknown,
+			errMsg:  "Failed to call FrrBgpCmd",
+			exist:   false,
+			on: func(mockNetlink *mocks.Netlink, mockFrr *mocks.Frr, errMsg string) {
+				mockFrr.EXPECT().FrrBgpCmd(mock.Anything, mock.Anything).Return("", errors.New(errMsg)).Once()
+			},
+		},
+		"successful call": {
+			id:      testBgpPeerID,
+			in:      &testBgpPeer,
+			out:     &testBgpPeerWithName,
+			errCode: codes.OK,
+			errMsg:  "",
+			exist:   false,
+			on: func(mockNetlink *mocks.Netlink, mockFrr *mocks.Frr, errMsg string) {
+				mockFrr.EXPECT().FrrBgpCmd(mock.Anything, mock.MatchedBy(func(command string) bool {
+					return strings.Contains(command, "neighbor 10.0.0.2 remote-as 65001") &&
+						strings.Contains(command, "address-family l2vpn evpn\nneighbor 10.0.0.2 activate")
+				})).Return("", nil).Once()
+			},
+		},
+		"successful unnumbered call": {
+			id:      testBgpPeerID,
+			in:      &BgpPeer{Spec: &BgpPeerSpec{Interface: "eth0", RemoteAs: 65001, AddressFamilies: []BgpAddressFamily{BgpL2vpnEvpn}, KeepaliveTime: 3, HoldTime: 9}},
+			out:     &BgpPeer{Name: testBgpPeerName, Spec: &BgpPeerSpec{Interface: "eth0", RemoteAs: 65001, AddressFamilies: []BgpAddressFamily{BgpL2vpnEvpn}, KeepaliveTime: 3, HoldTime: 9}, Status: &BgpPeerStatus{}},
+			errCode: codes.OK,
+			errMsg:  "",
+			exist:   false,
+			on: func(mockNetlink *mocks.Netlink, mockFrr *mocks.Frr, errMsg string) {
+				uplink := &netlink.Device{LinkAttrs: netlink.LinkAttrs{Name: "eth0"}}
+				mockNetlink.EXPECT().LinkByName(mock.Anything, "eth0").Return(uplink, nil).Once()
+				mockNetlink.EXPECT().LinkSetUp(mock.Anything, uplink).Return(nil).Once()
+				mockFrr.EXPECT().FrrBgpCmd(mock.Anything, mock.MatchedBy(func(command string) bool {
+					return strings.Contains(command, "neighbor eth0 interface remote-as 65001") &&
+						strings.Contains(command, "neighbor eth0 timers 3 9") &&
+						!strings.Contains(command, "ipv4 unicast")
+				})).Return("", nil).Once()
+			},
+		},
+		"missing uplink": {
+			id:      testBgpPeerID,
+			in:      &BgpPeer{Spec: &BgpPeerSpec{Interface: "eth0", PeerType: BgpExternal}},
+			out:     nil,
+			errCode: codes.NotFound,
+			errMsg:  "unable to find key eth0",
+			exist:   false,
+			on: func(mockNetlink *mocks.Netlink, mockFrr *mocks.Frr, errMsg string) {
+				mockNetlink.EXPECT().LinkByName(mock.Anything, "eth0").Return(nil, errors.New(errMsg)).Once()
+			},
+		},
+		"uplink without link-local": {
+			id:      testBgpPeerID,
+			in:      &BgpPeer{Spec: &BgpPeerSpec{Interface: "eth0", PeerType: BgpExternal}},
+			out:     nil,
+			errCode: codes.FailedPrecondition,
+			errMsg:  "interface eth0 has no IPv6 link-local address for the unnumbered session, see net.ipv6.conf.eth0.disable_ipv6",
+			exist:   false,
+			on: func(mockNetlink *mocks.Netlink, mockFrr *mocks.Frr, errMsg string) {
+				uplink := &netlink.Device{LinkAttrs: netlink.LinkAttrs{Name: "eth0", Flags: net.FlagUp}}
+				mockNetlink.EXPECT().LinkByName(mock.Anything, "eth0").Return(uplink, nil).Once()
+				mockNetlink.EXPECT().LinkSetUp(mock.Anything, uplink).Return(nil).Once()
+				mockNetlink.EXPECT().AddrList(mock.Anything, uplink, netlink.FAMILY_V6).Return(nil, nil).Once()
+			},
+		},
+		"successful unnumbered external call": {
+			id:      testBgpPeerID,
+			in:      &BgpPeer{Spec: &BgpPeerSpec{Interface: "eth0", PeerType: BgpExternal}},
+			out:     &BgpPeer{Name: testBgpPeerName, Spec: &BgpPeerSpec{Interface: "eth0", PeerType: BgpExternal}, Status: &BgpPeerStatus{}},
+			errCode: codes.OK,
+			errMsg:  "",
+			exist:   false,
+			on: func(mockNetlink *mocks.Netlink, mockFrr *mocks.Frr, errMsg string) {
+				uplink := &netlink.Device{LinkAttrs: netlink.LinkAttrs{Name: "eth0", Flags: net.FlagUp}}
+				linkLocal := netlink.Addr{IPNet: &net.IPNet{IP: net.ParseIP("fe80::1"), Mask: net.CIDRMask(64, 128)}}
+				mockNetlink.EXPECT().LinkByName(mock.Anything, "eth0").Return(uplink, nil).Once()
+				mockNetlink.EXPECT().LinkSetUp(mock.Anything, uplink).Return(nil).Once()
+				mockNetlink.EXPECT().AddrList(mock.Anything, uplink, netlink.FAMILY_V6).Return([]netlink.Addr{linkLocal}, nil).Once()
+				mockFrr.EXPECT().FrrBgpCmd(mock.Anything, mock.MatchedBy(func(command string) bool {
+					return strings.Contains(command, "neighbor eth0 interface remote-as external") &&
+						strings.Contains(command, "address-family ipv4 unicast\nneighbor eth0 activate")
+				})).Return("", nil).Once()
+			},
+		},
+	}
+
+	// run tests
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			mockNetlink := mocks.NewNetlink(t)
+			mockFrr := mocks.NewFrr(t)
+			opi := NewServerWithArgs(mockNetlink, mockFrr, gomap.NewStore(gomap.DefaultOptions))
+
+			if tt.exist {
+				opi.BgpPeers[testBgpPeerName] = testBgpPeerWithName.clone()
+			}
+			if tt.on != nil {
+				tt.on(mockNetlink, mockFrr, tt.errMsg)
+			}
+
+			request := &CreateBgpPeerRequest{BgpPeer: tt.in.clone(), BgpPeerID: tt.id}
+			response, err := opi.CreateBgpPeer(ctx, request)
+			if !reflect.DeepEqual(tt.out, response) {
+				t.Error("response: expected", tt.out, "received", response)
+			}
+
+			if er, ok := status.FromError(err); ok {
+				if er.Code() != tt.errCode {
+					t.Error("error code: expected", tt.errCode, "received", er.Code())
+				}
+				if er.Message() != tt.errMsg {
+					t.Error("error message: expected", tt.errMsg, "received", er.Message())
+				}
+			} else {
+				t.Error("expected grpc error status")
+			}
+		})
+	}
+
+	t.Run("tenant", func(t *testing.T) {
+		opi := NewServerWithArgs(mocks.NewNetlink(t), mocks.NewFrr(t), gomap.NewStore(gomap.DefaultOptions))
+		request := &CreateBgpPeerRequest{BgpPeer: testBgpPeer.clone(), BgpPeerID: testBgpPeerID}
+		_, err := opi.CreateBgpPeer(tenantContext("acme"), request)
+		if status.Code(err) != codes.PermissionDenied {
+			t.Error("error: expected", codes.PermissionDenied, "received", err)
+		}
+	})
+}
+
+func Test_DeleteBgpPeer(t *testing.T) {
+	tests := map[string]struct {
+		in      string
+		out     *emptypb.Empty
+		errCode codes.Code
+		errMsg  string
+		missing bool
+		on      func(mockFrr *mocks.Frr, errMsg string)
+	}{
+		"valid request with unknown key": {
+			in:      "unknown-id",
+			out:     nil,
+			errCode: codes.NotFound,
+			errMsg:  fmt.Sprintf("unable to find key %v", resourceIDToFullName("bgppeers", "unknown-id")),
+			missing: false,
+			on:      nil,
+		},
+		"unknown key with missing allowed": {
+			in:      "unknown-id",
+			out:     &emptypb.Empty{},
+			errCode: codes.OK,
+			errMsg:  "",
+			missing: true,
+			on:      nil,
+		},
+		"failed FrrBgpCmd call": {
+			in:      testBgpPeerID,
+			out:     nil,
+			errCode: codes.Unknown,
+			errMsg:  "Failed to call FrrBgpCmd",
+			missing: false,
+			on: func(mockFrr *mocks.Frr, errMsg string) {
+				mockFrr.EXPECT().FrrBgpCmd(mock.Anything, mock.Anything).Return("", errors.New(errMsg)).Once()
+			},
+		},
+		"successful call": {
+			in:      testBgpPeerID,
+			out:     &emptypb.Empty{},
+			errCode: codes.OK,
+			errMsg:  "",
+			missing: false,
+			on: func(mockFrr *mocks.Frr, errMsg string) {
+				mockFrr.EXPECT().FrrBgpCmd(mock.Anything, mock.MatchedBy(func(command string) bool {
+					return strings.Contains(command, "no neighbor 10.0.0.2")
+				})).Return("", nil).Once()
+			},
+		},
+	}
+
+	// run tests
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			mockFrr := mocks.NewFrr(t)
+			opi := NewServerWithArgs(mocks.NewNetlink(t), mockFrr, gomap.NewStore(gomap.DefaultOptions))
+
+			opi.BgpPeers[testBgpPeerName] = testBgpPeerWithName.clone()
+			if tt.on != nil {
+				tt.on(mockFrr, tt.errMsg)
+			}
+
+			request := &DeleteBgpPeerRequest{Name: resourceIDToFullName("bgppeers", tt.in), AllowMissing: tt.missing}
+			response, err := opi.DeleteBgpPeer(ctx, request)
+
+			if er, ok := status.FromError(err); ok {
+				if er.Code() != tt.errCode {
+					t.Error("error code: expected", tt.errCode, "received", er.Code())
+				}
+				if er.Message() != tt.errMsg {
+					t.Error("error message: expected", tt.errMsg, "received", er.Message())
+				}
+			} else {
+				t.Error("expected grpc error status")
+			}
+
+			if reflect.TypeOf(response) != reflect.TypeOf(tt.out) {
+				t.Error("response: expected", reflect.TypeOf(tt.out), "received", reflect.TypeOf(response))
+			}
+		})
+	}
+}
+
+func Test_GetBgpPeer(t *testing.T) {
+	mockFrr := mocks.NewFrr(t)
+	opi := NewServerWithArgs(mocks.NewNetlink(t), mockFrr, gomap.NewStore(gomap.DefaultOptions))
+	opi.BgpPeers[testBgpPeerName] = testBgpPeerWithName.clone()
+
+	summary := `{"default":{"l2VpnEvpn":{"peers":{"10.0.0.2":{"state":"Established"}}}}}`
+	mockFrr.EXPECT().FrrBgpCmd(mock.Anything, "show bgp vrf all summary json").Return(summary, nil).Once()
+	response, err := opi.GetBgpPeer(context.Background(), &GetBgpPeerRequest{Name: testBgpPeerName})
+	if err != nil {
+		t.Fatal("error: expected", nil, "received", err)
+	}
+	if response.Status.State != "Established" {
+		t.Error("state: expected Established, received", response.Status.State)
+	}
+}
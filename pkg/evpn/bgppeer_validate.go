@@ -0,0 +1,113 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"fmt"
+
+	"go.einride.tech/aip/resourcename"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// validBgpAddressFamily checks the family is one a BgpPeer can activate
+func validBgpAddressFamily(family BgpAddressFamily) bool {
+	for _, valid := range bgpAddressFamilies {
+		if family == valid {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) validateCreateBgpPeerRequest(in *CreateBgpPeerRequest) error {
+	// check required fields
+	switch {
+	case in.BgpPeer == nil:
+		return missingField("bgp_peer")
+	case in.BgpPeer.Spec == nil:
+		return missingField("bgp_peer.spec")
+	case in.BgpPeer.Spec.PeerIPAddress == nil && in.BgpPeer.Spec.Interface == "":
+		return missingField("bgp_peer.spec.peer_ip_address")
+	case in.BgpPeer.Spec.RemoteAs == 0 && in.BgpPeer.Spec.PeerType == "":
+		return missingField("bgp_peer.spec.remote_as")
+	}
+	// the AS number of the peer is either pinned or accepted by type
+	if in.BgpPeer.Spec.RemoteAs != 0 && in.BgpPeer.Spec.PeerType != "" {
+		msg := "only one of remote_as and peer_type can be set"
+		return badRequest("bgp_peer.spec.peer_type", status.Error(codes.InvalidArgument, msg))
+	}
+	switch in.BgpPeer.Spec.PeerType {
+	case "", BgpExternal, BgpInternal:
+	default:
+		msg := fmt.Sprintf("Peer type (%s) have to be %s or %s", in.BgpPeer.Spec.PeerType, BgpExternal, BgpInternal)
+		return badRequest("bgp_peer.spec.peer_type", status.Error(codes.InvalidArgument, msg))
+	}
+	if len(in.BgpPeer.Spec.Interface) > maxKernelNameLength {
+		msg := fmt.Sprintf("Interface (%s) have to be a kernel interface name of at most %d characters", in.BgpPeer.Spec.Interface, maxKernelNameLength)
+		return badRequest("bgp_peer.spec.interface", status.Error(codes.InvalidArgument, msg))
+	}
+	// a session is either numbered or unnumbered
+	if in.BgpPeer.Spec.PeerIPAddress != nil && in.BgpPeer.Spec.Interface != "" {
+		msg := "only one of peer_ip_address and interface can be set"
+		return badRequest("bgp_peer.spec.interface", status.Error(codes.InvalidArgument, msg))
+	}
+	for i, family := range in.BgpPeer.Spec.AddressFamilies {
+		if !validBgpAddressFamily(family) {
+			msg := fmt.Sprintf("Address family (%s) have to be %s, %s, %s or %s", family, BgpIPv4Unicast, BgpL2vpnEvpn, BgpIPv4Vpn, BgpIPv6Vpn)
+			field := fmt.Sprintf("bgp_peer.spec.address_families[%d]", i)
+			return badRequest(field, status.Error(codes.InvalidArgument, msg))
+		}
+	}
+	// check timers are in the range FRR accepts, see RFC 4271 section 4.2
+	keepalive, hold := in.BgpPeer.Spec.KeepaliveTime, in.BgpPeer.Spec.HoldTime
+	if keepalive != 0 || hold != 0 {
+		if hold < 3 || hold > 65535 {
+			msg := fmt.Sprintf("Hold time (%d) have to be between 3 and 65535", hold)
+			return badRequest("bgp_peer.spec.hold_time", status.Error(codes.InvalidArgument, msg))
+		}
+		if keepalive == 0 || keepalive >= hold {
+			msg := fmt.Sprintf("Keepalive time (%d) have to be between 1 and the hold time (%d)", keepalive, hold)
+			return badRequest("bgp_peer.spec.keepalive_time", status.Error(codes.InvalidArgument, msg))
+		}
+	}
+	// Validate that a RouteMap resource name conforms to the restrictions outlined in AIP-122.
+	if in.BgpPeer.Spec.ImportRouteMap != "" {
+		if err := badRequest("bgp_peer.spec.import_route_map", resourcename.Validate(in.BgpPeer.Spec.ImportRouteMap)); err != nil {
+			return err
+		}
+	}
+	if in.BgpPeer.Spec.ExportRouteMap != "" {
+		if err := badRequest("bgp_peer.spec.export_route_map", resourcename.Validate(in.BgpPeer.Spec.ExportRouteMap)); err != nil {
+			return err
+		}
+	}
+	// see https://google.aip.dev/133#user-specified-ids
+	if in.BgpPeerID != "" {
+		if err := badRequest("bgp_peer_id", validateResourceID(in.BgpPeerID, false)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Server) validateDeleteBgpPeerRequest(in *DeleteBgpPeerRequest) error {
+	// check required fields
+	if in.Name == "" {
+		return missingField("name")
+	}
+	// Validate that a resource name conforms to the restrictions outlined in AIP-122.
+	return badRequest("name", resourcename.Validate(in.Name))
+}
+
+func (s *Server) validateGetBgpPeerRequest(in *GetBgpPeerRequest) error {
+	// check required fields
+	if in.Name == "" {
+		return missingField("name")
+	}
+	// Validate that a resource name conforms to the restrictions outlined in AIP-122.
+	return badRequest("name", resourcename.Validate(in.Name))
+}
@@ -7,14 +7,13 @@ package evpn
 
 import (
 	"context"
-	"fmt"
 	"log"
 	"sort"
 
-	"github.com/google/uuid"
-
 	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
 
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils"
+
 	"go.einride.tech/aip/fieldbehavior"
 	"go.einride.tech/aip/resourceid"
 	"google.golang.org/grpc/codes"
@@ -34,27 +33,98 @@ func (s *Server) CreateLogicalBridge(ctx context.Context, in *pb.CreateLogicalBr
 	if err := s.validateCreateLogicalBridgeRequest(in); err != nil {
 		return nil, err
 	}
+	s.objectsMu.Lock()
+	defer s.objectsMu.Unlock()
+	return s.createLogicalBridge(ctx, in)
+}
+
+// createLogicalBridge creates a validated LogicalBridge
+func (s *Server) createLogicalBridge(ctx context.Context, in *pb.CreateLogicalBridgeRequest) (*pb.LogicalBridge, error) {
 	// see https://google.aip.dev/133#user-specified-ids
 	resourceID := resourceid.NewSystemGenerated()
 	if in.LogicalBridgeId != "" {
 		log.Printf("client provided the ID of a resource %v, ignoring the name field %v", in.LogicalBridgeId, in.LogicalBridge.Name)
 		resourceID = in.LogicalBridgeId
 	}
-	in.LogicalBridge.Name = resourceIDToFullName("bridges", resourceID)
+	name, err := newObjectName(ctx, "bridges", resourceID)
+	if err != nil {
+		return nil, err
+	}
+	in.LogicalBridge.Name = name
+	in.LogicalBridge.Spec.VtepIpPrefix = s.vtepIPPrefixOr(in.LogicalBridge.Spec.Vni, in.LogicalBridge.Spec.VtepIpPrefix)
+	labels, err := labelsFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	group, err := s.multicastGroupFor(ctx, in.LogicalBridge)
+	if err != nil {
+		return nil, err
+	}
+	geneve, err := s.geneveTunnelFor(ctx, in.LogicalBridge)
+	if err != nil {
+		return nil, err
+	}
+	// a geneve tunnel has a single remote TEP, there is nothing to flood to a group
+	if geneve != nil && group != "" {
+		msg := "a geneve tunnel cannot flood to a multicast group"
+		return nil, badRequest(utils.MulticastGroupMetadataKey, status.Error(codes.InvalidArgument, msg))
+	}
 	// idempotent API when called with same key, should return same object
 	obj, ok := s.Bridges[in.LogicalBridge.Name]
 	if ok {
+		// a different spec under the same key is a conflict, not a retry
+		if err := checkSameSpec(obj.Name, obj.Spec, in.LogicalBridge.Spec); err != nil {
+			return nil, err
+		}
 		log.Printf("Already existing LogicalBridge with id %v", in.LogicalBridge.Name)
 		return obj, nil
 	}
-	// configure netlink
-	if err := s.netlinkCreateLogicalBridge(ctx, in); err != nil {
+	if err := s.checkTenantQuota(in.LogicalBridge.Name, "LogicalBridge"); err != nil {
+		return nil, err
+	}
+	if err := s.validateLogicalBridgeQuota(in.LogicalBridge); err != nil {
+		return nil, err
+	}
+	// a vni used as the L3 vni of a Vrf would end up on two vxlan devices
+	if err := s.precheckVniFree(in.LogicalBridge.Name, in.LogicalBridge.Spec.Vni); err != nil {
+		return nil, err
+	}
+	// see https://google.aip.dev/163
+	if utils.IsValidateOnly(ctx) {
+		if err := s.precheckCreateLogicalBridge(ctx, in); err != nil {
+			return nil, err
+		}
+		response := protoClone(in.LogicalBridge)
+		response.Status = &pb.LogicalBridgeStatus{}
+		return response, nil
+	}
+	if group != "" {
+		s.MulticastGroups[in.LogicalBridge.Name] = group
+	}
+	if geneve != nil {
+		s.GeneveTunnels[in.LogicalBridge.Name] = *geneve
+	}
+	ctx, cancel := s.programmingContext(ctx)
+	defer cancel()
+	if err := s.dataplane.CreateLogicalBridge(ctx, in.LogicalBridge); err != nil {
+		s.forgetStatus(in.LogicalBridge.Name)
+		delete(s.MulticastGroups, in.LogicalBridge.Name)
+		delete(s.GeneveTunnels, in.LogicalBridge.Name)
 		return nil, err
 	}
-	// save object to the database
+	// save object to the database, the oper status is only computed on reads
 	response := protoClone(in.LogicalBridge)
-	response.Status = &pb.LogicalBridgeStatus{OperStatus: pb.LBOperStatus_LB_OPER_STATUS_UP}
-	s.Bridges[in.LogicalBridge.Name] = response
+	response.Status = &pb.LogicalBridgeStatus{}
+	s.putLogicalBridge(response)
+	s.persist("bridges")
+	s.setLabels(in.LogicalBridge.Name, labels)
+	if group != "" {
+		s.persistMulticastGroups()
+	}
+	if geneve != nil {
+		s.persistGeneveTunnels()
+	}
+	s.events.Publish(utils.WatchEvent{Type: utils.WatchAdded, Name: in.LogicalBridge.Name})
 	return response, nil
 }
 
@@ -64,21 +134,41 @@ func (s *Server) DeleteLogicalBridge(ctx context.Context, in *pb.DeleteLogicalBr
 	if err := s.validateDeleteLogicalBridgeRequest(in); err != nil {
 		return nil, err
 	}
+	s.objectsMu.Lock()
+	defer s.objectsMu.Unlock()
+	if err := s.checkEtag(ctx, in.Name); err != nil {
+		return nil, err
+	}
 	// fetch object from the database
 	obj, ok := s.Bridges[in.Name]
-	if !ok {
+	if !ok || !inTenant(ctx, in.Name) {
 		if in.AllowMissing {
 			return &emptypb.Empty{}, nil
 		}
 		err := status.Errorf(codes.NotFound, "unable to find key %s", in.Name)
 		return nil, err
 	}
-	// configure netlink
-	if err := s.netlinkDeleteLogicalBridge(ctx, obj); err != nil {
+	// refuse to leave dangling references, unless asked to delete them as well
+	if err := s.checkDependents(ctx, obj.Name, s.logicalBridgeDependents(obj.Name)); err != nil {
+		return nil, err
+	}
+	if utils.IsValidateOnly(ctx) {
+		return &emptypb.Empty{}, nil
+	}
+	ctx, cancel := s.programmingContext(ctx)
+	defer cancel()
+	if err := s.dataplane.DeleteLogicalBridge(ctx, obj); err != nil {
 		return nil, err
 	}
 	// remove from the Database
-	delete(s.Bridges, obj.Name)
+	s.removeLogicalBridge(obj.Name)
+	s.forgetStatus(obj.Name)
+	s.persist("bridges")
+	s.releaseLabels(obj.Name)
+	s.releaseMulticastGroup(obj.Name)
+	s.releaseGeneveTunnel(obj.Name)
+	s.events.Publish(utils.WatchEvent{Type: utils.WatchDeleted, Name: obj.Name})
+	delete(s.Adopted, obj.Name)
 	return &emptypb.Empty{}, nil
 }
 
@@ -88,31 +178,37 @@ func (s *Server) UpdateLogicalBridge(ctx context.Context, in *pb.UpdateLogicalBr
 	if err := s.validateUpdateLogicalBridgeRequest(in); err != nil {
 		return nil, err
 	}
+	s.objectsMu.Lock()
+	defer s.objectsMu.Unlock()
+	if err := s.checkEtag(ctx, in.LogicalBridge.Name); err != nil {
+		return nil, err
+	}
 	// fetch object from the database
 	bridge, ok := s.Bridges[in.LogicalBridge.Name]
-	if !ok {
+	if !ok || !inTenant(ctx, in.LogicalBridge.Name) {
 		// TODO: introduce "in.AllowMissing" field. In case "true", create a new resource, don't return error
 		err := status.Errorf(codes.NotFound, "unable to find key %s", in.LogicalBridge.Name)
 		return nil, err
 	}
-	// only if VNI is not empty
-	if bridge.Spec.Vni != nil {
-		vxlanName := fmt.Sprintf("vni%d", *bridge.Spec.Vni)
-		iface, err := s.nLink.LinkByName(ctx, vxlanName)
-		if err != nil {
-			err := status.Errorf(codes.NotFound, "unable to find key %s", vxlanName)
-			return nil, err
-		}
-		// base := iface.Attrs()
-		// iface.MTU = 1500 // TODO: remove this, just an example
-		if err := s.nLink.LinkModify(ctx, iface); err != nil {
-			fmt.Printf("Failed to update link: %v", err)
-			return nil, err
-		}
+	in.LogicalBridge.Spec.VtepIpPrefix = s.vtepIPPrefixOr(in.LogicalBridge.Spec.Vni, in.LogicalBridge.Spec.VtepIpPrefix)
+	labels, err := labelsFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if utils.IsValidateOnly(ctx) {
+		response := protoClone(in.LogicalBridge)
+		response.Status = &pb.LogicalBridgeStatus{}
+		return response, nil
+	}
+	if err := s.dataplane.UpdateLogicalBridge(ctx, bridge, in.LogicalBridge); err != nil {
+		return nil, err
 	}
 	response := protoClone(in.LogicalBridge)
-	response.Status = &pb.LogicalBridgeStatus{OperStatus: pb.LBOperStatus_LB_OPER_STATUS_UP}
-	s.Bridges[in.LogicalBridge.Name] = response
+	response.Status = &pb.LogicalBridgeStatus{}
+	s.putLogicalBridge(response)
+	s.persist("bridges")
+	s.setLabels(in.LogicalBridge.Name, labels)
+	s.events.Publish(utils.WatchEvent{Type: utils.WatchModified, Name: in.LogicalBridge.Name})
 	return response, nil
 }
 
@@ -122,51 +218,71 @@ func (s *Server) GetLogicalBridge(ctx context.Context, in *pb.GetLogicalBridgeRe
 	if err := s.validateGetLogicalBridgeRequest(in); err != nil {
 		return nil, err
 	}
+	s.objectsMu.RLock()
+	defer s.objectsMu.RUnlock()
 	// fetch object from the database
 	bridge, ok := s.Bridges[in.Name]
-	if !ok {
+	if !ok || !inTenant(ctx, in.Name) {
 		err := status.Errorf(codes.NotFound, "unable to find key %s", in.Name)
 		return nil, err
 	}
-	// only if VNI is not empty
-	if bridge.Spec.Vni != nil {
-		vxlanName := fmt.Sprintf("vni%d", *bridge.Spec.Vni)
-		_, err := s.nLink.LinkByName(ctx, vxlanName)
-		if err != nil {
-			err := status.Errorf(codes.NotFound, "unable to find key %s", vxlanName)
+	if !s.LiveRead {
+		if err := s.dataplane.GetLogicalBridge(ctx, bridge); err != nil {
 			return nil, err
 		}
 	}
+	degraded := map[string]error{}
+	operStatus := s.logicalBridgeOperStatus(ctx, bridge, degraded)
+	reportDegraded(ctx, degraded)
+	s.reportCounters(ctx, []string{in.Name})
+	s.reportLifecycles(ctx, []string{in.Name})
 	// TODO
-	return &pb.LogicalBridge{Name: in.Name, Spec: &pb.LogicalBridgeSpec{Vni: bridge.Spec.Vni, VlanId: bridge.Spec.VlanId}, Status: &pb.LogicalBridgeStatus{OperStatus: pb.LBOperStatus_LB_OPER_STATUS_UP}}, nil
+	return &pb.LogicalBridge{Name: in.Name, Spec: &pb.LogicalBridgeSpec{Vni: bridge.Spec.Vni, VlanId: bridge.Spec.VlanId}, Status: &pb.LogicalBridgeStatus{OperStatus: operStatus}}, nil
 }
 
 // ListLogicalBridges lists logical bridges
-func (s *Server) ListLogicalBridges(_ context.Context, in *pb.ListLogicalBridgesRequest) (*pb.ListLogicalBridgesResponse, error) {
+func (s *Server) ListLogicalBridges(ctx context.Context, in *pb.ListLogicalBridgesRequest) (*pb.ListLogicalBridgesResponse, error) {
 	// check required fields
 	if err := fieldbehavior.ValidateRequiredFields(in); err != nil {
 		return nil, err
 	}
+	s.objectsMu.RLock()
+	defer s.objectsMu.RUnlock()
 	// fetch pagination from the database, calculate size and offset
-	size, offset, perr := extractPagination(in.PageSize, in.PageToken, s.Pagination)
+	size, offset, perr := s.extractPagination(in.PageSize, in.PageToken)
 	if perr != nil {
 		return nil, perr
 	}
-	// fetch object from the database
-	Blobarray := []*pb.LogicalBridge{}
-	for _, bridge := range s.Bridges {
-		r := protoClone(bridge)
-		r.Status = &pb.LogicalBridgeStatus{OperStatus: pb.LBOperStatus_LB_OPER_STATUS_UP}
-		Blobarray = append(Blobarray, r)
-	}
-	// sort is needed, since MAP is unsorted in golang, and we might get different results
-	sortLogicalBridges(Blobarray)
-	log.Printf("Limiting result len(%d) to [%d:%d]", len(Blobarray), offset, size)
-	Blobarray, hasMoreElements := limitPagination(Blobarray, offset, size)
-	token := ""
-	if hasMoreElements {
-		token = uuid.New().String()
-		s.Pagination[token] = offset + size
+	selector, err := labelSelectorFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// fetch object from the database, the following pages are cut from the snapshot of the first one,
+	// copies of the stored objects taken under objectsMu
+	Blobarray, token, err := listPage(ctx, s, "logicalBridges", "", in.PageToken, offset, size, func() []*pb.LogicalBridge {
+		Blobarray := []*pb.LogicalBridge{}
+		for name, bridge := range s.Bridges {
+			if !inTenant(ctx, name) || !s.matchesLabels(selector, name) {
+				continue
+			}
+			Blobarray = append(Blobarray, protoClone(bridge))
+		}
+		// sort is needed, since MAP is unsorted in golang, and we might get different results
+		sortLogicalBridges(Blobarray)
+		return Blobarray
+	})
+	if err != nil {
+		return nil, err
+	}
+	Blobarray = clonePage(Blobarray)
+	degraded := map[string]error{}
+	names := make([]string, 0, len(Blobarray))
+	for _, r := range Blobarray {
+		r.Status = &pb.LogicalBridgeStatus{OperStatus: s.logicalBridgeOperStatus(ctx, r, degraded)}
+		names = append(names, r.Name)
 	}
+	reportDegraded(ctx, degraded)
+	s.reportCounters(ctx, names)
+	s.reportLifecycles(ctx, names)
 	return &pb.ListLogicalBridgesResponse{LogicalBridges: Blobarray, NextPageToken: token}, nil
 }
@@ -0,0 +1,47 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+)
+
+// frrCreateMulticastGroup enables PIM for the multicast group of a LogicalBridge, FRR
+// advertises the vni with a PIM-SM PMSI tunnel instead of ingress replication on its own
+// once the vxlan device has a group
+func (s *Server) frrCreateMulticastGroup(ctx context.Context, obj *pb.LogicalBridge) error {
+	group := s.multicastGroup(obj.Name)
+	var config strings.Builder
+	config.WriteString("configure terminal\n")
+	for _, iface := range s.Pim.Interfaces {
+		fmt.Fprintf(&config, "interface %s\nip pim\nexit\n", iface)
+	}
+	if s.Pim.RP != "" {
+		fmt.Fprintf(&config, "ip pim rp %s %s/32\n", s.Pim.RP, group)
+	}
+	config.WriteString("exit")
+	data, err := s.frr.FrrPimCmd(ctx, config.String())
+	fmt.Printf("FrrPimCmd: %v:%v", data, err)
+	return err
+}
+
+// frrDeleteMulticastGroup removes the rendezvous point of the group once no LogicalBridge uses
+// it anymore, PIM stays enabled on the interfaces
+func (s *Server) frrDeleteMulticastGroup(ctx context.Context, obj *pb.LogicalBridge) error {
+	group := s.multicastGroup(obj.Name)
+	if s.Pim.RP == "" || s.groupInUse(obj.Name, group.String()) {
+		return nil
+	}
+	data, err := s.frr.FrrPimCmd(ctx, fmt.Sprintf(
+		`configure terminal
+		no ip pim rp %s %s/32
+		exit`, s.Pim.RP, group))
+	fmt.Printf("FrrPimCmd: %v:%v", data, err)
+	return err
+}
@@ -20,21 +20,30 @@ import (
 	"google.golang.org/grpc/status"
 )
 
-func (s *Server) netlinkCreateLogicalBridge(ctx context.Context, in *pb.CreateLogicalBridgeRequest) error {
+func (s *Server) netlinkCreateLogicalBridge(ctx context.Context, obj *pb.LogicalBridge) error {
 	// create vxlan only if VNI is not empty
-	if in.LogicalBridge.Spec.Vni != nil {
-		bridge, err := s.nLink.LinkByName(ctx, tenantbridgeName)
+	if obj.Spec.Vni != nil {
+		bridge, err := s.tenantBridge(ctx)
 		if err != nil {
-			err := status.Errorf(codes.NotFound, "unable to find key %s", tenantbridgeName)
 			return err
 		}
-		// Example: ip link add vxlan-<LB-vlan-id> type vxlan id <LB-vni> local <vtep-ip> dstport 4789 nolearning proxy
-		myip := make(net.IP, 4)
-		binary.BigEndian.PutUint32(myip, in.LogicalBridge.Spec.VtepIpPrefix.Addr.GetV4Addr())
-		vxlanName := fmt.Sprintf("vni%d", *in.LogicalBridge.Spec.Vni)
-		vxlan := &netlink.Vxlan{LinkAttrs: netlink.LinkAttrs{Name: vxlanName}, VxlanId: int(*in.LogicalBridge.Spec.Vni), Port: 4789, Learning: false, SrcAddr: myip}
-		log.Printf("Creating Vxlan %v", vxlan)
-		// TODO: take Port from proto instead of hard-coded
+		vxlanName := fmt.Sprintf("vni%d", *obj.Spec.Vni)
+		var vxlan netlink.Link
+		if tunnel, ok := s.GeneveTunnels[obj.Name]; ok {
+			// Example: ip link add vni<LB-vni> type geneve id <LB-vni> remote <remote-tep> dstport 6081
+			vxlan = s.newGeneve(vxlanName, *obj.Spec.Vni, tunnel)
+		} else {
+			// Example: ip link add vxlan-<LB-vlan-id> type vxlan id <LB-vni> local <vtep-ip> dstport 4789 nolearning proxy
+			myip := make(net.IP, 4)
+			binary.BigEndian.PutUint32(myip, obj.Spec.VtepIpPrefix.Addr.GetV4Addr())
+			link := s.newVxlan(vxlanName, *obj.Spec.Vni, myip)
+			// BUM traffic is flooded to the multicast group instead of being replicated to every VTEP
+			if group := s.multicastGroup(obj.Name); group != nil {
+				link.Group = group
+			}
+			vxlan = link
+		}
+		log.Printf("Creating %s %v", vxlan.Type(), vxlan)
 		if err := s.nLink.LinkAdd(ctx, vxlan); err != nil {
 			fmt.Printf("Failed to create Vxlan link: %v", err)
 			return err
@@ -50,7 +59,7 @@ func (s *Server) netlinkCreateLogicalBridge(ctx context.Context, in *pb.CreateLo
 			return err
 		}
 		// Example: bridge vlan add dev vxlan-<LB-vlan-id> vid <LB-vlan-id> pvid untagged
-		if err := s.nLink.BridgeVlanAdd(ctx, vxlan, uint16(in.LogicalBridge.Spec.VlanId), true, true, false, false); err != nil {
+		if err := s.nLink.BridgeVlanAdd(ctx, vxlan, uint16(obj.Spec.VlanId), true, true, false, false); err != nil {
 			fmt.Printf("Failed to add vlan to bridge: %v", err)
 			return err
 		}
@@ -59,6 +68,25 @@ func (s *Server) netlinkCreateLogicalBridge(ctx context.Context, in *pb.CreateLo
 	return nil
 }
 
+func (s *Server) netlinkUpdateLogicalBridge(ctx context.Context, obj *pb.LogicalBridge) error {
+	// only if VNI is not empty
+	if obj.Spec.Vni != nil {
+		vxlanName := fmt.Sprintf("vni%d", *obj.Spec.Vni)
+		iface, err := s.nLink.LinkByName(ctx, vxlanName)
+		if err != nil {
+			err := status.Errorf(codes.NotFound, "unable to find key %s", vxlanName)
+			return err
+		}
+		// base := iface.Attrs()
+		// iface.MTU = 1500 // TODO: remove this, just an example
+		if err := s.nLink.LinkModify(ctx, iface); err != nil {
+			fmt.Printf("Failed to update link: %v", err)
+			return err
+		}
+	}
+	return nil
+}
+
 func (s *Server) netlinkDeleteLogicalBridge(ctx context.Context, obj *pb.LogicalBridge) error {
 	// only if VNI is not empty
 	if obj.Spec.Vni != nil {
@@ -14,6 +14,7 @@ import (
 	"net"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/philippgille/gokv/gomap"
 	"github.com/stretchr/testify/mock"
@@ -74,7 +75,7 @@ func Test_CreateLogicalBridge(t *testing.T) {
 			id:      "CapitalLettersNotAllowed",
 			in:      &testLogicalBridge,
 			out:     nil,
-			errCode: codes.Unknown,
+			errCode: codes.InvalidArgument,
 			errMsg:  fmt.Sprintf("user-settable ID must only contain lowercase, numbers and hyphens (%v)", "got: 'C' in position 0"),
 			exist:   false,
 			on:      nil,
@@ -83,7 +84,7 @@ func Test_CreateLogicalBridge(t *testing.T) {
 			id:      testLogicalBridgeID,
 			in:      nil,
 			out:     nil,
-			errCode: codes.Unknown,
+			errCode: codes.InvalidArgument,
 			errMsg:  "missing required field: logical_bridge",
 			exist:   false,
 			on:      nil,
@@ -94,7 +95,7 @@ func Test_CreateLogicalBridge(t *testing.T) {
 				Spec: &pb.LogicalBridgeSpec{},
 			},
 			out:     nil,
-			errCode: codes.Unknown,
+			errCode: codes.InvalidArgument,
 			errMsg:  "missing required field: logical_bridge.spec.vlan_id",
 			exist:   false,
 			on:      nil,
@@ -109,7 +110,7 @@ func Test_CreateLogicalBridge(t *testing.T) {
 			},
 			out:     nil,
 			errCode: codes.InvalidArgument,
-			errMsg:  fmt.Sprintf("VlanId value (%v) have to be between 1 and 4095", 4096),
+			errMsg:  fmt.Sprintf("VlanId value (%v) have to be between 1 and 4094", 4096),
 			exist:   false,
 			on:      nil,
 		},
@@ -124,9 +125,7 @@ func Test_CreateLogicalBridge(t *testing.T) {
 				Spec: &pb.LogicalBridgeSpec{
 					VlanId: 11,
 				},
-				Status: &pb.LogicalBridgeStatus{
-					OperStatus: pb.LBOperStatus_LB_OPER_STATUS_UP,
-				},
+				Status: &pb.LogicalBridgeStatus{},
 			},
 			errCode: codes.OK,
 			errMsg:  "",
@@ -141,6 +140,19 @@ func Test_CreateLogicalBridge(t *testing.T) {
 			errMsg:  "",
 			exist:   true,
 		},
+		"already exists with a different spec": {
+			id: testLogicalBridgeID,
+			in: &pb.LogicalBridge{
+				Spec: &pb.LogicalBridgeSpec{
+					Vni:    proto.Uint32(12),
+					VlanId: 22,
+				},
+			},
+			out:     nil,
+			errCode: codes.AlreadyExists,
+			errMsg:  fmt.Sprintf("%v already exists with a different spec", testLogicalBridgeName),
+			exist:   true,
+		},
 		"failed LinkByName call": {
 			id:      testLogicalBridgeID,
 			in:      &testLogicalBridge,
@@ -164,7 +176,7 @@ func Test_CreateLogicalBridge(t *testing.T) {
 				myip := make(net.IP, 4)
 				binary.BigEndian.PutUint32(myip, 167772162)
 				vxlanName := fmt.Sprintf("vni%d", *testLogicalBridge.Spec.Vni)
-				vxlan := &netlink.Vxlan{LinkAttrs: netlink.LinkAttrs{Name: vxlanName}, VxlanId: int(*testLogicalBridge.Spec.Vni), Port: 4789, Learning: false, SrcAddr: myip}
+				vxlan := &netlink.Vxlan{LinkAttrs: netlink.LinkAttrs{Name: vxlanName, Alias: managedAlias}, VxlanId: int(*testLogicalBridge.Spec.Vni), Port: 4789, Learning: false, SrcAddr: myip}
 				bridge := &netlink.Bridge{LinkAttrs: netlink.LinkAttrs{Name: tenantbridgeName}}
 				mockNetlink.EXPECT().LinkByName(mock.Anything, tenantbridgeName).Return(bridge, nil).Once()
 				mockNetlink.EXPECT().LinkAdd(mock.Anything, vxlan).Return(errors.New(errMsg)).Once()
@@ -181,7 +193,7 @@ func Test_CreateLogicalBridge(t *testing.T) {
 				myip := make(net.IP, 4)
 				binary.BigEndian.PutUint32(myip, 167772162)
 				vxlanName := fmt.Sprintf("vni%d", *testLogicalBridge.Spec.Vni)
-				vxlan := &netlink.Vxlan{LinkAttrs: netlink.LinkAttrs{Name: vxlanName}, VxlanId: int(*testLogicalBridge.Spec.Vni), Port: 4789, Learning: false, SrcAddr: myip}
+				vxlan := &netlink.Vxlan{LinkAttrs: netlink.LinkAttrs{Name: vxlanName, Alias: managedAlias}, VxlanId: int(*testLogicalBridge.Spec.Vni), Port: 4789, Learning: false, SrcAddr: myip}
 				bridge := &netlink.Bridge{LinkAttrs: netlink.LinkAttrs{Name: tenantbridgeName}}
 				mockNetlink.EXPECT().LinkByName(mock.Anything, tenantbridgeName).Return(bridge, nil).Once()
 				mockNetlink.EXPECT().LinkAdd(mock.Anything, vxlan).Return(nil).Once()
@@ -199,7 +211,7 @@ func Test_CreateLogicalBridge(t *testing.T) {
 				myip := make(net.IP, 4)
 				binary.BigEndian.PutUint32(myip, 167772162)
 				vxlanName := fmt.Sprintf("vni%d", *testLogicalBridge.Spec.Vni)
-				vxlan := &netlink.Vxlan{LinkAttrs: netlink.LinkAttrs{Name: vxlanName}, VxlanId: int(*testLogicalBridge.Spec.Vni), Port: 4789, Learning: false, SrcAddr: myip}
+				vxlan := &netlink.Vxlan{LinkAttrs: netlink.LinkAttrs{Name: vxlanName, Alias: managedAlias}, VxlanId: int(*testLogicalBridge.Spec.Vni), Port: 4789, Learning: false, SrcAddr: myip}
 				bridge := &netlink.Bridge{LinkAttrs: netlink.LinkAttrs{Name: tenantbridgeName}}
 				mockNetlink.EXPECT().LinkByName(mock.Anything, tenantbridgeName).Return(bridge, nil).Once()
 				mockNetlink.EXPECT().LinkAdd(mock.Anything, vxlan).Return(nil).Once()
@@ -218,7 +230,7 @@ func Test_CreateLogicalBridge(t *testing.T) {
 				myip := make(net.IP, 4)
 				binary.BigEndian.PutUint32(myip, 167772162)
 				vxlanName := fmt.Sprintf("vni%d", *testLogicalBridge.Spec.Vni)
-				vxlan := &netlink.Vxlan{LinkAttrs: netlink.LinkAttrs{Name: vxlanName}, VxlanId: int(*testLogicalBridge.Spec.Vni), Port: 4789, Learning: false, SrcAddr: myip}
+				vxlan := &netlink.Vxlan{LinkAttrs: netlink.LinkAttrs{Name: vxlanName, Alias: managedAlias}, VxlanId: int(*testLogicalBridge.Spec.Vni), Port: 4789, Learning: false, SrcAddr: myip}
 				bridge := &netlink.Bridge{LinkAttrs: netlink.LinkAttrs{Name: tenantbridgeName}}
 				mockNetlink.EXPECT().LinkByName(mock.Anything, tenantbridgeName).Return(bridge, nil).Once()
 				mockNetlink.EXPECT().LinkAdd(mock.Anything, vxlan).Return(nil).Once()
@@ -231,7 +243,7 @@ func Test_CreateLogicalBridge(t *testing.T) {
 		"successful call": {
 			id:      testLogicalBridgeID,
 			in:      &testLogicalBridge,
-			out:     &testLogicalBridgeWithStatus,
+			out:     &pb.LogicalBridge{Spec: testLogicalBridge.Spec, Status: &pb.LogicalBridgeStatus{}},
 			errCode: codes.OK,
 			errMsg:  "",
 			exist:   false,
@@ -239,7 +251,7 @@ func Test_CreateLogicalBridge(t *testing.T) {
 				myip := make(net.IP, 4)
 				binary.BigEndian.PutUint32(myip, 167772162)
 				vxlanName := fmt.Sprintf("vni%d", *testLogicalBridge.Spec.Vni)
-				vxlan := &netlink.Vxlan{LinkAttrs: netlink.LinkAttrs{Name: vxlanName}, VxlanId: int(*testLogicalBridge.Spec.Vni), Port: 4789, Learning: false, SrcAddr: myip}
+				vxlan := &netlink.Vxlan{LinkAttrs: netlink.LinkAttrs{Name: vxlanName, Alias: managedAlias}, VxlanId: int(*testLogicalBridge.Spec.Vni), Port: 4789, Learning: false, SrcAddr: myip}
 				bridge := &netlink.Bridge{LinkAttrs: netlink.LinkAttrs{Name: tenantbridgeName}}
 				mockNetlink.EXPECT().LinkByName(mock.Anything, tenantbridgeName).Return(bridge, nil).Once()
 				mockNetlink.EXPECT().LinkAdd(mock.Anything, vxlan).Return(nil).Once()
@@ -360,7 +372,7 @@ func Test_DeleteLogicalBridge(t *testing.T) {
 				myip := make(net.IP, 4)
 				binary.BigEndian.PutUint32(myip, 167772162)
 				vxlanName := fmt.Sprintf("vni%d", *testLogicalBridge.Spec.Vni)
-				vxlan := &netlink.Vxlan{LinkAttrs: netlink.LinkAttrs{Name: vxlanName}, VxlanId: int(*testLogicalBridge.Spec.Vni), Port: 4789, Learning: false, SrcAddr: myip}
+				vxlan := &netlink.Vxlan{LinkAttrs: netlink.LinkAttrs{Name: vxlanName, Alias: managedAlias}, VxlanId: int(*testLogicalBridge.Spec.Vni), Port: 4789, Learning: false, SrcAddr: myip}
 				mockNetlink.EXPECT().LinkByName(mock.Anything, vxlanName).Return(vxlan, nil).Once()
 				mockNetlink.EXPECT().LinkSetDown(mock.Anything, vxlan).Return(errors.New(errMsg)).Once()
 			},
@@ -375,7 +387,7 @@ func Test_DeleteLogicalBridge(t *testing.T) {
 				myip := make(net.IP, 4)
 				binary.BigEndian.PutUint32(myip, 167772162)
 				vxlanName := fmt.Sprintf("vni%d", *testLogicalBridge.Spec.Vni)
-				vxlan := &netlink.Vxlan{LinkAttrs: netlink.LinkAttrs{Name: vxlanName}, VxlanId: int(*testLogicalBridge.Spec.Vni), Port: 4789, Learning: false, SrcAddr: myip}
+				vxlan := &netlink.Vxlan{LinkAttrs: netlink.LinkAttrs{Name: vxlanName, Alias: managedAlias}, VxlanId: int(*testLogicalBridge.Spec.Vni), Port: 4789, Learning: false, SrcAddr: myip}
 				mockNetlink.EXPECT().LinkByName(mock.Anything, vxlanName).Return(vxlan, nil).Once()
 				mockNetlink.EXPECT().LinkSetDown(mock.Anything, vxlan).Return(nil).Once()
 				vid := uint16(testLogicalBridge.Spec.VlanId)
@@ -392,7 +404,7 @@ func Test_DeleteLogicalBridge(t *testing.T) {
 				myip := make(net.IP, 4)
 				binary.BigEndian.PutUint32(myip, 167772162)
 				vxlanName := fmt.Sprintf("vni%d", *testLogicalBridge.Spec.Vni)
-				vxlan := &netlink.Vxlan{LinkAttrs: netlink.LinkAttrs{Name: vxlanName}, VxlanId: int(*testLogicalBridge.Spec.Vni), Port: 4789, Learning: false, SrcAddr: myip}
+				vxlan := &netlink.Vxlan{LinkAttrs: netlink.LinkAttrs{Name: vxlanName, Alias: managedAlias}, VxlanId: int(*testLogicalBridge.Spec.Vni), Port: 4789, Learning: false, SrcAddr: myip}
 				mockNetlink.EXPECT().LinkByName(mock.Anything, vxlanName).Return(vxlan, nil).Once()
 				mockNetlink.EXPECT().LinkSetDown(mock.Anything, vxlan).Return(nil).Once()
 				vid := uint16(testLogicalBridge.Spec.VlanId)
@@ -410,7 +422,7 @@ func Test_DeleteLogicalBridge(t *testing.T) {
 				myip := make(net.IP, 4)
 				binary.BigEndian.PutUint32(myip, 167772162)
 				vxlanName := fmt.Sprintf("vni%d", *testLogicalBridge.Spec.Vni)
-				vxlan := &netlink.Vxlan{LinkAttrs: netlink.LinkAttrs{Name: vxlanName}, VxlanId: int(*testLogicalBridge.Spec.Vni), Port: 4789, Learning: false, SrcAddr: myip}
+				vxlan := &netlink.Vxlan{LinkAttrs: netlink.LinkAttrs{Name: vxlanName, Alias: managedAlias}, VxlanId: int(*testLogicalBridge.Spec.Vni), Port: 4789, Learning: false, SrcAddr: myip}
 				mockNetlink.EXPECT().LinkByName(mock.Anything, vxlanName).Return(vxlan, nil).Once()
 				mockNetlink.EXPECT().LinkSetDown(mock.Anything, vxlan).Return(nil).Once()
 				vid := uint16(testLogicalBridge.Spec.VlanId)
@@ -724,7 +736,12 @@ func Test_ListLogicalBridges(t *testing.T) {
 			client := pb.NewLogicalBridgeServiceClient(conn)
 
 			opi.Bridges[testLogicalBridgeName] = protoClone(&testLogicalBridgeWithStatus)
-			opi.Pagination["existing-pagination-token"] = 1
+			if err := opi.savePageToken("existing-pagination-token", 1, time.Now().Add(time.Minute)); err != nil {
+				t.Fatal(err)
+			}
+			if len(tt.out) != 0 {
+				mockNetlink.EXPECT().LinkByName(mock.Anything, "vni11").Return(&netlink.Device{LinkAttrs: netlink.LinkAttrs{OperState: netlink.OperUp}}, nil).Once()
+			}
 
 			request := &pb.ListLogicalBridgesRequest{PageSize: tt.size, PageToken: tt.token}
 			response, err := client.ListLogicalBridges(ctx, request)
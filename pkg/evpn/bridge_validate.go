@@ -7,64 +7,68 @@ package evpn
 import (
 	"fmt"
 
-	"go.einride.tech/aip/fieldbehavior"
 	"go.einride.tech/aip/fieldmask"
-	"go.einride.tech/aip/resourceid"
 	"go.einride.tech/aip/resourcename"
 
-	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
-
 	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
 )
 
 func (s *Server) validateCreateLogicalBridgeRequest(in *pb.CreateLogicalBridgeRequest) error {
 	// check required fields
-	if err := fieldbehavior.ValidateRequiredFields(in); err != nil {
+	if err := validateRequiredFields(in); err != nil {
+		return err
+	}
+	// check vlan id and vni are in range
+	if err := badRequest("logical_bridge.spec.vlan_id", s.validateVlanID(in.LogicalBridge.Spec.VlanId)); err != nil {
 		return err
 	}
-	// check vlan id is in range
-	if in.LogicalBridge.Spec.VlanId > 4095 {
-		msg := fmt.Sprintf("VlanId value (%d) have to be between 1 and 4095", in.LogicalBridge.Spec.VlanId)
-		return status.Errorf(codes.InvalidArgument, msg)
+	if err := badRequest("logical_bridge.spec.vni", validateVni(in.LogicalBridge.Spec.Vni)); err != nil {
+		return err
 	}
 	// see https://google.aip.dev/133#user-specified-ids
 	if in.LogicalBridgeId != "" {
-		if err := resourceid.ValidateUserSettable(in.LogicalBridgeId); err != nil {
+		if err := badRequest("logical_bridge_id", validateResourceID(in.LogicalBridgeId, false)); err != nil {
 			return err
 		}
 	}
-	// TODO: check in.LogicalBridge.Spec.Vni validity
 	return nil
 }
 
 func (s *Server) validateDeleteLogicalBridgeRequest(in *pb.DeleteLogicalBridgeRequest) error {
 	// check required fields
-	if err := fieldbehavior.ValidateRequiredFields(in); err != nil {
+	if err := validateRequiredFields(in); err != nil {
 		return err
 	}
 	// Validate that a resource name conforms to the restrictions outlined in AIP-122.
-	return resourcename.Validate(in.Name)
+	return badRequest("name", resourcename.Validate(in.Name))
 }
 
 func (s *Server) validateUpdateLogicalBridgeRequest(in *pb.UpdateLogicalBridgeRequest) error {
 	// check required fields
-	if err := fieldbehavior.ValidateRequiredFields(in); err != nil {
+	if err := validateRequiredFields(in); err != nil {
 		return err
 	}
 	// update_mask = 2
-	if err := fieldmask.Validate(in.UpdateMask, in.LogicalBridge); err != nil {
+	if err := badRequest("update_mask", fieldmask.Validate(in.UpdateMask, in.LogicalBridge)); err != nil {
 		return err
 	}
 	// Validate that a resource name conforms to the restrictions outlined in AIP-122.
-	return resourcename.Validate(in.LogicalBridge.Name)
+	return badRequest("logical_bridge.name", resourcename.Validate(in.LogicalBridge.Name))
 }
 
 func (s *Server) validateGetLogicalBridgeRequest(in *pb.GetLogicalBridgeRequest) error {
 	// check required fields
-	if err := fieldbehavior.ValidateRequiredFields(in); err != nil {
+	if err := validateRequiredFields(in); err != nil {
 		return err
 	}
 	// Validate that a resource name conforms to the restrictions outlined in AIP-122.
-	return resourcename.Validate(in.Name)
+	return badRequest("name", resourcename.Validate(in.Name))
+}
+
+// validateLogicalBridgeQuota checks a new LogicalBridge fits in the LogicalBridge and Vni limits
+func (s *Server) validateLogicalBridgeQuota(obj *pb.LogicalBridge) error {
+	if limit, ok := s.Quotas["LogicalBridge"]; ok && len(s.Bridges) >= limit {
+		return quotaExceeded("LogicalBridge", fmt.Sprintf("max number of LogicalBridges (%d) reached", limit))
+	}
+	return s.validateVniQuota(obj.Spec.Vni)
 }
@@ -0,0 +1,147 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"strings"
+
+	"cloud.google.com/go/longrunning/autogen/longrunningpb"
+
+	pe "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+)
+
+// Version and GitCommit identify the binary, set at build time with
+// -ldflags "-X github.com/opiproject/opi-evpn-bridge/pkg/evpn.Version=v1.2.3", the commit
+// defaulting to the one Go records when building from a git checkout
+var (
+	Version   = "dev"
+	GitCommit = ""
+)
+
+// opiAPIModule is the module of the messages and services the server implements
+const opiAPIModule = "github.com/opiproject/opi-api"
+
+// ipv6Proc lists the IPv6 addresses of the interfaces, missing when the kernel has no IPv6
+var ipv6Proc = "/proc/net/if_inet6"
+
+// offloadDataplanes are the backends forwarding outside of the kernel, in the hardware or XDP
+var offloadDataplanes = map[string]bool{
+	"ipu":       true,
+	"p4rt":      true,
+	"bluefield": true,
+	"octeon":    true,
+	"ebpf":      true,
+}
+
+// servedServices are the gRPC services implemented by the server
+var servedServices = []string{
+	pe.LogicalBridgeService_ServiceDesc.ServiceName,
+	pe.BridgePortService_ServiceDesc.ServiceName,
+	pe.VrfService_ServiceDesc.ServiceName,
+	pe.SviService_ServiceDesc.ServiceName,
+	ListStreamServiceDesc.ServiceName,
+	string(longrunningpb.File_google_longrunning_operations_proto.Services().ByName("Operations").FullName()),
+}
+
+// VersionInfo identifies the binary of the server
+// TODO: move to opi-api once the message is agreed upon
+type VersionInfo struct {
+	// Version is the release of the binary, dev when built without one
+	Version string `json:"version"`
+	// GitCommit is the commit the binary was built from, empty when unknown
+	GitCommit string `json:"gitCommit"`
+	// GoVersion is the Go release the binary was built with
+	GoVersion string `json:"goVersion"`
+	// OpiAPI is the version of the opi-api module the messages come from
+	OpiAPI string `json:"opiApi"`
+}
+
+// Capabilities are what the server supports, so controllers of heterogeneous fleets adapt
+// their requests to each gateway
+// TODO: move to opi-api once the message is agreed upon
+type Capabilities struct {
+	VersionInfo
+	// APIVersions are the versioned packages of the gRPC services served
+	APIVersions []string `json:"apiVersions"`
+	// Dataplane is the backend the objects are programmed into, see --dataplane
+	Dataplane string `json:"dataplane"`
+	// Features maps the optional features to whether they are available: ipv6, offload,
+	// qos, srv6, liveRead, statusMonitor and vtepProbes
+	Features map[string]bool `json:"features"`
+}
+
+// versionInfo returns the version of the binary, with the commit and the module versions
+// recorded by Go when they were not set at build time
+func versionInfo() VersionInfo {
+	info := VersionInfo{Version: Version, GitCommit: GitCommit, GoVersion: runtime.Version()}
+	build, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+	for _, setting := range build.Settings {
+		if setting.Key == "vcs.revision" && info.GitCommit == "" {
+			info.GitCommit = setting.Value
+		}
+	}
+	for _, dep := range build.Deps {
+		if dep.Path == opiAPIModule {
+			info.OpiAPI = dep.Version
+		}
+	}
+	return info
+}
+
+// apiVersions returns the packages of the served services, e.g. opi_api.network.evpn_gw.v1alpha1
+func apiVersions() []string {
+	packages := map[string]bool{}
+	for _, service := range servedServices {
+		packages[service[:strings.LastIndex(service, ".")]] = true
+	}
+	result := make([]string, 0, len(packages))
+	for name := range packages {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// GetVersionRequest is the request to get the version of the server
+// TODO: move to opi-api once the message is agreed upon
+type GetVersionRequest struct{}
+
+// GetVersion returns the version and the commit of the binary
+func (s *Server) GetVersion(_ context.Context, _ *GetVersionRequest) (*VersionInfo, error) {
+	info := versionInfo()
+	return &info, nil
+}
+
+// GetCapabilitiesRequest is the request to get what the server supports
+// TODO: move to opi-api once the message is agreed upon
+type GetCapabilitiesRequest struct{}
+
+// GetCapabilities returns the version of the server, the API versions it serves, its dataplane
+// and its optional features. The qos feature is reserved, the server not having QoS policies
+func (s *Server) GetCapabilities(_ context.Context, _ *GetCapabilitiesRequest) (*Capabilities, error) {
+	_, err := os.Stat(ipv6Proc)
+	return &Capabilities{
+		VersionInfo: versionInfo(),
+		APIVersions: apiVersions(),
+		Dataplane:   s.DataplaneName,
+		Features: map[string]bool{
+			"ipv6":          err == nil,
+			"offload":       offloadDataplanes[s.DataplaneName] || s.HwOffload,
+			"qos":           false,
+			"srv6":          s.Srv6.Prefix != "",
+			"liveRead":      s.LiveRead,
+			"statusMonitor": s.monitor != nil,
+			"vtepProbes":    s.vtepProber != nil,
+		},
+	}, nil
+}
@@ -0,0 +1,73 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/philippgille/gokv/gomap"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils/mocks"
+)
+
+func Test_GetCapabilities(t *testing.T) {
+	defer func(path string) { ipv6Proc = path }(ipv6Proc)
+	tests := map[string]struct {
+		dataplane string
+		hwOffload bool
+		srv6      string
+		ipv6      bool
+		features  map[string]bool
+	}{
+		"linux dataplane": {
+			dataplane: "linux",
+			ipv6:      true,
+			features:  map[string]bool{"ipv6": true},
+		},
+		"linux dataplane with switchdev offload": {
+			dataplane: "linux",
+			hwOffload: true,
+			srv6:      "fc00:0:1::/48",
+			features:  map[string]bool{"offload": true, "srv6": true},
+		},
+		"offloading dataplane": {
+			dataplane: "bluefield",
+			ipv6:      true,
+			features:  map[string]bool{"ipv6": true, "offload": true},
+		},
+	}
+	for testName, tt := range tests {
+		t.Run(testName, func(t *testing.T) {
+			ipv6Proc = filepath.Join(t.TempDir(), "if_inet6")
+			if tt.ipv6 {
+				ipv6Proc = t.TempDir()
+			}
+			opi := NewServerWithArgs(mocks.NewNetlink(t), mocks.NewFrr(t), gomap.NewStore(gomap.DefaultOptions))
+			opi.DataplaneName = tt.dataplane
+			opi.HwOffload = tt.hwOffload
+			opi.Srv6.Prefix = tt.srv6
+			response, err := opi.GetCapabilities(context.Background(), &GetCapabilitiesRequest{})
+			if err != nil {
+				t.Fatal("error: expected", nil, "received", err)
+			}
+			if response.Dataplane != tt.dataplane || response.Version != Version || response.GoVersion == "" {
+				t.Error("capabilities: unexpected", response)
+			}
+			expected := []string{"google.longrunning", "opi_api.network.evpn_gw.v1alpha1", "opi_evpn_bridge.v1alpha1"}
+			if !reflect.DeepEqual(response.APIVersions, expected) {
+				t.Error("API versions: expected", expected, "received", response.APIVersions)
+			}
+			for _, feature := range []string{"ipv6", "offload", "qos", "srv6", "liveRead", "statusMonitor", "vtepProbes"} {
+				enabled, ok := response.Features[feature]
+				if !ok || enabled != tt.features[feature] {
+					t.Error("feature", feature, "expected", tt.features[feature], "received", enabled, ok)
+				}
+			}
+		})
+	}
+}
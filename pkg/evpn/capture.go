@@ -0,0 +1,140 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// captureCountDefault and captureCountMax bound the packets of a capture
+	captureCountDefault = 100
+	captureCountMax     = 10000
+	// captureDurationDefault and captureDurationMax bound how long a capture runs
+	captureDurationDefault = 10 * time.Second
+	captureDurationMax     = 5 * time.Minute
+	// captureMaxRunning bounds the captures running at the same time, each of them
+	// copying the packets of its device to user space
+	captureMaxRunning = 4
+	// captureFilterMaxLength bounds the BPF filter expression
+	captureFilterMaxLength = 1024
+)
+
+// CapturePacketsRequest is the request to capture the packets of a BridgePort, the vlan
+// device of a Svi or the vni device of a LogicalBridge
+// TODO: move to opi-api once the message is agreed upon
+type CapturePacketsRequest struct {
+	// BridgePort is the name of the BridgePort to capture on, exclusive with the others
+	BridgePort string `json:"bridgePort"`
+	// Svi is the name of the Svi to capture on, exclusive with the others
+	Svi string `json:"svi"`
+	// LogicalBridge is the name of the LogicalBridge whose vni device to capture on,
+	// exclusive with the others
+	LogicalBridge string `json:"logicalBridge"`
+	// Filter is a BPF filter expression in the syntax of tcpdump, e.g. "icmp or arp"
+	Filter string `json:"filter"`
+	// Count is the number of packets after which the capture ends, 100 when 0
+	Count int32 `json:"count"`
+	// DurationSeconds is how long the capture runs at most, 10 seconds when 0
+	DurationSeconds int32 `json:"durationSeconds"`
+}
+
+// captureDevice returns the kernel device of the object the capture is requested on
+func (s *Server) captureDevice(ctx context.Context, in *CapturePacketsRequest) (string, error) {
+	set := 0
+	for _, name := range []string{in.BridgePort, in.Svi, in.LogicalBridge} {
+		if name != "" {
+			set++
+		}
+	}
+	switch {
+	case set == 0:
+		msg := "one of bridge_port, svi and logical_bridge is required"
+		return "", badRequest("bridge_port", status.Error(codes.InvalidArgument, msg))
+	case set > 1:
+		msg := "only one of bridge_port, svi and logical_bridge can be set"
+		return "", badRequest("bridge_port", status.Error(codes.InvalidArgument, msg))
+	case in.BridgePort != "":
+		if _, ok := s.Ports[in.BridgePort]; !ok || !inTenant(ctx, in.BridgePort) {
+			err := status.Errorf(codes.NotFound, "unable to find key %s", in.BridgePort)
+			return "", err
+		}
+		return s.portKernelName(in.BridgePort), nil
+	case in.Svi != "":
+		return s.diagnosticDevice(ctx, "", in.Svi)
+	default:
+		obj, ok := s.Bridges[in.LogicalBridge]
+		if !ok || !inTenant(ctx, in.LogicalBridge) {
+			err := status.Errorf(codes.NotFound, "unable to find key %s", in.LogicalBridge)
+			return "", err
+		}
+		if obj.Spec.Vni == nil {
+			err := status.Errorf(codes.FailedPrecondition, "LogicalBridge %s has no vni device", in.LogicalBridge)
+			return "", err
+		}
+		return fmt.Sprintf("vni%d", *obj.Spec.Vni), nil
+	}
+}
+
+// CapturePackets captures the packets of a BridgePort, Svi or LogicalBridge matching the
+// filter, sending them in the pcap format as soon as captured, until count packets are
+// captured or the duration elapsed. The tenants only capture on their own objects
+func (s *Server) CapturePackets(ctx context.Context, in *CapturePacketsRequest, send func(data []byte)) error {
+	dev, err := s.captureDevice(ctx, in)
+	if err != nil {
+		return err
+	}
+	if len(in.Filter) > captureFilterMaxLength {
+		msg := fmt.Sprintf("filter have to be at most %d characters", captureFilterMaxLength)
+		return badRequest("filter", status.Error(codes.InvalidArgument, msg))
+	}
+	// tcpdump runs as root, a filter is never passed as one of its options
+	if strings.HasPrefix(strings.TrimSpace(in.Filter), "-") {
+		msg := fmt.Sprintf("filter (%v) cannot start with -", in.Filter)
+		return badRequest("filter", status.Error(codes.InvalidArgument, msg))
+	}
+	count := int(in.Count)
+	if count == 0 {
+		count = captureCountDefault
+	}
+	if count < 1 || count > captureCountMax {
+		msg := fmt.Sprintf("count (%v) have to be between 1 and %d", in.Count, captureCountMax)
+		return badRequest("count", status.Error(codes.InvalidArgument, msg))
+	}
+	duration := time.Duration(in.DurationSeconds) * time.Second
+	if duration == 0 {
+		duration = captureDurationDefault
+	}
+	if duration < time.Second || duration > captureDurationMax {
+		msg := fmt.Sprintf("duration_seconds (%v) have to be between 1 and %d", in.DurationSeconds, int(captureDurationMax.Seconds()))
+		return badRequest("duration_seconds", status.Error(codes.InvalidArgument, msg))
+	}
+	if s.captures.Add(1) > captureMaxRunning {
+		s.captures.Add(-1)
+		err := status.Errorf(codes.ResourceExhausted, "%d packet captures are already running", captureMaxRunning)
+		return err
+	}
+	defer s.captures.Add(-1)
+
+	captureCtx, cancel := context.WithTimeout(ctx, duration)
+	defer cancel()
+	err = s.diagnostics.Capture(captureCtx, dev, in.Filter, count, send)
+	// the capture ending with its duration is not a failure
+	if err != nil && errors.Is(captureCtx.Err(), context.DeadlineExceeded) && ctx.Err() == nil {
+		return nil
+	}
+	if err != nil {
+		err = status.Errorf(codes.Unavailable, "unable to capture on %s: %v", dev, err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/philippgille/gokv/gomap"
+	"github.com/stretchr/testify/mock"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils/mocks"
+)
+
+// testPcapHeader is the global header of a pcap file, microsecond timestamps and ethernet frames
+var testPcapHeader = []byte{0xd4, 0xc3, 0xb2, 0xa1, 0x02, 0x00, 0x04, 0x00, 0, 0, 0, 0, 0, 0, 0, 0, 0x00, 0x00, 0x04, 0x00, 0x01, 0, 0, 0}
+
+func Test_CapturePackets(t *testing.T) {
+	tests := map[string]struct {
+		in         *CapturePacketsRequest
+		ctx        context.Context
+		dev        string
+		count      int
+		captureErr error
+		errCode    codes.Code
+		errMsg     string
+	}{
+		"capture on a BridgePort": {
+			in:    &CapturePacketsRequest{BridgePort: testBridgePortName, Filter: "icmp or arp"},
+			dev:   testBridgePortID,
+			count: captureCountDefault,
+		},
+		"capture on a LogicalBridge": {
+			in:    &CapturePacketsRequest{LogicalBridge: testLogicalBridgeName, Count: 10},
+			dev:   "vni11",
+			count: 10,
+		},
+		"capture on a Svi": {
+			in:    &CapturePacketsRequest{Svi: testSviName, Count: 1},
+			dev:   "vlan22",
+			count: 1,
+		},
+		"illegal filter": {
+			in:         &CapturePacketsRequest{BridgePort: testBridgePortName, Filter: "icmp or"},
+			dev:        testBridgePortID,
+			count:      captureCountDefault,
+			captureErr: errors.New("exit status 1: tcpdump: syntax error"),
+			errCode:    codes.Unavailable,
+			errMsg:     "unable to capture on opi-port8: exit status 1: tcpdump: syntax error",
+		},
+		"filter as an option": {
+			in:      &CapturePacketsRequest{BridgePort: testBridgePortName, Filter: "-w/etc/cron.d/x"},
+			errCode: codes.InvalidArgument,
+			errMsg:  "filter (-w/etc/cron.d/x) cannot start with -",
+		},
+		"no object": {
+			in:      &CapturePacketsRequest{},
+			errCode: codes.InvalidArgument,
+			errMsg:  "one of bridge_port, svi and logical_bridge is required",
+		},
+		"several objects": {
+			in:      &CapturePacketsRequest{BridgePort: testBridgePortName, LogicalBridge: testLogicalBridgeName},
+			errCode: codes.InvalidArgument,
+			errMsg:  "only one of bridge_port, svi and logical_bridge can be set",
+		},
+		"unknown BridgePort": {
+			in:      &CapturePacketsRequest{BridgePort: "unknown-port"},
+			errCode: codes.NotFound,
+			errMsg:  "unable to find key unknown-port",
+		},
+		"BridgePort of another tenant": {
+			in:      &CapturePacketsRequest{BridgePort: testBridgePortName},
+			ctx:     tenantContext("acme"),
+			errCode: codes.NotFound,
+			errMsg:  "unable to find key " + testBridgePortName,
+		},
+		"illegal count": {
+			in:      &CapturePacketsRequest{BridgePort: testBridgePortName, Count: -1},
+			errCode: codes.InvalidArgument,
+			errMsg:  "count (-1) have to be between 1 and 10000",
+		},
+		"illegal duration": {
+			in:      &CapturePacketsRequest{BridgePort: testBridgePortName, DurationSeconds: 3600},
+			errCode: codes.InvalidArgument,
+			errMsg:  "duration_seconds (3600) have to be between 1 and 300",
+		},
+	}
+	for testName, tt := range tests {
+		t.Run(testName, func(t *testing.T) {
+			mockDiagnostics := mocks.NewDiagnostics(t)
+			opi := NewServerWithArgs(mocks.NewNetlink(t), mocks.NewFrr(t), gomap.NewStore(gomap.DefaultOptions))
+			opi.diagnostics = mockDiagnostics
+			opi.Bridges[testLogicalBridgeName] = protoClone(&testLogicalBridge)
+			opi.Ports[testBridgePortName] = &pb.BridgePort{Name: testBridgePortName, Spec: testBridgePort.Spec}
+			opi.Svis[testSviName] = protoClone(&testSvi)
+			if tt.dev != "" {
+				mockDiagnostics.EXPECT().Capture(mock.Anything, tt.dev, tt.in.Filter, tt.count, mock.Anything).
+					RunAndReturn(func(_ context.Context, _ string, _ string, _ int, output func([]byte)) error {
+						if tt.captureErr != nil {
+							return tt.captureErr
+						}
+						output(testPcapHeader)
+						return nil
+					}).Once()
+			}
+			ctx := tt.ctx
+			if ctx == nil {
+				ctx = context.Background()
+			}
+			var data []byte
+			err := opi.CapturePackets(ctx, tt.in, func(captured []byte) {
+				data = append(data, captured...)
+			})
+			if er, ok := status.FromError(err); ok {
+				if er.Code() != tt.errCode {
+					t.Error("error code: expected", tt.errCode, "received", er.Code())
+				}
+				if er.Message() != tt.errMsg {
+					t.Error("error message: expected", tt.errMsg, "received", er.Message())
+				}
+			} else {
+				t.Error("expected grpc error status")
+			}
+			if err == nil && !bytes.Equal(data, testPcapHeader) {
+				t.Error("data: expected", testPcapHeader, "received", data)
+			}
+		})
+	}
+}
+
+func Test_CapturePacketsLimits(t *testing.T) {
+	mockDiagnostics := mocks.NewDiagnostics(t)
+	opi := NewServerWithArgs(mocks.NewNetlink(t), mocks.NewFrr(t), gomap.NewStore(gomap.DefaultOptions))
+	opi.diagnostics = mockDiagnostics
+	opi.Ports[testBridgePortName] = &pb.BridgePort{Name: testBridgePortName, Spec: testBridgePort.Spec}
+	in := &CapturePacketsRequest{BridgePort: testBridgePortName, DurationSeconds: 1}
+
+	// the capture ending with its duration is not a failure
+	mockDiagnostics.EXPECT().Capture(mock.Anything, testBridgePortID, "", captureCountDefault, mock.Anything).
+		RunAndReturn(func(ctx context.Context, _ string, _ string, _ int, _ func([]byte)) error {
+			<-ctx.Done()
+			return errors.New("signal: killed")
+		}).Once()
+	if err := opi.CapturePackets(context.Background(), in, func([]byte) {}); err != nil {
+		t.Error("error: expected", nil, "received", err)
+	}
+
+	// the running captures are bounded
+	opi.captures.Store(captureMaxRunning)
+	err := opi.CapturePackets(context.Background(), in, func([]byte) {})
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Error("error: expected", codes.ResourceExhausted, "received", err)
+	}
+	if running := opi.captures.Load(); running != captureMaxRunning {
+		t.Error("captures: expected", captureMaxRunning, "received", running)
+	}
+}
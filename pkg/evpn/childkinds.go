@@ -0,0 +1,279 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"encoding/json"
+	"path"
+)
+
+// childCalls are the calls driving one kind of child resources for the exported documents,
+// Resync and GetDrift, the child resources are structs encoded with utils.MarshalJSON
+type childCalls[T any] struct {
+	kind string
+	// document returns the objects of the kind in the exported documents
+	document func(snapshot *configSnapshot) *[]json.RawMessage
+	stored   map[string]*T
+	name     func(obj *T) string
+	spec     func(obj *T) interface{}
+	create   func(ctx context.Context, obj *T) error
+	// update is nil for the kinds without an update call, they are deleted and created again
+	update func(ctx context.Context, obj *T) error
+	del    func(ctx context.Context, name string) error
+	// resync programs the stored object again
+	resync func(ctx context.Context, obj *T) error
+	// drift adds the discrepancies of the stored object, nil for the kinds programmed into
+	// state the drift is not read from
+	drift func(ctx context.Context, d *objectDrift, obj *T)
+}
+
+// childKind is the childCalls of a kind, whatever its type
+type childKind interface {
+	documentObjects(snapshot *configSnapshot) *[]json.RawMessage
+	exportObjects() ([]json.RawMessage, error)
+	importObjects(ctx context.Context, raw []json.RawMessage) ([]string, error)
+	planObjects(ctx context.Context, raw []json.RawMessage) ([]plannedChange, []plannedChange, error)
+	resyncObjects(ctx context.Context, report func(name string, err error))
+	driftObjects(ctx context.Context, report func(name string, check func(d *objectDrift)))
+}
+
+func (c childCalls[T]) documentObjects(snapshot *configSnapshot) *[]json.RawMessage {
+	return c.document(snapshot)
+}
+
+// childKinds returns the kinds of child resources the Vrfs use, programmed before them, and
+// the kinds belonging to the Vrfs and LogicalBridges, programmed after them, in the order of Replay
+func (s *Server) childKinds() (used []childKind, owned []childKind) {
+	used = []childKind{
+		childCalls[UnderlayInterface]{
+			kind:     "UnderlayInterface",
+			document: func(snapshot *configSnapshot) *[]json.RawMessage { return &snapshot.UnderlayInterfaces },
+			stored:   s.UnderlayInterfaces,
+			name:     func(obj *UnderlayInterface) string { return obj.Name },
+			spec:     func(obj *UnderlayInterface) interface{} { return obj.Spec },
+			create: func(ctx context.Context, obj *UnderlayInterface) error {
+				in := &CreateUnderlayInterfaceRequest{UnderlayInterfaceID: path.Base(obj.Name), UnderlayInterface: &UnderlayInterface{Spec: obj.Spec}}
+				_, err := s.CreateUnderlayInterface(ctx, in)
+				return err
+			},
+			del: func(ctx context.Context, name string) error {
+				_, err := s.DeleteUnderlayInterface(ctx, &DeleteUnderlayInterfaceRequest{Name: name})
+				return err
+			},
+			resync: s.resyncUnderlayInterface,
+			drift:  s.underlayInterfaceDrift,
+		},
+		childCalls[TunnelSecurity]{
+			kind:     "TunnelSecurity",
+			document: func(snapshot *configSnapshot) *[]json.RawMessage { return &snapshot.TunnelSecurities },
+			stored:   s.TunnelSecurities,
+			name:     func(obj *TunnelSecurity) string { return obj.Name },
+			spec:     func(obj *TunnelSecurity) interface{} { return obj.Spec },
+			create: func(ctx context.Context, obj *TunnelSecurity) error {
+				in := &CreateTunnelSecurityRequest{TunnelSecurityID: path.Base(obj.Name), TunnelSecurity: &TunnelSecurity{Spec: obj.Spec}}
+				_, err := s.CreateTunnelSecurity(ctx, in)
+				return err
+			},
+			del: func(ctx context.Context, name string) error {
+				_, err := s.DeleteTunnelSecurity(ctx, &DeleteTunnelSecurityRequest{Name: name})
+				return err
+			},
+			resync: s.resyncTunnelSecurity,
+		},
+		childCalls[PrefixList]{
+			kind:     "PrefixList",
+			document: func(snapshot *configSnapshot) *[]json.RawMessage { return &snapshot.PrefixLists },
+			stored:   s.PrefixLists,
+			name:     func(obj *PrefixList) string { return obj.Name },
+			spec:     func(obj *PrefixList) interface{} { return obj.Spec },
+			create: func(ctx context.Context, obj *PrefixList) error {
+				in := &CreatePrefixListRequest{PrefixListID: path.Base(obj.Name), PrefixList: &PrefixList{Spec: obj.Spec}}
+				_, err := s.CreatePrefixList(ctx, in)
+				return err
+			},
+			update: func(ctx context.Context, obj *PrefixList) error {
+				_, err := s.UpdatePrefixList(ctx, &UpdatePrefixListRequest{PrefixList: obj})
+				return err
+			},
+			del: func(ctx context.Context, name string) error {
+				_, err := s.DeletePrefixList(ctx, &DeletePrefixListRequest{Name: name})
+				return err
+			},
+			resync: s.dataplane.CreatePrefixList,
+			drift:  s.prefixListDrift,
+		},
+		childCalls[RouteMap]{
+			kind:     "RouteMap",
+			document: func(snapshot *configSnapshot) *[]json.RawMessage { return &snapshot.RouteMaps },
+			stored:   s.RouteMaps,
+			name:     func(obj *RouteMap) string { return obj.Name },
+			spec:     func(obj *RouteMap) interface{} { return obj.Spec },
+			create: func(ctx context.Context, obj *RouteMap) error {
+				in := &CreateRouteMapRequest{RouteMapID: path.Base(obj.Name), RouteMap: &RouteMap{Spec: obj.Spec}}
+				_, err := s.CreateRouteMap(ctx, in)
+				return err
+			},
+			update: func(ctx context.Context, obj *RouteMap) error {
+				_, err := s.UpdateRouteMap(ctx, &UpdateRouteMapRequest{RouteMap: obj})
+				return err
+			},
+			del: func(ctx context.Context, name string) error {
+				_, err := s.DeleteRouteMap(ctx, &DeleteRouteMapRequest{Name: name})
+				return err
+			},
+			resync: s.dataplane.CreateRouteMap,
+			drift:  s.routeMapDrift,
+		},
+	}
+	owned = []childKind{
+		childCalls[Route]{
+			kind:     "Route",
+			document: func(snapshot *configSnapshot) *[]json.RawMessage { return &snapshot.Routes },
+			stored:   s.Routes,
+			name:     func(obj *Route) string { return obj.Name },
+			spec:     func(obj *Route) interface{} { return obj.Spec },
+			create: func(ctx context.Context, obj *Route) error {
+				in := &CreateRouteRequest{Parent: routeParent(obj.Name), RouteID: path.Base(obj.Name), Route: &Route{Spec: obj.Spec}}
+				_, err := s.CreateRoute(ctx, in)
+				return err
+			},
+			del: func(ctx context.Context, name string) error {
+				_, err := s.DeleteRoute(ctx, &DeleteRouteRequest{Name: name})
+				return err
+			},
+			resync: s.resyncRoute,
+			drift:  s.routeDrift,
+		},
+		childCalls[VrfLiteHandoff]{
+			kind:     "VrfLiteHandoff",
+			document: func(snapshot *configSnapshot) *[]json.RawMessage { return &snapshot.VrfLiteHandoffs },
+			stored:   s.Handoffs,
+			name:     func(obj *VrfLiteHandoff) string { return obj.Name },
+			spec:     func(obj *VrfLiteHandoff) interface{} { return obj.Spec },
+			create: func(ctx context.Context, obj *VrfLiteHandoff) error {
+				in := &CreateVrfLiteHandoffRequest{VrfLiteHandoffID: path.Base(obj.Name), VrfLiteHandoff: &VrfLiteHandoff{Spec: obj.Spec}}
+				_, err := s.CreateVrfLiteHandoff(ctx, in)
+				return err
+			},
+			del: func(ctx context.Context, name string) error {
+				_, err := s.DeleteVrfLiteHandoff(ctx, &DeleteVrfLiteHandoffRequest{Name: name})
+				return err
+			},
+			resync: s.resyncVrfLiteHandoff,
+			drift:  s.vrfLiteHandoffDrift,
+		},
+		childCalls[RouteLeak]{
+			kind:     "RouteLeak",
+			document: func(snapshot *configSnapshot) *[]json.RawMessage { return &snapshot.RouteLeaks },
+			stored:   s.RouteLeaks,
+			name:     func(obj *RouteLeak) string { return obj.Name },
+			spec:     func(obj *RouteLeak) interface{} { return obj.Spec },
+			create: func(ctx context.Context, obj *RouteLeak) error {
+				in := &CreateRouteLeakRequest{RouteLeakID: path.Base(obj.Name), RouteLeak: &RouteLeak{Spec: obj.Spec}}
+				_, err := s.CreateRouteLeak(ctx, in)
+				return err
+			},
+			del: func(ctx context.Context, name string) error {
+				_, err := s.DeleteRouteLeak(ctx, &DeleteRouteLeakRequest{Name: name})
+				return err
+			},
+			resync: s.resyncRouteLeak,
+			drift:  s.routeLeakDrift,
+		},
+		childCalls[BgpPeer]{
+			kind:     "BgpPeer",
+			document: func(snapshot *configSnapshot) *[]json.RawMessage { return &snapshot.BgpPeers },
+			stored:   s.BgpPeers,
+			name:     func(obj *BgpPeer) string { return obj.Name },
+			spec:     func(obj *BgpPeer) interface{} { return obj.Spec },
+			create: func(ctx context.Context, obj *BgpPeer) error {
+				in := &CreateBgpPeerRequest{BgpPeerID: path.Base(obj.Name), BgpPeer: &BgpPeer{Spec: obj.Spec}}
+				_, err := s.CreateBgpPeer(ctx, in)
+				return err
+			},
+			del: func(ctx context.Context, name string) error {
+				_, err := s.DeleteBgpPeer(ctx, &DeleteBgpPeerRequest{Name: name})
+				return err
+			},
+			resync: s.dataplane.CreateBgpPeer,
+			drift:  s.bgpPeerDrift,
+		},
+		childCalls[StaticFdbEntry]{
+			kind:     "StaticFdbEntry",
+			document: func(snapshot *configSnapshot) *[]json.RawMessage { return &snapshot.StaticFdbEntries },
+			stored:   s.FdbEntries,
+			name:     func(obj *StaticFdbEntry) string { return obj.Name },
+			spec:     func(obj *StaticFdbEntry) interface{} { return obj.Spec },
+			create: func(ctx context.Context, obj *StaticFdbEntry) error {
+				in := &CreateStaticFdbEntryRequest{Parent: staticFdbEntryParent(obj.Name), StaticFdbEntryID: path.Base(obj.Name), StaticFdbEntry: &StaticFdbEntry{Spec: obj.Spec}}
+				_, err := s.CreateStaticFdbEntry(ctx, in)
+				return err
+			},
+			del: func(ctx context.Context, name string) error {
+				_, err := s.DeleteStaticFdbEntry(ctx, &DeleteStaticFdbEntryRequest{Name: name})
+				return err
+			},
+			resync: s.resyncStaticFdbEntry,
+			drift:  s.staticFdbEntryDrift,
+		},
+		childCalls[SecurityPolicy]{
+			kind:     "SecurityPolicy",
+			document: func(snapshot *configSnapshot) *[]json.RawMessage { return &snapshot.SecurityPolicies },
+			stored:   s.Policies,
+			name:     func(obj *SecurityPolicy) string { return obj.Name },
+			spec:     func(obj *SecurityPolicy) interface{} { return obj.Spec },
+			create: func(ctx context.Context, obj *SecurityPolicy) error {
+				in := &CreateSecurityPolicyRequest{Parent: securityPolicyParent(obj.Name), SecurityPolicyID: path.Base(obj.Name), SecurityPolicy: &SecurityPolicy{Spec: obj.Spec}}
+				_, err := s.CreateSecurityPolicy(ctx, in)
+				return err
+			},
+			update: func(ctx context.Context, obj *SecurityPolicy) error {
+				_, err := s.UpdateSecurityPolicy(ctx, &UpdateSecurityPolicyRequest{SecurityPolicy: obj})
+				return err
+			},
+			del: func(ctx context.Context, name string) error {
+				_, err := s.DeleteSecurityPolicy(ctx, &DeleteSecurityPolicyRequest{Name: name})
+				return err
+			},
+			resync: s.resyncSecurityPolicy,
+		},
+		childCalls[NatRule]{
+			kind:     "NatRule",
+			document: func(snapshot *configSnapshot) *[]json.RawMessage { return &snapshot.NatRules },
+			stored:   s.NatRules,
+			name:     func(obj *NatRule) string { return obj.Name },
+			spec:     func(obj *NatRule) interface{} { return obj.Spec },
+			create: func(ctx context.Context, obj *NatRule) error {
+				in := &CreateNatRuleRequest{Parent: natRuleParent(obj.Name), NatRuleID: path.Base(obj.Name), NatRule: &NatRule{Spec: obj.Spec}}
+				_, err := s.CreateNatRule(ctx, in)
+				return err
+			},
+			del: func(ctx context.Context, name string) error {
+				_, err := s.DeleteNatRule(ctx, &DeleteNatRuleRequest{Name: name})
+				return err
+			},
+			resync: s.resyncNatRule,
+		},
+		childCalls[PbrRule]{
+			kind:     "PbrRule",
+			document: func(snapshot *configSnapshot) *[]json.RawMessage { return &snapshot.PbrRules },
+			stored:   s.PbrRules,
+			name:     func(obj *PbrRule) string { return obj.Name },
+			spec:     func(obj *PbrRule) interface{} { return obj.Spec },
+			create: func(ctx context.Context, obj *PbrRule) error {
+				in := &CreatePbrRuleRequest{Parent: pbrRuleParent(obj.Name), PbrRuleID: path.Base(obj.Name), PbrRule: &PbrRule{Spec: obj.Spec}}
+				_, err := s.CreatePbrRule(ctx, in)
+				return err
+			},
+			del: func(ctx context.Context, name string) error {
+				_, err := s.DeletePbrRule(ctx, &DeletePbrRuleRequest{Name: name})
+				return err
+			},
+			resync: s.resyncPbrRule,
+		},
+	}
+	return used, owned
+}
@@ -0,0 +1,178 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ConditionType names one aspect of the state of an object, see
+// https://github.com/kubernetes/community/blob/master/contributors/devel/sig-architecture/api-conventions.md#typical-status-properties
+type ConditionType string
+
+const (
+	// ConditionNetlinkProgrammed is true when the kernel devices of the object were programmed
+	ConditionNetlinkProgrammed ConditionType = "NetlinkProgrammed"
+	// ConditionFrrProgrammed is true when the FRR configuration of the object was applied
+	ConditionFrrProgrammed ConditionType = "FrrProgrammed"
+	// ConditionLinkUp is true when all the kernel devices of the object exist and are up
+	ConditionLinkUp ConditionType = "LinkUp"
+	// ConditionHwOffloaded is true when the forwarding of the object is offloaded to the
+	// switchdev driver of its port, only set with --hw_offload
+	ConditionHwOffloaded ConditionType = "HwOffloaded"
+	// ConditionTunnelsReachable is true when all the remote VTEPs of a LogicalBridge answer
+	// the probes, only set with --vtep_probe_interval
+	ConditionTunnelsReachable ConditionType = "TunnelsReachable"
+	// ConditionDegraded is true when any of the other conditions is false
+	ConditionDegraded ConditionType = "Degraded"
+)
+
+// ConditionStatus is True, False or Unknown
+type ConditionStatus string
+
+const (
+	// ConditionTrue means the condition holds
+	ConditionTrue ConditionStatus = "True"
+	// ConditionFalse means the condition does not hold
+	ConditionFalse ConditionStatus = "False"
+)
+
+// conditionReasons are the reasons of the conditions holding, the failed ones have
+// the code of their error as reason
+var conditionReasons = map[ConditionType]string{
+	ConditionNetlinkProgrammed: "Programmed",
+	ConditionFrrProgrammed:     "Programmed",
+	ConditionLinkUp:            "Up",
+	ConditionHwOffloaded:       "Offloaded",
+	ConditionTunnelsReachable:  "Reachable",
+	ConditionDegraded:          "Degraded",
+}
+
+// Condition is the latest observation of one aspect of the state of an object
+// TODO: move to the status of the objects in opi-api once the message is agreed upon
+type Condition struct {
+	Type    ConditionType   `json:"type"`
+	Status  ConditionStatus `json:"status"`
+	Reason  string          `json:"reason,omitempty"`
+	Message string          `json:"message,omitempty"`
+	// LastTransitionTime is the last time the status changed
+	LastTransitionTime time.Time `json:"lastTransitionTime"`
+}
+
+// conditionSet keeps the conditions of every object, set by the create and update paths,
+// the oper status checks and the status monitor
+type conditionSet struct {
+	mu     sync.Mutex
+	byName map[string][]Condition
+	now    func() time.Time
+}
+
+func newConditionSet() *conditionSet {
+	return &conditionSet{byName: map[string][]Condition{}, now: time.Now}
+}
+
+// set records the outcome of one condition of the object, false when err is not nil,
+// and derives its Degraded condition from the others
+func (c *conditionSet) set(name string, conditionType ConditionType, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	condition := Condition{Type: conditionType, Status: ConditionTrue, Reason: conditionReasons[conditionType]}
+	if err != nil {
+		condition.Status = ConditionFalse
+		condition.Reason = status.Code(err).String()
+		condition.Message = status.Convert(err).Message()
+	}
+	conditions := c.update(c.byName[name], condition)
+	// the object is degraded as soon as one layer failed
+	degraded := Condition{Type: ConditionDegraded, Status: ConditionFalse}
+	var failed []string
+	for _, other := range conditions {
+		if other.Type != ConditionDegraded && other.Status == ConditionFalse {
+			failed = append(failed, string(other.Type))
+		}
+	}
+	if len(failed) != 0 {
+		degraded.Status = ConditionTrue
+		degraded.Reason = conditionReasons[ConditionDegraded]
+		degraded.Message = "failed conditions: " + strings.Join(failed, ", ")
+	}
+	c.byName[name] = c.update(conditions, degraded)
+}
+
+// update replaces the condition of the same type, keeping its transition time when the
+// status did not change
+func (c *conditionSet) update(conditions []Condition, condition Condition) []Condition {
+	for i, old := range conditions {
+		if old.Type != condition.Type {
+			continue
+		}
+		condition.LastTransitionTime = old.LastTransitionTime
+		if old.Status != condition.Status {
+			condition.LastTransitionTime = c.now()
+		}
+		conditions[i] = condition
+		return conditions
+	}
+	condition.LastTransitionTime = c.now()
+	conditions = append(conditions, condition)
+	sort.Slice(conditions, func(i int, j int) bool {
+		return conditions[i].Type < conditions[j].Type
+	})
+	return conditions
+}
+
+// remove forgets the conditions of a deleted object, or of one that failed to be created
+func (c *conditionSet) remove(name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.byName, name)
+}
+
+func (c *conditionSet) get(name string) []Condition {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]Condition(nil), c.byName[name]...)
+}
+
+// GetConditionsRequest is the request to list the conditions of the objects
+// TODO: move to opi-api once the message is agreed upon
+type GetConditionsRequest struct {
+	// Name is the object to get the conditions of, all the objects when empty
+	Name string
+}
+
+// GetConditionsResponse lists the conditions per object name
+// TODO: move to opi-api once the message is agreed upon
+type GetConditionsResponse struct {
+	Conditions map[string][]Condition `json:"conditions"`
+}
+
+// GetConditions returns the conditions of an object, or of all of them, telling which
+// layer failed for the objects with a DOWN oper status
+func (s *Server) GetConditions(_ context.Context, in *GetConditionsRequest) (*GetConditionsResponse, error) {
+	response := &GetConditionsResponse{Conditions: map[string][]Condition{}}
+	if in.Name != "" {
+		conditions := s.conditions.get(in.Name)
+		if len(conditions) == 0 {
+			err := status.Errorf(codes.NotFound, "unable to find key %s", in.Name)
+			return nil, err
+		}
+		response.Conditions[in.Name] = conditions
+		return response, nil
+	}
+	s.conditions.mu.Lock()
+	defer s.conditions.mu.Unlock()
+	for name, conditions := range s.conditions.byName {
+		response.Conditions[name] = append([]Condition(nil), conditions...)
+	}
+	return response, nil
+}
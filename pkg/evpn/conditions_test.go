@@ -0,0 +1,81 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/philippgille/gokv/gomap"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils/mocks"
+)
+
+func Test_conditionSet(t *testing.T) {
+	now := time.Unix(1000, 0)
+	c := newConditionSet()
+	c.now = func() time.Time { return now }
+	name := resourceIDToFullName("vrfs", "blue")
+
+	c.set(name, ConditionNetlinkProgrammed, nil)
+	c.set(name, ConditionFrrProgrammed, status.Error(codes.Unavailable, "vtysh is not running"))
+	want := map[ConditionType]Condition{
+		ConditionDegraded:          {Type: ConditionDegraded, Status: ConditionTrue, Reason: "Degraded", Message: "failed conditions: FrrProgrammed", LastTransitionTime: now},
+		ConditionFrrProgrammed:     {Type: ConditionFrrProgrammed, Status: ConditionFalse, Reason: "Unavailable", Message: "vtysh is not running", LastTransitionTime: now},
+		ConditionNetlinkProgrammed: {Type: ConditionNetlinkProgrammed, Status: ConditionTrue, Reason: "Programmed", LastTransitionTime: now},
+	}
+	checkConditions(t, c.get(name), want)
+
+	// only the conditions changing status get a new transition time
+	later := now.Add(time.Minute)
+	c.now = func() time.Time { return later }
+	c.set(name, ConditionNetlinkProgrammed, nil)
+	c.set(name, ConditionFrrProgrammed, nil)
+	want = map[ConditionType]Condition{
+		ConditionDegraded:          {Type: ConditionDegraded, Status: ConditionFalse, LastTransitionTime: later},
+		ConditionFrrProgrammed:     {Type: ConditionFrrProgrammed, Status: ConditionTrue, Reason: "Programmed", LastTransitionTime: later},
+		ConditionNetlinkProgrammed: {Type: ConditionNetlinkProgrammed, Status: ConditionTrue, Reason: "Programmed", LastTransitionTime: now},
+	}
+	checkConditions(t, c.get(name), want)
+
+	c.remove(name)
+	if conditions := c.get(name); len(conditions) != 0 {
+		t.Error("conditions: expected none after remove, received", conditions)
+	}
+}
+
+func Test_GetConditions(t *testing.T) {
+	opi := NewServerWithArgs(mocks.NewNetlink(t), mocks.NewFrr(t), gomap.NewStore(gomap.DefaultOptions))
+	name := resourceIDToFullName("bridges", "blue")
+	opi.conditions.set(name, ConditionLinkUp, status.Error(codes.NotFound, "unable to find key vni10"))
+
+	response, err := opi.GetConditions(context.Background(), &GetConditionsRequest{})
+	if err != nil {
+		t.Fatal("error: expected", nil, "received", err)
+	}
+	if len(response.Conditions[name]) != 2 {
+		t.Error("conditions: expected LinkUp and Degraded, received", response.Conditions)
+	}
+	_, err = opi.GetConditions(context.Background(), &GetConditionsRequest{Name: resourceIDToFullName("bridges", "unknown")})
+	if status.Code(err) != codes.NotFound {
+		t.Error("error: expected", codes.NotFound, "received", err)
+	}
+}
+
+func checkConditions(t *testing.T, conditions []Condition, want map[ConditionType]Condition) {
+	t.Helper()
+	if len(conditions) != len(want) {
+		t.Fatal("conditions: expected", want, "received", conditions)
+	}
+	for _, condition := range conditions {
+		if condition != want[condition.Type] {
+			t.Error("condition: expected", want[condition.Type], "received", condition)
+		}
+	}
+}
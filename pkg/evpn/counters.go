@@ -0,0 +1,258 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/vishvananda/netlink"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// CountersMetadataKey is the grpc response header listing, one "<name>: <counter>=<count> ..."
+// value per object, the live counts of the Vrfs and LogicalBridges returned by Get/List
+// TODO: replace by status fields once they are added to opi-api
+const CountersMetadataKey = "x-opi-counters"
+
+// VrfCounters are the live counts of a Vrf
+// TODO: move to the status of the Vrfs in opi-api once the message is agreed upon
+type VrfCounters struct {
+	// Ipv4Routes and Ipv6Routes are the number of routes in the routing table of the Vrf
+	Ipv4Routes int `json:"ipv4Routes"`
+	Ipv6Routes int `json:"ipv6Routes"`
+	// EvpnPrefixes is the number of type-5 EVPN prefixes of the vni of the Vrf
+	EvpnPrefixes int `json:"evpnPrefixes"`
+}
+
+// LogicalBridgeCounters are the live counts of a LogicalBridge
+// TODO: move to the status of the LogicalBridges in opi-api once the message is agreed upon
+type LogicalBridgeCounters struct {
+	// LocalMacs and RemoteMacs are the number of MAC addresses of the vni learned on the
+	// BridgePorts and from the remote VTEPs
+	LocalMacs  int `json:"localMacs"`
+	RemoteMacs int `json:"remoteMacs"`
+	// EvpnPrefixes is the number of type-2 EVPN prefixes of the vni
+	EvpnPrefixes int `json:"evpnPrefixes"`
+}
+
+func (c VrfCounters) String() string {
+	return fmt.Sprintf("ipv4Routes=%d ipv6Routes=%d evpnPrefixes=%d", c.Ipv4Routes, c.Ipv6Routes, c.EvpnPrefixes)
+}
+
+func (c LogicalBridgeCounters) String() string {
+	return fmt.Sprintf("localMacs=%d remoteMacs=%d evpnPrefixes=%d", c.LocalMacs, c.RemoteMacs, c.EvpnPrefixes)
+}
+
+// GetCountersRequest is the request to read the live counts of the Vrfs and LogicalBridges
+// TODO: move to opi-api once the message is agreed upon
+type GetCountersRequest struct{}
+
+// GetCountersResponse are the live counts of the Vrfs and LogicalBridges by name, as of the
+// last refresh, the objects created since are missing
+// TODO: move to opi-api once the message is agreed upon
+type GetCountersResponse struct {
+	Vrfs           map[string]VrfCounters           `json:"vrfs"`
+	LogicalBridges map[string]LogicalBridgeCounters `json:"logicalBridges"`
+	// RefreshTime is the time of the last refresh, zero until the first one
+	RefreshTime time.Time `json:"refreshTime"`
+}
+
+// objectCounters keeps the counts of the last refresh
+type objectCounters struct {
+	mu          sync.Mutex
+	vrfs        map[string]VrfCounters
+	bridges     map[string]LogicalBridgeCounters
+	refreshTime time.Time
+}
+
+// StartCounters refreshes every interval the route counts of the Vrfs from their routing
+// tables, and the MAC and EVPN prefix counts of the Vrfs and LogicalBridges from FRR, until
+// ctx is done. Get and List return them in the CountersMetadataKey response header
+func (s *Server) StartCounters(ctx context.Context, interval time.Duration) {
+	c := &objectCounters{vrfs: map[string]VrfCounters{}, bridges: map[string]LogicalBridgeCounters{}}
+	s.counters = c
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			s.refreshCounters(ctx, c)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// refreshCounters counts the routes, MACs and EVPN prefixes of every Vrf and LogicalBridge,
+// the counts that cannot be read are left as they were. It reads the objects with objectsMu
+// held, as the calls do, then takes c.mu
+func (s *Server) refreshCounters(ctx context.Context, c *objectCounters) {
+	s.objectsMu.RLock()
+	defer s.objectsMu.RUnlock()
+	c.mu.Lock()
+	vrfs := make(map[string]VrfCounters, len(s.Vrfs))
+	bridges := make(map[string]LogicalBridgeCounters, len(s.Bridges))
+	for name := range s.Vrfs {
+		vrfs[name] = c.vrfs[name]
+	}
+	for name := range s.Bridges {
+		bridges[name] = c.bridges[name]
+	}
+	c.mu.Unlock()
+
+	for name, vrf := range s.Vrfs {
+		table := int(vrf.GetStatus().GetRoutingTable())
+		if table == 0 {
+			continue
+		}
+		counters := vrfs[name]
+		v4, err := s.nLink.RouteListFiltered(ctx, netlink.FAMILY_V4, &netlink.Route{Table: table}, netlink.RT_FILTER_TABLE)
+		if err != nil {
+			log.Printf("Failed to count the routes of %v: %v", name, err)
+			continue
+		}
+		v6, err := s.nLink.RouteListFiltered(ctx, netlink.FAMILY_V6, &netlink.Route{Table: table}, netlink.RT_FILTER_TABLE)
+		if err != nil {
+			log.Printf("Failed to count the routes of %v: %v", name, err)
+			continue
+		}
+		counters.Ipv4Routes, counters.Ipv6Routes = len(v4), len(v6)
+		vrfs[name] = counters
+	}
+	s.countEvpnPrefixes(ctx, vrfs, bridges)
+	s.countEvpnMacs(ctx, bridges)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.vrfs, c.bridges, c.refreshTime = vrfs, bridges, time.Now()
+}
+
+// countEvpnPrefixes counts the type-5 prefixes of the vnis of the Vrfs and the type-2 ones of
+// the vnis of the LogicalBridges, each prefix once whatever its number of paths
+func (s *Server) countEvpnPrefixes(ctx context.Context, vrfs map[string]VrfCounters, bridges map[string]LogicalBridgeCounters) {
+	data, err := s.frr.FrrBgpCmd(ctx, "show bgp l2vpn evpn json")
+	if err != nil {
+		log.Printf("Failed to count the EVPN prefixes: %v", err)
+		return
+	}
+	routes, err := parseEvpnRoutes(data)
+	if err != nil {
+		log.Printf("Failed to count the EVPN prefixes: %v", err)
+		return
+	}
+	prefixes := map[int]map[uint32]map[string]bool{2: {}, 5: {}}
+	for _, route := range routes {
+		byVni, ok := prefixes[route.Type]
+		if !ok {
+			continue
+		}
+		if byVni[route.Vni] == nil {
+			byVni[route.Vni] = map[string]bool{}
+		}
+		byVni[route.Vni][route.Rd+" "+route.Prefix] = true
+	}
+	for name, counters := range vrfs {
+		if vni := s.Vrfs[name].GetSpec().GetVni(); vni != 0 {
+			counters.EvpnPrefixes = len(prefixes[5][vni])
+			vrfs[name] = counters
+		}
+	}
+	for name, counters := range bridges {
+		if vni := s.Bridges[name].GetSpec().GetVni(); vni != 0 {
+			counters.EvpnPrefixes = len(prefixes[2][vni])
+			bridges[name] = counters
+		}
+	}
+}
+
+// countEvpnMacs counts the local and remote MAC addresses of the vnis of the LogicalBridges
+func (s *Server) countEvpnMacs(ctx context.Context, bridges map[string]LogicalBridgeCounters) {
+	data, err := s.frr.FrrZebraCmd(ctx, "show evpn mac vni all json")
+	if err != nil {
+		log.Printf("Failed to count the EVPN MAC addresses: %v", err)
+		return
+	}
+	table := map[string]struct {
+		Macs map[string]evpnMac `json:"macs"`
+	}{}
+	if err := unmarshalFrrJSON(data, &table); err != nil {
+		log.Printf("Failed to count the EVPN MAC addresses: %v", err)
+		return
+	}
+	for name, counters := range bridges {
+		vni := s.Bridges[name].GetSpec().GetVni()
+		if vni == 0 {
+			continue
+		}
+		counters.LocalMacs, counters.RemoteMacs = 0, 0
+		for _, entry := range table[strconv.FormatUint(uint64(vni), 10)].Macs {
+			if entry.Type == "local" {
+				counters.LocalMacs++
+			} else {
+				counters.RemoteMacs++
+			}
+		}
+		bridges[name] = counters
+	}
+}
+
+// GetCounters returns the counts of the last refresh of the Vrfs and LogicalBridges the
+// call sees, empty when the counters are not refreshed
+func (s *Server) GetCounters(ctx context.Context, _ *GetCountersRequest) (*GetCountersResponse, error) {
+	response := &GetCountersResponse{Vrfs: map[string]VrfCounters{}, LogicalBridges: map[string]LogicalBridgeCounters{}}
+	c := s.counters
+	if c == nil {
+		return response, nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for name, counters := range c.vrfs {
+		if inTenant(ctx, name) {
+			response.Vrfs[name] = counters
+		}
+	}
+	for name, counters := range c.bridges {
+		if inTenant(ctx, name) {
+			response.LogicalBridges[name] = counters
+		}
+	}
+	response.RefreshTime = c.refreshTime
+	return response, nil
+}
+
+// reportCounters attaches the counts of the objects to the response header, the objects
+// not counted yet are left out
+func (s *Server) reportCounters(ctx context.Context, names []string) {
+	c := s.counters
+	if c == nil {
+		return
+	}
+	md := metadata.MD{}
+	c.mu.Lock()
+	for _, name := range names {
+		if counters, ok := c.vrfs[name]; ok {
+			md.Append(CountersMetadataKey, fmt.Sprintf("%s: %v", name, counters))
+		} else if counters, ok := c.bridges[name]; ok {
+			md.Append(CountersMetadataKey, fmt.Sprintf("%s: %v", name, counters))
+		}
+	}
+	c.mu.Unlock()
+	if len(md) == 0 {
+		return
+	}
+	// fails when called out of a grpc server, e.g. in tests
+	if err := grpc.SetHeader(ctx, md); err != nil {
+		log.Printf("Failed to report counters: %v", err)
+	}
+}
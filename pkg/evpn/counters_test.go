@@ -0,0 +1,91 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/philippgille/gokv/gomap"
+	"github.com/stretchr/testify/mock"
+	"github.com/vishvananda/netlink"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils/mocks"
+)
+
+const bgpEvpnCountedRoutes = `show bgp l2vpn evpn json
+{
+"bgpLocalRouterId":"10.0.0.1",
+"10.0.0.1:2":{
+  "rd":"10.0.0.1:2",
+  "[2]:[0]:[48]:[aa:bb:cc:dd:ee:01]":{
+    "paths":[{"valid":true,"bestpath":true,"routeType":2,"mac":"aa:bb:cc:dd:ee:01","peerId":"(unspec)","extendedCommunity":{"string":"RT:65000:10 ET:8"},"nexthops":[{"ip":"10.0.0.1"}]}]
+  }
+},
+"10.0.0.2:2":{
+  "rd":"10.0.0.2:2",
+  "[2]:[0]:[48]:[aa:bb:cc:dd:ee:02]":{
+    "paths":[
+      {"valid":true,"bestpath":true,"routeType":2,"mac":"aa:bb:cc:dd:ee:02","peerId":"10.0.0.2","extendedCommunity":{"string":"RT:65000:10 ET:8"},"nexthops":[{"ip":"10.0.0.2"}]},
+      {"valid":true,"routeType":2,"mac":"aa:bb:cc:dd:ee:02","peerId":"10.0.0.3","extendedCommunity":{"string":"RT:65000:10 ET:8"},"nexthops":[{"ip":"10.0.0.2"}]}
+    ]
+  },
+  "[5]:[0]:[24]:[192.168.1.0]":{
+    "paths":[{"valid":true,"bestpath":true,"routeType":5,"ip":"192.168.1.0","ipLen":24,"peerId":"10.0.0.2","extendedCommunity":{"string":"RT:65000:100 ET:8"},"nexthops":[{"ip":"10.0.0.2"}]}]
+  }
+}
+}
+bgpd# `
+
+const evpnMacs = `show evpn mac vni all json
+{
+"10":{
+  "numMacs":3,
+  "macs":{
+    "aa:bb:cc:dd:ee:01":{"type":"local","intf":"eth1"},
+    "aa:bb:cc:dd:ee:02":{"type":"remote","remoteVtep":"10.0.0.2"},
+    "aa:bb:cc:dd:ee:03":{"type":"remote","remoteVtep":"10.0.0.3"}
+  }
+}
+}
+zebra# `
+
+func Test_RefreshCounters(t *testing.T) {
+	mockNetlink := mocks.NewNetlink(t)
+	mockFrr := mocks.NewFrr(t)
+	opi := NewServerWithArgs(mockNetlink, mockFrr, gomap.NewStore(gomap.DefaultOptions))
+	vrfVni, bridgeVni := uint32(100), uint32(10)
+	opi.Vrfs[testVrfName] = &pb.Vrf{Name: testVrfName, Spec: &pb.VrfSpec{Vni: &vrfVni}, Status: &pb.VrfStatus{RoutingTable: 1000}}
+	opi.Bridges[testLogicalBridgeName] = &pb.LogicalBridge{Name: testLogicalBridgeName, Spec: &pb.LogicalBridgeSpec{Vni: &bridgeVni, VlanId: 10}}
+
+	filter := &netlink.Route{Table: 1000}
+	mockNetlink.EXPECT().RouteListFiltered(mock.Anything, netlink.FAMILY_V4, filter, uint64(netlink.RT_FILTER_TABLE)).Return(make([]netlink.Route, 3), nil).Once()
+	mockNetlink.EXPECT().RouteListFiltered(mock.Anything, netlink.FAMILY_V6, filter, uint64(netlink.RT_FILTER_TABLE)).Return(make([]netlink.Route, 1), nil).Once()
+	mockFrr.EXPECT().FrrBgpCmd(mock.Anything, "show bgp l2vpn evpn json").Return(bgpEvpnCountedRoutes, nil).Once()
+	mockFrr.EXPECT().FrrZebraCmd(mock.Anything, "show evpn mac vni all json").Return(evpnMacs, nil).Once()
+
+	c := &objectCounters{}
+	opi.counters = c
+	opi.refreshCounters(context.Background(), c)
+
+	response, err := opi.GetCounters(context.Background(), &GetCountersRequest{})
+	if err != nil {
+		t.Fatal("error: expected", nil, "received", err)
+	}
+	vrfs := map[string]VrfCounters{testVrfName: {Ipv4Routes: 3, Ipv6Routes: 1, EvpnPrefixes: 1}}
+	if !reflect.DeepEqual(response.Vrfs, vrfs) {
+		t.Error("vrfs: expected", vrfs, "received", response.Vrfs)
+	}
+	bridges := map[string]LogicalBridgeCounters{testLogicalBridgeName: {LocalMacs: 1, RemoteMacs: 2, EvpnPrefixes: 2}}
+	if !reflect.DeepEqual(response.LogicalBridges, bridges) {
+		t.Error("logical bridges: expected", bridges, "received", response.LogicalBridges)
+	}
+	if response.RefreshTime.IsZero() {
+		t.Error("refresh time: expected to be set")
+	}
+}
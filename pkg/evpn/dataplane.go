@@ -0,0 +1,200 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"time"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+)
+
+// Dataplane programs the objects validated by the gRPC handlers into the forwarding plane.
+// The handlers own the store and resolve the referenced objects before calling it, the
+// default one drives the Linux kernel over netlink and FRR (see linuxDataplane), other
+// backends, e.g. hardware offloads, are plugged in with SetDataplane
+type Dataplane interface {
+	VrfDataplane
+	LogicalBridgeDataplane
+	BridgePortDataplane
+	SviDataplane
+	VrfLiteHandoffDataplane
+	RouteDataplane
+	BgpPeerDataplane
+	MacDataplane
+	StaticFdbEntryDataplane
+	SecurityPolicyDataplane
+	NatDataplane
+	PbrRuleDataplane
+	RoutePolicyDataplane
+	TunnelSecurityDataplane
+	UnderlayDataplane
+}
+
+// VrfDataplane programs Vrfs
+type VrfDataplane interface {
+	// CreateVrf programs the Vrf, its status holds the routing table and rmac to use
+	CreateVrf(ctx context.Context, obj *pb.Vrf) error
+	// UpdateVrf applies the new spec to the programmed Vrf
+	UpdateVrf(ctx context.Context, old *pb.Vrf, obj *pb.Vrf) error
+	DeleteVrf(ctx context.Context, obj *pb.Vrf) error
+	// GetVrf fails with NotFound when the Vrf is not programmed
+	GetVrf(ctx context.Context, obj *pb.Vrf) error
+	// CheckVrf fails when any part of the Vrf is missing or down, for its oper status
+	CheckVrf(ctx context.Context, obj *pb.Vrf) error
+	// PrecheckCreateVrf fails when CreateVrf would, without programming anything
+	PrecheckCreateVrf(ctx context.Context, obj *pb.Vrf) error
+	// ResyncVrf re-applies a stored Vrf and reports whether it had to be recreated
+	ResyncVrf(ctx context.Context, obj *pb.Vrf) (bool, error)
+}
+
+// LogicalBridgeDataplane programs LogicalBridges
+type LogicalBridgeDataplane interface {
+	CreateLogicalBridge(ctx context.Context, obj *pb.LogicalBridge) error
+	// UpdateLogicalBridge applies the new spec to the programmed LogicalBridge
+	UpdateLogicalBridge(ctx context.Context, old *pb.LogicalBridge, obj *pb.LogicalBridge) error
+	DeleteLogicalBridge(ctx context.Context, obj *pb.LogicalBridge) error
+	// GetLogicalBridge fails with NotFound when the LogicalBridge is not programmed
+	GetLogicalBridge(ctx context.Context, obj *pb.LogicalBridge) error
+	// CheckLogicalBridge fails when any part of the LogicalBridge is missing or down, for its oper status
+	CheckLogicalBridge(ctx context.Context, obj *pb.LogicalBridge) error
+	// PrecheckCreateLogicalBridge fails when CreateLogicalBridge would, without programming anything
+	PrecheckCreateLogicalBridge(ctx context.Context, obj *pb.LogicalBridge) error
+	// ResyncLogicalBridge re-applies a stored LogicalBridge
+	ResyncLogicalBridge(ctx context.Context, obj *pb.LogicalBridge) error
+}
+
+// BridgePortDataplane binds ports to LogicalBridges, the LogicalBridges are looked up by the
+// dataplane since they are validated to exist
+type BridgePortDataplane interface {
+	BindBridgePort(ctx context.Context, obj *pb.BridgePort) error
+	// UpdateBridgePort applies the new spec to the bound BridgePort
+	UpdateBridgePort(ctx context.Context, old *pb.BridgePort, obj *pb.BridgePort) error
+	UnbindBridgePort(ctx context.Context, obj *pb.BridgePort) error
+	// GetBridgePort fails with NotFound when the port is missing
+	GetBridgePort(ctx context.Context, obj *pb.BridgePort) error
+	// CheckBridgePort fails when any part of the BridgePort is missing or down, for its oper status
+	CheckBridgePort(ctx context.Context, obj *pb.BridgePort) error
+	// PrecheckBindBridgePort fails when BindBridgePort would, without programming anything
+	PrecheckBindBridgePort(ctx context.Context, obj *pb.BridgePort) error
+	// ResyncBridgePort re-applies a stored BridgePort
+	ResyncBridgePort(ctx context.Context, obj *pb.BridgePort) error
+}
+
+// SviDataplane programs Svis into their LogicalBridge and Vrf
+type SviDataplane interface {
+	CreateSvi(ctx context.Context, obj *pb.Svi, bridge *pb.LogicalBridge, vrf *pb.Vrf) error
+	// UpdateSvi applies the new spec to the programmed Svi
+	UpdateSvi(ctx context.Context, old *pb.Svi, obj *pb.Svi, bridge *pb.LogicalBridge) error
+	DeleteSvi(ctx context.Context, obj *pb.Svi, bridge *pb.LogicalBridge, vrf *pb.Vrf) error
+	// GetSvi fails with NotFound when the Svi is not programmed
+	GetSvi(ctx context.Context, obj *pb.Svi, bridge *pb.LogicalBridge) error
+	// CheckSvi fails when any part of the Svi is missing or down, for its oper status
+	CheckSvi(ctx context.Context, obj *pb.Svi) error
+	// PrecheckCreateSvi fails when CreateSvi would, without programming anything
+	PrecheckCreateSvi(ctx context.Context, obj *pb.Svi, bridge *pb.LogicalBridge, vrf *pb.Vrf) error
+	// ResyncSvi re-applies a stored Svi, force recreates it, e.g. when its Vrf was recreated
+	ResyncSvi(ctx context.Context, obj *pb.Svi, bridge *pb.LogicalBridge, vrf *pb.Vrf, force bool) error
+}
+
+// VrfLiteHandoffDataplane programs VRF-lite handoffs
+type VrfLiteHandoffDataplane interface {
+	CreateVrfLiteHandoff(ctx context.Context, obj *VrfLiteHandoff, vrf *pb.Vrf) error
+	DeleteVrfLiteHandoff(ctx context.Context, obj *VrfLiteHandoff, vrf *pb.Vrf) error
+	// GetVrfLiteHandoff fails with NotFound when the handoff is not programmed
+	GetVrfLiteHandoff(ctx context.Context, obj *VrfLiteHandoff) error
+}
+
+// RouteDataplane programs static routes and route leaks between Vrfs
+type RouteDataplane interface {
+	CreateRoute(ctx context.Context, obj *Route, vrf *pb.Vrf) error
+	DeleteRoute(ctx context.Context, obj *Route, vrf *pb.Vrf) error
+	CreateRouteLeak(ctx context.Context, obj *RouteLeak, src *pb.Vrf, dst *pb.Vrf) error
+	// DeleteRouteLeak removes the leak, its Vrfs may already be gone
+	DeleteRouteLeak(ctx context.Context, obj *RouteLeak) error
+}
+
+// BgpPeerDataplane configures the BGP sessions of the default instance, towards the underlay
+// and the EVPN overlay
+type BgpPeerDataplane interface {
+	CreateBgpPeer(ctx context.Context, obj *BgpPeer) error
+	DeleteBgpPeer(ctx context.Context, obj *BgpPeer) error
+}
+
+// batchDataplane is implemented by the dataplanes sharing work between the requests of a batch,
+// the returned context is used for all of them
+type batchDataplane interface {
+	PrepareBatch(ctx context.Context) (context.Context, error)
+}
+
+// Dataplane returns the dataplane the objects are programmed into, backends offloading
+// the forwarding wrap the default one to keep the kernel view FRR relies on
+func (s *Server) Dataplane() Dataplane {
+	return s.dataplane
+}
+
+// SetDataplane replaces the default Linux dataplane, before the server starts serving
+func (s *Server) SetDataplane(dataplane Dataplane) {
+	s.dataplane = dataplane
+}
+
+// MacDataplane controls the MAC learning of the LogicalBridges, the learning of each
+// BridgePort is applied by BindBridgePort
+type MacDataplane interface {
+	// SetMacAgeing sets how long the learned MAC addresses of all the LogicalBridges are kept
+	SetMacAgeing(ctx context.Context, ageing time.Duration) error
+	// FlushBridgeMacs deletes the MAC addresses learned on the LogicalBridge, only on the port
+	// when it is not nil, and returns how many were deleted
+	FlushBridgeMacs(ctx context.Context, bridge *pb.LogicalBridge, port *pb.BridgePort) (int, error)
+}
+
+// StaticFdbEntryDataplane pins MAC addresses of LogicalBridges to BridgePorts or remote VTEPs
+type StaticFdbEntryDataplane interface {
+	// CreateStaticFdbEntry installs or replaces the entry in the vlan of the LogicalBridge
+	CreateStaticFdbEntry(ctx context.Context, obj *StaticFdbEntry, bridge *pb.LogicalBridge) error
+	DeleteStaticFdbEntry(ctx context.Context, obj *StaticFdbEntry, bridge *pb.LogicalBridge) error
+}
+
+// SecurityPolicyDataplane filters the traffic routed between the Svis of a Vrf
+type SecurityPolicyDataplane interface {
+	// CreateSecurityPolicy applies or atomically replaces the policy of the Vrf, for its current Svis
+	CreateSecurityPolicy(ctx context.Context, obj *SecurityPolicy, vrf *pb.Vrf, svis []*pb.Svi) error
+	DeleteSecurityPolicy(ctx context.Context, obj *SecurityPolicy, vrf *pb.Vrf) error
+}
+
+// NatDataplane translates the addresses of the traffic between a Vrf and the external networks
+type NatDataplane interface {
+	// CreateNatRule applies the rule with the other rules of the Vrf, applying them again when it exists
+	CreateNatRule(ctx context.Context, obj *NatRule, vrf *pb.Vrf) error
+	DeleteNatRule(ctx context.Context, obj *NatRule, vrf *pb.Vrf) error
+}
+
+// PbrRuleDataplane steers the packets matching policy-based routing rules into Vrfs
+type PbrRuleDataplane interface {
+	CreatePbrRule(ctx context.Context, obj *PbrRule, vrf *pb.Vrf) error
+	DeletePbrRule(ctx context.Context, obj *PbrRule, vrf *pb.Vrf) error
+}
+
+// RoutePolicyDataplane programs the PrefixLists and RouteMaps filtering the routes of the BGP sessions
+type RoutePolicyDataplane interface {
+	// CreatePrefixList programs the PrefixList, replacing the entries of a programmed one
+	CreatePrefixList(ctx context.Context, obj *PrefixList) error
+	DeletePrefixList(ctx context.Context, obj *PrefixList) error
+	// CreateRouteMap programs the RouteMap, replacing the entries of a programmed one
+	CreateRouteMap(ctx context.Context, obj *RouteMap) error
+	DeleteRouteMap(ctx context.Context, obj *RouteMap) error
+}
+
+// TunnelSecurityDataplane encrypts the tunnels to remote VTEPs
+type TunnelSecurityDataplane interface {
+	CreateTunnelSecurity(ctx context.Context, obj *TunnelSecurity) error
+	DeleteTunnelSecurity(ctx context.Context, obj *TunnelSecurity) error
+}
+
+// UnderlayDataplane configures the addressing and routing of the underlay interfaces
+type UnderlayDataplane interface {
+	CreateUnderlayInterface(ctx context.Context, obj *UnderlayInterface) error
+	DeleteUnderlayInterface(ctx context.Context, obj *UnderlayInterface) error
+}
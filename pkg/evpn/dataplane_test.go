@@ -0,0 +1,64 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/philippgille/gokv/gomap"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils/mocks"
+)
+
+// fakeDataplane records the LogicalBridges it programs, other methods are not implemented
+type fakeDataplane struct {
+	Dataplane
+	bridges []string
+	err     error
+}
+
+func (d *fakeDataplane) CreateLogicalBridge(_ context.Context, obj *pb.LogicalBridge) error {
+	if d.err != nil {
+		return d.err
+	}
+	d.bridges = append(d.bridges, obj.Name)
+	return nil
+}
+
+func Test_SetDataplane(t *testing.T) {
+	tests := map[string]struct {
+		err     error
+		bridges int
+	}{
+		"programmed by the dataplane": {
+			bridges: 1,
+		},
+		"dataplane failure is not stored": {
+			err: errors.New("table full"),
+		},
+	}
+
+	for testName, tt := range tests {
+		t.Run(testName, func(t *testing.T) {
+			// no netlink or FRR call is expected
+			opi := NewServerWithArgs(mocks.NewNetlink(t), mocks.NewFrr(t), gomap.NewStore(gomap.DefaultOptions))
+			dataplane := &fakeDataplane{err: tt.err}
+			opi.SetDataplane(dataplane)
+
+			request := &pb.CreateLogicalBridgeRequest{LogicalBridgeId: testLogicalBridgeID, LogicalBridge: protoClone(&testLogicalBridge)}
+			_, err := opi.CreateLogicalBridge(context.Background(), request)
+			if !errors.Is(err, tt.err) {
+				t.Error("error: expected", tt.err, "received", err)
+			}
+			if len(dataplane.bridges) != tt.bridges || len(opi.Bridges) != tt.bridges {
+				t.Error("bridges: expected", tt.bridges, "received", dataplane.bridges, opi.Bridges)
+			}
+		})
+	}
+}
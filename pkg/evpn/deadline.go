@@ -0,0 +1,76 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"google.golang.org/grpc/status"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils"
+)
+
+// The netlink and FRR calls programming an object stop at the deadline of the gRPC call, or
+// when its client cancels it, and at ProgrammingTimeout in any case, so a hung vtysh cannot
+// keep the object, and the operation holding it, busy forever. A create stopped halfway is
+// rolled back, a delete stopped halfway leaves the object in place to be deleted again
+
+// defaultProgrammingTimeout is how long the programming of one object may take
+const defaultProgrammingTimeout = 30 * time.Second
+
+// programmingContext bounds the programming of one object by s.ProgrammingTimeout, on top of
+// the deadline of the call
+func (s *Server) programmingContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if s.ProgrammingTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, s.ProgrammingTimeout)
+}
+
+// rollbackContext gives the rollback of an aborted create a deadline of its own, the one of
+// the call being over
+func (s *Server) rollbackContext() (context.Context, context.CancelFunc) {
+	return s.programmingContext(context.Background())
+}
+
+// abortCreate rolls back the create of an object stopped by the cancellation or the deadline
+// of its call, running the undo steps, best effort, and fails with Canceled or
+// DeadlineExceeded. The other failures are returned as is
+func (d *linuxDataplane) abortCreate(ctx context.Context, name string, err error, undo ...func(context.Context) error) error {
+	if err == nil || ctx.Err() == nil {
+		return err
+	}
+	log.Printf("Creation of %v aborted, rolling it back: %v", name, err)
+	rollbackCtx, cancel := d.s.rollbackContext()
+	defer cancel()
+	for _, step := range undo {
+		if err := step(rollbackCtx); err != nil {
+			log.Printf("Failed to roll back %v: %v", name, err)
+		}
+	}
+	return status.FromContextError(ctx.Err()).Err()
+}
+
+// removeLinks deletes the interfaces which exist, those an aborted create got to
+func (s *Server) removeLinks(ctx context.Context, names ...string) error {
+	return removeLinksIn(ctx, s.nLink, names...)
+}
+
+// removeLinksIn is removeLinks in the kernel of nLink, e.g. the network namespace of a Vrf
+func removeLinksIn(ctx context.Context, nLink utils.Netlink, names ...string) error {
+	for _, name := range names {
+		link, err := nLink.LinkByName(ctx, name)
+		if err != nil {
+			continue
+		}
+		log.Printf("Rolling back %v", name)
+		if err := nLink.LinkDel(ctx, link); err != nil {
+			return err
+		}
+	}
+	return nil
+}
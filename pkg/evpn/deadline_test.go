@@ -0,0 +1,78 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/philippgille/gokv/gomap"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+	pc "github.com/opiproject/opi-api/network/opinetcommon/v1alpha1/gen/go"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/fake"
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils"
+)
+
+func Test_ProgrammingAborted(t *testing.T) {
+	tests := map[string]struct {
+		timeout time.Duration
+		cancel  bool
+		code    codes.Code
+	}{
+		"programming timeout": {
+			timeout: 50 * time.Millisecond,
+			code:    codes.DeadlineExceeded,
+		},
+		"client cancelled": {
+			cancel: true,
+			code:   codes.Canceled,
+		},
+	}
+	for testName, tt := range tests {
+		t.Run(testName, func(t *testing.T) {
+			ctx := context.Background()
+			opi := NewServerWithArgs(fake.NewNetlink(), fake.NewFrr(), gomap.NewStore(gomap.DefaultOptions))
+			opi.EnableFaultInjection()
+			opi.ProgrammingTimeout = tt.timeout
+			before, _ := opi.nLink.LinkList(ctx)
+
+			// the Vrf device is created, then bringing it up hangs
+			if err := opi.faults.SetRules([]utils.FaultRule{{Operation: "LinkSetUp", Delay: "1h"}}); err != nil {
+				t.Fatal(err)
+			}
+			callCtx := ctx
+			if tt.cancel {
+				var cancel context.CancelFunc
+				callCtx, cancel = context.WithCancel(ctx)
+				time.AfterFunc(50*time.Millisecond, cancel)
+			}
+			request := &pb.CreateVrfRequest{VrfId: testVrfID, Vrf: &pb.Vrf{Spec: &pb.VrfSpec{LoopbackIpPrefix: &pc.IPPrefix{Len: 24}}}}
+			_, err := opi.CreateVrf(callCtx, request)
+			if status.Code(err) != tt.code {
+				t.Fatal("error: expected", tt.code, "received", err)
+			}
+
+			// the Vrf device is rolled back, nothing is left to create it again
+			if after, _ := opi.nLink.LinkList(ctx); len(after) != len(before) {
+				t.Error("links: expected", len(before), "received", len(after))
+			}
+			if _, ok := opi.Vrfs[testVrfName]; ok {
+				t.Error("vrf: expected none, received", opi.Vrfs[testVrfName])
+			}
+			if err := opi.faults.SetRules(nil); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := opi.CreateVrf(ctx, request); err != nil {
+				t.Error("create again: expected", nil, "received", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"strings"
+
+	"github.com/vishvananda/netlink"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils"
+)
+
+// DegradedMetadataKey is the grpc response header listing, one "<name>: <error>"
+// value per object, why objects returned by Get/List in live read mode are degraded
+// TODO: replace by a status field once a DEGRADED state is added to opi-api
+const DegradedMetadataKey = "x-opi-degraded"
+
+// checkLinks fails with the first of the kernel devices that cannot be found
+func (s *Server) checkLinks(ctx context.Context, names ...string) error {
+	return checkLinksIn(ctx, s.nLink, names...)
+}
+
+// checkLinksIn is checkLinks in the kernel of nLink, e.g. the network namespace of a Vrf
+func checkLinksIn(ctx context.Context, nLink utils.Netlink, names ...string) error {
+	for _, name := range names {
+		if _, err := nLink.LinkByName(ctx, name); err != nil {
+			err := status.Errorf(codes.NotFound, "unable to find key %s", name)
+			return err
+		}
+	}
+	return nil
+}
+
+// linkIsUp reports whether the link is up, virtual devices without carrier report an unknown
+// oper state and are up when administratively up
+func linkIsUp(attrs *netlink.LinkAttrs) bool {
+	return attrs.OperState == netlink.OperUp || (attrs.OperState == netlink.OperUnknown && attrs.Flags&net.FlagUp != 0)
+}
+
+// checkLinksUp fails when any of the interfaces is missing or down, listing all of them,
+// with NotFound when one is missing and Unavailable when they are only down
+func (s *Server) checkLinksUp(ctx context.Context, names ...string) error {
+	return checkLinksUpIn(ctx, s.nLink, names...)
+}
+
+// checkLinksUpIn is checkLinksUp in the kernel of nLink, e.g. the network namespace of a Vrf
+func checkLinksUpIn(ctx context.Context, nLink utils.Netlink, names ...string) error {
+	var problems []string
+	code := codes.Unavailable
+	for _, name := range names {
+		link, err := nLink.LinkByName(ctx, name)
+		if err != nil {
+			code = codes.NotFound
+			problems = append(problems, fmt.Sprintf("unable to find key %s", name))
+			continue
+		}
+		if !linkIsUp(link.Attrs()) {
+			problems = append(problems, fmt.Sprintf("interface %s is down", name))
+		}
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+	return status.Error(code, strings.Join(problems, ", "))
+}
+
+// reportDegraded attaches the per-object error details to the response header
+func reportDegraded(ctx context.Context, details map[string]error) {
+	if len(details) == 0 {
+		return
+	}
+	// fails when called out of a grpc server, e.g. in tests
+	if err := grpc.SetHeader(ctx, degradedMetadata(details)); err != nil {
+		log.Printf("Failed to report degraded objects: %v", err)
+	}
+}
+
+// degradedMetadata logs the degraded objects and returns the metadata reporting them
+func degradedMetadata(details map[string]error) metadata.MD {
+	md := metadata.MD{}
+	for _, name := range sortedKeys(details) {
+		log.Printf("Degraded %s: %v", name, details[name])
+		md.Append(DegradedMetadataKey, fmt.Sprintf("%s: %v", name, status.Convert(details[name]).Message()))
+	}
+	return md
+}
+
+func (s *Server) vrfLinks(obj *pb.Vrf) []string {
+	var names []string
+	// the namespace of a Vrf isolated in one stands for its vrf device
+	if !s.vrfNetns() {
+		names = append(names, s.vrfKernelName(obj.Name))
+	}
+	if obj.Spec.Vni != nil {
+		names = append(names, fmt.Sprintf("br%d", *obj.Spec.Vni), fmt.Sprintf("vni%d", *obj.Spec.Vni))
+	}
+	if vrfLoopbackAddr(obj) != nil {
+		names = append(names, s.vrfLoopbackName(obj))
+	}
+	return names
+}
+
+func (s *Server) logicalBridgeLinks(obj *pb.LogicalBridge) []string {
+	if obj.Spec.Vni == nil {
+		return nil
+	}
+	return []string{fmt.Sprintf("vni%d", *obj.Spec.Vni)}
+}
+
+func (s *Server) bridgePortLinks(obj *pb.BridgePort) []string {
+	return []string{s.portKernelName(obj.Name)}
+}
+
+func (s *Server) sviLinks(obj *pb.Svi) []string {
+	bridgeObject, ok := s.Bridges[obj.Spec.LogicalBridge]
+	if !ok {
+		return nil
+	}
+	return []string{fmt.Sprintf("vlan%d", bridgeObject.Spec.VlanId)}
+}
@@ -0,0 +1,160 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/philippgille/gokv/gomap"
+	"github.com/stretchr/testify/mock"
+	"github.com/vishvananda/netlink"
+
+	"google.golang.org/protobuf/proto"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils/mocks"
+)
+
+func Test_ListLogicalBridgesLiveRead(t *testing.T) {
+	ctx := context.Background()
+	mockNetlink := mocks.NewNetlink(t)
+	mockFrr := mocks.NewFrr(t)
+	opi := NewServerWithArgs(mockNetlink, mockFrr, gomap.NewStore(gomap.DefaultOptions))
+	opi.LiveRead = true
+
+	healthyName := resourceIDToFullName("bridges", "healthy")
+	brokenName := resourceIDToFullName("bridges", "broken")
+	opi.Bridges[healthyName] = &pb.LogicalBridge{Name: healthyName, Spec: &pb.LogicalBridgeSpec{VlanId: 10, Vni: proto.Uint32(10)}}
+	opi.Bridges[brokenName] = &pb.LogicalBridge{Name: brokenName, Spec: &pb.LogicalBridgeSpec{VlanId: 20, Vni: proto.Uint32(20)}}
+	mockNetlink.EXPECT().LinkByName(mock.Anything, "vni10").Return(&netlink.Vxlan{LinkAttrs: netlink.LinkAttrs{OperState: netlink.OperUp}}, nil).Once()
+	mockNetlink.EXPECT().LinkByName(mock.Anything, "vni20").Return(nil, errors.New("Link not found")).Once()
+
+	response, err := opi.ListLogicalBridges(ctx, &pb.ListLogicalBridgesRequest{})
+	if err != nil {
+		t.Fatal("error: expected", nil, "received", err)
+	}
+	want := map[string]pb.LBOperStatus{
+		brokenName:  pb.LBOperStatus_LB_OPER_STATUS_DOWN,
+		healthyName: pb.LBOperStatus_LB_OPER_STATUS_UP,
+	}
+	if len(response.LogicalBridges) != len(want) {
+		t.Fatal("bridges: expected", len(want), "received", response.LogicalBridges)
+	}
+	for _, bridge := range response.LogicalBridges {
+		if bridge.Status.OperStatus != want[bridge.Name] {
+			t.Error("oper status of", bridge.Name, ": expected", want[bridge.Name], "received", bridge.Status.OperStatus)
+		}
+	}
+}
+
+func Test_GetBridgePortLiveRead(t *testing.T) {
+	tests := map[string]struct {
+		liveRead bool
+		wantErr  bool
+	}{
+		"missing interface fails the call": {
+			liveRead: false,
+			wantErr:  true,
+		},
+		"missing interface degrades the port": {
+			liveRead: true,
+			wantErr:  false,
+		},
+	}
+
+	for testName, tt := range tests {
+		t.Run(testName, func(t *testing.T) {
+			ctx := context.Background()
+			mockNetlink := mocks.NewNetlink(t)
+			mockFrr := mocks.NewFrr(t)
+			opi := NewServerWithArgs(mockNetlink, mockFrr, gomap.NewStore(gomap.DefaultOptions))
+			opi.LiveRead = tt.liveRead
+			opi.Ports[testBridgePortName] = protoClone(&testBridgePortWithStatus)
+			mockNetlink.EXPECT().LinkByName(mock.Anything, testBridgePortID).Return(nil, errors.New("Link not found")).Once()
+
+			response, err := opi.GetBridgePort(ctx, &pb.GetBridgePortRequest{Name: testBridgePortName})
+			if (err != nil) != tt.wantErr {
+				t.Fatal("error: expected", tt.wantErr, "received", err)
+			}
+			if !tt.wantErr && response.Status.OperStatus != pb.BPOperStatus_BP_OPER_STATUS_DOWN {
+				t.Error("oper status: expected", pb.BPOperStatus_BP_OPER_STATUS_DOWN, "received", response.Status.OperStatus)
+			}
+		})
+	}
+}
+
+func Test_GetLogicalBridgeOperStatus(t *testing.T) {
+	tests := map[string]struct {
+		link netlink.Link
+		want pb.LBOperStatus
+	}{
+		"admin and oper up": {
+			link: &netlink.Vxlan{LinkAttrs: netlink.LinkAttrs{OperState: netlink.OperUp}},
+			want: pb.LBOperStatus_LB_OPER_STATUS_UP,
+		},
+		"unknown oper state with IFF_UP": {
+			link: &netlink.Vxlan{LinkAttrs: netlink.LinkAttrs{OperState: netlink.OperUnknown, Flags: net.FlagUp}},
+			want: pb.LBOperStatus_LB_OPER_STATUS_UP,
+		},
+		"no carrier": {
+			link: &netlink.Vxlan{LinkAttrs: netlink.LinkAttrs{OperState: netlink.OperDown, Flags: net.FlagUp}},
+			want: pb.LBOperStatus_LB_OPER_STATUS_DOWN,
+		},
+		"admin down": {
+			link: &netlink.Vxlan{LinkAttrs: netlink.LinkAttrs{OperState: netlink.OperUnknown}},
+			want: pb.LBOperStatus_LB_OPER_STATUS_DOWN,
+		},
+	}
+
+	for testName, tt := range tests {
+		t.Run(testName, func(t *testing.T) {
+			ctx := context.Background()
+			mockNetlink := mocks.NewNetlink(t)
+			mockFrr := mocks.NewFrr(t)
+			opi := NewServerWithArgs(mockNetlink, mockFrr, gomap.NewStore(gomap.DefaultOptions))
+			opi.Bridges[testLogicalBridgeName] = protoClone(&testLogicalBridgeWithStatus)
+			// the existence check, then the live state
+			mockNetlink.EXPECT().LinkByName(mock.Anything, "vni11").Return(tt.link, nil).Twice()
+
+			response, err := opi.GetLogicalBridge(ctx, &pb.GetLogicalBridgeRequest{Name: testLogicalBridgeName})
+			if err != nil {
+				t.Fatal("error: expected", nil, "received", err)
+			}
+			if response.Status.OperStatus != tt.want {
+				t.Error("oper status: expected", tt.want, "received", response.Status.OperStatus)
+			}
+			if stored := opi.Bridges[testLogicalBridgeName].Status.OperStatus; stored != pb.LBOperStatus_LB_OPER_STATUS_UP {
+				t.Error("stored oper status: expected", pb.LBOperStatus_LB_OPER_STATUS_UP, "received", stored)
+			}
+		})
+	}
+}
+
+func Test_GetVrfOperStatus(t *testing.T) {
+	ctx := context.Background()
+	mockNetlink := mocks.NewNetlink(t)
+	mockFrr := mocks.NewFrr(t)
+	opi := NewServerWithArgs(mockNetlink, mockFrr, gomap.NewStore(gomap.DefaultOptions))
+	opi.LiveRead = true
+	opi.Vrfs[testVrfName] = protoClone(&testVrfWithStatus)
+	opi.Vrfs[testVrfName].Status.RoutingTable = 1001
+	up := &netlink.Device{LinkAttrs: netlink.LinkAttrs{OperState: netlink.OperUp}}
+	mockNetlink.EXPECT().LinkByName(mock.Anything, testVrfID).Return(up, nil).Once()
+	mockNetlink.EXPECT().LinkByName(mock.Anything, "br1000").Return(&netlink.Bridge{}, nil).Once()
+	mockNetlink.EXPECT().LinkByName(mock.Anything, "vni1000").Return(up, nil).Once()
+
+	response, err := opi.GetVrf(ctx, &pb.GetVrfRequest{Name: testVrfName})
+	if err != nil {
+		t.Fatal("error: expected", nil, "received", err)
+	}
+	want := &pb.VrfStatus{LocalAs: 65000, RoutingTable: 1001, OperStatus: pb.VRFOperStatus_VRF_OPER_STATUS_DOWN}
+	if !proto.Equal(response.Status, want) {
+		t.Error("status: expected", want, "received", response.Status)
+	}
+}
@@ -0,0 +1,183 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"path"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+	pc "github.com/opiproject/opi-api/network/opinetcommon/v1alpha1/gen/go"
+)
+
+// all demo objects use this prefix in their resource IDs, so they can be told apart on teardown
+const demoPrefix = "demo-"
+
+// CreateDemoTopologyRequest is the request to provision the reference demo topology
+type CreateDemoTopologyRequest struct {
+	// Ports are the kernel names of existing interfaces to plug into the demo
+	// bridges (e.g.: eth1), the first one as ACCESS and the others as TRUNK
+	Ports []string
+}
+
+// DemoTopology lists the names of the objects of the demo topology
+type DemoTopology struct {
+	Vrfs           []string `json:"vrfs"`
+	LogicalBridges []string `json:"logicalBridges"`
+	Svis           []string `json:"svis"`
+	BridgePorts    []string `json:"bridgePorts"`
+}
+
+func demoIPPrefix(a, b, c, d byte, length int32) *pc.IPPrefix {
+	return &pc.IPPrefix{
+		Addr: &pc.IPAddress{
+			Af: pc.IpAf_IP_AF_INET,
+			V4OrV6: &pc.IPAddress_V4Addr{
+				V4Addr: uint32(a)<<24 | uint32(b)<<16 | uint32(c)<<8 | uint32(d),
+			},
+		},
+		Len: length,
+	}
+}
+
+// CreateDemoTopology provisions 2 vrfs, 4 logical bridges with an svi each and
+// the optional ports in one call. On failure whatever was created is torn down
+func (s *Server) CreateDemoTopology(ctx context.Context, in *CreateDemoTopologyRequest) (*DemoTopology, error) {
+	topology, err := s.createDemoTopology(ctx, in)
+	if err != nil {
+		fmt.Printf("Failed to create demo topology: %v", err)
+		if _, derr := s.DeleteDemoTopology(ctx); derr != nil {
+			fmt.Printf("Failed to clean up demo topology: %v", derr)
+		}
+		return nil, err
+	}
+	return topology, nil
+}
+
+func (s *Server) createDemoTopology(ctx context.Context, in *CreateDemoTopologyRequest) (*DemoTopology, error) {
+	topology := &DemoTopology{}
+	vtep := demoIPPrefix(10, 0, 0, 2, 32)
+	vrfs := []string{"blue", "red"}
+	for i, color := range vrfs {
+		vrf, err := s.CreateVrf(ctx, &pb.CreateVrfRequest{
+			VrfId: demoPrefix + color,
+			Vrf: &pb.Vrf{Spec: &pb.VrfSpec{
+				Vni:              proto.Uint32(uint32(1000 * (i + 1))),
+				LoopbackIpPrefix: demoIPPrefix(10, 255, byte(i+1), 1, 32),
+				VtepIpPrefix:     vtep,
+			}},
+		})
+		if err != nil {
+			return nil, err
+		}
+		topology.Vrfs = append(topology.Vrfs, vrf.Name)
+	}
+	// two bridges with their svi per vrf
+	for i := 0; i < 4; i++ {
+		vid := uint32(10 * (i + 1))
+		bridge, err := s.CreateLogicalBridge(ctx, &pb.CreateLogicalBridgeRequest{
+			LogicalBridgeId: fmt.Sprintf("%sbridge%d", demoPrefix, vid),
+			LogicalBridge: &pb.LogicalBridge{Spec: &pb.LogicalBridgeSpec{
+				Vni:          proto.Uint32(vid),
+				VlanId:       vid,
+				VtepIpPrefix: vtep,
+			}},
+		})
+		if err != nil {
+			return nil, err
+		}
+		topology.LogicalBridges = append(topology.LogicalBridges, bridge.Name)
+		svi, err := s.CreateSvi(ctx, &pb.CreateSviRequest{
+			SviId: fmt.Sprintf("%ssvi%d", demoPrefix, vid),
+			Svi: &pb.Svi{Spec: &pb.SviSpec{
+				Vrf:           topology.Vrfs[i/2],
+				LogicalBridge: bridge.Name,
+				MacAddress:    []byte{0xaa, 0xbb, 0xcc, 0x00, 0x00, byte(vid)},
+				GwIpPrefix:    []*pc.IPPrefix{demoIPPrefix(10, byte(vid), 0, 1, 24)},
+			}},
+		})
+		if err != nil {
+			return nil, err
+		}
+		topology.Svis = append(topology.Svis, svi.Name)
+	}
+	for i, ifname := range in.Ports {
+		spec := &pb.BridgePortSpec{
+			MacAddress:     []byte{0xaa, 0xbb, 0xcc, 0x00, 0x01, byte(i)},
+			Ptype:          pb.BridgePortType_TRUNK,
+			LogicalBridges: topology.LogicalBridges,
+		}
+		if i == 0 {
+			spec.Ptype = pb.BridgePortType_ACCESS
+			spec.LogicalBridges = topology.LogicalBridges[:1]
+		}
+		port, err := s.CreateBridgePort(ctx, &pb.CreateBridgePortRequest{
+			BridgePortId: ifname,
+			BridgePort:   &pb.BridgePort{Spec: spec},
+		})
+		if err != nil {
+			return nil, err
+		}
+		topology.BridgePorts = append(topology.BridgePorts, port.Name)
+	}
+	log.Printf("Created demo topology %v", topology)
+	return topology, nil
+}
+
+func isDemoObject(name string) bool {
+	return strings.HasPrefix(path.Base(name), demoPrefix)
+}
+
+// DeleteDemoTopology tears down the demo topology, children first,
+// ports are part of it when they belong to a demo logical bridge
+func (s *Server) DeleteDemoTopology(ctx context.Context) (*DemoTopology, error) {
+	topology := &DemoTopology{}
+	for _, name := range sortedKeys(s.Ports) {
+		for _, bridge := range s.Ports[name].Spec.LogicalBridges {
+			if !isDemoObject(bridge) {
+				continue
+			}
+			if _, err := s.DeleteBridgePort(ctx, &pb.DeleteBridgePortRequest{Name: name, AllowMissing: true}); err != nil {
+				return nil, err
+			}
+			topology.BridgePorts = append(topology.BridgePorts, name)
+			break
+		}
+	}
+	for _, name := range sortedKeys(s.Svis) {
+		if !isDemoObject(name) {
+			continue
+		}
+		if _, err := s.DeleteSvi(ctx, &pb.DeleteSviRequest{Name: name, AllowMissing: true}); err != nil {
+			return nil, err
+		}
+		topology.Svis = append(topology.Svis, name)
+	}
+	for _, name := range sortedKeys(s.Bridges) {
+		if !isDemoObject(name) {
+			continue
+		}
+		if _, err := s.DeleteLogicalBridge(ctx, &pb.DeleteLogicalBridgeRequest{Name: name, AllowMissing: true}); err != nil {
+			return nil, err
+		}
+		topology.LogicalBridges = append(topology.LogicalBridges, name)
+	}
+	for _, name := range sortedKeys(s.Vrfs) {
+		if !isDemoObject(name) {
+			continue
+		}
+		if _, err := s.DeleteVrf(ctx, &pb.DeleteVrfRequest{Name: name, AllowMissing: true}); err != nil {
+			return nil, err
+		}
+		topology.Vrfs = append(topology.Vrfs, name)
+	}
+	log.Printf("Deleted demo topology %v", topology)
+	return topology, nil
+}
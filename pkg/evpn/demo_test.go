@@ -0,0 +1,74 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/philippgille/gokv/gomap"
+	"github.com/stretchr/testify/mock"
+	"github.com/vishvananda/netlink"
+
+	"google.golang.org/grpc/status"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils/mocks"
+)
+
+func Test_CreateDemoTopology(t *testing.T) {
+	t.Run("failed LinkAdd call", func(t *testing.T) {
+		ctx := context.Background()
+		mockNetlink := mocks.NewNetlink(t)
+		mockFrr := mocks.NewFrr(t)
+		store := gomap.NewStore(gomap.DefaultOptions)
+		opi := NewServerWithArgs(mockNetlink, mockFrr, store)
+
+		errMsg := "Failed to call LinkAdd"
+		vrf := &netlink.Vrf{LinkAttrs: netlink.LinkAttrs{Name: "demo-blue", Alias: managedAlias}, Table: 1001}
+		mockNetlink.EXPECT().LinkAdd(mock.Anything, vrf).Return(errors.New(errMsg)).Once()
+
+		response, err := opi.CreateDemoTopology(ctx, &CreateDemoTopologyRequest{})
+		if er := status.Convert(err); er.Message() != errMsg {
+			t.Error("error message: expected", errMsg, "received", er.Message())
+		}
+		if response != nil {
+			t.Error("response: expected", nil, "received", response)
+		}
+		if len(opi.Vrfs) != 0 {
+			t.Error("expected no vrfs left behind, received", opi.Vrfs)
+		}
+	})
+}
+
+func Test_DeleteDemoTopology(t *testing.T) {
+	ctx := context.Background()
+	mockNetlink := mocks.NewNetlink(t)
+	mockFrr := mocks.NewFrr(t)
+	store := gomap.NewStore(gomap.DefaultOptions)
+	opi := NewServerWithArgs(mockNetlink, mockFrr, store)
+
+	// vrfs without vni only need the vrf device removed
+	demoVrfName := resourceIDToFullName("vrfs", "demo-blue")
+	opi.Vrfs[demoVrfName] = &pb.Vrf{Name: demoVrfName, Spec: &pb.VrfSpec{}}
+	opi.Vrfs[testVrfName] = &pb.Vrf{Name: testVrfName, Spec: &pb.VrfSpec{}}
+	vrf := &netlink.Vrf{LinkAttrs: netlink.LinkAttrs{Name: "demo-blue", Alias: managedAlias}, Table: 1000}
+	mockNetlink.EXPECT().LinkByName(mock.Anything, "demo-blue").Return(vrf, nil).Once()
+	mockNetlink.EXPECT().LinkSetDown(mock.Anything, vrf).Return(nil).Once()
+	mockNetlink.EXPECT().LinkDel(mock.Anything, vrf).Return(nil).Once()
+
+	response, err := opi.DeleteDemoTopology(ctx)
+	if err != nil {
+		t.Error("error: expected", nil, "received", err)
+	}
+	if len(response.Vrfs) != 1 || response.Vrfs[0] != demoVrfName {
+		t.Error("deleted vrfs: expected", []string{demoVrfName}, "received", response.Vrfs)
+	}
+	if _, ok := opi.Vrfs[testVrfName]; !ok {
+		t.Error("expected non demo vrf to be kept, received", opi.Vrfs)
+	}
+}
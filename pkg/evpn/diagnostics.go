@@ -0,0 +1,158 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// pingCountDefault and pingCountMax bound the echo requests of a Ping
+	pingCountDefault = 5
+	pingCountMax     = 100
+	// tracerouteMaxHopsDefault and tracerouteMaxHopsMax bound the hops of a Traceroute
+	tracerouteMaxHopsDefault = 30
+	tracerouteMaxHopsMax     = 64
+)
+
+// PingRequest is the request to ping a destination from inside a Vrf, or from the
+// interface of a Svi
+// TODO: move to opi-api once the message is agreed upon
+type PingRequest struct {
+	// Vrf is the name of the Vrf to ping from, exclusive with Svi
+	Vrf string `json:"vrf"`
+	// Svi is the name of the Svi to ping from, exclusive with Vrf
+	Svi string `json:"svi"`
+	// Destination is the IPv4 or IPv6 address to ping
+	Destination string `json:"destination"`
+	// Count is the number of echo requests, 5 when 0
+	Count int32 `json:"count"`
+}
+
+// TracerouteRequest is the request to trace the path to a destination from inside a Vrf,
+// or from the interface of a Svi
+// TODO: move to opi-api once the message is agreed upon
+type TracerouteRequest struct {
+	// Vrf is the name of the Vrf to trace from, exclusive with Svi
+	Vrf string `json:"vrf"`
+	// Svi is the name of the Svi to trace from, exclusive with Vrf
+	Svi string `json:"svi"`
+	// Destination is the IPv4 or IPv6 address to trace the path to
+	Destination string `json:"destination"`
+	// MaxHops is the maximum number of hops, 30 when 0
+	MaxHops int32 `json:"maxHops"`
+}
+
+// DiagnosticOutput is a line printed by a Ping or a Traceroute, streamed as soon as printed
+type DiagnosticOutput struct {
+	// Line is the line printed by the tool
+	Line string `json:"line,omitempty"`
+	// Error ends the output of a tool failing after it started printing
+	Error string `json:"error,omitempty"`
+}
+
+// diagnosticDevice returns the kernel device the diagnostic of the Vrf or the Svi is bound to
+func (s *Server) diagnosticDevice(ctx context.Context, vrf string, svi string) (string, error) {
+	switch {
+	case vrf != "" && svi != "":
+		msg := "only one of vrf and svi can be set"
+		return "", badRequest("svi", status.Error(codes.InvalidArgument, msg))
+	case vrf != "":
+		if _, ok := s.Vrfs[vrf]; !ok || !inTenant(ctx, vrf) {
+			err := status.Errorf(codes.NotFound, "unable to find key %s", vrf)
+			return "", err
+		}
+		return s.vrfKernelName(vrf), nil
+	case svi != "":
+		obj, ok := s.Svis[svi]
+		if !ok || !inTenant(ctx, svi) {
+			err := status.Errorf(codes.NotFound, "unable to find key %s", svi)
+			return "", err
+		}
+		dev := s.sviKernelName(obj)
+		if dev == "" {
+			err := status.Errorf(codes.FailedPrecondition, "LogicalBridge of Svi %s not found", svi)
+			return "", err
+		}
+		return dev, nil
+	default:
+		msg := "one of vrf and svi is required"
+		return "", badRequest("vrf", status.Error(codes.InvalidArgument, msg))
+	}
+}
+
+// checkDiagnosticDestination validates the destination of a diagnostic
+func checkDiagnosticDestination(dst string) error {
+	if dst == "" {
+		return missingField("destination")
+	}
+	if net.ParseIP(dst) == nil {
+		msg := fmt.Sprintf("destination (%v) have to be an IPv4 or IPv6 address", dst)
+		return badRequest("destination", status.Error(codes.InvalidArgument, msg))
+	}
+	return nil
+}
+
+// Ping sends echo requests to the destination from inside the Vrf, bound to its VRF device,
+// or from the interface of the Svi, the output of ping being sent line by line. The
+// destination not answering is reported by the output and not an error
+func (s *Server) Ping(ctx context.Context, in *PingRequest, send func(*DiagnosticOutput)) error {
+	dev, err := s.diagnosticDevice(ctx, in.Vrf, in.Svi)
+	if err != nil {
+		return err
+	}
+	if err := checkDiagnosticDestination(in.Destination); err != nil {
+		return err
+	}
+	count := int(in.Count)
+	if count == 0 {
+		count = pingCountDefault
+	}
+	if count < 1 || count > pingCountMax {
+		msg := fmt.Sprintf("count (%v) have to be between 1 and %d", in.Count, pingCountMax)
+		return badRequest("count", status.Error(codes.InvalidArgument, msg))
+	}
+	err = s.diagnostics.Ping(ctx, dev, in.Destination, count, func(line string) {
+		send(&DiagnosticOutput{Line: line})
+	})
+	if err != nil {
+		err = status.Errorf(codes.Unavailable, "unable to ping %s from %s: %v", in.Destination, dev, err)
+		return err
+	}
+	return nil
+}
+
+// Traceroute traces the path to the destination from inside the Vrf, bound to its VRF
+// device, or from the interface of the Svi, the output of traceroute being sent line by line
+func (s *Server) Traceroute(ctx context.Context, in *TracerouteRequest, send func(*DiagnosticOutput)) error {
+	dev, err := s.diagnosticDevice(ctx, in.Vrf, in.Svi)
+	if err != nil {
+		return err
+	}
+	if err := checkDiagnosticDestination(in.Destination); err != nil {
+		return err
+	}
+	maxHops := int(in.MaxHops)
+	if maxHops == 0 {
+		maxHops = tracerouteMaxHopsDefault
+	}
+	if maxHops < 1 || maxHops > tracerouteMaxHopsMax {
+		msg := fmt.Sprintf("max_hops (%v) have to be between 1 and %d", in.MaxHops, tracerouteMaxHopsMax)
+		return badRequest("max_hops", status.Error(codes.InvalidArgument, msg))
+	}
+	err = s.diagnostics.Traceroute(ctx, dev, in.Destination, maxHops, func(line string) {
+		send(&DiagnosticOutput{Line: line})
+	})
+	if err != nil {
+		err = status.Errorf(codes.Unavailable, "unable to traceroute %s from %s: %v", in.Destination, dev, err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/philippgille/gokv/gomap"
+	"github.com/stretchr/testify/mock"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils/mocks"
+)
+
+var testPingOutput = []string{
+	"PING 10.0.0.1 (10.0.0.1) from 10.0.0.2 opi-vrf8: 56(84) bytes of data.",
+	"64 bytes from 10.0.0.1: icmp_seq=1 ttl=64 time=0.040 ms",
+}
+
+func Test_Ping(t *testing.T) {
+	tests := map[string]struct {
+		in      *PingRequest
+		dev     string
+		count   int
+		pingErr error
+		errCode codes.Code
+		errMsg  string
+	}{
+		"ping in a Vrf": {
+			in:    &PingRequest{Vrf: testVrfName, Destination: "10.0.0.1"},
+			dev:   testVrfID,
+			count: pingCountDefault,
+		},
+		"ping from a Svi": {
+			in:    &PingRequest{Svi: testSviName, Destination: "fe80::1", Count: 1},
+			dev:   "vlan22",
+			count: 1,
+		},
+		"ping failure": {
+			in:      &PingRequest{Vrf: testVrfName, Destination: "10.0.0.1", Count: 2},
+			dev:     testVrfID,
+			count:   2,
+			pingErr: errors.New("exit status 2: ping: SO_BINDTODEVICE opi-vrf8: No such device"),
+			errCode: codes.Unavailable,
+			errMsg:  "unable to ping 10.0.0.1 from opi-vrf8: exit status 2: ping: SO_BINDTODEVICE opi-vrf8: No such device",
+		},
+		"no Vrf nor Svi": {
+			in:      &PingRequest{Destination: "10.0.0.1"},
+			errCode: codes.InvalidArgument,
+			errMsg:  "one of vrf and svi is required",
+		},
+		"both Vrf and Svi": {
+			in:      &PingRequest{Vrf: testVrfName, Svi: testSviName, Destination: "10.0.0.1"},
+			errCode: codes.InvalidArgument,
+			errMsg:  "only one of vrf and svi can be set",
+		},
+		"unknown Vrf": {
+			in:      &PingRequest{Vrf: "unknown-vrf", Destination: "10.0.0.1"},
+			errCode: codes.NotFound,
+			errMsg:  "unable to find key unknown-vrf",
+		},
+		"illegal destination": {
+			in:      &PingRequest{Vrf: testVrfName, Destination: "gateway"},
+			errCode: codes.InvalidArgument,
+			errMsg:  "destination (gateway) have to be an IPv4 or IPv6 address",
+		},
+		"illegal count": {
+			in:      &PingRequest{Vrf: testVrfName, Destination: "10.0.0.1", Count: 1000},
+			errCode: codes.InvalidArgument,
+			errMsg:  "count (1000) have to be between 1 and 100",
+		},
+	}
+	for testName, tt := range tests {
+		t.Run(testName, func(t *testing.T) {
+			mockDiagnostics := mocks.NewDiagnostics(t)
+			opi := NewServerWithArgs(mocks.NewNetlink(t), mocks.NewFrr(t), gomap.NewStore(gomap.DefaultOptions))
+			opi.diagnostics = mockDiagnostics
+			opi.Bridges[testLogicalBridgeName] = protoClone(&testLogicalBridge)
+			opi.Vrfs[testVrfName] = protoClone(&testVrf)
+			opi.Svis[testSviName] = protoClone(&testSvi)
+			if tt.dev != "" {
+				mockDiagnostics.EXPECT().Ping(mock.Anything, tt.dev, tt.in.Destination, tt.count, mock.Anything).
+					RunAndReturn(func(_ context.Context, _ string, _ string, _ int, output func(string)) error {
+						for _, line := range testPingOutput {
+							output(line)
+						}
+						return tt.pingErr
+					}).Once()
+			}
+			var lines []string
+			err := opi.Ping(context.Background(), tt.in, func(output *DiagnosticOutput) {
+				lines = append(lines, output.Line)
+			})
+			if er, ok := status.FromError(err); ok {
+				if er.Code() != tt.errCode {
+					t.Error("error code: expected", tt.errCode, "received", er.Code())
+				}
+				if er.Message() != tt.errMsg {
+					t.Error("error message: expected", tt.errMsg, "received", er.Message())
+				}
+			} else {
+				t.Error("expected grpc error status")
+			}
+			// the lines printed before a failure are still streamed
+			if tt.dev != "" && !reflect.DeepEqual(lines, testPingOutput) {
+				t.Error("output: expected", testPingOutput, "received", lines)
+			}
+		})
+	}
+}
+
+func Test_Traceroute(t *testing.T) {
+	mockDiagnostics := mocks.NewDiagnostics(t)
+	opi := NewServerWithArgs(mocks.NewNetlink(t), mocks.NewFrr(t), gomap.NewStore(gomap.DefaultOptions))
+	opi.diagnostics = mockDiagnostics
+	opi.Vrfs[testVrfName] = protoClone(&testVrf)
+	opi.Svis[testSviName] = protoClone(&testSvi)
+	ctx := context.Background()
+
+	mockDiagnostics.EXPECT().Traceroute(mock.Anything, testVrfID, "10.0.0.1", tracerouteMaxHopsDefault, mock.Anything).
+		RunAndReturn(func(_ context.Context, _ string, _ string, _ int, output func(string)) error {
+			output(" 1  10.0.0.1  0.050 ms  0.020 ms  0.018 ms")
+			return nil
+		}).Once()
+	var lines []string
+	err := opi.Traceroute(ctx, &TracerouteRequest{Vrf: testVrfName, Destination: "10.0.0.1"}, func(output *DiagnosticOutput) {
+		lines = append(lines, output.Line)
+	})
+	if err != nil || len(lines) != 1 {
+		t.Error("traceroute: expected a hop, received", lines, err)
+	}
+
+	// the Svi is bound to the vlan device of its LogicalBridge, missing here
+	err = opi.Traceroute(ctx, &TracerouteRequest{Svi: testSviName, Destination: "10.0.0.1"}, func(*DiagnosticOutput) {})
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Error("error: expected", codes.FailedPrecondition, "received", err)
+	}
+	err = opi.Traceroute(ctx, &TracerouteRequest{Vrf: testVrfName, Destination: "10.0.0.1", MaxHops: 65}, func(*DiagnosticOutput) {})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Error("error: expected", codes.InvalidArgument, "received", err)
+	}
+	_, err = opi.diagnosticDevice(tenantContext("acme"), testVrfName, "")
+	if status.Code(err) != codes.NotFound {
+		t.Error("error: expected", codes.NotFound, "received", err)
+	}
+}
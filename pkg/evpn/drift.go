@@ -0,0 +1,468 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netlink/nl"
+	"golang.org/x/sys/unix"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+)
+
+// GetDriftRequest is the request to compare the stored objects with what is programmed
+// TODO: move to an AdminService in opi-api once the message is agreed upon
+type GetDriftRequest struct{}
+
+// ObjectDrift lists how what is programmed for an object differs from its stored spec
+// TODO: move to an AdminService in opi-api once the message is agreed upon
+type ObjectDrift struct {
+	Name          string   `json:"name"`
+	Discrepancies []string `json:"discrepancies"`
+}
+
+// GetDriftResponse lists the drifted objects, parents first, the others are left out
+// TODO: move to an AdminService in opi-api once the message is agreed upon
+type GetDriftResponse struct {
+	Objects []ObjectDrift `json:"objects"`
+}
+
+// driftState is the programmed state the objects are compared with, read once per call
+type driftState struct {
+	links map[string]netlink.Link
+	names map[int]string
+	vlans map[int32][]*nl.BridgeVlanInfo
+	// zebra and bgp are the running configurations of the FRR daemons
+	zebra string
+	bgp   string
+}
+
+// loadDriftState reads the links, the bridge vlans and the FRR running configurations
+func (s *Server) loadDriftState(ctx context.Context) (*driftState, error) {
+	links, err := s.nLink.LinkList(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "unable to list the links: %v", err)
+	}
+	state := &driftState{links: map[string]netlink.Link{}, names: map[int]string{}}
+	for _, link := range links {
+		state.links[link.Attrs().Name] = link
+		state.names[link.Attrs().Index] = link.Attrs().Name
+	}
+	if state.vlans, err = s.nLink.BridgeVlanList(ctx); err != nil {
+		return nil, status.Errorf(codes.Unavailable, "unable to list the bridge vlans: %v", err)
+	}
+	if state.zebra, err = s.frr.FrrZebraCmd(ctx, "show running-config"); err != nil {
+		return nil, status.Errorf(codes.Unavailable, "unable to read the zebra configuration: %v", err)
+	}
+	if state.bgp, err = s.frr.FrrBgpCmd(ctx, "show running-config"); err != nil {
+		return nil, status.Errorf(codes.Unavailable, "unable to read the bgp configuration: %v", err)
+	}
+	return state, nil
+}
+
+// objectDrift collects the discrepancies of an object
+type objectDrift struct {
+	state         *driftState
+	discrepancies []string
+}
+
+func (d *objectDrift) add(format string, args ...interface{}) {
+	d.discrepancies = append(d.discrepancies, fmt.Sprintf(format, args...))
+}
+
+// link returns the link of that name and type, nil after adding the discrepancy
+func (d *objectDrift) link(name string, linkType string) netlink.Link {
+	link, ok := d.state.links[name]
+	if !ok {
+		d.add("interface %s is missing", name)
+		return nil
+	}
+	if link.Type() != linkType {
+		d.add("interface %s is a %s, expected a %s", name, link.Type(), linkType)
+		return nil
+	}
+	if link.Attrs().Flags&net.FlagUp == 0 {
+		d.add("interface %s is administratively down", name)
+	}
+	return link
+}
+
+// device returns the link of that name, whatever its type, nil after adding the discrepancy
+func (d *objectDrift) device(name string) netlink.Link {
+	link, ok := d.state.links[name]
+	if !ok {
+		d.add("interface %s is missing", name)
+		return nil
+	}
+	if link.Attrs().Flags&net.FlagUp == 0 {
+		d.add("interface %s is administratively down", name)
+	}
+	return link
+}
+
+// master checks the master of the link, when both exist
+func (d *objectDrift) master(link netlink.Link, master string) {
+	if link == nil {
+		return
+	}
+	if _, ok := d.state.links[master]; !ok {
+		return
+	}
+	if actual := d.state.names[link.Attrs().MasterIndex]; actual != master {
+		if actual == "" {
+			actual = "no master"
+		}
+		d.add("interface %s has %s, expected master %s", link.Attrs().Name, actual, master)
+	}
+}
+
+// vlan checks that the bridge vlan is on the link, with the pvid and untagged flags
+func (d *objectDrift) vlan(link netlink.Link, vid uint16, pvid bool) {
+	if link == nil {
+		return
+	}
+	for _, info := range d.state.vlans[int32(link.Attrs().Index)] {
+		if info.Vid == vid {
+			if info.PortVID() != pvid || info.EngressUntag() != pvid {
+				d.add("vlan %d of interface %s has pvid untagged %t, expected %t", vid, link.Attrs().Name, info.PortVID(), pvid)
+			}
+			return
+		}
+	}
+	d.add("vlan %d is missing on interface %s", vid, link.Attrs().Name)
+}
+
+// address checks that the address is on the link
+func (d *objectDrift) address(ctx context.Context, s *Server, link netlink.Link, addr *net.IPNet) {
+	if link == nil || addr == nil {
+		return
+	}
+	addrs, err := s.nLink.AddrList(ctx, link, netlink.FAMILY_ALL)
+	if err != nil {
+		d.add("unable to list the addresses of interface %s: %v", link.Attrs().Name, err)
+		return
+	}
+	for _, a := range addrs {
+		if a.IPNet != nil && a.IPNet.String() == addr.String() {
+			return
+		}
+	}
+	d.add("address %s is missing on interface %s", addr, link.Attrs().Name)
+}
+
+// frr checks that the section of the running configuration has the line, or exists when line
+// is empty
+func (d *objectDrift) frr(daemon string, config string, header string, line string) {
+	section, ok := frrSection(config, header)
+	switch {
+	case !ok:
+		d.add("%s configuration is missing %q", daemon, header)
+	case line != "" && !strings.Contains("\n"+section+"\n", "\n"+line+"\n"):
+		d.add("%s configuration of %q is missing %q", daemon, header, line)
+	}
+}
+
+// frrLine checks that the running configuration has the unindented line
+func (d *objectDrift) frrLine(daemon string, config string, line string) {
+	if !strings.Contains("\n"+config+"\n", "\n"+line+"\n") {
+		d.add("%s configuration is missing %q", daemon, line)
+	}
+}
+
+// frrSection returns the trimmed lines of the section of the running configuration starting
+// with the header, up to the next unindented line
+func frrSection(config string, header string) (string, bool) {
+	lines := strings.Split(config, "\n")
+	for i, line := range lines {
+		if strings.TrimSpace(line) != header {
+			continue
+		}
+		var section []string
+		for _, next := range lines[i+1:] {
+			if next == "" || (next[0] != ' ' && next[0] != '\t') {
+				break
+			}
+			section = append(section, strings.TrimSpace(next))
+		}
+		return strings.Join(section, "\n"), true
+	}
+	return "", false
+}
+
+func (s *Server) vrfDrift(ctx context.Context, d *objectDrift, obj *pb.Vrf) {
+	vrfName := s.vrfKernelName(obj.Name)
+	// the devices of a Vrf isolated in a network namespace are not seen from the host
+	if s.vrfNetns() {
+		s.vrfFrrDrift(d, obj, vrfName)
+		return
+	}
+	if link := d.link(vrfName, "vrf"); link != nil && obj.Status != nil && obj.Status.RoutingTable != 0 {
+		if vrf, ok := link.(*netlink.Vrf); ok && vrf.Table != obj.Status.RoutingTable {
+			d.add("interface %s uses table %d, expected %d", vrfName, vrf.Table, obj.Status.RoutingTable)
+		}
+	}
+	if addr := vrfLoopbackAddr(obj); addr != nil {
+		loopback := d.link(s.vrfLoopbackName(obj), "dummy")
+		d.master(loopback, vrfName)
+		d.address(ctx, s, loopback, addr)
+	}
+	if obj.Spec.Vni == nil {
+		return
+	}
+	bridgeName := fmt.Sprintf("br%d", *obj.Spec.Vni)
+	d.master(d.link(bridgeName, "bridge"), vrfName)
+	vxlanName := fmt.Sprintf("vni%d", *obj.Spec.Vni)
+	vxlan := d.link(vxlanName, "vxlan")
+	if link, ok := vxlan.(*netlink.Vxlan); ok && uint32(link.VxlanId) != *obj.Spec.Vni {
+		d.add("interface %s has vni %d, expected %d", vxlanName, link.VxlanId, *obj.Spec.Vni)
+	}
+	d.master(vxlan, bridgeName)
+	s.vrfFrrDrift(d, obj, vrfName)
+}
+
+// vrfFrrDrift checks the FRR configuration of the L3 vni of the Vrf
+func (s *Server) vrfFrrDrift(d *objectDrift, obj *pb.Vrf, vrfName string) {
+	if obj.Spec.Vni == nil {
+		return
+	}
+	d.frr("zebra", d.state.zebra, "vrf "+vrfName, fmt.Sprintf("vni %d", *obj.Spec.Vni))
+	d.frr("bgp", d.state.bgp, fmt.Sprintf("router bgp %d vrf %s", s.Gateway.LocalAs, vrfName), "")
+}
+
+func (s *Server) logicalBridgeDrift(_ context.Context, d *objectDrift, obj *pb.LogicalBridge) {
+	if obj.Spec.Vni == nil {
+		return
+	}
+	vxlanName := fmt.Sprintf("vni%d", *obj.Spec.Vni)
+	linkType := "vxlan"
+	if _, ok := s.GeneveTunnels[obj.Name]; ok {
+		linkType = "geneve"
+	}
+	link := d.link(vxlanName, linkType)
+	if vxlan, ok := link.(*netlink.Vxlan); ok && uint32(vxlan.VxlanId) != *obj.Spec.Vni {
+		d.add("interface %s has vni %d, expected %d", vxlanName, vxlan.VxlanId, *obj.Spec.Vni)
+	}
+	d.master(link, tenantbridgeName)
+	d.vlan(link, uint16(obj.Spec.VlanId), true)
+}
+
+func (s *Server) bridgePortDrift(_ context.Context, d *objectDrift, obj *pb.BridgePort) {
+	name := s.portKernelName(obj.Name)
+	link, ok := d.state.links[name]
+	if !ok {
+		d.add("interface %s is missing", name)
+		return
+	}
+	if link.Attrs().Flags&net.FlagUp == 0 {
+		d.add("interface %s is administratively down", name)
+	}
+	if len(obj.Spec.MacAddress) > 0 && !bytes.Equal(link.Attrs().HardwareAddr, obj.Spec.MacAddress) {
+		d.add("interface %s has MAC %s, expected %s", name, link.Attrs().HardwareAddr, net.HardwareAddr(obj.Spec.MacAddress))
+	}
+	// the MACsec and service-tagged ports are plugged through other devices
+	if _, ok := s.PortMacsec[obj.Name]; ok || s.PortTrunkVlans(obj.Name).ServiceVlan != 0 {
+		return
+	}
+	d.master(link, tenantbridgeName)
+	trunk := s.PortTrunkVlans(obj.Name)
+	for _, bridgeRefName := range obj.Spec.LogicalBridges {
+		bridgeObject, ok := s.Bridges[bridgeRefName]
+		if !ok {
+			continue
+		}
+		if obj.Spec.Ptype == pb.BridgePortType_ACCESS {
+			d.vlan(link, uint16(bridgeObject.Spec.VlanId), true)
+		} else if _, translated := trunk.External(bridgeObject.Spec.VlanId); !translated {
+			d.vlan(link, uint16(bridgeObject.Spec.VlanId), bridgeObject.Spec.VlanId == trunk.Native)
+		}
+	}
+}
+
+func (s *Server) sviDrift(ctx context.Context, d *objectDrift, obj *pb.Svi) {
+	bridgeObject, ok := s.Bridges[obj.Spec.LogicalBridge]
+	if !ok {
+		d.add("LogicalBridge %s is missing", obj.Spec.LogicalBridge)
+		return
+	}
+	vrfName := s.vrfKernelName(obj.Spec.Vrf)
+	vlanName := fmt.Sprintf("vlan%d", bridgeObject.Spec.VlanId)
+	// the vlan devices of the Vrfs isolated in network namespaces are not seen from the host
+	if !s.vrfNetns() {
+		link := d.link(vlanName, "vlan")
+		d.master(link, vrfName)
+		if link != nil && len(obj.Spec.MacAddress) > 0 && !bytes.Equal(link.Attrs().HardwareAddr, obj.Spec.MacAddress) {
+			d.add("interface %s has MAC %s, expected %s", vlanName, link.Attrs().HardwareAddr, net.HardwareAddr(obj.Spec.MacAddress))
+		}
+		for _, gwip := range obj.Spec.GwIpPrefix {
+			ip := make(net.IP, 4)
+			binary.BigEndian.PutUint32(ip, gwip.Addr.GetV4Addr())
+			d.address(ctx, s, link, &net.IPNet{IP: ip, Mask: net.CIDRMask(int(gwip.Len), 32)})
+		}
+	}
+	if obj.Spec.EnableBgp {
+		d.frr("bgp", d.state.bgp, fmt.Sprintf("router bgp %d vrf %s", s.Gateway.LocalAs, vrfName), fmt.Sprintf("neighbor %s peer-group", vlanName))
+	}
+}
+
+func (s *Server) underlayInterfaceDrift(ctx context.Context, d *objectDrift, obj *UnderlayInterface) {
+	d.address(ctx, s, d.device(obj.Spec.Interface), s.underlayAddressOf(obj))
+	if obj.Spec.Role == UnderlayLoopback {
+		d.frr("bgp", d.state.bgp, fmt.Sprintf("router bgp %d", s.Gateway.LocalAs), fmt.Sprintf("network %s", underlayAddress(obj.Spec)))
+	}
+}
+
+func (s *Server) prefixListDrift(_ context.Context, d *objectDrift, obj *PrefixList) {
+	// the first line of the rendered list removes the previous entries
+	for _, line := range strings.Split(strings.TrimSpace(frrPrefixList(obj)), "\n")[1:] {
+		d.frrLine("bgp", d.state.bgp, line)
+	}
+}
+
+func (s *Server) routeMapDrift(_ context.Context, d *objectDrift, obj *RouteMap) {
+	for _, entry := range obj.Spec.Entries {
+		d.frr("bgp", d.state.bgp, fmt.Sprintf("route-map %s %s %d", routePolicyFrrName(obj.Name), entry.Action, entry.Seq), "")
+	}
+}
+
+func (s *Server) routeDrift(ctx context.Context, d *objectDrift, obj *Route) {
+	parent := routeParent(obj.Name)
+	vrf, ok := s.Vrfs[parent]
+	if !ok {
+		d.add("Vrf %s is missing", parent)
+		return
+	}
+	table := int(vrf.Status.GetRoutingTable())
+	filter := &netlink.Route{Table: table, Dst: routePrefix(obj)}
+	routes, err := s.nLink.RouteListFiltered(ctx, netlink.FAMILY_V4, filter, netlink.RT_FILTER_TABLE|netlink.RT_FILTER_DST)
+	switch {
+	case err != nil:
+		d.add("unable to list the routes of table %d: %v", table, err)
+	case len(routes) == 0:
+		d.add("route %s is missing in table %d", routePrefix(obj), table)
+	}
+	if obj.Spec.Redistribute {
+		d.frr("bgp", d.state.bgp, fmt.Sprintf("router bgp %d vrf %s", s.Gateway.LocalAs, s.vrfKernelName(vrf.Name)), fmt.Sprintf("network %s", routePrefix(obj)))
+	}
+}
+
+func (s *Server) vrfLiteHandoffDrift(ctx context.Context, d *objectDrift, obj *VrfLiteHandoff) {
+	vrfName := s.vrfKernelName(obj.Spec.Vrf)
+	link := d.link(s.handoffKernelName(obj), "vlan")
+	d.master(link, vrfName)
+	if obj.Spec.LocalIPPrefix != nil {
+		ip := make(net.IP, 4)
+		binary.BigEndian.PutUint32(ip, obj.Spec.LocalIPPrefix.Addr.GetV4Addr())
+		d.address(ctx, s, link, &net.IPNet{IP: ip, Mask: net.CIDRMask(int(obj.Spec.LocalIPPrefix.Len), 32)})
+	}
+	d.frr("bgp", d.state.bgp, fmt.Sprintf("router bgp %d vrf %s", s.Gateway.LocalAs, vrfName), fmt.Sprintf("neighbor %s remote-as %d", handoffPeerIP(obj.Spec), obj.Spec.RemoteAs))
+}
+
+func (s *Server) routeLeakDrift(_ context.Context, d *objectDrift, obj *RouteLeak) {
+	header := fmt.Sprintf("router bgp %d vrf %s", s.Gateway.LocalAs, s.vrfKernelName(obj.Spec.DestinationVrf))
+	d.frr("bgp", d.state.bgp, header, "import vrf "+s.vrfKernelName(obj.Spec.SourceVrf))
+}
+
+func (s *Server) bgpPeerDrift(_ context.Context, d *objectDrift, obj *BgpPeer) {
+	neighbor := BgpPeerNeighbor(obj.Spec)
+	line := fmt.Sprintf("neighbor %s remote-as %s", neighbor, bgpPeerRemoteAs(obj.Spec))
+	if obj.Spec.Interface != "" {
+		d.device(obj.Spec.Interface)
+		line = fmt.Sprintf("neighbor %s interface remote-as %s", neighbor, bgpPeerRemoteAs(obj.Spec))
+	}
+	d.frr("bgp", d.state.bgp, fmt.Sprintf("router bgp %d", s.Gateway.LocalAs), line)
+}
+
+func (s *Server) staticFdbEntryDrift(ctx context.Context, d *objectDrift, obj *StaticFdbEntry) {
+	parent := staticFdbEntryParent(obj.Name)
+	bridgeObject, ok := s.Bridges[parent]
+	if !ok {
+		d.add("LogicalBridge %s is missing", parent)
+		return
+	}
+	name := s.portKernelName(obj.Spec.BridgePort)
+	if obj.Spec.RemoteVtep != nil {
+		name = fmt.Sprintf("vni%d", *bridgeObject.Spec.Vni)
+	}
+	link := d.device(name)
+	if link == nil {
+		return
+	}
+	neighs, err := s.nLink.NeighList(ctx, link.Attrs().Index, unix.AF_BRIDGE)
+	if err != nil {
+		d.add("unable to list the fdb entries of interface %s: %v", name, err)
+		return
+	}
+	for _, neigh := range neighs {
+		if neigh.Vlan == int(bridgeObject.Spec.VlanId) && bytes.Equal(neigh.HardwareAddr, obj.Spec.MacAddress) {
+			return
+		}
+	}
+	d.add("fdb entry %s vlan %d is missing on interface %s", net.HardwareAddr(obj.Spec.MacAddress), bridgeObject.Spec.VlanId, name)
+}
+
+func (c childCalls[T]) driftObjects(ctx context.Context, report func(name string, check func(d *objectDrift))) {
+	if c.drift == nil {
+		return
+	}
+	for _, name := range sortedKeys(c.stored) {
+		obj := c.stored[name]
+		report(name, func(d *objectDrift) { c.drift(ctx, d, obj) })
+	}
+}
+
+// GetDrift compares every stored object with the links, bridge vlans, addresses, routes, fdb
+// entries and FRR running configuration programmed for it and reports the discrepancies, e.g.
+// after a manual `ip link set` or a partially failed call. The nftables rules of the
+// SecurityPolicies and NatRules, the rules of the PbrRules and the IPsec states of the
+// TunnelSecurities are not read back. Resync programs the drifted objects again
+func (s *Server) GetDrift(ctx context.Context, _ *GetDriftRequest) (*GetDriftResponse, error) {
+	state, err := s.loadDriftState(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.objectsMu.RLock()
+	defer s.objectsMu.RUnlock()
+	response := &GetDriftResponse{Objects: []ObjectDrift{}}
+	report := func(name string, check func(d *objectDrift)) {
+		if !inTenant(ctx, name) {
+			return
+		}
+		d := &objectDrift{state: state}
+		check(d)
+		if len(d.discrepancies) > 0 {
+			response.Objects = append(response.Objects, ObjectDrift{Name: name, Discrepancies: d.discrepancies})
+		}
+	}
+	used, owned := s.childKinds()
+	for _, kind := range used {
+		kind.driftObjects(ctx, report)
+	}
+	for _, name := range sortedKeys(s.Vrfs) {
+		report(name, func(d *objectDrift) { s.vrfDrift(ctx, d, s.Vrfs[name]) })
+	}
+	for _, name := range sortedKeys(s.Bridges) {
+		report(name, func(d *objectDrift) { s.logicalBridgeDrift(ctx, d, s.Bridges[name]) })
+	}
+	for _, name := range sortedKeys(s.Ports) {
+		report(name, func(d *objectDrift) { s.bridgePortDrift(ctx, d, s.Ports[name]) })
+	}
+	for _, name := range sortedKeys(s.Svis) {
+		report(name, func(d *objectDrift) { s.sviDrift(ctx, d, s.Svis[name]) })
+	}
+	for _, kind := range owned {
+		kind.driftObjects(ctx, report)
+	}
+	return response, nil
+}
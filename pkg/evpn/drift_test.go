@@ -0,0 +1,176 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"reflect"
+	"testing"
+
+	"github.com/philippgille/gokv/gomap"
+	"github.com/stretchr/testify/mock"
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netlink/nl"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/fake"
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils/mocks"
+)
+
+func Test_GetDrift(t *testing.T) {
+	vni := uint32(100)
+	up := netlink.LinkAttrs{Flags: net.FlagUp}
+	link := func(attrs netlink.LinkAttrs, name string, index int, master int) netlink.LinkAttrs {
+		attrs.Name, attrs.Index, attrs.MasterIndex = name, index, master
+		return attrs
+	}
+	inSync := []netlink.Link{
+		&netlink.Vrf{LinkAttrs: link(up, testVrfID, 1, 0), Table: 1000},
+		&netlink.Bridge{LinkAttrs: link(up, "br100", 2, 1)},
+		&netlink.Vxlan{LinkAttrs: link(up, "vni100", 3, 2), VxlanId: 100},
+		&netlink.Bridge{LinkAttrs: link(up, tenantbridgeName, 4, 0)},
+	}
+	zebra := fmt.Sprintf("vrf %s\n vni 100\nexit-vrf\n!\n", testVrfID)
+	bgp := fmt.Sprintf("router bgp 65000 vrf %s\n bgp router-id 10.0.0.1\nexit\n!\n", testVrfID)
+	tests := map[string]struct {
+		links   []netlink.Link
+		zebra   string
+		linkErr error
+		objects []ObjectDrift
+		errCode codes.Code
+	}{
+		"in sync": {
+			links:   inSync,
+			zebra:   zebra,
+			objects: []ObjectDrift{},
+		},
+		"drifted": {
+			links: []netlink.Link{
+				&netlink.Vrf{LinkAttrs: link(netlink.LinkAttrs{}, testVrfID, 1, 0), Table: 1001},
+				&netlink.Bridge{LinkAttrs: link(up, "br100", 2, 0)},
+				&netlink.Bridge{LinkAttrs: link(up, tenantbridgeName, 4, 0)},
+			},
+			zebra: fmt.Sprintf("vrf %s\nexit-vrf\n!\n", testVrfID),
+			objects: []ObjectDrift{{Name: testVrfName, Discrepancies: []string{
+				"interface " + testVrfID + " is administratively down",
+				"interface " + testVrfID + " uses table 1001, expected 1000",
+				"interface br100 has no master, expected master " + testVrfID,
+				"interface vni100 is missing",
+				fmt.Sprintf("zebra configuration of %q is missing %q", "vrf "+testVrfID, "vni 100"),
+			}}},
+		},
+		"links unavailable": {
+			linkErr: errors.New("Failed to list links"),
+			errCode: codes.Unavailable,
+		},
+	}
+
+	// run tests
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			mockNetlink := mocks.NewNetlink(t)
+			mockFrr := mocks.NewFrr(t)
+			opi := NewServerWithArgs(mockNetlink, mockFrr, gomap.NewStore(gomap.DefaultOptions))
+			opi.Gateway.LocalAs = 65000
+			opi.Vrfs[testVrfName] = &pb.Vrf{
+				Name:   testVrfName,
+				Spec:   &pb.VrfSpec{Vni: &vni},
+				Status: &pb.VrfStatus{RoutingTable: 1000},
+			}
+			mockNetlink.EXPECT().LinkList(mock.Anything).Return(tt.links, tt.linkErr).Once()
+			if tt.linkErr == nil {
+				mockNetlink.EXPECT().BridgeVlanList(mock.Anything).Return(map[int32][]*nl.BridgeVlanInfo{}, nil).Once()
+				mockFrr.EXPECT().FrrZebraCmd(mock.Anything, "show running-config").Return(tt.zebra, nil).Once()
+				mockFrr.EXPECT().FrrBgpCmd(mock.Anything, "show running-config").Return(bgp, nil).Once()
+			}
+
+			response, err := opi.GetDrift(context.Background(), &GetDriftRequest{})
+			if status.Code(err) != tt.errCode {
+				t.Fatal("error: expected", tt.errCode, "received", err)
+			}
+			if err != nil {
+				return
+			}
+			if !reflect.DeepEqual(response.Objects, tt.objects) {
+				t.Error("objects: expected", tt.objects, "received", response.Objects)
+			}
+		})
+	}
+}
+
+func Test_FrrSection(t *testing.T) {
+	config := "vrf blue\n vni 100\nexit-vrf\n!\nvrf red\nexit-vrf\n"
+	if section, ok := frrSection(config, "vrf blue"); !ok || section != "vni 100" {
+		t.Error("section: expected", "vni 100", "received", section, ok)
+	}
+	if section, ok := frrSection(config, "vrf red"); !ok || section != "" {
+		t.Error("section: expected empty, received", section, ok)
+	}
+	if _, ok := frrSection(config, "vrf green"); ok {
+		t.Error("section: expected vrf green to be missing")
+	}
+}
+
+func Test_GetDriftChildResources(t *testing.T) {
+	ctx := context.Background()
+	nLink := fake.NewNetlink()
+	frr := fake.NewFrr()
+	opi := NewServerWithArgs(nLink, frr, gomap.NewStore(gomap.DefaultOptions))
+	if _, err := opi.CreateVrf(ctx, &pb.CreateVrfRequest{VrfId: testVrfID, Vrf: protoClone(&testVrf)}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := opi.CreateRoute(ctx, &CreateRouteRequest{Parent: testVrfName, RouteID: testRouteID, Route: testRoute.clone()}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := opi.CreatePrefixList(ctx, &CreatePrefixListRequest{PrefixListID: testPrefixListID, PrefixList: testPrefixList.clone()}); err != nil {
+		t.Fatal(err)
+	}
+	drifted := func() map[string][]string {
+		response, err := opi.GetDrift(ctx, &GetDriftRequest{})
+		if err != nil {
+			t.Fatal("error: expected", nil, "received", err)
+		}
+		objects := map[string][]string{}
+		for _, obj := range response.Objects {
+			objects[obj.Name] = obj.Discrepancies
+		}
+		return objects
+	}
+	if objects := drifted(); objects[testRouteName] != nil || objects[testPrefixListName] != nil {
+		t.Error("objects: expected no drift, received", objects)
+	}
+
+	// e.g. ip route del 10.1.0.0/16 table 1001 and no ip prefix-list run by hand
+	table := opi.Vrfs[testVrfName].Status.RoutingTable
+	route, err := opi.netlinkRoute(ctx, testRoute.clone(), opi.Vrfs[testVrfName])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := nLink.RouteDel(ctx, route); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := frr.FrrBgpCmd(ctx, "configure terminal\nno ip prefix-list "+testPrefixListID+"\nexit"); err != nil {
+		t.Fatal(err)
+	}
+	objects := drifted()
+	routes := []string{fmt.Sprintf("route %s is missing in table %d", routePrefix(testRoute.clone()), table)}
+	if !reflect.DeepEqual(objects[testRouteName], routes) {
+		t.Error("route: expected", routes, "received", objects[testRouteName])
+	}
+	list := []string{
+		fmt.Sprintf("bgp configuration is missing %q", "ip prefix-list "+testPrefixListID+" seq 10 permit 10.0.0.0/8 ge 16 le 24"),
+		fmt.Sprintf("bgp configuration is missing %q", "ip prefix-list "+testPrefixListID+" seq 20 deny 10.0.0.0/8"),
+	}
+	if !reflect.DeepEqual(objects[testPrefixListName], list) {
+		t.Error("prefix-list: expected", list, "received", objects[testPrefixListName])
+	}
+}
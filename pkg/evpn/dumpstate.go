@@ -0,0 +1,235 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils"
+)
+
+// DumpStateRequest is the request to dump the internal state of the server
+// TODO: move to an AdminService in opi-api once the message is agreed upon
+type DumpStateRequest struct{}
+
+// StateAllocators are the values allocated to the objects, by value, to find the conflicts
+type StateAllocators struct {
+	// RoutingTables maps the routing tables to the Vrfs using them
+	RoutingTables map[uint32]string `json:"routingTables"`
+	// Vnis maps the vnis to the LogicalBridges and Vrfs using them
+	Vnis map[uint32]string `json:"vnis"`
+	// Vlans maps the vlans of the tenant bridge to the LogicalBridges using them
+	Vlans map[uint32]string `json:"vlans"`
+}
+
+// OperationState is an operation programming an object in the background
+type OperationState struct {
+	Name   string     `json:"name"`
+	Target string     `json:"target"`
+	Done   bool       `json:"done"`
+	DoneAt *time.Time `json:"doneAt,omitempty"`
+}
+
+// PageTokenState is a page token returned by a List call
+type PageTokenState struct {
+	Token   string    `json:"token"`
+	Offset  int       `json:"offset"`
+	Expires time.Time `json:"expires"`
+	// Snapshot tells whether the result of the first page is still held for the next pages
+	Snapshot bool `json:"snapshot"`
+}
+
+// DumpStateResponse is the internal state of the server, for field debugging. Its layout
+// follows the implementation and can change between releases, the keys are left out
+// TODO: move to an AdminService in opi-api once the message is agreed upon
+type DumpStateResponse struct {
+	// Objects are the stored objects by name, per kind
+	Objects map[string]json.RawMessage `json:"objects"`
+	// Options are the settings kept next to the objects by name, per kind
+	Options    map[string]interface{} `json:"options"`
+	Allocators StateAllocators        `json:"allocators"`
+	// FrrRetries maps the FRR configurations waiting to be retried to their failed attempts
+	FrrRetries map[string]int         `json:"frrRetries"`
+	Operations []OperationState       `json:"operations"`
+	PageTokens []PageTokenState       `json:"pageTokens"`
+	Conditions map[string][]Condition `json:"conditions"`
+	LiveStatus map[string]LiveStatus  `json:"liveStatus"`
+	Standby    bool                   `json:"standby"`
+}
+
+// dumpProtoObjects encodes the objects by name with protojson, as the API returns them
+func dumpProtoObjects[T proto.Message](m map[string]T) (json.RawMessage, error) {
+	objects := map[string]json.RawMessage{}
+	for name, obj := range m {
+		b, err := protojson.Marshal(obj)
+		if err != nil {
+			return nil, err
+		}
+		objects[name] = b
+	}
+	return json.Marshal(objects)
+}
+
+// dumpObjects encodes all the stored objects, the TunnelSecurities without their keys
+func (s *Server) dumpObjects() (map[string]json.RawMessage, error) {
+	securities := map[string]*TunnelSecurity{}
+	for name, security := range s.TunnelSecurities {
+		securities[name] = security.redacted()
+	}
+	objects := map[string]json.RawMessage{}
+	var err error
+	for kind, dump := range map[string]func() (json.RawMessage, error){
+		"logicalBridges":     func() (json.RawMessage, error) { return dumpProtoObjects(s.Bridges) },
+		"bridgePorts":        func() (json.RawMessage, error) { return dumpProtoObjects(s.Ports) },
+		"svis":               func() (json.RawMessage, error) { return dumpProtoObjects(s.Svis) },
+		"vrfs":               func() (json.RawMessage, error) { return dumpProtoObjects(s.Vrfs) },
+		"vrfLiteHandoffs":    func() (json.RawMessage, error) { return json.Marshal(s.Handoffs) },
+		"routes":             func() (json.RawMessage, error) { return json.Marshal(s.Routes) },
+		"routeLeaks":         func() (json.RawMessage, error) { return json.Marshal(s.RouteLeaks) },
+		"bgpPeers":           func() (json.RawMessage, error) { return json.Marshal(s.BgpPeers) },
+		"staticFdbEntries":   func() (json.RawMessage, error) { return json.Marshal(s.FdbEntries) },
+		"securityPolicies":   func() (json.RawMessage, error) { return json.Marshal(s.Policies) },
+		"natRules":           func() (json.RawMessage, error) { return json.Marshal(s.NatRules) },
+		"pbrRules":           func() (json.RawMessage, error) { return json.Marshal(s.PbrRules) },
+		"prefixLists":        func() (json.RawMessage, error) { return json.Marshal(s.PrefixLists) },
+		"routeMaps":          func() (json.RawMessage, error) { return json.Marshal(s.RouteMaps) },
+		"tunnelSecurities":   func() (json.RawMessage, error) { return json.Marshal(securities) },
+		"underlayInterfaces": func() (json.RawMessage, error) { return json.Marshal(s.UnderlayInterfaces) },
+	} {
+		if objects[kind], err = dump(); err != nil {
+			return nil, status.Errorf(codes.Internal, "unable to encode %s: %v", kind, err)
+		}
+	}
+	return objects, nil
+}
+
+// dumpOptions returns the settings kept next to the objects, the MACsec channels without their keys
+func (s *Server) dumpOptions() map[string]interface{} {
+	macsec := map[string]PortMacsec{}
+	for name, channel := range s.PortMacsec {
+		macsec[name] = PortMacsec{Peer: channel.Peer, Encrypt: channel.Encrypt}
+	}
+	return map[string]interface{}{
+		"adopted":          s.Adopted,
+		"kernelNames":      s.KernelNames,
+		"labels":           s.Labels,
+		"multicastGroups":  s.MulticastGroups,
+		"geneveTunnels":    s.GeneveTunnels,
+		"noMacLearning":    s.NoMacLearning,
+		"portMacsec":       macsec,
+		"trunkVlans":       s.TrunkVlans,
+		"anycastGateways":  s.AnycastGateways,
+		"sviAnnouncements": s.SviAnnouncements,
+		"routeTargets":     s.RouteTargets,
+		"asymmetricIrb":    s.AsymmetricIrb,
+		"srv6Vrfs":         s.Srv6Vrfs,
+	}
+}
+
+// dumpAllocators returns the routing tables, vnis and vlans in use
+func (s *Server) dumpAllocators() StateAllocators {
+	allocators := StateAllocators{RoutingTables: map[uint32]string{}, Vnis: map[uint32]string{}, Vlans: map[uint32]string{}}
+	for name, vrf := range s.Vrfs {
+		if table := vrf.GetStatus().GetRoutingTable(); table != 0 {
+			allocators.RoutingTables[table] = name
+		}
+		if vrf.GetSpec().Vni != nil {
+			allocators.Vnis[vrf.GetSpec().GetVni()] = name
+		}
+	}
+	for name, bridge := range s.Bridges {
+		if bridge.GetSpec().Vni != nil {
+			allocators.Vnis[bridge.GetSpec().GetVni()] = name
+		}
+		allocators.Vlans[bridge.GetSpec().GetVlanId()] = name
+	}
+	return allocators
+}
+
+// dumpOperations returns the running and recently finished operations, sorted by name
+func (s *Server) dumpOperations() []OperationState {
+	s.operations.mu.Lock()
+	defer s.operations.mu.Unlock()
+	operations := []OperationState{}
+	for _, name := range sortedKeys(s.operations.byName) {
+		o := s.operations.byName[name]
+		operation := OperationState{Name: name, Target: o.target, Done: o.op.Done}
+		if o.op.Done {
+			doneAt := o.doneAt
+			operation.DoneAt = &doneAt
+		}
+		operations = append(operations, operation)
+	}
+	return operations
+}
+
+// dumpPageTokens returns the stored page tokens, sorted by token
+func (s *Server) dumpPageTokens() ([]PageTokenState, error) {
+	s.paginationMu.Lock()
+	tokens, err := s.loadPageTokens()
+	s.paginationMu.Unlock()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "unable to load pagination tokens: %v", err)
+	}
+	s.listSnapshots.mu.Lock()
+	defer s.listSnapshots.mu.Unlock()
+	pageTokens := []PageTokenState{}
+	for _, token := range sortedKeys(tokens.Fields) {
+		value := tokens.Fields[token]
+		_, snapshot := s.listSnapshots.byToken[token]
+		pageTokens = append(pageTokens, PageTokenState{
+			Token:    token,
+			Offset:   int(value.GetStructValue().GetFields()["offset"].GetNumberValue()),
+			Expires:  pageTokenExpiry(value),
+			Snapshot: snapshot,
+		})
+	}
+	return pageTokens, nil
+}
+
+// DumpState returns the objects, the allocations, the pending FRR retries, the operations,
+// the page tokens and the conditions of the server, to debug it in the field. The keys of
+// the TunnelSecurities and of the MACsec channels are left out, tenants cannot dump the state
+func (s *Server) DumpState(ctx context.Context, _ *DumpStateRequest) (*DumpStateResponse, error) {
+	if tenant := utils.TenantFromContext(ctx); tenant != "" {
+		return nil, status.Errorf(codes.PermissionDenied, "tenant %s cannot dump the state of the server", tenant)
+	}
+	// the objects, options and allocators are read with objectsMu held, as the calls do
+	s.objectsMu.RLock()
+	objects, err := s.dumpObjects()
+	options, allocators := s.dumpOptions(), s.dumpAllocators()
+	s.objectsMu.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+	pageTokens, err := s.dumpPageTokens()
+	if err != nil {
+		return nil, err
+	}
+	s.conditions.mu.Lock()
+	conditions := map[string][]Condition{}
+	for name, objectConditions := range s.conditions.byName {
+		conditions[name] = append([]Condition(nil), objectConditions...)
+	}
+	s.conditions.mu.Unlock()
+	return &DumpStateResponse{
+		Objects:    objects,
+		Options:    options,
+		Allocators: allocators,
+		FrrRetries: s.frrRetries.Pending(),
+		Operations: s.dumpOperations(),
+		PageTokens: pageTokens,
+		Conditions: conditions,
+		LiveStatus: s.GetLiveStatuses(ctx),
+		Standby:    s.IsStandby(),
+	}, nil
+}
@@ -0,0 +1,74 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/philippgille/gokv/gomap"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils/mocks"
+)
+
+func Test_DumpState(t *testing.T) {
+	vni := uint32(100)
+	opi := NewServerWithArgs(mocks.NewNetlink(t), mocks.NewFrr(t), gomap.NewStore(gomap.DefaultOptions))
+	opi.Vrfs[testVrfName] = &pb.Vrf{
+		Name:   testVrfName,
+		Spec:   &pb.VrfSpec{Vni: &vni},
+		Status: &pb.VrfStatus{RoutingTable: 1000},
+	}
+	opi.Bridges[testLogicalBridgeName] = &pb.LogicalBridge{
+		Name: testLogicalBridgeName,
+		Spec: &pb.LogicalBridgeSpec{VlanId: 10},
+	}
+	opi.PortMacsec["//network.opiproject.org/ports/uplink"] = PortMacsec{Key: "secretkey", Peer: "00:11:22:33:44:55", PeerKey: "secretpeerkey"}
+	expires := time.Unix(time.Now().Add(time.Hour).Unix(), 0)
+	if err := opi.savePageToken("token", 50, expires); err != nil {
+		t.Fatal(err)
+	}
+
+	response, err := opi.DumpState(context.Background(), &DumpStateRequest{})
+	if err != nil {
+		t.Fatal("error: expected", nil, "received", err)
+	}
+	allocators := StateAllocators{
+		RoutingTables: map[uint32]string{1000: testVrfName},
+		Vnis:          map[uint32]string{100: testVrfName},
+		Vlans:         map[uint32]string{10: testLogicalBridgeName},
+	}
+	if !reflect.DeepEqual(response.Allocators, allocators) {
+		t.Error("allocators: expected", allocators, "received", response.Allocators)
+	}
+	pageTokens := []PageTokenState{{Token: "token", Offset: 50, Expires: expires}}
+	if !reflect.DeepEqual(response.PageTokens, pageTokens) {
+		t.Error("page tokens: expected", pageTokens, "received", response.PageTokens)
+	}
+	if !strings.Contains(string(response.Objects["vrfs"]), testVrfName) {
+		t.Error("vrfs: expected", testVrfName, "received", string(response.Objects["vrfs"]))
+	}
+	b, err := json.Marshal(response)
+	if err != nil {
+		t.Fatal("error: expected", nil, "received", err)
+	}
+	if strings.Contains(string(b), "secret") {
+		t.Error("keys: expected none, received", string(b))
+	}
+
+	_, err = opi.DumpState(tenantContext("blue"), &DumpStateRequest{})
+	if status.Code(err) != codes.PermissionDenied {
+		t.Error("error: expected", codes.PermissionDenied, "received", err)
+	}
+}
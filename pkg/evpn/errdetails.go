@@ -0,0 +1,51 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"strings"
+
+	"go.einride.tech/aip/fieldbehavior"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// badRequest attaches a BadRequest detail naming the invalid field of the request to err,
+// keeping its code and message, see https://google.aip.dev/193#error-details
+func badRequest(field string, err error) error {
+	if err == nil {
+		return nil
+	}
+	st := status.Convert(err)
+	if len(st.Details()) != 0 {
+		return err
+	}
+	detailed, derr := st.WithDetails(&errdetails.BadRequest{
+		FieldViolations: []*errdetails.BadRequest_FieldViolation{{Field: field, Description: st.Message()}},
+	})
+	if derr != nil {
+		return err
+	}
+	return detailed.Err()
+}
+
+// missingField is the error of a request missing one of its required fields
+func missingField(field string) error {
+	return badRequest(field, status.Error(codes.InvalidArgument, "missing required field: "+field))
+}
+
+// validateRequiredFields checks the fields of the request annotated as required,
+// naming the first missing one in the error details
+func validateRequiredFields(in proto.Message) error {
+	err := fieldbehavior.ValidateRequiredFields(in)
+	if err == nil {
+		return nil
+	}
+	// fieldbehavior only tells the path of the field in its message
+	return badRequest(strings.TrimPrefix(err.Error(), "missing required field: "), status.Error(codes.InvalidArgument, err.Error()))
+}
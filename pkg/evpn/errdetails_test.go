@@ -0,0 +1,84 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"testing"
+
+	"github.com/philippgille/gokv/gomap"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+	pc "github.com/opiproject/opi-api/network/opinetcommon/v1alpha1/gen/go"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils/mocks"
+)
+
+func Test_ValidationErrorDetails(t *testing.T) {
+	opi := NewServerWithArgs(mocks.NewNetlink(t), mocks.NewFrr(t), gomap.NewStore(gomap.DefaultOptions))
+	tests := map[string]struct {
+		call  func() error
+		code  codes.Code
+		field string
+	}{
+		"missing required field": {
+			call: func() error {
+				_, err := opi.CreateVrf(context.Background(), &pb.CreateVrfRequest{Vrf: &pb.Vrf{Spec: &pb.VrfSpec{}}})
+				return err
+			},
+			code:  codes.InvalidArgument,
+			field: "vrf.spec.loopback_ip_prefix",
+		},
+		"vni out of range": {
+			call: func() error {
+				_, err := opi.CreateLogicalBridge(context.Background(), &pb.CreateLogicalBridgeRequest{
+					LogicalBridge: &pb.LogicalBridge{Spec: &pb.LogicalBridgeSpec{VlanId: 10, Vni: proto.Uint32(1 << 24)}},
+				})
+				return err
+			},
+			code:  codes.InvalidArgument,
+			field: "logical_bridge.spec.vni",
+		},
+		"handwritten required field": {
+			call: func() error {
+				_, err := opi.CreateVrfLiteHandoff(context.Background(), &CreateVrfLiteHandoffRequest{})
+				return err
+			},
+			code:  codes.InvalidArgument,
+			field: "vrf_lite_handoff",
+		},
+		"invalid resource ID": {
+			call: func() error {
+				_, err := opi.CreateVrf(context.Background(), &pb.CreateVrfRequest{VrfId: "Blue", Vrf: &pb.Vrf{Spec: &pb.VrfSpec{LoopbackIpPrefix: &pc.IPPrefix{Len: 24}}}})
+				return err
+			},
+			code:  codes.InvalidArgument,
+			field: "vrf_id",
+		},
+	}
+	for testName, tt := range tests {
+		t.Run(testName, func(t *testing.T) {
+			st := status.Convert(tt.call())
+			if st.Code() != tt.code {
+				t.Error("error code: expected", tt.code, "received", st.Code())
+			}
+			if len(st.Details()) != 1 {
+				t.Fatal("details: expected 1, received", st.Details())
+			}
+			badRequest, ok := st.Details()[0].(*errdetails.BadRequest)
+			if !ok || len(badRequest.FieldViolations) != 1 {
+				t.Fatal("detail: expected BadRequest, received", st.Details()[0])
+			}
+			if violation := badRequest.FieldViolations[0]; violation.Field != tt.field || violation.Description != st.Message() {
+				t.Error("field violation: expected", tt.field, st.Message(), "received", violation)
+			}
+		})
+	}
+}
@@ -0,0 +1,205 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils"
+)
+
+// EtagMetadataKey is the grpc response header listing, one "<name>: <etag>" value per object,
+// the etags of the Vrfs, LogicalBridges, BridgePorts and Svis returned by Get, List, Create and
+// Update, to be sent back with utils.IfMatchMetadataKey by the Update or Delete of the object
+// TODO: replace by an etag field once it is added to the messages of opi-api
+const EtagMetadataKey = "x-opi-etag"
+
+// generationsKey is the store key of the generations of the objects, so their etags survive a failover
+const generationsKey = "generations"
+
+// etag is the opaque etag of a generation
+func etag(generation uint64) string {
+	return strconv.FormatUint(generation, 10)
+}
+
+// newGeneration gives a created or updated object the next generation of the server, which
+// changes its etag. The generations are server-wide, an object deleted and created again does
+// not get back the etag of its previous incarnation. With objectsMu held
+func (s *Server) newGeneration(name string) {
+	s.generation++
+	s.Generations[name] = s.generation
+	s.persistGenerations()
+}
+
+// releaseGeneration forgets the generation of a deleted object, with objectsMu held
+func (s *Server) releaseGeneration(name string) {
+	if _, ok := s.Generations[name]; ok {
+		delete(s.Generations, name)
+		s.persistGenerations()
+	}
+}
+
+func (s *Server) persistGenerations() {
+	msg := &structpb.Struct{Fields: make(map[string]*structpb.Value, len(s.Generations))}
+	for name, generation := range s.Generations {
+		msg.Fields[name] = structpb.NewNumberValue(float64(generation))
+	}
+	if err := s.store.Set(generationsKey, msg); err != nil {
+		fmt.Printf("Failed to persist %s: %v", generationsKey, err)
+	}
+}
+
+// loadGenerations restores the etags the replayed objects had before the failover, their
+// Create giving them new ones
+func (s *Server) loadGenerations() error {
+	msg := &structpb.Struct{}
+	found, err := s.store.Get(generationsKey, msg)
+	if err != nil || !found {
+		return err
+	}
+	for name, value := range msg.Fields {
+		if _, ok := s.Generations[name]; !ok {
+			continue
+		}
+		generation := uint64(value.GetNumberValue())
+		s.Generations[name] = generation
+		if generation > s.generation {
+			s.generation = generation
+		}
+	}
+	s.persistGenerations()
+	return nil
+}
+
+// etagCheckedKey marks the context of the Update and Delete calls EtagInterceptor let through
+type etagCheckedKey struct{}
+
+// checkEtag fails with FailedPrecondition when the Update or Delete of the object sends an
+// etag which is no longer its current one, or none when s.RequireEtag is set. The handlers
+// call it with objectsMu held, so two calls sending the same etag cannot both pass it before
+// either changed the object. The objects which do not exist are left to the handler, failing
+// with NotFound or allowed to be missing, and the calls which did not go through
+// EtagInterceptor are not checked
+func (s *Server) checkEtag(ctx context.Context, name string) error {
+	if checked, _ := ctx.Value(etagCheckedKey{}).(bool); !checked {
+		return nil
+	}
+	generation, ok := s.Generations[name]
+	if (!ok && s.lookupObject(name) == nil) || !inTenant(ctx, name) {
+		return nil
+	}
+	expected, sent := utils.MetadataValue(ctx, utils.IfMatchMetadataKey)
+	switch {
+	case !sent && s.RequireEtag:
+		return status.Errorf(codes.FailedPrecondition, "etag of %s required in %s", name, utils.IfMatchMetadataKey)
+	case sent && (!ok || expected != etag(generation)):
+		return status.Errorf(codes.FailedPrecondition, "etag %s of %s is not the current one, %s changed since it was read", expected, name, name)
+	}
+	return nil
+}
+
+// etagsMetadata returns the etags of the objects, the objects without one are left out, with
+// objectsMu held
+func (s *Server) etagsMetadata(names []string) metadata.MD {
+	md := metadata.MD{}
+	for _, name := range names {
+		if generation, ok := s.Generations[name]; ok {
+			md.Append(EtagMetadataKey, fmt.Sprintf("%s: %s", name, etag(generation)))
+		}
+	}
+	return md
+}
+
+// etagTarget returns the object an Update or Delete request changes
+func etagTarget(req interface{}) (string, bool) {
+	switch in := req.(type) {
+	case *pb.UpdateVrfRequest:
+		return in.GetVrf().GetName(), true
+	case *pb.DeleteVrfRequest:
+		return in.GetName(), true
+	case *pb.UpdateLogicalBridgeRequest:
+		return in.GetLogicalBridge().GetName(), true
+	case *pb.DeleteLogicalBridgeRequest:
+		return in.GetName(), true
+	case *pb.UpdateBridgePortRequest:
+		return in.GetBridgePort().GetName(), true
+	case *pb.DeleteBridgePortRequest:
+		return in.GetName(), true
+	case *pb.UpdateSviRequest:
+		return in.GetSvi().GetName(), true
+	case *pb.DeleteSviRequest:
+		return in.GetName(), true
+	}
+	return "", false
+}
+
+// etagNames returns the objects a response carries
+func etagNames(resp interface{}) []string {
+	var names []string
+	switch out := resp.(type) {
+	case *pb.Vrf:
+		names = append(names, out.GetName())
+	case *pb.LogicalBridge:
+		names = append(names, out.GetName())
+	case *pb.BridgePort:
+		names = append(names, out.GetName())
+	case *pb.Svi:
+		names = append(names, out.GetName())
+	case *pb.ListVrfsResponse:
+		for _, obj := range out.Vrfs {
+			names = append(names, obj.GetName())
+		}
+	case *pb.ListLogicalBridgesResponse:
+		for _, obj := range out.LogicalBridges {
+			names = append(names, obj.GetName())
+		}
+	case *pb.ListBridgePortsResponse:
+		for _, obj := range out.BridgePorts {
+			names = append(names, obj.GetName())
+		}
+	case *pb.ListSvisResponse:
+		for _, obj := range out.Svis {
+			names = append(names, obj.GetName())
+		}
+	}
+	return names
+}
+
+// EtagInterceptor has the Update and Delete calls of the Vrfs, LogicalBridges, BridgePorts and
+// Svis check their utils.IfMatchMetadataKey etag, so two controllers cannot silently overwrite
+// each other's changes, and returns the etags of the objects of the responses in the
+// EtagMetadataKey header. The calls the server makes itself, e.g. the cascading deletes, are
+// not checked
+func (s *Server) EtagInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if _, ok := etagTarget(req); ok {
+			ctx = context.WithValue(ctx, etagCheckedKey{}, true)
+		}
+		resp, err := handler(ctx, req)
+		if err != nil || utils.IsValidateOnly(ctx) {
+			return resp, err
+		}
+		s.objectsMu.RLock()
+		md := s.etagsMetadata(etagNames(resp))
+		s.objectsMu.RUnlock()
+		if len(md) != 0 {
+			if err := grpc.SetHeader(ctx, md); err != nil {
+				log.Printf("Failed to send the etags of %v: %v", info.FullMethod, err)
+			}
+		}
+		return resp, nil
+	}
+}
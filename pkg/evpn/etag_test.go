@@ -0,0 +1,170 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"log"
+	"net"
+	"testing"
+
+	"github.com/philippgille/gokv/gomap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/fake"
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils"
+)
+
+func Test_EtagInterceptor(t *testing.T) {
+	ctx := context.Background()
+	opi := NewServerWithArgs(fake.NewNetlink("eth1"), fake.NewFrr(), gomap.NewStore(gomap.DefaultOptions))
+	listener := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer(grpc.UnaryInterceptor(opi.EtagInterceptor()))
+	pb.RegisterBridgePortServiceServer(server, opi)
+	go func() {
+		if err := server.Serve(listener); err != nil {
+			log.Fatal(err)
+		}
+	}()
+	defer server.Stop()
+	conn, err := grpc.DialContext(ctx, "", grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+			return listener.Dial()
+		}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	client := pb.NewBridgePortServiceClient(conn)
+	name := resourceIDToFullName("ports", "eth1")
+	ifMatch := func(etag string) context.Context {
+		return metadata.AppendToOutgoingContext(ctx, utils.IfMatchMetadataKey, etag)
+	}
+	spec := &pb.BridgePortSpec{MacAddress: []byte{0x02, 0x00, 0x00, 0x00, 0x00, 0x01}, Ptype: pb.BridgePortType_TRUNK}
+
+	var header metadata.MD
+	if _, err := client.CreateBridgePort(ctx, &pb.CreateBridgePortRequest{BridgePortId: "eth1", BridgePort: &pb.BridgePort{Spec: spec}}, grpc.Header(&header)); err != nil {
+		t.Fatal("CreateBridgePort: expected", nil, "received", err)
+	}
+	created := etag(opi.Generations[name])
+	if expected := []string{name + ": " + created}; len(header.Get(EtagMetadataKey)) != 1 || header.Get(EtagMetadataKey)[0] != expected[0] {
+		t.Error("create etag: expected", expected, "received", header.Get(EtagMetadataKey))
+	}
+	header = nil
+	if _, err := client.ListBridgePorts(ctx, &pb.ListBridgePortsRequest{}, grpc.Header(&header)); err != nil || len(header.Get(EtagMetadataKey)) != 1 {
+		t.Error("list etags: expected 1, received", header.Get(EtagMetadataKey), err)
+	}
+
+	// the update with the etag read succeeds and changes it, the one of the other controller
+	// still sending the etag it read fails
+	if _, err := client.UpdateBridgePort(ifMatch(created), &pb.UpdateBridgePortRequest{BridgePort: &pb.BridgePort{Name: name, Spec: spec}}); err != nil {
+		t.Fatal("UpdateBridgePort: expected", nil, "received", err)
+	}
+	if updated := etag(opi.Generations[name]); updated == created {
+		t.Error("updated etag: expected a new one, received", updated)
+	}
+	_, err = client.UpdateBridgePort(ifMatch(created), &pb.UpdateBridgePortRequest{BridgePort: &pb.BridgePort{Name: name, Spec: spec}})
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Error("stale update: expected", codes.FailedPrecondition, "received", err)
+	}
+	if _, err := client.DeleteBridgePort(ifMatch(created), &pb.DeleteBridgePortRequest{Name: name}); status.Code(err) != codes.FailedPrecondition {
+		t.Error("stale delete: expected", codes.FailedPrecondition, "received", err)
+	}
+
+	opi.RequireEtag = true
+	if _, err := client.DeleteBridgePort(ctx, &pb.DeleteBridgePortRequest{Name: name}); status.Code(err) != codes.FailedPrecondition {
+		t.Error("delete without etag: expected", codes.FailedPrecondition, "received", err)
+	}
+	if _, err := client.DeleteBridgePort(ifMatch(etag(opi.Generations[name])), &pb.DeleteBridgePortRequest{Name: name}); err != nil {
+		t.Error("delete: expected", nil, "received", err)
+	}
+	if _, ok := opi.Generations[name]; ok {
+		t.Error("deleted etag: expected none, received", opi.Generations[name])
+	}
+	// a missing object is left to the handler
+	if _, err := client.DeleteBridgePort(ctx, &pb.DeleteBridgePortRequest{Name: name, AllowMissing: true}); err != nil {
+		t.Error("delete missing: expected", nil, "received", err)
+	}
+}
+
+func Test_EtagInterceptorConcurrentCalls(t *testing.T) {
+	ctx := context.Background()
+	opi := NewServerWithArgs(fake.NewNetlink("eth1"), fake.NewFrr(), gomap.NewStore(gomap.DefaultOptions))
+	listener := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer(grpc.UnaryInterceptor(opi.EtagInterceptor()))
+	pb.RegisterBridgePortServiceServer(server, opi)
+	go func() {
+		if err := server.Serve(listener); err != nil {
+			log.Fatal(err)
+		}
+	}()
+	defer server.Stop()
+	conn, err := grpc.DialContext(ctx, "", grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+			return listener.Dial()
+		}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	client := pb.NewBridgePortServiceClient(conn)
+	name := resourceIDToFullName("ports", "eth1")
+	spec := &pb.BridgePortSpec{MacAddress: []byte{0x02, 0x00, 0x00, 0x00, 0x00, 0x01}, Ptype: pb.BridgePortType_TRUNK}
+	if _, err := client.CreateBridgePort(ctx, &pb.CreateBridgePortRequest{BridgePortId: "eth1", BridgePort: &pb.BridgePort{Spec: spec}}); err != nil {
+		t.Fatal("CreateBridgePort: expected", nil, "received", err)
+	}
+	opi.objectsMu.RLock()
+	created := etag(opi.Generations[name])
+	opi.objectsMu.RUnlock()
+
+	// the controllers which read the same etag race to update, only one of them wins
+	const count = 4
+	errs := make(chan error, count)
+	for i := 0; i < count; i++ {
+		go func() {
+			ctx := metadata.AppendToOutgoingContext(ctx, utils.IfMatchMetadataKey, created)
+			_, err := client.UpdateBridgePort(ctx, &pb.UpdateBridgePortRequest{BridgePort: &pb.BridgePort{Name: name, Spec: spec}})
+			errs <- err
+		}()
+	}
+	updated := 0
+	for i := 0; i < count; i++ {
+		err := <-errs
+		switch status.Code(err) {
+		case codes.OK:
+			updated++
+		case codes.FailedPrecondition:
+		default:
+			t.Error("error: expected", codes.FailedPrecondition, "received", err)
+		}
+	}
+	if updated != 1 {
+		t.Error("updates: expected", 1, "received", updated)
+	}
+}
+
+func Test_LoadGenerations(t *testing.T) {
+	store := gomap.NewStore(gomap.DefaultOptions)
+	opi := NewServerWithArgs(fake.NewNetlink(), fake.NewFrr(), store)
+	opi.generation = 41
+	opi.putVrf(&pb.Vrf{Name: testVrfName, Spec: &pb.VrfSpec{}})
+
+	// the replayed Vrf is given a new generation by its Create, then its previous one back
+	standby := NewServerWithArgs(fake.NewNetlink(), fake.NewFrr(), store)
+	standby.Generations[testVrfName] = 1
+	if err := standby.loadGenerations(); err != nil {
+		t.Fatal("error: expected", nil, "received", err)
+	}
+	if standby.Generations[testVrfName] != 42 || standby.generation != 42 {
+		t.Error("generation: expected", 42, "received", standby.Generations[testVrfName], standby.generation)
+	}
+}
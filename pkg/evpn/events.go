@@ -0,0 +1,181 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils"
+)
+
+// busEventTypes maps the watch events to the resource events of the event bus
+var busEventTypes = map[utils.WatchEventType]utils.BusEventType{
+	utils.WatchAdded:    utils.BusResourceCreated,
+	utils.WatchModified: utils.BusResourceModified,
+	utils.WatchDeleted:  utils.BusResourceDeleted,
+}
+
+// operState is the link and BGP peer state last seen, to publish the changes only
+type operState struct {
+	links map[string]bool
+	peers map[string]string
+}
+
+// bgpSummary is the part of "show bgp vrf all summary json" holding the peer states,
+// keyed by vrf then address family
+type bgpSummary map[string]map[string]json.RawMessage
+
+type bgpAddressFamily struct {
+	Peers map[string]struct {
+		State string `json:"state"`
+	} `json:"peers"`
+}
+
+// StartEventPublisher publishes the resource changes as they happen, and the link and BGP
+// peer state changes found polling the kernel and FRR every interval, until ctx is done. The
+// link and peer changes are recorded for ListEvents and dampened, and only recorded when pub
+// is nil
+func (s *Server) StartEventPublisher(ctx context.Context, pub utils.EventPublisher, interval time.Duration) {
+	if pub != nil {
+		go s.publishResourceEvents(ctx, pub)
+	}
+	go func() {
+		state := &operState{}
+		s.pollOperState(ctx, pub, state)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.pollOperState(ctx, pub, state)
+			}
+		}
+	}()
+}
+
+func (s *Server) publishResourceEvents(ctx context.Context, pub utils.EventPublisher) {
+	w := s.Watch()
+	defer func() { s.Unwatch(w) }()
+	for {
+		ev, err := w.Next(ctx)
+		if errors.Is(err, utils.ErrWatcherEvicted) {
+			// the bus is only best effort, keep going with the next events
+			log.Printf("Event publisher fell behind, resource events were dropped")
+			w = s.Watch()
+			continue
+		}
+		if err != nil {
+			return
+		}
+		if busType, ok := busEventTypes[ev.Type]; ok {
+			pub.Publish(utils.BusEvent{Type: busType, Name: ev.Name, State: ev.State})
+		}
+	}
+}
+
+// pollOperState publishes the changes since the previous poll, the first poll only records
+// the current state, then the changes of the resources no longer dampened that were suppressed
+func (s *Server) pollOperState(ctx context.Context, pub utils.EventPublisher, state *operState) {
+	if links, err := s.linkStates(ctx); err != nil {
+		fmt.Printf("Failed to list links: %v", err)
+	} else {
+		if state.links != nil {
+			for name, up := range links {
+				if was, ok := state.links[name]; ok && was == up {
+					continue
+				}
+				busType := utils.BusLinkDown
+				if up {
+					busType = utils.BusLinkUp
+				}
+				s.publishOperEvent(pub, name, utils.BusEvent{Type: busType, Interface: name})
+			}
+		}
+		state.links = links
+	}
+	if peers, err := s.BgpPeerStates(ctx); err != nil {
+		fmt.Printf("Failed to get BGP peers: %v", err)
+	} else {
+		if state.peers != nil {
+			for key, peerState := range peers {
+				if state.peers[key] == peerState {
+					continue
+				}
+				vrf, peer, _ := strings.Cut(key, "|")
+				s.publishOperEvent(pub, key, utils.BusEvent{Type: utils.BusBgpPeerState, Vrf: vrf, Peer: peer, State: peerState})
+			}
+			// a peer removed from FRR is reported as down
+			for key := range state.peers {
+				if _, ok := peers[key]; !ok {
+					vrf, peer, _ := strings.Cut(key, "|")
+					s.publishOperEvent(pub, key, utils.BusEvent{Type: utils.BusBgpPeerState, Vrf: vrf, Peer: peer, State: "Deleted"})
+				}
+			}
+		}
+		state.peers = peers
+	}
+	for _, ev := range s.flaps.reuse(time.Now(), s.Dampening) {
+		if pub != nil {
+			pub.Publish(ev)
+		}
+	}
+}
+
+func (s *Server) linkStates(ctx context.Context) (map[string]bool, error) {
+	links, err := s.nLink.LinkList(ctx)
+	if err != nil {
+		return nil, err
+	}
+	states := map[string]bool{}
+	for _, link := range links {
+		states[link.Attrs().Name] = linkIsUp(link.Attrs())
+	}
+	return states, nil
+}
+
+// BgpPeerStates returns the state of every BGP peer seen by FRR, e.g. Established, keyed by
+// vrf|peer
+func (s *Server) BgpPeerStates(ctx context.Context) (map[string]string, error) {
+	data, err := s.frr.FrrBgpCmd(ctx, "show bgp vrf all summary json")
+	if err != nil {
+		return nil, err
+	}
+	summary := bgpSummary{}
+	if err := unmarshalFrrJSON(data, &summary); err != nil {
+		return nil, err
+	}
+	states := map[string]string{}
+	for vrf, families := range summary {
+		for _, raw := range families {
+			family := bgpAddressFamily{}
+			// vrfId, vrfName and the like are not address families
+			if err := json.Unmarshal(raw, &family); err != nil {
+				continue
+			}
+			for peer, info := range family.Peers {
+				states[vrf+"|"+peer] = info.State
+			}
+		}
+	}
+	return states, nil
+}
+
+// unmarshalFrrJSON decodes the output of a vtysh json command, the vtysh prompt and the
+// command echo surround the JSON
+func unmarshalFrrJSON(data string, v interface{}) error {
+	start, end := strings.Index(data, "{"), strings.LastIndex(data, "}")
+	if start < 0 || end < start {
+		return fmt.Errorf("no JSON in FRR output: %q", data)
+	}
+	return json.Unmarshal([]byte(data[start:end+1]), v)
+}
@@ -0,0 +1,71 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"testing"
+
+	"github.com/philippgille/gokv/gomap"
+	"github.com/stretchr/testify/mock"
+	"github.com/vishvananda/netlink"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils"
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils/mocks"
+)
+
+type fakePublisher struct {
+	events []utils.BusEvent
+}
+
+func (p *fakePublisher) Publish(ev utils.BusEvent) {
+	p.events = append(p.events, ev)
+}
+
+const bgpSummaryEstablished = `show bgp vrf all summary json
+{
+"default":{"ipv4Unicast":{"routerId":"10.0.0.1","peers":{"10.0.0.2":{"state":"Established"}}}},
+"blue":{"ipv4Unicast":{"peers":{"10.1.0.2":{"state":"Active"}}}}
+}
+bgpd# `
+
+const bgpSummaryIdle = `{
+"default":{"ipv4Unicast":{"peers":{"10.0.0.2":{"state":"Idle"}}}}
+}`
+
+func Test_pollOperState(t *testing.T) {
+	mockNetlink := mocks.NewNetlink(t)
+	mockFrr := mocks.NewFrr(t)
+	opi := NewServerWithArgs(mockNetlink, mockFrr, gomap.NewStore(gomap.DefaultOptions))
+	pub := &fakePublisher{}
+	state := &operState{}
+
+	eth0 := &netlink.Device{LinkAttrs: netlink.LinkAttrs{Name: "eth0", OperState: netlink.OperUp}}
+	mockNetlink.EXPECT().LinkList(mock.Anything).Return([]netlink.Link{eth0}, nil).Once()
+	mockFrr.EXPECT().FrrBgpCmd(mock.Anything, mock.Anything).Return(bgpSummaryEstablished, nil).Once()
+	opi.pollOperState(context.Background(), pub, state)
+	if len(pub.events) != 0 {
+		t.Error("first poll: expected no events, received", pub.events)
+	}
+
+	eth0down := &netlink.Device{LinkAttrs: netlink.LinkAttrs{Name: "eth0", OperState: netlink.OperDown}}
+	mockNetlink.EXPECT().LinkList(mock.Anything).Return([]netlink.Link{eth0down}, nil).Once()
+	mockFrr.EXPECT().FrrBgpCmd(mock.Anything, mock.Anything).Return(bgpSummaryIdle, nil).Once()
+	opi.pollOperState(context.Background(), pub, state)
+
+	want := []utils.BusEvent{
+		{Type: utils.BusLinkDown, Interface: "eth0"},
+		{Type: utils.BusBgpPeerState, Vrf: "default", Peer: "10.0.0.2", State: "Idle"},
+		{Type: utils.BusBgpPeerState, Vrf: "blue", Peer: "10.1.0.2", State: "Deleted"},
+	}
+	if len(pub.events) != len(want) {
+		t.Fatal("events: expected", want, "received", pub.events)
+	}
+	for i := range want {
+		if pub.events[i] != want[i] {
+			t.Error("event: expected", want[i], "received", pub.events[i])
+		}
+	}
+}
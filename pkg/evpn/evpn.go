@@ -6,10 +6,15 @@
 package evpn
 
 import (
+	"bytes"
 	"crypto/rand"
 	"fmt"
 	"log"
+	"sync"
+	"sync/atomic"
+	"time"
 
+	"cloud.google.com/go/longrunning/autogen/longrunningpb"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/trace"
 
@@ -25,7 +30,11 @@ import (
 )
 
 const (
-	tenantbridgeName = "br-tenant"
+	tenantbridgeName  = "br-tenant"
+	watchBufferSize   = 128
+	watchStaleTimeout = time.Minute
+	frrRetryInitial   = time.Second
+	frrRetryMax       = time.Minute
 )
 
 // Server represents the Server object
@@ -34,14 +43,135 @@ type Server struct {
 	pe.UnimplementedSviServiceServer
 	pe.UnimplementedLogicalBridgeServiceServer
 	pe.UnimplementedBridgePortServiceServer
+	longrunningpb.UnimplementedOperationsServer
 	Bridges    map[string]*pe.LogicalBridge
 	Ports      map[string]*pe.BridgePort
 	Svis       map[string]*pe.Svi
 	Vrfs       map[string]*pe.Vrf
-	Pagination map[string]int
-	nLink      utils.Netlink
-	frr        utils.Frr
-	tracer     trace.Tracer
+	Handoffs   map[string]*VrfLiteHandoff
+	Routes     map[string]*Route
+	RouteLeaks map[string]*RouteLeak
+	BgpPeers   map[string]*BgpPeer
+	FdbEntries map[string]*StaticFdbEntry
+	Policies   map[string]*SecurityPolicy
+	NatRules   map[string]*NatRule
+	PbrRules   map[string]*PbrRule
+	Adopted    map[string]bool
+	// PrefixLists and RouteMaps are the routing policies referenced by the BGP sessions
+	PrefixLists map[string]*PrefixList
+	RouteMaps   map[string]*RouteMap
+	// TunnelSecurities encrypt the tunnels to remote VTEPs with IPsec
+	TunnelSecurities map[string]*TunnelSecurity
+	// UnderlayInterfaces are the VTEP loopback and the uplinks of the underlay
+	UnderlayInterfaces map[string]*UnderlayInterface
+	// KernelNames maps object names to their kernel interface names, when those had to be shortened
+	KernelNames map[string]string
+	// Labels maps object names to their labels and annotations, for the labeled objects only
+	Labels map[string]*ObjectLabels
+	// Generations maps object names to the generation their etag is made of, see EtagInterceptor
+	Generations map[string]uint64
+	// RequireEtag makes the Update and Delete calls without an etag fail, instead of overwriting
+	// the object whatever its changes since it was read
+	RequireEtag bool
+	// LiveRead makes Get and List check the kernel devices of the objects and
+	// return the missing ones as degraded instead of failing the whole call
+	LiveRead bool
+	// RejectDefaultVlan makes vlan 1 invalid for LogicalBridges and VrfLiteHandoffs
+	RejectDefaultVlan bool
+	// Quotas limits the number of objects of the whole server, see ParseQuotas
+	Quotas map[string]int
+	// TenantQuotas is the max number of objects of each type a tenant can create
+	TenantQuotas map[string]int
+	// Gateway is the local AS, router-id and VTEP loopback of the gateway
+	Gateway GatewayConfig
+	// Vxlan are the tunnel parameters of the vni devices
+	Vxlan VxlanOptions
+	// MulticastGroups maps the LogicalBridges flooding to a multicast group, instead of
+	// using ingress replication, to their group
+	MulticastGroups map[string]string
+	// GeneveTunnels are the geneve tunnels of the LogicalBridges using one instead of vxlan
+	GeneveTunnels map[string]GeneveTunnel
+	// NoMacLearning are the BridgePorts created with MAC learning off
+	NoMacLearning map[string]bool
+	// PortMacsec are the MACsec secure channels of the BridgePorts created with one
+	PortMacsec map[string]PortMacsec
+	// TrunkVlans are the native vlan and vlan translations of the TRUNK BridgePorts having any
+	TrunkVlans map[string]TrunkVlans
+	// MacAgeing is how long the learned MAC addresses are kept, the dataplane default when 0
+	MacAgeing time.Duration
+	// AnycastGateways are the Svis acting as distributed anycast gateways
+	AnycastGateways map[string]bool
+	// SviAnnouncements are the announcements of the gateway addresses of the Svis not
+	// using the defaults, see DefaultSviAnnouncement
+	SviAnnouncements map[string]SviAnnouncement
+	// RouteTargets maps the Vrfs created with an explicit route distinguisher or route
+	// targets to them, the others use the ones FRR auto-derives from their vni
+	RouteTargets map[string]VrfRouteTargets
+	// AsymmetricIrb are the Vrfs routing between their Svis over the vnis of their LogicalBridges,
+	// instead of an L3 vni
+	AsymmetricIrb map[string]bool
+	// Srv6Vrfs maps the Vrfs using the SRv6 overlay, instead of an L3 vni, to their routing
+	// table, the function of their SID
+	Srv6Vrfs map[string]uint32
+	// Srv6 is the locator the SIDs of the SRv6 Vrfs are allocated from
+	Srv6 Srv6Options
+	// HwOffload makes the BridgePorts report whether their forwarding is offloaded to the
+	// switchdev driver of their port, see checkHwOffload
+	HwOffload bool
+	// Pim is the PIM configuration of the multicast underlay
+	Pim PimOptions
+	// MacMobility are the thresholds of the duplicate address detection of EVPN
+	MacMobility MacMobilityOptions
+	// Dampening are the thresholds of the dampening of the link and BGP peer events
+	Dampening DampeningOptions
+	// DataplaneName is the backend set with SetDataplane, as named by --dataplane
+	DataplaneName string
+	// ProgrammingTimeout bounds the netlink and FRR programming of one object, on top of the
+	// deadline of the call, not bounded when 0
+	ProgrammingTimeout time.Duration
+	// VrfIsolation tells whether the Vrfs are vrf devices or network namespaces, see --vrf_isolation
+	VrfIsolation VrfIsolation
+	// PageTokenTTL is how long the NextPageToken of a List call can be used
+	PageTokenTTL  time.Duration
+	nLink         utils.Netlink
+	frr           utils.Frr
+	lldp          utils.Lldp
+	sysfs         utils.Sysfs
+	nft           utils.Nftables
+	ipsec         utils.Ipsec
+	macsec        utils.Macsec
+	diagnostics   utils.Diagnostics
+	announcer     utils.Announcer
+	dataplane     Dataplane
+	tracer        trace.Tracer
+	slo           *utils.SloTracker
+	audit         *utils.AuditLog
+	standby       atomic.Bool
+	leaseRenewed  time.Time
+	captures      atomic.Int32
+	announcements sync.WaitGroup
+	events        *utils.WatchBroker
+	monitor       *statusMonitor
+	counters      *objectCounters
+	flaps         *flapTracker
+	vtepProber    *vtepProber
+	orphans       *orphanSweeper
+	conditions    *conditionSet
+	frrRetries    *utils.RetryQueue
+	faults        *utils.FaultInjector
+	operations    *operationSet
+	// objectsMu guards the Vrfs, Bridges, Ports and Svis, their child resources and the maps
+	// keyed by their names, held by their calls, for writing by the ones changing them, and by the goroutines
+	// reading or changing them in the background. It is taken before statusMonitor.mu
+	objectsMu     sync.RWMutex
+	paginationMu  sync.Mutex
+	listSnapshots *listSnapshotSet
+	indexes       *objectIndexes
+	lifecycles    *lifecycleSet
+	// generation is the last generation given to an object, see newGeneration
+	generation uint64
+	configFile string
+	baseConfig RuntimeConfig
 	store      gokv.Store
 }
 
@@ -64,23 +194,106 @@ func NewServerWithArgs(nLink utils.Netlink, frr utils.Frr, store gokv.Store) *Se
 	if store == nil {
 		log.Panic("nil for Store is not allowed")
 	}
-	return &Server{
-		Bridges:    make(map[string]*pe.LogicalBridge),
-		Ports:      make(map[string]*pe.BridgePort),
-		Svis:       make(map[string]*pe.Svi),
-		Vrfs:       make(map[string]*pe.Vrf),
-		Pagination: make(map[string]int),
-		nLink:      nLink,
-		frr:        frr,
-		tracer:     otel.Tracer(""),
-		store:      store,
+	s := &Server{
+		Bridges:            make(map[string]*pe.LogicalBridge),
+		Ports:              make(map[string]*pe.BridgePort),
+		Svis:               make(map[string]*pe.Svi),
+		Vrfs:               make(map[string]*pe.Vrf),
+		Handoffs:           make(map[string]*VrfLiteHandoff),
+		Routes:             make(map[string]*Route),
+		RouteLeaks:         make(map[string]*RouteLeak),
+		BgpPeers:           make(map[string]*BgpPeer),
+		FdbEntries:         make(map[string]*StaticFdbEntry),
+		Policies:           make(map[string]*SecurityPolicy),
+		NatRules:           make(map[string]*NatRule),
+		PbrRules:           make(map[string]*PbrRule),
+		Adopted:            make(map[string]bool),
+		PrefixLists:        make(map[string]*PrefixList),
+		RouteMaps:          make(map[string]*RouteMap),
+		TunnelSecurities:   make(map[string]*TunnelSecurity),
+		UnderlayInterfaces: make(map[string]*UnderlayInterface),
+		KernelNames:        make(map[string]string),
+		Labels:             make(map[string]*ObjectLabels),
+		Generations:        make(map[string]uint64),
+		Quotas:             make(map[string]int),
+		TenantQuotas:       make(map[string]int),
+		Gateway:            DefaultGatewayConfig(),
+		Vxlan:              DefaultVxlanOptions(),
+		MulticastGroups:    make(map[string]string),
+		GeneveTunnels:      make(map[string]GeneveTunnel),
+		RouteTargets:       make(map[string]VrfRouteTargets),
+		AsymmetricIrb:      make(map[string]bool),
+		Srv6Vrfs:           make(map[string]uint32),
+		Srv6:               DefaultSrv6Options(),
+		AnycastGateways:    make(map[string]bool),
+		SviAnnouncements:   make(map[string]SviAnnouncement),
+		TrunkVlans:         make(map[string]TrunkVlans),
+		NoMacLearning:      make(map[string]bool),
+		PortMacsec:         make(map[string]PortMacsec),
+		Pim:                DefaultPimOptions(),
+		MacMobility:        DefaultMacMobilityOptions(),
+		Dampening:          DefaultDampeningOptions(),
+		DataplaneName:      "linux",
+		PageTokenTTL:       defaultPageTokenTTL,
+		ProgrammingTimeout: defaultProgrammingTimeout,
+		nLink:              nLink,
+		frr:                frr,
+		lldp:               utils.NewLldpWrapper(),
+		sysfs:              utils.NewSysfsWrapper(),
+		nft:                utils.NewNftablesWrapper(),
+		ipsec:              utils.NewIpsecWrapper(),
+		macsec:             utils.NewMacsecWrapper(),
+		diagnostics:        utils.NewDiagnosticsWrapper(),
+		announcer:          utils.NewAnnouncerWrapper(),
+		tracer:             otel.Tracer(""),
+		slo:                utils.DefaultSloTracker(),
+		audit:              utils.DefaultAuditLog(),
+		events:             utils.NewWatchBroker(watchBufferSize, watchStaleTimeout),
+		conditions:         newConditionSet(),
+		flaps:              newFlapTracker(),
+		operations:         newOperationSet(),
+		listSnapshots:      newListSnapshotSet(),
+		indexes:            newObjectIndexes(),
+		lifecycles:         newLifecycleSet(),
+		store:              store,
 	}
+	s.frrRetries = utils.NewRetryQueue(frrRetryInitial, frrRetryMax, s.reportFrrRetry)
+	s.dataplane = &linuxDataplane{s: s}
+	return s
 }
 
 func resourceIDToFullName(container string, resourceID string) string {
 	return fmt.Sprintf("//network.opiproject.org/%s/%s", container, resourceID)
 }
 
+// checkSameSpec fails with AlreadyExists when a Create call reuses the name of a
+// stored object with a different spec
+func checkSameSpec(name string, stored proto.Message, requested proto.Message) error {
+	if proto.Equal(stored, requested) {
+		return nil
+	}
+	msg := fmt.Sprintf("%s already exists with a different spec", name)
+	return status.Error(codes.AlreadyExists, msg)
+}
+
+// checkSameChildSpec is checkSameSpec for the child resources, whose specs are not messages
+// and are compared by their utils.MarshalJSON encoding
+func checkSameChildSpec(name string, stored interface{}, requested interface{}) error {
+	a, err := utils.MarshalJSON(stored)
+	if err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	b, err := utils.MarshalJSON(requested)
+	if err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	if bytes.Equal(a, b) {
+		return nil
+	}
+	msg := fmt.Sprintf("%s already exists with a different spec", name)
+	return status.Error(codes.AlreadyExists, msg)
+}
+
 func protoClone[T proto.Message](protoStruct T) T {
 	return proto.Clone(protoStruct).(T)
 }
@@ -97,7 +310,7 @@ func generateRandMAC() ([]byte, error) {
 	return buf, nil
 }
 
-func extractPagination(pageSize int32, pageToken string, pagination map[string]int) (size int, offset int, err error) {
+func (s *Server) extractPagination(pageSize int32, pageToken string) (size int, offset int, err error) {
 	const (
 		maxPageSize     = 250
 		defaultPageSize = 50
@@ -115,12 +328,10 @@ func extractPagination(pageSize int32, pageToken string, pagination map[string]i
 	// fetch offset from the database using opaque token
 	offset = 0
 	if pageToken != "" {
-		var ok bool
-		offset, ok = pagination[pageToken]
-		if !ok {
-			return -1, -1, status.Errorf(codes.NotFound, "unable to find pagination token %s", pageToken)
+		offset, err = s.pageTokenOffset(pageToken)
+		if err != nil {
+			return -1, -1, err
 		}
-		log.Printf("Found offset %d from pagination token: %s", offset, pageToken)
 	}
 	return size, offset, nil
 }
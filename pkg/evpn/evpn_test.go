@@ -31,6 +31,7 @@ func dialer(opi *Server) func(context.Context, string) (net.Conn, error) {
 	pe.RegisterBridgePortServiceServer(server, opi)
 	pe.RegisterVrfServiceServer(server, opi)
 	pe.RegisterSviServiceServer(server, opi)
+	RegisterListStreamServer(server, opi)
 
 	go func() {
 		if err := server.Serve(listener); err != nil {
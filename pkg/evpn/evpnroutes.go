@@ -0,0 +1,253 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils"
+)
+
+// EvpnRoute is a type-2 MAC/IP, type-3 IMET or type-5 IP prefix route of the BGP l2vpn evpn table
+type EvpnRoute struct {
+	// Type is the EVPN route type, 2, 3 or 5
+	Type int `json:"type"`
+	// Rd is the route distinguisher the route was advertised with
+	Rd string `json:"rd"`
+	// Prefix is the route as FRR prints it, e.g.: [2]:[0]:[48]:[aa:bb:cc:dd:ee:ff]
+	Prefix string `json:"prefix"`
+	// Vni is taken from the first route target, FRR auto-derives them as RT:AS:VNI
+	Vni uint32 `json:"vni,omitempty"`
+	// Mac is the MAC address of a type-2 route
+	Mac string `json:"mac,omitempty"`
+	// IP is the host of a type-2 route, the originating VTEP of a type-3 route and the
+	// prefix address of a type-5 route
+	IP string `json:"ip,omitempty"`
+	// IPLen is the prefix length of a type-5 route
+	IPLen int `json:"ipLen,omitempty"`
+	// Nexthop is the VTEP the route points to
+	Nexthop string `json:"nexthop,omitempty"`
+	// Peer is the BGP peer the route was learned from, empty for local routes
+	Peer string `json:"peer,omitempty"`
+	// Best tells whether this path is the one selected
+	Best bool `json:"best"`
+	// RouteTargets are the route targets of the extended communities
+	RouteTargets []string `json:"routeTargets,omitempty"`
+}
+
+// GetEvpnRoutesRequest is the request to inspect the EVPN routes
+// TODO: move to opi-api once the message is agreed upon
+type GetEvpnRoutesRequest struct {
+	// Vni is the vni of a LogicalBridge or a Vrf to get the routes of, all of them when 0
+	Vni uint32
+	// Vrf is the name of a Vrf to get the routes of, instead of its vni
+	Vrf string
+}
+
+// GetEvpnRoutesResponse lists the EVPN routes, sorted by type, rd and prefix
+// TODO: move to opi-api once the message is agreed upon
+type GetEvpnRoutesResponse struct {
+	Routes []EvpnRoute `json:"routes"`
+}
+
+// evpnPath is the part of a path of "show bgp l2vpn evpn json" the routes are made of
+type evpnPath struct {
+	RouteType         int             `json:"routeType"`
+	Mac               string          `json:"mac"`
+	IP                string          `json:"ip"`
+	IPLen             int             `json:"ipLen"`
+	PeerID            string          `json:"peerId"`
+	Bestpath          json.RawMessage `json:"bestpath"`
+	ExtendedCommunity struct {
+		String string `json:"string"`
+	} `json:"extendedCommunity"`
+	Nexthops []struct {
+		IP string `json:"ip"`
+	} `json:"nexthops"`
+}
+
+// GetEvpnRoutes returns the type-2, type-3 and type-5 routes FRR knows of, filtered by vni or
+// Vrf, to debug the overlay through the same API it is configured with
+func (s *Server) GetEvpnRoutes(ctx context.Context, in *GetEvpnRoutesRequest) (*GetEvpnRoutesResponse, error) {
+	if in.Vni != 0 && in.Vrf != "" {
+		msg := "only one of vni and vrf can be set"
+		return nil, badRequest("vrf", status.Error(codes.InvalidArgument, msg))
+	}
+	vni := in.Vni
+	if in.Vrf != "" {
+		vrf, ok := s.Vrfs[in.Vrf]
+		if !ok || !inTenant(ctx, in.Vrf) {
+			err := status.Errorf(codes.NotFound, "unable to find key %s", in.Vrf)
+			return nil, err
+		}
+		if vrf.Spec.Vni == nil {
+			err := status.Errorf(codes.FailedPrecondition, "vrf %s has no vni, it has no EVPN routes", in.Vrf)
+			return nil, err
+		}
+		vni = *vrf.Spec.Vni
+	}
+	vnis := s.tenantVnis(ctx)
+	if vni != 0 && vnis != nil && !vnis[vni] {
+		err := status.Errorf(codes.NotFound, "unable to find vni %d", vni)
+		return nil, err
+	}
+	data, err := s.frr.FrrBgpCmd(ctx, "show bgp l2vpn evpn json")
+	if err != nil {
+		err = status.Errorf(codes.Unavailable, "unable to get EVPN routes from FRR: %v", err)
+		return nil, err
+	}
+	routes, err := parseEvpnRoutes(data)
+	if err != nil {
+		err = status.Errorf(codes.Internal, "unable to parse EVPN routes from FRR: %v", err)
+		return nil, err
+	}
+	response := &GetEvpnRoutesResponse{Routes: []EvpnRoute{}}
+	for _, route := range routes {
+		if vni != 0 && !route.hasVni(func(v uint32) bool { return v == vni }) {
+			continue
+		}
+		if vnis != nil && !route.hasVni(func(v uint32) bool { return vnis[v] }) {
+			continue
+		}
+		response.Routes = append(response.Routes, route)
+	}
+	return response, nil
+}
+
+// tenantVnis returns the vnis of the LogicalBridges and Vrfs of the tenant of the call, nil
+// when the call is not made for a tenant and sees all of them
+func (s *Server) tenantVnis(ctx context.Context) map[uint32]bool {
+	if utils.TenantFromContext(ctx) == "" {
+		return nil
+	}
+	vnis := map[uint32]bool{}
+	for name, bridge := range s.Bridges {
+		if bridge.Spec.Vni != nil && inTenant(ctx, name) {
+			vnis[*bridge.Spec.Vni] = true
+		}
+	}
+	for name, vrf := range s.Vrfs {
+		if vrf.Spec.Vni != nil && inTenant(ctx, name) {
+			vnis[*vrf.Spec.Vni] = true
+		}
+	}
+	return vnis
+}
+
+// parseEvpnRoutes parses "show bgp l2vpn evpn json", keyed by rd then prefix, the other
+// keys (e.g.: bgpLocalRouterId, numPrefix) are skipped
+func parseEvpnRoutes(data string) ([]EvpnRoute, error) {
+	table := map[string]json.RawMessage{}
+	if err := unmarshalFrrJSON(data, &table); err != nil {
+		return nil, err
+	}
+	routes := []EvpnRoute{}
+	for rd, raw := range table {
+		prefixes := map[string]json.RawMessage{}
+		if err := json.Unmarshal(raw, &prefixes); err != nil {
+			continue
+		}
+		for prefix, raw := range prefixes {
+			entry := struct {
+				Paths []json.RawMessage `json:"paths"`
+			}{}
+			if err := json.Unmarshal(raw, &entry); err != nil {
+				continue
+			}
+			paths, err := evpnPaths(entry.Paths)
+			if err != nil {
+				return nil, fmt.Errorf("invalid paths of %s %s: %w", rd, prefix, err)
+			}
+			for _, path := range paths {
+				if path.RouteType != 2 && path.RouteType != 3 && path.RouteType != 5 {
+					continue
+				}
+				routes = append(routes, newEvpnRoute(rd, prefix, path))
+			}
+		}
+	}
+	sort.SliceStable(routes, func(i, j int) bool {
+		if routes[i].Type != routes[j].Type {
+			return routes[i].Type < routes[j].Type
+		}
+		if routes[i].Rd != routes[j].Rd {
+			return routes[i].Rd < routes[j].Rd
+		}
+		return routes[i].Prefix < routes[j].Prefix
+	})
+	return routes, nil
+}
+
+// evpnPaths decodes the paths of a prefix, older FRR versions group them in arrays of multipaths
+func evpnPaths(raws []json.RawMessage) ([]evpnPath, error) {
+	paths := []evpnPath{}
+	for _, raw := range raws {
+		if strings.HasPrefix(strings.TrimSpace(string(raw)), "[") {
+			var group []evpnPath
+			if err := json.Unmarshal(raw, &group); err != nil {
+				return nil, err
+			}
+			paths = append(paths, group...)
+			continue
+		}
+		var path evpnPath
+		if err := json.Unmarshal(raw, &path); err != nil {
+			return nil, err
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+func newEvpnRoute(rd string, prefix string, path evpnPath) EvpnRoute {
+	route := EvpnRoute{
+		Type:   path.RouteType,
+		Rd:     rd,
+		Prefix: prefix,
+		Mac:    path.Mac,
+		IP:     path.IP,
+		// bestpath is true in older FRR versions, an object with the selection reason in newer ones
+		Best: len(path.Bestpath) > 0 && string(path.Bestpath) != "false",
+	}
+	if path.RouteType == 5 {
+		route.IPLen = path.IPLen
+	}
+	if path.PeerID != "(unspec)" {
+		route.Peer = path.PeerID
+	}
+	if len(path.Nexthops) > 0 {
+		route.Nexthop = path.Nexthops[0].IP
+	}
+	for _, community := range strings.Fields(path.ExtendedCommunity.String) {
+		if strings.HasPrefix(community, "RT:") {
+			route.RouteTargets = append(route.RouteTargets, strings.TrimPrefix(community, "RT:"))
+		}
+	}
+	// a type-2 route of a symmetric IRB carries the RT of the Vrf too, the first one is the LogicalBridge
+	route.hasVni(func(vni uint32) bool {
+		route.Vni = vni
+		return true
+	})
+	return route
+}
+
+// hasVni reports whether the vni of one of the route targets of the route matches
+func (r *EvpnRoute) hasVni(match func(vni uint32) bool) bool {
+	for _, rt := range r.RouteTargets {
+		vni, err := strconv.ParseUint(rt[strings.LastIndex(rt, ":")+1:], 10, 32)
+		if err == nil && match(uint32(vni)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,172 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/philippgille/gokv/gomap"
+	"github.com/stretchr/testify/mock"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils/mocks"
+)
+
+const bgpEvpnRoutes = `show bgp l2vpn evpn json
+{
+"bgpTableVersion":4,
+"bgpLocalRouterId":"10.0.0.1",
+"defaultLocPrf":100,
+"localAS":65000,
+"10.0.0.1:2":{
+  "rd":"10.0.0.1:2",
+  "[3]:[0]:[32]:[10.0.0.1]":{
+    "prefix":"[3]:[0]:[32]:[10.0.0.1]",
+    "prefixLen":352,
+    "paths":[{"valid":true,"bestpath":{"overall":true},"routeType":3,"ethTag":0,"ipLen":32,"ip":"10.0.0.1","peerId":"(unspec)","extendedCommunity":{"string":"RT:65000:10 ET:8"},"nexthops":[{"ip":"10.0.0.1","afi":"ipv4","used":true}]}]
+  }
+},
+"10.0.0.2:3":{
+  "rd":"10.0.0.2:3",
+  "[2]:[0]:[48]:[aa:bb:cc:dd:ee:ff]:[32]:[10.1.0.5]":{
+    "prefix":"[2]:[0]:[48]:[aa:bb:cc:dd:ee:ff]:[32]:[10.1.0.5]",
+    "prefixLen":352,
+    "paths":[[{"valid":true,"bestpath":true,"routeType":2,"ethTag":0,"macLen":48,"mac":"aa:bb:cc:dd:ee:ff","ipLen":32,"ip":"10.1.0.5","peerId":"10.0.0.2","extendedCommunity":{"string":"RT:65000:10 RT:65000:1000 ET:8 Rmac:00:00:00:00:00:02"},"nexthops":[{"ip":"10.0.0.2","afi":"ipv4","used":true}]}]]
+  },
+  "[5]:[0]:[24]:[10.2.0.0]":{
+    "prefix":"[5]:[0]:[24]:[10.2.0.0]",
+    "prefixLen":352,
+    "paths":[{"valid":true,"routeType":5,"ethTag":0,"ipLen":24,"ip":"10.2.0.0","peerId":"10.0.0.2","extendedCommunity":{"string":"RT:65000:1000 ET:8"},"nexthops":[{"ip":"10.0.0.2","afi":"ipv4","used":true}]}]
+  }
+},
+"numPrefix":3,
+"totalPrefix":3
+}
+bgpd# `
+
+var (
+	testEvpnType2Route = EvpnRoute{
+		Type:         2,
+		Rd:           "10.0.0.2:3",
+		Prefix:       "[2]:[0]:[48]:[aa:bb:cc:dd:ee:ff]:[32]:[10.1.0.5]",
+		Vni:          10,
+		Mac:          "aa:bb:cc:dd:ee:ff",
+		IP:           "10.1.0.5",
+		Nexthop:      "10.0.0.2",
+		Peer:         "10.0.0.2",
+		Best:         true,
+		RouteTargets: []string{"65000:10", "65000:1000"},
+	}
+	testEvpnType3Route = EvpnRoute{
+		Type:         3,
+		Rd:           "10.0.0.1:2",
+		Prefix:       "[3]:[0]:[32]:[10.0.0.1]",
+		Vni:          10,
+		IP:           "10.0.0.1",
+		Nexthop:      "10.0.0.1",
+		Best:         true,
+		RouteTargets: []string{"65000:10"},
+	}
+	testEvpnType5Route = EvpnRoute{
+		Type:         5,
+		Rd:           "10.0.0.2:3",
+		Prefix:       "[5]:[0]:[24]:[10.2.0.0]",
+		Vni:          1000,
+		IP:           "10.2.0.0",
+		IPLen:        24,
+		Nexthop:      "10.0.0.2",
+		Peer:         "10.0.0.2",
+		RouteTargets: []string{"65000:1000"},
+	}
+)
+
+func Test_GetEvpnRoutes(t *testing.T) {
+	tests := map[string]struct {
+		in      *GetEvpnRoutesRequest
+		frr     bool
+		frrErr  error
+		out     []EvpnRoute
+		errCode codes.Code
+	}{
+		"all routes": {
+			in:  &GetEvpnRoutesRequest{},
+			frr: true,
+			out: []EvpnRoute{testEvpnType2Route, testEvpnType3Route, testEvpnType5Route},
+		},
+		"routes of a LogicalBridge vni": {
+			in:  &GetEvpnRoutesRequest{Vni: 10},
+			frr: true,
+			out: []EvpnRoute{testEvpnType2Route, testEvpnType3Route},
+		},
+		"routes of a Vrf": {
+			in:  &GetEvpnRoutesRequest{Vrf: testVrfName},
+			frr: true,
+			out: []EvpnRoute{testEvpnType2Route, testEvpnType5Route},
+		},
+		"unknown Vrf": {
+			in:      &GetEvpnRoutesRequest{Vrf: resourceIDToFullName("vrfs", "unknown")},
+			errCode: codes.NotFound,
+		},
+		"vni and Vrf": {
+			in:      &GetEvpnRoutesRequest{Vni: 10, Vrf: testVrfName},
+			errCode: codes.InvalidArgument,
+		},
+		"FRR failure": {
+			in:      &GetEvpnRoutesRequest{},
+			frr:     true,
+			frrErr:  errors.New("vtysh is not running"),
+			errCode: codes.Unavailable,
+		},
+	}
+	for testName, tt := range tests {
+		t.Run(testName, func(t *testing.T) {
+			mockFrr := mocks.NewFrr(t)
+			opi := NewServerWithArgs(mocks.NewNetlink(t), mockFrr, gomap.NewStore(gomap.DefaultOptions))
+			opi.Vrfs[testVrfName] = &pb.Vrf{Name: testVrfName, Spec: &pb.VrfSpec{Vni: proto.Uint32(1000)}}
+			if tt.frr {
+				mockFrr.EXPECT().FrrBgpCmd(mock.Anything, "show bgp l2vpn evpn json").Return(bgpEvpnRoutes, tt.frrErr).Once()
+			}
+			response, err := opi.GetEvpnRoutes(context.Background(), tt.in)
+			if status.Code(err) != tt.errCode {
+				t.Error("error: expected", tt.errCode, "received", err)
+			}
+			if err != nil {
+				return
+			}
+			if !reflect.DeepEqual(response.Routes, tt.out) {
+				t.Error("routes: expected", tt.out, "received", response.Routes)
+			}
+		})
+	}
+}
+
+func Test_GetEvpnRoutesInTenant(t *testing.T) {
+	mockFrr := mocks.NewFrr(t)
+	opi := NewServerWithArgs(mocks.NewNetlink(t), mockFrr, gomap.NewStore(gomap.DefaultOptions))
+	bridge := tenantFullName("acme", "bridges", "bridge1")
+	opi.Bridges[bridge] = &pb.LogicalBridge{Name: bridge, Spec: &pb.LogicalBridgeSpec{VlanId: 10, Vni: proto.Uint32(10)}}
+
+	// the routes of the vnis of other tenants are not returned
+	mockFrr.EXPECT().FrrBgpCmd(mock.Anything, mock.Anything).Return(bgpEvpnRoutes, nil).Once()
+	response, err := opi.GetEvpnRoutes(tenantContext("acme"), &GetEvpnRoutesRequest{})
+	if err != nil {
+		t.Fatal("error: expected", nil, "received", err)
+	}
+	if len(response.Routes) != 2 {
+		t.Error("routes: expected 2 routes of vni 10, received", response.Routes)
+	}
+	_, err = opi.GetEvpnRoutes(tenantContext("acme"), &GetEvpnRoutesRequest{Vni: 1000})
+	if status.Code(err) != codes.NotFound {
+		t.Error("error: expected", codes.NotFound, "received", err)
+	}
+}
@@ -0,0 +1,36 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"log"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils"
+)
+
+// EnableFaultInjection wraps the netlink and FRR calls of the server with the faults of the
+// runtime config, so the rollbacks, the FRR retries and the Degraded statuses can be tested
+// end to end. For testing only, it has to be called before the dataplane is set
+func (s *Server) EnableFaultInjection() {
+	if s.faults != nil {
+		return
+	}
+	log.Printf("Fault injection enabled, the netlink and FRR calls fail as the config says")
+	s.faults = utils.NewFaultInjector()
+	s.nLink = utils.NewFaultyNetlink(s.nLink, s.faults)
+	s.frr = utils.NewFaultyFrr(s.frr, s.faults)
+}
+
+// faultRules returns the faults injected, none when the fault injection is disabled
+func (s *Server) faultRules() []utils.FaultRule {
+	if s.faults == nil {
+		return nil
+	}
+	rules := s.faults.Rules()
+	if len(rules) == 0 {
+		return nil
+	}
+	return rules
+}
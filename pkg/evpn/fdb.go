@@ -0,0 +1,233 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	pc "github.com/opiproject/opi-api/network/opinetcommon/v1alpha1/gen/go"
+
+	"go.einride.tech/aip/resourceid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// StaticFdbEntry pins a MAC address of a LogicalBridge to a BridgePort or a remote VTEP,
+// child resource of a LogicalBridge, the entry is in the vlan of the LogicalBridge and is
+// neither learned, aged out nor flushed
+// TODO: move to opi-api once the message is agreed upon
+type StaticFdbEntry struct {
+	Name string
+	Spec *StaticFdbEntrySpec
+}
+
+// StaticFdbEntrySpec is the desired configuration of a StaticFdbEntry
+type StaticFdbEntrySpec struct {
+	// MacAddress is the pinned MAC address
+	MacAddress []byte
+	// BridgePort is the name of the BridgePort of the LogicalBridge the MAC address is behind
+	BridgePort string
+	// RemoteVtep is the VTEP the MAC address is behind, instead of BridgePort, it requires a vni
+	RemoteVtep *pc.IPAddress
+}
+
+// CreateStaticFdbEntryRequest is the request to create a StaticFdbEntry in a LogicalBridge
+type CreateStaticFdbEntryRequest struct {
+	// Parent is the name of the LogicalBridge
+	Parent           string
+	StaticFdbEntryID string
+	StaticFdbEntry   *StaticFdbEntry
+}
+
+// DeleteStaticFdbEntryRequest is the request to delete a StaticFdbEntry
+type DeleteStaticFdbEntryRequest struct {
+	Name         string
+	AllowMissing bool
+}
+
+// ListStaticFdbEntriesRequest is the request to list StaticFdbEntries of a LogicalBridge
+type ListStaticFdbEntriesRequest struct {
+	// Parent is the name of the LogicalBridge
+	Parent    string
+	PageSize  int32
+	PageToken string
+}
+
+// ListStaticFdbEntriesResponse is the response of listing StaticFdbEntries
+type ListStaticFdbEntriesResponse struct {
+	StaticFdbEntries []*StaticFdbEntry
+	NextPageToken    string
+}
+
+func (e *StaticFdbEntry) clone() *StaticFdbEntry {
+	if e == nil {
+		return nil
+	}
+	c := &StaticFdbEntry{Name: e.Name}
+	if e.Spec != nil {
+		spec := *e.Spec
+		spec.MacAddress = append([]byte(nil), e.Spec.MacAddress...)
+		if e.Spec.RemoteVtep != nil {
+			spec.RemoteVtep = protoClone(e.Spec.RemoteVtep)
+		}
+		c.Spec = &spec
+	}
+	return c
+}
+
+func sortStaticFdbEntries(entries []*StaticFdbEntry) {
+	sort.Slice(entries, func(i int, j int) bool {
+		return entries[i].Name < entries[j].Name
+	})
+}
+
+func staticFdbEntryParent(name string) string {
+	// path.Dir would collapse the leading "//" of the full resource name
+	return name[:strings.LastIndex(name, "/fdbentries/")]
+}
+
+// CreateStaticFdbEntry executes the creation of the static MAC address
+func (s *Server) CreateStaticFdbEntry(ctx context.Context, in *CreateStaticFdbEntryRequest) (*StaticFdbEntry, error) {
+	// check input correctness
+	if err := s.validateCreateStaticFdbEntryRequest(in); err != nil {
+		return nil, err
+	}
+	// see https://google.aip.dev/133#user-specified-ids
+	resourceID := resourceid.NewSystemGenerated()
+	if in.StaticFdbEntryID != "" {
+		log.Printf("client provided the ID of a resource %v, ignoring the name field %v", in.StaticFdbEntryID, in.StaticFdbEntry.Name)
+		resourceID = in.StaticFdbEntryID
+	}
+	in.StaticFdbEntry.Name = fmt.Sprintf("%s/fdbentries/%s", in.Parent, resourceID)
+	s.objectsMu.Lock()
+	defer s.objectsMu.Unlock()
+	// idempotent API when called with same key, should return same object
+	obj, ok := s.FdbEntries[in.StaticFdbEntry.Name]
+	if ok && inTenant(ctx, in.Parent) {
+		// a different spec under the same key is a conflict, not a retry
+		if err := checkSameChildSpec(obj.Name, obj.Spec, in.StaticFdbEntry.Spec); err != nil {
+			return nil, err
+		}
+		log.Printf("Already existing StaticFdbEntry with id %v", in.StaticFdbEntry.Name)
+		return obj.clone(), nil
+	}
+	// now get LogicalBridge to fetch the vlan and vni
+	bridge, ok := s.Bridges[in.Parent]
+	if !ok || !inTenant(ctx, in.Parent) {
+		err := status.Errorf(codes.NotFound, "unable to find key %s", in.Parent)
+		return nil, err
+	}
+	if in.StaticFdbEntry.Spec.BridgePort != "" {
+		port, ok := s.Ports[in.StaticFdbEntry.Spec.BridgePort]
+		if !ok || !inTenant(ctx, in.StaticFdbEntry.Spec.BridgePort) {
+			err := status.Errorf(codes.NotFound, "unable to find key %s", in.StaticFdbEntry.Spec.BridgePort)
+			return nil, err
+		}
+		if !portInBridge(port, in.Parent) {
+			err := status.Errorf(codes.FailedPrecondition, "port %s is not in %s", port.Name, in.Parent)
+			return nil, err
+		}
+	} else if bridge.Spec.Vni == nil {
+		err := status.Errorf(codes.FailedPrecondition, "a remote_vtep requires a vni on %s", in.Parent)
+		return nil, err
+	}
+	// the bridge keeps a single entry per MAC address and vlan
+	mac := string(in.StaticFdbEntry.Spec.MacAddress)
+	for _, entry := range s.FdbEntries {
+		if staticFdbEntryParent(entry.Name) == in.Parent && string(entry.Spec.MacAddress) == mac {
+			err := status.Errorf(codes.AlreadyExists, "MAC address of %s already exists as %s", in.StaticFdbEntry.Name, entry.Name)
+			return nil, err
+		}
+	}
+	if err := s.dataplane.CreateStaticFdbEntry(ctx, in.StaticFdbEntry, bridge); err != nil {
+		s.forgetStatus(in.StaticFdbEntry.Name)
+		return nil, status.Convert(err).Err()
+	}
+	// save object to the database
+	response := in.StaticFdbEntry.clone()
+	s.FdbEntries[in.StaticFdbEntry.Name] = response
+	persistObjects(s, "fdbentries", s.FdbEntries)
+	return response.clone(), nil
+}
+
+// DeleteStaticFdbEntry deletes a static MAC address, learning applies again
+func (s *Server) DeleteStaticFdbEntry(ctx context.Context, in *DeleteStaticFdbEntryRequest) (*emptypb.Empty, error) {
+	// check input correctness
+	if err := s.validateDeleteStaticFdbEntryRequest(in); err != nil {
+		return nil, err
+	}
+	s.objectsMu.Lock()
+	defer s.objectsMu.Unlock()
+	return s.deleteStaticFdbEntry(ctx, in)
+}
+
+// deleteStaticFdbEntry deletes a validated static MAC address, with objectsMu held
+func (s *Server) deleteStaticFdbEntry(ctx context.Context, in *DeleteStaticFdbEntryRequest) (*emptypb.Empty, error) {
+	// fetch object from the database
+	obj, ok := s.FdbEntries[in.Name]
+	if !ok || !inTenant(ctx, in.Name) {
+		if in.AllowMissing {
+			return &emptypb.Empty{}, nil
+		}
+		err := status.Errorf(codes.NotFound, "unable to find key %s", in.Name)
+		return nil, err
+	}
+	// fetch object from the database
+	parent := staticFdbEntryParent(obj.Name)
+	bridge, ok := s.Bridges[parent]
+	if !ok {
+		err := status.Errorf(codes.NotFound, "unable to find key %s", parent)
+		return nil, err
+	}
+	if err := s.dataplane.DeleteStaticFdbEntry(ctx, obj, bridge); err != nil {
+		return nil, status.Convert(err).Err()
+	}
+	// remove from the Database
+	delete(s.FdbEntries, obj.Name)
+	persistObjects(s, "fdbentries", s.FdbEntries)
+	s.forgetStatus(obj.Name)
+	return &emptypb.Empty{}, nil
+}
+
+// ListStaticFdbEntries lists static MAC addresses of a LogicalBridge
+func (s *Server) ListStaticFdbEntries(ctx context.Context, in *ListStaticFdbEntriesRequest) (*ListStaticFdbEntriesResponse, error) {
+	// check input correctness
+	if err := s.validateListStaticFdbEntriesRequest(in); err != nil {
+		return nil, err
+	}
+	if !inTenant(ctx, in.Parent) {
+		err := status.Errorf(codes.NotFound, "unable to find key %s", in.Parent)
+		return nil, err
+	}
+	// fetch pagination from the database, calculate size and offset
+	size, offset, perr := s.extractPagination(in.PageSize, in.PageToken)
+	if perr != nil {
+		return nil, perr
+	}
+	s.objectsMu.RLock()
+	defer s.objectsMu.RUnlock()
+	// fetch object from the database, the following pages are cut from the snapshot of the first one
+	Blobarray, token, err := listPage(ctx, s, "staticFdbEntries", in.Parent, in.PageToken, offset, size, func() []*StaticFdbEntry {
+		Blobarray := []*StaticFdbEntry{}
+		for _, entry := range s.FdbEntries {
+			if staticFdbEntryParent(entry.Name) != in.Parent {
+				continue
+			}
+			Blobarray = append(Blobarray, entry.clone())
+		}
+		// sort is needed, since MAP is unsorted in golang, and we might get different results
+		sortStaticFdbEntries(Blobarray)
+		return Blobarray
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &ListStaticFdbEntriesResponse{StaticFdbEntries: Blobarray, NextPageToken: token}, nil
+}
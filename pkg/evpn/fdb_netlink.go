@@ -0,0 +1,100 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"log"
+	"net"
+
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// netlinkStaticFdbEntry returns the static entry of the tenant bridge, on the port or on the
+// vni device of the LogicalBridge, and the entry of the vni device pointing to the remote VTEP
+func (s *Server) netlinkStaticFdbEntry(ctx context.Context, obj *StaticFdbEntry, bridge *pb.LogicalBridge) (*netlink.Neigh, *netlink.Neigh, error) {
+	name := s.portKernelName(obj.Spec.BridgePort)
+	if obj.Spec.RemoteVtep != nil {
+		name = fmt.Sprintf("vni%d", *bridge.Spec.Vni)
+	}
+	iface, err := s.nLink.LinkByName(ctx, name)
+	if err != nil {
+		err := status.Errorf(codes.NotFound, "unable to find key %s", name)
+		return nil, nil, err
+	}
+	master := &netlink.Neigh{
+		LinkIndex:    iface.Attrs().Index,
+		Family:       unix.AF_BRIDGE,
+		Flags:        netlink.NTF_MASTER,
+		State:        netlink.NUD_NOARP,
+		Vlan:         int(bridge.Spec.VlanId),
+		HardwareAddr: obj.Spec.MacAddress,
+	}
+	if obj.Spec.RemoteVtep == nil {
+		return master, nil, nil
+	}
+	vtep := make(net.IP, 4)
+	binary.BigEndian.PutUint32(vtep, obj.Spec.RemoteVtep.GetV4Addr())
+	remote := &netlink.Neigh{
+		LinkIndex:    iface.Attrs().Index,
+		Family:       unix.AF_BRIDGE,
+		Flags:        netlink.NTF_SELF,
+		State:        netlink.NUD_PERMANENT,
+		IP:           vtep,
+		HardwareAddr: obj.Spec.MacAddress,
+	}
+	return master, remote, nil
+}
+
+func (s *Server) netlinkCreateStaticFdbEntry(ctx context.Context, obj *StaticFdbEntry, bridge *pb.LogicalBridge) error {
+	master, remote, err := s.netlinkStaticFdbEntry(ctx, obj, bridge)
+	if err != nil {
+		return err
+	}
+	// Example: bridge fdb replace aa:bb:cc:00:00:41 dev eth2 master static vlan 10
+	log.Printf("Creating StaticFdbEntry %v", master)
+	if err := s.nLink.NeighSet(ctx, master); err != nil {
+		fmt.Printf("Failed to replace fdb entry: %v", err)
+		return err
+	}
+	if remote == nil {
+		return nil
+	}
+	// Example: bridge fdb append aa:bb:cc:00:00:41 dev vni1000 dst 10.0.0.2 self permanent
+	if err := s.nLink.NeighAppend(ctx, remote); err != nil {
+		fmt.Printf("Failed to append fdb entry: %v", err)
+		return err
+	}
+	return nil
+}
+
+func (s *Server) netlinkDeleteStaticFdbEntry(ctx context.Context, obj *StaticFdbEntry, bridge *pb.LogicalBridge) error {
+	master, remote, err := s.netlinkStaticFdbEntry(ctx, obj, bridge)
+	if err != nil {
+		return err
+	}
+	// Example: bridge fdb del aa:bb:cc:00:00:41 dev vni1000 dst 10.0.0.2 self
+	if remote != nil {
+		if err := s.nLink.NeighDel(ctx, remote); err != nil {
+			fmt.Printf("Failed to delete fdb entry: %v", err)
+			return err
+		}
+	}
+	// Example: bridge fdb del aa:bb:cc:00:00:41 dev eth2 master vlan 10
+	log.Printf("Deleting StaticFdbEntry %v", master)
+	if err := s.nLink.NeighDel(ctx, master); err != nil {
+		fmt.Printf("Failed to delete fdb entry: %v", err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,362 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"reflect"
+	"testing"
+
+	"github.com/philippgille/gokv/gomap"
+	"github.com/stretchr/testify/mock"
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+	pc "github.com/opiproject/opi-api/network/opinetcommon/v1alpha1/gen/go"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils"
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils/mocks"
+)
+
+var (
+	testStaticFdbEntryID   = "opi-mac8"
+	testStaticFdbEntryName = fmt.Sprintf("%s/fdbentries/%s", testLogicalBridgeName, testStaticFdbEntryID)
+	testStaticFdbEntry     = StaticFdbEntry{
+		Spec: &StaticFdbEntrySpec{
+			MacAddress: []byte{0xaa, 0xbb, 0xcc, 0x00, 0x00, 0x41},
+			BridgePort: testBridgePortName,
+		},
+	}
+	testStaticFdbEntryWithName = StaticFdbEntry{
+		Name: testStaticFdbEntryName,
+		Spec: testStaticFdbEntry.Spec,
+	}
+	testStaticFdbEntryKernel = &netlink.Neigh{
+		LinkIndex:    5,
+		Family:       unix.AF_BRIDGE,
+		Flags:        netlink.NTF_MASTER,
+		State:        netlink.NUD_NOARP,
+		Vlan:         22,
+		HardwareAddr: net.HardwareAddr{0xaa, 0xbb, 0xcc, 0x00, 0x00, 0x41},
+	}
+	testRemoteVtep = &pc.IPAddress{Af: pc.IpAf_IP_AF_INET, V4OrV6: &pc.IPAddress_V4Addr{V4Addr: 167772163}}
+)
+
+func Test_CreateStaticFdbEntry(t *testing.T) {
+	tests := map[string]struct {
+		id      string
+		in      *StaticFdbEntry
+		out     *StaticFdbEntry
+		errCode codes.Code
+		errMsg  string
+		exist   bool
+		on      func(mockNetlink *mocks.Netlink, errMsg string)
+	}{
+		"illegal resource_id": {
+			id:      "CapitalLettersNotAllowed",
+			in:      &testStaticFdbEntry,
+			out:     nil,
+			errCode: codes.InvalidArgument,
+			errMsg:  fmt.Sprintf("user-settable ID must only contain lowercase, numbers and hyphens (%v)", "got: 'C' in position 0"),
+			exist:   false,
+			on:      nil,
+		},
+		"no required mac_address field": {
+			id:      testStaticFdbEntryID,
+			in:      &StaticFdbEntry{Spec: &StaticFdbEntrySpec{BridgePort: testBridgePortName}},
+			out:     nil,
+			errCode: codes.InvalidArgument,
+			errMsg:  "missing required field: static_fdb_entry.spec.mac_address",
+			exist:   false,
+			on:      nil,
+		},
+		"both port and remote vtep": {
+			id:      testStaticFdbEntryID,
+			in:      &StaticFdbEntry{Spec: &StaticFdbEntrySpec{MacAddress: testStaticFdbEntry.Spec.MacAddress, BridgePort: testBridgePortName, RemoteVtep: testRemoteVtep}},
+			out:     nil,
+			errCode: codes.InvalidArgument,
+			errMsg:  "exactly one of bridge_port and remote_vtep have to be set",
+			exist:   false,
+			on:      nil,
+		},
+		"port of another LogicalBridge": {
+			id:      testStaticFdbEntryID,
+			in:      &StaticFdbEntry{Spec: &StaticFdbEntrySpec{MacAddress: testStaticFdbEntry.Spec.MacAddress, BridgePort: resourceIDToFullName("ports", "other")}},
+			out:     nil,
+			errCode: codes.FailedPrecondition,
+			errMsg:  fmt.Sprintf("port %v is not in %v", resourceIDToFullName("ports", "other"), testLogicalBridgeName),
+			exist:   false,
+			on:      nil,
+		},
+		"already exists": {
+			id:      testStaticFdbEntryID,
+			in:      &testStaticFdbEntry,
+			out:     &testStaticFdbEntryWithName,
+			errCode: codes.OK,
+			errMsg:  "",
+			exist:   true,
+			on:      nil,
+		},
+		"already exists with a different spec": {
+			id:      testStaticFdbEntryID,
+			in:      &StaticFdbEntry{Spec: &StaticFdbEntrySpec{MacAddress: []byte{0xaa, 0xbb, 0xcc, 0x00, 0x00, 0x42}, BridgePort: testBridgePortName}},
+			out:     nil,
+			errCode: codes.AlreadyExists,
+			errMsg:  fmt.Sprintf("%s already exists with a different spec", testStaticFdbEntryName),
+			exist:   true,
+			on:      nil,
+		},
+		"same MAC address": {
+			id:      "opi-mac9",
+			in:      &testStaticFdbEntry,
+			out:     nil,
+			errCode: codes.AlreadyExists,
+			errMsg:  fmt.Sprintf("MAC address of %v/fdbentries/opi-mac9 already exists as %v", testLogicalBridgeName, testStaticFdbEntryName),
+			exist:   true,
+			on:      nil,
+		},
+		"failed NeighSet call": {
+			id:      testStaticFdbEntryID,
+			in:      &testStaticFdbEntry,
+			out:     nil,
+			errCode: codes.Unknown,
+			errMsg:  "Failed to call NeighSet",
+			exist:   false,
+			on: func(mockNetlink *mocks.Netlink, errMsg string) {
+				mockNetlink.EXPECT().LinkByName(mock.Anything, testBridgePortID).Return(&netlink.Device{LinkAttrs: netlink.LinkAttrs{Index: 5}}, nil).Once()
+				mockNetlink.EXPECT().NeighSet(mock.Anything, testStaticFdbEntryKernel).Return(errors.New(errMsg)).Once()
+			},
+		},
+		"successful call": {
+			id:      testStaticFdbEntryID,
+			in:      &testStaticFdbEntry,
+			out:     &testStaticFdbEntryWithName,
+			errCode: codes.OK,
+			errMsg:  "",
+			exist:   false,
+			on: func(mockNetlink *mocks.Netlink, errMsg string) {
+				mockNetlink.EXPECT().LinkByName(mock.Anything, testBridgePortID).Return(&netlink.Device{LinkAttrs: netlink.LinkAttrs{Index: 5}}, nil).Once()
+				mockNetlink.EXPECT().NeighSet(mock.Anything, testStaticFdbEntryKernel).Return(nil).Once()
+			},
+		},
+		"successful remote vtep call": {
+			id:      testStaticFdbEntryID,
+			in:      &StaticFdbEntry{Spec: &StaticFdbEntrySpec{MacAddress: testStaticFdbEntry.Spec.MacAddress, RemoteVtep: testRemoteVtep}},
+			out:     &StaticFdbEntry{Name: testStaticFdbEntryName, Spec: &StaticFdbEntrySpec{MacAddress: testStaticFdbEntry.Spec.MacAddress, RemoteVtep: testRemoteVtep}},
+			errCode: codes.OK,
+			errMsg:  "",
+			exist:   false,
+			on: func(mockNetlink *mocks.Netlink, errMsg string) {
+				mockNetlink.EXPECT().LinkByName(mock.Anything, "vni11").Return(&netlink.Vxlan{LinkAttrs: netlink.LinkAttrs{Index: 5}}, nil).Once()
+				mockNetlink.EXPECT().NeighSet(mock.Anything, testStaticFdbEntryKernel).Return(nil).Once()
+				mockNetlink.EXPECT().NeighAppend(mock.Anything, mock.MatchedBy(func(neigh *netlink.Neigh) bool {
+					return neigh.LinkIndex == 5 && neigh.Flags == netlink.NTF_SELF && neigh.IP.Equal(net.IPv4(10, 0, 0, 3))
+				})).Return(nil).Once()
+			},
+		},
+	}
+
+	// run tests
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			mockNetlink := mocks.NewNetlink(t)
+			mockFrr := mocks.NewFrr(t)
+			store := gomap.NewStore(gomap.DefaultOptions)
+			opi := NewServerWithArgs(mockNetlink, mockFrr, store)
+
+			opi.Bridges[testLogicalBridgeName] = protoClone(&testLogicalBridgeWithStatus)
+			opi.Ports[testBridgePortName] = protoClone(&testBridgePortWithStatus)
+			other := resourceIDToFullName("ports", "other")
+			opi.Ports[other] = &pb.BridgePort{Name: other, Spec: &pb.BridgePortSpec{LogicalBridges: []string{resourceIDToFullName("bridges", "other")}}}
+			if tt.exist {
+				opi.FdbEntries[testStaticFdbEntryName] = testStaticFdbEntryWithName.clone()
+			}
+			if tt.on != nil {
+				tt.on(mockNetlink, tt.errMsg)
+			}
+
+			request := &CreateStaticFdbEntryRequest{Parent: testLogicalBridgeName, StaticFdbEntry: tt.in.clone(), StaticFdbEntryID: tt.id}
+			response, err := opi.CreateStaticFdbEntry(ctx, request)
+			if !reflect.DeepEqual(tt.out, response) {
+				t.Error("response: expected", tt.out, "received", response)
+			}
+
+			if er, ok := status.FromError(err); ok {
+				if er.Code() != tt.errCode {
+					t.Error("error code: expected", tt.errCode, "received", er.Code())
+				}
+				if er.Message() != tt.errMsg {
+					t.Error("error message: expected", tt.errMsg, "received", er.Message())
+				}
+			} else {
+				t.Error("expected grpc error status")
+			}
+		})
+	}
+}
+
+func Test_DeleteStaticFdbEntry(t *testing.T) {
+	tests := map[string]struct {
+		in      string
+		out     *emptypb.Empty
+		errCode codes.Code
+		errMsg  string
+		missing bool
+		on      func(mockNetlink *mocks.Netlink, errMsg string)
+	}{
+		"valid request with unknown key": {
+			in:      "unknown-id",
+			out:     nil,
+			errCode: codes.NotFound,
+			errMsg:  fmt.Sprintf("unable to find key %v", fmt.Sprintf("%s/fdbentries/%s", testLogicalBridgeName, "unknown-id")),
+			missing: false,
+			on:      nil,
+		},
+		"unknown key with missing allowed": {
+			in:      "unknown-id",
+			out:     &emptypb.Empty{},
+			errCode: codes.OK,
+			errMsg:  "",
+			missing: true,
+			on:      nil,
+		},
+		"failed NeighDel call": {
+			in:      testStaticFdbEntryID,
+			out:     nil,
+			errCode: codes.Unknown,
+			errMsg:  "Failed to call NeighDel",
+			missing: false,
+			on: func(mockNetlink *mocks.Netlink, errMsg string) {
+				mockNetlink.EXPECT().LinkByName(mock.Anything, testBridgePortID).Return(&netlink.Device{LinkAttrs: netlink.LinkAttrs{Index: 5}}, nil).Once()
+				mockNetlink.EXPECT().NeighDel(mock.Anything, testStaticFdbEntryKernel).Return(errors.New(errMsg)).Once()
+			},
+		},
+		"successful call": {
+			in:      testStaticFdbEntryID,
+			out:     &emptypb.Empty{},
+			errCode: codes.OK,
+			errMsg:  "",
+			missing: false,
+			on: func(mockNetlink *mocks.Netlink, errMsg string) {
+				mockNetlink.EXPECT().LinkByName(mock.Anything, testBridgePortID).Return(&netlink.Device{LinkAttrs: netlink.LinkAttrs{Index: 5}}, nil).Once()
+				mockNetlink.EXPECT().NeighDel(mock.Anything, testStaticFdbEntryKernel).Return(nil).Once()
+			},
+		},
+	}
+
+	// run tests
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			mockNetlink := mocks.NewNetlink(t)
+			mockFrr := mocks.NewFrr(t)
+			store := gomap.NewStore(gomap.DefaultOptions)
+			opi := NewServerWithArgs(mockNetlink, mockFrr, store)
+
+			opi.Bridges[testLogicalBridgeName] = protoClone(&testLogicalBridgeWithStatus)
+			opi.FdbEntries[testStaticFdbEntryName] = testStaticFdbEntryWithName.clone()
+			if tt.on != nil {
+				tt.on(mockNetlink, tt.errMsg)
+			}
+
+			request := &DeleteStaticFdbEntryRequest{Name: fmt.Sprintf("%s/fdbentries/%s", testLogicalBridgeName, tt.in), AllowMissing: tt.missing}
+			response, err := opi.DeleteStaticFdbEntry(ctx, request)
+
+			if er, ok := status.FromError(err); ok {
+				if er.Code() != tt.errCode {
+					t.Error("error code: expected", tt.errCode, "received", er.Code())
+				}
+				if er.Message() != tt.errMsg {
+					t.Error("error message: expected", tt.errMsg, "received", er.Message())
+				}
+			} else {
+				t.Error("expected grpc error status")
+			}
+
+			if reflect.TypeOf(response) != reflect.TypeOf(tt.out) {
+				t.Error("response: expected", reflect.TypeOf(tt.out), "received", reflect.TypeOf(response))
+			}
+		})
+	}
+
+	t.Run("pinned port", func(t *testing.T) {
+		opi := NewServerWithArgs(mocks.NewNetlink(t), mocks.NewFrr(t), gomap.NewStore(gomap.DefaultOptions))
+		opi.Ports[testBridgePortName] = protoClone(&testBridgePortWithStatus)
+		opi.FdbEntries[testStaticFdbEntryName] = testStaticFdbEntryWithName.clone()
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(utils.ValidateOnlyMetadataKey, "true"))
+		_, err := opi.DeleteBridgePort(ctx, &pb.DeleteBridgePortRequest{Name: testBridgePortName})
+		if status.Code(err) != codes.FailedPrecondition {
+			t.Error("error: expected", codes.FailedPrecondition, "received", err)
+		}
+	})
+}
+
+func Test_ListStaticFdbEntries(t *testing.T) {
+	tests := map[string]struct {
+		parent  string
+		out     []*StaticFdbEntry
+		errCode codes.Code
+		errMsg  string
+	}{
+		"entries of bridge": {
+			parent:  testLogicalBridgeName,
+			out:     []*StaticFdbEntry{&testStaticFdbEntryWithName},
+			errCode: codes.OK,
+			errMsg:  "",
+		},
+		"entries of other bridge": {
+			parent:  resourceIDToFullName("bridges", "other-bridge"),
+			out:     []*StaticFdbEntry{},
+			errCode: codes.OK,
+			errMsg:  "",
+		},
+		"no required parent field": {
+			parent:  "",
+			out:     nil,
+			errCode: codes.InvalidArgument,
+			errMsg:  "missing required field: parent",
+		},
+	}
+
+	// run tests
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			opi := NewServerWithArgs(mocks.NewNetlink(t), mocks.NewFrr(t), gomap.NewStore(gomap.DefaultOptions))
+
+			opi.FdbEntries[testStaticFdbEntryName] = testStaticFdbEntryWithName.clone()
+
+			response, err := opi.ListStaticFdbEntries(ctx, &ListStaticFdbEntriesRequest{Parent: tt.parent})
+			var entries []*StaticFdbEntry
+			if response != nil {
+				entries = response.StaticFdbEntries
+			}
+			if !reflect.DeepEqual(tt.out, entries) {
+				t.Error("response: expected", tt.out, "received", entries)
+			}
+
+			if er, ok := status.FromError(err); ok {
+				if er.Code() != tt.errCode {
+					t.Error("error code: expected", tt.errCode, "received", er.Code())
+				}
+				if er.Message() != tt.errMsg {
+					t.Error("error message: expected", tt.errMsg, "received", er.Message())
+				}
+			} else {
+				t.Error("expected grpc error status")
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"go.einride.tech/aip/resourcename"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (s *Server) validateCreateStaticFdbEntryRequest(in *CreateStaticFdbEntryRequest) error {
+	// check required fields
+	switch {
+	case in.Parent == "":
+		return missingField("parent")
+	case in.StaticFdbEntry == nil:
+		return missingField("static_fdb_entry")
+	case in.StaticFdbEntry.Spec == nil:
+		return missingField("static_fdb_entry.spec")
+	case len(in.StaticFdbEntry.Spec.MacAddress) == 0:
+		return missingField("static_fdb_entry.spec.mac_address")
+	}
+	if len(in.StaticFdbEntry.Spec.MacAddress) != 6 {
+		msg := "mac_address have to be 6 bytes long"
+		return badRequest("static_fdb_entry.spec.mac_address", status.Error(codes.InvalidArgument, msg))
+	}
+	// the MAC address is either behind a local port or behind a remote VTEP
+	spec := in.StaticFdbEntry.Spec
+	if (spec.BridgePort == "") == (spec.RemoteVtep == nil) {
+		msg := "exactly one of bridge_port and remote_vtep have to be set"
+		return badRequest("static_fdb_entry.spec.bridge_port", status.Error(codes.InvalidArgument, msg))
+	}
+	if spec.RemoteVtep != nil && spec.RemoteVtep.GetV4Addr() == 0 {
+		msg := "remote_vtep have to be an IPv4 address"
+		return badRequest("static_fdb_entry.spec.remote_vtep", status.Error(codes.InvalidArgument, msg))
+	}
+	// Validate that a LogicalBridge resource name conforms to the restrictions outlined in AIP-122.
+	if err := badRequest("parent", resourcename.Validate(in.Parent)); err != nil {
+		return err
+	}
+	// see https://google.aip.dev/133#user-specified-ids
+	if in.StaticFdbEntryID != "" {
+		if err := badRequest("static_fdb_entry_id", validateResourceID(in.StaticFdbEntryID, false)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Server) validateDeleteStaticFdbEntryRequest(in *DeleteStaticFdbEntryRequest) error {
+	// check required fields
+	if in.Name == "" {
+		return missingField("name")
+	}
+	// Validate that a resource name conforms to the restrictions outlined in AIP-122.
+	return badRequest("name", resourcename.Validate(in.Name))
+}
+
+func (s *Server) validateListStaticFdbEntriesRequest(in *ListStaticFdbEntriesRequest) error {
+	// check required fields
+	if in.Parent == "" {
+		return missingField("parent")
+	}
+	// Validate that a resource name conforms to the restrictions outlined in AIP-122.
+	return badRequest("parent", resourcename.Validate(in.Parent))
+}
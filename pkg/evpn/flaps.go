@@ -0,0 +1,217 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils"
+)
+
+const (
+	// flapPenalty is the penalty of a single transition, as in BGP route flap dampening
+	flapPenalty = 1000
+	// flapHistoryLength is the number of transitions kept per resource
+	flapHistoryLength = 32
+)
+
+// DampeningOptions are the thresholds of the dampening of the link and BGP peer events, as in
+// BGP route flap dampening: every transition of a resource adds a penalty of 1000, halved every
+// HalfLife, and its events are suppressed from the moment the penalty reaches Suppress until
+// it decays under Reuse
+type DampeningOptions struct {
+	// HalfLife is the time the penalty takes to halve, not dampened when 0
+	HalfLife time.Duration `json:"halfLife"`
+	// Suppress is the penalty the events start being suppressed at
+	Suppress int `json:"suppress"`
+	// Reuse is the penalty the events stop being suppressed under
+	Reuse int `json:"reuse"`
+}
+
+// DefaultDampeningOptions suppress a resource after three transitions within a fraction of the
+// half-life, the dampening being disabled until a half-life is set
+func DefaultDampeningOptions() DampeningOptions {
+	return DampeningOptions{Suppress: 2500, Reuse: 750}
+}
+
+// Validate checks the reuse threshold is under the suppress one
+func (o DampeningOptions) Validate() error {
+	switch {
+	case o.HalfLife < 0:
+		return fmt.Errorf("invalid dampening half-life %v", o.HalfLife)
+	case o.Reuse <= 0 || o.Reuse >= o.Suppress:
+		return fmt.Errorf("invalid dampening thresholds, expected 0 < reuse %d < suppress %d", o.Reuse, o.Suppress)
+	}
+	return nil
+}
+
+// OperEvent is a transition of a link or BGP peer, as published to the event bus
+// TODO: move to opi-api once the message is agreed upon
+type OperEvent struct {
+	utils.BusEvent
+	// Suppressed tells whether the event was not published, the resource being dampened
+	Suppressed bool `json:"suppressed"`
+}
+
+// ResourceEvents are the recent transitions of a link or BGP peer, oldest first
+// TODO: move to opi-api once the message is agreed upon
+type ResourceEvents struct {
+	// Resource is the interface name of a link, or vrf|peer for a BGP peer
+	Resource string `json:"resource"`
+	// Penalty is the dampening penalty as of the call
+	Penalty int `json:"penalty"`
+	// Suppressed tells whether the events of the resource are suppressed
+	Suppressed bool        `json:"suppressed"`
+	Events     []OperEvent `json:"events"`
+}
+
+// ListEventsRequest is the request to list the recent link and BGP peer transitions
+// TODO: move to opi-api once the message is agreed upon
+type ListEventsRequest struct {
+	// Resource is the interface name of a link, or vrf|peer for a BGP peer, to list the
+	// transitions of, all of them when empty
+	Resource string
+}
+
+// ListEventsResponse lists the resources that transitioned, sorted by resource
+// TODO: move to opi-api once the message is agreed upon
+type ListEventsResponse struct {
+	Resources []ResourceEvents `json:"resources"`
+	// Dampening are the thresholds of the dampening
+	Dampening DampeningOptions `json:"dampening"`
+}
+
+// resourceFlaps is the dampening state and transition history of a resource
+type resourceFlaps struct {
+	penalty    float64
+	updated    time.Time
+	suppressed bool
+	// last is the last transition, published is the state last published
+	last      utils.BusEvent
+	published string
+	history   []OperEvent
+}
+
+// decay brings the penalty to now
+func (f *resourceFlaps) decay(now time.Time, halfLife time.Duration) {
+	if halfLife > 0 && f.penalty > 0 {
+		f.penalty *= math.Exp2(-float64(now.Sub(f.updated)) / float64(halfLife))
+	}
+	f.updated = now
+}
+
+// flapTracker keeps the transitions of the links and BGP peers seen by the event poller
+type flapTracker struct {
+	mu        sync.Mutex
+	resources map[string]*resourceFlaps
+}
+
+func newFlapTracker() *flapTracker {
+	return &flapTracker{resources: map[string]*resourceFlaps{}}
+}
+
+// busEventState is the state a link or BGP peer event reports
+func busEventState(ev utils.BusEvent) string {
+	if ev.Type == utils.BusBgpPeerState {
+		return ev.State
+	}
+	return string(ev.Type)
+}
+
+// record adds a transition of the resource and tells whether it is to be published, not when
+// the resource is suppressed
+func (t *flapTracker) record(resource string, ev utils.BusEvent, now time.Time, o DampeningOptions) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	f, ok := t.resources[resource]
+	if !ok {
+		f = &resourceFlaps{}
+		t.resources[resource] = f
+	}
+	f.decay(now, o.HalfLife)
+	if o.HalfLife > 0 {
+		f.penalty += flapPenalty
+		if f.penalty >= float64(o.Suppress) {
+			f.suppressed = true
+		}
+	}
+	f.last = ev
+	if !f.suppressed {
+		f.published = busEventState(ev)
+	}
+	recorded := ev
+	recorded.Time = now
+	f.history = append(f.history, OperEvent{BusEvent: recorded, Suppressed: f.suppressed})
+	if len(f.history) > flapHistoryLength {
+		f.history = f.history[len(f.history)-flapHistoryLength:]
+	}
+	return !f.suppressed
+}
+
+// reuse lifts the suppression of the resources whose penalty decayed under the reuse threshold,
+// and returns the last transition of those whose state changed while suppressed, to be published
+func (t *flapTracker) reuse(now time.Time, o DampeningOptions) []utils.BusEvent {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	events := []utils.BusEvent{}
+	for _, resource := range sortedKeys(t.resources) {
+		f := t.resources[resource]
+		if !f.suppressed {
+			continue
+		}
+		f.decay(now, o.HalfLife)
+		if o.HalfLife > 0 && f.penalty >= float64(o.Reuse) {
+			continue
+		}
+		f.suppressed = false
+		if state := busEventState(f.last); state != f.published {
+			f.published = state
+			events = append(events, f.last)
+		}
+	}
+	return events
+}
+
+// publishOperEvent records the transition of the resource and publishes it unless the resource
+// is suppressed
+func (s *Server) publishOperEvent(pub utils.EventPublisher, resource string, ev utils.BusEvent) {
+	if s.flaps.record(resource, ev, time.Now(), s.Dampening) && pub != nil {
+		pub.Publish(ev)
+	}
+}
+
+// ListEvents returns the recent transitions of the links and BGP peers seen by the event
+// poller, with their dampening state, to troubleshoot the flapping uplinks and peers. The
+// links and peers are not tenant objects, the tenants cannot list them
+func (s *Server) ListEvents(ctx context.Context, in *ListEventsRequest) (*ListEventsResponse, error) {
+	if tenant := utils.TenantFromContext(ctx); tenant != "" {
+		return nil, status.Errorf(codes.PermissionDenied, "tenant %s cannot list the link and BGP peer events", tenant)
+	}
+	response := &ListEventsResponse{Resources: []ResourceEvents{}, Dampening: s.Dampening}
+	now := time.Now()
+	s.flaps.mu.Lock()
+	defer s.flaps.mu.Unlock()
+	for _, resource := range sortedKeys(s.flaps.resources) {
+		if in.Resource != "" && resource != in.Resource {
+			continue
+		}
+		f := s.flaps.resources[resource]
+		f.decay(now, s.Dampening.HalfLife)
+		response.Resources = append(response.Resources, ResourceEvents{
+			Resource:   resource,
+			Penalty:    int(math.Round(f.penalty)),
+			Suppressed: f.suppressed,
+			Events:     append([]OperEvent{}, f.history...),
+		})
+	}
+	return response, nil
+}
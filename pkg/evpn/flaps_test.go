@@ -0,0 +1,85 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/philippgille/gokv/gomap"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils"
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils/mocks"
+)
+
+func Test_FlapDampening(t *testing.T) {
+	options := DampeningOptions{HalfLife: time.Minute, Suppress: 2500, Reuse: 750}
+	flaps := newFlapTracker()
+	down := utils.BusEvent{Type: utils.BusLinkDown, Interface: "eth0"}
+	up := utils.BusEvent{Type: utils.BusLinkUp, Interface: "eth0"}
+	start := time.Now()
+
+	tests := []struct {
+		name      string
+		ev        utils.BusEvent
+		after     time.Duration
+		published bool
+	}{
+		{name: "first down", ev: down, published: true},
+		{name: "first up", ev: up, after: time.Second, published: true},
+		{name: "second down", ev: down, after: 2 * time.Second, published: false},
+		{name: "second up", ev: up, after: 3 * time.Second, published: false},
+		{name: "third down", ev: down, after: 4 * time.Second, published: false},
+	}
+	for _, tt := range tests {
+		if published := flaps.record("eth0", tt.ev, start.Add(tt.after), options); published != tt.published {
+			t.Error(tt.name, "published: expected", tt.published, "received", published)
+		}
+	}
+
+	// about 4500 after 5s, under 750 after three half-lives
+	if events := flaps.reuse(start.Add(time.Minute), options); len(events) != 0 {
+		t.Error("still suppressed: expected no events, received", events)
+	}
+	events := flaps.reuse(start.Add(4*time.Minute), options)
+	if len(events) != 1 || events[0] != down {
+		t.Error("reused: expected", down, "received", events)
+	}
+	if events := flaps.reuse(start.Add(5*time.Minute), options); len(events) != 0 {
+		t.Error("already reused: expected no events, received", events)
+	}
+
+	opi := NewServerWithArgs(mocks.NewNetlink(t), mocks.NewFrr(t), gomap.NewStore(gomap.DefaultOptions))
+	opi.Dampening = options
+	opi.flaps = flaps
+	response, err := opi.ListEvents(context.Background(), &ListEventsRequest{Resource: "eth0"})
+	if err != nil {
+		t.Fatal("error: expected", nil, "received", err)
+	}
+	if len(response.Resources) != 1 || len(response.Resources[0].Events) != len(tests) {
+		t.Fatal("resources: expected eth0 with", len(tests), "events, received", response.Resources)
+	}
+	for i, ev := range response.Resources[0].Events {
+		if ev.Suppressed == tests[i].published {
+			t.Error(tests[i].name, "suppressed: expected", !tests[i].published, "received", ev.Suppressed)
+		}
+	}
+	if response.Resources[0].Suppressed {
+		t.Error("suppressed: expected", false, "received", true)
+	}
+
+	response, err = opi.ListEvents(context.Background(), &ListEventsRequest{Resource: "eth1"})
+	if err != nil || len(response.Resources) != 0 {
+		t.Error("unknown resource: expected no resources, received", response, err)
+	}
+	_, err = opi.ListEvents(tenantContext("blue"), &ListEventsRequest{})
+	if status.Code(err) != codes.PermissionDenied {
+		t.Error("error: expected", codes.PermissionDenied, "received", err)
+	}
+}
@@ -0,0 +1,154 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils"
+)
+
+// A restarted FRR daemon starts from its startup configuration, without the configuration
+// of the Vrfs and Svis applied over vty since, so it is replayed when a restart is seen
+
+// frrDaemon is a daemon holding the configuration of the Vrfs and Svis
+type frrDaemon struct {
+	name string
+	cmd  func(ctx context.Context, command string) (string, error)
+}
+
+// frrMonitor keeps what the last check saw of each daemon
+type frrMonitor struct {
+	daemons []frrDaemon
+	// reachable tells whether the daemon answered, for the daemons checked at least once
+	reachable map[string]bool
+	// pids are the pids of the daemons, when FRR runs on the same host
+	pids map[string]int
+}
+
+func (s *Server) newFrrMonitor() *frrMonitor {
+	return &frrMonitor{
+		daemons: []frrDaemon{
+			{"zebra", s.frr.FrrZebraCmd},
+			{"bgpd", s.frr.FrrBgpCmd},
+		},
+		reachable: map[string]bool{},
+		pids:      map[string]int{},
+	}
+}
+
+// StartFrrMonitor checks every interval whether zebra or bgpd restarted, seen as a daemon
+// answering again after it stopped answering on its vty socket, or as a new pid, until ctx
+// is done. The Vrfs and Svis are Degraded from the moment a daemon stops answering or
+// restarted until their FRR configuration is replayed
+func (s *Server) StartFrrMonitor(ctx context.Context, interval time.Duration) {
+	m := s.newFrrMonitor()
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			s.checkFrr(ctx, m)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// checkFrr checks the daemons once and replays the configuration when one of them restarted,
+// the first check only records what it saw
+func (s *Server) checkFrr(ctx context.Context, m *frrMonitor) {
+	restarted := ""
+	for _, daemon := range m.daemons {
+		_, err := daemon.cmd(ctx, "show version")
+		wasReachable, seen := m.reachable[daemon.name]
+		m.reachable[daemon.name] = err == nil
+		if err != nil {
+			if !seen || wasReachable {
+				log.Printf("FRR daemon %s stopped answering: %v", daemon.name, err)
+				s.frrConfigurationLost(status.Errorf(codes.Unavailable, "FRR daemon %s is not answering", daemon.name))
+			}
+			continue
+		}
+		if seen && !wasReachable {
+			log.Printf("FRR daemon %s is answering again", daemon.name)
+			restarted = daemon.name
+		}
+		if pid, err := s.frr.DaemonPid(ctx, daemon.name); err == nil {
+			if old, ok := m.pids[daemon.name]; ok && old != pid {
+				log.Printf("FRR daemon %s restarted, pid %d instead of %d", daemon.name, pid, old)
+				restarted = daemon.name
+			}
+			m.pids[daemon.name] = pid
+		}
+	}
+	if restarted != "" {
+		s.frrConfigurationLost(status.Errorf(codes.Unavailable, "FRR daemon %s restarted, its configuration is being replayed", restarted))
+		s.replayFrr(ctx)
+	}
+}
+
+// frrConfigurationLost marks the Vrfs and Svis Degraded, their FRR configuration being lost
+func (s *Server) frrConfigurationLost(err error) {
+	if s.IsStandby() {
+		return
+	}
+	s.objectsMu.RLock()
+	defer s.objectsMu.RUnlock()
+	for _, name := range append(sortedKeys(s.Vrfs), sortedKeys(s.Svis)...) {
+		s.conditions.set(name, ConditionFrrProgrammed, err)
+		s.events.Publish(utils.WatchEvent{Type: utils.WatchModified, Name: name})
+	}
+}
+
+// replayFrr re-applies the Vrfs then the Svis to the dataplane, as Resync does, the
+// objects failing to be replayed being retried in the background. Both hold objectsMu
+// as the calls changing the objects do
+func (s *Server) replayFrr(ctx context.Context) {
+	if s.IsStandby() {
+		return
+	}
+	s.objectsMu.Lock()
+	defer s.objectsMu.Unlock()
+	log.Printf("Replaying the FRR configuration of %d Vrfs and %d Svis", len(s.Vrfs), len(s.Svis))
+	replayed := func(name string, err error, retry utils.RetryFunc) {
+		s.conditions.set(name, ConditionFrrProgrammed, err)
+		if err != nil {
+			log.Printf("Failed to replay %v in FRR, retrying in the background: %v", name, err)
+			s.frrRetries.Add(name, retry)
+			return
+		}
+		s.frrRetries.Cancel(name)
+		s.events.Publish(utils.WatchEvent{Type: utils.WatchModified, Name: name})
+	}
+	// the Svis of a recreated Vrf were detached from it with the old device
+	recreatedVrfs := map[string]bool{}
+	for _, name := range sortedKeys(s.Vrfs) {
+		obj := s.Vrfs[name]
+		recreated, err := s.dataplane.ResyncVrf(ctx, obj)
+		recreatedVrfs[name] = recreated
+		replayed(name, err, func(ctx context.Context) error {
+			s.objectsMu.Lock()
+			defer s.objectsMu.Unlock()
+			_, err := s.dataplane.ResyncVrf(ctx, obj)
+			return err
+		})
+	}
+	for _, name := range sortedKeys(s.Svis) {
+		obj := s.Svis[name]
+		replayed(name, s.resyncSvi(ctx, obj, recreatedVrfs[obj.Spec.Vrf]), func(ctx context.Context) error {
+			s.objectsMu.Lock()
+			defer s.objectsMu.Unlock()
+			return s.resyncSvi(ctx, obj, false)
+		})
+	}
+}
@@ -0,0 +1,121 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/philippgille/gokv/gomap"
+	"github.com/stretchr/testify/mock"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+	pc "github.com/opiproject/opi-api/network/opinetcommon/v1alpha1/gen/go"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/fake"
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils/mocks"
+)
+
+// replayDataplane records the Vrfs it resyncs, other methods are not implemented
+type replayDataplane struct {
+	Dataplane
+	vrfs []string
+}
+
+func (d *replayDataplane) ResyncVrf(_ context.Context, obj *pb.Vrf) (bool, error) {
+	d.vrfs = append(d.vrfs, obj.Name)
+	return false, nil
+}
+
+func Test_CheckFrr(t *testing.T) {
+	mockFrr := mocks.NewFrr(t)
+	opi := NewServerWithArgs(mocks.NewNetlink(t), mockFrr, gomap.NewStore(gomap.DefaultOptions))
+	dataplane := &replayDataplane{}
+	opi.SetDataplane(dataplane)
+	opi.Vrfs[testVrfName] = protoClone(&testVrfWithStatus)
+
+	bgpdPid := 100
+	var bgpdErr error
+	mockFrr.EXPECT().FrrZebraCmd(mock.Anything, "show version").Return("", nil)
+	mockFrr.EXPECT().FrrBgpCmd(mock.Anything, "show version").RunAndReturn(func(context.Context, string) (string, error) {
+		return "", bgpdErr
+	})
+	mockFrr.EXPECT().DaemonPid(mock.Anything, "zebra").Return(0, errors.New("no such file or directory"))
+	mockFrr.EXPECT().DaemonPid(mock.Anything, "bgpd").RunAndReturn(func(context.Context, string) (int, error) {
+		return bgpdPid, nil
+	})
+	frrProgrammed := func() ConditionStatus {
+		for _, condition := range opi.conditions.get(testVrfName) {
+			if condition.Type == ConditionFrrProgrammed {
+				return condition.Status
+			}
+		}
+		return ""
+	}
+
+	tests := []struct {
+		name     string
+		pid      int
+		err      error
+		replays  int
+		expected ConditionStatus
+	}{
+		{name: "first check", pid: 100},
+		{name: "same pid", pid: 100},
+		{name: "new pid", pid: 101, replays: 1, expected: ConditionTrue},
+		{name: "not answering", pid: 101, err: errors.New("connection refused"), replays: 1, expected: ConditionFalse},
+		{name: "still not answering", pid: 101, err: errors.New("connection refused"), replays: 1, expected: ConditionFalse},
+		{name: "answering again", pid: 101, replays: 2, expected: ConditionTrue},
+	}
+
+	m := opi.newFrrMonitor()
+	for _, tt := range tests {
+		bgpdPid, bgpdErr = tt.pid, tt.err
+		opi.checkFrr(context.Background(), m)
+		if len(dataplane.vrfs) != tt.replays {
+			t.Error(tt.name, "replays: expected", tt.replays, "received", dataplane.vrfs)
+		}
+		if status := frrProgrammed(); status != tt.expected {
+			t.Error(tt.name, "FrrProgrammed: expected", tt.expected, "received", status)
+		}
+	}
+}
+
+func Test_ReplayFrrConcurrentCalls(t *testing.T) {
+	ctx := context.Background()
+	opi := NewServerWithArgs(fake.NewNetlink(), fake.NewFrr(), gomap.NewStore(gomap.DefaultOptions))
+
+	// the monitor replays while the Vrfs are being created
+	const count = 10
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < count; i++ {
+			opi.frrConfigurationLost(status.Error(codes.Unavailable, "FRR daemon bgpd restarted"))
+			opi.replayFrr(ctx)
+		}
+	}()
+	for i := 0; i < count; i++ {
+		request := &pb.CreateVrfRequest{VrfId: fmt.Sprintf("blue%d", i), Vrf: &pb.Vrf{Spec: &pb.VrfSpec{LoopbackIpPrefix: &pc.IPPrefix{Len: 24}}}}
+		if _, err := opi.CreateVrf(ctx, request); err != nil {
+			t.Fatal("error: expected", nil, "received", err)
+		}
+	}
+	<-done
+
+	opi.replayFrr(ctx)
+	for name := range opi.Vrfs {
+		for _, condition := range opi.conditions.get(name) {
+			if condition.Type == ConditionFrrProgrammed && condition.Status != ConditionTrue {
+				t.Error(name, "FrrProgrammed: expected", ConditionTrue, "received", condition)
+			}
+		}
+	}
+}
@@ -0,0 +1,39 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"log"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils"
+)
+
+// FRR may be restarting when an object is created, its FRR configuration is then applied
+// again in the background until it succeeds, the object staying Degraded until it does
+
+// reportFrrRetry records the outcome of a retry in the conditions of the object,
+// the watchers are told once its configuration was applied
+func (s *Server) reportFrrRetry(name string, attempt int, err error) {
+	s.conditions.set(name, ConditionFrrProgrammed, err)
+	if err != nil {
+		log.Printf("Retry %d of FRR programming of %v failed: %v", attempt, name, err)
+		return
+	}
+	log.Printf("FRR programming of %v succeeded after %d retries", name, attempt)
+	s.events.Publish(utils.WatchEvent{Type: utils.WatchModified, Name: name})
+}
+
+// GetFrrRetries returns the objects whose FRR configuration is waiting to be applied again,
+// with the number of retries that already failed
+func (s *Server) GetFrrRetries() map[string]int {
+	return s.frrRetries.Pending()
+}
+
+// forgetStatus drops the conditions and the pending FRR retry of a deleted object,
+// or of one that failed to be created
+func (s *Server) forgetStatus(name string) {
+	s.frrRetries.Cancel(name)
+	s.conditions.remove(name)
+}
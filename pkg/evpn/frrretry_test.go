@@ -0,0 +1,62 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/philippgille/gokv/gomap"
+	"github.com/stretchr/testify/mock"
+	"github.com/vishvananda/netlink"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+	pc "github.com/opiproject/opi-api/network/opinetcommon/v1alpha1/gen/go"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils"
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils/mocks"
+)
+
+func Test_CreateVrfRetriesFrr(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	mockNetlink := mocks.NewNetlink(t)
+	mockFrr := mocks.NewFrr(t)
+	opi := NewServerWithArgs(mockNetlink, mockFrr, gomap.NewStore(gomap.DefaultOptions))
+	opi.frrRetries = utils.NewRetryQueue(time.Millisecond, time.Millisecond, opi.reportFrrRetry)
+	w := opi.Watch()
+	defer opi.Unwatch(w)
+
+	vrf := &netlink.Vrf{LinkAttrs: netlink.LinkAttrs{Name: testVrfID, Alias: managedAlias}, Table: 1000}
+	mockNetlink.EXPECT().LinkAdd(mock.Anything, vrf).Return(nil).Once()
+	mockNetlink.EXPECT().LinkSetUp(mock.Anything, vrf).Return(nil).Once()
+	mockFrr.EXPECT().FrrZebraCmd(mock.Anything, mock.Anything).Return("", errors.New("Failed to call FrrZebraCmd")).Once()
+	mockFrr.EXPECT().FrrZebraCmd(mock.Anything, mock.Anything).Return("", nil).Once()
+
+	// FRR failing does not fail the creation, it leaves the Vrf degraded until the retry
+	response, err := opi.CreateVrf(ctx, &pb.CreateVrfRequest{VrfId: testVrfID, Vrf: &pb.Vrf{Spec: &pb.VrfSpec{LoopbackIpPrefix: &pc.IPPrefix{Len: 24}}}})
+	if err != nil {
+		t.Fatal("error: expected", nil, "received", err)
+	}
+	for _, want := range []utils.WatchEventType{utils.WatchAdded, utils.WatchModified} {
+		ev, err := w.Next(ctx)
+		if err != nil {
+			t.Fatal("error: expected", nil, "received", err)
+		}
+		if ev.Type != want || ev.Name != response.Name {
+			t.Error("event: expected", want, response.Name, "received", ev)
+		}
+	}
+	for _, condition := range opi.conditions.get(response.Name) {
+		if condition.Type == ConditionDegraded && condition.Status != ConditionFalse {
+			t.Error("condition: expected not degraded after the retry, received", condition)
+		}
+	}
+	if pending := opi.GetFrrRetries(); len(pending) != 0 {
+		t.Error("retries: expected none after success, received", pending)
+	}
+}
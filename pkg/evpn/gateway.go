@@ -0,0 +1,75 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+	pc "github.com/opiproject/opi-api/network/opinetcommon/v1alpha1/gen/go"
+)
+
+// GatewayConfig is the BGP identity of the gateway, shared by the default and the Vrf BGP instances
+type GatewayConfig struct {
+	// LocalAs is the AS number of the gateway, reported in the status of the Vrfs
+	LocalAs uint32 `json:"localAs"`
+	// RouterID is the BGP router-id, FRR picks one of the addresses when empty, the Vrfs with
+	// a loopback_ip_prefix use their loopback instead
+	RouterID string `json:"routerId,omitempty"`
+	// VtepIP is the VTEP loopback address, the source of the VXLAN tunnels of the
+	// LogicalBridges and Vrfs created without a vtep_ip_prefix
+	VtepIP string `json:"vtepIp,omitempty"`
+}
+
+// DefaultGatewayConfig is the private AS the FRR configuration always used
+func DefaultGatewayConfig() GatewayConfig {
+	return GatewayConfig{LocalAs: 65000}
+}
+
+// Validate checks the AS number and the addresses
+func (c GatewayConfig) Validate() error {
+	if c.LocalAs == 0 {
+		return fmt.Errorf("invalid local AS %d, has to be between 1 and 4294967295", c.LocalAs)
+	}
+	if c.RouterID != "" && net.ParseIP(c.RouterID).To4() == nil {
+		return fmt.Errorf("invalid router-id %q, has to be an IPv4 address", c.RouterID)
+	}
+	if c.VtepIP != "" && net.ParseIP(c.VtepIP).To4() == nil {
+		return fmt.Errorf("invalid VTEP address %q, has to be an IPv4 address", c.VtepIP)
+	}
+	return nil
+}
+
+// vtepIPPrefixOr returns the vtep_ip_prefix of an object with a vni, the VTEP loopback of the
+// gateway when it is not set, so all the dataplanes see the source of the tunnels in the spec
+func (s *Server) vtepIPPrefixOr(vni *uint32, prefix *pc.IPPrefix) *pc.IPPrefix {
+	if vni == nil || prefix != nil || s.Gateway.VtepIP == "" {
+		return prefix
+	}
+	return ipToPrefix(net.ParseIP(s.Gateway.VtepIP), 32)
+}
+
+// vrfRouterID returns the router-id of the BGP instance of a Vrf, its loopback when it has one
+func (s *Server) vrfRouterID(obj *pb.Vrf) string {
+	if obj.Spec.LoopbackIpPrefix != nil && obj.Spec.LoopbackIpPrefix.Addr != nil {
+		loopback := make(net.IP, 4)
+		binary.BigEndian.PutUint32(loopback, obj.Spec.LoopbackIpPrefix.Addr.GetV4Addr())
+		return loopback.String()
+	}
+	return s.Gateway.RouterID
+}
+
+// GetGatewayConfigRequest is the request to get the BGP identity of the gateway
+// TODO: move to opi-api once the message is agreed upon
+type GetGatewayConfigRequest struct{}
+
+// GetGatewayConfig returns the local AS, router-id and VTEP loopback the objects are configured with
+func (s *Server) GetGatewayConfig(_ context.Context, _ *GetGatewayConfigRequest) (*GatewayConfig, error) {
+	config := s.Gateway
+	return &config, nil
+}
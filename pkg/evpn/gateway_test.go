@@ -0,0 +1,92 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/philippgille/gokv/gomap"
+	"github.com/stretchr/testify/mock"
+
+	"google.golang.org/protobuf/proto"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils/mocks"
+)
+
+func TestGatewayConfig_Validate(t *testing.T) {
+	tests := map[string]struct {
+		in    GatewayConfig
+		valid bool
+	}{
+		"default": {
+			in:    DefaultGatewayConfig(),
+			valid: true,
+		},
+		"all set": {
+			in:    GatewayConfig{LocalAs: 4200000000, RouterID: "10.0.0.1", VtepIP: "10.0.0.1"},
+			valid: true,
+		},
+		"no AS": {
+			in:    GatewayConfig{},
+			valid: false,
+		},
+		"IPv6 router-id": {
+			in:    GatewayConfig{LocalAs: 65000, RouterID: "fd00::1"},
+			valid: false,
+		},
+		"invalid VTEP address": {
+			in:    GatewayConfig{LocalAs: 65000, VtepIP: "vtep"},
+			valid: false,
+		},
+	}
+	for testName, tt := range tests {
+		t.Run(testName, func(t *testing.T) {
+			err := tt.in.Validate()
+			if (err == nil) != tt.valid {
+				t.Error("valid: expected", tt.valid, "received", err)
+			}
+		})
+	}
+}
+
+func Test_vtepIPPrefixOr(t *testing.T) {
+	opi := NewServerWithArgs(mocks.NewNetlink(t), mocks.NewFrr(t), gomap.NewStore(gomap.DefaultOptions))
+	spec := testVrf.Spec.VtepIpPrefix
+	if prefix := opi.vtepIPPrefixOr(proto.Uint32(1000), nil); prefix != nil {
+		t.Error("prefix: expected", nil, "received", prefix)
+	}
+	opi.Gateway.VtepIP = "10.0.0.9"
+	if prefix := opi.vtepIPPrefixOr(proto.Uint32(1000), spec); prefix != spec {
+		t.Error("prefix: expected", spec, "received", prefix)
+	}
+	if prefix := opi.vtepIPPrefixOr(nil, nil); prefix != nil {
+		t.Error("prefix: expected", nil, "received", prefix)
+	}
+	prefix := opi.vtepIPPrefixOr(proto.Uint32(1000), nil)
+	expected := ipToPrefix(net.ParseIP("10.0.0.9"), 32)
+	if !proto.Equal(prefix, expected) {
+		t.Error("prefix: expected", expected, "received", prefix)
+	}
+}
+
+func Test_frrCreateVrfRequestLocalAs(t *testing.T) {
+	mockFrr := mocks.NewFrr(t)
+	opi := NewServerWithArgs(mocks.NewNetlink(t), mockFrr, gomap.NewStore(gomap.DefaultOptions))
+	opi.Gateway = GatewayConfig{LocalAs: 65100, RouterID: "10.0.0.1"}
+	vrf := &pb.Vrf{Name: testVrfName, Spec: &pb.VrfSpec{Vni: proto.Uint32(1000)}}
+
+	mockFrr.EXPECT().FrrZebraCmd(mock.Anything, mock.Anything).Return("", nil)
+	mockFrr.EXPECT().FrrBgpCmd(mock.Anything, mock.MatchedBy(func(command string) bool {
+		return strings.Contains(command, "router bgp 65100 vrf") && strings.Contains(command, "bgp router-id 10.0.0.1\n")
+	})).Return("", nil).Once()
+	if err := opi.frrCreateVrfRequest(context.Background(), &pb.CreateVrfRequest{Vrf: vrf}); err != nil {
+		t.Error("error: expected", nil, "received", err)
+	}
+}
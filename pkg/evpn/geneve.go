@@ -0,0 +1,181 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/vishvananda/netlink"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils"
+)
+
+const (
+	// geneveTunnelsKey is the store key of the geneve tunnels of the LogicalBridges
+	geneveTunnelsKey = "genevetunnels"
+	// defaultGenevePort is the IANA assigned Geneve port
+	defaultGenevePort = 6081
+)
+
+// TunnelType is the encapsulation of the vni of a LogicalBridge
+type TunnelType string
+
+const (
+	// TunnelVxlan is a vxlan device whose remote VTEPs are learned from BGP-EVPN
+	TunnelVxlan TunnelType = "vxlan"
+	// TunnelGeneve is a geneve device to a single remote TEP, without BGP-EVPN
+	TunnelGeneve TunnelType = "geneve"
+)
+
+// GeneveTunnel is the geneve tunnel of a LogicalBridge, instead of a vxlan device
+type GeneveTunnel struct {
+	// Remote is the IPv4 address of the remote TEP
+	Remote string
+	// Port is the UDP destination port, 6081 by default
+	Port int
+	// TTL of the outer IP header, 0 leaves the default of the dataplane
+	TTL int
+}
+
+// parseGeneveTunnel parses the remote=A.B.C.D,port=N,ttl=N options of a geneve tunnel
+func parseGeneveTunnel(options string) (GeneveTunnel, error) {
+	tunnel := GeneveTunnel{Port: defaultGenevePort}
+	for _, option := range strings.Split(options, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(option), "=")
+		if !ok {
+			return tunnel, fmt.Errorf("%q has to be in key=value format", option)
+		}
+		switch key {
+		case "remote":
+			ip := net.ParseIP(value).To4()
+			if ip == nil {
+				return tunnel, fmt.Errorf("remote %q has to be an IPv4 address", value)
+			}
+			tunnel.Remote = ip.String()
+		case "port":
+			port, err := strconv.ParseUint(value, 10, 16)
+			if err != nil || port == 0 {
+				return tunnel, fmt.Errorf("port %q has to be between 1 and 65535", value)
+			}
+			tunnel.Port = int(port)
+		case "ttl":
+			ttl, err := strconv.ParseUint(value, 10, 8)
+			if err != nil {
+				return tunnel, fmt.Errorf("ttl %q has to be between 0 and 255", value)
+			}
+			tunnel.TTL = int(ttl)
+		default:
+			return tunnel, fmt.Errorf("unknown option %q, expected remote, port or ttl", key)
+		}
+	}
+	if tunnel.Remote == "" {
+		return tunnel, fmt.Errorf("remote is required")
+	}
+	return tunnel, nil
+}
+
+// geneveTunnelFor returns the geneve tunnel of a new LogicalBridge, sent with the call, or kept
+// from a previous incarnation of the same LogicalBridge (e.g.: on replay), nil for vxlan
+func (s *Server) geneveTunnelFor(ctx context.Context, obj *pb.LogicalBridge) (*GeneveTunnel, error) {
+	value, ok := utils.MetadataValue(ctx, utils.TunnelTypeMetadataKey)
+	if !ok || value == "" {
+		if tunnel, ok := s.GeneveTunnels[obj.Name]; ok {
+			return &tunnel, nil
+		}
+		return nil, nil
+	}
+	switch TunnelType(value) {
+	case TunnelVxlan:
+		return nil, nil
+	case TunnelGeneve:
+		if obj.Spec.Vni == nil {
+			msg := "a geneve tunnel requires a vni"
+			return nil, badRequest(utils.TunnelTypeMetadataKey, status.Error(codes.InvalidArgument, msg))
+		}
+		options, _ := utils.MetadataValue(ctx, utils.TunnelOptionsMetadataKey)
+		tunnel, err := parseGeneveTunnel(options)
+		if err != nil {
+			msg := fmt.Sprintf("invalid geneve tunnel %v", err)
+			return nil, badRequest(utils.TunnelOptionsMetadataKey, status.Error(codes.InvalidArgument, msg))
+		}
+		return &tunnel, nil
+	}
+	msg := fmt.Sprintf("invalid tunnel type %q, expected %s or %s", value, TunnelVxlan, TunnelGeneve)
+	return nil, badRequest(utils.TunnelTypeMetadataKey, status.Error(codes.InvalidArgument, msg))
+}
+
+// LogicalBridgeTunnel returns the encapsulation of the vni of a LogicalBridge, for the dataplanes
+func (s *Server) LogicalBridgeTunnel(obj *pb.LogicalBridge) TunnelType {
+	if _, ok := s.GeneveTunnels[obj.Name]; ok {
+		return TunnelGeneve
+	}
+	return TunnelVxlan
+}
+
+// newGeneve returns the geneve device of a LogicalBridge, the TOS of the vxlan devices applies too
+func (s *Server) newGeneve(name string, vni uint32, tunnel GeneveTunnel) *netlink.Geneve {
+	return &netlink.Geneve{
+		LinkAttrs: netlink.LinkAttrs{Name: name, Alias: managedAlias},
+		ID:        vni,
+		Remote:    net.ParseIP(tunnel.Remote),
+		Dport:     uint16(tunnel.Port),
+		Ttl:       uint8(tunnel.TTL),
+		Tos:       uint8(s.Vxlan.TOS),
+	}
+}
+
+func (s *Server) persistGeneveTunnels() {
+	fields := make(map[string]interface{}, len(s.GeneveTunnels))
+	for name, tunnel := range s.GeneveTunnels {
+		fields[name] = map[string]interface{}{
+			"remote": tunnel.Remote,
+			"port":   float64(tunnel.Port),
+			"ttl":    float64(tunnel.TTL),
+		}
+	}
+	msg, err := structpb.NewStruct(fields)
+	if err == nil {
+		err = s.store.Set(geneveTunnelsKey, msg)
+	}
+	if err != nil {
+		fmt.Printf("Failed to persist %s: %v", geneveTunnelsKey, err)
+	}
+}
+
+// loadGeneveTunnels restores the tunnels, so replayed LogicalBridges keep their encapsulation
+func (s *Server) loadGeneveTunnels() error {
+	msg := &structpb.Struct{}
+	found, err := s.store.Get(geneveTunnelsKey, msg)
+	if err != nil || !found {
+		return err
+	}
+	for name, value := range msg.Fields {
+		fields := value.GetStructValue().GetFields()
+		s.GeneveTunnels[name] = GeneveTunnel{
+			Remote: fields["remote"].GetStringValue(),
+			Port:   int(fields["port"].GetNumberValue()),
+			TTL:    int(fields["ttl"].GetNumberValue()),
+		}
+	}
+	return nil
+}
+
+// releaseGeneveTunnel forgets the tunnel of a deleted LogicalBridge
+func (s *Server) releaseGeneveTunnel(name string) {
+	if _, ok := s.GeneveTunnels[name]; ok {
+		delete(s.GeneveTunnels, name)
+		s.persistGeneveTunnels()
+	}
+}
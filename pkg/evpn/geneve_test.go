@@ -0,0 +1,139 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"testing"
+
+	"github.com/philippgille/gokv/gomap"
+	"github.com/stretchr/testify/mock"
+	"github.com/vishvananda/netlink"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils"
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils/mocks"
+)
+
+func Test_parseGeneveTunnel(t *testing.T) {
+	tests := map[string]struct {
+		in    string
+		out   GeneveTunnel
+		valid bool
+	}{
+		"remote only": {in: "remote=10.0.0.9", out: GeneveTunnel{Remote: "10.0.0.9", Port: 6081}, valid: true},
+		"all options": {in: "remote=10.0.0.9, port=6082, ttl=64", out: GeneveTunnel{Remote: "10.0.0.9", Port: 6082, TTL: 64}, valid: true},
+		"no remote":   {in: "port=6081", valid: false},
+		"empty":       {in: "", valid: false},
+		"ipv6 remote": {in: "remote=fd00::9", valid: false},
+		"port zero":   {in: "remote=10.0.0.9,port=0", valid: false},
+		"ttl too big": {in: "remote=10.0.0.9,ttl=256", valid: false},
+		"unknown":     {in: "remote=10.0.0.9,csum=true", valid: false},
+		"no value":    {in: "remote", valid: false},
+	}
+	for testName, tt := range tests {
+		t.Run(testName, func(t *testing.T) {
+			tunnel, err := parseGeneveTunnel(tt.in)
+			if (err == nil) != tt.valid {
+				t.Error("valid: expected", tt.valid, "received", err)
+			}
+			if err == nil && tunnel != tt.out {
+				t.Error("tunnel: expected", tt.out, "received", tunnel)
+			}
+		})
+	}
+}
+
+func Test_geneveTunnelFor(t *testing.T) {
+	tests := map[string]struct {
+		md      metadata.MD
+		vni     *uint32
+		out     *GeneveTunnel
+		errCode codes.Code
+	}{
+		"default": {
+			md:  metadata.MD{},
+			vni: proto.Uint32(10),
+		},
+		"vxlan": {
+			md:  metadata.Pairs(utils.TunnelTypeMetadataKey, "vxlan"),
+			vni: proto.Uint32(10),
+		},
+		"geneve": {
+			md:  metadata.Pairs(utils.TunnelTypeMetadataKey, "geneve", utils.TunnelOptionsMetadataKey, "remote=10.0.0.9"),
+			vni: proto.Uint32(10),
+			out: &GeneveTunnel{Remote: "10.0.0.9", Port: 6081},
+		},
+		"geneve without vni": {
+			md:      metadata.Pairs(utils.TunnelTypeMetadataKey, "geneve", utils.TunnelOptionsMetadataKey, "remote=10.0.0.9"),
+			errCode: codes.InvalidArgument,
+		},
+		"geneve without remote": {
+			md:      metadata.Pairs(utils.TunnelTypeMetadataKey, "geneve"),
+			vni:     proto.Uint32(10),
+			errCode: codes.InvalidArgument,
+		},
+		"invalid type": {
+			md:      metadata.Pairs(utils.TunnelTypeMetadataKey, "gre"),
+			vni:     proto.Uint32(10),
+			errCode: codes.InvalidArgument,
+		},
+	}
+	for testName, tt := range tests {
+		t.Run(testName, func(t *testing.T) {
+			opi := NewServerWithArgs(mocks.NewNetlink(t), mocks.NewFrr(t), gomap.NewStore(gomap.DefaultOptions))
+			ctx := metadata.NewIncomingContext(context.Background(), tt.md)
+			obj := &pb.LogicalBridge{Name: testLogicalBridgeName, Spec: &pb.LogicalBridgeSpec{VlanId: 10, Vni: tt.vni}}
+			tunnel, err := opi.geneveTunnelFor(ctx, obj)
+			if status.Code(err) != tt.errCode {
+				t.Error("error: expected", tt.errCode, "received", err)
+			}
+			if !reflect.DeepEqual(tunnel, tt.out) {
+				t.Error("tunnel: expected", tt.out, "received", tunnel)
+			}
+		})
+	}
+}
+
+func Test_GeneveLogicalBridge(t *testing.T) {
+	mockNetlink := mocks.NewNetlink(t)
+	opi := NewServerWithArgs(mockNetlink, mocks.NewFrr(t), gomap.NewStore(gomap.DefaultOptions))
+	opi.GeneveTunnels[testLogicalBridgeName] = GeneveTunnel{Remote: "10.0.0.9", Port: 6081, TTL: 64}
+	obj := protoClone(&testLogicalBridgeWithStatus)
+	obj.Name = testLogicalBridgeName
+	if tunnel := opi.LogicalBridgeTunnel(obj); tunnel != TunnelGeneve {
+		t.Error("tunnel: expected", TunnelGeneve, "received", tunnel)
+	}
+
+	// the geneve device replaces the vxlan one, under the same name
+	geneve := &netlink.Geneve{LinkAttrs: netlink.LinkAttrs{Name: "vni11", Alias: managedAlias}, ID: 11, Remote: net.ParseIP("10.0.0.9"), Dport: 6081, Ttl: 64}
+	bridge := &netlink.Bridge{LinkAttrs: netlink.LinkAttrs{Name: tenantbridgeName}}
+	mockNetlink.EXPECT().LinkByName(mock.Anything, tenantbridgeName).Return(bridge, nil).Once()
+	mockNetlink.EXPECT().LinkAdd(mock.Anything, geneve).Return(nil).Once()
+	mockNetlink.EXPECT().LinkSetMaster(mock.Anything, geneve, bridge).Return(nil).Once()
+	mockNetlink.EXPECT().LinkSetUp(mock.Anything, geneve).Return(nil).Once()
+	mockNetlink.EXPECT().BridgeVlanAdd(mock.Anything, geneve, uint16(22), true, true, false, false).Return(nil).Once()
+	if err := opi.netlinkCreateLogicalBridge(context.Background(), obj); err != nil {
+		t.Error("error: expected", nil, "received", err)
+	}
+
+	// the tunnel is kept for the replay
+	opi.persistGeneveTunnels()
+	restored := NewServerWithArgs(mocks.NewNetlink(t), mocks.NewFrr(t), opi.store)
+	if err := restored.loadGeneveTunnels(); err != nil {
+		t.Fatal("error: expected", nil, "received", err)
+	}
+	if !reflect.DeepEqual(restored.GeneveTunnels, opi.GeneveTunnels) {
+		t.Error("geneve tunnels: expected", opi.GeneveTunnels, "received", restored.GeneveTunnels)
+	}
+}
@@ -0,0 +1,338 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"path"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils"
+)
+
+// persist writes the whole collection of the given container to the shared store,
+// so the HA peer can replay it on failover
+func (s *Server) persist(container string) {
+	var msg proto.Message
+	switch container {
+	case "vrfs":
+		r := &pb.ListVrfsResponse{}
+		for _, name := range sortedKeys(s.Vrfs) {
+			r.Vrfs = append(r.Vrfs, s.Vrfs[name])
+		}
+		msg = r
+	case "bridges":
+		r := &pb.ListLogicalBridgesResponse{}
+		for _, name := range sortedKeys(s.Bridges) {
+			r.LogicalBridges = append(r.LogicalBridges, s.Bridges[name])
+		}
+		msg = r
+	case "ports":
+		r := &pb.ListBridgePortsResponse{}
+		for _, name := range sortedKeys(s.Ports) {
+			r.BridgePorts = append(r.BridgePorts, s.Ports[name])
+		}
+		msg = r
+	case "svis":
+		r := &pb.ListSvisResponse{}
+		for _, name := range sortedKeys(s.Svis) {
+			r.Svis = append(r.Svis, s.Svis[name])
+		}
+		msg = r
+	default:
+		log.Panicf("unknown container %s", container)
+	}
+	if err := s.store.Set(container, msg); err != nil {
+		fmt.Printf("Failed to persist %s: %v", container, err)
+	}
+}
+
+// persistObjects writes a collection of child resources to the shared store, encoded with
+// utils.MarshalJSON since they are not messages
+func persistObjects[T any](s *Server, container string, objects map[string]*T) {
+	list := make([]*T, 0, len(objects))
+	for _, name := range sortedKeys(objects) {
+		list = append(list, objects[name])
+	}
+	data, err := utils.MarshalJSON(list)
+	if err == nil {
+		err = s.store.Set(container, wrapperspb.Bytes(data))
+	}
+	if err != nil {
+		fmt.Printf("Failed to persist %s: %v", container, err)
+	}
+}
+
+// loadObjects reads a collection of child resources written by persistObjects, none when
+// the container is missing
+func loadObjects[T any](s *Server, container string) ([]*T, error) {
+	data := &wrapperspb.BytesValue{}
+	found, err := s.store.Get(container, data)
+	if err != nil || !found {
+		return nil, err
+	}
+	var objects []*T
+	if err := utils.UnmarshalJSON(data.Value, &objects); err != nil {
+		return nil, err
+	}
+	return objects, nil
+}
+
+// Replay programs every object found in the shared store into this node,
+// parents first, used when becoming the active instance of an HA pair, the
+// objects failing are reported together once the others are programmed
+func (s *Server) Replay(ctx context.Context) error {
+	if err := s.loadKernelNames(); err != nil {
+		return err
+	}
+	if err := s.loadLabels(); err != nil {
+		return err
+	}
+	if err := s.loadMulticastGroups(); err != nil {
+		return err
+	}
+	if err := s.loadGeneveTunnels(); err != nil {
+		return err
+	}
+	if err := s.loadRouteTargets(); err != nil {
+		return err
+	}
+	if err := s.loadAnycastGateways(); err != nil {
+		return err
+	}
+	if err := s.loadSviAnnouncements(); err != nil {
+		return err
+	}
+	if err := s.loadAsymmetricIrb(); err != nil {
+		return err
+	}
+	if err := s.loadSrv6Vrfs(); err != nil {
+		return err
+	}
+	if err := s.loadNoMacLearning(); err != nil {
+		return err
+	}
+	if err := s.loadTrunkVlans(); err != nil {
+		return err
+	}
+	if err := s.loadPortMacsec(); err != nil {
+		return err
+	}
+	// one object failing, e.g. on a port missing from this node, does not stop the others
+	var errs []error
+	replayed := func(name string, err error) {
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+	underlays, err := loadObjects[UnderlayInterface](s, "underlayinterfaces")
+	replayed("underlayinterfaces", err)
+	for _, obj := range underlays {
+		log.Printf("Replaying UnderlayInterface %v", obj.Name)
+		in := &CreateUnderlayInterfaceRequest{UnderlayInterfaceID: path.Base(obj.Name), UnderlayInterface: &UnderlayInterface{Spec: obj.Spec}}
+		_, err := s.CreateUnderlayInterface(ctx, in)
+		replayed(obj.Name, err)
+	}
+	securities, err := loadObjects[TunnelSecurity](s, "tunnelsecurities")
+	replayed("tunnelsecurities", err)
+	for _, obj := range securities {
+		log.Printf("Replaying TunnelSecurity %v", obj.Name)
+		in := &CreateTunnelSecurityRequest{TunnelSecurityID: path.Base(obj.Name), TunnelSecurity: &TunnelSecurity{Spec: obj.Spec}}
+		_, err := s.CreateTunnelSecurity(ctx, in)
+		replayed(obj.Name, err)
+	}
+	prefixLists, err := loadObjects[PrefixList](s, "prefixlists")
+	replayed("prefixlists", err)
+	for _, obj := range prefixLists {
+		log.Printf("Replaying PrefixList %v", obj.Name)
+		in := &CreatePrefixListRequest{PrefixListID: path.Base(obj.Name), PrefixList: &PrefixList{Spec: obj.Spec}}
+		_, err := s.CreatePrefixList(ctx, in)
+		replayed(obj.Name, err)
+	}
+	routeMaps, err := loadObjects[RouteMap](s, "routemaps")
+	replayed("routemaps", err)
+	for _, obj := range routeMaps {
+		log.Printf("Replaying RouteMap %v", obj.Name)
+		in := &CreateRouteMapRequest{RouteMapID: path.Base(obj.Name), RouteMap: &RouteMap{Spec: obj.Spec}}
+		_, err := s.CreateRouteMap(ctx, in)
+		replayed(obj.Name, err)
+	}
+	vrfs := &pb.ListVrfsResponse{}
+	_, err = s.store.Get("vrfs", vrfs)
+	replayed("vrfs", err)
+	for _, obj := range vrfs.Vrfs {
+		log.Printf("Replaying Vrf %v", obj.Name)
+		in := &pb.CreateVrfRequest{VrfId: path.Base(obj.Name), Vrf: &pb.Vrf{Spec: obj.Spec}}
+		_, err := s.CreateVrf(withTenantOf(ctx, obj.Name), in)
+		replayed(obj.Name, err)
+	}
+	bridges := &pb.ListLogicalBridgesResponse{}
+	_, err = s.store.Get("bridges", bridges)
+	replayed("bridges", err)
+	for _, obj := range bridges.LogicalBridges {
+		log.Printf("Replaying LogicalBridge %v", obj.Name)
+		in := &pb.CreateLogicalBridgeRequest{LogicalBridgeId: path.Base(obj.Name), LogicalBridge: &pb.LogicalBridge{Spec: obj.Spec}}
+		_, err := s.CreateLogicalBridge(withTenantOf(ctx, obj.Name), in)
+		replayed(obj.Name, err)
+	}
+	ports := &pb.ListBridgePortsResponse{}
+	_, err = s.store.Get("ports", ports)
+	replayed("ports", err)
+	for _, obj := range ports.BridgePorts {
+		log.Printf("Replaying BridgePort %v", obj.Name)
+		in := &pb.CreateBridgePortRequest{BridgePortId: path.Base(obj.Name), BridgePort: &pb.BridgePort{Spec: obj.Spec}}
+		_, err := s.CreateBridgePort(withTenantOf(ctx, obj.Name), in)
+		replayed(obj.Name, err)
+	}
+	svis := &pb.ListSvisResponse{}
+	_, err = s.store.Get("svis", svis)
+	replayed("svis", err)
+	for _, obj := range svis.Svis {
+		log.Printf("Replaying Svi %v", obj.Name)
+		in := &pb.CreateSviRequest{SviId: path.Base(obj.Name), Svi: &pb.Svi{Spec: obj.Spec}}
+		_, err := s.CreateSvi(withTenantOf(ctx, obj.Name), in)
+		replayed(obj.Name, err)
+	}
+	routes, err := loadObjects[Route](s, "routes")
+	replayed("routes", err)
+	for _, obj := range routes {
+		log.Printf("Replaying Route %v", obj.Name)
+		in := &CreateRouteRequest{Parent: routeParent(obj.Name), RouteID: path.Base(obj.Name), Route: &Route{Spec: obj.Spec}}
+		_, err := s.CreateRoute(withTenantOf(ctx, obj.Name), in)
+		replayed(obj.Name, err)
+	}
+	handoffs, err := loadObjects[VrfLiteHandoff](s, "handoffs")
+	replayed("handoffs", err)
+	for _, obj := range handoffs {
+		log.Printf("Replaying VrfLiteHandoff %v", obj.Name)
+		in := &CreateVrfLiteHandoffRequest{VrfLiteHandoffID: path.Base(obj.Name), VrfLiteHandoff: &VrfLiteHandoff{Spec: obj.Spec}}
+		_, err := s.CreateVrfLiteHandoff(withTenantOf(ctx, obj.Name), in)
+		replayed(obj.Name, err)
+	}
+	leaks, err := loadObjects[RouteLeak](s, "routeleaks")
+	replayed("routeleaks", err)
+	for _, obj := range leaks {
+		log.Printf("Replaying RouteLeak %v", obj.Name)
+		in := &CreateRouteLeakRequest{RouteLeakID: path.Base(obj.Name), RouteLeak: &RouteLeak{Spec: obj.Spec}}
+		_, err := s.CreateRouteLeak(withTenantOf(ctx, obj.Name), in)
+		replayed(obj.Name, err)
+	}
+	peers, err := loadObjects[BgpPeer](s, "bgppeers")
+	replayed("bgppeers", err)
+	for _, obj := range peers {
+		log.Printf("Replaying BgpPeer %v", obj.Name)
+		in := &CreateBgpPeerRequest{BgpPeerID: path.Base(obj.Name), BgpPeer: &BgpPeer{Spec: obj.Spec}}
+		_, err := s.CreateBgpPeer(withTenantOf(ctx, obj.Name), in)
+		replayed(obj.Name, err)
+	}
+	entries, err := loadObjects[StaticFdbEntry](s, "fdbentries")
+	replayed("fdbentries", err)
+	for _, obj := range entries {
+		log.Printf("Replaying StaticFdbEntry %v", obj.Name)
+		in := &CreateStaticFdbEntryRequest{Parent: staticFdbEntryParent(obj.Name), StaticFdbEntryID: path.Base(obj.Name), StaticFdbEntry: &StaticFdbEntry{Spec: obj.Spec}}
+		_, err := s.CreateStaticFdbEntry(withTenantOf(ctx, obj.Name), in)
+		replayed(obj.Name, err)
+	}
+	policies, err := loadObjects[SecurityPolicy](s, "securitypolicies")
+	replayed("securitypolicies", err)
+	for _, obj := range policies {
+		log.Printf("Replaying SecurityPolicy %v", obj.Name)
+		in := &CreateSecurityPolicyRequest{Parent: securityPolicyParent(obj.Name), SecurityPolicyID: path.Base(obj.Name), SecurityPolicy: &SecurityPolicy{Spec: obj.Spec}}
+		_, err := s.CreateSecurityPolicy(withTenantOf(ctx, obj.Name), in)
+		replayed(obj.Name, err)
+	}
+	natRules, err := loadObjects[NatRule](s, "natrules")
+	replayed("natrules", err)
+	for _, obj := range natRules {
+		log.Printf("Replaying NatRule %v", obj.Name)
+		in := &CreateNatRuleRequest{Parent: natRuleParent(obj.Name), NatRuleID: path.Base(obj.Name), NatRule: &NatRule{Spec: obj.Spec}}
+		_, err := s.CreateNatRule(withTenantOf(ctx, obj.Name), in)
+		replayed(obj.Name, err)
+	}
+	pbrRules, err := loadObjects[PbrRule](s, "pbrrules")
+	replayed("pbrrules", err)
+	for _, obj := range pbrRules {
+		log.Printf("Replaying PbrRule %v", obj.Name)
+		in := &CreatePbrRuleRequest{Parent: pbrRuleParent(obj.Name), PbrRuleID: path.Base(obj.Name), PbrRule: &PbrRule{Spec: obj.Spec}}
+		_, err := s.CreatePbrRule(withTenantOf(ctx, obj.Name), in)
+		replayed(obj.Name, err)
+	}
+	replayed("generations", s.loadGenerations())
+	if len(errs) != 0 {
+		msgs := make([]string, 0, len(errs))
+		for _, err := range errs {
+			msgs = append(msgs, err.Error())
+		}
+		return fmt.Errorf("failed to replay %d objects: %s", len(errs), strings.Join(msgs, "; "))
+	}
+	return nil
+}
+
+// IsStandby reports whether this instance is the HA standby and must not program state
+func (s *Server) IsStandby() bool {
+	return s.standby.Load()
+}
+
+// campaign runs a single election round and fails over when the lease is won, the
+// active instance steps down once it could not renew the lease for a whole ttl, as the
+// peer may hold it by then
+func (s *Server) campaign(ctx context.Context, lease utils.Lease, ttl time.Duration) error {
+	held, err := lease.Acquire(ctx)
+	if err != nil {
+		fmt.Printf("Failed to acquire HA lease: %v", err)
+		if !s.IsStandby() && time.Since(s.leaseRenewed) >= ttl {
+			log.Printf("HA lease not renewed for %v, becoming standby", ttl)
+			s.standby.Store(true)
+		}
+		return err
+	}
+	if held {
+		s.leaseRenewed = time.Now()
+	}
+	switch {
+	case held && s.IsStandby():
+		log.Printf("HA lease acquired, becoming active")
+		if err := s.Replay(ctx); err != nil {
+			fmt.Printf("Failed to replay state: %v", err)
+			return err
+		}
+		s.standby.Store(false)
+	case !held && !s.IsStandby():
+		log.Printf("HA lease lost, becoming standby")
+		s.standby.Store(true)
+	}
+	return nil
+}
+
+// StartHA enters standby, runs the first election round and keeps campaigning
+// for the lease, which expires after ttl, in the background until ctx is done,
+// the lease is released on exit so the peer takes over without waiting for expiry
+func (s *Server) StartHA(ctx context.Context, lease utils.Lease, ttl time.Duration, interval time.Duration) {
+	s.standby.Store(true)
+	_ = s.campaign(ctx, lease, ttl)
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				if err := lease.Release(context.Background()); err != nil {
+					fmt.Printf("Failed to release HA lease: %v", err)
+				}
+				return
+			case <-ticker.C:
+				_ = s.campaign(ctx, lease, ttl)
+			}
+		}
+	}()
+}
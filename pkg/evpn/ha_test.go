@@ -0,0 +1,169 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/philippgille/gokv/gomap"
+	"github.com/stretchr/testify/mock"
+	"github.com/vishvananda/netlink"
+
+	"google.golang.org/protobuf/proto"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+	pc "github.com/opiproject/opi-api/network/opinetcommon/v1alpha1/gen/go"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/fake"
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils"
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils/mocks"
+)
+
+type fakeLease struct {
+	held bool
+	err  error
+}
+
+func (l *fakeLease) Acquire(_ context.Context) (bool, error) {
+	return l.held, l.err
+}
+
+func (l *fakeLease) Release(_ context.Context) error {
+	return nil
+}
+
+func Test_Failover(t *testing.T) {
+	tests := map[string]struct {
+		lease       *fakeLease
+		wantStandby bool
+		wantVrfs    int
+		on          func(mockNetlink *mocks.Netlink, mockFrr *mocks.Frr)
+	}{
+		"lease held by peer": {
+			lease:       &fakeLease{held: false},
+			wantStandby: true,
+			wantVrfs:    0,
+			on:          nil,
+		},
+		"failed Acquire call": {
+			lease:       &fakeLease{err: errors.New("Failed to call Acquire")},
+			wantStandby: true,
+			wantVrfs:    0,
+			on:          nil,
+		},
+		"lease acquired replays state": {
+			lease:       &fakeLease{held: true},
+			wantStandby: false,
+			wantVrfs:    1,
+			on: func(mockNetlink *mocks.Netlink, mockFrr *mocks.Frr) {
+				vrf := &netlink.Vrf{LinkAttrs: netlink.LinkAttrs{Name: testVrfID, Alias: managedAlias}, Table: 1000}
+				mockNetlink.EXPECT().LinkAdd(mock.Anything, vrf).Return(nil).Once()
+				mockNetlink.EXPECT().LinkSetUp(mock.Anything, vrf).Return(nil).Once()
+				mockFrr.EXPECT().FrrZebraCmd(mock.Anything, "show vrf").Return("", nil).Once()
+			},
+		},
+	}
+
+	// run tests
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			store := gomap.NewStore(gomap.Options{Codec: utils.ProtoCodec{}})
+
+			// the active node persists its objects in the shared store
+			active := NewServerWithArgs(mocks.NewNetlink(t), mocks.NewFrr(t), store)
+			active.Vrfs[testVrfName] = &pb.Vrf{Name: testVrfName, Spec: &pb.VrfSpec{LoopbackIpPrefix: &pc.IPPrefix{Len: 24}}}
+			active.persist("vrfs")
+
+			mockNetlink := mocks.NewNetlink(t)
+			mockFrr := mocks.NewFrr(t)
+			standby := NewServerWithArgs(mockNetlink, mockFrr, store)
+			standby.standby.Store(true)
+			if tt.on != nil {
+				tt.on(mockNetlink, mockFrr)
+			}
+
+			_ = standby.campaign(ctx, tt.lease, time.Minute)
+			if standby.IsStandby() != tt.wantStandby {
+				t.Error("standby: expected", tt.wantStandby, "received", standby.IsStandby())
+			}
+			if len(standby.Vrfs) != tt.wantVrfs {
+				t.Error("vrfs: expected", tt.wantVrfs, "received", len(standby.Vrfs))
+			}
+			if vrf, ok := standby.Vrfs[testVrfName]; ok && !proto.Equal(vrf.Spec, active.Vrfs[testVrfName].Spec) {
+				t.Error("vrf: expected", active.Vrfs[testVrfName], "received", standby.Vrfs[testVrfName])
+			}
+		})
+	}
+
+	t.Run("lease lost steps down", func(t *testing.T) {
+		ctx := context.Background()
+		store := gomap.NewStore(gomap.Options{Codec: utils.ProtoCodec{}})
+		opi := NewServerWithArgs(mocks.NewNetlink(t), mocks.NewFrr(t), store)
+
+		_ = opi.campaign(ctx, &fakeLease{held: false}, time.Minute)
+		if !opi.IsStandby() {
+			t.Error("standby: expected", true, "received", opi.IsStandby())
+		}
+	})
+	t.Run("lease not renewed steps down", func(t *testing.T) {
+		ctx := context.Background()
+		store := gomap.NewStore(gomap.Options{Codec: utils.ProtoCodec{}})
+		opi := NewServerWithArgs(mocks.NewNetlink(t), mocks.NewFrr(t), store)
+		lease := &fakeLease{held: true}
+
+		_ = opi.campaign(ctx, lease, time.Minute)
+		lease.err = errors.New("Failed to call Acquire")
+		_ = opi.campaign(ctx, lease, time.Minute)
+		if opi.IsStandby() {
+			t.Error("standby within the ttl: expected", false, "received", opi.IsStandby())
+		}
+		opi.leaseRenewed = opi.leaseRenewed.Add(-time.Minute)
+		_ = opi.campaign(ctx, lease, time.Minute)
+		if !opi.IsStandby() {
+			t.Error("standby after the ttl: expected", true, "received", opi.IsStandby())
+		}
+	})
+}
+
+func Test_ReplayChildResources(t *testing.T) {
+	ctx := context.Background()
+	store := gomap.NewStore(gomap.Options{Codec: utils.ProtoCodec{}})
+
+	// the active node persists the child resources next to their Vrf
+	active := NewServerWithArgs(fake.NewNetlink(), fake.NewFrr(), store)
+	if _, err := active.CreateVrf(ctx, &pb.CreateVrfRequest{VrfId: testVrfID, Vrf: protoClone(&testVrf)}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := active.CreateRoute(ctx, &CreateRouteRequest{Parent: testVrfName, RouteID: testRouteID, Route: testRoute.clone()}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := active.CreatePrefixList(ctx, &CreatePrefixListRequest{PrefixListID: testPrefixListID, PrefixList: testPrefixList.clone()}); err != nil {
+		t.Fatal(err)
+	}
+	// a BridgePort on an interface missing from the standby node fails alone
+	active.Ports[testBridgePortName] = protoClone(&testBridgePort)
+	active.Ports[testBridgePortName].Name = testBridgePortName
+	active.persist("ports")
+
+	standby := NewServerWithArgs(fake.NewNetlink(), fake.NewFrr(), store)
+	err := standby.Replay(ctx)
+	if err == nil || !strings.Contains(err.Error(), testBridgePortName) {
+		t.Error("error: expected the failure of", testBridgePortName, "received", err)
+	}
+	if _, ok := standby.Vrfs[testVrfName]; !ok {
+		t.Error("vrf: expected", testVrfName, "received", standby.Vrfs)
+	}
+	if route, ok := standby.Routes[testRouteName]; !ok || checkSameChildSpec(route.Name, route.Spec, testRoute.clone().Spec) != nil {
+		t.Error("route: expected", testRoute.Spec, "received", route)
+	}
+	if list, ok := standby.PrefixLists[testPrefixListName]; !ok || checkSameChildSpec(list.Name, list.Spec, testPrefixList.clone().Spec) != nil {
+		t.Error("prefix-list: expected", testPrefixList.Spec, "received", list)
+	}
+}
@@ -0,0 +1,271 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+	pc "github.com/opiproject/opi-api/network/opinetcommon/v1alpha1/gen/go"
+
+	"go.einride.tech/aip/resourceid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// VrfLiteHandoff bundles the VLAN sub-interface on a shared uplink, its
+// addressing and the eBGP session towards an upstream router into a single
+// VRF-lite interconnect resource
+// TODO: move to opi-api once the message is agreed upon
+type VrfLiteHandoff struct {
+	Name   string
+	Spec   *VrfLiteHandoffSpec
+	Status *VrfLiteHandoffStatus
+}
+
+// VrfLiteHandoffSpec is the desired configuration of a VrfLiteHandoff
+type VrfLiteHandoffSpec struct {
+	// Vrf is the name of the Vrf resource to hand off
+	Vrf string
+	// Uplink is the kernel name of the shared uplink interface (e.g.: eth0)
+	Uplink string
+	// VlanID is the VLAN used on the uplink for this Vrf
+	VlanID uint32
+	// LocalIPPrefix is assigned to the VLAN sub-interface
+	LocalIPPrefix *pc.IPPrefix
+	// PeerIPAddress is the address of the upstream router
+	PeerIPAddress *pc.IPAddress
+	// RemoteAs is the AS number of the upstream router
+	RemoteAs uint32
+	// ImportRouteMap is the name of the RouteMap filtering the routes received from the upstream router
+	ImportRouteMap string
+	// ExportRouteMap is the name of the RouteMap filtering the routes advertised to the upstream router
+	ExportRouteMap string
+}
+
+// VrfLiteHandoffStatus is the observed state of a VrfLiteHandoff
+type VrfLiteHandoffStatus struct {
+	// InterfaceName is the kernel name of the VLAN sub-interface
+	InterfaceName string
+	OperStatus    pb.VRFOperStatus
+}
+
+// CreateVrfLiteHandoffRequest is the request to create a VrfLiteHandoff
+type CreateVrfLiteHandoffRequest struct {
+	VrfLiteHandoffID string
+	VrfLiteHandoff   *VrfLiteHandoff
+}
+
+// DeleteVrfLiteHandoffRequest is the request to delete a VrfLiteHandoff
+type DeleteVrfLiteHandoffRequest struct {
+	Name         string
+	AllowMissing bool
+}
+
+// GetVrfLiteHandoffRequest is the request to get a VrfLiteHandoff
+type GetVrfLiteHandoffRequest struct {
+	Name string
+}
+
+// ListVrfLiteHandoffsRequest is the request to list VrfLiteHandoffs
+type ListVrfLiteHandoffsRequest struct {
+	PageSize  int32
+	PageToken string
+}
+
+// ListVrfLiteHandoffsResponse is the response of listing VrfLiteHandoffs
+type ListVrfLiteHandoffsResponse struct {
+	VrfLiteHandoffs []*VrfLiteHandoff
+	NextPageToken   string
+}
+
+func (h *VrfLiteHandoff) clone() *VrfLiteHandoff {
+	if h == nil {
+		return nil
+	}
+	r := &VrfLiteHandoff{Name: h.Name}
+	if h.Spec != nil {
+		spec := *h.Spec
+		if h.Spec.LocalIPPrefix != nil {
+			spec.LocalIPPrefix = protoClone(h.Spec.LocalIPPrefix)
+		}
+		if h.Spec.PeerIPAddress != nil {
+			spec.PeerIPAddress = protoClone(h.Spec.PeerIPAddress)
+		}
+		r.Spec = &spec
+	}
+	if h.Status != nil {
+		st := *h.Status
+		r.Status = &st
+	}
+	return r
+}
+
+func sortVrfLiteHandoffs(handoffs []*VrfLiteHandoff) {
+	sort.Slice(handoffs, func(i int, j int) bool {
+		return handoffs[i].Name < handoffs[j].Name
+	})
+}
+
+func handoffInterfaceName(spec *VrfLiteHandoffSpec) string {
+	return fmt.Sprintf("%s.%d", spec.Uplink, spec.VlanID)
+}
+
+// handoffKernelName returns the kernel name of the VLAN sub-interface, long uplink names do not fit in IFNAMSIZ
+func (s *Server) handoffKernelName(obj *VrfLiteHandoff) string {
+	return s.lookupKernelName(obj.Name, handoffInterfaceName(obj.Spec))
+}
+
+// CreateVrfLiteHandoff executes the creation of the VRF-lite handoff
+func (s *Server) CreateVrfLiteHandoff(ctx context.Context, in *CreateVrfLiteHandoffRequest) (*VrfLiteHandoff, error) {
+	// check input correctness
+	if err := s.validateCreateVrfLiteHandoffRequest(in); err != nil {
+		return nil, err
+	}
+	// see https://google.aip.dev/133#user-specified-ids
+	resourceID := resourceid.NewSystemGenerated()
+	if in.VrfLiteHandoffID != "" {
+		log.Printf("client provided the ID of a resource %v, ignoring the name field %v", in.VrfLiteHandoffID, in.VrfLiteHandoff.Name)
+		resourceID = in.VrfLiteHandoffID
+	}
+	name, err := newObjectName(ctx, "handoffs", resourceID)
+	if err != nil {
+		return nil, err
+	}
+	in.VrfLiteHandoff.Name = name
+	s.objectsMu.Lock()
+	defer s.objectsMu.Unlock()
+	// idempotent API when called with same key, should return same object
+	obj, ok := s.Handoffs[in.VrfLiteHandoff.Name]
+	if ok {
+		// a different spec under the same key is a conflict, not a retry
+		if err := checkSameChildSpec(obj.Name, obj.Spec, in.VrfLiteHandoff.Spec); err != nil {
+			return nil, err
+		}
+		log.Printf("Already existing VrfLiteHandoff with id %v", in.VrfLiteHandoff.Name)
+		return obj.clone(), nil
+	}
+	if err := checkSameTenant(in.VrfLiteHandoff.Name, in.VrfLiteHandoff.Spec.Vrf); err != nil {
+		return nil, err
+	}
+	// now get Vrf to plug the sub-interface into
+	vrf, ok := s.Vrfs[in.VrfLiteHandoff.Spec.Vrf]
+	if !ok {
+		err := status.Errorf(codes.NotFound, "unable to find key %s", in.VrfLiteHandoff.Spec.Vrf)
+		return nil, err
+	}
+	if err := s.checkRouteMaps(in.VrfLiteHandoff.Spec.ImportRouteMap, in.VrfLiteHandoff.Spec.ExportRouteMap); err != nil {
+		return nil, err
+	}
+	wanted := handoffInterfaceName(in.VrfLiteHandoff.Spec)
+	s.setKernelName(in.VrfLiteHandoff.Name, wanted, s.kernelNameFor(in.VrfLiteHandoff.Name, wanted))
+	if err := s.dataplane.CreateVrfLiteHandoff(ctx, in.VrfLiteHandoff, vrf); err != nil {
+		s.releaseKernelName(in.VrfLiteHandoff.Name)
+		s.forgetStatus(in.VrfLiteHandoff.Name)
+		return nil, status.Convert(err).Err()
+	}
+	// save object to the database
+	response := in.VrfLiteHandoff.clone()
+	response.Status = &VrfLiteHandoffStatus{
+		InterfaceName: s.handoffKernelName(in.VrfLiteHandoff),
+		OperStatus:    pb.VRFOperStatus_VRF_OPER_STATUS_UP,
+	}
+	s.Handoffs[in.VrfLiteHandoff.Name] = response
+	persistObjects(s, "handoffs", s.Handoffs)
+	s.refreshNat(ctx, vrf)
+	return response.clone(), nil
+}
+
+// DeleteVrfLiteHandoff deletes a VRF-lite handoff
+func (s *Server) DeleteVrfLiteHandoff(ctx context.Context, in *DeleteVrfLiteHandoffRequest) (*emptypb.Empty, error) {
+	// check input correctness
+	if err := s.validateDeleteVrfLiteHandoffRequest(in); err != nil {
+		return nil, err
+	}
+	s.objectsMu.Lock()
+	defer s.objectsMu.Unlock()
+	return s.deleteVrfLiteHandoff(ctx, in)
+}
+
+// deleteVrfLiteHandoff deletes a validated VRF-lite handoff, with objectsMu held
+func (s *Server) deleteVrfLiteHandoff(ctx context.Context, in *DeleteVrfLiteHandoffRequest) (*emptypb.Empty, error) {
+	// fetch object from the database
+	obj, ok := s.Handoffs[in.Name]
+	if !ok || !inTenant(ctx, in.Name) {
+		if in.AllowMissing {
+			return &emptypb.Empty{}, nil
+		}
+		err := status.Errorf(codes.NotFound, "unable to find key %s", in.Name)
+		return nil, err
+	}
+	// fetch object from the database
+	vrf, ok := s.Vrfs[obj.Spec.Vrf]
+	if !ok {
+		err := status.Errorf(codes.NotFound, "unable to find key %s", obj.Spec.Vrf)
+		return nil, err
+	}
+	if err := s.dataplane.DeleteVrfLiteHandoff(ctx, obj, vrf); err != nil {
+		return nil, status.Convert(err).Err()
+	}
+	// remove from the Database
+	delete(s.Handoffs, obj.Name)
+	persistObjects(s, "handoffs", s.Handoffs)
+	s.forgetStatus(obj.Name)
+	s.releaseKernelName(obj.Name)
+	s.refreshNat(ctx, vrf)
+	return &emptypb.Empty{}, nil
+}
+
+// GetVrfLiteHandoff gets a VRF-lite handoff
+func (s *Server) GetVrfLiteHandoff(ctx context.Context, in *GetVrfLiteHandoffRequest) (*VrfLiteHandoff, error) {
+	// check input correctness
+	if err := s.validateGetVrfLiteHandoffRequest(in); err != nil {
+		return nil, err
+	}
+	s.objectsMu.RLock()
+	defer s.objectsMu.RUnlock()
+	// fetch object from the database
+	obj, ok := s.Handoffs[in.Name]
+	if !ok || !inTenant(ctx, in.Name) {
+		err := status.Errorf(codes.NotFound, "unable to find key %s", in.Name)
+		return nil, err
+	}
+	if err := s.dataplane.GetVrfLiteHandoff(ctx, obj); err != nil {
+		return nil, status.Convert(err).Err()
+	}
+	return obj.clone(), nil
+}
+
+// ListVrfLiteHandoffs lists VRF-lite handoffs
+func (s *Server) ListVrfLiteHandoffs(ctx context.Context, in *ListVrfLiteHandoffsRequest) (*ListVrfLiteHandoffsResponse, error) {
+	// fetch pagination from the database, calculate size and offset
+	size, offset, perr := s.extractPagination(in.PageSize, in.PageToken)
+	if perr != nil {
+		return nil, perr
+	}
+	s.objectsMu.RLock()
+	defer s.objectsMu.RUnlock()
+	// fetch object from the database, the following pages are cut from the snapshot of the first one
+	Blobarray, token, err := listPage(ctx, s, "vrfLiteHandoffs", "", in.PageToken, offset, size, func() []*VrfLiteHandoff {
+		Blobarray := []*VrfLiteHandoff{}
+		for name, handoff := range s.Handoffs {
+			if !inTenant(ctx, name) {
+				continue
+			}
+			Blobarray = append(Blobarray, handoff.clone())
+		}
+		// sort is needed, since MAP is unsorted in golang, and we might get different results
+		sortVrfLiteHandoffs(Blobarray)
+		return Blobarray
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &ListVrfLiteHandoffsResponse{VrfLiteHandoffs: Blobarray, NextPageToken: token}, nil
+}
@@ -0,0 +1,48 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
+)
+
+func handoffPeerIP(spec *VrfLiteHandoffSpec) net.IP {
+	peer := make(net.IP, 4)
+	binary.BigEndian.PutUint32(peer, spec.PeerIPAddress.GetV4Addr())
+	return peer
+}
+
+func (s *Server) frrCreateVrfLiteHandoffRequest(ctx context.Context, in *CreateVrfLiteHandoffRequest, vrfName string) error {
+	data, err := s.frr.FrrBgpCmd(ctx, fmt.Sprintf(
+		`configure terminal
+		router bgp %[4]d vrf %[1]s
+		neighbor %[2]s remote-as %[3]d
+		address-family ipv4 unicast
+			neighbor %[2]s activate
+			%[5]sexit-address-family
+		exit`, vrfName, handoffPeerIP(in.VrfLiteHandoff.Spec), in.VrfLiteHandoff.Spec.RemoteAs, s.Gateway.LocalAs,
+		frrNeighborRouteMaps(handoffPeerIP(in.VrfLiteHandoff.Spec).String(), in.VrfLiteHandoff.Spec.ImportRouteMap, in.VrfLiteHandoff.Spec.ExportRouteMap)))
+	fmt.Printf("FrrBgpCmd: %v:%v", data, err)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (s *Server) frrDeleteVrfLiteHandoffRequest(ctx context.Context, obj *VrfLiteHandoff, vrfName string) error {
+	data, err := s.frr.FrrBgpCmd(ctx, fmt.Sprintf(
+		`configure terminal
+		router bgp %d vrf %s
+		no neighbor %s
+		exit`, s.Gateway.LocalAs, vrfName, handoffPeerIP(obj.Spec)))
+	fmt.Printf("FrrBgpCmd: %v:%v", data, err)
+	if err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"log"
+	"net"
+
+	"github.com/vishvananda/netlink"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (s *Server) netlinkCreateVrfLiteHandoff(ctx context.Context, in *CreateVrfLiteHandoffRequest, vrf *pb.Vrf) error {
+	spec := in.VrfLiteHandoff.Spec
+	uplink, err := s.nLink.LinkByName(ctx, spec.Uplink)
+	if err != nil {
+		err := status.Errorf(codes.NotFound, "unable to find key %s", spec.Uplink)
+		return err
+	}
+	// Example: ip link add link eth0 name eth0.100 type vlan id 100
+	vlanName := s.handoffKernelName(in.VrfLiteHandoff)
+	vlandev := &netlink.Vlan{LinkAttrs: netlink.LinkAttrs{Name: vlanName, ParentIndex: uplink.Attrs().Index, Alias: managedAlias}, VlanId: int(spec.VlanID)}
+	log.Printf("Creating VLAN %v", vlandev)
+	if err := s.nLink.LinkAdd(ctx, vlandev); err != nil {
+		fmt.Printf("Failed to create vlan link: %v", err)
+		return err
+	}
+	// get net device by name
+	vrfName := s.vrfKernelName(vrf.Name)
+	vrfdev, err := s.nLink.LinkByName(ctx, vrfName)
+	if err != nil {
+		err := status.Errorf(codes.NotFound, "unable to find key %s", vrf.Name)
+		return err
+	}
+	// Example: ip link set eth0.100 master <vrf-name>
+	if err := s.nLink.LinkSetMaster(ctx, vlandev, vrfdev); err != nil {
+		fmt.Printf("Failed to add vlandev to vrf: %v", err)
+		return err
+	}
+	// Example: ip address add <local-ip-with-prefixlength> dev eth0.100
+	myip := make(net.IP, 4)
+	binary.BigEndian.PutUint32(myip, spec.LocalIPPrefix.Addr.GetV4Addr())
+	addr := &netlink.Addr{IPNet: &net.IPNet{IP: myip, Mask: net.CIDRMask(int(spec.LocalIPPrefix.Len), 32)}}
+	if err := s.nLink.AddrAdd(ctx, vlandev, addr); err != nil {
+		fmt.Printf("Failed to set IP on link: %v", err)
+		return err
+	}
+	// Example: ip link set eth0.100 up
+	if err := s.nLink.LinkSetUp(ctx, vlandev); err != nil {
+		fmt.Printf("Failed to up link: %v", err)
+		return err
+	}
+	return nil
+}
+
+func (s *Server) netlinkDeleteVrfLiteHandoff(ctx context.Context, obj *VrfLiteHandoff) error {
+	vlanName := s.handoffKernelName(obj)
+	vlandev, err := s.nLink.LinkByName(ctx, vlanName)
+	if err != nil {
+		err := status.Errorf(codes.NotFound, "unable to find key %s", vlanName)
+		return err
+	}
+	log.Printf("Deleting VLAN %v", vlandev)
+	// bring link down
+	if err := s.nLink.LinkSetDown(ctx, vlandev); err != nil {
+		fmt.Printf("Failed to up link: %v", err)
+		return err
+	}
+	// use netlink to delete vlan
+	if err := s.nLink.LinkDel(ctx, vlandev); err != nil {
+		fmt.Printf("Failed to delete link: %v", err)
+		return err
+	}
+	return nil
+}
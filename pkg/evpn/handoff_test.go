@@ -0,0 +1,344 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"reflect"
+	"testing"
+
+	"github.com/philippgille/gokv/gomap"
+	"github.com/stretchr/testify/mock"
+	"github.com/vishvananda/netlink"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+	pc "github.com/opiproject/opi-api/network/opinetcommon/v1alpha1/gen/go"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils/mocks"
+)
+
+var (
+	testVrfLiteHandoffID   = "opi-handoff8"
+	testVrfLiteHandoffName = resourceIDToFullName("handoffs", testVrfLiteHandoffID)
+	testVrfLiteHandoff     = VrfLiteHandoff{
+		Spec: &VrfLiteHandoffSpec{
+			Vrf:    testVrfName,
+			Uplink: "eth0",
+			VlanID: 100,
+			LocalIPPrefix: &pc.IPPrefix{
+				Addr: &pc.IPAddress{
+					Af: pc.IpAf_IP_AF_INET,
+					V4OrV6: &pc.IPAddress_V4Addr{
+						V4Addr: 167772162,
+					},
+				},
+				Len: 30,
+			},
+			PeerIPAddress: &pc.IPAddress{
+				Af: pc.IpAf_IP_AF_INET,
+				V4OrV6: &pc.IPAddress_V4Addr{
+					V4Addr: 167772161,
+				},
+			},
+			RemoteAs: 65100,
+		},
+	}
+	testVrfLiteHandoffWithStatus = VrfLiteHandoff{
+		Name: testVrfLiteHandoffName,
+		Spec: testVrfLiteHandoff.Spec,
+		Status: &VrfLiteHandoffStatus{
+			InterfaceName: "eth0.100",
+			OperStatus:    pb.VRFOperStatus_VRF_OPER_STATUS_UP,
+		},
+	}
+)
+
+func Test_CreateVrfLiteHandoff(t *testing.T) {
+	tests := map[string]struct {
+		id      string
+		in      *VrfLiteHandoff
+		out     *VrfLiteHandoff
+		errCode codes.Code
+		errMsg  string
+		exist   bool
+		on      func(mockNetlink *mocks.Netlink, mockFrr *mocks.Frr, errMsg string)
+	}{
+		"illegal resource_id": {
+			id:      "CapitalLettersNotAllowed",
+			in:      &testVrfLiteHandoff,
+			out:     nil,
+			errCode: codes.InvalidArgument,
+			errMsg:  fmt.Sprintf("user-settable ID must only contain lowercase, numbers and hyphens (%v)", "got: 'C' in position 0"),
+			exist:   false,
+			on:      nil,
+		},
+		"no required handoff field": {
+			id:      testVrfLiteHandoffID,
+			in:      nil,
+			out:     nil,
+			errCode: codes.InvalidArgument,
+			errMsg:  "missing required field: vrf_lite_handoff",
+			exist:   false,
+			on:      nil,
+		},
+		"no required uplink field": {
+			id: testVrfLiteHandoffID,
+			in: &VrfLiteHandoff{
+				Spec: &VrfLiteHandoffSpec{
+					Vrf: testVrfName,
+				},
+			},
+			out:     nil,
+			errCode: codes.InvalidArgument,
+			errMsg:  "missing required field: vrf_lite_handoff.spec.uplink",
+			exist:   false,
+			on:      nil,
+		},
+		"illegal VlanId": {
+			id: testVrfLiteHandoffID,
+			in: &VrfLiteHandoff{
+				Spec: &VrfLiteHandoffSpec{
+					Vrf:           testVrfName,
+					Uplink:        "eth0",
+					VlanID:        4095,
+					LocalIPPrefix: testVrfLiteHandoff.Spec.LocalIPPrefix,
+					PeerIPAddress: testVrfLiteHandoff.Spec.PeerIPAddress,
+					RemoteAs:      65100,
+				},
+			},
+			out:     nil,
+			errCode: codes.InvalidArgument,
+			errMsg:  fmt.Sprintf("VlanId value (%v) have to be between 1 and 4094", 4095),
+			exist:   false,
+			on:      nil,
+		},
+		"already exists": {
+			id:      testVrfLiteHandoffID,
+			in:      &testVrfLiteHandoff,
+			out:     &testVrfLiteHandoffWithStatus,
+			errCode: codes.OK,
+			errMsg:  "",
+			exist:   true,
+			on:      nil,
+		},
+		"already exists with a different spec": {
+			id: testVrfLiteHandoffID,
+			in: &VrfLiteHandoff{
+				Spec: &VrfLiteHandoffSpec{
+					Vrf:           testVrfName,
+					Uplink:        "eth0",
+					VlanID:        200,
+					LocalIPPrefix: testVrfLiteHandoff.Spec.LocalIPPrefix,
+					PeerIPAddress: testVrfLiteHandoff.Spec.PeerIPAddress,
+					RemoteAs:      65100,
+				},
+			},
+			out:     nil,
+			errCode: codes.AlreadyExists,
+			errMsg:  fmt.Sprintf("%s already exists with a different spec", testVrfLiteHandoffName),
+			exist:   true,
+			on:      nil,
+		},
+		"failed uplink LinkByName call": {
+			id:      testVrfLiteHandoffID,
+			in:      &testVrfLiteHandoff,
+			out:     nil,
+			errCode: codes.NotFound,
+			errMsg:  fmt.Sprintf("unable to find key %v", "eth0"),
+			exist:   false,
+			on: func(mockNetlink *mocks.Netlink, mockFrr *mocks.Frr, errMsg string) {
+				mockNetlink.EXPECT().LinkByName(mock.Anything, "eth0").Return(nil, errors.New(errMsg)).Once()
+			},
+		},
+		"failed LinkAdd call": {
+			id:      testVrfLiteHandoffID,
+			in:      &testVrfLiteHandoff,
+			out:     nil,
+			errCode: codes.Unknown,
+			errMsg:  "Failed to call LinkAdd",
+			exist:   false,
+			on: func(mockNetlink *mocks.Netlink, mockFrr *mocks.Frr, errMsg string) {
+				uplink := &netlink.Device{LinkAttrs: netlink.LinkAttrs{Name: "eth0", Index: 7}}
+				vlandev := &netlink.Vlan{LinkAttrs: netlink.LinkAttrs{Name: "eth0.100", ParentIndex: 7, Alias: managedAlias}, VlanId: 100}
+				mockNetlink.EXPECT().LinkByName(mock.Anything, "eth0").Return(uplink, nil).Once()
+				mockNetlink.EXPECT().LinkAdd(mock.Anything, vlandev).Return(errors.New(errMsg)).Once()
+			},
+		},
+		"failed FrrBgpCmd call": {
+			id:      testVrfLiteHandoffID,
+			in:      &testVrfLiteHandoff,
+			out:     nil,
+			errCode: codes.Unknown,
+			errMsg:  "Failed to call FrrBgpCmd",
+			exist:   false,
+			on: func(mockNetlink *mocks.Netlink, mockFrr *mocks.Frr, errMsg string) {
+				uplink := &netlink.Device{LinkAttrs: netlink.LinkAttrs{Name: "eth0", Index: 7}}
+				vlandev := &netlink.Vlan{LinkAttrs: netlink.LinkAttrs{Name: "eth0.100", ParentIndex: 7, Alias: managedAlias}, VlanId: 100}
+				vrf := &netlink.Vrf{LinkAttrs: netlink.LinkAttrs{Name: testVrfID, Alias: managedAlias}, Table: 1000}
+				addr := &netlink.Addr{IPNet: &net.IPNet{IP: net.IPv4(10, 0, 0, 2).To4(), Mask: net.CIDRMask(30, 32)}}
+				mockNetlink.EXPECT().LinkByName(mock.Anything, "eth0").Return(uplink, nil).Once()
+				mockNetlink.EXPECT().LinkAdd(mock.Anything, vlandev).Return(nil).Once()
+				mockNetlink.EXPECT().LinkByName(mock.Anything, testVrfID).Return(vrf, nil).Once()
+				mockNetlink.EXPECT().LinkSetMaster(mock.Anything, vlandev, vrf).Return(nil).Once()
+				mockNetlink.EXPECT().AddrAdd(mock.Anything, vlandev, addr).Return(nil).Once()
+				mockNetlink.EXPECT().LinkSetUp(mock.Anything, vlandev).Return(nil).Once()
+				mockFrr.EXPECT().FrrBgpCmd(mock.Anything, mock.Anything).Return("", errors.New(errMsg)).Once()
+			},
+		},
+		"successful call": {
+			id:      testVrfLiteHandoffID,
+			in:      &testVrfLiteHandoff,
+			out:     &testVrfLiteHandoffWithStatus,
+			errCode: codes.OK,
+			errMsg:  "",
+			exist:   false,
+			on: func(mockNetlink *mocks.Netlink, mockFrr *mocks.Frr, errMsg string) {
+				uplink := &netlink.Device{LinkAttrs: netlink.LinkAttrs{Name: "eth0", Index: 7}}
+				vlandev := &netlink.Vlan{LinkAttrs: netlink.LinkAttrs{Name: "eth0.100", ParentIndex: 7, Alias: managedAlias}, VlanId: 100}
+				vrf := &netlink.Vrf{LinkAttrs: netlink.LinkAttrs{Name: testVrfID, Alias: managedAlias}, Table: 1000}
+				addr := &netlink.Addr{IPNet: &net.IPNet{IP: net.IPv4(10, 0, 0, 2).To4(), Mask: net.CIDRMask(30, 32)}}
+				mockNetlink.EXPECT().LinkByName(mock.Anything, "eth0").Return(uplink, nil).Once()
+				mockNetlink.EXPECT().LinkAdd(mock.Anything, vlandev).Return(nil).Once()
+				mockNetlink.EXPECT().LinkByName(mock.Anything, testVrfID).Return(vrf, nil).Once()
+				mockNetlink.EXPECT().LinkSetMaster(mock.Anything, vlandev, vrf).Return(nil).Once()
+				mockNetlink.EXPECT().AddrAdd(mock.Anything, vlandev, addr).Return(nil).Once()
+				mockNetlink.EXPECT().LinkSetUp(mock.Anything, vlandev).Return(nil).Once()
+				mockFrr.EXPECT().FrrBgpCmd(mock.Anything, mock.Anything).Return("", nil).Once()
+			},
+		},
+	}
+
+	// run tests
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			mockNetlink := mocks.NewNetlink(t)
+			mockFrr := mocks.NewFrr(t)
+			store := gomap.NewStore(gomap.DefaultOptions)
+			opi := NewServerWithArgs(mockNetlink, mockFrr, store)
+
+			opi.Vrfs[testVrfName] = protoClone(&testVrfWithStatus)
+			if tt.exist {
+				opi.Handoffs[testVrfLiteHandoffName] = testVrfLiteHandoffWithStatus.clone()
+			}
+			if tt.on != nil {
+				tt.on(mockNetlink, mockFrr, tt.errMsg)
+			}
+
+			request := &CreateVrfLiteHandoffRequest{VrfLiteHandoff: tt.in.clone(), VrfLiteHandoffID: tt.id}
+			response, err := opi.CreateVrfLiteHandoff(ctx, request)
+			if !reflect.DeepEqual(tt.out, response) {
+				t.Error("response: expected", tt.out, "received", response)
+			}
+
+			if er, ok := status.FromError(err); ok {
+				if er.Code() != tt.errCode {
+					t.Error("error code: expected", tt.errCode, "received", er.Code())
+				}
+				if er.Message() != tt.errMsg {
+					t.Error("error message: expected", tt.errMsg, "received", er.Message())
+				}
+			} else {
+				t.Error("expected grpc error status")
+			}
+		})
+	}
+}
+
+func Test_DeleteVrfLiteHandoff(t *testing.T) {
+	tests := map[string]struct {
+		in      string
+		out     *emptypb.Empty
+		errCode codes.Code
+		errMsg  string
+		missing bool
+		on      func(mockNetlink *mocks.Netlink, mockFrr *mocks.Frr, errMsg string)
+	}{
+		"valid request with unknown key": {
+			in:      "unknown-id",
+			out:     nil,
+			errCode: codes.NotFound,
+			errMsg:  fmt.Sprintf("unable to find key %v", resourceIDToFullName("handoffs", "unknown-id")),
+			missing: false,
+			on:      nil,
+		},
+		"unknown key with missing allowed": {
+			in:      "unknown-id",
+			out:     &emptypb.Empty{},
+			errCode: codes.OK,
+			errMsg:  "",
+			missing: true,
+			on:      nil,
+		},
+		"failed LinkByName call": {
+			in:      testVrfLiteHandoffID,
+			out:     nil,
+			errCode: codes.NotFound,
+			errMsg:  fmt.Sprintf("unable to find key %v", "eth0.100"),
+			missing: false,
+			on: func(mockNetlink *mocks.Netlink, mockFrr *mocks.Frr, errMsg string) {
+				mockFrr.EXPECT().FrrBgpCmd(mock.Anything, mock.Anything).Return("", nil).Once()
+				mockNetlink.EXPECT().LinkByName(mock.Anything, "eth0.100").Return(nil, errors.New(errMsg)).Once()
+			},
+		},
+		"successful call": {
+			in:      testVrfLiteHandoffID,
+			out:     &emptypb.Empty{},
+			errCode: codes.OK,
+			errMsg:  "",
+			missing: false,
+			on: func(mockNetlink *mocks.Netlink, mockFrr *mocks.Frr, errMsg string) {
+				vlandev := &netlink.Vlan{LinkAttrs: netlink.LinkAttrs{Name: "eth0.100", ParentIndex: 7, Alias: managedAlias}, VlanId: 100}
+				mockFrr.EXPECT().FrrBgpCmd(mock.Anything, mock.Anything).Return("", nil).Once()
+				mockNetlink.EXPECT().LinkByName(mock.Anything, "eth0.100").Return(vlandev, nil).Once()
+				mockNetlink.EXPECT().LinkSetDown(mock.Anything, vlandev).Return(nil).Once()
+				mockNetlink.EXPECT().LinkDel(mock.Anything, vlandev).Return(nil).Once()
+			},
+		},
+	}
+
+	// run tests
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			mockNetlink := mocks.NewNetlink(t)
+			mockFrr := mocks.NewFrr(t)
+			store := gomap.NewStore(gomap.DefaultOptions)
+			opi := NewServerWithArgs(mockNetlink, mockFrr, store)
+
+			fname1 := resourceIDToFullName("handoffs", tt.in)
+			opi.Vrfs[testVrfName] = protoClone(&testVrfWithStatus)
+			opi.Handoffs[testVrfLiteHandoffName] = testVrfLiteHandoffWithStatus.clone()
+			if tt.on != nil {
+				tt.on(mockNetlink, mockFrr, tt.errMsg)
+			}
+
+			request := &DeleteVrfLiteHandoffRequest{Name: fname1, AllowMissing: tt.missing}
+			response, err := opi.DeleteVrfLiteHandoff(ctx, request)
+
+			if er, ok := status.FromError(err); ok {
+				if er.Code() != tt.errCode {
+					t.Error("error code: expected", tt.errCode, "received", er.Code())
+				}
+				if er.Message() != tt.errMsg {
+					t.Error("error message: expected", tt.errMsg, "received", er.Message())
+				}
+			} else {
+				t.Error("expected grpc error status")
+			}
+
+			if reflect.TypeOf(response) != reflect.TypeOf(tt.out) {
+				t.Error("response: expected", reflect.TypeOf(tt.out), "received", reflect.TypeOf(response))
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"go.einride.tech/aip/resourcename"
+)
+
+func (s *Server) validateCreateVrfLiteHandoffRequest(in *CreateVrfLiteHandoffRequest) error {
+	// check required fields
+	switch {
+	case in.VrfLiteHandoff == nil:
+		return missingField("vrf_lite_handoff")
+	case in.VrfLiteHandoff.Spec == nil:
+		return missingField("vrf_lite_handoff.spec")
+	case in.VrfLiteHandoff.Spec.Vrf == "":
+		return missingField("vrf_lite_handoff.spec.vrf")
+	case in.VrfLiteHandoff.Spec.Uplink == "":
+		return missingField("vrf_lite_handoff.spec.uplink")
+	case in.VrfLiteHandoff.Spec.VlanID == 0:
+		return missingField("vrf_lite_handoff.spec.vlan_id")
+	case in.VrfLiteHandoff.Spec.LocalIPPrefix == nil || in.VrfLiteHandoff.Spec.LocalIPPrefix.Addr == nil:
+		return missingField("vrf_lite_handoff.spec.local_ip_prefix")
+	case in.VrfLiteHandoff.Spec.PeerIPAddress == nil:
+		return missingField("vrf_lite_handoff.spec.peer_ip_address")
+	case in.VrfLiteHandoff.Spec.RemoteAs == 0:
+		return missingField("vrf_lite_handoff.spec.remote_as")
+	}
+	// check vlan id is in range
+	if err := badRequest("vrf_lite_handoff.spec.vlan_id", s.validateVlanID(in.VrfLiteHandoff.Spec.VlanID)); err != nil {
+		return err
+	}
+	// Validate that a Vrf resource name conforms to the restrictions outlined in AIP-122.
+	if err := badRequest("vrf_lite_handoff.spec.vrf", resourcename.Validate(in.VrfLiteHandoff.Spec.Vrf)); err != nil {
+		return err
+	}
+	// Validate that a RouteMap resource name conforms to the restrictions outlined in AIP-122.
+	if in.VrfLiteHandoff.Spec.ImportRouteMap != "" {
+		if err := badRequest("vrf_lite_handoff.spec.import_route_map", resourcename.Validate(in.VrfLiteHandoff.Spec.ImportRouteMap)); err != nil {
+			return err
+		}
+	}
+	if in.VrfLiteHandoff.Spec.ExportRouteMap != "" {
+		if err := badRequest("vrf_lite_handoff.spec.export_route_map", resourcename.Validate(in.VrfLiteHandoff.Spec.ExportRouteMap)); err != nil {
+			return err
+		}
+	}
+	// see https://google.aip.dev/133#user-specified-ids
+	if in.VrfLiteHandoffID != "" {
+		if err := badRequest("vrf_lite_handoff_id", validateResourceID(in.VrfLiteHandoffID, false)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Server) validateDeleteVrfLiteHandoffRequest(in *DeleteVrfLiteHandoffRequest) error {
+	// check required fields
+	if in.Name == "" {
+		return missingField("name")
+	}
+	// Validate that a resource name conforms to the restrictions outlined in AIP-122.
+	return badRequest("name", resourcename.Validate(in.Name))
+}
+
+func (s *Server) validateGetVrfLiteHandoffRequest(in *GetVrfLiteHandoffRequest) error {
+	// check required fields
+	if in.Name == "" {
+		return missingField("name")
+	}
+	// Validate that a resource name conforms to the restrictions outlined in AIP-122.
+	return badRequest("name", resourcename.Validate(in.Name))
+}
@@ -0,0 +1,213 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"sort"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+)
+
+// objectIndex maps a key of the objects of a collection, e.g. the vni of the Vrfs, to their
+// names, so the lookups and uniqueness checks do not walk the whole collection
+type objectIndex[T any, K comparable] struct {
+	keys   func(obj T) []K
+	byKey  map[K]map[string]bool
+	byName map[string][]K
+}
+
+func newObjectIndex[T any, K comparable](keys func(obj T) []K) *objectIndex[T, K] {
+	return &objectIndex[T, K]{keys: keys, byKey: map[K]map[string]bool{}, byName: map[string][]K{}}
+}
+
+// put indexes the object under its keys, replacing the ones of the object it updates
+func (x *objectIndex[T, K]) put(name string, obj T) {
+	x.remove(name)
+	keys := x.keys(obj)
+	for _, key := range keys {
+		names, ok := x.byKey[key]
+		if !ok {
+			names = map[string]bool{}
+			x.byKey[key] = names
+		}
+		names[name] = true
+	}
+	x.byName[name] = keys
+}
+
+// remove forgets the keys of a deleted object
+func (x *objectIndex[T, K]) remove(name string) {
+	for _, key := range x.byName[name] {
+		delete(x.byKey[key], name)
+		if len(x.byKey[key]) == 0 {
+			delete(x.byKey, key)
+		}
+	}
+	delete(x.byName, name)
+}
+
+// lookup returns the sorted names of the objects having the key. The collections are exported
+// and filled directly by some callers, e.g. the tests, so the index is rebuilt when it no
+// longer has as many objects as the collection, and the names are checked against it
+func (x *objectIndex[T, K]) lookup(objects map[string]T, key K) []string {
+	if len(x.byName) != len(objects) {
+		x.rebuild(objects)
+	}
+	var names []string
+	for name := range x.byKey[key] {
+		obj, ok := objects[name]
+		if !ok {
+			x.rebuild(objects)
+			return x.lookup(objects, key)
+		}
+		for _, k := range x.keys(obj) {
+			if k == key {
+				names = append(names, name)
+				break
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// count returns the number of distinct keys of the objects
+func (x *objectIndex[T, K]) count(objects map[string]T) int {
+	if len(x.byName) != len(objects) {
+		x.rebuild(objects)
+	}
+	return len(x.byKey)
+}
+
+func (x *objectIndex[T, K]) rebuild(objects map[string]T) {
+	x.byKey, x.byName = map[K]map[string]bool{}, map[string][]K{}
+	for name, obj := range objects {
+		x.put(name, obj)
+	}
+}
+
+// objectIndexes are the secondary indexes of the Vrfs, LogicalBridges, BridgePorts and Svis
+type objectIndexes struct {
+	vrfVni     *objectIndex[*pb.Vrf, uint32]
+	bridgeVni  *objectIndex[*pb.LogicalBridge, uint32]
+	bridgeVlan *objectIndex[*pb.LogicalBridge, uint32]
+	// portBridge and sviBridge index the BridgePorts and Svis by the LogicalBridges they reference
+	portBridge *objectIndex[*pb.BridgePort, string]
+	sviBridge  *objectIndex[*pb.Svi, string]
+	sviVrf     *objectIndex[*pb.Svi, string]
+}
+
+func newObjectIndexes() *objectIndexes {
+	return &objectIndexes{
+		vrfVni: newObjectIndex(func(obj *pb.Vrf) []uint32 {
+			if obj.GetSpec().Vni == nil {
+				return nil
+			}
+			return []uint32{*obj.Spec.Vni}
+		}),
+		bridgeVni: newObjectIndex(func(obj *pb.LogicalBridge) []uint32 {
+			if obj.GetSpec().Vni == nil {
+				return nil
+			}
+			return []uint32{*obj.Spec.Vni}
+		}),
+		bridgeVlan: newObjectIndex(func(obj *pb.LogicalBridge) []uint32 {
+			return []uint32{obj.GetSpec().GetVlanId()}
+		}),
+		portBridge: newObjectIndex(func(obj *pb.BridgePort) []string {
+			return obj.GetSpec().GetLogicalBridges()
+		}),
+		sviBridge: newObjectIndex(func(obj *pb.Svi) []string {
+			return []string{obj.GetSpec().GetLogicalBridge()}
+		}),
+		sviVrf: newObjectIndex(func(obj *pb.Svi) []string {
+			return []string{obj.GetSpec().GetVrf()}
+		}),
+	}
+}
+
+// putVrf saves the Vrf, created or updated, gives it a new etag, makes it ACTIVE and indexes it
+func (s *Server) putVrf(obj *pb.Vrf) {
+	s.Vrfs[obj.Name] = obj
+	s.newGeneration(obj.Name)
+	s.lifecycles.set(obj.Name, LifecycleActive, nil)
+	s.indexes.vrfVni.put(obj.Name, obj)
+}
+
+// removeVrf deletes the Vrf, its etag, its lifecycle and its index entries
+func (s *Server) removeVrf(name string) {
+	delete(s.Vrfs, name)
+	s.releaseGeneration(name)
+	s.lifecycles.set(name, LifecycleActive, nil)
+	s.indexes.vrfVni.remove(name)
+}
+
+// putLogicalBridge saves the LogicalBridge, created or updated, gives it a new etag, makes it ACTIVE and indexes it
+func (s *Server) putLogicalBridge(obj *pb.LogicalBridge) {
+	s.Bridges[obj.Name] = obj
+	s.newGeneration(obj.Name)
+	s.lifecycles.set(obj.Name, LifecycleActive, nil)
+	s.indexes.bridgeVni.put(obj.Name, obj)
+	s.indexes.bridgeVlan.put(obj.Name, obj)
+}
+
+// removeLogicalBridge deletes the LogicalBridge, its etag, its lifecycle and its index entries
+func (s *Server) removeLogicalBridge(name string) {
+	delete(s.Bridges, name)
+	s.releaseGeneration(name)
+	s.lifecycles.set(name, LifecycleActive, nil)
+	s.indexes.bridgeVni.remove(name)
+	s.indexes.bridgeVlan.remove(name)
+}
+
+// putBridgePort saves the BridgePort, created or updated, gives it a new etag, makes it ACTIVE and indexes it
+func (s *Server) putBridgePort(obj *pb.BridgePort) {
+	s.Ports[obj.Name] = obj
+	s.newGeneration(obj.Name)
+	s.lifecycles.set(obj.Name, LifecycleActive, nil)
+	s.indexes.portBridge.put(obj.Name, obj)
+}
+
+// removeBridgePort deletes the BridgePort, its etag, its lifecycle and its index entries
+func (s *Server) removeBridgePort(name string) {
+	delete(s.Ports, name)
+	s.releaseGeneration(name)
+	s.lifecycles.set(name, LifecycleActive, nil)
+	s.indexes.portBridge.remove(name)
+}
+
+// putSvi saves the Svi, created or updated, gives it a new etag, makes it ACTIVE and indexes it
+func (s *Server) putSvi(obj *pb.Svi) {
+	s.Svis[obj.Name] = obj
+	s.newGeneration(obj.Name)
+	s.lifecycles.set(obj.Name, LifecycleActive, nil)
+	s.indexes.sviBridge.put(obj.Name, obj)
+	s.indexes.sviVrf.put(obj.Name, obj)
+}
+
+// removeSvi deletes the Svi, its etag, its lifecycle and its index entries
+func (s *Server) removeSvi(name string) {
+	delete(s.Svis, name)
+	s.releaseGeneration(name)
+	s.lifecycles.set(name, LifecycleActive, nil)
+	s.indexes.sviBridge.remove(name)
+	s.indexes.sviVrf.remove(name)
+}
+
+// vniUsers returns the Vrfs and LogicalBridges using the vni, sorted by name
+func (s *Server) vniUsers(vni uint32) []string {
+	names := s.indexes.vrfVni.lookup(s.Vrfs, vni)
+	names = append(names, s.indexes.bridgeVni.lookup(s.Bridges, vni)...)
+	sort.Strings(names)
+	return names
+}
+
+// vlanBridge returns the LogicalBridge of the vlan, empty when none
+func (s *Server) vlanBridge(vlan uint32) string {
+	if names := s.indexes.bridgeVlan.lookup(s.Bridges, vlan); len(names) != 0 {
+		return names[0]
+	}
+	return ""
+}
@@ -0,0 +1,103 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/philippgille/gokv/gomap"
+	"google.golang.org/protobuf/proto"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils/mocks"
+)
+
+func Test_ObjectIndexes(t *testing.T) {
+	opi := NewServerWithArgs(mocks.NewNetlink(t), mocks.NewFrr(t), gomap.NewStore(gomap.DefaultOptions))
+	bridge := func(id string, vlan uint32, vni uint32) *pb.LogicalBridge {
+		return &pb.LogicalBridge{Name: resourceIDToFullName("bridges", id), Spec: &pb.LogicalBridgeSpec{VlanId: vlan, Vni: proto.Uint32(vni)}}
+	}
+	opi.putLogicalBridge(bridge("vlan10", 10, 1000))
+	opi.putLogicalBridge(bridge("vlan20", 20, 2000))
+	opi.putVrf(&pb.Vrf{Name: testVrfName, Spec: &pb.VrfSpec{Vni: proto.Uint32(3000)}})
+	opi.putBridgePort(&pb.BridgePort{Name: resourceIDToFullName("ports", "eth1"), Spec: &pb.BridgePortSpec{
+		LogicalBridges: []string{resourceIDToFullName("bridges", "vlan10"), resourceIDToFullName("bridges", "vlan20")},
+	}})
+	opi.putSvi(&pb.Svi{Name: resourceIDToFullName("svis", "svi10"), Spec: &pb.SviSpec{
+		Vrf: testVrfName, LogicalBridge: resourceIDToFullName("bridges", "vlan10"),
+	}})
+
+	tests := []struct {
+		name     string
+		lookup   func() interface{}
+		expected interface{}
+	}{
+		{name: "vni of a LogicalBridge", lookup: func() interface{} { return opi.vniUsers(2000) }, expected: []string{resourceIDToFullName("bridges", "vlan20")}},
+		{name: "vni of a Vrf", lookup: func() interface{} { return opi.vniUsers(3000) }, expected: []string{testVrfName}},
+		{name: "unused vni", lookup: func() interface{} { return opi.vniUsers(4000) }, expected: []string(nil)},
+		{name: "vlan", lookup: func() interface{} { return opi.vlanBridge(10) }, expected: resourceIDToFullName("bridges", "vlan10")},
+		{name: "unused vlan", lookup: func() interface{} { return opi.vlanBridge(30) }, expected: ""},
+		{name: "dependents", lookup: func() interface{} { return opi.logicalBridgeDependents(resourceIDToFullName("bridges", "vlan10")) }, expected: []string{
+			resourceIDToFullName("svis", "svi10"), resourceIDToFullName("ports", "eth1"),
+		}},
+		{name: "Svis of the Vrf", lookup: func() interface{} { return opi.vrfDependents(testVrfName) }, expected: []string{resourceIDToFullName("svis", "svi10")}},
+	}
+	for _, tt := range tests {
+		if received := tt.lookup(); !reflect.DeepEqual(received, tt.expected) {
+			t.Error(tt.name, ": expected", tt.expected, "received", received)
+		}
+	}
+
+	// an update moves the LogicalBridge to its new keys, a delete forgets them
+	opi.putLogicalBridge(bridge("vlan20", 30, 2000))
+	if received := opi.vlanBridge(20); received != "" {
+		t.Error("updated vlan: expected none, received", received)
+	}
+	opi.removeLogicalBridge(resourceIDToFullName("bridges", "vlan10"))
+	if received := opi.vniUsers(1000); len(received) != 0 {
+		t.Error("deleted vni: expected none, received", received)
+	}
+
+	// the collections filled directly are indexed on the next lookup
+	opi.Bridges[resourceIDToFullName("bridges", "vlan40")] = bridge("vlan40", 40, 4000)
+	if received := opi.vlanBridge(40); received != resourceIDToFullName("bridges", "vlan40") {
+		t.Error("direct write: expected vlan40, received", received)
+	}
+	delete(opi.Bridges, resourceIDToFullName("bridges", "vlan40"))
+	opi.Bridges[resourceIDToFullName("bridges", "vlan50")] = bridge("vlan50", 50, 5000)
+	if received := opi.vniUsers(4000); len(received) != 0 {
+		t.Error("direct delete: expected none, received", received)
+	}
+}
+
+func Test_ListSharesStoredObjects(t *testing.T) {
+	opi := NewServerWithArgs(mocks.NewNetlink(t), mocks.NewFrr(t), gomap.NewStore(gomap.DefaultOptions))
+	opi.LiveRead = true
+	for i := 0; i < 3; i++ {
+		name := resourceIDToFullName("svis", fmt.Sprintf("svi%d", i))
+		opi.putSvi(&pb.Svi{Name: name, Spec: &pb.SviSpec{Vrf: testVrfName}})
+	}
+	response, err := opi.ListSvis(context.Background(), &pb.ListSvisRequest{PageSize: 2})
+	if err != nil {
+		t.Fatal("error: expected", nil, "received", err)
+	}
+	if len(response.Svis) != 2 || response.Svis[0].Status == nil {
+		t.Fatal("page: expected 2 Svis with a status, received", response.Svis)
+	}
+	// the page is a copy, the snapshot of the next pages holds the stored objects
+	for _, svi := range opi.Svis {
+		if svi.Status != nil {
+			t.Error("stored status: expected", nil, "received", svi.Status)
+		}
+	}
+	next, err := opi.ListSvis(context.Background(), &pb.ListSvisRequest{PageSize: 2, PageToken: response.NextPageToken})
+	if err != nil || len(next.Svis) != 1 || next.Svis[0] == opi.Svis[next.Svis[0].Name] {
+		t.Error("next page: expected a copy of the last Svi, received", next.GetSvis(), err)
+	}
+}
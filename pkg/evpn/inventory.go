@@ -0,0 +1,162 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"net"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/vishvananda/netlink"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// HostInterfaceKind tells what kind of device a host interface is
+type HostInterfaceKind string
+
+const (
+	// HostInterfacePhysical is a port of a NIC without SR-IOV
+	HostInterfacePhysical HostInterfaceKind = "physical"
+	// HostInterfacePf is the physical function of a NIC with SR-IOV virtual functions
+	HostInterfacePf HostInterfaceKind = "pf"
+	// HostInterfaceVf is a SR-IOV virtual function
+	HostInterfaceVf HostInterfaceKind = "vf"
+	// HostInterfaceRepresentor is the representor of a function of the eswitch, e.g.: of a
+	// virtual function given to a host
+	HostInterfaceRepresentor HostInterfaceKind = "representor"
+)
+
+// representorPortName matches the phys_port_name of the eswitch representors, e.g.: pf0vf1,
+// pf0sf2 or c1pf0vf1 on a multi-host controller
+var representorPortName = regexp.MustCompile(`^(c\d+)?pf\d+((vf|sf)\d+)?$`)
+
+// HostInterface is an interface of the DPU eligible to become a BridgePort
+type HostInterface struct {
+	// Name is the kernel name of the interface, the bridge_port_id of its BridgePort
+	Name string `json:"name"`
+	// MacAddress is the MAC address of the interface
+	MacAddress string `json:"macAddress,omitempty"`
+	// Mtu is the MTU of the interface
+	Mtu int `json:"mtu"`
+	// OperStatus is the oper status of the link, e.g.: up or down
+	OperStatus string `json:"operStatus"`
+	// SpeedMbps is the link speed, 0 when unknown or the link is down
+	SpeedMbps uint32 `json:"speedMbps,omitempty"`
+	// PciAddress is the PCI address of the device of the interface, e.g.: 0000:03:00.0
+	PciAddress string `json:"pciAddress,omitempty"`
+	// Kind tells what kind of device the interface is
+	Kind HostInterfaceKind `json:"kind"`
+	// NumVfs is the number of virtual functions of a physical function
+	NumVfs uint32 `json:"numVfs,omitempty"`
+	// PhysicalFunction is the PCI address of the physical function of a virtual function
+	PhysicalFunction string `json:"physicalFunction,omitempty"`
+	// PortName is the eswitch port of a representor, e.g.: pf0vf1 for the virtual function 1 of
+	// the physical function 0
+	PortName string `json:"portName,omitempty"`
+	// BridgePort is the name of the BridgePort already created on the interface
+	BridgePort string `json:"bridgePort,omitempty"`
+}
+
+// ListHostInterfacesRequest is the request to list the interfaces eligible to become BridgePorts
+// TODO: move to opi-api once the message is agreed upon
+type ListHostInterfacesRequest struct{}
+
+// ListHostInterfacesResponse lists the host interfaces, sorted by name
+// TODO: move to opi-api once the message is agreed upon
+type ListHostInterfacesResponse struct {
+	Interfaces []HostInterface `json:"interfaces"`
+}
+
+// ListHostInterfaces returns the network devices of the DPU, ports, physical and virtual
+// functions and their representors, so BridgePorts can be created without out-of-band
+// knowledge of the port naming. The virtual interfaces (e.g.: bridges, vxlan and vlan devices)
+// are skipped, and a tenant does not see the interfaces of the BridgePorts of other tenants
+func (s *Server) ListHostInterfaces(ctx context.Context, _ *ListHostInterfacesRequest) (*ListHostInterfacesResponse, error) {
+	ifaces, err := s.hostInterfaces(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ports := map[string]string{}
+	for name := range s.Ports {
+		ports[s.portKernelName(name)] = name
+	}
+	response := &ListHostInterfacesResponse{Interfaces: []HostInterface{}}
+	for _, iface := range ifaces {
+		port, ok := ports[iface.Name]
+		if ok && !inTenant(ctx, port) {
+			continue
+		}
+		iface.BridgePort = port
+		response.Interfaces = append(response.Interfaces, iface)
+	}
+	return response, nil
+}
+
+// hostInterfaces returns the network devices of the DPU, sorted by name
+func (s *Server) hostInterfaces(ctx context.Context) ([]HostInterface, error) {
+	links, err := s.nLink.LinkList(ctx)
+	if err != nil {
+		err = status.Errorf(codes.Unavailable, "unable to list the interfaces: %v", err)
+		return nil, err
+	}
+	ifaces := []HostInterface{}
+	for _, link := range links {
+		attrs := link.Attrs()
+		if link.Type() != "device" || attrs.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		ifaces = append(ifaces, s.hostInterface(ctx, link))
+	}
+	sort.Slice(ifaces, func(i, j int) bool {
+		return ifaces[i].Name < ifaces[j].Name
+	})
+	return ifaces, nil
+}
+
+// hostInterface describes a link from its netlink attributes and its sysfs device, a missing
+// sysfs attribute only leaves the matching field empty
+func (s *Server) hostInterface(ctx context.Context, link netlink.Link) HostInterface {
+	attrs := link.Attrs()
+	iface := HostInterface{
+		Name:       attrs.Name,
+		Mtu:        attrs.MTU,
+		OperStatus: attrs.OperState.String(),
+		Kind:       HostInterfacePhysical,
+	}
+	if len(attrs.HardwareAddr) > 0 {
+		iface.MacAddress = attrs.HardwareAddr.String()
+	}
+	// the speed is -1 while the link is down
+	if value, err := s.sysfs.LinkAttr(ctx, attrs.Name, "speed"); err == nil {
+		if speed, err := strconv.ParseUint(value, 10, 32); err == nil {
+			iface.SpeedMbps = uint32(speed)
+		}
+	}
+	if value, err := s.sysfs.LinkAttr(ctx, attrs.Name, "device"); err == nil {
+		iface.PciAddress = value
+	}
+	// a representor shares the PCI device of its physical function
+	if value, err := s.sysfs.LinkAttr(ctx, attrs.Name, "phys_port_name"); err == nil && representorPortName.MatchString(value) {
+		iface.Kind = HostInterfaceRepresentor
+		iface.PortName = value
+		return iface
+	}
+	if value, err := s.sysfs.LinkAttr(ctx, attrs.Name, "device/physfn"); err == nil {
+		iface.Kind = HostInterfaceVf
+		iface.PhysicalFunction = value
+		return iface
+	}
+	if value, err := s.sysfs.LinkAttr(ctx, attrs.Name, "device/sriov_numvfs"); err == nil {
+		if vfs, err := strconv.ParseUint(value, 10, 32); err == nil && vfs > 0 {
+			iface.Kind = HostInterfacePf
+			iface.NumVfs = uint32(vfs)
+		}
+	}
+	return iface
+}
@@ -0,0 +1,90 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"errors"
+	"net"
+	"reflect"
+	"testing"
+
+	"github.com/philippgille/gokv/gomap"
+	"github.com/stretchr/testify/mock"
+	"github.com/vishvananda/netlink"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils/mocks"
+)
+
+func Test_ListHostInterfaces(t *testing.T) {
+	mac := net.HardwareAddr{0xaa, 0xbb, 0xcc, 0x00, 0x00, 0x01}
+	links := []netlink.Link{
+		&netlink.Device{LinkAttrs: netlink.LinkAttrs{Name: "lo", Flags: net.FlagLoopback}},
+		&netlink.Device{LinkAttrs: netlink.LinkAttrs{Name: "p0", MTU: 9000, HardwareAddr: mac, OperState: netlink.OperUp}},
+		&netlink.Device{LinkAttrs: netlink.LinkAttrs{Name: "pf0vf1", MTU: 1500, OperState: netlink.OperDown}},
+		&netlink.Device{LinkAttrs: netlink.LinkAttrs{Name: "eth1", MTU: 1500, OperState: netlink.OperUp}},
+		&netlink.Device{LinkAttrs: netlink.LinkAttrs{Name: testBridgePortID, MTU: 1500, OperState: netlink.OperUp}},
+		&netlink.Bridge{LinkAttrs: netlink.LinkAttrs{Name: tenantbridgeName}},
+	}
+	sysfs := map[string]string{
+		"p0/speed":               "100000",
+		"p0/device":              "0000:03:00.0",
+		"p0/phys_port_name":      "p0",
+		"p0/device/sriov_numvfs": "4",
+		"pf0vf1/speed":           "-1",
+		"pf0vf1/device":          "0000:03:00.0",
+		"pf0vf1/phys_port_name":  "pf0vf1",
+		"eth1/device":            "0000:03:00.3",
+		"eth1/device/physfn":     "0000:03:00.0",
+	}
+	want := []HostInterface{
+		{Name: "eth1", Mtu: 1500, OperStatus: "up", PciAddress: "0000:03:00.3", Kind: HostInterfaceVf, PhysicalFunction: "0000:03:00.0"},
+		{Name: testBridgePortID, Mtu: 1500, OperStatus: "up", Kind: HostInterfacePhysical, BridgePort: testBridgePortName},
+		{Name: "p0", MacAddress: "aa:bb:cc:00:00:01", Mtu: 9000, OperStatus: "up", SpeedMbps: 100000, PciAddress: "0000:03:00.0", Kind: HostInterfacePf, NumVfs: 4},
+		{Name: "pf0vf1", Mtu: 1500, OperStatus: "down", PciAddress: "0000:03:00.0", Kind: HostInterfaceRepresentor, PortName: "pf0vf1"},
+	}
+
+	mockNetlink := mocks.NewNetlink(t)
+	mockSysfs := mocks.NewSysfs(t)
+	opi := NewServerWithArgs(mockNetlink, mocks.NewFrr(t), gomap.NewStore(gomap.DefaultOptions))
+	opi.sysfs = mockSysfs
+	opi.Ports[testBridgePortName] = &pb.BridgePort{Name: testBridgePortName, Spec: testBridgePort.Spec}
+	mockNetlink.EXPECT().LinkList(mock.Anything).Return(links, nil).Once()
+	mockSysfs.EXPECT().LinkAttr(mock.Anything, mock.Anything, mock.Anything).RunAndReturn(func(_ context.Context, name string, attr string) (string, error) {
+		if value, ok := sysfs[name+"/"+attr]; ok {
+			return value, nil
+		}
+		return "", errors.New("no such file or directory")
+	})
+
+	response, err := opi.ListHostInterfaces(context.Background(), &ListHostInterfacesRequest{})
+	if err != nil {
+		t.Fatal("error: expected", nil, "received", err)
+	}
+	if !reflect.DeepEqual(response.Interfaces, want) {
+		t.Error("interfaces: expected", want, "received", response.Interfaces)
+	}
+
+	// a tenant does not see the interfaces of the BridgePorts of others
+	mockNetlink.EXPECT().LinkList(mock.Anything).Return(links, nil).Once()
+	response, err = opi.ListHostInterfaces(tenantContext("acme"), &ListHostInterfacesRequest{})
+	if err != nil {
+		t.Fatal("error: expected", nil, "received", err)
+	}
+	if len(response.Interfaces) != len(want)-1 {
+		t.Error("interfaces: expected", len(want)-1, "received", response.Interfaces)
+	}
+
+	mockNetlink.EXPECT().LinkList(mock.Anything).Return(nil, errors.New("Failed to call LinkList")).Once()
+	_, err = opi.ListHostInterfaces(context.Background(), &ListHostInterfacesRequest{})
+	if status.Code(err) != codes.Unavailable {
+		t.Error("error: expected", codes.Unavailable, "received", err)
+	}
+}
@@ -0,0 +1,148 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils"
+)
+
+// asymmetricIrbKey is the store key of the Vrfs using asymmetric IRB
+const asymmetricIrbKey = "asymmetricirb"
+
+// IrbMode is how a Vrf routes between its Svis across VTEPs
+type IrbMode string
+
+const (
+	// IrbSymmetric routes over the L3 vni of the Vrf: the ingress VTEP routes into the L3 vni,
+	// the egress one routes out of it, so a VTEP only needs the Svis of its local hosts
+	IrbSymmetric IrbMode = "symmetric"
+	// IrbAsymmetric routes over the vni of the destination: the ingress VTEP routes and bridges
+	// into the vni of the destination Svi, so every VTEP needs all the Svis of the Vrf
+	IrbAsymmetric IrbMode = "asymmetric"
+)
+
+// asymmetricIrbFor reports whether a new Vrf uses asymmetric IRB, sent with the call, or kept
+// from a previous incarnation of the same Vrf (e.g.: on replay)
+func (s *Server) asymmetricIrbFor(ctx context.Context, obj *pb.Vrf) (bool, error) {
+	value, ok := utils.MetadataValue(ctx, utils.IrbModeMetadataKey)
+	if !ok || value == "" {
+		return s.AsymmetricIrb[obj.Name], nil
+	}
+	switch IrbMode(value) {
+	case IrbSymmetric:
+		if obj.Spec.Vni == nil {
+			msg := "symmetric IRB requires the L3 vni of the Vrf"
+			return false, badRequest(utils.IrbModeMetadataKey, status.Error(codes.InvalidArgument, msg))
+		}
+		return false, nil
+	case IrbAsymmetric:
+		// the routed packets leave with the vni of their destination, an L3 vni would be unused
+		if obj.Spec.Vni != nil {
+			msg := "asymmetric IRB routes over the vnis of the Svis, the Vrf cannot have a vni"
+			return false, badRequest(utils.IrbModeMetadataKey, status.Error(codes.InvalidArgument, msg))
+		}
+		return true, nil
+	}
+	msg := fmt.Sprintf("invalid IRB mode %q, expected %s or %s", value, IrbSymmetric, IrbAsymmetric)
+	return false, badRequest(utils.IrbModeMetadataKey, status.Error(codes.InvalidArgument, msg))
+}
+
+// VrfIrbMode returns the IRB mode of a Vrf, empty for a Vrf routing its Svis locally only, for the dataplanes
+func (s *Server) VrfIrbMode(obj *pb.Vrf) IrbMode {
+	switch {
+	case s.AsymmetricIrb[obj.Name]:
+		return IrbAsymmetric
+	case obj.Spec.Vni != nil:
+		return IrbSymmetric
+	}
+	return ""
+}
+
+// checkIrbBridge checks a new Svi of an asymmetric IRB Vrf is on a LogicalBridge with a vni,
+// the remote VTEPs reach its hosts over it
+func (s *Server) checkIrbBridge(vrf *pb.Vrf, bridge *pb.LogicalBridge) error {
+	if !s.AsymmetricIrb[vrf.Name] || bridge.Spec.Vni != nil {
+		return nil
+	}
+	msg := fmt.Sprintf("asymmetric IRB Vrf %s requires a vni on %s", vrf.Name, bridge.Name)
+	return status.Error(codes.FailedPrecondition, msg)
+}
+
+// frrAdvertiseSviIP advertises the MAC and IP of the Svi of an asymmetric IRB Vrf on the vni,
+// the hosts of the remote VTEPs reach the gateway of this one over it
+func (s *Server) frrAdvertiseSviIP(ctx context.Context, bridge *pb.LogicalBridge) error {
+	data, err := s.frr.FrrBgpCmd(ctx, fmt.Sprintf(
+		`configure terminal
+		router bgp %d
+		address-family l2vpn evpn
+		vni %d
+		advertise-svi-ip
+		exit-vni
+		exit-address-family
+		exit`, s.Gateway.LocalAs, *bridge.Spec.Vni))
+	fmt.Printf("FrrBgpCmd: %v:%v", data, err)
+	return err
+}
+
+// frrWithdrawSviIP stops advertising the Svi of the vni
+func (s *Server) frrWithdrawSviIP(ctx context.Context, bridge *pb.LogicalBridge) error {
+	data, err := s.frr.FrrBgpCmd(ctx, fmt.Sprintf(
+		`configure terminal
+		router bgp %d
+		address-family l2vpn evpn
+		vni %d
+		no advertise-svi-ip
+		exit-vni
+		exit-address-family
+		exit`, s.Gateway.LocalAs, *bridge.Spec.Vni))
+	fmt.Printf("FrrBgpCmd: %v:%v", data, err)
+	return err
+}
+
+func (s *Server) persistAsymmetricIrb() {
+	fields := make(map[string]interface{}, len(s.AsymmetricIrb))
+	for name := range s.AsymmetricIrb {
+		fields[name] = true
+	}
+	msg, err := structpb.NewStruct(fields)
+	if err == nil {
+		err = s.store.Set(asymmetricIrbKey, msg)
+	}
+	if err != nil {
+		fmt.Printf("Failed to persist %s: %v", asymmetricIrbKey, err)
+	}
+}
+
+// loadAsymmetricIrb restores the IRB modes, so replayed Vrfs keep routing the same way
+func (s *Server) loadAsymmetricIrb() error {
+	msg := &structpb.Struct{}
+	found, err := s.store.Get(asymmetricIrbKey, msg)
+	if err != nil || !found {
+		return err
+	}
+	for name, value := range msg.Fields {
+		if value.GetBoolValue() {
+			s.AsymmetricIrb[name] = true
+		}
+	}
+	return nil
+}
+
+// releaseAsymmetricIrb forgets a deleted Vrf
+func (s *Server) releaseAsymmetricIrb(name string) {
+	if s.AsymmetricIrb[name] {
+		delete(s.AsymmetricIrb, name)
+		s.persistAsymmetricIrb()
+	}
+}
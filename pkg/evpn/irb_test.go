@@ -0,0 +1,114 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/philippgille/gokv/gomap"
+	"github.com/stretchr/testify/mock"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils"
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils/mocks"
+)
+
+func Test_asymmetricIrbFor(t *testing.T) {
+	tests := map[string]struct {
+		md      metadata.MD
+		vni     *uint32
+		out     bool
+		errCode codes.Code
+	}{
+		"default with a vni": {
+			md:  metadata.MD{},
+			vni: proto.Uint32(1000),
+			out: false,
+		},
+		"symmetric": {
+			md:  metadata.Pairs(utils.IrbModeMetadataKey, "symmetric"),
+			vni: proto.Uint32(1000),
+			out: false,
+		},
+		"symmetric without vni": {
+			md:      metadata.Pairs(utils.IrbModeMetadataKey, "symmetric"),
+			errCode: codes.InvalidArgument,
+		},
+		"asymmetric": {
+			md:  metadata.Pairs(utils.IrbModeMetadataKey, "asymmetric"),
+			out: true,
+		},
+		"asymmetric with a vni": {
+			md:      metadata.Pairs(utils.IrbModeMetadataKey, "asymmetric"),
+			vni:     proto.Uint32(1000),
+			errCode: codes.InvalidArgument,
+		},
+		"invalid mode": {
+			md:      metadata.Pairs(utils.IrbModeMetadataKey, "centralized"),
+			errCode: codes.InvalidArgument,
+		},
+	}
+	for testName, tt := range tests {
+		t.Run(testName, func(t *testing.T) {
+			opi := NewServerWithArgs(mocks.NewNetlink(t), mocks.NewFrr(t), gomap.NewStore(gomap.DefaultOptions))
+			ctx := metadata.NewIncomingContext(context.Background(), tt.md)
+			obj := &pb.Vrf{Name: testVrfName, Spec: &pb.VrfSpec{Vni: tt.vni}}
+			asymmetric, err := opi.asymmetricIrbFor(ctx, obj)
+			if status.Code(err) != tt.errCode {
+				t.Error("error: expected", tt.errCode, "received", err)
+			}
+			if asymmetric != tt.out {
+				t.Error("asymmetric: expected", tt.out, "received", asymmetric)
+			}
+		})
+	}
+}
+
+func Test_AsymmetricIrbSvi(t *testing.T) {
+	vrf := &pb.Vrf{Name: testVrfName, Spec: &pb.VrfSpec{}}
+	bridge := protoClone(&testLogicalBridgeWithStatus)
+	mockFrr := mocks.NewFrr(t)
+	opi := NewServerWithArgs(mocks.NewNetlink(t), mockFrr, gomap.NewStore(gomap.DefaultOptions))
+	opi.AsymmetricIrb[testVrfName] = true
+	if mode := opi.VrfIrbMode(vrf); mode != IrbAsymmetric {
+		t.Error("mode: expected", IrbAsymmetric, "received", mode)
+	}
+
+	// the remote VTEPs reach the hosts of the Svi over the vni of its LogicalBridge
+	local := &pb.LogicalBridge{Name: bridge.Name, Spec: &pb.LogicalBridgeSpec{VlanId: 22}}
+	if err := opi.checkIrbBridge(vrf, local); status.Code(err) != codes.FailedPrecondition {
+		t.Error("error: expected", codes.FailedPrecondition, "received", err)
+	}
+	if err := opi.checkIrbBridge(vrf, bridge); err != nil {
+		t.Error("error: expected", nil, "received", err)
+	}
+
+	mockFrr.EXPECT().FrrBgpCmd(mock.Anything, mock.MatchedBy(func(command string) bool {
+		return strings.Contains(command, "vni 11\n\t\tadvertise-svi-ip\n")
+	})).Return("", nil).Once()
+	d := &linuxDataplane{s: opi}
+	if err := d.frrCreateSviGateway(context.Background(), &testSviWithStatus, bridge, vrf); err != nil {
+		t.Error("error: expected", nil, "received", err)
+	}
+
+	// the mode is kept for the replay
+	opi.persistAsymmetricIrb()
+	restored := NewServerWithArgs(mocks.NewNetlink(t), mocks.NewFrr(t), opi.store)
+	if err := restored.loadAsymmetricIrb(); err != nil {
+		t.Fatal("error: expected", nil, "received", err)
+	}
+	if !reflect.DeepEqual(restored.AsymmetricIrb, opi.AsymmetricIrb) {
+		t.Error("asymmetric IRB: expected", opi.AsymmetricIrb, "received", restored.AsymmetricIrb)
+	}
+}
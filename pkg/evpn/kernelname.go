@@ -0,0 +1,141 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"path"
+
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// kernelNamesKey is the store key of the kernel name mapping table
+const kernelNamesKey = "kernelnames"
+
+// hashedKernelName derives a name of exactly maxKernelNameLength characters,
+// keeping the start of the wanted name readable (e.g.: 71ab9c-8d1e5f02)
+func hashedKernelName(name string, wanted string, salt int) string {
+	h := fnv.New32a()
+	_, _ = fmt.Fprintf(h, "%s#%d", name, salt)
+	prefix := wanted
+	if len(prefix) > maxKernelNameLength-9 {
+		prefix = prefix[:maxKernelNameLength-9]
+	}
+	return fmt.Sprintf("%s-%08x", prefix, h.Sum32())
+}
+
+// kernelNameTaken reports whether another object already maps to the kernel name
+func (s *Server) kernelNameTaken(name string, kernel string) bool {
+	for owner, used := range s.KernelNames {
+		if owner != name && used == kernel {
+			return true
+		}
+	}
+	return false
+}
+
+// kernelNameFor returns the kernel interface name to use for a new object,
+// the wanted one when it fits in IFNAMSIZ and is free, a deterministic hashed
+// one otherwise, and always for the objects of a tenant since tenants pick
+// their IDs independently. Nothing is recorded until setKernelName is called
+func (s *Server) kernelNameFor(name string, wanted string) string {
+	if kernel, ok := s.KernelNames[name]; ok {
+		return kernel
+	}
+	if len(wanted) <= maxKernelNameLength && tenantOf(name) == "" && !s.kernelNameTaken(name, wanted) {
+		return wanted
+	}
+	kernel := hashedKernelName(name, wanted, 0)
+	for salt := 1; s.kernelNameTaken(name, kernel); salt++ {
+		kernel = hashedKernelName(name, wanted, salt)
+	}
+	return kernel
+}
+
+// setKernelName records the kernel name of a programmed object, only the
+// names that differ from the wanted one are kept in the mapping table
+func (s *Server) setKernelName(name string, wanted string, kernel string) {
+	if kernel == wanted {
+		return
+	}
+	s.KernelNames[name] = kernel
+	s.persistKernelNames()
+}
+
+// lookupKernelName returns the kernel interface name of an existing object
+func (s *Server) lookupKernelName(name string, wanted string) string {
+	if kernel, ok := s.KernelNames[name]; ok {
+		return kernel
+	}
+	return wanted
+}
+
+// releaseKernelName frees the kernel interface name of a deleted object
+func (s *Server) releaseKernelName(name string) {
+	if _, ok := s.KernelNames[name]; ok {
+		delete(s.KernelNames, name)
+		s.persistKernelNames()
+	}
+}
+
+// vrfKernelName returns the name of the kernel vrf device, also used as vrf name in FRR
+func (s *Server) vrfKernelName(vrfName string) string {
+	return s.lookupKernelName(vrfName, path.Base(vrfName))
+}
+
+// VrfKernelName returns the name of the kernel vrf device of the Vrf, for the dataplanes
+// managing their own devices in it
+func (s *Server) VrfKernelName(vrfName string) string {
+	return s.vrfKernelName(vrfName)
+}
+
+// portKernelName returns the name of the kernel interface of the BridgePort, its resource ID
+// unless it was created on the interface of a PCI address or a VF index
+func (s *Server) portKernelName(portName string) string {
+	return s.lookupKernelName(portName, path.Base(portName))
+}
+
+// PortKernelName returns the name of the kernel interface of the BridgePort, for the dataplanes
+func (s *Server) PortKernelName(portName string) string {
+	return s.portKernelName(portName)
+}
+
+// GetKernelNames returns the kernel interface names of the objects whose names had to be shortened
+func (s *Server) GetKernelNames(_ context.Context) map[string]string {
+	names := make(map[string]string, len(s.KernelNames))
+	for name, kernel := range s.KernelNames {
+		names[name] = kernel
+	}
+	return names
+}
+
+func (s *Server) persistKernelNames() {
+	fields := make(map[string]interface{}, len(s.KernelNames))
+	for name, kernel := range s.KernelNames {
+		fields[name] = kernel
+	}
+	msg, err := structpb.NewStruct(fields)
+	if err == nil {
+		err = s.store.Set(kernelNamesKey, msg)
+	}
+	if err != nil {
+		fmt.Printf("Failed to persist %s: %v", kernelNamesKey, err)
+	}
+}
+
+// loadKernelNames restores the mapping table, so replayed objects keep their kernel names
+func (s *Server) loadKernelNames() error {
+	msg := &structpb.Struct{}
+	found, err := s.store.Get(kernelNamesKey, msg)
+	if err != nil || !found {
+		return err
+	}
+	for name, value := range msg.Fields {
+		s.KernelNames[name] = value.GetStringValue()
+	}
+	return nil
+}
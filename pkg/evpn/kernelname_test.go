@@ -0,0 +1,93 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/philippgille/gokv/gomap"
+	"github.com/stretchr/testify/mock"
+	"github.com/vishvananda/netlink"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+	pc "github.com/opiproject/opi-api/network/opinetcommon/v1alpha1/gen/go"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils/mocks"
+)
+
+func Test_kernelNameFor(t *testing.T) {
+	longName := resourceIDToFullName("vrfs", "5ad1b2a0-3c4d-4e5f-8a9b-0c1d2e3f4a5b")
+	tests := map[string]struct {
+		name   string
+		wanted string
+		taken  map[string]string
+		want   string
+	}{
+		"short name is kept": {
+			name:   testVrfName,
+			wanted: testVrfID,
+			taken:  map[string]string{},
+			want:   testVrfID,
+		},
+		"long name is hashed": {
+			name:   longName,
+			wanted: "5ad1b2a0-3c4d-4e5f-8a9b-0c1d2e3f4a5b",
+			taken:  map[string]string{},
+			want:   hashedKernelName(longName, "5ad1b2a0-3c4d-4e5f-8a9b-0c1d2e3f4a5b", 0),
+		},
+		"short name taken by a hashed one": {
+			name:   testVrfName,
+			wanted: "5ad1b2-0badcafe",
+			taken:  map[string]string{longName: "5ad1b2-0badcafe"},
+			want:   hashedKernelName(testVrfName, "5ad1b2-0badcafe", 0),
+		},
+		"hash collision is salted": {
+			name:   longName,
+			wanted: "5ad1b2a0-3c4d-4e5f-8a9b-0c1d2e3f4a5b",
+			taken:  map[string]string{"other": hashedKernelName(longName, "5ad1b2a0-3c4d-4e5f-8a9b-0c1d2e3f4a5b", 0)},
+			want:   hashedKernelName(longName, "5ad1b2a0-3c4d-4e5f-8a9b-0c1d2e3f4a5b", 1),
+		},
+	}
+
+	for testName, tt := range tests {
+		t.Run(testName, func(t *testing.T) {
+			opi := NewServerWithArgs(mocks.NewNetlink(t), mocks.NewFrr(t), gomap.NewStore(gomap.DefaultOptions))
+			opi.KernelNames = tt.taken
+			got := opi.kernelNameFor(tt.name, tt.wanted)
+			if got != tt.want {
+				t.Errorf("kernelNameFor() = %v, want %v", got, tt.want)
+			}
+			if len(got) > maxKernelNameLength {
+				t.Errorf("kernelNameFor() = %v, longer than %v", got, maxKernelNameLength)
+			}
+		})
+	}
+}
+
+func Test_CreateVrfSystemGeneratedID(t *testing.T) {
+	ctx := context.Background()
+	mockNetlink := mocks.NewNetlink(t)
+	mockFrr := mocks.NewFrr(t)
+	opi := NewServerWithArgs(mockNetlink, mockFrr, gomap.NewStore(gomap.DefaultOptions))
+
+	var kernelName string
+	mockNetlink.EXPECT().LinkAdd(mock.Anything, mock.Anything).RunAndReturn(func(_ context.Context, link netlink.Link) error {
+		kernelName = link.Attrs().Name
+		return errors.New("Failed to call LinkAdd")
+	}).Once()
+
+	request := &pb.CreateVrfRequest{Vrf: &pb.Vrf{Spec: &pb.VrfSpec{LoopbackIpPrefix: &pc.IPPrefix{Len: 24}}}}
+	if _, err := opi.CreateVrf(ctx, request); err == nil {
+		t.Error("expected LinkAdd error")
+	}
+	if kernelName == "" || len(kernelName) > maxKernelNameLength {
+		t.Errorf("LinkAdd() name = %v, want at most %v characters", kernelName, maxKernelNameLength)
+	}
+	if len(opi.KernelNames) != 0 {
+		t.Error("expected kernel name to be released on failure, received", opi.KernelNames)
+	}
+}
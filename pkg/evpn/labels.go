@@ -0,0 +1,170 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils"
+)
+
+// labelsKey is the store key of the labels and annotations, so they survive a failover
+const labelsKey = "labels"
+
+// ObjectLabels is the labels and annotations of a LogicalBridge, BridgePort, Vrf or Svi,
+// set with utils.LabelsMetadataKey and utils.AnnotationsMetadataKey
+// TODO: move to opi-api once the messages get labels and annotations fields
+type ObjectLabels struct {
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// labelsFromContext returns the labels and annotations sent with a Create or Update call,
+// nil when the call carries neither of them
+func labelsFromContext(ctx context.Context) (*ObjectLabels, error) {
+	labels, hasLabels := utils.MetadataValue(ctx, utils.LabelsMetadataKey)
+	annotations, hasAnnotations := utils.MetadataValue(ctx, utils.AnnotationsMetadataKey)
+	if !hasLabels && !hasAnnotations {
+		return nil, nil
+	}
+	obj := &ObjectLabels{}
+	var err error
+	if obj.Labels, err = utils.ParseLabels(labels, false); err != nil {
+		return nil, badRequest(utils.LabelsMetadataKey, status.Error(codes.InvalidArgument, err.Error()))
+	}
+	if obj.Annotations, err = utils.ParseLabels(annotations, true); err != nil {
+		return nil, badRequest(utils.AnnotationsMetadataKey, status.Error(codes.InvalidArgument, err.Error()))
+	}
+	return obj, nil
+}
+
+// labelSelectorFromContext returns the label selector of a List call, matching everything when not sent
+func labelSelectorFromContext(ctx context.Context) (utils.LabelSelector, error) {
+	value, _ := utils.MetadataValue(ctx, utils.LabelSelectorMetadataKey)
+	selector, err := utils.ParseLabelSelector(value)
+	if err != nil {
+		return nil, badRequest(utils.LabelSelectorMetadataKey, status.Error(codes.InvalidArgument, err.Error()))
+	}
+	return selector, nil
+}
+
+// setLabels replaces the labels and annotations of an object, nil keeps the current ones
+func (s *Server) setLabels(name string, labels *ObjectLabels) {
+	if labels == nil {
+		return
+	}
+	s.Labels[name] = labels
+	s.persistLabels()
+}
+
+// releaseLabels forgets the labels and annotations of a deleted object
+func (s *Server) releaseLabels(name string) {
+	if _, ok := s.Labels[name]; ok {
+		delete(s.Labels, name)
+		s.persistLabels()
+	}
+}
+
+// matchesLabels reports whether the labels of an object match the selector of a List call
+func (s *Server) matchesLabels(selector utils.LabelSelector, name string) bool {
+	if len(selector) == 0 {
+		return true
+	}
+	labels := map[string]string{}
+	if obj, ok := s.Labels[name]; ok {
+		labels = obj.Labels
+	}
+	return selector.Matches(labels)
+}
+
+func stringMapToStruct(values map[string]string) *structpb.Struct {
+	fields := make(map[string]*structpb.Value, len(values))
+	for key, value := range values {
+		fields[key] = structpb.NewStringValue(value)
+	}
+	return &structpb.Struct{Fields: fields}
+}
+
+func structToStringMap(msg *structpb.Struct) map[string]string {
+	values := make(map[string]string, len(msg.GetFields()))
+	for key, value := range msg.GetFields() {
+		values[key] = value.GetStringValue()
+	}
+	return values
+}
+
+func (s *Server) persistLabels() {
+	msg := &structpb.Struct{Fields: make(map[string]*structpb.Value, len(s.Labels))}
+	for name, obj := range s.Labels {
+		msg.Fields[name] = structpb.NewStructValue(&structpb.Struct{Fields: map[string]*structpb.Value{
+			"labels":      structpb.NewStructValue(stringMapToStruct(obj.Labels)),
+			"annotations": structpb.NewStructValue(stringMapToStruct(obj.Annotations)),
+		}})
+	}
+	if err := s.store.Set(labelsKey, msg); err != nil {
+		fmt.Printf("Failed to persist %s: %v", labelsKey, err)
+	}
+}
+
+// loadLabels restores the labels and annotations of the replayed objects
+func (s *Server) loadLabels() error {
+	msg := &structpb.Struct{}
+	found, err := s.store.Get(labelsKey, msg)
+	if err != nil || !found {
+		return err
+	}
+	for name, value := range msg.Fields {
+		fields := value.GetStructValue().GetFields()
+		s.Labels[name] = &ObjectLabels{
+			Labels:      structToStringMap(fields["labels"].GetStructValue()),
+			Annotations: structToStringMap(fields["annotations"].GetStructValue()),
+		}
+	}
+	return nil
+}
+
+// GetLabelsRequest is the request to get the labels and annotations of the objects
+// TODO: move to opi-api once the message is agreed upon
+type GetLabelsRequest struct {
+	// Name is the object to get the labels of, all the labeled objects when empty
+	Name string
+}
+
+// GetLabelsResponse lists the labels and annotations per object name
+// TODO: move to opi-api once the message is agreed upon
+type GetLabelsResponse struct {
+	Labels map[string]*ObjectLabels `json:"labels"`
+}
+
+// GetLabels returns the labels and annotations of an object, or of all the labeled objects,
+// since the opi-api messages do not carry them yet
+func (s *Server) GetLabels(ctx context.Context, in *GetLabelsRequest) (*GetLabelsResponse, error) {
+	s.objectsMu.RLock()
+	defer s.objectsMu.RUnlock()
+	response := &GetLabelsResponse{Labels: map[string]*ObjectLabels{}}
+	if in.Name != "" {
+		if s.lookupObject(in.Name) == nil || !inTenant(ctx, in.Name) {
+			err := status.Errorf(codes.NotFound, "unable to find key %s", in.Name)
+			return nil, err
+		}
+		labels, ok := s.Labels[in.Name]
+		if !ok {
+			labels = &ObjectLabels{}
+		}
+		response.Labels[in.Name] = labels
+		return response, nil
+	}
+	for name, labels := range s.Labels {
+		if inTenant(ctx, name) {
+			response.Labels[name] = labels
+		}
+	}
+	return response, nil
+}
@@ -0,0 +1,120 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/philippgille/gokv/gomap"
+	"github.com/stretchr/testify/mock"
+	"github.com/vishvananda/netlink"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils"
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils/mocks"
+)
+
+func Test_ListWithLabelSelector(t *testing.T) {
+	mockNetlink := mocks.NewNetlink(t)
+	opi := NewServerWithArgs(mockNetlink, mocks.NewFrr(t), gomap.NewStore(gomap.DefaultOptions))
+	mockNetlink.EXPECT().LinkByName(mock.Anything, mock.Anything).Return(&netlink.Device{LinkAttrs: netlink.LinkAttrs{OperState: netlink.OperUp}}, nil).Maybe()
+	for id, labels := range map[string]map[string]string{
+		"bridge1": {"app": "web"},
+		"bridge2": {"app": "db"},
+		"bridge3": nil,
+	} {
+		name := resourceIDToFullName("bridges", id)
+		opi.Bridges[name] = &pb.LogicalBridge{Name: name, Spec: &pb.LogicalBridgeSpec{VlanId: 10}}
+		if labels != nil {
+			opi.setLabels(name, &ObjectLabels{Labels: labels})
+		}
+	}
+	tests := map[string]struct {
+		selector string
+		out      []string
+		errCode  codes.Code
+	}{
+		"matching label": {
+			selector: "app=web",
+			out:      []string{resourceIDToFullName("bridges", "bridge1")},
+			errCode:  codes.OK,
+		},
+		"unset label": {
+			selector: "!app",
+			out:      []string{resourceIDToFullName("bridges", "bridge3")},
+			errCode:  codes.OK,
+		},
+		"invalid selector": {
+			selector: "app=web server",
+			errCode:  codes.InvalidArgument,
+		},
+	}
+	for testName, tt := range tests {
+		t.Run(testName, func(t *testing.T) {
+			ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(utils.LabelSelectorMetadataKey, tt.selector))
+			response, err := opi.ListLogicalBridges(ctx, &pb.ListLogicalBridgesRequest{})
+			if status.Code(err) != tt.errCode {
+				t.Fatal("error code: expected", tt.errCode, "received", err)
+			}
+			if err != nil {
+				return
+			}
+			names := []string{}
+			for _, bridge := range response.LogicalBridges {
+				names = append(names, bridge.Name)
+			}
+			if !reflect.DeepEqual(names, tt.out) {
+				t.Error("bridges: expected", tt.out, "received", names)
+			}
+		})
+	}
+}
+
+func Test_GetLabels(t *testing.T) {
+	store := gomap.NewStore(gomap.DefaultOptions)
+	opi := NewServerWithArgs(mocks.NewNetlink(t), mocks.NewFrr(t), store)
+	name := resourceIDToFullName("bridges", "bridge1")
+	opi.Bridges[name] = &pb.LogicalBridge{Name: name, Spec: &pb.LogicalBridgeSpec{VlanId: 10}}
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(
+		utils.LabelsMetadataKey, "app=web",
+		utils.AnnotationsMetadataKey, "owner=Jane Doe",
+	))
+	labels, err := labelsFromContext(ctx)
+	if err != nil {
+		t.Fatal("error: expected", nil, "received", err)
+	}
+	opi.setLabels(name, labels)
+
+	// the labels are persisted, so the HA peer gets them back
+	peer := NewServerWithArgs(mocks.NewNetlink(t), mocks.NewFrr(t), store)
+	peer.Bridges[name] = opi.Bridges[name]
+	if err := peer.loadLabels(); err != nil {
+		t.Fatal("error: expected", nil, "received", err)
+	}
+	response, err := peer.GetLabels(context.Background(), &GetLabelsRequest{Name: name})
+	if err != nil {
+		t.Fatal("error: expected", nil, "received", err)
+	}
+	expected := &ObjectLabels{Labels: map[string]string{"app": "web"}, Annotations: map[string]string{"owner": "Jane Doe"}}
+	if !reflect.DeepEqual(response.Labels[name], expected) {
+		t.Error("labels: expected", expected, "received", response.Labels[name])
+	}
+	_, err = peer.GetLabels(context.Background(), &GetLabelsRequest{Name: resourceIDToFullName("bridges", "unknown")})
+	if status.Code(err) != codes.NotFound {
+		t.Error("error code: expected", codes.NotFound, "received", err)
+	}
+
+	ctx = metadata.NewIncomingContext(context.Background(), metadata.Pairs(utils.LabelsMetadataKey, "app"))
+	if _, err := labelsFromContext(ctx); status.Code(err) != codes.InvalidArgument {
+		t.Error("error code: expected", codes.InvalidArgument, "received", err)
+	}
+}
@@ -0,0 +1,191 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils"
+)
+
+// LifecycleState is the stage of the life of a Vrf, LogicalBridge, BridgePort or Svi, telling
+// the callers of the asynchronous calls whether the object is still being programmed
+// TODO: move to the status of the objects in opi-api once the message is agreed upon
+type LifecycleState string
+
+const (
+	// LifecycleCreating is an object being programmed by an asynchronous Create
+	LifecycleCreating LifecycleState = "CREATING"
+	// LifecycleActive is an object programmed, the state of all the objects of the synchronous calls
+	LifecycleActive LifecycleState = "ACTIVE"
+	// LifecycleDeleting is an object being torn down by an asynchronous Delete
+	LifecycleDeleting LifecycleState = "DELETING"
+	// LifecycleError is an object whose asynchronous Create or Delete failed, see its operation
+	LifecycleError LifecycleState = "ERROR"
+)
+
+// LifecycleMetadataKey is the grpc response header listing, one "<name>: <state>" value per
+// object, the lifecycle states of the Vrfs, LogicalBridges, BridgePorts and Svis returned by Get/List
+// TODO: replace by a status field once it is added to opi-api
+const LifecycleMetadataKey = "x-opi-lifecycle"
+
+// Lifecycle is the lifecycle state of an object, with the error of the failed operation
+// TODO: move to the status of the objects in opi-api once the message is agreed upon
+type Lifecycle struct {
+	State   LifecycleState `json:"state"`
+	Message string         `json:"message,omitempty"`
+}
+
+// lifecycleSet keeps the lifecycle of the objects which are not ACTIVE, changed by the
+// operations in the background
+type lifecycleSet struct {
+	mu     sync.Mutex
+	byName map[string]Lifecycle
+}
+
+func newLifecycleSet() *lifecycleSet {
+	return &lifecycleSet{byName: map[string]Lifecycle{}}
+}
+
+// set records the state of the object, the ERROR state with the message of err, an ACTIVE
+// object is forgotten
+func (l *lifecycleSet) set(name string, state LifecycleState, err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if state == LifecycleActive {
+		delete(l.byName, name)
+		return
+	}
+	lifecycle := Lifecycle{State: state}
+	if err != nil {
+		lifecycle.Message = status.Convert(err).Message()
+	}
+	l.byName[name] = lifecycle
+}
+
+// get returns the lifecycle of an object, ACTIVE when it is not being changed
+func (l *lifecycleSet) get(name string) Lifecycle {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if lifecycle, ok := l.byName[name]; ok {
+		return lifecycle
+	}
+	return Lifecycle{State: LifecycleActive}
+}
+
+// checkNotDeleting fails with FailedPrecondition while an asynchronous Delete tears the object
+// down, it can neither be changed nor referenced by new objects
+func (s *Server) checkNotDeleting(name string) error {
+	if s.lifecycles.get(name).State == LifecycleDeleting {
+		return status.Errorf(codes.FailedPrecondition, "%s is being deleted", name)
+	}
+	return nil
+}
+
+// startLifecycleOperation marks the object CREATING or DELETING and runs its programming in
+// the background with startOperation, the object is then ACTIVE, or forgotten once deleted,
+// or in ERROR when it failed. The watchers see the objects going DELETING and failing
+func (s *Server) startLifecycleOperation(ctx context.Context, target string, state LifecycleState, run func(context.Context) (proto.Message, error)) string {
+	s.lifecycles.set(target, state, nil)
+	if state == LifecycleDeleting {
+		s.events.Publish(utils.WatchEvent{Type: utils.WatchModified, Name: target, State: string(state)})
+	}
+	return s.startOperation(ctx, target, func(ctx context.Context) (proto.Message, error) {
+		response, err := run(ctx)
+		if err != nil {
+			s.lifecycles.set(target, LifecycleError, err)
+			found := s.LookupObject(target) != nil
+			if found {
+				s.events.Publish(utils.WatchEvent{Type: utils.WatchModified, Name: target, State: string(LifecycleError)})
+			}
+			return nil, err
+		}
+		s.lifecycles.set(target, LifecycleActive, nil)
+		return response, nil
+	})
+}
+
+// lifecyclesMetadata returns the lifecycle states of the objects
+func (s *Server) lifecyclesMetadata(names []string) metadata.MD {
+	md := metadata.MD{}
+	for _, name := range names {
+		md.Append(LifecycleMetadataKey, fmt.Sprintf("%s: %s", name, s.lifecycles.get(name).State))
+	}
+	return md
+}
+
+// reportLifecycles attaches the lifecycle states of the objects to the response header
+func (s *Server) reportLifecycles(ctx context.Context, names []string) {
+	if len(names) == 0 {
+		return
+	}
+	// fails when called out of a grpc server, e.g. in tests
+	if err := grpc.SetHeader(ctx, s.lifecyclesMetadata(names)); err != nil {
+		log.Printf("Failed to report lifecycle states: %v", err)
+	}
+}
+
+// GetLifecyclesRequest is the request to get the lifecycle states of the objects
+// TODO: move to opi-api once the message is agreed upon
+type GetLifecyclesRequest struct {
+	// Name is the object to get the lifecycle of, all the objects when empty
+	Name string
+}
+
+// GetLifecyclesResponse lists the lifecycle states per object name
+// TODO: move to opi-api once the message is agreed upon
+type GetLifecyclesResponse struct {
+	Lifecycles map[string]Lifecycle `json:"lifecycles"`
+}
+
+// GetLifecycles returns the lifecycle state of an object, or of all the Vrfs, LogicalBridges,
+// BridgePorts and Svis, the objects still being created or which failed to be included
+func (s *Server) GetLifecycles(ctx context.Context, in *GetLifecyclesRequest) (*GetLifecyclesResponse, error) {
+	response := &GetLifecyclesResponse{Lifecycles: map[string]Lifecycle{}}
+	s.lifecycles.mu.Lock()
+	pending := make(map[string]Lifecycle, len(s.lifecycles.byName))
+	for name, lifecycle := range s.lifecycles.byName {
+		pending[name] = lifecycle
+	}
+	s.lifecycles.mu.Unlock()
+	s.objectsMu.RLock()
+	defer s.objectsMu.RUnlock()
+	if in.Name != "" {
+		lifecycle, ok := pending[in.Name]
+		if (!ok && s.lookupObject(in.Name) == nil) || !inTenant(ctx, in.Name) {
+			err := status.Errorf(codes.NotFound, "unable to find key %s", in.Name)
+			return nil, err
+		}
+		if !ok {
+			lifecycle = Lifecycle{State: LifecycleActive}
+		}
+		response.Lifecycles[in.Name] = lifecycle
+		return response, nil
+	}
+	names := append(append(append(sortedKeys(s.Vrfs), sortedKeys(s.Bridges)...), sortedKeys(s.Ports)...), sortedKeys(s.Svis)...)
+	for name := range pending {
+		names = append(names, name)
+	}
+	for _, name := range names {
+		if !inTenant(ctx, name) {
+			continue
+		}
+		lifecycle, ok := pending[name]
+		if !ok {
+			lifecycle = Lifecycle{State: LifecycleActive}
+		}
+		response.Lifecycles[name] = lifecycle
+	}
+	return response, nil
+}
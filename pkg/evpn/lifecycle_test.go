@@ -0,0 +1,172 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/longrunning/autogen/longrunningpb"
+	"github.com/philippgille/gokv/gomap"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+	pc "github.com/opiproject/opi-api/network/opinetcommon/v1alpha1/gen/go"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/fake"
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils"
+)
+
+func Test_LifecycleOperation(t *testing.T) {
+	ctx := context.Background()
+	opi := NewServerWithArgs(fake.NewNetlink(), fake.NewFrr(), gomap.NewStore(gomap.DefaultOptions))
+	opi.putVrf(&pb.Vrf{Name: testVrfName, Spec: &pb.VrfSpec{}})
+	w := opi.Watch()
+	defer opi.Unwatch(w)
+
+	// the Vrf is DELETING until the teardown is done, it cannot be changed meanwhile
+	release := make(chan error)
+	name := opi.startLifecycleOperation(ctx, testVrfName, LifecycleDeleting, func(ctx context.Context) (proto.Message, error) {
+		return &emptypb.Empty{}, <-release
+	})
+	if ev, err := w.Next(ctx); err != nil || ev.State != string(LifecycleDeleting) {
+		t.Error("event: expected", LifecycleDeleting, "received", ev, err)
+	}
+	response, err := opi.GetLifecycles(ctx, &GetLifecyclesRequest{Name: testVrfName})
+	if err != nil || response.Lifecycles[testVrfName].State != LifecycleDeleting {
+		t.Error("lifecycle: expected", LifecycleDeleting, "received", response, err)
+	}
+	_, err = opi.UpdateVrf(ctx, &pb.UpdateVrfRequest{Vrf: &pb.Vrf{Name: testVrfName, Spec: &pb.VrfSpec{LoopbackIpPrefix: &pc.IPPrefix{Len: 24}}}})
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Error("update: expected", codes.FailedPrecondition, "received", err)
+	}
+	if _, err := opi.DeleteVrf(ctx, &pb.DeleteVrfRequest{Name: testVrfName}); status.Code(err) != codes.Aborted {
+		t.Error("delete: expected", codes.Aborted, "received", err)
+	}
+
+	// a failed teardown leaves the Vrf in ERROR, with the error of the operation
+	release <- status.Error(codes.Unavailable, "zebra is down")
+	if _, err := opi.WaitOperation(ctx, &longrunningpb.WaitOperationRequest{Name: name, Timeout: durationpb.New(5 * time.Second)}); err != nil {
+		t.Fatal("error: expected", nil, "received", err)
+	}
+	expected := Lifecycle{State: LifecycleError, Message: "zebra is down"}
+	if received := opi.lifecycles.get(testVrfName); received != expected {
+		t.Error("lifecycle: expected", expected, "received", received)
+	}
+	if ev, err := w.Next(ctx); err != nil || ev.State != string(LifecycleError) {
+		t.Error("event: expected", LifecycleError, "received", ev, err)
+	}
+
+	// the objects still being created are listed, the others are ACTIVE
+	opi.lifecycles.set(resourceIDToFullName("svis", "svi10"), LifecycleCreating, nil)
+	opi.putLogicalBridge(&pb.LogicalBridge{Name: resourceIDToFullName("bridges", "vlan10"), Spec: &pb.LogicalBridgeSpec{VlanId: 10}})
+	response, err = opi.GetLifecycles(ctx, &GetLifecyclesRequest{})
+	if err != nil {
+		t.Fatal("error: expected", nil, "received", err)
+	}
+	expectedStates := map[string]LifecycleState{
+		testVrfName:                               LifecycleError,
+		resourceIDToFullName("svis", "svi10"):     LifecycleCreating,
+		resourceIDToFullName("bridges", "vlan10"): LifecycleActive,
+	}
+	for name, state := range expectedStates {
+		if response.Lifecycles[name].State != state {
+			t.Error(name, ": expected", state, "received", response.Lifecycles[name])
+		}
+	}
+	if _, err := opi.GetLifecycles(ctx, &GetLifecyclesRequest{Name: resourceIDToFullName("svis", "unknown")}); status.Code(err) != codes.NotFound {
+		t.Error("unknown: expected", codes.NotFound, "received", err)
+	}
+}
+
+func Test_DeleteVrfAsync(t *testing.T) {
+	ctx := context.Background()
+	opi := NewServerWithArgs(fake.NewNetlink(), fake.NewFrr(), gomap.NewStore(gomap.DefaultOptions))
+	vrf, err := opi.CreateVrf(ctx, &pb.CreateVrfRequest{VrfId: testVrfID, Vrf: &pb.Vrf{Spec: &pb.VrfSpec{LoopbackIpPrefix: &pc.IPPrefix{Len: 24}}}})
+	if err != nil {
+		t.Fatal("CreateVrf: expected", nil, "received", err)
+	}
+	async := metadata.NewIncomingContext(ctx, metadata.Pairs(utils.AsyncMetadataKey, "true"))
+	if _, err := opi.DeleteVrf(async, &pb.DeleteVrfRequest{Name: vrf.Name}); err != nil {
+		t.Fatal("DeleteVrf: expected", nil, "received", err)
+	}
+	operations, err := opi.ListOperations(ctx, &longrunningpb.ListOperationsRequest{})
+	if err != nil || len(operations.Operations) != 1 {
+		t.Fatal("operations: expected 1, received", operations, err)
+	}
+	op, err := opi.WaitOperation(ctx, &longrunningpb.WaitOperationRequest{Name: operations.Operations[0].Name, Timeout: durationpb.New(5 * time.Second)})
+	if err != nil || !op.Done || op.GetError() != nil {
+		t.Fatal("operation: expected done, received", op, err)
+	}
+	if _, ok := opi.Vrfs[vrf.Name]; ok {
+		t.Error("vrf: expected deleted, received", opi.Vrfs[vrf.Name])
+	}
+	if _, err := opi.GetLifecycles(ctx, &GetLifecyclesRequest{Name: vrf.Name}); status.Code(err) != codes.NotFound {
+		t.Error("lifecycle: expected", codes.NotFound, "received", err)
+	}
+	if _, err := opi.DeleteVrf(async, &pb.DeleteVrfRequest{Name: vrf.Name}); status.Code(err) != codes.NotFound {
+		t.Error("delete again: expected", codes.NotFound, "received", err)
+	}
+}
+
+func Test_DeleteVrfAsyncConcurrentCalls(t *testing.T) {
+	ctx := context.Background()
+	opi := NewServerWithArgs(fake.NewNetlink(), fake.NewFrr(), gomap.NewStore(gomap.DefaultOptions))
+	const count = 10
+	names := make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		request := &pb.CreateVrfRequest{VrfId: fmt.Sprintf("blue%d", i), Vrf: &pb.Vrf{Spec: &pb.VrfSpec{LoopbackIpPrefix: &pc.IPPrefix{Len: 24}}}}
+		vrf, err := opi.CreateVrf(ctx, request)
+		if err != nil {
+			t.Fatal("CreateVrf: expected", nil, "received", err)
+		}
+		names = append(names, vrf.Name)
+	}
+
+	// the teardowns forget the Vrfs while the next calls read them
+	async := metadata.NewIncomingContext(ctx, metadata.Pairs(utils.AsyncMetadataKey, "true"))
+	for _, name := range names {
+		if _, err := opi.DeleteVrf(async, &pb.DeleteVrfRequest{Name: name}); err != nil {
+			t.Fatal("DeleteVrf: expected", nil, "received", err)
+		}
+		if _, err := opi.GetLifecycles(ctx, &GetLifecyclesRequest{}); err != nil {
+			t.Error("GetLifecycles: expected", nil, "received", err)
+		}
+	}
+	operations, err := opi.ListOperations(ctx, &longrunningpb.ListOperationsRequest{})
+	if err != nil || len(operations.Operations) != count {
+		t.Fatal("operations: expected", count, "received", operations, err)
+	}
+	for _, op := range operations.Operations {
+		op, err := opi.WaitOperation(ctx, &longrunningpb.WaitOperationRequest{Name: op.Name, Timeout: durationpb.New(5 * time.Second)})
+		if err != nil || !op.Done || op.GetError() != nil {
+			t.Fatal("operation: expected done, received", op, err)
+		}
+	}
+	if len(opi.Vrfs) != 0 {
+		t.Error("vrfs: expected deleted, received", opi.Vrfs)
+	}
+}
+
+func Test_LifecycleSet(t *testing.T) {
+	l := newLifecycleSet()
+	l.set(testVrfName, LifecycleError, errors.New("failed"))
+	if received := l.get(testVrfName); received.State != LifecycleError || received.Message != "failed" {
+		t.Error("error: expected", LifecycleError, "received", received)
+	}
+	l.set(testVrfName, LifecycleActive, nil)
+	if len(l.byName) != 0 || l.get(testVrfName).State != LifecycleActive {
+		t.Error("active: expected forgotten, received", l.byName)
+	}
+}
@@ -0,0 +1,529 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"path"
+	"time"
+
+	"github.com/vishvananda/netlink"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// linuxDataplane is the default Dataplane, objects become kernel devices, programmed
+// over netlink (see the *_netlink.go files), and FRR configuration (see the *_frr.go files).
+// It shares the kernel names and the stored LogicalBridges of the server it belongs to
+type linuxDataplane struct {
+	s *Server
+}
+
+// tenantBridgeKey carries the tenant bridge looked up once for a whole batch
+type tenantBridgeKey struct{}
+
+// tenantBridge returns the bridge all the LogicalBridges and BridgePorts are plugged into
+func (s *Server) tenantBridge(ctx context.Context) (netlink.Link, error) {
+	if bridge, ok := ctx.Value(tenantBridgeKey{}).(netlink.Link); ok {
+		return bridge, nil
+	}
+	bridge, err := s.nLink.LinkByName(ctx, tenantbridgeName)
+	if err != nil {
+		err := status.Errorf(codes.NotFound, "unable to find key %s", tenantbridgeName)
+		return nil, err
+	}
+	return bridge, nil
+}
+
+// PrepareBatch looks the tenant bridge up once for all the requests of the batch
+func (d *linuxDataplane) PrepareBatch(ctx context.Context) (context.Context, error) {
+	bridge, err := d.s.tenantBridge(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return context.WithValue(ctx, tenantBridgeKey{}, bridge), nil
+}
+
+// programmed records whether one layer of the object was programmed in its conditions
+func (d *linuxDataplane) programmed(name string, layer ConditionType, err error) error {
+	d.s.conditions.set(name, layer, err)
+	if layer == ConditionFrrProgrammed && err == nil {
+		// e.g. applied by a resync in the meantime
+		d.s.frrRetries.Cancel(name)
+	}
+	return err
+}
+
+// frrProgrammed applies the FRR configuration of a new Vrf or Svi, a failure, e.g. while FRR
+// restarts, does not fail the creation, the object is Degraded until the configuration is
+// applied in the background. A call cancelled or past its deadline fails, to be rolled back
+func (d *linuxDataplane) frrProgrammed(ctx context.Context, name string, apply utils.RetryFunc) error {
+	err := apply(ctx)
+	if err != nil && ctx.Err() != nil {
+		return err
+	}
+	if err := d.programmed(name, ConditionFrrProgrammed, err); err != nil {
+		log.Printf("Failed to program %v in FRR, retrying in the background: %v", name, err)
+		d.s.frrRetries.Add(name, apply)
+	}
+	return nil
+}
+
+func (d *linuxDataplane) CreateVrf(ctx context.Context, obj *pb.Vrf) error {
+	in := &pb.CreateVrfRequest{Vrf: obj}
+	// configure netlink
+	err := d.s.netlinkCreateVrf(ctx, in, obj.GetStatus().GetRoutingTable(), obj.GetStatus().GetRmac())
+	links := func(ctx context.Context) error {
+		return d.s.removeVrfLinks(ctx, obj)
+	}
+	if err := d.programmed(obj.Name, ConditionNetlinkProgrammed, err); err != nil {
+		return d.abortCreate(ctx, obj.Name, err, links)
+	}
+	// configure FRR
+	err = d.frrProgrammed(ctx, obj.Name, func(ctx context.Context) error {
+		return d.s.frrCreateVrfRequest(ctx, in)
+	})
+	return d.abortCreate(ctx, obj.Name, err, func(ctx context.Context) error {
+		return d.s.frrDeleteVrfRequest(ctx, obj)
+	}, links)
+}
+
+func (d *linuxDataplane) UpdateVrf(ctx context.Context, old *pb.Vrf, _ *pb.Vrf) error {
+	return d.programmed(old.Name, ConditionNetlinkProgrammed, d.s.netlinkUpdateVrf(ctx, old))
+}
+
+func (d *linuxDataplane) DeleteVrf(ctx context.Context, obj *pb.Vrf) error {
+	// configure netlink
+	if err := d.s.netlinkDeleteVrf(ctx, obj); err != nil {
+		return err
+	}
+	// delete from FRR
+	return d.s.frrDeleteVrfRequest(ctx, obj)
+}
+
+func (d *linuxDataplane) GetVrf(ctx context.Context, obj *pb.Vrf) error {
+	// the namespace of a Vrf isolated in one stands for its vrf device
+	if d.s.vrfNetns() {
+		_, err := d.s.vrfNetlink(ctx, obj.Name)
+		return err
+	}
+	return d.s.checkLinks(ctx, d.s.vrfKernelName(obj.Name))
+}
+
+func (d *linuxDataplane) CheckVrf(ctx context.Context, obj *pb.Vrf) error {
+	nLink, err := d.s.vrfNetlink(ctx, obj.Name)
+	if err != nil {
+		return err
+	}
+	return checkLinksUpIn(ctx, nLink, d.s.vrfLinks(obj)...)
+}
+
+// PrecheckCreateVrf checks none of the Vrf devices exist, under the kernel name CreateVrf would pick
+func (d *linuxDataplane) PrecheckCreateVrf(ctx context.Context, obj *pb.Vrf) error {
+	names := []string{d.s.kernelNameFor(obj.Name, path.Base(obj.Name))}
+	if obj.Spec.Vni != nil {
+		names = append(names, fmt.Sprintf("br%d", *obj.Spec.Vni), fmt.Sprintf("vni%d", *obj.Spec.Vni))
+	}
+	if vrfLoopbackAddr(obj) != nil {
+		names = append(names, vrfLoopbackKernelName(obj.Name, names[0]))
+	}
+	return d.s.precheckLinksAbsent(ctx, names...)
+}
+
+// ResyncVrf recreates the Vrf devices when one is missing and always re-applies FRR
+func (d *linuxDataplane) ResyncVrf(ctx context.Context, obj *pb.Vrf) (bool, error) {
+	in := &pb.CreateVrfRequest{Vrf: obj}
+	recreated := false
+	nLink, err := d.s.vrfNetlink(ctx, obj.Name)
+	if err == nil {
+		err = checkLinksIn(ctx, nLink, d.s.vrfLinks(obj)...)
+	}
+	if err != nil {
+		log.Printf("Recreating Vrf %v: %v", obj.Name, err)
+		// best effort removal of the devices left over
+		if err := d.s.netlinkDeleteVrf(ctx, obj); err != nil {
+			log.Printf("Failed to clean up Vrf %v: %v", obj.Name, err)
+		}
+		err := d.s.netlinkCreateVrf(ctx, in, obj.GetStatus().GetRoutingTable(), obj.GetStatus().GetRmac())
+		if err := d.programmed(obj.Name, ConditionNetlinkProgrammed, err); err != nil {
+			return true, err
+		}
+		recreated = true
+	}
+	return recreated, d.programmed(obj.Name, ConditionFrrProgrammed, d.s.frrCreateVrfRequest(ctx, in))
+}
+
+func (d *linuxDataplane) CreateLogicalBridge(ctx context.Context, obj *pb.LogicalBridge) error {
+	links := func(ctx context.Context) error {
+		return d.s.removeLinks(ctx, d.s.logicalBridgeLinks(obj)...)
+	}
+	if err := d.programmed(obj.Name, ConditionNetlinkProgrammed, d.s.netlinkCreateLogicalBridge(ctx, obj)); err != nil {
+		return d.abortCreate(ctx, obj.Name, err, links)
+	}
+	// only the LogicalBridges flooding to a multicast group have FRR configuration
+	if d.s.multicastGroup(obj.Name) == nil {
+		return nil
+	}
+	err := d.frrProgrammed(ctx, obj.Name, func(ctx context.Context) error {
+		return d.s.frrCreateMulticastGroup(ctx, obj)
+	})
+	return d.abortCreate(ctx, obj.Name, err, func(ctx context.Context) error {
+		return d.s.frrDeleteMulticastGroup(ctx, obj)
+	}, links)
+}
+
+func (d *linuxDataplane) UpdateLogicalBridge(ctx context.Context, old *pb.LogicalBridge, _ *pb.LogicalBridge) error {
+	return d.programmed(old.Name, ConditionNetlinkProgrammed, d.s.netlinkUpdateLogicalBridge(ctx, old))
+}
+
+func (d *linuxDataplane) DeleteLogicalBridge(ctx context.Context, obj *pb.LogicalBridge) error {
+	if err := d.s.netlinkDeleteLogicalBridge(ctx, obj); err != nil {
+		return err
+	}
+	if d.s.multicastGroup(obj.Name) == nil {
+		return nil
+	}
+	return d.s.frrDeleteMulticastGroup(ctx, obj)
+}
+
+func (d *linuxDataplane) GetLogicalBridge(ctx context.Context, obj *pb.LogicalBridge) error {
+	return d.s.checkLinks(ctx, d.s.logicalBridgeLinks(obj)...)
+}
+
+func (d *linuxDataplane) CheckLogicalBridge(ctx context.Context, obj *pb.LogicalBridge) error {
+	return d.s.checkLinksUp(ctx, d.s.logicalBridgeLinks(obj)...)
+}
+
+func (d *linuxDataplane) PrecheckCreateLogicalBridge(ctx context.Context, obj *pb.LogicalBridge) error {
+	return d.s.precheckLinksAbsent(ctx, d.s.logicalBridgeLinks(obj)...)
+}
+
+// ResyncLogicalBridge recreates the vxlan device when it is missing
+func (d *linuxDataplane) ResyncLogicalBridge(ctx context.Context, obj *pb.LogicalBridge) error {
+	if err := d.GetLogicalBridge(ctx, obj); err == nil {
+		return nil
+	}
+	log.Printf("Recreating LogicalBridge %v", obj.Name)
+	return d.programmed(obj.Name, ConditionNetlinkProgrammed, d.s.netlinkCreateLogicalBridge(ctx, obj))
+}
+
+func (d *linuxDataplane) BindBridgePort(ctx context.Context, obj *pb.BridgePort) error {
+	if err := d.programmed(obj.Name, ConditionNetlinkProgrammed, d.s.netlinkCreateBridgePort(ctx, obj)); err != nil {
+		// the port itself is not created by the bridge, only unbound
+		return d.abortCreate(ctx, obj.Name, err, func(ctx context.Context) error {
+			return d.s.netlinkDeleteBridgePort(ctx, obj)
+		})
+	}
+	d.s.checkHwOffload(ctx, obj)
+	return nil
+}
+
+func (d *linuxDataplane) UpdateBridgePort(ctx context.Context, old *pb.BridgePort, obj *pb.BridgePort) error {
+	return d.programmed(old.Name, ConditionNetlinkProgrammed, d.s.netlinkUpdateBridgePort(ctx, old, obj))
+}
+
+func (d *linuxDataplane) UnbindBridgePort(ctx context.Context, obj *pb.BridgePort) error {
+	return d.s.netlinkDeleteBridgePort(ctx, obj)
+}
+
+func (d *linuxDataplane) GetBridgePort(ctx context.Context, obj *pb.BridgePort) error {
+	return d.s.checkLinks(ctx, d.s.bridgePortLinks(obj)...)
+}
+
+func (d *linuxDataplane) CheckBridgePort(ctx context.Context, obj *pb.BridgePort) error {
+	return d.s.checkLinksUp(ctx, d.s.bridgePortLinks(obj)...)
+}
+
+// PrecheckBindBridgePort checks the tenant bridge and the port itself exist,
+// the port is not created by the bridge
+func (d *linuxDataplane) PrecheckBindBridgePort(ctx context.Context, obj *pb.BridgePort) error {
+	return d.s.checkLinks(ctx, append([]string{tenantbridgeName}, d.s.bridgePortLinks(obj)...)...)
+}
+
+// ResyncBridgePort re-applies the bridge and vlan memberships, the port itself is not
+// created by the bridge so it is not recreated when missing
+func (d *linuxDataplane) ResyncBridgePort(ctx context.Context, obj *pb.BridgePort) error {
+	if err := d.programmed(obj.Name, ConditionNetlinkProgrammed, d.s.netlinkCreateBridgePort(ctx, obj)); err != nil {
+		return err
+	}
+	d.s.checkHwOffload(ctx, obj)
+	return nil
+}
+
+func (d *linuxDataplane) SetMacAgeing(ctx context.Context, ageing time.Duration) error {
+	return d.s.netlinkSetMacAgeing(ctx, ageing)
+}
+
+func (d *linuxDataplane) FlushBridgeMacs(ctx context.Context, bridge *pb.LogicalBridge, port *pb.BridgePort) (int, error) {
+	return d.s.netlinkFlushBridgeMacs(ctx, bridge, port)
+}
+
+func (d *linuxDataplane) CreateStaticFdbEntry(ctx context.Context, obj *StaticFdbEntry, bridge *pb.LogicalBridge) error {
+	return d.programmed(obj.Name, ConditionNetlinkProgrammed, d.s.netlinkCreateStaticFdbEntry(ctx, obj, bridge))
+}
+
+func (d *linuxDataplane) DeleteStaticFdbEntry(ctx context.Context, obj *StaticFdbEntry, bridge *pb.LogicalBridge) error {
+	return d.s.netlinkDeleteStaticFdbEntry(ctx, obj, bridge)
+}
+
+func (d *linuxDataplane) CreateSecurityPolicy(ctx context.Context, obj *SecurityPolicy, vrf *pb.Vrf, svis []*pb.Svi) error {
+	return d.programmed(obj.Name, ConditionNetlinkProgrammed, d.s.nftCreateSecurityPolicy(ctx, obj, vrf, svis))
+}
+
+func (d *linuxDataplane) DeleteSecurityPolicy(ctx context.Context, obj *SecurityPolicy, vrf *pb.Vrf) error {
+	return d.s.nftDeleteSecurityPolicy(ctx, obj, vrf)
+}
+
+func (d *linuxDataplane) CreateNatRule(ctx context.Context, obj *NatRule, vrf *pb.Vrf) error {
+	return d.programmed(obj.Name, ConditionNetlinkProgrammed, d.s.nftSetNatRules(ctx, obj, vrf, false))
+}
+
+func (d *linuxDataplane) DeleteNatRule(ctx context.Context, obj *NatRule, vrf *pb.Vrf) error {
+	return d.s.nftSetNatRules(ctx, obj, vrf, true)
+}
+
+func (d *linuxDataplane) CreatePbrRule(ctx context.Context, obj *PbrRule, vrf *pb.Vrf) error {
+	return d.programmed(obj.Name, ConditionNetlinkProgrammed, d.s.netlinkCreatePbrRule(ctx, obj, vrf))
+}
+
+func (d *linuxDataplane) DeletePbrRule(ctx context.Context, obj *PbrRule, vrf *pb.Vrf) error {
+	return d.s.netlinkDeletePbrRule(ctx, obj, vrf)
+}
+
+func (d *linuxDataplane) CreatePrefixList(ctx context.Context, obj *PrefixList) error {
+	return d.programmed(obj.Name, ConditionFrrProgrammed, d.s.frrCreatePrefixListRequest(ctx, obj))
+}
+
+func (d *linuxDataplane) DeletePrefixList(ctx context.Context, obj *PrefixList) error {
+	return d.s.frrDeletePrefixListRequest(ctx, obj)
+}
+
+func (d *linuxDataplane) CreateRouteMap(ctx context.Context, obj *RouteMap) error {
+	return d.programmed(obj.Name, ConditionFrrProgrammed, d.s.frrCreateRouteMapRequest(ctx, obj))
+}
+
+func (d *linuxDataplane) DeleteRouteMap(ctx context.Context, obj *RouteMap) error {
+	return d.s.frrDeleteRouteMapRequest(ctx, obj)
+}
+
+func (d *linuxDataplane) CreateTunnelSecurity(ctx context.Context, obj *TunnelSecurity) error {
+	if obj.Spec.Mode == TunnelSecurityIke {
+		return d.programmed(obj.Name, ConditionNetlinkProgrammed, d.s.swanctlCreateTunnelSecurity(ctx, obj))
+	}
+	return d.programmed(obj.Name, ConditionNetlinkProgrammed, d.s.netlinkCreateTunnelSecurity(ctx, obj))
+}
+
+func (d *linuxDataplane) DeleteTunnelSecurity(ctx context.Context, obj *TunnelSecurity) error {
+	if obj.Spec.Mode == TunnelSecurityIke {
+		return d.s.swanctlDeleteTunnelSecurity(ctx, obj)
+	}
+	return d.s.netlinkDeleteTunnelSecurity(ctx, obj)
+}
+
+func (d *linuxDataplane) CreateUnderlayInterface(ctx context.Context, obj *UnderlayInterface) error {
+	// configure netlink
+	if err := d.programmed(obj.Name, ConditionNetlinkProgrammed, d.s.netlinkCreateUnderlayInterface(ctx, obj)); err != nil {
+		return err
+	}
+	// configure FRR
+	return d.frrProgrammed(ctx, obj.Name, func(ctx context.Context) error {
+		return d.s.frrCreateUnderlayInterfaceRequest(ctx, obj)
+	})
+}
+
+func (d *linuxDataplane) DeleteUnderlayInterface(ctx context.Context, obj *UnderlayInterface) error {
+	// the routing goes before the address it advertises
+	if err := d.s.frrDeleteUnderlayInterfaceRequest(ctx, obj); err != nil {
+		return err
+	}
+	return d.s.netlinkDeleteUnderlayInterface(ctx, obj)
+}
+
+func (d *linuxDataplane) CreateSvi(ctx context.Context, obj *pb.Svi, bridge *pb.LogicalBridge, vrf *pb.Vrf) error {
+	in := &pb.CreateSviRequest{Svi: obj}
+	vlanName := fmt.Sprintf("vlan%d", bridge.Spec.VlanId)
+	links := func(ctx context.Context) error {
+		if err := d.s.removeLinks(ctx, vlanName); err != nil {
+			return err
+		}
+		// moved into the namespace of its Vrf before the create was aborted
+		if nLink, err := d.s.vrfNetlink(ctx, vrf.Name); err == nil && d.s.vrfNetns() {
+			return removeLinksIn(ctx, nLink, vlanName)
+		}
+		return nil
+	}
+	// configure netlink
+	if err := d.programmed(obj.Name, ConditionNetlinkProgrammed, d.s.netlinkCreateSvi(ctx, in, bridge, vrf)); err != nil {
+		return d.abortCreate(ctx, obj.Name, err, links)
+	}
+	// configure FRR
+	err := d.frrProgrammed(ctx, obj.Name, func(ctx context.Context) error {
+		if err := d.s.frrCreateSviRequest(ctx, in, d.s.vrfKernelName(vrf.Name), vlanName); err != nil {
+			return err
+		}
+		return d.frrCreateSviGateway(ctx, obj, bridge, vrf)
+	})
+	return d.abortCreate(ctx, obj.Name, err, func(ctx context.Context) error {
+		return d.s.frrDeleteSviRequest(ctx, obj, d.s.vrfKernelName(vrf.Name), vlanName)
+	}, links)
+}
+
+func (d *linuxDataplane) UpdateSvi(ctx context.Context, old *pb.Svi, _ *pb.Svi, bridge *pb.LogicalBridge) error {
+	return d.programmed(old.Name, ConditionNetlinkProgrammed, d.s.netlinkUpdateSvi(ctx, bridge))
+}
+
+func (d *linuxDataplane) DeleteSvi(ctx context.Context, obj *pb.Svi, bridge *pb.LogicalBridge, vrf *pb.Vrf) error {
+	// configure netlink
+	if err := d.s.netlinkDeleteSvi(ctx, &pb.DeleteSviRequest{Name: obj.Name}, bridge, vrf); err != nil {
+		return err
+	}
+	// delete from FRR
+	vlanName := fmt.Sprintf("vlan%d", bridge.Spec.VlanId)
+	if err := d.s.frrDeleteSviRequest(ctx, obj, d.s.vrfKernelName(vrf.Name), vlanName); err != nil {
+		return err
+	}
+	switch {
+	case d.s.IsAnycastGateway(obj.Name):
+		return d.s.frrDeleteAnycastGateway(ctx, bridge)
+	case d.s.VrfIrbMode(vrf) == IrbAsymmetric:
+		return d.s.frrWithdrawSviIP(ctx, bridge)
+	}
+	return nil
+}
+
+// frrCreateSviGateway advertises the gateway of the Svi on the vni of its LogicalBridge, the
+// shared one of an anycast gateway or its own one in an asymmetric IRB Vrf
+func (d *linuxDataplane) frrCreateSviGateway(ctx context.Context, obj *pb.Svi, bridge *pb.LogicalBridge, vrf *pb.Vrf) error {
+	switch {
+	case d.s.IsAnycastGateway(obj.Name):
+		return d.s.frrCreateAnycastGateway(ctx, bridge)
+	case d.s.VrfIrbMode(vrf) == IrbAsymmetric:
+		return d.s.frrAdvertiseSviIP(ctx, bridge)
+	}
+	return nil
+}
+
+func (d *linuxDataplane) GetSvi(ctx context.Context, obj *pb.Svi, bridge *pb.LogicalBridge) error {
+	nLink, err := d.s.vrfNetlink(ctx, obj.Spec.Vrf)
+	if err != nil {
+		return err
+	}
+	return checkLinksIn(ctx, nLink, fmt.Sprintf("vlan%d", bridge.Spec.VlanId))
+}
+
+func (d *linuxDataplane) CheckSvi(ctx context.Context, obj *pb.Svi) error {
+	nLink, err := d.s.vrfNetlink(ctx, obj.Spec.Vrf)
+	if err != nil {
+		return err
+	}
+	return checkLinksUpIn(ctx, nLink, d.s.sviLinks(obj)...)
+}
+
+// PrecheckCreateSvi checks the tenant bridge and the Vrf device exist and the vlan device does not
+func (d *linuxDataplane) PrecheckCreateSvi(ctx context.Context, _ *pb.Svi, bridge *pb.LogicalBridge, vrf *pb.Vrf) error {
+	if err := d.s.checkLinks(ctx, tenantbridgeName); err != nil {
+		return err
+	}
+	if err := d.GetVrf(ctx, vrf); err != nil {
+		return err
+	}
+	return d.s.precheckLinksAbsent(ctx, fmt.Sprintf("vlan%d", bridge.Spec.VlanId))
+}
+
+// ResyncSvi recreates the vlan device when it is missing or forced, and always re-applies FRR
+func (d *linuxDataplane) ResyncSvi(ctx context.Context, obj *pb.Svi, bridge *pb.LogicalBridge, vrf *pb.Vrf, force bool) error {
+	in := &pb.CreateSviRequest{Svi: obj}
+	nLink, err := d.s.vrfNetlink(ctx, vrf.Name)
+	if err == nil {
+		err = checkLinksIn(ctx, nLink, d.s.sviLinks(obj)...)
+	}
+	if err != nil || force {
+		log.Printf("Recreating Svi %v", obj.Name)
+		// best effort removal of the device left over
+		if err := d.s.netlinkDeleteSvi(ctx, &pb.DeleteSviRequest{Name: obj.Name}, bridge, vrf); err != nil {
+			log.Printf("Failed to clean up Svi %v: %v", obj.Name, err)
+		}
+		if err := d.programmed(obj.Name, ConditionNetlinkProgrammed, d.s.netlinkCreateSvi(ctx, in, bridge, vrf)); err != nil {
+			return err
+		}
+	}
+	vlanName := fmt.Sprintf("vlan%d", bridge.Spec.VlanId)
+	err = d.s.frrCreateSviRequest(ctx, in, d.s.vrfKernelName(vrf.Name), vlanName)
+	if err == nil {
+		err = d.frrCreateSviGateway(ctx, obj, bridge, vrf)
+	}
+	return d.programmed(obj.Name, ConditionFrrProgrammed, err)
+}
+
+func (d *linuxDataplane) CreateVrfLiteHandoff(ctx context.Context, obj *VrfLiteHandoff, vrf *pb.Vrf) error {
+	in := &CreateVrfLiteHandoffRequest{VrfLiteHandoff: obj}
+	// configure netlink
+	if err := d.programmed(obj.Name, ConditionNetlinkProgrammed, d.s.netlinkCreateVrfLiteHandoff(ctx, in, vrf)); err != nil {
+		return err
+	}
+	// configure FRR
+	err := d.s.frrCreateVrfLiteHandoffRequest(ctx, in, d.s.vrfKernelName(vrf.Name))
+	return d.programmed(obj.Name, ConditionFrrProgrammed, err)
+}
+
+func (d *linuxDataplane) DeleteVrfLiteHandoff(ctx context.Context, obj *VrfLiteHandoff, vrf *pb.Vrf) error {
+	// delete from FRR
+	if err := d.s.frrDeleteVrfLiteHandoffRequest(ctx, obj, d.s.vrfKernelName(vrf.Name)); err != nil {
+		return err
+	}
+	// configure netlink
+	return d.s.netlinkDeleteVrfLiteHandoff(ctx, obj)
+}
+
+func (d *linuxDataplane) GetVrfLiteHandoff(ctx context.Context, obj *VrfLiteHandoff) error {
+	return d.s.checkLinks(ctx, d.s.handoffKernelName(obj))
+}
+
+func (d *linuxDataplane) CreateRoute(ctx context.Context, obj *Route, vrf *pb.Vrf) error {
+	// configure netlink
+	if err := d.programmed(obj.Name, ConditionNetlinkProgrammed, d.s.netlinkCreateRoute(ctx, obj, vrf)); err != nil {
+		return err
+	}
+	// configure FRR
+	err := d.s.frrCreateRouteRequest(ctx, obj, d.s.vrfKernelName(vrf.Name))
+	return d.programmed(obj.Name, ConditionFrrProgrammed, err)
+}
+
+func (d *linuxDataplane) DeleteRoute(ctx context.Context, obj *Route, vrf *pb.Vrf) error {
+	// delete from FRR
+	if err := d.s.frrDeleteRouteRequest(ctx, obj, d.s.vrfKernelName(vrf.Name)); err != nil {
+		return err
+	}
+	// configure netlink
+	return d.s.netlinkDeleteRoute(ctx, obj, vrf)
+}
+
+func (d *linuxDataplane) CreateRouteLeak(ctx context.Context, obj *RouteLeak, src *pb.Vrf, dst *pb.Vrf) error {
+	err := d.s.frrCreateRouteLeakRequest(ctx, obj, d.s.vrfKernelName(src.Name), d.s.vrfKernelName(dst.Name))
+	return d.programmed(obj.Name, ConditionFrrProgrammed, err)
+}
+
+func (d *linuxDataplane) DeleteRouteLeak(ctx context.Context, obj *RouteLeak) error {
+	return d.s.frrDeleteRouteLeakRequest(ctx, obj, d.s.vrfKernelName(obj.Spec.SourceVrf), d.s.vrfKernelName(obj.Spec.DestinationVrf))
+}
+
+func (d *linuxDataplane) CreateBgpPeer(ctx context.Context, obj *BgpPeer) error {
+	// configure netlink
+	if err := d.programmed(obj.Name, ConditionNetlinkProgrammed, d.s.netlinkCreateBgpPeer(ctx, obj)); err != nil {
+		return err
+	}
+	return d.programmed(obj.Name, ConditionFrrProgrammed, d.s.frrCreateBgpPeerRequest(ctx, obj))
+}
+
+func (d *linuxDataplane) DeleteBgpPeer(ctx context.Context, obj *BgpPeer) error {
+	return d.s.frrDeleteBgpPeerRequest(ctx, obj)
+}
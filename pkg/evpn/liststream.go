@@ -0,0 +1,286 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+
+	"go.einride.tech/aip/fieldbehavior"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// defaultStreamChunkSize is the number of objects per message of the streaming lists
+	defaultStreamChunkSize = 250
+	// maxStreamChunkSize bounds the size of the messages of the streaming lists
+	maxStreamChunkSize = 1000
+)
+
+// ListStreamServer is the service streaming all the LogicalBridges or BridgePorts in chunks,
+// for the controllers resyncing thousands of objects at once, without the bookkeeping of the
+// page tokens. The page_size of the requests is the number of objects per message, the
+// page_token has to be empty and the next_page_token of the responses always is
+// TODO: move to opi-api once the service is agreed upon
+type ListStreamServer interface {
+	ListLogicalBridgesStream(*pb.ListLogicalBridgesRequest, ListLogicalBridgesStreamServer) error
+	ListBridgePortsStream(*pb.ListBridgePortsRequest, ListBridgePortsStreamServer) error
+}
+
+// ListLogicalBridgesStreamServer sends the chunks of LogicalBridges
+type ListLogicalBridgesStreamServer interface {
+	Send(*pb.ListLogicalBridgesResponse) error
+	grpc.ServerStream
+}
+
+// ListBridgePortsStreamServer sends the chunks of BridgePorts
+type ListBridgePortsStreamServer interface {
+	Send(*pb.ListBridgePortsResponse) error
+	grpc.ServerStream
+}
+
+type listStreamServer[T any] struct {
+	grpc.ServerStream
+}
+
+func (x *listStreamServer[T]) Send(m *T) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// ListStreamServiceDesc is the grpc.ServiceDesc of the ListStreamServer
+var ListStreamServiceDesc = grpc.ServiceDesc{
+	ServiceName: "opi_evpn_bridge.v1alpha1.ListStreamService",
+	HandlerType: (*ListStreamServer)(nil),
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName: "ListLogicalBridgesStream",
+			Handler: func(srv interface{}, stream grpc.ServerStream) error {
+				in := &pb.ListLogicalBridgesRequest{}
+				if err := stream.RecvMsg(in); err != nil {
+					return err
+				}
+				return srv.(ListStreamServer).ListLogicalBridgesStream(in, &listStreamServer[pb.ListLogicalBridgesResponse]{stream})
+			},
+			ServerStreams: true,
+		},
+		{
+			StreamName: "ListBridgePortsStream",
+			Handler: func(srv interface{}, stream grpc.ServerStream) error {
+				in := &pb.ListBridgePortsRequest{}
+				if err := stream.RecvMsg(in); err != nil {
+					return err
+				}
+				return srv.(ListStreamServer).ListBridgePortsStream(in, &listStreamServer[pb.ListBridgePortsResponse]{stream})
+			},
+			ServerStreams: true,
+		},
+	},
+	Metadata: "pkg/evpn/liststream.go",
+}
+
+// RegisterListStreamServer registers the streaming lists on a gRPC server
+func RegisterListStreamServer(s grpc.ServiceRegistrar, srv ListStreamServer) {
+	s.RegisterService(&ListStreamServiceDesc, srv)
+}
+
+// ListStreamClient is the client of the ListStreamServer
+type ListStreamClient interface {
+	ListLogicalBridgesStream(ctx context.Context, in *pb.ListLogicalBridgesRequest, opts ...grpc.CallOption) (ListLogicalBridgesStreamClient, error)
+	ListBridgePortsStream(ctx context.Context, in *pb.ListBridgePortsRequest, opts ...grpc.CallOption) (ListBridgePortsStreamClient, error)
+}
+
+// ListLogicalBridgesStreamClient receives the chunks of LogicalBridges, until io.EOF
+type ListLogicalBridgesStreamClient interface {
+	Recv() (*pb.ListLogicalBridgesResponse, error)
+	grpc.ClientStream
+}
+
+// ListBridgePortsStreamClient receives the chunks of BridgePorts, until io.EOF
+type ListBridgePortsStreamClient interface {
+	Recv() (*pb.ListBridgePortsResponse, error)
+	grpc.ClientStream
+}
+
+type listStreamClient[T any] struct {
+	grpc.ClientStream
+}
+
+func (x *listStreamClient[T]) Recv() (*T, error) {
+	m := new(T)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+type listStreamServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+// NewListStreamClient creates the client of the streaming lists
+func NewListStreamClient(cc grpc.ClientConnInterface) ListStreamClient {
+	return &listStreamServiceClient{cc}
+}
+
+// openListStream sends the request of a streaming list
+func (c *listStreamServiceClient) openListStream(ctx context.Context, index int, in interface{}, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	desc := &ListStreamServiceDesc.Streams[index]
+	method := fmt.Sprintf("/%s/%s", ListStreamServiceDesc.ServiceName, desc.StreamName)
+	stream, err := c.cc.NewStream(ctx, desc, method, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if err := stream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := stream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return stream, nil
+}
+
+func (c *listStreamServiceClient) ListLogicalBridgesStream(ctx context.Context, in *pb.ListLogicalBridgesRequest, opts ...grpc.CallOption) (ListLogicalBridgesStreamClient, error) {
+	stream, err := c.openListStream(ctx, 0, in, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &listStreamClient[pb.ListLogicalBridgesResponse]{stream}, nil
+}
+
+func (c *listStreamServiceClient) ListBridgePortsStream(ctx context.Context, in *pb.ListBridgePortsRequest, opts ...grpc.CallOption) (ListBridgePortsStreamClient, error) {
+	stream, err := c.openListStream(ctx, 1, in, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &listStreamClient[pb.ListBridgePortsResponse]{stream}, nil
+}
+
+// build time check that struct implements interface
+var _ ListStreamServer = (*Server)(nil)
+
+// streamChunkSize returns the number of objects per message asked for
+func streamChunkSize(pageSize int32, pageToken string) (int, error) {
+	switch {
+	case pageToken != "":
+		return 0, status.Error(codes.InvalidArgument, "page tokens are not used by the streaming lists")
+	case pageSize < 0:
+		return 0, status.Error(codes.InvalidArgument, "negative PageSize is not allowed")
+	case pageSize == 0:
+		return defaultStreamChunkSize, nil
+	case pageSize > maxStreamChunkSize:
+		return maxStreamChunkSize, nil
+	}
+	return int(pageSize), nil
+}
+
+// streamChunks sends the objects by chunks of size, computing their status per chunk, the
+// objects degraded are reported in the trailer, after the etags and lifecycle states of the
+// objects, as the headers go with the first chunk
+func streamChunks[T any](ctx context.Context, objects []T, size int, trailer metadata.MD, send func(chunk []T, degraded map[string]error) error) error {
+	degraded := map[string]error{}
+	for start := 0; start < len(objects); start += size {
+		if err := ctx.Err(); err != nil {
+			return status.FromContextError(err).Err()
+		}
+		end := start + size
+		if end > len(objects) {
+			end = len(objects)
+		}
+		if err := send(objects[start:end], degraded); err != nil {
+			return err
+		}
+	}
+	trailer = metadata.Join(trailer, degradedMetadata(degraded))
+	if len(trailer) == 0 {
+		return nil
+	}
+	// fails when called out of a grpc server, e.g. in tests
+	if err := grpc.SetTrailer(ctx, trailer); err != nil {
+		log.Printf("Failed to send the trailer of the streaming list: %v", err)
+	}
+	return nil
+}
+
+// ListLogicalBridgesStream streams all the LogicalBridges, sorted by name as ListLogicalBridges
+func (s *Server) ListLogicalBridgesStream(in *pb.ListLogicalBridgesRequest, stream ListLogicalBridgesStreamServer) error {
+	ctx := stream.Context()
+	if err := fieldbehavior.ValidateRequiredFields(in); err != nil {
+		return err
+	}
+	size, err := streamChunkSize(in.PageSize, in.PageToken)
+	if err != nil {
+		return err
+	}
+	selector, err := labelSelectorFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	// the objects are copied under objectsMu, which is not held while the chunks are sent
+	s.objectsMu.RLock()
+	bridges, names := []*pb.LogicalBridge{}, []string{}
+	for name, bridge := range s.Bridges {
+		if inTenant(ctx, name) && s.matchesLabels(selector, name) {
+			bridges = append(bridges, protoClone(bridge))
+			names = append(names, name)
+		}
+	}
+	sortLogicalBridges(bridges)
+	sort.Strings(names)
+	md := metadata.Join(s.etagsMetadata(names), s.lifecyclesMetadata(names))
+	s.objectsMu.RUnlock()
+	return streamChunks(ctx, bridges, size, md, func(chunk []*pb.LogicalBridge, degraded map[string]error) error {
+		s.objectsMu.RLock()
+		for _, r := range chunk {
+			r.Status = &pb.LogicalBridgeStatus{OperStatus: s.logicalBridgeOperStatus(ctx, r, degraded)}
+		}
+		s.objectsMu.RUnlock()
+		return stream.Send(&pb.ListLogicalBridgesResponse{LogicalBridges: chunk})
+	})
+}
+
+// ListBridgePortsStream streams all the BridgePorts, sorted by name as ListBridgePorts
+func (s *Server) ListBridgePortsStream(in *pb.ListBridgePortsRequest, stream ListBridgePortsStreamServer) error {
+	ctx := stream.Context()
+	if err := fieldbehavior.ValidateRequiredFields(in); err != nil {
+		return err
+	}
+	size, err := streamChunkSize(in.PageSize, in.PageToken)
+	if err != nil {
+		return err
+	}
+	selector, err := labelSelectorFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	// the objects are copied under objectsMu, which is not held while the chunks are sent
+	s.objectsMu.RLock()
+	ports, names := []*pb.BridgePort{}, []string{}
+	for name, port := range s.Ports {
+		if inTenant(ctx, name) && s.matchesLabels(selector, name) {
+			ports = append(ports, protoClone(port))
+			names = append(names, name)
+		}
+	}
+	sortBridgePorts(ports)
+	sort.Strings(names)
+	md := metadata.Join(s.etagsMetadata(names), s.lifecyclesMetadata(names))
+	s.objectsMu.RUnlock()
+	return streamChunks(ctx, ports, size, md, func(chunk []*pb.BridgePort, degraded map[string]error) error {
+		s.objectsMu.RLock()
+		for _, r := range chunk {
+			r.Status = &pb.BridgePortStatus{OperStatus: s.bridgePortOperStatus(ctx, r, degraded)}
+		}
+		s.objectsMu.RUnlock()
+		return stream.Send(&pb.ListBridgePortsResponse{BridgePorts: chunk})
+	})
+}
@@ -0,0 +1,183 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/philippgille/gokv/gomap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+	pc "github.com/opiproject/opi-api/network/opinetcommon/v1alpha1/gen/go"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/fake"
+)
+
+func Test_ListLogicalBridgesStream(t *testing.T) {
+	ctx := context.Background()
+	opi := NewServerWithArgs(fake.NewNetlink(), fake.NewFrr(), gomap.NewStore(gomap.DefaultOptions))
+	conn, err := grpc.DialContext(ctx, "", grpc.WithTransportCredentials(insecure.NewCredentials()), grpc.WithContextDialer(dialer(opi)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	client := NewListStreamClient(conn)
+
+	receive := func(in *pb.ListLogicalBridgesRequest) ([][]string, metadata.MD, error) {
+		stream, err := client.ListLogicalBridgesStream(ctx, in)
+		if err != nil {
+			return nil, nil, err
+		}
+		chunks := [][]string{}
+		for {
+			response, err := stream.Recv()
+			if err == io.EOF {
+				return chunks, stream.Trailer(), nil
+			}
+			if err != nil {
+				return chunks, nil, err
+			}
+			if response.NextPageToken != "" {
+				t.Error("next page token: expected none, received", response.NextPageToken)
+			}
+			names := []string{}
+			for _, bridge := range response.LogicalBridges {
+				names = append(names, bridge.Name)
+			}
+			chunks = append(chunks, names)
+		}
+	}
+
+	// nothing to stream sends no message at all
+	chunks, _, err := receive(&pb.ListLogicalBridgesRequest{})
+	if err != nil || len(chunks) != 0 {
+		t.Error("empty: expected no chunks, received", chunks, err)
+	}
+
+	for _, vlan := range []uint32{50, 10, 40, 20, 30} {
+		_, err := opi.CreateLogicalBridge(ctx, &pb.CreateLogicalBridgeRequest{
+			LogicalBridgeId: fmt.Sprintf("vlan%d", vlan),
+			LogicalBridge: &pb.LogicalBridge{Spec: &pb.LogicalBridgeSpec{VlanId: vlan, Vni: proto.Uint32(vlan), VtepIpPrefix: &pc.IPPrefix{
+				Addr: &pc.IPAddress{Af: pc.IpAf_IP_AF_INET, V4OrV6: &pc.IPAddress_V4Addr{V4Addr: 0x0a000001}}, Len: 32,
+			}}},
+		})
+		if err != nil {
+			t.Fatal("CreateLogicalBridge: expected", nil, "received", err)
+		}
+	}
+	chunks, trailer, err := receive(&pb.ListLogicalBridgesRequest{PageSize: 2})
+	if err != nil {
+		t.Fatal("error: expected", nil, "received", err)
+	}
+	name := func(vlan int) string { return resourceIDToFullName("bridges", fmt.Sprintf("vlan%d", vlan)) }
+	expected := [][]string{{name(10), name(20)}, {name(30), name(40)}, {name(50)}}
+	if !reflect.DeepEqual(chunks, expected) {
+		t.Error("chunks: expected", expected, "received", chunks)
+	}
+	if degraded := trailer.Get(DegradedMetadataKey); len(degraded) != 0 {
+		t.Error("degraded: expected none, received", degraded)
+	}
+	if etags := trailer.Get(EtagMetadataKey); len(etags) != 5 || etags[0] != name(10)+": "+etag(opi.Generations[name(10)]) {
+		t.Error("etags: expected 5 sorted by name, received", etags)
+	}
+
+	// a LogicalBridge without its kernel device is reported in the trailer
+	opi.putLogicalBridge(&pb.LogicalBridge{Name: name(60), Spec: &pb.LogicalBridgeSpec{VlanId: 60, Vni: proto.Uint32(60)}})
+	chunks, trailer, err = receive(&pb.ListLogicalBridgesRequest{})
+	if err != nil || len(chunks) != 1 || len(chunks[0]) != 6 {
+		t.Error("default chunk: expected 6 LogicalBridges, received", chunks, err)
+	}
+	if degraded := trailer.Get(DegradedMetadataKey); len(degraded) != 1 {
+		t.Error("degraded: expected the LogicalBridge without its kernel device, received", degraded)
+	}
+
+	if _, _, err := receive(&pb.ListLogicalBridgesRequest{PageToken: "unknown-pagination-token"}); status.Code(err) != codes.InvalidArgument {
+		t.Error("page token: expected", codes.InvalidArgument, "received", err)
+	}
+}
+
+func Test_ListBridgePortsStream(t *testing.T) {
+	ctx := context.Background()
+	opi := NewServerWithArgs(fake.NewNetlink("eth1", "eth2", "eth3"), fake.NewFrr(), gomap.NewStore(gomap.DefaultOptions))
+	conn, err := grpc.DialContext(ctx, "", grpc.WithTransportCredentials(insecure.NewCredentials()), grpc.WithContextDialer(dialer(opi)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	for i := 3; i > 0; i-- {
+		_, err := opi.CreateBridgePort(ctx, &pb.CreateBridgePortRequest{
+			BridgePortId: fmt.Sprintf("eth%d", i),
+			BridgePort: &pb.BridgePort{Spec: &pb.BridgePortSpec{
+				MacAddress: []byte{0x02, 0x00, 0x00, 0x00, 0x00, byte(i)},
+				Ptype:      pb.BridgePortType_TRUNK,
+			}},
+		})
+		if err != nil {
+			t.Fatal("CreateBridgePort: expected", nil, "received", err)
+		}
+	}
+	stream, err := NewListStreamClient(conn).ListBridgePortsStream(ctx, &pb.ListBridgePortsRequest{PageSize: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sizes, names := []int{}, []string{}
+	for {
+		response, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal("error: expected", nil, "received", err)
+		}
+		sizes = append(sizes, len(response.BridgePorts))
+		for _, port := range response.BridgePorts {
+			names = append(names, port.Name)
+			if port.Status.GetOperStatus() != pb.BPOperStatus_BP_OPER_STATUS_UP {
+				t.Error("status: expected up, received", port.Status)
+			}
+		}
+	}
+	expected := []string{resourceIDToFullName("ports", "eth1"), resourceIDToFullName("ports", "eth2"), resourceIDToFullName("ports", "eth3")}
+	if !reflect.DeepEqual(sizes, []int{2, 1}) || !reflect.DeepEqual(names, expected) {
+		t.Error("chunks: expected", expected, "received", sizes, names)
+	}
+}
+
+func Test_StreamChunkSize(t *testing.T) {
+	tests := map[string]struct {
+		pageSize  int32
+		pageToken string
+		expected  int
+		errCode   codes.Code
+	}{
+		"default":   {pageSize: 0, expected: defaultStreamChunkSize},
+		"asked for": {pageSize: 10, expected: 10},
+		"capped":    {pageSize: maxStreamChunkSize + 1, expected: maxStreamChunkSize},
+		"negative":  {pageSize: -1, errCode: codes.InvalidArgument},
+		"token":     {pageSize: 10, pageToken: "token", errCode: codes.InvalidArgument},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			size, err := streamChunkSize(tt.pageSize, tt.pageToken)
+			if status.Code(err) != tt.errCode {
+				t.Error("error code: expected", tt.errCode, "received", err)
+			}
+			if size != tt.expected {
+				t.Error("size: expected", tt.expected, "received", size)
+			}
+		})
+	}
+}
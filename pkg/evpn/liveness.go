@@ -0,0 +1,20 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"fmt"
+)
+
+// CheckLiveness makes a netlink call as the programming calls do, for the systemd watchdog,
+// failing or hanging along with them when the kernel does not answer, e.g. when a stuck call
+// holds the rtnl lock
+func (s *Server) CheckLiveness(ctx context.Context) error {
+	if _, err := s.nLink.LinkByName(ctx, "lo"); err != nil {
+		return fmt.Errorf("netlink is not answering: %v", err)
+	}
+	return nil
+}
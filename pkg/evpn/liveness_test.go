@@ -0,0 +1,31 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/philippgille/gokv/gomap"
+	"github.com/stretchr/testify/mock"
+	"github.com/vishvananda/netlink"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils/mocks"
+)
+
+func Test_CheckLiveness(t *testing.T) {
+	mockNetlink := mocks.NewNetlink(t)
+	opi := NewServerWithArgs(mockNetlink, mocks.NewFrr(t), gomap.NewStore(gomap.DefaultOptions))
+
+	mockNetlink.EXPECT().LinkByName(mock.Anything, "lo").Return(&netlink.Device{}, nil).Once()
+	if err := opi.CheckLiveness(context.Background()); err != nil {
+		t.Error("error: expected", nil, "received", err)
+	}
+	mockNetlink.EXPECT().LinkByName(mock.Anything, "lo").Return(nil, errors.New("timeout")).Once()
+	if err := opi.CheckLiveness(context.Background()); err == nil {
+		t.Error("error: expected a failure")
+	}
+}
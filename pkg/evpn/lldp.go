@@ -0,0 +1,167 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils"
+)
+
+// PortNeighbor is the switch and the port an interface is cabled to, as advertised with LLDP
+type PortNeighbor struct {
+	// Interface is the kernel name of the interface the neighbor was seen on, e.g.: eth0
+	Interface string `json:"interface"`
+	// BridgePort is the name of the BridgePort of the interface, empty for the uplinks
+	BridgePort string `json:"bridgePort,omitempty"`
+	// ChassisID identifies the neighbor, usually its base MAC address
+	ChassisID string `json:"chassisId"`
+	// SystemName is the host name of the neighbor
+	SystemName string `json:"systemName,omitempty"`
+	// SystemDescription is the model and software version of the neighbor
+	SystemDescription string `json:"systemDescription,omitempty"`
+	// ManagementAddresses are the addresses the neighbor is managed through
+	ManagementAddresses []string `json:"managementAddresses,omitempty"`
+	// PortID identifies the port of the neighbor, usually its interface name
+	PortID string `json:"portId"`
+	// PortDescription is the description of the port of the neighbor
+	PortDescription string `json:"portDescription,omitempty"`
+	// Age is how long lldpd has known the neighbor, e.g.: 0 day, 00:12:34
+	Age string `json:"age,omitempty"`
+}
+
+// GetPortNeighborsRequest is the request to get the LLDP neighbors of the ports
+// TODO: move to opi-api once the message is agreed upon
+type GetPortNeighborsRequest struct {
+	// BridgePort is the name of a BridgePort to get the neighbor of, all the ports when empty
+	BridgePort string
+	// Interface is the kernel name of an uplink to get the neighbor of, instead of BridgePort
+	Interface string
+}
+
+// GetPortNeighborsResponse lists the LLDP neighbors, sorted by interface
+// TODO: move to opi-api once the message is agreed upon
+type GetPortNeighborsResponse struct {
+	Neighbors []PortNeighbor `json:"neighbors"`
+}
+
+// lldpValue is a value of "lldpcli -f json0", always wrapped in an array
+type lldpValue struct {
+	Value string `json:"value"`
+}
+
+// lldpInterface is the part of an interface of "lldpcli -f json0 show neighbors details" the
+// neighbors are made of
+type lldpInterface struct {
+	Name    string `json:"name"`
+	Age     string `json:"age"`
+	Chassis []struct {
+		ID     []lldpValue `json:"id"`
+		Name   []lldpValue `json:"name"`
+		Descr  []lldpValue `json:"descr"`
+		MgmtIP []lldpValue `json:"mgmt-ip"`
+	} `json:"chassis"`
+	Port []struct {
+		ID    []lldpValue `json:"id"`
+		Descr []lldpValue `json:"descr"`
+	} `json:"port"`
+}
+
+// GetPortNeighbors returns the switch and port seen with LLDP on each uplink and BridgePort,
+// to verify the cabling and the fabric attachment through the same API the bridge is
+// configured with. The uplinks are shared by all the tenants, the tenants only see the
+// neighbors of their BridgePorts
+func (s *Server) GetPortNeighbors(ctx context.Context, in *GetPortNeighborsRequest) (*GetPortNeighborsResponse, error) {
+	if in.BridgePort != "" && in.Interface != "" {
+		msg := "only one of bridge_port and interface can be set"
+		return nil, badRequest("interface", status.Error(codes.InvalidArgument, msg))
+	}
+	iface := in.Interface
+	if in.BridgePort != "" {
+		if _, ok := s.Ports[in.BridgePort]; !ok || !inTenant(ctx, in.BridgePort) {
+			err := status.Errorf(codes.NotFound, "unable to find key %s", in.BridgePort)
+			return nil, err
+		}
+		iface = s.portKernelName(in.BridgePort)
+	}
+	ports := map[string]string{}
+	for name := range s.Ports {
+		if inTenant(ctx, name) {
+			ports[s.portKernelName(name)] = name
+		}
+	}
+	data, err := s.lldp.LldpNeighbors(ctx)
+	if err != nil {
+		err = status.Errorf(codes.Unavailable, "unable to get LLDP neighbors from lldpd: %v", err)
+		return nil, err
+	}
+	neighbors, err := parseLldpNeighbors(data)
+	if err != nil {
+		err = status.Errorf(codes.Internal, "unable to parse LLDP neighbors from lldpd: %v", err)
+		return nil, err
+	}
+	tenant := utils.TenantFromContext(ctx) != ""
+	response := &GetPortNeighborsResponse{Neighbors: []PortNeighbor{}}
+	for _, neighbor := range neighbors {
+		if iface != "" && neighbor.Interface != iface {
+			continue
+		}
+		neighbor.BridgePort = ports[neighbor.Interface]
+		if tenant && neighbor.BridgePort == "" {
+			continue
+		}
+		response.Neighbors = append(response.Neighbors, neighbor)
+	}
+	return response, nil
+}
+
+// parseLldpNeighbors parses "lldpcli -f json0 show neighbors details", an interface seeing
+// several neighbors (e.g.: behind an unmanaged switch) is listed once per neighbor
+func parseLldpNeighbors(data string) ([]PortNeighbor, error) {
+	output := struct {
+		Lldp []struct {
+			Interface []lldpInterface `json:"interface"`
+		} `json:"lldp"`
+	}{}
+	if err := json.Unmarshal([]byte(data), &output); err != nil {
+		return nil, err
+	}
+	neighbors := []PortNeighbor{}
+	for _, lldp := range output.Lldp {
+		for _, iface := range lldp.Interface {
+			neighbor := PortNeighbor{Interface: iface.Name, Age: iface.Age}
+			if len(iface.Chassis) > 0 {
+				chassis := iface.Chassis[0]
+				neighbor.ChassisID = firstLldpValue(chassis.ID)
+				neighbor.SystemName = firstLldpValue(chassis.Name)
+				neighbor.SystemDescription = firstLldpValue(chassis.Descr)
+				for _, address := range chassis.MgmtIP {
+					neighbor.ManagementAddresses = append(neighbor.ManagementAddresses, address.Value)
+				}
+			}
+			if len(iface.Port) > 0 {
+				neighbor.PortID = firstLldpValue(iface.Port[0].ID)
+				neighbor.PortDescription = firstLldpValue(iface.Port[0].Descr)
+			}
+			neighbors = append(neighbors, neighbor)
+		}
+	}
+	sort.SliceStable(neighbors, func(i, j int) bool {
+		return neighbors[i].Interface < neighbors[j].Interface
+	})
+	return neighbors, nil
+}
+
+func firstLldpValue(values []lldpValue) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0].Value
+}
@@ -0,0 +1,140 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/philippgille/gokv/gomap"
+	"github.com/stretchr/testify/mock"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils/mocks"
+)
+
+const lldpNeighbors = `{"lldp":[{"interface":[
+{"name":"opi-port8","via":"LLDP","rid":"2","age":"0 day, 00:05:00",
+ "chassis":[{"id":[{"type":"mac","value":"aa:bb:cc:00:00:02"}],"name":[{"value":"host1"}]}],
+ "port":[{"id":[{"type":"mac","value":"aa:bb:cc:00:00:12"}],"descr":[{"value":"ens1f0"}]}]},
+{"name":"eth0","via":"LLDP","rid":"1","age":"0 day, 01:00:00",
+ "chassis":[{"id":[{"type":"mac","value":"aa:bb:cc:00:00:01"}],"name":[{"value":"leaf1"}],"descr":[{"value":"SONiC 202305"}],"mgmt-ip":[{"value":"10.0.0.1"},{"value":"fd00::1"}]}],
+ "port":[{"id":[{"type":"ifname","value":"Ethernet4"}],"descr":[{"value":"to dpu1"}]}]}
+]}]}`
+
+var (
+	testUplinkNeighbor = PortNeighbor{
+		Interface:           "eth0",
+		ChassisID:           "aa:bb:cc:00:00:01",
+		SystemName:          "leaf1",
+		SystemDescription:   "SONiC 202305",
+		ManagementAddresses: []string{"10.0.0.1", "fd00::1"},
+		PortID:              "Ethernet4",
+		PortDescription:     "to dpu1",
+		Age:                 "0 day, 01:00:00",
+	}
+	testBridgePortNeighbor = PortNeighbor{
+		Interface:       testBridgePortID,
+		BridgePort:      testBridgePortName,
+		ChassisID:       "aa:bb:cc:00:00:02",
+		SystemName:      "host1",
+		PortID:          "aa:bb:cc:00:00:12",
+		PortDescription: "ens1f0",
+		Age:             "0 day, 00:05:00",
+	}
+)
+
+func Test_GetPortNeighbors(t *testing.T) {
+	tests := map[string]struct {
+		in      *GetPortNeighborsRequest
+		lldp    bool
+		lldpErr error
+		out     []PortNeighbor
+		errCode codes.Code
+	}{
+		"all neighbors": {
+			in:   &GetPortNeighborsRequest{},
+			lldp: true,
+			out:  []PortNeighbor{testUplinkNeighbor, testBridgePortNeighbor},
+		},
+		"neighbor of a BridgePort": {
+			in:   &GetPortNeighborsRequest{BridgePort: testBridgePortName},
+			lldp: true,
+			out:  []PortNeighbor{testBridgePortNeighbor},
+		},
+		"neighbor of an uplink": {
+			in:   &GetPortNeighborsRequest{Interface: "eth0"},
+			lldp: true,
+			out:  []PortNeighbor{testUplinkNeighbor},
+		},
+		"no neighbor": {
+			in:   &GetPortNeighborsRequest{Interface: "eth1"},
+			lldp: true,
+			out:  []PortNeighbor{},
+		},
+		"unknown BridgePort": {
+			in:      &GetPortNeighborsRequest{BridgePort: resourceIDToFullName("ports", "unknown")},
+			errCode: codes.NotFound,
+		},
+		"BridgePort and interface": {
+			in:      &GetPortNeighborsRequest{BridgePort: testBridgePortName, Interface: "eth0"},
+			errCode: codes.InvalidArgument,
+		},
+		"lldpd failure": {
+			in:      &GetPortNeighborsRequest{},
+			lldp:    true,
+			lldpErr: errors.New("unable to connect to lldpd daemon"),
+			errCode: codes.Unavailable,
+		},
+	}
+	for testName, tt := range tests {
+		t.Run(testName, func(t *testing.T) {
+			mockLldp := mocks.NewLldp(t)
+			opi := NewServerWithArgs(mocks.NewNetlink(t), mocks.NewFrr(t), gomap.NewStore(gomap.DefaultOptions))
+			opi.lldp = mockLldp
+			opi.Ports[testBridgePortName] = &pb.BridgePort{Name: testBridgePortName, Spec: testBridgePort.Spec}
+			if tt.lldp {
+				mockLldp.EXPECT().LldpNeighbors(mock.Anything).Return(lldpNeighbors, tt.lldpErr).Once()
+			}
+			response, err := opi.GetPortNeighbors(context.Background(), tt.in)
+			if status.Code(err) != tt.errCode {
+				t.Error("error: expected", tt.errCode, "received", err)
+			}
+			if err != nil {
+				return
+			}
+			if !reflect.DeepEqual(response.Neighbors, tt.out) {
+				t.Error("neighbors: expected", tt.out, "received", response.Neighbors)
+			}
+		})
+	}
+}
+
+func Test_GetPortNeighborsInTenant(t *testing.T) {
+	mockLldp := mocks.NewLldp(t)
+	opi := NewServerWithArgs(mocks.NewNetlink(t), mocks.NewFrr(t), gomap.NewStore(gomap.DefaultOptions))
+	opi.lldp = mockLldp
+	opi.Ports[testBridgePortName] = &pb.BridgePort{Name: testBridgePortName, Spec: testBridgePort.Spec}
+
+	// the uplinks and the BridgePorts of the other tenants are not returned
+	mockLldp.EXPECT().LldpNeighbors(mock.Anything).Return(lldpNeighbors, nil).Once()
+	response, err := opi.GetPortNeighbors(tenantContext("acme"), &GetPortNeighborsRequest{})
+	if err != nil {
+		t.Fatal("error: expected", nil, "received", err)
+	}
+	if len(response.Neighbors) != 0 {
+		t.Error("neighbors: expected none, received", response.Neighbors)
+	}
+	_, err = opi.GetPortNeighbors(tenantContext("acme"), &GetPortNeighborsRequest{BridgePort: testBridgePortName})
+	if status.Code(err) != codes.NotFound {
+		t.Error("error: expected", codes.NotFound, "received", err)
+	}
+}
@@ -0,0 +1,148 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils"
+)
+
+// noMacLearningKey is the store key of the BridgePorts not learning MAC addresses
+const noMacLearningKey = "nomaclearning"
+
+// FlushBridgeMacsRequest is the request to forget the MAC addresses learned on a LogicalBridge
+// TODO: move to opi-api once the message is agreed upon
+type FlushBridgeMacsRequest struct {
+	// LogicalBridge is the name of the LogicalBridge to flush
+	LogicalBridge string `json:"logicalBridge"`
+	// BridgePort is the name of a BridgePort of the LogicalBridge to only flush the MAC
+	// addresses learned on, all of them when empty
+	BridgePort string `json:"bridgePort,omitempty"`
+}
+
+// FlushBridgeMacsResponse tells how many MAC addresses were flushed
+// TODO: move to opi-api once the message is agreed upon
+type FlushBridgeMacsResponse struct {
+	Flushed int32 `json:"flushed"`
+}
+
+// macLearningFor reports whether a new BridgePort learns MAC addresses, sent with the call, or
+// kept from a previous incarnation of the same BridgePort (e.g.: on replay)
+func (s *Server) macLearningFor(ctx context.Context, obj *pb.BridgePort) (bool, error) {
+	value, ok := utils.MetadataValue(ctx, utils.MacLearningMetadataKey)
+	if !ok {
+		return !s.NoMacLearning[obj.Name], nil
+	}
+	learning, err := strconv.ParseBool(value)
+	if err != nil {
+		msg := fmt.Sprintf("invalid MAC learning %q, expected true or false", value)
+		return false, badRequest(utils.MacLearningMetadataKey, status.Error(codes.InvalidArgument, msg))
+	}
+	return learning, nil
+}
+
+// MacLearning reports whether the BridgePort learns MAC addresses, for the dataplanes
+func (s *Server) MacLearning(name string) bool {
+	return !s.NoMacLearning[name]
+}
+
+func (s *Server) persistNoMacLearning() {
+	fields := make(map[string]interface{}, len(s.NoMacLearning))
+	for name := range s.NoMacLearning {
+		fields[name] = true
+	}
+	msg, err := structpb.NewStruct(fields)
+	if err == nil {
+		err = s.store.Set(noMacLearningKey, msg)
+	}
+	if err != nil {
+		fmt.Printf("Failed to persist %s: %v", noMacLearningKey, err)
+	}
+}
+
+// loadNoMacLearning restores the BridgePorts not learning, so they are replayed the same way
+func (s *Server) loadNoMacLearning() error {
+	msg := &structpb.Struct{}
+	found, err := s.store.Get(noMacLearningKey, msg)
+	if err != nil || !found {
+		return err
+	}
+	for name, value := range msg.Fields {
+		if value.GetBoolValue() {
+			s.NoMacLearning[name] = true
+		}
+	}
+	return nil
+}
+
+// releaseNoMacLearning forgets a deleted BridgePort
+func (s *Server) releaseNoMacLearning(name string) {
+	if s.NoMacLearning[name] {
+		delete(s.NoMacLearning, name)
+		s.persistNoMacLearning()
+	}
+}
+
+// portInBridge reports whether the BridgePort is a member of the LogicalBridge
+func portInBridge(port *pb.BridgePort, bridge string) bool {
+	for _, name := range port.Spec.LogicalBridges {
+		if name == bridge {
+			return true
+		}
+	}
+	return false
+}
+
+// ApplyMacAgeing sets the MacAgeing of the server into the dataplane, the dataplane default
+// is left when it is 0
+func (s *Server) ApplyMacAgeing(ctx context.Context) error {
+	if s.MacAgeing == 0 {
+		return nil
+	}
+	return s.dataplane.SetMacAgeing(ctx, s.MacAgeing)
+}
+
+// FlushBridgeMacs forgets the MAC addresses learned on a LogicalBridge, or on one of its
+// BridgePorts, e.g. after a host migration instead of waiting for them to age out. The remote
+// MAC addresses installed by EVPN are kept
+func (s *Server) FlushBridgeMacs(ctx context.Context, in *FlushBridgeMacsRequest) (*FlushBridgeMacsResponse, error) {
+	if in.LogicalBridge == "" {
+		return nil, missingField("logical_bridge")
+	}
+	bridge, ok := s.Bridges[in.LogicalBridge]
+	if !ok || !inTenant(ctx, in.LogicalBridge) {
+		err := status.Errorf(codes.NotFound, "unable to find key %s", in.LogicalBridge)
+		return nil, err
+	}
+	var port *pb.BridgePort
+	if in.BridgePort != "" {
+		port, ok = s.Ports[in.BridgePort]
+		if !ok || !inTenant(ctx, in.BridgePort) {
+			err := status.Errorf(codes.NotFound, "unable to find key %s", in.BridgePort)
+			return nil, err
+		}
+		if !portInBridge(port, in.LogicalBridge) {
+			err := status.Errorf(codes.FailedPrecondition, "port %s is not in %s", in.BridgePort, in.LogicalBridge)
+			return nil, err
+		}
+	}
+	if utils.IsValidateOnly(ctx) {
+		return &FlushBridgeMacsResponse{}, nil
+	}
+	flushed, err := s.dataplane.FlushBridgeMacs(ctx, bridge, port)
+	if err != nil {
+		return nil, err
+	}
+	return &FlushBridgeMacsResponse{Flushed: int32(flushed)}, nil
+}
@@ -0,0 +1,82 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// netlinkSetMacAgeing sets the ageing time of the tenant bridge, shared by all the LogicalBridges
+func (s *Server) netlinkSetMacAgeing(ctx context.Context, ageing time.Duration) error {
+	link, err := s.tenantBridge(ctx)
+	if err != nil {
+		return err
+	}
+	bridge, ok := link.(*netlink.Bridge)
+	if !ok {
+		err := status.Errorf(codes.FailedPrecondition, "%s is not a bridge", tenantbridgeName)
+		return err
+	}
+	// the kernel counts in hundredths of a second
+	centiseconds := uint32(ageing / (10 * time.Millisecond))
+	// Example: ip link set br-tenant type bridge ageing_time 30000
+	bridge.AgeingTime = &centiseconds
+	if err := s.nLink.LinkModify(ctx, bridge); err != nil {
+		fmt.Printf("Failed to set ageing time: %v", err)
+		return err
+	}
+	return nil
+}
+
+// netlinkFlushBridgeMacs deletes the fdb entries learned in the vlan of the LogicalBridge, the
+// static entries and the remote ones installed by FRR (extern_learn) are kept
+func (s *Server) netlinkFlushBridgeMacs(ctx context.Context, obj *pb.LogicalBridge, port *pb.BridgePort) (int, error) {
+	bridge, err := s.tenantBridge(ctx)
+	if err != nil {
+		return 0, err
+	}
+	linkIndex := 0
+	if port != nil {
+		iface, err := s.nLink.LinkByName(ctx, s.portKernelName(port.Name))
+		if err != nil {
+			err := status.Errorf(codes.NotFound, "unable to find key %s", s.portKernelName(port.Name))
+			return 0, err
+		}
+		linkIndex = iface.Attrs().Index
+	}
+	// Example: bridge fdb show br br-tenant vlan 10
+	neighs, err := s.nLink.NeighList(ctx, linkIndex, unix.AF_BRIDGE)
+	if err != nil {
+		fmt.Printf("Failed to list fdb entries: %v", err)
+		return 0, err
+	}
+	flushed := 0
+	for i := range neighs {
+		neigh := &neighs[i]
+		if neigh.MasterIndex != bridge.Attrs().Index || neigh.Vlan != int(obj.Spec.VlanId) {
+			continue
+		}
+		if neigh.State&(netlink.NUD_PERMANENT|netlink.NUD_NOARP) != 0 || neigh.Flags&netlink.NTF_EXT_LEARNED != 0 {
+			continue
+		}
+		// Example: bridge fdb del aa:bb:cc:00:00:41 dev eth2 master vlan 10
+		if err := s.nLink.NeighDel(ctx, neigh); err != nil {
+			fmt.Printf("Failed to delete fdb entry %v: %v", neigh.HardwareAddr, err)
+			return flushed, err
+		}
+		flushed++
+	}
+	return flushed, nil
+}
@@ -0,0 +1,146 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/philippgille/gokv/gomap"
+	"github.com/stretchr/testify/mock"
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils"
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils/mocks"
+)
+
+func Test_macLearningFor(t *testing.T) {
+	tests := map[string]struct {
+		md      metadata.MD
+		kept    bool
+		out     bool
+		errCode codes.Code
+	}{
+		"default": {
+			md:  metadata.MD{},
+			out: true,
+		},
+		"learning off": {
+			md:  metadata.Pairs(utils.MacLearningMetadataKey, "false"),
+			out: false,
+		},
+		"kept from a previous incarnation": {
+			md:   metadata.MD{},
+			kept: true,
+			out:  false,
+		},
+		"invalid value": {
+			md:      metadata.Pairs(utils.MacLearningMetadataKey, "sometimes"),
+			errCode: codes.InvalidArgument,
+		},
+	}
+	for testName, tt := range tests {
+		t.Run(testName, func(t *testing.T) {
+			opi := NewServerWithArgs(mocks.NewNetlink(t), mocks.NewFrr(t), gomap.NewStore(gomap.DefaultOptions))
+			if tt.kept {
+				opi.NoMacLearning[testBridgePortName] = true
+			}
+			ctx := metadata.NewIncomingContext(context.Background(), tt.md)
+			learning, err := opi.macLearningFor(ctx, &pb.BridgePort{Name: testBridgePortName})
+			if status.Code(err) != tt.errCode {
+				t.Error("error: expected", tt.errCode, "received", err)
+			}
+			if learning != tt.out {
+				t.Error("learning: expected", tt.out, "received", learning)
+			}
+		})
+	}
+}
+
+func Test_FlushBridgeMacs(t *testing.T) {
+	tests := map[string]struct {
+		in      *FlushBridgeMacsRequest
+		out     int32
+		errCode codes.Code
+		on      func(mockNetlink *mocks.Netlink)
+	}{
+		"missing LogicalBridge": {
+			in:      &FlushBridgeMacsRequest{},
+			errCode: codes.InvalidArgument,
+		},
+		"unknown LogicalBridge": {
+			in:      &FlushBridgeMacsRequest{LogicalBridge: resourceIDToFullName("bridges", "unknown")},
+			errCode: codes.NotFound,
+		},
+		"port of another LogicalBridge": {
+			in:      &FlushBridgeMacsRequest{LogicalBridge: testLogicalBridgeName, BridgePort: resourceIDToFullName("ports", "other")},
+			errCode: codes.FailedPrecondition,
+		},
+		"learned entries of the vlan": {
+			in:  &FlushBridgeMacsRequest{LogicalBridge: testLogicalBridgeName},
+			out: 1,
+			on: func(mockNetlink *mocks.Netlink) {
+				bridge := &netlink.Bridge{LinkAttrs: netlink.LinkAttrs{Name: tenantbridgeName, Index: 3}}
+				mac, _ := net.ParseMAC("aa:bb:cc:00:00:41")
+				learned := netlink.Neigh{LinkIndex: 5, MasterIndex: 3, Vlan: 22, HardwareAddr: mac, State: netlink.NUD_REACHABLE}
+				mockNetlink.EXPECT().LinkByName(mock.Anything, tenantbridgeName).Return(bridge, nil).Once()
+				mockNetlink.EXPECT().NeighList(mock.Anything, 0, unix.AF_BRIDGE).Return([]netlink.Neigh{
+					learned,
+					{LinkIndex: 5, MasterIndex: 3, Vlan: 22, HardwareAddr: mac, State: netlink.NUD_PERMANENT},
+					{LinkIndex: 6, MasterIndex: 3, Vlan: 22, HardwareAddr: mac, State: netlink.NUD_REACHABLE, Flags: netlink.NTF_EXT_LEARNED},
+					{LinkIndex: 5, MasterIndex: 3, Vlan: 23, HardwareAddr: mac, State: netlink.NUD_REACHABLE},
+				}, nil).Once()
+				mockNetlink.EXPECT().NeighDel(mock.Anything, &learned).Return(nil).Once()
+			},
+		},
+	}
+	for testName, tt := range tests {
+		t.Run(testName, func(t *testing.T) {
+			mockNetlink := mocks.NewNetlink(t)
+			opi := NewServerWithArgs(mockNetlink, mocks.NewFrr(t), gomap.NewStore(gomap.DefaultOptions))
+			opi.Bridges[testLogicalBridgeName] = protoClone(&testLogicalBridgeWithStatus)
+			other := resourceIDToFullName("ports", "other")
+			opi.Ports[other] = &pb.BridgePort{Name: other, Spec: &pb.BridgePortSpec{LogicalBridges: []string{resourceIDToFullName("bridges", "other")}}}
+			if tt.on != nil {
+				tt.on(mockNetlink)
+			}
+			response, err := opi.FlushBridgeMacs(context.Background(), tt.in)
+			if status.Code(err) != tt.errCode {
+				t.Error("error: expected", tt.errCode, "received", err)
+			}
+			if err == nil && response.Flushed != tt.out {
+				t.Error("flushed: expected", tt.out, "received", response.Flushed)
+			}
+		})
+	}
+}
+
+func Test_ApplyMacAgeing(t *testing.T) {
+	mockNetlink := mocks.NewNetlink(t)
+	opi := NewServerWithArgs(mockNetlink, mocks.NewFrr(t), gomap.NewStore(gomap.DefaultOptions))
+	// nothing to apply by default
+	if err := opi.ApplyMacAgeing(context.Background()); err != nil {
+		t.Error("error: expected", nil, "received", err)
+	}
+	opi.MacAgeing = 5 * time.Minute
+	bridge := &netlink.Bridge{LinkAttrs: netlink.LinkAttrs{Name: tenantbridgeName, Index: 3}}
+	mockNetlink.EXPECT().LinkByName(mock.Anything, tenantbridgeName).Return(bridge, nil).Once()
+	mockNetlink.EXPECT().LinkModify(mock.Anything, mock.MatchedBy(func(link netlink.Link) bool {
+		br, ok := link.(*netlink.Bridge)
+		return ok && br.AgeingTime != nil && *br.AgeingTime == 30000
+	})).Return(nil).Once()
+	if err := opi.ApplyMacAgeing(context.Background()); err != nil {
+		t.Error("error: expected", nil, "received", err)
+	}
+}
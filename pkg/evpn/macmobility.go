@@ -0,0 +1,225 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// macMobilityName is the name the FRR configuration of the duplicate address detection is
+// retried under, as the objects are
+const macMobilityName = "mac-mobility"
+
+// MacMobilityOptions are the dampening thresholds of the EVPN duplicate address detection
+// of FRR: a MAC address moving MaxMoves times within Time is a duplicate, frozen for
+// FreezeTime, or until cleared with FreezePermanent, the last location then being kept
+type MacMobilityOptions struct {
+	// MaxMoves is the number of moves making a MAC address a duplicate, 2-1000
+	MaxMoves int `json:"maxMoves"`
+	// Time is the window the moves are counted in, 2s-1800s
+	Time time.Duration `json:"time"`
+	// FreezeTime is how long a duplicate stays frozen, 30s-3600s, not frozen when 0
+	FreezeTime time.Duration `json:"freezeTime,omitempty"`
+	// FreezePermanent keeps the duplicates frozen until cleared in FRR
+	FreezePermanent bool `json:"freezePermanent,omitempty"`
+}
+
+// DefaultMacMobilityOptions are the defaults of FRR, 5 moves in 180 seconds, not frozen
+func DefaultMacMobilityOptions() MacMobilityOptions {
+	return MacMobilityOptions{MaxMoves: 5, Time: 180 * time.Second}
+}
+
+// Validate checks the thresholds are in the ranges of FRR
+func (o MacMobilityOptions) Validate() error {
+	switch {
+	case o.MaxMoves < 2 || o.MaxMoves > 1000:
+		return fmt.Errorf("invalid duplicate address detection max moves %d, expected 2-1000", o.MaxMoves)
+	case o.Time < 2*time.Second || o.Time > 1800*time.Second || o.Time%time.Second != 0:
+		return fmt.Errorf("invalid duplicate address detection time %v, expected 2s-1800s in seconds", o.Time)
+	case o.FreezeTime != 0 && (o.FreezeTime < 30*time.Second || o.FreezeTime > 3600*time.Second || o.FreezeTime%time.Second != 0):
+		return fmt.Errorf("invalid duplicate address detection freeze time %v, expected 30s-3600s in seconds", o.FreezeTime)
+	case o.FreezeTime != 0 && o.FreezePermanent:
+		return fmt.Errorf("invalid duplicate address detection freeze, either a freeze time or permanent")
+	}
+	return nil
+}
+
+// frozen tells whether the duplicates are frozen
+func (o MacMobilityOptions) frozen() bool {
+	return o.FreezeTime != 0 || o.FreezePermanent
+}
+
+// ApplyMacMobility configures the thresholds of the duplicate address detection in the
+// default BGP instance, the defaults of FRR being left untouched. The configuration is
+// retried in the background when FRR is not reachable yet
+func (s *Server) ApplyMacMobility(ctx context.Context) {
+	if s.MacMobility == DefaultMacMobilityOptions() {
+		return
+	}
+	apply := func(ctx context.Context) error {
+		return s.frrMacMobility(ctx)
+	}
+	if err := apply(ctx); err != nil {
+		log.Printf("Failed to configure the duplicate address detection in FRR, retrying in the background: %v", err)
+		s.frrRetries.Add(macMobilityName, apply)
+	}
+}
+
+func (s *Server) frrMacMobility(ctx context.Context) error {
+	freeze := "no dup-addr-detection freeze"
+	switch {
+	case s.MacMobility.FreezePermanent:
+		freeze = "dup-addr-detection freeze permanent"
+	case s.MacMobility.FreezeTime != 0:
+		freeze = fmt.Sprintf("dup-addr-detection freeze %d", int(s.MacMobility.FreezeTime.Seconds()))
+	}
+	data, err := s.frr.FrrBgpCmd(ctx, fmt.Sprintf(
+		`configure terminal
+		router bgp %d
+		address-family l2vpn evpn
+		dup-addr-detection max-moves %d time %d
+		%s
+		exit-address-family
+		exit`, s.Gateway.LocalAs, s.MacMobility.MaxMoves, int(s.MacMobility.Time.Seconds()), freeze))
+	fmt.Printf("FrrBgpCmd: %v:%v", data, err)
+	return err
+}
+
+// MacMobilityEvent is a MAC address of a LogicalBridge that moved between the BridgePorts and
+// the remote VTEPs, with its EVPN MAC mobility sequence number, or a duplicate one
+// TODO: move to opi-api once the message is agreed upon
+type MacMobilityEvent struct {
+	// LogicalBridge is the name of the LogicalBridge
+	LogicalBridge string `json:"logicalBridge"`
+	// Mac is the MAC address
+	Mac string `json:"mac"`
+	// Local tells whether the MAC address is currently learned locally
+	Local bool `json:"local"`
+	// Location is the interface it is learned on, or the remote VTEP it is behind
+	Location string `json:"location"`
+	// Sequence is the MAC mobility sequence number, incremented on every move
+	Sequence uint32 `json:"sequence"`
+	// DetectionCount is the number of moves within the detection window
+	DetectionCount uint32 `json:"detectionCount"`
+	// Duplicate tells whether the duplicate address detection flagged it
+	Duplicate bool `json:"duplicate"`
+	// Frozen tells whether it is a duplicate kept at its location
+	Frozen bool `json:"frozen"`
+}
+
+// ListMacMobilityEventsRequest is the request to list the moved and duplicate MAC addresses
+// TODO: move to opi-api once the message is agreed upon
+type ListMacMobilityEventsRequest struct {
+	// LogicalBridge is the name of a LogicalBridge to list the MAC addresses of, all of them when empty
+	LogicalBridge string
+	// DuplicatesOnly only lists the duplicate MAC addresses
+	DuplicatesOnly bool
+}
+
+// ListMacMobilityEventsResponse lists the MAC addresses, sorted by LogicalBridge then MAC address
+// TODO: move to opi-api once the message is agreed upon
+type ListMacMobilityEventsResponse struct {
+	Events []MacMobilityEvent `json:"events"`
+	// MacMobility are the thresholds of the duplicate address detection
+	MacMobility MacMobilityOptions `json:"macMobility"`
+}
+
+// evpnMac is an entry of "show evpn mac vni all json", keyed by vni then MAC address
+type evpnMac struct {
+	Type           string `json:"type"`
+	Intf           string `json:"intf"`
+	RemoteVtep     string `json:"remoteVtep"`
+	LocalSequence  uint32 `json:"localSequence"`
+	RemoteSequence uint32 `json:"remoteSequence"`
+	DetectionCount uint32 `json:"detectionCount"`
+	IsDuplicate    bool   `json:"isDuplicate"`
+}
+
+// ListMacMobilityEvents returns the MAC addresses of the LogicalBridges that moved at least once,
+// from the EVPN MAC table of zebra, so the churn and the duplicates are visible before they
+// are dampened. The tenants only see their LogicalBridges
+func (s *Server) ListMacMobilityEvents(ctx context.Context, in *ListMacMobilityEventsRequest) (*ListMacMobilityEventsResponse, error) {
+	if in.LogicalBridge != "" {
+		bridge, ok := s.Bridges[in.LogicalBridge]
+		if !ok || !inTenant(ctx, in.LogicalBridge) {
+			err := status.Errorf(codes.NotFound, "unable to find key %s", in.LogicalBridge)
+			return nil, err
+		}
+		if bridge.Spec.Vni == nil {
+			err := status.Errorf(codes.FailedPrecondition, "LogicalBridge %s has no vni, it has no EVPN MAC addresses", in.LogicalBridge)
+			return nil, err
+		}
+	}
+	bridges := map[uint32]string{}
+	for name, bridge := range s.Bridges {
+		if bridge.Spec.Vni == nil || !inTenant(ctx, name) || (in.LogicalBridge != "" && name != in.LogicalBridge) {
+			continue
+		}
+		bridges[*bridge.Spec.Vni] = name
+	}
+	data, err := s.frr.FrrZebraCmd(ctx, "show evpn mac vni all json")
+	if err != nil {
+		err = status.Errorf(codes.Unavailable, "unable to get EVPN MAC addresses from FRR: %v", err)
+		return nil, err
+	}
+	table := map[string]struct {
+		Macs map[string]evpnMac `json:"macs"`
+	}{}
+	if err := unmarshalFrrJSON(data, &table); err != nil {
+		err = status.Errorf(codes.Internal, "unable to parse EVPN MAC addresses from FRR: %v", err)
+		return nil, err
+	}
+	response := &ListMacMobilityEventsResponse{Events: []MacMobilityEvent{}, MacMobility: s.MacMobility}
+	for key, vni := range table {
+		value, err := strconv.ParseUint(key, 10, 32)
+		if err != nil {
+			continue
+		}
+		name, ok := bridges[uint32(value)]
+		if !ok {
+			continue
+		}
+		for mac, entry := range vni.Macs {
+			event := MacMobilityEvent{
+				LogicalBridge:  name,
+				Mac:            mac,
+				Local:          entry.Type == "local",
+				Location:       entry.Intf,
+				Sequence:       entry.LocalSequence,
+				DetectionCount: entry.DetectionCount,
+				Duplicate:      entry.IsDuplicate,
+				Frozen:         entry.IsDuplicate && s.MacMobility.frozen(),
+			}
+			if !event.Local {
+				event.Location = entry.RemoteVtep
+			}
+			if entry.RemoteSequence > event.Sequence {
+				event.Sequence = entry.RemoteSequence
+			}
+			if event.Sequence == 0 && !event.Duplicate {
+				continue
+			}
+			if in.DuplicatesOnly && !event.Duplicate {
+				continue
+			}
+			response.Events = append(response.Events, event)
+		}
+	}
+	sort.Slice(response.Events, func(i int, j int) bool {
+		if response.Events[i].LogicalBridge != response.Events[j].LogicalBridge {
+			return response.Events[i].LogicalBridge < response.Events[j].LogicalBridge
+		}
+		return response.Events[i].Mac < response.Events[j].Mac
+	})
+	return response, nil
+}
@@ -0,0 +1,183 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/philippgille/gokv/gomap"
+	"github.com/stretchr/testify/mock"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils/mocks"
+)
+
+const zebraEvpnMacs = `show evpn mac vni all json
+{
+"11":{
+  "numMacs":3,
+  "macs":{
+    "aa:bb:cc:dd:ee:01":{"type":"local","intf":"opi-port8","vlan":22,"localSequence":0,"remoteSequence":0,"detectionCount":0,"isDuplicate":false},
+    "aa:bb:cc:dd:ee:02":{"type":"remote","remoteVtep":"10.0.0.2","localSequence":1,"remoteSequence":3,"detectionCount":2,"isDuplicate":false},
+    "aa:bb:cc:dd:ee:03":{"type":"local","intf":"opi-port8","vlan":22,"localSequence":8,"remoteSequence":7,"detectionCount":5,"isDuplicate":true}
+  }
+},
+"12":{
+  "numMacs":1,
+  "macs":{
+    "aa:bb:cc:dd:ee:04":{"type":"remote","remoteVtep":"10.0.0.3","localSequence":0,"remoteSequence":2,"detectionCount":1,"isDuplicate":false}
+  }
+}
+}
+zebra# `
+
+var (
+	testMovedMac = MacMobilityEvent{
+		LogicalBridge:  testLogicalBridgeName,
+		Mac:            "aa:bb:cc:dd:ee:02",
+		Location:       "10.0.0.2",
+		Sequence:       3,
+		DetectionCount: 2,
+	}
+	testDuplicateMac = MacMobilityEvent{
+		LogicalBridge:  testLogicalBridgeName,
+		Mac:            "aa:bb:cc:dd:ee:03",
+		Local:          true,
+		Location:       "opi-port8",
+		Sequence:       8,
+		DetectionCount: 5,
+		Duplicate:      true,
+	}
+)
+
+func Test_ListMacMobilityEvents(t *testing.T) {
+	tests := map[string]struct {
+		in      *ListMacMobilityEventsRequest
+		frr     bool
+		frrErr  error
+		freeze  time.Duration
+		out     []MacMobilityEvent
+		errCode codes.Code
+	}{
+		"moved and duplicate MAC addresses": {
+			in:  &ListMacMobilityEventsRequest{},
+			frr: true,
+			out: []MacMobilityEvent{testMovedMac, testDuplicateMac},
+		},
+		"duplicate MAC addresses of a LogicalBridge": {
+			in:  &ListMacMobilityEventsRequest{LogicalBridge: testLogicalBridgeName, DuplicatesOnly: true},
+			frr: true,
+			out: []MacMobilityEvent{testDuplicateMac},
+		},
+		"frozen duplicate MAC addresses": {
+			in:     &ListMacMobilityEventsRequest{DuplicatesOnly: true},
+			frr:    true,
+			freeze: time.Minute,
+			out: []MacMobilityEvent{func() MacMobilityEvent {
+				frozen := testDuplicateMac
+				frozen.Frozen = true
+				return frozen
+			}()},
+		},
+		"unknown LogicalBridge": {
+			in:      &ListMacMobilityEventsRequest{LogicalBridge: resourceIDToFullName("bridges", "unknown")},
+			errCode: codes.NotFound,
+		},
+		"zebra failure": {
+			in:      &ListMacMobilityEventsRequest{},
+			frr:     true,
+			frrErr:  errors.New("connection refused"),
+			errCode: codes.Unavailable,
+		},
+	}
+	for testName, tt := range tests {
+		t.Run(testName, func(t *testing.T) {
+			mockFrr := mocks.NewFrr(t)
+			opi := NewServerWithArgs(mocks.NewNetlink(t), mockFrr, gomap.NewStore(gomap.DefaultOptions))
+			opi.Bridges[testLogicalBridgeName] = protoClone(&testLogicalBridge)
+			opi.MacMobility.FreezeTime = tt.freeze
+			if tt.frr {
+				mockFrr.EXPECT().FrrZebraCmd(mock.Anything, "show evpn mac vni all json").Return(zebraEvpnMacs, tt.frrErr).Once()
+			}
+			response, err := opi.ListMacMobilityEvents(context.Background(), tt.in)
+			if status.Code(err) != tt.errCode {
+				t.Error("error: expected", tt.errCode, "received", err)
+			}
+			if err != nil {
+				return
+			}
+			// the MAC addresses of the vnis of no LogicalBridge are skipped
+			if !reflect.DeepEqual(response.Events, tt.out) {
+				t.Error("events: expected", tt.out, "received", response.Events)
+			}
+		})
+	}
+}
+
+func Test_ListMacMobilityEventsInTenant(t *testing.T) {
+	opi := NewServerWithArgs(mocks.NewNetlink(t), mocks.NewFrr(t), gomap.NewStore(gomap.DefaultOptions))
+	opi.Bridges[testLogicalBridgeName] = protoClone(&testLogicalBridge)
+	_, err := opi.ListMacMobilityEvents(tenantContext("acme"), &ListMacMobilityEventsRequest{LogicalBridge: testLogicalBridgeName})
+	if status.Code(err) != codes.NotFound {
+		t.Error("error: expected", codes.NotFound, "received", err)
+	}
+	opi.Bridges[testLogicalBridgeName] = &pb.LogicalBridge{Name: testLogicalBridgeName, Spec: &pb.LogicalBridgeSpec{VlanId: 22}}
+	_, err = opi.ListMacMobilityEvents(context.Background(), &ListMacMobilityEventsRequest{LogicalBridge: testLogicalBridgeName})
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Error("error: expected", codes.FailedPrecondition, "received", err)
+	}
+}
+
+func Test_MacMobilityOptions(t *testing.T) {
+	tests := map[string]struct {
+		options MacMobilityOptions
+		valid   bool
+	}{
+		"defaults":                  {options: DefaultMacMobilityOptions(), valid: true},
+		"frozen":                    {options: MacMobilityOptions{MaxMoves: 3, Time: time.Minute, FreezeTime: time.Minute}, valid: true},
+		"frozen permanently":        {options: MacMobilityOptions{MaxMoves: 3, Time: time.Minute, FreezePermanent: true}, valid: true},
+		"too few moves":             {options: MacMobilityOptions{MaxMoves: 1, Time: time.Minute}},
+		"window too long":           {options: MacMobilityOptions{MaxMoves: 5, Time: time.Hour}},
+		"window not seconds":        {options: MacMobilityOptions{MaxMoves: 5, Time: 2500 * time.Millisecond}},
+		"freeze too short":          {options: MacMobilityOptions{MaxMoves: 5, Time: time.Minute, FreezeTime: time.Second}},
+		"freeze time and permanent": {options: MacMobilityOptions{MaxMoves: 5, Time: time.Minute, FreezeTime: time.Minute, FreezePermanent: true}},
+	}
+	for testName, tt := range tests {
+		t.Run(testName, func(t *testing.T) {
+			if err := tt.options.Validate(); (err == nil) != tt.valid {
+				t.Error("valid: expected", tt.valid, "received", err)
+			}
+		})
+	}
+}
+
+func Test_ApplyMacMobility(t *testing.T) {
+	mockFrr := mocks.NewFrr(t)
+	opi := NewServerWithArgs(mocks.NewNetlink(t), mockFrr, gomap.NewStore(gomap.DefaultOptions))
+
+	// the defaults of FRR are left untouched
+	opi.ApplyMacMobility(context.Background())
+
+	opi.MacMobility = MacMobilityOptions{MaxMoves: 3, Time: time.Minute, FreezePermanent: true}
+	mockFrr.EXPECT().FrrBgpCmd(mock.Anything, `configure terminal
+		router bgp 65000
+		address-family l2vpn evpn
+		dup-addr-detection max-moves 3 time 60
+		dup-addr-detection freeze permanent
+		exit-address-family
+		exit`).Return("", nil).Once()
+	opi.ApplyMacMobility(context.Background())
+	if retries := opi.GetFrrRetries(); len(retries) != 0 {
+		t.Error("retries: expected none, received", retries)
+	}
+}
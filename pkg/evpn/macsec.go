@@ -0,0 +1,251 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils"
+)
+
+// portMacsecKey is the store key of the MACsec secure channels of the BridgePorts
+const portMacsecKey = "portmacsec"
+
+// PortMacsec is the static MACsec secure channel of a BridgePort with the single peer of its link,
+// e.g. the top of rack switch of an uplink, the port is plugged into the bridge through a macsec
+// device encrypting and authenticating the frames
+type PortMacsec struct {
+	// Key is the hex encoded transmit SAK, 16 or 32 bytes for GCM-AES-128 or GCM-AES-256
+	Key string
+	// Peer is the MAC address of the peer, whose secure channel is identified by it and port 1
+	Peer string
+	// PeerKey is the hex encoded SAK of the peer, of the length of Key
+	PeerKey string
+	// Encrypt the frames, they are only authenticated when false
+	Encrypt bool
+}
+
+// cipher returns the cipher suite of the keys, in iproute2 syntax
+func (m PortMacsec) cipher() string {
+	if len(m.Key) == 64 {
+		return "gcm-aes-256"
+	}
+	return "gcm-aes-128"
+}
+
+// parsePortMacsec parses the key=HEX,peer=MAC,peer-key=HEX,encrypt=BOOL options of a secure channel
+func parsePortMacsec(options string) (PortMacsec, error) {
+	macsec := PortMacsec{Encrypt: true}
+	for _, option := range strings.Split(options, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(option), "=")
+		if !ok {
+			return macsec, fmt.Errorf("%q has to be in key=value format", option)
+		}
+		switch key {
+		case "key", "peer-key":
+			raw, err := hex.DecodeString(value)
+			if err != nil || (len(raw) != 16 && len(raw) != 32) {
+				return macsec, fmt.Errorf("%s has to be 16 or 32 hex encoded bytes", key)
+			}
+			if key == "key" {
+				macsec.Key = strings.ToLower(value)
+			} else {
+				macsec.PeerKey = strings.ToLower(value)
+			}
+		case "peer":
+			mac, err := net.ParseMAC(value)
+			if err != nil || len(mac) != 6 {
+				return macsec, fmt.Errorf("peer %q has to be a MAC address", value)
+			}
+			macsec.Peer = mac.String()
+		case "encrypt":
+			encrypt, err := strconv.ParseBool(value)
+			if err != nil {
+				return macsec, fmt.Errorf("encrypt %q has to be true or false", value)
+			}
+			macsec.Encrypt = encrypt
+		default:
+			return macsec, fmt.Errorf("unknown option %q, expected key, peer, peer-key or encrypt", key)
+		}
+	}
+	switch {
+	case macsec.Key == "" || macsec.Peer == "" || macsec.PeerKey == "":
+		return macsec, fmt.Errorf("key, peer and peer-key are required")
+	case len(macsec.Key) != len(macsec.PeerKey):
+		return macsec, fmt.Errorf("key and peer-key have to be of the same cipher suite")
+	case macsec.Key == macsec.PeerKey:
+		// reusing a GCM key in both directions would reuse its nonces
+		return macsec, fmt.Errorf("key and peer-key have to be different")
+	}
+	return macsec, nil
+}
+
+// macsecFor returns the MACsec secure channel of a new BridgePort, sent with the call, or kept
+// from a previous incarnation of the same BridgePort (e.g.: on replay), nil without MACsec
+func (s *Server) macsecFor(ctx context.Context, obj *pb.BridgePort) (*PortMacsec, error) {
+	value, ok := utils.MetadataValue(ctx, utils.MacsecMetadataKey)
+	if !ok || value == "" {
+		if macsec, ok := s.PortMacsec[obj.Name]; ok {
+			return &macsec, nil
+		}
+		return nil, nil
+	}
+	macsec, err := parsePortMacsec(value)
+	if err != nil {
+		msg := fmt.Sprintf("invalid MACsec %v", err)
+		return nil, badRequest(utils.MacsecMetadataKey, status.Error(codes.InvalidArgument, msg))
+	}
+	return &macsec, nil
+}
+
+// IsMacsecPort reports whether the BridgePort is secured with MACsec, for the dataplanes
+func (s *Server) IsMacsecPort(obj *pb.BridgePort) bool {
+	_, ok := s.PortMacsec[obj.Name]
+	return ok
+}
+
+// macsecKernelName returns the name of the macsec device of a BridgePort
+func (s *Server) macsecKernelName(obj *pb.BridgePort) string {
+	wanted := s.portKernelName(obj.Name) + "-sec"
+	if len(wanted) <= maxKernelNameLength {
+		return wanted
+	}
+	return hashedKernelName(obj.Name+"/macsec", wanted, 0)
+}
+
+// macsecScript returns the ip batch script creating the macsec device of a BridgePort on top of
+// its interface, with the transmit association and the receive channel of the peer
+func (s *Server) macsecScript(obj *pb.BridgePort, macsec PortMacsec) string {
+	dev, encrypt := s.macsecKernelName(obj), "off"
+	if macsec.Encrypt {
+		encrypt = "on"
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "link add link %s name %s type macsec port 1 cipher %s encrypt %s\n",
+		s.portKernelName(obj.Name), dev, macsec.cipher(), encrypt)
+	fmt.Fprintf(&b, "macsec add %s tx sa 0 pn 1 on key 00 %s\n", dev, macsec.Key)
+	fmt.Fprintf(&b, "macsec add %s rx port 1 address %s\n", dev, macsec.Peer)
+	fmt.Fprintf(&b, "macsec add %s rx port 1 address %s sa 0 pn 1 on key 01 %s\n", dev, macsec.Peer, macsec.PeerKey)
+	return b.String()
+}
+
+// MacsecCounters are the counters of the secure channels of a BridgePort
+type MacsecCounters struct {
+	// BridgePort is the name of the BridgePort
+	BridgePort string `json:"bridgePort"`
+	utils.MacsecStats
+}
+
+// GetMacsecCountersRequest is the request to read the MACsec counters of the BridgePorts
+// TODO: move to the status of the BridgePorts in opi-api once the message is agreed upon
+type GetMacsecCountersRequest struct {
+	// BridgePort is the name of a BridgePort to read the counters of, all the MACsec ones when empty
+	BridgePort string
+}
+
+// GetMacsecCountersResponse lists the counters per BridgePort, sorted by name
+// TODO: move to the status of the BridgePorts in opi-api once the message is agreed upon
+type GetMacsecCountersResponse struct {
+	Ports []MacsecCounters `json:"ports"`
+}
+
+// GetMacsecCounters reads the protected and encrypted frames sent, and the valid and invalid
+// ones received, on the secure channels of the BridgePorts
+func (s *Server) GetMacsecCounters(ctx context.Context, in *GetMacsecCountersRequest) (*GetMacsecCountersResponse, error) {
+	// the kernel names are read under the lock, the counters without it
+	s.objectsMu.RLock()
+	if in.BridgePort != "" {
+		obj, ok := s.Ports[in.BridgePort]
+		if !ok || !inTenant(ctx, in.BridgePort) {
+			s.objectsMu.RUnlock()
+			err := status.Errorf(codes.NotFound, "unable to find key %s", in.BridgePort)
+			return nil, err
+		}
+		if !s.IsMacsecPort(obj) {
+			s.objectsMu.RUnlock()
+			err := status.Errorf(codes.FailedPrecondition, "BridgePort %s is not secured with MACsec", in.BridgePort)
+			return nil, err
+		}
+	}
+	kernelNames := map[string]string{}
+	for name := range s.PortMacsec {
+		obj, ok := s.Ports[name]
+		if !ok || !inTenant(ctx, name) || (in.BridgePort != "" && name != in.BridgePort) {
+			continue
+		}
+		kernelNames[name] = s.macsecKernelName(obj)
+	}
+	s.objectsMu.RUnlock()
+	response := &GetMacsecCountersResponse{Ports: []MacsecCounters{}}
+	for name, kernelName := range kernelNames {
+		stats, err := s.macsec.MacsecStats(ctx, kernelName)
+		if err != nil {
+			return nil, err
+		}
+		response.Ports = append(response.Ports, MacsecCounters{BridgePort: name, MacsecStats: stats})
+	}
+	sort.Slice(response.Ports, func(i int, j int) bool {
+		return response.Ports[i].BridgePort < response.Ports[j].BridgePort
+	})
+	return response, nil
+}
+
+func (s *Server) persistPortMacsec() {
+	fields := make(map[string]interface{}, len(s.PortMacsec))
+	for name, macsec := range s.PortMacsec {
+		fields[name] = map[string]interface{}{
+			"key":     macsec.Key,
+			"peer":    macsec.Peer,
+			"peerKey": macsec.PeerKey,
+			"encrypt": macsec.Encrypt,
+		}
+	}
+	msg, err := structpb.NewStruct(fields)
+	if err == nil {
+		err = s.store.Set(portMacsecKey, msg)
+	}
+	if err != nil {
+		fmt.Printf("Failed to persist %s: %v", portMacsecKey, err)
+	}
+}
+
+// loadPortMacsec restores the secure channels, so replayed BridgePorts stay secured
+func (s *Server) loadPortMacsec() error {
+	msg := &structpb.Struct{}
+	found, err := s.store.Get(portMacsecKey, msg)
+	if err != nil || !found {
+		return err
+	}
+	for name, value := range msg.Fields {
+		fields := value.GetStructValue().GetFields()
+		s.PortMacsec[name] = PortMacsec{
+			Key:     fields["key"].GetStringValue(),
+			Peer:    fields["peer"].GetStringValue(),
+			PeerKey: fields["peerKey"].GetStringValue(),
+			Encrypt: fields["encrypt"].GetBoolValue(),
+		}
+	}
+	return nil
+}
+
+// releasePortMacsec forgets the secure channel of a deleted BridgePort
+func (s *Server) releasePortMacsec(name string) {
+	if _, ok := s.PortMacsec[name]; ok {
+		delete(s.PortMacsec, name)
+		s.persistPortMacsec()
+	}
+}
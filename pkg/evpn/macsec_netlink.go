@@ -0,0 +1,60 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/vishvananda/netlink"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// netlinkPortMacsec returns the macsec device of the port, created with its secure channels when missing
+func (s *Server) netlinkPortMacsec(ctx context.Context, obj *pb.BridgePort, iface netlink.Link, macsec PortMacsec) (netlink.Link, error) {
+	dev := s.macsecKernelName(obj)
+	if macsecdev, err := s.nLink.LinkByName(ctx, dev); err == nil {
+		return macsecdev, nil
+	}
+	// Example: ip link set eth2 up
+	if err := s.nLink.LinkSetUp(ctx, iface); err != nil {
+		fmt.Printf("Failed to up iface link: %v", err)
+		return nil, err
+	}
+	// Example: ip link add link eth2 name eth2-sec type macsec port 1 cipher gcm-aes-128 encrypt on
+	log.Printf("Creating MACsec %v", dev)
+	if err := s.macsec.MacsecApply(ctx, s.macsecScript(obj, macsec)); err != nil {
+		fmt.Printf("Failed to create macsec link: %v", err)
+		return nil, err
+	}
+	macsecdev, err := s.nLink.LinkByName(ctx, dev)
+	if err != nil {
+		err := status.Errorf(codes.NotFound, "unable to find key %s", dev)
+		return nil, err
+	}
+	return macsecdev, nil
+}
+
+// netlinkDeletePortMacsec deletes the macsec device of the port, with its secure channels
+func (s *Server) netlinkDeletePortMacsec(ctx context.Context, obj *pb.BridgePort) error {
+	dev := s.macsecKernelName(obj)
+	macsecdev, err := s.nLink.LinkByName(ctx, dev)
+	if err != nil {
+		err := status.Errorf(codes.NotFound, "unable to find key %s", dev)
+		return err
+	}
+	// Example: ip link del eth2-sec
+	log.Printf("Deleting MACsec %v", macsecdev)
+	if err := s.nLink.LinkDel(ctx, macsecdev); err != nil {
+		fmt.Printf("Failed to delete link: %v", err)
+		return err
+	}
+	return nil
+}
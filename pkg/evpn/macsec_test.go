@@ -0,0 +1,170 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/philippgille/gokv/gomap"
+	"github.com/stretchr/testify/mock"
+	"github.com/vishvananda/netlink"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils"
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils/mocks"
+)
+
+const (
+	testMacsecKey     = "000102030405060708090a0b0c0d0e0f"
+	testMacsecPeerKey = "101112131415161718191a1b1c1d1e1f"
+)
+
+func Test_parsePortMacsec(t *testing.T) {
+	tests := map[string]struct {
+		in    string
+		out   PortMacsec
+		valid bool
+	}{
+		"gcm-aes-128": {
+			in:    "key=" + testMacsecKey + ",peer=AA:BB:CC:00:00:01,peer-key=" + testMacsecPeerKey,
+			out:   PortMacsec{Key: testMacsecKey, Peer: "aa:bb:cc:00:00:01", PeerKey: testMacsecPeerKey, Encrypt: true},
+			valid: true,
+		},
+		"integrity only": {
+			in:    "key=" + testMacsecKey + ", peer=aa:bb:cc:00:00:01, peer-key=" + testMacsecPeerKey + ", encrypt=false",
+			out:   PortMacsec{Key: testMacsecKey, Peer: "aa:bb:cc:00:00:01", PeerKey: testMacsecPeerKey},
+			valid: true,
+		},
+		"no peer":         {in: "key=" + testMacsecKey + ",peer-key=" + testMacsecPeerKey, valid: false},
+		"same keys":       {in: "key=" + testMacsecKey + ",peer=aa:bb:cc:00:00:01,peer-key=" + testMacsecKey, valid: false},
+		"short key":       {in: "key=0001,peer=aa:bb:cc:00:00:01,peer-key=" + testMacsecPeerKey, valid: false},
+		"mixed suites":    {in: "key=" + testMacsecKey + testMacsecKey + ",peer=aa:bb:cc:00:00:01,peer-key=" + testMacsecPeerKey, valid: false},
+		"invalid peer":    {in: "key=" + testMacsecKey + ",peer=eth2,peer-key=" + testMacsecPeerKey, valid: false},
+		"invalid encrypt": {in: "key=" + testMacsecKey + ",peer=aa:bb:cc:00:00:01,peer-key=" + testMacsecPeerKey + ",encrypt=yes", valid: false},
+		"unknown option":  {in: "ckn=01,key=" + testMacsecKey + ",peer=aa:bb:cc:00:00:01,peer-key=" + testMacsecPeerKey, valid: false},
+		"not key=value":   {in: "mka", valid: false},
+		"empty":           {in: "", valid: false},
+	}
+	for testName, tt := range tests {
+		t.Run(testName, func(t *testing.T) {
+			macsec, err := parsePortMacsec(tt.in)
+			if (err == nil) != tt.valid {
+				t.Error("valid: expected", tt.valid, "received", err)
+			}
+			if err == nil && macsec != tt.out {
+				t.Error("macsec: expected", tt.out, "received", macsec)
+			}
+		})
+	}
+}
+
+func Test_macsecFor(t *testing.T) {
+	stored := PortMacsec{Key: testMacsecKey, Peer: "aa:bb:cc:00:00:01", PeerKey: testMacsecPeerKey}
+	tests := map[string]struct {
+		md      metadata.MD
+		stored  bool
+		out     *PortMacsec
+		errCode codes.Code
+	}{
+		"none": {
+			md: metadata.MD{},
+		},
+		"kept on replay": {
+			md:     metadata.MD{},
+			stored: true,
+			out:    &stored,
+		},
+		"static keys": {
+			md:  metadata.Pairs(utils.MacsecMetadataKey, "key="+testMacsecKey+",peer=aa:bb:cc:00:00:01,peer-key="+testMacsecPeerKey),
+			out: &PortMacsec{Key: testMacsecKey, Peer: "aa:bb:cc:00:00:01", PeerKey: testMacsecPeerKey, Encrypt: true},
+		},
+		"invalid": {
+			md:      metadata.Pairs(utils.MacsecMetadataKey, "key="+testMacsecKey),
+			errCode: codes.InvalidArgument,
+		},
+	}
+	for testName, tt := range tests {
+		t.Run(testName, func(t *testing.T) {
+			opi := NewServerWithArgs(mocks.NewNetlink(t), mocks.NewFrr(t), gomap.NewStore(gomap.DefaultOptions))
+			if tt.stored {
+				opi.PortMacsec[testBridgePortName] = stored
+			}
+			ctx := metadata.NewIncomingContext(context.Background(), tt.md)
+			obj := &pb.BridgePort{Name: testBridgePortName, Spec: testBridgePort.Spec}
+			macsec, err := opi.macsecFor(ctx, obj)
+			if status.Code(err) != tt.errCode {
+				t.Error("error: expected", tt.errCode, "received", err)
+			}
+			if !reflect.DeepEqual(macsec, tt.out) {
+				t.Error("macsec: expected", tt.out, "received", macsec)
+			}
+		})
+	}
+}
+
+func Test_MacsecBridgePort(t *testing.T) {
+	mockNetlink := mocks.NewNetlink(t)
+	mockMacsec := mocks.NewMacsec(t)
+	opi := NewServerWithArgs(mockNetlink, mocks.NewFrr(t), gomap.NewStore(gomap.DefaultOptions))
+	opi.macsec = mockMacsec
+	opi.PortMacsec[testBridgePortName] = PortMacsec{Key: testMacsecKey, Peer: "aa:bb:cc:00:00:01", PeerKey: testMacsecPeerKey, Encrypt: true}
+	obj := &pb.BridgePort{Name: testBridgePortName, Spec: &pb.BridgePortSpec{Ptype: pb.BridgePortType_TRUNK}}
+	if !opi.IsMacsecPort(obj) {
+		t.Error("macsec: expected", true, "received", false)
+	}
+
+	// the macsec device, not the port, is plugged into the bridge
+	script := "link add link opi-port8 name opi-port8-sec type macsec port 1 cipher gcm-aes-128 encrypt on\n" +
+		"macsec add opi-port8-sec tx sa 0 pn 1 on key 00 " + testMacsecKey + "\n" +
+		"macsec add opi-port8-sec rx port 1 address aa:bb:cc:00:00:01\n" +
+		"macsec add opi-port8-sec rx port 1 address aa:bb:cc:00:00:01 sa 0 pn 1 on key 01 " + testMacsecPeerKey + "\n"
+	bridge := &netlink.Bridge{LinkAttrs: netlink.LinkAttrs{Name: tenantbridgeName}}
+	iface := &netlink.Dummy{LinkAttrs: netlink.LinkAttrs{Name: testBridgePortID}}
+	macsecdev := &netlink.Dummy{LinkAttrs: netlink.LinkAttrs{Name: "opi-port8-sec"}}
+	mockNetlink.EXPECT().LinkByName(mock.Anything, tenantbridgeName).Return(bridge, nil).Once()
+	mockNetlink.EXPECT().LinkByName(mock.Anything, testBridgePortID).Return(iface, nil).Once()
+	mockNetlink.EXPECT().LinkByName(mock.Anything, "opi-port8-sec").Return(nil, netlink.LinkNotFoundError{}).Once()
+	mockNetlink.EXPECT().LinkSetUp(mock.Anything, iface).Return(nil).Once()
+	mockMacsec.EXPECT().MacsecApply(mock.Anything, script).Return(nil).Once()
+	mockNetlink.EXPECT().LinkByName(mock.Anything, "opi-port8-sec").Return(macsecdev, nil).Once()
+	mockNetlink.EXPECT().LinkSetMaster(mock.Anything, macsecdev, bridge).Return(nil).Once()
+	mockNetlink.EXPECT().LinkSetUp(mock.Anything, macsecdev).Return(nil).Once()
+	if err := opi.netlinkCreateBridgePort(context.Background(), obj); err != nil {
+		t.Error("error: expected", nil, "received", err)
+	}
+
+	// the counters are read from the macsec device
+	opi.Ports[testBridgePortName] = obj
+	stats := utils.MacsecStats{OutPktsProtected: 3, OutPktsEncrypted: 3, InPktsOK: 5, InPktsInvalid: 1}
+	mockMacsec.EXPECT().MacsecStats(mock.Anything, "opi-port8-sec").Return(stats, nil).Once()
+	response, err := opi.GetMacsecCounters(context.Background(), &GetMacsecCountersRequest{BridgePort: testBridgePortName})
+	if err != nil {
+		t.Fatal("error: expected", nil, "received", err)
+	}
+	expected := []MacsecCounters{{BridgePort: testBridgePortName, MacsecStats: stats}}
+	if !reflect.DeepEqual(response.Ports, expected) {
+		t.Error("counters: expected", expected, "received", response.Ports)
+	}
+	if _, err := opi.GetMacsecCounters(context.Background(), &GetMacsecCountersRequest{BridgePort: "unknown"}); status.Code(err) != codes.NotFound {
+		t.Error("error: expected", codes.NotFound, "received", err)
+	}
+
+	// the keys are kept for the replay
+	opi.persistPortMacsec()
+	restored := NewServerWithArgs(mocks.NewNetlink(t), mocks.NewFrr(t), opi.store)
+	if err := restored.loadPortMacsec(); err != nil {
+		t.Fatal("error: expected", nil, "received", err)
+	}
+	if !reflect.DeepEqual(restored.PortMacsec, opi.PortMacsec) {
+		t.Error("macsec: expected", opi.PortMacsec, "received", restored.PortMacsec)
+	}
+}
@@ -0,0 +1,374 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils"
+)
+
+const (
+	monitorRetryInterval = 5 * time.Second
+	// monitorRefreshDelay batches the notifications of a burst, e.g. the routes of a flapping
+	// peer, into a single refresh of the status of the objects
+	monitorRefreshDelay = 100 * time.Millisecond
+)
+
+// LiveStatus is the state of an object as last notified by the kernel
+type LiveStatus struct {
+	OperUp bool `json:"operUp"`
+	// Reason tells why the object is down
+	Reason string `json:"reason,omitempty"`
+	// LearnedMacs is the number of dynamic fdb entries of a LogicalBridge vlan or a BridgePort
+	LearnedMacs int `json:"learnedMacs,omitempty"`
+	// Routes is the number of routes in the routing table of a Vrf
+	Routes int `json:"routes,omitempty"`
+	code   codes.Code
+}
+
+// err returns why the object is down, nil when it is up
+func (l LiveStatus) err() error {
+	if l.OperUp {
+		return nil
+	}
+	return status.Error(l.code, l.Reason)
+}
+
+type monitoredLink struct {
+	index int
+	up    bool
+}
+
+type fdbEntry struct {
+	linkIndex int
+	vlan      int
+	mac       string
+}
+
+type routeEntry struct {
+	table  int
+	family int
+	dst    string
+}
+
+// statusMonitor keeps the kernel links, learned fdb entries and routes seen in the netlink
+// notifications, and the status of the objects last computed from them
+type statusMonitor struct {
+	mu       sync.Mutex
+	synced   bool
+	links    map[string]monitoredLink
+	fdb      map[fdbEntry]bool
+	routes   map[routeEntry]bool
+	statuses map[string]LiveStatus
+}
+
+// monitorSubscription holds the update channels of one subscription to the kernel notifications
+type monitorSubscription struct {
+	links  chan netlink.LinkUpdate
+	neighs chan netlink.NeighUpdate
+	routes chan netlink.RouteUpdate
+	cancel context.CancelFunc
+}
+
+// StartStatusMonitor subscribes to the kernel link, neighbor and route notifications and keeps
+// the status of the objects up to date from them until ctx is done. Get and List then report the
+// oper status from it instead of looking the devices up, and every status change is sent to the
+// watchers as a MODIFIED event. The subscriptions are retried when they fail, Get and List going
+// back to looking the devices up in the meantime
+func (s *Server) StartStatusMonitor(ctx context.Context) error {
+	m := &statusMonitor{statuses: map[string]LiveStatus{}}
+	sub, err := s.subscribeMonitor(ctx, m)
+	if err != nil {
+		return err
+	}
+	s.monitor = m
+	go func() {
+		for {
+			s.watchMonitor(ctx, m, sub)
+			for sub = nil; sub == nil; {
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(monitorRetryInterval):
+				}
+				if sub, err = s.subscribeMonitor(ctx, m); err != nil {
+					log.Printf("Failed to subscribe to kernel notifications: %v", err)
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+// subscribeMonitor subscribes to the notifications then lists the current links, so no link
+// change is missed in between, the existing fdb entries and routes come with their subscriptions
+func (s *Server) subscribeMonitor(ctx context.Context, m *statusMonitor) (*monitorSubscription, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	sub := &monitorSubscription{
+		links:  make(chan netlink.LinkUpdate, watchBufferSize),
+		neighs: make(chan netlink.NeighUpdate, watchBufferSize),
+		routes: make(chan netlink.RouteUpdate, watchBufferSize),
+		cancel: cancel,
+	}
+	if err := s.nLink.LinkSubscribe(ctx, sub.links, false); err != nil {
+		cancel()
+		return nil, err
+	}
+	if err := s.nLink.NeighSubscribe(ctx, sub.neighs, true); err != nil {
+		sub.stop()
+		return nil, err
+	}
+	if err := s.nLink.RouteSubscribe(ctx, sub.routes, true); err != nil {
+		sub.stop()
+		return nil, err
+	}
+	links, err := s.nLink.LinkList(ctx)
+	if err != nil {
+		sub.stop()
+		return nil, err
+	}
+	m.mu.Lock()
+	m.links = map[string]monitoredLink{}
+	m.fdb = map[fdbEntry]bool{}
+	m.routes = map[routeEntry]bool{}
+	for _, link := range links {
+		m.links[link.Attrs().Name] = monitoredLink{index: link.Attrs().Index, up: linkIsUp(link.Attrs())}
+	}
+	m.synced = true
+	m.mu.Unlock()
+	s.refreshLiveStatus(m)
+	return sub, nil
+}
+
+// stop cancels the subscriptions and drains their channels, the netlink goroutines
+// only exit once they are not blocked sending to them
+func (sub *monitorSubscription) stop() {
+	sub.cancel()
+	go func() {
+		for range sub.links {
+		}
+	}()
+	go func() {
+		for range sub.neighs {
+		}
+	}()
+	go func() {
+		for range sub.routes {
+		}
+	}()
+}
+
+// watchMonitor applies the updates until ctx is done or one of the subscriptions fails, the
+// status of the objects is refreshed monitorRefreshDelay after the first update of a burst
+func (s *Server) watchMonitor(ctx context.Context, m *statusMonitor, sub *monitorSubscription) {
+	defer sub.stop()
+	var refresh <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-refresh:
+			refresh = nil
+			s.refreshLiveStatus(m)
+			continue
+		case update, ok := <-sub.links:
+			if !m.applyUpdate(ok, func() { m.applyLink(update) }) {
+				return
+			}
+		case update, ok := <-sub.neighs:
+			if !m.applyUpdate(ok, func() { m.applyNeigh(update) }) {
+				return
+			}
+		case update, ok := <-sub.routes:
+			if !m.applyUpdate(ok, func() { m.applyRoute(update) }) {
+				return
+			}
+		}
+		if refresh == nil {
+			refresh = time.After(monitorRefreshDelay)
+		}
+	}
+}
+
+// applyUpdate applies an update received from a subscription, it returns false when the
+// subscription was closed instead
+func (m *statusMonitor) applyUpdate(received bool, apply func()) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !received {
+		log.Printf("Kernel notifications stopped, resubscribing in %v", monitorRetryInterval)
+		m.synced = false
+		return false
+	}
+	apply()
+	return true
+}
+
+func (m *statusMonitor) applyLink(update netlink.LinkUpdate) {
+	attrs := update.Link.Attrs()
+	if update.Header.Type == unix.RTM_DELLINK {
+		delete(m.links, attrs.Name)
+		return
+	}
+	m.links[attrs.Name] = monitoredLink{index: attrs.Index, up: linkIsUp(attrs)}
+}
+
+// applyNeigh only keeps the fdb entries learned by the bridges
+func (m *statusMonitor) applyNeigh(update netlink.NeighUpdate) {
+	if update.Family != unix.AF_BRIDGE {
+		return
+	}
+	key := fdbEntry{linkIndex: update.LinkIndex, vlan: update.Vlan, mac: update.HardwareAddr.String()}
+	if update.Type == unix.RTM_DELNEIGH || update.State&netlink.NUD_PERMANENT != 0 {
+		delete(m.fdb, key)
+		return
+	}
+	m.fdb[key] = true
+}
+
+func (m *statusMonitor) applyRoute(update netlink.RouteUpdate) {
+	key := routeEntry{table: update.Table, family: update.Family, dst: fmt.Sprint(update.Dst)}
+	if update.Type == unix.RTM_DELROUTE {
+		delete(m.routes, key)
+		return
+	}
+	m.routes[key] = true
+}
+
+// refreshLiveStatus recomputes the status of every object, sending the changes to the
+// watchers. It reads the objects with objectsMu held, as the calls do, then takes m.mu
+func (s *Server) refreshLiveStatus(m *statusMonitor) {
+	s.objectsMu.RLock()
+	defer s.objectsMu.RUnlock()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	statuses := map[string]LiveStatus{}
+	for name, obj := range s.Vrfs {
+		statuses[name] = s.liveVrfStatus(m, obj)
+	}
+	for name, obj := range s.Bridges {
+		statuses[name] = s.liveLogicalBridgeStatus(m, obj)
+	}
+	for name, obj := range s.Ports {
+		statuses[name] = s.liveBridgePortStatus(m, obj)
+	}
+	for name, obj := range s.Svis {
+		statuses[name] = s.liveSviStatus(m, obj)
+	}
+	for _, name := range sortedKeys(statuses) {
+		s.conditions.set(name, ConditionLinkUp, statuses[name].err())
+		// the objects created since the last refresh already had their ADDED event
+		if old, ok := m.statuses[name]; ok && old != statuses[name] {
+			s.events.Publish(utils.WatchEvent{Type: utils.WatchModified, Name: name})
+		}
+	}
+	m.statuses = statuses
+}
+
+// linksStatus is down when any of the interfaces is missing or down, as checkLinksUp
+func (m *statusMonitor) linksStatus(names ...string) LiveStatus {
+	var problems []string
+	code := codes.Unavailable
+	for _, name := range names {
+		link, ok := m.links[name]
+		if !ok {
+			code = codes.NotFound
+			problems = append(problems, fmt.Sprintf("unable to find key %s", name))
+			continue
+		}
+		if !link.up {
+			problems = append(problems, fmt.Sprintf("interface %s is down", name))
+		}
+	}
+	if len(problems) == 0 {
+		return LiveStatus{OperUp: true}
+	}
+	return LiveStatus{Reason: strings.Join(problems, ", "), code: code}
+}
+
+func (s *Server) liveVrfStatus(m *statusMonitor, obj *pb.Vrf) LiveStatus {
+	live := m.linksStatus(s.vrfLinks(obj)...)
+	if obj.Status == nil {
+		return live
+	}
+	for route := range m.routes {
+		if route.table == int(obj.Status.RoutingTable) {
+			live.Routes++
+		}
+	}
+	return live
+}
+
+func (s *Server) liveLogicalBridgeStatus(m *statusMonitor, obj *pb.LogicalBridge) LiveStatus {
+	live := m.linksStatus(s.logicalBridgeLinks(obj)...)
+	for entry := range m.fdb {
+		if entry.vlan == int(obj.Spec.VlanId) {
+			live.LearnedMacs++
+		}
+	}
+	return live
+}
+
+func (s *Server) liveBridgePortStatus(m *statusMonitor, obj *pb.BridgePort) LiveStatus {
+	names := s.bridgePortLinks(obj)
+	live := m.linksStatus(names...)
+	link, ok := m.links[names[0]]
+	if !ok {
+		return live
+	}
+	for entry := range m.fdb {
+		if entry.linkIndex == link.index {
+			live.LearnedMacs++
+		}
+	}
+	return live
+}
+
+func (s *Server) liveSviStatus(m *statusMonitor, obj *pb.Svi) LiveStatus {
+	return m.linksStatus(s.sviLinks(obj)...)
+}
+
+// checkLive returns why the object is down from the status monitor, or from the
+// dataplane check when the monitor is not running
+func checkLive[T any](ctx context.Context, s *Server, obj T, live func(*statusMonitor, T) LiveStatus, check func(context.Context, T) error) error {
+	if m := s.monitor; m != nil {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		if m.synced {
+			return live(m, obj).err()
+		}
+	}
+	return check(ctx, obj)
+}
+
+// GetLiveStatuses returns the status of every object as last computed by the status monitor,
+// empty when it is not running
+func (s *Server) GetLiveStatuses(_ context.Context) map[string]LiveStatus {
+	statuses := map[string]LiveStatus{}
+	m := s.monitor
+	if m == nil {
+		return statuses
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for name, live := range m.statuses {
+		statuses[name] = live
+	}
+	return statuses
+}
@@ -0,0 +1,122 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/philippgille/gokv/gomap"
+	"github.com/stretchr/testify/mock"
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+
+	"google.golang.org/protobuf/proto"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+	pc "github.com/opiproject/opi-api/network/opinetcommon/v1alpha1/gen/go"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/fake"
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils"
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils/mocks"
+)
+
+func Test_StartStatusMonitor(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	mockNetlink := mocks.NewNetlink(t)
+	mockFrr := mocks.NewFrr(t)
+	opi := NewServerWithArgs(mockNetlink, mockFrr, gomap.NewStore(gomap.DefaultOptions))
+
+	name := resourceIDToFullName("bridges", "blue")
+	opi.Bridges[name] = &pb.LogicalBridge{Name: name, Spec: &pb.LogicalBridgeSpec{VlanId: 10, Vni: proto.Uint32(10)}}
+
+	var links chan<- netlink.LinkUpdate
+	var neighs chan<- netlink.NeighUpdate
+	mockNetlink.EXPECT().LinkSubscribe(mock.Anything, mock.Anything, false).RunAndReturn(
+		func(_ context.Context, ch chan<- netlink.LinkUpdate, _ bool) error {
+			links = ch
+			return nil
+		}).Once()
+	mockNetlink.EXPECT().NeighSubscribe(mock.Anything, mock.Anything, true).RunAndReturn(
+		func(_ context.Context, ch chan<- netlink.NeighUpdate, _ bool) error {
+			neighs = ch
+			return nil
+		}).Once()
+	mockNetlink.EXPECT().RouteSubscribe(mock.Anything, mock.Anything, true).Return(nil).Once()
+	vni10 := &netlink.Vxlan{LinkAttrs: netlink.LinkAttrs{Name: "vni10", Index: 7, OperState: netlink.OperUp}}
+	mockNetlink.EXPECT().LinkList(mock.Anything).Return([]netlink.Link{vni10}, nil).Once()
+
+	if err := opi.StartStatusMonitor(ctx); err != nil {
+		t.Fatal("error: expected", nil, "received", err)
+	}
+	w := opi.Watch()
+	defer opi.Unwatch(w)
+
+	mac, _ := net.ParseMAC("00:11:22:33:44:55")
+	neighs <- netlink.NeighUpdate{Type: unix.RTM_NEWNEIGH, Neigh: netlink.Neigh{Family: unix.AF_BRIDGE, LinkIndex: 7, Vlan: 10, HardwareAddr: mac}}
+	expectModified(ctx, t, w, name)
+	if live := opi.GetLiveStatuses(ctx)[name]; !live.OperUp || live.LearnedMacs != 1 {
+		t.Error("live status: expected up with 1 mac, received", live)
+	}
+
+	down := &netlink.Vxlan{LinkAttrs: netlink.LinkAttrs{Name: "vni10", Index: 7, OperState: netlink.OperDown}}
+	links <- netlink.LinkUpdate{Header: unix.NlMsghdr{Type: unix.RTM_NEWLINK}, Link: down}
+	expectModified(ctx, t, w, name)
+
+	// the oper status comes from the monitor, without looking the device up
+	opi.LiveRead = true
+	response, err := opi.GetLogicalBridge(ctx, &pb.GetLogicalBridgeRequest{Name: name})
+	if err != nil {
+		t.Fatal("error: expected", nil, "received", err)
+	}
+	if response.Status.OperStatus != pb.LBOperStatus_LB_OPER_STATUS_DOWN {
+		t.Error("oper status: expected", pb.LBOperStatus_LB_OPER_STATUS_DOWN, "received", response.Status.OperStatus)
+	}
+}
+
+func Test_StatusMonitorConcurrentCalls(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	opi := NewServerWithArgs(fake.NewNetlink(), fake.NewFrr(), gomap.NewStore(gomap.DefaultOptions))
+	if err := opi.StartStatusMonitor(ctx); err != nil {
+		t.Fatal("error: expected", nil, "received", err)
+	}
+
+	// the refreshes of the notifications of the devices created run along the next creates
+	const count = 10
+	for i := 0; i < count; i++ {
+		vlan := uint32(10 + i)
+		spec := &pb.LogicalBridgeSpec{VlanId: vlan, Vni: proto.Uint32(vlan), VtepIpPrefix: &pc.IPPrefix{Addr: &pc.IPAddress{Af: pc.IpAf_IP_AF_INET, V4OrV6: &pc.IPAddress_V4Addr{V4Addr: 0x0a000001}}, Len: 32}}
+		request := &pb.CreateLogicalBridgeRequest{LogicalBridgeId: fmt.Sprintf("vlan%d", vlan), LogicalBridge: &pb.LogicalBridge{Spec: spec}}
+		if _, err := opi.CreateLogicalBridge(ctx, request); err != nil {
+			t.Fatal("error: expected", nil, "received", err)
+		}
+		time.Sleep(monitorRefreshDelay / 2)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for len(opi.GetLiveStatuses(ctx)) != count {
+		if time.Now().After(deadline) {
+			t.Fatal("live statuses: expected", count, "received", opi.GetLiveStatuses(ctx))
+		}
+		time.Sleep(monitorRefreshDelay)
+	}
+}
+
+func expectModified(ctx context.Context, t *testing.T, w *utils.Watcher, name string) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	ev, err := w.Next(ctx)
+	if err != nil {
+		t.Fatal("error: expected", nil, "received", err)
+	}
+	if ev.Type != utils.WatchModified || ev.Name != name {
+		t.Error("event: expected", utils.WatchModified, name, "received", ev)
+	}
+}
@@ -0,0 +1,113 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils"
+)
+
+// multicastGroupsKey is the store key of the multicast groups of the LogicalBridges
+const multicastGroupsKey = "multicastgroups"
+
+// PimOptions is the PIM configuration FRR needs for the LogicalBridges flooding their
+// BUM traffic to a multicast group instead of using BGP-EVPN ingress replication
+type PimOptions struct {
+	// Interfaces run PIM, the VTEP source (lo) and the underlay uplinks
+	Interfaces []string
+	// RP is the rendezvous point of the groups, none when empty (e.g.: for SSM groups)
+	RP string
+}
+
+// DefaultPimOptions only runs PIM on the loopback the VTEP addresses are on
+func DefaultPimOptions() PimOptions {
+	return PimOptions{Interfaces: []string{"lo"}}
+}
+
+// multicastGroupFor returns the multicast group a new LogicalBridge floods to, sent with the call,
+// or kept from a previous incarnation of the same LogicalBridge (e.g.: on replay), empty for
+// ingress replication
+func (s *Server) multicastGroupFor(ctx context.Context, obj *pb.LogicalBridge) (string, error) {
+	group, ok := utils.MetadataValue(ctx, utils.MulticastGroupMetadataKey)
+	if !ok {
+		return s.MulticastGroups[obj.Name], nil
+	}
+	if group == "" {
+		return "", nil
+	}
+	ip := net.ParseIP(group).To4()
+	if ip == nil || !ip.IsMulticast() || ip.IsLinkLocalMulticast() {
+		msg := fmt.Sprintf("invalid multicast group %s, expected an IPv4 multicast address outside of 224.0.0.0/24", group)
+		return "", badRequest(utils.MulticastGroupMetadataKey, status.Error(codes.InvalidArgument, msg))
+	}
+	if obj.Spec.Vni == nil {
+		msg := "a multicast group requires a vni"
+		return "", badRequest(utils.MulticastGroupMetadataKey, status.Error(codes.InvalidArgument, msg))
+	}
+	return ip.String(), nil
+}
+
+// multicastGroup returns the multicast group of a LogicalBridge, nil for ingress replication
+func (s *Server) multicastGroup(name string) net.IP {
+	group, ok := s.MulticastGroups[name]
+	if !ok {
+		return nil
+	}
+	return net.ParseIP(group).To4()
+}
+
+// groupInUse reports whether another LogicalBridge floods to the group
+func (s *Server) groupInUse(name string, group string) bool {
+	for other, g := range s.MulticastGroups {
+		if other != name && g == group {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) persistMulticastGroups() {
+	fields := make(map[string]interface{}, len(s.MulticastGroups))
+	for name, group := range s.MulticastGroups {
+		fields[name] = group
+	}
+	msg, err := structpb.NewStruct(fields)
+	if err == nil {
+		err = s.store.Set(multicastGroupsKey, msg)
+	}
+	if err != nil {
+		fmt.Printf("Failed to persist %s: %v", multicastGroupsKey, err)
+	}
+}
+
+// loadMulticastGroups restores the groups, so replayed LogicalBridges keep flooding to them
+func (s *Server) loadMulticastGroups() error {
+	msg := &structpb.Struct{}
+	found, err := s.store.Get(multicastGroupsKey, msg)
+	if err != nil || !found {
+		return err
+	}
+	for name, value := range msg.Fields {
+		s.MulticastGroups[name] = value.GetStringValue()
+	}
+	return nil
+}
+
+// releaseMulticastGroup forgets the group of a deleted LogicalBridge
+func (s *Server) releaseMulticastGroup(name string) {
+	if _, ok := s.MulticastGroups[name]; ok {
+		delete(s.MulticastGroups, name)
+		s.persistMulticastGroups()
+	}
+}
@@ -0,0 +1,100 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/philippgille/gokv/gomap"
+	"github.com/stretchr/testify/mock"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils"
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils/mocks"
+)
+
+func Test_multicastGroupFor(t *testing.T) {
+	tests := map[string]struct {
+		group   string
+		vni     *uint32
+		out     string
+		errCode codes.Code
+	}{
+		"ingress replication": {
+			group: "",
+			vni:   proto.Uint32(10),
+			out:   "",
+		},
+		"valid group": {
+			group: "239.1.1.10",
+			vni:   proto.Uint32(10),
+			out:   "239.1.1.10",
+		},
+		"unicast address": {
+			group:   "10.0.0.1",
+			vni:     proto.Uint32(10),
+			errCode: codes.InvalidArgument,
+		},
+		"link-local group": {
+			group:   "224.0.0.5",
+			vni:     proto.Uint32(10),
+			errCode: codes.InvalidArgument,
+		},
+		"no vni": {
+			group:   "239.1.1.10",
+			errCode: codes.InvalidArgument,
+		},
+	}
+	for testName, tt := range tests {
+		t.Run(testName, func(t *testing.T) {
+			opi := NewServerWithArgs(mocks.NewNetlink(t), mocks.NewFrr(t), gomap.NewStore(gomap.DefaultOptions))
+			ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(utils.MulticastGroupMetadataKey, tt.group))
+			obj := &pb.LogicalBridge{Name: testLogicalBridgeName, Spec: &pb.LogicalBridgeSpec{VlanId: 10, Vni: tt.vni}}
+			group, err := opi.multicastGroupFor(ctx, obj)
+			if status.Code(err) != tt.errCode {
+				t.Error("error: expected", tt.errCode, "received", err)
+			}
+			if group != tt.out {
+				t.Error("group: expected", tt.out, "received", group)
+			}
+		})
+	}
+}
+
+func Test_frrMulticastGroup(t *testing.T) {
+	mockFrr := mocks.NewFrr(t)
+	opi := NewServerWithArgs(mocks.NewNetlink(t), mockFrr, gomap.NewStore(gomap.DefaultOptions))
+	opi.Pim = PimOptions{Interfaces: []string{"lo", "eth0"}, RP: "10.0.0.100"}
+	other := resourceIDToFullName("bridges", "other")
+	opi.MulticastGroups[testLogicalBridgeName] = "239.1.1.10"
+	opi.MulticastGroups[other] = "239.1.1.10"
+	obj := &pb.LogicalBridge{Name: testLogicalBridgeName, Spec: &pb.LogicalBridgeSpec{VlanId: 10, Vni: proto.Uint32(10)}}
+
+	mockFrr.EXPECT().FrrPimCmd(mock.Anything, mock.MatchedBy(func(command string) bool {
+		return strings.Contains(command, "interface eth0\nip pim") && strings.Contains(command, "ip pim rp 10.0.0.100 239.1.1.10/32")
+	})).Return("", nil).Once()
+	if err := opi.frrCreateMulticastGroup(context.Background(), obj); err != nil {
+		t.Error("error: expected", nil, "received", err)
+	}
+	// the rendezvous point stays while another LogicalBridge uses the group
+	if err := opi.frrDeleteMulticastGroup(context.Background(), obj); err != nil {
+		t.Error("error: expected", nil, "received", err)
+	}
+	delete(opi.MulticastGroups, other)
+	mockFrr.EXPECT().FrrPimCmd(mock.Anything, mock.MatchedBy(func(command string) bool {
+		return strings.Contains(command, "no ip pim rp 10.0.0.100 239.1.1.10/32")
+	})).Return("", nil).Once()
+	if err := opi.frrDeleteMulticastGroup(context.Background(), obj); err != nil {
+		t.Error("error: expected", nil, "received", err)
+	}
+}
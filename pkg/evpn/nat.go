@@ -0,0 +1,268 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+	pc "github.com/opiproject/opi-api/network/opinetcommon/v1alpha1/gen/go"
+
+	"go.einride.tech/aip/resourceid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// NatType is the direction of a NatRule, in nftables syntax
+type NatType string
+
+const (
+	// NatTypeSource translates the private source addresses of the connections leaving the Vrf
+	NatTypeSource NatType = "snat"
+	// NatTypeDestination translates the public destination address of the connections entering the Vrf
+	NatTypeDestination NatType = "dnat"
+)
+
+// NatRule translates the IPv4 addresses of the traffic between a Vrf and the external
+// networks, i.e. its L3 VNI and VrfLiteHandoffs, child resource of a Vrf
+// TODO: move to opi-api once the message is agreed upon
+type NatRule struct {
+	Name string
+	Spec *NatRuleSpec
+}
+
+// NatRuleSpec is the desired configuration of a NatRule
+type NatRuleSpec struct {
+	// Type is snat or dnat
+	Type NatType
+	// SourcePrefix is, for snat, the private addresses translated to the pool
+	SourcePrefix *pc.IPPrefix
+	// PoolStart is, for snat, the first public address of the pool
+	PoolStart *pc.IPAddress
+	// PoolEnd is, for snat, the last public address of the pool, the pool is PoolStart alone when nil
+	PoolEnd *pc.IPAddress
+	// ExternalAddress is, for dnat, the public address the connections are sent to
+	ExternalAddress *pc.IPAddress
+	// InternalAddress is, for dnat, the private address the connections are forwarded to
+	InternalAddress *pc.IPAddress
+	// Protocol restricts a dnat mapping to the ExternalPort of a transport protocol, optional
+	Protocol SecurityProtocol
+	// ExternalPort is the port the connections are sent to, set with Protocol
+	ExternalPort uint32
+	// InternalPort is the port they are forwarded to, ExternalPort when 0
+	InternalPort uint32
+}
+
+// CreateNatRuleRequest is the request to create a NatRule in a Vrf
+type CreateNatRuleRequest struct {
+	// Parent is the name of the Vrf
+	Parent    string
+	NatRuleID string
+	NatRule   *NatRule
+}
+
+// DeleteNatRuleRequest is the request to delete a NatRule
+type DeleteNatRuleRequest struct {
+	Name         string
+	AllowMissing bool
+}
+
+// ListNatRulesRequest is the request to list the NatRules of a Vrf
+type ListNatRulesRequest struct {
+	// Parent is the name of the Vrf
+	Parent    string
+	PageSize  int32
+	PageToken string
+}
+
+// ListNatRulesResponse is the response of listing NatRules
+type ListNatRulesResponse struct {
+	NatRules      []*NatRule
+	NextPageToken string
+}
+
+func (r *NatRule) clone() *NatRule {
+	if r == nil {
+		return nil
+	}
+	c := &NatRule{Name: r.Name}
+	if r.Spec != nil {
+		spec := *r.Spec
+		if r.Spec.SourcePrefix != nil {
+			spec.SourcePrefix = protoClone(r.Spec.SourcePrefix)
+		}
+		for _, addr := range []**pc.IPAddress{&spec.PoolStart, &spec.PoolEnd, &spec.ExternalAddress, &spec.InternalAddress} {
+			if *addr != nil {
+				*addr = protoClone(*addr)
+			}
+		}
+		c.Spec = &spec
+	}
+	return c
+}
+
+func sortNatRules(rules []*NatRule) {
+	sort.Slice(rules, func(i int, j int) bool {
+		return rules[i].Name < rules[j].Name
+	})
+}
+
+func natRuleParent(name string) string {
+	// path.Dir would collapse the leading "//" of the full resource name
+	return name[:strings.LastIndex(name, "/natrules/")]
+}
+
+// vrfNatRules returns the NatRules of the Vrf, sorted by name
+func (s *Server) vrfNatRules(vrf string) []*NatRule {
+	var rules []*NatRule
+	for _, obj := range s.NatRules {
+		if natRuleParent(obj.Name) == vrf {
+			rules = append(rules, obj)
+		}
+	}
+	sortNatRules(rules)
+	return rules
+}
+
+// refreshNat applies the NatRules of a Vrf again once one of its external interfaces was
+// created or deleted
+func (s *Server) refreshNat(ctx context.Context, vrf *pb.Vrf) {
+	rules := s.vrfNatRules(vrf.Name)
+	if len(rules) == 0 {
+		return
+	}
+	// creating an existing rule applies all the rules of the Vrf again
+	if err := s.dataplane.CreateNatRule(ctx, rules[0], vrf); err != nil {
+		log.Printf("Failed to apply the NatRules of %v again: %v", vrf.Name, err)
+	}
+}
+
+// CreateNatRule executes the creation of an address translation of a Vrf
+func (s *Server) CreateNatRule(ctx context.Context, in *CreateNatRuleRequest) (*NatRule, error) {
+	// check input correctness
+	if err := s.validateCreateNatRuleRequest(in); err != nil {
+		return nil, err
+	}
+	// see https://google.aip.dev/133#user-specified-ids
+	resourceID := resourceid.NewSystemGenerated()
+	if in.NatRuleID != "" {
+		log.Printf("client provided the ID of a resource %v, ignoring the name field %v", in.NatRuleID, in.NatRule.Name)
+		resourceID = in.NatRuleID
+	}
+	in.NatRule.Name = fmt.Sprintf("%s/natrules/%s", in.Parent, resourceID)
+	s.objectsMu.Lock()
+	defer s.objectsMu.Unlock()
+	// idempotent API when called with same key, should return same object
+	obj, ok := s.NatRules[in.NatRule.Name]
+	if ok && inTenant(ctx, in.Parent) {
+		// a different spec under the same key is a conflict, not a retry
+		if err := checkSameChildSpec(obj.Name, obj.Spec, in.NatRule.Spec); err != nil {
+			return nil, err
+		}
+		log.Printf("Already existing NatRule with id %v", in.NatRule.Name)
+		return obj.clone(), nil
+	}
+	// now get Vrf to plug the rule into
+	vrf, ok := s.Vrfs[in.Parent]
+	if !ok || !inTenant(ctx, in.Parent) {
+		err := status.Errorf(codes.NotFound, "unable to find key %s", in.Parent)
+		return nil, err
+	}
+	// a public address and port can only be forwarded to one place
+	if in.NatRule.Spec.Type == NatTypeDestination {
+		key := natDestinationKey(in.NatRule.Spec)
+		for _, rule := range s.vrfNatRules(in.Parent) {
+			if rule.Spec.Type == NatTypeDestination && natDestinationKey(rule.Spec) == key {
+				err := status.Errorf(codes.AlreadyExists, "%s is already mapped by %s", key, rule.Name)
+				return nil, err
+			}
+		}
+	}
+	if err := s.dataplane.CreateNatRule(ctx, in.NatRule, vrf); err != nil {
+		s.forgetStatus(in.NatRule.Name)
+		return nil, status.Convert(err).Err()
+	}
+	// save object to the database
+	response := in.NatRule.clone()
+	s.NatRules[in.NatRule.Name] = response
+	persistObjects(s, "natrules", s.NatRules)
+	return response.clone(), nil
+}
+
+// DeleteNatRule deletes an address translation of a Vrf, the connections it translated are
+// kept until they expire
+func (s *Server) DeleteNatRule(ctx context.Context, in *DeleteNatRuleRequest) (*emptypb.Empty, error) {
+	// check input correctness
+	if err := s.validateDeleteNatRuleRequest(in); err != nil {
+		return nil, err
+	}
+	s.objectsMu.Lock()
+	defer s.objectsMu.Unlock()
+	return s.deleteNatRule(ctx, in)
+}
+
+// deleteNatRule deletes a validated address translation, with objectsMu held
+func (s *Server) deleteNatRule(ctx context.Context, in *DeleteNatRuleRequest) (*emptypb.Empty, error) {
+	// fetch object from the database
+	obj, ok := s.NatRules[in.Name]
+	if !ok || !inTenant(ctx, in.Name) {
+		if in.AllowMissing {
+			return &emptypb.Empty{}, nil
+		}
+		err := status.Errorf(codes.NotFound, "unable to find key %s", in.Name)
+		return nil, err
+	}
+	// fetch object from the database
+	parent := natRuleParent(obj.Name)
+	vrf, ok := s.Vrfs[parent]
+	if !ok {
+		err := status.Errorf(codes.NotFound, "unable to find key %s", parent)
+		return nil, err
+	}
+	if err := s.dataplane.DeleteNatRule(ctx, obj, vrf); err != nil {
+		return nil, status.Convert(err).Err()
+	}
+	// remove from the Database
+	delete(s.NatRules, obj.Name)
+	persistObjects(s, "natrules", s.NatRules)
+	s.forgetStatus(obj.Name)
+	return &emptypb.Empty{}, nil
+}
+
+// ListNatRules lists the address translations of a Vrf
+func (s *Server) ListNatRules(ctx context.Context, in *ListNatRulesRequest) (*ListNatRulesResponse, error) {
+	// check input correctness
+	if err := s.validateListNatRulesRequest(in); err != nil {
+		return nil, err
+	}
+	if !inTenant(ctx, in.Parent) {
+		err := status.Errorf(codes.NotFound, "unable to find key %s", in.Parent)
+		return nil, err
+	}
+	// fetch pagination from the database, calculate size and offset
+	size, offset, perr := s.extractPagination(in.PageSize, in.PageToken)
+	if perr != nil {
+		return nil, perr
+	}
+	s.objectsMu.RLock()
+	defer s.objectsMu.RUnlock()
+	// fetch object from the database, the following pages are cut from the snapshot of the first one
+	Blobarray, token, err := listPage(ctx, s, "natRules", in.Parent, in.PageToken, offset, size, func() []*NatRule {
+		Blobarray := []*NatRule{}
+		for _, rule := range s.vrfNatRules(in.Parent) {
+			Blobarray = append(Blobarray, rule.clone())
+		}
+		return Blobarray
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &ListNatRulesResponse{NatRules: Blobarray, NextPageToken: token}, nil
+}
@@ -0,0 +1,147 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"log"
+	"net"
+	"sort"
+	"strings"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+	pc "github.com/opiproject/opi-api/network/opinetcommon/v1alpha1/gen/go"
+)
+
+// natTable is the nftables table of the NatRules of a Vrf
+func natTable(vrfName string) string {
+	return "opi-nat-" + vrfName
+}
+
+func natAddress(addr *pc.IPAddress) net.IP {
+	ip := make(net.IP, 4)
+	binary.BigEndian.PutUint32(ip, addr.GetV4Addr())
+	return ip
+}
+
+// natDestinationKey is what a dnat rule matches, e.g. 203.0.113.10 or 203.0.113.10 tcp dport 443
+func natDestinationKey(spec *NatRuleSpec) string {
+	if spec.Protocol == "" {
+		return natAddress(spec.ExternalAddress).String()
+	}
+	return fmt.Sprintf("%s %s dport %d", natAddress(spec.ExternalAddress), spec.Protocol, spec.ExternalPort)
+}
+
+// vrfExternalDevices returns the kernel names of the interfaces a Vrf reaches the external
+// networks through: the bridge of its L3 VNI and its VrfLiteHandoffs
+func (s *Server) vrfExternalDevices(vrf *pb.Vrf) []string {
+	var devices []string
+	if vrf.Spec.Vni != nil {
+		devices = append(devices, fmt.Sprintf("br%d", *vrf.Spec.Vni))
+	}
+	for _, obj := range s.Handoffs {
+		if obj.Spec.Vrf == vrf.Name {
+			devices = append(devices, s.handoffKernelName(obj))
+		}
+	}
+	sort.Strings(devices)
+	return devices
+}
+
+// nftNatRule renders the statement of a rule
+func nftNatRule(spec *NatRuleSpec) string {
+	if spec.Type == NatTypeSource {
+		prefix := ipPrefixToNet(spec.SourcePrefix)
+		pool := natAddress(spec.PoolStart).String()
+		if spec.PoolEnd != nil {
+			pool = fmt.Sprintf("%s-%s", pool, natAddress(spec.PoolEnd))
+		}
+		return fmt.Sprintf("ip saddr %s snat to %s", prefix, pool)
+	}
+	internal := natAddress(spec.InternalAddress).String()
+	if spec.Protocol != "" {
+		port := spec.InternalPort
+		if port == 0 {
+			port = spec.ExternalPort
+		}
+		internal = fmt.Sprintf("%s:%d", internal, port)
+	}
+	return fmt.Sprintf("ip daddr %s dnat to %s", natDestinationKey(spec), internal)
+}
+
+// nftNatScript renders the NatRules of a Vrf as the nat chains of its external interfaces,
+// replacing the previous ones in the same transaction. The translations are only set up for
+// the first packet of a connection, conntrack translates the following ones and the replies
+func (s *Server) nftNatScript(vrf *pb.Vrf, rules []*NatRule) string {
+	table := natTable(s.vrfKernelName(vrf.Name))
+	var b strings.Builder
+	// create the table first, so it can always be deleted
+	fmt.Fprintf(&b, "table ip %s\n", table)
+	fmt.Fprintf(&b, "delete table ip %s\n", table)
+	if len(rules) == 0 {
+		return b.String()
+	}
+	devices := s.vrfExternalDevices(vrf)
+	quoted := make([]string, 0, len(devices))
+	for _, device := range devices {
+		quoted = append(quoted, fmt.Sprintf("%q", device))
+	}
+	// the port mappings come first, they are more specific than the address ones
+	var ports, addresses, sources []string
+	for _, rule := range rules {
+		switch {
+		case rule.Spec.Type == NatTypeSource:
+			sources = append(sources, nftNatRule(rule.Spec))
+		case rule.Spec.Protocol != "":
+			ports = append(ports, nftNatRule(rule.Spec))
+		default:
+			addresses = append(addresses, nftNatRule(rule.Spec))
+		}
+	}
+	chains := []struct {
+		name, hook, device string
+		priority           int
+		statements         []string
+	}{
+		{"prerouting", "prerouting", "iifname", -100, append(ports, addresses...)},
+		{"postrouting", "postrouting", "oifname", 100, sources},
+	}
+	fmt.Fprintf(&b, "table ip %s {\n", table)
+	for _, chain := range chains {
+		fmt.Fprintf(&b, "\tchain %s {\n", chain.name)
+		fmt.Fprintf(&b, "\t\ttype nat hook %s priority %d; policy accept;\n", chain.hook, chain.priority)
+		// nothing is translated until the Vrf has an external interface
+		if len(quoted) > 0 && len(chain.statements) > 0 {
+			fmt.Fprintf(&b, "\t\t%s != { %s } return\n", chain.device, strings.Join(quoted, ", "))
+			for _, statement := range chain.statements {
+				fmt.Fprintf(&b, "\t\t%s\n", statement)
+			}
+		}
+		b.WriteString("\t}\n")
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// nftSetNatRules applies the rules of the Vrf, with obj added, or removed when deleted is true
+func (s *Server) nftSetNatRules(ctx context.Context, obj *NatRule, vrf *pb.Vrf, deleted bool) error {
+	var rules []*NatRule
+	for _, rule := range s.vrfNatRules(vrf.Name) {
+		if rule.Name != obj.Name {
+			rules = append(rules, rule)
+		}
+	}
+	if !deleted {
+		rules = append(rules, obj)
+		sortNatRules(rules)
+	}
+	if err := s.nft.NftApply(ctx, s.nftNatScript(vrf, rules)); err != nil {
+		log.Printf("Failed to apply the NatRules of %v: %s", vrf.Name, err)
+		return err
+	}
+	return nil
+}
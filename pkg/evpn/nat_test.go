@@ -0,0 +1,290 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/philippgille/gokv/gomap"
+	"github.com/stretchr/testify/mock"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	pc "github.com/opiproject/opi-api/network/opinetcommon/v1alpha1/gen/go"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils/mocks"
+)
+
+func testIPv4(addr uint32) *pc.IPAddress {
+	return &pc.IPAddress{Af: pc.IpAf_IP_AF_INET, V4OrV6: &pc.IPAddress_V4Addr{V4Addr: addr}}
+}
+
+var (
+	testNatRuleID   = "opi-snat8"
+	testNatRuleName = fmt.Sprintf("%s/natrules/%s", testVrfName, testNatRuleID)
+	testNatRule     = NatRule{
+		Spec: &NatRuleSpec{
+			Type:         NatTypeSource,
+			SourcePrefix: &pc.IPPrefix{Addr: testIPv4(167772160), Len: 24},
+			PoolStart:    testIPv4(3405803777),
+			PoolEnd:      testIPv4(3405803780),
+		},
+	}
+	testNatRuleWithName = NatRule{
+		Name: testNatRuleName,
+		Spec: testNatRule.Spec,
+	}
+	testDnatRuleName = fmt.Sprintf("%s/natrules/%s", testVrfName, "opi-dnat8")
+	testDnatRule     = NatRule{
+		Name: testDnatRuleName,
+		Spec: &NatRuleSpec{
+			Type:            NatTypeDestination,
+			ExternalAddress: testIPv4(3405803786),
+			InternalAddress: testIPv4(167772170),
+			Protocol:        SecurityProtocolTCP,
+			ExternalPort:    443,
+			InternalPort:    8443,
+		},
+	}
+	testNatScript = `table ip opi-nat-opi-vrf8
+delete table ip opi-nat-opi-vrf8
+table ip opi-nat-opi-vrf8 {
+	chain prerouting {
+		type nat hook prerouting priority -100; policy accept;
+		iifname != { "br1000", "eth0.100" } return
+		ip daddr 203.0.113.10 tcp dport 443 dnat to 10.0.0.10:8443
+	}
+	chain postrouting {
+		type nat hook postrouting priority 100; policy accept;
+		oifname != { "br1000", "eth0.100" } return
+		ip saddr 10.0.0.0/24 snat to 203.0.113.1-203.0.113.4
+	}
+}
+`
+)
+
+// newNatServer returns a server with a Vrf reaching the external networks through its L3 VNI and a VrfLiteHandoff
+func newNatServer(t *testing.T, mockNftables *mocks.Nftables) *Server {
+	opi := NewServerWithArgs(mocks.NewNetlink(t), mocks.NewFrr(t), gomap.NewStore(gomap.DefaultOptions))
+	opi.nft = mockNftables
+	opi.Vrfs[testVrfName] = protoClone(&testVrfWithStatus)
+	opi.Handoffs[testVrfLiteHandoffName] = testVrfLiteHandoffWithStatus.clone()
+	opi.NatRules[testDnatRuleName] = testDnatRule.clone()
+	return opi
+}
+
+func Test_CreateNatRule(t *testing.T) {
+	tests := map[string]struct {
+		id      string
+		in      *NatRule
+		out     *NatRule
+		errCode codes.Code
+		errMsg  string
+		exist   bool
+		on      func(mockNftables *mocks.Nftables, errMsg string)
+	}{
+		"no required pool_start field": {
+			id:      testNatRuleID,
+			in:      &NatRule{Spec: &NatRuleSpec{Type: NatTypeSource, SourcePrefix: testNatRule.Spec.SourcePrefix}},
+			out:     nil,
+			errCode: codes.InvalidArgument,
+			errMsg:  "missing required field: nat_rule.spec.pool_start",
+			exist:   false,
+			on:      nil,
+		},
+		"illegal type": {
+			id:      testNatRuleID,
+			in:      &NatRule{Spec: &NatRuleSpec{Type: "masquerade"}},
+			out:     nil,
+			errCode: codes.InvalidArgument,
+			errMsg:  `type "masquerade" have to be snat or dnat`,
+			exist:   false,
+			on:      nil,
+		},
+		"pool end before start": {
+			id:      testNatRuleID,
+			in:      &NatRule{Spec: &NatRuleSpec{Type: NatTypeSource, SourcePrefix: testNatRule.Spec.SourcePrefix, PoolStart: testIPv4(3405803780), PoolEnd: testIPv4(3405803777)}},
+			out:     nil,
+			errCode: codes.InvalidArgument,
+			errMsg:  "pool_end have to be an IPv4 address after pool_start",
+			exist:   false,
+			on:      nil,
+		},
+		"port without protocol": {
+			id:      testNatRuleID,
+			in:      &NatRule{Spec: &NatRuleSpec{Type: NatTypeDestination, ExternalAddress: testIPv4(3405803786), InternalAddress: testIPv4(167772170), ExternalPort: 443}},
+			out:     nil,
+			errCode: codes.InvalidArgument,
+			errMsg:  "external_port and internal_port require a protocol",
+			exist:   false,
+			on:      nil,
+		},
+		"already mapped destination": {
+			id:      "opi-dnat9",
+			in:      &NatRule{Spec: testDnatRule.Spec},
+			out:     nil,
+			errCode: codes.AlreadyExists,
+			errMsg:  fmt.Sprintf("203.0.113.10 tcp dport 443 is already mapped by %v", testDnatRuleName),
+			exist:   false,
+			on:      nil,
+		},
+		"already exists": {
+			id:      testNatRuleID,
+			in:      &testNatRule,
+			out:     &testNatRuleWithName,
+			errCode: codes.OK,
+			errMsg:  "",
+			exist:   true,
+			on:      nil,
+		},
+		"already exists with a different spec": {
+			id:      testNatRuleID,
+			in:      &testDnatRule,
+			out:     nil,
+			errCode: codes.AlreadyExists,
+			errMsg:  fmt.Sprintf("%s already exists with a different spec", testNatRuleName),
+			exist:   true,
+			on:      nil,
+		},
+		"failed NftApply call": {
+			id:      testNatRuleID,
+			in:      &testNatRule,
+			out:     nil,
+			errCode: codes.Unknown,
+			errMsg:  "Failed to call NftApply",
+			exist:   false,
+			on: func(mockNftables *mocks.Nftables, errMsg string) {
+				mockNftables.EXPECT().NftApply(mock.Anything, testNatScript).Return(errors.New(errMsg)).Once()
+			},
+		},
+		"successful call": {
+			id:      testNatRuleID,
+			in:      &testNatRule,
+			out:     &testNatRuleWithName,
+			errCode: codes.OK,
+			errMsg:  "",
+			exist:   false,
+			on: func(mockNftables *mocks.Nftables, errMsg string) {
+				mockNftables.EXPECT().NftApply(mock.Anything, testNatScript).Return(nil).Once()
+			},
+		},
+	}
+
+	// run tests
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			mockNftables := mocks.NewNftables(t)
+			opi := newNatServer(t, mockNftables)
+			if tt.exist {
+				opi.NatRules[testNatRuleName] = testNatRuleWithName.clone()
+			}
+			if tt.on != nil {
+				tt.on(mockNftables, tt.errMsg)
+			}
+
+			request := &CreateNatRuleRequest{Parent: testVrfName, NatRule: tt.in.clone(), NatRuleID: tt.id}
+			response, err := opi.CreateNatRule(ctx, request)
+			if !reflect.DeepEqual(tt.out, response) {
+				t.Error("response: expected", tt.out, "received", response)
+			}
+
+			if er, ok := status.FromError(err); ok {
+				if er.Code() != tt.errCode {
+					t.Error("error code: expected", tt.errCode, "received", er.Code())
+				}
+				if er.Message() != tt.errMsg {
+					t.Error("error message: expected", tt.errMsg, "received", er.Message())
+				}
+			} else {
+				t.Error("expected grpc error status")
+			}
+		})
+	}
+}
+
+func Test_DeleteNatRule(t *testing.T) {
+	tests := map[string]struct {
+		in      string
+		out     *emptypb.Empty
+		errCode codes.Code
+		errMsg  string
+		missing bool
+		on      func(mockNftables *mocks.Nftables, errMsg string)
+	}{
+		"valid request with unknown key": {
+			in:      "unknown-id",
+			out:     nil,
+			errCode: codes.NotFound,
+			errMsg:  fmt.Sprintf("unable to find key %v", fmt.Sprintf("%s/natrules/%s", testVrfName, "unknown-id")),
+			missing: false,
+			on:      nil,
+		},
+		"unknown key with missing allowed": {
+			in:      "unknown-id",
+			out:     &emptypb.Empty{},
+			errCode: codes.OK,
+			errMsg:  "",
+			missing: true,
+			on:      nil,
+		},
+		"failed NftApply call": {
+			in:      "opi-dnat8",
+			out:     nil,
+			errCode: codes.Unknown,
+			errMsg:  "Failed to call NftApply",
+			missing: false,
+			on: func(mockNftables *mocks.Nftables, errMsg string) {
+				mockNftables.EXPECT().NftApply(mock.Anything, "table ip opi-nat-opi-vrf8\ndelete table ip opi-nat-opi-vrf8\n").Return(errors.New(errMsg)).Once()
+			},
+		},
+		"successful call": {
+			in:      "opi-dnat8",
+			out:     &emptypb.Empty{},
+			errCode: codes.OK,
+			errMsg:  "",
+			missing: false,
+			on: func(mockNftables *mocks.Nftables, errMsg string) {
+				mockNftables.EXPECT().NftApply(mock.Anything, "table ip opi-nat-opi-vrf8\ndelete table ip opi-nat-opi-vrf8\n").Return(nil).Once()
+			},
+		},
+	}
+
+	// run tests
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			mockNftables := mocks.NewNftables(t)
+			opi := newNatServer(t, mockNftables)
+			if tt.on != nil {
+				tt.on(mockNftables, tt.errMsg)
+			}
+
+			request := &DeleteNatRuleRequest{Name: fmt.Sprintf("%s/natrules/%s", testVrfName, tt.in), AllowMissing: tt.missing}
+			response, err := opi.DeleteNatRule(ctx, request)
+
+			if er, ok := status.FromError(err); ok {
+				if er.Code() != tt.errCode {
+					t.Error("error code: expected", tt.errCode, "received", er.Code())
+				}
+				if er.Message() != tt.errMsg {
+					t.Error("error message: expected", tt.errMsg, "received", er.Message())
+				}
+			} else {
+				t.Error("expected grpc error status")
+			}
+
+			if reflect.TypeOf(response) != reflect.TypeOf(tt.out) {
+				t.Error("response: expected", reflect.TypeOf(tt.out), "received", reflect.TypeOf(response))
+			}
+		})
+	}
+}
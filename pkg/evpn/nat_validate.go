@@ -0,0 +1,119 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"fmt"
+
+	"go.einride.tech/aip/resourcename"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// validateNatRuleSpec checks the fields of the type of the rule are set, only IPv4 is translated
+func validateNatRuleSpec(spec *NatRuleSpec) error {
+	switch spec.Type {
+	case NatTypeSource:
+		switch {
+		case spec.SourcePrefix == nil || spec.SourcePrefix.Addr == nil:
+			return missingField("nat_rule.spec.source_prefix")
+		case spec.PoolStart == nil:
+			return missingField("nat_rule.spec.pool_start")
+		}
+		if spec.SourcePrefix.Len > 32 {
+			msg := fmt.Sprintf("Prefix length (%d) have to be between 0 and 32", spec.SourcePrefix.Len)
+			return badRequest("nat_rule.spec.source_prefix.len", status.Error(codes.InvalidArgument, msg))
+		}
+		if spec.PoolStart.GetV4Addr() == 0 {
+			msg := "pool_start have to be an IPv4 address"
+			return badRequest("nat_rule.spec.pool_start", status.Error(codes.InvalidArgument, msg))
+		}
+		if spec.PoolEnd != nil && spec.PoolEnd.GetV4Addr() < spec.PoolStart.GetV4Addr() {
+			msg := "pool_end have to be an IPv4 address after pool_start"
+			return badRequest("nat_rule.spec.pool_end", status.Error(codes.InvalidArgument, msg))
+		}
+	case NatTypeDestination:
+		switch {
+		case spec.ExternalAddress == nil:
+			return missingField("nat_rule.spec.external_address")
+		case spec.InternalAddress == nil:
+			return missingField("nat_rule.spec.internal_address")
+		}
+		if spec.ExternalAddress.GetV4Addr() == 0 {
+			msg := "external_address have to be an IPv4 address"
+			return badRequest("nat_rule.spec.external_address", status.Error(codes.InvalidArgument, msg))
+		}
+		if spec.InternalAddress.GetV4Addr() == 0 {
+			msg := "internal_address have to be an IPv4 address"
+			return badRequest("nat_rule.spec.internal_address", status.Error(codes.InvalidArgument, msg))
+		}
+		switch spec.Protocol {
+		case "":
+			if spec.ExternalPort != 0 || spec.InternalPort != 0 {
+				msg := "external_port and internal_port require a protocol"
+				return badRequest("nat_rule.spec.protocol", status.Error(codes.InvalidArgument, msg))
+			}
+		case SecurityProtocolTCP, SecurityProtocolUDP:
+			if spec.ExternalPort == 0 || spec.ExternalPort > 65535 || spec.InternalPort > 65535 {
+				msg := fmt.Sprintf("Port (%d) have to be between 1 and 65535", spec.ExternalPort)
+				return badRequest("nat_rule.spec.external_port", status.Error(codes.InvalidArgument, msg))
+			}
+		default:
+			msg := fmt.Sprintf("protocol %q have to be %s or %s", spec.Protocol, SecurityProtocolTCP, SecurityProtocolUDP)
+			return badRequest("nat_rule.spec.protocol", status.Error(codes.InvalidArgument, msg))
+		}
+	default:
+		msg := fmt.Sprintf("type %q have to be %s or %s", spec.Type, NatTypeSource, NatTypeDestination)
+		return badRequest("nat_rule.spec.type", status.Error(codes.InvalidArgument, msg))
+	}
+	return nil
+}
+
+func (s *Server) validateCreateNatRuleRequest(in *CreateNatRuleRequest) error {
+	// check required fields
+	switch {
+	case in.Parent == "":
+		return missingField("parent")
+	case in.NatRule == nil:
+		return missingField("nat_rule")
+	case in.NatRule.Spec == nil:
+		return missingField("nat_rule.spec")
+	case in.NatRule.Spec.Type == "":
+		return missingField("nat_rule.spec.type")
+	}
+	if err := validateNatRuleSpec(in.NatRule.Spec); err != nil {
+		return err
+	}
+	// Validate that a Vrf resource name conforms to the restrictions outlined in AIP-122.
+	if err := badRequest("parent", resourcename.Validate(in.Parent)); err != nil {
+		return err
+	}
+	// see https://google.aip.dev/133#user-specified-ids
+	if in.NatRuleID != "" {
+		if err := badRequest("nat_rule_id", validateResourceID(in.NatRuleID, false)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Server) validateDeleteNatRuleRequest(in *DeleteNatRuleRequest) error {
+	// check required fields
+	if in.Name == "" {
+		return missingField("name")
+	}
+	// Validate that a resource name conforms to the restrictions outlined in AIP-122.
+	return badRequest("name", resourcename.Validate(in.Name))
+}
+
+func (s *Server) validateListNatRulesRequest(in *ListNatRulesRequest) error {
+	// check required fields
+	if in.Parent == "" {
+		return missingField("parent")
+	}
+	// Validate that a resource name conforms to the restrictions outlined in AIP-122.
+	return badRequest("parent", resourcename.Validate(in.Parent))
+}
@@ -0,0 +1,107 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"sort"
+
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+)
+
+// With --hw_offload the bridge relies on the switchdev drivers of the ports to offload its
+// forwarding: the drivers mirror the fdb entries, vlans and vxlan tunnels of the ports they
+// own into the eswitch, the other ports are forwarded in software by the kernel bridge
+
+// checkHwOffload records in the HwOffloaded condition of a BridgePort whether its port is owned
+// by a switchdev driver, only with --hw_offload. The BridgePort of a port forwarded in software
+// is Degraded but still forwards
+func (s *Server) checkHwOffload(ctx context.Context, obj *pb.BridgePort) {
+	if !s.HwOffload {
+		return
+	}
+	kernel := s.portKernelName(obj.Name)
+	// only the ports of a switchdev driver have a switch ID
+	var err error
+	if _, serr := s.sysfs.LinkAttr(ctx, kernel, "phys_switch_id"); serr != nil {
+		err = status.Errorf(codes.FailedPrecondition, "%s is not a switchdev port, its traffic is forwarded in software", kernel)
+	}
+	s.conditions.set(obj.Name, ConditionHwOffloaded, err)
+}
+
+// OffloadCounters are the fdb entries of a LogicalBridge, by where they are forwarded
+type OffloadCounters struct {
+	// LogicalBridge is the name of the LogicalBridge
+	LogicalBridge string `json:"logicalBridge"`
+	// Offloaded is the number of fdb entries the switchdev drivers offloaded
+	Offloaded int32 `json:"offloaded"`
+	// Software is the number of fdb entries only forwarded by the kernel bridge
+	Software int32 `json:"software"`
+}
+
+// GetOffloadCountersRequest is the request to count the offloaded fdb entries
+// TODO: move to opi-api once the message is agreed upon
+type GetOffloadCountersRequest struct {
+	// LogicalBridge is the name of a LogicalBridge to count the entries of, all of them when empty
+	LogicalBridge string
+}
+
+// GetOffloadCountersResponse lists the counters per LogicalBridge, sorted by name
+// TODO: move to opi-api once the message is agreed upon
+type GetOffloadCountersResponse struct {
+	Bridges []OffloadCounters `json:"bridges"`
+}
+
+// GetOffloadCounters counts the fdb entries, learned, static and remote ones installed by
+// EVPN, of the LogicalBridges offloaded to the eswitch and those forwarded in software, e.g.
+// to find the hosts missing the fast path
+func (s *Server) GetOffloadCounters(ctx context.Context, in *GetOffloadCountersRequest) (*GetOffloadCountersResponse, error) {
+	counters := map[int]*OffloadCounters{}
+	for name, obj := range s.Bridges {
+		if !inTenant(ctx, name) || (in.LogicalBridge != "" && name != in.LogicalBridge) {
+			continue
+		}
+		counters[int(obj.Spec.VlanId)] = &OffloadCounters{LogicalBridge: name}
+	}
+	if in.LogicalBridge != "" && len(counters) == 0 {
+		err := status.Errorf(codes.NotFound, "unable to find key %s", in.LogicalBridge)
+		return nil, err
+	}
+	bridge, err := s.tenantBridge(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// Example: bridge fdb show br br-tenant
+	neighs, err := s.nLink.NeighList(ctx, 0, unix.AF_BRIDGE)
+	if err != nil {
+		err = status.Errorf(codes.Unavailable, "unable to list fdb entries: %v", err)
+		return nil, err
+	}
+	for _, neigh := range neighs {
+		counter, ok := counters[neigh.Vlan]
+		if !ok || neigh.MasterIndex != bridge.Attrs().Index {
+			continue
+		}
+		if neigh.Flags&netlink.NTF_OFFLOADED != 0 {
+			counter.Offloaded++
+		} else {
+			counter.Software++
+		}
+	}
+	response := &GetOffloadCountersResponse{Bridges: []OffloadCounters{}}
+	for _, counter := range counters {
+		response.Bridges = append(response.Bridges, *counter)
+	}
+	sort.Slice(response.Bridges, func(i, j int) bool {
+		return response.Bridges[i].LogicalBridge < response.Bridges[j].LogicalBridge
+	})
+	return response, nil
+}
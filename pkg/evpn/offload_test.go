@@ -0,0 +1,131 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/philippgille/gokv/gomap"
+	"github.com/stretchr/testify/mock"
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils/mocks"
+)
+
+func Test_GetOffloadCounters(t *testing.T) {
+	bridge := &netlink.Bridge{LinkAttrs: netlink.LinkAttrs{Name: tenantbridgeName, Index: 3}}
+	neighs := []netlink.Neigh{
+		{LinkIndex: 5, MasterIndex: 3, Vlan: 22, Flags: netlink.NTF_OFFLOADED},
+		{LinkIndex: 6, MasterIndex: 3, Vlan: 22, Flags: netlink.NTF_OFFLOADED | netlink.NTF_EXT_LEARNED},
+		{LinkIndex: 7, MasterIndex: 3, Vlan: 22},
+		{LinkIndex: 5, MasterIndex: 3, Vlan: 23},
+		// fdb entries of the port itself
+		{LinkIndex: 5, Vlan: 22, Flags: netlink.NTF_SELF},
+	}
+	tests := map[string]struct {
+		in      *GetOffloadCountersRequest
+		list    bool
+		listErr error
+		out     []OffloadCounters
+		errCode codes.Code
+	}{
+		"all LogicalBridges": {
+			in:   &GetOffloadCountersRequest{},
+			list: true,
+			out: []OffloadCounters{
+				{LogicalBridge: resourceIDToFullName("bridges", "opi-bridge10"), Software: 1},
+				{LogicalBridge: testLogicalBridgeName, Offloaded: 2, Software: 1},
+			},
+		},
+		"one LogicalBridge": {
+			in:   &GetOffloadCountersRequest{LogicalBridge: testLogicalBridgeName},
+			list: true,
+			out:  []OffloadCounters{{LogicalBridge: testLogicalBridgeName, Offloaded: 2, Software: 1}},
+		},
+		"unknown LogicalBridge": {
+			in:      &GetOffloadCountersRequest{LogicalBridge: resourceIDToFullName("bridges", "unknown")},
+			errCode: codes.NotFound,
+		},
+		"failed fdb listing": {
+			in:      &GetOffloadCountersRequest{},
+			list:    true,
+			listErr: errors.New("Failed to call NeighList"),
+			errCode: codes.Unavailable,
+		},
+	}
+	for testName, tt := range tests {
+		t.Run(testName, func(t *testing.T) {
+			mockNetlink := mocks.NewNetlink(t)
+			opi := NewServerWithArgs(mockNetlink, mocks.NewFrr(t), gomap.NewStore(gomap.DefaultOptions))
+			opi.Bridges[testLogicalBridgeName] = protoClone(&testLogicalBridgeWithStatus)
+			other := resourceIDToFullName("bridges", "opi-bridge10")
+			opi.Bridges[other] = &pb.LogicalBridge{Name: other, Spec: &pb.LogicalBridgeSpec{VlanId: 23}}
+			if tt.list {
+				mockNetlink.EXPECT().LinkByName(mock.Anything, tenantbridgeName).Return(bridge, nil).Once()
+				mockNetlink.EXPECT().NeighList(mock.Anything, 0, unix.AF_BRIDGE).Return(neighs, tt.listErr).Once()
+			}
+			response, err := opi.GetOffloadCounters(context.Background(), tt.in)
+			if status.Code(err) != tt.errCode {
+				t.Error("error: expected", tt.errCode, "received", err)
+			}
+			if err == nil && !reflect.DeepEqual(response.Bridges, tt.out) {
+				t.Error("counters: expected", tt.out, "received", response.Bridges)
+			}
+		})
+	}
+}
+
+func Test_checkHwOffload(t *testing.T) {
+	tests := map[string]struct {
+		hwOffload bool
+		switchID  bool
+		out       ConditionStatus
+	}{
+		"offload disabled": {},
+		"switchdev port": {
+			hwOffload: true,
+			switchID:  true,
+			out:       ConditionTrue,
+		},
+		"software port": {
+			hwOffload: true,
+			out:       ConditionFalse,
+		},
+	}
+	for testName, tt := range tests {
+		t.Run(testName, func(t *testing.T) {
+			mockSysfs := mocks.NewSysfs(t)
+			opi := NewServerWithArgs(mocks.NewNetlink(t), mocks.NewFrr(t), gomap.NewStore(gomap.DefaultOptions))
+			opi.sysfs = mockSysfs
+			opi.HwOffload = tt.hwOffload
+			if tt.hwOffload {
+				var err error
+				if !tt.switchID {
+					err = errors.New("operation not supported")
+				}
+				mockSysfs.EXPECT().LinkAttr(mock.Anything, testBridgePortID, "phys_switch_id").Return("aabbcc0000000001", err).Once()
+			}
+			opi.checkHwOffload(context.Background(), &pb.BridgePort{Name: testBridgePortName, Spec: testBridgePort.Spec})
+			var received ConditionStatus
+			for _, condition := range opi.conditions.get(testBridgePortName) {
+				if condition.Type == ConditionHwOffloaded {
+					received = condition.Status
+				}
+			}
+			if received != tt.out {
+				t.Error("HwOffloaded: expected", tt.out, "received", received)
+			}
+		})
+	}
+}
@@ -0,0 +1,205 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/longrunning/autogen/longrunningpb"
+	"github.com/google/uuid"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils"
+)
+
+// operationRetention is how long the finished operations are kept when nobody deletes them
+const operationRetention = time.Hour
+
+// operation tracks the programming of one object in the background
+type operation struct {
+	op     *longrunningpb.Operation
+	target string
+	cancel context.CancelFunc
+	done   chan struct{}
+	doneAt time.Time
+}
+
+// operationSet keeps the running and recently finished operations by name
+type operationSet struct {
+	mu     sync.Mutex
+	byName map[string]*operation
+}
+
+func newOperationSet() *operationSet {
+	return &operationSet{byName: map[string]*operation{}}
+}
+
+// checkNoOperation fails with Aborted while an operation is still programming the object,
+// a Create or Delete retried before it finished must not program it a second time
+func (s *Server) checkNoOperation(target string) error {
+	s.operations.mu.Lock()
+	defer s.operations.mu.Unlock()
+	for name, o := range s.operations.byName {
+		if o.target == target && !o.op.Done {
+			return status.Errorf(codes.Aborted, "operation %s is still programming %s", name, target)
+		}
+	}
+	return nil
+}
+
+// startOperation runs the programming of the target object in the background, its name
+// is returned to the caller in the OperationMetadataKey response header. run takes
+// objectsMu itself, as the calls changing the objects do
+func (s *Server) startOperation(ctx context.Context, target string, run func(context.Context) (proto.Message, error)) string {
+	// the programming outlives the call, only CancelOperation stops it
+	opCtx, cancel := context.WithCancel(context.Background())
+	o := &operation{
+		op:     &longrunningpb.Operation{Name: "operations/" + uuid.New().String()},
+		target: target,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	s.operations.mu.Lock()
+	s.operations.prune(time.Now())
+	s.operations.byName[o.op.Name] = o
+	s.operations.mu.Unlock()
+	if err := grpc.SetHeader(ctx, metadata.Pairs(utils.OperationMetadataKey, o.op.Name)); err != nil {
+		log.Printf("Failed to send operation %v of %v: %v", o.op.Name, target, err)
+	}
+	go func() {
+		defer cancel()
+		response, err := run(opCtx)
+		if err == nil {
+			response, err = anypb.New(response)
+		}
+		s.operations.mu.Lock()
+		defer s.operations.mu.Unlock()
+		if err != nil {
+			log.Printf("Operation %v programming %v failed: %v", o.op.Name, target, err)
+			o.op.Result = &longrunningpb.Operation_Error{Error: status.Convert(err).Proto()}
+		} else {
+			o.op.Result = &longrunningpb.Operation_Response{Response: response.(*anypb.Any)}
+		}
+		o.op.Done = true
+		o.doneAt = time.Now()
+		close(o.done)
+	}()
+	return o.op.Name
+}
+
+// prune forgets the operations finished for longer than operationRetention, with mu held
+func (o *operationSet) prune(now time.Time) {
+	for name, op := range o.byName {
+		if op.op.Done && now.Sub(op.doneAt) > operationRetention {
+			delete(o.byName, name)
+		}
+	}
+}
+
+// find returns the operation with the given name and a copy of its current state
+func (o *operationSet) find(name string) (*operation, *longrunningpb.Operation, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	op, ok := o.byName[name]
+	if !ok {
+		err := status.Errorf(codes.NotFound, "unable to find key %s", name)
+		return nil, nil, err
+	}
+	return op, protoClone(op.op), nil
+}
+
+// GetOperation returns the latest state of an operation
+func (s *Server) GetOperation(_ context.Context, in *longrunningpb.GetOperationRequest) (*longrunningpb.Operation, error) {
+	_, response, err := s.operations.find(in.Name)
+	return response, err
+}
+
+// ListOperations lists the running and recently finished operations, filters are not supported
+func (s *Server) ListOperations(ctx context.Context, in *longrunningpb.ListOperationsRequest) (*longrunningpb.ListOperationsResponse, error) {
+	if in.Filter != "" {
+		return nil, status.Error(codes.InvalidArgument, "filter is not supported")
+	}
+	size, offset, perr := s.extractPagination(in.PageSize, in.PageToken)
+	if perr != nil {
+		return nil, perr
+	}
+	Blobarray, token, err := listPage(ctx, s, "operations", "", in.PageToken, offset, size, func() []*longrunningpb.Operation {
+		s.operations.mu.Lock()
+		Blobarray := []*longrunningpb.Operation{}
+		for _, op := range s.operations.byName {
+			Blobarray = append(Blobarray, protoClone(op.op))
+		}
+		s.operations.mu.Unlock()
+		sort.Slice(Blobarray, func(i int, j int) bool {
+			return Blobarray[i].Name < Blobarray[j].Name
+		})
+		return Blobarray
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &longrunningpb.ListOperationsResponse{Operations: Blobarray, NextPageToken: token}, nil
+}
+
+// CancelOperation stops the programming of a running operation, which then fails with
+// Canceled unless it already got too far to be stopped. Cancelling a finished operation
+// does nothing
+func (s *Server) CancelOperation(_ context.Context, in *longrunningpb.CancelOperationRequest) (*emptypb.Empty, error) {
+	op, _, err := s.operations.find(in.Name)
+	if err != nil {
+		return nil, err
+	}
+	op.cancel()
+	return &emptypb.Empty{}, nil
+}
+
+// DeleteOperation forgets a finished operation, a running one is still what stops a Create or
+// Delete retried too early and has to be cancelled and waited for first
+func (s *Server) DeleteOperation(_ context.Context, in *longrunningpb.DeleteOperationRequest) (*emptypb.Empty, error) {
+	s.operations.mu.Lock()
+	defer s.operations.mu.Unlock()
+	op, ok := s.operations.byName[in.Name]
+	if !ok {
+		err := status.Errorf(codes.NotFound, "unable to find key %s", in.Name)
+		return nil, err
+	}
+	if !op.op.Done {
+		return nil, status.Errorf(codes.FailedPrecondition, "operation %s is still running, cancel it first", in.Name)
+	}
+	delete(s.operations.byName, in.Name)
+	return &emptypb.Empty{}, nil
+}
+
+// WaitOperation waits until the operation is done, the timeout expires or the call is
+// cancelled, and returns its latest state
+func (s *Server) WaitOperation(ctx context.Context, in *longrunningpb.WaitOperationRequest) (*longrunningpb.Operation, error) {
+	op, _, err := s.operations.find(in.Name)
+	if err != nil {
+		return nil, err
+	}
+	if in.Timeout != nil {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, in.Timeout.AsDuration())
+		defer cancel()
+	}
+	select {
+	case <-op.done:
+	case <-ctx.Done():
+	}
+	s.operations.mu.Lock()
+	defer s.operations.mu.Unlock()
+	return protoClone(op.op), nil
+}
@@ -0,0 +1,189 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/longrunning/autogen/longrunningpb"
+	"github.com/philippgille/gokv/gomap"
+	"github.com/stretchr/testify/mock"
+	"github.com/vishvananda/netlink"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+	pc "github.com/opiproject/opi-api/network/opinetcommon/v1alpha1/gen/go"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/fake"
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils"
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils/mocks"
+)
+
+func Test_CreateVrfAsync(t *testing.T) {
+	tests := map[string]struct {
+		setUpErr error
+		errCode  codes.Code
+	}{
+		"programmed in the background": {
+			setUpErr: nil,
+			errCode:  codes.OK,
+		},
+		"failed in the background": {
+			setUpErr: errors.New("Failed to call LinkSetUp"),
+			errCode:  codes.Unknown,
+		},
+	}
+	for testName, tt := range tests {
+		t.Run(testName, func(t *testing.T) {
+			ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(utils.AsyncMetadataKey, "true"))
+			mockNetlink := mocks.NewNetlink(t)
+			mockFrr := mocks.NewFrr(t)
+			opi := NewServerWithArgs(mockNetlink, mockFrr, gomap.NewStore(gomap.DefaultOptions))
+
+			// the programming is held until the first call returned
+			release := make(chan struct{})
+			vrf := &netlink.Vrf{LinkAttrs: netlink.LinkAttrs{Name: testVrfID, Alias: managedAlias}, Table: 1000}
+			mockNetlink.EXPECT().LinkAdd(mock.Anything, vrf).RunAndReturn(func(context.Context, netlink.Link) error {
+				<-release
+				return nil
+			}).Once()
+			mockNetlink.EXPECT().LinkSetUp(mock.Anything, vrf).Return(tt.setUpErr).Once()
+			if tt.setUpErr == nil {
+				mockFrr.EXPECT().FrrZebraCmd(mock.Anything, mock.Anything).Return("", nil).Once()
+			}
+
+			request := &pb.CreateVrfRequest{VrfId: testVrfID, Vrf: &pb.Vrf{Spec: &pb.VrfSpec{LoopbackIpPrefix: &pc.IPPrefix{Len: 24}}}}
+			response, err := opi.CreateVrf(ctx, request)
+			if err != nil {
+				t.Fatal("error: expected", nil, "received", err)
+			}
+			if _, ok := opi.Vrfs[response.Name]; ok {
+				t.Error("vrf: expected not saved before the operation is done")
+			}
+			// the other calls go on while the Vrf is programmed
+			_, err = opi.GetVrf(ctx, &pb.GetVrfRequest{Name: response.Name})
+			if status.Code(err) != codes.NotFound {
+				t.Error("error: expected", codes.NotFound, "received", err)
+			}
+			operations, err := opi.ListOperations(ctx, &longrunningpb.ListOperationsRequest{})
+			if err != nil || len(operations.Operations) != 1 {
+				t.Fatal("operations: expected 1, received", operations, err)
+			}
+			close(release)
+
+			op, err := opi.WaitOperation(ctx, &longrunningpb.WaitOperationRequest{Name: operations.Operations[0].Name, Timeout: durationpb.New(5 * time.Second)})
+			if err != nil {
+				t.Fatal("error: expected", nil, "received", err)
+			}
+			if !op.Done {
+				t.Fatal("operation: expected done, received", op)
+			}
+			if tt.errCode != codes.OK {
+				if codes.Code(op.GetError().GetCode()) != tt.errCode {
+					t.Error("operation error: expected", tt.errCode, "received", op.GetError())
+				}
+				return
+			}
+			created := &pb.Vrf{}
+			if err := op.GetResponse().UnmarshalTo(created); err != nil {
+				t.Fatal("error: expected", nil, "received", err)
+			}
+			if created.Name != response.Name || opi.Vrfs[response.Name] == nil {
+				t.Error("vrf: expected saved", response.Name, "received", created)
+			}
+			// a retry does not program the object a second time
+			retried, err := opi.CreateVrf(ctx, request)
+			if err != nil || retried.Name != response.Name {
+				t.Error("vrf: expected", response.Name, "received", retried, err)
+			}
+		})
+	}
+}
+
+func Test_CreateVrfAsyncConcurrentCalls(t *testing.T) {
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(utils.AsyncMetadataKey, "true"))
+	opi := NewServerWithArgs(fake.NewNetlink(), fake.NewFrr(), gomap.NewStore(gomap.DefaultOptions))
+
+	// the operations save the Vrfs while the next calls read them
+	const count = 10
+	for i := 0; i < count; i++ {
+		request := &pb.CreateVrfRequest{VrfId: fmt.Sprintf("blue%d", i), Vrf: &pb.Vrf{Spec: &pb.VrfSpec{LoopbackIpPrefix: &pc.IPPrefix{Len: 24}}}}
+		response, err := opi.CreateVrf(ctx, request)
+		if err != nil {
+			t.Fatal("error: expected", nil, "received", err)
+		}
+		if _, err := opi.GetVrf(ctx, &pb.GetVrfRequest{Name: response.Name}); err != nil && status.Code(err) != codes.NotFound {
+			t.Error("error: expected", codes.NotFound, "received", err)
+		}
+	}
+	operations, err := opi.ListOperations(ctx, &longrunningpb.ListOperationsRequest{})
+	if err != nil || len(operations.Operations) != count {
+		t.Fatal("operations: expected", count, "received", operations, err)
+	}
+	for _, op := range operations.Operations {
+		op, err := opi.WaitOperation(ctx, &longrunningpb.WaitOperationRequest{Name: op.Name, Timeout: durationpb.New(5 * time.Second)})
+		if err != nil || !op.Done || op.GetError() != nil {
+			t.Fatal("operation: expected done, received", op, err)
+		}
+	}
+	if len(opi.Vrfs) != count {
+		t.Error("vrfs: expected", count, "received", len(opi.Vrfs))
+	}
+}
+
+func Test_Operations(t *testing.T) {
+	ctx := context.Background()
+	opi := NewServerWithArgs(mocks.NewNetlink(t), mocks.NewFrr(t), gomap.NewStore(gomap.DefaultOptions))
+
+	// cancelling stops the programming blocked on its context
+	name := opi.startOperation(ctx, testVrfName, func(ctx context.Context) (proto.Message, error) {
+		<-ctx.Done()
+		return nil, status.FromContextError(ctx.Err()).Err()
+	})
+	if _, err := opi.CancelOperation(ctx, &longrunningpb.CancelOperationRequest{Name: name}); err != nil {
+		t.Fatal("error: expected", nil, "received", err)
+	}
+	op, err := opi.WaitOperation(ctx, &longrunningpb.WaitOperationRequest{Name: name})
+	if err != nil {
+		t.Fatal("error: expected", nil, "received", err)
+	}
+	if codes.Code(op.GetError().GetCode()) != codes.Canceled {
+		t.Error("operation error: expected", codes.Canceled, "received", op.GetError())
+	}
+
+	// a running operation cannot be forgotten
+	running := opi.startOperation(ctx, testVrfName, func(ctx context.Context) (proto.Message, error) {
+		<-ctx.Done()
+		return nil, status.FromContextError(ctx.Err()).Err()
+	})
+	_, err = opi.DeleteOperation(ctx, &longrunningpb.DeleteOperationRequest{Name: running})
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Error("error: expected", codes.FailedPrecondition, "received", err)
+	}
+	if _, err := opi.CancelOperation(ctx, &longrunningpb.CancelOperationRequest{Name: running}); err != nil {
+		t.Fatal("error: expected", nil, "received", err)
+	}
+
+	if _, err := opi.DeleteOperation(ctx, &longrunningpb.DeleteOperationRequest{Name: name}); err != nil {
+		t.Fatal("error: expected", nil, "received", err)
+	}
+	_, err = opi.GetOperation(ctx, &longrunningpb.GetOperationRequest{Name: name})
+	if status.Code(err) != codes.NotFound {
+		t.Error("error: expected", codes.NotFound, "received", err)
+	}
+	_, err = opi.ListOperations(ctx, &longrunningpb.ListOperationsRequest{Filter: "done=true"})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Error("error: expected", codes.InvalidArgument, "received", err)
+	}
+}
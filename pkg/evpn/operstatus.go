@@ -0,0 +1,60 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+)
+
+// The oper status of an object comes from the live state of its parts in the dataplane, e.g.
+// the IFF_UP flag and carrier of its kernel devices. It is never stored, it is kept up to date
+// from the kernel notifications when the status monitor runs, and computed on every read
+// otherwise. The parts down are reported in the degraded header
+
+// vrfOperStatus returns the live oper status of the Vrf, adding why it is down to degraded
+func (s *Server) vrfOperStatus(ctx context.Context, obj *pb.Vrf, degraded map[string]error) pb.VRFOperStatus {
+	err := checkLive(ctx, s, obj, s.liveVrfStatus, s.dataplane.CheckVrf)
+	s.conditions.set(obj.Name, ConditionLinkUp, err)
+	if err != nil {
+		degraded[obj.Name] = err
+		return pb.VRFOperStatus_VRF_OPER_STATUS_DOWN
+	}
+	return pb.VRFOperStatus_VRF_OPER_STATUS_UP
+}
+
+// logicalBridgeOperStatus returns the live oper status of the LogicalBridge, adding why it is down to degraded
+func (s *Server) logicalBridgeOperStatus(ctx context.Context, obj *pb.LogicalBridge, degraded map[string]error) pb.LBOperStatus {
+	err := checkLive(ctx, s, obj, s.liveLogicalBridgeStatus, s.dataplane.CheckLogicalBridge)
+	s.conditions.set(obj.Name, ConditionLinkUp, err)
+	if err != nil {
+		degraded[obj.Name] = err
+		return pb.LBOperStatus_LB_OPER_STATUS_DOWN
+	}
+	return pb.LBOperStatus_LB_OPER_STATUS_UP
+}
+
+// bridgePortOperStatus returns the live oper status of the BridgePort, adding why it is down to degraded
+func (s *Server) bridgePortOperStatus(ctx context.Context, obj *pb.BridgePort, degraded map[string]error) pb.BPOperStatus {
+	err := checkLive(ctx, s, obj, s.liveBridgePortStatus, s.dataplane.CheckBridgePort)
+	s.conditions.set(obj.Name, ConditionLinkUp, err)
+	if err != nil {
+		degraded[obj.Name] = err
+		return pb.BPOperStatus_BP_OPER_STATUS_DOWN
+	}
+	return pb.BPOperStatus_BP_OPER_STATUS_UP
+}
+
+// sviOperStatus returns the live oper status of the Svi, adding why it is down to degraded
+func (s *Server) sviOperStatus(ctx context.Context, obj *pb.Svi, degraded map[string]error) pb.SVIOperStatus {
+	err := checkLive(ctx, s, obj, s.liveSviStatus, s.dataplane.CheckSvi)
+	s.conditions.set(obj.Name, ConditionLinkUp, err)
+	if err != nil {
+		degraded[obj.Name] = err
+		return pb.SVIOperStatus_SVI_OPER_STATUS_DOWN
+	}
+	return pb.SVIOperStatus_SVI_OPER_STATUS_UP
+}
@@ -0,0 +1,338 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netlink/nl"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils"
+)
+
+// OrphanPolicy tells the sweeper what to do with the orphaned kernel interfaces and bridge
+// vlans, those left over by a crash or a manual experiment which no stored object is backed by
+type OrphanPolicy string
+
+const (
+	// OrphanOff does not sweep
+	OrphanOff OrphanPolicy = "off"
+	// OrphanReport logs the orphans and lists them in GetOrphans
+	OrphanReport OrphanPolicy = "report"
+	// OrphanRemove deletes the orphans found by two sweeps in a row
+	OrphanRemove OrphanPolicy = "remove"
+)
+
+// ParseOrphanPolicy parses the --orphan_policy flag, off, report or remove
+func ParseOrphanPolicy(value string) (OrphanPolicy, error) {
+	switch policy := OrphanPolicy(value); policy {
+	case OrphanOff, OrphanReport, OrphanRemove:
+		return policy, nil
+	}
+	return "", fmt.Errorf("invalid orphan policy %q, expected off, report or remove", value)
+}
+
+// managedAlias is the alias of the interfaces the server creates, only those are swept, never
+// an interface of the operator named like the devices of the gateway
+const managedAlias = "opi-evpn-bridge"
+
+// Orphan is a kernel interface or a bridge vlan named or placed like those of the Vrfs,
+// LogicalBridges and Svis, which none of the stored objects is backed by
+// TODO: move to an AdminService in opi-api once the message is agreed upon
+type Orphan struct {
+	// Interface is the orphaned interface, or the one the orphaned vlan is on
+	Interface string `json:"interface"`
+	// Type is the type of the interface, e.g. vrf or vxlan
+	Type string `json:"type"`
+	// Vlan is the orphaned bridge vlan, none when the whole interface is orphaned
+	Vlan uint16 `json:"vlan,omitempty"`
+	// FirstSeen is when the sweeper first found it, zero when it did not yet
+	FirstSeen time.Time `json:"firstSeen"`
+}
+
+// key identifies the orphan across the sweeps
+func (o Orphan) key() string {
+	if o.Vlan != 0 {
+		return fmt.Sprintf("%s vlan %d", o.Interface, o.Vlan)
+	}
+	return o.Interface
+}
+
+// GetOrphansRequest is the request to list the orphaned kernel interfaces and bridge vlans
+// TODO: move to an AdminService in opi-api once the message is agreed upon
+type GetOrphansRequest struct{}
+
+// GetOrphansResponse lists the orphans, sorted by interface then vlan
+// TODO: move to an AdminService in opi-api once the message is agreed upon
+type GetOrphansResponse struct {
+	Orphans []Orphan `json:"orphans"`
+}
+
+// orphanSweeper remembers when the orphans were first found, so only those outliving a
+// whole interval, not the devices of an object being created meanwhile, are removed
+type orphanSweeper struct {
+	mu        sync.Mutex
+	firstSeen map[string]time.Time
+	now       func() time.Time
+}
+
+func newOrphanSweeper() *orphanSweeper {
+	return &orphanSweeper{firstSeen: map[string]time.Time{}, now: time.Now}
+}
+
+// observe stamps the orphans of a sweep with the time they were first found, those gone
+// since the last sweep being forgotten, and tells whether each was found by the last one
+func (w *orphanSweeper) observe(orphans []Orphan) []bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	now := w.now()
+	seen := make(map[string]time.Time, len(orphans))
+	before := make([]bool, len(orphans))
+	for i := range orphans {
+		key := orphans[i].key()
+		first, ok := w.firstSeen[key]
+		if !ok {
+			first = now
+		}
+		before[i] = ok
+		orphans[i].FirstSeen = first
+		seen[key] = first
+	}
+	w.firstSeen = seen
+	return before
+}
+
+// numbered reports whether the name is the prefix followed by a number, e.g. vni10
+func numbered(name string, prefix string) bool {
+	if !strings.HasPrefix(name, prefix) {
+		return false
+	}
+	_, err := strconv.ParseUint(strings.TrimPrefix(name, prefix), 10, 32)
+	return err == nil
+}
+
+// expectedLinks returns the interfaces the stored Vrfs, LogicalBridges and Svis are programmed
+// as, with objectsMu held
+func (s *Server) expectedLinks() map[string]bool {
+	expected := map[string]bool{tenantbridgeName: true}
+	for _, obj := range s.Vrfs {
+		for _, name := range s.vrfLinks(obj) {
+			expected[name] = true
+		}
+	}
+	for _, obj := range s.Bridges {
+		for _, name := range s.logicalBridgeLinks(obj) {
+			expected[name] = true
+		}
+	}
+	for _, obj := range s.Svis {
+		for _, name := range s.sviLinks(obj) {
+			expected[name] = true
+		}
+	}
+	return expected
+}
+
+// expectedVlans returns the vlans of the ports of the tenant bridge, those of the stored
+// LogicalBridges, and of the bridge itself, those the stored Svis route, with objectsMu held
+func (s *Server) expectedVlans() (bridgeVlans map[uint16]bool, sviVlans map[uint16]bool) {
+	// vlan 1 is the default pvid of the kernel bridge
+	bridgeVlans = map[uint16]bool{1: true}
+	for _, obj := range s.Bridges {
+		bridgeVlans[uint16(obj.Spec.VlanId)] = true
+	}
+	sviVlans = map[uint16]bool{1: true}
+	for _, obj := range s.Svis {
+		if bridge, ok := s.Bridges[obj.Spec.LogicalBridge]; ok {
+			sviVlans[uint16(bridge.Spec.VlanId)] = true
+		}
+	}
+	return bridgeVlans, sviVlans
+}
+
+// findOrphans lists the Vrf devices, vni vxlan devices, br bridges of the L3 vnis, Vrf loopbacks
+// and vlan devices of the Svis the server created, carrying managedAlias, without a stored
+// object, and the vlans of the tenant bridge and of its ports without a LogicalBridge, or
+// without a Svi for the vlans of the bridge itself. The other interfaces are left alone, they
+// are not the gateway's
+func (s *Server) findOrphans(ctx context.Context) ([]Orphan, error) {
+	links, err := s.nLink.LinkList(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "unable to list the links: %v", err)
+	}
+	k := &kernelLinks{byIndex: make(map[int]netlink.Link), byName: make(map[string]netlink.Link)}
+	for _, link := range links {
+		k.byIndex[link.Attrs().Index] = link
+		k.byName[link.Attrs().Name] = link
+	}
+	tenant, hasTenant := k.byName[tenantbridgeName]
+	var vlans map[int32][]*nl.BridgeVlanInfo
+	if hasTenant {
+		if vlans, err = s.nLink.BridgeVlanList(ctx); err != nil {
+			return nil, status.Errorf(codes.Unavailable, "unable to list the bridge vlans: %v", err)
+		}
+	}
+	// the stored objects are read under the lock, the kernel without it
+	s.objectsMu.RLock()
+	expected := s.expectedLinks()
+	bridgeVlans, sviVlans := s.expectedVlans()
+	s.objectsMu.RUnlock()
+
+	orphans := []Orphan{}
+	orphaned := map[string]bool{}
+	for _, link := range links {
+		name := link.Attrs().Name
+		candidate := false
+		switch link.(type) {
+		case *netlink.Vrf:
+			candidate = true
+		case *netlink.Vxlan:
+			candidate = numbered(name, "vni")
+		case *netlink.Bridge:
+			candidate = numbered(name, "br")
+		case *netlink.Dummy:
+			master := k.master(link)
+			candidate = strings.HasSuffix(name, "-lo") && master != nil && master.Type() == "vrf"
+		case *netlink.Vlan:
+			parent := k.byIndex[link.Attrs().ParentIndex]
+			candidate = numbered(name, "vlan") && parent != nil && parent.Attrs().Name == tenantbridgeName
+		}
+		if candidate && link.Attrs().Alias == managedAlias && !expected[name] {
+			orphans = append(orphans, Orphan{Interface: name, Type: link.Type()})
+			orphaned[name] = true
+		}
+	}
+	if !hasTenant {
+		return sortOrphans(orphans), nil
+	}
+	for index, infos := range vlans {
+		link := k.byIndex[int(index)]
+		var expectedVlans map[uint16]bool
+		switch {
+		case link == nil || orphaned[link.Attrs().Name]:
+			// the vlans of an orphaned device go away with it
+			continue
+		case link.Attrs().Index == tenant.Attrs().Index:
+			// the vlans of the bridge itself are those the Svis route
+			expectedVlans = sviVlans
+		case link.Attrs().MasterIndex == tenant.Attrs().Index:
+			expectedVlans = bridgeVlans
+		default:
+			continue
+		}
+		for _, info := range infos {
+			if !expectedVlans[info.Vid] {
+				orphans = append(orphans, Orphan{Interface: link.Attrs().Name, Type: link.Type(), Vlan: info.Vid})
+			}
+		}
+	}
+	return sortOrphans(orphans), nil
+}
+
+// sortOrphans sorts the orphans by interface then vlan
+func sortOrphans(orphans []Orphan) []Orphan {
+	sort.Slice(orphans, func(i, j int) bool {
+		if orphans[i].Interface != orphans[j].Interface {
+			return orphans[i].Interface < orphans[j].Interface
+		}
+		return orphans[i].Vlan < orphans[j].Vlan
+	})
+	return orphans
+}
+
+// removeOrphan deletes the orphaned interface, or the orphaned vlan from its interface
+func (s *Server) removeOrphan(ctx context.Context, o Orphan) error {
+	link, err := s.nLink.LinkByName(ctx, o.Interface)
+	if err != nil {
+		// already gone
+		return nil
+	}
+	if o.Vlan == 0 {
+		// Example: ip link del vni10
+		return s.nLink.LinkDel(ctx, link)
+	}
+	// Example: bridge vlan del dev eth2 vid 20, or dev br-tenant vid 20 self
+	self := o.Interface == tenantbridgeName
+	return s.nLink.BridgeVlanDel(ctx, link, o.Vlan, false, false, self, false)
+}
+
+// StartOrphanSweeper looks every interval for the orphaned interfaces and bridge vlans, see
+// findOrphans, until ctx is done, and logs them or, with OrphanRemove, deletes those which
+// were already orphaned at the previous sweep
+func (s *Server) StartOrphanSweeper(ctx context.Context, interval time.Duration, policy OrphanPolicy) {
+	if policy == OrphanOff {
+		return
+	}
+	w := newOrphanSweeper()
+	s.orphans = w
+	go func() {
+		s.sweepOrphans(ctx, w, policy)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.sweepOrphans(ctx, w, policy)
+			}
+		}
+	}()
+}
+
+// sweepOrphans runs a sweep, the standby instance leaves the dataplane to the leader
+func (s *Server) sweepOrphans(ctx context.Context, w *orphanSweeper, policy OrphanPolicy) {
+	if s.IsStandby() {
+		return
+	}
+	orphans, err := s.findOrphans(ctx)
+	if err != nil {
+		log.Printf("Failed to look for orphaned interfaces: %v", err)
+		return
+	}
+	before := w.observe(orphans)
+	for i, o := range orphans {
+		if policy != OrphanRemove || !before[i] {
+			log.Printf("Found orphaned %s %s, no object is backed by it", o.Type, o.key())
+			continue
+		}
+		if err := s.removeOrphan(ctx, o); err != nil {
+			log.Printf("Failed to remove orphaned %s %s: %v", o.Type, o.key(), err)
+			continue
+		}
+		log.Printf("Removed orphaned %s %s, found at %v", o.Type, o.key(), o.FirstSeen)
+	}
+}
+
+// GetOrphans lists the interfaces and bridge vlans no stored object is backed by, when the
+// sweeper runs with the time it first found them. Tenants cannot list them
+func (s *Server) GetOrphans(ctx context.Context, _ *GetOrphansRequest) (*GetOrphansResponse, error) {
+	if tenant := utils.TenantFromContext(ctx); tenant != "" {
+		return nil, status.Errorf(codes.PermissionDenied, "tenant %s cannot list the orphaned interfaces", tenant)
+	}
+	orphans, err := s.findOrphans(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if w := s.orphans; w != nil {
+		w.mu.Lock()
+		for i := range orphans {
+			orphans[i].FirstSeen = w.firstSeen[orphans[i].key()]
+		}
+		w.mu.Unlock()
+	}
+	return &GetOrphansResponse{Orphans: orphans}, nil
+}
@@ -0,0 +1,108 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/philippgille/gokv/gomap"
+	"github.com/vishvananda/netlink"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+	pc "github.com/opiproject/opi-api/network/opinetcommon/v1alpha1/gen/go"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/fake"
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils"
+)
+
+func Test_SweepOrphans(t *testing.T) {
+	ctx := context.Background()
+	nLink := fake.NewNetlink("eth1", "eth2")
+	opi := NewServerWithArgs(nLink, fake.NewFrr(), gomap.NewStore(gomap.DefaultOptions))
+	vni := uint32(10)
+	vtep := &pc.IPPrefix{Addr: &pc.IPAddress{Af: pc.IpAf_IP_AF_INET, V4OrV6: &pc.IPAddress_V4Addr{V4Addr: 0x0a000001}}, Len: 32}
+	bridge, err := opi.CreateLogicalBridge(ctx, &pb.CreateLogicalBridgeRequest{LogicalBridgeId: "vlan10", LogicalBridge: &pb.LogicalBridge{Spec: &pb.LogicalBridgeSpec{VlanId: 10, Vni: &vni, VtepIpPrefix: vtep}}})
+	if err != nil {
+		t.Fatal("CreateLogicalBridge: expected", nil, "received", err)
+	}
+	spec := &pb.BridgePortSpec{MacAddress: []byte{0x02, 0x00, 0x00, 0x00, 0x00, 0x01}, Ptype: pb.BridgePortType_ACCESS, LogicalBridges: []string{bridge.Name}}
+	if _, err := opi.CreateBridgePort(ctx, &pb.CreateBridgePortRequest{BridgePortId: "eth1", BridgePort: &pb.BridgePort{Spec: spec}}); err != nil {
+		t.Fatal("CreateBridgePort: expected", nil, "received", err)
+	}
+
+	// left over by a crash: a Vrf, a LogicalBridge and its vlan on the port, the vlan of a Svi
+	tenant, _ := nLink.LinkByName(ctx, tenantbridgeName)
+	port, _ := nLink.LinkByName(ctx, "eth1")
+	stale := &netlink.Vxlan{LinkAttrs: netlink.LinkAttrs{Name: "vni20", Alias: managedAlias}, VxlanId: 20}
+	for _, err := range []error{
+		nLink.LinkAdd(ctx, &netlink.Vrf{LinkAttrs: netlink.LinkAttrs{Name: "blue", Alias: managedAlias}, Table: 1005}),
+		nLink.LinkAdd(ctx, stale),
+		nLink.LinkSetMaster(ctx, stale, tenant),
+		nLink.BridgeVlanAdd(ctx, stale, 20, true, true, false, false),
+		nLink.BridgeVlanAdd(ctx, port, 20, false, false, false, false),
+		nLink.BridgeVlanAdd(ctx, tenant, 30, false, false, true, false),
+		// not named like the devices of the gateway
+		nLink.LinkAdd(ctx, &netlink.Dummy{LinkAttrs: netlink.LinkAttrs{Name: "dummy0"}}),
+		// named like the devices of the gateway, but not created by it
+		nLink.LinkAdd(ctx, &netlink.Vrf{LinkAttrs: netlink.LinkAttrs{Name: "green"}, Table: 1006}),
+	} {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	expected := []Orphan{
+		{Interface: "blue", Type: "vrf"},
+		{Interface: tenantbridgeName, Type: "bridge", Vlan: 30},
+		{Interface: "eth1", Type: "device", Vlan: 20},
+		{Interface: "vni20", Type: "vxlan"},
+	}
+	response, err := opi.GetOrphans(ctx, &GetOrphansRequest{})
+	if err != nil || !reflect.DeepEqual(response.Orphans, expected) {
+		t.Fatal("orphans: expected", expected, "received", response, err)
+	}
+
+	// the first sweep finds them, the next one removes those still orphaned
+	w := newOrphanSweeper()
+	opi.orphans = w
+	opi.sweepOrphans(ctx, w, OrphanRemove)
+	if response, _ := opi.GetOrphans(ctx, &GetOrphansRequest{}); len(response.Orphans) != len(expected) || response.Orphans[0].FirstSeen.IsZero() {
+		t.Error("first sweep: expected", len(expected), "orphans found, received", response.Orphans)
+	}
+	opi.sweepOrphans(ctx, w, OrphanRemove)
+	if response, err := opi.GetOrphans(ctx, &GetOrphansRequest{}); err != nil || len(response.Orphans) != 0 {
+		t.Error("second sweep: expected none, received", response, err)
+	}
+	for _, name := range []string{"vni10", "eth1", "dummy0", "green"} {
+		if _, err := nLink.LinkByName(ctx, name); err != nil {
+			t.Error(name, ": expected kept, received", err)
+		}
+	}
+	vlans, _ := nLink.BridgeVlanList(ctx)
+	if infos := vlans[int32(port.Attrs().Index)]; len(infos) != 1 || infos[0].Vid != 10 {
+		t.Error("port vlans: expected [10], received", infos)
+	}
+
+	tenantCtx := metadata.NewIncomingContext(ctx, metadata.Pairs(utils.TenantMetadataKey, "acme"))
+	if _, err := opi.GetOrphans(tenantCtx, &GetOrphansRequest{}); status.Code(err) != codes.PermissionDenied {
+		t.Error("tenant: expected", codes.PermissionDenied, "received", err)
+	}
+}
+
+func Test_ParseOrphanPolicy(t *testing.T) {
+	for _, value := range []string{"off", "report", "remove"} {
+		if policy, err := ParseOrphanPolicy(value); err != nil || string(policy) != value {
+			t.Error(value, ": expected", value, "received", policy, err)
+		}
+	}
+	if _, err := ParseOrphanPolicy("delete"); err == nil {
+		t.Error("delete: expected an error, received", nil)
+	}
+}
@@ -0,0 +1,188 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils"
+)
+
+const (
+	// paginationKey is the store key of the page tokens, so List iterations survive restarts
+	paginationKey = "pagination"
+	// defaultPageTokenTTL is how long a page token can be used after it was returned
+	defaultPageTokenTTL = time.Hour
+)
+
+// loadPageTokens returns the stored page tokens, each one a Struct with its offset and expiry
+func (s *Server) loadPageTokens() (*structpb.Struct, error) {
+	tokens := &structpb.Struct{}
+	if _, err := s.store.Get(paginationKey, tokens); err != nil {
+		return nil, err
+	}
+	if tokens.Fields == nil {
+		tokens.Fields = make(map[string]*structpb.Value)
+	}
+	return tokens, nil
+}
+
+// savePageToken stores the offset the next page of a List call starts at
+func (s *Server) savePageToken(token string, offset int, expires time.Time) error {
+	s.paginationMu.Lock()
+	defer s.paginationMu.Unlock()
+	tokens, err := s.loadPageTokens()
+	if err != nil {
+		return err
+	}
+	// expired tokens are kept for another TTL, to tell the clients their token expired
+	// instead of not finding it, and then garbage-collected
+	now := time.Now()
+	for name, value := range tokens.Fields {
+		if pageTokenExpiry(value).Add(s.PageTokenTTL).Before(now) {
+			delete(tokens.Fields, name)
+		}
+	}
+	tokens.Fields[token] = structpb.NewStructValue(&structpb.Struct{Fields: map[string]*structpb.Value{
+		"offset":  structpb.NewNumberValue(float64(offset)),
+		"expires": structpb.NewNumberValue(float64(expires.Unix())),
+	}})
+	return s.store.Set(paginationKey, tokens)
+}
+
+// nextPageToken returns the token of the page starting at offset, empty when there is none
+func (s *Server) nextPageToken(hasMoreElements bool, offset int) (string, error) {
+	if !hasMoreElements {
+		return "", nil
+	}
+	token := uuid.New().String()
+	if err := s.savePageToken(token, offset, time.Now().Add(s.PageTokenTTL)); err != nil {
+		return "", status.Errorf(codes.Internal, "unable to store pagination token: %v", err)
+	}
+	return token, nil
+}
+
+// pageTokenOffset returns the offset stored for the token, see https://google.aip.dev/158
+func (s *Server) pageTokenOffset(token string) (int, error) {
+	s.paginationMu.Lock()
+	defer s.paginationMu.Unlock()
+	tokens, err := s.loadPageTokens()
+	if err != nil {
+		return -1, status.Errorf(codes.Internal, "unable to load pagination token %s: %v", token, err)
+	}
+	value, ok := tokens.Fields[token]
+	if !ok {
+		return -1, status.Errorf(codes.NotFound, "unable to find pagination token %s", token)
+	}
+	if pageTokenExpiry(value).Before(time.Now()) {
+		return -1, status.Errorf(codes.InvalidArgument, "pagination token %s expired, restart the listing", token)
+	}
+	offset := int(value.GetStructValue().GetFields()["offset"].GetNumberValue())
+	log.Printf("Found offset %d from pagination token: %s", offset, token)
+	return offset, nil
+}
+
+func pageTokenExpiry(value *structpb.Value) time.Time {
+	return time.Unix(int64(value.GetStructValue().GetFields()["expires"].GetNumberValue()), 0)
+}
+
+// listSnapshot is the full result of the first page of a List call, the following pages
+// are cut from it so a multi-page listing reflects a single point in time
+type listSnapshot struct {
+	scope   string
+	items   interface{}
+	expires time.Time
+}
+
+// listSnapshotSet keeps the List snapshots by the page tokens returned with them
+type listSnapshotSet struct {
+	mu      sync.Mutex
+	byToken map[string]listSnapshot
+}
+
+func newListSnapshotSet() *listSnapshotSet {
+	return &listSnapshotSet{byToken: make(map[string]listSnapshot)}
+}
+
+// listScope identifies what a List call lists, a page token only continues a listing of the
+// same collection and parent, with the same label selector and in the same tenant
+func listScope(ctx context.Context, collection string, parent string) string {
+	selector, _ := utils.MetadataValue(ctx, utils.LabelSelectorMetadataKey)
+	return strings.Join([]string{collection, parent, selector, utils.TenantFromContext(ctx)}, "\x00")
+}
+
+// save keeps the snapshot for the token, forgetting the expired ones
+func (l *listSnapshotSet) save(token string, scope string, items interface{}, expires time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now()
+	for name, snapshot := range l.byToken {
+		if snapshot.expires.Before(now) {
+			delete(l.byToken, name)
+		}
+	}
+	l.byToken[token] = listSnapshot{scope: scope, items: items, expires: expires}
+}
+
+func (l *listSnapshotSet) find(token string) (listSnapshot, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	snapshot, ok := l.byToken[token]
+	return snapshot, ok
+}
+
+// listPage returns the page of the sorted objects returned by list and the token of the
+// next page. The pages following the first one are cut from the snapshot taken by the first
+// page, list is only called again when the snapshot was lost, e.g. after a restart. A token
+// returned by another listing fails with InvalidArgument
+func listPage[T any](ctx context.Context, s *Server, collection string, parent string, pageToken string, offset int, size int, list func() []T) ([]T, string, error) {
+	scope := listScope(ctx, collection, parent)
+	var all []T
+	if snapshot, ok := s.listSnapshots.find(pageToken); ok && pageToken != "" {
+		items, ok := snapshot.items.([]T)
+		if !ok || snapshot.scope != scope {
+			msg := fmt.Sprintf("pagination token %s was returned by another listing, restart the listing", pageToken)
+			return nil, "", badRequest("page_token", status.Error(codes.InvalidArgument, msg))
+		}
+		all = items
+	} else {
+		all = list()
+	}
+	log.Printf("Limiting result len(%d) to [%d:%d]", len(all), offset, size)
+	if offset > len(all) {
+		offset = len(all)
+	}
+	page, hasMoreElements := limitPagination(all, offset, size)
+	token, err := s.nextPageToken(hasMoreElements, offset+size)
+	if err != nil {
+		return nil, "", err
+	}
+	if token != "" {
+		s.listSnapshots.save(token, scope, all, time.Now().Add(s.PageTokenTTL))
+	}
+	return page, token, nil
+}
+
+// clonePage copies the objects of a page, so the caller can set their status without changing
+// the snapshot the following pages are cut from
+func clonePage[T proto.Message](page []T) []T {
+	clones := make([]T, len(page))
+	for i, obj := range page {
+		clones[i] = protoClone(obj)
+	}
+	return clones
+}
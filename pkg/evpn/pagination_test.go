@@ -0,0 +1,203 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/philippgille/gokv/gomap"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/fake"
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils"
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils/mocks"
+)
+
+func Test_PageTokens(t *testing.T) {
+	store := gomap.NewStore(gomap.DefaultOptions)
+	opi := NewServerWithArgs(mocks.NewNetlink(t), mocks.NewFrr(t), store)
+	token, err := opi.nextPageToken(true, 5)
+	if err != nil {
+		t.Fatal("error: expected", nil, "received", err)
+	}
+	if err := opi.savePageToken("expired-token", 1, time.Now().Add(-time.Minute)); err != nil {
+		t.Fatal("error: expected", nil, "received", err)
+	}
+	if err := opi.savePageToken("collected-token", 1, time.Now().Add(-2*opi.PageTokenTTL)); err != nil {
+		t.Fatal("error: expected", nil, "received", err)
+	}
+	if err := opi.savePageToken("other-token", 1, time.Now().Add(time.Minute)); err != nil {
+		t.Fatal("error: expected", nil, "received", err)
+	}
+
+	// the tokens are kept in the store, so a restarted server still finds them
+	restarted := NewServerWithArgs(mocks.NewNetlink(t), mocks.NewFrr(t), store)
+	tests := map[string]struct {
+		token   string
+		offset  int
+		errCode codes.Code
+	}{
+		"valid token": {
+			token:   token,
+			offset:  5,
+			errCode: codes.OK,
+		},
+		"expired token": {
+			token:   "expired-token",
+			offset:  -1,
+			errCode: codes.InvalidArgument,
+		},
+		"garbage-collected token": {
+			token:   "collected-token",
+			offset:  -1,
+			errCode: codes.NotFound,
+		},
+	}
+	for testName, tt := range tests {
+		t.Run(testName, func(t *testing.T) {
+			offset, err := restarted.pageTokenOffset(tt.token)
+			if status.Code(err) != tt.errCode {
+				t.Error("error code: expected", tt.errCode, "received", err)
+			}
+			if offset != tt.offset {
+				t.Error("offset: expected", tt.offset, "received", offset)
+			}
+		})
+	}
+
+	if _, err := restarted.ListVrfs(context.Background(), &pb.ListVrfsRequest{PageToken: "expired-token"}); status.Code(err) != codes.InvalidArgument {
+		t.Error("error code: expected", codes.InvalidArgument, "received", err)
+	}
+}
+
+func Test_ListSnapshot(t *testing.T) {
+	opi := NewServerWithArgs(mocks.NewNetlink(t), mocks.NewFrr(t), gomap.NewStore(gomap.DefaultOptions))
+	for _, id := range []string{"leak2", "leak3", "leak4"} {
+		name := resourceIDToFullName("routeleaks", id)
+		opi.RouteLeaks[name] = &RouteLeak{Name: name, Spec: &RouteLeakSpec{}}
+	}
+	response, err := opi.ListRouteLeaks(context.Background(), &ListRouteLeaksRequest{PageSize: 1})
+	if err != nil {
+		t.Fatal("error: expected", nil, "received", err)
+	}
+	listed := []string{response.RouteLeaks[0].Name}
+
+	// changes made during the listing do not shift the following pages
+	added := resourceIDToFullName("routeleaks", "leak1")
+	opi.RouteLeaks[added] = &RouteLeak{Name: added, Spec: &RouteLeakSpec{}}
+	delete(opi.RouteLeaks, resourceIDToFullName("routeleaks", "leak3"))
+	for response.NextPageToken != "" {
+		response, err = opi.ListRouteLeaks(context.Background(), &ListRouteLeaksRequest{PageSize: 1, PageToken: response.NextPageToken})
+		if err != nil {
+			t.Fatal("error: expected", nil, "received", err)
+		}
+		for _, leak := range response.RouteLeaks {
+			listed = append(listed, leak.Name)
+		}
+	}
+	expected := []string{
+		resourceIDToFullName("routeleaks", "leak2"),
+		resourceIDToFullName("routeleaks", "leak3"),
+		resourceIDToFullName("routeleaks", "leak4"),
+	}
+	if !reflect.DeepEqual(listed, expected) {
+		t.Error("route leaks: expected", expected, "received", listed)
+	}
+}
+
+func Test_ListSnapshotScope(t *testing.T) {
+	opi := NewServerWithArgs(mocks.NewNetlink(t), mocks.NewFrr(t), gomap.NewStore(gomap.DefaultOptions))
+	for _, id := range []string{"leak1", "leak2"} {
+		name := resourceIDToFullName("routeleaks", id)
+		opi.RouteLeaks[name] = &RouteLeak{Name: name, Spec: &RouteLeakSpec{}}
+	}
+	response, err := opi.ListRouteLeaks(context.Background(), &ListRouteLeaksRequest{PageSize: 1})
+	if err != nil || response.NextPageToken == "" {
+		t.Fatal("error: expected", nil, "received", response, err)
+	}
+	token := response.NextPageToken
+
+	// the token of the route leaks does not continue another listing
+	tests := map[string]func() error{
+		"another collection": func() error {
+			_, err := opi.ListRoutes(context.Background(), &ListRoutesRequest{PageSize: 1, PageToken: token})
+			return err
+		},
+		"another tenant": func() error {
+			_, err := opi.ListRouteLeaks(tenantContext("acme"), &ListRouteLeaksRequest{PageSize: 1, PageToken: token})
+			return err
+		},
+		"another selector": func() error {
+			ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(utils.LabelSelectorMetadataKey, "env=prod"))
+			_, err := opi.ListRouteLeaks(ctx, &ListRouteLeaksRequest{PageSize: 1, PageToken: token})
+			return err
+		},
+	}
+	for testName, call := range tests {
+		t.Run(testName, func(t *testing.T) {
+			if err := call(); status.Code(err) != codes.InvalidArgument {
+				t.Error("error code: expected", codes.InvalidArgument, "received", err)
+			}
+		})
+	}
+	if _, err := opi.ListRouteLeaks(context.Background(), &ListRouteLeaksRequest{PageSize: 1, PageToken: token}); err != nil {
+		t.Error("error: expected", nil, "received", err)
+	}
+}
+
+func Test_ListConcurrentCreate(t *testing.T) {
+	ctx := context.Background()
+	opi := NewServerWithArgs(fake.NewNetlink(), fake.NewFrr(), gomap.NewStore(gomap.DefaultOptions))
+
+	// the LogicalBridges are created while they are being listed
+	const count = 20
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < count; i++ {
+			request := &pb.CreateLogicalBridgeRequest{LogicalBridgeId: fmt.Sprintf("vlan%d", 10+i), LogicalBridge: &pb.LogicalBridge{Spec: &pb.LogicalBridgeSpec{VlanId: uint32(10 + i)}}}
+			if _, err := opi.CreateLogicalBridge(ctx, request); err != nil {
+				t.Error("error: expected", nil, "received", err)
+			}
+		}
+	}()
+	for listed := -1; listed != count; {
+		// the pages of a listing come from its first page, without duplicates nor gaps
+		names := []string{}
+		token := ""
+		for {
+			response, err := opi.ListLogicalBridges(ctx, &pb.ListLogicalBridgesRequest{PageSize: 3, PageToken: token})
+			if err != nil {
+				t.Fatal("error: expected", nil, "received", err)
+			}
+			for _, bridge := range response.LogicalBridges {
+				names = append(names, bridge.Name)
+			}
+			if token = response.NextPageToken; token == "" {
+				break
+			}
+		}
+		if !sort.StringsAreSorted(names) {
+			t.Fatal("listing: expected sorted, received", names)
+		}
+		for i := 1; i < len(names); i++ {
+			if names[i] == names[i-1] {
+				t.Fatal("listing: expected no duplicates, received", names)
+			}
+		}
+		listed = len(names)
+	}
+	<-done
+}
@@ -0,0 +1,221 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	pc "github.com/opiproject/opi-api/network/opinetcommon/v1alpha1/gen/go"
+
+	"go.einride.tech/aip/resourceid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// PbrRule is a policy-based routing rule steering the IPv4 packets it matches to the
+// routing table of a Vrf, whatever their destination, child resource of the Vrf
+// TODO: move to opi-api once the message is agreed upon
+type PbrRule struct {
+	Name string
+	Spec *PbrRuleSpec
+}
+
+// PbrRuleSpec is the desired configuration of a PbrRule, at least one match is needed
+type PbrRuleSpec struct {
+	// Priority orders the rules, lower first, unique among the PbrRules
+	Priority uint32
+	// SourcePrefix matches the source address of the packets, optional
+	SourcePrefix *pc.IPPrefix
+	// Fwmark matches the firewall mark of the packets, e.g. set by nftables, optional
+	Fwmark uint32
+	// FwmarkMask is the bits of the mark compared to Fwmark, all of them when 0
+	FwmarkMask uint32
+	// InInterface is the kernel name of the interface the packets are received on, optional
+	InInterface string
+}
+
+// CreatePbrRuleRequest is the request to create a PbrRule into a Vrf
+type CreatePbrRuleRequest struct {
+	// Parent is the name of the Vrf
+	Parent    string
+	PbrRuleID string
+	PbrRule   *PbrRule
+}
+
+// DeletePbrRuleRequest is the request to delete a PbrRule
+type DeletePbrRuleRequest struct {
+	Name         string
+	AllowMissing bool
+}
+
+// ListPbrRulesRequest is the request to list the PbrRules into a Vrf
+type ListPbrRulesRequest struct {
+	// Parent is the name of the Vrf
+	Parent    string
+	PageSize  int32
+	PageToken string
+}
+
+// ListPbrRulesResponse is the response of listing PbrRules
+type ListPbrRulesResponse struct {
+	PbrRules      []*PbrRule
+	NextPageToken string
+}
+
+func (r *PbrRule) clone() *PbrRule {
+	if r == nil {
+		return nil
+	}
+	c := &PbrRule{Name: r.Name}
+	if r.Spec != nil {
+		spec := *r.Spec
+		if r.Spec.SourcePrefix != nil {
+			spec.SourcePrefix = protoClone(r.Spec.SourcePrefix)
+		}
+		c.Spec = &spec
+	}
+	return c
+}
+
+func sortPbrRules(rules []*PbrRule) {
+	sort.Slice(rules, func(i int, j int) bool {
+		return rules[i].Name < rules[j].Name
+	})
+}
+
+func pbrRuleParent(name string) string {
+	// path.Dir would collapse the leading "//" of the full resource name
+	return name[:strings.LastIndex(name, "/pbrrules/")]
+}
+
+// CreatePbrRule executes the creation of the policy-based routing rule
+func (s *Server) CreatePbrRule(ctx context.Context, in *CreatePbrRuleRequest) (*PbrRule, error) {
+	// check input correctness
+	if err := s.validateCreatePbrRuleRequest(in); err != nil {
+		return nil, err
+	}
+	// see https://google.aip.dev/133#user-specified-ids
+	resourceID := resourceid.NewSystemGenerated()
+	if in.PbrRuleID != "" {
+		log.Printf("client provided the ID of a resource %v, ignoring the name field %v", in.PbrRuleID, in.PbrRule.Name)
+		resourceID = in.PbrRuleID
+	}
+	in.PbrRule.Name = fmt.Sprintf("%s/pbrrules/%s", in.Parent, resourceID)
+	s.objectsMu.Lock()
+	defer s.objectsMu.Unlock()
+	// idempotent API when called with same key, should return same object
+	obj, ok := s.PbrRules[in.PbrRule.Name]
+	if ok && inTenant(ctx, in.Parent) {
+		// a different spec under the same key is a conflict, not a retry
+		if err := checkSameChildSpec(obj.Name, obj.Spec, in.PbrRule.Spec); err != nil {
+			return nil, err
+		}
+		log.Printf("Already existing PbrRule with id %v", in.PbrRule.Name)
+		return obj.clone(), nil
+	}
+	// now get Vrf to steer the packets into
+	vrf, ok := s.Vrfs[in.Parent]
+	if !ok || !inTenant(ctx, in.Parent) {
+		err := status.Errorf(codes.NotFound, "unable to find key %s", in.Parent)
+		return nil, err
+	}
+	// the priority identifies the kernel rule
+	for _, rule := range s.PbrRules {
+		if rule.Spec.Priority == in.PbrRule.Spec.Priority {
+			err := status.Errorf(codes.AlreadyExists, "priority %d is already used by %s", rule.Spec.Priority, rule.Name)
+			return nil, err
+		}
+	}
+	if err := s.dataplane.CreatePbrRule(ctx, in.PbrRule, vrf); err != nil {
+		s.forgetStatus(in.PbrRule.Name)
+		return nil, status.Convert(err).Err()
+	}
+	// save object to the database
+	response := in.PbrRule.clone()
+	s.PbrRules[in.PbrRule.Name] = response
+	persistObjects(s, "pbrrules", s.PbrRules)
+	return response.clone(), nil
+}
+
+// DeletePbrRule deletes the policy-based routing rule, the packets it matched follow the
+// destination routing again
+func (s *Server) DeletePbrRule(ctx context.Context, in *DeletePbrRuleRequest) (*emptypb.Empty, error) {
+	// check input correctness
+	if err := s.validateDeletePbrRuleRequest(in); err != nil {
+		return nil, err
+	}
+	s.objectsMu.Lock()
+	defer s.objectsMu.Unlock()
+	return s.deletePbrRule(ctx, in)
+}
+
+// deletePbrRule deletes a validated policy-based routing rule, with objectsMu held
+func (s *Server) deletePbrRule(ctx context.Context, in *DeletePbrRuleRequest) (*emptypb.Empty, error) {
+	// fetch object from the database
+	obj, ok := s.PbrRules[in.Name]
+	if !ok || !inTenant(ctx, in.Name) {
+		if in.AllowMissing {
+			return &emptypb.Empty{}, nil
+		}
+		err := status.Errorf(codes.NotFound, "unable to find key %s", in.Name)
+		return nil, err
+	}
+	// fetch object from the database
+	parent := pbrRuleParent(obj.Name)
+	vrf, ok := s.Vrfs[parent]
+	if !ok {
+		err := status.Errorf(codes.NotFound, "unable to find key %s", parent)
+		return nil, err
+	}
+	if err := s.dataplane.DeletePbrRule(ctx, obj, vrf); err != nil {
+		return nil, status.Convert(err).Err()
+	}
+	// remove from the Database
+	delete(s.PbrRules, obj.Name)
+	persistObjects(s, "pbrrules", s.PbrRules)
+	s.forgetStatus(obj.Name)
+	return &emptypb.Empty{}, nil
+}
+
+// ListPbrRules lists the policy-based routing rules into a Vrf
+func (s *Server) ListPbrRules(ctx context.Context, in *ListPbrRulesRequest) (*ListPbrRulesResponse, error) {
+	// check input correctness
+	if err := s.validateListPbrRulesRequest(in); err != nil {
+		return nil, err
+	}
+	if !inTenant(ctx, in.Parent) {
+		err := status.Errorf(codes.NotFound, "unable to find key %s", in.Parent)
+		return nil, err
+	}
+	// fetch pagination from the database, calculate size and offset
+	size, offset, perr := s.extractPagination(in.PageSize, in.PageToken)
+	if perr != nil {
+		return nil, perr
+	}
+	s.objectsMu.RLock()
+	defer s.objectsMu.RUnlock()
+	// fetch object from the database, the following pages are cut from the snapshot of the first one
+	Blobarray, token, err := listPage(ctx, s, "pbrRules", in.Parent, in.PageToken, offset, size, func() []*PbrRule {
+		Blobarray := []*PbrRule{}
+		for _, rule := range s.PbrRules {
+			if pbrRuleParent(rule.Name) != in.Parent {
+				continue
+			}
+			Blobarray = append(Blobarray, rule.clone())
+		}
+		// sort is needed, since MAP is unsorted in golang, and we might get different results
+		sortPbrRules(Blobarray)
+		return Blobarray
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &ListPbrRulesResponse{PbrRules: Blobarray, NextPageToken: token}, nil
+}
@@ -0,0 +1,55 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/vishvananda/netlink"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+)
+
+func netlinkPbrRule(obj *PbrRule, vrf *pb.Vrf) *netlink.Rule {
+	rule := netlink.NewRule()
+	rule.Family = netlink.FAMILY_V4
+	rule.Priority = int(obj.Spec.Priority)
+	rule.Table = int(vrf.Status.GetRoutingTable())
+	if obj.Spec.SourcePrefix != nil {
+		rule.Src = ipPrefixToNet(obj.Spec.SourcePrefix)
+	}
+	if obj.Spec.Fwmark != 0 {
+		rule.Mark = int(obj.Spec.Fwmark)
+		if obj.Spec.FwmarkMask != 0 {
+			rule.Mask = int(obj.Spec.FwmarkMask)
+		}
+	}
+	rule.IifName = obj.Spec.InInterface
+	return rule
+}
+
+func (s *Server) netlinkCreatePbrRule(ctx context.Context, obj *PbrRule, vrf *pb.Vrf) error {
+	rule := netlinkPbrRule(obj, vrf)
+	// Example: ip rule add priority 100 from 10.0.0.0/24 fwmark 0x10/0xff iif eth2 table 1000
+	log.Printf("Creating PbrRule %v", rule)
+	if err := s.nLink.RuleAdd(ctx, rule); err != nil {
+		fmt.Printf("Failed to add rule: %v", err)
+		return err
+	}
+	return nil
+}
+
+func (s *Server) netlinkDeletePbrRule(ctx context.Context, obj *PbrRule, vrf *pb.Vrf) error {
+	rule := netlinkPbrRule(obj, vrf)
+	// Example: ip rule del priority 100 from 10.0.0.0/24 fwmark 0x10/0xff iif eth2 table 1000
+	log.Printf("Deleting PbrRule %v", rule)
+	if err := s.nLink.RuleDel(ctx, rule); err != nil {
+		fmt.Printf("Failed to delete rule: %v", err)
+		return err
+	}
+	return nil
+}
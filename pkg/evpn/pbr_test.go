@@ -0,0 +1,272 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"reflect"
+	"testing"
+
+	"github.com/philippgille/gokv/gomap"
+	"github.com/stretchr/testify/mock"
+	"github.com/vishvananda/netlink"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	pc "github.com/opiproject/opi-api/network/opinetcommon/v1alpha1/gen/go"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils/mocks"
+)
+
+var (
+	testPbrRuleID   = "opi-pbr8"
+	testPbrRuleName = fmt.Sprintf("%s/pbrrules/%s", testVrfName, testPbrRuleID)
+	testPbrRule     = PbrRule{
+		Spec: &PbrRuleSpec{
+			Priority:     100,
+			SourcePrefix: &pc.IPPrefix{Addr: &pc.IPAddress{Af: pc.IpAf_IP_AF_INET, V4OrV6: &pc.IPAddress_V4Addr{V4Addr: 167772160}}, Len: 24},
+			Fwmark:       0x10,
+			FwmarkMask:   0xff,
+			InInterface:  "eth2",
+		},
+	}
+	testPbrRuleWithName = PbrRule{
+		Name: testPbrRuleName,
+		Spec: testPbrRule.Spec,
+	}
+)
+
+// testPbrRuleKernel matches the kernel rule of testPbrRule
+func testPbrRuleKernel(rule *netlink.Rule) bool {
+	return rule.Family == netlink.FAMILY_V4 && rule.Priority == 100 && rule.Table == 1000 &&
+		rule.Src.String() == "10.0.0.0/24" && rule.Mark == 0x10 && rule.Mask == 0xff && rule.IifName == "eth2"
+}
+
+func Test_CreatePbrRule(t *testing.T) {
+	tests := map[string]struct {
+		id      string
+		in      *PbrRule
+		out     *PbrRule
+		errCode codes.Code
+		errMsg  string
+		exist   bool
+		on      func(mockNetlink *mocks.Netlink, errMsg string)
+	}{
+		"no required priority field": {
+			id:      testPbrRuleID,
+			in:      &PbrRule{Spec: &PbrRuleSpec{InInterface: "eth2"}},
+			out:     nil,
+			errCode: codes.InvalidArgument,
+			errMsg:  "missing required field: pbr_rule.spec.priority",
+			exist:   false,
+			on:      nil,
+		},
+		"priority of the main table": {
+			id:      testPbrRuleID,
+			in:      &PbrRule{Spec: &PbrRuleSpec{Priority: 32766, InInterface: "eth2"}},
+			out:     nil,
+			errCode: codes.InvalidArgument,
+			errMsg:  fmt.Sprintf("Priority (%d) have to be between 1 and %d", 32766, pbrMaxPriority),
+			exist:   false,
+			on:      nil,
+		},
+		"no match": {
+			id:      testPbrRuleID,
+			in:      &PbrRule{Spec: &PbrRuleSpec{Priority: 100}},
+			out:     nil,
+			errCode: codes.InvalidArgument,
+			errMsg:  "pbr_rule must match a source_prefix, a fwmark or an in_interface",
+			exist:   false,
+			on:      nil,
+		},
+		"already exists": {
+			id:      testPbrRuleID,
+			in:      &testPbrRule,
+			out:     &testPbrRuleWithName,
+			errCode: codes.OK,
+			errMsg:  "",
+			exist:   true,
+			on:      nil,
+		},
+		"already exists with a different spec": {
+			id:      testPbrRuleID,
+			in:      &PbrRule{Spec: &PbrRuleSpec{Priority: 200, InInterface: "eth2"}},
+			out:     nil,
+			errCode: codes.AlreadyExists,
+			errMsg:  fmt.Sprintf("%s already exists with a different spec", testPbrRuleName),
+			exist:   true,
+			on:      nil,
+		},
+		"priority in use": {
+			id:      "opi-pbr9",
+			in:      &testPbrRule,
+			out:     nil,
+			errCode: codes.AlreadyExists,
+			errMsg:  fmt.Sprintf("priority 100 is already used by %v", testPbrRuleName),
+			exist:   true,
+			on:      nil,
+		},
+		"failed RuleAdd call": {
+			id:      testPbrRuleID,
+			in:      &testPbrRule,
+			out:     nil,
+			errCode: codes.Unknown,
+			errMsg:  "Failed to call RuleAdd",
+			exist:   false,
+			on: func(mockNetlink *mocks.Netlink, errMsg string) {
+				mockNetlink.EXPECT().RuleAdd(mock.Anything, mock.MatchedBy(testPbrRuleKernel)).Return(errors.New(errMsg)).Once()
+			},
+		},
+		"successful call": {
+			id:      testPbrRuleID,
+			in:      &testPbrRule,
+			out:     &testPbrRuleWithName,
+			errCode: codes.OK,
+			errMsg:  "",
+			exist:   false,
+			on: func(mockNetlink *mocks.Netlink, errMsg string) {
+				mockNetlink.EXPECT().RuleAdd(mock.Anything, mock.MatchedBy(testPbrRuleKernel)).Return(nil).Once()
+			},
+		},
+	}
+
+	// run tests
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			mockNetlink := mocks.NewNetlink(t)
+			mockFrr := mocks.NewFrr(t)
+			store := gomap.NewStore(gomap.DefaultOptions)
+			opi := NewServerWithArgs(mockNetlink, mockFrr, store)
+
+			opi.Vrfs[testVrfName] = protoClone(&testVrfWithStatus)
+			opi.Vrfs[testVrfName].Status.RoutingTable = 1000
+			if tt.exist {
+				opi.PbrRules[testPbrRuleName] = testPbrRuleWithName.clone()
+			}
+			if tt.on != nil {
+				tt.on(mockNetlink, tt.errMsg)
+			}
+
+			request := &CreatePbrRuleRequest{Parent: testVrfName, PbrRule: tt.in.clone(), PbrRuleID: tt.id}
+			response, err := opi.CreatePbrRule(ctx, request)
+			if !reflect.DeepEqual(tt.out, response) {
+				t.Error("response: expected", tt.out, "received", response)
+			}
+
+			if er, ok := status.FromError(err); ok {
+				if er.Code() != tt.errCode {
+					t.Error("error code: expected", tt.errCode, "received", er.Code())
+				}
+				if er.Message() != tt.errMsg {
+					t.Error("error message: expected", tt.errMsg, "received", er.Message())
+				}
+			} else {
+				t.Error("expected grpc error status")
+			}
+		})
+	}
+}
+
+func Test_DeletePbrRule(t *testing.T) {
+	tests := map[string]struct {
+		in      string
+		out     *emptypb.Empty
+		errCode codes.Code
+		errMsg  string
+		missing bool
+		on      func(mockNetlink *mocks.Netlink, errMsg string)
+	}{
+		"valid request with unknown key": {
+			in:      "unknown-id",
+			out:     nil,
+			errCode: codes.NotFound,
+			errMsg:  fmt.Sprintf("unable to find key %v", fmt.Sprintf("%s/pbrrules/%s", testVrfName, "unknown-id")),
+			missing: false,
+			on:      nil,
+		},
+		"unknown key with missing allowed": {
+			in:      "unknown-id",
+			out:     &emptypb.Empty{},
+			errCode: codes.OK,
+			errMsg:  "",
+			missing: true,
+			on:      nil,
+		},
+		"failed RuleDel call": {
+			in:      testPbrRuleID,
+			out:     nil,
+			errCode: codes.Unknown,
+			errMsg:  "Failed to call RuleDel",
+			missing: false,
+			on: func(mockNetlink *mocks.Netlink, errMsg string) {
+				mockNetlink.EXPECT().RuleDel(mock.Anything, mock.MatchedBy(testPbrRuleKernel)).Return(errors.New(errMsg)).Once()
+			},
+		},
+		"successful call": {
+			in:      testPbrRuleID,
+			out:     &emptypb.Empty{},
+			errCode: codes.OK,
+			errMsg:  "",
+			missing: false,
+			on: func(mockNetlink *mocks.Netlink, errMsg string) {
+				mockNetlink.EXPECT().RuleDel(mock.Anything, mock.MatchedBy(testPbrRuleKernel)).Return(nil).Once()
+			},
+		},
+	}
+
+	// run tests
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			mockNetlink := mocks.NewNetlink(t)
+			mockFrr := mocks.NewFrr(t)
+			store := gomap.NewStore(gomap.DefaultOptions)
+			opi := NewServerWithArgs(mockNetlink, mockFrr, store)
+
+			opi.Vrfs[testVrfName] = protoClone(&testVrfWithStatus)
+			opi.Vrfs[testVrfName].Status.RoutingTable = 1000
+			opi.PbrRules[testPbrRuleName] = testPbrRuleWithName.clone()
+			if tt.on != nil {
+				tt.on(mockNetlink, tt.errMsg)
+			}
+
+			request := &DeletePbrRuleRequest{Name: fmt.Sprintf("%s/pbrrules/%s", testVrfName, tt.in), AllowMissing: tt.missing}
+			response, err := opi.DeletePbrRule(ctx, request)
+
+			if er, ok := status.FromError(err); ok {
+				if er.Code() != tt.errCode {
+					t.Error("error code: expected", tt.errCode, "received", er.Code())
+				}
+				if er.Message() != tt.errMsg {
+					t.Error("error message: expected", tt.errMsg, "received", er.Message())
+				}
+			} else {
+				t.Error("expected grpc error status")
+			}
+
+			if reflect.TypeOf(response) != reflect.TypeOf(tt.out) {
+				t.Error("response: expected", reflect.TypeOf(tt.out), "received", reflect.TypeOf(response))
+			}
+		})
+	}
+}
+
+func Test_netlinkPbrRule(t *testing.T) {
+	// a rule matching only the interface leaves the other selectors unset
+	rule := netlinkPbrRule(&PbrRule{Spec: &PbrRuleSpec{Priority: 200, InInterface: "eth3"}}, &testVrfWithStatus)
+	if rule.Src != nil || rule.Mark != -1 || rule.Mask != -1 || rule.IifName != "eth3" {
+		t.Error("rule: expected iif eth3 only, received", rule, rule.Mark, rule.Mask)
+	}
+	_, prefix, _ := net.ParseCIDR("10.0.0.0/24")
+	if src := netlinkPbrRule(&testPbrRuleWithName, &testVrfWithStatus).Src; src.String() != prefix.String() {
+		t.Error("src: expected", prefix, "received", src)
+	}
+}
@@ -0,0 +1,79 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"fmt"
+
+	"go.einride.tech/aip/resourcename"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// pbrMaxPriority is the last priority before the rules of the main and default tables
+const pbrMaxPriority = 32765
+
+func (s *Server) validateCreatePbrRuleRequest(in *CreatePbrRuleRequest) error {
+	// check required fields
+	switch {
+	case in.Parent == "":
+		return missingField("parent")
+	case in.PbrRule == nil:
+		return missingField("pbr_rule")
+	case in.PbrRule.Spec == nil:
+		return missingField("pbr_rule.spec")
+	case in.PbrRule.Spec.Priority == 0:
+		return missingField("pbr_rule.spec.priority")
+	}
+	spec := in.PbrRule.Spec
+	// the local table rule is at priority 0, the main table one at 32766
+	if spec.Priority > pbrMaxPriority {
+		msg := fmt.Sprintf("Priority (%d) have to be between 1 and %d", spec.Priority, pbrMaxPriority)
+		return badRequest("pbr_rule.spec.priority", status.Error(codes.InvalidArgument, msg))
+	}
+	// a rule without match would steer all the traffic of the host
+	if spec.SourcePrefix == nil && spec.Fwmark == 0 && spec.InInterface == "" {
+		msg := "pbr_rule must match a source_prefix, a fwmark or an in_interface"
+		return badRequest("pbr_rule.spec", status.Error(codes.InvalidArgument, msg))
+	}
+	if spec.SourcePrefix != nil && (spec.SourcePrefix.Addr == nil || spec.SourcePrefix.Len > 32) {
+		msg := fmt.Sprintf("Prefix length (%d) have to be between 0 and 32", spec.SourcePrefix.Len)
+		return badRequest("pbr_rule.spec.source_prefix", status.Error(codes.InvalidArgument, msg))
+	}
+	if spec.FwmarkMask != 0 && spec.Fwmark == 0 {
+		msg := "fwmark_mask requires a fwmark"
+		return badRequest("pbr_rule.spec.fwmark_mask", status.Error(codes.InvalidArgument, msg))
+	}
+	// Validate that a Vrf resource name conforms to the restrictions outlined in AIP-122.
+	if err := badRequest("parent", resourcename.Validate(in.Parent)); err != nil {
+		return err
+	}
+	// see https://google.aip.dev/133#user-specified-ids
+	if in.PbrRuleID != "" {
+		if err := badRequest("pbr_rule_id", validateResourceID(in.PbrRuleID, false)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Server) validateDeletePbrRuleRequest(in *DeletePbrRuleRequest) error {
+	// check required fields
+	if in.Name == "" {
+		return missingField("name")
+	}
+	// Validate that a resource name conforms to the restrictions outlined in AIP-122.
+	return badRequest("name", resourcename.Validate(in.Name))
+}
+
+func (s *Server) validateListPbrRulesRequest(in *ListPbrRulesRequest) error {
+	// check required fields
+	if in.Parent == "" {
+		return missingField("parent")
+	}
+	// Validate that a resource name conforms to the restrictions outlined in AIP-122.
+	return badRequest("parent", resourcename.Validate(in.Parent))
+}
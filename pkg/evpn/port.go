@@ -7,16 +7,16 @@ package evpn
 
 import (
 	"context"
-	"fmt"
 	"log"
 	"path"
 	"sort"
 
-	"github.com/google/uuid"
 	// "github.com/vishvananda/netlink"
 
 	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
 
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils"
+
 	"go.einride.tech/aip/fieldbehavior"
 	"go.einride.tech/aip/resourceid"
 	"google.golang.org/grpc/codes"
@@ -36,82 +36,114 @@ func (s *Server) CreateBridgePort(ctx context.Context, in *pb.CreateBridgePortRe
 	if err := s.validateCreateBridgePortRequest(in); err != nil {
 		return nil, err
 	}
+	s.objectsMu.Lock()
+	defer s.objectsMu.Unlock()
+	return s.createBridgePort(ctx, in)
+}
+
+// createBridgePort creates a validated BridgePort
+func (s *Server) createBridgePort(ctx context.Context, in *pb.CreateBridgePortRequest) (*pb.BridgePort, error) {
 	// see https://google.aip.dev/133#user-specified-ids
 	resourceID := resourceid.NewSystemGenerated()
 	if in.BridgePortId != "" {
 		log.Printf("client provided the ID of a resource %v, ignoring the name field %v", in.BridgePortId, in.BridgePort.Name)
 		resourceID = in.BridgePortId
 	}
-	in.BridgePort.Name = resourceIDToFullName("ports", resourceID)
-	// idempotent API when called with same key, should return same object
-	obj, ok := s.Ports[in.BridgePort.Name]
-	if ok {
-		log.Printf("Already existing BridgePort with id %v", in.BridgePort.Name)
-		return obj, nil
+	name, err := newObjectName(ctx, "ports", resourceID)
+	if err != nil {
+		return nil, err
+	}
+	in.BridgePort.Name = name
+	labels, err := labelsFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	learning, err := s.macLearningFor(ctx, in.BridgePort)
+	if err != nil {
+		return nil, err
+	}
+	trunk, err := s.trunkVlansFor(ctx, in.BridgePort)
+	if err != nil {
+		return nil, err
 	}
-	// not found, so create a new one
-	bridge, err := s.nLink.LinkByName(ctx, tenantbridgeName)
+	kernel, err := s.portKernelNameFor(ctx, in.BridgePort)
 	if err != nil {
-		err := status.Errorf(codes.NotFound, "unable to find key %s", tenantbridgeName)
 		return nil, err
 	}
-	// get base interface (e.g.: eth2)
-	iface, err := s.nLink.LinkByName(ctx, resourceID)
-	// TODO: maybe we need to create a new iface here and not rely on existing one ?
-	//		 iface := &netlink.Dummy{LinkAttrs: netlink.LinkAttrs{Name: resourceID}}
+	macsec, err := s.macsecFor(ctx, in.BridgePort)
 	if err != nil {
-		err := status.Errorf(codes.NotFound, "unable to find key %s", resourceID)
 		return nil, err
 	}
-	// Example: ip link set eth2 addr aa:bb:cc:00:00:41
-	if len(in.BridgePort.Spec.MacAddress) > 0 {
-		if err := s.nLink.LinkSetHardwareAddr(ctx, iface, in.BridgePort.Spec.MacAddress); err != nil {
-			fmt.Printf("Failed to set MAC on link: %v", err)
+	// idempotent API when called with same key, should return same object
+	obj, ok := s.Ports[in.BridgePort.Name]
+	if ok {
+		// a different spec under the same key is a conflict, not a retry
+		if err := checkSameSpec(obj.Name, obj.Spec, in.BridgePort.Spec); err != nil {
 			return nil, err
 		}
+		log.Printf("Already existing BridgePort with id %v", in.BridgePort.Name)
+		return obj, nil
+	}
+	if err := checkSameTenant(in.BridgePort.Name, in.BridgePort.Spec.LogicalBridges...); err != nil {
+		return nil, err
 	}
-	// Example: ip link set eth2 master br-tenant
-	if err := s.nLink.LinkSetMaster(ctx, iface, bridge); err != nil {
-		fmt.Printf("Failed to add iface to bridge: %v", err)
+	if err := s.validateBridgePortQuota(in.BridgePort); err != nil {
 		return nil, err
 	}
-	// add port to specified logical bridges
-	for _, bridgeRefName := range in.BridgePort.Spec.LogicalBridges {
-		fmt.Printf("add iface to logical bridge %s", bridgeRefName)
-		// get object from DB
-		bridgeObject, ok := s.Bridges[bridgeRefName]
-		if !ok {
-			err := status.Errorf(codes.NotFound, "unable to find key %s", bridgeRefName)
+	// the dataplanes find the interface of the port through its kernel name
+	onDevice := kernel != path.Base(in.BridgePort.Name)
+	if onDevice {
+		s.KernelNames[in.BridgePort.Name] = kernel
+	}
+	// see https://google.aip.dev/163
+	if utils.IsValidateOnly(ctx) {
+		err := s.precheckCreateBridgePort(ctx, in)
+		delete(s.KernelNames, in.BridgePort.Name)
+		if err != nil {
 			return nil, err
 		}
-		vid := uint16(bridgeObject.Spec.VlanId)
-		switch in.BridgePort.Spec.Ptype {
-		case pb.BridgePortType_ACCESS:
-			// Example: bridge vlan add dev eth2 vid 20 pvid untagged
-			if err := s.nLink.BridgeVlanAdd(ctx, iface, vid, true, true, false, false); err != nil {
-				fmt.Printf("Failed to add vlan to bridge: %v", err)
-				return nil, err
-			}
-		case pb.BridgePortType_TRUNK:
-			// Example: bridge vlan add dev eth2 vid 20
-			if err := s.nLink.BridgeVlanAdd(ctx, iface, vid, false, false, false, false); err != nil {
-				fmt.Printf("Failed to add vlan to bridge: %v", err)
-				return nil, err
-			}
-		default:
-			msg := fmt.Sprintf("Only ACCESS or TRUNK supported and not (%d)", in.BridgePort.Spec.Ptype)
-			return nil, status.Errorf(codes.InvalidArgument, msg)
-		}
+		response := protoClone(in.BridgePort)
+		response.Status = &pb.BridgePortStatus{}
+		return response, nil
+	}
+	if !learning {
+		s.NoMacLearning[in.BridgePort.Name] = true
+	}
+	if !trunk.empty() {
+		s.TrunkVlans[in.BridgePort.Name] = trunk
 	}
-	// Example: ip link set eth2 up
-	if err := s.nLink.LinkSetUp(ctx, iface); err != nil {
-		fmt.Printf("Failed to up iface link: %v", err)
+	if macsec != nil {
+		s.PortMacsec[in.BridgePort.Name] = *macsec
+	}
+	ctx, cancel := s.programmingContext(ctx)
+	defer cancel()
+	if err := s.dataplane.BindBridgePort(ctx, in.BridgePort); err != nil {
+		s.forgetStatus(in.BridgePort.Name)
+		delete(s.NoMacLearning, in.BridgePort.Name)
+		delete(s.TrunkVlans, in.BridgePort.Name)
+		delete(s.PortMacsec, in.BridgePort.Name)
+		delete(s.KernelNames, in.BridgePort.Name)
 		return nil, err
 	}
 	// save object to the database
 	response := protoClone(in.BridgePort)
-	response.Status = &pb.BridgePortStatus{OperStatus: pb.BPOperStatus_BP_OPER_STATUS_UP}
-	s.Ports[in.BridgePort.Name] = response
+	response.Status = &pb.BridgePortStatus{}
+	s.putBridgePort(response)
+	s.persist("ports")
+	s.setLabels(in.BridgePort.Name, labels)
+	if !learning {
+		s.persistNoMacLearning()
+	}
+	if !trunk.empty() {
+		s.persistTrunkVlans()
+	}
+	if macsec != nil {
+		s.persistPortMacsec()
+	}
+	if onDevice {
+		s.persistKernelNames()
+	}
+	s.events.Publish(utils.WatchEvent{Type: utils.WatchAdded, Name: in.BridgePort.Name})
 	return response, nil
 }
 
@@ -121,48 +153,47 @@ func (s *Server) DeleteBridgePort(ctx context.Context, in *pb.DeleteBridgePortRe
 	if err := s.validateDeleteBridgePortRequest(in); err != nil {
 		return nil, err
 	}
+	s.objectsMu.Lock()
+	defer s.objectsMu.Unlock()
+	if err := s.checkEtag(ctx, in.Name); err != nil {
+		return nil, err
+	}
+	return s.deleteBridgePort(ctx, in)
+}
+
+// deleteBridgePort deletes a validated BridgePort, with objectsMu held
+func (s *Server) deleteBridgePort(ctx context.Context, in *pb.DeleteBridgePortRequest) (*emptypb.Empty, error) {
 	// fetch object from the database
 	iface, ok := s.Ports[in.Name]
-	if !ok {
+	if !ok || !inTenant(ctx, in.Name) {
 		if in.AllowMissing {
 			return &emptypb.Empty{}, nil
 		}
 		err := status.Errorf(codes.NotFound, "unable to find key %s", in.Name)
 		return nil, err
 	}
-	resourceID := path.Base(iface.Name)
-	// use netlink to find interface
-	dummy, err := s.nLink.LinkByName(ctx, resourceID)
-	if err != nil {
-		err := status.Errorf(codes.NotFound, "unable to find key %s", resourceID)
-		return nil, err
-	}
-	// bring link down
-	if err := s.nLink.LinkSetDown(ctx, dummy); err != nil {
-		fmt.Printf("Failed to up link: %v", err)
+	// refuse to leave dangling references, unless asked to delete them as well
+	if err := s.checkDependents(ctx, iface.Name, s.bridgePortDependents(iface.Name)); err != nil {
 		return nil, err
 	}
-	// delete bridge vlan
-	for _, bridgeRefName := range iface.Spec.LogicalBridges {
-		// get object from DB
-		bridgeObject, ok := s.Bridges[bridgeRefName]
-		if !ok {
-			err := status.Errorf(codes.NotFound, "unable to find key %s", bridgeRefName)
-			return nil, err
-		}
-		vid := uint16(bridgeObject.Spec.VlanId)
-		if err := s.nLink.BridgeVlanDel(ctx, dummy, vid, true, true, false, false); err != nil {
-			fmt.Printf("Failed to delete vlan to bridge: %v", err)
-			return nil, err
-		}
+	if utils.IsValidateOnly(ctx) {
+		return &emptypb.Empty{}, nil
 	}
-	// use netlink to delete dummy interface
-	if err := s.nLink.LinkDel(ctx, dummy); err != nil {
-		fmt.Printf("Failed to delete link: %v", err)
+	ctx, cancel := s.programmingContext(ctx)
+	defer cancel()
+	if err := s.dataplane.UnbindBridgePort(ctx, iface); err != nil {
 		return nil, err
 	}
 	// remove from the Database
-	delete(s.Ports, iface.Name)
+	s.removeBridgePort(iface.Name)
+	s.forgetStatus(iface.Name)
+	s.persist("ports")
+	s.releaseLabels(iface.Name)
+	s.releaseNoMacLearning(iface.Name)
+	s.releaseTrunkVlans(iface.Name)
+	s.releasePortMacsec(iface.Name)
+	s.releaseKernelName(iface.Name)
+	s.events.Publish(utils.WatchEvent{Type: utils.WatchDeleted, Name: iface.Name})
 	return &emptypb.Empty{}, nil
 }
 
@@ -172,28 +203,45 @@ func (s *Server) UpdateBridgePort(ctx context.Context, in *pb.UpdateBridgePortRe
 	if err := s.validateUpdateBridgePortRequest(in); err != nil {
 		return nil, err
 	}
+	s.objectsMu.Lock()
+	defer s.objectsMu.Unlock()
+	if err := s.checkEtag(ctx, in.BridgePort.Name); err != nil {
+		return nil, err
+	}
 	// fetch object from the database
 	port, ok := s.Ports[in.BridgePort.Name]
-	if !ok {
+	if !ok || !inTenant(ctx, in.BridgePort.Name) {
 		// TODO: introduce "in.AllowMissing" field. In case "true", create a new resource, don't return error
 		err := status.Errorf(codes.NotFound, "unable to find key %s", in.BridgePort.Name)
 		return nil, err
 	}
-	resourceID := path.Base(port.Name)
-	iface, err := s.nLink.LinkByName(ctx, resourceID)
+	labels, err := labelsFromContext(ctx)
 	if err != nil {
-		err := status.Errorf(codes.NotFound, "unable to find key %s", resourceID)
 		return nil, err
 	}
-	// base := iface.Attrs()
-	// iface.MTU = 1500 // TODO: remove this, just an example
-	if err := s.nLink.LinkModify(ctx, iface); err != nil {
-		fmt.Printf("Failed to update link: %v", err)
+	if err := checkSameTenant(in.BridgePort.Name, in.BridgePort.GetSpec().GetLogicalBridges()...); err != nil {
+		return nil, err
+	}
+	if err := s.validateBridgePortQuota(in.BridgePort); err != nil {
+		return nil, err
+	}
+	if err := s.validateBridgePortUpdate(port, in.BridgePort); err != nil {
+		return nil, err
+	}
+	if utils.IsValidateOnly(ctx) {
+		response := protoClone(in.BridgePort)
+		response.Status = &pb.BridgePortStatus{}
+		return response, nil
+	}
+	if err := s.dataplane.UpdateBridgePort(ctx, port, in.BridgePort); err != nil {
 		return nil, err
 	}
 	response := protoClone(in.BridgePort)
-	response.Status = &pb.BridgePortStatus{OperStatus: pb.BPOperStatus_BP_OPER_STATUS_UP}
-	s.Ports[in.BridgePort.Name] = response
+	response.Status = &pb.BridgePortStatus{}
+	s.putBridgePort(response)
+	s.persist("ports")
+	s.setLabels(in.BridgePort.Name, labels)
+	s.events.Publish(utils.WatchEvent{Type: utils.WatchModified, Name: in.BridgePort.Name})
 	return response, nil
 }
 
@@ -203,48 +251,69 @@ func (s *Server) GetBridgePort(ctx context.Context, in *pb.GetBridgePortRequest)
 	if err := s.validateGetBridgePortRequest(in); err != nil {
 		return nil, err
 	}
+	s.objectsMu.RLock()
+	defer s.objectsMu.RUnlock()
 	// fetch object from the database
 	port, ok := s.Ports[in.Name]
-	if !ok {
+	if !ok || !inTenant(ctx, in.Name) {
 		err := status.Errorf(codes.NotFound, "unable to find key %s", in.Name)
 		return nil, err
 	}
-	resourceID := path.Base(port.Name)
-	_, err := s.nLink.LinkByName(ctx, resourceID)
-	if err != nil {
-		err := status.Errorf(codes.NotFound, "unable to find key %s", resourceID)
-		return nil, err
+	if !s.LiveRead {
+		if err := s.dataplane.GetBridgePort(ctx, port); err != nil {
+			return nil, err
+		}
 	}
+	degraded := map[string]error{}
+	operStatus := s.bridgePortOperStatus(ctx, port, degraded)
+	reportDegraded(ctx, degraded)
+	s.reportLifecycles(ctx, []string{in.Name})
 	// TODO
-	return &pb.BridgePort{Name: in.Name, Spec: &pb.BridgePortSpec{MacAddress: port.Spec.MacAddress}, Status: &pb.BridgePortStatus{OperStatus: pb.BPOperStatus_BP_OPER_STATUS_UP}}, nil
+	return &pb.BridgePort{Name: in.Name, Spec: &pb.BridgePortSpec{MacAddress: port.Spec.MacAddress}, Status: &pb.BridgePortStatus{OperStatus: operStatus}}, nil
 }
 
 // ListBridgePorts lists logical bridges
-func (s *Server) ListBridgePorts(_ context.Context, in *pb.ListBridgePortsRequest) (*pb.ListBridgePortsResponse, error) {
+func (s *Server) ListBridgePorts(ctx context.Context, in *pb.ListBridgePortsRequest) (*pb.ListBridgePortsResponse, error) {
 	// check required fields
 	if err := fieldbehavior.ValidateRequiredFields(in); err != nil {
 		return nil, err
 	}
+	s.objectsMu.RLock()
+	defer s.objectsMu.RUnlock()
 	// fetch pagination from the database, calculate size and offset
-	size, offset, perr := extractPagination(in.PageSize, in.PageToken, s.Pagination)
+	size, offset, perr := s.extractPagination(in.PageSize, in.PageToken)
 	if perr != nil {
 		return nil, perr
 	}
-	// fetch object from the database
-	Blobarray := []*pb.BridgePort{}
-	for _, port := range s.Ports {
-		r := protoClone(port)
-		r.Status = &pb.BridgePortStatus{OperStatus: pb.BPOperStatus_BP_OPER_STATUS_UP}
-		Blobarray = append(Blobarray, r)
-	}
-	// sort is needed, since MAP is unsorted in golang, and we might get different results
-	sortBridgePorts(Blobarray)
-	log.Printf("Limiting result len(%d) to [%d:%d]", len(Blobarray), offset, size)
-	Blobarray, hasMoreElements := limitPagination(Blobarray, offset, size)
-	token := ""
-	if hasMoreElements {
-		token = uuid.New().String()
-		s.Pagination[token] = offset + size
+	selector, err := labelSelectorFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// fetch object from the database, the following pages are cut from the snapshot of the first one,
+	// copies of the stored objects taken under objectsMu
+	Blobarray, token, err := listPage(ctx, s, "bridgePorts", "", in.PageToken, offset, size, func() []*pb.BridgePort {
+		Blobarray := []*pb.BridgePort{}
+		for name, port := range s.Ports {
+			if !inTenant(ctx, name) || !s.matchesLabels(selector, name) {
+				continue
+			}
+			Blobarray = append(Blobarray, protoClone(port))
+		}
+		// sort is needed, since MAP is unsorted in golang, and we might get different results
+		sortBridgePorts(Blobarray)
+		return Blobarray
+	})
+	if err != nil {
+		return nil, err
+	}
+	Blobarray = clonePage(Blobarray)
+	degraded := map[string]error{}
+	names := make([]string, 0, len(Blobarray))
+	for _, r := range Blobarray {
+		r.Status = &pb.BridgePortStatus{OperStatus: s.bridgePortOperStatus(ctx, r, degraded)}
+		names = append(names, r.Name)
 	}
+	reportDegraded(ctx, degraded)
+	s.reportLifecycles(ctx, names)
 	return &pb.ListBridgePortsResponse{BridgePorts: Blobarray, NextPageToken: token}, nil
 }
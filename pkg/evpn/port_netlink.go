@@ -0,0 +1,332 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/vishvananda/netlink"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// netlinkCreateBridgePort plugs the port into the tenant bridge and its LogicalBridges,
+// every step is safe to repeat on an already programmed port
+func (s *Server) netlinkCreateBridgePort(ctx context.Context, obj *pb.BridgePort) error {
+	bridge, err := s.tenantBridge(ctx)
+	if err != nil {
+		return err
+	}
+	// get base interface (e.g.: eth2)
+	resourceID := s.portKernelName(obj.Name)
+	iface, err := s.nLink.LinkByName(ctx, resourceID)
+	// TODO: maybe we need to create a new iface here and not rely on existing one ?
+	//		 iface := &netlink.Dummy{LinkAttrs: netlink.LinkAttrs{Name: resourceID}}
+	if err != nil {
+		err := status.Errorf(codes.NotFound, "unable to find key %s", resourceID)
+		return err
+	}
+	// Example: ip link set eth2 addr aa:bb:cc:00:00:41
+	if len(obj.Spec.MacAddress) > 0 {
+		if err := s.nLink.LinkSetHardwareAddr(ctx, iface, obj.Spec.MacAddress); err != nil {
+			fmt.Printf("Failed to set MAC on link: %v", err)
+			return err
+		}
+	}
+	// a MACsec port is plugged through its macsec device, the interface only carries the
+	// secured frames
+	if macsec, ok := s.PortMacsec[obj.Name]; ok {
+		iface, err = s.netlinkPortMacsec(ctx, obj, iface, macsec)
+		if err != nil {
+			return err
+		}
+	}
+	// a service-tagged port is plugged through its 802.1ad sub-interface, carrying the
+	// vlans of the LogicalBridges as inner tags
+	member := iface
+	if svlan := s.PortTrunkVlans(obj.Name).ServiceVlan; svlan != 0 {
+		member, err = s.netlinkPortVlan(ctx, obj, iface, svlan, netlink.VLAN_PROTOCOL_8021AD)
+		if err != nil {
+			return err
+		}
+	}
+	// Example: ip link set eth2 master br-tenant
+	if err := s.nLink.LinkSetMaster(ctx, member, bridge); err != nil {
+		fmt.Printf("Failed to add iface to bridge: %v", err)
+		return err
+	}
+	// Example: bridge link set dev eth2 learning off
+	if !s.MacLearning(obj.Name) {
+		if err := s.nLink.LinkSetLearning(ctx, member, false); err != nil {
+			fmt.Printf("Failed to turn learning off: %v", err)
+			return err
+		}
+	}
+	// add port to specified logical bridges
+	for _, bridgeRefName := range obj.Spec.LogicalBridges {
+		fmt.Printf("add iface to logical bridge %s", bridgeRefName)
+		if err := s.netlinkAddBridgePortVlan(ctx, obj, iface, member, bridge, bridgeRefName); err != nil {
+			return err
+		}
+	}
+	// Example: ip link set eth2 up
+	if err := s.nLink.LinkSetUp(ctx, iface); err != nil {
+		fmt.Printf("Failed to up iface link: %v", err)
+		return err
+	}
+	if member != iface {
+		// Example: ip link set eth2.300 up
+		if err := s.nLink.LinkSetUp(ctx, member); err != nil {
+			fmt.Printf("Failed to up link: %v", err)
+			return err
+		}
+	}
+	return nil
+}
+
+// netlinkUpdateBridgePort adds and removes only the vlans of the LogicalBridges that changed,
+// the port stays up and keeps forwarding in the others. A failed step undoes the previous
+// ones, so the port is left with either the old or the new vlans
+func (s *Server) netlinkUpdateBridgePort(ctx context.Context, old *pb.BridgePort, obj *pb.BridgePort) error {
+	bridge, err := s.tenantBridge(ctx)
+	if err != nil {
+		return err
+	}
+	resourceID := s.portKernelName(obj.Name)
+	if s.IsMacsecPort(obj) {
+		resourceID = s.macsecKernelName(obj)
+	}
+	iface, err := s.nLink.LinkByName(ctx, resourceID)
+	if err != nil {
+		err := status.Errorf(codes.NotFound, "unable to find key %s", resourceID)
+		return err
+	}
+	member := iface
+	if svlan := s.PortTrunkVlans(obj.Name).ServiceVlan; svlan != 0 {
+		member, err = s.netlinkPortVlan(ctx, obj, iface, svlan, netlink.VLAN_PROTOCOL_8021AD)
+		if err != nil {
+			return err
+		}
+	}
+	added, removed := bridgePortChanges(old, obj)
+	var undo []func()
+	rollback := func() {
+		for i := len(undo) - 1; i >= 0; i-- {
+			undo[i]()
+		}
+	}
+	for _, bridgeRefName := range added {
+		if err := s.netlinkAddBridgePortVlan(ctx, obj, iface, member, bridge, bridgeRefName); err != nil {
+			rollback()
+			return err
+		}
+		bridgeRefName := bridgeRefName
+		undo = append(undo, func() {
+			if err := s.netlinkDelBridgePortVlan(ctx, obj, member, bridgeRefName); err != nil {
+				fmt.Printf("Failed to undo vlan of %s: %v", bridgeRefName, err)
+			}
+		})
+	}
+	for _, bridgeRefName := range removed {
+		if err := s.netlinkDelBridgePortVlan(ctx, old, member, bridgeRefName); err != nil {
+			rollback()
+			return err
+		}
+		bridgeRefName := bridgeRefName
+		undo = append(undo, func() {
+			if err := s.netlinkAddBridgePortVlan(ctx, old, iface, member, bridge, bridgeRefName); err != nil {
+				fmt.Printf("Failed to undo vlan of %s: %v", bridgeRefName, err)
+			}
+		})
+	}
+	return nil
+}
+
+func (s *Server) netlinkDeleteBridgePort(ctx context.Context, obj *pb.BridgePort) error {
+	resourceID := s.portKernelName(obj.Name)
+	// use netlink to find interface
+	dummy, err := s.nLink.LinkByName(ctx, resourceID)
+	if err != nil {
+		err := status.Errorf(codes.NotFound, "unable to find key %s", resourceID)
+		return err
+	}
+	// bring link down
+	if err := s.nLink.LinkSetDown(ctx, dummy); err != nil {
+		fmt.Printf("Failed to up link: %v", err)
+		return err
+	}
+	// the vlans of a service-tagged port go away with its 802.1ad sub-interface, and those of
+	// a MACsec port, with its sub-interfaces, with its macsec device
+	bridges := obj.Spec.LogicalBridges
+	if s.IsMacsecPort(obj) {
+		if err := s.netlinkDeletePortMacsec(ctx, obj); err != nil {
+			return err
+		}
+		bridges = nil
+	} else if svlan := s.PortTrunkVlans(obj.Name).ServiceVlan; svlan != 0 {
+		if err := s.netlinkDeletePortVlan(ctx, obj, svlan); err != nil {
+			return err
+		}
+		bridges = nil
+	}
+	// delete bridge vlan
+	for _, bridgeRefName := range bridges {
+		if err := s.netlinkDelBridgePortVlan(ctx, obj, dummy, bridgeRefName); err != nil {
+			return err
+		}
+	}
+	// use netlink to delete dummy interface
+	if err := s.nLink.LinkDel(ctx, dummy); err != nil {
+		fmt.Printf("Failed to delete link: %v", err)
+		return err
+	}
+	return nil
+}
+
+// bridgePortChanges returns the LogicalBridges the port is added to and removed from, all of
+// them when its type changes since the vlans are then tagged differently
+func bridgePortChanges(old *pb.BridgePort, obj *pb.BridgePort) ([]string, []string) {
+	if old.Spec.Ptype != obj.Spec.Ptype {
+		return obj.Spec.LogicalBridges, old.Spec.LogicalBridges
+	}
+	var added, removed []string
+	for _, name := range obj.Spec.LogicalBridges {
+		if !portInBridge(old, name) {
+			added = append(added, name)
+		}
+	}
+	for _, name := range old.Spec.LogicalBridges {
+		if !portInBridge(obj, name) {
+			removed = append(removed, name)
+		}
+	}
+	return added, removed
+}
+
+// netlinkAddBridgePortVlan adds the port to the vlan of a LogicalBridge, through member, the
+// port itself or its 802.1ad sub-interface
+func (s *Server) netlinkAddBridgePortVlan(ctx context.Context, obj *pb.BridgePort, iface netlink.Link, member netlink.Link, bridge netlink.Link, bridgeRefName string) error {
+	// get object from DB
+	bridgeObject, ok := s.Bridges[bridgeRefName]
+	if !ok {
+		err := status.Errorf(codes.NotFound, "unable to find key %s", bridgeRefName)
+		return err
+	}
+	vid := uint16(bridgeObject.Spec.VlanId)
+	switch obj.Spec.Ptype {
+	case pb.BridgePortType_ACCESS:
+		// Example: bridge vlan add dev eth2 vid 20 pvid untagged
+		if err := s.nLink.BridgeVlanAdd(ctx, member, vid, true, true, false, false); err != nil {
+			fmt.Printf("Failed to add vlan to bridge: %v", err)
+			return err
+		}
+	case pb.BridgePortType_TRUNK:
+		trunk := s.PortTrunkVlans(obj.Name)
+		if external, ok := trunk.External(bridgeObject.Spec.VlanId); ok {
+			return s.netlinkCreateTranslatedVlan(ctx, obj, iface, bridge, external, vid)
+		}
+		// Example: bridge vlan add dev eth2 vid 20 [pvid untagged]
+		native := bridgeObject.Spec.VlanId == trunk.Native
+		if err := s.nLink.BridgeVlanAdd(ctx, member, vid, native, native, false, false); err != nil {
+			fmt.Printf("Failed to add vlan to bridge: %v", err)
+			return err
+		}
+	default:
+		msg := fmt.Sprintf("Only ACCESS or TRUNK supported and not (%d)", obj.Spec.Ptype)
+		return status.Error(codes.InvalidArgument, msg)
+	}
+	return nil
+}
+
+// netlinkDelBridgePortVlan removes the port from the vlan of a LogicalBridge
+func (s *Server) netlinkDelBridgePortVlan(ctx context.Context, obj *pb.BridgePort, member netlink.Link, bridgeRefName string) error {
+	// get object from DB
+	bridgeObject, ok := s.Bridges[bridgeRefName]
+	if !ok {
+		err := status.Errorf(codes.NotFound, "unable to find key %s", bridgeRefName)
+		return err
+	}
+	// the translated vlans go away with their sub-interface
+	if external, ok := s.PortTrunkVlans(obj.Name).External(bridgeObject.Spec.VlanId); ok {
+		return s.netlinkDeletePortVlan(ctx, obj, external)
+	}
+	// Example: bridge vlan del dev eth2 vid 20
+	vid := uint16(bridgeObject.Spec.VlanId)
+	if err := s.nLink.BridgeVlanDel(ctx, member, vid, true, true, false, false); err != nil {
+		fmt.Printf("Failed to delete vlan to bridge: %v", err)
+		return err
+	}
+	return nil
+}
+
+// netlinkCreateTranslatedVlan plugs a VLAN sub-interface of the port receiving the external vlan
+// into the vlan of the LogicalBridge as an access port, the kernel bridge having no per port
+// vlan mapping, the tag is popped on ingress and the external one pushed on egress
+func (s *Server) netlinkCreateTranslatedVlan(ctx context.Context, obj *pb.BridgePort, iface netlink.Link, bridge netlink.Link, external uint32, vid uint16) error {
+	vlandev, err := s.netlinkPortVlan(ctx, obj, iface, external, netlink.VLAN_PROTOCOL_8021Q)
+	if err != nil {
+		return err
+	}
+	// Example: ip link set eth2.100 master br-tenant
+	if err := s.nLink.LinkSetMaster(ctx, vlandev, bridge); err != nil {
+		fmt.Printf("Failed to add vlandev to bridge: %v", err)
+		return err
+	}
+	if !s.MacLearning(obj.Name) {
+		if err := s.nLink.LinkSetLearning(ctx, vlandev, false); err != nil {
+			fmt.Printf("Failed to turn learning off: %v", err)
+			return err
+		}
+	}
+	// Example: bridge vlan add dev eth2.100 vid 20 pvid untagged
+	if err := s.nLink.BridgeVlanAdd(ctx, vlandev, vid, true, true, false, false); err != nil {
+		fmt.Printf("Failed to add vlan to bridge: %v", err)
+		return err
+	}
+	// Example: ip link set eth2.100 up
+	if err := s.nLink.LinkSetUp(ctx, vlandev); err != nil {
+		fmt.Printf("Failed to up link: %v", err)
+		return err
+	}
+	return nil
+}
+
+// netlinkPortVlan returns the VLAN sub-interface of the port, created when missing
+func (s *Server) netlinkPortVlan(ctx context.Context, obj *pb.BridgePort, iface netlink.Link, vlan uint32, protocol netlink.VlanProtocol) (netlink.Link, error) {
+	vlanName := s.portVlanKernelName(obj, vlan)
+	if vlandev, err := s.nLink.LinkByName(ctx, vlanName); err == nil {
+		return vlandev, nil
+	}
+	// Example: ip link add link eth2 name eth2.300 type vlan proto 802.1ad id 300
+	vlandev := &netlink.Vlan{LinkAttrs: netlink.LinkAttrs{Name: vlanName, ParentIndex: iface.Attrs().Index, Alias: managedAlias}, VlanId: int(vlan), VlanProtocol: protocol}
+	log.Printf("Creating VLAN %v", vlandev)
+	if err := s.nLink.LinkAdd(ctx, vlandev); err != nil {
+		fmt.Printf("Failed to create vlan link: %v", err)
+		return nil, err
+	}
+	return vlandev, nil
+}
+
+// netlinkDeletePortVlan deletes the VLAN sub-interface of the port
+func (s *Server) netlinkDeletePortVlan(ctx context.Context, obj *pb.BridgePort, vlan uint32) error {
+	vlanName := s.portVlanKernelName(obj, vlan)
+	vlandev, err := s.nLink.LinkByName(ctx, vlanName)
+	if err != nil {
+		err := status.Errorf(codes.NotFound, "unable to find key %s", vlanName)
+		return err
+	}
+	// Example: ip link del eth2.100
+	log.Printf("Deleting VLAN %v", vlandev)
+	if err := s.nLink.LinkDel(ctx, vlandev); err != nil {
+		fmt.Printf("Failed to delete link: %v", err)
+		return err
+	}
+	return nil
+}
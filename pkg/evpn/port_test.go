@@ -13,6 +13,7 @@ import (
 	"net"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/philippgille/gokv/gomap"
 	"github.com/stretchr/testify/mock"
@@ -64,7 +65,7 @@ func Test_CreateBridgePort(t *testing.T) {
 			id:      "CapitalLettersNotAllowed",
 			in:      &testBridgePort,
 			out:     nil,
-			errCode: codes.Unknown,
+			errCode: codes.InvalidArgument,
 			errMsg:  fmt.Sprintf("user-settable ID must only contain lowercase, numbers and hyphens (%v)", "got: 'C' in position 0"),
 			exist:   false,
 			on:      nil,
@@ -82,7 +83,7 @@ func Test_CreateBridgePort(t *testing.T) {
 			id:      testBridgePortID,
 			in:      nil,
 			out:     nil,
-			errCode: codes.Unknown,
+			errCode: codes.InvalidArgument,
 			errMsg:  "missing required field: bridge_port",
 			exist:   false,
 			on:      nil,
@@ -93,7 +94,7 @@ func Test_CreateBridgePort(t *testing.T) {
 				Spec: &pb.BridgePortSpec{},
 			},
 			out:     nil,
-			errCode: codes.Unknown,
+			errCode: codes.InvalidArgument,
 			errMsg:  "missing required field: bridge_port.spec.mac_address",
 			exist:   false,
 			on:      nil,
@@ -106,7 +107,7 @@ func Test_CreateBridgePort(t *testing.T) {
 				},
 			},
 			out:     nil,
-			errCode: codes.Unknown,
+			errCode: codes.InvalidArgument,
 			errMsg:  "missing required field: bridge_port.spec.ptype",
 			exist:   false,
 			on:      nil,
@@ -273,7 +274,7 @@ func Test_CreateBridgePort(t *testing.T) {
 		"successful call": {
 			id:      testBridgePortID,
 			in:      &testBridgePort,
-			out:     &testBridgePortWithStatus,
+			out:     &pb.BridgePort{Spec: testBridgePort.Spec, Status: &pb.BridgePortStatus{}},
 			errCode: codes.OK,
 			errMsg:  "",
 			exist:   false,
@@ -594,6 +595,117 @@ func Test_UpdateBridgePort(t *testing.T) {
 	}
 }
 
+func Test_netlinkUpdateBridgePort(t *testing.T) {
+	otherBridgeName := resourceIDToFullName("bridges", "opi-bridge10")
+	otherBridge := &pb.LogicalBridge{Name: otherBridgeName, Spec: &pb.LogicalBridgeSpec{VlanId: 33}}
+	tests := map[string]struct {
+		bridges []string
+		errCode codes.Code
+		on      func(mockNetlink *mocks.Netlink, iface netlink.Link)
+	}{
+		"unchanged": {
+			bridges: []string{testLogicalBridgeName},
+			on:      nil,
+		},
+		"added bridge": {
+			bridges: []string{testLogicalBridgeName, otherBridgeName},
+			on: func(mockNetlink *mocks.Netlink, iface netlink.Link) {
+				mockNetlink.EXPECT().BridgeVlanAdd(mock.Anything, iface, uint16(33), false, false, false, false).Return(nil).Once()
+			},
+		},
+		"moved bridge": {
+			bridges: []string{otherBridgeName},
+			on: func(mockNetlink *mocks.Netlink, iface netlink.Link) {
+				mockNetlink.EXPECT().BridgeVlanAdd(mock.Anything, iface, uint16(33), false, false, false, false).Return(nil).Once()
+				mockNetlink.EXPECT().BridgeVlanDel(mock.Anything, iface, uint16(22), true, true, false, false).Return(nil).Once()
+			},
+		},
+		"failed BridgeVlanDel call rolls back": {
+			bridges: []string{otherBridgeName},
+			errCode: codes.Unknown,
+			on: func(mockNetlink *mocks.Netlink, iface netlink.Link) {
+				mockNetlink.EXPECT().BridgeVlanAdd(mock.Anything, iface, uint16(33), false, false, false, false).Return(nil).Once()
+				mockNetlink.EXPECT().BridgeVlanDel(mock.Anything, iface, uint16(22), true, true, false, false).Return(errors.New("Failed to call BridgeVlanDel")).Once()
+				mockNetlink.EXPECT().BridgeVlanDel(mock.Anything, iface, uint16(33), true, true, false, false).Return(nil).Once()
+			},
+		},
+	}
+	for testName, tt := range tests {
+		t.Run(testName, func(t *testing.T) {
+			mockNetlink := mocks.NewNetlink(t)
+			opi := NewServerWithArgs(mockNetlink, mocks.NewFrr(t), gomap.NewStore(gomap.DefaultOptions))
+			opi.Bridges[testLogicalBridgeName] = protoClone(&testLogicalBridgeWithStatus)
+			opi.Bridges[otherBridgeName] = otherBridge
+
+			bridge := &netlink.Bridge{LinkAttrs: netlink.LinkAttrs{Name: tenantbridgeName}}
+			mockNetlink.EXPECT().LinkByName(mock.Anything, tenantbridgeName).Return(bridge, nil).Once()
+			iface := &netlink.Dummy{LinkAttrs: netlink.LinkAttrs{Name: testBridgePortID}}
+			mockNetlink.EXPECT().LinkByName(mock.Anything, testBridgePortID).Return(iface, nil).Once()
+			if tt.on != nil {
+				tt.on(mockNetlink, iface)
+			}
+
+			old := protoClone(&testBridgePortWithStatus)
+			obj := protoClone(old)
+			obj.Spec.LogicalBridges = tt.bridges
+			err := opi.netlinkUpdateBridgePort(context.Background(), old, obj)
+			if status.Code(err) != tt.errCode {
+				t.Error("error code: expected", tt.errCode, "received", status.Code(err))
+			}
+		})
+	}
+}
+
+func Test_validateBridgePortUpdate(t *testing.T) {
+	otherBridgeName := resourceIDToFullName("bridges", "opi-bridge10")
+	tests := map[string]struct {
+		bridges []string
+		trunk   TrunkVlans
+		pinned  bool
+		errCode codes.Code
+	}{
+		"left bridge": {
+			bridges: nil,
+			errCode: codes.OK,
+		},
+		"unknown bridge": {
+			bridges: []string{otherBridgeName},
+			errCode: codes.NotFound,
+		},
+		"pinned MAC address": {
+			bridges: nil,
+			pinned:  true,
+			errCode: codes.FailedPrecondition,
+		},
+		"native vlan": {
+			bridges: nil,
+			trunk:   TrunkVlans{Native: 22},
+			errCode: codes.FailedPrecondition,
+		},
+	}
+	for testName, tt := range tests {
+		t.Run(testName, func(t *testing.T) {
+			opi := NewServerWithArgs(mocks.NewNetlink(t), mocks.NewFrr(t), gomap.NewStore(gomap.DefaultOptions))
+			opi.Bridges[testLogicalBridgeName] = protoClone(&testLogicalBridgeWithStatus)
+			opi.TrunkVlans[testBridgePortName] = tt.trunk
+			if tt.pinned {
+				opi.FdbEntries[testStaticFdbEntryName] = &StaticFdbEntry{
+					Name: testStaticFdbEntryName,
+					Spec: &StaticFdbEntrySpec{BridgePort: testBridgePortName},
+				}
+			}
+
+			old := protoClone(&testBridgePortWithStatus)
+			obj := protoClone(old)
+			obj.Spec.LogicalBridges = tt.bridges
+			err := opi.validateBridgePortUpdate(old, obj)
+			if status.Code(err) != tt.errCode {
+				t.Error("error code: expected", tt.errCode, "received", err)
+			}
+		})
+	}
+}
+
 func Test_GetBridgePort(t *testing.T) {
 	tests := map[string]struct {
 		in      string
@@ -754,7 +866,12 @@ func Test_ListBridgePorts(t *testing.T) {
 			client := pb.NewBridgePortServiceClient(conn)
 
 			opi.Ports[testBridgePortName] = protoClone(&testBridgePortWithStatus)
-			opi.Pagination["existing-pagination-token"] = 1
+			if err := opi.savePageToken("existing-pagination-token", 1, time.Now().Add(time.Minute)); err != nil {
+				t.Fatal(err)
+			}
+			if len(tt.out) != 0 {
+				mockNetlink.EXPECT().LinkByName(mock.Anything, testBridgePortID).Return(&netlink.Device{LinkAttrs: netlink.LinkAttrs{OperState: netlink.OperUp}}, nil).Once()
+			}
 
 			request := &pb.ListBridgePortsRequest{PageSize: tt.size, PageToken: tt.token}
 			response, err := client.ListBridgePorts(ctx, request)
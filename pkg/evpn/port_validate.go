@@ -7,9 +7,7 @@ package evpn
 import (
 	"fmt"
 
-	"go.einride.tech/aip/fieldbehavior"
 	"go.einride.tech/aip/fieldmask"
-	"go.einride.tech/aip/resourceid"
 	"go.einride.tech/aip/resourcename"
 
 	"google.golang.org/grpc/codes"
@@ -20,18 +18,18 @@ import (
 
 func (s *Server) validateCreateBridgePortRequest(in *pb.CreateBridgePortRequest) error {
 	// check required fields
-	if err := fieldbehavior.ValidateRequiredFields(in); err != nil {
+	if err := validateRequiredFields(in); err != nil {
 		return err
 	}
 	// for Access type, the LogicalBridge list must have only one item
 	length := len(in.BridgePort.Spec.LogicalBridges)
 	if in.BridgePort.Spec.Ptype == pb.BridgePortType_ACCESS && length > 1 {
 		msg := fmt.Sprintf("ACCESS type must have single LogicalBridge and not (%d)", length)
-		return status.Errorf(codes.InvalidArgument, msg)
+		return badRequest("bridge_port.spec.logical_bridges", status.Error(codes.InvalidArgument, msg))
 	}
 	// see https://google.aip.dev/133#user-specified-ids
 	if in.BridgePortId != "" {
-		if err := resourceid.ValidateUserSettable(in.BridgePortId); err != nil {
+		if err := badRequest("bridge_port_id", validateResourceID(in.BridgePortId, true)); err != nil {
 			return err
 		}
 	}
@@ -41,31 +39,90 @@ func (s *Server) validateCreateBridgePortRequest(in *pb.CreateBridgePortRequest)
 
 func (s *Server) validateDeleteBridgePortRequest(in *pb.DeleteBridgePortRequest) error {
 	// check required fields
-	if err := fieldbehavior.ValidateRequiredFields(in); err != nil {
+	if err := validateRequiredFields(in); err != nil {
 		return err
 	}
 	// Validate that a resource name conforms to the restrictions outlined in AIP-122.
-	return resourcename.Validate(in.Name)
+	return badRequest("name", resourcename.Validate(in.Name))
 }
 
 func (s *Server) validateUpdateBridgePortRequest(in *pb.UpdateBridgePortRequest) error {
 	// check required fields
-	if err := fieldbehavior.ValidateRequiredFields(in); err != nil {
+	if err := validateRequiredFields(in); err != nil {
 		return err
 	}
 	// update_mask = 2
-	if err := fieldmask.Validate(in.UpdateMask, in.BridgePort); err != nil {
+	if err := badRequest("update_mask", fieldmask.Validate(in.UpdateMask, in.BridgePort)); err != nil {
 		return err
 	}
 	// Validate that a resource name conforms to the restrictions outlined in AIP-122.
-	return resourcename.Validate(in.BridgePort.Name)
+	return badRequest("bridge_port.name", resourcename.Validate(in.BridgePort.Name))
 }
 
 func (s *Server) validateGetBridgePortRequest(in *pb.GetBridgePortRequest) error {
 	// check required fields
-	if err := fieldbehavior.ValidateRequiredFields(in); err != nil {
+	if err := validateRequiredFields(in); err != nil {
 		return err
 	}
 	// Validate that a resource name conforms to the restrictions outlined in AIP-122.
-	return resourcename.Validate(in.Name)
+	return badRequest("name", resourcename.Validate(in.Name))
+}
+
+// validateBridgePortQuota checks every LogicalBridge the BridgePort is added to fits in the
+// BridgePortsPerLogicalBridge limit, the port being created or updated is not counted
+func (s *Server) validateBridgePortQuota(obj *pb.BridgePort) error {
+	limit, ok := s.Quotas["BridgePortsPerLogicalBridge"]
+	if !ok {
+		return nil
+	}
+	for _, bridge := range obj.GetSpec().GetLogicalBridges() {
+		count := 0
+		for _, name := range s.indexes.portBridge.lookup(s.Ports, bridge) {
+			if name != obj.Name {
+				count++
+			}
+		}
+		if count >= limit {
+			return quotaExceeded(bridge, fmt.Sprintf("max number of BridgePorts (%d) in %s reached", limit, bridge))
+		}
+	}
+	return nil
+}
+
+// validateBridgePortUpdate checks the LogicalBridges the BridgePort is added to exist, and that
+// it does not leave a LogicalBridge still needed by its static MAC addresses or vlan settings
+func (s *Server) validateBridgePortUpdate(old *pb.BridgePort, obj *pb.BridgePort) error {
+	length := len(obj.Spec.LogicalBridges)
+	if obj.Spec.Ptype == pb.BridgePortType_ACCESS && length > 1 {
+		msg := fmt.Sprintf("ACCESS type must have single LogicalBridge and not (%d)", length)
+		return badRequest("bridge_port.spec.logical_bridges", status.Error(codes.InvalidArgument, msg))
+	}
+	added, removed := bridgePortChanges(old, obj)
+	for _, bridgeRefName := range added {
+		if _, ok := s.Bridges[bridgeRefName]; !ok {
+			err := status.Errorf(codes.NotFound, "unable to find key %s", bridgeRefName)
+			return err
+		}
+	}
+	trunk := s.PortTrunkVlans(old.Name)
+	for _, bridgeRefName := range removed {
+		if portInBridge(obj, bridgeRefName) {
+			continue
+		}
+		for _, entry := range s.FdbEntries {
+			if entry.Spec.BridgePort == old.Name && staticFdbEntryParent(entry.Name) == bridgeRefName {
+				err := status.Errorf(codes.FailedPrecondition, "port %s cannot leave %s, %s is pinned to it", old.Name, bridgeRefName, entry.Name)
+				return err
+			}
+		}
+		bridge, ok := s.Bridges[bridgeRefName]
+		if !ok {
+			continue
+		}
+		if _, translated := trunk.External(bridge.Spec.VlanId); translated || bridge.Spec.VlanId == trunk.Native {
+			err := status.Errorf(codes.FailedPrecondition, "port %s cannot leave %s, vlan %d is native or translated on it", old.Name, bridgeRefName, bridge.Spec.VlanId)
+			return err
+		}
+	}
+	return nil
 }
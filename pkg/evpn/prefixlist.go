@@ -0,0 +1,237 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"log"
+	"path"
+	"sort"
+
+	pc "github.com/opiproject/opi-api/network/opinetcommon/v1alpha1/gen/go"
+
+	"go.einride.tech/aip/resourceid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// RouteAction is what happens to the routes an entry of a PrefixList or a RouteMap matches, in FRR syntax
+type RouteAction string
+
+const (
+	// RoutePermit accepts the route
+	RoutePermit RouteAction = "permit"
+	// RouteDeny rejects the route
+	RouteDeny RouteAction = "deny"
+)
+
+// PrefixList is an FRR prefix-list, matched by the entries of RouteMaps, instead of hand-editing frr.conf
+// TODO: move to opi-api once the message is agreed upon
+type PrefixList struct {
+	Name string
+	Spec *PrefixListSpec
+}
+
+// PrefixListSpec is the desired configuration of a PrefixList
+type PrefixListSpec struct {
+	// Entries are evaluated by increasing Seq, the first matching one applies, the other routes are denied
+	Entries []*PrefixListEntry
+}
+
+// PrefixListEntry matches the IPv4 routes of a prefix
+type PrefixListEntry struct {
+	// Seq orders the entries, unique in the PrefixList
+	Seq    uint32
+	Action RouteAction
+	Prefix *pc.IPPrefix
+	// Ge matches the routes of Prefix at least Ge bits long, only Prefix itself when Ge and Le are 0
+	Ge uint32
+	// Le matches the routes of Prefix at most Le bits long
+	Le uint32
+}
+
+// CreatePrefixListRequest is the request to create a PrefixList
+type CreatePrefixListRequest struct {
+	PrefixListID string
+	PrefixList   *PrefixList
+}
+
+// UpdatePrefixListRequest is the request to replace the entries of a PrefixList
+type UpdatePrefixListRequest struct {
+	PrefixList *PrefixList
+}
+
+// DeletePrefixListRequest is the request to delete a PrefixList
+type DeletePrefixListRequest struct {
+	Name         string
+	AllowMissing bool
+}
+
+// ListPrefixListsRequest is the request to list PrefixLists
+type ListPrefixListsRequest struct {
+	PageSize  int32
+	PageToken string
+}
+
+// ListPrefixListsResponse is the response of listing PrefixLists
+type ListPrefixListsResponse struct {
+	PrefixLists   []*PrefixList
+	NextPageToken string
+}
+
+func (p *PrefixList) clone() *PrefixList {
+	if p == nil {
+		return nil
+	}
+	c := &PrefixList{Name: p.Name}
+	if p.Spec != nil {
+		spec := &PrefixListSpec{}
+		for _, entry := range p.Spec.Entries {
+			e := *entry
+			if entry.Prefix != nil {
+				e.Prefix = protoClone(entry.Prefix)
+			}
+			spec.Entries = append(spec.Entries, &e)
+		}
+		c.Spec = spec
+	}
+	return c
+}
+
+func sortPrefixLists(lists []*PrefixList) {
+	sort.Slice(lists, func(i int, j int) bool {
+		return lists[i].Name < lists[j].Name
+	})
+}
+
+// routePolicyFrrName is the name of a PrefixList or a RouteMap in FRR, its resource ID
+func routePolicyFrrName(name string) string {
+	return path.Base(name)
+}
+
+// CreatePrefixList executes the creation of the prefix-list
+func (s *Server) CreatePrefixList(ctx context.Context, in *CreatePrefixListRequest) (*PrefixList, error) {
+	// check input correctness
+	if err := s.validateCreatePrefixListRequest(in); err != nil {
+		return nil, err
+	}
+	if err := checkNoTenant(ctx); err != nil {
+		return nil, err
+	}
+	// see https://google.aip.dev/133#user-specified-ids
+	resourceID := resourceid.NewSystemGenerated()
+	if in.PrefixListID != "" {
+		log.Printf("client provided the ID of a resource %v, ignoring the name field %v", in.PrefixListID, in.PrefixList.Name)
+		resourceID = in.PrefixListID
+	}
+	in.PrefixList.Name = resourceIDToFullName("prefixlists", resourceID)
+	s.objectsMu.Lock()
+	defer s.objectsMu.Unlock()
+	// idempotent API when called with same key, should return same object
+	obj, ok := s.PrefixLists[in.PrefixList.Name]
+	if ok {
+		// a different spec under the same key is a conflict, not a retry
+		if err := checkSameChildSpec(obj.Name, obj.Spec, in.PrefixList.Spec); err != nil {
+			return nil, err
+		}
+		log.Printf("Already existing PrefixList with id %v", in.PrefixList.Name)
+		return obj.clone(), nil
+	}
+	if err := s.dataplane.CreatePrefixList(ctx, in.PrefixList); err != nil {
+		s.forgetStatus(in.PrefixList.Name)
+		return nil, status.Convert(err).Err()
+	}
+	// save object to the database
+	response := in.PrefixList.clone()
+	s.PrefixLists[in.PrefixList.Name] = response
+	persistObjects(s, "prefixlists", s.PrefixLists)
+	return response.clone(), nil
+}
+
+// UpdatePrefixList replaces the entries of the prefix-list, the RouteMaps matching it see the new entries
+func (s *Server) UpdatePrefixList(ctx context.Context, in *UpdatePrefixListRequest) (*PrefixList, error) {
+	// check input correctness
+	if err := s.validateUpdatePrefixListRequest(in); err != nil {
+		return nil, err
+	}
+	if err := checkNoTenant(ctx); err != nil {
+		return nil, err
+	}
+	s.objectsMu.Lock()
+	defer s.objectsMu.Unlock()
+	// fetch object from the database
+	if _, ok := s.PrefixLists[in.PrefixList.Name]; !ok {
+		err := status.Errorf(codes.NotFound, "unable to find key %s", in.PrefixList.Name)
+		return nil, err
+	}
+	if err := s.dataplane.CreatePrefixList(ctx, in.PrefixList); err != nil {
+		return nil, status.Convert(err).Err()
+	}
+	// save object to the database
+	response := in.PrefixList.clone()
+	s.PrefixLists[in.PrefixList.Name] = response
+	persistObjects(s, "prefixlists", s.PrefixLists)
+	return response.clone(), nil
+}
+
+// DeletePrefixList deletes the prefix-list
+func (s *Server) DeletePrefixList(ctx context.Context, in *DeletePrefixListRequest) (*emptypb.Empty, error) {
+	// check input correctness
+	if err := s.validateDeletePrefixListRequest(in); err != nil {
+		return nil, err
+	}
+	if err := checkNoTenant(ctx); err != nil {
+		return nil, err
+	}
+	s.objectsMu.Lock()
+	defer s.objectsMu.Unlock()
+	// fetch object from the database
+	obj, ok := s.PrefixLists[in.Name]
+	if !ok {
+		if in.AllowMissing {
+			return &emptypb.Empty{}, nil
+		}
+		err := status.Errorf(codes.NotFound, "unable to find key %s", in.Name)
+		return nil, err
+	}
+	// the RouteMaps matching the list go first
+	if err := s.checkDependents(ctx, obj.Name, s.prefixListDependents(obj.Name)); err != nil {
+		return nil, err
+	}
+	if err := s.dataplane.DeletePrefixList(ctx, obj); err != nil {
+		return nil, status.Convert(err).Err()
+	}
+	// remove from the Database
+	delete(s.PrefixLists, obj.Name)
+	persistObjects(s, "prefixlists", s.PrefixLists)
+	s.forgetStatus(obj.Name)
+	return &emptypb.Empty{}, nil
+}
+
+// ListPrefixLists lists the prefix-lists
+func (s *Server) ListPrefixLists(ctx context.Context, in *ListPrefixListsRequest) (*ListPrefixListsResponse, error) {
+	// fetch pagination from the database, calculate size and offset
+	size, offset, perr := s.extractPagination(in.PageSize, in.PageToken)
+	if perr != nil {
+		return nil, perr
+	}
+	s.objectsMu.RLock()
+	defer s.objectsMu.RUnlock()
+	// fetch object from the database, the following pages are cut from the snapshot of the first one
+	Blobarray, token, err := listPage(ctx, s, "prefixLists", "", in.PageToken, offset, size, func() []*PrefixList {
+		Blobarray := []*PrefixList{}
+		for _, list := range s.PrefixLists {
+			Blobarray = append(Blobarray, list.clone())
+		}
+		// sort is needed, since MAP is unsorted in golang, and we might get different results
+		sortPrefixLists(Blobarray)
+		return Blobarray
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &ListPrefixListsResponse{PrefixLists: Blobarray, NextPageToken: token}, nil
+}
@@ -0,0 +1,53 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// frrPrefixList renders the entries of the list, replacing the previous ones
+func frrPrefixList(obj *PrefixList) string {
+	name := routePolicyFrrName(obj.Name)
+	var b strings.Builder
+	fmt.Fprintf(&b, "no ip prefix-list %s\n", name)
+	for _, entry := range obj.Spec.Entries {
+		// Example: ip prefix-list tenants seq 10 permit 10.0.0.0/8 ge 16 le 24
+		fmt.Fprintf(&b, "ip prefix-list %s seq %d %s %s", name, entry.Seq, entry.Action, ipPrefixToNet(entry.Prefix))
+		if entry.Ge != 0 {
+			fmt.Fprintf(&b, " ge %d", entry.Ge)
+		}
+		if entry.Le != 0 {
+			fmt.Fprintf(&b, " le %d", entry.Le)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+func (s *Server) frrCreatePrefixListRequest(ctx context.Context, obj *PrefixList) error {
+	data, err := s.frr.FrrBgpCmd(ctx, fmt.Sprintf(
+		`configure terminal
+		%sexit`, frrPrefixList(obj)))
+	fmt.Printf("FrrBgpCmd: %v:%v", data, err)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (s *Server) frrDeletePrefixListRequest(ctx context.Context, obj *PrefixList) error {
+	data, err := s.frr.FrrBgpCmd(ctx, fmt.Sprintf(
+		`configure terminal
+		no ip prefix-list %s
+		exit`, routePolicyFrrName(obj.Name)))
+	fmt.Printf("FrrBgpCmd: %v:%v", data, err)
+	if err != nil {
+		return err
+	}
+	return nil
+}
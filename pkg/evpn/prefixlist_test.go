@@ -0,0 +1,277 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/philippgille/gokv/gomap"
+	"github.com/stretchr/testify/mock"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	pc "github.com/opiproject/opi-api/network/opinetcommon/v1alpha1/gen/go"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils"
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils/mocks"
+)
+
+var (
+	testPrefixListID   = "opi-prefixes8"
+	testPrefixListName = resourceIDToFullName("prefixlists", testPrefixListID)
+	testPrefixListNet  = &pc.IPPrefix{Addr: &pc.IPAddress{Af: pc.IpAf_IP_AF_INET, V4OrV6: &pc.IPAddress_V4Addr{V4Addr: 167772160}}, Len: 8}
+	testPrefixList     = PrefixList{
+		Spec: &PrefixListSpec{
+			Entries: []*PrefixListEntry{
+				{Seq: 10, Action: RoutePermit, Prefix: testPrefixListNet, Ge: 16, Le: 24},
+				{Seq: 20, Action: RouteDeny, Prefix: testPrefixListNet},
+			},
+		},
+	}
+	testPrefixListWithName = PrefixList{
+		Name: testPrefixListName,
+		Spec: testPrefixList.Spec,
+	}
+)
+
+func Test_CreatePrefixList(t *testing.T) {
+	tests := map[string]struct {
+		id      string
+		in      *PrefixList
+		out     *PrefixList
+		errCode codes.Code
+		errMsg  string
+		exist   bool
+		on      func(mockFrr *mocks.Frr, errMsg string)
+	}{
+		"no entries": {
+			id:      testPrefixListID,
+			in:      &PrefixList{Spec: &PrefixListSpec{}},
+			out:     nil,
+			errCode: codes.InvalidArgument,
+			errMsg:  "missing required field: prefix_list.spec.entries",
+			exist:   false,
+			on:      nil,
+		},
+		"duplicated seq": {
+			id: testPrefixListID,
+			in: &PrefixList{Spec: &PrefixListSpec{Entries: []*PrefixListEntry{
+				{Seq: 10, Action: RoutePermit, Prefix: testPrefixListNet},
+				{Seq: 10, Action: RouteDeny, Prefix: testPrefixListNet},
+			}}},
+			out:     nil,
+			errCode: codes.InvalidArgument,
+			errMsg:  "seq 10 is used by several entries",
+			exist:   false,
+			on:      nil,
+		},
+		"invalid action": {
+			id:      testPrefixListID,
+			in:      &PrefixList{Spec: &PrefixListSpec{Entries: []*PrefixListEntry{{Seq: 10, Action: "accept", Prefix: testPrefixListNet}}}},
+			out:     nil,
+			errCode: codes.InvalidArgument,
+			errMsg:  fmt.Sprintf("action %q have to be %s or %s", "accept", RoutePermit, RouteDeny),
+			exist:   false,
+			on:      nil,
+		},
+		"ge shorter than the prefix": {
+			id:      testPrefixListID,
+			in:      &PrefixList{Spec: &PrefixListSpec{Entries: []*PrefixListEntry{{Seq: 10, Action: RoutePermit, Prefix: testPrefixListNet, Ge: 8}}}},
+			out:     nil,
+			errCode: codes.InvalidArgument,
+			errMsg:  "ge (8) have to be between 9 and 32",
+			exist:   false,
+			on:      nil,
+		},
+		"le shorter than ge": {
+			id:      testPrefixListID,
+			in:      &PrefixList{Spec: &PrefixListSpec{Entries: []*PrefixListEntry{{Seq: 10, Action: RoutePermit, Prefix: testPrefixListNet, Ge: 24, Le: 16}}}},
+			out:     nil,
+			errCode: codes.InvalidArgument,
+			errMsg:  "le (16) have to be between ge or 9 and 32",
+			exist:   false,
+			on:      nil,
+		},
+		"already exists": {
+			id:      testPrefixListID,
+			in:      &testPrefixList,
+			out:     &testPrefixListWithName,
+			errCode: codes.OK,
+			errMsg:  "",
+			exist:   true,
+			on:      nil,
+		},
+		"already exists with a different spec": {
+			id:      testPrefixListID,
+			in:      &PrefixList{Spec: &PrefixListSpec{Entries: []*PrefixListEntry{{Seq: 10, Action: RouteDeny, Prefix: testPrefixListNet}}}},
+			out:     nil,
+			errCode: codes.AlreadyExists,
+			errMsg:  fmt.Sprintf("%s already exists with a different spec", testPrefixListName),
+			exist:   true,
+			on:      nil,
+		},
+		"failed FrrBgpCmd call": {
+			id:      testPrefixListID,
+			in:      &testPrefixList,
+			out:     nil,
+			errCode: codes.Unknown,
+			errMsg:  "Failed to call FrrBgpCmd",
+			exist:   false,
+			on: func(mockFrr *mocks.Frr, errMsg string) {
+				mockFrr.EXPECT().FrrBgpCmd(mock.Anything, mock.Anything).Return("", errors.New(errMsg)).Once()
+			},
+		},
+		"successful call": {
+			id:      testPrefixListID,
+			in:      &testPrefixList,
+			out:     &testPrefixListWithName,
+			errCode: codes.OK,
+			errMsg:  "",
+			exist:   false,
+			on: func(mockFrr *mocks.Frr, errMsg string) {
+				mockFrr.EXPECT().FrrBgpCmd(mock.Anything, mock.MatchedBy(func(command string) bool {
+					return strings.Contains(command, "no ip prefix-list opi-prefixes8\n") &&
+						strings.Contains(command, "ip prefix-list opi-prefixes8 seq 10 permit 10.0.0.0/8 ge 16 le 24\n") &&
+						strings.Contains(command, "ip prefix-list opi-prefixes8 seq 20 deny 10.0.0.0/8\n")
+				})).Return("", nil).Once()
+			},
+		},
+	}
+
+	// run tests
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			mockFrr := mocks.NewFrr(t)
+			opi := NewServerWithArgs(mocks.NewNetlink(t), mockFrr, gomap.NewStore(gomap.DefaultOptions))
+
+			if tt.exist {
+				opi.PrefixLists[testPrefixListName] = testPrefixListWithName.clone()
+			}
+			if tt.on != nil {
+				tt.on(mockFrr, tt.errMsg)
+			}
+
+			request := &CreatePrefixListRequest{PrefixList: tt.in.clone(), PrefixListID: tt.id}
+			response, err := opi.CreatePrefixList(ctx, request)
+			if !reflect.DeepEqual(tt.out, response) {
+				t.Error("response: expected", tt.out, "received", response)
+			}
+
+			if er, ok := status.FromError(err); ok {
+				if er.Code() != tt.errCode {
+					t.Error("error code: expected", tt.errCode, "received", er.Code())
+				}
+				if er.Message() != tt.errMsg {
+					t.Error("error message: expected", tt.errMsg, "received", er.Message())
+				}
+			} else {
+				t.Error("expected grpc error status")
+			}
+		})
+	}
+}
+
+func Test_DeletePrefixList(t *testing.T) {
+	tests := map[string]struct {
+		in         string
+		out        *emptypb.Empty
+		errCode    codes.Code
+		errMsg     string
+		missing    bool
+		referenced bool
+		on         func(mockFrr *mocks.Frr, errMsg string)
+	}{
+		"valid request with unknown key": {
+			in:      "unknown-id",
+			out:     nil,
+			errCode: codes.NotFound,
+			errMsg:  fmt.Sprintf("unable to find key %v", resourceIDToFullName("prefixlists", "unknown-id")),
+			missing: false,
+			on:      nil,
+		},
+		"unknown key with missing allowed": {
+			in:      "unknown-id",
+			out:     &emptypb.Empty{},
+			errCode: codes.OK,
+			errMsg:  "",
+			missing: true,
+			on:      nil,
+		},
+		"matched by a route-map": {
+			in:         testPrefixListID,
+			out:        nil,
+			errCode:    codes.FailedPrecondition,
+			errMsg:     fmt.Sprintf("%s is still referenced by %s, delete them first or set %s", testPrefixListName, testRouteMapName, utils.CascadeMetadataKey),
+			missing:    false,
+			referenced: true,
+			on:         nil,
+		},
+		"failed FrrBgpCmd call": {
+			in:      testPrefixListID,
+			out:     nil,
+			errCode: codes.Unknown,
+			errMsg:  "Failed to call FrrBgpCmd",
+			missing: false,
+			on: func(mockFrr *mocks.Frr, errMsg string) {
+				mockFrr.EXPECT().FrrBgpCmd(mock.Anything, mock.Anything).Return("", errors.New(errMsg)).Once()
+			},
+		},
+		"successful call": {
+			in:      testPrefixListID,
+			out:     &emptypb.Empty{},
+			errCode: codes.OK,
+			errMsg:  "",
+			missing: false,
+			on: func(mockFrr *mocks.Frr, errMsg string) {
+				mockFrr.EXPECT().FrrBgpCmd(mock.Anything, mock.MatchedBy(func(command string) bool {
+					return strings.Contains(command, "no ip prefix-list opi-prefixes8")
+				})).Return("", nil).Once()
+			},
+		},
+	}
+
+	// run tests
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			mockFrr := mocks.NewFrr(t)
+			opi := NewServerWithArgs(mocks.NewNetlink(t), mockFrr, gomap.NewStore(gomap.DefaultOptions))
+
+			opi.PrefixLists[testPrefixListName] = testPrefixListWithName.clone()
+			if tt.referenced {
+				opi.RouteMaps[testRouteMapName] = testRouteMapWithName.clone()
+			}
+			if tt.on != nil {
+				tt.on(mockFrr, tt.errMsg)
+			}
+
+			request := &DeletePrefixListRequest{Name: resourceIDToFullName("prefixlists", tt.in), AllowMissing: tt.missing}
+			response, err := opi.DeletePrefixList(ctx, request)
+
+			if er, ok := status.FromError(err); ok {
+				if er.Code() != tt.errCode {
+					t.Error("error code: expected", tt.errCode, "received", er.Code())
+				}
+				if er.Message() != tt.errMsg {
+					t.Error("error message: expected", tt.errMsg, "received", er.Message())
+				}
+			} else {
+				t.Error("expected grpc error status")
+			}
+
+			if reflect.TypeOf(response) != reflect.TypeOf(tt.out) {
+				t.Error("response: expected", reflect.TypeOf(tt.out), "received", reflect.TypeOf(response))
+			}
+		})
+	}
+}
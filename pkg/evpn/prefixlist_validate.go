@@ -0,0 +1,110 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"fmt"
+
+	"go.einride.tech/aip/resourcename"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// validateRouteEntry checks the sequence number and the action of an entry of a PrefixList or a RouteMap
+func validateRouteEntry(field string, seq uint32, action RouteAction, seqs map[uint32]bool) error {
+	if seq == 0 {
+		return missingField(field + ".seq")
+	}
+	if seqs[seq] {
+		msg := fmt.Sprintf("seq %d is used by several entries", seq)
+		return badRequest(field+".seq", status.Error(codes.InvalidArgument, msg))
+	}
+	seqs[seq] = true
+	if action != RoutePermit && action != RouteDeny {
+		msg := fmt.Sprintf("action %q have to be %s or %s", action, RoutePermit, RouteDeny)
+		return badRequest(field+".action", status.Error(codes.InvalidArgument, msg))
+	}
+	return nil
+}
+
+func validatePrefixListSpec(spec *PrefixListSpec) error {
+	if len(spec.Entries) == 0 {
+		return missingField("prefix_list.spec.entries")
+	}
+	seqs := map[uint32]bool{}
+	for i, entry := range spec.Entries {
+		field := fmt.Sprintf("prefix_list.spec.entries[%d]", i)
+		if entry == nil {
+			return missingField(field)
+		}
+		if err := validateRouteEntry(field, entry.Seq, entry.Action, seqs); err != nil {
+			return err
+		}
+		if entry.Prefix == nil || entry.Prefix.Addr == nil {
+			return missingField(field + ".prefix")
+		}
+		if entry.Prefix.Len > 32 {
+			msg := fmt.Sprintf("Prefix length (%d) have to be between 0 and 32", entry.Prefix.Len)
+			return badRequest(field+".prefix.len", status.Error(codes.InvalidArgument, msg))
+		}
+		// FRR wants len < ge <= le <= 32
+		if entry.Ge != 0 && (entry.Ge <= uint32(entry.Prefix.Len) || entry.Ge > 32) {
+			msg := fmt.Sprintf("ge (%d) have to be between %d and 32", entry.Ge, entry.Prefix.Len+1)
+			return badRequest(field+".ge", status.Error(codes.InvalidArgument, msg))
+		}
+		if entry.Le != 0 && (entry.Le <= uint32(entry.Prefix.Len) || entry.Le > 32 || entry.Le < entry.Ge) {
+			msg := fmt.Sprintf("le (%d) have to be between ge or %d and 32", entry.Le, entry.Prefix.Len+1)
+			return badRequest(field+".le", status.Error(codes.InvalidArgument, msg))
+		}
+	}
+	return nil
+}
+
+func (s *Server) validateCreatePrefixListRequest(in *CreatePrefixListRequest) error {
+	// check required fields
+	switch {
+	case in.PrefixList == nil:
+		return missingField("prefix_list")
+	case in.PrefixList.Spec == nil:
+		return missingField("prefix_list.spec")
+	}
+	if err := validatePrefixListSpec(in.PrefixList.Spec); err != nil {
+		return err
+	}
+	// see https://google.aip.dev/133#user-specified-ids
+	if in.PrefixListID != "" {
+		if err := badRequest("prefix_list_id", validateResourceID(in.PrefixListID, false)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Server) validateUpdatePrefixListRequest(in *UpdatePrefixListRequest) error {
+	// check required fields
+	switch {
+	case in.PrefixList == nil:
+		return missingField("prefix_list")
+	case in.PrefixList.Name == "":
+		return missingField("prefix_list.name")
+	case in.PrefixList.Spec == nil:
+		return missingField("prefix_list.spec")
+	}
+	if err := validatePrefixListSpec(in.PrefixList.Spec); err != nil {
+		return err
+	}
+	// Validate that a resource name conforms to the restrictions outlined in AIP-122.
+	return badRequest("prefix_list.name", resourcename.Validate(in.PrefixList.Name))
+}
+
+func (s *Server) validateDeletePrefixListRequest(in *DeletePrefixListRequest) error {
+	// check required fields
+	if in.Name == "" {
+		return missingField("name")
+	}
+	// Validate that a resource name conforms to the restrictions outlined in AIP-122.
+	return badRequest("name", resourcename.Validate(in.Name))
+}
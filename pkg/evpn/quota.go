@@ -0,0 +1,87 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// quotaTypes are the limits the whole server can be given, keeping a runaway orchestrator
+// from exhausting the kernel resources of the DPU
+var quotaTypes = map[string]bool{
+	"LogicalBridge":               true,
+	"Vrf":                         true,
+	"BridgePortsPerLogicalBridge": true,
+	"Vni":                         true,
+}
+
+// ParseQuotas parses the limits of the server in Limit=N,Limit=N format
+// (e.g.: LogicalBridge=1000,Vrf=64,BridgePortsPerLogicalBridge=32,Vni=1024)
+func ParseQuotas(value string) (map[string]int, error) {
+	return parseQuotas(value, quotaTypes)
+}
+
+// parseQuotas parses non-negative limits in Name=N,Name=N format, for the given names only
+func parseQuotas(value string, types map[string]bool) (map[string]int, error) {
+	quotas := make(map[string]int)
+	if value == "" {
+		return quotas, nil
+	}
+	for _, item := range strings.Split(value, ",") {
+		object, count, ok := strings.Cut(item, "=")
+		if !ok || !types[object] {
+			return nil, fmt.Errorf("invalid quota %q, expected %s", item, strings.Join(quotaFormats(types), " or "))
+		}
+		n, err := strconv.Atoi(count)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid quota %q, N has to be a non-negative number", item)
+		}
+		quotas[object] = n
+	}
+	return quotas, nil
+}
+
+func quotaFormats(types map[string]bool) []string {
+	formats := []string{}
+	for object := range types {
+		formats = append(formats, object+"=N")
+	}
+	sort.Strings(formats)
+	return formats
+}
+
+// quotaExceeded is the ResourceExhausted error of a limit reached, with a QuotaFailure
+// detail telling the limit, see https://google.aip.dev/193#error-details
+func quotaExceeded(subject string, msg string) error {
+	st := status.New(codes.ResourceExhausted, msg)
+	detailed, err := st.WithDetails(&errdetails.QuotaFailure{
+		Violations: []*errdetails.QuotaFailure_Violation{{Subject: subject, Description: msg}},
+	})
+	if err != nil {
+		return st.Err()
+	}
+	return detailed.Err()
+}
+
+// validateVniQuota checks a vni not used yet by a LogicalBridge or a Vrf fits in the Vni limit
+func (s *Server) validateVniQuota(vni *uint32) error {
+	limit, ok := s.Quotas["Vni"]
+	if !ok || vni == nil {
+		return nil
+	}
+	// a vni is used by one LogicalBridge or Vrf only
+	used := s.indexes.bridgeVni.count(s.Bridges) + s.indexes.vrfVni.count(s.Vrfs)
+	if len(s.vniUsers(*vni)) == 0 && used >= limit {
+		return quotaExceeded("Vni", fmt.Sprintf("max number of VNIs (%d) reached", limit))
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"testing"
+
+	"github.com/philippgille/gokv/gomap"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+	pc "github.com/opiproject/opi-api/network/opinetcommon/v1alpha1/gen/go"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils/mocks"
+)
+
+func Test_Quotas(t *testing.T) {
+	opi := NewServerWithArgs(mocks.NewNetlink(t), mocks.NewFrr(t), gomap.NewStore(gomap.DefaultOptions))
+	opi.Quotas = map[string]int{"LogicalBridge": 2, "Vni": 1, "BridgePortsPerLogicalBridge": 1, "Vrf": 0}
+	bridge := resourceIDToFullName("bridges", "bridge1")
+	opi.Bridges[bridge] = &pb.LogicalBridge{Name: bridge, Spec: &pb.LogicalBridgeSpec{VlanId: 10, Vni: proto.Uint32(10)}}
+	port := resourceIDToFullName("ports", "port1")
+	opi.Ports[port] = &pb.BridgePort{Name: port, Spec: &pb.BridgePortSpec{LogicalBridges: []string{bridge}}}
+	tests := map[string]struct {
+		call    func() error
+		subject string
+	}{
+		"vrf limit": {
+			call: func() error {
+				_, err := opi.CreateVrf(context.Background(), &pb.CreateVrfRequest{VrfId: "blue", Vrf: &pb.Vrf{Spec: &pb.VrfSpec{
+					LoopbackIpPrefix: &pc.IPPrefix{Len: 24},
+				}}})
+				return err
+			},
+			subject: "Vrf",
+		},
+		"vni limit": {
+			call: func() error {
+				_, err := opi.CreateLogicalBridge(context.Background(), &pb.CreateLogicalBridgeRequest{LogicalBridgeId: "bridge2", LogicalBridge: &pb.LogicalBridge{Spec: &pb.LogicalBridgeSpec{
+					VlanId: 20,
+					Vni:    proto.Uint32(20),
+				}}})
+				return err
+			},
+			subject: "Vni",
+		},
+		"ports per bridge limit": {
+			call: func() error {
+				_, err := opi.CreateBridgePort(context.Background(), &pb.CreateBridgePortRequest{BridgePortId: "port2", BridgePort: &pb.BridgePort{Spec: &pb.BridgePortSpec{
+					MacAddress:     []byte{0xCB, 0xB8, 0x33, 0x4C, 0x88, 0x4F},
+					Ptype:          pb.BridgePortType_ACCESS,
+					LogicalBridges: []string{bridge},
+				}}})
+				return err
+			},
+			subject: bridge,
+		},
+	}
+	for testName, tt := range tests {
+		t.Run(testName, func(t *testing.T) {
+			st := status.Convert(tt.call())
+			if st.Code() != codes.ResourceExhausted {
+				t.Fatal("error code: expected", codes.ResourceExhausted, "received", st.Code())
+			}
+			if len(st.Details()) != 1 {
+				t.Fatal("details: expected 1, received", st.Details())
+			}
+			failure, ok := st.Details()[0].(*errdetails.QuotaFailure)
+			if !ok || len(failure.Violations) != 1 || failure.Violations[0].Subject != tt.subject {
+				t.Error("quota failure: expected", tt.subject, "received", st.Details()[0])
+			}
+		})
+	}
+
+	// the BridgePort being updated is not counted against its own LogicalBridges
+	if err := opi.validateBridgePortQuota(opi.Ports[port]); err != nil {
+		t.Error("error: expected", nil, "received", err)
+	}
+	// a LogicalBridge reusing an existing vni does not count against the Vni limit
+	if err := opi.validateVniQuota(proto.Uint32(10)); err != nil {
+		t.Error("error: expected", nil, "received", err)
+	}
+}
+
+func Test_ParseQuotas(t *testing.T) {
+	quotas, err := ParseQuotas("BridgePortsPerLogicalBridge=32,Vni=1024")
+	if err != nil || quotas["BridgePortsPerLogicalBridge"] != 32 || quotas["Vni"] != 1024 {
+		t.Error("quotas: expected BridgePortsPerLogicalBridge=32,Vni=1024, received", quotas, err)
+	}
+	if _, err := ParseQuotas("Vni=-1"); err == nil {
+		t.Error("error: expected negative limit, received", nil)
+	}
+}
@@ -0,0 +1,48 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"fmt"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// maxVlanID is the highest usable 802.1Q vlan, 4095 is reserved
+	maxVlanID = 4094
+	// defaultVlanID is the default vlan of most switches, rejected when s.RejectDefaultVlan is set
+	defaultVlanID = 1
+	// maxVni is the highest 24 bits VXLAN network identifier
+	maxVni = 1<<24 - 1
+)
+
+// validateVlanID fails for the reserved vlans 0 and 4095, out of range ones and,
+// when configured, the default vlan
+func (s *Server) validateVlanID(vlanID uint32) error {
+	if vlanID < 1 || vlanID > maxVlanID {
+		msg := fmt.Sprintf("VlanId value (%d) have to be between 1 and %d", vlanID, maxVlanID)
+		return status.Error(codes.InvalidArgument, msg)
+	}
+	if s.RejectDefaultVlan && vlanID == defaultVlanID {
+		msg := fmt.Sprintf("VlanId value (%d) is the default vlan and is reserved", vlanID)
+		return status.Error(codes.InvalidArgument, msg)
+	}
+	return nil
+}
+
+// validateVni fails for a vni not fitting 24 bits, 0 is rejected as well since
+// the kernel treats it as a wildcard, a missing vni is valid
+func validateVni(vni *uint32) error {
+	if vni == nil {
+		return nil
+	}
+	if *vni < 1 || *vni > maxVni {
+		msg := fmt.Sprintf("Vni value (%d) have to be between 1 and %d", *vni, maxVni)
+		return status.Error(codes.InvalidArgument, msg)
+	}
+	return nil
+}
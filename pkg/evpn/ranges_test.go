@@ -0,0 +1,66 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"testing"
+
+	"github.com/philippgille/gokv/gomap"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils/mocks"
+)
+
+func Test_validateVlanID(t *testing.T) {
+	tests := map[string]struct {
+		vlanID            uint32
+		rejectDefaultVlan bool
+		wantErr           bool
+	}{
+		"reserved 0":                {vlanID: 0, wantErr: true},
+		"default vlan allowed":      {vlanID: 1, wantErr: false},
+		"default vlan rejected":     {vlanID: 1, rejectDefaultVlan: true, wantErr: true},
+		"highest usable":            {vlanID: 4094, wantErr: false},
+		"reserved 4095":             {vlanID: 4095, wantErr: true},
+		"out of 12 bits":            {vlanID: 4096, wantErr: true},
+		"regular vlan with default": {vlanID: 10, rejectDefaultVlan: true, wantErr: false},
+	}
+	for testName, tt := range tests {
+		t.Run(testName, func(t *testing.T) {
+			opi := NewServerWithArgs(mocks.NewNetlink(t), mocks.NewFrr(t), gomap.NewStore(gomap.DefaultOptions))
+			opi.RejectDefaultVlan = tt.rejectDefaultVlan
+			err := opi.validateVlanID(tt.vlanID)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateVlanID() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && status.Code(err) != codes.InvalidArgument {
+				t.Errorf("validateVlanID() code = %v, want %v", status.Code(err), codes.InvalidArgument)
+			}
+		})
+	}
+}
+
+func Test_validateVni(t *testing.T) {
+	tests := map[string]struct {
+		vni     *uint32
+		wantErr bool
+	}{
+		"missing":        {vni: nil, wantErr: false},
+		"zero":           {vni: proto.Uint32(0), wantErr: true},
+		"highest":        {vni: proto.Uint32(16777215), wantErr: false},
+		"out of 24 bits": {vni: proto.Uint32(16777216), wantErr: true},
+	}
+	for testName, tt := range tests {
+		t.Run(testName, func(t *testing.T) {
+			err := validateVni(tt.vni)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateVni() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
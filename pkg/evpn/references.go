@@ -0,0 +1,176 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils"
+)
+
+// logicalBridgeDependents lists the StaticFdbEntries, Svis and BridgePorts referencing the LogicalBridge, in deletion order
+func (s *Server) logicalBridgeDependents(name string) []string {
+	var entries, svis, ports []string
+	for _, obj := range s.FdbEntries {
+		if staticFdbEntryParent(obj.Name) == name {
+			entries = append(entries, obj.Name)
+		}
+	}
+	svis = s.indexes.sviBridge.lookup(s.Svis, name)
+	ports = s.indexes.portBridge.lookup(s.Ports, name)
+	sort.Strings(entries)
+	dependents := append(entries, svis...)
+	return append(dependents, ports...)
+}
+
+// bridgePortDependents lists the StaticFdbEntries pinned to the BridgePort
+func (s *Server) bridgePortDependents(name string) []string {
+	var entries []string
+	for _, obj := range s.FdbEntries {
+		if obj.Spec.BridgePort == name {
+			entries = append(entries, obj.Name)
+		}
+	}
+	sort.Strings(entries)
+	return entries
+}
+
+// vrfDependents lists the SecurityPolicies, NatRules, PbrRules, RouteLeaks, Routes, VrfLiteHandoffs and Svis referencing the Vrf, in deletion order
+func (s *Server) vrfDependents(name string) []string {
+	var policies, nats, pbrs, leaks, routes, handoffs, svis []string
+	for _, obj := range s.Policies {
+		if securityPolicyParent(obj.Name) == name {
+			policies = append(policies, obj.Name)
+		}
+	}
+	for _, obj := range s.NatRules {
+		if natRuleParent(obj.Name) == name {
+			nats = append(nats, obj.Name)
+		}
+	}
+	for _, obj := range s.PbrRules {
+		if pbrRuleParent(obj.Name) == name {
+			pbrs = append(pbrs, obj.Name)
+		}
+	}
+	for _, obj := range s.RouteLeaks {
+		if obj.Spec.SourceVrf == name || obj.Spec.DestinationVrf == name {
+			leaks = append(leaks, obj.Name)
+		}
+	}
+	for _, obj := range s.Routes {
+		if routeParent(obj.Name) == name {
+			routes = append(routes, obj.Name)
+		}
+	}
+	for _, obj := range s.Handoffs {
+		if obj.Spec.Vrf == name {
+			handoffs = append(handoffs, obj.Name)
+		}
+	}
+	svis = append(svis, s.indexes.sviVrf.lookup(s.Svis, name)...)
+	for _, names := range [][]string{policies, nats, pbrs, leaks, routes, handoffs, svis} {
+		sort.Strings(names)
+	}
+	dependents := append(policies, nats...)
+	dependents = append(dependents, pbrs...)
+	dependents = append(dependents, leaks...)
+	dependents = append(dependents, routes...)
+	dependents = append(dependents, handoffs...)
+	return append(dependents, svis...)
+}
+
+// prefixListDependents lists the RouteMaps matching the PrefixList
+func (s *Server) prefixListDependents(name string) []string {
+	var maps []string
+	for _, obj := range s.RouteMaps {
+		for _, entry := range obj.Spec.Entries {
+			if entry.MatchPrefixList == name {
+				maps = append(maps, obj.Name)
+				break
+			}
+		}
+	}
+	sort.Strings(maps)
+	return maps
+}
+
+// routeMapDependents lists the BgpPeers and VrfLiteHandoffs referencing the RouteMap, in deletion order
+func (s *Server) routeMapDependents(name string) []string {
+	var peers, handoffs []string
+	for _, obj := range s.BgpPeers {
+		if obj.Spec.ImportRouteMap == name || obj.Spec.ExportRouteMap == name {
+			peers = append(peers, obj.Name)
+		}
+	}
+	for _, obj := range s.Handoffs {
+		if obj.Spec.ImportRouteMap == name || obj.Spec.ExportRouteMap == name {
+			handoffs = append(handoffs, obj.Name)
+		}
+	}
+	sort.Strings(peers)
+	sort.Strings(handoffs)
+	return append(peers, handoffs...)
+}
+
+// checkDependents fails with FailedPrecondition while dependents remain, unless
+// the call asks for a cascade, in which case they are deleted first
+func (s *Server) checkDependents(ctx context.Context, name string, dependents []string) error {
+	if len(dependents) == 0 {
+		return nil
+	}
+	if !utils.IsCascade(ctx) {
+		msg := fmt.Sprintf("%s is still referenced by %s, delete them first or set %s", name, strings.Join(dependents, ", "), utils.CascadeMetadataKey)
+		return status.Error(codes.FailedPrecondition, msg)
+	}
+	if utils.IsValidateOnly(ctx) {
+		return nil
+	}
+	for _, dependent := range dependents {
+		if err := s.deleteDependent(ctx, dependent); err != nil {
+			fmt.Printf("Failed to delete dependent %s of %s: %v", dependent, name, err)
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteDependent deletes a dependent of any type, with objectsMu held by the Delete of the
+// object it depends on
+func (s *Server) deleteDependent(ctx context.Context, name string) error {
+	var err error
+	if _, ok := s.Policies[name]; ok {
+		_, err = s.deleteSecurityPolicy(ctx, &DeleteSecurityPolicyRequest{Name: name, AllowMissing: true})
+	} else if _, ok := s.NatRules[name]; ok {
+		_, err = s.deleteNatRule(ctx, &DeleteNatRuleRequest{Name: name, AllowMissing: true})
+	} else if _, ok := s.PbrRules[name]; ok {
+		_, err = s.deletePbrRule(ctx, &DeletePbrRuleRequest{Name: name, AllowMissing: true})
+	} else if _, ok := s.RouteLeaks[name]; ok {
+		_, err = s.deleteRouteLeak(ctx, &DeleteRouteLeakRequest{Name: name, AllowMissing: true})
+	} else if _, ok := s.Routes[name]; ok {
+		_, err = s.deleteRoute(ctx, &DeleteRouteRequest{Name: name, AllowMissing: true})
+	} else if _, ok := s.Handoffs[name]; ok {
+		_, err = s.deleteVrfLiteHandoff(ctx, &DeleteVrfLiteHandoffRequest{Name: name, AllowMissing: true})
+	} else if _, ok := s.FdbEntries[name]; ok {
+		_, err = s.deleteStaticFdbEntry(ctx, &DeleteStaticFdbEntryRequest{Name: name, AllowMissing: true})
+	} else if _, ok := s.Svis[name]; ok {
+		_, err = s.deleteSvi(ctx, &pb.DeleteSviRequest{Name: name, AllowMissing: true})
+	} else if _, ok := s.Ports[name]; ok {
+		_, err = s.deleteBridgePort(ctx, &pb.DeleteBridgePortRequest{Name: name, AllowMissing: true})
+	} else if _, ok := s.RouteMaps[name]; ok {
+		_, err = s.deleteRouteMap(ctx, &DeleteRouteMapRequest{Name: name, AllowMissing: true})
+	} else if _, ok := s.BgpPeers[name]; ok {
+		_, err = s.deleteBgpPeer(ctx, &DeleteBgpPeerRequest{Name: name, AllowMissing: true})
+	}
+	return err
+}
@@ -0,0 +1,121 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/philippgille/gokv/gomap"
+	"github.com/stretchr/testify/mock"
+	"github.com/vishvananda/netlink"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils"
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils/mocks"
+)
+
+func Test_DeleteLogicalBridgeReferenced(t *testing.T) {
+	tests := map[string]struct {
+		md          metadata.MD
+		errCode     codes.Code
+		wantDeleted bool
+		on          func(mockNetlink *mocks.Netlink, mockFrr *mocks.Frr)
+	}{
+		"referenced by a BridgePort": {
+			md:          metadata.MD{},
+			errCode:     codes.FailedPrecondition,
+			wantDeleted: false,
+			on:          nil,
+		},
+		"cascade validate only": {
+			md:          metadata.Pairs(utils.CascadeMetadataKey, "true", utils.ValidateOnlyMetadataKey, "true"),
+			errCode:     codes.OK,
+			wantDeleted: false,
+			on:          nil,
+		},
+		"cascade fails on the BridgePort": {
+			md:          metadata.Pairs(utils.CascadeMetadataKey, "true"),
+			errCode:     codes.NotFound,
+			wantDeleted: false,
+			on: func(mockNetlink *mocks.Netlink, mockFrr *mocks.Frr) {
+				mockNetlink.EXPECT().LinkByName(mock.Anything, testBridgePortID).Return(nil, errors.New("Link not found")).Once()
+			},
+		},
+		"cascade deletes the BridgePort first": {
+			md:          metadata.Pairs(utils.CascadeMetadataKey, "true"),
+			errCode:     codes.OK,
+			wantDeleted: true,
+			on: func(mockNetlink *mocks.Netlink, mockFrr *mocks.Frr) {
+				iface := &netlink.Dummy{LinkAttrs: netlink.LinkAttrs{Name: testBridgePortID}}
+				vid := uint16(testLogicalBridge.Spec.VlanId)
+				mockNetlink.EXPECT().LinkByName(mock.Anything, testBridgePortID).Return(iface, nil).Once()
+				mockNetlink.EXPECT().LinkSetDown(mock.Anything, iface).Return(nil).Once()
+				mockNetlink.EXPECT().BridgeVlanDel(mock.Anything, iface, vid, true, true, false, false).Return(nil).Once()
+				mockNetlink.EXPECT().LinkDel(mock.Anything, iface).Return(nil).Once()
+				vxlan := &netlink.Vxlan{LinkAttrs: netlink.LinkAttrs{Name: "vni11", Alias: managedAlias}}
+				mockNetlink.EXPECT().LinkByName(mock.Anything, "vni11").Return(vxlan, nil).Once()
+				mockNetlink.EXPECT().LinkSetDown(mock.Anything, vxlan).Return(nil).Once()
+				mockNetlink.EXPECT().BridgeVlanDel(mock.Anything, vxlan, vid, true, true, false, false).Return(nil).Once()
+				mockNetlink.EXPECT().LinkDel(mock.Anything, vxlan).Return(nil).Once()
+			},
+		},
+	}
+
+	for testName, tt := range tests {
+		t.Run(testName, func(t *testing.T) {
+			ctx := metadata.NewIncomingContext(context.Background(), tt.md)
+			mockNetlink := mocks.NewNetlink(t)
+			mockFrr := mocks.NewFrr(t)
+			opi := NewServerWithArgs(mockNetlink, mockFrr, gomap.NewStore(gomap.DefaultOptions))
+			opi.Bridges[testLogicalBridgeName] = protoClone(&testLogicalBridgeWithStatus)
+			opi.Ports[testBridgePortName] = protoClone(&testBridgePortWithStatus)
+			if tt.on != nil {
+				tt.on(mockNetlink, mockFrr)
+			}
+
+			_, err := opi.DeleteLogicalBridge(ctx, &pb.DeleteLogicalBridgeRequest{Name: testLogicalBridgeName})
+			if status.Code(err) != tt.errCode {
+				t.Error("error code: expected", tt.errCode, "received", status.Code(err), err)
+			}
+			_, bridgeExists := opi.Bridges[testLogicalBridgeName]
+			_, portExists := opi.Ports[testBridgePortName]
+			if bridgeExists == tt.wantDeleted || portExists == tt.wantDeleted {
+				t.Error("deleted: expected", tt.wantDeleted, "received bridge", !bridgeExists, "port", !portExists)
+			}
+		})
+	}
+}
+
+func Test_vrfDependents(t *testing.T) {
+	opi := NewServerWithArgs(mocks.NewNetlink(t), mocks.NewFrr(t), gomap.NewStore(gomap.DefaultOptions))
+	otherVrfName := resourceIDToFullName("vrfs", "other")
+	sviName := resourceIDToFullName("svis", "svi1")
+	handoffName := resourceIDToFullName("handoffs", "handoff1")
+	leakName := resourceIDToFullName("routeleaks", "leak1")
+	opi.Svis[sviName] = &pb.Svi{Name: sviName, Spec: &pb.SviSpec{Vrf: testVrfName}}
+	opi.Handoffs[handoffName] = &VrfLiteHandoff{Name: handoffName, Spec: &VrfLiteHandoffSpec{Vrf: testVrfName}}
+	opi.RouteLeaks[leakName] = &RouteLeak{Name: leakName, Spec: &RouteLeakSpec{SourceVrf: otherVrfName, DestinationVrf: testVrfName}}
+
+	want := []string{leakName, handoffName, sviName}
+	got := opi.vrfDependents(testVrfName)
+	if len(got) != len(want) {
+		t.Fatalf("vrfDependents() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("vrfDependents() = %v, want %v", got, want)
+		}
+	}
+	if got := opi.vrfDependents(resourceIDToFullName("vrfs", "unused")); len(got) != 0 {
+		t.Errorf("vrfDependents() = %v, want none", got)
+	}
+}
@@ -0,0 +1,147 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils"
+)
+
+var (
+	// pciAddress matches a PCI address in domain:bus:device.function format, e.g.: 0000:03:00.2
+	pciAddress = regexp.MustCompile(`^[0-9a-f]{4}:[0-9a-f]{2}:[0-9a-f]{2}\.[0-7]$`)
+	// uplinkPortName matches the phys_port_name of the uplink of a physical function, e.g.: p0
+	uplinkPortName = regexp.MustCompile(`^p(\d+)$`)
+)
+
+// parseVfIndex parses the vf or pf/vf format of a VF index
+func parseVfIndex(value string) (uint32, uint32, error) {
+	pf, vf := "0", value
+	if before, after, ok := strings.Cut(value, "/"); ok {
+		pf, vf = before, after
+	}
+	pfIndex, err := strconv.ParseUint(pf, 10, 16)
+	if err != nil {
+		return 0, 0, fmt.Errorf("%q has to be in vf or pf/vf format", value)
+	}
+	vfIndex, err := strconv.ParseUint(vf, 10, 16)
+	if err != nil {
+		return 0, 0, fmt.Errorf("%q has to be in vf or pf/vf format", value)
+	}
+	return uint32(pfIndex), uint32(vfIndex), nil
+}
+
+// portKernelNameFor returns the kernel interface of a new BridgePort, the one of the PCI
+// address or VF index sent with the call, or kept from a previous incarnation of the same
+// BridgePort (e.g.: on replay), its resource ID otherwise
+func (s *Server) portKernelNameFor(ctx context.Context, obj *pb.BridgePort) (string, error) {
+	address, hasAddress := utils.MetadataValue(ctx, utils.PciAddressMetadataKey)
+	index, hasIndex := utils.MetadataValue(ctx, utils.VfIndexMetadataKey)
+	if (!hasAddress || address == "") && (!hasIndex || index == "") {
+		return s.portKernelName(obj.Name), nil
+	}
+	if hasAddress && address != "" && hasIndex && index != "" {
+		msg := "only one of the PCI address and the VF index can be set"
+		return "", badRequest(utils.VfIndexMetadataKey, status.Error(codes.InvalidArgument, msg))
+	}
+	ifaces, err := s.hostInterfaces(ctx)
+	if err != nil {
+		return "", err
+	}
+	var kernel string
+	if hasAddress && address != "" {
+		address = strings.ToLower(address)
+		if !pciAddress.MatchString(address) {
+			msg := fmt.Sprintf("invalid PCI address %q, expected domain:bus:device.function", address)
+			return "", badRequest(utils.PciAddressMetadataKey, status.Error(codes.InvalidArgument, msg))
+		}
+		kernel, err = s.pciAddressInterface(ctx, ifaces, address)
+	} else {
+		pf, vf, perr := parseVfIndex(index)
+		if perr != nil {
+			msg := fmt.Sprintf("invalid VF index %v", perr)
+			return "", badRequest(utils.VfIndexMetadataKey, status.Error(codes.InvalidArgument, msg))
+		}
+		kernel, err = s.vfRepresentor(ctx, ifaces, pf, vf)
+	}
+	if err != nil {
+		return "", err
+	}
+	// a port cannot be plugged twice into the bridge
+	for name := range s.Ports {
+		if name != obj.Name && s.portKernelName(name) == kernel {
+			err := status.Errorf(codes.AlreadyExists, "interface %s is already used by %s", kernel, name)
+			return "", err
+		}
+	}
+	return kernel, nil
+}
+
+// pciAddressInterface returns the representor of the virtual function of a PCI address, or
+// the interface of a port when the address is not the one of a virtual function
+func (s *Server) pciAddressInterface(ctx context.Context, ifaces []HostInterface, address string) (string, error) {
+	for _, iface := range ifaces {
+		if iface.Kind != HostInterfacePf {
+			continue
+		}
+		for vf := uint32(0); vf < iface.NumVfs; vf++ {
+			value, err := s.sysfs.LinkAttr(ctx, iface.Name, fmt.Sprintf("device/virtfn%d", vf))
+			if err != nil || value != address {
+				continue
+			}
+			// the uplink of the pf N is named pN by the switchdev drivers
+			pf := uint64(0)
+			if name, err := s.sysfs.LinkAttr(ctx, iface.Name, "phys_port_name"); err == nil {
+				if match := uplinkPortName.FindStringSubmatch(name); match != nil {
+					pf, _ = strconv.ParseUint(match[1], 10, 16)
+				}
+			}
+			return s.vfRepresentor(ctx, ifaces, uint32(pf), vf)
+		}
+	}
+	// the representors share the PCI address of their physical function
+	for _, iface := range ifaces {
+		if iface.PciAddress == address && iface.Kind != HostInterfaceRepresentor {
+			return iface.Name, nil
+		}
+	}
+	err := status.Errorf(codes.NotFound, "unable to find the interface of PCI address %s", address)
+	return "", err
+}
+
+// vfRepresentor returns the representor of a virtual function, after checking its eswitch is
+// in switchdev mode, the bridge offloading the forwarding between the representors to it
+func (s *Server) vfRepresentor(ctx context.Context, ifaces []HostInterface, pf uint32, vf uint32) (string, error) {
+	port := regexp.MustCompile(fmt.Sprintf(`^(c\d+)?pf%dvf%d$`, pf, vf))
+	for _, iface := range ifaces {
+		if iface.Kind != HostInterfaceRepresentor || !port.MatchString(iface.PortName) {
+			continue
+		}
+		if iface.PciAddress != "" {
+			dev, err := s.nLink.DevLinkGetDeviceByName(ctx, "pci", iface.PciAddress)
+			if err != nil {
+				err := status.Errorf(codes.Unavailable, "unable to get the eswitch of %s: %v", iface.PciAddress, err)
+				return "", err
+			}
+			if mode := dev.Attrs.Eswitch.Mode; mode != "switchdev" {
+				err := status.Errorf(codes.FailedPrecondition, "eswitch of %s is in %s mode, representors require switchdev", iface.PciAddress, mode)
+				return "", err
+			}
+		}
+		return iface.Name, nil
+	}
+	err := status.Errorf(codes.NotFound, "unable to find the representor of vf %d of pf %d", vf, pf)
+	return "", err
+}
@@ -0,0 +1,147 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/philippgille/gokv/gomap"
+	"github.com/stretchr/testify/mock"
+	"github.com/vishvananda/netlink"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils"
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils/mocks"
+)
+
+func Test_portKernelNameFor(t *testing.T) {
+	links := []netlink.Link{
+		&netlink.Device{LinkAttrs: netlink.LinkAttrs{Name: "p0"}},
+		&netlink.Device{LinkAttrs: netlink.LinkAttrs{Name: "pf0vf1"}},
+		&netlink.Device{LinkAttrs: netlink.LinkAttrs{Name: "eth1"}},
+	}
+	sysfs := map[string]string{
+		"p0/device":              "0000:03:00.0",
+		"p0/phys_port_name":      "p0",
+		"p0/device/sriov_numvfs": "2",
+		"p0/device/virtfn0":      "0000:03:00.2",
+		"p0/device/virtfn1":      "0000:03:00.3",
+		"pf0vf1/device":          "0000:03:00.0",
+		"pf0vf1/phys_port_name":  "pf0vf1",
+		"eth1/device":            "0000:03:00.3",
+		"eth1/device/physfn":     "0000:03:00.0",
+	}
+	tests := map[string]struct {
+		md      metadata.MD
+		eswitch string
+		used    bool
+		out     string
+		errCode codes.Code
+	}{
+		"resource id": {
+			md:  metadata.MD{},
+			out: testBridgePortID,
+		},
+		"vf index": {
+			md:      metadata.Pairs(utils.VfIndexMetadataKey, "1"),
+			eswitch: "switchdev",
+			out:     "pf0vf1",
+		},
+		"pf and vf index": {
+			md:      metadata.Pairs(utils.VfIndexMetadataKey, "0/1"),
+			eswitch: "switchdev",
+			out:     "pf0vf1",
+		},
+		"PCI address of a vf": {
+			md:      metadata.Pairs(utils.PciAddressMetadataKey, "0000:03:00.3"),
+			eswitch: "switchdev",
+			out:     "pf0vf1",
+		},
+		"PCI address of a port": {
+			md:  metadata.Pairs(utils.PciAddressMetadataKey, "0000:03:00.0"),
+			out: "p0",
+		},
+		"unknown vf": {
+			md:      metadata.Pairs(utils.VfIndexMetadataKey, "7"),
+			errCode: codes.NotFound,
+		},
+		"vf without representor": {
+			md:      metadata.Pairs(utils.PciAddressMetadataKey, "0000:03:00.2"),
+			errCode: codes.NotFound,
+		},
+		"legacy eswitch": {
+			md:      metadata.Pairs(utils.VfIndexMetadataKey, "1"),
+			eswitch: "legacy",
+			errCode: codes.FailedPrecondition,
+		},
+		"already used": {
+			md:      metadata.Pairs(utils.VfIndexMetadataKey, "1"),
+			eswitch: "switchdev",
+			used:    true,
+			errCode: codes.AlreadyExists,
+		},
+		"invalid PCI address": {
+			md:      metadata.Pairs(utils.PciAddressMetadataKey, "03:00.3"),
+			errCode: codes.InvalidArgument,
+		},
+		"invalid vf index": {
+			md:      metadata.Pairs(utils.VfIndexMetadataKey, "vf1"),
+			errCode: codes.InvalidArgument,
+		},
+		"PCI address and vf index": {
+			md:      metadata.Pairs(utils.PciAddressMetadataKey, "0000:03:00.3", utils.VfIndexMetadataKey, "1"),
+			errCode: codes.InvalidArgument,
+		},
+	}
+	for testName, tt := range tests {
+		t.Run(testName, func(t *testing.T) {
+			mockNetlink := mocks.NewNetlink(t)
+			mockSysfs := mocks.NewSysfs(t)
+			opi := NewServerWithArgs(mockNetlink, mocks.NewFrr(t), gomap.NewStore(gomap.DefaultOptions))
+			opi.sysfs = mockSysfs
+			mockNetlink.EXPECT().LinkList(mock.Anything).Return(links, nil).Maybe()
+			mockSysfs.EXPECT().LinkAttr(mock.Anything, mock.Anything, mock.Anything).RunAndReturn(func(_ context.Context, name string, attr string) (string, error) {
+				if value, ok := sysfs[name+"/"+attr]; ok {
+					return value, nil
+				}
+				return "", errors.New("no such file or directory")
+			}).Maybe()
+			if tt.eswitch != "" {
+				dev := &netlink.DevlinkDevice{BusName: "pci", DeviceName: "0000:03:00.0"}
+				dev.Attrs.Eswitch.Mode = tt.eswitch
+				mockNetlink.EXPECT().DevLinkGetDeviceByName(mock.Anything, "pci", "0000:03:00.0").Return(dev, nil).Once()
+			}
+			if tt.used {
+				other := resourceIDToFullName("ports", "other")
+				opi.Ports[other] = &pb.BridgePort{Name: other}
+				opi.KernelNames[other] = "pf0vf1"
+			}
+
+			ctx := metadata.NewIncomingContext(context.Background(), tt.md)
+			kernel, err := opi.portKernelNameFor(ctx, &pb.BridgePort{Name: testBridgePortName, Spec: testBridgePort.Spec})
+			if status.Code(err) != tt.errCode {
+				t.Error("error code: expected", tt.errCode, "received", err)
+			}
+			if kernel != tt.out {
+				t.Error("kernel name: expected", tt.out, "received", kernel)
+			}
+		})
+	}
+
+	// a replayed port keeps its representor
+	opi := NewServerWithArgs(mocks.NewNetlink(t), mocks.NewFrr(t), gomap.NewStore(gomap.DefaultOptions))
+	opi.KernelNames[testBridgePortName] = "pf0vf1"
+	kernel, err := opi.portKernelNameFor(context.Background(), &pb.BridgePort{Name: testBridgePortName, Spec: testBridgePort.Spec})
+	if err != nil || kernel != "pf0vf1" {
+		t.Error("kernel name: expected pf0vf1, received", kernel, err)
+	}
+}
@@ -0,0 +1,58 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"fmt"
+	"regexp"
+
+	"go.einride.tech/aip/resourceid"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// maxKernelNameLength is IFNAMSIZ minus the terminating NUL
+const maxKernelNameLength = 15
+
+// reservedResourceIDs have a special meaning for the kernel or FRR and cannot name an object
+var reservedResourceIDs = map[string]bool{
+	// the FRR and kernel default vrf
+	"default": true,
+	// /proc/sys/net/ipv4/conf/ entries
+	"all": true,
+	"lo":  true,
+	// shared bridge all logical bridges and ports are plugged into
+	tenantbridgeName: true,
+}
+
+// generatedKernelName matches the names this server derives for its own kernel devices,
+// a user provided kernel name of that shape would collide with them
+var generatedKernelName = regexp.MustCompile(`^(br|vni|vlan)[0-9]+$`)
+
+// validateResourceID checks a user provided resource ID, kernel is set for the
+// types whose ID is used as-is as a Linux interface name
+func validateResourceID(id string, kernel bool) error {
+	// see https://google.aip.dev/133#user-specified-ids
+	if err := resourceid.ValidateUserSettable(id); err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	if reservedResourceIDs[id] {
+		msg := fmt.Sprintf("resource ID %s is reserved", id)
+		return status.Error(codes.InvalidArgument, msg)
+	}
+	if !kernel {
+		return nil
+	}
+	if len(id) > maxKernelNameLength {
+		msg := fmt.Sprintf("resource ID %s is used as interface name and cannot be longer than %d characters", id, maxKernelNameLength)
+		return status.Error(codes.InvalidArgument, msg)
+	}
+	if generatedKernelName.MatchString(id) {
+		msg := fmt.Sprintf("resource ID %s collides with generated interface names", id)
+		return status.Error(codes.InvalidArgument, msg)
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/quick"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// resourceIDAlphabet is biased towards the allowed characters so that the
+// generated IDs regularly pass validation
+const resourceIDAlphabet = "abcdefghijklmnopqrstuvwxyz0123456789--A_. "
+
+// randomResourceID generates IDs up to 70 characters, around the 63 and 15 limits
+type randomResourceID string
+
+func (randomResourceID) Generate(r *rand.Rand, _ int) reflect.Value {
+	b := make([]byte, r.Intn(70))
+	for i := range b {
+		b[i] = resourceIDAlphabet[r.Intn(len(resourceIDAlphabet))]
+	}
+	return reflect.ValueOf(randomResourceID(b))
+}
+
+func isValidIDChar(c rune) bool {
+	return (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') || c == '-'
+}
+
+func Test_validateResourceID(t *testing.T) {
+	tests := map[string]struct {
+		id      string
+		kernel  bool
+		errCode codes.Code
+	}{
+		"valid id": {
+			id:      "opi-vrf8",
+			kernel:  true,
+			errCode: codes.OK,
+		},
+		"reserved id": {
+			id:      "default",
+			kernel:  false,
+			errCode: codes.InvalidArgument,
+		},
+		"too long kernel name": {
+			id:      "very-long-vrf-name",
+			kernel:  true,
+			errCode: codes.InvalidArgument,
+		},
+		"long id not used as kernel name": {
+			id:      "very-long-bridge-name",
+			kernel:  false,
+			errCode: codes.OK,
+		},
+		"collides with generated name": {
+			id:      "vni100",
+			kernel:  true,
+			errCode: codes.InvalidArgument,
+		},
+		"illegal characters": {
+			id:      "CapitalLettersNotAllowed",
+			kernel:  false,
+			errCode: codes.InvalidArgument,
+		},
+	}
+
+	for testName, tt := range tests {
+		t.Run(testName, func(t *testing.T) {
+			err := validateResourceID(tt.id, tt.kernel)
+			if er := status.Convert(err); er.Code() != tt.errCode {
+				t.Errorf("validateResourceID() = %v, want %v", err, tt.errCode)
+			}
+		})
+	}
+
+	t.Run("accepted ids are valid interface names", func(t *testing.T) {
+		property := func(id randomResourceID) bool {
+			if validateResourceID(string(id), true) != nil {
+				return true
+			}
+			return len(id) > 0 && len(id) <= maxKernelNameLength && strings.IndexFunc(string(id), func(c rune) bool { return !isValidIDChar(c) }) < 0
+		}
+		if err := quick.Check(property, &quick.Config{MaxCount: 5000}); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("accepted ids are accepted regardless of kernel use", func(t *testing.T) {
+		property := func(id randomResourceID) bool {
+			return validateResourceID(string(id), true) != nil || validateResourceID(string(id), false) == nil
+		}
+		if err := quick.Check(property, &quick.Config{MaxCount: 5000}); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("reserved ids are always rejected", func(t *testing.T) {
+		for id := range reservedResourceIDs {
+			if err := validateResourceID(id, false); err == nil {
+				t.Errorf("validateResourceID(%v) = %v, want error", id, err)
+			}
+		}
+	})
+}
@@ -0,0 +1,206 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ResyncRequest is the request to re-apply the stored objects to the dataplane
+// TODO: move to an AdminService in opi-api once the message is agreed upon
+type ResyncRequest struct{}
+
+// ResyncResponse reports the result for every stored object, parents first
+// TODO: move to an AdminService in opi-api once the message is agreed upon
+type ResyncResponse struct {
+	Results []BatchResult `json:"results"`
+}
+
+// Resync re-applies every stored object to the dataplane, in the order of Replay, to recover
+// after an FRR restart, a manual `ip link del` or a kernel module reload. Vrfs, LogicalBridges,
+// BridgePorts and Svis whose kernel devices still exist only get their FRR configuration
+// re-applied, the others are recreated. The child resources are programmed again, after
+// removing what is left of them when programming them fails on it, and a failed object does
+// not stop the following ones. It holds objectsMu as the calls changing the objects do
+func (s *Server) Resync(ctx context.Context, _ *ResyncRequest) (*ResyncResponse, error) {
+	s.objectsMu.Lock()
+	defer s.objectsMu.Unlock()
+	response := &ResyncResponse{}
+	report := func(name string, err error) {
+		if err != nil {
+			fmt.Printf("Failed to resync %s: %v", name, err)
+		}
+		response.Results = append(response.Results, batchResult(name, err))
+	}
+	used, owned := s.childKinds()
+	for _, kind := range used {
+		kind.resyncObjects(ctx, report)
+	}
+	// the Svis of a recreated Vrf were detached from it with the old device
+	recreatedVrfs := map[string]bool{}
+	for _, name := range sortedKeys(s.Vrfs) {
+		recreated, err := s.dataplane.ResyncVrf(ctx, s.Vrfs[name])
+		recreatedVrfs[name] = recreated
+		report(name, err)
+	}
+	for _, name := range sortedKeys(s.Bridges) {
+		report(name, s.dataplane.ResyncLogicalBridge(ctx, s.Bridges[name]))
+	}
+	for _, name := range sortedKeys(s.Ports) {
+		report(name, s.dataplane.ResyncBridgePort(ctx, s.Ports[name]))
+	}
+	for _, name := range sortedKeys(s.Svis) {
+		obj := s.Svis[name]
+		report(name, s.resyncSvi(ctx, obj, recreatedVrfs[obj.Spec.Vrf]))
+	}
+	for _, kind := range owned {
+		kind.resyncObjects(ctx, report)
+	}
+	return response, nil
+}
+
+// resyncSvi resolves the LogicalBridge and Vrf of the Svi for the dataplane
+func (s *Server) resyncSvi(ctx context.Context, obj *pb.Svi, force bool) error {
+	bridgeObject, ok := s.Bridges[obj.Spec.LogicalBridge]
+	if !ok {
+		err := status.Errorf(codes.NotFound, "unable to find key %s", obj.Spec.LogicalBridge)
+		return err
+	}
+	vrf, ok := s.Vrfs[obj.Spec.Vrf]
+	if !ok {
+		err := status.Errorf(codes.NotFound, "unable to find key %s", obj.Spec.Vrf)
+		return err
+	}
+	return s.dataplane.ResyncSvi(ctx, obj, bridgeObject, vrf, force)
+}
+
+func (c childCalls[T]) resyncObjects(ctx context.Context, report func(name string, err error)) {
+	for _, name := range sortedKeys(c.stored) {
+		report(name, c.resync(ctx, c.stored[name]))
+	}
+}
+
+// recreate removes what is left of a child resource, best effort, before programming it again,
+// for the kinds failing to program what already exists
+func recreate(name string, del func() error, create func() error) error {
+	if err := del(); err != nil {
+		log.Printf("Failed to clean up %v: %v", name, err)
+	}
+	return create()
+}
+
+// storedVrf returns the Vrf a child resource is programmed into
+func (s *Server) storedVrf(name string) (*pb.Vrf, error) {
+	vrf, ok := s.Vrfs[name]
+	if !ok {
+		err := status.Errorf(codes.NotFound, "unable to find key %s", name)
+		return nil, err
+	}
+	return vrf, nil
+}
+
+func (s *Server) resyncUnderlayInterface(ctx context.Context, obj *UnderlayInterface) error {
+	return recreate(obj.Name, func() error {
+		return s.dataplane.DeleteUnderlayInterface(ctx, obj)
+	}, func() error {
+		return s.dataplane.CreateUnderlayInterface(ctx, obj)
+	})
+}
+
+func (s *Server) resyncTunnelSecurity(ctx context.Context, obj *TunnelSecurity) error {
+	return recreate(obj.Name, func() error {
+		return s.dataplane.DeleteTunnelSecurity(ctx, obj)
+	}, func() error {
+		return s.dataplane.CreateTunnelSecurity(ctx, obj)
+	})
+}
+
+func (s *Server) resyncRoute(ctx context.Context, obj *Route) error {
+	vrf, err := s.storedVrf(routeParent(obj.Name))
+	if err != nil {
+		return err
+	}
+	return recreate(obj.Name, func() error {
+		return s.dataplane.DeleteRoute(ctx, obj, vrf)
+	}, func() error {
+		return s.dataplane.CreateRoute(ctx, obj, vrf)
+	})
+}
+
+func (s *Server) resyncVrfLiteHandoff(ctx context.Context, obj *VrfLiteHandoff) error {
+	vrf, err := s.storedVrf(obj.Spec.Vrf)
+	if err != nil {
+		return err
+	}
+	return recreate(obj.Name, func() error {
+		return s.dataplane.DeleteVrfLiteHandoff(ctx, obj, vrf)
+	}, func() error {
+		return s.dataplane.CreateVrfLiteHandoff(ctx, obj, vrf)
+	})
+}
+
+// resyncRouteLeak applies the FRR configuration of the leak again, replacing the one left
+func (s *Server) resyncRouteLeak(ctx context.Context, obj *RouteLeak) error {
+	src, err := s.storedVrf(obj.Spec.SourceVrf)
+	if err != nil {
+		return err
+	}
+	dst, err := s.storedVrf(obj.Spec.DestinationVrf)
+	if err != nil {
+		return err
+	}
+	return s.dataplane.CreateRouteLeak(ctx, obj, src, dst)
+}
+
+func (s *Server) resyncStaticFdbEntry(ctx context.Context, obj *StaticFdbEntry) error {
+	parent := staticFdbEntryParent(obj.Name)
+	bridge, ok := s.Bridges[parent]
+	if !ok {
+		err := status.Errorf(codes.NotFound, "unable to find key %s", parent)
+		return err
+	}
+	return recreate(obj.Name, func() error {
+		return s.dataplane.DeleteStaticFdbEntry(ctx, obj, bridge)
+	}, func() error {
+		return s.dataplane.CreateStaticFdbEntry(ctx, obj, bridge)
+	})
+}
+
+// resyncSecurityPolicy replaces the policy atomically, it is never removed in the meantime
+func (s *Server) resyncSecurityPolicy(ctx context.Context, obj *SecurityPolicy) error {
+	vrf, err := s.storedVrf(securityPolicyParent(obj.Name))
+	if err != nil {
+		return err
+	}
+	return s.dataplane.CreateSecurityPolicy(ctx, obj, vrf, s.vrfSvis(vrf.Name))
+}
+
+// resyncNatRule applies the rules of the Vrf again, replacing the ones left
+func (s *Server) resyncNatRule(ctx context.Context, obj *NatRule) error {
+	vrf, err := s.storedVrf(natRuleParent(obj.Name))
+	if err != nil {
+		return err
+	}
+	return s.dataplane.CreateNatRule(ctx, obj, vrf)
+}
+
+func (s *Server) resyncPbrRule(ctx context.Context, obj *PbrRule) error {
+	vrf, err := s.storedVrf(pbrRuleParent(obj.Name))
+	if err != nil {
+		return err
+	}
+	return recreate(obj.Name, func() error {
+		return s.dataplane.DeletePbrRule(ctx, obj, vrf)
+	}, func() error {
+		return s.dataplane.CreatePbrRule(ctx, obj, vrf)
+	})
+}
@@ -0,0 +1,122 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/philippgille/gokv/gomap"
+	"github.com/stretchr/testify/mock"
+	"github.com/vishvananda/netlink"
+
+	"google.golang.org/grpc/codes"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/fake"
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils/mocks"
+)
+
+func Test_ResyncLogicalBridge(t *testing.T) {
+	tests := map[string]struct {
+		wantCode codes.Code
+		on       func(mockNetlink *mocks.Netlink)
+	}{
+		"vxlan still present": {
+			wantCode: codes.OK,
+			on: func(mockNetlink *mocks.Netlink) {
+				mockNetlink.EXPECT().LinkByName(mock.Anything, "vni11").Return(&netlink.Vxlan{}, nil).Once()
+			},
+		},
+		"vxlan deleted is recreated": {
+			wantCode: codes.OK,
+			on: func(mockNetlink *mocks.Netlink) {
+				bridge := &netlink.Bridge{LinkAttrs: netlink.LinkAttrs{Name: tenantbridgeName}}
+				mockNetlink.EXPECT().LinkByName(mock.Anything, "vni11").Return(nil, errors.New("Link not found")).Once()
+				mockNetlink.EXPECT().LinkByName(mock.Anything, tenantbridgeName).Return(bridge, nil).Once()
+				mockNetlink.EXPECT().LinkAdd(mock.Anything, mock.Anything).Return(nil).Once()
+				mockNetlink.EXPECT().LinkSetMaster(mock.Anything, mock.Anything, bridge).Return(nil).Once()
+				mockNetlink.EXPECT().LinkSetUp(mock.Anything, mock.Anything).Return(nil).Once()
+				vid := uint16(testLogicalBridge.Spec.VlanId)
+				mockNetlink.EXPECT().BridgeVlanAdd(mock.Anything, mock.Anything, vid, true, true, false, false).Return(nil).Once()
+			},
+		},
+		"failed recreate": {
+			wantCode: codes.NotFound,
+			on: func(mockNetlink *mocks.Netlink) {
+				mockNetlink.EXPECT().LinkByName(mock.Anything, "vni11").Return(nil, errors.New("Link not found")).Once()
+				mockNetlink.EXPECT().LinkByName(mock.Anything, tenantbridgeName).Return(nil, errors.New("Link not found")).Once()
+			},
+		},
+	}
+
+	for testName, tt := range tests {
+		t.Run(testName, func(t *testing.T) {
+			mockNetlink := mocks.NewNetlink(t)
+			mockFrr := mocks.NewFrr(t)
+			opi := NewServerWithArgs(mockNetlink, mockFrr, gomap.NewStore(gomap.DefaultOptions))
+			opi.Bridges[testLogicalBridgeName] = protoClone(&testLogicalBridgeWithStatus)
+			tt.on(mockNetlink)
+
+			response, err := opi.Resync(context.Background(), &ResyncRequest{})
+			if err != nil {
+				t.Fatal("error: expected", nil, "received", err)
+			}
+			if len(response.Results) != 1 || response.Results[0].Code != tt.wantCode.String() {
+				t.Error("results: expected", tt.wantCode, "received", response.Results)
+			}
+		})
+	}
+}
+
+func Test_ResyncVrfFrrRestart(t *testing.T) {
+	mockNetlink := mocks.NewNetlink(t)
+	mockFrr := mocks.NewFrr(t)
+	opi := NewServerWithArgs(mockNetlink, mockFrr, gomap.NewStore(gomap.DefaultOptions))
+	opi.Vrfs[testVrfName] = protoClone(&testVrfWithStatus)
+
+	// devices are intact, only FRR is configured again
+	mockNetlink.EXPECT().LinkByName(mock.Anything, mock.Anything).Return(&netlink.Dummy{}, nil).Times(3)
+	mockFrr.EXPECT().FrrZebraCmd(mock.Anything, mock.Anything).Return("", nil).Twice()
+	mockFrr.EXPECT().FrrBgpCmd(mock.Anything, mock.Anything).Return("", nil).Once()
+
+	response, err := opi.Resync(context.Background(), &ResyncRequest{})
+	if err != nil {
+		t.Fatal("error: expected", nil, "received", err)
+	}
+	if len(response.Results) != 1 || response.Results[0].Code != codes.OK.String() {
+		t.Error("results: expected", codes.OK, "received", response.Results)
+	}
+}
+
+func Test_ResyncChildResources(t *testing.T) {
+	ctx := context.Background()
+	nLink := fake.NewNetlink()
+	opi := NewServerWithArgs(nLink, fake.NewFrr(), gomap.NewStore(gomap.DefaultOptions))
+	if _, err := opi.CreateVrf(ctx, &pb.CreateVrfRequest{VrfId: testVrfID, Vrf: protoClone(&testVrf)}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := opi.CreateRoute(ctx, &CreateRouteRequest{Parent: testVrfName, RouteID: testRouteID, Route: testRoute.clone()}); err != nil {
+		t.Fatal(err)
+	}
+
+	// the Route still programmed is removed before being added again
+	response, err := opi.Resync(ctx, &ResyncRequest{})
+	if err != nil {
+		t.Fatal("error: expected", nil, "received", err)
+	}
+	results := []BatchResult{{Name: testVrfName, Code: codes.OK.String()}, {Name: testRouteName, Code: codes.OK.String()}}
+	if !reflect.DeepEqual(response.Results, results) {
+		t.Error("results: expected", results, "received", response.Results)
+	}
+	filter := &netlink.Route{Table: int(opi.Vrfs[testVrfName].Status.RoutingTable), Dst: routePrefix(testRoute.clone())}
+	routes, _ := nLink.RouteListFiltered(ctx, netlink.FAMILY_V4, filter, netlink.RT_FILTER_TABLE|netlink.RT_FILTER_DST)
+	if len(routes) != 1 {
+		t.Error("routes: expected", 1, "received", routes)
+	}
+}
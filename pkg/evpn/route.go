@@ -0,0 +1,215 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	pc "github.com/opiproject/opi-api/network/opinetcommon/v1alpha1/gen/go"
+
+	"go.einride.tech/aip/resourceid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// Route is a static route, child resource of a Vrf
+// TODO: move to opi-api once the message is agreed upon
+type Route struct {
+	Name string
+	Spec *RouteSpec
+}
+
+// RouteSpec is the desired configuration of a static Route
+type RouteSpec struct {
+	// Prefix is the destination of the route
+	Prefix *pc.IPPrefix
+	// NextHop is the gateway address, optional when Interface is set
+	NextHop *pc.IPAddress
+	// Interface is the kernel name of the egress interface, optional when NextHop is set
+	Interface string
+	// Metric is the route priority
+	Metric uint32
+	// Redistribute advertises the route in the Vrf BGP instance
+	Redistribute bool
+}
+
+// CreateRouteRequest is the request to create a static Route in a Vrf
+type CreateRouteRequest struct {
+	// Parent is the name of the Vrf
+	Parent  string
+	RouteID string
+	Route   *Route
+}
+
+// DeleteRouteRequest is the request to delete a static Route
+type DeleteRouteRequest struct {
+	Name         string
+	AllowMissing bool
+}
+
+// ListRoutesRequest is the request to list static Routes of a Vrf
+type ListRoutesRequest struct {
+	// Parent is the name of the Vrf
+	Parent    string
+	PageSize  int32
+	PageToken string
+}
+
+// ListRoutesResponse is the response of listing static Routes
+type ListRoutesResponse struct {
+	Routes        []*Route
+	NextPageToken string
+}
+
+func (r *Route) clone() *Route {
+	if r == nil {
+		return nil
+	}
+	c := &Route{Name: r.Name}
+	if r.Spec != nil {
+		spec := *r.Spec
+		if r.Spec.Prefix != nil {
+			spec.Prefix = protoClone(r.Spec.Prefix)
+		}
+		if r.Spec.NextHop != nil {
+			spec.NextHop = protoClone(r.Spec.NextHop)
+		}
+		c.Spec = &spec
+	}
+	return c
+}
+
+func sortRoutes(routes []*Route) {
+	sort.Slice(routes, func(i int, j int) bool {
+		return routes[i].Name < routes[j].Name
+	})
+}
+
+func routeParent(name string) string {
+	// path.Dir would collapse the leading "//" of the full resource name
+	return name[:strings.LastIndex(name, "/routes/")]
+}
+
+// CreateRoute executes the creation of the static route
+func (s *Server) CreateRoute(ctx context.Context, in *CreateRouteRequest) (*Route, error) {
+	// check input correctness
+	if err := s.validateCreateRouteRequest(in); err != nil {
+		return nil, err
+	}
+	// see https://google.aip.dev/133#user-specified-ids
+	resourceID := resourceid.NewSystemGenerated()
+	if in.RouteID != "" {
+		log.Printf("client provided the ID of a resource %v, ignoring the name field %v", in.RouteID, in.Route.Name)
+		resourceID = in.RouteID
+	}
+	in.Route.Name = fmt.Sprintf("%s/routes/%s", in.Parent, resourceID)
+	s.objectsMu.Lock()
+	defer s.objectsMu.Unlock()
+	// idempotent API when called with same key, should return same object
+	obj, ok := s.Routes[in.Route.Name]
+	if ok && inTenant(ctx, in.Parent) {
+		// a different spec under the same key is a conflict, not a retry
+		if err := checkSameChildSpec(obj.Name, obj.Spec, in.Route.Spec); err != nil {
+			return nil, err
+		}
+		log.Printf("Already existing Route with id %v", in.Route.Name)
+		return obj.clone(), nil
+	}
+	// now get Vrf to fetch routing table
+	vrf, ok := s.Vrfs[in.Parent]
+	if !ok || !inTenant(ctx, in.Parent) {
+		err := status.Errorf(codes.NotFound, "unable to find key %s", in.Parent)
+		return nil, err
+	}
+	if err := s.dataplane.CreateRoute(ctx, in.Route, vrf); err != nil {
+		s.forgetStatus(in.Route.Name)
+		return nil, status.Convert(err).Err()
+	}
+	// save object to the database
+	response := in.Route.clone()
+	s.Routes[in.Route.Name] = response
+	persistObjects(s, "routes", s.Routes)
+	return response.clone(), nil
+}
+
+// DeleteRoute deletes a static route
+func (s *Server) DeleteRoute(ctx context.Context, in *DeleteRouteRequest) (*emptypb.Empty, error) {
+	// check input correctness
+	if err := s.validateDeleteRouteRequest(in); err != nil {
+		return nil, err
+	}
+	s.objectsMu.Lock()
+	defer s.objectsMu.Unlock()
+	return s.deleteRoute(ctx, in)
+}
+
+// deleteRoute deletes a validated static route, with objectsMu held
+func (s *Server) deleteRoute(ctx context.Context, in *DeleteRouteRequest) (*emptypb.Empty, error) {
+	// fetch object from the database
+	obj, ok := s.Routes[in.Name]
+	if !ok || !inTenant(ctx, in.Name) {
+		if in.AllowMissing {
+			return &emptypb.Empty{}, nil
+		}
+		err := status.Errorf(codes.NotFound, "unable to find key %s", in.Name)
+		return nil, err
+	}
+	// fetch object from the database
+	parent := routeParent(obj.Name)
+	vrf, ok := s.Vrfs[parent]
+	if !ok {
+		err := status.Errorf(codes.NotFound, "unable to find key %s", parent)
+		return nil, err
+	}
+	if err := s.dataplane.DeleteRoute(ctx, obj, vrf); err != nil {
+		return nil, status.Convert(err).Err()
+	}
+	// remove from the Database
+	delete(s.Routes, obj.Name)
+	persistObjects(s, "routes", s.Routes)
+	s.forgetStatus(obj.Name)
+	return &emptypb.Empty{}, nil
+}
+
+// ListRoutes lists static routes of a Vrf
+func (s *Server) ListRoutes(ctx context.Context, in *ListRoutesRequest) (*ListRoutesResponse, error) {
+	// check input correctness
+	if err := s.validateListRoutesRequest(in); err != nil {
+		return nil, err
+	}
+	if !inTenant(ctx, in.Parent) {
+		err := status.Errorf(codes.NotFound, "unable to find key %s", in.Parent)
+		return nil, err
+	}
+	// fetch pagination from the database, calculate size and offset
+	size, offset, perr := s.extractPagination(in.PageSize, in.PageToken)
+	if perr != nil {
+		return nil, perr
+	}
+	s.objectsMu.RLock()
+	defer s.objectsMu.RUnlock()
+	// fetch object from the database, the following pages are cut from the snapshot of the first one
+	Blobarray, token, err := listPage(ctx, s, "routes", in.Parent, in.PageToken, offset, size, func() []*Route {
+		Blobarray := []*Route{}
+		for _, route := range s.Routes {
+			if !strings.HasPrefix(route.Name, in.Parent+"/") {
+				continue
+			}
+			Blobarray = append(Blobarray, route.clone())
+		}
+		// sort is needed, since MAP is unsorted in golang, and we might get different results
+		sortRoutes(Blobarray)
+		return Blobarray
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &ListRoutesResponse{Routes: Blobarray, NextPageToken: token}, nil
+}
@@ -0,0 +1,60 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
+
+	pc "github.com/opiproject/opi-api/network/opinetcommon/v1alpha1/gen/go"
+)
+
+func ipPrefixToNet(prefix *pc.IPPrefix) *net.IPNet {
+	dst := make(net.IP, 4)
+	binary.BigEndian.PutUint32(dst, prefix.Addr.GetV4Addr())
+	mask := net.CIDRMask(int(prefix.Len), 32)
+	return &net.IPNet{IP: dst.Mask(mask), Mask: mask}
+}
+
+func routePrefix(obj *Route) *net.IPNet {
+	return ipPrefixToNet(obj.Spec.Prefix)
+}
+
+func (s *Server) frrCreateRouteRequest(ctx context.Context, obj *Route, vrfName string) error {
+	// kernel routes are only advertised when explicitly asked for
+	if obj.Spec.Redistribute {
+		data, err := s.frr.FrrBgpCmd(ctx, fmt.Sprintf(
+			`configure terminal
+			router bgp %d vrf %s
+			address-family ipv4 unicast
+				network %s
+				exit-address-family
+			exit`, s.Gateway.LocalAs, vrfName, routePrefix(obj)))
+		fmt.Printf("FrrBgpCmd: %v:%v", data, err)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Server) frrDeleteRouteRequest(ctx context.Context, obj *Route, vrfName string) error {
+	if obj.Spec.Redistribute {
+		data, err := s.frr.FrrBgpCmd(ctx, fmt.Sprintf(
+			`configure terminal
+			router bgp %d vrf %s
+			address-family ipv4 unicast
+				no network %s
+				exit-address-family
+			exit`, s.Gateway.LocalAs, vrfName, routePrefix(obj)))
+		fmt.Printf("FrrBgpCmd: %v:%v", data, err)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"log"
+	"net"
+
+	"github.com/vishvananda/netlink"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (s *Server) netlinkRoute(ctx context.Context, obj *Route, vrf *pb.Vrf) (*netlink.Route, error) {
+	route := &netlink.Route{
+		Dst:      routePrefix(obj),
+		Priority: int(obj.Spec.Metric),
+		Table:    int(vrf.Status.GetRoutingTable()),
+	}
+	if obj.Spec.NextHop != nil {
+		gw := make(net.IP, 4)
+		binary.BigEndian.PutUint32(gw, obj.Spec.NextHop.GetV4Addr())
+		route.Gw = gw
+	}
+	if obj.Spec.Interface != "" {
+		iface, err := s.nLink.LinkByName(ctx, obj.Spec.Interface)
+		if err != nil {
+			err := status.Errorf(codes.NotFound, "unable to find key %s", obj.Spec.Interface)
+			return nil, err
+		}
+		route.LinkIndex = iface.Attrs().Index
+	}
+	return route, nil
+}
+
+func (s *Server) netlinkCreateRoute(ctx context.Context, obj *Route, vrf *pb.Vrf) error {
+	route, err := s.netlinkRoute(ctx, obj, vrf)
+	if err != nil {
+		return err
+	}
+	// Example: ip route add 10.1.0.0/16 via 10.0.0.1 dev eth2 metric 10 table 1000
+	log.Printf("Creating Route %v", route)
+	if err := s.nLink.RouteAdd(ctx, route); err != nil {
+		fmt.Printf("Failed to add route: %v", err)
+		return err
+	}
+	return nil
+}
+
+func (s *Server) netlinkDeleteRoute(ctx context.Context, obj *Route, vrf *pb.Vrf) error {
+	route, err := s.netlinkRoute(ctx, obj, vrf)
+	if err != nil {
+		return err
+	}
+	// Example: ip route del 10.1.0.0/16 via 10.0.0.1 dev eth2 metric 10 table 1000
+	log.Printf("Deleting Route %v", route)
+	if err := s.nLink.RouteDel(ctx, route); err != nil {
+		fmt.Printf("Failed to delete route: %v", err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,352 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"reflect"
+	"testing"
+
+	"github.com/philippgille/gokv/gomap"
+	"github.com/stretchr/testify/mock"
+	"github.com/vishvananda/netlink"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+	pc "github.com/opiproject/opi-api/network/opinetcommon/v1alpha1/gen/go"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils/mocks"
+)
+
+var (
+	testRouteID   = "opi-route8"
+	testRouteName = fmt.Sprintf("%s/routes/%s", testVrfName, testRouteID)
+	testRoute     = Route{
+		Spec: &RouteSpec{
+			Prefix: &pc.IPPrefix{
+				Addr: &pc.IPAddress{
+					Af: pc.IpAf_IP_AF_INET,
+					V4OrV6: &pc.IPAddress_V4Addr{
+						V4Addr: 167837696,
+					},
+				},
+				Len: 16,
+			},
+			NextHop: &pc.IPAddress{
+				Af: pc.IpAf_IP_AF_INET,
+				V4OrV6: &pc.IPAddress_V4Addr{
+					V4Addr: 167772161,
+				},
+			},
+			Metric:       10,
+			Redistribute: true,
+		},
+	}
+	testRouteWithName = Route{
+		Name: testRouteName,
+		Spec: testRoute.Spec,
+	}
+	testRouteKernel = &netlink.Route{
+		Dst:      &net.IPNet{IP: net.IPv4(10, 1, 0, 0).To4(), Mask: net.CIDRMask(16, 32)},
+		Gw:       net.IPv4(10, 0, 0, 1).To4(),
+		Priority: 10,
+		Table:    1000,
+	}
+)
+
+func Test_CreateRoute(t *testing.T) {
+	tests := map[string]struct {
+		id      string
+		in      *Route
+		out     *Route
+		errCode codes.Code
+		errMsg  string
+		exist   bool
+		on      func(mockNetlink *mocks.Netlink, mockFrr *mocks.Frr, errMsg string)
+	}{
+		"illegal resource_id": {
+			id:      "CapitalLettersNotAllowed",
+			in:      &testRoute,
+			out:     nil,
+			errCode: codes.InvalidArgument,
+			errMsg:  fmt.Sprintf("user-settable ID must only contain lowercase, numbers and hyphens (%v)", "got: 'C' in position 0"),
+			exist:   false,
+			on:      nil,
+		},
+		"no required prefix field": {
+			id: testRouteID,
+			in: &Route{
+				Spec: &RouteSpec{},
+			},
+			out:     nil,
+			errCode: codes.InvalidArgument,
+			errMsg:  "missing required field: route.spec.prefix",
+			exist:   false,
+			on:      nil,
+		},
+		"no next hop nor interface": {
+			id: testRouteID,
+			in: &Route{
+				Spec: &RouteSpec{
+					Prefix: testRoute.Spec.Prefix,
+				},
+			},
+			out:     nil,
+			errCode: codes.InvalidArgument,
+			errMsg:  "route must have next_hop or interface",
+			exist:   false,
+			on:      nil,
+		},
+		"already exists": {
+			id:      testRouteID,
+			in:      &testRoute,
+			out:     &testRouteWithName,
+			errCode: codes.OK,
+			errMsg:  "",
+			exist:   true,
+			on:      nil,
+		},
+		"already exists with a different spec": {
+			id: testRouteID,
+			in: &Route{
+				Spec: &RouteSpec{
+					Prefix:  testRoute.Spec.Prefix,
+					NextHop: testRoute.Spec.NextHop,
+					Metric:  20,
+				},
+			},
+			out:     nil,
+			errCode: codes.AlreadyExists,
+			errMsg:  fmt.Sprintf("%s already exists with a different spec", testRouteName),
+			exist:   true,
+			on:      nil,
+		},
+		"failed RouteAdd call": {
+			id:      testRouteID,
+			in:      &testRoute,
+			out:     nil,
+			errCode: codes.Unknown,
+			errMsg:  "Failed to call RouteAdd",
+			exist:   false,
+			on: func(mockNetlink *mocks.Netlink, mockFrr *mocks.Frr, errMsg string) {
+				mockNetlink.EXPECT().RouteAdd(mock.Anything, testRouteKernel).Return(errors.New(errMsg)).Once()
+			},
+		},
+		"failed FrrBgpCmd call": {
+			id:      testRouteID,
+			in:      &testRoute,
+			out:     nil,
+			errCode: codes.Unknown,
+			errMsg:  "Failed to call FrrBgpCmd",
+			exist:   false,
+			on: func(mockNetlink *mocks.Netlink, mockFrr *mocks.Frr, errMsg string) {
+				mockNetlink.EXPECT().RouteAdd(mock.Anything, testRouteKernel).Return(nil).Once()
+				mockFrr.EXPECT().FrrBgpCmd(mock.Anything, mock.Anything).Return("", errors.New(errMsg)).Once()
+			},
+		},
+		"successful call": {
+			id:      testRouteID,
+			in:      &testRoute,
+			out:     &testRouteWithName,
+			errCode: codes.OK,
+			errMsg:  "",
+			exist:   false,
+			on: func(mockNetlink *mocks.Netlink, mockFrr *mocks.Frr, errMsg string) {
+				mockNetlink.EXPECT().RouteAdd(mock.Anything, testRouteKernel).Return(nil).Once()
+				mockFrr.EXPECT().FrrBgpCmd(mock.Anything, mock.Anything).Return("", nil).Once()
+			},
+		},
+	}
+
+	// run tests
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			mockNetlink := mocks.NewNetlink(t)
+			mockFrr := mocks.NewFrr(t)
+			store := gomap.NewStore(gomap.DefaultOptions)
+			opi := NewServerWithArgs(mockNetlink, mockFrr, store)
+
+			opi.Vrfs[testVrfName] = protoClone(&testVrfWithStatus)
+			opi.Vrfs[testVrfName].Status = &pb.VrfStatus{RoutingTable: 1000}
+			if tt.exist {
+				opi.Routes[testRouteName] = testRouteWithName.clone()
+			}
+			if tt.on != nil {
+				tt.on(mockNetlink, mockFrr, tt.errMsg)
+			}
+
+			request := &CreateRouteRequest{Parent: testVrfName, Route: tt.in.clone(), RouteID: tt.id}
+			response, err := opi.CreateRoute(ctx, request)
+			if !reflect.DeepEqual(tt.out, response) {
+				t.Error("response: expected", tt.out, "received", response)
+			}
+
+			if er, ok := status.FromError(err); ok {
+				if er.Code() != tt.errCode {
+					t.Error("error code: expected", tt.errCode, "received", er.Code())
+				}
+				if er.Message() != tt.errMsg {
+					t.Error("error message: expected", tt.errMsg, "received", er.Message())
+				}
+			} else {
+				t.Error("expected grpc error status")
+			}
+		})
+	}
+}
+
+func Test_DeleteRoute(t *testing.T) {
+	tests := map[string]struct {
+		in      string
+		out     *emptypb.Empty
+		errCode codes.Code
+		errMsg  string
+		missing bool
+		on      func(mockNetlink *mocks.Netlink, mockFrr *mocks.Frr, errMsg string)
+	}{
+		"valid request with unknown key": {
+			in:      "unknown-id",
+			out:     nil,
+			errCode: codes.NotFound,
+			errMsg:  fmt.Sprintf("unable to find key %v", fmt.Sprintf("%s/routes/%s", testVrfName, "unknown-id")),
+			missing: false,
+			on:      nil,
+		},
+		"unknown key with missing allowed": {
+			in:      "unknown-id",
+			out:     &emptypb.Empty{},
+			errCode: codes.OK,
+			errMsg:  "",
+			missing: true,
+			on:      nil,
+		},
+		"failed RouteDel call": {
+			in:      testRouteID,
+			out:     nil,
+			errCode: codes.Unknown,
+			errMsg:  "Failed to call RouteDel",
+			missing: false,
+			on: func(mockNetlink *mocks.Netlink, mockFrr *mocks.Frr, errMsg string) {
+				mockFrr.EXPECT().FrrBgpCmd(mock.Anything, mock.Anything).Return("", nil).Once()
+				mockNetlink.EXPECT().RouteDel(mock.Anything, testRouteKernel).Return(errors.New(errMsg)).Once()
+			},
+		},
+		"successful call": {
+			in:      testRouteID,
+			out:     &emptypb.Empty{},
+			errCode: codes.OK,
+			errMsg:  "",
+			missing: false,
+			on: func(mockNetlink *mocks.Netlink, mockFrr *mocks.Frr, errMsg string) {
+				mockFrr.EXPECT().FrrBgpCmd(mock.Anything, mock.Anything).Return("", nil).Once()
+				mockNetlink.EXPECT().RouteDel(mock.Anything, testRouteKernel).Return(nil).Once()
+			},
+		},
+	}
+
+	// run tests
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			mockNetlink := mocks.NewNetlink(t)
+			mockFrr := mocks.NewFrr(t)
+			store := gomap.NewStore(gomap.DefaultOptions)
+			opi := NewServerWithArgs(mockNetlink, mockFrr, store)
+
+			opi.Vrfs[testVrfName] = protoClone(&testVrfWithStatus)
+			opi.Vrfs[testVrfName].Status = &pb.VrfStatus{RoutingTable: 1000}
+			opi.Routes[testRouteName] = testRouteWithName.clone()
+			if tt.on != nil {
+				tt.on(mockNetlink, mockFrr, tt.errMsg)
+			}
+
+			request := &DeleteRouteRequest{Name: fmt.Sprintf("%s/routes/%s", testVrfName, tt.in), AllowMissing: tt.missing}
+			response, err := opi.DeleteRoute(ctx, request)
+
+			if er, ok := status.FromError(err); ok {
+				if er.Code() != tt.errCode {
+					t.Error("error code: expected", tt.errCode, "received", er.Code())
+				}
+				if er.Message() != tt.errMsg {
+					t.Error("error message: expected", tt.errMsg, "received", er.Message())
+				}
+			} else {
+				t.Error("expected grpc error status")
+			}
+
+			if reflect.TypeOf(response) != reflect.TypeOf(tt.out) {
+				t.Error("response: expected", reflect.TypeOf(tt.out), "received", reflect.TypeOf(response))
+			}
+		})
+	}
+}
+
+func Test_ListRoutes(t *testing.T) {
+	tests := map[string]struct {
+		parent  string
+		out     []*Route
+		errCode codes.Code
+		errMsg  string
+	}{
+		"routes of vrf": {
+			parent:  testVrfName,
+			out:     []*Route{&testRouteWithName},
+			errCode: codes.OK,
+			errMsg:  "",
+		},
+		"routes of other vrf": {
+			parent:  resourceIDToFullName("vrfs", "other-vrf"),
+			out:     []*Route{},
+			errCode: codes.OK,
+			errMsg:  "",
+		},
+		"no required parent field": {
+			parent:  "",
+			out:     nil,
+			errCode: codes.InvalidArgument,
+			errMsg:  "missing required field: parent",
+		},
+	}
+
+	// run tests
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			mockNetlink := mocks.NewNetlink(t)
+			mockFrr := mocks.NewFrr(t)
+			store := gomap.NewStore(gomap.DefaultOptions)
+			opi := NewServerWithArgs(mockNetlink, mockFrr, store)
+
+			opi.Routes[testRouteName] = testRouteWithName.clone()
+
+			response, err := opi.ListRoutes(ctx, &ListRoutesRequest{Parent: tt.parent})
+			var routes []*Route
+			if response != nil {
+				routes = response.Routes
+			}
+			if !reflect.DeepEqual(tt.out, routes) {
+				t.Error("response: expected", tt.out, "received", routes)
+			}
+
+			if er, ok := status.FromError(err); ok {
+				if er.Code() != tt.errCode {
+					t.Error("error code: expected", tt.errCode, "received", er.Code())
+				}
+				if er.Message() != tt.errMsg {
+					t.Error("error message: expected", tt.errMsg, "received", er.Message())
+				}
+			} else {
+				t.Error("expected grpc error status")
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"fmt"
+
+	"go.einride.tech/aip/resourcename"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (s *Server) validateCreateRouteRequest(in *CreateRouteRequest) error {
+	// check required fields
+	switch {
+	case in.Parent == "":
+		return missingField("parent")
+	case in.Route == nil:
+		return missingField("route")
+	case in.Route.Spec == nil:
+		return missingField("route.spec")
+	case in.Route.Spec.Prefix == nil || in.Route.Spec.Prefix.Addr == nil:
+		return missingField("route.spec.prefix")
+	}
+	// either next hop or egress interface is needed to resolve the route
+	if in.Route.Spec.NextHop == nil && in.Route.Spec.Interface == "" {
+		return badRequest("route.spec.next_hop", status.Error(codes.InvalidArgument, "route must have next_hop or interface"))
+	}
+	// check prefix length is in range
+	if in.Route.Spec.Prefix.Len > 32 {
+		msg := fmt.Sprintf("Prefix length (%d) have to be between 0 and 32", in.Route.Spec.Prefix.Len)
+		return badRequest("route.spec.prefix.len", status.Error(codes.InvalidArgument, msg))
+	}
+	// Validate that a Vrf resource name conforms to the restrictions outlined in AIP-122.
+	if err := badRequest("parent", resourcename.Validate(in.Parent)); err != nil {
+		return err
+	}
+	// see https://google.aip.dev/133#user-specified-ids
+	if in.RouteID != "" {
+		if err := badRequest("route_id", validateResourceID(in.RouteID, false)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Server) validateDeleteRouteRequest(in *DeleteRouteRequest) error {
+	// check required fields
+	if in.Name == "" {
+		return missingField("name")
+	}
+	// Validate that a resource name conforms to the restrictions outlined in AIP-122.
+	return badRequest("name", resourcename.Validate(in.Name))
+}
+
+func (s *Server) validateListRoutesRequest(in *ListRoutesRequest) error {
+	// check required fields
+	if in.Parent == "" {
+		return missingField("parent")
+	}
+	// Validate that a resource name conforms to the restrictions outlined in AIP-122.
+	return badRequest("parent", resourcename.Validate(in.Parent))
+}
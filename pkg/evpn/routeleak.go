@@ -0,0 +1,227 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"log"
+	"sort"
+
+	pc "github.com/opiproject/opi-api/network/opinetcommon/v1alpha1/gen/go"
+
+	"go.einride.tech/aip/resourceid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// RouteLeak imports the routes of a source Vrf into a destination Vrf,
+// e.g. to reach shared services from several tenants
+// TODO: move to opi-api once the message is agreed upon
+type RouteLeak struct {
+	Name string
+	Spec *RouteLeakSpec
+}
+
+// RouteLeakSpec is the desired configuration of a RouteLeak
+type RouteLeakSpec struct {
+	// SourceVrf is the name of the Vrf the routes are exported from
+	SourceVrf string
+	// DestinationVrf is the name of the Vrf the routes are imported into
+	DestinationVrf string
+	// Prefixes optionally restricts the leaked routes, all routes are leaked when empty
+	Prefixes []*pc.IPPrefix
+}
+
+// CreateRouteLeakRequest is the request to create a RouteLeak
+type CreateRouteLeakRequest struct {
+	RouteLeakID string
+	RouteLeak   *RouteLeak
+}
+
+// DeleteRouteLeakRequest is the request to delete a RouteLeak
+type DeleteRouteLeakRequest struct {
+	Name         string
+	AllowMissing bool
+}
+
+// GetRouteLeakRequest is the request to get a RouteLeak
+type GetRouteLeakRequest struct {
+	Name string
+}
+
+// ListRouteLeaksRequest is the request to list RouteLeaks
+type ListRouteLeaksRequest struct {
+	PageSize  int32
+	PageToken string
+}
+
+// ListRouteLeaksResponse is the response of listing RouteLeaks
+type ListRouteLeaksResponse struct {
+	RouteLeaks    []*RouteLeak
+	NextPageToken string
+}
+
+func (r *RouteLeak) clone() *RouteLeak {
+	if r == nil {
+		return nil
+	}
+	c := &RouteLeak{Name: r.Name}
+	if r.Spec != nil {
+		spec := *r.Spec
+		spec.Prefixes = nil
+		for _, prefix := range r.Spec.Prefixes {
+			spec.Prefixes = append(spec.Prefixes, protoClone(prefix))
+		}
+		c.Spec = &spec
+	}
+	return c
+}
+
+func sortRouteLeaks(leaks []*RouteLeak) {
+	sort.Slice(leaks, func(i int, j int) bool {
+		return leaks[i].Name < leaks[j].Name
+	})
+}
+
+// CreateRouteLeak executes the creation of the route leak
+func (s *Server) CreateRouteLeak(ctx context.Context, in *CreateRouteLeakRequest) (*RouteLeak, error) {
+	// check input correctness
+	if err := s.validateCreateRouteLeakRequest(in); err != nil {
+		return nil, err
+	}
+	// see https://google.aip.dev/133#user-specified-ids
+	resourceID := resourceid.NewSystemGenerated()
+	if in.RouteLeakID != "" {
+		log.Printf("client provided the ID of a resource %v, ignoring the name field %v", in.RouteLeakID, in.RouteLeak.Name)
+		resourceID = in.RouteLeakID
+	}
+	name, err := newObjectName(ctx, "routeleaks", resourceID)
+	if err != nil {
+		return nil, err
+	}
+	in.RouteLeak.Name = name
+	s.objectsMu.Lock()
+	defer s.objectsMu.Unlock()
+	// idempotent API when called with same key, should return same object
+	obj, ok := s.RouteLeaks[in.RouteLeak.Name]
+	if ok {
+		// a different spec under the same key is a conflict, not a retry
+		if err := checkSameChildSpec(obj.Name, obj.Spec, in.RouteLeak.Spec); err != nil {
+			return nil, err
+		}
+		log.Printf("Already existing RouteLeak with id %v", in.RouteLeak.Name)
+		return obj.clone(), nil
+	}
+	if err := checkSameTenant(in.RouteLeak.Name, in.RouteLeak.Spec.SourceVrf, in.RouteLeak.Spec.DestinationVrf); err != nil {
+		return nil, err
+	}
+	// now get both Vrfs
+	src, ok := s.Vrfs[in.RouteLeak.Spec.SourceVrf]
+	if !ok {
+		err := status.Errorf(codes.NotFound, "unable to find key %s", in.RouteLeak.Spec.SourceVrf)
+		return nil, err
+	}
+	dst, ok := s.Vrfs[in.RouteLeak.Spec.DestinationVrf]
+	if !ok {
+		err := status.Errorf(codes.NotFound, "unable to find key %s", in.RouteLeak.Spec.DestinationVrf)
+		return nil, err
+	}
+	// FRR supports a single import route-map per Vrf
+	if len(in.RouteLeak.Spec.Prefixes) > 0 {
+		for _, leak := range s.RouteLeaks {
+			if leak.Spec.DestinationVrf == dst.Name && len(leak.Spec.Prefixes) > 0 {
+				err := status.Errorf(codes.FailedPrecondition, "Vrf %s already imports filtered routes via %s", dst.Name, leak.Name)
+				return nil, err
+			}
+		}
+	}
+	if err := s.dataplane.CreateRouteLeak(ctx, in.RouteLeak, src, dst); err != nil {
+		s.forgetStatus(in.RouteLeak.Name)
+		return nil, status.Convert(err).Err()
+	}
+	// save object to the database
+	response := in.RouteLeak.clone()
+	s.RouteLeaks[in.RouteLeak.Name] = response
+	persistObjects(s, "routeleaks", s.RouteLeaks)
+	return response.clone(), nil
+}
+
+// DeleteRouteLeak deletes a route leak
+func (s *Server) DeleteRouteLeak(ctx context.Context, in *DeleteRouteLeakRequest) (*emptypb.Empty, error) {
+	// check input correctness
+	if err := s.validateDeleteRouteLeakRequest(in); err != nil {
+		return nil, err
+	}
+	s.objectsMu.Lock()
+	defer s.objectsMu.Unlock()
+	return s.deleteRouteLeak(ctx, in)
+}
+
+// deleteRouteLeak deletes a validated route leak, with objectsMu held
+func (s *Server) deleteRouteLeak(ctx context.Context, in *DeleteRouteLeakRequest) (*emptypb.Empty, error) {
+	// fetch object from the database
+	obj, ok := s.RouteLeaks[in.Name]
+	if !ok || !inTenant(ctx, in.Name) {
+		if in.AllowMissing {
+			return &emptypb.Empty{}, nil
+		}
+		err := status.Errorf(codes.NotFound, "unable to find key %s", in.Name)
+		return nil, err
+	}
+	if err := s.dataplane.DeleteRouteLeak(ctx, obj); err != nil {
+		return nil, status.Convert(err).Err()
+	}
+	// remove from the Database
+	delete(s.RouteLeaks, obj.Name)
+	persistObjects(s, "routeleaks", s.RouteLeaks)
+	s.forgetStatus(obj.Name)
+	return &emptypb.Empty{}, nil
+}
+
+// GetRouteLeak gets a route leak
+func (s *Server) GetRouteLeak(ctx context.Context, in *GetRouteLeakRequest) (*RouteLeak, error) {
+	// check input correctness
+	if err := s.validateGetRouteLeakRequest(in); err != nil {
+		return nil, err
+	}
+	s.objectsMu.RLock()
+	defer s.objectsMu.RUnlock()
+	// fetch object from the database
+	obj, ok := s.RouteLeaks[in.Name]
+	if !ok || !inTenant(ctx, in.Name) {
+		err := status.Errorf(codes.NotFound, "unable to find key %s", in.Name)
+		return nil, err
+	}
+	return obj.clone(), nil
+}
+
+// ListRouteLeaks lists route leaks
+func (s *Server) ListRouteLeaks(ctx context.Context, in *ListRouteLeaksRequest) (*ListRouteLeaksResponse, error) {
+	// fetch pagination from the database, calculate size and offset
+	size, offset, perr := s.extractPagination(in.PageSize, in.PageToken)
+	if perr != nil {
+		return nil, perr
+	}
+	s.objectsMu.RLock()
+	defer s.objectsMu.RUnlock()
+	// fetch object from the database, the following pages are cut from the snapshot of the first one
+	Blobarray, token, err := listPage(ctx, s, "routeLeaks", "", in.PageToken, offset, size, func() []*RouteLeak {
+		Blobarray := []*RouteLeak{}
+		for name, leak := range s.RouteLeaks {
+			if !inTenant(ctx, name) {
+				continue
+			}
+			Blobarray = append(Blobarray, leak.clone())
+		}
+		// sort is needed, since MAP is unsorted in golang, and we might get different results
+		sortRouteLeaks(Blobarray)
+		return Blobarray
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &ListRouteLeaksResponse{RouteLeaks: Blobarray, NextPageToken: token}, nil
+}
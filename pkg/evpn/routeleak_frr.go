@@ -0,0 +1,83 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+)
+
+func routeLeakMapName(obj *RouteLeak) string {
+	return fmt.Sprintf("leak-%s", path.Base(obj.Name))
+}
+
+func (s *Server) frrCreateRouteLeakRequest(ctx context.Context, obj *RouteLeak, srcName string, dstName string) error {
+	// restrict leaked prefixes with a route-map, only when asked for
+	importMap := ""
+	if len(obj.Spec.Prefixes) > 0 {
+		mapName := routeLeakMapName(obj)
+		var prefixList strings.Builder
+		for i, prefix := range obj.Spec.Prefixes {
+			fmt.Fprintf(&prefixList, "ip prefix-list %s seq %d permit %s\n", mapName, (i+1)*5, ipPrefixToNet(prefix))
+		}
+		data, err := s.frr.FrrBgpCmd(ctx, fmt.Sprintf(
+			`configure terminal
+			%[1]sroute-map %[2]s permit 10
+				match ip address prefix-list %[2]s
+				exit
+			exit`, prefixList.String(), mapName))
+		fmt.Printf("FrrBgpCmd: %v:%v", data, err)
+		if err != nil {
+			return err
+		}
+		importMap = fmt.Sprintf("import vrf route-map %s\n", mapName)
+	}
+	// Example: router bgp 65000 vrf <dst> / address-family ipv4 unicast / import vrf <src>
+	data, err := s.frr.FrrBgpCmd(ctx, fmt.Sprintf(
+		`configure terminal
+		router bgp %d vrf %s
+		address-family ipv4 unicast
+			%simport vrf %s
+			exit-address-family
+		exit`, s.Gateway.LocalAs, dstName, importMap, srcName))
+	fmt.Printf("FrrBgpCmd: %v:%v", data, err)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (s *Server) frrDeleteRouteLeakRequest(ctx context.Context, obj *RouteLeak, srcName string, dstName string) error {
+	importMap := ""
+	if len(obj.Spec.Prefixes) > 0 {
+		importMap = fmt.Sprintf("no import vrf route-map %s\n", routeLeakMapName(obj))
+	}
+	data, err := s.frr.FrrBgpCmd(ctx, fmt.Sprintf(
+		`configure terminal
+		router bgp %d vrf %s
+		address-family ipv4 unicast
+			no import vrf %s
+			%sexit-address-family
+		exit`, s.Gateway.LocalAs, dstName, srcName, importMap))
+	fmt.Printf("FrrBgpCmd: %v:%v", data, err)
+	if err != nil {
+		return err
+	}
+	if len(obj.Spec.Prefixes) > 0 {
+		mapName := routeLeakMapName(obj)
+		data, err := s.frr.FrrBgpCmd(ctx, fmt.Sprintf(
+			`configure terminal
+			no route-map %[1]s
+			no ip prefix-list %[1]s
+			exit`, mapName))
+		fmt.Printf("FrrBgpCmd: %v:%v", data, err)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,273 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/philippgille/gokv/gomap"
+	"github.com/stretchr/testify/mock"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	pc "github.com/opiproject/opi-api/network/opinetcommon/v1alpha1/gen/go"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils/mocks"
+)
+
+var (
+	testRouteLeakID       = "opi-leak8"
+	testRouteLeakName     = resourceIDToFullName("routeleaks", testRouteLeakID)
+	testSharedVrfName     = resourceIDToFullName("vrfs", "shared")
+	testRouteLeakPrefix   = &pc.IPPrefix{Addr: &pc.IPAddress{Af: pc.IpAf_IP_AF_INET, V4OrV6: &pc.IPAddress_V4Addr{V4Addr: 167837696}}, Len: 16}
+	testRouteLeakFilter   = []*pc.IPPrefix{testRouteLeakPrefix}
+	testRouteLeak         = RouteLeak{Spec: &RouteLeakSpec{SourceVrf: testSharedVrfName, DestinationVrf: testVrfName}}
+	testRouteLeakWithName = RouteLeak{Name: testRouteLeakName, Spec: testRouteLeak.Spec}
+)
+
+func Test_CreateRouteLeak(t *testing.T) {
+	tests := map[string]struct {
+		id      string
+		in      *RouteLeak
+		out     *RouteLeak
+		errCode codes.Code
+		errMsg  string
+		exist   bool
+		on      func(mockNetlink *mocks.Netlink, mockFrr *mocks.Frr, errMsg string)
+	}{
+		"illegal resource_id": {
+			id:      "CapitalLettersNotAllowed",
+			in:      &testRouteLeak,
+			out:     nil,
+			errCode: codes.InvalidArgument,
+			errMsg:  fmt.Sprintf("user-settable ID must only contain lowercase, numbers and hyphens (%v)", "got: 'C' in position 0"),
+			exist:   false,
+			on:      nil,
+		},
+		"no required source_vrf field": {
+			id:      testRouteLeakID,
+			in:      &RouteLeak{Spec: &RouteLeakSpec{DestinationVrf: testVrfName}},
+			out:     nil,
+			errCode: codes.InvalidArgument,
+			errMsg:  "missing required field: route_leak.spec.source_vrf",
+			exist:   false,
+			on:      nil,
+		},
+		"same source and destination": {
+			id:      testRouteLeakID,
+			in:      &RouteLeak{Spec: &RouteLeakSpec{SourceVrf: testVrfName, DestinationVrf: testVrfName}},
+			out:     nil,
+			errCode: codes.InvalidArgument,
+			errMsg:  "source_vrf and destination_vrf have to be different",
+			exist:   false,
+			on:      nil,
+		},
+		"unknown source vrf": {
+			id:      testRouteLeakID,
+			in:      &RouteLeak{Spec: &RouteLeakSpec{SourceVrf: resourceIDToFullName("vrfs", "unknown-id"), DestinationVrf: testVrfName}},
+			out:     nil,
+			errCode: codes.NotFound,
+			errMsg:  fmt.Sprintf("unable to find key %v", resourceIDToFullName("vrfs", "unknown-id")),
+			exist:   false,
+			on:      nil,
+		},
+		"already exists": {
+			id:      testRouteLeakID,
+			in:      &testRouteLeak,
+			out:     &testRouteLeakWithName,
+			errCode: codes.OK,
+			errMsg:  "",
+			exist:   true,
+			on:      nil,
+		},
+		"already exists with a different spec": {
+			id:      testRouteLeakID,
+			in:      &RouteLeak{Spec: &RouteLeakSpec{SourceVrf: testSharedVrfName, DestinationVrf: testVrfName, Prefixes: testRouteLeakFilter}},
+			out:     nil,
+			errCode: codes.AlreadyExists,
+			errMsg:  fmt.Sprintf("%s already exists with a different spec", testRouteLeakName),
+			exist:   true,
+			on:      nil,
+		},
+		"failed FrrBgpCmd call": {
+			id:      testRouteLeakID,
+			in:      &testRouteLeak,
+			out:     nil,
+			errCode: codes.Unknown,
+			errMsg:  "Failed to call FrrBgpCmd",
+			exist:   false,
+			on: func(mockNetlink *mocks.Netlink, mockFrr *mocks.Frr, errMsg string) {
+				mockFrr.EXPECT().FrrBgpCmd(mock.Anything, mock.Anything).Return("", errors.New(errMsg)).Once()
+			},
+		},
+		"successful call": {
+			id:      testRouteLeakID,
+			in:      &testRouteLeak,
+			out:     &testRouteLeakWithName,
+			errCode: codes.OK,
+			errMsg:  "",
+			exist:   false,
+			on: func(mockNetlink *mocks.Netlink, mockFrr *mocks.Frr, errMsg string) {
+				mockFrr.EXPECT().FrrBgpCmd(mock.Anything, mock.Anything).Return("", nil).Once()
+			},
+		},
+		"successful call with prefixes": {
+			id:      testRouteLeakID,
+			in:      &RouteLeak{Spec: &RouteLeakSpec{SourceVrf: testSharedVrfName, DestinationVrf: testVrfName, Prefixes: testRouteLeakFilter}},
+			out:     &RouteLeak{Name: testRouteLeakName, Spec: &RouteLeakSpec{SourceVrf: testSharedVrfName, DestinationVrf: testVrfName, Prefixes: testRouteLeakFilter}},
+			errCode: codes.OK,
+			errMsg:  "",
+			exist:   false,
+			on: func(mockNetlink *mocks.Netlink, mockFrr *mocks.Frr, errMsg string) {
+				mockFrr.EXPECT().FrrBgpCmd(mock.Anything, mock.Anything).Return("", nil).Twice()
+			},
+		},
+	}
+
+	// run tests
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			mockNetlink := mocks.NewNetlink(t)
+			mockFrr := mocks.NewFrr(t)
+			store := gomap.NewStore(gomap.DefaultOptions)
+			opi := NewServerWithArgs(mockNetlink, mockFrr, store)
+
+			opi.Vrfs[testVrfName] = protoClone(&testVrfWithStatus)
+			opi.Vrfs[testSharedVrfName] = protoClone(&testVrfWithStatus)
+			opi.Vrfs[testSharedVrfName].Name = testSharedVrfName
+			if tt.exist {
+				opi.RouteLeaks[testRouteLeakName] = testRouteLeakWithName.clone()
+			}
+			if tt.on != nil {
+				tt.on(mockNetlink, mockFrr, tt.errMsg)
+			}
+
+			request := &CreateRouteLeakRequest{RouteLeak: tt.in.clone(), RouteLeakID: tt.id}
+			response, err := opi.CreateRouteLeak(ctx, request)
+			if !reflect.DeepEqual(tt.out, response) {
+				t.Error("response: expected", tt.out, "received", response)
+			}
+
+			if er, ok := status.FromError(err); ok {
+				if er.Code() != tt.errCode {
+					t.Error("error code: expected", tt.errCode, "received", er.Code())
+				}
+				if er.Message() != tt.errMsg {
+					t.Error("error message: expected", tt.errMsg, "received", er.Message())
+				}
+			} else {
+				t.Error("expected grpc error status")
+			}
+		})
+	}
+
+	t.Run("second filtered leak into same vrf", func(t *testing.T) {
+		ctx := context.Background()
+		mockNetlink := mocks.NewNetlink(t)
+		mockFrr := mocks.NewFrr(t)
+		store := gomap.NewStore(gomap.DefaultOptions)
+		opi := NewServerWithArgs(mockNetlink, mockFrr, store)
+
+		opi.Vrfs[testVrfName] = protoClone(&testVrfWithStatus)
+		opi.Vrfs[testSharedVrfName] = protoClone(&testVrfWithStatus)
+		opi.Vrfs[testSharedVrfName].Name = testSharedVrfName
+		opi.RouteLeaks[testRouteLeakName] = &RouteLeak{Name: testRouteLeakName, Spec: &RouteLeakSpec{SourceVrf: testSharedVrfName, DestinationVrf: testVrfName, Prefixes: testRouteLeakFilter}}
+
+		request := &CreateRouteLeakRequest{RouteLeakID: "opi-leak9", RouteLeak: &RouteLeak{Spec: &RouteLeakSpec{SourceVrf: testSharedVrfName, DestinationVrf: testVrfName, Prefixes: testRouteLeakFilter}}}
+		_, err := opi.CreateRouteLeak(ctx, request)
+		if er := status.Convert(err); er.Code() != codes.FailedPrecondition {
+			t.Error("error code: expected", codes.FailedPrecondition, "received", er.Code())
+		}
+	})
+}
+
+func Test_DeleteRouteLeak(t *testing.T) {
+	tests := map[string]struct {
+		in      string
+		out     *emptypb.Empty
+		errCode codes.Code
+		errMsg  string
+		missing bool
+		on      func(mockNetlink *mocks.Netlink, mockFrr *mocks.Frr, errMsg string)
+	}{
+		"valid request with unknown key": {
+			in:      "unknown-id",
+			out:     nil,
+			errCode: codes.NotFound,
+			errMsg:  fmt.Sprintf("unable to find key %v", resourceIDToFullName("routeleaks", "unknown-id")),
+			missing: false,
+			on:      nil,
+		},
+		"unknown key with missing allowed": {
+			in:      "unknown-id",
+			out:     &emptypb.Empty{},
+			errCode: codes.OK,
+			errMsg:  "",
+			missing: true,
+			on:      nil,
+		},
+		"failed FrrBgpCmd call": {
+			in:      testRouteLeakID,
+			out:     nil,
+			errCode: codes.Unknown,
+			errMsg:  "Failed to call FrrBgpCmd",
+			missing: false,
+			on: func(mockNetlink *mocks.Netlink, mockFrr *mocks.Frr, errMsg string) {
+				mockFrr.EXPECT().FrrBgpCmd(mock.Anything, mock.Anything).Return("", errors.New(errMsg)).Once()
+			},
+		},
+		"successful call": {
+			in:      testRouteLeakID,
+			out:     &emptypb.Empty{},
+			errCode: codes.OK,
+			errMsg:  "",
+			missing: false,
+			on: func(mockNetlink *mocks.Netlink, mockFrr *mocks.Frr, errMsg string) {
+				mockFrr.EXPECT().FrrBgpCmd(mock.Anything, mock.Anything).Return("", nil).Once()
+			},
+		},
+	}
+
+	// run tests
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			mockNetlink := mocks.NewNetlink(t)
+			mockFrr := mocks.NewFrr(t)
+			store := gomap.NewStore(gomap.DefaultOptions)
+			opi := NewServerWithArgs(mockNetlink, mockFrr, store)
+
+			opi.RouteLeaks[testRouteLeakName] = testRouteLeakWithName.clone()
+			if tt.on != nil {
+				tt.on(mockNetlink, mockFrr, tt.errMsg)
+			}
+
+			request := &DeleteRouteLeakRequest{Name: resourceIDToFullName("routeleaks", tt.in), AllowMissing: tt.missing}
+			response, err := opi.DeleteRouteLeak(ctx, request)
+
+			if er, ok := status.FromError(err); ok {
+				if er.Code() != tt.errCode {
+					t.Error("error code: expected", tt.errCode, "received", er.Code())
+				}
+				if er.Message() != tt.errMsg {
+					t.Error("error message: expected", tt.errMsg, "received", er.Message())
+				}
+			} else {
+				t.Error("expected grpc error status")
+			}
+
+			if reflect.TypeOf(response) != reflect.TypeOf(tt.out) {
+				t.Error("response: expected", reflect.TypeOf(tt.out), "received", reflect.TypeOf(response))
+			}
+		})
+	}
+}
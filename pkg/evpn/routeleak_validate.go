@@ -0,0 +1,72 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"fmt"
+
+	"go.einride.tech/aip/resourcename"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (s *Server) validateCreateRouteLeakRequest(in *CreateRouteLeakRequest) error {
+	// check required fields
+	switch {
+	case in.RouteLeak == nil:
+		return missingField("route_leak")
+	case in.RouteLeak.Spec == nil:
+		return missingField("route_leak.spec")
+	case in.RouteLeak.Spec.SourceVrf == "":
+		return missingField("route_leak.spec.source_vrf")
+	case in.RouteLeak.Spec.DestinationVrf == "":
+		return missingField("route_leak.spec.destination_vrf")
+	}
+	// leaking a Vrf into itself makes no sense
+	if in.RouteLeak.Spec.SourceVrf == in.RouteLeak.Spec.DestinationVrf {
+		return badRequest("route_leak.spec.destination_vrf", status.Error(codes.InvalidArgument, "source_vrf and destination_vrf have to be different"))
+	}
+	// check prefix lengths are in range
+	for i, prefix := range in.RouteLeak.Spec.Prefixes {
+		if prefix.Addr == nil || prefix.Len > 32 {
+			msg := fmt.Sprintf("Prefix length (%d) have to be between 0 and 32", prefix.Len)
+			field := fmt.Sprintf("route_leak.spec.prefixes[%d]", i)
+			return badRequest(field, status.Error(codes.InvalidArgument, msg))
+		}
+	}
+	// Validate that a Vrf resource name conforms to the restrictions outlined in AIP-122.
+	if err := badRequest("route_leak.spec.source_vrf", resourcename.Validate(in.RouteLeak.Spec.SourceVrf)); err != nil {
+		return err
+	}
+	if err := badRequest("route_leak.spec.destination_vrf", resourcename.Validate(in.RouteLeak.Spec.DestinationVrf)); err != nil {
+		return err
+	}
+	// see https://google.aip.dev/133#user-specified-ids
+	if in.RouteLeakID != "" {
+		if err := badRequest("route_leak_id", validateResourceID(in.RouteLeakID, false)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Server) validateDeleteRouteLeakRequest(in *DeleteRouteLeakRequest) error {
+	// check required fields
+	if in.Name == "" {
+		return missingField("name")
+	}
+	// Validate that a resource name conforms to the restrictions outlined in AIP-122.
+	return badRequest("name", resourcename.Validate(in.Name))
+}
+
+func (s *Server) validateGetRouteLeakRequest(in *GetRouteLeakRequest) error {
+	// check required fields
+	if in.Name == "" {
+		return missingField("name")
+	}
+	// Validate that a resource name conforms to the restrictions outlined in AIP-122.
+	return badRequest("name", resourcename.Validate(in.Name))
+}
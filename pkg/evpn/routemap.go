@@ -0,0 +1,260 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"log"
+	"sort"
+
+	"go.einride.tech/aip/resourceid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// RouteMap is an FRR route-map filtering and modifying the routes of the BgpPeers and
+// VrfLiteHandoffs referencing it, instead of hand-editing frr.conf
+// TODO: move to opi-api once the message is agreed upon
+type RouteMap struct {
+	Name string
+	Spec *RouteMapSpec
+}
+
+// RouteMapSpec is the desired configuration of a RouteMap
+type RouteMapSpec struct {
+	// Entries are evaluated by increasing Seq, the first matching one applies, the other routes are denied
+	Entries []*RouteMapEntry
+}
+
+// RouteMapEntry matches routes and sets their attributes when it permits them
+type RouteMapEntry struct {
+	// Seq orders the entries, unique in the RouteMap
+	Seq    uint32
+	Action RouteAction
+	// MatchPrefixList is the name of the PrefixList the routes have to match, all routes match when empty
+	MatchPrefixList string
+	// SetLocalPreference sets the local preference of the routes, kept when 0
+	SetLocalPreference uint32
+	// SetMetric sets the MED of the routes, kept when 0
+	SetMetric uint32
+	// SetCommunities are added to the communities of the routes (e.g.: 65000:100 or no-export)
+	SetCommunities []string
+}
+
+// CreateRouteMapRequest is the request to create a RouteMap
+type CreateRouteMapRequest struct {
+	RouteMapID string
+	RouteMap   *RouteMap
+}
+
+// UpdateRouteMapRequest is the request to replace the entries of a RouteMap
+type UpdateRouteMapRequest struct {
+	RouteMap *RouteMap
+}
+
+// DeleteRouteMapRequest is the request to delete a RouteMap
+type DeleteRouteMapRequest struct {
+	Name         string
+	AllowMissing bool
+}
+
+// ListRouteMapsRequest is the request to list RouteMaps
+type ListRouteMapsRequest struct {
+	PageSize  int32
+	PageToken string
+}
+
+// ListRouteMapsResponse is the response of listing RouteMaps
+type ListRouteMapsResponse struct {
+	RouteMaps     []*RouteMap
+	NextPageToken string
+}
+
+func (m *RouteMap) clone() *RouteMap {
+	if m == nil {
+		return nil
+	}
+	c := &RouteMap{Name: m.Name}
+	if m.Spec != nil {
+		spec := &RouteMapSpec{}
+		for _, entry := range m.Spec.Entries {
+			e := *entry
+			e.SetCommunities = append([]string(nil), entry.SetCommunities...)
+			spec.Entries = append(spec.Entries, &e)
+		}
+		c.Spec = spec
+	}
+	return c
+}
+
+func sortRouteMaps(maps []*RouteMap) {
+	sort.Slice(maps, func(i int, j int) bool {
+		return maps[i].Name < maps[j].Name
+	})
+}
+
+// checkRouteMapPrefixLists checks the PrefixLists matched by the entries exist
+func (s *Server) checkRouteMapPrefixLists(obj *RouteMap) error {
+	for _, entry := range obj.Spec.Entries {
+		if entry.MatchPrefixList == "" {
+			continue
+		}
+		if _, ok := s.PrefixLists[entry.MatchPrefixList]; !ok {
+			err := status.Errorf(codes.NotFound, "unable to find key %s", entry.MatchPrefixList)
+			return err
+		}
+	}
+	return nil
+}
+
+// checkRouteMaps checks the RouteMaps referenced by a BGP session exist
+func (s *Server) checkRouteMaps(names ...string) error {
+	for _, name := range names {
+		if name == "" {
+			continue
+		}
+		if _, ok := s.RouteMaps[name]; !ok {
+			err := status.Errorf(codes.NotFound, "unable to find key %s", name)
+			return err
+		}
+	}
+	return nil
+}
+
+// CreateRouteMap executes the creation of the route-map
+func (s *Server) CreateRouteMap(ctx context.Context, in *CreateRouteMapRequest) (*RouteMap, error) {
+	// check input correctness
+	if err := s.validateCreateRouteMapRequest(in); err != nil {
+		return nil, err
+	}
+	if err := checkNoTenant(ctx); err != nil {
+		return nil, err
+	}
+	// see https://google.aip.dev/133#user-specified-ids
+	resourceID := resourceid.NewSystemGenerated()
+	if in.RouteMapID != "" {
+		log.Printf("client provided the ID of a resource %v, ignoring the name field %v", in.RouteMapID, in.RouteMap.Name)
+		resourceID = in.RouteMapID
+	}
+	in.RouteMap.Name = resourceIDToFullName("routemaps", resourceID)
+	s.objectsMu.Lock()
+	defer s.objectsMu.Unlock()
+	// idempotent API when called with same key, should return same object
+	obj, ok := s.RouteMaps[in.RouteMap.Name]
+	if ok {
+		// a different spec under the same key is a conflict, not a retry
+		if err := checkSameChildSpec(obj.Name, obj.Spec, in.RouteMap.Spec); err != nil {
+			return nil, err
+		}
+		log.Printf("Already existing RouteMap with id %v", in.RouteMap.Name)
+		return obj.clone(), nil
+	}
+	if err := s.checkRouteMapPrefixLists(in.RouteMap); err != nil {
+		return nil, err
+	}
+	if err := s.dataplane.CreateRouteMap(ctx, in.RouteMap); err != nil {
+		s.forgetStatus(in.RouteMap.Name)
+		return nil, status.Convert(err).Err()
+	}
+	// save object to the database
+	response := in.RouteMap.clone()
+	s.RouteMaps[in.RouteMap.Name] = response
+	persistObjects(s, "routemaps", s.RouteMaps)
+	return response.clone(), nil
+}
+
+// UpdateRouteMap replaces the entries of the route-map, the sessions referencing it apply the new entries
+func (s *Server) UpdateRouteMap(ctx context.Context, in *UpdateRouteMapRequest) (*RouteMap, error) {
+	// check input correctness
+	if err := s.validateUpdateRouteMapRequest(in); err != nil {
+		return nil, err
+	}
+	if err := checkNoTenant(ctx); err != nil {
+		return nil, err
+	}
+	s.objectsMu.Lock()
+	defer s.objectsMu.Unlock()
+	// fetch object from the database
+	if _, ok := s.RouteMaps[in.RouteMap.Name]; !ok {
+		err := status.Errorf(codes.NotFound, "unable to find key %s", in.RouteMap.Name)
+		return nil, err
+	}
+	if err := s.checkRouteMapPrefixLists(in.RouteMap); err != nil {
+		return nil, err
+	}
+	if err := s.dataplane.CreateRouteMap(ctx, in.RouteMap); err != nil {
+		return nil, status.Convert(err).Err()
+	}
+	// save object to the database
+	response := in.RouteMap.clone()
+	s.RouteMaps[in.RouteMap.Name] = response
+	persistObjects(s, "routemaps", s.RouteMaps)
+	return response.clone(), nil
+}
+
+// DeleteRouteMap deletes the route-map
+func (s *Server) DeleteRouteMap(ctx context.Context, in *DeleteRouteMapRequest) (*emptypb.Empty, error) {
+	// check input correctness
+	if err := s.validateDeleteRouteMapRequest(in); err != nil {
+		return nil, err
+	}
+	if err := checkNoTenant(ctx); err != nil {
+		return nil, err
+	}
+	s.objectsMu.Lock()
+	defer s.objectsMu.Unlock()
+	return s.deleteRouteMap(ctx, in)
+}
+
+// deleteRouteMap deletes a validated route-map, with objectsMu held
+func (s *Server) deleteRouteMap(ctx context.Context, in *DeleteRouteMapRequest) (*emptypb.Empty, error) {
+	// fetch object from the database
+	obj, ok := s.RouteMaps[in.Name]
+	if !ok {
+		if in.AllowMissing {
+			return &emptypb.Empty{}, nil
+		}
+		err := status.Errorf(codes.NotFound, "unable to find key %s", in.Name)
+		return nil, err
+	}
+	// the BGP sessions referencing the map go first
+	if err := s.checkDependents(ctx, obj.Name, s.routeMapDependents(obj.Name)); err != nil {
+		return nil, err
+	}
+	if err := s.dataplane.DeleteRouteMap(ctx, obj); err != nil {
+		return nil, status.Convert(err).Err()
+	}
+	// remove from the Database
+	delete(s.RouteMaps, obj.Name)
+	persistObjects(s, "routemaps", s.RouteMaps)
+	s.forgetStatus(obj.Name)
+	return &emptypb.Empty{}, nil
+}
+
+// ListRouteMaps lists the route-maps
+func (s *Server) ListRouteMaps(ctx context.Context, in *ListRouteMapsRequest) (*ListRouteMapsResponse, error) {
+	// fetch pagination from the database, calculate size and offset
+	size, offset, perr := s.extractPagination(in.PageSize, in.PageToken)
+	if perr != nil {
+		return nil, perr
+	}
+	s.objectsMu.RLock()
+	defer s.objectsMu.RUnlock()
+	// fetch object from the database, the following pages are cut from the snapshot of the first one
+	Blobarray, token, err := listPage(ctx, s, "routeMaps", "", in.PageToken, offset, size, func() []*RouteMap {
+		Blobarray := []*RouteMap{}
+		for _, routeMap := range s.RouteMaps {
+			Blobarray = append(Blobarray, routeMap.clone())
+		}
+		// sort is needed, since MAP is unsorted in golang, and we might get different results
+		sortRouteMaps(Blobarray)
+		return Blobarray
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &ListRouteMapsResponse{RouteMaps: Blobarray, NextPageToken: token}, nil
+}
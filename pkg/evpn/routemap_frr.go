@@ -0,0 +1,71 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// frrRouteMap renders the entries of the map, replacing the previous ones
+func frrRouteMap(obj *RouteMap) string {
+	name := routePolicyFrrName(obj.Name)
+	var b strings.Builder
+	fmt.Fprintf(&b, "no route-map %s\n", name)
+	for _, entry := range obj.Spec.Entries {
+		// Example: route-map from-core permit 10
+		fmt.Fprintf(&b, "route-map %s %s %d\n", name, entry.Action, entry.Seq)
+		if entry.MatchPrefixList != "" {
+			fmt.Fprintf(&b, "match ip address prefix-list %s\n", routePolicyFrrName(entry.MatchPrefixList))
+		}
+		if entry.SetLocalPreference != 0 {
+			fmt.Fprintf(&b, "set local-preference %d\n", entry.SetLocalPreference)
+		}
+		if entry.SetMetric != 0 {
+			fmt.Fprintf(&b, "set metric %d\n", entry.SetMetric)
+		}
+		if len(entry.SetCommunities) != 0 {
+			fmt.Fprintf(&b, "set community %s additive\n", strings.Join(entry.SetCommunities, " "))
+		}
+		b.WriteString("exit\n")
+	}
+	return b.String()
+}
+
+// frrNeighborRouteMaps renders the route-maps of a BGP session, in an address family
+func frrNeighborRouteMaps(neighbor string, importRouteMap string, exportRouteMap string) string {
+	var b strings.Builder
+	if importRouteMap != "" {
+		fmt.Fprintf(&b, "neighbor %s route-map %s in\n", neighbor, routePolicyFrrName(importRouteMap))
+	}
+	if exportRouteMap != "" {
+		fmt.Fprintf(&b, "neighbor %s route-map %s out\n", neighbor, routePolicyFrrName(exportRouteMap))
+	}
+	return b.String()
+}
+
+func (s *Server) frrCreateRouteMapRequest(ctx context.Context, obj *RouteMap) error {
+	data, err := s.frr.FrrBgpCmd(ctx, fmt.Sprintf(
+		`configure terminal
+		%sexit`, frrRouteMap(obj)))
+	fmt.Printf("FrrBgpCmd: %v:%v", data, err)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (s *Server) frrDeleteRouteMapRequest(ctx context.Context, obj *RouteMap) error {
+	data, err := s.frr.FrrBgpCmd(ctx, fmt.Sprintf(
+		`configure terminal
+		no route-map %s
+		exit`, routePolicyFrrName(obj.Name)))
+	fmt.Printf("FrrBgpCmd: %v:%v", data, err)
+	if err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,276 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/philippgille/gokv/gomap"
+	"github.com/stretchr/testify/mock"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils"
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils/mocks"
+)
+
+var (
+	testRouteMapID   = "opi-map8"
+	testRouteMapName = resourceIDToFullName("routemaps", testRouteMapID)
+	testRouteMap     = RouteMap{
+		Spec: &RouteMapSpec{
+			Entries: []*RouteMapEntry{
+				{Seq: 10, Action: RoutePermit, MatchPrefixList: testPrefixListName, SetLocalPreference: 200, SetCommunities: []string{"65000:100", "no-export"}},
+				{Seq: 20, Action: RouteDeny},
+			},
+		},
+	}
+	testRouteMapWithName = RouteMap{
+		Name: testRouteMapName,
+		Spec: testRouteMap.Spec,
+	}
+)
+
+func Test_CreateRouteMap(t *testing.T) {
+	tests := map[string]struct {
+		id      string
+		in      *RouteMap
+		out     *RouteMap
+		errCode codes.Code
+		errMsg  string
+		exist   bool
+		on      func(mockFrr *mocks.Frr, errMsg string)
+	}{
+		"no seq": {
+			id:      testRouteMapID,
+			in:      &RouteMap{Spec: &RouteMapSpec{Entries: []*RouteMapEntry{{Action: RoutePermit}}}},
+			out:     nil,
+			errCode: codes.InvalidArgument,
+			errMsg:  "missing required field: route_map.spec.entries[0].seq",
+			exist:   false,
+			on:      nil,
+		},
+		"invalid community": {
+			id:      testRouteMapID,
+			in:      &RouteMap{Spec: &RouteMapSpec{Entries: []*RouteMapEntry{{Seq: 10, Action: RoutePermit, SetCommunities: []string{"65000:70000"}}}}},
+			out:     nil,
+			errCode: codes.InvalidArgument,
+			errMsg:  fmt.Sprintf("community %q has to be in ASN:NN format or a well-known community", "65000:70000"),
+			exist:   false,
+			on:      nil,
+		},
+		"unknown prefix-list": {
+			id:      testRouteMapID,
+			in:      &RouteMap{Spec: &RouteMapSpec{Entries: []*RouteMapEntry{{Seq: 10, Action: RoutePermit, MatchPrefixList: "prefixlists/unknown"}}}},
+			out:     nil,
+			errCode: codes.NotFound,
+			errMsg:  "unable to find key prefixlists/unknown",
+			exist:   false,
+			on:      nil,
+		},
+		"already exists": {
+			id:      testRouteMapID,
+			in:      &testRouteMap,
+			out:     &testRouteMapWithName,
+			errCode: codes.OK,
+			errMsg:  "",
+			exist:   true,
+			on:      nil,
+		},
+		"already exists with a different spec": {
+			id:      testRouteMapID,
+			in:      &RouteMap{Spec: &RouteMapSpec{Entries: []*RouteMapEntry{{Seq: 10, Action: RouteDeny}}}},
+			out:     nil,
+			errCode: codes.AlreadyExists,
+			errMsg:  fmt.Sprintf("%s already exists with a different spec", testRouteMapName),
+			exist:   true,
+			on:      nil,
+		},
+		"failed FrrBgpCmd call": {
+			id:      testRouteMapID,
+			in:      &testRouteMap,
+			out:     nil,
+			errCode: codes.Unknown,
+			errMsg:  "Failed to call FrrBgpCmd",
+			exist:   false,
+			on: func(mockFrr *mocks.Frr, errMsg string) {
+				mockFrr.EXPECT().FrrBgpCmd(mock.Anything, mock.Anything).Return("", errors.New(errMsg)).Once()
+			},
+		},
+		"successful call": {
+			id:      testRouteMapID,
+			in:      &testRouteMap,
+			out:     &testRouteMapWithName,
+			errCode: codes.OK,
+			errMsg:  "",
+			exist:   false,
+			on: func(mockFrr *mocks.Frr, errMsg string) {
+				mockFrr.EXPECT().FrrBgpCmd(mock.Anything, mock.MatchedBy(func(command string) bool {
+					return strings.Contains(command, "no route-map opi-map8\n") &&
+						strings.Contains(command, "route-map opi-map8 permit 10\n"+
+							"match ip address prefix-list opi-prefixes8\n"+
+							"set local-preference 200\n"+
+							"set community 65000:100 no-export additive\nexit\n") &&
+						strings.Contains(command, "route-map opi-map8 deny 20\nexit\n")
+				})).Return("", nil).Once()
+			},
+		},
+	}
+
+	// run tests
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			mockFrr := mocks.NewFrr(t)
+			opi := NewServerWithArgs(mocks.NewNetlink(t), mockFrr, gomap.NewStore(gomap.DefaultOptions))
+
+			opi.PrefixLists[testPrefixListName] = testPrefixListWithName.clone()
+			if tt.exist {
+				opi.RouteMaps[testRouteMapName] = testRouteMapWithName.clone()
+			}
+			if tt.on != nil {
+				tt.on(mockFrr, tt.errMsg)
+			}
+
+			request := &CreateRouteMapRequest{RouteMap: tt.in.clone(), RouteMapID: tt.id}
+			response, err := opi.CreateRouteMap(ctx, request)
+			if !reflect.DeepEqual(tt.out, response) {
+				t.Error("response: expected", tt.out, "received", response)
+			}
+
+			if er, ok := status.FromError(err); ok {
+				if er.Code() != tt.errCode {
+					t.Error("error code: expected", tt.errCode, "received", er.Code())
+				}
+				if er.Message() != tt.errMsg {
+					t.Error("error message: expected", tt.errMsg, "received", er.Message())
+				}
+			} else {
+				t.Error("expected grpc error status")
+			}
+		})
+	}
+}
+
+func Test_DeleteRouteMap(t *testing.T) {
+	tests := map[string]struct {
+		in         string
+		out        *emptypb.Empty
+		errCode    codes.Code
+		errMsg     string
+		missing    bool
+		referenced bool
+		on         func(mockFrr *mocks.Frr, errMsg string)
+	}{
+		"valid request with unknown key": {
+			in:      "unknown-id",
+			out:     nil,
+			errCode: codes.NotFound,
+			errMsg:  fmt.Sprintf("unable to find key %v", resourceIDToFullName("routemaps", "unknown-id")),
+			missing: false,
+			on:      nil,
+		},
+		"unknown key with missing allowed": {
+			in:      "unknown-id",
+			out:     &emptypb.Empty{},
+			errCode: codes.OK,
+			errMsg:  "",
+			missing: true,
+			on:      nil,
+		},
+		"referenced by a bgp peer": {
+			in:         testRouteMapID,
+			out:        nil,
+			errCode:    codes.FailedPrecondition,
+			errMsg:     fmt.Sprintf("%s is still referenced by %s, delete them first or set %s", testRouteMapName, testBgpPeerName, utils.CascadeMetadataKey),
+			missing:    false,
+			referenced: true,
+			on:         nil,
+		},
+		"successful call": {
+			in:      testRouteMapID,
+			out:     &emptypb.Empty{},
+			errCode: codes.OK,
+			errMsg:  "",
+			missing: false,
+			on: func(mockFrr *mocks.Frr, errMsg string) {
+				mockFrr.EXPECT().FrrBgpCmd(mock.Anything, mock.MatchedBy(func(command string) bool {
+					return strings.Contains(command, "no route-map opi-map8")
+				})).Return("", nil).Once()
+			},
+		},
+	}
+
+	// run tests
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			mockFrr := mocks.NewFrr(t)
+			opi := NewServerWithArgs(mocks.NewNetlink(t), mockFrr, gomap.NewStore(gomap.DefaultOptions))
+
+			opi.RouteMaps[testRouteMapName] = testRouteMapWithName.clone()
+			if tt.referenced {
+				peer := testBgpPeerWithName.clone()
+				peer.Spec.ImportRouteMap = testRouteMapName
+				opi.BgpPeers[testBgpPeerName] = peer
+			}
+			if tt.on != nil {
+				tt.on(mockFrr, tt.errMsg)
+			}
+
+			request := &DeleteRouteMapRequest{Name: resourceIDToFullName("routemaps", tt.in), AllowMissing: tt.missing}
+			response, err := opi.DeleteRouteMap(ctx, request)
+
+			if er, ok := status.FromError(err); ok {
+				if er.Code() != tt.errCode {
+					t.Error("error code: expected", tt.errCode, "received", er.Code())
+				}
+				if er.Message() != tt.errMsg {
+					t.Error("error message: expected", tt.errMsg, "received", er.Message())
+				}
+			} else {
+				t.Error("expected grpc error status")
+			}
+
+			if reflect.TypeOf(response) != reflect.TypeOf(tt.out) {
+				t.Error("response: expected", reflect.TypeOf(tt.out), "received", reflect.TypeOf(response))
+			}
+		})
+	}
+}
+
+func Test_CreateBgpPeerRouteMaps(t *testing.T) {
+	in := &BgpPeer{Spec: &BgpPeerSpec{PeerIPAddress: testBgpPeerAddress, RemoteAs: 65001, AddressFamilies: []BgpAddressFamily{BgpIPv4Unicast}, ExportRouteMap: testRouteMapName}}
+
+	t.Run("unknown route-map", func(t *testing.T) {
+		opi := NewServerWithArgs(mocks.NewNetlink(t), mocks.NewFrr(t), gomap.NewStore(gomap.DefaultOptions))
+		_, err := opi.CreateBgpPeer(context.Background(), &CreateBgpPeerRequest{BgpPeer: in.clone(), BgpPeerID: testBgpPeerID})
+		if status.Code(err) != codes.NotFound {
+			t.Error("error: expected", codes.NotFound, "received", err)
+		}
+	})
+
+	t.Run("route-map in the address families", func(t *testing.T) {
+		mockFrr := mocks.NewFrr(t)
+		opi := NewServerWithArgs(mocks.NewNetlink(t), mockFrr, gomap.NewStore(gomap.DefaultOptions))
+		opi.RouteMaps[testRouteMapName] = testRouteMapWithName.clone()
+		mockFrr.EXPECT().FrrBgpCmd(mock.Anything, mock.MatchedBy(func(command string) bool {
+			return strings.Contains(command, "address-family ipv4 unicast\nneighbor 10.0.0.2 activate\n"+
+				"neighbor 10.0.0.2 route-map opi-map8 out\nexit-address-family\n") &&
+				!strings.Contains(command, "route-map opi-map8 in")
+		})).Return("", nil).Once()
+		_, err := opi.CreateBgpPeer(context.Background(), &CreateBgpPeerRequest{BgpPeer: in.clone(), BgpPeerID: testBgpPeerID})
+		if err != nil {
+			t.Error("error: expected nil, received", err)
+		}
+	})
+}
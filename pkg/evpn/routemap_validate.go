@@ -0,0 +1,109 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"go.einride.tech/aip/resourcename"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// validCommunity checks a community is in ASN:NN format or one of the well-known ones FRR accepts
+func validCommunity(community string) bool {
+	switch community {
+	case "no-export", "no-advertise", "local-AS", "internet", "graceful-shutdown", "blackhole":
+		return true
+	}
+	asn, value, ok := strings.Cut(community, ":")
+	if !ok {
+		return false
+	}
+	_, err := strconv.ParseUint(asn, 10, 16)
+	if err != nil {
+		return false
+	}
+	_, err = strconv.ParseUint(value, 10, 16)
+	return err == nil
+}
+
+func validateRouteMapSpec(spec *RouteMapSpec) error {
+	if len(spec.Entries) == 0 {
+		return missingField("route_map.spec.entries")
+	}
+	seqs := map[uint32]bool{}
+	for i, entry := range spec.Entries {
+		field := fmt.Sprintf("route_map.spec.entries[%d]", i)
+		if entry == nil {
+			return missingField(field)
+		}
+		if err := validateRouteEntry(field, entry.Seq, entry.Action, seqs); err != nil {
+			return err
+		}
+		// Validate that a PrefixList resource name conforms to the restrictions outlined in AIP-122.
+		if entry.MatchPrefixList != "" {
+			if err := badRequest(field+".match_prefix_list", resourcename.Validate(entry.MatchPrefixList)); err != nil {
+				return err
+			}
+		}
+		for _, community := range entry.SetCommunities {
+			if !validCommunity(community) {
+				msg := fmt.Sprintf("community %q has to be in ASN:NN format or a well-known community", community)
+				return badRequest(field+".set_communities", status.Error(codes.InvalidArgument, msg))
+			}
+		}
+	}
+	return nil
+}
+
+func (s *Server) validateCreateRouteMapRequest(in *CreateRouteMapRequest) error {
+	// check required fields
+	switch {
+	case in.RouteMap == nil:
+		return missingField("route_map")
+	case in.RouteMap.Spec == nil:
+		return missingField("route_map.spec")
+	}
+	if err := validateRouteMapSpec(in.RouteMap.Spec); err != nil {
+		return err
+	}
+	// see https://google.aip.dev/133#user-specified-ids
+	if in.RouteMapID != "" {
+		if err := badRequest("route_map_id", validateResourceID(in.RouteMapID, false)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Server) validateUpdateRouteMapRequest(in *UpdateRouteMapRequest) error {
+	// check required fields
+	switch {
+	case in.RouteMap == nil:
+		return missingField("route_map")
+	case in.RouteMap.Name == "":
+		return missingField("route_map.name")
+	case in.RouteMap.Spec == nil:
+		return missingField("route_map.spec")
+	}
+	if err := validateRouteMapSpec(in.RouteMap.Spec); err != nil {
+		return err
+	}
+	// Validate that a resource name conforms to the restrictions outlined in AIP-122.
+	return badRequest("route_map.name", resourcename.Validate(in.RouteMap.Name))
+}
+
+func (s *Server) validateDeleteRouteMapRequest(in *DeleteRouteMapRequest) error {
+	// check required fields
+	if in.Name == "" {
+		return missingField("name")
+	}
+	// Validate that a resource name conforms to the restrictions outlined in AIP-122.
+	return badRequest("name", resourcename.Validate(in.Name))
+}
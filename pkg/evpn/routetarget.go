@@ -0,0 +1,177 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils"
+)
+
+// routeTargetsKey is the store key of the route distinguishers and route targets of the Vrfs
+const routeTargetsKey = "routetargets"
+
+// VrfRouteTargets is the EVPN route policy of a Vrf, FRR auto-derives the empty parts, the route
+// distinguisher from the router-id and the route targets from the AS and the vni
+type VrfRouteTargets struct {
+	// Rd is the route distinguisher of the type-5 routes of the Vrf
+	Rd string
+	// Import are the route targets of the routes imported into the Vrf
+	Import []string
+	// Export are the route targets the routes of the Vrf are advertised with
+	Export []string
+}
+
+func (rt VrfRouteTargets) empty() bool {
+	return rt.Rd == "" && len(rt.Import) == 0 && len(rt.Export) == 0
+}
+
+// parseRouteDistinguisher checks the ASN:NN or A.B.C.D:NN format shared by route distinguishers
+// and route targets, a 4 bytes administrator leaves 2 bytes to the assigned number
+func parseRouteDistinguisher(value string) error {
+	admin, assigned, ok := strings.Cut(value, ":")
+	if !ok {
+		return fmt.Errorf("%q has to be in ASN:NN or A.B.C.D:NN format", value)
+	}
+	bits := 32
+	if ip := net.ParseIP(admin); ip != nil {
+		if ip.To4() == nil {
+			return fmt.Errorf("%q has to use an IPv4 address", value)
+		}
+		bits = 16
+	} else {
+		asn, err := strconv.ParseUint(admin, 10, 32)
+		if err != nil || asn == 0 {
+			return fmt.Errorf("%q has to start with an AS number between 1 and 4294967295 or an IPv4 address", value)
+		}
+		if asn > 65535 {
+			bits = 16
+		}
+	}
+	if _, err := strconv.ParseUint(assigned, 10, bits); err != nil {
+		return fmt.Errorf("%q has to end with a number of %d bits", value, bits)
+	}
+	return nil
+}
+
+// routeTargetsFromContext returns the route distinguisher and route targets sent with the call
+func routeTargetsFromContext(ctx context.Context) (VrfRouteTargets, error) {
+	rt := VrfRouteTargets{}
+	if rd, ok := utils.MetadataValue(ctx, utils.RouteDistinguisherMetadataKey); ok && rd != "" {
+		if err := parseRouteDistinguisher(rd); err != nil {
+			msg := fmt.Sprintf("invalid route distinguisher %v", err)
+			return rt, badRequest(utils.RouteDistinguisherMetadataKey, status.Error(codes.InvalidArgument, msg))
+		}
+		rt.Rd = rd
+	}
+	for key, targets := range map[string]*[]string{
+		utils.ImportRouteTargetsMetadataKey: &rt.Import,
+		utils.ExportRouteTargetsMetadataKey: &rt.Export,
+	} {
+		value, ok := utils.MetadataValue(ctx, key)
+		if !ok || value == "" {
+			continue
+		}
+		for _, target := range strings.Split(value, ",") {
+			target = strings.TrimSpace(target)
+			if err := parseRouteDistinguisher(target); err != nil {
+				msg := fmt.Sprintf("invalid route target %v", err)
+				return rt, badRequest(key, status.Error(codes.InvalidArgument, msg))
+			}
+			*targets = append(*targets, target)
+		}
+	}
+	return rt, nil
+}
+
+// routeTargetsFor returns the route policy of a new Vrf, sent with the call or kept from a
+// previous incarnation of the same Vrf (e.g.: on replay), the VPN routes of an SRv6 Vrf use it too
+func (s *Server) routeTargetsFor(ctx context.Context, obj *pb.Vrf, srv6 bool) (VrfRouteTargets, error) {
+	rt, err := routeTargetsFromContext(ctx)
+	if err != nil {
+		return rt, err
+	}
+	if rt.empty() {
+		return s.RouteTargets[obj.Name], nil
+	}
+	if obj.Spec.Vni == nil && !srv6 {
+		msg := "a route distinguisher or route targets require a vni or the srv6 overlay"
+		return rt, badRequest(utils.RouteDistinguisherMetadataKey, status.Error(codes.InvalidArgument, msg))
+	}
+	return rt, nil
+}
+
+// frrRouteTargets returns the l2vpn evpn address family lines of the route policy of a Vrf
+func (s *Server) frrRouteTargets(name string) string {
+	rt := s.RouteTargets[name]
+	config := ""
+	if rt.Rd != "" {
+		config += fmt.Sprintf("rd %s\n", rt.Rd)
+	}
+	for _, target := range rt.Import {
+		config += fmt.Sprintf("route-target import %s\n", target)
+	}
+	for _, target := range rt.Export {
+		config += fmt.Sprintf("route-target export %s\n", target)
+	}
+	return config
+}
+
+func (s *Server) persistRouteTargets() {
+	fields := make(map[string]interface{}, len(s.RouteTargets))
+	for name, rt := range s.RouteTargets {
+		fields[name] = map[string]interface{}{
+			"rd":     rt.Rd,
+			"import": strings.Join(rt.Import, ","),
+			"export": strings.Join(rt.Export, ","),
+		}
+	}
+	msg, err := structpb.NewStruct(fields)
+	if err == nil {
+		err = s.store.Set(routeTargetsKey, msg)
+	}
+	if err != nil {
+		fmt.Printf("Failed to persist %s: %v", routeTargetsKey, err)
+	}
+}
+
+// loadRouteTargets restores the route policies, so replayed Vrfs keep advertising the same routes
+func (s *Server) loadRouteTargets() error {
+	msg := &structpb.Struct{}
+	found, err := s.store.Get(routeTargetsKey, msg)
+	if err != nil || !found {
+		return err
+	}
+	for name, value := range msg.Fields {
+		fields := value.GetStructValue().GetFields()
+		rt := VrfRouteTargets{Rd: fields["rd"].GetStringValue()}
+		if targets := fields["import"].GetStringValue(); targets != "" {
+			rt.Import = strings.Split(targets, ",")
+		}
+		if targets := fields["export"].GetStringValue(); targets != "" {
+			rt.Export = strings.Split(targets, ",")
+		}
+		s.RouteTargets[name] = rt
+	}
+	return nil
+}
+
+// releaseRouteTargets forgets the route policy of a deleted Vrf
+func (s *Server) releaseRouteTargets(name string) {
+	if _, ok := s.RouteTargets[name]; ok {
+		delete(s.RouteTargets, name)
+		s.persistRouteTargets()
+	}
+}
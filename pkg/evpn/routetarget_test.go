@@ -0,0 +1,125 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/philippgille/gokv/gomap"
+	"github.com/stretchr/testify/mock"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils"
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils/mocks"
+)
+
+func Test_parseRouteDistinguisher(t *testing.T) {
+	tests := map[string]struct {
+		in    string
+		valid bool
+	}{
+		"2 bytes AS":                 {in: "65000:100000", valid: true},
+		"4 bytes AS":                 {in: "4200000000:100", valid: true},
+		"4 bytes AS with big number": {in: "4200000000:100000", valid: false},
+		"IPv4 address":               {in: "10.0.0.1:100", valid: true},
+		"IPv6 address":               {in: "fd00::1:100", valid: false},
+		"no separator":               {in: "65000", valid: false},
+		"AS 0":                       {in: "0:100", valid: false},
+		"not a number":               {in: "65000:abc", valid: false},
+	}
+	for testName, tt := range tests {
+		t.Run(testName, func(t *testing.T) {
+			err := parseRouteDistinguisher(tt.in)
+			if (err == nil) != tt.valid {
+				t.Error("valid: expected", tt.valid, "received", err)
+			}
+		})
+	}
+}
+
+func Test_routeTargetsFor(t *testing.T) {
+	tests := map[string]struct {
+		md      metadata.MD
+		vni     *uint32
+		srv6    bool
+		out     VrfRouteTargets
+		errCode codes.Code
+	}{
+		"auto-derived": {
+			md:  metadata.MD{},
+			vni: proto.Uint32(1000),
+			out: VrfRouteTargets{},
+		},
+		"explicit": {
+			md: metadata.Pairs(utils.RouteDistinguisherMetadataKey, "10.0.0.1:1000",
+				utils.ImportRouteTargetsMetadataKey, "65000:1000, 65000:2000",
+				utils.ExportRouteTargetsMetadataKey, "65000:1000"),
+			vni: proto.Uint32(1000),
+			out: VrfRouteTargets{Rd: "10.0.0.1:1000", Import: []string{"65000:1000", "65000:2000"}, Export: []string{"65000:1000"}},
+		},
+		"invalid route target": {
+			md:      metadata.Pairs(utils.ImportRouteTargetsMetadataKey, "65000"),
+			vni:     proto.Uint32(1000),
+			errCode: codes.InvalidArgument,
+		},
+		"no vni": {
+			md:      metadata.Pairs(utils.RouteDistinguisherMetadataKey, "10.0.0.1:1000"),
+			errCode: codes.InvalidArgument,
+		},
+		"srv6 without vni": {
+			md:   metadata.Pairs(utils.RouteDistinguisherMetadataKey, "10.0.0.1:1000"),
+			srv6: true,
+			out:  VrfRouteTargets{Rd: "10.0.0.1:1000"},
+		},
+	}
+	for testName, tt := range tests {
+		t.Run(testName, func(t *testing.T) {
+			opi := NewServerWithArgs(mocks.NewNetlink(t), mocks.NewFrr(t), gomap.NewStore(gomap.DefaultOptions))
+			ctx := metadata.NewIncomingContext(context.Background(), tt.md)
+			obj := &pb.Vrf{Name: testVrfName, Spec: &pb.VrfSpec{Vni: tt.vni}}
+			rt, err := opi.routeTargetsFor(ctx, obj, tt.srv6)
+			if status.Code(err) != tt.errCode {
+				t.Error("error: expected", tt.errCode, "received", err)
+			}
+			if err == nil && !reflect.DeepEqual(rt, tt.out) {
+				t.Error("route targets: expected", tt.out, "received", rt)
+			}
+		})
+	}
+}
+
+func Test_frrCreateVrfRequestRouteTargets(t *testing.T) {
+	mockFrr := mocks.NewFrr(t)
+	opi := NewServerWithArgs(mocks.NewNetlink(t), mockFrr, gomap.NewStore(gomap.DefaultOptions))
+	opi.RouteTargets[testVrfName] = VrfRouteTargets{Rd: "10.0.0.1:1000", Import: []string{"65000:2000"}, Export: []string{"65000:3000"}}
+	vrf := &pb.Vrf{Name: testVrfName, Spec: &pb.VrfSpec{Vni: proto.Uint32(1000)}}
+
+	mockFrr.EXPECT().FrrZebraCmd(mock.Anything, mock.Anything).Return("", nil)
+	mockFrr.EXPECT().FrrBgpCmd(mock.Anything, mock.MatchedBy(func(command string) bool {
+		return strings.Contains(command, "rd 10.0.0.1:1000\nroute-target import 65000:2000\nroute-target export 65000:3000\n")
+	})).Return("", nil).Once()
+	if err := opi.frrCreateVrfRequest(context.Background(), &pb.CreateVrfRequest{Vrf: vrf}); err != nil {
+		t.Error("error: expected", nil, "received", err)
+	}
+
+	// the route policy is kept for the replay
+	opi.persistRouteTargets()
+	restored := NewServerWithArgs(mocks.NewNetlink(t), mocks.NewFrr(t), opi.store)
+	if err := restored.loadRouteTargets(); err != nil {
+		t.Fatal("error: expected", nil, "received", err)
+	}
+	if !reflect.DeepEqual(restored.RouteTargets, opi.RouteTargets) {
+		t.Error("route targets: expected", opi.RouteTargets, "received", restored.RouteTargets)
+	}
+}
@@ -0,0 +1,225 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"reflect"
+	"time"
+
+	"github.com/ghodss/yaml"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils"
+)
+
+// RuntimeConfig are the settings of the server that change without restarting it, read from
+// the --config file on top of the command line, the programmed objects being left untouched
+// TODO: move to opi-api once the message is agreed upon
+type RuntimeConfig struct {
+	// LogLevel is the minimum level of the call logs: debug, info, warn or error
+	LogLevel string `json:"logLevel"`
+	// FrrAddress is the host the FRR daemons are reached at
+	FrrAddress string `json:"frrAddress"`
+	// LiveRead makes Get and List check the kernel devices of the objects
+	LiveRead bool `json:"liveRead"`
+	// RejectDefaultVlan makes vlan 1 invalid for the new LogicalBridges and VrfLiteHandoffs
+	RejectDefaultVlan bool `json:"rejectDefaultVlan"`
+	// HwOffload makes the BridgePorts report whether their forwarding is offloaded
+	HwOffload bool `json:"hwOffload"`
+	// PageTokenTTL is how long the NextPageToken of a List call can be used, e.g. 1h
+	PageTokenTTL string `json:"pageTokenTtl"`
+	// Faults are injected into the netlink and FRR calls, for testing only, see
+	// EnableFaultInjection
+	Faults []utils.FaultRule `json:"faults,omitempty"`
+}
+
+// Validate checks the log level, the FRR host, the page token TTL and the faults
+func (c RuntimeConfig) Validate() error {
+	if _, err := utils.ParseLogLevel(c.LogLevel); err != nil {
+		return err
+	}
+	if c.FrrAddress == "" {
+		return fmt.Errorf("invalid FRR address, has to be a host name or an IP address")
+	}
+	if _, _, err := net.SplitHostPort(c.FrrAddress); err == nil {
+		return fmt.Errorf("invalid FRR address %q, the ports of the daemons are fixed", c.FrrAddress)
+	}
+	ttl, err := time.ParseDuration(c.PageTokenTTL)
+	if err != nil || ttl <= 0 {
+		return fmt.Errorf("invalid page token TTL %q, has to be a positive duration", c.PageTokenTTL)
+	}
+	for _, rule := range c.Faults {
+		if err := rule.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ParseRuntimeConfig parses a YAML or JSON config document, the settings it does not have
+// keeping their value in base, e.g. the one given on the command line. Unknown settings are
+// rejected, a misspelled one would silently keep its value
+func ParseRuntimeConfig(document []byte, base RuntimeConfig) (RuntimeConfig, error) {
+	data, err := yaml.YAMLToJSON(document)
+	if err != nil {
+		return base, err
+	}
+	config := base
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&config); err != nil && err != io.EOF {
+		return base, err
+	}
+	if err := config.Validate(); err != nil {
+		return base, err
+	}
+	return config, nil
+}
+
+// runtimeConfig returns the settings the server currently runs with
+func (s *Server) runtimeConfig() RuntimeConfig {
+	return RuntimeConfig{
+		LogLevel:          utils.LogLevelName(utils.CurrentLogLevel()),
+		FrrAddress:        utils.FrrAddress(),
+		LiveRead:          s.LiveRead,
+		RejectDefaultVlan: s.RejectDefaultVlan,
+		HwOffload:         s.HwOffload,
+		PageTokenTTL:      s.PageTokenTTL.String(),
+		Faults:            s.faultRules(),
+	}
+}
+
+// applyRuntimeConfig switches the server to a validated config and returns the settings that
+// changed. The calls in flight may still see the previous settings
+func (s *Server) applyRuntimeConfig(config RuntimeConfig) []string {
+	old := s.runtimeConfig()
+	var changed []string
+	if config.LogLevel != old.LogLevel {
+		level, _ := utils.ParseLogLevel(config.LogLevel)
+		utils.SetLogLevel(level)
+		changed = append(changed, "logLevel")
+	}
+	if config.FrrAddress != old.FrrAddress {
+		utils.SetFrrAddress(config.FrrAddress)
+		changed = append(changed, "frrAddress")
+	}
+	if config.LiveRead != old.LiveRead {
+		s.LiveRead = config.LiveRead
+		changed = append(changed, "liveRead")
+	}
+	if config.RejectDefaultVlan != old.RejectDefaultVlan {
+		s.RejectDefaultVlan = config.RejectDefaultVlan
+		changed = append(changed, "rejectDefaultVlan")
+	}
+	if config.HwOffload != old.HwOffload {
+		s.HwOffload = config.HwOffload
+		changed = append(changed, "hwOffload")
+	}
+	if ttl, _ := time.ParseDuration(config.PageTokenTTL); ttl != s.PageTokenTTL {
+		s.PageTokenTTL = ttl
+		changed = append(changed, "pageTokenTtl")
+	}
+	if !reflect.DeepEqual(config.Faults, old.Faults) && s.faults != nil {
+		// validated with the config, the rules cannot be rejected
+		_ = s.faults.SetRules(config.Faults)
+		changed = append(changed, "faults")
+	}
+	for _, name := range changed {
+		log.Printf("Runtime config %s changed", name)
+	}
+	return changed
+}
+
+// SetConfigFile makes ReloadConfig read the file, on top of the settings the server runs with
+// now, e.g. those of the command line. The file is applied at once when it is given
+func (s *Server) SetConfigFile(path string) error {
+	s.configFile = path
+	s.baseConfig = s.runtimeConfig()
+	if path == "" {
+		return nil
+	}
+	_, err := s.ReloadConfig(context.Background(), &ReloadConfigRequest{})
+	return err
+}
+
+// GetRuntimeConfigRequest is the request to get the settings the server runs with
+// TODO: move to opi-api once the message is agreed upon
+type GetRuntimeConfigRequest struct{}
+
+// GetRuntimeConfig returns the settings the server runs with
+func (s *Server) GetRuntimeConfig(_ context.Context, _ *GetRuntimeConfigRequest) (*RuntimeConfig, error) {
+	config := s.runtimeConfig()
+	return &config, nil
+}
+
+// ReloadConfigRequest is the request to read the config file again
+// TODO: move to opi-api once the message is agreed upon
+type ReloadConfigRequest struct{}
+
+// ReloadConfigResponse is the config the server now runs with and the settings that changed
+// TODO: move to opi-api once the message is agreed upon
+type ReloadConfigResponse struct {
+	Config  RuntimeConfig `json:"config"`
+	Changed []string      `json:"changed"`
+}
+
+// ReloadConfig reads the config file again and applies it, e.g. on SIGHUP, without restarting
+// the server. An invalid file is not applied at all, the server keeping its settings
+func (s *Server) ReloadConfig(_ context.Context, _ *ReloadConfigRequest) (*ReloadConfigResponse, error) {
+	if s.configFile == "" {
+		err := status.Error(codes.FailedPrecondition, "the server was started without a config file")
+		return nil, err
+	}
+	document, err := os.ReadFile(s.configFile)
+	if err != nil {
+		err = status.Errorf(codes.FailedPrecondition, "unable to read %s: %v", s.configFile, err)
+		return nil, err
+	}
+	config, err := ParseRuntimeConfig(document, s.baseConfig)
+	if err != nil {
+		err = status.Errorf(codes.InvalidArgument, "invalid config %s: %v", s.configFile, err)
+		return nil, err
+	}
+	if len(config.Faults) != 0 && s.faults == nil {
+		err = status.Errorf(codes.FailedPrecondition, "invalid config %s: the fault injection is disabled", s.configFile)
+		return nil, err
+	}
+	changed := s.applyRuntimeConfig(config)
+	log.Printf("Reloaded %s, %d settings changed", s.configFile, len(changed))
+	return &ReloadConfigResponse{Config: config, Changed: append([]string{}, changed...)}, nil
+}
+
+// SetLogLevelRequest is the request to change the minimum level of the call logs
+// TODO: move to opi-api once the message is agreed upon
+type SetLogLevelRequest struct {
+	// Level is debug, info, warn or error
+	Level string `json:"level"`
+}
+
+// SetLogLevel changes the minimum level of the call logs until the config file is reloaded,
+// e.g. to debug an issue without restarting the server
+func (s *Server) SetLogLevel(_ context.Context, in *SetLogLevelRequest) (*RuntimeConfig, error) {
+	if in.Level == "" {
+		return nil, missingField("level")
+	}
+	level, err := utils.ParseLogLevel(in.Level)
+	if err != nil {
+		return nil, badRequest("level", status.Error(codes.InvalidArgument, err.Error()))
+	}
+	utils.SetLogLevel(level)
+	log.Printf("Log level set to %s", in.Level)
+	config := s.runtimeConfig()
+	return &config, nil
+}
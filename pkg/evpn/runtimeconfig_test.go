@@ -0,0 +1,154 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/logging"
+	"github.com/philippgille/gokv/gomap"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils"
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils/mocks"
+)
+
+func Test_ParseRuntimeConfig(t *testing.T) {
+	base := RuntimeConfig{LogLevel: "debug", FrrAddress: "localhost", PageTokenTTL: "1h0m0s"}
+	tests := map[string]struct {
+		document string
+		out      RuntimeConfig
+		valid    bool
+	}{
+		"partial document": {
+			document: "logLevel: warn\nliveRead: true\n",
+			out:      RuntimeConfig{LogLevel: "warn", FrrAddress: "localhost", LiveRead: true, PageTokenTTL: "1h0m0s"},
+			valid:    true,
+		},
+		"empty document": {
+			document: "",
+			out:      base,
+			valid:    true,
+		},
+		"json document": {
+			document: `{"frrAddress": "10.0.0.5", "pageTokenTtl": "30m"}`,
+			out:      RuntimeConfig{LogLevel: "debug", FrrAddress: "10.0.0.5", PageTokenTTL: "30m"},
+			valid:    true,
+		},
+		"unknown setting": {
+			document: "liveReads: true\n",
+		},
+		"invalid log level": {
+			document: "logLevel: trace\n",
+		},
+		"FRR address with a port": {
+			document: "frrAddress: localhost:2605\n",
+		},
+		"invalid page token TTL": {
+			document: "pageTokenTtl: -1h\n",
+		},
+		"faults": {
+			document: "faults:\n- operation: LinkAdd\n  match: br\n  error: EEXIST\n  count: 1\n",
+			out: RuntimeConfig{LogLevel: "debug", FrrAddress: "localhost", PageTokenTTL: "1h0m0s", Faults: []utils.FaultRule{
+				{Operation: "LinkAdd", Match: "br", Error: "EEXIST", Count: 1},
+			}},
+			valid: true,
+		},
+		"fault without an error": {
+			document: "faults:\n- operation: LinkAdd\n",
+		},
+	}
+	for testName, tt := range tests {
+		t.Run(testName, func(t *testing.T) {
+			config, err := ParseRuntimeConfig([]byte(tt.document), base)
+			if (err == nil) != tt.valid {
+				t.Error("valid: expected", tt.valid, "received", err)
+			}
+			if err == nil && !reflect.DeepEqual(config, tt.out) {
+				t.Error("config: expected", tt.out, "received", config)
+			}
+		})
+	}
+}
+
+func Test_ReloadConfig(t *testing.T) {
+	defer utils.SetLogLevel(utils.CurrentLogLevel())
+	defer utils.SetFrrAddress(utils.FrrAddress())
+	utils.SetLogLevel(logging.LevelDebug)
+	opi := NewServerWithArgs(mocks.NewNetlink(t), mocks.NewFrr(t), gomap.NewStore(gomap.DefaultOptions))
+	opi.PageTokenTTL = time.Hour
+
+	// without a config file there is nothing to reload
+	if err := opi.SetConfigFile(""); err != nil {
+		t.Fatal("error: expected", nil, "received", err)
+	}
+	_, err := opi.ReloadConfig(context.Background(), &ReloadConfigRequest{})
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Error("error: expected", codes.FailedPrecondition, "received", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("logLevel: info\nliveRead: true\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := opi.SetConfigFile(path); err != nil {
+		t.Fatal("error: expected", nil, "received", err)
+	}
+	if !opi.LiveRead || utils.CurrentLogLevel() != logging.LevelInfo {
+		t.Error("config: expected the file applied, received", opi.runtimeConfig())
+	}
+
+	// the settings removed from the file are back to those of the command line
+	if err := os.WriteFile(path, []byte("frrAddress: frr.example.com\nrejectDefaultVlan: true\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	response, err := opi.ReloadConfig(context.Background(), &ReloadConfigRequest{})
+	if err != nil {
+		t.Fatal("error: expected", nil, "received", err)
+	}
+	expected := []string{"logLevel", "frrAddress", "liveRead", "rejectDefaultVlan"}
+	if !reflect.DeepEqual(response.Changed, expected) {
+		t.Error("changed: expected", expected, "received", response.Changed)
+	}
+	if opi.LiveRead || !opi.RejectDefaultVlan || utils.FrrAddress() != "frr.example.com" || utils.CurrentLogLevel() != logging.LevelDebug {
+		t.Error("config: expected the file applied, received", opi.runtimeConfig())
+	}
+
+	// an invalid file is not applied at all
+	if err := os.WriteFile(path, []byte("rejectDefaultVlan: false\nlogLevel: trace\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	_, err = opi.ReloadConfig(context.Background(), &ReloadConfigRequest{})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Error("error: expected", codes.InvalidArgument, "received", err)
+	}
+	if !opi.RejectDefaultVlan {
+		t.Error("config: expected the previous one kept, received", opi.runtimeConfig())
+	}
+}
+
+func Test_SetLogLevel(t *testing.T) {
+	defer utils.SetLogLevel(utils.CurrentLogLevel())
+	opi := NewServerWithArgs(mocks.NewNetlink(t), mocks.NewFrr(t), gomap.NewStore(gomap.DefaultOptions))
+	config, err := opi.SetLogLevel(context.Background(), &SetLogLevelRequest{Level: "error"})
+	if err != nil || config.LogLevel != "error" || utils.CurrentLogLevel() != logging.LevelError {
+		t.Error("log level: expected error, received", config, err)
+	}
+	_, err = opi.SetLogLevel(context.Background(), &SetLogLevelRequest{Level: "verbose"})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Error("error: expected", codes.InvalidArgument, "received", err)
+	}
+	_, err = opi.SetLogLevel(context.Background(), &SetLogLevelRequest{})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Error("error: expected", codes.InvalidArgument, "received", err)
+	}
+}
@@ -0,0 +1,339 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+
+	"go.einride.tech/aip/resourceid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// SecurityAction is what happens to a new connection, in nftables syntax
+type SecurityAction string
+
+const (
+	// SecurityActionAllow lets the connection through, its replies included
+	SecurityActionAllow SecurityAction = "accept"
+	// SecurityActionDeny drops the packets of the connection
+	SecurityActionDeny SecurityAction = "drop"
+)
+
+// SecurityProtocol is the transport protocol of a connection, in nftables syntax
+type SecurityProtocol string
+
+const (
+	// SecurityProtocolTCP matches the TCP connections
+	SecurityProtocolTCP SecurityProtocol = "tcp"
+	// SecurityProtocolUDP matches the UDP flows
+	SecurityProtocolUDP SecurityProtocol = "udp"
+)
+
+// SecurityPolicy is the stateful firewall of the traffic routed between the Svis of a Vrf,
+// child resource of a Vrf, at most one per Vrf, e.g. at the boundary between the segments
+// of a tenant. The traffic from or to the other interfaces of the Vrf is not filtered
+// TODO: move to opi-api once the message is agreed upon
+type SecurityPolicy struct {
+	Name string
+	Spec *SecurityPolicySpec
+}
+
+// SecurityPolicySpec is the desired configuration of a SecurityPolicy
+type SecurityPolicySpec struct {
+	// DefaultAction applies to the new connections no rule matches
+	DefaultAction SecurityAction
+	// Rules are evaluated in order, the first matching one applies
+	Rules []*SecurityRule
+}
+
+// SecurityRule matches new connections between the Svis of the Vrf, the replies of an allowed
+// connection are always let through
+type SecurityRule struct {
+	// SourceSvi is the name of the Svi the connection comes from, any Svi of the Vrf when empty
+	SourceSvi string
+	// DestinationSvi is the name of the Svi the connection goes to, any Svi of the Vrf when empty
+	DestinationSvi string
+	// Protocol is the transport protocol of the connection, any protocol when empty
+	Protocol SecurityProtocol
+	// DestinationPorts are the ports of the connection, any port when empty, they require Protocol
+	DestinationPorts []uint32
+	// Action applies to the new connections the rule matches
+	Action SecurityAction
+}
+
+// CreateSecurityPolicyRequest is the request to create the SecurityPolicy of a Vrf
+type CreateSecurityPolicyRequest struct {
+	// Parent is the name of the Vrf
+	Parent           string
+	SecurityPolicyID string
+	SecurityPolicy   *SecurityPolicy
+}
+
+// UpdateSecurityPolicyRequest is the request to replace the rules of a SecurityPolicy
+type UpdateSecurityPolicyRequest struct {
+	SecurityPolicy *SecurityPolicy
+}
+
+// DeleteSecurityPolicyRequest is the request to delete a SecurityPolicy
+type DeleteSecurityPolicyRequest struct {
+	Name         string
+	AllowMissing bool
+}
+
+// ListSecurityPoliciesRequest is the request to list the SecurityPolicies of a Vrf
+type ListSecurityPoliciesRequest struct {
+	// Parent is the name of the Vrf
+	Parent    string
+	PageSize  int32
+	PageToken string
+}
+
+// ListSecurityPoliciesResponse is the response of listing SecurityPolicies
+type ListSecurityPoliciesResponse struct {
+	SecurityPolicies []*SecurityPolicy
+	NextPageToken    string
+}
+
+func (p *SecurityPolicy) clone() *SecurityPolicy {
+	if p == nil {
+		return nil
+	}
+	c := &SecurityPolicy{Name: p.Name}
+	if p.Spec != nil {
+		spec := *p.Spec
+		spec.Rules = nil
+		for _, rule := range p.Spec.Rules {
+			r := *rule
+			r.DestinationPorts = append([]uint32(nil), rule.DestinationPorts...)
+			spec.Rules = append(spec.Rules, &r)
+		}
+		c.Spec = &spec
+	}
+	return c
+}
+
+func sortSecurityPolicies(policies []*SecurityPolicy) {
+	sort.Slice(policies, func(i int, j int) bool {
+		return policies[i].Name < policies[j].Name
+	})
+}
+
+func securityPolicyParent(name string) string {
+	// path.Dir would collapse the leading "//" of the full resource name
+	return name[:strings.LastIndex(name, "/securitypolicies/")]
+}
+
+// vrfSvis returns the Svis of the Vrf, sorted by name
+func (s *Server) vrfSvis(vrf string) []*pb.Svi {
+	var svis []*pb.Svi
+	for _, name := range s.indexes.sviVrf.lookup(s.Svis, vrf) {
+		svis = append(svis, s.Svis[name])
+	}
+	return svis
+}
+
+// checkSecurityRules checks the Svis of the rules exist and are in the Vrf of the policy
+func (s *Server) checkSecurityRules(ctx context.Context, obj *SecurityPolicy, vrf string) error {
+	for _, rule := range obj.Spec.Rules {
+		for _, name := range []string{rule.SourceSvi, rule.DestinationSvi} {
+			if name == "" {
+				continue
+			}
+			svi, ok := s.Svis[name]
+			if !ok || !inTenant(ctx, name) {
+				err := status.Errorf(codes.NotFound, "unable to find key %s", name)
+				return err
+			}
+			if svi.Spec.Vrf != vrf {
+				err := status.Errorf(codes.FailedPrecondition, "Svi %s is not in %s", name, vrf)
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// refreshSecurityPolicy applies the SecurityPolicy of a Vrf again once one of its Svis was
+// created or deleted, the policy only knows the Svis it was applied with. The rules of a
+// deleted Svi no longer match
+func (s *Server) refreshSecurityPolicy(ctx context.Context, vrf *pb.Vrf) {
+	for _, obj := range s.Policies {
+		if securityPolicyParent(obj.Name) != vrf.Name {
+			continue
+		}
+		if err := s.dataplane.CreateSecurityPolicy(ctx, obj, vrf, s.vrfSvis(vrf.Name)); err != nil {
+			log.Printf("Failed to apply SecurityPolicy %v again: %v", obj.Name, err)
+		}
+	}
+}
+
+// CreateSecurityPolicy executes the creation of the firewall of a Vrf
+func (s *Server) CreateSecurityPolicy(ctx context.Context, in *CreateSecurityPolicyRequest) (*SecurityPolicy, error) {
+	// check input correctness
+	if err := s.validateCreateSecurityPolicyRequest(in); err != nil {
+		return nil, err
+	}
+	// see https://google.aip.dev/133#user-specified-ids
+	resourceID := resourceid.NewSystemGenerated()
+	if in.SecurityPolicyID != "" {
+		log.Printf("client provided the ID of a resource %v, ignoring the name field %v", in.SecurityPolicyID, in.SecurityPolicy.Name)
+		resourceID = in.SecurityPolicyID
+	}
+	in.SecurityPolicy.Name = fmt.Sprintf("%s/securitypolicies/%s", in.Parent, resourceID)
+	s.objectsMu.Lock()
+	defer s.objectsMu.Unlock()
+	// idempotent API when called with same key, should return same object
+	obj, ok := s.Policies[in.SecurityPolicy.Name]
+	if ok && inTenant(ctx, in.Parent) {
+		// a different spec under the same key is a conflict, not a retry
+		if err := checkSameChildSpec(obj.Name, obj.Spec, in.SecurityPolicy.Spec); err != nil {
+			return nil, err
+		}
+		log.Printf("Already existing SecurityPolicy with id %v", in.SecurityPolicy.Name)
+		return obj.clone(), nil
+	}
+	// now get Vrf to plug the policy into
+	vrf, ok := s.Vrfs[in.Parent]
+	if !ok || !inTenant(ctx, in.Parent) {
+		err := status.Errorf(codes.NotFound, "unable to find key %s", in.Parent)
+		return nil, err
+	}
+	// the traffic of a Vrf goes through a single forward chain
+	for _, policy := range s.Policies {
+		if securityPolicyParent(policy.Name) == in.Parent {
+			err := status.Errorf(codes.AlreadyExists, "%s already has SecurityPolicy %s", in.Parent, policy.Name)
+			return nil, err
+		}
+	}
+	if err := s.checkSecurityRules(ctx, in.SecurityPolicy, in.Parent); err != nil {
+		return nil, err
+	}
+	if err := s.dataplane.CreateSecurityPolicy(ctx, in.SecurityPolicy, vrf, s.vrfSvis(vrf.Name)); err != nil {
+		s.forgetStatus(in.SecurityPolicy.Name)
+		return nil, status.Convert(err).Err()
+	}
+	// save object to the database
+	response := in.SecurityPolicy.clone()
+	s.Policies[in.SecurityPolicy.Name] = response
+	persistObjects(s, "securitypolicies", s.Policies)
+	return response.clone(), nil
+}
+
+// UpdateSecurityPolicy replaces the default action and the rules of a firewall, the new
+// ruleset is applied at once and the established connections are kept
+func (s *Server) UpdateSecurityPolicy(ctx context.Context, in *UpdateSecurityPolicyRequest) (*SecurityPolicy, error) {
+	// check input correctness
+	if err := s.validateUpdateSecurityPolicyRequest(in); err != nil {
+		return nil, err
+	}
+	s.objectsMu.Lock()
+	defer s.objectsMu.Unlock()
+	// fetch object from the database
+	old, ok := s.Policies[in.SecurityPolicy.Name]
+	if !ok || !inTenant(ctx, in.SecurityPolicy.Name) {
+		err := status.Errorf(codes.NotFound, "unable to find key %s", in.SecurityPolicy.Name)
+		return nil, err
+	}
+	parent := securityPolicyParent(old.Name)
+	vrf, ok := s.Vrfs[parent]
+	if !ok {
+		err := status.Errorf(codes.NotFound, "unable to find key %s", parent)
+		return nil, err
+	}
+	if err := s.checkSecurityRules(ctx, in.SecurityPolicy, parent); err != nil {
+		return nil, err
+	}
+	if err := s.dataplane.CreateSecurityPolicy(ctx, in.SecurityPolicy, vrf, s.vrfSvis(vrf.Name)); err != nil {
+		return nil, status.Convert(err).Err()
+	}
+	// save object to the database
+	response := in.SecurityPolicy.clone()
+	s.Policies[in.SecurityPolicy.Name] = response
+	persistObjects(s, "securitypolicies", s.Policies)
+	return response.clone(), nil
+}
+
+// DeleteSecurityPolicy deletes the firewall of a Vrf, its traffic is no longer filtered
+func (s *Server) DeleteSecurityPolicy(ctx context.Context, in *DeleteSecurityPolicyRequest) (*emptypb.Empty, error) {
+	// check input correctness
+	if err := s.validateDeleteSecurityPolicyRequest(in); err != nil {
+		return nil, err
+	}
+	s.objectsMu.Lock()
+	defer s.objectsMu.Unlock()
+	return s.deleteSecurityPolicy(ctx, in)
+}
+
+// deleteSecurityPolicy deletes a validated firewall, with objectsMu held
+func (s *Server) deleteSecurityPolicy(ctx context.Context, in *DeleteSecurityPolicyRequest) (*emptypb.Empty, error) {
+	// fetch object from the database
+	obj, ok := s.Policies[in.Name]
+	if !ok || !inTenant(ctx, in.Name) {
+		if in.AllowMissing {
+			return &emptypb.Empty{}, nil
+		}
+		err := status.Errorf(codes.NotFound, "unable to find key %s", in.Name)
+		return nil, err
+	}
+	// fetch object from the database
+	parent := securityPolicyParent(obj.Name)
+	vrf, ok := s.Vrfs[parent]
+	if !ok {
+		err := status.Errorf(codes.NotFound, "unable to find key %s", parent)
+		return nil, err
+	}
+	if err := s.dataplane.DeleteSecurityPolicy(ctx, obj, vrf); err != nil {
+		return nil, status.Convert(err).Err()
+	}
+	// remove from the Database
+	delete(s.Policies, obj.Name)
+	persistObjects(s, "securitypolicies", s.Policies)
+	s.forgetStatus(obj.Name)
+	return &emptypb.Empty{}, nil
+}
+
+// ListSecurityPolicies lists the firewall of a Vrf
+func (s *Server) ListSecurityPolicies(ctx context.Context, in *ListSecurityPoliciesRequest) (*ListSecurityPoliciesResponse, error) {
+	// check input correctness
+	if err := s.validateListSecurityPoliciesRequest(in); err != nil {
+		return nil, err
+	}
+	if !inTenant(ctx, in.Parent) {
+		err := status.Errorf(codes.NotFound, "unable to find key %s", in.Parent)
+		return nil, err
+	}
+	// fetch pagination from the database, calculate size and offset
+	size, offset, perr := s.extractPagination(in.PageSize, in.PageToken)
+	if perr != nil {
+		return nil, perr
+	}
+	s.objectsMu.RLock()
+	defer s.objectsMu.RUnlock()
+	// fetch object from the database, the following pages are cut from the snapshot of the first one
+	Blobarray, token, err := listPage(ctx, s, "securityPolicies", in.Parent, in.PageToken, offset, size, func() []*SecurityPolicy {
+		Blobarray := []*SecurityPolicy{}
+		for _, policy := range s.Policies {
+			if securityPolicyParent(policy.Name) != in.Parent {
+				continue
+			}
+			Blobarray = append(Blobarray, policy.clone())
+		}
+		// sort is needed, since MAP is unsorted in golang, and we might get different results
+		sortSecurityPolicies(Blobarray)
+		return Blobarray
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &ListSecurityPoliciesResponse{SecurityPolicies: Blobarray, NextPageToken: token}, nil
+}
@@ -0,0 +1,120 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+)
+
+// securityPolicyTable is the nftables table of the SecurityPolicy of a Vrf
+func securityPolicyTable(vrfName string) string {
+	return "opi-" + vrfName
+}
+
+// sviKernelName is the name of the vlan device of the Svi, empty when its LogicalBridge is gone
+func (s *Server) sviKernelName(svi *pb.Svi) string {
+	bridge, ok := s.Bridges[svi.Spec.LogicalBridge]
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf("vlan%d", bridge.Spec.VlanId)
+}
+
+// nftSecurityPolicyScript renders the policy as a forward chain of the Vrf, replacing the
+// previous one in the same transaction. The routed packets enter the forward hook on the
+// Vrf device, the Svi they came from is their slave device (sdif)
+func (s *Server) nftSecurityPolicyScript(obj *SecurityPolicy, vrf *pb.Vrf, svis []*pb.Svi) string {
+	vrfName := s.vrfKernelName(vrf.Name)
+	table := securityPolicyTable(vrfName)
+	devices := map[string]string{}
+	var quoted []string
+	for _, svi := range svis {
+		if name := s.sviKernelName(svi); name != "" {
+			devices[svi.Name] = name
+			quoted = append(quoted, fmt.Sprintf("%q", name))
+		}
+	}
+	var b strings.Builder
+	// create the table first, so it can always be deleted
+	fmt.Fprintf(&b, "table inet %s\n", table)
+	fmt.Fprintf(&b, "delete table inet %s\n", table)
+	fmt.Fprintf(&b, "table inet %s {\n", table)
+	b.WriteString("\tchain forward {\n")
+	b.WriteString("\t\ttype filter hook forward priority 0; policy accept;\n")
+	if len(quoted) > 0 {
+		set := strings.Join(quoted, ", ")
+		fmt.Fprintf(&b, "\t\tiifname != %q return\n", vrfName)
+		fmt.Fprintf(&b, "\t\tmeta sdifname != { %s } return\n", set)
+		fmt.Fprintf(&b, "\t\toifname != { %s } return\n", set)
+		b.WriteString("\t\tct state established,related accept\n")
+		b.WriteString("\t\tct state invalid drop\n")
+		for _, rule := range obj.Spec.Rules {
+			match, ok := nftSecurityRuleMatch(rule, devices)
+			if !ok {
+				// one of its Svis was deleted, the rule no longer matches anything
+				continue
+			}
+			fmt.Fprintf(&b, "\t\t%s%s\n", match, rule.Action)
+		}
+		fmt.Fprintf(&b, "\t\t%s\n", obj.Spec.DefaultAction)
+	}
+	b.WriteString("\t}\n")
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// nftSecurityRuleMatch renders the match of a rule, false when one of its Svis has no device
+func nftSecurityRuleMatch(rule *SecurityRule, devices map[string]string) (string, bool) {
+	var b strings.Builder
+	if rule.SourceSvi != "" {
+		name, ok := devices[rule.SourceSvi]
+		if !ok {
+			return "", false
+		}
+		fmt.Fprintf(&b, "meta sdifname %q ", name)
+	}
+	if rule.DestinationSvi != "" {
+		name, ok := devices[rule.DestinationSvi]
+		if !ok {
+			return "", false
+		}
+		fmt.Fprintf(&b, "oifname %q ", name)
+	}
+	switch {
+	case len(rule.DestinationPorts) > 0:
+		ports := make([]string, 0, len(rule.DestinationPorts))
+		for _, port := range rule.DestinationPorts {
+			ports = append(ports, fmt.Sprint(port))
+		}
+		fmt.Fprintf(&b, "%s dport { %s } ", rule.Protocol, strings.Join(ports, ", "))
+	case rule.Protocol != "":
+		fmt.Fprintf(&b, "meta l4proto %s ", rule.Protocol)
+	}
+	return b.String(), true
+}
+
+func (s *Server) nftCreateSecurityPolicy(ctx context.Context, obj *SecurityPolicy, vrf *pb.Vrf, svis []*pb.Svi) error {
+	if err := s.nft.NftApply(ctx, s.nftSecurityPolicyScript(obj, vrf, svis)); err != nil {
+		log.Printf("Failed to apply SecurityPolicy %v: %s", obj.Name, err)
+		return err
+	}
+	return nil
+}
+
+func (s *Server) nftDeleteSecurityPolicy(ctx context.Context, obj *SecurityPolicy, vrf *pb.Vrf) error {
+	table := securityPolicyTable(s.vrfKernelName(vrf.Name))
+	// create the table first, so the deletion is idempotent
+	script := fmt.Sprintf("table inet %s\ndelete table inet %s\n", table, table)
+	if err := s.nft.NftApply(ctx, script); err != nil {
+		log.Printf("Failed to delete SecurityPolicy %v: %s", obj.Name, err)
+		return err
+	}
+	return nil
+}
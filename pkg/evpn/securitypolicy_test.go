@@ -0,0 +1,307 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/philippgille/gokv/gomap"
+	"github.com/stretchr/testify/mock"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils/mocks"
+)
+
+var (
+	testSecurityPolicyID   = "opi-policy8"
+	testSecurityPolicyName = fmt.Sprintf("%s/securitypolicies/%s", testVrfName, testSecurityPolicyID)
+	testOtherSviName       = resourceIDToFullName("svis", "opi-svi9")
+	testSecurityPolicy     = SecurityPolicy{
+		Spec: &SecurityPolicySpec{
+			DefaultAction: SecurityActionDeny,
+			Rules: []*SecurityRule{
+				{SourceSvi: testSviName, DestinationSvi: testOtherSviName, Protocol: SecurityProtocolTCP, DestinationPorts: []uint32{22, 443}, Action: SecurityActionAllow},
+				{Protocol: SecurityProtocolUDP, Action: SecurityActionAllow},
+			},
+		},
+	}
+	testSecurityPolicyWithName = SecurityPolicy{
+		Name: testSecurityPolicyName,
+		Spec: testSecurityPolicy.Spec,
+	}
+	testSecurityPolicyScript = `table inet opi-opi-vrf8
+delete table inet opi-opi-vrf8
+table inet opi-opi-vrf8 {
+	chain forward {
+		type filter hook forward priority 0; policy accept;
+		iifname != "opi-vrf8" return
+		meta sdifname != { "vlan22", "vlan23" } return
+		oifname != { "vlan22", "vlan23" } return
+		ct state established,related accept
+		ct state invalid drop
+		meta sdifname "vlan22" oifname "vlan23" tcp dport { 22, 443 } accept
+		meta l4proto udp accept
+		drop
+	}
+}
+`
+)
+
+// newSecurityPolicyServer returns a server with a Vrf and two Svis in it
+func newSecurityPolicyServer(t *testing.T, mockNftables *mocks.Nftables) *Server {
+	opi := NewServerWithArgs(mocks.NewNetlink(t), mocks.NewFrr(t), gomap.NewStore(gomap.DefaultOptions))
+	opi.nft = mockNftables
+	opi.Vrfs[testVrfName] = protoClone(&testVrfWithStatus)
+	opi.Bridges[testLogicalBridgeName] = protoClone(&testLogicalBridgeWithStatus)
+	opi.Svis[testSviName] = protoClone(&testSviWithStatus)
+	other := resourceIDToFullName("bridges", "opi-bridge23")
+	opi.Bridges[other] = &pb.LogicalBridge{Name: other, Spec: &pb.LogicalBridgeSpec{VlanId: 23}}
+	opi.Svis[testOtherSviName] = &pb.Svi{Name: testOtherSviName, Spec: &pb.SviSpec{Vrf: testVrfName, LogicalBridge: other}}
+	return opi
+}
+
+func Test_CreateSecurityPolicy(t *testing.T) {
+	tests := map[string]struct {
+		id      string
+		in      *SecurityPolicy
+		out     *SecurityPolicy
+		errCode codes.Code
+		errMsg  string
+		exist   bool
+		on      func(mockNftables *mocks.Nftables, errMsg string)
+	}{
+		"no required default_action field": {
+			id:      testSecurityPolicyID,
+			in:      &SecurityPolicy{Spec: &SecurityPolicySpec{}},
+			out:     nil,
+			errCode: codes.InvalidArgument,
+			errMsg:  "missing required field: security_policy.spec.default_action",
+			exist:   false,
+			on:      nil,
+		},
+		"ports without protocol": {
+			id:      testSecurityPolicyID,
+			in:      &SecurityPolicy{Spec: &SecurityPolicySpec{DefaultAction: SecurityActionDeny, Rules: []*SecurityRule{{DestinationPorts: []uint32{22}, Action: SecurityActionAllow}}}},
+			out:     nil,
+			errCode: codes.InvalidArgument,
+			errMsg:  "destination_ports require a protocol",
+			exist:   false,
+			on:      nil,
+		},
+		"illegal action": {
+			id:      testSecurityPolicyID,
+			in:      &SecurityPolicy{Spec: &SecurityPolicySpec{DefaultAction: SecurityActionDeny, Rules: []*SecurityRule{{Action: "reject"}}}},
+			out:     nil,
+			errCode: codes.InvalidArgument,
+			errMsg:  `action "reject" have to be accept or drop`,
+			exist:   false,
+			on:      nil,
+		},
+		"Svi of another Vrf": {
+			id:      testSecurityPolicyID,
+			in:      &SecurityPolicy{Spec: &SecurityPolicySpec{DefaultAction: SecurityActionDeny, Rules: []*SecurityRule{{SourceSvi: resourceIDToFullName("svis", "other"), Action: SecurityActionAllow}}}},
+			out:     nil,
+			errCode: codes.FailedPrecondition,
+			errMsg:  fmt.Sprintf("Svi %v is not in %v", resourceIDToFullName("svis", "other"), testVrfName),
+			exist:   false,
+			on:      nil,
+		},
+		"unknown Svi": {
+			id:      testSecurityPolicyID,
+			in:      &SecurityPolicy{Spec: &SecurityPolicySpec{DefaultAction: SecurityActionDeny, Rules: []*SecurityRule{{DestinationSvi: resourceIDToFullName("svis", "unknown"), Action: SecurityActionAllow}}}},
+			out:     nil,
+			errCode: codes.NotFound,
+			errMsg:  fmt.Sprintf("unable to find key %v", resourceIDToFullName("svis", "unknown")),
+			exist:   false,
+			on:      nil,
+		},
+		"already exists": {
+			id:      testSecurityPolicyID,
+			in:      &testSecurityPolicy,
+			out:     &testSecurityPolicyWithName,
+			errCode: codes.OK,
+			errMsg:  "",
+			exist:   true,
+			on:      nil,
+		},
+		"already exists with a different spec": {
+			id:      testSecurityPolicyID,
+			in:      &SecurityPolicy{Spec: &SecurityPolicySpec{DefaultAction: SecurityActionAllow}},
+			out:     nil,
+			errCode: codes.AlreadyExists,
+			errMsg:  fmt.Sprintf("%s already exists with a different spec", testSecurityPolicyName),
+			exist:   true,
+			on:      nil,
+		},
+		"second policy of the Vrf": {
+			id:      "opi-policy9",
+			in:      &testSecurityPolicy,
+			out:     nil,
+			errCode: codes.AlreadyExists,
+			errMsg:  fmt.Sprintf("%v already has SecurityPolicy %v", testVrfName, testSecurityPolicyName),
+			exist:   true,
+			on:      nil,
+		},
+		"failed NftApply call": {
+			id:      testSecurityPolicyID,
+			in:      &testSecurityPolicy,
+			out:     nil,
+			errCode: codes.Unknown,
+			errMsg:  "Failed to call NftApply",
+			exist:   false,
+			on: func(mockNftables *mocks.Nftables, errMsg string) {
+				mockNftables.EXPECT().NftApply(mock.Anything, testSecurityPolicyScript).Return(errors.New(errMsg)).Once()
+			},
+		},
+		"successful call": {
+			id:      testSecurityPolicyID,
+			in:      &testSecurityPolicy,
+			out:     &testSecurityPolicyWithName,
+			errCode: codes.OK,
+			errMsg:  "",
+			exist:   false,
+			on: func(mockNftables *mocks.Nftables, errMsg string) {
+				mockNftables.EXPECT().NftApply(mock.Anything, testSecurityPolicyScript).Return(nil).Once()
+			},
+		},
+	}
+
+	// run tests
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			mockNftables := mocks.NewNftables(t)
+			opi := newSecurityPolicyServer(t, mockNftables)
+			other := resourceIDToFullName("svis", "other")
+			opi.Svis[other] = &pb.Svi{Name: other, Spec: &pb.SviSpec{Vrf: resourceIDToFullName("vrfs", "other")}}
+			if tt.exist {
+				opi.Policies[testSecurityPolicyName] = testSecurityPolicyWithName.clone()
+			}
+			if tt.on != nil {
+				tt.on(mockNftables, tt.errMsg)
+			}
+
+			request := &CreateSecurityPolicyRequest{Parent: testVrfName, SecurityPolicy: tt.in.clone(), SecurityPolicyID: tt.id}
+			response, err := opi.CreateSecurityPolicy(ctx, request)
+			if !reflect.DeepEqual(tt.out, response) {
+				t.Error("response: expected", tt.out, "received", response)
+			}
+
+			if er, ok := status.FromError(err); ok {
+				if er.Code() != tt.errCode {
+					t.Error("error code: expected", tt.errCode, "received", er.Code())
+				}
+				if er.Message() != tt.errMsg {
+					t.Error("error message: expected", tt.errMsg, "received", er.Message())
+				}
+			} else {
+				t.Error("expected grpc error status")
+			}
+		})
+	}
+}
+
+func Test_DeleteSecurityPolicy(t *testing.T) {
+	tests := map[string]struct {
+		in      string
+		out     *emptypb.Empty
+		errCode codes.Code
+		errMsg  string
+		missing bool
+		on      func(mockNftables *mocks.Nftables, errMsg string)
+	}{
+		"valid request with unknown key": {
+			in:      "unknown-id",
+			out:     nil,
+			errCode: codes.NotFound,
+			errMsg:  fmt.Sprintf("unable to find key %v", fmt.Sprintf("%s/securitypolicies/%s", testVrfName, "unknown-id")),
+			missing: false,
+			on:      nil,
+		},
+		"unknown key with missing allowed": {
+			in:      "unknown-id",
+			out:     &emptypb.Empty{},
+			errCode: codes.OK,
+			errMsg:  "",
+			missing: true,
+			on:      nil,
+		},
+		"failed NftApply call": {
+			in:      testSecurityPolicyID,
+			out:     nil,
+			errCode: codes.Unknown,
+			errMsg:  "Failed to call NftApply",
+			missing: false,
+			on: func(mockNftables *mocks.Nftables, errMsg string) {
+				mockNftables.EXPECT().NftApply(mock.Anything, "table inet opi-opi-vrf8\ndelete table inet opi-opi-vrf8\n").Return(errors.New(errMsg)).Once()
+			},
+		},
+		"successful call": {
+			in:      testSecurityPolicyID,
+			out:     &emptypb.Empty{},
+			errCode: codes.OK,
+			errMsg:  "",
+			missing: false,
+			on: func(mockNftables *mocks.Nftables, errMsg string) {
+				mockNftables.EXPECT().NftApply(mock.Anything, "table inet opi-opi-vrf8\ndelete table inet opi-opi-vrf8\n").Return(nil).Once()
+			},
+		},
+	}
+
+	// run tests
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			mockNftables := mocks.NewNftables(t)
+			opi := newSecurityPolicyServer(t, mockNftables)
+			opi.Policies[testSecurityPolicyName] = testSecurityPolicyWithName.clone()
+			if tt.on != nil {
+				tt.on(mockNftables, tt.errMsg)
+			}
+
+			request := &DeleteSecurityPolicyRequest{Name: fmt.Sprintf("%s/securitypolicies/%s", testVrfName, tt.in), AllowMissing: tt.missing}
+			response, err := opi.DeleteSecurityPolicy(ctx, request)
+
+			if er, ok := status.FromError(err); ok {
+				if er.Code() != tt.errCode {
+					t.Error("error code: expected", tt.errCode, "received", er.Code())
+				}
+				if er.Message() != tt.errMsg {
+					t.Error("error message: expected", tt.errMsg, "received", er.Message())
+				}
+			} else {
+				t.Error("expected grpc error status")
+			}
+
+			if reflect.TypeOf(response) != reflect.TypeOf(tt.out) {
+				t.Error("response: expected", reflect.TypeOf(tt.out), "received", reflect.TypeOf(response))
+			}
+		})
+	}
+}
+
+func Test_refreshSecurityPolicy(t *testing.T) {
+	mockNftables := mocks.NewNftables(t)
+	opi := newSecurityPolicyServer(t, mockNftables)
+	opi.Policies[testSecurityPolicyName] = testSecurityPolicyWithName.clone()
+	// the rule of the deleted Svi is dropped, the remaining Svi is still filtered
+	delete(opi.Svis, testOtherSviName)
+	mockNftables.EXPECT().NftApply(mock.Anything, mock.MatchedBy(func(script string) bool {
+		return strings.Contains(script, `meta sdifname != { "vlan22" } return`) &&
+			!strings.Contains(script, "dport") && strings.Contains(script, "meta l4proto udp accept")
+	})).Return(nil).Once()
+
+	opi.refreshSecurityPolicy(context.Background(), opi.Vrfs[testVrfName])
+}
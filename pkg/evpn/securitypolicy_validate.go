@@ -0,0 +1,129 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"fmt"
+
+	"go.einride.tech/aip/resourcename"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func validSecurityAction(action SecurityAction) bool {
+	return action == SecurityActionAllow || action == SecurityActionDeny
+}
+
+// validateSecurityPolicySpec checks the actions, protocols and ports of the rules
+func validateSecurityPolicySpec(spec *SecurityPolicySpec) error {
+	if !validSecurityAction(spec.DefaultAction) {
+		msg := fmt.Sprintf("default_action %q have to be %s or %s", spec.DefaultAction, SecurityActionAllow, SecurityActionDeny)
+		return badRequest("security_policy.spec.default_action", status.Error(codes.InvalidArgument, msg))
+	}
+	for i, rule := range spec.Rules {
+		field := fmt.Sprintf("security_policy.spec.rules[%d]", i)
+		if rule == nil {
+			return missingField(field)
+		}
+		if !validSecurityAction(rule.Action) {
+			msg := fmt.Sprintf("action %q have to be %s or %s", rule.Action, SecurityActionAllow, SecurityActionDeny)
+			return badRequest(field+".action", status.Error(codes.InvalidArgument, msg))
+		}
+		switch rule.Protocol {
+		case "", SecurityProtocolTCP, SecurityProtocolUDP:
+		default:
+			msg := fmt.Sprintf("protocol %q have to be %s or %s", rule.Protocol, SecurityProtocolTCP, SecurityProtocolUDP)
+			return badRequest(field+".protocol", status.Error(codes.InvalidArgument, msg))
+		}
+		if len(rule.DestinationPorts) > 0 && rule.Protocol == "" {
+			msg := "destination_ports require a protocol"
+			return badRequest(field+".destination_ports", status.Error(codes.InvalidArgument, msg))
+		}
+		for _, port := range rule.DestinationPorts {
+			if port == 0 || port > 65535 {
+				msg := fmt.Sprintf("Port (%d) have to be between 1 and 65535", port)
+				return badRequest(field+".destination_ports", status.Error(codes.InvalidArgument, msg))
+			}
+		}
+		// Validate that an Svi resource name conforms to the restrictions outlined in AIP-122.
+		if rule.SourceSvi != "" {
+			if err := badRequest(field+".source_svi", resourcename.Validate(rule.SourceSvi)); err != nil {
+				return err
+			}
+		}
+		if rule.DestinationSvi != "" {
+			if err := badRequest(field+".destination_svi", resourcename.Validate(rule.DestinationSvi)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *Server) validateCreateSecurityPolicyRequest(in *CreateSecurityPolicyRequest) error {
+	// check required fields
+	switch {
+	case in.Parent == "":
+		return missingField("parent")
+	case in.SecurityPolicy == nil:
+		return missingField("security_policy")
+	case in.SecurityPolicy.Spec == nil:
+		return missingField("security_policy.spec")
+	case in.SecurityPolicy.Spec.DefaultAction == "":
+		return missingField("security_policy.spec.default_action")
+	}
+	if err := validateSecurityPolicySpec(in.SecurityPolicy.Spec); err != nil {
+		return err
+	}
+	// Validate that a Vrf resource name conforms to the restrictions outlined in AIP-122.
+	if err := badRequest("parent", resourcename.Validate(in.Parent)); err != nil {
+		return err
+	}
+	// see https://google.aip.dev/133#user-specified-ids
+	if in.SecurityPolicyID != "" {
+		if err := badRequest("security_policy_id", validateResourceID(in.SecurityPolicyID, false)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Server) validateUpdateSecurityPolicyRequest(in *UpdateSecurityPolicyRequest) error {
+	// check required fields
+	switch {
+	case in.SecurityPolicy == nil:
+		return missingField("security_policy")
+	case in.SecurityPolicy.Name == "":
+		return missingField("security_policy.name")
+	case in.SecurityPolicy.Spec == nil:
+		return missingField("security_policy.spec")
+	case in.SecurityPolicy.Spec.DefaultAction == "":
+		return missingField("security_policy.spec.default_action")
+	}
+	if err := validateSecurityPolicySpec(in.SecurityPolicy.Spec); err != nil {
+		return err
+	}
+	// Validate that a resource name conforms to the restrictions outlined in AIP-122.
+	return badRequest("security_policy.name", resourcename.Validate(in.SecurityPolicy.Name))
+}
+
+func (s *Server) validateDeleteSecurityPolicyRequest(in *DeleteSecurityPolicyRequest) error {
+	// check required fields
+	if in.Name == "" {
+		return missingField("name")
+	}
+	// Validate that a resource name conforms to the restrictions outlined in AIP-122.
+	return badRequest("name", resourcename.Validate(in.Name))
+}
+
+func (s *Server) validateListSecurityPoliciesRequest(in *ListSecurityPoliciesRequest) error {
+	// check required fields
+	if in.Parent == "" {
+		return missingField("parent")
+	}
+	// Validate that a resource name conforms to the restrictions outlined in AIP-122.
+	return badRequest("parent", resourcename.Validate(in.Parent))
+}
@@ -0,0 +1,33 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils"
+)
+
+// GetSloReportRequest is the request to summarize dataplane operation outcomes
+// TODO: move to opi-api once the message is agreed upon
+type GetSloReportRequest struct {
+	// Window is the rolling window to report on, zero means the whole retention
+	Window time.Duration
+}
+
+// GetSloReport returns success ratios and error breakdown of netlink operations
+// per operation kind and object type over the requested rolling window
+func (s *Server) GetSloReport(_ context.Context, in *GetSloReportRequest) (*utils.SloReport, error) {
+	if in.Window < 0 {
+		msg := fmt.Sprintf("Window value (%v) must not be negative", in.Window)
+		return nil, status.Error(codes.InvalidArgument, msg)
+	}
+	return s.slo.Report(in.Window), nil
+}
@@ -0,0 +1,270 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"path"
+
+	"github.com/ghodss/yaml"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils"
+)
+
+// configSnapshotVersion is bumped on every incompatible change of the document layout
+const configSnapshotVersion = 1
+
+// ExportConfigRequest is the request to serialize the whole configuration
+// TODO: move to opi-api once the message is agreed upon
+type ExportConfigRequest struct {
+	// Format is either "json" (default) or "yaml"
+	Format string
+}
+
+// ExportConfigResponse holds the serialized configuration
+// TODO: move to opi-api once the message is agreed upon
+type ExportConfigResponse struct {
+	Document []byte
+}
+
+// ImportConfigRequest is the request to restore a previously exported configuration
+// TODO: move to opi-api once the message is agreed upon
+type ImportConfigRequest struct {
+	// Document is either JSON or YAML
+	Document []byte
+}
+
+// ImportConfigResponse lists the names of the restored objects
+// TODO: move to opi-api once the message is agreed upon
+type ImportConfigResponse struct {
+	Names []string `json:"names"`
+}
+
+// configSnapshot is the versioned document, every object is encoded with protojson, the
+// child resources with utils.MarshalJSON
+type configSnapshot struct {
+	Version            int               `json:"version"`
+	Vrfs               []json.RawMessage `json:"vrfs"`
+	LogicalBridges     []json.RawMessage `json:"logicalBridges"`
+	BridgePorts        []json.RawMessage `json:"bridgePorts"`
+	Svis               []json.RawMessage `json:"svis"`
+	UnderlayInterfaces []json.RawMessage `json:"underlayInterfaces"`
+	TunnelSecurities   []json.RawMessage `json:"tunnelSecurities"`
+	PrefixLists        []json.RawMessage `json:"prefixLists"`
+	RouteMaps          []json.RawMessage `json:"routeMaps"`
+	Routes             []json.RawMessage `json:"routes"`
+	VrfLiteHandoffs    []json.RawMessage `json:"vrfLiteHandoffs"`
+	RouteLeaks         []json.RawMessage `json:"routeLeaks"`
+	BgpPeers           []json.RawMessage `json:"bgpPeers"`
+	StaticFdbEntries   []json.RawMessage `json:"staticFdbEntries"`
+	SecurityPolicies   []json.RawMessage `json:"securityPolicies"`
+	NatRules           []json.RawMessage `json:"natRules"`
+	PbrRules           []json.RawMessage `json:"pbrRules"`
+}
+
+func marshalObjects[T proto.Message](m map[string]T) ([]json.RawMessage, error) {
+	objects := []json.RawMessage{}
+	for _, name := range sortedKeys(m) {
+		b, err := protojson.Marshal(m[name])
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, b)
+	}
+	return objects, nil
+}
+
+func (c childCalls[T]) exportObjects() ([]json.RawMessage, error) {
+	objects := []json.RawMessage{}
+	for _, name := range sortedKeys(c.stored) {
+		b, err := utils.MarshalJSON(c.stored[name])
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, b)
+	}
+	return objects, nil
+}
+
+// importObjects drives the create call of every object of the kind, in the order of the document
+func (c childCalls[T]) importObjects(ctx context.Context, raw []json.RawMessage) ([]string, error) {
+	var names []string
+	for _, b := range raw {
+		obj := new(T)
+		if err := utils.UnmarshalJSON(b, obj); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid %s: %v", c.kind, err)
+		}
+		name := c.name(obj)
+		if !inTenant(ctx, name) {
+			err := status.Errorf(codes.PermissionDenied, "%s is not an object of the tenant", name)
+			return nil, err
+		}
+		log.Printf("Importing %s %v", c.kind, name)
+		if err := c.create(withTenantOf(ctx, name), obj); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// ExportConfig serializes all LogicalBridges, Vrfs, Svis, BridgePorts and their child resources
+// into a single document, the keys of the TunnelSecurities included
+func (s *Server) ExportConfig(_ context.Context, in *ExportConfigRequest) (*ExportConfigResponse, error) {
+	if in.Format != "" && in.Format != "json" && in.Format != "yaml" {
+		msg := fmt.Sprintf("unsupported format %s", in.Format)
+		return nil, status.Error(codes.InvalidArgument, msg)
+	}
+	s.objectsMu.RLock()
+	defer s.objectsMu.RUnlock()
+	var err error
+	snapshot := configSnapshot{Version: configSnapshotVersion}
+	if snapshot.Vrfs, err = marshalObjects(s.Vrfs); err != nil {
+		return nil, err
+	}
+	if snapshot.LogicalBridges, err = marshalObjects(s.Bridges); err != nil {
+		return nil, err
+	}
+	if snapshot.BridgePorts, err = marshalObjects(s.Ports); err != nil {
+		return nil, err
+	}
+	if snapshot.Svis, err = marshalObjects(s.Svis); err != nil {
+		return nil, err
+	}
+	used, owned := s.childKinds()
+	for _, kind := range append(used, owned...) {
+		if *kind.documentObjects(&snapshot), err = kind.exportObjects(); err != nil {
+			return nil, err
+		}
+	}
+	document, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if in.Format == "yaml" {
+		if document, err = yaml.JSONToYAML(document); err != nil {
+			return nil, err
+		}
+	}
+	return &ExportConfigResponse{Document: document}, nil
+}
+
+// parseConfigSnapshot decodes an exported document, JSON or YAML
+func parseConfigSnapshot(data []byte) (*configSnapshot, error) {
+	// JSON is valid YAML, so both are accepted
+	document, err := yaml.YAMLToJSON(data)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid config document: %v", err)
+	}
+	snapshot := &configSnapshot{}
+	if err := json.Unmarshal(document, snapshot); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid config document: %v", err)
+	}
+	if snapshot.Version != configSnapshotVersion {
+		msg := fmt.Sprintf("unsupported config document version %d", snapshot.Version)
+		return nil, status.Error(codes.InvalidArgument, msg)
+	}
+	return snapshot, nil
+}
+
+// ImportConfig restores an exported document driving the regular create paths, in the order
+// of Replay, objects that already exist are left untouched
+func (s *Server) ImportConfig(ctx context.Context, in *ImportConfigRequest) (*ImportConfigResponse, error) {
+	snapshot, err := parseConfigSnapshot(in.Document)
+	if err != nil {
+		return nil, err
+	}
+	response := &ImportConfigResponse{}
+	used, owned := s.childKinds()
+	for _, kind := range used {
+		names, err := kind.importObjects(ctx, *kind.documentObjects(snapshot))
+		if err != nil {
+			return nil, err
+		}
+		response.Names = append(response.Names, names...)
+	}
+	for _, b := range snapshot.Vrfs {
+		obj := &pb.Vrf{}
+		if err := protojson.Unmarshal(b, obj); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid Vrf: %v", err)
+		}
+		if !inTenant(ctx, obj.Name) {
+			err := status.Errorf(codes.PermissionDenied, "%s is not an object of the tenant", obj.Name)
+			return nil, err
+		}
+		log.Printf("Importing Vrf %v", obj.Name)
+		in := &pb.CreateVrfRequest{VrfId: path.Base(obj.Name), Vrf: &pb.Vrf{Spec: obj.Spec}}
+		if _, err := s.CreateVrf(withTenantOf(ctx, obj.Name), in); err != nil {
+			return nil, err
+		}
+		response.Names = append(response.Names, obj.Name)
+	}
+	for _, b := range snapshot.LogicalBridges {
+		obj := &pb.LogicalBridge{}
+		if err := protojson.Unmarshal(b, obj); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid LogicalBridge: %v", err)
+		}
+		if !inTenant(ctx, obj.Name) {
+			err := status.Errorf(codes.PermissionDenied, "%s is not an object of the tenant", obj.Name)
+			return nil, err
+		}
+		log.Printf("Importing LogicalBridge %v", obj.Name)
+		in := &pb.CreateLogicalBridgeRequest{LogicalBridgeId: path.Base(obj.Name), LogicalBridge: &pb.LogicalBridge{Spec: obj.Spec}}
+		if _, err := s.CreateLogicalBridge(withTenantOf(ctx, obj.Name), in); err != nil {
+			return nil, err
+		}
+		response.Names = append(response.Names, obj.Name)
+	}
+	for _, b := range snapshot.BridgePorts {
+		obj := &pb.BridgePort{}
+		if err := protojson.Unmarshal(b, obj); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid BridgePort: %v", err)
+		}
+		if !inTenant(ctx, obj.Name) {
+			err := status.Errorf(codes.PermissionDenied, "%s is not an object of the tenant", obj.Name)
+			return nil, err
+		}
+		log.Printf("Importing BridgePort %v", obj.Name)
+		in := &pb.CreateBridgePortRequest{BridgePortId: path.Base(obj.Name), BridgePort: &pb.BridgePort{Spec: obj.Spec}}
+		if _, err := s.CreateBridgePort(withTenantOf(ctx, obj.Name), in); err != nil {
+			return nil, err
+		}
+		response.Names = append(response.Names, obj.Name)
+	}
+	for _, b := range snapshot.Svis {
+		obj := &pb.Svi{}
+		if err := protojson.Unmarshal(b, obj); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid Svi: %v", err)
+		}
+		if !inTenant(ctx, obj.Name) {
+			err := status.Errorf(codes.PermissionDenied, "%s is not an object of the tenant", obj.Name)
+			return nil, err
+		}
+		log.Printf("Importing Svi %v", obj.Name)
+		in := &pb.CreateSviRequest{SviId: path.Base(obj.Name), Svi: &pb.Svi{Spec: obj.Spec}}
+		if _, err := s.CreateSvi(withTenantOf(ctx, obj.Name), in); err != nil {
+			return nil, err
+		}
+		response.Names = append(response.Names, obj.Name)
+	}
+	for _, kind := range owned {
+		names, err := kind.importObjects(ctx, *kind.documentObjects(snapshot))
+		if err != nil {
+			return nil, err
+		}
+		response.Names = append(response.Names, names...)
+	}
+	return response, nil
+}
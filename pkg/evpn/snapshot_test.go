@@ -0,0 +1,114 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/philippgille/gokv/gomap"
+	"github.com/stretchr/testify/mock"
+	"github.com/vishvananda/netlink"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+	pc "github.com/opiproject/opi-api/network/opinetcommon/v1alpha1/gen/go"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/fake"
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils/mocks"
+)
+
+func Test_ExportImportConfig(t *testing.T) {
+	tests := map[string]struct {
+		format string
+	}{
+		"json document": {
+			format: "json",
+		},
+		"yaml document": {
+			format: "yaml",
+		},
+	}
+
+	// run tests
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			source := NewServerWithArgs(mocks.NewNetlink(t), mocks.NewFrr(t), gomap.NewStore(gomap.DefaultOptions))
+			source.Vrfs[testVrfName] = &pb.Vrf{Name: testVrfName, Spec: &pb.VrfSpec{LoopbackIpPrefix: &pc.IPPrefix{Len: 24}}}
+
+			exported, err := source.ExportConfig(ctx, &ExportConfigRequest{Format: tt.format})
+			if err != nil {
+				t.Fatal("export: expected", nil, "received", err)
+			}
+
+			mockNetlink := mocks.NewNetlink(t)
+			mockFrr := mocks.NewFrr(t)
+			target := NewServerWithArgs(mockNetlink, mockFrr, gomap.NewStore(gomap.DefaultOptions))
+			vrf := &netlink.Vrf{LinkAttrs: netlink.LinkAttrs{Name: testVrfID, Alias: managedAlias}, Table: 1000}
+			mockNetlink.EXPECT().LinkAdd(mock.Anything, vrf).Return(nil).Once()
+			mockNetlink.EXPECT().LinkSetUp(mock.Anything, vrf).Return(nil).Once()
+			mockFrr.EXPECT().FrrZebraCmd(mock.Anything, "show vrf").Return("", nil).Once()
+
+			response, err := target.ImportConfig(ctx, &ImportConfigRequest{Document: exported.Document})
+			if err != nil {
+				t.Fatal("import: expected", nil, "received", err)
+			}
+			if len(response.Names) != 1 || response.Names[0] != testVrfName {
+				t.Error("names: expected", []string{testVrfName}, "received", response.Names)
+			}
+			if obj, ok := target.Vrfs[testVrfName]; !ok || !proto.Equal(obj.Spec, source.Vrfs[testVrfName].Spec) {
+				t.Error("vrf: expected", source.Vrfs[testVrfName], "received", target.Vrfs[testVrfName])
+			}
+		})
+	}
+
+	t.Run("unsupported version", func(t *testing.T) {
+		opi := NewServerWithArgs(mocks.NewNetlink(t), mocks.NewFrr(t), gomap.NewStore(gomap.DefaultOptions))
+		_, err := opi.ImportConfig(context.Background(), &ImportConfigRequest{Document: []byte(`{"version": 0}`)})
+		if er := status.Convert(err); er.Code() != codes.InvalidArgument {
+			t.Error("error code: expected", codes.InvalidArgument, "received", er.Code())
+		}
+	})
+}
+
+func Test_ExportImportChildResources(t *testing.T) {
+	ctx := context.Background()
+	source := NewServerWithArgs(fake.NewNetlink(), fake.NewFrr(), gomap.NewStore(gomap.DefaultOptions))
+	if _, err := source.CreateVrf(ctx, &pb.CreateVrfRequest{VrfId: testVrfID, Vrf: protoClone(&testVrf)}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := source.CreateRoute(ctx, &CreateRouteRequest{Parent: testVrfName, RouteID: testRouteID, Route: testRoute.clone()}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := source.CreatePrefixList(ctx, &CreatePrefixListRequest{PrefixListID: testPrefixListID, PrefixList: testPrefixList.clone()}); err != nil {
+		t.Fatal(err)
+	}
+	exported, err := source.ExportConfig(ctx, &ExportConfigRequest{Format: "yaml"})
+	if err != nil {
+		t.Fatal("export: expected", nil, "received", err)
+	}
+
+	// the PrefixLists go before the Vrfs using them, the Routes after their Vrf
+	target := NewServerWithArgs(fake.NewNetlink(), fake.NewFrr(), gomap.NewStore(gomap.DefaultOptions))
+	response, err := target.ImportConfig(ctx, &ImportConfigRequest{Document: exported.Document})
+	if err != nil {
+		t.Fatal("import: expected", nil, "received", err)
+	}
+	names := []string{testPrefixListName, testVrfName, testRouteName}
+	if !reflect.DeepEqual(response.Names, names) {
+		t.Error("names: expected", names, "received", response.Names)
+	}
+	if route, ok := target.Routes[testRouteName]; !ok || checkSameChildSpec(route.Name, route.Spec, testRoute.clone().Spec) != nil {
+		t.Error("route: expected", testRoute.Spec, "received", route)
+	}
+	if list, ok := target.PrefixLists[testPrefixListName]; !ok || checkSameChildSpec(list.Name, list.Spec, testPrefixList.clone().Spec) != nil {
+		t.Error("prefix-list: expected", testPrefixList.Spec, "received", list)
+	}
+}
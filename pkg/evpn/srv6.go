@@ -0,0 +1,293 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"net"
+	"strings"
+
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netlink/nl"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils"
+)
+
+const (
+	// srv6VrfsKey is the store key of the Vrfs using the SRv6 overlay
+	srv6VrfsKey = "srv6vrfs"
+	// srv6TableBase is the first routing table of the SRv6 Vrfs, above the ones of the vxlan Vrfs
+	srv6TableBase = 2000
+	// srv6FuncBits is the length of the function of the SIDs, the routing table of their Vrf
+	srv6FuncBits = 16
+	// the seg6local End.DT46 action and its vrftable attribute, missing from netlink
+	seg6LocalActionEndDT46 = 16
+	seg6LocalVrfTable      = 9
+)
+
+// Overlay is how the routes of a Vrf are carried between the gateways
+type Overlay string
+
+const (
+	// OverlayVxlan carries them over the L3 vni of the Vrf, with BGP-EVPN type-5 routes
+	OverlayVxlan Overlay = "vxlan"
+	// OverlaySrv6 carries them over an End.DT46 SID of the locator of the gateway, with BGP
+	// L3VPN routes, the Vrf has no vni
+	OverlaySrv6 Overlay = "srv6"
+)
+
+// Srv6Options is the SRv6 locator the SIDs of the SRv6 Vrfs are allocated from
+type Srv6Options struct {
+	// Locator is the name of the locator in FRR
+	Locator string
+	// Prefix is the IPv6 prefix of the locator, the SRv6 overlay is disabled when empty
+	Prefix string
+}
+
+// DefaultSrv6Options leaves the SRv6 overlay disabled
+func DefaultSrv6Options() Srv6Options {
+	return Srv6Options{Locator: "opi"}
+}
+
+// Validate checks the locator prefix leaves room for the function of the SIDs
+func (o Srv6Options) Validate() error {
+	if o.Prefix == "" {
+		return nil
+	}
+	if o.Locator == "" || strings.ContainsAny(o.Locator, " \t\n") {
+		return fmt.Errorf("invalid srv6 locator name %q", o.Locator)
+	}
+	ip, prefix, err := net.ParseCIDR(o.Prefix)
+	if err != nil || ip.To4() != nil {
+		return fmt.Errorf("invalid srv6 locator %q, expected an IPv6 prefix", o.Prefix)
+	}
+	if ones, _ := prefix.Mask.Size(); ones > 128-srv6FuncBits || !ip.Equal(prefix.IP) {
+		return fmt.Errorf("invalid srv6 locator %q, expected a network of at most %d bits", o.Prefix, 128-srv6FuncBits)
+	}
+	return nil
+}
+
+// srv6OverlayFor reports whether a new Vrf uses the SRv6 overlay, sent with the call, or kept
+// from a previous incarnation of the same Vrf (e.g.: on replay)
+func (s *Server) srv6OverlayFor(ctx context.Context, obj *pb.Vrf) (bool, error) {
+	value, ok := utils.MetadataValue(ctx, utils.OverlayMetadataKey)
+	if !ok || value == "" {
+		_, ok := s.Srv6Vrfs[obj.Name]
+		return ok, nil
+	}
+	switch Overlay(value) {
+	case OverlayVxlan:
+		return false, nil
+	case OverlaySrv6:
+		// the routes are decapsulated by the SID, a vxlan device would be unused
+		if obj.Spec.Vni != nil {
+			msg := "the srv6 overlay routes over a SID of the locator, the Vrf cannot have a vni"
+			return false, badRequest(utils.OverlayMetadataKey, status.Error(codes.InvalidArgument, msg))
+		}
+		if s.Srv6.Prefix == "" {
+			msg := "the srv6 overlay requires the locator of the gateway, see --srv6_locator"
+			return false, status.Error(codes.FailedPrecondition, msg)
+		}
+		return true, nil
+	}
+	msg := fmt.Sprintf("invalid overlay %q, expected %s or %s", value, OverlayVxlan, OverlaySrv6)
+	return false, badRequest(utils.OverlayMetadataKey, status.Error(codes.InvalidArgument, msg))
+}
+
+// checkSrv6RouteTargets checks an SRv6 Vrf has the route distinguisher and route targets of its
+// VPN routes, FRR cannot derive them without a vni
+func checkSrv6RouteTargets(rt VrfRouteTargets) error {
+	if rt.Rd == "" || len(rt.Import) == 0 || len(rt.Export) == 0 {
+		msg := "the srv6 overlay requires a route distinguisher and import and export route targets"
+		return badRequest(utils.RouteDistinguisherMetadataKey, status.Error(codes.InvalidArgument, msg))
+	}
+	return nil
+}
+
+// srv6TableFor returns the routing table of an SRv6 Vrf, also the function of its SID, kept from
+// a previous incarnation of the same Vrf so the SID does not change on replay
+func (s *Server) srv6TableFor(name string) uint32 {
+	if table, ok := s.Srv6Vrfs[name]; ok {
+		return table
+	}
+	used := map[uint32]bool{}
+	for _, table := range s.Srv6Vrfs {
+		used[table] = true
+	}
+	table := uint32(srv6TableBase)
+	for used[table] {
+		table++
+	}
+	return table
+}
+
+// IsSrv6Vrf reports whether a Vrf uses the SRv6 overlay, for the dataplanes
+func (s *Server) IsSrv6Vrf(obj *pb.Vrf) bool {
+	_, ok := s.Srv6Vrfs[obj.Name]
+	return ok
+}
+
+// srv6Sid returns the End.DT46 SID of an SRv6 Vrf, its routing table is the function following
+// the locator prefix
+func (s *Server) srv6Sid(table uint32) net.IP {
+	_, prefix, _ := net.ParseCIDR(s.Srv6.Prefix)
+	ones, _ := prefix.Mask.Size()
+	sid := new(big.Int).SetBytes(prefix.IP.To16())
+	sid.Or(sid, new(big.Int).Lsh(big.NewInt(int64(table)), uint(128-ones-srv6FuncBits)))
+	return sid.FillBytes(make(net.IP, net.IPv6len))
+}
+
+// seg6LocalDT46Encap is the seg6local End.DT46 encap of a SID, decapsulating the IPv4 and IPv6
+// packets into the routing table of a Vrf, the kernel needs net.vrf.strict_mode=1
+type seg6LocalDT46Encap struct {
+	VrfTable int
+}
+
+func (e *seg6LocalDT46Encap) Type() int {
+	return nl.LWTUNNEL_ENCAP_SEG6_LOCAL
+}
+
+func (e *seg6LocalDT46Encap) Decode(buf []byte) error {
+	attrs, err := nl.ParseRouteAttr(buf)
+	if err != nil {
+		return err
+	}
+	for _, attr := range attrs {
+		switch attr.Attr.Type {
+		case nl.SEG6_LOCAL_ACTION:
+			if action := nl.NativeEndian().Uint32(attr.Value); action != seg6LocalActionEndDT46 {
+				return fmt.Errorf("unexpected seg6local action %d", action)
+			}
+		case seg6LocalVrfTable:
+			e.VrfTable = int(nl.NativeEndian().Uint32(attr.Value))
+		}
+	}
+	return nil
+}
+
+func (e *seg6LocalDT46Encap) Encode() ([]byte, error) {
+	native := nl.NativeEndian()
+	res := make([]byte, 16)
+	native.PutUint16(res, 8)
+	native.PutUint16(res[2:], nl.SEG6_LOCAL_ACTION)
+	native.PutUint32(res[4:], seg6LocalActionEndDT46)
+	native.PutUint16(res[8:], 8)
+	native.PutUint16(res[10:], seg6LocalVrfTable)
+	native.PutUint32(res[12:], uint32(e.VrfTable))
+	return res, nil
+}
+
+func (e *seg6LocalDT46Encap) String() string {
+	return fmt.Sprintf("action End.DT46 vrftable %d", e.VrfTable)
+}
+
+func (e *seg6LocalDT46Encap) Equal(x netlink.Encap) bool {
+	o, ok := x.(*seg6LocalDT46Encap)
+	return ok && o.VrfTable == e.VrfTable
+}
+
+// srv6SidRoute returns the route of the SID of an SRv6 Vrf, in the main table on the Vrf device
+func (s *Server) srv6SidRoute(vrf netlink.Link, table uint32) *netlink.Route {
+	return &netlink.Route{
+		LinkIndex: vrf.Attrs().Index,
+		Dst:       &net.IPNet{IP: s.srv6Sid(table), Mask: net.CIDRMask(128, 128)},
+		Encap:     &seg6LocalDT46Encap{VrfTable: int(table)},
+		Family:    netlink.FAMILY_V6,
+	}
+}
+
+// frrCreateSrv6Vrf declares the locator, shared by all the SRv6 Vrfs, and exports the routes of
+// the Vrf as VPN routes with its SID
+func (s *Server) frrCreateSrv6Vrf(ctx context.Context, obj *pb.Vrf, vrfName string) error {
+	data, err := s.frr.FrrZebraCmd(ctx, fmt.Sprintf(
+		`configure terminal
+		segment-routing
+		srv6
+		locators
+		locator %s
+		prefix %s func-bits %d
+		exit
+		exit
+		exit
+		exit
+		exit`, s.Srv6.Locator, s.Srv6.Prefix, srv6FuncBits))
+	fmt.Printf("FrrZebraCmd: %v:%v", data, err)
+	if err != nil {
+		return err
+	}
+	rt := s.RouteTargets[obj.Name]
+	var families strings.Builder
+	for _, family := range []string{"ipv4", "ipv6"} {
+		fmt.Fprintf(&families, `address-family %s unicast
+			redistribute connected
+			rd vpn export %s
+			rt vpn import %s
+			rt vpn export %s
+			export vpn
+			import vpn
+			exit-address-family
+			`, family, rt.Rd, strings.Join(rt.Import, " "), strings.Join(rt.Export, " "))
+	}
+	routerID := ""
+	if id := s.vrfRouterID(obj); id != "" {
+		routerID = fmt.Sprintf("bgp router-id %s\n", id)
+	}
+	data, err = s.frr.FrrBgpCmd(ctx, fmt.Sprintf(
+		`configure terminal
+		router bgp %d
+		segment-routing srv6
+		locator %s
+		exit
+		exit
+		router bgp %d vrf %s
+		%ssid vpn per-vrf export %d
+		%sexit`, s.Gateway.LocalAs, s.Srv6.Locator, s.Gateway.LocalAs, vrfName, routerID,
+		s.Srv6Vrfs[obj.Name], families.String()))
+	fmt.Printf("FrrBgpCmd: %v:%v", data, err)
+	return err
+}
+
+func (s *Server) persistSrv6Vrfs() {
+	fields := make(map[string]interface{}, len(s.Srv6Vrfs))
+	for name, table := range s.Srv6Vrfs {
+		fields[name] = float64(table)
+	}
+	msg, err := structpb.NewStruct(fields)
+	if err == nil {
+		err = s.store.Set(srv6VrfsKey, msg)
+	}
+	if err != nil {
+		fmt.Printf("Failed to persist %s: %v", srv6VrfsKey, err)
+	}
+}
+
+// loadSrv6Vrfs restores the SRv6 Vrfs, so replayed Vrfs keep their overlay and their SID
+func (s *Server) loadSrv6Vrfs() error {
+	msg := &structpb.Struct{}
+	found, err := s.store.Get(srv6VrfsKey, msg)
+	if err != nil || !found {
+		return err
+	}
+	for name, value := range msg.Fields {
+		s.Srv6Vrfs[name] = uint32(value.GetNumberValue())
+	}
+	return nil
+}
+
+// releaseSrv6Vrf forgets a deleted Vrf
+func (s *Server) releaseSrv6Vrf(name string) {
+	if _, ok := s.Srv6Vrfs[name]; ok {
+		delete(s.Srv6Vrfs, name)
+		s.persistSrv6Vrfs()
+	}
+}
@@ -0,0 +1,182 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/philippgille/gokv/gomap"
+	"github.com/stretchr/testify/mock"
+	"github.com/vishvananda/netlink"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils"
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils/mocks"
+)
+
+func Test_Srv6Options(t *testing.T) {
+	tests := map[string]struct {
+		in    Srv6Options
+		valid bool
+	}{
+		"disabled":       {in: DefaultSrv6Options(), valid: true},
+		"locator":        {in: Srv6Options{Locator: "opi", Prefix: "fd00:0:1::/48"}, valid: true},
+		"ipv4 prefix":    {in: Srv6Options{Locator: "opi", Prefix: "10.0.0.0/8"}, valid: false},
+		"no room":        {in: Srv6Options{Locator: "opi", Prefix: "fd00:0:1::/120"}, valid: false},
+		"host bits":      {in: Srv6Options{Locator: "opi", Prefix: "fd00:0:1::1/48"}, valid: false},
+		"no name":        {in: Srv6Options{Prefix: "fd00:0:1::/48"}, valid: false},
+		"not a prefix":   {in: Srv6Options{Locator: "opi", Prefix: "fd00:0:1::"}, valid: false},
+		"name with tabs": {in: Srv6Options{Locator: "o\tpi", Prefix: "fd00:0:1::/48"}, valid: false},
+	}
+	for testName, tt := range tests {
+		t.Run(testName, func(t *testing.T) {
+			err := tt.in.Validate()
+			if (err == nil) != tt.valid {
+				t.Error("valid: expected", tt.valid, "received", err)
+			}
+		})
+	}
+}
+
+func Test_srv6OverlayFor(t *testing.T) {
+	tests := map[string]struct {
+		md      metadata.MD
+		vni     *uint32
+		locator string
+		out     bool
+		errCode codes.Code
+	}{
+		"default": {
+			md:  metadata.MD{},
+			vni: proto.Uint32(1000),
+			out: false,
+		},
+		"vxlan": {
+			md:  metadata.Pairs(utils.OverlayMetadataKey, "vxlan"),
+			vni: proto.Uint32(1000),
+			out: false,
+		},
+		"srv6": {
+			md:      metadata.Pairs(utils.OverlayMetadataKey, "srv6"),
+			locator: "fd00:0:1::/48",
+			out:     true,
+		},
+		"srv6 with a vni": {
+			md:      metadata.Pairs(utils.OverlayMetadataKey, "srv6"),
+			vni:     proto.Uint32(1000),
+			locator: "fd00:0:1::/48",
+			errCode: codes.InvalidArgument,
+		},
+		"srv6 without locator": {
+			md:      metadata.Pairs(utils.OverlayMetadataKey, "srv6"),
+			errCode: codes.FailedPrecondition,
+		},
+		"invalid overlay": {
+			md:      metadata.Pairs(utils.OverlayMetadataKey, "mpls"),
+			errCode: codes.InvalidArgument,
+		},
+	}
+	for testName, tt := range tests {
+		t.Run(testName, func(t *testing.T) {
+			opi := NewServerWithArgs(mocks.NewNetlink(t), mocks.NewFrr(t), gomap.NewStore(gomap.DefaultOptions))
+			opi.Srv6.Prefix = tt.locator
+			ctx := metadata.NewIncomingContext(context.Background(), tt.md)
+			obj := &pb.Vrf{Name: testVrfName, Spec: &pb.VrfSpec{Vni: tt.vni}}
+			srv6, err := opi.srv6OverlayFor(ctx, obj)
+			if status.Code(err) != tt.errCode {
+				t.Error("error: expected", tt.errCode, "received", err)
+			}
+			if srv6 != tt.out {
+				t.Error("srv6: expected", tt.out, "received", srv6)
+			}
+		})
+	}
+}
+
+func Test_Srv6Vrf(t *testing.T) {
+	mockNetlink := mocks.NewNetlink(t)
+	mockFrr := mocks.NewFrr(t)
+	opi := NewServerWithArgs(mockNetlink, mockFrr, gomap.NewStore(gomap.DefaultOptions))
+	opi.Srv6.Prefix = "fd00:0:1::/48"
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(
+		utils.OverlayMetadataKey, "srv6",
+		utils.RouteDistinguisherMetadataKey, "65000:2000",
+		utils.ImportRouteTargetsMetadataKey, "65000:2000",
+		utils.ExportRouteTargetsMetadataKey, "65000:2000"))
+
+	// the SID is the locator followed by the routing table of the Vrf
+	mockNetlink.EXPECT().LinkAdd(mock.Anything, mock.Anything).Return(nil).Once()
+	mockNetlink.EXPECT().LinkSetUp(mock.Anything, mock.Anything).Return(nil).Once()
+	mockNetlink.EXPECT().RouteAdd(mock.Anything, mock.MatchedBy(func(route *netlink.Route) bool {
+		encap, ok := route.Encap.(*seg6LocalDT46Encap)
+		return ok && encap.VrfTable == 2000 && route.Dst.String() == "fd00:0:1:7d0::/128"
+	})).Return(nil).Once()
+	mockFrr.EXPECT().FrrZebraCmd(mock.Anything, mock.MatchedBy(func(command string) bool {
+		return strings.Contains(command, "locator opi\n\t\tprefix fd00:0:1::/48 func-bits 16\n")
+	})).Return("", nil).Once()
+	mockFrr.EXPECT().FrrBgpCmd(mock.Anything, mock.MatchedBy(func(command string) bool {
+		return strings.Contains(command, "sid vpn per-vrf export 2000\n") &&
+			strings.Contains(command, "address-family ipv6 unicast") &&
+			strings.Contains(command, "rd vpn export 65000:2000\n")
+	})).Return("", nil).Once()
+	mockFrr.EXPECT().FrrZebraCmd(mock.Anything, "show vrf").Return("", nil).Once()
+
+	request := &pb.CreateVrfRequest{VrfId: testVrfID, Vrf: &pb.Vrf{Spec: &pb.VrfSpec{LoopbackIpPrefix: testVrf.Spec.LoopbackIpPrefix}}}
+	response, err := opi.CreateVrf(ctx, request)
+	if err != nil {
+		t.Fatal("error: expected", nil, "received", err)
+	}
+	if response.Status.RoutingTable != 2000 || !opi.IsSrv6Vrf(response) {
+		t.Error("routing table: expected", 2000, "received", response.Status.RoutingTable)
+	}
+
+	// the next SRv6 Vrf gets the next table
+	if table := opi.srv6TableFor("vrfs/other"); table != 2001 {
+		t.Error("table: expected", 2001, "received", table)
+	}
+
+	// the overlay and the table are kept for the replay
+	restored := NewServerWithArgs(mocks.NewNetlink(t), mocks.NewFrr(t), opi.store)
+	if err := restored.loadSrv6Vrfs(); err != nil {
+		t.Fatal("error: expected", nil, "received", err)
+	}
+	if !reflect.DeepEqual(restored.Srv6Vrfs, opi.Srv6Vrfs) {
+		t.Error("srv6 Vrfs: expected", opi.Srv6Vrfs, "received", restored.Srv6Vrfs)
+	}
+}
+
+func Test_Srv6VrfRouteTargets(t *testing.T) {
+	opi := NewServerWithArgs(mocks.NewNetlink(t), mocks.NewFrr(t), gomap.NewStore(gomap.DefaultOptions))
+	opi.Srv6.Prefix = "fd00:0:1::/48"
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(utils.OverlayMetadataKey, "srv6"))
+	request := &pb.CreateVrfRequest{VrfId: testVrfID, Vrf: &pb.Vrf{Spec: &pb.VrfSpec{LoopbackIpPrefix: testVrf.Spec.LoopbackIpPrefix}}}
+	if _, err := opi.CreateVrf(ctx, request); status.Code(err) != codes.InvalidArgument {
+		t.Error("error: expected", codes.InvalidArgument, "received", err)
+	}
+}
+
+func Test_seg6LocalDT46Encap(t *testing.T) {
+	encap := &seg6LocalDT46Encap{VrfTable: 2000}
+	buf, err := encap.Encode()
+	if err != nil {
+		t.Fatal("error: expected", nil, "received", err)
+	}
+	decoded := &seg6LocalDT46Encap{}
+	if err := decoded.Decode(buf); err != nil {
+		t.Fatal("error: expected", nil, "received", err)
+	}
+	if !encap.Equal(decoded) {
+		t.Error("encap: expected", encap, "received", decoded)
+	}
+}
@@ -7,19 +7,18 @@ package evpn
 
 import (
 	"context"
-	"fmt"
 	"log"
-	"path"
 	"sort"
 
-	"github.com/google/uuid"
-
 	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
 
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils"
+
 	"go.einride.tech/aip/fieldbehavior"
 	"go.einride.tech/aip/resourceid"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/emptypb"
 )
 
@@ -41,13 +40,34 @@ func (s *Server) CreateSvi(ctx context.Context, in *pb.CreateSviRequest) (*pb.Sv
 		log.Printf("client provided the ID of a resource %v, ignoring the name field %v", in.SviId, in.Svi.Name)
 		resourceID = in.SviId
 	}
-	in.Svi.Name = resourceIDToFullName("svis", resourceID)
+	name, err := newObjectName(ctx, "svis", resourceID)
+	if err != nil {
+		return nil, err
+	}
+	in.Svi.Name = name
+	s.objectsMu.Lock()
+	defer s.objectsMu.Unlock()
+	labels, err := labelsFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
 	// idempotent API when called with same key, should return same object
 	obj, ok := s.Svis[in.Svi.Name]
 	if ok {
+		// a different spec under the same key is a conflict, not a retry
+		if err := checkSameSpec(obj.Name, obj.Spec, in.Svi.Spec); err != nil {
+			return nil, err
+		}
 		log.Printf("Already existing Svi with id %v", in.Svi.Name)
 		return obj, nil
 	}
+	// a Create retried while the previous one programs the Svi must not do it twice
+	if err := s.checkNoOperation(in.Svi.Name); err != nil {
+		return nil, err
+	}
+	if err := checkSameTenant(in.Svi.Name, in.Svi.Spec.LogicalBridge, in.Svi.Spec.Vrf); err != nil {
+		return nil, err
+	}
 	// now get LogicalBridge object to fetch VID field
 	bridgeObject, ok := s.Bridges[in.Svi.Spec.LogicalBridge]
 	if !ok {
@@ -60,21 +80,87 @@ func (s *Server) CreateSvi(ctx context.Context, in *pb.CreateSviRequest) (*pb.Sv
 		err := status.Errorf(codes.NotFound, "unable to find key %s", in.Svi.Spec.Vrf)
 		return nil, err
 	}
-	// configure netlink
-	if err := s.netlinkCreateSvi(ctx, in, bridgeObject, vrf); err != nil {
+	if err := s.checkNotDeleting(vrf.Name); err != nil {
+		return nil, err
+	}
+	if err := s.checkIrbBridge(vrf, bridgeObject); err != nil {
+		return nil, err
+	}
+	anycast, err := s.anycastGatewayFor(ctx, in.Svi, bridgeObject)
+	if err != nil {
+		return nil, err
+	}
+	announcement, err := s.sviAnnouncementFor(ctx, in.Svi)
+	if err != nil {
 		return nil, err
 	}
-	// configure FRR
-	vid := uint16(bridgeObject.Spec.VlanId)
-	vlanName := fmt.Sprintf("vlan%d", vid)
-	vrfName := path.Base(vrf.Name)
-	if err := s.frrCreateSviRequest(ctx, in, vrfName, vlanName); err != nil {
+	// see https://google.aip.dev/163
+	if utils.IsValidateOnly(ctx) {
+		if err := s.precheckCreateSvi(ctx, in, bridgeObject, vrf); err != nil {
+			return nil, err
+		}
+		response := protoClone(in.Svi)
+		response.Status = &pb.SviStatus{}
+		return response, nil
+	}
+	if anycast {
+		s.AnycastGateways[in.Svi.Name] = true
+	}
+	s.setSviAnnouncement(in.Svi.Name, announcement)
+	// see https://google.aip.dev/151
+	if utils.IsAsync(ctx) {
+		s.startLifecycleOperation(ctx, in.Svi.Name, LifecycleCreating, func(ctx context.Context) (proto.Message, error) {
+			// the other calls go on while the devices are programmed, only saving the Svi
+			// waits for them
+			s.objectsMu.RLock()
+			err := s.createSviDataplane(ctx, in.Svi, bridgeObject, vrf)
+			s.objectsMu.RUnlock()
+			s.objectsMu.Lock()
+			defer s.objectsMu.Unlock()
+			return s.saveSvi(ctx, in.Svi, vrf, labels, err)
+		})
+		// the oper status is unknown until the operation is done
+		response := protoClone(in.Svi)
+		response.Status = &pb.SviStatus{}
+		return response, nil
+	}
+	err = s.createSviDataplane(ctx, in.Svi, bridgeObject, vrf)
+	return s.saveSvi(ctx, in.Svi, vrf, labels, err)
+}
+
+// createSviDataplane programs the devices and FRR configuration of a new Svi, with objectsMu
+// held for reading at least
+func (s *Server) createSviDataplane(ctx context.Context, svi *pb.Svi, bridgeObject *pb.LogicalBridge, vrf *pb.Vrf) error {
+	ctx, cancel := s.programmingContext(ctx)
+	defer cancel()
+	return s.dataplane.CreateSvi(ctx, svi, bridgeObject, vrf)
+}
+
+// saveSvi saves a new Svi once programmed, or forgets what was reserved for it when the
+// programming failed, with objectsMu held
+func (s *Server) saveSvi(ctx context.Context, svi *pb.Svi, vrf *pb.Vrf, labels *ObjectLabels, err error) (*pb.Svi, error) {
+	if err != nil {
+		s.forgetStatus(svi.Name)
+		delete(s.AnycastGateways, svi.Name)
+		delete(s.SviAnnouncements, svi.Name)
 		return nil, err
 	}
 	// save object to the database
-	response := protoClone(in.Svi)
-	response.Status = &pb.SviStatus{OperStatus: pb.SVIOperStatus_SVI_OPER_STATUS_UP}
-	s.Svis[in.Svi.Name] = response
+	response := protoClone(svi)
+	response.Status = &pb.SviStatus{}
+	s.putSvi(response)
+	s.persist("svis")
+	s.setLabels(svi.Name, labels)
+	if s.AnycastGateways[svi.Name] {
+		s.persistAnycastGateways()
+	}
+	if _, ok := s.SviAnnouncements[svi.Name]; ok {
+		s.persistSviAnnouncements()
+	}
+	s.refreshSecurityPolicy(ctx, vrf)
+	s.events.Publish(utils.WatchEvent{Type: utils.WatchAdded, Name: svi.Name})
+	// the hosts may still cache the address of a previous gateway, e.g. on another VTEP
+	s.announceSvi(response)
 	return response, nil
 }
 
@@ -84,9 +170,19 @@ func (s *Server) DeleteSvi(ctx context.Context, in *pb.DeleteSviRequest) (*empty
 	if err := s.validateDeleteSviRequest(in); err != nil {
 		return nil, err
 	}
+	s.objectsMu.Lock()
+	defer s.objectsMu.Unlock()
+	if err := s.checkEtag(ctx, in.Name); err != nil {
+		return nil, err
+	}
+	return s.deleteSvi(ctx, in)
+}
+
+// deleteSvi deletes a validated Svi, with objectsMu held
+func (s *Server) deleteSvi(ctx context.Context, in *pb.DeleteSviRequest) (*emptypb.Empty, error) {
 	// fetch object from the database
 	obj, ok := s.Svis[in.Name]
-	if !ok {
+	if !ok || !inTenant(ctx, in.Name) {
 		if in.AllowMissing {
 			return &emptypb.Empty{}, nil
 		}
@@ -105,19 +201,24 @@ func (s *Server) DeleteSvi(ctx context.Context, in *pb.DeleteSviRequest) (*empty
 		err := status.Errorf(codes.NotFound, "unable to find key %s", obj.Spec.Vrf)
 		return nil, err
 	}
-	// configure netlink
-	if err := s.netlinkDeleteSvi(ctx, in, bridgeObject, vrf); err != nil {
-		return nil, err
+	if utils.IsValidateOnly(ctx) {
+		return &emptypb.Empty{}, nil
 	}
-	// delete from FRR
-	vrfName := path.Base(vrf.Name)
-	vid := uint16(bridgeObject.Spec.VlanId)
-	vlanName := fmt.Sprintf("vlan%d", vid)
-	if err := s.frrDeleteSviRequest(ctx, obj, vrfName, vlanName); err != nil {
+	ctx, cancel := s.programmingContext(ctx)
+	defer cancel()
+	if err := s.dataplane.DeleteSvi(ctx, obj, bridgeObject, vrf); err != nil {
 		return nil, err
 	}
 	// remove from the Database
-	delete(s.Svis, obj.Name)
+	s.removeSvi(obj.Name)
+	s.forgetStatus(obj.Name)
+	s.persist("svis")
+	s.releaseLabels(obj.Name)
+	s.releaseAnycastGateway(obj.Name)
+	s.releaseSviAnnouncement(obj.Name)
+	s.refreshSecurityPolicy(ctx, vrf)
+	s.events.Publish(utils.WatchEvent{Type: utils.WatchDeleted, Name: obj.Name})
+	delete(s.Adopted, obj.Name)
 	return &emptypb.Empty{}, nil
 }
 
@@ -127,34 +228,55 @@ func (s *Server) UpdateSvi(ctx context.Context, in *pb.UpdateSviRequest) (*pb.Sv
 	if err := s.validateUpdateSviRequest(in); err != nil {
 		return nil, err
 	}
+	s.objectsMu.Lock()
+	defer s.objectsMu.Unlock()
+	if err := s.checkEtag(ctx, in.Svi.Name); err != nil {
+		return nil, err
+	}
 	// fetch object from the database
 	svi, ok := s.Svis[in.Svi.Name]
-	if !ok {
+	if !ok || !inTenant(ctx, in.Svi.Name) {
 		// TODO: introduce "in.AllowMissing" field. In case "true", create a new resource, don't return error
 		err := status.Errorf(codes.NotFound, "unable to find key %s", in.Svi.Name)
 		return nil, err
 	}
+	labels, err := labelsFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkSameTenant(in.Svi.Name, in.Svi.GetSpec().GetLogicalBridge(), in.Svi.GetSpec().GetVrf()); err != nil {
+		return nil, err
+	}
 	// use netlink to find VlanId from LogicalBridge object
 	bridgeObject, ok := s.Bridges[svi.Spec.LogicalBridge]
 	if !ok {
 		err := status.Errorf(codes.NotFound, "unable to find key %s", svi.Spec.LogicalBridge)
 		return nil, err
 	}
-	vlanName := fmt.Sprintf("vlan%d", bridgeObject.Spec.VlanId)
-	iface, err := s.nLink.LinkByName(ctx, vlanName)
+	announcement, err := s.sviAnnouncementFor(ctx, in.Svi)
 	if err != nil {
-		err := status.Errorf(codes.NotFound, "unable to find key %s", vlanName)
 		return nil, err
 	}
-	// base := iface.Attrs()
-	// iface.MTU = 1500 // TODO: remove this, just an example
-	if err := s.nLink.LinkModify(ctx, iface); err != nil {
-		fmt.Printf("Failed to update link: %v", err)
+	if utils.IsValidateOnly(ctx) {
+		response := protoClone(in.Svi)
+		response.Status = &pb.SviStatus{}
+		return response, nil
+	}
+	if err := s.dataplane.UpdateSvi(ctx, svi, in.Svi, bridgeObject); err != nil {
 		return nil, err
 	}
 	response := protoClone(in.Svi)
-	response.Status = &pb.SviStatus{OperStatus: pb.SVIOperStatus_SVI_OPER_STATUS_UP}
-	s.Svis[in.Svi.Name] = response
+	response.Status = &pb.SviStatus{}
+	s.putSvi(response)
+	s.persist("svis")
+	s.setLabels(in.Svi.Name, labels)
+	if s.setSviAnnouncement(in.Svi.Name, announcement) {
+		s.persistSviAnnouncements()
+	}
+	s.events.Publish(utils.WatchEvent{Type: utils.WatchModified, Name: in.Svi.Name})
+	if sviGatewayMoved(svi, response) {
+		s.announceSvi(response)
+	}
 	return response, nil
 }
 
@@ -164,9 +286,11 @@ func (s *Server) GetSvi(ctx context.Context, in *pb.GetSviRequest) (*pb.Svi, err
 	if err := s.validateGetSviRequest(in); err != nil {
 		return nil, err
 	}
+	s.objectsMu.RLock()
+	defer s.objectsMu.RUnlock()
 	// fetch object from the database
 	obj, ok := s.Svis[in.Name]
-	if !ok {
+	if !ok || !inTenant(ctx, in.Name) {
 		err := status.Errorf(codes.NotFound, "unable to find key %s", in.Name)
 		return nil, err
 	}
@@ -176,42 +300,61 @@ func (s *Server) GetSvi(ctx context.Context, in *pb.GetSviRequest) (*pb.Svi, err
 		err := status.Errorf(codes.NotFound, "unable to find key %s", obj.Spec.LogicalBridge)
 		return nil, err
 	}
-	vlanName := fmt.Sprintf("vlan%d", bridgeObject.Spec.VlanId)
-	_, err := s.nLink.LinkByName(ctx, vlanName)
-	if err != nil {
-		err := status.Errorf(codes.NotFound, "unable to find key %s", vlanName)
-		return nil, err
+	if !s.LiveRead {
+		if err := s.dataplane.GetSvi(ctx, obj, bridgeObject); err != nil {
+			return nil, err
+		}
 	}
+	degraded := map[string]error{}
+	operStatus := s.sviOperStatus(ctx, obj, degraded)
+	reportDegraded(ctx, degraded)
+	s.reportLifecycles(ctx, []string{in.Name})
 	// TODO
-	return &pb.Svi{Name: in.Name, Spec: &pb.SviSpec{MacAddress: obj.Spec.MacAddress, EnableBgp: obj.Spec.EnableBgp, RemoteAs: obj.Spec.RemoteAs}, Status: &pb.SviStatus{OperStatus: pb.SVIOperStatus_SVI_OPER_STATUS_UP}}, nil
+	return &pb.Svi{Name: in.Name, Spec: &pb.SviSpec{MacAddress: obj.Spec.MacAddress, EnableBgp: obj.Spec.EnableBgp, RemoteAs: obj.Spec.RemoteAs}, Status: &pb.SviStatus{OperStatus: operStatus}}, nil
 }
 
 // ListSvis lists logical bridges
-func (s *Server) ListSvis(_ context.Context, in *pb.ListSvisRequest) (*pb.ListSvisResponse, error) {
+func (s *Server) ListSvis(ctx context.Context, in *pb.ListSvisRequest) (*pb.ListSvisResponse, error) {
 	// check required fields
 	if err := fieldbehavior.ValidateRequiredFields(in); err != nil {
 		return nil, err
 	}
+	s.objectsMu.RLock()
+	defer s.objectsMu.RUnlock()
 	// fetch pagination from the database, calculate size and offset
-	size, offset, perr := extractPagination(in.PageSize, in.PageToken, s.Pagination)
+	size, offset, perr := s.extractPagination(in.PageSize, in.PageToken)
 	if perr != nil {
 		return nil, perr
 	}
-	// fetch object from the database
-	Blobarray := []*pb.Svi{}
-	for _, svi := range s.Svis {
-		r := protoClone(svi)
-		r.Status = &pb.SviStatus{OperStatus: pb.SVIOperStatus_SVI_OPER_STATUS_UP}
-		Blobarray = append(Blobarray, r)
-	}
-	// sort is needed, since MAP is unsorted in golang, and we might get different results
-	sortSvis(Blobarray)
-	log.Printf("Limiting result len(%d) to [%d:%d]", len(Blobarray), offset, size)
-	Blobarray, hasMoreElements := limitPagination(Blobarray, offset, size)
-	token := ""
-	if hasMoreElements {
-		token = uuid.New().String()
-		s.Pagination[token] = offset + size
+	selector, err := labelSelectorFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// fetch object from the database, the following pages are cut from the snapshot of the first one,
+	// copies of the stored objects taken under objectsMu
+	Blobarray, token, err := listPage(ctx, s, "svis", "", in.PageToken, offset, size, func() []*pb.Svi {
+		Blobarray := []*pb.Svi{}
+		for name, svi := range s.Svis {
+			if !inTenant(ctx, name) || !s.matchesLabels(selector, name) {
+				continue
+			}
+			Blobarray = append(Blobarray, protoClone(svi))
+		}
+		// sort is needed, since MAP is unsorted in golang, and we might get different results
+		sortSvis(Blobarray)
+		return Blobarray
+	})
+	if err != nil {
+		return nil, err
+	}
+	Blobarray = clonePage(Blobarray)
+	degraded := map[string]error{}
+	names := make([]string, 0, len(Blobarray))
+	for _, r := range Blobarray {
+		r.Status = &pb.SviStatus{OperStatus: s.sviOperStatus(ctx, r, degraded)}
+		names = append(names, r.Name)
 	}
+	reportDegraded(ctx, degraded)
+	s.reportLifecycles(ctx, names)
 	return &pb.ListSvisResponse{Svis: Blobarray, NextPageToken: token}, nil
 }
@@ -11,7 +11,6 @@ import (
 	"fmt"
 	"log"
 	"net"
-	"path"
 
 	"github.com/vishvananda/netlink"
 
@@ -35,7 +34,7 @@ func (s *Server) netlinkCreateSvi(ctx context.Context, in *pb.CreateSviRequest,
 	}
 	// Example: ip link add link br-tenant name <link_svi> type vlan id <vlan-id>
 	vlanName := fmt.Sprintf("vlan%d", vid)
-	vlandev := &netlink.Vlan{LinkAttrs: netlink.LinkAttrs{Name: vlanName, ParentIndex: bridge.Attrs().Index}, VlanId: int(vid)}
+	vlandev := &netlink.Vlan{LinkAttrs: netlink.LinkAttrs{Name: vlanName, ParentIndex: bridge.Attrs().Index, Alias: managedAlias}, VlanId: int(vid)}
 	log.Printf("Creating VLAN %v", vlandev)
 	if err := s.nLink.LinkAdd(ctx, vlandev); err != nil {
 		fmt.Printf("Failed to create vlan link: %v", err)
@@ -48,38 +47,68 @@ func (s *Server) netlinkCreateSvi(ctx context.Context, in *pb.CreateSviRequest,
 			return err
 		}
 	}
+	vrfName := s.vrfKernelName(vrf.Name)
+	nLink, err := s.vrfNetlink(ctx, vrf.Name)
+	if err != nil {
+		return err
+	}
+	var link netlink.Link = vlandev
+	// the addresses of a device are flushed when it changes namespace, it moves first
+	if s.vrfNetns() {
+		// Example: ip link set <link_svi> netns <vrf-name>
+		if link, err = s.moveToVrfNetns(ctx, nLink, vlandev, vrfName); err != nil {
+			return err
+		}
+	}
 	// Example: ip address add <svi-ip-with prefixlength> dev <link_svi>
 	for _, gwip := range in.Svi.Spec.GwIpPrefix {
-		fmt.Printf("Assign the GW IP address %v to the SVI interface %v", gwip, vlandev)
+		fmt.Printf("Assign the GW IP address %v to the SVI interface %v", gwip, link)
 		myip := make(net.IP, 4)
 		binary.BigEndian.PutUint32(myip, gwip.Addr.GetV4Addr())
 		addr := &netlink.Addr{IPNet: &net.IPNet{IP: myip, Mask: net.CIDRMask(int(gwip.Len), 32)}}
-		if err := s.nLink.AddrAdd(ctx, vlandev, addr); err != nil {
+		if err := nLink.AddrAdd(ctx, link, addr); err != nil {
 			fmt.Printf("Failed to set IP on link: %v", err)
 			return err
 		}
 	}
-	// get net device by name
-	vrfName := path.Base(vrf.Name)
-	vrfdev, err := s.nLink.LinkByName(ctx, vrfName)
-	if err != nil {
-		err := status.Errorf(codes.NotFound, "unable to find key %s", vrf.Name)
+	if !s.vrfNetns() {
+		// get net device by name
+		vrfdev, err := s.nLink.LinkByName(ctx, vrfName)
+		if err != nil {
+			err := status.Errorf(codes.NotFound, "unable to find key %s", vrf.Name)
+			return err
+		}
+		// Example: ip link set <link_svi> master <vrf-name> up
+		if err := s.nLink.LinkSetMaster(ctx, link, vrfdev); err != nil {
+			fmt.Printf("Failed to add vlandev to vrf: %v", err)
+			return err
+		}
+	}
+	// Example: ip link set <link_svi> up
+	if err := nLink.LinkSetUp(ctx, link); err != nil {
+		fmt.Printf("Failed to up link: %v", err)
 		return err
 	}
-	// Example: ip link set <link_svi> master <vrf-name> up
-	if err := s.nLink.LinkSetMaster(ctx, vlandev, vrfdev); err != nil {
-		fmt.Printf("Failed to add vlandev to vrf: %v", err)
+	return nil
+}
+
+func (s *Server) netlinkUpdateSvi(ctx context.Context, bridgeObject *pb.LogicalBridge) error {
+	vlanName := fmt.Sprintf("vlan%d", bridgeObject.Spec.VlanId)
+	iface, err := s.nLink.LinkByName(ctx, vlanName)
+	if err != nil {
+		err := status.Errorf(codes.NotFound, "unable to find key %s", vlanName)
 		return err
 	}
-	// Example: ip link set <link_svi> up
-	if err := s.nLink.LinkSetUp(ctx, vlandev); err != nil {
-		fmt.Printf("Failed to up link: %v", err)
+	// base := iface.Attrs()
+	// iface.MTU = 1500 // TODO: remove this, just an example
+	if err := s.nLink.LinkModify(ctx, iface); err != nil {
+		fmt.Printf("Failed to update link: %v", err)
 		return err
 	}
 	return nil
 }
 
-func (s *Server) netlinkDeleteSvi(ctx context.Context, _ *pb.DeleteSviRequest, bridgeObject *pb.LogicalBridge, _ *pb.Vrf) error {
+func (s *Server) netlinkDeleteSvi(ctx context.Context, _ *pb.DeleteSviRequest, bridgeObject *pb.LogicalBridge, vrf *pb.Vrf) error {
 	// use netlink to find br-tenant
 	bridge, err := s.nLink.LinkByName(ctx, tenantbridgeName)
 	if err != nil {
@@ -92,20 +121,25 @@ func (s *Server) netlinkDeleteSvi(ctx context.Context, _ *pb.DeleteSviRequest, b
 		fmt.Printf("Failed to del vlan to bridge: %v", err)
 		return err
 	}
+	// the vlan device is in the namespace of a Vrf isolated in one
+	nLink, err := s.vrfNetlink(ctx, vrf.GetName())
+	if err != nil {
+		return err
+	}
 	vlanName := fmt.Sprintf("vlan%d", vid)
-	vlandev, err := s.nLink.LinkByName(ctx, vlanName)
+	vlandev, err := nLink.LinkByName(ctx, vlanName)
 	if err != nil {
 		err := status.Errorf(codes.NotFound, "unable to find key %s", vlanName)
 		return err
 	}
 	log.Printf("Deleting VLAN %v", vlandev)
 	// bring link down
-	if err := s.nLink.LinkSetDown(ctx, vlandev); err != nil {
+	if err := nLink.LinkSetDown(ctx, vlandev); err != nil {
 		fmt.Printf("Failed to up link: %v", err)
 		return err
 	}
 	// use netlink to delete vlan
-	if err := s.nLink.LinkDel(ctx, vlandev); err != nil {
+	if err := nLink.LinkDel(ctx, vlandev); err != nil {
 		fmt.Printf("Failed to delete link: %v", err)
 		return err
 	}
@@ -13,6 +13,7 @@ import (
 	"net"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/philippgille/gokv/gomap"
 	"github.com/stretchr/testify/mock"
@@ -66,7 +67,7 @@ func Test_CreateSvi(t *testing.T) {
 			id:      "CapitalLettersNotAllowed",
 			in:      &testSvi,
 			out:     nil,
-			errCode: codes.Unknown,
+			errCode: codes.InvalidArgument,
 			errMsg:  fmt.Sprintf("user-settable ID must only contain lowercase, numbers and hyphens (%v)", "got: 'C' in position 0"),
 			exist:   false,
 			on:      nil,
@@ -84,7 +85,7 @@ func Test_CreateSvi(t *testing.T) {
 			id:      testSviID,
 			in:      nil,
 			out:     nil,
-			errCode: codes.Unknown,
+			errCode: codes.InvalidArgument,
 			errMsg:  "missing required field: svi",
 			exist:   false,
 			on:      nil,
@@ -95,7 +96,7 @@ func Test_CreateSvi(t *testing.T) {
 				Spec: &pb.SviSpec{},
 			},
 			out:     nil,
-			errCode: codes.Unknown,
+			errCode: codes.InvalidArgument,
 			errMsg:  "missing required field: svi.spec.vrf",
 			exist:   false,
 			on:      nil,
@@ -108,7 +109,7 @@ func Test_CreateSvi(t *testing.T) {
 				},
 			},
 			out:     nil,
-			errCode: codes.Unknown,
+			errCode: codes.InvalidArgument,
 			errMsg:  "missing required field: svi.spec.logical_bridge",
 			exist:   false,
 			on:      nil,
@@ -122,7 +123,7 @@ func Test_CreateSvi(t *testing.T) {
 				},
 			},
 			out:     nil,
-			errCode: codes.Unknown,
+			errCode: codes.InvalidArgument,
 			errMsg:  "missing required field: svi.spec.mac_address",
 			exist:   false,
 			on:      nil,
@@ -137,7 +138,7 @@ func Test_CreateSvi(t *testing.T) {
 				},
 			},
 			out:     nil,
-			errCode: codes.Unknown,
+			errCode: codes.InvalidArgument,
 			errMsg:  "missing required field: svi.spec.gw_ip_prefix",
 			exist:   false,
 			on:      nil,
@@ -244,7 +245,7 @@ func Test_CreateSvi(t *testing.T) {
 				mockNetlink.EXPECT().LinkByName(mock.Anything, tenantbridgeName).Return(bridge, nil).Once()
 				mockNetlink.EXPECT().BridgeVlanAdd(mock.Anything, bridge, vid, false, false, true, false).Return(nil).Once()
 				vlanName := fmt.Sprintf("vlan%d", vid)
-				vlandev := &netlink.Vlan{LinkAttrs: netlink.LinkAttrs{Name: vlanName, ParentIndex: bridge.Attrs().Index}, VlanId: int(vid)}
+				vlandev := &netlink.Vlan{LinkAttrs: netlink.LinkAttrs{Name: vlanName, ParentIndex: bridge.Attrs().Index, Alias: managedAlias}, VlanId: int(vid)}
 				mockNetlink.EXPECT().LinkAdd(mock.Anything, vlandev).Return(errors.New(errMsg)).Once()
 			},
 		},
@@ -261,7 +262,7 @@ func Test_CreateSvi(t *testing.T) {
 				mockNetlink.EXPECT().LinkByName(mock.Anything, tenantbridgeName).Return(bridge, nil).Once()
 				mockNetlink.EXPECT().BridgeVlanAdd(mock.Anything, bridge, vid, false, false, true, false).Return(nil).Once()
 				vlanName := fmt.Sprintf("vlan%d", vid)
-				vlandev := &netlink.Vlan{LinkAttrs: netlink.LinkAttrs{Name: vlanName, ParentIndex: bridge.Attrs().Index}, VlanId: int(vid)}
+				vlandev := &netlink.Vlan{LinkAttrs: netlink.LinkAttrs{Name: vlanName, ParentIndex: bridge.Attrs().Index, Alias: managedAlias}, VlanId: int(vid)}
 				mockNetlink.EXPECT().LinkAdd(mock.Anything, vlandev).Return(nil).Once()
 				mac := net.HardwareAddr(testSvi.Spec.MacAddress[:])
 				mockNetlink.EXPECT().LinkSetHardwareAddr(mock.Anything, vlandev, mac).Return(errors.New(errMsg)).Once()
@@ -280,7 +281,7 @@ func Test_CreateSvi(t *testing.T) {
 				mockNetlink.EXPECT().LinkByName(mock.Anything, tenantbridgeName).Return(bridge, nil).Once()
 				mockNetlink.EXPECT().BridgeVlanAdd(mock.Anything, bridge, vid, false, false, true, false).Return(nil).Once()
 				vlanName := fmt.Sprintf("vlan%d", vid)
-				vlandev := &netlink.Vlan{LinkAttrs: netlink.LinkAttrs{Name: vlanName, ParentIndex: bridge.Attrs().Index}, VlanId: int(vid)}
+				vlandev := &netlink.Vlan{LinkAttrs: netlink.LinkAttrs{Name: vlanName, ParentIndex: bridge.Attrs().Index, Alias: managedAlias}, VlanId: int(vid)}
 				mockNetlink.EXPECT().LinkAdd(mock.Anything, vlandev).Return(nil).Once()
 				mac := net.HardwareAddr(testSvi.Spec.MacAddress[:])
 				mockNetlink.EXPECT().LinkSetHardwareAddr(mock.Anything, vlandev, mac).Return(nil).Once()
@@ -302,7 +303,7 @@ func Test_CreateSvi(t *testing.T) {
 				mockNetlink.EXPECT().LinkByName(mock.Anything, tenantbridgeName).Return(bridge, nil).Once()
 				mockNetlink.EXPECT().BridgeVlanAdd(mock.Anything, bridge, vid, false, false, true, false).Return(nil).Once()
 				vlanName := fmt.Sprintf("vlan%d", vid)
-				vlandev := &netlink.Vlan{LinkAttrs: netlink.LinkAttrs{Name: vlanName, ParentIndex: bridge.Attrs().Index}, VlanId: int(vid)}
+				vlandev := &netlink.Vlan{LinkAttrs: netlink.LinkAttrs{Name: vlanName, ParentIndex: bridge.Attrs().Index, Alias: managedAlias}, VlanId: int(vid)}
 				mockNetlink.EXPECT().LinkAdd(mock.Anything, vlandev).Return(nil).Once()
 				mac := net.HardwareAddr(testSvi.Spec.MacAddress[:])
 				mockNetlink.EXPECT().LinkSetHardwareAddr(mock.Anything, vlandev, mac).Return(nil).Once()
@@ -325,14 +326,14 @@ func Test_CreateSvi(t *testing.T) {
 				mockNetlink.EXPECT().LinkByName(mock.Anything, tenantbridgeName).Return(bridge, nil).Once()
 				mockNetlink.EXPECT().BridgeVlanAdd(mock.Anything, bridge, vid, false, false, true, false).Return(nil).Once()
 				vlanName := fmt.Sprintf("vlan%d", vid)
-				vlandev := &netlink.Vlan{LinkAttrs: netlink.LinkAttrs{Name: vlanName, ParentIndex: bridge.Attrs().Index}, VlanId: int(vid)}
+				vlandev := &netlink.Vlan{LinkAttrs: netlink.LinkAttrs{Name: vlanName, ParentIndex: bridge.Attrs().Index, Alias: managedAlias}, VlanId: int(vid)}
 				mockNetlink.EXPECT().LinkAdd(mock.Anything, vlandev).Return(nil).Once()
 				mac := net.HardwareAddr(testSvi.Spec.MacAddress[:])
 				mockNetlink.EXPECT().LinkSetHardwareAddr(mock.Anything, vlandev, mac).Return(nil).Once()
 				myip := make(net.IP, 4)
 				addr := &netlink.Addr{IPNet: &net.IPNet{IP: myip, Mask: net.CIDRMask(24, 32)}}
 				mockNetlink.EXPECT().AddrAdd(mock.Anything, vlandev, addr).Return(nil).Once()
-				vrfdev := &netlink.Vrf{LinkAttrs: netlink.LinkAttrs{Name: testVrfID}, Table: 1001}
+				vrfdev := &netlink.Vrf{LinkAttrs: netlink.LinkAttrs{Name: testVrfID, Alias: managedAlias}, Table: 1001}
 				mockNetlink.EXPECT().LinkByName(mock.Anything, testVrfID).Return(vrfdev, nil).Once()
 				mockNetlink.EXPECT().LinkSetMaster(mock.Anything, vlandev, vrfdev).Return(errors.New(errMsg)).Once()
 			},
@@ -350,14 +351,14 @@ func Test_CreateSvi(t *testing.T) {
 				mockNetlink.EXPECT().LinkByName(mock.Anything, tenantbridgeName).Return(bridge, nil).Once()
 				mockNetlink.EXPECT().BridgeVlanAdd(mock.Anything, bridge, vid, false, false, true, false).Return(nil).Once()
 				vlanName := fmt.Sprintf("vlan%d", vid)
-				vlandev := &netlink.Vlan{LinkAttrs: netlink.LinkAttrs{Name: vlanName, ParentIndex: bridge.Attrs().Index}, VlanId: int(vid)}
+				vlandev := &netlink.Vlan{LinkAttrs: netlink.LinkAttrs{Name: vlanName, ParentIndex: bridge.Attrs().Index, Alias: managedAlias}, VlanId: int(vid)}
 				mockNetlink.EXPECT().LinkAdd(mock.Anything, vlandev).Return(nil).Once()
 				mac := net.HardwareAddr(testSvi.Spec.MacAddress[:])
 				mockNetlink.EXPECT().LinkSetHardwareAddr(mock.Anything, vlandev, mac).Return(nil).Once()
 				myip := make(net.IP, 4)
 				addr := &netlink.Addr{IPNet: &net.IPNet{IP: myip, Mask: net.CIDRMask(24, 32)}}
 				mockNetlink.EXPECT().AddrAdd(mock.Anything, vlandev, addr).Return(nil).Once()
-				vrfdev := &netlink.Vrf{LinkAttrs: netlink.LinkAttrs{Name: testVrfID}, Table: 1001}
+				vrfdev := &netlink.Vrf{LinkAttrs: netlink.LinkAttrs{Name: testVrfID, Alias: managedAlias}, Table: 1001}
 				mockNetlink.EXPECT().LinkByName(mock.Anything, testVrfID).Return(vrfdev, nil).Once()
 				mockNetlink.EXPECT().LinkSetMaster(mock.Anything, vlandev, vrfdev).Return(nil).Once()
 				mockNetlink.EXPECT().LinkSetUp(mock.Anything, vlandev).Return(errors.New(errMsg)).Once()
@@ -366,7 +367,7 @@ func Test_CreateSvi(t *testing.T) {
 		"successful call": {
 			id:      testSviID,
 			in:      &testSvi,
-			out:     &testSviWithStatus,
+			out:     &pb.Svi{Spec: testSvi.Spec, Status: &pb.SviStatus{}},
 			errCode: codes.OK,
 			errMsg:  "",
 			exist:   false,
@@ -376,14 +377,14 @@ func Test_CreateSvi(t *testing.T) {
 				mockNetlink.EXPECT().LinkByName(mock.Anything, tenantbridgeName).Return(bridge, nil).Once()
 				mockNetlink.EXPECT().BridgeVlanAdd(mock.Anything, bridge, vid, false, false, true, false).Return(nil).Once()
 				vlanName := fmt.Sprintf("vlan%d", vid)
-				vlandev := &netlink.Vlan{LinkAttrs: netlink.LinkAttrs{Name: vlanName, ParentIndex: bridge.Attrs().Index}, VlanId: int(vid)}
+				vlandev := &netlink.Vlan{LinkAttrs: netlink.LinkAttrs{Name: vlanName, ParentIndex: bridge.Attrs().Index, Alias: managedAlias}, VlanId: int(vid)}
 				mockNetlink.EXPECT().LinkAdd(mock.Anything, vlandev).Return(nil).Once()
 				mac := net.HardwareAddr(testSvi.Spec.MacAddress[:])
 				mockNetlink.EXPECT().LinkSetHardwareAddr(mock.Anything, vlandev, mac).Return(nil).Once()
 				myip := make(net.IP, 4)
 				addr := &netlink.Addr{IPNet: &net.IPNet{IP: myip, Mask: net.CIDRMask(24, 32)}}
 				mockNetlink.EXPECT().AddrAdd(mock.Anything, vlandev, addr).Return(nil).Once()
-				vrfdev := &netlink.Vrf{LinkAttrs: netlink.LinkAttrs{Name: testVrfID}, Table: 1001}
+				vrfdev := &netlink.Vrf{LinkAttrs: netlink.LinkAttrs{Name: testVrfID, Alias: managedAlias}, Table: 1001}
 				mockNetlink.EXPECT().LinkByName(mock.Anything, testVrfID).Return(vrfdev, nil).Once()
 				mockNetlink.EXPECT().LinkSetMaster(mock.Anything, vlandev, vrfdev).Return(nil).Once()
 				mockNetlink.EXPECT().LinkSetUp(mock.Anything, vlandev).Return(nil).Once()
@@ -533,7 +534,7 @@ func Test_DeleteSvi(t *testing.T) {
 				vid := uint16(testLogicalBridge.Spec.VlanId)
 				mockNetlink.EXPECT().BridgeVlanDel(mock.Anything, bridge, vid, false, false, true, false).Return(nil).Once()
 				vlanName := fmt.Sprintf("vlan%d", vid)
-				vlandev := &netlink.Vlan{LinkAttrs: netlink.LinkAttrs{Name: vlanName, ParentIndex: bridge.Attrs().Index}, VlanId: int(vid)}
+				vlandev := &netlink.Vlan{LinkAttrs: netlink.LinkAttrs{Name: vlanName, ParentIndex: bridge.Attrs().Index, Alias: managedAlias}, VlanId: int(vid)}
 				mockNetlink.EXPECT().LinkByName(mock.Anything, vlanName).Return(vlandev, nil).Once()
 				mockNetlink.EXPECT().LinkSetDown(mock.Anything, vlandev).Return(errors.New(errMsg)).Once()
 			},
@@ -550,7 +551,7 @@ func Test_DeleteSvi(t *testing.T) {
 				vid := uint16(testLogicalBridge.Spec.VlanId)
 				mockNetlink.EXPECT().BridgeVlanDel(mock.Anything, bridge, vid, false, false, true, false).Return(nil).Once()
 				vlanName := fmt.Sprintf("vlan%d", vid)
-				vlandev := &netlink.Vlan{LinkAttrs: netlink.LinkAttrs{Name: vlanName, ParentIndex: bridge.Attrs().Index}, VlanId: int(vid)}
+				vlandev := &netlink.Vlan{LinkAttrs: netlink.LinkAttrs{Name: vlanName, ParentIndex: bridge.Attrs().Index, Alias: managedAlias}, VlanId: int(vid)}
 				mockNetlink.EXPECT().LinkByName(mock.Anything, vlanName).Return(vlandev, nil).Once()
 				mockNetlink.EXPECT().LinkSetDown(mock.Anything, vlandev).Return(nil).Once()
 				mockNetlink.EXPECT().LinkDel(mock.Anything, vlandev).Return(errors.New(errMsg)).Once()
@@ -568,7 +569,7 @@ func Test_DeleteSvi(t *testing.T) {
 				vid := uint16(testLogicalBridge.Spec.VlanId)
 				mockNetlink.EXPECT().BridgeVlanDel(mock.Anything, bridge, vid, false, false, true, false).Return(nil).Once()
 				vlanName := fmt.Sprintf("vlan%d", vid)
-				vlandev := &netlink.Vlan{LinkAttrs: netlink.LinkAttrs{Name: vlanName, ParentIndex: bridge.Attrs().Index}, VlanId: int(vid)}
+				vlandev := &netlink.Vlan{LinkAttrs: netlink.LinkAttrs{Name: vlanName, ParentIndex: bridge.Attrs().Index, Alias: managedAlias}, VlanId: int(vid)}
 				mockNetlink.EXPECT().LinkByName(mock.Anything, vlanName).Return(vlandev, nil).Once()
 				mockNetlink.EXPECT().LinkSetDown(mock.Anything, vlandev).Return(nil).Once()
 				mockNetlink.EXPECT().LinkDel(mock.Anything, vlandev).Return(nil).Once()
@@ -883,7 +884,9 @@ func Test_ListSvis(t *testing.T) {
 			client := pb.NewSviServiceClient(conn)
 
 			opi.Svis[testSviName] = protoClone(&testSviWithStatus)
-			opi.Pagination["existing-pagination-token"] = 1
+			if err := opi.savePageToken("existing-pagination-token", 1, time.Now().Add(time.Minute)); err != nil {
+				t.Fatal(err)
+			}
 
 			request := &pb.ListSvisRequest{PageSize: tt.size, PageToken: tt.token}
 			response, err := client.ListSvis(ctx, request)
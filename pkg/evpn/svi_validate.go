@@ -5,9 +5,7 @@
 package evpn
 
 import (
-	"go.einride.tech/aip/fieldbehavior"
 	"go.einride.tech/aip/fieldmask"
-	"go.einride.tech/aip/resourceid"
 	"go.einride.tech/aip/resourcename"
 
 	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
@@ -15,20 +13,20 @@ import (
 
 func (s *Server) validateCreateSviRequest(in *pb.CreateSviRequest) error {
 	// check required fields
-	if err := fieldbehavior.ValidateRequiredFields(in); err != nil {
+	if err := validateRequiredFields(in); err != nil {
 		return err
 	}
 	// Validate that a LogicalBridge resource name conforms to the restrictions outlined in AIP-122.
-	if err := resourcename.Validate(in.Svi.Spec.LogicalBridge); err != nil {
+	if err := badRequest("svi.spec.logical_bridge", resourcename.Validate(in.Svi.Spec.LogicalBridge)); err != nil {
 		return err
 	}
 	// Validate that a Vrf resource name conforms to the restrictions outlined in AIP-122.
-	if err := resourcename.Validate(in.Svi.Spec.Vrf); err != nil {
+	if err := badRequest("svi.spec.vrf", resourcename.Validate(in.Svi.Spec.Vrf)); err != nil {
 		return err
 	}
 	// see https://google.aip.dev/133#user-specified-ids
 	if in.SviId != "" {
-		if err := resourceid.ValidateUserSettable(in.SviId); err != nil {
+		if err := badRequest("svi_id", validateResourceID(in.SviId, false)); err != nil {
 			return err
 		}
 	}
@@ -38,31 +36,31 @@ func (s *Server) validateCreateSviRequest(in *pb.CreateSviRequest) error {
 
 func (s *Server) validateDeleteSviRequest(in *pb.DeleteSviRequest) error {
 	// check required fields
-	if err := fieldbehavior.ValidateRequiredFields(in); err != nil {
+	if err := validateRequiredFields(in); err != nil {
 		return err
 	}
 	// Validate that a resource name conforms to the restrictions outlined in AIP-122.
-	return resourcename.Validate(in.Name)
+	return badRequest("name", resourcename.Validate(in.Name))
 }
 
 func (s *Server) validateUpdateSviRequest(in *pb.UpdateSviRequest) error {
 	// check required fields
-	if err := fieldbehavior.ValidateRequiredFields(in); err != nil {
+	if err := validateRequiredFields(in); err != nil {
 		return err
 	}
 	// update_mask = 2
-	if err := fieldmask.Validate(in.UpdateMask, in.Svi); err != nil {
+	if err := badRequest("update_mask", fieldmask.Validate(in.UpdateMask, in.Svi)); err != nil {
 		return err
 	}
 	// Validate that a resource name conforms to the restrictions outlined in AIP-122.
-	return resourcename.Validate(in.Svi.Name)
+	return badRequest("svi.name", resourcename.Validate(in.Svi.Name))
 }
 
 func (s *Server) validateGetSviRequest(in *pb.GetSviRequest) error {
 	// check required fields
-	if err := fieldbehavior.ValidateRequiredFields(in); err != nil {
+	if err := validateRequiredFields(in); err != nil {
 		return err
 	}
 	// Validate that a resource name conforms to the restrictions outlined in AIP-122.
-	return resourcename.Validate(in.Name)
+	return badRequest("name", resourcename.Validate(in.Name))
 }
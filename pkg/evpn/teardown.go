@@ -0,0 +1,114 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+)
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Teardown deletes every managed object from the kernel and FRR, children first,
+// used on exit when the kernel state should not outlive the bridge.
+// It is best effort: all objects are attempted and the first error is returned
+func (s *Server) Teardown(ctx context.Context) error {
+	var first error
+	check := func(name string, err error) {
+		if err != nil {
+			fmt.Printf("Failed to tear down %s: %v", name, err)
+			if first == nil {
+				first = err
+			}
+		}
+	}
+	log.Printf("Tearing down all managed objects")
+	for _, name := range sortedKeys(s.Policies) {
+		_, err := s.DeleteSecurityPolicy(ctx, &DeleteSecurityPolicyRequest{Name: name, AllowMissing: true})
+		check(name, err)
+	}
+	for _, name := range sortedKeys(s.NatRules) {
+		_, err := s.DeleteNatRule(ctx, &DeleteNatRuleRequest{Name: name, AllowMissing: true})
+		check(name, err)
+	}
+	for _, name := range sortedKeys(s.PbrRules) {
+		_, err := s.DeletePbrRule(ctx, &DeletePbrRuleRequest{Name: name, AllowMissing: true})
+		check(name, err)
+	}
+	for _, name := range sortedKeys(s.RouteLeaks) {
+		_, err := s.DeleteRouteLeak(ctx, &DeleteRouteLeakRequest{Name: name, AllowMissing: true})
+		check(name, err)
+	}
+	for _, name := range sortedKeys(s.Routes) {
+		_, err := s.DeleteRoute(ctx, &DeleteRouteRequest{Name: name, AllowMissing: true})
+		check(name, err)
+	}
+	for _, name := range sortedKeys(s.Handoffs) {
+		_, err := s.DeleteVrfLiteHandoff(ctx, &DeleteVrfLiteHandoffRequest{Name: name, AllowMissing: true})
+		check(name, err)
+	}
+	for _, name := range sortedKeys(s.FdbEntries) {
+		_, err := s.DeleteStaticFdbEntry(ctx, &DeleteStaticFdbEntryRequest{Name: name, AllowMissing: true})
+		check(name, err)
+	}
+	for _, name := range sortedKeys(s.Svis) {
+		_, err := s.DeleteSvi(ctx, &pb.DeleteSviRequest{Name: name, AllowMissing: true})
+		check(name, err)
+	}
+	for _, name := range sortedKeys(s.Ports) {
+		_, err := s.DeleteBridgePort(ctx, &pb.DeleteBridgePortRequest{Name: name, AllowMissing: true})
+		check(name, err)
+	}
+	for _, name := range sortedKeys(s.Bridges) {
+		_, err := s.DeleteLogicalBridge(ctx, &pb.DeleteLogicalBridgeRequest{Name: name, AllowMissing: true})
+		check(name, err)
+	}
+	for _, name := range sortedKeys(s.Vrfs) {
+		_, err := s.DeleteVrf(ctx, &pb.DeleteVrfRequest{Name: name, AllowMissing: true})
+		check(name, err)
+	}
+	// the underlay sessions go last, the overlay routes withdrawn above still need them
+	for _, name := range sortedKeys(s.BgpPeers) {
+		_, err := s.DeleteBgpPeer(ctx, &DeleteBgpPeerRequest{Name: name, AllowMissing: true})
+		check(name, err)
+	}
+	// the routing policies are only referenced by the sessions
+	for _, name := range sortedKeys(s.RouteMaps) {
+		_, err := s.DeleteRouteMap(ctx, &DeleteRouteMapRequest{Name: name, AllowMissing: true})
+		check(name, err)
+	}
+	for _, name := range sortedKeys(s.PrefixLists) {
+		_, err := s.DeletePrefixList(ctx, &DeletePrefixListRequest{Name: name, AllowMissing: true})
+		check(name, err)
+	}
+	// the protections go last, no tunnel is left to send in clear
+	for _, name := range sortedKeys(s.TunnelSecurities) {
+		_, err := s.DeleteTunnelSecurity(ctx, &DeleteTunnelSecurityRequest{Name: name, AllowMissing: true})
+		check(name, err)
+	}
+	// the underlay interfaces carry everything above, the loopback goes after the unnumbered
+	// uplinks borrowing its address
+	for _, role := range []UnderlayRole{UnderlayUplink, UnderlayLoopback} {
+		for _, name := range sortedKeys(s.UnderlayInterfaces) {
+			if s.UnderlayInterfaces[name].Spec.Role != role {
+				continue
+			}
+			_, err := s.DeleteUnderlayInterface(ctx, &DeleteUnderlayInterfaceRequest{Name: name, AllowMissing: true})
+			check(name, err)
+		}
+	}
+	return first
+}
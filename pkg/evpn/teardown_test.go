@@ -0,0 +1,73 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/philippgille/gokv/gomap"
+	"github.com/stretchr/testify/mock"
+	"github.com/vishvananda/netlink"
+
+	"google.golang.org/grpc/status"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils/mocks"
+)
+
+func Test_Teardown(t *testing.T) {
+	tests := map[string]struct {
+		errMsg string
+		on     func(mockNetlink *mocks.Netlink, mockFrr *mocks.Frr, errMsg string)
+	}{
+		"failed LinkByName call": {
+			errMsg: "unable to find key " + testVrfID,
+			on: func(mockNetlink *mocks.Netlink, mockFrr *mocks.Frr, errMsg string) {
+				mockFrr.EXPECT().FrrBgpCmd(mock.Anything, mock.Anything).Return("", nil).Once()
+				mockNetlink.EXPECT().LinkByName(mock.Anything, testVrfID).Return(nil, errors.New(errMsg)).Once()
+			},
+		},
+		"successful call": {
+			errMsg: "",
+			on: func(mockNetlink *mocks.Netlink, mockFrr *mocks.Frr, errMsg string) {
+				vrf := &netlink.Vrf{LinkAttrs: netlink.LinkAttrs{Name: testVrfID, Alias: managedAlias}, Table: 1000}
+				mockFrr.EXPECT().FrrBgpCmd(mock.Anything, mock.Anything).Return("", nil).Once()
+				mockNetlink.EXPECT().LinkByName(mock.Anything, testVrfID).Return(vrf, nil).Once()
+				mockNetlink.EXPECT().LinkSetDown(mock.Anything, vrf).Return(nil).Once()
+				mockNetlink.EXPECT().LinkDel(mock.Anything, vrf).Return(nil).Once()
+			},
+		},
+	}
+
+	// run tests
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			mockNetlink := mocks.NewNetlink(t)
+			mockFrr := mocks.NewFrr(t)
+			store := gomap.NewStore(gomap.DefaultOptions)
+			opi := NewServerWithArgs(mockNetlink, mockFrr, store)
+
+			// vrf without vni only needs the vrf device removed
+			opi.Vrfs[testVrfName] = &pb.Vrf{Name: testVrfName, Spec: &pb.VrfSpec{}}
+			opi.RouteLeaks[testRouteLeakName] = testRouteLeakWithName.clone()
+			tt.on(mockNetlink, mockFrr, tt.errMsg)
+
+			err := opi.Teardown(ctx)
+			if er := status.Convert(err); er.Message() != tt.errMsg {
+				t.Error("error message: expected", tt.errMsg, "received", er.Message())
+			}
+			if len(opi.RouteLeaks) != 0 {
+				t.Error("expected route leaks to be torn down, received", opi.RouteLeaks)
+			}
+			if tt.errMsg == "" && len(opi.Vrfs) != 0 {
+				t.Error("expected vrfs to be torn down, received", opi.Vrfs)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// InterfaceState is the oper-status and the counters of a kernel interface, for the telemetry
+// collectors
+type InterfaceState struct {
+	Up bool
+	// Counters are keyed by their openconfig-interfaces name, e.g. in-octets
+	Counters map[string]uint64
+}
+
+// InterfaceStates returns the state of every kernel interface, keyed by name
+func (s *Server) InterfaceStates(ctx context.Context) (map[string]InterfaceState, error) {
+	links, err := s.nLink.LinkList(ctx)
+	if err != nil {
+		return nil, err
+	}
+	states := make(map[string]InterfaceState, len(links))
+	for _, link := range links {
+		attrs := link.Attrs()
+		state := InterfaceState{Up: linkIsUp(attrs), Counters: map[string]uint64{}}
+		if stats := attrs.Statistics; stats != nil {
+			state.Counters = map[string]uint64{
+				"in-octets":         stats.RxBytes,
+				"in-pkts":           stats.RxPackets,
+				"in-errors":         stats.RxErrors,
+				"in-discards":       stats.RxDropped,
+				"in-multicast-pkts": stats.Multicast,
+				"out-octets":        stats.TxBytes,
+				"out-pkts":          stats.TxPackets,
+				"out-errors":        stats.TxErrors,
+				"out-discards":      stats.TxDropped,
+			}
+		}
+		states[attrs.Name] = state
+	}
+	return states, nil
+}
+
+// ObjectState is the oper-status and the spec of a LogicalBridge, BridgePort, Vrf or Svi
+type ObjectState struct {
+	// Kind is logical-bridge, bridge-port, vrf or svi
+	Kind string
+	// OperStatus is the name of the oper-status of the object, e.g. LB_OPER_STATUS_UP
+	OperStatus string
+	Spec       proto.Message
+}
+
+// ObjectStates returns the state of the objects of the tenant of the call, all of them without
+// one, keyed by name. The oper-status is computed from the live state, as by Get and List
+func (s *Server) ObjectStates(ctx context.Context) map[string]ObjectState {
+	s.objectsMu.RLock()
+	defer s.objectsMu.RUnlock()
+	states := map[string]ObjectState{}
+	// the degraded header is only reported to the gRPC calls
+	degraded := map[string]error{}
+	for name, obj := range s.Bridges {
+		if inTenant(ctx, name) {
+			states[name] = ObjectState{Kind: "logical-bridge", OperStatus: s.logicalBridgeOperStatus(ctx, obj, degraded).String(), Spec: protoClone(obj.GetSpec())}
+		}
+	}
+	for name, obj := range s.Ports {
+		if inTenant(ctx, name) {
+			states[name] = ObjectState{Kind: "bridge-port", OperStatus: s.bridgePortOperStatus(ctx, obj, degraded).String(), Spec: protoClone(obj.GetSpec())}
+		}
+	}
+	for name, obj := range s.Vrfs {
+		if inTenant(ctx, name) {
+			states[name] = ObjectState{Kind: "vrf", OperStatus: s.vrfOperStatus(ctx, obj, degraded).String(), Spec: protoClone(obj.GetSpec())}
+		}
+	}
+	for name, obj := range s.Svis {
+		if inTenant(ctx, name) {
+			states[name] = ObjectState{Kind: "svi", OperStatus: s.sviOperStatus(ctx, obj, degraded).String(), Spec: protoClone(obj.GetSpec())}
+		}
+	}
+	return states
+}
@@ -0,0 +1,126 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils"
+)
+
+// Objects created by a call scoped to a tenant, see utils.TenantMetadataKey, are named
+// //network.opiproject.org/tenants/{tenant}/{collection}/{id}, they can only reference the
+// objects of the same tenant and count against the quotas of their tenant. The objects
+// created without a tenant keep their global names and only reference global objects
+
+// tenantQuotaTypes are the object types a tenant quota can be set for
+var tenantQuotaTypes = map[string]bool{
+	"LogicalBridge": true,
+	"Vrf":           true,
+}
+
+// ParseTenantQuotas parses the max number of objects of each type a tenant can create,
+// in ObjectType=N,ObjectType=N format (e.g.: LogicalBridge=10,Vrf=2)
+func ParseTenantQuotas(value string) (map[string]int, error) {
+	return parseQuotas(value, tenantQuotaTypes)
+}
+
+// tenantFullName is resourceIDToFullName for the objects of a tenant, global when tenant is empty
+func tenantFullName(tenant string, container string, resourceID string) string {
+	if tenant == "" {
+		return resourceIDToFullName(container, resourceID)
+	}
+	return resourceIDToFullName("tenants", fmt.Sprintf("%s/%s/%s", tenant, container, resourceID))
+}
+
+// tenantOf returns the tenant of an object name, empty for a global object
+func tenantOf(name string) string {
+	prefix := resourceIDToFullName("tenants", "")
+	if !strings.HasPrefix(name, prefix) {
+		return ""
+	}
+	tenant, _, _ := strings.Cut(strings.TrimPrefix(name, prefix), "/")
+	return tenant
+}
+
+// newObjectName returns the full name of an object created by the call, in its tenant
+func newObjectName(ctx context.Context, container string, resourceID string) (string, error) {
+	tenant := utils.TenantFromContext(ctx)
+	if tenant != "" {
+		if err := validateResourceID(tenant, false); err != nil {
+			err = status.Errorf(codes.InvalidArgument, "invalid tenant %s: %v", tenant, status.Convert(err).Message())
+			return "", badRequest(utils.TenantMetadataKey, err)
+		}
+	}
+	return tenantFullName(tenant, container, resourceID), nil
+}
+
+// inTenant reports whether List returns the object to the call, all objects are
+// returned to the calls not scoped to a tenant
+func inTenant(ctx context.Context, name string) bool {
+	tenant := utils.TenantFromContext(ctx)
+	return tenant == "" || tenantOf(name) == tenant
+}
+
+// withTenantOf scopes ctx to the tenant of the object, to create it again under the same name
+func withTenantOf(ctx context.Context, name string) context.Context {
+	md, _ := metadata.FromIncomingContext(ctx)
+	md = md.Copy()
+	md.Delete(utils.TenantMetadataKey)
+	if tenant := tenantOf(name); tenant != "" {
+		md.Set(utils.TenantMetadataKey, tenant)
+	}
+	return metadata.NewIncomingContext(ctx, md)
+}
+
+// checkSameTenant refuses to let an object reference the objects of another tenant,
+// the empty references are left to the other checks
+func checkSameTenant(name string, refs ...string) error {
+	for _, ref := range refs {
+		if ref != "" && tenantOf(ref) != tenantOf(name) {
+			err := status.Errorf(codes.PermissionDenied, "%s cannot reference %s of another tenant", name, ref)
+			return err
+		}
+	}
+	return nil
+}
+
+// checkTenantQuota fails with ResourceExhausted when the tenant of a new object already
+// has as many objects of its type as its quota allows
+func (s *Server) checkTenantQuota(name string, objectType string) error {
+	tenant := tenantOf(name)
+	quota, ok := s.TenantQuotas[objectType]
+	if tenant == "" || !ok {
+		return nil
+	}
+	count := 0
+	switch objectType {
+	case "LogicalBridge":
+		count = countInTenant(s.Bridges, tenant)
+	case "Vrf":
+		count = countInTenant(s.Vrfs, tenant)
+	}
+	if count >= quota {
+		msg := fmt.Sprintf("tenant %s already has %d %s objects, its quota", tenant, count, objectType)
+		return status.Error(codes.ResourceExhausted, msg)
+	}
+	return nil
+}
+
+func countInTenant[T any](objects map[string]T, tenant string) int {
+	count := 0
+	for name := range objects {
+		if tenantOf(name) == tenant {
+			count++
+		}
+	}
+	return count
+}
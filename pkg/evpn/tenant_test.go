@@ -0,0 +1,235 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"testing"
+
+	"github.com/philippgille/gokv/gomap"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+	pc "github.com/opiproject/opi-api/network/opinetcommon/v1alpha1/gen/go"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils"
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils/mocks"
+)
+
+func tenantContext(tenant string) context.Context {
+	return metadata.NewIncomingContext(context.Background(), metadata.Pairs(utils.TenantMetadataKey, tenant))
+}
+
+func Test_tenantOf(t *testing.T) {
+	tests := map[string]struct {
+		name   string
+		tenant string
+	}{
+		"global object": {
+			name:   resourceIDToFullName("vrfs", "blue"),
+			tenant: "",
+		},
+		"tenant object": {
+			name:   tenantFullName("acme", "vrfs", "blue"),
+			tenant: "acme",
+		},
+	}
+	for testName, tt := range tests {
+		t.Run(testName, func(t *testing.T) {
+			if tenant := tenantOf(tt.name); tenant != tt.tenant {
+				t.Error("tenant: expected", tt.tenant, "received", tenant)
+			}
+		})
+	}
+	if name := tenantFullName("acme", "bridges", "bridge1"); name != "//network.opiproject.org/tenants/acme/bridges/bridge1" {
+		t.Error("name: expected //network.opiproject.org/tenants/acme/bridges/bridge1, received", name)
+	}
+}
+
+func Test_TenantIsolation(t *testing.T) {
+	opi := NewServerWithArgs(mocks.NewNetlink(t), mocks.NewFrr(t), gomap.NewStore(gomap.DefaultOptions))
+	otherVrf := tenantFullName("other", "vrfs", "blue")
+	bridge := tenantFullName("acme", "bridges", "bridge1")
+	opi.Vrfs[otherVrf] = &pb.Vrf{Name: otherVrf, Spec: &pb.VrfSpec{}}
+	opi.Bridges[bridge] = &pb.LogicalBridge{Name: bridge, Spec: &pb.LogicalBridgeSpec{VlanId: 10}}
+
+	// an Svi of acme cannot attach to the Vrf of another tenant
+	_, err := opi.CreateSvi(tenantContext("acme"), &pb.CreateSviRequest{SviId: "svi1", Svi: &pb.Svi{Spec: &pb.SviSpec{
+		Vrf:           otherVrf,
+		LogicalBridge: bridge,
+		MacAddress:    []byte{0xCB, 0xB8, 0x33, 0x4C, 0x88, 0x4F},
+		GwIpPrefix:    []*pc.IPPrefix{{Len: 24}},
+	}}})
+	if status.Code(err) != codes.PermissionDenied {
+		t.Error("error: expected", codes.PermissionDenied, "received", err)
+	}
+	// nor can a global BridgePort attach to the LogicalBridge of a tenant
+	_, err = opi.CreateBridgePort(context.Background(), &pb.CreateBridgePortRequest{BridgePortId: "eth1", BridgePort: &pb.BridgePort{Spec: &pb.BridgePortSpec{
+		MacAddress:     []byte{0xCB, 0xB8, 0x33, 0x4C, 0x88, 0x4F},
+		Ptype:          pb.BridgePortType_ACCESS,
+		LogicalBridges: []string{bridge},
+	}}})
+	if status.Code(err) != codes.PermissionDenied {
+		t.Error("error: expected", codes.PermissionDenied, "received", err)
+	}
+}
+
+func Test_CrossTenantAccess(t *testing.T) {
+	opi := NewServerWithArgs(mocks.NewNetlink(t), mocks.NewFrr(t), gomap.NewStore(gomap.DefaultOptions))
+	prefix := &pc.IPPrefix{Addr: &pc.IPAddress{Af: pc.IpAf_IP_AF_INET, V4OrV6: &pc.IPAddress_V4Addr{V4Addr: 0x0a000001}}, Len: 24}
+	mac := []byte{0xCB, 0xB8, 0x33, 0x4C, 0x88, 0x4F}
+	vrf := &pb.Vrf{Name: tenantFullName("other", "vrfs", "blue"), Spec: &pb.VrfSpec{Vni: proto.Uint32(1000), LoopbackIpPrefix: prefix, VtepIpPrefix: prefix}}
+	bridge := &pb.LogicalBridge{Name: tenantFullName("other", "bridges", "bridge1"), Spec: &pb.LogicalBridgeSpec{VlanId: 10, Vni: proto.Uint32(10), VtepIpPrefix: prefix}}
+	port := &pb.BridgePort{Name: tenantFullName("other", "ports", "eth1"), Spec: &pb.BridgePortSpec{MacAddress: mac, Ptype: pb.BridgePortType_ACCESS, LogicalBridges: []string{bridge.Name}}}
+	svi := &pb.Svi{Name: tenantFullName("other", "svis", "svi1"), Spec: &pb.SviSpec{Vrf: vrf.Name, LogicalBridge: bridge.Name, MacAddress: mac, GwIpPrefix: []*pc.IPPrefix{prefix}}}
+	opi.Vrfs[vrf.Name] = vrf
+	opi.Bridges[bridge.Name] = bridge
+	opi.Ports[port.Name] = port
+	opi.Svis[svi.Name] = svi
+
+	// the objects of another tenant do not exist for acme, whatever the call
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(utils.TenantMetadataKey, "acme", utils.IfMatchMetadataKey, "W/\"1\""))
+	tests := map[string]func() error{
+		"GetVrf": func() error {
+			_, err := opi.GetVrf(ctx, &pb.GetVrfRequest{Name: vrf.Name})
+			return err
+		},
+		"UpdateVrf": func() error {
+			_, err := opi.UpdateVrf(ctx, &pb.UpdateVrfRequest{Vrf: protoClone(vrf)})
+			return err
+		},
+		"DeleteVrf": func() error {
+			_, err := opi.DeleteVrf(ctx, &pb.DeleteVrfRequest{Name: vrf.Name})
+			return err
+		},
+		"GetLogicalBridge": func() error {
+			_, err := opi.GetLogicalBridge(ctx, &pb.GetLogicalBridgeRequest{Name: bridge.Name})
+			return err
+		},
+		"UpdateLogicalBridge": func() error {
+			_, err := opi.UpdateLogicalBridge(ctx, &pb.UpdateLogicalBridgeRequest{LogicalBridge: protoClone(bridge)})
+			return err
+		},
+		"DeleteLogicalBridge": func() error {
+			_, err := opi.DeleteLogicalBridge(ctx, &pb.DeleteLogicalBridgeRequest{Name: bridge.Name})
+			return err
+		},
+		"GetBridgePort": func() error {
+			_, err := opi.GetBridgePort(ctx, &pb.GetBridgePortRequest{Name: port.Name})
+			return err
+		},
+		"UpdateBridgePort": func() error {
+			_, err := opi.UpdateBridgePort(ctx, &pb.UpdateBridgePortRequest{BridgePort: protoClone(port)})
+			return err
+		},
+		"DeleteBridgePort": func() error {
+			_, err := opi.DeleteBridgePort(ctx, &pb.DeleteBridgePortRequest{Name: port.Name})
+			return err
+		},
+		"GetSvi": func() error {
+			_, err := opi.GetSvi(ctx, &pb.GetSviRequest{Name: svi.Name})
+			return err
+		},
+		"UpdateSvi": func() error {
+			_, err := opi.UpdateSvi(ctx, &pb.UpdateSviRequest{Svi: protoClone(svi)})
+			return err
+		},
+		"DeleteSvi": func() error {
+			_, err := opi.DeleteSvi(ctx, &pb.DeleteSviRequest{Name: svi.Name})
+			return err
+		},
+	}
+	for testName, call := range tests {
+		t.Run(testName, func(t *testing.T) {
+			if err := call(); status.Code(err) != codes.NotFound {
+				t.Error("error: expected", codes.NotFound, "received", err)
+			}
+		})
+	}
+	// nor are they reported as changed by a stale etag
+	checked := context.WithValue(ctx, etagCheckedKey{}, true)
+	for _, name := range []string{vrf.Name, bridge.Name, port.Name, svi.Name} {
+		if err := opi.checkEtag(checked, name); err != nil {
+			t.Error(name, ": expected", nil, "received", err)
+		}
+	}
+	if _, err := opi.DeleteVrf(ctx, &pb.DeleteVrfRequest{Name: vrf.Name, AllowMissing: true}); err != nil {
+		t.Error("allowed missing: expected", nil, "received", err)
+	}
+	if len(opi.Vrfs) != 1 || len(opi.Bridges) != 1 || len(opi.Ports) != 1 || len(opi.Svis) != 1 {
+		t.Error("objects: expected kept, received", opi.Vrfs, opi.Bridges, opi.Ports, opi.Svis)
+	}
+}
+
+func Test_TenantQuota(t *testing.T) {
+	opi := NewServerWithArgs(mocks.NewNetlink(t), mocks.NewFrr(t), gomap.NewStore(gomap.DefaultOptions))
+	opi.TenantQuotas = map[string]int{"LogicalBridge": 1}
+	bridge := tenantFullName("acme", "bridges", "bridge1")
+	opi.Bridges[bridge] = &pb.LogicalBridge{Name: bridge, Spec: &pb.LogicalBridgeSpec{VlanId: 10}}
+
+	in := &pb.CreateLogicalBridgeRequest{LogicalBridgeId: "bridge2", LogicalBridge: &pb.LogicalBridge{Spec: &pb.LogicalBridgeSpec{VlanId: 20, Vni: proto.Uint32(20)}}}
+	_, err := opi.CreateLogicalBridge(tenantContext("acme"), in)
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Error("error: expected", codes.ResourceExhausted, "received", err)
+	}
+	// retrying the creation of an existing object does not count against the quota
+	in = &pb.CreateLogicalBridgeRequest{LogicalBridgeId: "bridge1", LogicalBridge: &pb.LogicalBridge{Spec: &pb.LogicalBridgeSpec{VlanId: 10}}}
+	if _, err = opi.CreateLogicalBridge(tenantContext("acme"), in); err != nil {
+		t.Error("error: expected", nil, "received", err)
+	}
+	_, err = opi.CreateLogicalBridge(tenantContext("Not_Valid"), in)
+	if status.Code(err) != codes.InvalidArgument {
+		t.Error("error: expected", codes.InvalidArgument, "received", err)
+	}
+}
+
+func Test_ListInTenant(t *testing.T) {
+	opi := NewServerWithArgs(mocks.NewNetlink(t), mocks.NewFrr(t), gomap.NewStore(gomap.DefaultOptions))
+	for _, name := range []string{
+		resourceIDToFullName("routeleaks", "leak1"),
+		tenantFullName("acme", "routeleaks", "leak2"),
+		tenantFullName("other", "routeleaks", "leak3"),
+	} {
+		opi.RouteLeaks[name] = &RouteLeak{Name: name, Spec: &RouteLeakSpec{}}
+	}
+	tests := map[string]struct {
+		ctx   context.Context
+		count int
+	}{
+		"all objects without tenant": {
+			ctx:   context.Background(),
+			count: 3,
+		},
+		"objects of the tenant": {
+			ctx:   tenantContext("acme"),
+			count: 1,
+		},
+	}
+	for testName, tt := range tests {
+		t.Run(testName, func(t *testing.T) {
+			response, err := opi.ListRouteLeaks(tt.ctx, &ListRouteLeaksRequest{})
+			if err != nil {
+				t.Fatal("error: expected", nil, "received", err)
+			}
+			if len(response.RouteLeaks) != tt.count {
+				t.Error("route leaks: expected", tt.count, "received", response.RouteLeaks)
+			}
+		})
+	}
+}
+
+func Test_ParseTenantQuotas(t *testing.T) {
+	quotas, err := ParseTenantQuotas("LogicalBridge=10,Vrf=2")
+	if err != nil || quotas["LogicalBridge"] != 10 || quotas["Vrf"] != 2 {
+		t.Error("quotas: expected LogicalBridge=10,Vrf=2, received", quotas, err)
+	}
+	if _, err := ParseTenantQuotas("Svi=1"); err == nil {
+		t.Error("error: expected unsupported object type, received", nil)
+	}
+}
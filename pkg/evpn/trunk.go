@@ -0,0 +1,233 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils"
+)
+
+// trunkVlansKey is the store key of the native, translated and service vlans of the TRUNK BridgePorts
+const trunkVlansKey = "trunkvlans"
+
+// TrunkVlans are the vlan options of a TRUNK BridgePort, by default the vlans of all its
+// LogicalBridges are tagged on the wire
+type TrunkVlans struct {
+	// Native is the vlan of the LogicalBridge the untagged frames belong to, none when 0
+	Native uint32
+	// Translations maps the external vlans seen on the wire to the vlans of the LogicalBridges
+	Translations map[uint32]uint32
+	// ServiceVlan is the outer 802.1ad tag of a service-tagged port, the vlans of the
+	// LogicalBridges being the inner customer tags, none when 0
+	ServiceVlan uint32
+}
+
+func (t TrunkVlans) empty() bool {
+	return t.Native == 0 && len(t.Translations) == 0 && t.ServiceVlan == 0
+}
+
+// External returns the vlan on the wire of the vlan of a LogicalBridge, when it is translated
+func (t TrunkVlans) External(internal uint32) (uint32, bool) {
+	for external, vlan := range t.Translations {
+		if vlan == internal {
+			return external, true
+		}
+	}
+	return 0, false
+}
+
+// String returns the translations in the external=internal format of the metadata
+func (t TrunkVlans) String() string {
+	externals := make([]int, 0, len(t.Translations))
+	for external := range t.Translations {
+		externals = append(externals, int(external))
+	}
+	sort.Ints(externals)
+	pairs := make([]string, 0, len(externals))
+	for _, external := range externals {
+		pairs = append(pairs, fmt.Sprintf("%d=%d", external, t.Translations[uint32(external)]))
+	}
+	return strings.Join(pairs, ",")
+}
+
+func parseVlanID(value string) (uint32, error) {
+	vlan, err := strconv.ParseUint(strings.TrimSpace(value), 10, 32)
+	if err != nil || vlan < 1 || vlan > 4094 {
+		return 0, fmt.Errorf("vlan %q have to be between 1 and 4094", value)
+	}
+	return uint32(vlan), nil
+}
+
+// parseVlanTranslations parses the external=internal pairs of the metadata
+func parseVlanTranslations(value string) (map[uint32]uint32, error) {
+	translations := make(map[uint32]uint32)
+	internals := make(map[uint32]bool)
+	for _, pair := range strings.Split(value, ",") {
+		external, internal, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("%q has to be in external=internal format", pair)
+		}
+		from, err := parseVlanID(external)
+		if err != nil {
+			return nil, err
+		}
+		to, err := parseVlanID(internal)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := translations[from]; ok || internals[to] {
+			return nil, fmt.Errorf("%q translates a vlan twice", pair)
+		}
+		translations[from] = to
+		internals[to] = true
+	}
+	return translations, nil
+}
+
+// trunkVlansFor returns the vlan options of a new BridgePort, sent with the call, or kept from
+// a previous incarnation of the same BridgePort (e.g.: on replay)
+func (s *Server) trunkVlansFor(ctx context.Context, obj *pb.BridgePort) (TrunkVlans, error) {
+	native, hasNative := utils.MetadataValue(ctx, utils.NativeVlanMetadataKey)
+	translation, hasTranslation := utils.MetadataValue(ctx, utils.VlanTranslationMetadataKey)
+	service, hasService := utils.MetadataValue(ctx, utils.ServiceVlanMetadataKey)
+	if !hasNative && !hasTranslation && !hasService {
+		return s.TrunkVlans[obj.Name], nil
+	}
+	trunk := TrunkVlans{}
+	if obj.Spec.Ptype != pb.BridgePortType_TRUNK {
+		msg := "a native vlan, vlan translations or a service vlan require a TRUNK BridgePort"
+		return trunk, badRequest("bridge_port.spec.ptype", status.Error(codes.InvalidArgument, msg))
+	}
+	if hasService && service != "" {
+		vlan, err := parseVlanID(service)
+		if err == nil && hasTranslation && translation != "" {
+			err = fmt.Errorf("%d cannot be combined with vlan translations", vlan)
+		}
+		if err != nil {
+			msg := fmt.Sprintf("invalid service vlan %v", err)
+			return trunk, badRequest(utils.ServiceVlanMetadataKey, status.Error(codes.InvalidArgument, msg))
+		}
+		trunk.ServiceVlan = vlan
+	}
+	// only the vlans of the LogicalBridges of the port can be selected
+	vlans := make(map[uint32]bool)
+	for _, bridgeRefName := range obj.Spec.LogicalBridges {
+		if bridge, ok := s.Bridges[bridgeRefName]; ok {
+			vlans[bridge.Spec.VlanId] = true
+		}
+	}
+	if hasNative && native != "" {
+		vlan, err := parseVlanID(native)
+		if err == nil && !vlans[vlan] {
+			err = fmt.Errorf("vlan %d is not the vlan of a LogicalBridge of the port", vlan)
+		}
+		if err != nil {
+			msg := fmt.Sprintf("invalid native vlan %v", err)
+			return trunk, badRequest(utils.NativeVlanMetadataKey, status.Error(codes.InvalidArgument, msg))
+		}
+		trunk.Native = vlan
+	}
+	if hasTranslation && translation != "" {
+		translations, err := parseVlanTranslations(translation)
+		if err != nil {
+			msg := fmt.Sprintf("invalid vlan translation %v", err)
+			return trunk, badRequest(utils.VlanTranslationMetadataKey, status.Error(codes.InvalidArgument, msg))
+		}
+		trunk.Translations = translations
+		for external, internal := range translations {
+			msg := ""
+			switch _, translated := trunk.External(external); {
+			case !vlans[internal]:
+				msg = fmt.Sprintf("vlan %d is not the vlan of a LogicalBridge of the port", internal)
+			case internal == trunk.Native:
+				msg = fmt.Sprintf("native vlan %d cannot be translated", internal)
+			case vlans[external] && !translated:
+				// the vlan would be seen twice on the wire, tagged as itself and as the translation
+				msg = fmt.Sprintf("vlan %d is already carried by the port", external)
+			}
+			if msg != "" {
+				msg = "invalid vlan translation " + msg
+				return trunk, badRequest(utils.VlanTranslationMetadataKey, status.Error(codes.InvalidArgument, msg))
+			}
+		}
+	}
+	return trunk, nil
+}
+
+// PortTrunkVlans returns the vlan options of the BridgePort, for the dataplanes
+func (s *Server) PortTrunkVlans(name string) TrunkVlans {
+	return s.TrunkVlans[name]
+}
+
+// portVlanKernelName returns the name of the VLAN sub-interface of the port receiving a
+// translated or service vlan, long port names do not fit in IFNAMSIZ
+func (s *Server) portVlanKernelName(obj *pb.BridgePort, vlan uint32) string {
+	wanted := fmt.Sprintf("%s.%d", s.portKernelName(obj.Name), vlan)
+	if len(wanted) <= maxKernelNameLength {
+		return wanted
+	}
+	return hashedKernelName(fmt.Sprintf("%s/vlans/%d", obj.Name, vlan), wanted, 0)
+}
+
+func (s *Server) persistTrunkVlans() {
+	fields := make(map[string]interface{}, len(s.TrunkVlans))
+	for name, trunk := range s.TrunkVlans {
+		fields[name] = map[string]interface{}{
+			"native":       float64(trunk.Native),
+			"translations": trunk.String(),
+			"service":      float64(trunk.ServiceVlan),
+		}
+	}
+	msg, err := structpb.NewStruct(fields)
+	if err == nil {
+		err = s.store.Set(trunkVlansKey, msg)
+	}
+	if err != nil {
+		fmt.Printf("Failed to persist %s: %v", trunkVlansKey, err)
+	}
+}
+
+// loadTrunkVlans restores the vlan options, so replayed BridgePorts keep the same vlans on the wire
+func (s *Server) loadTrunkVlans() error {
+	msg := &structpb.Struct{}
+	found, err := s.store.Get(trunkVlansKey, msg)
+	if err != nil || !found {
+		return err
+	}
+	for name, value := range msg.Fields {
+		fields := value.GetStructValue().GetFields()
+		trunk := TrunkVlans{
+			Native:      uint32(fields["native"].GetNumberValue()),
+			ServiceVlan: uint32(fields["service"].GetNumberValue()),
+		}
+		if translations := fields["translations"].GetStringValue(); translations != "" {
+			trunk.Translations, err = parseVlanTranslations(translations)
+			if err != nil {
+				return err
+			}
+		}
+		s.TrunkVlans[name] = trunk
+	}
+	return nil
+}
+
+// releaseTrunkVlans forgets a deleted BridgePort
+func (s *Server) releaseTrunkVlans(name string) {
+	if _, ok := s.TrunkVlans[name]; ok {
+		delete(s.TrunkVlans, name)
+		s.persistTrunkVlans()
+	}
+}
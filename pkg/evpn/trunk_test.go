@@ -0,0 +1,203 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"errors"
+	"net"
+	"reflect"
+	"testing"
+
+	"github.com/philippgille/gokv/gomap"
+	"github.com/stretchr/testify/mock"
+	"github.com/vishvananda/netlink"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils"
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils/mocks"
+)
+
+func Test_trunkVlansFor(t *testing.T) {
+	tests := map[string]struct {
+		md      metadata.MD
+		ptype   pb.BridgePortType
+		kept    bool
+		out     TrunkVlans
+		errCode codes.Code
+	}{
+		"default": {
+			md:    metadata.MD{},
+			ptype: pb.BridgePortType_TRUNK,
+			out:   TrunkVlans{},
+		},
+		"kept from a previous incarnation": {
+			md:    metadata.MD{},
+			ptype: pb.BridgePortType_TRUNK,
+			kept:  true,
+			out:   TrunkVlans{Native: 22},
+		},
+		"native vlan": {
+			md:    metadata.Pairs(utils.NativeVlanMetadataKey, "22"),
+			ptype: pb.BridgePortType_TRUNK,
+			out:   TrunkVlans{Native: 22},
+		},
+		"vlan translation": {
+			md:    metadata.Pairs(utils.VlanTranslationMetadataKey, "100=22"),
+			ptype: pb.BridgePortType_TRUNK,
+			out:   TrunkVlans{Translations: map[uint32]uint32{100: 22}},
+		},
+		"service vlan": {
+			md:    metadata.Pairs(utils.ServiceVlanMetadataKey, "300", utils.NativeVlanMetadataKey, "22"),
+			ptype: pb.BridgePortType_TRUNK,
+			out:   TrunkVlans{Native: 22, ServiceVlan: 300},
+		},
+		"service vlan with translations": {
+			md:      metadata.Pairs(utils.ServiceVlanMetadataKey, "300", utils.VlanTranslationMetadataKey, "100=22"),
+			ptype:   pb.BridgePortType_TRUNK,
+			errCode: codes.InvalidArgument,
+		},
+		"access port": {
+			md:      metadata.Pairs(utils.NativeVlanMetadataKey, "22"),
+			ptype:   pb.BridgePortType_ACCESS,
+			errCode: codes.InvalidArgument,
+		},
+		"native vlan of no LogicalBridge": {
+			md:      metadata.Pairs(utils.NativeVlanMetadataKey, "23"),
+			ptype:   pb.BridgePortType_TRUNK,
+			errCode: codes.InvalidArgument,
+		},
+		"translated native vlan": {
+			md:      metadata.Pairs(utils.NativeVlanMetadataKey, "22", utils.VlanTranslationMetadataKey, "100=22"),
+			ptype:   pb.BridgePortType_TRUNK,
+			errCode: codes.InvalidArgument,
+		},
+		"translated twice": {
+			md:      metadata.Pairs(utils.VlanTranslationMetadataKey, "100=22,200=22"),
+			ptype:   pb.BridgePortType_TRUNK,
+			errCode: codes.InvalidArgument,
+		},
+		"external vlan already carried": {
+			md:      metadata.Pairs(utils.VlanTranslationMetadataKey, "22=30"),
+			ptype:   pb.BridgePortType_TRUNK,
+			errCode: codes.InvalidArgument,
+		},
+		"invalid vlan": {
+			md:      metadata.Pairs(utils.VlanTranslationMetadataKey, "4095=22"),
+			ptype:   pb.BridgePortType_TRUNK,
+			errCode: codes.InvalidArgument,
+		},
+	}
+	for testName, tt := range tests {
+		t.Run(testName, func(t *testing.T) {
+			opi := NewServerWithArgs(mocks.NewNetlink(t), mocks.NewFrr(t), gomap.NewStore(gomap.DefaultOptions))
+			opi.Bridges[testLogicalBridgeName] = protoClone(&testLogicalBridgeWithStatus)
+			other := resourceIDToFullName("bridges", "other")
+			opi.Bridges[other] = &pb.LogicalBridge{Name: other, Spec: &pb.LogicalBridgeSpec{VlanId: 30}}
+			if tt.kept {
+				opi.TrunkVlans[testBridgePortName] = TrunkVlans{Native: 22}
+			}
+			port := &pb.BridgePort{Name: testBridgePortName, Spec: &pb.BridgePortSpec{Ptype: tt.ptype, LogicalBridges: []string{testLogicalBridgeName, other}}}
+			ctx := metadata.NewIncomingContext(context.Background(), tt.md)
+			trunk, err := opi.trunkVlansFor(ctx, port)
+			if status.Code(err) != tt.errCode {
+				t.Error("error: expected", tt.errCode, "received", err)
+			}
+			if err == nil && !reflect.DeepEqual(trunk, tt.out) {
+				t.Error("trunk vlans: expected", tt.out, "received", trunk)
+			}
+		})
+	}
+}
+
+func Test_netlinkCreateBridgePort_TrunkVlans(t *testing.T) {
+	tests := map[string]struct {
+		trunk   TrunkVlans
+		errCode codes.Code
+		on      func(mockNetlink *mocks.Netlink, iface netlink.Link, bridge netlink.Link)
+	}{
+		"native vlan": {
+			trunk: TrunkVlans{Native: 22},
+			on: func(mockNetlink *mocks.Netlink, iface netlink.Link, bridge netlink.Link) {
+				mockNetlink.EXPECT().BridgeVlanAdd(mock.Anything, iface, uint16(22), true, true, false, false).Return(nil).Once()
+				mockNetlink.EXPECT().LinkSetUp(mock.Anything, iface).Return(nil).Once()
+			},
+		},
+		"vlan translation": {
+			trunk: TrunkVlans{Translations: map[uint32]uint32{100: 22}},
+			on: func(mockNetlink *mocks.Netlink, iface netlink.Link, bridge netlink.Link) {
+				mockNetlink.EXPECT().LinkByName(mock.Anything, testBridgePortID+".100").Return(nil, errors.New("Link not found")).Once()
+				mockNetlink.EXPECT().LinkAdd(mock.Anything, mock.MatchedBy(func(link netlink.Link) bool {
+					vlan, ok := link.(*netlink.Vlan)
+					return ok && vlan.Name == testBridgePortID+".100" && vlan.VlanId == 100 && vlan.ParentIndex == 5
+				})).Return(nil).Once()
+				mockNetlink.EXPECT().LinkSetMaster(mock.Anything, mock.Anything, bridge).Return(nil).Once()
+				mockNetlink.EXPECT().BridgeVlanAdd(mock.Anything, mock.Anything, uint16(22), true, true, false, false).Return(nil).Once()
+				mockNetlink.EXPECT().LinkSetUp(mock.Anything, mock.Anything).Return(nil).Twice()
+			},
+		},
+		"service vlan": {
+			trunk: TrunkVlans{ServiceVlan: 300},
+			on: func(mockNetlink *mocks.Netlink, iface netlink.Link, bridge netlink.Link) {
+				svlan := &netlink.Vlan{LinkAttrs: netlink.LinkAttrs{Name: testBridgePortID + ".300", Index: 6}}
+				mockNetlink.EXPECT().LinkByName(mock.Anything, testBridgePortID+".300").Return(svlan, nil).Once()
+				mockNetlink.EXPECT().LinkSetMaster(mock.Anything, svlan, bridge).Return(nil).Once()
+				mockNetlink.EXPECT().BridgeVlanAdd(mock.Anything, svlan, uint16(22), false, false, false, false).Return(nil).Once()
+				mockNetlink.EXPECT().LinkSetUp(mock.Anything, iface).Return(nil).Once()
+				mockNetlink.EXPECT().LinkSetUp(mock.Anything, svlan).Return(nil).Once()
+			},
+		},
+		"created service vlan": {
+			trunk: TrunkVlans{ServiceVlan: 300},
+			on: func(mockNetlink *mocks.Netlink, iface netlink.Link, bridge netlink.Link) {
+				mockNetlink.EXPECT().LinkByName(mock.Anything, testBridgePortID+".300").Return(nil, errors.New("Link not found")).Once()
+				mockNetlink.EXPECT().LinkAdd(mock.Anything, mock.MatchedBy(func(link netlink.Link) bool {
+					vlan, ok := link.(*netlink.Vlan)
+					return ok && vlan.VlanId == 300 && vlan.VlanProtocol == netlink.VLAN_PROTOCOL_8021AD
+				})).Return(nil).Once()
+				mockNetlink.EXPECT().LinkSetMaster(mock.Anything, mock.Anything, bridge).Return(nil).Once()
+				mockNetlink.EXPECT().BridgeVlanAdd(mock.Anything, mock.Anything, uint16(22), false, false, false, false).Return(nil).Once()
+				mockNetlink.EXPECT().LinkSetUp(mock.Anything, mock.Anything).Return(nil).Twice()
+			},
+		},
+		"failed LinkAdd call": {
+			trunk:   TrunkVlans{Translations: map[uint32]uint32{100: 22}},
+			errCode: codes.Unknown,
+			on: func(mockNetlink *mocks.Netlink, iface netlink.Link, bridge netlink.Link) {
+				mockNetlink.EXPECT().LinkByName(mock.Anything, testBridgePortID+".100").Return(nil, errors.New("Link not found")).Once()
+				mockNetlink.EXPECT().LinkAdd(mock.Anything, mock.Anything).Return(errors.New("Failed to call LinkAdd")).Once()
+			},
+		},
+	}
+	for testName, tt := range tests {
+		t.Run(testName, func(t *testing.T) {
+			mockNetlink := mocks.NewNetlink(t)
+			opi := NewServerWithArgs(mockNetlink, mocks.NewFrr(t), gomap.NewStore(gomap.DefaultOptions))
+			opi.Bridges[testLogicalBridgeName] = protoClone(&testLogicalBridgeWithStatus)
+			opi.TrunkVlans[testBridgePortName] = tt.trunk
+
+			bridge := &netlink.Bridge{LinkAttrs: netlink.LinkAttrs{Name: tenantbridgeName}}
+			mockNetlink.EXPECT().LinkByName(mock.Anything, tenantbridgeName).Return(bridge, nil).Once()
+			iface := &netlink.Dummy{LinkAttrs: netlink.LinkAttrs{Name: testBridgePortID, Index: 5}}
+			mockNetlink.EXPECT().LinkByName(mock.Anything, testBridgePortID).Return(iface, nil).Once()
+			mac := net.HardwareAddr(testBridgePort.Spec.MacAddress[:])
+			mockNetlink.EXPECT().LinkSetHardwareAddr(mock.Anything, iface, mac).Return(nil).Once()
+			if tt.trunk.ServiceVlan == 0 {
+				mockNetlink.EXPECT().LinkSetMaster(mock.Anything, iface, bridge).Return(nil).Once()
+			}
+			tt.on(mockNetlink, iface, bridge)
+
+			err := opi.netlinkCreateBridgePort(context.Background(), protoClone(&testBridgePortWithStatus))
+			if status.Code(err) != tt.errCode {
+				t.Error("error: expected", tt.errCode, "received", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,239 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"encoding/binary"
+	"log"
+	"net"
+	"sort"
+
+	pc "github.com/opiproject/opi-api/network/opinetcommon/v1alpha1/gen/go"
+
+	"go.einride.tech/aip/resourceid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// TunnelSecurityMode is how the keys of the IPsec security associations of a TunnelSecurity are set
+type TunnelSecurityMode string
+
+const (
+	// TunnelSecurityStatic uses the SPIs and keys of the spec, mirrored on the remote VTEP
+	TunnelSecurityStatic TunnelSecurityMode = "static"
+	// TunnelSecurityIke negotiates and rotates the keys with IKEv2, through strongSwan, authenticated
+	// with the pre-shared key of the spec
+	TunnelSecurityIke TunnelSecurityMode = "ike"
+)
+
+// TunnelSecurity encrypts the UDP traffic between the VTEP of the gateway and a remote VTEP, i.e.
+// the vxlan and geneve tunnels to it, with IPsec ESP in transport mode, for underlays crossing
+// untrusted networks
+// TODO: move to opi-api once the message is agreed upon
+type TunnelSecurity struct {
+	Name string
+	Spec *TunnelSecuritySpec
+}
+
+// TunnelSecuritySpec is the desired configuration of a TunnelSecurity, the keys are write-only and
+// left empty in the responses
+type TunnelSecuritySpec struct {
+	// RemoteVtep is the IPv4 address of the remote VTEP, unique among the TunnelSecurities
+	RemoteVtep *pc.IPAddress
+	Mode       TunnelSecurityMode
+	// OutboundSpi and InboundSpi are the SPIs of the static security associations to and from the
+	// remote VTEP, the inbound one is unique among the TunnelSecurities
+	OutboundSpi uint32
+	InboundSpi  uint32
+	// OutboundKey and InboundKey are the hex encoded AES-GCM keys of the static security
+	// associations, followed by their 4 bytes salt, 20 or 36 bytes in total
+	OutboundKey string
+	InboundKey  string
+	// PreSharedKey authenticates the IKE sessions with the remote VTEP
+	PreSharedKey string
+}
+
+// CreateTunnelSecurityRequest is the request to create a TunnelSecurity
+type CreateTunnelSecurityRequest struct {
+	TunnelSecurityID string
+	TunnelSecurity   *TunnelSecurity
+}
+
+// DeleteTunnelSecurityRequest is the request to delete a TunnelSecurity
+type DeleteTunnelSecurityRequest struct {
+	Name         string
+	AllowMissing bool
+}
+
+// ListTunnelSecuritiesRequest is the request to list TunnelSecurities
+type ListTunnelSecuritiesRequest struct {
+	PageSize  int32
+	PageToken string
+}
+
+// ListTunnelSecuritiesResponse is the response of listing TunnelSecurities
+type ListTunnelSecuritiesResponse struct {
+	TunnelSecurities []*TunnelSecurity
+	NextPageToken    string
+}
+
+func (t *TunnelSecurity) clone() *TunnelSecurity {
+	if t == nil {
+		return nil
+	}
+	c := &TunnelSecurity{Name: t.Name}
+	if t.Spec != nil {
+		spec := *t.Spec
+		if t.Spec.RemoteVtep != nil {
+			spec.RemoteVtep = protoClone(t.Spec.RemoteVtep)
+		}
+		c.Spec = &spec
+	}
+	return c
+}
+
+// redacted returns a copy of the TunnelSecurity without its keys, for the responses
+func (t *TunnelSecurity) redacted() *TunnelSecurity {
+	c := t.clone()
+	if c != nil && c.Spec != nil {
+		c.Spec.OutboundKey = ""
+		c.Spec.InboundKey = ""
+		c.Spec.PreSharedKey = ""
+	}
+	return c
+}
+
+func sortTunnelSecurities(securities []*TunnelSecurity) {
+	sort.Slice(securities, func(i int, j int) bool {
+		return securities[i].Name < securities[j].Name
+	})
+}
+
+// tunnelSecurityRemote returns the remote VTEP of a TunnelSecurity
+func tunnelSecurityRemote(spec *TunnelSecuritySpec) net.IP {
+	remote := make(net.IP, 4)
+	binary.BigEndian.PutUint32(remote, spec.RemoteVtep.GetV4Addr())
+	return remote
+}
+
+// checkTunnelSecurityUnique checks no other TunnelSecurity protects the remote VTEP or uses the
+// inbound SPI, the kernel keys the inbound security associations by SPI only
+func (s *Server) checkTunnelSecurityUnique(obj *TunnelSecurity) error {
+	remote := tunnelSecurityRemote(obj.Spec)
+	for _, other := range s.TunnelSecurities {
+		if tunnelSecurityRemote(other.Spec).Equal(remote) {
+			return status.Errorf(codes.AlreadyExists, "remote VTEP %s is already protected by %s", remote, other.Name)
+		}
+		if obj.Spec.Mode == TunnelSecurityStatic && other.Spec.Mode == TunnelSecurityStatic &&
+			other.Spec.InboundSpi == obj.Spec.InboundSpi {
+			return status.Errorf(codes.AlreadyExists, "inbound SPI %#x is already used by %s", obj.Spec.InboundSpi, other.Name)
+		}
+	}
+	return nil
+}
+
+// CreateTunnelSecurity executes the creation of the IPsec protection of the tunnels to a remote VTEP
+func (s *Server) CreateTunnelSecurity(ctx context.Context, in *CreateTunnelSecurityRequest) (*TunnelSecurity, error) {
+	// check input correctness
+	if err := s.validateCreateTunnelSecurityRequest(in); err != nil {
+		return nil, err
+	}
+	if err := checkNoTenant(ctx); err != nil {
+		return nil, err
+	}
+	// see https://google.aip.dev/133#user-specified-ids
+	resourceID := resourceid.NewSystemGenerated()
+	if in.TunnelSecurityID != "" {
+		log.Printf("client provided the ID of a resource %v, ignoring the name field %v", in.TunnelSecurityID, in.TunnelSecurity.Name)
+		resourceID = in.TunnelSecurityID
+	}
+	in.TunnelSecurity.Name = resourceIDToFullName("tunnelsecurities", resourceID)
+	s.objectsMu.Lock()
+	defer s.objectsMu.Unlock()
+	// idempotent API when called with same key, should return same object
+	obj, ok := s.TunnelSecurities[in.TunnelSecurity.Name]
+	if ok {
+		// a different spec under the same key is a conflict, not a retry
+		if err := checkSameChildSpec(obj.Name, obj.Spec, in.TunnelSecurity.Spec); err != nil {
+			return nil, err
+		}
+		log.Printf("Already existing TunnelSecurity with id %v", in.TunnelSecurity.Name)
+		return obj.redacted(), nil
+	}
+	// the security associations are between the VTEP loopbacks
+	if s.Gateway.VtepIP == "" {
+		msg := "tunnel security requires the VTEP address of the gateway, see --vtep_ip"
+		return nil, status.Error(codes.FailedPrecondition, msg)
+	}
+	if err := s.checkTunnelSecurityUnique(in.TunnelSecurity); err != nil {
+		return nil, err
+	}
+	if err := s.dataplane.CreateTunnelSecurity(ctx, in.TunnelSecurity); err != nil {
+		s.forgetStatus(in.TunnelSecurity.Name)
+		return nil, status.Convert(err).Err()
+	}
+	// save object to the database
+	response := in.TunnelSecurity.clone()
+	s.TunnelSecurities[in.TunnelSecurity.Name] = response
+	persistObjects(s, "tunnelsecurities", s.TunnelSecurities)
+	return response.redacted(), nil
+}
+
+// DeleteTunnelSecurity deletes the IPsec protection, the tunnels to the remote VTEP are sent in clear again
+func (s *Server) DeleteTunnelSecurity(ctx context.Context, in *DeleteTunnelSecurityRequest) (*emptypb.Empty, error) {
+	// check input correctness
+	if err := s.validateDeleteTunnelSecurityRequest(in); err != nil {
+		return nil, err
+	}
+	if err := checkNoTenant(ctx); err != nil {
+		return nil, err
+	}
+	s.objectsMu.Lock()
+	defer s.objectsMu.Unlock()
+	// fetch object from the database
+	obj, ok := s.TunnelSecurities[in.Name]
+	if !ok {
+		if in.AllowMissing {
+			return &emptypb.Empty{}, nil
+		}
+		err := status.Errorf(codes.NotFound, "unable to find key %s", in.Name)
+		return nil, err
+	}
+	if err := s.dataplane.DeleteTunnelSecurity(ctx, obj); err != nil {
+		return nil, status.Convert(err).Err()
+	}
+	// remove from the Database
+	delete(s.TunnelSecurities, obj.Name)
+	persistObjects(s, "tunnelsecurities", s.TunnelSecurities)
+	s.forgetStatus(obj.Name)
+	return &emptypb.Empty{}, nil
+}
+
+// ListTunnelSecurities lists the IPsec protections of the tunnels, without their keys
+func (s *Server) ListTunnelSecurities(ctx context.Context, in *ListTunnelSecuritiesRequest) (*ListTunnelSecuritiesResponse, error) {
+	// fetch pagination from the database, calculate size and offset
+	size, offset, perr := s.extractPagination(in.PageSize, in.PageToken)
+	if perr != nil {
+		return nil, perr
+	}
+	s.objectsMu.RLock()
+	defer s.objectsMu.RUnlock()
+	// fetch object from the database, the following pages are cut from the snapshot of the first one
+	Blobarray, token, err := listPage(ctx, s, "tunnelSecurities", "", in.PageToken, offset, size, func() []*TunnelSecurity {
+		Blobarray := []*TunnelSecurity{}
+		for _, security := range s.TunnelSecurities {
+			Blobarray = append(Blobarray, security.redacted())
+		}
+		// sort is needed, since MAP is unsorted in golang, and we might get different results
+		sortTunnelSecurities(Blobarray)
+		return Blobarray
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &ListTunnelSecuritiesResponse{TunnelSecurities: Blobarray, NextPageToken: token}, nil
+}
@@ -0,0 +1,104 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net"
+	"syscall"
+
+	"github.com/vishvananda/netlink"
+)
+
+const (
+	// ipsecAead is the kernel name of the AES-GCM algorithm of the ESP security associations
+	ipsecAead = "rfc4106(gcm(aes))"
+	// ipsecIcvBits is the length of the integrity check value of AES-GCM
+	ipsecIcvBits = 128
+)
+
+// xfrmTunnelSecurityStates returns the outbound and inbound ESP security associations of a static TunnelSecurity
+func (s *Server) xfrmTunnelSecurityStates(obj *TunnelSecurity) []*netlink.XfrmState {
+	local, remote := net.ParseIP(s.Gateway.VtepIP).To4(), tunnelSecurityRemote(obj.Spec)
+	state := func(src, dst net.IP, spi uint32, key string) *netlink.XfrmState {
+		// the keys were validated with the request
+		raw, _ := hex.DecodeString(key)
+		return &netlink.XfrmState{
+			Src:   src,
+			Dst:   dst,
+			Proto: netlink.XFRM_PROTO_ESP,
+			Mode:  netlink.XFRM_MODE_TRANSPORT,
+			Spi:   int(spi),
+			Aead:  &netlink.XfrmStateAlgo{Name: ipsecAead, Key: raw, ICVLen: ipsecIcvBits},
+		}
+	}
+	return []*netlink.XfrmState{
+		state(local, remote, obj.Spec.OutboundSpi, obj.Spec.OutboundKey),
+		state(remote, local, obj.Spec.InboundSpi, obj.Spec.InboundKey),
+	}
+}
+
+// xfrmTunnelSecurityPolicies returns the policies requiring ESP for the UDP traffic to and from the
+// remote VTEP, the vxlan and geneve tunnels whatever their port
+func (s *Server) xfrmTunnelSecurityPolicies(obj *TunnelSecurity) []*netlink.XfrmPolicy {
+	local, remote := net.ParseIP(s.Gateway.VtepIP).To4(), tunnelSecurityRemote(obj.Spec)
+	policy := func(src, dst net.IP, dir netlink.Dir) *netlink.XfrmPolicy {
+		return &netlink.XfrmPolicy{
+			Src:   &net.IPNet{IP: src, Mask: net.CIDRMask(32, 32)},
+			Dst:   &net.IPNet{IP: dst, Mask: net.CIDRMask(32, 32)},
+			Proto: netlink.Proto(syscall.IPPROTO_UDP),
+			Dir:   dir,
+			Tmpls: []netlink.XfrmPolicyTmpl{{Src: src, Dst: dst, Proto: netlink.XFRM_PROTO_ESP, Mode: netlink.XFRM_MODE_TRANSPORT}},
+		}
+	}
+	return []*netlink.XfrmPolicy{
+		policy(local, remote, netlink.XFRM_DIR_OUT),
+		policy(remote, local, netlink.XFRM_DIR_IN),
+	}
+}
+
+func (s *Server) netlinkCreateTunnelSecurity(ctx context.Context, obj *TunnelSecurity) error {
+	// Example: ip xfrm state add src 10.0.0.1 dst 10.0.0.2 proto esp spi 0x1000 mode transport aead 'rfc4106(gcm(aes))' 0x... 128
+	for _, state := range s.xfrmTunnelSecurityStates(obj) {
+		log.Printf("Creating TunnelSecurity state to %v spi %#x", state.Dst, state.Spi)
+		if err := s.nLink.XfrmStateAdd(ctx, state); err != nil {
+			fmt.Printf("Failed to add xfrm state: %v", err)
+			return err
+		}
+	}
+	// the policies go last, the traffic is never dropped for lack of a security association
+	// Example: ip xfrm policy add src 10.0.0.1/32 dst 10.0.0.2/32 proto udp dir out tmpl src 10.0.0.1 dst 10.0.0.2 proto esp mode transport
+	for _, policy := range s.xfrmTunnelSecurityPolicies(obj) {
+		log.Printf("Creating TunnelSecurity policy %v", policy)
+		if err := s.nLink.XfrmPolicyAdd(ctx, policy); err != nil {
+			fmt.Printf("Failed to add xfrm policy: %v", err)
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Server) netlinkDeleteTunnelSecurity(ctx context.Context, obj *TunnelSecurity) error {
+	// Example: ip xfrm policy del src 10.0.0.1/32 dst 10.0.0.2/32 proto udp dir out
+	for _, policy := range s.xfrmTunnelSecurityPolicies(obj) {
+		log.Printf("Deleting TunnelSecurity policy %v", policy)
+		if err := s.nLink.XfrmPolicyDel(ctx, policy); err != nil {
+			fmt.Printf("Failed to delete xfrm policy: %v", err)
+			return err
+		}
+	}
+	// Example: ip xfrm state del src 10.0.0.1 dst 10.0.0.2 proto esp spi 0x1000
+	for _, state := range s.xfrmTunnelSecurityStates(obj) {
+		log.Printf("Deleting TunnelSecurity state to %v spi %#x", state.Dst, state.Spi)
+		if err := s.nLink.XfrmStateDel(ctx, state); err != nil {
+			fmt.Printf("Failed to delete xfrm state: %v", err)
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"path"
+)
+
+// swanctlConnection is the name of the strongSwan connection of a TunnelSecurity
+func swanctlConnection(obj *TunnelSecurity) string {
+	return "opi-" + path.Base(obj.Name)
+}
+
+// swanctlTunnelSecurity renders the swanctl configuration of an ike TunnelSecurity, the child SA
+// is trapped so the first packet of a tunnel to the remote VTEP starts the IKE negotiation
+func (s *Server) swanctlTunnelSecurity(obj *TunnelSecurity) string {
+	name, remote := swanctlConnection(obj), tunnelSecurityRemote(obj.Spec)
+	return fmt.Sprintf(`connections {
+	%[1]s {
+		version = 2
+		local_addrs = %[2]s
+		remote_addrs = %[3]s
+		local {
+			auth = psk
+			id = %[2]s
+		}
+		remote {
+			auth = psk
+			id = %[3]s
+		}
+		children {
+			%[1]s {
+				mode = transport
+				local_ts = dynamic[udp]
+				remote_ts = dynamic[udp]
+				esp_proposals = aes256gcm16-ecp256,aes128gcm16-ecp256
+				start_action = trap
+			}
+		}
+	}
+}
+secrets {
+	ike-%[1]s {
+		id = %[3]s
+		secret = "%[4]s"
+	}
+}
+`, name, s.Gateway.VtepIP, remote, obj.Spec.PreSharedKey)
+}
+
+func (s *Server) swanctlCreateTunnelSecurity(ctx context.Context, obj *TunnelSecurity) error {
+	name := swanctlConnection(obj)
+	log.Printf("Creating TunnelSecurity connection %v", name)
+	if err := s.ipsec.IpsecLoad(ctx, name, s.swanctlTunnelSecurity(obj)); err != nil {
+		fmt.Printf("Failed to load swanctl connection: %v", err)
+		return err
+	}
+	return nil
+}
+
+func (s *Server) swanctlDeleteTunnelSecurity(ctx context.Context, obj *TunnelSecurity) error {
+	name := swanctlConnection(obj)
+	log.Printf("Deleting TunnelSecurity connection %v", name)
+	if err := s.ipsec.IpsecUnload(ctx, name); err != nil {
+		fmt.Printf("Failed to unload swanctl connection: %v", err)
+		return err
+	}
+	return nil
+}
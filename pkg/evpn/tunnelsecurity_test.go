@@ -0,0 +1,389 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/philippgille/gokv/gomap"
+	"github.com/stretchr/testify/mock"
+	"github.com/vishvananda/netlink"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	pc "github.com/opiproject/opi-api/network/opinetcommon/v1alpha1/gen/go"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils/mocks"
+)
+
+var (
+	testTunnelSecurityID   = "opi-ipsec8"
+	testTunnelSecurityName = resourceIDToFullName("tunnelsecurities", testTunnelSecurityID)
+	testTunnelSecurityVtep = &pc.IPAddress{Af: pc.IpAf_IP_AF_INET, V4OrV6: &pc.IPAddress_V4Addr{V4Addr: 167772162}}
+	testTunnelSecurity     = TunnelSecurity{
+		Spec: &TunnelSecuritySpec{
+			RemoteVtep:  testTunnelSecurityVtep,
+			Mode:        TunnelSecurityStatic,
+			OutboundSpi: 0x1000,
+			InboundSpi:  0x2000,
+			OutboundKey: "000102030405060708090a0b0c0d0e0f10111213",
+			InboundKey:  "101112131415161718191a1b1c1d1e1f20212223",
+		},
+	}
+	testTunnelSecurityWithName = TunnelSecurity{
+		Name: testTunnelSecurityName,
+		Spec: testTunnelSecurity.Spec,
+	}
+	testTunnelSecurityRedacted = TunnelSecurity{
+		Name: testTunnelSecurityName,
+		Spec: &TunnelSecuritySpec{
+			RemoteVtep:  testTunnelSecurityVtep,
+			Mode:        TunnelSecurityStatic,
+			OutboundSpi: 0x1000,
+			InboundSpi:  0x2000,
+		},
+	}
+)
+
+// testTunnelSecurityState matches the security associations of testTunnelSecurity
+func testTunnelSecurityState(state *netlink.XfrmState) bool {
+	return state.Proto == netlink.XFRM_PROTO_ESP && state.Mode == netlink.XFRM_MODE_TRANSPORT &&
+		state.Aead != nil && state.Aead.Name == "rfc4106(gcm(aes))" && len(state.Aead.Key) == 20 &&
+		((state.Spi == 0x1000 && state.Dst.String() == "10.0.0.2") || (state.Spi == 0x2000 && state.Dst.String() == "10.0.0.1"))
+}
+
+// testTunnelSecurityPolicy matches the policies of testTunnelSecurity
+func testTunnelSecurityPolicy(policy *netlink.XfrmPolicy) bool {
+	return policy.Proto == 17 && len(policy.Tmpls) == 1 && policy.Tmpls[0].Proto == netlink.XFRM_PROTO_ESP &&
+		((policy.Dir == netlink.XFRM_DIR_OUT && policy.Dst.String() == "10.0.0.2/32") ||
+			(policy.Dir == netlink.XFRM_DIR_IN && policy.Dst.String() == "10.0.0.1/32"))
+}
+
+func Test_CreateTunnelSecurity(t *testing.T) {
+	tests := map[string]struct {
+		id      string
+		in      *TunnelSecurity
+		out     *TunnelSecurity
+		errCode codes.Code
+		errMsg  string
+		exist   bool
+		vtep    string
+		on      func(mockNetlink *mocks.Netlink, mockIpsec *mocks.Ipsec, errMsg string)
+	}{
+		"no required remote_vtep field": {
+			id:      testTunnelSecurityID,
+			in:      &TunnelSecurity{Spec: &TunnelSecuritySpec{Mode: TunnelSecurityStatic}},
+			out:     nil,
+			errCode: codes.InvalidArgument,
+			errMsg:  "missing required field: tunnel_security.spec.remote_vtep",
+			exist:   false,
+			vtep:    "10.0.0.1",
+			on:      nil,
+		},
+		"invalid mode": {
+			id:      testTunnelSecurityID,
+			in:      &TunnelSecurity{Spec: &TunnelSecuritySpec{RemoteVtep: testTunnelSecurityVtep, Mode: "gre"}},
+			out:     nil,
+			errCode: codes.InvalidArgument,
+			errMsg:  "Mode (gre) have to be static or ike",
+			exist:   false,
+			vtep:    "10.0.0.1",
+			on:      nil,
+		},
+		"reserved spi": {
+			id: testTunnelSecurityID,
+			in: &TunnelSecurity{Spec: &TunnelSecuritySpec{RemoteVtep: testTunnelSecurityVtep, Mode: TunnelSecurityStatic,
+				OutboundSpi: 255, InboundSpi: 0x2000}},
+			out:     nil,
+			errCode: codes.InvalidArgument,
+			errMsg:  "SPI (255) have to be at least 256",
+			exist:   false,
+			vtep:    "10.0.0.1",
+			on:      nil,
+		},
+		"short key": {
+			id: testTunnelSecurityID,
+			in: &TunnelSecurity{Spec: &TunnelSecuritySpec{RemoteVtep: testTunnelSecurityVtep, Mode: TunnelSecurityStatic,
+				OutboundSpi: 0x1000, InboundSpi: 0x2000, OutboundKey: "00010203"}},
+			out:     nil,
+			errCode: codes.InvalidArgument,
+			errMsg:  "key has to be 20 or 36 hex encoded bytes, the AES key followed by its salt",
+			exist:   false,
+			vtep:    "10.0.0.1",
+			on:      nil,
+		},
+		"same key in both directions": {
+			id: testTunnelSecurityID,
+			in: &TunnelSecurity{Spec: &TunnelSecuritySpec{RemoteVtep: testTunnelSecurityVtep, Mode: TunnelSecurityStatic,
+				OutboundSpi: 0x1000, InboundSpi: 0x2000, OutboundKey: testTunnelSecurity.Spec.OutboundKey, InboundKey: testTunnelSecurity.Spec.OutboundKey}},
+			out:     nil,
+			errCode: codes.InvalidArgument,
+			errMsg:  "inbound_key and outbound_key have to be different",
+			exist:   false,
+			vtep:    "10.0.0.1",
+			on:      nil,
+		},
+		"ike with keys": {
+			id: testTunnelSecurityID,
+			in: &TunnelSecurity{Spec: &TunnelSecuritySpec{RemoteVtep: testTunnelSecurityVtep, Mode: TunnelSecurityIke,
+				OutboundSpi: 0x1000, PreSharedKey: "0123456789abcdef"}},
+			out:     nil,
+			errCode: codes.InvalidArgument,
+			errMsg:  "the SPIs and keys of the ike mode are negotiated, only pre_shared_key can be set",
+			exist:   false,
+			vtep:    "10.0.0.1",
+			on:      nil,
+		},
+		"weak pre-shared key": {
+			id:      testTunnelSecurityID,
+			in:      &TunnelSecurity{Spec: &TunnelSecuritySpec{RemoteVtep: testTunnelSecurityVtep, Mode: TunnelSecurityIke, PreSharedKey: "secret"}},
+			out:     nil,
+			errCode: codes.InvalidArgument,
+			errMsg:  "pre_shared_key has to be at least 16 characters, without quotes, backslashes or newlines",
+			exist:   false,
+			vtep:    "10.0.0.1",
+			on:      nil,
+		},
+		"no vtep": {
+			id:      testTunnelSecurityID,
+			in:      &testTunnelSecurity,
+			out:     nil,
+			errCode: codes.FailedPrecondition,
+			errMsg:  "tunnel security requires the VTEP address of the gateway, see --vtep_ip",
+			exist:   false,
+			vtep:    "",
+			on:      nil,
+		},
+		"already exists": {
+			id:      testTunnelSecurityID,
+			in:      &testTunnelSecurity,
+			out:     &testTunnelSecurityRedacted,
+			errCode: codes.OK,
+			errMsg:  "",
+			exist:   true,
+			vtep:    "10.0.0.1",
+			on:      nil,
+		},
+		"already exists with a different spec": {
+			id:      testTunnelSecurityID,
+			in:      &TunnelSecurity{Spec: &TunnelSecuritySpec{RemoteVtep: testTunnelSecurityVtep, Mode: TunnelSecurityStatic, OutboundSpi: 0x3000, InboundSpi: 0x4000, OutboundKey: "000102030405060708090a0b0c0d0e0f10111213", InboundKey: "101112131415161718191a1b1c1d1e1f20212223"}},
+			out:     nil,
+			errCode: codes.AlreadyExists,
+			errMsg:  fmt.Sprintf("%s already exists with a different spec", testTunnelSecurityName),
+			exist:   true,
+			vtep:    "10.0.0.1",
+			on:      nil,
+		},
+		"remote vtep in use": {
+			id:      "opi-ipsec9",
+			in:      &testTunnelSecurity,
+			out:     nil,
+			errCode: codes.AlreadyExists,
+			errMsg:  fmt.Sprintf("remote VTEP 10.0.0.2 is already protected by %v", testTunnelSecurityName),
+			exist:   true,
+			vtep:    "10.0.0.1",
+			on:      nil,
+		},
+		"failed XfrmStateAdd call": {
+			id:      testTunnelSecurityID,
+			in:      &testTunnelSecurity,
+			out:     nil,
+			errCode: codes.Unknown,
+			errMsg:  "Failed to call XfrmStateAdd",
+			exist:   false,
+			vtep:    "10.0.0.1",
+			on: func(mockNetlink *mocks.Netlink, mockIpsec *mocks.Ipsec, errMsg string) {
+				mockNetlink.EXPECT().XfrmStateAdd(mock.Anything, mock.MatchedBy(testTunnelSecurityState)).Return(errors.New(errMsg)).Once()
+			},
+		},
+		"successful static call": {
+			id:      testTunnelSecurityID,
+			in:      &testTunnelSecurity,
+			out:     &testTunnelSecurityRedacted,
+			errCode: codes.OK,
+			errMsg:  "",
+			exist:   false,
+			vtep:    "10.0.0.1",
+			on: func(mockNetlink *mocks.Netlink, mockIpsec *mocks.Ipsec, errMsg string) {
+				mockNetlink.EXPECT().XfrmStateAdd(mock.Anything, mock.MatchedBy(testTunnelSecurityState)).Return(nil).Twice()
+				mockNetlink.EXPECT().XfrmPolicyAdd(mock.Anything, mock.MatchedBy(testTunnelSecurityPolicy)).Return(nil).Twice()
+			},
+		},
+		"successful ike call": {
+			id:      testTunnelSecurityID,
+			in:      &TunnelSecurity{Spec: &TunnelSecuritySpec{RemoteVtep: testTunnelSecurityVtep, Mode: TunnelSecurityIke, PreSharedKey: "0123456789abcdef"}},
+			out:     &TunnelSecurity{Name: testTunnelSecurityName, Spec: &TunnelSecuritySpec{RemoteVtep: testTunnelSecurityVtep, Mode: TunnelSecurityIke}},
+			errCode: codes.OK,
+			errMsg:  "",
+			exist:   false,
+			vtep:    "10.0.0.1",
+			on: func(mockNetlink *mocks.Netlink, mockIpsec *mocks.Ipsec, errMsg string) {
+				mockIpsec.EXPECT().IpsecLoad(mock.Anything, "opi-"+testTunnelSecurityID, mock.MatchedBy(func(conf string) bool {
+					return strings.Contains(conf, "local_addrs = 10.0.0.1\n") && strings.Contains(conf, "remote_addrs = 10.0.0.2\n") &&
+						strings.Contains(conf, "mode = transport\n") && strings.Contains(conf, "secret = \"0123456789abcdef\"\n")
+				})).Return(nil).Once()
+			},
+		},
+	}
+
+	// run tests
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			mockNetlink := mocks.NewNetlink(t)
+			mockFrr := mocks.NewFrr(t)
+			mockIpsec := mocks.NewIpsec(t)
+			store := gomap.NewStore(gomap.DefaultOptions)
+			opi := NewServerWithArgs(mockNetlink, mockFrr, store)
+			opi.ipsec = mockIpsec
+			opi.Gateway.VtepIP = tt.vtep
+
+			if tt.exist {
+				opi.TunnelSecurities[testTunnelSecurityName] = testTunnelSecurityWithName.clone()
+			}
+			if tt.on != nil {
+				tt.on(mockNetlink, mockIpsec, tt.errMsg)
+			}
+
+			request := &CreateTunnelSecurityRequest{TunnelSecurity: tt.in.clone(), TunnelSecurityID: tt.id}
+			response, err := opi.CreateTunnelSecurity(ctx, request)
+			if !reflect.DeepEqual(tt.out, response) {
+				t.Error("response: expected", tt.out, "received", response)
+			}
+
+			if er, ok := status.FromError(err); ok {
+				if er.Code() != tt.errCode {
+					t.Error("error code: expected", tt.errCode, "received", er.Code())
+				}
+				if er.Message() != tt.errMsg {
+					t.Error("error message: expected", tt.errMsg, "received", er.Message())
+				}
+			} else {
+				t.Error("expected grpc error status")
+			}
+		})
+	}
+}
+
+func Test_DeleteTunnelSecurity(t *testing.T) {
+	tests := map[string]struct {
+		in      string
+		out     *emptypb.Empty
+		errCode codes.Code
+		errMsg  string
+		missing bool
+		on      func(mockNetlink *mocks.Netlink, errMsg string)
+	}{
+		"valid request with unknown key": {
+			in:      "unknown-id",
+			out:     nil,
+			errCode: codes.NotFound,
+			errMsg:  fmt.Sprintf("unable to find key %v", resourceIDToFullName("tunnelsecurities", "unknown-id")),
+			missing: false,
+			on:      nil,
+		},
+		"unknown key with missing allowed": {
+			in:      "unknown-id",
+			out:     &emptypb.Empty{},
+			errCode: codes.OK,
+			errMsg:  "",
+			missing: true,
+			on:      nil,
+		},
+		"failed XfrmPolicyDel call": {
+			in:      testTunnelSecurityID,
+			out:     nil,
+			errCode: codes.Unknown,
+			errMsg:  "Failed to call XfrmPolicyDel",
+			missing: false,
+			on: func(mockNetlink *mocks.Netlink, errMsg string) {
+				mockNetlink.EXPECT().XfrmPolicyDel(mock.Anything, mock.MatchedBy(testTunnelSecurityPolicy)).Return(errors.New(errMsg)).Once()
+			},
+		},
+		"successful call": {
+			in:      testTunnelSecurityID,
+			out:     &emptypb.Empty{},
+			errCode: codes.OK,
+			errMsg:  "",
+			missing: false,
+			on: func(mockNetlink *mocks.Netlink, errMsg string) {
+				mockNetlink.EXPECT().XfrmPolicyDel(mock.Anything, mock.MatchedBy(testTunnelSecurityPolicy)).Return(nil).Twice()
+				mockNetlink.EXPECT().XfrmStateDel(mock.Anything, mock.MatchedBy(testTunnelSecurityState)).Return(nil).Twice()
+			},
+		},
+	}
+
+	// run tests
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			mockNetlink := mocks.NewNetlink(t)
+			mockFrr := mocks.NewFrr(t)
+			store := gomap.NewStore(gomap.DefaultOptions)
+			opi := NewServerWithArgs(mockNetlink, mockFrr, store)
+			opi.Gateway.VtepIP = "10.0.0.1"
+
+			opi.TunnelSecurities[testTunnelSecurityName] = testTunnelSecurityWithName.clone()
+			if tt.on != nil {
+				tt.on(mockNetlink, tt.errMsg)
+			}
+
+			request := &DeleteTunnelSecurityRequest{Name: resourceIDToFullName("tunnelsecurities", tt.in), AllowMissing: tt.missing}
+			response, err := opi.DeleteTunnelSecurity(ctx, request)
+
+			if er, ok := status.FromError(err); ok {
+				if er.Code() != tt.errCode {
+					t.Error("error code: expected", tt.errCode, "received", er.Code())
+				}
+				if er.Message() != tt.errMsg {
+					t.Error("error message: expected", tt.errMsg, "received", er.Message())
+				}
+			} else {
+				t.Error("expected grpc error status")
+			}
+
+			if reflect.TypeOf(response) != reflect.TypeOf(tt.out) {
+				t.Error("response: expected", reflect.TypeOf(tt.out), "received", reflect.TypeOf(response))
+			}
+		})
+	}
+}
+
+func Test_DeleteTunnelSecurityIke(t *testing.T) {
+	mockIpsec := mocks.NewIpsec(t)
+	opi := NewServerWithArgs(mocks.NewNetlink(t), mocks.NewFrr(t), gomap.NewStore(gomap.DefaultOptions))
+	opi.ipsec = mockIpsec
+	opi.TunnelSecurities[testTunnelSecurityName] = &TunnelSecurity{
+		Name: testTunnelSecurityName,
+		Spec: &TunnelSecuritySpec{RemoteVtep: testTunnelSecurityVtep, Mode: TunnelSecurityIke, PreSharedKey: "0123456789abcdef"},
+	}
+	mockIpsec.EXPECT().IpsecUnload(mock.Anything, "opi-"+testTunnelSecurityID).Return(nil).Once()
+	if _, err := opi.DeleteTunnelSecurity(context.Background(), &DeleteTunnelSecurityRequest{Name: testTunnelSecurityName}); err != nil {
+		t.Error("error: expected", nil, "received", err)
+	}
+	if _, ok := opi.TunnelSecurities[testTunnelSecurityName]; ok {
+		t.Error("TunnelSecurity: expected deleted, still present")
+	}
+}
+
+func Test_ListTunnelSecurities(t *testing.T) {
+	opi := NewServerWithArgs(mocks.NewNetlink(t), mocks.NewFrr(t), gomap.NewStore(gomap.DefaultOptions))
+	opi.TunnelSecurities[testTunnelSecurityName] = testTunnelSecurityWithName.clone()
+	response, err := opi.ListTunnelSecurities(context.Background(), &ListTunnelSecuritiesRequest{})
+	if err != nil {
+		t.Fatal("error: expected", nil, "received", err)
+	}
+	// the keys are never returned
+	if !reflect.DeepEqual(response.TunnelSecurities, []*TunnelSecurity{&testTunnelSecurityRedacted}) {
+		t.Error("response: expected", &testTunnelSecurityRedacted, "received", response.TunnelSecurities)
+	}
+}
@@ -0,0 +1,130 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	pc "github.com/opiproject/opi-api/network/opinetcommon/v1alpha1/gen/go"
+
+	"go.einride.tech/aip/resourcename"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// ipsecMinSpi is the first SPI not reserved by IANA
+	ipsecMinSpi = 256
+	// ipsecSaltLen is the salt following the AES key of rfc4106(gcm(aes))
+	ipsecSaltLen = 4
+	// ipsecMinPreSharedKeyLen keeps the pre-shared keys out of reach of dictionary attacks
+	ipsecMinPreSharedKeyLen = 16
+)
+
+// validateTunnelSecurityKey checks a static key is an AES-128 or AES-256 key followed by the salt
+func validateTunnelSecurityKey(field string, key string) error {
+	if key == "" {
+		return missingField(field)
+	}
+	raw, err := hex.DecodeString(key)
+	if err != nil || (len(raw) != 16+ipsecSaltLen && len(raw) != 32+ipsecSaltLen) {
+		msg := fmt.Sprintf("key has to be %d or %d hex encoded bytes, the AES key followed by its salt", 16+ipsecSaltLen, 32+ipsecSaltLen)
+		return badRequest(field, status.Error(codes.InvalidArgument, msg))
+	}
+	return nil
+}
+
+func validateTunnelSecuritySpi(field string, spi uint32) error {
+	if spi == 0 {
+		return missingField(field)
+	}
+	if spi < ipsecMinSpi {
+		msg := fmt.Sprintf("SPI (%d) have to be at least %d", spi, ipsecMinSpi)
+		return badRequest(field, status.Error(codes.InvalidArgument, msg))
+	}
+	return nil
+}
+
+func validateTunnelSecuritySpec(spec *TunnelSecuritySpec) error {
+	if spec.RemoteVtep.Af != pc.IpAf_IP_AF_INET || spec.RemoteVtep.GetV4Addr() == 0 {
+		msg := "remote_vtep has to be an IPv4 address"
+		return badRequest("tunnel_security.spec.remote_vtep", status.Error(codes.InvalidArgument, msg))
+	}
+	switch spec.Mode {
+	case TunnelSecurityStatic:
+		if spec.PreSharedKey != "" {
+			msg := "pre_shared_key is only used by the ike mode"
+			return badRequest("tunnel_security.spec.pre_shared_key", status.Error(codes.InvalidArgument, msg))
+		}
+		if err := validateTunnelSecuritySpi("tunnel_security.spec.outbound_spi", spec.OutboundSpi); err != nil {
+			return err
+		}
+		if err := validateTunnelSecuritySpi("tunnel_security.spec.inbound_spi", spec.InboundSpi); err != nil {
+			return err
+		}
+		if err := validateTunnelSecurityKey("tunnel_security.spec.outbound_key", spec.OutboundKey); err != nil {
+			return err
+		}
+		if err := validateTunnelSecurityKey("tunnel_security.spec.inbound_key", spec.InboundKey); err != nil {
+			return err
+		}
+		// reusing a GCM key in both directions would reuse its nonces
+		if strings.EqualFold(spec.InboundKey, spec.OutboundKey) {
+			msg := "inbound_key and outbound_key have to be different"
+			return badRequest("tunnel_security.spec.inbound_key", status.Error(codes.InvalidArgument, msg))
+		}
+	case TunnelSecurityIke:
+		if spec.OutboundSpi != 0 || spec.InboundSpi != 0 || spec.OutboundKey != "" || spec.InboundKey != "" {
+			msg := "the SPIs and keys of the ike mode are negotiated, only pre_shared_key can be set"
+			return badRequest("tunnel_security.spec", status.Error(codes.InvalidArgument, msg))
+		}
+		if spec.PreSharedKey == "" {
+			return missingField("tunnel_security.spec.pre_shared_key")
+		}
+		// the key is quoted in the swanctl configuration
+		if len(spec.PreSharedKey) < ipsecMinPreSharedKeyLen || strings.ContainsAny(spec.PreSharedKey, "\"\\\n\r") {
+			msg := fmt.Sprintf("pre_shared_key has to be at least %d characters, without quotes, backslashes or newlines", ipsecMinPreSharedKeyLen)
+			return badRequest("tunnel_security.spec.pre_shared_key", status.Error(codes.InvalidArgument, msg))
+		}
+	default:
+		msg := fmt.Sprintf("Mode (%s) have to be %s or %s", spec.Mode, TunnelSecurityStatic, TunnelSecurityIke)
+		return badRequest("tunnel_security.spec.mode", status.Error(codes.InvalidArgument, msg))
+	}
+	return nil
+}
+
+func (s *Server) validateCreateTunnelSecurityRequest(in *CreateTunnelSecurityRequest) error {
+	// check required fields
+	switch {
+	case in.TunnelSecurity == nil:
+		return missingField("tunnel_security")
+	case in.TunnelSecurity.Spec == nil:
+		return missingField("tunnel_security.spec")
+	case in.TunnelSecurity.Spec.RemoteVtep == nil:
+		return missingField("tunnel_security.spec.remote_vtep")
+	}
+	if err := validateTunnelSecuritySpec(in.TunnelSecurity.Spec); err != nil {
+		return err
+	}
+	// see https://google.aip.dev/133#user-specified-ids
+	if in.TunnelSecurityID != "" {
+		if err := badRequest("tunnel_security_id", validateResourceID(in.TunnelSecurityID, false)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Server) validateDeleteTunnelSecurityRequest(in *DeleteTunnelSecurityRequest) error {
+	// check required fields
+	if in.Name == "" {
+		return missingField("name")
+	}
+	// Validate that a resource name conforms to the restrictions outlined in AIP-122.
+	return badRequest("name", resourcename.Validate(in.Name))
+}
@@ -0,0 +1,265 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"encoding/binary"
+	"log"
+	"net"
+	"sort"
+
+	pc "github.com/opiproject/opi-api/network/opinetcommon/v1alpha1/gen/go"
+
+	"go.einride.tech/aip/resourceid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// UnderlayRole is what an UnderlayInterface is to the underlay
+type UnderlayRole string
+
+const (
+	// UnderlayLoopback holds the /32 address of the gateway, the VTEP and router-id, advertised
+	// in the underlay
+	UnderlayLoopback UnderlayRole = "loopback"
+	// UnderlayUplink is an interface towards an underlay router, numbered or unnumbered
+	UnderlayUplink UnderlayRole = "uplink"
+)
+
+// underlayLoopbackDefault is the interface of a loopback created without one
+const underlayLoopbackDefault = "lo"
+
+// UnderlayInterface is the underlay addressing and routing of an interface of the gateway,
+// the VTEP loopback or an uplink, instead of configuring them by hand before starting it
+// TODO: move to opi-api once the message is agreed upon
+type UnderlayInterface struct {
+	Name string
+	Spec *UnderlayInterfaceSpec
+}
+
+// UnderlayInterfaceSpec is the desired configuration of an UnderlayInterface
+type UnderlayInterfaceSpec struct {
+	Role UnderlayRole
+	// Interface is the kernel name of the interface (e.g.: eth0), an uplink has to exist, a
+	// loopback other than lo is created as a dummy device
+	Interface string
+	// IPPrefix is the address of the interface, a /32 for the loopback
+	IPPrefix *pc.IPPrefix
+	// Unnumbered uplinks borrow the address of the loopback instead of an IPPrefix, their BGP
+	// sessions use the IPv6 link-local addresses (see BgpPeerSpec.Interface)
+	Unnumbered bool
+	// Ospf runs OSPF on the interface in OspfArea, the loopback is advertised passively and
+	// the uplinks are point-to-point
+	Ospf     bool
+	OspfArea uint32
+}
+
+// CreateUnderlayInterfaceRequest is the request to create an UnderlayInterface
+type CreateUnderlayInterfaceRequest struct {
+	UnderlayInterfaceID string
+	UnderlayInterface   *UnderlayInterface
+}
+
+// DeleteUnderlayInterfaceRequest is the request to delete an UnderlayInterface
+type DeleteUnderlayInterfaceRequest struct {
+	Name         string
+	AllowMissing bool
+}
+
+// ListUnderlayInterfacesRequest is the request to list UnderlayInterfaces
+type ListUnderlayInterfacesRequest struct {
+	PageSize  int32
+	PageToken string
+}
+
+// ListUnderlayInterfacesResponse is the response of listing UnderlayInterfaces
+type ListUnderlayInterfacesResponse struct {
+	UnderlayInterfaces []*UnderlayInterface
+	NextPageToken      string
+}
+
+func (u *UnderlayInterface) clone() *UnderlayInterface {
+	if u == nil {
+		return nil
+	}
+	c := &UnderlayInterface{Name: u.Name}
+	if u.Spec != nil {
+		spec := *u.Spec
+		if u.Spec.IPPrefix != nil {
+			spec.IPPrefix = protoClone(u.Spec.IPPrefix)
+		}
+		c.Spec = &spec
+	}
+	return c
+}
+
+func sortUnderlayInterfaces(interfaces []*UnderlayInterface) {
+	sort.Slice(interfaces, func(i int, j int) bool {
+		return interfaces[i].Name < interfaces[j].Name
+	})
+}
+
+// underlayAddress returns the address of an UnderlayInterface, nil for an unnumbered uplink
+func underlayAddress(spec *UnderlayInterfaceSpec) *net.IPNet {
+	if spec.IPPrefix == nil || spec.IPPrefix.Addr == nil {
+		return nil
+	}
+	ip := make(net.IP, 4)
+	binary.BigEndian.PutUint32(ip, spec.IPPrefix.Addr.GetV4Addr())
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(int(spec.IPPrefix.Len), 32)}
+}
+
+// UnderlayLoopbackInterface returns the loopback UnderlayInterface, nil when there is none
+func (s *Server) UnderlayLoopbackInterface() *UnderlayInterface {
+	for _, obj := range s.UnderlayInterfaces {
+		if obj.Spec.Role == UnderlayLoopback {
+			return obj
+		}
+	}
+	return nil
+}
+
+// underlayAddressOf returns the address configured on the interface, the borrowed /32 of the
+// loopback for an unnumbered uplink
+func (s *Server) underlayAddressOf(obj *UnderlayInterface) *net.IPNet {
+	if obj.Spec.Unnumbered {
+		if loopback := s.UnderlayLoopbackInterface(); loopback != nil {
+			return underlayAddress(loopback.Spec)
+		}
+		return nil
+	}
+	return underlayAddress(obj.Spec)
+}
+
+// checkUnderlayInterfaceUnique checks the gateway has a single loopback and the interfaces are
+// configured once
+func (s *Server) checkUnderlayInterfaceUnique(obj *UnderlayInterface) error {
+	for _, other := range s.UnderlayInterfaces {
+		if other.Spec.Interface == obj.Spec.Interface {
+			return status.Errorf(codes.AlreadyExists, "interface %s is already configured by %s", obj.Spec.Interface, other.Name)
+		}
+		if obj.Spec.Role == UnderlayLoopback && other.Spec.Role == UnderlayLoopback {
+			return status.Errorf(codes.AlreadyExists, "the underlay loopback is already configured by %s", other.Name)
+		}
+	}
+	return nil
+}
+
+// CreateUnderlayInterface executes the creation of the addressing and routing of an underlay interface
+func (s *Server) CreateUnderlayInterface(ctx context.Context, in *CreateUnderlayInterfaceRequest) (*UnderlayInterface, error) {
+	// check input correctness
+	if err := s.validateCreateUnderlayInterfaceRequest(in); err != nil {
+		return nil, err
+	}
+	if err := checkNoTenant(ctx); err != nil {
+		return nil, err
+	}
+	// see https://google.aip.dev/133#user-specified-ids
+	resourceID := resourceid.NewSystemGenerated()
+	if in.UnderlayInterfaceID != "" {
+		log.Printf("client provided the ID of a resource %v, ignoring the name field %v", in.UnderlayInterfaceID, in.UnderlayInterface.Name)
+		resourceID = in.UnderlayInterfaceID
+	}
+	in.UnderlayInterface.Name = resourceIDToFullName("underlayinterfaces", resourceID)
+	// defaulted first, a retry without the interface is the same spec
+	if in.UnderlayInterface.Spec.Role == UnderlayLoopback && in.UnderlayInterface.Spec.Interface == "" {
+		in.UnderlayInterface.Spec.Interface = underlayLoopbackDefault
+	}
+	s.objectsMu.Lock()
+	defer s.objectsMu.Unlock()
+	// idempotent API when called with same key, should return same object
+	obj, ok := s.UnderlayInterfaces[in.UnderlayInterface.Name]
+	if ok {
+		// a different spec under the same key is a conflict, not a retry
+		if err := checkSameChildSpec(obj.Name, obj.Spec, in.UnderlayInterface.Spec); err != nil {
+			return nil, err
+		}
+		log.Printf("Already existing UnderlayInterface with id %v", in.UnderlayInterface.Name)
+		return obj.clone(), nil
+	}
+	if err := s.checkUnderlayInterfaceUnique(in.UnderlayInterface); err != nil {
+		return nil, err
+	}
+	// the unnumbered uplinks borrow the address of the loopback
+	if in.UnderlayInterface.Spec.Unnumbered && s.UnderlayLoopbackInterface() == nil {
+		msg := "unnumbered uplinks require the underlay loopback, create it first"
+		return nil, status.Error(codes.FailedPrecondition, msg)
+	}
+	if err := s.dataplane.CreateUnderlayInterface(ctx, in.UnderlayInterface); err != nil {
+		s.forgetStatus(in.UnderlayInterface.Name)
+		return nil, status.Convert(err).Err()
+	}
+	// save object to the database
+	response := in.UnderlayInterface.clone()
+	s.UnderlayInterfaces[in.UnderlayInterface.Name] = response
+	persistObjects(s, "underlayinterfaces", s.UnderlayInterfaces)
+	return response.clone(), nil
+}
+
+// DeleteUnderlayInterface removes the addressing and routing of an underlay interface, the
+// interface itself stays, except the dummy device of a loopback
+func (s *Server) DeleteUnderlayInterface(ctx context.Context, in *DeleteUnderlayInterfaceRequest) (*emptypb.Empty, error) {
+	// check input correctness
+	if err := s.validateDeleteUnderlayInterfaceRequest(in); err != nil {
+		return nil, err
+	}
+	if err := checkNoTenant(ctx); err != nil {
+		return nil, err
+	}
+	s.objectsMu.Lock()
+	defer s.objectsMu.Unlock()
+	// fetch object from the database
+	obj, ok := s.UnderlayInterfaces[in.Name]
+	if !ok {
+		if in.AllowMissing {
+			return &emptypb.Empty{}, nil
+		}
+		err := status.Errorf(codes.NotFound, "unable to find key %s", in.Name)
+		return nil, err
+	}
+	if obj.Spec.Role == UnderlayLoopback {
+		for _, other := range s.UnderlayInterfaces {
+			if other.Spec.Unnumbered {
+				err := status.Errorf(codes.FailedPrecondition, "the unnumbered uplink %s still borrows the address of %s", other.Name, obj.Name)
+				return nil, err
+			}
+		}
+	}
+	if err := s.dataplane.DeleteUnderlayInterface(ctx, obj); err != nil {
+		return nil, status.Convert(err).Err()
+	}
+	// remove from the Database
+	delete(s.UnderlayInterfaces, obj.Name)
+	persistObjects(s, "underlayinterfaces", s.UnderlayInterfaces)
+	s.forgetStatus(obj.Name)
+	return &emptypb.Empty{}, nil
+}
+
+// ListUnderlayInterfaces lists the underlay interfaces
+func (s *Server) ListUnderlayInterfaces(ctx context.Context, in *ListUnderlayInterfacesRequest) (*ListUnderlayInterfacesResponse, error) {
+	// fetch pagination from the database, calculate size and offset
+	size, offset, perr := s.extractPagination(in.PageSize, in.PageToken)
+	if perr != nil {
+		return nil, perr
+	}
+	s.objectsMu.RLock()
+	defer s.objectsMu.RUnlock()
+	// fetch object from the database, the following pages are cut from the snapshot of the first one
+	Blobarray, token, err := listPage(ctx, s, "underlayInterfaces", "", in.PageToken, offset, size, func() []*UnderlayInterface {
+		Blobarray := []*UnderlayInterface{}
+		for _, underlay := range s.UnderlayInterfaces {
+			Blobarray = append(Blobarray, underlay.clone())
+		}
+		// sort is needed, since MAP is unsorted in golang, and we might get different results
+		sortUnderlayInterfaces(Blobarray)
+		return Blobarray
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &ListUnderlayInterfacesResponse{UnderlayInterfaces: Blobarray, NextPageToken: token}, nil
+}
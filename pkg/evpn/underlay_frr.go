@@ -0,0 +1,83 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"fmt"
+)
+
+// frrUnderlayOspfMode returns the OSPF mode of the interface, the loopback only being advertised
+func frrUnderlayOspfMode(obj *UnderlayInterface) string {
+	if obj.Spec.Role == UnderlayLoopback {
+		return "ip ospf passive"
+	}
+	return "ip ospf network point-to-point"
+}
+
+func (s *Server) frrCreateUnderlayInterfaceRequest(ctx context.Context, obj *UnderlayInterface) error {
+	if obj.Spec.Ospf {
+		routerID := ""
+		if s.Gateway.RouterID != "" {
+			routerID = fmt.Sprintf("ospf router-id %s\n", s.Gateway.RouterID)
+		}
+		data, err := s.frr.FrrOspfCmd(ctx, fmt.Sprintf(
+			`configure terminal
+			router ospf
+			%sexit
+			interface %s
+			ip ospf area %d
+			%s
+			exit`, routerID, obj.Spec.Interface, obj.Spec.OspfArea, frrUnderlayOspfMode(obj)))
+		fmt.Printf("FrrOspfCmd: %v:%v", data, err)
+		if err != nil {
+			return err
+		}
+	}
+	// the VTEP loopback is reachable through the BGP sessions of the default instance
+	if obj.Spec.Role == UnderlayLoopback {
+		data, err := s.frr.FrrBgpCmd(ctx, fmt.Sprintf(
+			`configure terminal
+			router bgp %d
+			address-family ipv4 unicast
+			network %s
+			exit-address-family
+			exit`, s.Gateway.LocalAs, underlayAddress(obj.Spec)))
+		fmt.Printf("FrrBgpCmd: %v:%v", data, err)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Server) frrDeleteUnderlayInterfaceRequest(ctx context.Context, obj *UnderlayInterface) error {
+	if obj.Spec.Role == UnderlayLoopback {
+		data, err := s.frr.FrrBgpCmd(ctx, fmt.Sprintf(
+			`configure terminal
+			router bgp %d
+			address-family ipv4 unicast
+			no network %s
+			exit-address-family
+			exit`, s.Gateway.LocalAs, underlayAddress(obj.Spec)))
+		fmt.Printf("FrrBgpCmd: %v:%v", data, err)
+		if err != nil {
+			return err
+		}
+	}
+	if obj.Spec.Ospf {
+		data, err := s.frr.FrrOspfCmd(ctx, fmt.Sprintf(
+			`configure terminal
+			interface %s
+			no %s
+			no ip ospf area
+			exit`, obj.Spec.Interface, frrUnderlayOspfMode(obj)))
+		fmt.Printf("FrrOspfCmd: %v:%v", data, err)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/vishvananda/netlink"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// underlayDummy reports whether the interface of the UnderlayInterface is a dummy device owned by it
+func underlayDummy(obj *UnderlayInterface) bool {
+	return obj.Spec.Role == UnderlayLoopback && obj.Spec.Interface != underlayLoopbackDefault
+}
+
+func (s *Server) netlinkCreateUnderlayInterface(ctx context.Context, obj *UnderlayInterface) error {
+	link, err := s.nLink.LinkByName(ctx, obj.Spec.Interface)
+	if err != nil {
+		if !underlayDummy(obj) {
+			err := status.Errorf(codes.NotFound, "unable to find key %s", obj.Spec.Interface)
+			return err
+		}
+		// Example: ip link add name lo0 type dummy
+		link = &netlink.Dummy{LinkAttrs: netlink.LinkAttrs{Name: obj.Spec.Interface, Alias: managedAlias}}
+		log.Printf("Creating loopback %v", link)
+		if err := s.nLink.LinkAdd(ctx, link); err != nil {
+			fmt.Printf("Failed to create dummy link: %v", err)
+			return err
+		}
+	}
+	// Example: ip address add 10.0.0.1/32 dev lo0, the address of lo0 for an unnumbered eth0
+	addr := s.underlayAddressOf(obj)
+	if addr == nil {
+		err := status.Errorf(codes.FailedPrecondition, "unable to find the address of %s", obj.Name)
+		return err
+	}
+	if err := s.nLink.AddrAdd(ctx, link, &netlink.Addr{IPNet: addr}); err != nil {
+		fmt.Printf("Failed to set IP on link: %v", err)
+		return err
+	}
+	// Example: ip link set eth0 up
+	if err := s.nLink.LinkSetUp(ctx, link); err != nil {
+		fmt.Printf("Failed to up link: %v", err)
+		return err
+	}
+	return nil
+}
+
+func (s *Server) netlinkDeleteUnderlayInterface(ctx context.Context, obj *UnderlayInterface) error {
+	link, err := s.nLink.LinkByName(ctx, obj.Spec.Interface)
+	if err != nil {
+		err := status.Errorf(codes.NotFound, "unable to find key %s", obj.Spec.Interface)
+		return err
+	}
+	// Example: ip link del lo0, its address goes away with it
+	if underlayDummy(obj) {
+		log.Printf("Deleting loopback %v", link)
+		if err := s.nLink.LinkDel(ctx, link); err != nil {
+			fmt.Printf("Failed to delete link: %v", err)
+			return err
+		}
+		return nil
+	}
+	// Example: ip address del 10.0.0.1/32 dev lo, the interface itself stays
+	if addr := s.underlayAddressOf(obj); addr != nil {
+		if err := s.nLink.AddrDel(ctx, link, &netlink.Addr{IPNet: addr}); err != nil {
+			fmt.Printf("Failed to delete IP on link: %v", err)
+			return err
+		}
+	}
+	return nil
+}
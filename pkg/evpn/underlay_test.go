@@ -0,0 +1,402 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/philippgille/gokv/gomap"
+	"github.com/stretchr/testify/mock"
+	"github.com/vishvananda/netlink"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	pc "github.com/opiproject/opi-api/network/opinetcommon/v1alpha1/gen/go"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils/mocks"
+)
+
+var (
+	testUnderlayLoopbackID   = "opi-loopback8"
+	testUnderlayLoopbackName = resourceIDToFullName("underlayinterfaces", testUnderlayLoopbackID)
+	testUnderlayLoopback     = UnderlayInterface{
+		Spec: &UnderlayInterfaceSpec{
+			Role:      UnderlayLoopback,
+			Interface: "lo0",
+			IPPrefix:  &pc.IPPrefix{Addr: &pc.IPAddress{Af: pc.IpAf_IP_AF_INET, V4OrV6: &pc.IPAddress_V4Addr{V4Addr: 167772161}}, Len: 32},
+			Ospf:      true,
+		},
+	}
+	testUnderlayLoopbackWithName = UnderlayInterface{
+		Name: testUnderlayLoopbackName,
+		Spec: testUnderlayLoopback.Spec,
+	}
+	testUnderlayUplinkID   = "opi-uplink8"
+	testUnderlayUplinkName = resourceIDToFullName("underlayinterfaces", testUnderlayUplinkID)
+	testUnderlayUplink     = UnderlayInterface{
+		Spec: &UnderlayInterfaceSpec{
+			Role:       UnderlayUplink,
+			Interface:  "eth0",
+			Unnumbered: true,
+			Ospf:       true,
+		},
+	}
+)
+
+// testUnderlayAddr matches the address of testUnderlayLoopback
+func testUnderlayAddr(addr *netlink.Addr) bool {
+	return addr.IPNet.String() == "10.0.0.1/32"
+}
+
+func Test_CreateUnderlayInterface(t *testing.T) {
+	tests := map[string]struct {
+		id       string
+		in       *UnderlayInterface
+		out      *UnderlayInterface
+		errCode  codes.Code
+		errMsg   string
+		loopback bool
+		on       func(mockNetlink *mocks.Netlink, mockFrr *mocks.Frr, errMsg string)
+	}{
+		"invalid role": {
+			id:       testUnderlayUplinkID,
+			in:       &UnderlayInterface{Spec: &UnderlayInterfaceSpec{Role: "spine", Interface: "eth0"}},
+			out:      nil,
+			errCode:  codes.InvalidArgument,
+			errMsg:   "Role (spine) have to be loopback or uplink",
+			loopback: false,
+			on:       nil,
+		},
+		"loopback not a /32": {
+			id: testUnderlayLoopbackID,
+			in: &UnderlayInterface{Spec: &UnderlayInterfaceSpec{Role: UnderlayLoopback,
+				IPPrefix: &pc.IPPrefix{Addr: &pc.IPAddress{Af: pc.IpAf_IP_AF_INET, V4OrV6: &pc.IPAddress_V4Addr{V4Addr: 167772161}}, Len: 24}}},
+			out:      nil,
+			errCode:  codes.InvalidArgument,
+			errMsg:   "Prefix length (24) of the loopback have to be 32",
+			loopback: false,
+			on:       nil,
+		},
+		"uplink without address": {
+			id:       testUnderlayUplinkID,
+			in:       &UnderlayInterface{Spec: &UnderlayInterfaceSpec{Role: UnderlayUplink, Interface: "eth0"}},
+			out:      nil,
+			errCode:  codes.InvalidArgument,
+			errMsg:   "missing required field: underlay_interface.spec.ip_prefix",
+			loopback: false,
+			on:       nil,
+		},
+		"numbered and unnumbered": {
+			id: testUnderlayUplinkID,
+			in: &UnderlayInterface{Spec: &UnderlayInterfaceSpec{Role: UnderlayUplink, Interface: "eth0", Unnumbered: true,
+				IPPrefix: &pc.IPPrefix{Addr: &pc.IPAddress{Af: pc.IpAf_IP_AF_INET, V4OrV6: &pc.IPAddress_V4Addr{V4Addr: 167837953}}, Len: 31}}},
+			out:      nil,
+			errCode:  codes.InvalidArgument,
+			errMsg:   "only one of ip_prefix and unnumbered can be set",
+			loopback: false,
+			on:       nil,
+		},
+		"area without ospf": {
+			id:       testUnderlayUplinkID,
+			in:       &UnderlayInterface{Spec: &UnderlayInterfaceSpec{Role: UnderlayUplink, Interface: "eth0", Unnumbered: true, OspfArea: 1}},
+			out:      nil,
+			errCode:  codes.InvalidArgument,
+			errMsg:   "ospf_area requires ospf",
+			loopback: false,
+			on:       nil,
+		},
+		"unnumbered without loopback": {
+			id:       testUnderlayUplinkID,
+			in:       &testUnderlayUplink,
+			out:      nil,
+			errCode:  codes.FailedPrecondition,
+			errMsg:   "unnumbered uplinks require the underlay loopback, create it first",
+			loopback: false,
+			on:       nil,
+		},
+		"already exists with a different spec": {
+			id:       testUnderlayLoopbackID,
+			in:       &UnderlayInterface{Spec: &UnderlayInterfaceSpec{Role: UnderlayLoopback, Interface: "lo1", IPPrefix: testUnderlayLoopback.Spec.IPPrefix}},
+			out:      nil,
+			errCode:  codes.AlreadyExists,
+			errMsg:   fmt.Sprintf("%s already exists with a different spec", testUnderlayLoopbackName),
+			loopback: true,
+			on:       nil,
+		},
+		"already exists": {
+			id:       testUnderlayLoopbackID,
+			in:       &testUnderlayLoopback,
+			out:      &testUnderlayLoopbackWithName,
+			errCode:  codes.OK,
+			errMsg:   "",
+			loopback: true,
+			on:       nil,
+		},
+		"second loopback": {
+			id:       "opi-loopback9",
+			in:       &UnderlayInterface{Spec: &UnderlayInterfaceSpec{Role: UnderlayLoopback, IPPrefix: testUnderlayLoopback.Spec.IPPrefix}},
+			out:      nil,
+			errCode:  codes.AlreadyExists,
+			errMsg:   fmt.Sprintf("the underlay loopback is already configured by %v", testUnderlayLoopbackName),
+			loopback: true,
+			on:       nil,
+		},
+		"interface in use": {
+			id:       testUnderlayUplinkID,
+			in:       &UnderlayInterface{Spec: &UnderlayInterfaceSpec{Role: UnderlayUplink, Interface: "lo0", Unnumbered: true}},
+			out:      nil,
+			errCode:  codes.AlreadyExists,
+			errMsg:   fmt.Sprintf("interface lo0 is already configured by %v", testUnderlayLoopbackName),
+			loopback: true,
+			on:       nil,
+		},
+		"missing uplink": {
+			id:       testUnderlayUplinkID,
+			in:       &testUnderlayUplink,
+			out:      nil,
+			errCode:  codes.NotFound,
+			errMsg:   "unable to find key eth0",
+			loopback: true,
+			on: func(mockNetlink *mocks.Netlink, mockFrr *mocks.Frr, errMsg string) {
+				mockNetlink.EXPECT().LinkByName(mock.Anything, "eth0").Return(nil, errors.New(errMsg)).Once()
+			},
+		},
+		"failed AddrAdd call": {
+			id:       testUnderlayUplinkID,
+			in:       &testUnderlayUplink,
+			out:      nil,
+			errCode:  codes.Unknown,
+			errMsg:   "Failed to call AddrAdd",
+			loopback: true,
+			on: func(mockNetlink *mocks.Netlink, mockFrr *mocks.Frr, errMsg string) {
+				uplink := &netlink.Device{LinkAttrs: netlink.LinkAttrs{Name: "eth0"}}
+				mockNetlink.EXPECT().LinkByName(mock.Anything, "eth0").Return(uplink, nil).Once()
+				mockNetlink.EXPECT().AddrAdd(mock.Anything, uplink, mock.MatchedBy(testUnderlayAddr)).Return(errors.New(errMsg)).Once()
+			},
+		},
+		"successful loopback call": {
+			id:       testUnderlayLoopbackID,
+			in:       &testUnderlayLoopback,
+			out:      &testUnderlayLoopbackWithName,
+			errCode:  codes.OK,
+			errMsg:   "",
+			loopback: false,
+			on: func(mockNetlink *mocks.Netlink, mockFrr *mocks.Frr, errMsg string) {
+				loopback := &netlink.Dummy{LinkAttrs: netlink.LinkAttrs{Name: "lo0", Alias: managedAlias}}
+				mockNetlink.EXPECT().LinkByName(mock.Anything, "lo0").Return(nil, netlink.LinkNotFoundError{}).Once()
+				mockNetlink.EXPECT().LinkAdd(mock.Anything, loopback).Return(nil).Once()
+				mockNetlink.EXPECT().AddrAdd(mock.Anything, loopback, mock.MatchedBy(testUnderlayAddr)).Return(nil).Once()
+				mockNetlink.EXPECT().LinkSetUp(mock.Anything, loopback).Return(nil).Once()
+				mockFrr.EXPECT().FrrOspfCmd(mock.Anything, mock.MatchedBy(func(command string) bool {
+					return strings.Contains(command, "interface lo0") && strings.Contains(command, "ip ospf area 0") &&
+						strings.Contains(command, "ip ospf passive")
+				})).Return("", nil).Once()
+				mockFrr.EXPECT().FrrBgpCmd(mock.Anything, mock.MatchedBy(func(command string) bool {
+					return strings.Contains(command, "router bgp 65000") && strings.Contains(command, "network 10.0.0.1/32")
+				})).Return("", nil).Once()
+			},
+		},
+		"successful unnumbered call": {
+			id:       testUnderlayUplinkID,
+			in:       &testUnderlayUplink,
+			out:      &UnderlayInterface{Name: testUnderlayUplinkName, Spec: testUnderlayUplink.Spec},
+			errCode:  codes.OK,
+			errMsg:   "",
+			loopback: true,
+			on: func(mockNetlink *mocks.Netlink, mockFrr *mocks.Frr, errMsg string) {
+				uplink := &netlink.Device{LinkAttrs: netlink.LinkAttrs{Name: "eth0"}}
+				mockNetlink.EXPECT().LinkByName(mock.Anything, "eth0").Return(uplink, nil).Once()
+				mockNetlink.EXPECT().AddrAdd(mock.Anything, uplink, mock.MatchedBy(testUnderlayAddr)).Return(nil).Once()
+				mockNetlink.EXPECT().LinkSetUp(mock.Anything, uplink).Return(nil).Once()
+				mockFrr.EXPECT().FrrOspfCmd(mock.Anything, mock.MatchedBy(func(command string) bool {
+					return strings.Contains(command, "interface eth0") && strings.Contains(command, "ip ospf network point-to-point")
+				})).Return("", nil).Once()
+			},
+		},
+	}
+
+	// run tests
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			mockNetlink := mocks.NewNetlink(t)
+			mockFrr := mocks.NewFrr(t)
+			store := gomap.NewStore(gomap.DefaultOptions)
+			opi := NewServerWithArgs(mockNetlink, mockFrr, store)
+
+			if tt.loopback {
+				opi.UnderlayInterfaces[testUnderlayLoopbackName] = testUnderlayLoopbackWithName.clone()
+			}
+			if tt.on != nil {
+				tt.on(mockNetlink, mockFrr, tt.errMsg)
+			}
+
+			request := &CreateUnderlayInterfaceRequest{UnderlayInterface: tt.in.clone(), UnderlayInterfaceID: tt.id}
+			response, err := opi.CreateUnderlayInterface(ctx, request)
+			if !reflect.DeepEqual(tt.out, response) {
+				t.Error("response: expected", tt.out, "received", response)
+			}
+
+			if er, ok := status.FromError(err); ok {
+				if er.Code() != tt.errCode {
+					t.Error("error code: expected", tt.errCode, "received", er.Code())
+				}
+				if er.Message() != tt.errMsg {
+					t.Error("error message: expected", tt.errMsg, "received", er.Message())
+				}
+			} else {
+				t.Error("expected grpc error status")
+			}
+		})
+	}
+}
+
+func Test_DeleteUnderlayInterface(t *testing.T) {
+	tests := map[string]struct {
+		in      string
+		out     *emptypb.Empty
+		errCode codes.Code
+		errMsg  string
+		missing bool
+		on      func(mockNetlink *mocks.Netlink, mockFrr *mocks.Frr, errMsg string)
+	}{
+		"valid request with unknown key": {
+			in:      "unknown-id",
+			out:     nil,
+			errCode: codes.NotFound,
+			errMsg:  fmt.Sprintf("unable to find key %v", resourceIDToFullName("underlayinterfaces", "unknown-id")),
+			missing: false,
+			on:      nil,
+		},
+		"unknown key with missing allowed": {
+			in:      "unknown-id",
+			out:     &emptypb.Empty{},
+			errCode: codes.OK,
+			errMsg:  "",
+			missing: true,
+			on:      nil,
+		},
+		"loopback borrowed": {
+			in:      testUnderlayLoopbackID,
+			out:     nil,
+			errCode: codes.FailedPrecondition,
+			errMsg:  fmt.Sprintf("the unnumbered uplink %v still borrows the address of %v", testUnderlayUplinkName, testUnderlayLoopbackName),
+			missing: false,
+			on:      nil,
+		},
+		"failed AddrDel call": {
+			in:      testUnderlayUplinkID,
+			out:     nil,
+			errCode: codes.Unknown,
+			errMsg:  "Failed to call AddrDel",
+			missing: false,
+			on: func(mockNetlink *mocks.Netlink, mockFrr *mocks.Frr, errMsg string) {
+				uplink := &netlink.Device{LinkAttrs: netlink.LinkAttrs{Name: "eth0"}}
+				mockFrr.EXPECT().FrrOspfCmd(mock.Anything, mock.Anything).Return("", nil).Once()
+				mockNetlink.EXPECT().LinkByName(mock.Anything, "eth0").Return(uplink, nil).Once()
+				mockNetlink.EXPECT().AddrDel(mock.Anything, uplink, mock.MatchedBy(testUnderlayAddr)).Return(errors.New(errMsg)).Once()
+			},
+		},
+		"successful call": {
+			in:      testUnderlayUplinkID,
+			out:     &emptypb.Empty{},
+			errCode: codes.OK,
+			errMsg:  "",
+			missing: false,
+			on: func(mockNetlink *mocks.Netlink, mockFrr *mocks.Frr, errMsg string) {
+				uplink := &netlink.Device{LinkAttrs: netlink.LinkAttrs{Name: "eth0"}}
+				mockFrr.EXPECT().FrrOspfCmd(mock.Anything, mock.MatchedBy(func(command string) bool {
+					return strings.Contains(command, "no ip ospf network point-to-point") && strings.Contains(command, "no ip ospf area")
+				})).Return("", nil).Once()
+				mockNetlink.EXPECT().LinkByName(mock.Anything, "eth0").Return(uplink, nil).Once()
+				mockNetlink.EXPECT().AddrDel(mock.Anything, uplink, mock.MatchedBy(testUnderlayAddr)).Return(nil).Once()
+			},
+		},
+	}
+
+	// run tests
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			mockNetlink := mocks.NewNetlink(t)
+			mockFrr := mocks.NewFrr(t)
+			store := gomap.NewStore(gomap.DefaultOptions)
+			opi := NewServerWithArgs(mockNetlink, mockFrr, store)
+
+			opi.UnderlayInterfaces[testUnderlayLoopbackName] = testUnderlayLoopbackWithName.clone()
+			opi.UnderlayInterfaces[testUnderlayUplinkName] = &UnderlayInterface{Name: testUnderlayUplinkName, Spec: testUnderlayUplink.Spec}
+			if tt.on != nil {
+				tt.on(mockNetlink, mockFrr, tt.errMsg)
+			}
+
+			request := &DeleteUnderlayInterfaceRequest{Name: resourceIDToFullName("underlayinterfaces", tt.in), AllowMissing: tt.missing}
+			response, err := opi.DeleteUnderlayInterface(ctx, request)
+
+			if er, ok := status.FromError(err); ok {
+				if er.Code() != tt.errCode {
+					t.Error("error code: expected", tt.errCode, "received", er.Code())
+				}
+				if er.Message() != tt.errMsg {
+					t.Error("error message: expected", tt.errMsg, "received", er.Message())
+				}
+			} else {
+				t.Error("expected grpc error status")
+			}
+
+			if reflect.TypeOf(response) != reflect.TypeOf(tt.out) {
+				t.Error("response: expected", reflect.TypeOf(tt.out), "received", reflect.TypeOf(response))
+			}
+		})
+	}
+}
+
+func Test_DeleteUnderlayLoopback(t *testing.T) {
+	mockNetlink := mocks.NewNetlink(t)
+	mockFrr := mocks.NewFrr(t)
+	opi := NewServerWithArgs(mockNetlink, mockFrr, gomap.NewStore(gomap.DefaultOptions))
+	opi.UnderlayInterfaces[testUnderlayLoopbackName] = testUnderlayLoopbackWithName.clone()
+
+	// the dummy loopback goes away with its address
+	loopback := &netlink.Dummy{LinkAttrs: netlink.LinkAttrs{Name: "lo0"}}
+	mockFrr.EXPECT().FrrBgpCmd(mock.Anything, mock.MatchedBy(func(command string) bool {
+		return strings.Contains(command, "no network 10.0.0.1/32")
+	})).Return("", nil).Once()
+	mockFrr.EXPECT().FrrOspfCmd(mock.Anything, mock.Anything).Return("", nil).Once()
+	mockNetlink.EXPECT().LinkByName(mock.Anything, "lo0").Return(loopback, nil).Once()
+	mockNetlink.EXPECT().LinkDel(mock.Anything, loopback).Return(nil).Once()
+	if _, err := opi.DeleteUnderlayInterface(context.Background(), &DeleteUnderlayInterfaceRequest{Name: testUnderlayLoopbackName}); err != nil {
+		t.Error("error: expected", nil, "received", err)
+	}
+	if len(opi.UnderlayInterfaces) != 0 {
+		t.Error("UnderlayInterfaces: expected empty, received", opi.UnderlayInterfaces)
+	}
+}
+
+func Test_ListUnderlayInterfaces(t *testing.T) {
+	opi := NewServerWithArgs(mocks.NewNetlink(t), mocks.NewFrr(t), gomap.NewStore(gomap.DefaultOptions))
+	uplink := &UnderlayInterface{Name: testUnderlayUplinkName, Spec: testUnderlayUplink.Spec}
+	opi.UnderlayInterfaces[testUnderlayUplinkName] = uplink.clone()
+	opi.UnderlayInterfaces[testUnderlayLoopbackName] = testUnderlayLoopbackWithName.clone()
+	response, err := opi.ListUnderlayInterfaces(context.Background(), &ListUnderlayInterfacesRequest{})
+	if err != nil {
+		t.Fatal("error: expected", nil, "received", err)
+	}
+	expected := []*UnderlayInterface{&testUnderlayLoopbackWithName, uplink}
+	if !reflect.DeepEqual(response.UnderlayInterfaces, expected) {
+		t.Error("response: expected", expected, "received", response.UnderlayInterfaces)
+	}
+	// the unnumbered uplink borrows the address of the loopback
+	if addr := opi.underlayAddressOf(uplink); addr == nil || !addr.IP.Equal(net.ParseIP("10.0.0.1")) {
+		t.Error("address: expected", "10.0.0.1/32", "received", addr)
+	}
+}
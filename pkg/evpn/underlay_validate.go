@@ -0,0 +1,93 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"fmt"
+	"strings"
+
+	pc "github.com/opiproject/opi-api/network/opinetcommon/v1alpha1/gen/go"
+
+	"go.einride.tech/aip/resourcename"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func validateUnderlayInterfaceSpec(spec *UnderlayInterfaceSpec) error {
+	// the interface is a kernel device, and an FRR interface
+	if len(spec.Interface) > maxKernelNameLength || strings.ContainsAny(spec.Interface, "/ \t\n") {
+		msg := fmt.Sprintf("Interface (%s) have to be a kernel interface name of at most %d characters", spec.Interface, maxKernelNameLength)
+		return badRequest("underlay_interface.spec.interface", status.Error(codes.InvalidArgument, msg))
+	}
+	prefix := spec.IPPrefix
+	if prefix != nil && (prefix.Addr == nil || prefix.Addr.Af != pc.IpAf_IP_AF_INET || prefix.Len == 0 || prefix.Len > 32) {
+		msg := "ip_prefix has to be an IPv4 prefix, with a length between 1 and 32"
+		return badRequest("underlay_interface.spec.ip_prefix", status.Error(codes.InvalidArgument, msg))
+	}
+	switch spec.Role {
+	case UnderlayLoopback:
+		if spec.Unnumbered {
+			msg := "only the uplinks can be unnumbered"
+			return badRequest("underlay_interface.spec.unnumbered", status.Error(codes.InvalidArgument, msg))
+		}
+		if prefix == nil {
+			return missingField("underlay_interface.spec.ip_prefix")
+		}
+		if prefix.Len != 32 {
+			msg := fmt.Sprintf("Prefix length (%d) of the loopback have to be 32", prefix.Len)
+			return badRequest("underlay_interface.spec.ip_prefix", status.Error(codes.InvalidArgument, msg))
+		}
+	case UnderlayUplink:
+		if spec.Interface == "" {
+			return missingField("underlay_interface.spec.interface")
+		}
+		// an uplink is either numbered or unnumbered
+		if prefix == nil && !spec.Unnumbered {
+			return missingField("underlay_interface.spec.ip_prefix")
+		}
+		if prefix != nil && spec.Unnumbered {
+			msg := "only one of ip_prefix and unnumbered can be set"
+			return badRequest("underlay_interface.spec.unnumbered", status.Error(codes.InvalidArgument, msg))
+		}
+	default:
+		msg := fmt.Sprintf("Role (%s) have to be %s or %s", spec.Role, UnderlayLoopback, UnderlayUplink)
+		return badRequest("underlay_interface.spec.role", status.Error(codes.InvalidArgument, msg))
+	}
+	if spec.OspfArea != 0 && !spec.Ospf {
+		msg := "ospf_area requires ospf"
+		return badRequest("underlay_interface.spec.ospf_area", status.Error(codes.InvalidArgument, msg))
+	}
+	return nil
+}
+
+func (s *Server) validateCreateUnderlayInterfaceRequest(in *CreateUnderlayInterfaceRequest) error {
+	// check required fields
+	switch {
+	case in.UnderlayInterface == nil:
+		return missingField("underlay_interface")
+	case in.UnderlayInterface.Spec == nil:
+		return missingField("underlay_interface.spec")
+	}
+	if err := validateUnderlayInterfaceSpec(in.UnderlayInterface.Spec); err != nil {
+		return err
+	}
+	// see https://google.aip.dev/133#user-specified-ids
+	if in.UnderlayInterfaceID != "" {
+		if err := badRequest("underlay_interface_id", validateResourceID(in.UnderlayInterfaceID, false)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Server) validateDeleteUnderlayInterfaceRequest(in *DeleteUnderlayInterfaceRequest) error {
+	// check required fields
+	if in.Name == "" {
+		return missingField("name")
+	}
+	// Validate that a resource name conforms to the restrictions outlined in AIP-122.
+	return badRequest("name", resourcename.Validate(in.Name))
+}
@@ -0,0 +1,85 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"fmt"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// precheckLinksAbsent fails when one of the kernel devices a create would add already exists
+func (s *Server) precheckLinksAbsent(ctx context.Context, names ...string) error {
+	for _, name := range names {
+		if _, err := s.nLink.LinkByName(ctx, name); err == nil {
+			msg := fmt.Sprintf("interface %s already exists", name)
+			return status.Error(codes.AlreadyExists, msg)
+		}
+	}
+	return nil
+}
+
+// precheckVniFree fails when the vni is already used by another Vrf or LogicalBridge,
+// both end up as a vni<N> vxlan device
+func (s *Server) precheckVniFree(name string, vni *uint32) error {
+	if vni == nil {
+		return nil
+	}
+	for _, other := range s.vniUsers(*vni) {
+		if other != name {
+			msg := fmt.Sprintf("vni %d already used by %s", *vni, other)
+			return status.Error(codes.AlreadyExists, msg)
+		}
+	}
+	return nil
+}
+
+// precheckCreateVrf runs the checks a validate only CreateVrf call performs instead of programming
+func (s *Server) precheckCreateVrf(ctx context.Context, in *pb.CreateVrfRequest) error {
+	return s.dataplane.PrecheckCreateVrf(ctx, in.Vrf)
+}
+
+// precheckCreateLogicalBridge runs the checks a validate only CreateLogicalBridge call performs instead of programming
+func (s *Server) precheckCreateLogicalBridge(ctx context.Context, in *pb.CreateLogicalBridgeRequest) error {
+	if other := s.vlanBridge(in.LogicalBridge.Spec.VlanId); other != "" {
+		msg := fmt.Sprintf("VlanId %d already used by %s", in.LogicalBridge.Spec.VlanId, other)
+		return status.Error(codes.AlreadyExists, msg)
+	}
+	return s.dataplane.PrecheckCreateLogicalBridge(ctx, in.LogicalBridge)
+}
+
+// precheckCreateBridgePort runs the checks a validate only CreateBridgePort call performs instead of programming
+func (s *Server) precheckCreateBridgePort(ctx context.Context, in *pb.CreateBridgePortRequest) error {
+	if err := s.dataplane.PrecheckBindBridgePort(ctx, in.BridgePort); err != nil {
+		return err
+	}
+	for _, bridgeRefName := range in.BridgePort.Spec.LogicalBridges {
+		if _, ok := s.Bridges[bridgeRefName]; !ok {
+			err := status.Errorf(codes.NotFound, "unable to find key %s", bridgeRefName)
+			return err
+		}
+	}
+	switch in.BridgePort.Spec.Ptype {
+	case pb.BridgePortType_ACCESS, pb.BridgePortType_TRUNK:
+	default:
+		msg := fmt.Sprintf("Only ACCESS or TRUNK supported and not (%d)", in.BridgePort.Spec.Ptype)
+		return status.Error(codes.InvalidArgument, msg)
+	}
+	return nil
+}
+
+// precheckCreateSvi runs the checks a validate only CreateSvi call performs instead of programming,
+// the referenced LogicalBridge and Vrf were already resolved by the caller
+func (s *Server) precheckCreateSvi(ctx context.Context, in *pb.CreateSviRequest, bridgeObject *pb.LogicalBridge, vrf *pb.Vrf) error {
+	if others := s.indexes.sviBridge.lookup(s.Svis, in.Svi.Spec.LogicalBridge); len(others) != 0 {
+		msg := fmt.Sprintf("LogicalBridge %s already has Svi %s", in.Svi.Spec.LogicalBridge, others[0])
+		return status.Error(codes.AlreadyExists, msg)
+	}
+	return s.dataplane.PrecheckCreateSvi(ctx, in.Svi, bridgeObject, vrf)
+}
@@ -0,0 +1,107 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/philippgille/gokv/gomap"
+	"github.com/stretchr/testify/mock"
+	"github.com/vishvananda/netlink"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils"
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils/mocks"
+)
+
+func Test_CreateLogicalBridgeValidateOnly(t *testing.T) {
+	tests := map[string]struct {
+		out     *pb.LogicalBridge
+		errCode codes.Code
+		errMsg  string
+		exist   *pb.LogicalBridge
+		on      func(mockNetlink *mocks.Netlink, mockFrr *mocks.Frr, errMsg string)
+	}{
+		"vlan conflict": {
+			out:     nil,
+			errCode: codes.AlreadyExists,
+			errMsg:  "VlanId 22 already used by " + resourceIDToFullName("bridges", "other"),
+			exist:   &pb.LogicalBridge{Name: resourceIDToFullName("bridges", "other"), Spec: &pb.LogicalBridgeSpec{VlanId: 22}},
+			on:      nil,
+		},
+		"vni conflict": {
+			out:     nil,
+			errCode: codes.AlreadyExists,
+			errMsg:  "vni 11 already used by " + resourceIDToFullName("bridges", "other"),
+			exist:   &pb.LogicalBridge{Name: resourceIDToFullName("bridges", "other"), Spec: &pb.LogicalBridgeSpec{VlanId: 33, Vni: proto.Uint32(11)}},
+			on:      nil,
+		},
+		"netlink name collision": {
+			out:     nil,
+			errCode: codes.AlreadyExists,
+			errMsg:  "interface vni11 already exists",
+			exist:   nil,
+			on: func(mockNetlink *mocks.Netlink, mockFrr *mocks.Frr, errMsg string) {
+				vxlan := &netlink.Vxlan{LinkAttrs: netlink.LinkAttrs{Name: "vni11", Alias: managedAlias}}
+				mockNetlink.EXPECT().LinkByName(mock.Anything, "vni11").Return(vxlan, nil).Once()
+			},
+		},
+		"successful call": {
+			out:     &pb.LogicalBridge{Name: testLogicalBridgeName, Spec: testLogicalBridge.Spec, Status: &pb.LogicalBridgeStatus{}},
+			errCode: codes.OK,
+			errMsg:  "",
+			exist:   nil,
+			on: func(mockNetlink *mocks.Netlink, mockFrr *mocks.Frr, errMsg string) {
+				mockNetlink.EXPECT().LinkByName(mock.Anything, "vni11").Return(nil, errors.New("Link not found")).Once()
+			},
+		},
+	}
+
+	// run tests
+	for testName, tt := range tests {
+		t.Run(testName, func(t *testing.T) {
+			md := metadata.Pairs(utils.ValidateOnlyMetadataKey, "true")
+			ctx := metadata.NewIncomingContext(context.Background(), md)
+			mockNetlink := mocks.NewNetlink(t)
+			mockFrr := mocks.NewFrr(t)
+			store := gomap.NewStore(gomap.DefaultOptions)
+			opi := NewServerWithArgs(mockNetlink, mockFrr, store)
+			if tt.exist != nil {
+				opi.Bridges[tt.exist.Name] = tt.exist
+			}
+			if tt.on != nil {
+				tt.on(mockNetlink, mockFrr, tt.errMsg)
+			}
+
+			request := &pb.CreateLogicalBridgeRequest{LogicalBridge: protoClone(&testLogicalBridge), LogicalBridgeId: testLogicalBridgeID}
+			response, err := opi.CreateLogicalBridge(ctx, request)
+			if !proto.Equal(response, tt.out) {
+				t.Error("response: expected", tt.out, "received", response)
+			}
+
+			if er, ok := status.FromError(err); ok {
+				if er.Code() != tt.errCode {
+					t.Error("error code: expected", tt.errCode, "received", er.Code())
+				}
+				if er.Message() != tt.errMsg {
+					t.Error("error message: expected", tt.errMsg, "received", er.Message())
+				}
+			} else {
+				t.Error("expected grpc error status")
+			}
+			if _, ok := opi.Bridges[testLogicalBridgeName]; ok {
+				t.Error("expected validate only call to leave the database untouched")
+			}
+		})
+	}
+}
@@ -10,17 +10,17 @@ import (
 	"fmt"
 	"log"
 	"math"
-	"path"
 	"sort"
 
-	"github.com/google/uuid"
-
 	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
 
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils"
+
 	"go.einride.tech/aip/fieldbehavior"
 	"go.einride.tech/aip/resourceid"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/emptypb"
 )
 
@@ -42,37 +42,161 @@ func (s *Server) CreateVrf(ctx context.Context, in *pb.CreateVrfRequest) (*pb.Vr
 		log.Printf("client provided the ID of a resource %v, ignoring the name field %v", in.VrfId, in.Vrf.Name)
 		resourceID = in.VrfId
 	}
-	in.Vrf.Name = resourceIDToFullName("vrfs", resourceID)
+	name, err := newObjectName(ctx, "vrfs", resourceID)
+	if err != nil {
+		return nil, err
+	}
+	in.Vrf.Name = name
+	s.objectsMu.Lock()
+	defer s.objectsMu.Unlock()
+	in.Vrf.Spec.VtepIpPrefix = s.vtepIPPrefixOr(in.Vrf.Spec.Vni, in.Vrf.Spec.VtepIpPrefix)
+	labels, err := labelsFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	srv6, err := s.srv6OverlayFor(ctx, in.Vrf)
+	if err != nil {
+		return nil, err
+	}
+	routeTargets, err := s.routeTargetsFor(ctx, in.Vrf, srv6)
+	if err != nil {
+		return nil, err
+	}
+	asymmetric, err := s.asymmetricIrbFor(ctx, in.Vrf)
+	if err != nil {
+		return nil, err
+	}
+	if srv6 {
+		// the End.DT46 SID decapsulates into the table of a vrf device
+		if s.vrfNetns() {
+			msg := "the srv6 overlay decapsulates into the table of a vrf device, it cannot be used with the netns vrf isolation"
+			return nil, status.Error(codes.FailedPrecondition, msg)
+		}
+		// the Svis of an asymmetric IRB Vrf route over the vnis of their LogicalBridges
+		if asymmetric {
+			msg := "asymmetric IRB routes over the vnis of the Svis, it cannot use the srv6 overlay"
+			return nil, badRequest(utils.IrbModeMetadataKey, status.Error(codes.InvalidArgument, msg))
+		}
+		if err := checkSrv6RouteTargets(routeTargets); err != nil {
+			return nil, err
+		}
+	}
 	// idempotent API when called with same key, should return same object
 	obj, ok := s.Vrfs[in.Vrf.Name]
 	if ok {
+		// a different spec under the same key is a conflict, not a retry
+		if err := checkSameSpec(obj.Name, obj.Spec, in.Vrf.Spec); err != nil {
+			return nil, err
+		}
 		log.Printf("Already existing Vrf with id %v", in.Vrf.Name)
 		return obj, nil
 	}
+	// the vxlan device of the L3 vni needs the source of its tunnels
+	if in.Vrf.Spec.Vni != nil && in.Vrf.Spec.VtepIpPrefix == nil {
+		return nil, missingField("vrf.spec.vtep_ip_prefix")
+	}
+	// a Create retried while the previous one programs the Vrf must not do it twice
+	if err := s.checkNoOperation(in.Vrf.Name); err != nil {
+		return nil, err
+	}
+	if err := s.checkTenantQuota(in.Vrf.Name, "Vrf"); err != nil {
+		return nil, err
+	}
+	if err := s.validateVrfQuota(in.Vrf); err != nil {
+		return nil, err
+	}
+	// the L3 vni gets its own vxlan device, it cannot be shared with another Vrf or a LogicalBridge
+	if err := s.precheckVniFree(in.Vrf.Name, in.Vrf.Spec.Vni); err != nil {
+		return nil, err
+	}
 	// TODO: consider choosing random table ID
 	tableID := uint32(1000)
 	if in.Vrf.Spec.Vni != nil {
 		tableID = uint32(1001 + math.Mod(float64(*in.Vrf.Spec.Vni), 10.0))
 	}
+	// the End.DT46 SID decapsulates into the table, it cannot be shared with another Vrf
+	if srv6 {
+		tableID = s.srv6TableFor(in.Vrf.Name)
+	}
+	// see https://google.aip.dev/163
+	if utils.IsValidateOnly(ctx) {
+		if err := s.precheckCreateVrf(ctx, in); err != nil {
+			return nil, err
+		}
+		response := protoClone(in.Vrf)
+		response.Status = &pb.VrfStatus{LocalAs: s.Gateway.LocalAs, RoutingTable: tableID}
+		return response, nil
+	}
 	// generate random mac, since it is not part of user facing API
 	mac, err := generateRandMAC()
 	if err != nil {
 		fmt.Printf("Failed to generate random MAC: %v", err)
 		return nil, err
 	}
-	// configure netlink
-	if err := s.netlinkCreateVrf(ctx, in, tableID, mac); err != nil {
-		return nil, err
+	// system generated IDs do not fit in IFNAMSIZ
+	s.setKernelName(in.Vrf.Name, resourceID, s.kernelNameFor(in.Vrf.Name, resourceID))
+	response := protoClone(in.Vrf)
+	response.Status = &pb.VrfStatus{LocalAs: s.Gateway.LocalAs, RoutingTable: tableID, Rmac: mac}
+	if !routeTargets.empty() {
+		s.RouteTargets[in.Vrf.Name] = routeTargets
+	}
+	if asymmetric {
+		s.AsymmetricIrb[in.Vrf.Name] = true
 	}
-	// configure FRR
-	if err := s.frrCreateVrfRequest(ctx, in); err != nil {
+	if srv6 {
+		s.Srv6Vrfs[in.Vrf.Name] = tableID
+	}
+	// see https://google.aip.dev/151
+	if utils.IsAsync(ctx) {
+		s.startLifecycleOperation(ctx, response.Name, LifecycleCreating, func(ctx context.Context) (proto.Message, error) {
+			// the other calls go on while the devices are programmed, only saving the Vrf
+			// waits for them
+			s.objectsMu.RLock()
+			err := s.createVrfDataplane(ctx, response)
+			s.objectsMu.RUnlock()
+			s.objectsMu.Lock()
+			defer s.objectsMu.Unlock()
+			return s.saveVrf(response, labels, err)
+		})
+		return response, nil
+	}
+	return s.saveVrf(response, labels, s.createVrfDataplane(ctx, response))
+}
+
+// createVrfDataplane programs the devices and FRR configuration of a new Vrf, with objectsMu
+// held for reading at least
+func (s *Server) createVrfDataplane(ctx context.Context, obj *pb.Vrf) error {
+	ctx, cancel := s.programmingContext(ctx)
+	defer cancel()
+	return s.dataplane.CreateVrf(ctx, obj)
+}
+
+// saveVrf saves a new Vrf once programmed, or forgets what was reserved for it when the
+// programming failed, with objectsMu held
+func (s *Server) saveVrf(obj *pb.Vrf, labels *ObjectLabels, err error) (*pb.Vrf, error) {
+	if err != nil {
+		s.releaseKernelName(obj.Name)
+		s.forgetStatus(obj.Name)
+		delete(s.RouteTargets, obj.Name)
+		delete(s.AsymmetricIrb, obj.Name)
+		delete(s.Srv6Vrfs, obj.Name)
 		return nil, err
 	}
 	// save object to the database
-	response := protoClone(in.Vrf)
-	response.Status = &pb.VrfStatus{LocalAs: 4, RoutingTable: tableID, Rmac: mac}
-	s.Vrfs[in.Vrf.Name] = response
-	return response, nil
+	s.putVrf(obj)
+	s.persist("vrfs")
+	s.setLabels(obj.Name, labels)
+	if _, ok := s.RouteTargets[obj.Name]; ok {
+		s.persistRouteTargets()
+	}
+	if s.AsymmetricIrb[obj.Name] {
+		s.persistAsymmetricIrb()
+	}
+	if s.IsSrv6Vrf(obj) {
+		s.persistSrv6Vrfs()
+	}
+	s.events.Publish(utils.WatchEvent{Type: utils.WatchAdded, Name: obj.Name})
+	return obj, nil
 }
 
 // DeleteVrf deletes a VRF
@@ -81,56 +205,132 @@ func (s *Server) DeleteVrf(ctx context.Context, in *pb.DeleteVrfRequest) (*empty
 	if err := s.validateDeleteVrfRequest(in); err != nil {
 		return nil, err
 	}
+	// a retry fails at once instead of waiting for the operation tearing the Vrf down
+	if err := s.checkNoOperation(in.Name); err != nil && inTenant(ctx, in.Name) {
+		return nil, err
+	}
+	s.objectsMu.Lock()
+	defer s.objectsMu.Unlock()
+	if err := s.checkEtag(ctx, in.Name); err != nil {
+		return nil, err
+	}
 	// fetch object from the database
 	obj, ok := s.Vrfs[in.Name]
-	if !ok {
+	if !ok || !inTenant(ctx, in.Name) {
 		if in.AllowMissing {
 			return &emptypb.Empty{}, nil
 		}
 		err := status.Errorf(codes.NotFound, "unable to find key %s", in.Name)
 		return nil, err
 	}
-	// configure netlink
-	if err := s.netlinkDeleteVrf(ctx, obj); err != nil {
+	// a Delete retried while the previous one tears the Vrf down must not do it twice
+	if err := s.checkNoOperation(obj.Name); err != nil {
 		return nil, err
 	}
-	// delete from FRR
-	if err := s.frrDeleteVrfRequest(ctx, obj); err != nil {
+	// refuse to leave dangling references, unless asked to delete them as well
+	if err := s.checkDependents(ctx, obj.Name, s.vrfDependents(obj.Name)); err != nil {
 		return nil, err
 	}
-	// remove from the Database
-	delete(s.Vrfs, obj.Name)
+	if utils.IsValidateOnly(ctx) {
+		return &emptypb.Empty{}, nil
+	}
+	// see https://google.aip.dev/151, the Vrf is DELETING until its teardown is done
+	if utils.IsAsync(ctx) {
+		s.startLifecycleOperation(ctx, obj.Name, LifecycleDeleting, func(ctx context.Context) (proto.Message, error) {
+			s.objectsMu.RLock()
+			err := s.deleteVrfDataplane(ctx, obj)
+			s.objectsMu.RUnlock()
+			if err != nil {
+				return nil, err
+			}
+			s.objectsMu.Lock()
+			defer s.objectsMu.Unlock()
+			s.forgetVrf(obj)
+			return &emptypb.Empty{}, nil
+		})
+		return &emptypb.Empty{}, nil
+	}
+	if err := s.deleteVrfDataplane(ctx, obj); err != nil {
+		return nil, err
+	}
+	s.forgetVrf(obj)
 	return &emptypb.Empty{}, nil
 }
 
+// deleteVrfDataplane deletes the devices and FRR configuration of a Vrf, with objectsMu
+// held for reading at least
+func (s *Server) deleteVrfDataplane(ctx context.Context, obj *pb.Vrf) error {
+	ctx, cancel := s.programmingContext(ctx)
+	defer cancel()
+	return s.dataplane.DeleteVrf(ctx, obj)
+}
+
+// forgetVrf removes a deleted Vrf from the database, with objectsMu held
+func (s *Server) forgetVrf(obj *pb.Vrf) {
+	s.removeVrf(obj.Name)
+	s.forgetStatus(obj.Name)
+	s.persist("vrfs")
+	s.releaseKernelName(obj.Name)
+	s.releaseLabels(obj.Name)
+	s.releaseRouteTargets(obj.Name)
+	s.releaseAsymmetricIrb(obj.Name)
+	s.releaseSrv6Vrf(obj.Name)
+	s.events.Publish(utils.WatchEvent{Type: utils.WatchDeleted, Name: obj.Name})
+	delete(s.Adopted, obj.Name)
+}
+
 // UpdateVrf updates an VRF
 func (s *Server) UpdateVrf(ctx context.Context, in *pb.UpdateVrfRequest) (*pb.Vrf, error) {
 	// check input correctness
 	if err := s.validateUpdateVrfRequest(in); err != nil {
 		return nil, err
 	}
+	// nor does an update of the Vrf wait for its teardown to fail
+	if err := s.checkNotDeleting(in.Vrf.Name); err != nil && inTenant(ctx, in.Vrf.Name) {
+		return nil, err
+	}
+	s.objectsMu.Lock()
+	defer s.objectsMu.Unlock()
+	if err := s.checkEtag(ctx, in.Vrf.Name); err != nil {
+		return nil, err
+	}
 	// fetch object from the database
 	vrf, ok := s.Vrfs[in.Vrf.Name]
-	if !ok {
+	if !ok || !inTenant(ctx, in.Vrf.Name) {
 		// TODO: introduce "in.AllowMissing" field. In case "true", create a new resource, don't return error
 		err := status.Errorf(codes.NotFound, "unable to find key %s", in.Vrf.Name)
 		return nil, err
 	}
-	resourceID := path.Base(vrf.Name)
-	iface, err := s.nLink.LinkByName(ctx, resourceID)
+	if err := s.checkNotDeleting(vrf.Name); err != nil {
+		return nil, err
+	}
+	// the L3 vni devices are only created with the Vrf
+	if vrf.Spec.GetVni() != in.Vrf.Spec.GetVni() || (vrf.Spec.Vni == nil) != (in.Vrf.Spec.Vni == nil) {
+		msg := "the vni of a Vrf cannot be changed, delete and create the Vrf again"
+		return nil, badRequest("vrf.spec.vni", status.Error(codes.InvalidArgument, msg))
+	}
+	in.Vrf.Spec.VtepIpPrefix = s.vtepIPPrefixOr(in.Vrf.Spec.Vni, in.Vrf.Spec.VtepIpPrefix)
+	labels, err := labelsFromContext(ctx)
 	if err != nil {
-		err := status.Errorf(codes.NotFound, "unable to find key %s", resourceID)
 		return nil, err
 	}
-	// base := iface.Attrs()
-	// iface.MTU = 1500 // TODO: remove this, just an example
-	if err := s.nLink.LinkModify(ctx, iface); err != nil {
-		fmt.Printf("Failed to update link: %v", err)
+	// the table and the router MAC were chosen when the Vrf was created, the Routes, the
+	// PbrRules and the dataplanes read them
+	vrfStatus := &pb.VrfStatus{LocalAs: s.Gateway.LocalAs, RoutingTable: vrf.Status.GetRoutingTable(), Rmac: vrf.Status.GetRmac()}
+	if utils.IsValidateOnly(ctx) {
+		response := protoClone(in.Vrf)
+		response.Status = vrfStatus
+		return response, nil
+	}
+	if err := s.dataplane.UpdateVrf(ctx, vrf, in.Vrf); err != nil {
 		return nil, err
 	}
 	response := protoClone(in.Vrf)
-	response.Status = &pb.VrfStatus{LocalAs: 4}
-	s.Vrfs[in.Vrf.Name] = response
+	response.Status = vrfStatus
+	s.putVrf(response)
+	s.persist("vrfs")
+	s.setLabels(in.Vrf.Name, labels)
+	s.events.Publish(utils.WatchEvent{Type: utils.WatchModified, Name: in.Vrf.Name})
 	return response, nil
 }
 
@@ -140,48 +340,78 @@ func (s *Server) GetVrf(ctx context.Context, in *pb.GetVrfRequest) (*pb.Vrf, err
 	if err := s.validateGetVrfRequest(in); err != nil {
 		return nil, err
 	}
+	s.objectsMu.RLock()
+	defer s.objectsMu.RUnlock()
 	// fetch object from the database
 	obj, ok := s.Vrfs[in.Name]
-	if !ok {
+	if !ok || !inTenant(ctx, in.Name) {
 		err := status.Errorf(codes.NotFound, "unable to find key %s", in.Name)
 		return nil, err
 	}
-	resourceID := path.Base(obj.Name)
-	_, err := s.nLink.LinkByName(ctx, resourceID)
-	if err != nil {
-		err := status.Errorf(codes.NotFound, "unable to find key %s", resourceID)
-		return nil, err
+	if !s.LiveRead {
+		if err := s.dataplane.GetVrf(ctx, obj); err != nil {
+			return nil, err
+		}
+	}
+	degraded := map[string]error{}
+	vrfStatus := &pb.VrfStatus{}
+	if obj.Status != nil {
+		vrfStatus = protoClone(obj.Status)
 	}
+	vrfStatus.OperStatus = s.vrfOperStatus(ctx, obj, degraded)
+	reportDegraded(ctx, degraded)
+	s.reportCounters(ctx, []string{in.Name})
+	s.reportLifecycles(ctx, []string{in.Name})
 	// TODO
-	return &pb.Vrf{Name: in.Name, Spec: &pb.VrfSpec{Vni: obj.Spec.Vni}, Status: &pb.VrfStatus{LocalAs: 77}}, nil
+	return &pb.Vrf{Name: in.Name, Spec: &pb.VrfSpec{Vni: obj.Spec.Vni}, Status: vrfStatus}, nil
 }
 
 // ListVrfs lists logical bridges
-func (s *Server) ListVrfs(_ context.Context, in *pb.ListVrfsRequest) (*pb.ListVrfsResponse, error) {
+func (s *Server) ListVrfs(ctx context.Context, in *pb.ListVrfsRequest) (*pb.ListVrfsResponse, error) {
 	// check required fields
 	if err := fieldbehavior.ValidateRequiredFields(in); err != nil {
 		return nil, err
 	}
+	s.objectsMu.RLock()
+	defer s.objectsMu.RUnlock()
 	// fetch pagination from the database, calculate size and offset
-	size, offset, perr := extractPagination(in.PageSize, in.PageToken, s.Pagination)
+	size, offset, perr := s.extractPagination(in.PageSize, in.PageToken)
 	if perr != nil {
 		return nil, perr
 	}
-	// fetch object from the database
-	Blobarray := []*pb.Vrf{}
-	for _, vrf := range s.Vrfs {
-		r := protoClone(vrf)
-		r.Status = &pb.VrfStatus{LocalAs: 4}
-		Blobarray = append(Blobarray, r)
-	}
-	// sort is needed, since MAP is unsorted in golang, and we might get different results
-	sortVrfs(Blobarray)
-	log.Printf("Limiting result len(%d) to [%d:%d]", len(Blobarray), offset, size)
-	Blobarray, hasMoreElements := limitPagination(Blobarray, offset, size)
-	token := ""
-	if hasMoreElements {
-		token = uuid.New().String()
-		s.Pagination[token] = offset + size
+	selector, err := labelSelectorFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// fetch object from the database, the following pages are cut from the snapshot of the first one,
+	// copies of the stored objects taken under objectsMu
+	Blobarray, token, err := listPage(ctx, s, "vrfs", "", in.PageToken, offset, size, func() []*pb.Vrf {
+		Blobarray := []*pb.Vrf{}
+		for name, vrf := range s.Vrfs {
+			if !inTenant(ctx, name) || !s.matchesLabels(selector, name) {
+				continue
+			}
+			Blobarray = append(Blobarray, protoClone(vrf))
+		}
+		// sort is needed, since MAP is unsorted in golang, and we might get different results
+		sortVrfs(Blobarray)
+		return Blobarray
+	})
+	if err != nil {
+		return nil, err
+	}
+	Blobarray = clonePage(Blobarray)
+	degraded := map[string]error{}
+	names := make([]string, 0, len(Blobarray))
+	for _, r := range Blobarray {
+		if r.Status == nil {
+			r.Status = &pb.VrfStatus{}
+		}
+		r.Status.OperStatus = s.vrfOperStatus(ctx, r, degraded)
+		names = append(names, r.Name)
 	}
+	reportDegraded(ctx, degraded)
+	s.reportCounters(ctx, names)
+	s.reportLifecycles(ctx, names)
 	return &pb.ListVrfsResponse{Vrfs: Blobarray, NextPageToken: token}, nil
 }
@@ -7,13 +7,12 @@ package evpn
 import (
 	"context"
 	"fmt"
-	"path"
 
 	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
 )
 
 func (s *Server) frrCreateVrfRequest(ctx context.Context, in *pb.CreateVrfRequest) error {
-	vrfName := path.Base(in.Vrf.Name)
+	vrfName := s.vrfKernelName(in.Vrf.Name)
 	if in.Vrf.Spec.Vni != nil {
 		data, err := s.frr.FrrZebraCmd(ctx, fmt.Sprintf(
 			`configure terminal
@@ -27,11 +26,14 @@ func (s *Server) frrCreateVrfRequest(ctx context.Context, in *pb.CreateVrfReques
 		}
 	}
 	if in.Vrf.Spec.Vni != nil {
-		// TODO: add "bgp router-id <vrf-loopback>" based on in.Vrf.Spec.LoopbackIpPrefix.Addr.GetV4Addr()
+		routerID := ""
+		if id := s.vrfRouterID(in.Vrf); id != "" {
+			routerID = fmt.Sprintf("bgp router-id %s\n", id)
+		}
 		data, err := s.frr.FrrBgpCmd(ctx, fmt.Sprintf(
 			`configure terminal
-			router bgp 65000 vrf %s
-			no bgp log-neighbor-changes
+			router bgp %d vrf %s
+			%sno bgp log-neighbor-changes
 			bgp ebgp-requires-policy
 			no bgp default show-hostname
 			no bgp default show-nexthop-hostname
@@ -45,13 +47,18 @@ func (s *Server) frrCreateVrfRequest(ctx context.Context, in *pb.CreateVrfReques
 				exit-address-family
 			address-family l2vpn evpn
 				advertise ipv4 unicast
-				exit-address-family
-			exit`, vrfName))
+				%sexit-address-family
+			exit`, s.Gateway.LocalAs, vrfName, routerID, s.frrRouteTargets(in.Vrf.Name)))
 		fmt.Printf("FrrBgpCmd: %v:%v", data, err)
 		if err != nil {
 			return err
 		}
 	}
+	if s.IsSrv6Vrf(in.Vrf) {
+		if err := s.frrCreateSrv6Vrf(ctx, in.Vrf, vrfName); err != nil {
+			return err
+		}
+	}
 	// check FRR for debug
 	data, err := s.frr.FrrZebraCmd(ctx, "show vrf")
 	fmt.Printf("FrrZebraCmd: %v:%v", data, err)
@@ -62,12 +69,12 @@ func (s *Server) frrCreateVrfRequest(ctx context.Context, in *pb.CreateVrfReques
 }
 
 func (s *Server) frrDeleteVrfRequest(ctx context.Context, obj *pb.Vrf) error {
-	vrfName := path.Base(obj.Name)
-	if obj.Spec.Vni != nil {
+	vrfName := s.vrfKernelName(obj.Name)
+	if obj.Spec.Vni != nil || s.IsSrv6Vrf(obj) {
 		data, err := s.frr.FrrBgpCmd(ctx, fmt.Sprintf(
 			`configure terminal
-			no router bgp 65000 vrf %s
-			exit`, vrfName))
+			no router bgp %d vrf %s
+			exit`, s.Gateway.LocalAs, vrfName))
 		fmt.Printf("FrrBgpCmd: %v:%v", data, err)
 		if err != nil {
 			return err
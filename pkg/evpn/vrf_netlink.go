@@ -11,7 +11,6 @@ import (
 	"fmt"
 	"log"
 	"net"
-	"path"
 
 	"github.com/vishvananda/netlink"
 
@@ -22,9 +21,12 @@ import (
 )
 
 func (s *Server) netlinkCreateVrf(ctx context.Context, in *pb.CreateVrfRequest, tableID uint32, mac []byte) error {
-	vrfName := path.Base(in.Vrf.Name)
+	if s.vrfNetns() {
+		return s.netnsCreateVrf(ctx, in, mac)
+	}
+	vrfName := s.vrfKernelName(in.Vrf.Name)
 	// Example: ip link add blue type vrf table 1000
-	vrf := &netlink.Vrf{LinkAttrs: netlink.LinkAttrs{Name: vrfName}, Table: tableID}
+	vrf := &netlink.Vrf{LinkAttrs: netlink.LinkAttrs{Name: vrfName, Alias: managedAlias}, Table: tableID}
 	log.Printf("Creating VRF %v", vrf)
 	if err := s.nLink.LinkAdd(ctx, vrf); err != nil {
 		fmt.Printf("Failed to create VRF link: %v", err)
@@ -35,13 +37,37 @@ func (s *Server) netlinkCreateVrf(ctx context.Context, in *pb.CreateVrfRequest,
 		fmt.Printf("Failed to up VRF link: %v", err)
 		return err
 	}
-	// Example: ip address add <vrf-loopback> dev <vrf-name>
-	if in.Vrf.Spec.LoopbackIpPrefix != nil && in.Vrf.Spec.LoopbackIpPrefix.Addr != nil && in.Vrf.Spec.LoopbackIpPrefix.Len > 0 {
-		myip := make(net.IP, 4)
-		binary.BigEndian.PutUint32(myip, in.Vrf.Spec.LoopbackIpPrefix.Addr.GetV4Addr())
-		addr := &netlink.Addr{IPNet: &net.IPNet{IP: myip, Mask: net.CIDRMask(int(in.Vrf.Spec.LoopbackIpPrefix.Len), 32)}}
-		if err := s.nLink.AddrAdd(ctx, vrf, addr); err != nil {
-			fmt.Printf("Failed to set IP on VRF link: %v", err)
+	// the loopback address is held by a dummy device of the Vrf, visible to the operator
+	if addr := vrfLoopbackAddr(in.Vrf); addr != nil {
+		// Example: ip link add blue-lo type dummy
+		loopback := &netlink.Dummy{LinkAttrs: netlink.LinkAttrs{Name: s.vrfLoopbackName(in.Vrf), Alias: managedAlias}}
+		log.Printf("Creating VRF loopback %v", loopback)
+		if err := s.nLink.LinkAdd(ctx, loopback); err != nil {
+			fmt.Printf("Failed to create loopback link: %v", err)
+			return err
+		}
+		// Example: ip link set blue-lo master blue
+		if err := s.nLink.LinkSetMaster(ctx, loopback, vrf); err != nil {
+			fmt.Printf("Failed to add loopback to VRF: %v", err)
+			return err
+		}
+		// Example: ip address add <vrf-loopback> dev blue-lo
+		if err := s.nLink.AddrAdd(ctx, loopback, &netlink.Addr{IPNet: addr}); err != nil {
+			fmt.Printf("Failed to set IP on loopback link: %v", err)
+			return err
+		}
+		// Example: ip link set blue-lo up
+		if err := s.nLink.LinkSetUp(ctx, loopback); err != nil {
+			fmt.Printf("Failed to up loopback link: %v", err)
+			return err
+		}
+	}
+	// Example: ip -6 route add <sid>/128 encap seg6local action End.DT46 vrftable 2000 dev blue
+	if table, ok := s.Srv6Vrfs[in.Vrf.Name]; ok {
+		route := s.srv6SidRoute(vrf, table)
+		log.Printf("Creating SRv6 SID %v", route)
+		if err := s.nLink.RouteAdd(ctx, route); err != nil {
+			fmt.Printf("Failed to add SRv6 SID route: %v", err)
 			return err
 		}
 	}
@@ -52,7 +78,7 @@ func (s *Server) netlinkCreateVrf(ctx context.Context, in *pb.CreateVrfRequest,
 	if in.Vrf.Spec.Vni != nil {
 		// Example: ip link add br100 type bridge
 		bridgeName := fmt.Sprintf("br%d", *in.Vrf.Spec.Vni)
-		bridge := &netlink.Bridge{LinkAttrs: netlink.LinkAttrs{Name: bridgeName}}
+		bridge := &netlink.Bridge{LinkAttrs: netlink.LinkAttrs{Name: bridgeName, Alias: managedAlias}}
 		log.Printf("Creating Linux Bridge %v", bridge)
 		if err := s.nLink.LinkAdd(ctx, bridge); err != nil {
 			fmt.Printf("Failed to create Bridge link: %v", err)
@@ -77,8 +103,7 @@ func (s *Server) netlinkCreateVrf(ctx context.Context, in *pb.CreateVrfRequest,
 		vxlanName := fmt.Sprintf("vni%d", *in.Vrf.Spec.Vni)
 		myip := make(net.IP, 4)
 		binary.BigEndian.PutUint32(myip, in.Vrf.Spec.VtepIpPrefix.Addr.GetV4Addr())
-		// TODO: take Port from proto instead of hard-coded
-		vxlan := &netlink.Vxlan{LinkAttrs: netlink.LinkAttrs{Name: vxlanName}, VxlanId: int(*in.Vrf.Spec.Vni), Port: 4789, Learning: false, SrcAddr: myip}
+		vxlan := s.newVxlan(vxlanName, *in.Vrf.Spec.Vni, myip)
 		log.Printf("Creating VXLAN %v", vxlan)
 		if err := s.nLink.LinkAdd(ctx, vxlan); err != nil {
 			fmt.Printf("Failed to create Vxlan link: %v", err)
@@ -98,7 +123,31 @@ func (s *Server) netlinkCreateVrf(ctx context.Context, in *pb.CreateVrfRequest,
 	return nil
 }
 
+func (s *Server) netlinkUpdateVrf(ctx context.Context, obj *pb.Vrf) error {
+	// a namespace has nothing to modify
+	if s.vrfNetns() {
+		_, err := s.vrfNetlink(ctx, obj.Name)
+		return err
+	}
+	resourceID := s.vrfKernelName(obj.Name)
+	iface, err := s.nLink.LinkByName(ctx, resourceID)
+	if err != nil {
+		err := status.Errorf(codes.NotFound, "unable to find key %s", resourceID)
+		return err
+	}
+	// base := iface.Attrs()
+	// iface.MTU = 1500 // TODO: remove this, just an example
+	if err := s.nLink.LinkModify(ctx, iface); err != nil {
+		fmt.Printf("Failed to update link: %v", err)
+		return err
+	}
+	return nil
+}
+
 func (s *Server) netlinkDeleteVrf(ctx context.Context, obj *pb.Vrf) error {
+	if s.vrfNetns() {
+		return s.netnsDeleteVrf(ctx, obj)
+	}
 	// delete bridge and vxlan only if VNI value is not empty
 	if obj.Spec.Vni != nil {
 		// use netlink to find VXLAN device
@@ -138,7 +187,18 @@ func (s *Server) netlinkDeleteVrf(ctx context.Context, obj *pb.Vrf) error {
 			return err
 		}
 	}
-	vrfName := path.Base(obj.Name)
+	// the loopback is not deleted with the VRF, a missing one is left alone so a half
+	// created Vrf can still be cleaned up
+	if vrfLoopbackAddr(obj) != nil {
+		if loopback, err := s.nLink.LinkByName(ctx, s.vrfLoopbackName(obj)); err == nil {
+			log.Printf("Deleting VRF loopback %v", loopback)
+			if err := s.nLink.LinkDel(ctx, loopback); err != nil {
+				fmt.Printf("Failed to delete link: %v", err)
+				return err
+			}
+		}
+	}
+	vrfName := s.vrfKernelName(obj.Name)
 	// use netlink to find VRF
 	vrf, err := s.nLink.LinkByName(ctx, vrfName)
 	log.Printf("Deleting VRF %v", vrf)
@@ -146,6 +206,13 @@ func (s *Server) netlinkDeleteVrf(ctx context.Context, obj *pb.Vrf) error {
 		err := status.Errorf(codes.NotFound, "unable to find key %s", vrfName)
 		return err
 	}
+	// Example: ip -6 route del <sid>/128 dev blue
+	if table, ok := s.Srv6Vrfs[obj.Name]; ok {
+		if err := s.nLink.RouteDel(ctx, s.srv6SidRoute(vrf, table)); err != nil {
+			fmt.Printf("Failed to delete SRv6 SID route: %v", err)
+			return err
+		}
+	}
 	// bring link down
 	if err := s.nLink.LinkSetDown(ctx, vrf); err != nil {
 		fmt.Printf("Failed to up link: %v", err)
@@ -6,6 +6,7 @@
 package evpn
 
 import (
+	"bytes"
 	"context"
 	"encoding/binary"
 	"errors"
@@ -14,6 +15,7 @@ import (
 	"net"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/philippgille/gokv/gomap"
 	"github.com/stretchr/testify/mock"
@@ -22,6 +24,7 @@ import (
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/emptypb"
@@ -30,6 +33,8 @@ import (
 	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
 	pc "github.com/opiproject/opi-api/network/opinetcommon/v1alpha1/gen/go"
 
+	"github.com/opiproject/opi-evpn-bridge/pkg/fake"
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils"
 	"github.com/opiproject/opi-evpn-bridge/pkg/utils/mocks"
 )
 
@@ -63,7 +68,7 @@ var (
 		Name: testVrfName,
 		Spec: testVrf.Spec,
 		Status: &pb.VrfStatus{
-			LocalAs: 4,
+			LocalAs: 65000,
 		},
 	}
 )
@@ -82,7 +87,7 @@ func Test_CreateVrf(t *testing.T) {
 			id:      "CapitalLettersNotAllowed",
 			in:      &testVrf,
 			out:     nil,
-			errCode: codes.Unknown,
+			errCode: codes.InvalidArgument,
 			errMsg:  fmt.Sprintf("user-settable ID must only contain lowercase, numbers and hyphens (%v)", "got: 'C' in position 0"),
 			exist:   false,
 			on:      nil,
@@ -91,7 +96,7 @@ func Test_CreateVrf(t *testing.T) {
 			id:      testVrfID,
 			in:      nil,
 			out:     nil,
-			errCode: codes.Unknown,
+			errCode: codes.InvalidArgument,
 			errMsg:  "missing required field: vrf",
 			exist:   false,
 			on:      nil,
@@ -102,7 +107,7 @@ func Test_CreateVrf(t *testing.T) {
 				Spec: &pb.VrfSpec{},
 			},
 			out:     nil,
-			errCode: codes.Unknown,
+			errCode: codes.InvalidArgument,
 			errMsg:  "missing required field: vrf.spec.loopback_ip_prefix",
 			exist:   false,
 			on:      nil,
@@ -116,6 +121,22 @@ func Test_CreateVrf(t *testing.T) {
 			exist:   true,
 			on:      nil,
 		},
+		"already exists with a different spec": {
+			id: testVrfID,
+			in: &pb.Vrf{
+				Spec: &pb.VrfSpec{
+					Vni: proto.Uint32(2000),
+					LoopbackIpPrefix: &pc.IPPrefix{
+						Len: 24,
+					},
+				},
+			},
+			out:     nil,
+			errCode: codes.AlreadyExists,
+			errMsg:  fmt.Sprintf("%v already exists with a different spec", testVrfName),
+			exist:   true,
+			on:      nil,
+		},
 		"valid request empty VNI amd empty Loopback": {
 			id: testVrfID,
 			in: &pb.Vrf{
@@ -132,7 +153,7 @@ func Test_CreateVrf(t *testing.T) {
 					},
 				},
 				Status: &pb.VrfStatus{
-					LocalAs:      4,
+					LocalAs:      65000,
 					RoutingTable: 1000,
 					Rmac:         []byte{0xCB, 0xB8, 0x33, 0x4C, 0x88, 0x4F},
 				},
@@ -141,7 +162,7 @@ func Test_CreateVrf(t *testing.T) {
 			errMsg:  "",
 			exist:   false,
 			on: func(mockNetlink *mocks.Netlink, mockFrr *mocks.Frr, errMsg string) {
-				vrf := &netlink.Vrf{LinkAttrs: netlink.LinkAttrs{Name: testVrfID}, Table: 1000}
+				vrf := &netlink.Vrf{LinkAttrs: netlink.LinkAttrs{Name: testVrfID, Alias: managedAlias}, Table: 1000}
 				mockNetlink.EXPECT().LinkAdd(mock.Anything, vrf).Return(nil).Once()
 				mockNetlink.EXPECT().LinkSetUp(mock.Anything, vrf).Return(nil).Once()
 				// frr
@@ -156,7 +177,7 @@ func Test_CreateVrf(t *testing.T) {
 			errMsg:  "Failed to call LinkAdd",
 			exist:   false,
 			on: func(mockNetlink *mocks.Netlink, mockFrr *mocks.Frr, errMsg string) {
-				vrf := &netlink.Vrf{LinkAttrs: netlink.LinkAttrs{Name: testVrfID}, Table: 1001}
+				vrf := &netlink.Vrf{LinkAttrs: netlink.LinkAttrs{Name: testVrfID, Alias: managedAlias}, Table: 1001}
 				mockNetlink.EXPECT().LinkAdd(mock.Anything, vrf).Return(errors.New(errMsg)).Once()
 			},
 		},
@@ -168,7 +189,7 @@ func Test_CreateVrf(t *testing.T) {
 			errMsg:  "Failed to call LinkSetUp",
 			exist:   false,
 			on: func(mockNetlink *mocks.Netlink, mockFrr *mocks.Frr, errMsg string) {
-				vrf := &netlink.Vrf{LinkAttrs: netlink.LinkAttrs{Name: testVrfID}, Table: 1001}
+				vrf := &netlink.Vrf{LinkAttrs: netlink.LinkAttrs{Name: testVrfID, Alias: managedAlias}, Table: 1001}
 				mockNetlink.EXPECT().LinkAdd(mock.Anything, vrf).Return(nil).Once()
 				mockNetlink.EXPECT().LinkSetUp(mock.Anything, vrf).Return(errors.New(errMsg)).Once()
 			},
@@ -182,8 +203,8 @@ func Test_CreateVrf(t *testing.T) {
 			exist:   false,
 			on: func(mockNetlink *mocks.Netlink, mockFrr *mocks.Frr, errMsg string) {
 				bridgeName := fmt.Sprintf("br%d", *testVrf.Spec.Vni)
-				vrf := &netlink.Vrf{LinkAttrs: netlink.LinkAttrs{Name: testVrfID}, Table: 1001}
-				bridge := &netlink.Bridge{LinkAttrs: netlink.LinkAttrs{Name: bridgeName}}
+				vrf := &netlink.Vrf{LinkAttrs: netlink.LinkAttrs{Name: testVrfID, Alias: managedAlias}, Table: 1001}
+				bridge := &netlink.Bridge{LinkAttrs: netlink.LinkAttrs{Name: bridgeName, Alias: managedAlias}}
 				mockNetlink.EXPECT().LinkAdd(mock.Anything, vrf).Return(nil).Once()
 				mockNetlink.EXPECT().LinkSetUp(mock.Anything, vrf).Return(nil).Once()
 				mockNetlink.EXPECT().LinkAdd(mock.Anything, bridge).Return(errors.New(errMsg)).Once()
@@ -198,8 +219,8 @@ func Test_CreateVrf(t *testing.T) {
 			exist:   false,
 			on: func(mockNetlink *mocks.Netlink, mockFrr *mocks.Frr, errMsg string) {
 				bridgeName := fmt.Sprintf("br%d", *testVrf.Spec.Vni)
-				vrf := &netlink.Vrf{LinkAttrs: netlink.LinkAttrs{Name: testVrfID}, Table: 1001}
-				bridge := &netlink.Bridge{LinkAttrs: netlink.LinkAttrs{Name: bridgeName}}
+				vrf := &netlink.Vrf{LinkAttrs: netlink.LinkAttrs{Name: testVrfID, Alias: managedAlias}, Table: 1001}
+				bridge := &netlink.Bridge{LinkAttrs: netlink.LinkAttrs{Name: bridgeName, Alias: managedAlias}}
 				mockNetlink.EXPECT().LinkAdd(mock.Anything, vrf).Return(nil).Once()
 				mockNetlink.EXPECT().LinkSetUp(mock.Anything, vrf).Return(nil).Once()
 				mockNetlink.EXPECT().LinkAdd(mock.Anything, bridge).Return(nil).Once()
@@ -215,8 +236,8 @@ func Test_CreateVrf(t *testing.T) {
 			exist:   false,
 			on: func(mockNetlink *mocks.Netlink, mockFrr *mocks.Frr, errMsg string) {
 				bridgeName := fmt.Sprintf("br%d", *testVrf.Spec.Vni)
-				vrf := &netlink.Vrf{LinkAttrs: netlink.LinkAttrs{Name: testVrfID}, Table: 1001}
-				bridge := &netlink.Bridge{LinkAttrs: netlink.LinkAttrs{Name: bridgeName}}
+				vrf := &netlink.Vrf{LinkAttrs: netlink.LinkAttrs{Name: testVrfID, Alias: managedAlias}, Table: 1001}
+				bridge := &netlink.Bridge{LinkAttrs: netlink.LinkAttrs{Name: bridgeName, Alias: managedAlias}}
 				mockNetlink.EXPECT().LinkAdd(mock.Anything, vrf).Return(nil).Once()
 				mockNetlink.EXPECT().LinkSetUp(mock.Anything, vrf).Return(nil).Once()
 				mockNetlink.EXPECT().LinkAdd(mock.Anything, bridge).Return(nil).Once()
@@ -233,8 +254,8 @@ func Test_CreateVrf(t *testing.T) {
 			exist:   false,
 			on: func(mockNetlink *mocks.Netlink, mockFrr *mocks.Frr, errMsg string) {
 				bridgeName := fmt.Sprintf("br%d", *testVrf.Spec.Vni)
-				vrf := &netlink.Vrf{LinkAttrs: netlink.LinkAttrs{Name: testVrfID}, Table: 1001}
-				bridge := &netlink.Bridge{LinkAttrs: netlink.LinkAttrs{Name: bridgeName}}
+				vrf := &netlink.Vrf{LinkAttrs: netlink.LinkAttrs{Name: testVrfID, Alias: managedAlias}, Table: 1001}
+				bridge := &netlink.Bridge{LinkAttrs: netlink.LinkAttrs{Name: bridgeName, Alias: managedAlias}}
 				mockNetlink.EXPECT().LinkAdd(mock.Anything, vrf).Return(nil).Once()
 				mockNetlink.EXPECT().LinkSetUp(mock.Anything, vrf).Return(nil).Once()
 				mockNetlink.EXPECT().LinkAdd(mock.Anything, bridge).Return(nil).Once()
@@ -252,8 +273,8 @@ func Test_CreateVrf(t *testing.T) {
 			exist:   false,
 			on: func(mockNetlink *mocks.Netlink, mockFrr *mocks.Frr, errMsg string) {
 				bridgeName := fmt.Sprintf("br%d", *testVrf.Spec.Vni)
-				vrf := &netlink.Vrf{LinkAttrs: netlink.LinkAttrs{Name: testVrfID}, Table: 1001}
-				bridge := &netlink.Bridge{LinkAttrs: netlink.LinkAttrs{Name: bridgeName}}
+				vrf := &netlink.Vrf{LinkAttrs: netlink.LinkAttrs{Name: testVrfID, Alias: managedAlias}, Table: 1001}
+				bridge := &netlink.Bridge{LinkAttrs: netlink.LinkAttrs{Name: bridgeName, Alias: managedAlias}}
 				mockNetlink.EXPECT().LinkAdd(mock.Anything, vrf).Return(nil).Once()
 				mockNetlink.EXPECT().LinkSetUp(mock.Anything, vrf).Return(nil).Once()
 				mockNetlink.EXPECT().LinkAdd(mock.Anything, bridge).Return(nil).Once()
@@ -263,7 +284,7 @@ func Test_CreateVrf(t *testing.T) {
 				myip := make(net.IP, 4)
 				binary.BigEndian.PutUint32(myip, 167772162)
 				vxlanName := fmt.Sprintf("vni%d", *testVrf.Spec.Vni)
-				vxlan := &netlink.Vxlan{LinkAttrs: netlink.LinkAttrs{Name: vxlanName}, VxlanId: int(*testVrf.Spec.Vni), Port: 4789, Learning: false, SrcAddr: myip}
+				vxlan := &netlink.Vxlan{LinkAttrs: netlink.LinkAttrs{Name: vxlanName, Alias: managedAlias}, VxlanId: int(*testVrf.Spec.Vni), Port: 4789, Learning: false, SrcAddr: myip}
 				mockNetlink.EXPECT().LinkAdd(mock.Anything, vxlan).Return(errors.New(errMsg)).Once()
 			},
 		},
@@ -276,8 +297,8 @@ func Test_CreateVrf(t *testing.T) {
 			exist:   false,
 			on: func(mockNetlink *mocks.Netlink, mockFrr *mocks.Frr, errMsg string) {
 				bridgeName := fmt.Sprintf("br%d", *testVrf.Spec.Vni)
-				vrf := &netlink.Vrf{LinkAttrs: netlink.LinkAttrs{Name: testVrfID}, Table: 1001}
-				bridge := &netlink.Bridge{LinkAttrs: netlink.LinkAttrs{Name: bridgeName}}
+				vrf := &netlink.Vrf{LinkAttrs: netlink.LinkAttrs{Name: testVrfID, Alias: managedAlias}, Table: 1001}
+				bridge := &netlink.Bridge{LinkAttrs: netlink.LinkAttrs{Name: bridgeName, Alias: managedAlias}}
 				mockNetlink.EXPECT().LinkAdd(mock.Anything, vrf).Return(nil).Once()
 				mockNetlink.EXPECT().LinkSetUp(mock.Anything, vrf).Return(nil).Once()
 				mockNetlink.EXPECT().LinkAdd(mock.Anything, bridge).Return(nil).Once()
@@ -287,7 +308,7 @@ func Test_CreateVrf(t *testing.T) {
 				myip := make(net.IP, 4)
 				binary.BigEndian.PutUint32(myip, 167772162)
 				vxlanName := fmt.Sprintf("vni%d", *testVrf.Spec.Vni)
-				vxlan := &netlink.Vxlan{LinkAttrs: netlink.LinkAttrs{Name: vxlanName}, VxlanId: int(*testVrf.Spec.Vni), Port: 4789, Learning: false, SrcAddr: myip}
+				vxlan := &netlink.Vxlan{LinkAttrs: netlink.LinkAttrs{Name: vxlanName, Alias: managedAlias}, VxlanId: int(*testVrf.Spec.Vni), Port: 4789, Learning: false, SrcAddr: myip}
 				mockNetlink.EXPECT().LinkAdd(mock.Anything, vxlan).Return(nil).Once()
 				mockNetlink.EXPECT().LinkSetMaster(mock.Anything, vxlan, bridge).Return(errors.New(errMsg)).Once()
 			},
@@ -301,8 +322,8 @@ func Test_CreateVrf(t *testing.T) {
 			exist:   false,
 			on: func(mockNetlink *mocks.Netlink, mockFrr *mocks.Frr, errMsg string) {
 				bridgeName := fmt.Sprintf("br%d", *testVrf.Spec.Vni)
-				vrf := &netlink.Vrf{LinkAttrs: netlink.LinkAttrs{Name: testVrfID}, Table: 1001}
-				bridge := &netlink.Bridge{LinkAttrs: netlink.LinkAttrs{Name: bridgeName}}
+				vrf := &netlink.Vrf{LinkAttrs: netlink.LinkAttrs{Name: testVrfID, Alias: managedAlias}, Table: 1001}
+				bridge := &netlink.Bridge{LinkAttrs: netlink.LinkAttrs{Name: bridgeName, Alias: managedAlias}}
 				mockNetlink.EXPECT().LinkAdd(mock.Anything, vrf).Return(nil).Once()
 				mockNetlink.EXPECT().LinkSetUp(mock.Anything, vrf).Return(nil).Once()
 				mockNetlink.EXPECT().LinkAdd(mock.Anything, bridge).Return(nil).Once()
@@ -312,7 +333,7 @@ func Test_CreateVrf(t *testing.T) {
 				myip := make(net.IP, 4)
 				binary.BigEndian.PutUint32(myip, 167772162)
 				vxlanName := fmt.Sprintf("vni%d", *testVrf.Spec.Vni)
-				vxlan := &netlink.Vxlan{LinkAttrs: netlink.LinkAttrs{Name: vxlanName}, VxlanId: int(*testVrf.Spec.Vni), Port: 4789, Learning: false, SrcAddr: myip}
+				vxlan := &netlink.Vxlan{LinkAttrs: netlink.LinkAttrs{Name: vxlanName, Alias: managedAlias}, VxlanId: int(*testVrf.Spec.Vni), Port: 4789, Learning: false, SrcAddr: myip}
 				mockNetlink.EXPECT().LinkAdd(mock.Anything, vxlan).Return(nil).Once()
 				mockNetlink.EXPECT().LinkSetMaster(mock.Anything, vxlan, bridge).Return(nil).Once()
 				mockNetlink.EXPECT().LinkSetUp(mock.Anything, vxlan).Return(errors.New(errMsg)).Once()
@@ -433,7 +454,7 @@ func Test_DeleteVrf(t *testing.T) {
 				myip := make(net.IP, 4)
 				binary.BigEndian.PutUint32(myip, 167772162)
 				vxlanName := fmt.Sprintf("vni%d", *testVrf.Spec.Vni)
-				vxlan := &netlink.Vxlan{LinkAttrs: netlink.LinkAttrs{Name: vxlanName}, VxlanId: int(*testVrf.Spec.Vni), Port: 4789, Learning: false, SrcAddr: myip}
+				vxlan := &netlink.Vxlan{LinkAttrs: netlink.LinkAttrs{Name: vxlanName, Alias: managedAlias}, VxlanId: int(*testVrf.Spec.Vni), Port: 4789, Learning: false, SrcAddr: myip}
 				mockNetlink.EXPECT().LinkByName(mock.Anything, vxlanName).Return(vxlan, nil).Once()
 				mockNetlink.EXPECT().LinkSetDown(mock.Anything, vxlan).Return(errors.New(errMsg)).Once()
 			},
@@ -448,7 +469,7 @@ func Test_DeleteVrf(t *testing.T) {
 				myip := make(net.IP, 4)
 				binary.BigEndian.PutUint32(myip, 167772162)
 				vxlanName := fmt.Sprintf("vni%d", *testVrf.Spec.Vni)
-				vxlan := &netlink.Vxlan{LinkAttrs: netlink.LinkAttrs{Name: vxlanName}, VxlanId: int(*testVrf.Spec.Vni), Port: 4789, Learning: false, SrcAddr: myip}
+				vxlan := &netlink.Vxlan{LinkAttrs: netlink.LinkAttrs{Name: vxlanName, Alias: managedAlias}, VxlanId: int(*testVrf.Spec.Vni), Port: 4789, Learning: false, SrcAddr: myip}
 				mockNetlink.EXPECT().LinkByName(mock.Anything, vxlanName).Return(vxlan, nil).Once()
 				mockNetlink.EXPECT().LinkSetDown(mock.Anything, vxlan).Return(nil).Once()
 				mockNetlink.EXPECT().LinkDel(mock.Anything, vxlan).Return(errors.New(errMsg)).Once()
@@ -464,7 +485,7 @@ func Test_DeleteVrf(t *testing.T) {
 				myip := make(net.IP, 4)
 				binary.BigEndian.PutUint32(myip, 167772162)
 				vxlanName := fmt.Sprintf("vni%d", *testVrf.Spec.Vni)
-				vxlan := &netlink.Vxlan{LinkAttrs: netlink.LinkAttrs{Name: vxlanName}, VxlanId: int(*testVrf.Spec.Vni), Port: 4789, Learning: false, SrcAddr: myip}
+				vxlan := &netlink.Vxlan{LinkAttrs: netlink.LinkAttrs{Name: vxlanName, Alias: managedAlias}, VxlanId: int(*testVrf.Spec.Vni), Port: 4789, Learning: false, SrcAddr: myip}
 				mockNetlink.EXPECT().LinkByName(mock.Anything, vxlanName).Return(vxlan, nil).Once()
 				mockNetlink.EXPECT().LinkSetDown(mock.Anything, vxlan).Return(nil).Once()
 				mockNetlink.EXPECT().LinkDel(mock.Anything, vxlan).Return(nil).Once()
@@ -482,12 +503,12 @@ func Test_DeleteVrf(t *testing.T) {
 				myip := make(net.IP, 4)
 				binary.BigEndian.PutUint32(myip, 167772162)
 				vxlanName := fmt.Sprintf("vni%d", *testVrf.Spec.Vni)
-				vxlan := &netlink.Vxlan{LinkAttrs: netlink.LinkAttrs{Name: vxlanName}, VxlanId: int(*testVrf.Spec.Vni), Port: 4789, Learning: false, SrcAddr: myip}
+				vxlan := &netlink.Vxlan{LinkAttrs: netlink.LinkAttrs{Name: vxlanName, Alias: managedAlias}, VxlanId: int(*testVrf.Spec.Vni), Port: 4789, Learning: false, SrcAddr: myip}
 				mockNetlink.EXPECT().LinkByName(mock.Anything, vxlanName).Return(vxlan, nil).Once()
 				mockNetlink.EXPECT().LinkSetDown(mock.Anything, vxlan).Return(nil).Once()
 				mockNetlink.EXPECT().LinkDel(mock.Anything, vxlan).Return(nil).Once()
 				bridgeName := fmt.Sprintf("br%d", *testVrf.Spec.Vni)
-				bridge := &netlink.Bridge{LinkAttrs: netlink.LinkAttrs{Name: bridgeName}}
+				bridge := &netlink.Bridge{LinkAttrs: netlink.LinkAttrs{Name: bridgeName, Alias: managedAlias}}
 				mockNetlink.EXPECT().LinkByName(mock.Anything, bridgeName).Return(bridge, nil).Once()
 				mockNetlink.EXPECT().LinkSetDown(mock.Anything, bridge).Return(errors.New(errMsg)).Once()
 			},
@@ -502,12 +523,12 @@ func Test_DeleteVrf(t *testing.T) {
 				myip := make(net.IP, 4)
 				binary.BigEndian.PutUint32(myip, 167772162)
 				vxlanName := fmt.Sprintf("vni%d", *testVrf.Spec.Vni)
-				vxlan := &netlink.Vxlan{LinkAttrs: netlink.LinkAttrs{Name: vxlanName}, VxlanId: int(*testVrf.Spec.Vni), Port: 4789, Learning: false, SrcAddr: myip}
+				vxlan := &netlink.Vxlan{LinkAttrs: netlink.LinkAttrs{Name: vxlanName, Alias: managedAlias}, VxlanId: int(*testVrf.Spec.Vni), Port: 4789, Learning: false, SrcAddr: myip}
 				mockNetlink.EXPECT().LinkByName(mock.Anything, vxlanName).Return(vxlan, nil).Once()
 				mockNetlink.EXPECT().LinkSetDown(mock.Anything, vxlan).Return(nil).Once()
 				mockNetlink.EXPECT().LinkDel(mock.Anything, vxlan).Return(nil).Once()
 				bridgeName := fmt.Sprintf("br%d", *testVrf.Spec.Vni)
-				bridge := &netlink.Bridge{LinkAttrs: netlink.LinkAttrs{Name: bridgeName}}
+				bridge := &netlink.Bridge{LinkAttrs: netlink.LinkAttrs{Name: bridgeName, Alias: managedAlias}}
 				mockNetlink.EXPECT().LinkByName(mock.Anything, bridgeName).Return(bridge, nil).Once()
 				mockNetlink.EXPECT().LinkSetDown(mock.Anything, bridge).Return(nil).Once()
 				mockNetlink.EXPECT().LinkDel(mock.Anything, bridge).Return(errors.New(errMsg)).Once()
@@ -523,12 +544,12 @@ func Test_DeleteVrf(t *testing.T) {
 				myip := make(net.IP, 4)
 				binary.BigEndian.PutUint32(myip, 167772162)
 				vxlanName := fmt.Sprintf("vni%d", *testVrf.Spec.Vni)
-				vxlan := &netlink.Vxlan{LinkAttrs: netlink.LinkAttrs{Name: vxlanName}, VxlanId: int(*testVrf.Spec.Vni), Port: 4789, Learning: false, SrcAddr: myip}
+				vxlan := &netlink.Vxlan{LinkAttrs: netlink.LinkAttrs{Name: vxlanName, Alias: managedAlias}, VxlanId: int(*testVrf.Spec.Vni), Port: 4789, Learning: false, SrcAddr: myip}
 				mockNetlink.EXPECT().LinkByName(mock.Anything, vxlanName).Return(vxlan, nil).Once()
 				mockNetlink.EXPECT().LinkSetDown(mock.Anything, vxlan).Return(nil).Once()
 				mockNetlink.EXPECT().LinkDel(mock.Anything, vxlan).Return(nil).Once()
 				bridgeName := fmt.Sprintf("br%d", *testVrf.Spec.Vni)
-				bridge := &netlink.Bridge{LinkAttrs: netlink.LinkAttrs{Name: bridgeName}}
+				bridge := &netlink.Bridge{LinkAttrs: netlink.LinkAttrs{Name: bridgeName, Alias: managedAlias}}
 				mockNetlink.EXPECT().LinkByName(mock.Anything, bridgeName).Return(bridge, nil).Once()
 				mockNetlink.EXPECT().LinkSetDown(mock.Anything, bridge).Return(nil).Once()
 				mockNetlink.EXPECT().LinkDel(mock.Anything, bridge).Return(nil).Once()
@@ -545,16 +566,16 @@ func Test_DeleteVrf(t *testing.T) {
 				myip := make(net.IP, 4)
 				binary.BigEndian.PutUint32(myip, 167772162)
 				vxlanName := fmt.Sprintf("vni%d", *testVrf.Spec.Vni)
-				vxlan := &netlink.Vxlan{LinkAttrs: netlink.LinkAttrs{Name: vxlanName}, VxlanId: int(*testVrf.Spec.Vni), Port: 4789, Learning: false, SrcAddr: myip}
+				vxlan := &netlink.Vxlan{LinkAttrs: netlink.LinkAttrs{Name: vxlanName, Alias: managedAlias}, VxlanId: int(*testVrf.Spec.Vni), Port: 4789, Learning: false, SrcAddr: myip}
 				mockNetlink.EXPECT().LinkByName(mock.Anything, vxlanName).Return(vxlan, nil).Once()
 				mockNetlink.EXPECT().LinkSetDown(mock.Anything, vxlan).Return(nil).Once()
 				mockNetlink.EXPECT().LinkDel(mock.Anything, vxlan).Return(nil).Once()
 				bridgeName := fmt.Sprintf("br%d", *testVrf.Spec.Vni)
-				bridge := &netlink.Bridge{LinkAttrs: netlink.LinkAttrs{Name: bridgeName}}
+				bridge := &netlink.Bridge{LinkAttrs: netlink.LinkAttrs{Name: bridgeName, Alias: managedAlias}}
 				mockNetlink.EXPECT().LinkByName(mock.Anything, bridgeName).Return(bridge, nil).Once()
 				mockNetlink.EXPECT().LinkSetDown(mock.Anything, bridge).Return(nil).Once()
 				mockNetlink.EXPECT().LinkDel(mock.Anything, bridge).Return(nil).Once()
-				vrf := &netlink.Vrf{LinkAttrs: netlink.LinkAttrs{Name: testVrfID}, Table: 1001}
+				vrf := &netlink.Vrf{LinkAttrs: netlink.LinkAttrs{Name: testVrfID, Alias: managedAlias}, Table: 1001}
 				mockNetlink.EXPECT().LinkByName(mock.Anything, testVrfID).Return(vrf, nil).Once()
 				mockNetlink.EXPECT().LinkSetDown(mock.Anything, vrf).Return(errors.New(errMsg)).Once()
 			},
@@ -569,16 +590,16 @@ func Test_DeleteVrf(t *testing.T) {
 				myip := make(net.IP, 4)
 				binary.BigEndian.PutUint32(myip, 167772162)
 				vxlanName := fmt.Sprintf("vni%d", *testVrf.Spec.Vni)
-				vxlan := &netlink.Vxlan{LinkAttrs: netlink.LinkAttrs{Name: vxlanName}, VxlanId: int(*testVrf.Spec.Vni), Port: 4789, Learning: false, SrcAddr: myip}
+				vxlan := &netlink.Vxlan{LinkAttrs: netlink.LinkAttrs{Name: vxlanName, Alias: managedAlias}, VxlanId: int(*testVrf.Spec.Vni), Port: 4789, Learning: false, SrcAddr: myip}
 				mockNetlink.EXPECT().LinkByName(mock.Anything, vxlanName).Return(vxlan, nil).Once()
 				mockNetlink.EXPECT().LinkSetDown(mock.Anything, vxlan).Return(nil).Once()
 				mockNetlink.EXPECT().LinkDel(mock.Anything, vxlan).Return(nil).Once()
 				bridgeName := fmt.Sprintf("br%d", *testVrf.Spec.Vni)
-				bridge := &netlink.Bridge{LinkAttrs: netlink.LinkAttrs{Name: bridgeName}}
+				bridge := &netlink.Bridge{LinkAttrs: netlink.LinkAttrs{Name: bridgeName, Alias: managedAlias}}
 				mockNetlink.EXPECT().LinkByName(mock.Anything, bridgeName).Return(bridge, nil).Once()
 				mockNetlink.EXPECT().LinkSetDown(mock.Anything, bridge).Return(nil).Once()
 				mockNetlink.EXPECT().LinkDel(mock.Anything, bridge).Return(nil).Once()
-				vrf := &netlink.Vrf{LinkAttrs: netlink.LinkAttrs{Name: testVrfID}, Table: 1001}
+				vrf := &netlink.Vrf{LinkAttrs: netlink.LinkAttrs{Name: testVrfID, Alias: managedAlias}, Table: 1001}
 				mockNetlink.EXPECT().LinkByName(mock.Anything, testVrfID).Return(vrf, nil).Once()
 				mockNetlink.EXPECT().LinkSetDown(mock.Anything, vrf).Return(nil).Once()
 				mockNetlink.EXPECT().LinkDel(mock.Anything, vrf).Return(errors.New(errMsg)).Once()
@@ -594,16 +615,16 @@ func Test_DeleteVrf(t *testing.T) {
 				myip := make(net.IP, 4)
 				binary.BigEndian.PutUint32(myip, 167772162)
 				vxlanName := fmt.Sprintf("vni%d", *testVrf.Spec.Vni)
-				vxlan := &netlink.Vxlan{LinkAttrs: netlink.LinkAttrs{Name: vxlanName}, VxlanId: int(*testVrf.Spec.Vni), Port: 4789, Learning: false, SrcAddr: myip}
+				vxlan := &netlink.Vxlan{LinkAttrs: netlink.LinkAttrs{Name: vxlanName, Alias: managedAlias}, VxlanId: int(*testVrf.Spec.Vni), Port: 4789, Learning: false, SrcAddr: myip}
 				mockNetlink.EXPECT().LinkByName(mock.Anything, vxlanName).Return(vxlan, nil).Once()
 				mockNetlink.EXPECT().LinkSetDown(mock.Anything, vxlan).Return(nil).Once()
 				mockNetlink.EXPECT().LinkDel(mock.Anything, vxlan).Return(nil).Once()
 				bridgeName := fmt.Sprintf("br%d", *testVrf.Spec.Vni)
-				bridge := &netlink.Bridge{LinkAttrs: netlink.LinkAttrs{Name: bridgeName}}
+				bridge := &netlink.Bridge{LinkAttrs: netlink.LinkAttrs{Name: bridgeName, Alias: managedAlias}}
 				mockNetlink.EXPECT().LinkByName(mock.Anything, bridgeName).Return(bridge, nil).Once()
 				mockNetlink.EXPECT().LinkSetDown(mock.Anything, bridge).Return(nil).Once()
 				mockNetlink.EXPECT().LinkDel(mock.Anything, bridge).Return(nil).Once()
-				vrf := &netlink.Vrf{LinkAttrs: netlink.LinkAttrs{Name: testVrfID}, Table: 1001}
+				vrf := &netlink.Vrf{LinkAttrs: netlink.LinkAttrs{Name: testVrfID, Alias: managedAlias}, Table: 1001}
 				mockNetlink.EXPECT().LinkByName(mock.Anything, testVrfID).Return(vrf, nil).Once()
 				mockNetlink.EXPECT().LinkSetDown(mock.Anything, vrf).Return(nil).Once()
 				mockNetlink.EXPECT().LinkDel(mock.Anything, vrf).Return(nil).Once()
@@ -693,6 +714,18 @@ func Test_UpdateVrf(t *testing.T) {
 			start:   false,
 			exist:   true,
 		},
+		"vni change": {
+			mask: nil,
+			in: &pb.Vrf{
+				Name: testVrfName,
+				Spec: &pb.VrfSpec{Vni: proto.Uint32(2000), LoopbackIpPrefix: spec.LoopbackIpPrefix},
+			},
+			out:     nil,
+			errCode: codes.InvalidArgument,
+			errMsg:  "the vni of a Vrf cannot be changed, delete and create the Vrf again",
+			start:   false,
+			exist:   true,
+		},
 		"valid request with unknown key": {
 			mask: nil,
 			in: &pb.Vrf{
@@ -836,6 +869,8 @@ func Test_GetVrf(t *testing.T) {
 }
 
 func Test_ListVrfs(t *testing.T) {
+	testVrfListed := protoClone(&testVrfWithStatus)
+	testVrfListed.Status.OperStatus = pb.VRFOperStatus_VRF_OPER_STATUS_UP
 	tests := map[string]struct {
 		in      string
 		out     []*pb.Vrf
@@ -846,7 +881,7 @@ func Test_ListVrfs(t *testing.T) {
 	}{
 		"example test": {
 			in:      "",
-			out:     []*pb.Vrf{&testVrfWithStatus},
+			out:     []*pb.Vrf{testVrfListed},
 			errCode: codes.OK,
 			errMsg:  "",
 			size:    0,
@@ -870,7 +905,7 @@ func Test_ListVrfs(t *testing.T) {
 		},
 		"pagination overflow": {
 			in:      "",
-			out:     []*pb.Vrf{&testVrfWithStatus},
+			out:     []*pb.Vrf{testVrfListed},
 			errCode: codes.OK,
 			errMsg:  "",
 			size:    1000,
@@ -878,7 +913,7 @@ func Test_ListVrfs(t *testing.T) {
 		},
 		"pagination normal": {
 			in:      "",
-			out:     []*pb.Vrf{&testVrfWithStatus},
+			out:     []*pb.Vrf{testVrfListed},
 			errCode: codes.OK,
 			errMsg:  "",
 			size:    1,
@@ -919,7 +954,14 @@ func Test_ListVrfs(t *testing.T) {
 			client := pb.NewVrfServiceClient(conn)
 
 			opi.Vrfs[testVrfName] = protoClone(&testVrfWithStatus)
-			opi.Pagination["existing-pagination-token"] = 1
+			if err := opi.savePageToken("existing-pagination-token", 1, time.Now().Add(time.Minute)); err != nil {
+				t.Fatal(err)
+			}
+			if len(tt.out) != 0 {
+				for _, name := range []string{testVrfID, "br1000", "vni1000"} {
+					mockNetlink.EXPECT().LinkByName(mock.Anything, name).Return(&netlink.Device{LinkAttrs: netlink.LinkAttrs{OperState: netlink.OperUp}}, nil).Once()
+				}
+			}
 
 			request := &pb.ListVrfsRequest{PageSize: tt.size, PageToken: tt.token}
 			response, err := client.ListVrfs(ctx, request)
@@ -945,3 +987,31 @@ func Test_ListVrfs(t *testing.T) {
 		})
 	}
 }
+
+func Test_UpdateVrfKeepsStatus(t *testing.T) {
+	ctx := context.Background()
+	opi := NewServerWithArgs(fake.NewNetlink(), fake.NewFrr(), gomap.NewStore(gomap.DefaultOptions))
+	created, err := opi.CreateVrf(ctx, &pb.CreateVrfRequest{VrfId: testVrfID, Vrf: protoClone(&testVrf)})
+	if err != nil {
+		t.Fatal("create: expected", nil, "received", err)
+	}
+	update := protoClone(created)
+	update.Spec.LoopbackIpPrefix = &pc.IPPrefix{Len: 32}
+	for _, validateOnly := range []bool{true, false} {
+		ctx := ctx
+		if validateOnly {
+			ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(utils.ValidateOnlyMetadataKey, "true"))
+		}
+		updated, err := opi.UpdateVrf(ctx, &pb.UpdateVrfRequest{Vrf: protoClone(update)})
+		if err != nil {
+			t.Fatal("update: expected", nil, "received", err)
+		}
+		// the Routes are programmed into the table of the stored Vrf
+		if updated.Status.RoutingTable != created.Status.RoutingTable || !bytes.Equal(updated.Status.Rmac, created.Status.Rmac) {
+			t.Error("validate only", validateOnly, "status: expected", created.Status, "received", updated.Status)
+		}
+	}
+	if stored := opi.Vrfs[testVrfName].Status; stored.RoutingTable != created.Status.RoutingTable || !bytes.Equal(stored.Rmac, created.Status.Rmac) {
+		t.Error("stored status: expected", created.Status, "received", stored)
+	}
+}
@@ -5,9 +5,9 @@
 package evpn
 
 import (
-	"go.einride.tech/aip/fieldbehavior"
+	"fmt"
+
 	"go.einride.tech/aip/fieldmask"
-	"go.einride.tech/aip/resourceid"
 	"go.einride.tech/aip/resourcename"
 
 	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
@@ -15,46 +15,54 @@ import (
 
 func (s *Server) validateCreateVrfRequest(in *pb.CreateVrfRequest) error {
 	// check required fields
-	if err := fieldbehavior.ValidateRequiredFields(in); err != nil {
+	if err := validateRequiredFields(in); err != nil {
 		return err
 	}
 	// see https://google.aip.dev/133#user-specified-ids
 	if in.VrfId != "" {
-		if err := resourceid.ValidateUserSettable(in.VrfId); err != nil {
+		if err := badRequest("vrf_id", validateResourceID(in.VrfId, true)); err != nil {
 			return err
 		}
 	}
-	// TODO: check in.Vrf.Spec.Vni validity
-	return nil
+	// check vni is in range
+	return badRequest("vrf.spec.vni", validateVni(in.Vrf.Spec.Vni))
 }
 
 func (s *Server) validateDeleteVrfRequest(in *pb.DeleteVrfRequest) error {
 	// check required fields
-	if err := fieldbehavior.ValidateRequiredFields(in); err != nil {
+	if err := validateRequiredFields(in); err != nil {
 		return err
 	}
 	// Validate that a resource name conforms to the restrictions outlined in AIP-122.
-	return resourcename.Validate(in.Name)
+	return badRequest("name", resourcename.Validate(in.Name))
 }
 
 func (s *Server) validateUpdateVrfRequest(in *pb.UpdateVrfRequest) error {
 	// check required fields
-	if err := fieldbehavior.ValidateRequiredFields(in); err != nil {
+	if err := validateRequiredFields(in); err != nil {
 		return err
 	}
 	// update_mask = 2
-	if err := fieldmask.Validate(in.UpdateMask, in.Vrf); err != nil {
+	if err := badRequest("update_mask", fieldmask.Validate(in.UpdateMask, in.Vrf)); err != nil {
 		return err
 	}
 	// Validate that a resource name conforms to the restrictions outlined in AIP-122.
-	return resourcename.Validate(in.Vrf.Name)
+	return badRequest("vrf.name", resourcename.Validate(in.Vrf.Name))
 }
 
 func (s *Server) validateGetVrfRequest(in *pb.GetVrfRequest) error {
 	// check required fields
-	if err := fieldbehavior.ValidateRequiredFields(in); err != nil {
+	if err := validateRequiredFields(in); err != nil {
 		return err
 	}
 	// Validate that a resource name conforms to the restrictions outlined in AIP-122.
-	return resourcename.Validate(in.Name)
+	return badRequest("name", resourcename.Validate(in.Name))
+}
+
+// validateVrfQuota checks a new Vrf fits in the Vrf and Vni limits
+func (s *Server) validateVrfQuota(obj *pb.Vrf) error {
+	if limit, ok := s.Quotas["Vrf"]; ok && len(s.Vrfs) >= limit {
+		return quotaExceeded("Vrf", fmt.Sprintf("max number of Vrfs (%d) reached", limit))
+	}
+	return s.validateVniQuota(obj.Spec.Vni)
 }
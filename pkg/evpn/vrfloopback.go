@@ -0,0 +1,105 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"sort"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+)
+
+// vrfLoopbackAddr returns the loopback_ip_prefix of a Vrf, nil when it has none
+func vrfLoopbackAddr(obj *pb.Vrf) *net.IPNet {
+	prefix := obj.GetSpec().GetLoopbackIpPrefix()
+	if prefix == nil || prefix.Addr == nil || prefix.Len <= 0 {
+		return nil
+	}
+	ip := make(net.IP, 4)
+	binary.BigEndian.PutUint32(ip, prefix.Addr.GetV4Addr())
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(int(prefix.Len), 32)}
+}
+
+// vrfLoopbackKernelName returns the name of the loopback device of a Vrf, from the kernel name of the Vrf
+func vrfLoopbackKernelName(vrfName string, vrfKernel string) string {
+	wanted := vrfKernel + "-lo"
+	if len(wanted) <= maxKernelNameLength {
+		return wanted
+	}
+	return hashedKernelName(vrfName+"/loopback", wanted, 0)
+}
+
+// vrfLoopbackName returns the name of the loopback device of a Vrf (e.g.: blue-lo)
+func (s *Server) vrfLoopbackName(obj *pb.Vrf) string {
+	return vrfLoopbackKernelName(obj.Name, s.vrfKernelName(obj.Name))
+}
+
+// VrfLoopback is the loopback device of a Vrf, holding its loopback_ip_prefix
+// TODO: move to the status of the Vrfs in opi-api once the message is agreed upon
+type VrfLoopback struct {
+	// Vrf is the name of the Vrf
+	Vrf string `json:"vrf"`
+	// Interface is the kernel name of the loopback device
+	Interface string `json:"interface"`
+	// Address is the loopback_ip_prefix of the Vrf
+	Address string `json:"address"`
+	// OperStatus is up or down, missing when the device is not found
+	OperStatus string `json:"operStatus"`
+}
+
+// GetVrfLoopbacksRequest is the request to read the loopback devices of the Vrfs
+type GetVrfLoopbacksRequest struct {
+	// Vrf is the name of a Vrf to read the loopback of, all the Vrfs with one when empty
+	Vrf string
+}
+
+// GetVrfLoopbacksResponse lists the loopbacks, sorted by Vrf
+type GetVrfLoopbacksResponse struct {
+	Loopbacks []VrfLoopback `json:"loopbacks"`
+}
+
+// GetVrfLoopbacks reads the loopback devices of the Vrfs, with their address and oper status
+func (s *Server) GetVrfLoopbacks(ctx context.Context, in *GetVrfLoopbacksRequest) (*GetVrfLoopbacksResponse, error) {
+	s.objectsMu.RLock()
+	defer s.objectsMu.RUnlock()
+	if in.Vrf != "" {
+		obj, ok := s.Vrfs[in.Vrf]
+		if !ok || !inTenant(ctx, in.Vrf) {
+			err := status.Errorf(codes.NotFound, "unable to find key %s", in.Vrf)
+			return nil, err
+		}
+		if vrfLoopbackAddr(obj) == nil {
+			err := status.Errorf(codes.FailedPrecondition, "Vrf %s has no loopback_ip_prefix", in.Vrf)
+			return nil, err
+		}
+	}
+	response := &GetVrfLoopbacksResponse{Loopbacks: []VrfLoopback{}}
+	for name, obj := range s.Vrfs {
+		addr := vrfLoopbackAddr(obj)
+		if addr == nil || !inTenant(ctx, name) || (in.Vrf != "" && name != in.Vrf) {
+			continue
+		}
+		loopback := VrfLoopback{Vrf: name, Interface: s.vrfLoopbackName(obj), Address: addr.String(), OperStatus: "missing"}
+		// in the network namespace of a Vrf isolated in one
+		if nLink, err := s.vrfNetlink(ctx, name); err == nil {
+			if link, err := nLink.LinkByName(ctx, loopback.Interface); err == nil {
+				loopback.OperStatus = "down"
+				if link.Attrs().Flags&net.FlagUp != 0 {
+					loopback.OperStatus = "up"
+				}
+			}
+		}
+		response.Loopbacks = append(response.Loopbacks, loopback)
+	}
+	sort.Slice(response.Loopbacks, func(i int, j int) bool {
+		return response.Loopbacks[i].Vrf < response.Loopbacks[j].Vrf
+	})
+	return response, nil
+}
@@ -0,0 +1,91 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"errors"
+	"net"
+	"reflect"
+	"testing"
+
+	"github.com/philippgille/gokv/gomap"
+	"github.com/stretchr/testify/mock"
+	"github.com/vishvananda/netlink"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils/mocks"
+)
+
+func Test_vrfLoopbackKernelName(t *testing.T) {
+	if name := vrfLoopbackKernelName(testVrfName, "blue"); name != "blue-lo" {
+		t.Error("name: expected", "blue-lo", "received", name)
+	}
+	// the long names are hashed, like the other kernel names
+	name := vrfLoopbackKernelName(testVrfName, "verylongvrfname")
+	if len(name) != maxKernelNameLength || name == vrfLoopbackKernelName("vrfs/other", "verylongvrfname") {
+		t.Error("name: expected a unique hashed name, received", name)
+	}
+}
+
+func Test_VrfLoopback(t *testing.T) {
+	ctx := context.Background()
+	mockNetlink := mocks.NewNetlink(t)
+	opi := NewServerWithArgs(mockNetlink, mocks.NewFrr(t), gomap.NewStore(gomap.DefaultOptions))
+	obj := &pb.Vrf{Name: testVrfName, Spec: &pb.VrfSpec{LoopbackIpPrefix: ipToPrefix(net.IPv4(10, 1, 1, 1), 32)}}
+	addr := &netlink.Addr{IPNet: &net.IPNet{IP: net.IPv4(10, 1, 1, 1).To4(), Mask: net.CIDRMask(32, 32)}}
+
+	// the address is held by a dummy device enslaved to the VRF
+	vrf := &netlink.Vrf{LinkAttrs: netlink.LinkAttrs{Name: testVrfID, Alias: managedAlias}, Table: 1001}
+	loopback := &netlink.Dummy{LinkAttrs: netlink.LinkAttrs{Name: testVrfID + "-lo", Alias: managedAlias}}
+	mockNetlink.EXPECT().LinkAdd(mock.Anything, vrf).Return(nil).Once()
+	mockNetlink.EXPECT().LinkSetUp(mock.Anything, vrf).Return(nil).Once()
+	mockNetlink.EXPECT().LinkAdd(mock.Anything, loopback).Return(nil).Once()
+	mockNetlink.EXPECT().LinkSetMaster(mock.Anything, loopback, vrf).Return(nil).Once()
+	mockNetlink.EXPECT().AddrAdd(mock.Anything, loopback, addr).Return(nil).Once()
+	mockNetlink.EXPECT().LinkSetUp(mock.Anything, loopback).Return(nil).Once()
+	if err := opi.netlinkCreateVrf(ctx, &pb.CreateVrfRequest{Vrf: obj}, 1001, nil); err != nil {
+		t.Fatal("error: expected", nil, "received", err)
+	}
+	if links := opi.vrfLinks(obj); !reflect.DeepEqual(links, []string{testVrfID, testVrfID + "-lo"}) {
+		t.Error("links: expected the loopback, received", links)
+	}
+
+	// the loopback is reported with its oper status
+	opi.Vrfs[testVrfName] = obj
+	up := &netlink.Dummy{LinkAttrs: netlink.LinkAttrs{Name: testVrfID + "-lo", Alias: managedAlias, Flags: net.FlagUp}}
+	mockNetlink.EXPECT().LinkByName(mock.Anything, testVrfID+"-lo").Return(up, nil).Once()
+	response, err := opi.GetVrfLoopbacks(ctx, &GetVrfLoopbacksRequest{Vrf: testVrfName})
+	if err != nil {
+		t.Fatal("error: expected", nil, "received", err)
+	}
+	expected := []VrfLoopback{{Vrf: testVrfName, Interface: testVrfID + "-lo", Address: "10.1.1.1/32", OperStatus: "up"}}
+	if !reflect.DeepEqual(response.Loopbacks, expected) {
+		t.Error("loopbacks: expected", expected, "received", response.Loopbacks)
+	}
+	mockNetlink.EXPECT().LinkByName(mock.Anything, testVrfID+"-lo").Return(nil, errors.New("not found")).Once()
+	response, err = opi.GetVrfLoopbacks(ctx, &GetVrfLoopbacksRequest{})
+	if err != nil || len(response.Loopbacks) != 1 || response.Loopbacks[0].OperStatus != "missing" {
+		t.Error("loopbacks: expected a missing loopback, received", response, err)
+	}
+	opi.Vrfs["vrfs/other"] = &pb.Vrf{Name: "vrfs/other", Spec: &pb.VrfSpec{}}
+	if _, err := opi.GetVrfLoopbacks(ctx, &GetVrfLoopbacksRequest{Vrf: "vrfs/other"}); status.Code(err) != codes.FailedPrecondition {
+		t.Error("error: expected", codes.FailedPrecondition, "received", err)
+	}
+
+	// the loopback is deleted before the VRF
+	mockNetlink.EXPECT().LinkByName(mock.Anything, testVrfID+"-lo").Return(loopback, nil).Once()
+	mockNetlink.EXPECT().LinkDel(mock.Anything, loopback).Return(nil).Once()
+	mockNetlink.EXPECT().LinkByName(mock.Anything, testVrfID).Return(vrf, nil).Once()
+	mockNetlink.EXPECT().LinkSetDown(mock.Anything, vrf).Return(nil).Once()
+	mockNetlink.EXPECT().LinkDel(mock.Anything, vrf).Return(nil).Once()
+	if err := opi.netlinkDeleteVrf(ctx, obj); err != nil {
+		t.Error("error: expected", nil, "received", err)
+	}
+}
@@ -0,0 +1,203 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"log"
+	"net"
+
+	"github.com/vishvananda/netlink"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils"
+)
+
+// VrfIsolation is how the Vrfs are isolated from each other in the kernel
+type VrfIsolation string
+
+const (
+	// VrfIsolationDevice makes every Vrf a vrf device with its own routing table, the default
+	VrfIsolationDevice VrfIsolation = "vrf"
+	// VrfIsolationNetns makes every Vrf a network namespace, for the kernels without the vrf
+	// module. FRR has to run zebra with the netns vrf backend, zebra -n
+	VrfIsolationNetns VrfIsolation = "netns"
+)
+
+// ParseVrfIsolation parses the --vrf_isolation flag, vrf or netns
+func ParseVrfIsolation(value string) (VrfIsolation, error) {
+	switch isolation := VrfIsolation(value); isolation {
+	case VrfIsolationDevice, VrfIsolationNetns:
+		return isolation, nil
+	}
+	return "", fmt.Errorf("invalid vrf isolation %q, expected vrf or netns", value)
+}
+
+// vrfNetns reports whether the Vrfs are network namespaces instead of vrf devices
+func (s *Server) vrfNetns() bool {
+	return s.VrfIsolation == VrfIsolationNetns
+}
+
+// vrfNetlink returns the netlink of the kernel the devices of the Vrf, named as in the API,
+// and of its Svis are in: the host for a vrf device, the namespace of the Vrf otherwise
+func (s *Server) vrfNetlink(ctx context.Context, vrfName string) (utils.Netlink, error) {
+	if !s.vrfNetns() {
+		return s.nLink, nil
+	}
+	name := s.vrfKernelName(vrfName)
+	nLink, err := s.nLink.Netns(ctx, name)
+	if err != nil {
+		err := status.Errorf(codes.NotFound, "unable to find key %s", name)
+		return nil, err
+	}
+	return nLink, nil
+}
+
+func (s *Server) netnsCreateVrf(ctx context.Context, in *pb.CreateVrfRequest, mac []byte) error {
+	vrfName := s.vrfKernelName(in.Vrf.Name)
+	// Example: ip netns add blue
+	log.Printf("Creating VRF network namespace %v", vrfName)
+	if err := s.nLink.NetnsAdd(ctx, vrfName); err != nil {
+		fmt.Printf("Failed to create VRF network namespace: %v", err)
+		return err
+	}
+	nLink, err := s.vrfNetlink(ctx, in.Vrf.Name)
+	if err != nil {
+		return err
+	}
+	// Example: ip -n blue link set lo up
+	lo, err := nLink.LinkByName(ctx, "lo")
+	if err != nil {
+		err := status.Errorf(codes.NotFound, "unable to find key %s", "lo")
+		return err
+	}
+	if err := nLink.LinkSetUp(ctx, lo); err != nil {
+		fmt.Printf("Failed to up loopback link: %v", err)
+		return err
+	}
+	// the loopback address is held by a dummy device of the Vrf, as with a vrf device
+	if addr := vrfLoopbackAddr(in.Vrf); addr != nil {
+		// Example: ip -n blue link add blue-lo type dummy
+		loopback := &netlink.Dummy{LinkAttrs: netlink.LinkAttrs{Name: s.vrfLoopbackName(in.Vrf), Alias: managedAlias}}
+		log.Printf("Creating VRF loopback %v", loopback)
+		if err := nLink.LinkAdd(ctx, loopback); err != nil {
+			fmt.Printf("Failed to create loopback link: %v", err)
+			return err
+		}
+		// Example: ip -n blue address add <vrf-loopback> dev blue-lo
+		if err := nLink.AddrAdd(ctx, loopback, &netlink.Addr{IPNet: addr}); err != nil {
+			fmt.Printf("Failed to set IP on loopback link: %v", err)
+			return err
+		}
+		// Example: ip -n blue link set blue-lo up
+		if err := nLink.LinkSetUp(ctx, loopback); err != nil {
+			fmt.Printf("Failed to up loopback link: %v", err)
+			return err
+		}
+	}
+	// create bridge and vxlan only if VNI value is not empty
+	if in.Vrf.Spec.Vni == nil {
+		return nil
+	}
+	// Example: ip -n blue link add br100 type bridge
+	bridgeName := fmt.Sprintf("br%d", *in.Vrf.Spec.Vni)
+	bridge := &netlink.Bridge{LinkAttrs: netlink.LinkAttrs{Name: bridgeName, Alias: managedAlias}}
+	log.Printf("Creating Linux Bridge %v", bridge)
+	if err := nLink.LinkAdd(ctx, bridge); err != nil {
+		fmt.Printf("Failed to create Bridge link: %v", err)
+		return err
+	}
+	// Example: ip -n blue link set br100 addr aa:bb:cc:00:00:02
+	if err := nLink.LinkSetHardwareAddr(ctx, bridge, mac); err != nil {
+		fmt.Printf("Failed to set MAC on Bridge link: %v", err)
+		return err
+	}
+	// Example: ip -n blue link set br100 up
+	if err := nLink.LinkSetUp(ctx, bridge); err != nil {
+		fmt.Printf("Failed to up Bridge link: %v", err)
+		return err
+	}
+	// the vxlan device is created in the host, its tunnels keep using the underlay of the
+	// host once it is moved into the namespace
+	// Example: ip link add vni100 type vxlan local 10.0.0.4 dstport 4789 id 100 nolearning
+	vxlanName := fmt.Sprintf("vni%d", *in.Vrf.Spec.Vni)
+	myip := make(net.IP, 4)
+	binary.BigEndian.PutUint32(myip, in.Vrf.Spec.VtepIpPrefix.Addr.GetV4Addr())
+	vxlan := s.newVxlan(vxlanName, *in.Vrf.Spec.Vni, myip)
+	log.Printf("Creating VXLAN %v", vxlan)
+	if err := s.nLink.LinkAdd(ctx, vxlan); err != nil {
+		fmt.Printf("Failed to create Vxlan link: %v", err)
+		return err
+	}
+	// Example: ip link set vni100 netns blue
+	vxlandev, err := s.moveToVrfNetns(ctx, nLink, vxlan, vrfName)
+	if err != nil {
+		return err
+	}
+	// Example: ip -n blue link set vni100 master br100 addrgenmode none
+	if err := nLink.LinkSetMaster(ctx, vxlandev, bridge); err != nil {
+		fmt.Printf("Failed to add Vxlan to bridge: %v", err)
+		return err
+	}
+	// Example: ip -n blue link set vni100 up
+	if err := nLink.LinkSetUp(ctx, vxlandev); err != nil {
+		fmt.Printf("Failed to up Vxlan link: %v", err)
+		return err
+	}
+	return nil
+}
+
+// moveToVrfNetns moves the link created in the host into the namespace of the Vrf, where its
+// addresses and master are set, and returns it as found there
+func (s *Server) moveToVrfNetns(ctx context.Context, nLink utils.Netlink, link netlink.Link, vrfName string) (netlink.Link, error) {
+	if err := s.nLink.LinkSetNs(ctx, link, vrfName); err != nil {
+		fmt.Printf("Failed to move link to VRF network namespace: %v", err)
+		return nil, err
+	}
+	moved, err := nLink.LinkByName(ctx, link.Attrs().Name)
+	if err != nil {
+		err := status.Errorf(codes.NotFound, "unable to find key %s", link.Attrs().Name)
+		return nil, err
+	}
+	return moved, nil
+}
+
+func (s *Server) netnsDeleteVrf(ctx context.Context, obj *pb.Vrf) error {
+	vrfName := s.vrfKernelName(obj.Name)
+	if _, err := s.vrfNetlink(ctx, obj.Name); err != nil {
+		return err
+	}
+	// the loopback, bridge and vxlan devices are deleted with the namespace
+	// Example: ip netns del blue
+	log.Printf("Deleting VRF network namespace %v", vrfName)
+	if err := s.nLink.NetnsDel(ctx, vrfName); err != nil {
+		fmt.Printf("Failed to delete VRF network namespace: %v", err)
+		return err
+	}
+	return nil
+}
+
+// removeVrfLinks deletes the devices an aborted CreateVrf got to, and the namespace of the Vrf
+func (s *Server) removeVrfLinks(ctx context.Context, obj *pb.Vrf) error {
+	if !s.vrfNetns() {
+		return s.removeLinks(ctx, s.vrfLinks(obj)...)
+	}
+	// the vxlan device is left in the host when moving it failed
+	if obj.Spec.Vni != nil {
+		if err := s.removeLinks(ctx, fmt.Sprintf("vni%d", *obj.Spec.Vni)); err != nil {
+			return err
+		}
+	}
+	if _, err := s.vrfNetlink(ctx, obj.Name); err != nil {
+		return nil
+	}
+	return s.netnsDeleteVrf(ctx, obj)
+}
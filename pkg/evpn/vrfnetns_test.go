@@ -0,0 +1,117 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/philippgille/gokv/gomap"
+	"github.com/vishvananda/netlink"
+	"google.golang.org/protobuf/proto"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+	pc "github.com/opiproject/opi-api/network/opinetcommon/v1alpha1/gen/go"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/fake"
+)
+
+func Test_VrfNetns(t *testing.T) {
+	ctx := context.Background()
+	nLink := fake.NewNetlink()
+	opi := NewServerWithArgs(nLink, fake.NewFrr(), gomap.NewStore(gomap.DefaultOptions))
+	opi.VrfIsolation = VrfIsolationNetns
+	prefix := func(addr uint32, length int32) *pc.IPPrefix {
+		return &pc.IPPrefix{Addr: &pc.IPAddress{Af: pc.IpAf_IP_AF_INET, V4OrV6: &pc.IPAddress_V4Addr{V4Addr: addr}}, Len: length}
+	}
+	vrf, err := opi.CreateVrf(ctx, &pb.CreateVrfRequest{VrfId: "blue", Vrf: &pb.Vrf{Spec: &pb.VrfSpec{
+		Vni:              proto.Uint32(1000),
+		LoopbackIpPrefix: prefix(0x0a000101, 32),
+		VtepIpPrefix:     prefix(0x0a000001, 32),
+	}}})
+	if err != nil {
+		t.Fatal("CreateVrf: expected", nil, "received", err)
+	}
+	bridge, err := opi.CreateLogicalBridge(ctx, &pb.CreateLogicalBridgeRequest{LogicalBridgeId: "vlan10", LogicalBridge: &pb.LogicalBridge{Spec: &pb.LogicalBridgeSpec{
+		VlanId:       10,
+		Vni:          proto.Uint32(10),
+		VtepIpPrefix: prefix(0x0a000001, 32),
+	}}})
+	if err != nil {
+		t.Fatal("CreateLogicalBridge: expected", nil, "received", err)
+	}
+	svi, err := opi.CreateSvi(ctx, &pb.CreateSviRequest{SviId: "svi10", Svi: &pb.Svi{Spec: &pb.SviSpec{
+		Vrf:           vrf.Name,
+		LogicalBridge: bridge.Name,
+		MacAddress:    []byte{0x02, 0x00, 0x00, 0x00, 0x00, 0x02},
+		GwIpPrefix:    []*pc.IPPrefix{prefix(0x0a0a0a01, 24)},
+	}}})
+	if err != nil {
+		t.Fatal("CreateSvi: expected", nil, "received", err)
+	}
+
+	// the Vrf is a namespace holding its loopback, L3 vni and Svi, not a vrf device
+	for _, name := range []string{"blue", "blue-lo", "br1000", "vni1000", "vlan10"} {
+		if link, err := nLink.LinkByName(ctx, name); err == nil {
+			t.Error(name, ": expected none in the host, received", link)
+		}
+	}
+	ns, err := nLink.Netns(ctx, "blue")
+	if err != nil {
+		t.Fatal("namespace: expected", nil, "received", err)
+	}
+	addrs := map[string]string{"blue-lo": "10.0.1.1/32", "vlan10": "10.10.10.1/24"}
+	for _, name := range []string{"lo", "blue-lo", "br1000", "vni1000", "vlan10"} {
+		link, err := ns.LinkByName(ctx, name)
+		if err != nil {
+			t.Error(name, ": expected in the namespace, received", err)
+			continue
+		}
+		if link.Attrs().Flags&net.FlagUp == 0 {
+			t.Error(name, ": expected up, received", link.Attrs().Flags)
+		}
+		if addr, ok := addrs[name]; ok {
+			if list, _ := ns.AddrList(ctx, link, netlink.FAMILY_V4); len(list) != 1 || list[0].IPNet.String() != addr {
+				t.Error(name, ": expected", addr, "received", list)
+			}
+		}
+	}
+	if vxlan, _ := ns.LinkByName(ctx, "vni1000"); vxlan != nil {
+		if br, _ := ns.LinkByName(ctx, "br1000"); br == nil || vxlan.Attrs().MasterIndex != br.Attrs().Index {
+			t.Error("vni1000: expected master br1000, received", vxlan.Attrs().MasterIndex)
+		}
+	}
+	if _, err := opi.GetVrf(ctx, &pb.GetVrfRequest{Name: vrf.Name}); err != nil {
+		t.Error("GetVrf: expected", nil, "received", err)
+	}
+	if drift, err := opi.GetDrift(ctx, &GetDriftRequest{}); err != nil || len(drift.Objects) != 0 {
+		t.Error("drift: expected none, received", drift, err)
+	}
+
+	if _, err := opi.DeleteSvi(ctx, &pb.DeleteSviRequest{Name: svi.Name}); err != nil {
+		t.Fatal("DeleteSvi: expected", nil, "received", err)
+	}
+	if link, err := ns.LinkByName(ctx, "vlan10"); err == nil {
+		t.Error("vlan10: expected deleted, received", link)
+	}
+	if _, err := opi.DeleteVrf(ctx, &pb.DeleteVrfRequest{Name: vrf.Name}); err != nil {
+		t.Fatal("DeleteVrf: expected", nil, "received", err)
+	}
+	if _, err := nLink.Netns(ctx, "blue"); err == nil {
+		t.Error("namespace: expected deleted, received", nil)
+	}
+}
+
+func Test_ParseVrfIsolation(t *testing.T) {
+	for _, value := range []string{"vrf", "netns"} {
+		if isolation, err := ParseVrfIsolation(value); err != nil || string(isolation) != value {
+			t.Error(value, ": expected", value, "received", isolation, err)
+		}
+	}
+	if _, err := ParseVrfIsolation("namespace"); err == nil {
+		t.Error("namespace: expected an error, received", nil)
+	}
+}
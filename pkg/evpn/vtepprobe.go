@@ -0,0 +1,267 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils"
+)
+
+// vtepProbeCount is the number of echo requests of a probe, the VTEP is reachable
+// when any of them is answered
+const vtepProbeCount = 2
+
+// RemoteVtep is the reachability of a remote VTEP a LogicalBridge has a tunnel to, the
+// VTEPs being those advertising a type-3 IMET route for its vni
+// TODO: move to the status of the LogicalBridges in opi-api once the message is agreed upon
+type RemoteVtep struct {
+	// LogicalBridge is the name of the LogicalBridge
+	LogicalBridge string `json:"logicalBridge"`
+	// Vtep is the address of the remote VTEP
+	Vtep string `json:"vtep"`
+	// Reachable tells whether the last probe was answered
+	Reachable bool `json:"reachable"`
+	// Reason tells why the VTEP is unreachable
+	Reason string `json:"reason,omitempty"`
+	// LastProbeTime is the time of the last probe
+	LastProbeTime time.Time `json:"lastProbeTime"`
+	// LastTransitionTime is the last time the reachability changed
+	LastTransitionTime time.Time `json:"lastTransitionTime"`
+}
+
+// GetRemoteVtepsRequest is the request to read the reachability of the remote VTEPs
+type GetRemoteVtepsRequest struct {
+	// LogicalBridge is the name of a LogicalBridge to read the VTEPs of, all of them when empty
+	LogicalBridge string
+}
+
+// GetRemoteVtepsResponse lists the remote VTEPs, sorted by LogicalBridge then VTEP
+type GetRemoteVtepsResponse struct {
+	Vteps []RemoteVtep `json:"vteps"`
+}
+
+// vtepReachability is the outcome of the last probes of a remote VTEP
+type vtepReachability struct {
+	reachable          bool
+	reason             string
+	lastProbeTime      time.Time
+	lastTransitionTime time.Time
+}
+
+// vtepProber keeps the remote VTEPs of each vni seen in the last round, and their reachability
+type vtepProber struct {
+	mu           sync.Mutex
+	vnis         map[uint32][]string
+	reachability map[string]vtepReachability
+	now          func() time.Time
+}
+
+func newVtepProber() *vtepProber {
+	return &vtepProber{vnis: map[uint32][]string{}, reachability: map[string]vtepReachability{}, now: time.Now}
+}
+
+// StartVtepProbes probes every interval the remote VTEPs the LogicalBridges have tunnels to,
+// learned from the type-3 IMET routes of FRR, with echo requests sourced from the VTEP of
+// the gateway, until ctx is done. The TunnelsReachable condition of a LogicalBridge is false
+// while one of its VTEPs does not answer, so a black-holed tunnel shows before the tenants notice
+func (s *Server) StartVtepProbes(ctx context.Context, interval time.Duration) {
+	p := newVtepProber()
+	s.vtepProber = p
+	go func() {
+		s.probeVteps(ctx, p)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.probeVteps(ctx, p)
+			}
+		}
+	}()
+}
+
+// probeVteps runs a round of probes, the VTEPs being probed in parallel
+func (s *Server) probeVteps(ctx context.Context, p *vtepProber) {
+	vnis, err := s.remoteVteps(ctx)
+	if err != nil {
+		fmt.Printf("Failed to get the remote VTEPs: %v", err)
+		return
+	}
+	vteps := map[string]bool{}
+	for _, addrs := range vnis {
+		for _, addr := range addrs {
+			vteps[addr] = true
+		}
+	}
+	var wg sync.WaitGroup
+	results := make(map[string]error, len(vteps))
+	var resultsMu sync.Mutex
+	for vtep := range vteps {
+		wg.Add(1)
+		go func(vtep string) {
+			defer wg.Done()
+			err := s.probeVtep(ctx, vtep)
+			resultsMu.Lock()
+			defer resultsMu.Unlock()
+			results[vtep] = err
+		}(vtep)
+	}
+	wg.Wait()
+
+	// the LogicalBridges are read with objectsMu held, as the calls do, then p.mu is taken
+	s.objectsMu.RLock()
+	defer s.objectsMu.RUnlock()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := p.now()
+	reachability := map[string]vtepReachability{}
+	for vtep, err := range results {
+		probed := vtepReachability{reachable: err == nil, lastProbeTime: now, lastTransitionTime: now}
+		if err != nil {
+			probed.reason = err.Error()
+		}
+		if old, ok := p.reachability[vtep]; ok && old.reachable == probed.reachable {
+			probed.lastTransitionTime = old.lastTransitionTime
+		}
+		reachability[vtep] = probed
+	}
+	p.vnis = vnis
+	p.reachability = reachability
+	for name, bridge := range s.Bridges {
+		if bridge.Spec.Vni == nil {
+			continue
+		}
+		var unreachable []string
+		for _, vtep := range vnis[*bridge.Spec.Vni] {
+			if !reachability[vtep].reachable {
+				unreachable = append(unreachable, vtep)
+			}
+		}
+		var err error
+		if len(unreachable) != 0 {
+			err = status.Errorf(codes.Unavailable, "remote VTEPs %s are unreachable", strings.Join(unreachable, ", "))
+		}
+		old := s.conditionOf(name, ConditionTunnelsReachable)
+		s.conditions.set(name, ConditionTunnelsReachable, err)
+		if old != nil && old.Status != s.conditionOf(name, ConditionTunnelsReachable).Status {
+			log.Printf("Tunnels of %s reachable: %v", name, err == nil)
+			s.events.Publish(utils.WatchEvent{Type: utils.WatchModified, Name: name})
+		}
+	}
+}
+
+// conditionOf returns the condition of the given type of the object, nil when it is not set
+func (s *Server) conditionOf(name string, conditionType ConditionType) *Condition {
+	for _, condition := range s.conditions.get(name) {
+		if condition.Type == conditionType {
+			return &condition
+		}
+	}
+	return nil
+}
+
+// remoteVteps returns the VTEPs advertising a type-3 IMET route of each vni, sorted, the
+// local routes and the VTEP of the gateway itself being skipped
+func (s *Server) remoteVteps(ctx context.Context) (map[uint32][]string, error) {
+	data, err := s.frr.FrrBgpCmd(ctx, "show bgp l2vpn evpn json")
+	if err != nil {
+		return nil, err
+	}
+	routes, err := parseEvpnRoutes(data)
+	if err != nil {
+		return nil, err
+	}
+	seen := map[uint32]map[string]bool{}
+	for _, route := range routes {
+		if route.Type != 3 || route.Peer == "" || route.IP == "" || route.IP == s.Gateway.VtepIP {
+			continue
+		}
+		route.hasVni(func(vni uint32) bool {
+			if seen[vni] == nil {
+				seen[vni] = map[string]bool{}
+			}
+			seen[vni][route.IP] = true
+			return false
+		})
+	}
+	vnis := make(map[uint32][]string, len(seen))
+	for vni, vteps := range seen {
+		vnis[vni] = sortedKeys(vteps)
+	}
+	return vnis, nil
+}
+
+// probeVtep sends echo requests to the VTEP from the VTEP of the gateway, the path the
+// tunnels take, nil when any of them is answered
+func (s *Server) probeVtep(ctx context.Context, vtep string) error {
+	answered := false
+	err := s.diagnostics.Ping(ctx, s.Gateway.VtepIP, vtep, vtepProbeCount, func(line string) {
+		if strings.Contains(line, " bytes from ") {
+			answered = true
+		}
+	})
+	if err != nil {
+		return err
+	}
+	if !answered {
+		return fmt.Errorf("no answer to %d echo requests", vtepProbeCount)
+	}
+	return nil
+}
+
+// GetRemoteVteps returns the reachability of the remote VTEPs of the LogicalBridges found by
+// the last round of probes, empty when the probes are not running
+func (s *Server) GetRemoteVteps(ctx context.Context, in *GetRemoteVtepsRequest) (*GetRemoteVtepsResponse, error) {
+	s.objectsMu.RLock()
+	defer s.objectsMu.RUnlock()
+	if in.LogicalBridge != "" {
+		if _, ok := s.Bridges[in.LogicalBridge]; !ok || !inTenant(ctx, in.LogicalBridge) {
+			err := status.Errorf(codes.NotFound, "unable to find key %s", in.LogicalBridge)
+			return nil, err
+		}
+	}
+	response := &GetRemoteVtepsResponse{Vteps: []RemoteVtep{}}
+	p := s.vtepProber
+	if p == nil {
+		return response, nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for name, bridge := range s.Bridges {
+		if bridge.Spec.Vni == nil || !inTenant(ctx, name) || (in.LogicalBridge != "" && name != in.LogicalBridge) {
+			continue
+		}
+		for _, vtep := range p.vnis[*bridge.Spec.Vni] {
+			probed := p.reachability[vtep]
+			response.Vteps = append(response.Vteps, RemoteVtep{
+				LogicalBridge:      name,
+				Vtep:               vtep,
+				Reachable:          probed.reachable,
+				Reason:             probed.reason,
+				LastProbeTime:      probed.lastProbeTime,
+				LastTransitionTime: probed.lastTransitionTime,
+			})
+		}
+	}
+	sort.Slice(response.Vteps, func(i int, j int) bool {
+		if response.Vteps[i].LogicalBridge != response.Vteps[j].LogicalBridge {
+			return response.Vteps[i].LogicalBridge < response.Vteps[j].LogicalBridge
+		}
+		return response.Vteps[i].Vtep < response.Vteps[j].Vtep
+	})
+	return response, nil
+}
@@ -0,0 +1,120 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/philippgille/gokv/gomap"
+	"github.com/stretchr/testify/mock"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils/mocks"
+)
+
+const bgpEvpnImetRoutes = `show bgp l2vpn evpn json
+{
+"bgpLocalRouterId":"10.0.0.1",
+"10.0.0.1:2":{
+  "rd":"10.0.0.1:2",
+  "[3]:[0]:[32]:[10.0.0.1]":{
+    "paths":[{"valid":true,"bestpath":true,"routeType":3,"ip":"10.0.0.1","peerId":"(unspec)","extendedCommunity":{"string":"RT:65000:11 ET:8"},"nexthops":[{"ip":"10.0.0.1"}]}]
+  }
+},
+"10.0.0.2:2":{
+  "rd":"10.0.0.2:2",
+  "[3]:[0]:[32]:[10.0.0.2]":{
+    "paths":[{"valid":true,"bestpath":true,"routeType":3,"ip":"10.0.0.2","peerId":"10.0.0.2","extendedCommunity":{"string":"RT:65000:11 ET:8"},"nexthops":[{"ip":"10.0.0.2"}]}]
+  }
+},
+"10.0.0.3:2":{
+  "rd":"10.0.0.3:2",
+  "[3]:[0]:[32]:[10.0.0.3]":{
+    "paths":[{"valid":true,"bestpath":true,"routeType":3,"ip":"10.0.0.3","peerId":"10.0.0.2","extendedCommunity":{"string":"RT:65000:11 ET:8"},"nexthops":[{"ip":"10.0.0.3"}]}]
+  }
+}
+}
+bgpd# `
+
+func Test_VtepProbes(t *testing.T) {
+	ctx := context.Background()
+	mockFrr := mocks.NewFrr(t)
+	mockDiagnostics := mocks.NewDiagnostics(t)
+	opi := NewServerWithArgs(mocks.NewNetlink(t), mockFrr, gomap.NewStore(gomap.DefaultOptions))
+	opi.diagnostics = mockDiagnostics
+	opi.Gateway.VtepIP = "10.0.0.1"
+	opi.Bridges[testLogicalBridgeName] = protoClone(&testLogicalBridge)
+	p := newVtepProber()
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	p.now = func() time.Time { return now }
+	opi.vtepProber = p
+	answer := func(vtep string, answered bool) {
+		mockDiagnostics.EXPECT().Ping(mock.Anything, "10.0.0.1", vtep, vtepProbeCount, mock.Anything).
+			RunAndReturn(func(_ context.Context, _ string, _ string, _ int, output func(string)) error {
+				output("PING " + vtep + " (" + vtep + ") from 10.0.0.1 : 56(84) bytes of data.")
+				if answered {
+					output("64 bytes from " + vtep + ": icmp_seq=1 ttl=64 time=0.040 ms")
+				}
+				return nil
+			}).Once()
+	}
+
+	// the local IMET route is not probed, the VTEP of the gateway being skipped
+	mockFrr.EXPECT().FrrBgpCmd(mock.Anything, "show bgp l2vpn evpn json").Return(bgpEvpnImetRoutes, nil).Once()
+	answer("10.0.0.2", true)
+	answer("10.0.0.3", false)
+	opi.probeVteps(ctx, p)
+	condition := opi.conditionOf(testLogicalBridgeName, ConditionTunnelsReachable)
+	if condition == nil || condition.Status != ConditionFalse || condition.Message != "remote VTEPs 10.0.0.3 are unreachable" {
+		t.Error("condition: expected 10.0.0.3 unreachable, received", condition)
+	}
+	response, err := opi.GetRemoteVteps(ctx, &GetRemoteVtepsRequest{LogicalBridge: testLogicalBridgeName})
+	if err != nil {
+		t.Fatal("error: expected", nil, "received", err)
+	}
+	expected := []RemoteVtep{
+		{LogicalBridge: testLogicalBridgeName, Vtep: "10.0.0.2", Reachable: true, LastProbeTime: now, LastTransitionTime: now},
+		{LogicalBridge: testLogicalBridgeName, Vtep: "10.0.0.3", Reason: "no answer to 2 echo requests", LastProbeTime: now, LastTransitionTime: now},
+	}
+	if !reflect.DeepEqual(response.Vteps, expected) {
+		t.Error("vteps: expected", expected, "received", response.Vteps)
+	}
+
+	// the tunnel recovers, only its transition time changes
+	later := now.Add(time.Minute)
+	p.now = func() time.Time { return later }
+	mockFrr.EXPECT().FrrBgpCmd(mock.Anything, "show bgp l2vpn evpn json").Return(bgpEvpnImetRoutes, nil).Once()
+	answer("10.0.0.2", true)
+	mockDiagnostics.EXPECT().Ping(mock.Anything, "10.0.0.1", "10.0.0.3", vtepProbeCount, mock.Anything).Return(errors.New("exit status 2")).Once()
+	opi.probeVteps(ctx, p)
+	response, _ = opi.GetRemoteVteps(ctx, &GetRemoteVtepsRequest{})
+	if len(response.Vteps) != 2 || response.Vteps[0].LastTransitionTime != now || response.Vteps[1].Reason != "exit status 2" {
+		t.Error("vteps: expected the first transition kept, received", response.Vteps)
+	}
+	mockFrr.EXPECT().FrrBgpCmd(mock.Anything, "show bgp l2vpn evpn json").Return(bgpEvpnImetRoutes, nil).Once()
+	answer("10.0.0.2", true)
+	answer("10.0.0.3", true)
+	opi.probeVteps(ctx, p)
+	condition = opi.conditionOf(testLogicalBridgeName, ConditionTunnelsReachable)
+	if condition == nil || condition.Status != ConditionTrue {
+		t.Error("condition: expected reachable, received", condition)
+	}
+	response, _ = opi.GetRemoteVteps(ctx, &GetRemoteVtepsRequest{})
+	if !response.Vteps[1].Reachable || response.Vteps[1].LastTransitionTime != later {
+		t.Error("vteps: expected 10.0.0.3 reachable, received", response.Vteps)
+	}
+
+	// the tenants only see their LogicalBridges
+	_, err = opi.GetRemoteVteps(tenantContext("acme"), &GetRemoteVtepsRequest{LogicalBridge: testLogicalBridgeName})
+	if status.Code(err) != codes.NotFound {
+		t.Error("error: expected", codes.NotFound, "received", err)
+	}
+}
@@ -0,0 +1,60 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/vishvananda/netlink"
+)
+
+// defaultVxlanPort is the IANA assigned VXLAN port, Linux defaults to the legacy 8472
+const defaultVxlanPort = 4789
+
+// VxlanOptions are the tunnel parameters of the vni devices of the LogicalBridges and Vrfs
+// TODO: move to LogicalBridgeSpec and VrfSpec once they are added to opi-api
+type VxlanOptions struct {
+	// Port is the UDP destination port of the tunnels, 4789 or 8472 for older peers
+	Port int
+	// TTL of the outer IP header, 0 leaves the default of the dataplane
+	TTL int
+	// TOS of the outer IP header, DSCP included, 1 inherits it from the inner packet
+	TOS int
+	// Learning learns the remote MAC addresses from the data plane too, off by default
+	// since EVPN distributes them
+	Learning bool
+}
+
+// DefaultVxlanOptions are the parameters the vni devices were always created with
+func DefaultVxlanOptions() VxlanOptions {
+	return VxlanOptions{Port: defaultVxlanPort}
+}
+
+// Validate checks the parameters fit in their header fields
+func (o VxlanOptions) Validate() error {
+	switch {
+	case o.Port < 1 || o.Port > 65535:
+		return fmt.Errorf("invalid vxlan port %d, expected 1-65535", o.Port)
+	case o.TTL < 0 || o.TTL > 255:
+		return fmt.Errorf("invalid vxlan ttl %d, expected 0-255", o.TTL)
+	case o.TOS < 0 || o.TOS > 255:
+		return fmt.Errorf("invalid vxlan tos %d, expected 0-255", o.TOS)
+	}
+	return nil
+}
+
+// newVxlan returns the vni device of a LogicalBridge or a Vrf, with the tunnel parameters of the server
+func (s *Server) newVxlan(name string, vni uint32, local net.IP) *netlink.Vxlan {
+	return &netlink.Vxlan{
+		LinkAttrs: netlink.LinkAttrs{Name: name, Alias: managedAlias},
+		VxlanId:   int(vni),
+		Port:      s.Vxlan.Port,
+		TTL:       s.Vxlan.TTL,
+		TOS:       s.Vxlan.TOS,
+		Learning:  s.Vxlan.Learning,
+		SrcAddr:   local,
+	}
+}
@@ -0,0 +1,61 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"net"
+	"reflect"
+	"testing"
+
+	"github.com/philippgille/gokv/gomap"
+	"github.com/vishvananda/netlink"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils/mocks"
+)
+
+func Test_newVxlan(t *testing.T) {
+	opi := NewServerWithArgs(mocks.NewNetlink(t), mocks.NewFrr(t), gomap.NewStore(gomap.DefaultOptions))
+	local := net.IPv4(10, 0, 0, 4)
+	tests := map[string]struct {
+		options VxlanOptions
+		out     *netlink.Vxlan
+	}{
+		"defaults": {
+			options: DefaultVxlanOptions(),
+			out:     &netlink.Vxlan{LinkAttrs: netlink.LinkAttrs{Name: "vni100", Alias: managedAlias}, VxlanId: 100, Port: 4789, SrcAddr: local},
+		},
+		"tunnel parameters": {
+			options: VxlanOptions{Port: 8472, TTL: 64, TOS: 184, Learning: true},
+			out:     &netlink.Vxlan{LinkAttrs: netlink.LinkAttrs{Name: "vni100", Alias: managedAlias}, VxlanId: 100, Port: 8472, TTL: 64, TOS: 184, Learning: true, SrcAddr: local},
+		},
+	}
+	for testName, tt := range tests {
+		t.Run(testName, func(t *testing.T) {
+			opi.Vxlan = tt.options
+			if vxlan := opi.newVxlan("vni100", 100, local); !reflect.DeepEqual(vxlan, tt.out) {
+				t.Error("vxlan: expected", tt.out, "received", vxlan)
+			}
+		})
+	}
+}
+
+func TestVxlanOptions_Validate(t *testing.T) {
+	tests := map[string]struct {
+		options VxlanOptions
+		wantErr bool
+	}{
+		"defaults":      {options: DefaultVxlanOptions()},
+		"zero port":     {options: VxlanOptions{}, wantErr: true},
+		"ttl too large": {options: VxlanOptions{Port: 4789, TTL: 256}, wantErr: true},
+		"negative tos":  {options: VxlanOptions{Port: 4789, TOS: -1}, wantErr: true},
+	}
+	for testName, tt := range tests {
+		t.Run(testName, func(t *testing.T) {
+			if err := tt.options.Validate(); (err != nil) != tt.wantErr {
+				t.Error("error: expected", tt.wantErr, "received", err)
+			}
+		})
+	}
+}
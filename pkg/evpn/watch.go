@@ -0,0 +1,20 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package evpn is the main package of the application
+package evpn
+
+import (
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils"
+)
+
+// Watch subscribes to change events of bridges, ports, svis and vrfs,
+// a RESYNC event means events were dropped and the objects have to be listed again
+func (s *Server) Watch() *utils.Watcher {
+	return s.events.Subscribe()
+}
+
+// Unwatch unsubscribes a watcher returned by Watch
+func (s *Server) Unwatch(w *utils.Watcher) {
+	s.events.Unsubscribe(w)
+}
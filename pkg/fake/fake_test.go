@@ -0,0 +1,207 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package fake simulates the kernel and FRR in memory, so the whole API can be exercised
+// without root, FRR or a DPU, e.g. in CI or on a laptop
+package fake
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/philippgille/gokv/gomap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+	pc "github.com/opiproject/opi-api/network/opinetcommon/v1alpha1/gen/go"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/evpn"
+)
+
+func ipv4Prefix(addr uint32, length int32) *pc.IPPrefix {
+	return &pc.IPPrefix{
+		Addr: &pc.IPAddress{Af: pc.IpAf_IP_AF_INET, V4OrV6: &pc.IPAddress_V4Addr{V4Addr: addr}},
+		Len:  length,
+	}
+}
+
+func Test_Lifecycle(t *testing.T) {
+	ctx := context.Background()
+	nLink, frr := NewNetlink("eth1"), NewFrr()
+	opi := evpn.NewServerWithArgs(nLink, frr, gomap.NewStore(gomap.DefaultOptions))
+
+	vrf, err := opi.CreateVrf(ctx, &pb.CreateVrfRequest{VrfId: "blue", Vrf: &pb.Vrf{Spec: &pb.VrfSpec{
+		Vni:              proto.Uint32(1000),
+		LoopbackIpPrefix: ipv4Prefix(0x0a000101, 32),
+		VtepIpPrefix:     ipv4Prefix(0x0a000001, 32),
+	}}})
+	if err != nil {
+		t.Fatal("CreateVrf: expected", nil, "received", err)
+	}
+	bridge, err := opi.CreateLogicalBridge(ctx, &pb.CreateLogicalBridgeRequest{LogicalBridgeId: "vlan10", LogicalBridge: &pb.LogicalBridge{Spec: &pb.LogicalBridgeSpec{
+		VlanId:       10,
+		Vni:          proto.Uint32(10),
+		VtepIpPrefix: ipv4Prefix(0x0a000001, 32),
+	}}})
+	if err != nil {
+		t.Fatal("CreateLogicalBridge: expected", nil, "received", err)
+	}
+	port, err := opi.CreateBridgePort(ctx, &pb.CreateBridgePortRequest{BridgePortId: "eth1", BridgePort: &pb.BridgePort{Spec: &pb.BridgePortSpec{
+		MacAddress:     []byte{0x02, 0x00, 0x00, 0x00, 0x00, 0x01},
+		Ptype:          pb.BridgePortType_ACCESS,
+		LogicalBridges: []string{bridge.Name},
+	}}})
+	if err != nil {
+		t.Fatal("CreateBridgePort: expected", nil, "received", err)
+	}
+	svi, err := opi.CreateSvi(ctx, &pb.CreateSviRequest{SviId: "svi10", Svi: &pb.Svi{Spec: &pb.SviSpec{
+		Vrf:           vrf.Name,
+		LogicalBridge: bridge.Name,
+		MacAddress:    []byte{0x02, 0x00, 0x00, 0x00, 0x00, 0x02},
+		GwIpPrefix:    []*pc.IPPrefix{ipv4Prefix(0x0a0a0a01, 24)},
+	}}})
+	if err != nil {
+		t.Fatal("CreateSvi: expected", nil, "received", err)
+	}
+
+	drift, err := opi.GetDrift(ctx, &evpn.GetDriftRequest{})
+	if err != nil {
+		t.Fatal("GetDrift: expected", nil, "received", err)
+	}
+	if len(drift.Objects) != 0 {
+		t.Error("drift: expected none, received", drift.Objects)
+	}
+	if config := frr.RunningConfig("zebra"); !strings.Contains(config, "vrf blue\n vni 1000\nexit-vrf\n") {
+		t.Error("zebra: expected vrf blue with vni 1000, received", config)
+	}
+
+	for _, name := range []string{svi.Name, port.Name, bridge.Name, vrf.Name} {
+		var err error
+		switch {
+		case name == svi.Name:
+			_, err = opi.DeleteSvi(ctx, &pb.DeleteSviRequest{Name: name})
+		case name == port.Name:
+			_, err = opi.DeleteBridgePort(ctx, &pb.DeleteBridgePortRequest{Name: name})
+		case name == bridge.Name:
+			_, err = opi.DeleteLogicalBridge(ctx, &pb.DeleteLogicalBridgeRequest{Name: name})
+		default:
+			_, err = opi.DeleteVrf(ctx, &pb.DeleteVrfRequest{Name: name})
+		}
+		if err != nil {
+			t.Fatal("delete", name, ": expected", nil, "received", err)
+		}
+	}
+	links, _ := nLink.LinkList(ctx)
+	names := []string{}
+	for _, link := range links {
+		names = append(names, link.Attrs().Name)
+	}
+	// the port interface goes away with its BridgePort
+	if strings.Join(names, ",") != "lo,br-tenant" {
+		t.Error("links: expected lo,br-tenant, received", names)
+	}
+	if config := frr.RunningConfig("zebra"); strings.Contains(config, "vrf blue") {
+		t.Error("zebra: expected no vrf blue, received", config)
+	}
+}
+
+func Test_FaultInjection(t *testing.T) {
+	ctx := context.Background()
+	nLink, frr := NewNetlink(), NewFrr()
+	opi := evpn.NewServerWithArgs(nLink, frr, gomap.NewStore(gomap.DefaultOptions))
+	opi.EnableFaultInjection()
+	config := filepath.Join(t.TempDir(), "config.yaml")
+	faults := "faults:\n  - operation: FrrZebraCmd\n    match: vni 1000\n    error: ECONNREFUSED\n  - operation: LinkSetUp\n    match: green\n    error: EBUSY\n"
+	if err := os.WriteFile(config, []byte(faults), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := opi.SetConfigFile(config); err != nil {
+		t.Fatal("SetConfigFile: expected", nil, "received", err)
+	}
+
+	// an FRR failure leaves the Vrf created but degraded, retried in the background
+	vrf, err := opi.CreateVrf(ctx, &pb.CreateVrfRequest{VrfId: "blue", Vrf: &pb.Vrf{Spec: &pb.VrfSpec{
+		Vni:              proto.Uint32(1000),
+		LoopbackIpPrefix: ipv4Prefix(0x0a000101, 32),
+		VtepIpPrefix:     ipv4Prefix(0x0a000001, 32),
+	}}})
+	if err != nil {
+		t.Fatal("CreateVrf: expected", nil, "received", err)
+	}
+	conditions, err := opi.GetConditions(ctx, &evpn.GetConditionsRequest{Name: vrf.Name})
+	if err != nil {
+		t.Fatal("GetConditions: expected", nil, "received", err)
+	}
+	for _, condition := range conditions.Conditions[vrf.Name] {
+		expected := evpn.ConditionTrue
+		if condition.Type == evpn.ConditionFrrProgrammed {
+			expected = evpn.ConditionFalse
+		}
+		if condition.Type != evpn.ConditionNetlinkProgrammed && condition.Type != evpn.ConditionFrrProgrammed && condition.Type != evpn.ConditionDegraded {
+			continue
+		}
+		if condition.Status != expected {
+			t.Error(condition.Type, ": expected", expected, "received", condition.Status)
+		}
+	}
+	if config := frr.RunningConfig("zebra"); strings.Contains(config, "vni 1000") {
+		t.Error("zebra: expected no vni 1000, received", config)
+	}
+
+	// a netlink failure fails the Vrf, which is not stored
+	_, err = opi.CreateVrf(ctx, &pb.CreateVrfRequest{VrfId: "green", Vrf: &pb.Vrf{Spec: &pb.VrfSpec{
+		Vni:              proto.Uint32(2000),
+		LoopbackIpPrefix: ipv4Prefix(0x0a000201, 32),
+		VtepIpPrefix:     ipv4Prefix(0x0a000001, 32),
+	}}})
+	if status.Code(err) != codes.Unknown || !strings.Contains(err.Error(), "device or resource busy") {
+		t.Fatal("CreateVrf: expected device or resource busy, received", err)
+	}
+	_, err = opi.GetVrf(ctx, &pb.GetVrfRequest{Name: "//network.opiproject.org/vrfs/green"})
+	if status.Code(err) != codes.NotFound {
+		t.Error("GetVrf: expected", codes.NotFound, "received", err)
+	}
+}
+
+func Test_Netns(t *testing.T) {
+	ctx := context.Background()
+	nLink := NewNetlink("eth1")
+	if err := nLink.NetnsAdd(ctx, "red"); err != nil {
+		t.Fatal("NetnsAdd: expected", nil, "received", err)
+	}
+	if err := nLink.NetnsAdd(ctx, "red"); !errors.Is(err, syscall.EEXIST) {
+		t.Error("NetnsAdd again: expected", syscall.EEXIST, "received", err)
+	}
+	port, _ := nLink.LinkByName(ctx, "eth1")
+	if err := nLink.LinkSetNs(ctx, port, "red"); err != nil {
+		t.Fatal("LinkSetNs: expected", nil, "received", err)
+	}
+	ns, err := nLink.Netns(ctx, "red")
+	if err != nil {
+		t.Fatal("Netns: expected", nil, "received", err)
+	}
+	if _, err := ns.LinkByName(ctx, "eth1"); err != nil {
+		t.Error("eth1: expected in red, received", err)
+	}
+	if _, err := nLink.LinkByName(ctx, "eth1"); err == nil {
+		t.Error("eth1: expected gone from the host, received", nil)
+	}
+
+	// the physical interfaces go back to the host with the namespace deleted
+	if err := nLink.NetnsDel(ctx, "red"); err != nil {
+		t.Fatal("NetnsDel: expected", nil, "received", err)
+	}
+	if _, err := nLink.LinkByName(ctx, "eth1"); err != nil {
+		t.Error("eth1: expected back in the host, received", err)
+	}
+	if _, err := nLink.Netns(ctx, "red"); !errors.Is(err, syscall.ENOENT) {
+		t.Error("Netns: expected", syscall.ENOENT, "received", err)
+	}
+}
@@ -0,0 +1,247 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package fake simulates the kernel and FRR in memory, so the whole API can be exercised
+// without root, FRR or a DPU, e.g. in CI or on a laptop
+package fake
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ziutek/telnet"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils"
+)
+
+// frrPorts names the daemons by their vty port, see
+// https://docs.frrouting.org/en/latest/setup.html#services
+var frrPorts = map[int]string{
+	2601: "zebra",
+	2604: "ospfd",
+	2605: "bgpd",
+	2611: "pimd",
+}
+
+// frrNode is a line of the running configuration, with the lines of the section it opens
+type frrNode struct {
+	line     string
+	children []*frrNode
+}
+
+// child returns the child of that line, nil when missing
+func (node *frrNode) child(line string) *frrNode {
+	for _, child := range node.children {
+		if child.line == line {
+			return child
+		}
+	}
+	return nil
+}
+
+// remove removes the child of that line, or else the children starting with it, e.g. all the
+// lines of a neighbor
+func (node *frrNode) remove(line string) {
+	children := node.children[:0]
+	exact := node.child(line) != nil
+	for _, child := range node.children {
+		if child.line == line || (!exact && strings.HasPrefix(child.line, line+" ")) {
+			continue
+		}
+		children = append(children, child)
+	}
+	node.children = children
+}
+
+// opensSection tells whether the line under parent enters a section, as vtysh changes node
+func opensSection(parent *frrNode, line string) bool {
+	for _, prefix := range []string{"vrf ", "router ", "interface ", "route-map ", "segment-routing", "srv6", "locators", "locator ", "address-family "} {
+		if strings.HasPrefix(line, prefix) {
+			return true
+		}
+	}
+	return strings.HasPrefix(line, "vni ") && strings.HasPrefix(parent.line, "address-family l2vpn")
+}
+
+// closing is the line ending the section in the running configuration
+func (node *frrNode) closing() string {
+	switch {
+	case strings.HasPrefix(node.line, "vrf "):
+		return "exit-vrf"
+	case strings.HasPrefix(node.line, "address-family "):
+		return "exit-address-family"
+	}
+	return "exit"
+}
+
+func (node *frrNode) write(b *strings.Builder, indent string) {
+	fmt.Fprintf(b, "%s%s\n", indent, node.line)
+	if len(node.children) == 0 {
+		return
+	}
+	for _, child := range node.children {
+		child.write(b, indent+" ")
+	}
+	fmt.Fprintf(b, "%s%s\n", indent, node.closing())
+}
+
+// Frr keeps the running configuration of each daemon, built from the configure terminal
+// commands, and answers the show commands from it, the json ones with no routes, MACs or
+// peers, as a freshly started FRR without neighbors would
+type Frr struct {
+	mu      sync.Mutex
+	configs map[string]*frrNode
+}
+
+// NewFrr creates the daemons with an empty configuration
+func NewFrr() *Frr {
+	return &Frr{configs: map[string]*frrNode{}}
+}
+
+// build time check that struct implements interface
+var _ utils.Frr = (*Frr)(nil)
+
+// RunningConfig returns the running configuration of the daemon, e.g. bgpd, as
+// "show running-config" prints it
+func (f *Frr) RunningConfig(daemon string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	b := &strings.Builder{}
+	b.WriteString("Building configuration...\n\nCurrent configuration:\n!\nfrr version fake\nfrr defaults datacenter\n!\n")
+	if root, ok := f.configs[daemon]; ok {
+		for _, node := range root.children {
+			node.write(b, "")
+			b.WriteString("!\n")
+		}
+	}
+	b.WriteString("end\n")
+	return b.String()
+}
+
+// configure applies the lines of a configure terminal command to the daemon configuration
+func (f *Frr) configure(daemon string, lines []string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	root, ok := f.configs[daemon]
+	if !ok {
+		root = &frrNode{}
+		f.configs[daemon] = root
+	}
+	stack := []*frrNode{root}
+	for _, line := range lines {
+		node := stack[len(stack)-1]
+		switch {
+		case line == "end":
+			return ""
+		case line == "exit" || line == "quit" || strings.HasPrefix(line, "exit-"):
+			if len(stack) == 1 {
+				return ""
+			}
+			stack = stack[:len(stack)-1]
+		case strings.HasPrefix(line, "no "):
+			node.remove(strings.TrimPrefix(line, "no "))
+		case opensSection(node, line):
+			child := node.child(line)
+			if child == nil {
+				child = &frrNode{line: line}
+				node.children = append(node.children, child)
+			}
+			stack = append(stack, child)
+		default:
+			if node.child(line) == nil {
+				node.children = append(node.children, &frrNode{line: line})
+			}
+		}
+	}
+	return ""
+}
+
+// show answers a show command of the daemon
+func (f *Frr) show(daemon string, command string) string {
+	switch {
+	case command == "show running-config":
+		return f.RunningConfig(daemon)
+	case command == "show version":
+		return "FRRouting fake (evpn-bridge)."
+	case command == "show vrf":
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		vrfs := []string{}
+		if root, ok := f.configs["zebra"]; ok {
+			for _, node := range root.children {
+				if strings.HasPrefix(node.line, "vrf ") {
+					vrfs = append(vrfs, fmt.Sprintf("%s inactive (configured)", node.line))
+				}
+			}
+		}
+		sort.Strings(vrfs)
+		return strings.Join(vrfs, "\n")
+	case strings.HasSuffix(command, " json"):
+		return "{}"
+	}
+	return ""
+}
+
+// TelnetDialAndCommunicate runs the command on the daemon of the vty port
+func (f *Frr) TelnetDialAndCommunicate(_ context.Context, command string, port int) (string, error) {
+	daemon, ok := frrPorts[port]
+	if !ok {
+		return "", fmt.Errorf("no FRR daemon on port %d", port)
+	}
+	lines := []string{}
+	for _, line := range strings.Split(command, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) == 0 {
+		return "", nil
+	}
+	if lines[0] == "configure terminal" {
+		return f.configure(daemon, lines[1:]), nil
+	}
+	return f.show(daemon, strings.Join(lines, " ")), nil
+}
+
+// FrrZebraCmd runs the command on zebra
+func (f *Frr) FrrZebraCmd(ctx context.Context, command string) (string, error) {
+	return f.TelnetDialAndCommunicate(ctx, command, 2601)
+}
+
+// FrrBgpCmd runs the command on bgpd
+func (f *Frr) FrrBgpCmd(ctx context.Context, command string) (string, error) {
+	return f.TelnetDialAndCommunicate(ctx, command, 2605)
+}
+
+// FrrPimCmd runs the command on pimd
+func (f *Frr) FrrPimCmd(ctx context.Context, command string) (string, error) {
+	return f.TelnetDialAndCommunicate(ctx, command, 2611)
+}
+
+// FrrOspfCmd runs the command on ospfd
+func (f *Frr) FrrOspfCmd(ctx context.Context, command string) (string, error) {
+	return f.TelnetDialAndCommunicate(ctx, command, 2604)
+}
+
+// DaemonPid returns the same pid for every daemon, the simulated daemons never restart
+func (f *Frr) DaemonPid(_ context.Context, _ string) (int, error) {
+	return 1, nil
+}
+
+// Password does nothing, there is no vty connection
+func (f *Frr) Password(_ *telnet.Conn, _ string) error {
+	return nil
+}
+
+// EnterPrivileged does nothing, there is no vty connection
+func (f *Frr) EnterPrivileged(_ *telnet.Conn) error {
+	return nil
+}
+
+// ExitPrivileged does nothing, there is no vty connection
+func (f *Frr) ExitPrivileged(_ *telnet.Conn) error {
+	return nil
+}
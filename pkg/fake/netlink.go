@@ -0,0 +1,868 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package fake simulates the kernel and FRR in memory, so the whole API can be exercised
+// without root, FRR or a DPU, e.g. in CI or on a laptop
+package fake
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"reflect"
+	"sync"
+	"syscall"
+
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netlink/nl"
+	"golang.org/x/sys/unix"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils"
+)
+
+// tenantBridgeName is the bridge the evpn package plugs the LogicalBridges and BridgePorts
+// into, created by the host setup on a real system
+const tenantBridgeName = "br-tenant"
+
+// subscriber is a channel of a Subscribe call, closed once its ctx is done
+type subscriber[T any] struct {
+	mu     sync.Mutex
+	ctx    context.Context
+	ch     chan<- T
+	closed bool
+}
+
+func newSubscriber[T any](ctx context.Context, ch chan<- T) *subscriber[T] {
+	sub := &subscriber[T]{ctx: ctx, ch: ch}
+	go func() {
+		<-ctx.Done()
+		sub.mu.Lock()
+		defer sub.mu.Unlock()
+		sub.closed = true
+		close(ch)
+	}()
+	return sub
+}
+
+// send blocks until the update is received, as the kernel socket does, or ctx is done
+func (sub *subscriber[T]) send(update T) {
+	sub.mu.Lock()
+	defer sub.mu.Unlock()
+	if sub.closed {
+		return
+	}
+	select {
+	case sub.ch <- update:
+	case <-sub.ctx.Done():
+	}
+}
+
+// Netlink keeps the links, addresses, bridge vlans, fdb and neighbor entries, routes, rules
+// and xfrm states and policies the calls program, as the kernel would
+type Netlink struct {
+	mu        sync.Mutex
+	links     map[int]netlink.Link
+	nextIndex int
+	addrs     map[int][]netlink.Addr
+	vlans     map[int32][]*nl.BridgeVlanInfo
+	neighs    []netlink.Neigh
+	routes    []netlink.Route
+	rules     []netlink.Rule
+	states    []netlink.XfrmState
+	policies  []netlink.XfrmPolicy
+
+	linkSubs  []*subscriber[netlink.LinkUpdate]
+	neighSubs []*subscriber[netlink.NeighUpdate]
+	routeSubs []*subscriber[netlink.RouteUpdate]
+
+	// host is the kernel holding the named network namespaces, nil for the host itself
+	host       *Netlink
+	namespaces map[string]*Netlink
+}
+
+// NewNetlink creates a kernel with the loopback, the tenant bridge and the given interfaces,
+// e.g. the uplink and the ports of the BridgePorts, up
+func NewNetlink(interfaces ...string) *Netlink {
+	n := newKernel()
+	n.namespaces = map[string]*Netlink{}
+	n.addUp(&netlink.Device{LinkAttrs: netlink.LinkAttrs{Name: "lo", Flags: net.FlagLoopback}})
+	n.addUp(&netlink.Bridge{LinkAttrs: netlink.LinkAttrs{Name: tenantBridgeName}, VlanFiltering: &[]bool{true}[0]})
+	for _, name := range interfaces {
+		n.addUp(&netlink.Device{LinkAttrs: netlink.LinkAttrs{Name: name}})
+	}
+	return n
+}
+
+// newKernel creates a kernel without any link, e.g. a new network namespace
+func newKernel() *Netlink {
+	return &Netlink{
+		links:     map[int]netlink.Link{},
+		nextIndex: 1,
+		addrs:     map[int][]netlink.Addr{},
+		vlans:     map[int32][]*nl.BridgeVlanInfo{},
+	}
+}
+
+// build time check that struct implements interface
+var _ utils.Netlink = (*Netlink)(nil)
+
+func (n *Netlink) addUp(link netlink.Link) {
+	attrs := link.Attrs()
+	attrs.Index = n.nextIndex
+	attrs.Flags |= net.FlagUp
+	attrs.OperState = netlink.OperUp
+	n.links[attrs.Index] = link
+	n.nextIndex++
+}
+
+// cloneLink copies the link, so the caller cannot change the stored one behind the kernel
+func cloneLink(link netlink.Link) netlink.Link {
+	v := reflect.ValueOf(link)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return link
+	}
+	c := reflect.New(v.Elem().Type())
+	c.Elem().Set(v.Elem())
+	return c.Interface().(netlink.Link)
+}
+
+// find returns the stored link of the link, by index then name, nil when missing
+func (n *Netlink) find(link netlink.Link) netlink.Link {
+	if link == nil {
+		return nil
+	}
+	if stored, ok := n.links[link.Attrs().Index]; ok && link.Attrs().Index != 0 {
+		return stored
+	}
+	for _, stored := range n.links {
+		if stored.Attrs().Name == link.Attrs().Name {
+			return stored
+		}
+	}
+	return nil
+}
+
+// changeLink applies change to the stored link and notifies the subscribers
+func (n *Netlink) changeLink(link netlink.Link, change func(stored netlink.Link) error) error {
+	n.mu.Lock()
+	stored := n.find(link)
+	if stored == nil {
+		n.mu.Unlock()
+		return syscall.ENODEV
+	}
+	if err := change(stored); err != nil {
+		n.mu.Unlock()
+		return err
+	}
+	update := netlink.LinkUpdate{Link: cloneLink(stored)}
+	update.Header.Type = unix.RTM_NEWLINK
+	subs := n.linkSubs
+	n.mu.Unlock()
+	for _, sub := range subs {
+		sub.send(update)
+	}
+	return nil
+}
+
+// LinkByName returns the link of that name
+func (n *Netlink) LinkByName(_ context.Context, name string) (netlink.Link, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	stored := n.find(&netlink.Device{LinkAttrs: netlink.LinkAttrs{Name: name}})
+	if stored == nil {
+		return nil, netlink.LinkNotFoundError{}
+	}
+	return cloneLink(stored), nil
+}
+
+// LinkModify replaces the link of the same name, keeping its index and state
+func (n *Netlink) LinkModify(_ context.Context, link netlink.Link) error {
+	n.mu.Lock()
+	stored := n.find(link)
+	if stored == nil {
+		n.mu.Unlock()
+		return syscall.ENODEV
+	}
+	modified := cloneLink(link)
+	attrs, old := modified.Attrs(), stored.Attrs()
+	attrs.Index, attrs.Flags, attrs.OperState, attrs.MasterIndex = old.Index, old.Flags, old.OperState, old.MasterIndex
+	n.links[attrs.Index] = modified
+	n.mu.Unlock()
+	return n.changeLink(modified, func(netlink.Link) error { return nil })
+}
+
+// LinkSetHardwareAddr sets the MAC address of the link
+func (n *Netlink) LinkSetHardwareAddr(_ context.Context, link netlink.Link, hwaddr net.HardwareAddr) error {
+	return n.changeLink(link, func(stored netlink.Link) error {
+		stored.Attrs().HardwareAddr = hwaddr
+		return nil
+	})
+}
+
+func sameAddr(a, b *netlink.Addr) bool {
+	return a.IPNet != nil && b.IPNet != nil && a.IPNet.String() == b.IPNet.String()
+}
+
+// AddrAdd adds the address to the link, failing with EEXIST when it has it
+func (n *Netlink) AddrAdd(_ context.Context, link netlink.Link, addr *netlink.Addr) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	stored := n.find(link)
+	if stored == nil {
+		return syscall.ENODEV
+	}
+	index := stored.Attrs().Index
+	for i := range n.addrs[index] {
+		if sameAddr(&n.addrs[index][i], addr) {
+			return syscall.EEXIST
+		}
+	}
+	added := *addr
+	added.LinkIndex = index
+	n.addrs[index] = append(n.addrs[index], added)
+	return nil
+}
+
+// AddrDel removes the address from the link, failing with EADDRNOTAVAIL when it does not have it
+func (n *Netlink) AddrDel(_ context.Context, link netlink.Link, addr *netlink.Addr) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	stored := n.find(link)
+	if stored == nil {
+		return syscall.ENODEV
+	}
+	index := stored.Attrs().Index
+	for i := range n.addrs[index] {
+		if sameAddr(&n.addrs[index][i], addr) {
+			n.addrs[index] = append(n.addrs[index][:i], n.addrs[index][i+1:]...)
+			return nil
+		}
+	}
+	return syscall.EADDRNOTAVAIL
+}
+
+// LinkAdd creates the link, down, and sets its index, failing with EEXIST when the name is taken
+func (n *Netlink) LinkAdd(_ context.Context, link netlink.Link) error {
+	n.mu.Lock()
+	if n.find(&netlink.Device{LinkAttrs: netlink.LinkAttrs{Name: link.Attrs().Name}}) != nil {
+		n.mu.Unlock()
+		return syscall.EEXIST
+	}
+	link.Attrs().Index = n.nextIndex
+	n.nextIndex++
+	stored := cloneLink(link)
+	attrs := stored.Attrs()
+	attrs.Flags &^= net.FlagUp
+	attrs.OperState = netlink.OperDown
+	if len(attrs.HardwareAddr) == 0 && link.Type() != "vrf" {
+		attrs.HardwareAddr = net.HardwareAddr{0x02, 0, 0, 0, byte(attrs.Index >> 8), byte(attrs.Index)}
+	}
+	n.links[attrs.Index] = stored
+	n.mu.Unlock()
+	return n.changeLink(stored, func(netlink.Link) error { return nil })
+}
+
+// LinkDel deletes the link with its addresses, vlans, neighbors and routes, and releases the
+// links enslaved to it
+func (n *Netlink) LinkDel(_ context.Context, link netlink.Link) error {
+	n.mu.Lock()
+	stored := n.find(link)
+	if stored == nil {
+		n.mu.Unlock()
+		return syscall.ENODEV
+	}
+	index := stored.Attrs().Index
+	delete(n.links, index)
+	delete(n.addrs, index)
+	delete(n.vlans, int32(index))
+	for _, other := range n.links {
+		if other.Attrs().MasterIndex == index {
+			other.Attrs().MasterIndex = 0
+		}
+	}
+	neighs := n.neighs[:0]
+	for _, neigh := range n.neighs {
+		if neigh.LinkIndex != index && neigh.MasterIndex != index {
+			neighs = append(neighs, neigh)
+		}
+	}
+	n.neighs = neighs
+	routes := n.routes[:0]
+	for _, route := range n.routes {
+		if route.LinkIndex != index {
+			routes = append(routes, route)
+		}
+	}
+	n.routes = routes
+	update := netlink.LinkUpdate{Link: cloneLink(stored)}
+	update.Header.Type = unix.RTM_DELLINK
+	subs := n.linkSubs
+	n.mu.Unlock()
+	for _, sub := range subs {
+		sub.send(update)
+	}
+	return nil
+}
+
+// LinkSetUp brings the link administratively and operationally up
+func (n *Netlink) LinkSetUp(_ context.Context, link netlink.Link) error {
+	return n.changeLink(link, func(stored netlink.Link) error {
+		stored.Attrs().Flags |= net.FlagUp
+		stored.Attrs().OperState = netlink.OperUp
+		return nil
+	})
+}
+
+// LinkSetDown brings the link administratively and operationally down
+func (n *Netlink) LinkSetDown(_ context.Context, link netlink.Link) error {
+	return n.changeLink(link, func(stored netlink.Link) error {
+		stored.Attrs().Flags &^= net.FlagUp
+		stored.Attrs().OperState = netlink.OperDown
+		return nil
+	})
+}
+
+// LinkSetMaster enslaves the link to the bridge or vrf
+func (n *Netlink) LinkSetMaster(_ context.Context, link netlink.Link, master netlink.Link) error {
+	n.mu.Lock()
+	stored := n.find(master)
+	n.mu.Unlock()
+	if stored == nil {
+		return syscall.ENODEV
+	}
+	index := stored.Attrs().Index
+	return n.changeLink(link, func(stored netlink.Link) error {
+		stored.Attrs().MasterIndex = index
+		return nil
+	})
+}
+
+// LinkSetNoMaster releases the link from its master
+func (n *Netlink) LinkSetNoMaster(_ context.Context, link netlink.Link) error {
+	return n.changeLink(link, func(stored netlink.Link) error {
+		stored.Attrs().MasterIndex = 0
+		return nil
+	})
+}
+
+// LinkSetLearning turns the MAC learning of the bridge port on or off
+func (n *Netlink) LinkSetLearning(_ context.Context, link netlink.Link, mode bool) error {
+	return n.changeLink(link, func(stored netlink.Link) error {
+		protinfo := netlink.Protinfo{}
+		if stored.Attrs().Protinfo != nil {
+			protinfo = *stored.Attrs().Protinfo
+		}
+		protinfo.Learning = mode
+		stored.Attrs().Protinfo = &protinfo
+		return nil
+	})
+}
+
+// BridgeVlanAdd adds the vlan to the bridge port, or changes its flags, a new pvid replacing
+// the previous one
+func (n *Netlink) BridgeVlanAdd(_ context.Context, link netlink.Link, vid uint16, pvid, untagged, _, _ bool) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	stored := n.find(link)
+	if stored == nil {
+		return syscall.ENODEV
+	}
+	index := int32(stored.Attrs().Index)
+	info := &nl.BridgeVlanInfo{Vid: vid}
+	if pvid {
+		info.Flags |= nl.BRIDGE_VLAN_INFO_PVID
+	}
+	if untagged {
+		info.Flags |= nl.BRIDGE_VLAN_INFO_UNTAGGED
+	}
+	vlans := []*nl.BridgeVlanInfo{}
+	for _, other := range n.vlans[index] {
+		if other.Vid == vid {
+			continue
+		}
+		if pvid {
+			other.Flags &^= nl.BRIDGE_VLAN_INFO_PVID
+		}
+		vlans = append(vlans, other)
+	}
+	n.vlans[index] = append(vlans, info)
+	return nil
+}
+
+// BridgeVlanDel removes the vlan from the bridge port, failing with ENOENT when it does not have it
+func (n *Netlink) BridgeVlanDel(_ context.Context, link netlink.Link, vid uint16, _, _, _, _ bool) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	stored := n.find(link)
+	if stored == nil {
+		return syscall.ENODEV
+	}
+	index := int32(stored.Attrs().Index)
+	for i, info := range n.vlans[index] {
+		if info.Vid == vid {
+			n.vlans[index] = append(n.vlans[index][:i], n.vlans[index][i+1:]...)
+			return nil
+		}
+	}
+	return syscall.ENOENT
+}
+
+// routeTable is the table of the route, the main one when unset
+func routeTable(route *netlink.Route) int {
+	if route.Table == 0 {
+		return unix.RT_TABLE_MAIN
+	}
+	return route.Table
+}
+
+func routeDst(route *netlink.Route) string {
+	if route.Dst == nil {
+		return "default"
+	}
+	return route.Dst.String()
+}
+
+func sameRoute(a, b *netlink.Route) bool {
+	return routeTable(a) == routeTable(b) && routeDst(a) == routeDst(b) && a.Priority == b.Priority
+}
+
+func (n *Netlink) notifyRoute(route netlink.Route, updateType uint16) {
+	n.mu.Lock()
+	subs := n.routeSubs
+	n.mu.Unlock()
+	for _, sub := range subs {
+		sub.send(netlink.RouteUpdate{Type: updateType, Route: route})
+	}
+}
+
+// RouteAdd adds the route, failing with EEXIST when the table has one to the same destination
+func (n *Netlink) RouteAdd(_ context.Context, route *netlink.Route) error {
+	n.mu.Lock()
+	for i := range n.routes {
+		if sameRoute(&n.routes[i], route) {
+			n.mu.Unlock()
+			return syscall.EEXIST
+		}
+	}
+	n.routes = append(n.routes, *route)
+	n.mu.Unlock()
+	n.notifyRoute(*route, unix.RTM_NEWROUTE)
+	return nil
+}
+
+// RouteDel removes the route, failing with ESRCH when it is missing
+func (n *Netlink) RouteDel(_ context.Context, route *netlink.Route) error {
+	n.mu.Lock()
+	for i := range n.routes {
+		if sameRoute(&n.routes[i], route) {
+			deleted := n.routes[i]
+			n.routes = append(n.routes[:i], n.routes[i+1:]...)
+			n.mu.Unlock()
+			n.notifyRoute(deleted, unix.RTM_DELROUTE)
+			return nil
+		}
+	}
+	n.mu.Unlock()
+	return syscall.ESRCH
+}
+
+// routeFamily is the family of the destination of the route, or its gateway for the default ones
+func routeFamily(route *netlink.Route) int {
+	ip := route.Gw
+	if route.Dst != nil {
+		ip = route.Dst.IP
+	}
+	if ip != nil && ip.To4() == nil {
+		return netlink.FAMILY_V6
+	}
+	return netlink.FAMILY_V4
+}
+
+// RouteListFiltered lists the routes of the family matching the table, output interface and
+// destination of filter selected by filterMask
+func (n *Netlink) RouteListFiltered(_ context.Context, family int, filter *netlink.Route, filterMask uint64) ([]netlink.Route, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	routes := []netlink.Route{}
+	for i := range n.routes {
+		route := &n.routes[i]
+		switch {
+		case family != netlink.FAMILY_ALL && routeFamily(route) != family:
+		case filterMask&netlink.RT_FILTER_TABLE != 0 && filter.Table != unix.RT_TABLE_UNSPEC && routeTable(route) != routeTable(filter):
+		case filterMask&netlink.RT_FILTER_OIF != 0 && route.LinkIndex != filter.LinkIndex:
+		case filterMask&netlink.RT_FILTER_DST != 0 && routeDst(route) != routeDst(filter):
+		default:
+			routes = append(routes, *route)
+		}
+	}
+	return routes, nil
+}
+
+// RuleAdd adds the policy routing rule, failing with EEXIST when it exists
+func (n *Netlink) RuleAdd(_ context.Context, rule *netlink.Rule) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for _, stored := range n.rules {
+		if stored.String() == rule.String() {
+			return syscall.EEXIST
+		}
+	}
+	n.rules = append(n.rules, *rule)
+	return nil
+}
+
+// RuleDel removes the policy routing rule, failing with ENOENT when it is missing
+func (n *Netlink) RuleDel(_ context.Context, rule *netlink.Rule) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for i, stored := range n.rules {
+		if stored.String() == rule.String() {
+			n.rules = append(n.rules[:i], n.rules[i+1:]...)
+			return nil
+		}
+	}
+	return syscall.ENOENT
+}
+
+// XfrmStateAdd adds the IPsec SA, failing with EEXIST when its SPI is taken
+func (n *Netlink) XfrmStateAdd(_ context.Context, state *netlink.XfrmState) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for _, stored := range n.states {
+		if stored.Spi == state.Spi && stored.Dst.Equal(state.Dst) && stored.Proto == state.Proto {
+			return syscall.EEXIST
+		}
+	}
+	n.states = append(n.states, *state)
+	return nil
+}
+
+// XfrmStateDel removes the IPsec SA, failing with ESRCH when it is missing
+func (n *Netlink) XfrmStateDel(_ context.Context, state *netlink.XfrmState) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for i, stored := range n.states {
+		if stored.Spi == state.Spi && stored.Dst.Equal(state.Dst) && stored.Proto == state.Proto {
+			n.states = append(n.states[:i], n.states[i+1:]...)
+			return nil
+		}
+	}
+	return syscall.ESRCH
+}
+
+func samePolicy(a, b *netlink.XfrmPolicy) bool {
+	return a.Dir == b.Dir && a.Src.String() == b.Src.String() && a.Dst.String() == b.Dst.String()
+}
+
+// XfrmPolicyAdd adds the IPsec policy, failing with EEXIST when one has the same selector
+func (n *Netlink) XfrmPolicyAdd(_ context.Context, policy *netlink.XfrmPolicy) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for i := range n.policies {
+		if samePolicy(&n.policies[i], policy) {
+			return syscall.EEXIST
+		}
+	}
+	n.policies = append(n.policies, *policy)
+	return nil
+}
+
+// XfrmPolicyDel removes the IPsec policy, failing with ENOENT when it is missing
+func (n *Netlink) XfrmPolicyDel(_ context.Context, policy *netlink.XfrmPolicy) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for i := range n.policies {
+		if samePolicy(&n.policies[i], policy) {
+			n.policies = append(n.policies[:i], n.policies[i+1:]...)
+			return nil
+		}
+	}
+	return syscall.ENOENT
+}
+
+// LinkList lists the links by index
+func (n *Netlink) LinkList(_ context.Context) ([]netlink.Link, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	links := make([]netlink.Link, 0, len(n.links))
+	for index := 1; index < n.nextIndex; index++ {
+		if link, ok := n.links[index]; ok {
+			links = append(links, cloneLink(link))
+		}
+	}
+	return links, nil
+}
+
+// AddrList lists the addresses of the family on the link, of all links when link is nil
+func (n *Netlink) AddrList(_ context.Context, link netlink.Link, family int) ([]netlink.Addr, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	index := 0
+	if link != nil {
+		stored := n.find(link)
+		if stored == nil {
+			return nil, syscall.ENODEV
+		}
+		index = stored.Attrs().Index
+	}
+	addrs := []netlink.Addr{}
+	for linkIndex, linkAddrs := range n.addrs {
+		if index != 0 && linkIndex != index {
+			continue
+		}
+		for _, addr := range linkAddrs {
+			if addr.IPNet == nil {
+				continue
+			}
+			v4 := addr.IP.To4() != nil
+			if family == netlink.FAMILY_ALL || (family == netlink.FAMILY_V4) == v4 {
+				addrs = append(addrs, addr)
+			}
+		}
+	}
+	return addrs, nil
+}
+
+// BridgeVlanList lists the vlans of the bridge ports by link index
+func (n *Netlink) BridgeVlanList(_ context.Context) (map[int32][]*nl.BridgeVlanInfo, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	vlans := map[int32][]*nl.BridgeVlanInfo{}
+	for index, infos := range n.vlans {
+		for _, info := range infos {
+			copied := *info
+			vlans[index] = append(vlans[index], &copied)
+		}
+	}
+	return vlans, nil
+}
+
+// NeighList lists the neighbor and fdb entries of the family on the link, of all links when
+// linkIndex is 0
+func (n *Netlink) NeighList(_ context.Context, linkIndex, family int) ([]netlink.Neigh, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	neighs := []netlink.Neigh{}
+	for _, neigh := range n.neighs {
+		if (linkIndex == 0 || neigh.LinkIndex == linkIndex) && (family == netlink.FAMILY_ALL || neigh.Family == family) {
+			neighs = append(neighs, neigh)
+		}
+	}
+	return neighs, nil
+}
+
+func sameNeigh(a, b *netlink.Neigh) bool {
+	return a.LinkIndex == b.LinkIndex && a.Family == b.Family && a.Vlan == b.Vlan &&
+		a.HardwareAddr.String() == b.HardwareAddr.String() && a.IP.Equal(b.IP)
+}
+
+func (n *Netlink) notifyNeigh(neigh netlink.Neigh, updateType uint16) {
+	n.mu.Lock()
+	subs := n.neighSubs
+	n.mu.Unlock()
+	for _, sub := range subs {
+		sub.send(netlink.NeighUpdate{Type: updateType, Neigh: neigh})
+	}
+}
+
+// NeighDel removes the neighbor or fdb entry, failing with ENOENT when it is missing
+func (n *Netlink) NeighDel(_ context.Context, neigh *netlink.Neigh) error {
+	n.mu.Lock()
+	for i := range n.neighs {
+		if sameNeigh(&n.neighs[i], neigh) {
+			deleted := n.neighs[i]
+			n.neighs = append(n.neighs[:i], n.neighs[i+1:]...)
+			n.mu.Unlock()
+			n.notifyNeigh(deleted, unix.RTM_DELNEIGH)
+			return nil
+		}
+	}
+	n.mu.Unlock()
+	return syscall.ENOENT
+}
+
+// NeighAppend adds the entry, even next to one for the same address, e.g. the flooding
+// entries of the remote VTEPs
+func (n *Netlink) NeighAppend(_ context.Context, neigh *netlink.Neigh) error {
+	n.mu.Lock()
+	n.neighs = append(n.neighs, *neigh)
+	n.mu.Unlock()
+	n.notifyNeigh(*neigh, unix.RTM_NEWNEIGH)
+	return nil
+}
+
+// NeighSet adds the entry or replaces the one for the same address
+func (n *Netlink) NeighSet(_ context.Context, neigh *netlink.Neigh) error {
+	n.mu.Lock()
+	replaced := false
+	for i := range n.neighs {
+		if sameNeigh(&n.neighs[i], neigh) {
+			n.neighs[i] = *neigh
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		n.neighs = append(n.neighs, *neigh)
+	}
+	n.mu.Unlock()
+	n.notifyNeigh(*neigh, unix.RTM_NEWNEIGH)
+	return nil
+}
+
+// DevLinkGetDeviceByName fails, the simulated ports have no devlink device
+func (n *Netlink) DevLinkGetDeviceByName(_ context.Context, bus string, device string) (*netlink.DevlinkDevice, error) {
+	return nil, fmt.Errorf("no devlink device %s/%s in the simulated kernel", bus, device)
+}
+
+// LinkSubscribe sends the link changes to ch until ctx is done, then closes ch
+func (n *Netlink) LinkSubscribe(ctx context.Context, ch chan<- netlink.LinkUpdate, listExisting bool) error {
+	sub := newSubscriber(ctx, ch)
+	n.mu.Lock()
+	n.linkSubs = append(n.linkSubs, sub)
+	n.mu.Unlock()
+	if listExisting {
+		links, _ := n.LinkList(ctx)
+		go func() {
+			for _, link := range links {
+				update := netlink.LinkUpdate{Link: link}
+				update.Header.Type = unix.RTM_NEWLINK
+				sub.send(update)
+			}
+		}()
+	}
+	return nil
+}
+
+// NeighSubscribe sends the neighbor and fdb changes to ch until ctx is done, then closes ch
+func (n *Netlink) NeighSubscribe(ctx context.Context, ch chan<- netlink.NeighUpdate, listExisting bool) error {
+	sub := newSubscriber(ctx, ch)
+	n.mu.Lock()
+	n.neighSubs = append(n.neighSubs, sub)
+	existing := append([]netlink.Neigh{}, n.neighs...)
+	n.mu.Unlock()
+	if listExisting {
+		go func() {
+			for _, neigh := range existing {
+				sub.send(netlink.NeighUpdate{Type: unix.RTM_NEWNEIGH, Neigh: neigh})
+			}
+		}()
+	}
+	return nil
+}
+
+// RouteSubscribe sends the route changes to ch until ctx is done, then closes ch
+func (n *Netlink) RouteSubscribe(ctx context.Context, ch chan<- netlink.RouteUpdate, listExisting bool) error {
+	sub := newSubscriber(ctx, ch)
+	n.mu.Lock()
+	n.routeSubs = append(n.routeSubs, sub)
+	existing := append([]netlink.Route{}, n.routes...)
+	n.mu.Unlock()
+	if listExisting {
+		go func() {
+			for _, route := range existing {
+				sub.send(netlink.RouteUpdate{Type: unix.RTM_NEWROUTE, Route: route})
+			}
+		}()
+	}
+	return nil
+}
+
+// root returns the kernel holding the named network namespaces, those being seen from all
+func (n *Netlink) root() *Netlink {
+	if n.host != nil {
+		return n.host
+	}
+	return n
+}
+
+// NetnsAdd creates the named network namespace with its loopback, down, failing with EEXIST
+// when the name is taken
+func (n *Netlink) NetnsAdd(_ context.Context, name string) error {
+	root := n.root()
+	root.mu.Lock()
+	defer root.mu.Unlock()
+	if _, ok := root.namespaces[name]; ok {
+		return syscall.EEXIST
+	}
+	ns := newKernel()
+	ns.host = root
+	ns.addUp(&netlink.Device{LinkAttrs: netlink.LinkAttrs{Name: "lo", Flags: net.FlagLoopback}})
+	lo := ns.links[1].Attrs()
+	lo.Flags &^= net.FlagUp
+	lo.OperState = netlink.OperDown
+	root.namespaces[name] = ns
+	return nil
+}
+
+// NetnsDel deletes the named network namespace with its virtual links, its physical ones
+// going back to the host
+func (n *Netlink) NetnsDel(ctx context.Context, name string) error {
+	root := n.root()
+	root.mu.Lock()
+	ns, ok := root.namespaces[name]
+	delete(root.namespaces, name)
+	root.mu.Unlock()
+	if !ok {
+		return syscall.ENOENT
+	}
+	links, _ := ns.LinkList(ctx)
+	for _, link := range links {
+		if device, ok := link.(*netlink.Device); ok && device.Name != "lo" {
+			if err := ns.LinkSetNs(ctx, link, ""); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// LinkSetNs moves the link, down and without its addresses and master, into the named network
+// namespace, or into the host for an empty name
+func (n *Netlink) LinkSetNs(ctx context.Context, link netlink.Link, name string) error {
+	root := n.root()
+	target := root
+	if name != "" {
+		root.mu.Lock()
+		ns, ok := root.namespaces[name]
+		root.mu.Unlock()
+		if !ok {
+			return syscall.ENOENT
+		}
+		target = ns
+	}
+	n.mu.Lock()
+	stored := n.find(link)
+	n.mu.Unlock()
+	if stored == nil {
+		return syscall.ENODEV
+	}
+	if target == n {
+		return nil
+	}
+	target.mu.Lock()
+	taken := target.find(&netlink.Device{LinkAttrs: netlink.LinkAttrs{Name: stored.Attrs().Name}}) != nil
+	target.mu.Unlock()
+	if taken {
+		return syscall.EEXIST
+	}
+	moved := cloneLink(stored)
+	if err := n.LinkDel(ctx, stored); err != nil {
+		return err
+	}
+	moved.Attrs().Index = 0
+	moved.Attrs().MasterIndex = 0
+	return target.LinkAdd(ctx, moved)
+}
+
+// Netns returns the kernel of the named network namespace, failing with ENOENT when missing
+func (n *Netlink) Netns(_ context.Context, name string) (utils.Netlink, error) {
+	root := n.root()
+	root.mu.Lock()
+	defer root.mu.Unlock()
+	ns, ok := root.namespaces[name]
+	if !ok {
+		return nil, syscall.ENOENT
+	}
+	return ns, nil
+}
@@ -0,0 +1,546 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package gnmi serves the telemetry and the configured objects of the EVPN server over gNMI
+package gnmi
+
+import (
+	"fmt"
+	"sort"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// The messages of gnmi.proto (github.com/openconfig/gnmi) the server uses, encoded by hand as
+// the module is not a dependency. The fields keep the numbers of gnmi.proto, the unknown ones
+// are skipped when decoding
+
+// Encoding is the encoding of the values
+type Encoding int32
+
+// Encodings of gnmi.proto
+const (
+	EncodingJSON     Encoding = 0
+	EncodingBytes    Encoding = 1
+	EncodingProto    Encoding = 2
+	EncodingASCII    Encoding = 3
+	EncodingJSONIETF Encoding = 4
+)
+
+// SubscriptionListMode is the mode of a subscription list
+type SubscriptionListMode int32
+
+// Subscription list modes of gnmi.proto
+const (
+	ListModeStream SubscriptionListMode = 0
+	ListModeOnce   SubscriptionListMode = 1
+	ListModePoll   SubscriptionListMode = 2
+)
+
+// SubscriptionMode is the mode of a subscription of a stream
+type SubscriptionMode int32
+
+// Subscription modes of gnmi.proto
+const (
+	ModeTargetDefined SubscriptionMode = 0
+	ModeOnChange      SubscriptionMode = 1
+	ModeSample        SubscriptionMode = 2
+)
+
+// PathElem is an element of a path with its keys, e.g. interface[name=eth0]
+type PathElem struct {
+	Name string
+	Key  map[string]string
+}
+
+// Path is a path of the data tree
+type Path struct {
+	Origin string
+	Elem   []PathElem
+	Target string
+}
+
+// JSON is a value sent as JSON_IETF, e.g. the spec of an object
+type JSON string
+
+// Update is the new value of a leaf: a string, uint64, int64, bool or JSON
+type Update struct {
+	Path Path
+	Val  interface{}
+}
+
+// Notification is a set of updated and deleted leaves
+type Notification struct {
+	Timestamp int64
+	Prefix    *Path
+	Update    []Update
+	Delete    []Path
+}
+
+// ModelData is a data model supported by the server
+type ModelData struct {
+	Name         string
+	Organization string
+	Version      string
+}
+
+// CapabilityRequest is the request of Capabilities
+type CapabilityRequest struct{}
+
+// CapabilityResponse lists the models and encodings supported by the server
+type CapabilityResponse struct {
+	SupportedModels    []ModelData
+	SupportedEncodings []Encoding
+	GNMIVersion        string
+}
+
+// GetRequest is the request of Get
+type GetRequest struct {
+	Prefix   *Path
+	Path     []Path
+	Encoding Encoding
+}
+
+// GetResponse holds the values of the paths of Get
+type GetResponse struct {
+	Notification []Notification
+}
+
+// Subscription is a path subscribed to
+type Subscription struct {
+	Path Path
+	Mode SubscriptionMode
+	// SampleInterval is in nanoseconds
+	SampleInterval    uint64
+	SuppressRedundant bool
+}
+
+// SubscriptionList is the first request of Subscribe
+type SubscriptionList struct {
+	Prefix       *Path
+	Subscription []Subscription
+	Mode         SubscriptionListMode
+	Encoding     Encoding
+	UpdatesOnly  bool
+}
+
+// SubscribeRequest is a request of Subscribe, the subscriptions then the polls
+type SubscribeRequest struct {
+	Subscribe *SubscriptionList
+	Poll      bool
+}
+
+// SubscribeResponse is a notification or the end of the initial values
+type SubscribeResponse struct {
+	Update       *Notification
+	SyncResponse bool
+}
+
+// Operation is the operation of a path of Set
+type Operation int32
+
+// Operations of gnmi.proto
+const (
+	OperationDelete  Operation = 1
+	OperationReplace Operation = 2
+	OperationUpdate  Operation = 3
+)
+
+// SetRequest is the request of Set, the deleted paths, then the replaced and the updated ones
+// with their JSON or JSON_IETF values
+type SetRequest struct {
+	Prefix  *Path
+	Delete  []Path
+	Replace []Update
+	Update  []Update
+}
+
+// UpdateResult is the outcome of a path of Set
+type UpdateResult struct {
+	Path Path
+	Op   Operation
+}
+
+// SetResponse lists the paths changed by Set
+type SetResponse struct {
+	Prefix    *Path
+	Response  []UpdateResult
+	Timestamp int64
+}
+
+// marshaler and unmarshaler are implemented by the messages sent and received
+type marshaler interface {
+	marshal() []byte
+}
+
+type unmarshaler interface {
+	unmarshal(data []byte) error
+}
+
+// codec encodes the gNMI messages of the gNMI gRPC server
+type codec struct{}
+
+func (codec) Marshal(v interface{}) ([]byte, error) {
+	m, ok := v.(marshaler)
+	if !ok {
+		return nil, fmt.Errorf("cannot marshal %T as a gNMI message", v)
+	}
+	return m.marshal(), nil
+}
+
+func (codec) Unmarshal(data []byte, v interface{}) error {
+	m, ok := v.(unmarshaler)
+	if !ok {
+		return fmt.Errorf("cannot unmarshal %T as a gNMI message", v)
+	}
+	return m.unmarshal(data)
+}
+
+func (codec) Name() string {
+	return "proto"
+}
+
+func appendMessage(b []byte, num protowire.Number, m []byte) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, m)
+}
+
+func appendString(b []byte, num protowire.Number, s string) []byte {
+	if s == "" {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, s)
+}
+
+func appendVarint(b []byte, num protowire.Number, v uint64) []byte {
+	if v == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, v)
+}
+
+// fields calls field with each field of a message, the values of the varint fields or the
+// content of the length delimited ones, skipping the others
+func fields(data []byte, field func(num protowire.Number, v uint64, b []byte) error) error {
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		data = data[n:]
+		var v uint64
+		var b []byte
+		switch typ {
+		case protowire.VarintType:
+			v, n = protowire.ConsumeVarint(data)
+		case protowire.BytesType:
+			b, n = protowire.ConsumeBytes(data)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, data)
+		}
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		data = data[n:]
+		if typ == protowire.VarintType || typ == protowire.BytesType {
+			if err := field(num, v, b); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (e PathElem) marshal() []byte {
+	b := appendString(nil, 1, e.Name)
+	keys := make([]string, 0, len(e.Key))
+	for key := range e.Key {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		entry := appendString(nil, 1, key)
+		entry = appendString(entry, 2, e.Key[key])
+		b = appendMessage(b, 2, entry)
+	}
+	return b
+}
+
+func (e *PathElem) unmarshal(data []byte) error {
+	return fields(data, func(num protowire.Number, _ uint64, b []byte) error {
+		switch num {
+		case 1:
+			e.Name = string(b)
+		case 2:
+			var key, value string
+			err := fields(b, func(num protowire.Number, _ uint64, b []byte) error {
+				if num == 1 {
+					key = string(b)
+				} else if num == 2 {
+					value = string(b)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			if e.Key == nil {
+				e.Key = map[string]string{}
+			}
+			e.Key[key] = value
+		}
+		return nil
+	})
+}
+
+func (p Path) marshal() []byte {
+	b := appendString(nil, 2, p.Origin)
+	for _, elem := range p.Elem {
+		b = appendMessage(b, 3, elem.marshal())
+	}
+	return appendString(b, 4, p.Target)
+}
+
+func (p *Path) unmarshal(data []byte) error {
+	return fields(data, func(num protowire.Number, _ uint64, b []byte) error {
+		switch num {
+		case 1:
+			// the deprecated element field of the older clients
+			p.Elem = append(p.Elem, PathElem{Name: string(b)})
+		case 2:
+			p.Origin = string(b)
+		case 3:
+			elem := PathElem{}
+			if err := elem.unmarshal(b); err != nil {
+				return err
+			}
+			p.Elem = append(p.Elem, elem)
+		case 4:
+			p.Target = string(b)
+		}
+		return nil
+	})
+}
+
+// marshalTypedValue encodes a value as a TypedValue
+func marshalTypedValue(v interface{}) []byte {
+	var b []byte
+	switch v := v.(type) {
+	case string:
+		b = protowire.AppendTag(b, 1, protowire.BytesType)
+		b = protowire.AppendString(b, v)
+	case int64:
+		b = protowire.AppendTag(b, 2, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(v))
+	case uint64:
+		b = protowire.AppendTag(b, 3, protowire.VarintType)
+		b = protowire.AppendVarint(b, v)
+	case bool:
+		b = protowire.AppendTag(b, 4, protowire.VarintType)
+		b = protowire.AppendVarint(b, protowire.EncodeBool(v))
+	case JSON:
+		b = protowire.AppendTag(b, 11, protowire.BytesType)
+		b = protowire.AppendString(b, string(v))
+	default:
+		b = protowire.AppendTag(b, 1, protowire.BytesType)
+		b = protowire.AppendString(b, fmt.Sprint(v))
+	}
+	return b
+}
+
+// unmarshalTypedValue decodes the value of a TypedValue, JSON for the json_val and json_ietf_val fields
+// and nil for the types the server does not read
+func unmarshalTypedValue(data []byte) (interface{}, error) {
+	var val interface{}
+	err := fields(data, func(num protowire.Number, _ uint64, b []byte) error {
+		switch num {
+		case 1:
+			val = string(b)
+		case 10, 11:
+			val = JSON(b)
+		}
+		return nil
+	})
+	return val, err
+}
+
+func (u *Update) unmarshal(data []byte) error {
+	return fields(data, func(num protowire.Number, _ uint64, b []byte) error {
+		switch num {
+		case 1:
+			return u.Path.unmarshal(b)
+		case 3:
+			val, err := unmarshalTypedValue(b)
+			u.Val = val
+			return err
+		}
+		return nil
+	})
+}
+
+func (u Update) marshal() []byte {
+	b := appendMessage(nil, 1, u.Path.marshal())
+	return appendMessage(b, 3, marshalTypedValue(u.Val))
+}
+
+func (n Notification) marshal() []byte {
+	b := appendVarint(nil, 1, uint64(n.Timestamp))
+	if n.Prefix != nil {
+		b = appendMessage(b, 2, n.Prefix.marshal())
+	}
+	for _, update := range n.Update {
+		b = appendMessage(b, 4, update.marshal())
+	}
+	for _, path := range n.Delete {
+		b = appendMessage(b, 5, path.marshal())
+	}
+	return b
+}
+
+func (r *CapabilityRequest) unmarshal(_ []byte) error {
+	return nil
+}
+
+func (r *CapabilityResponse) marshal() []byte {
+	var b []byte
+	for _, model := range r.SupportedModels {
+		m := appendString(nil, 1, model.Name)
+		m = appendString(m, 2, model.Organization)
+		m = appendString(m, 3, model.Version)
+		b = appendMessage(b, 1, m)
+	}
+	for _, encoding := range r.SupportedEncodings {
+		b = protowire.AppendTag(b, 2, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(encoding))
+	}
+	return appendString(b, 3, r.GNMIVersion)
+}
+
+func (r *GetRequest) unmarshal(data []byte) error {
+	return fields(data, func(num protowire.Number, v uint64, b []byte) error {
+		switch num {
+		case 1:
+			r.Prefix = &Path{}
+			return r.Prefix.unmarshal(b)
+		case 2:
+			path := Path{}
+			if err := path.unmarshal(b); err != nil {
+				return err
+			}
+			r.Path = append(r.Path, path)
+		case 5:
+			r.Encoding = Encoding(v)
+		}
+		return nil
+	})
+}
+
+func (r *GetResponse) marshal() []byte {
+	var b []byte
+	for _, notification := range r.Notification {
+		b = appendMessage(b, 1, notification.marshal())
+	}
+	return b
+}
+
+func (s *Subscription) unmarshal(data []byte) error {
+	return fields(data, func(num protowire.Number, v uint64, b []byte) error {
+		switch num {
+		case 1:
+			return s.Path.unmarshal(b)
+		case 2:
+			s.Mode = SubscriptionMode(v)
+		case 3:
+			s.SampleInterval = v
+		case 4:
+			s.SuppressRedundant = protowire.DecodeBool(v)
+		}
+		return nil
+	})
+}
+
+func (l *SubscriptionList) unmarshal(data []byte) error {
+	return fields(data, func(num protowire.Number, v uint64, b []byte) error {
+		switch num {
+		case 1:
+			l.Prefix = &Path{}
+			return l.Prefix.unmarshal(b)
+		case 2:
+			subscription := Subscription{}
+			if err := subscription.unmarshal(b); err != nil {
+				return err
+			}
+			l.Subscription = append(l.Subscription, subscription)
+		case 5:
+			l.Mode = SubscriptionListMode(v)
+		case 8:
+			l.Encoding = Encoding(v)
+		case 9:
+			l.UpdatesOnly = protowire.DecodeBool(v)
+		}
+		return nil
+	})
+}
+
+func (r *SubscribeRequest) unmarshal(data []byte) error {
+	return fields(data, func(num protowire.Number, _ uint64, b []byte) error {
+		switch num {
+		case 1:
+			r.Subscribe = &SubscriptionList{}
+			return r.Subscribe.unmarshal(b)
+		case 3:
+			r.Poll = true
+		}
+		return nil
+	})
+}
+
+func (r *SubscribeResponse) marshal() []byte {
+	if r.Update != nil {
+		return appendMessage(nil, 1, r.Update.marshal())
+	}
+	b := protowire.AppendTag(nil, 3, protowire.VarintType)
+	return protowire.AppendVarint(b, protowire.EncodeBool(r.SyncResponse))
+}
+
+func (r *SetRequest) unmarshal(data []byte) error {
+	return fields(data, func(num protowire.Number, _ uint64, b []byte) error {
+		switch num {
+		case 1:
+			r.Prefix = &Path{}
+			return r.Prefix.unmarshal(b)
+		case 2:
+			path := Path{}
+			if err := path.unmarshal(b); err != nil {
+				return err
+			}
+			r.Delete = append(r.Delete, path)
+		case 3, 4:
+			update := Update{}
+			if err := update.unmarshal(b); err != nil {
+				return err
+			}
+			if num == 3 {
+				r.Replace = append(r.Replace, update)
+			} else {
+				r.Update = append(r.Update, update)
+			}
+		}
+		return nil
+	})
+}
+
+func (r *SetResponse) marshal() []byte {
+	var b []byte
+	if r.Prefix != nil {
+		b = appendMessage(b, 1, r.Prefix.marshal())
+	}
+	for _, result := range r.Response {
+		m := appendMessage(nil, 2, result.Path.marshal())
+		m = appendVarint(m, 4, uint64(result.Op))
+		b = appendMessage(b, 2, m)
+	}
+	return appendVarint(b, 4, uint64(r.Timestamp))
+}
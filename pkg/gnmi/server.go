@@ -0,0 +1,335 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package gnmi serves the telemetry and the configured objects of the EVPN server over gNMI
+package gnmi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/evpn"
+	"github.com/opiproject/opi-evpn-bridge/pkg/openconfig"
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils"
+)
+
+const (
+	// version is the version of the gNMI specification implemented
+	version = "0.10.0"
+	// defaultPollInterval is how often the state of the ON_CHANGE subscriptions is compared
+	defaultPollInterval = time.Second
+	// minSampleInterval is the shortest sample interval, the default one of SAMPLE subscriptions
+	minSampleInterval = time.Second
+)
+
+// models are the data models of the paths served
+var models = []ModelData{
+	{Name: "openconfig-interfaces", Organization: "OpenConfig working group"},
+	{Name: "openconfig-network-instance", Organization: "OpenConfig working group"},
+	{Name: "opi-evpn-bridge", Organization: "Open Programmable Infrastructure"},
+}
+
+// Server serves the gnmi.gNMI service from the state of the EVPN server:
+//
+//	/interfaces/interface[name=*]/state/oper-status and .../state/counters/in-octets...
+//	/network-instances/network-instance[name=*]/protocols/protocol[identifier=BGP][name=bgp]/bgp/neighbors/neighbor[neighbor-address=*]/state/session-state
+//	/evpn/logical-bridges/logical-bridge[name=*]/state/oper-status and .../config, likewise
+//	for bridge-ports, vrfs and svis
+//
+// The tenants only see their objects. Set configures the OpenConfig network instances and
+// interfaces, translated to the objects of the EVPN server
+type Server struct {
+	opi    *evpn.Server
+	config *openconfig.Applier
+	// PollInterval is how often the state of the ON_CHANGE subscriptions is compared
+	PollInterval time.Duration
+}
+
+// NewServer creates the gNMI server of the EVPN server
+func NewServer(opi *evpn.Server) *Server {
+	return &Server{opi: opi, config: openconfig.NewApplier(opi), PollInterval: defaultPollInterval}
+}
+
+// ServerOptions are the options of the gRPC server the gNMI service is registered on, its
+// messages having their own codec
+func ServerOptions() []grpc.ServerOption {
+	return []grpc.ServerOption{grpc.ForceServerCodec(codec{})}
+}
+
+// Register registers the gNMI service on a gRPC server created with ServerOptions
+func (s *Server) Register(g *grpc.Server) {
+	g.RegisterService(&serviceDesc, s)
+}
+
+// gnmiServer is the service implemented by Server
+type gnmiServer interface {
+	Capabilities(context.Context, *CapabilityRequest) (*CapabilityResponse, error)
+	Get(context.Context, *GetRequest) (*GetResponse, error)
+	Set(context.Context, *SetRequest) (*SetResponse, error)
+	Subscribe(grpc.ServerStream) error
+}
+
+var serviceDesc = grpc.ServiceDesc{
+	ServiceName: "gnmi.gNMI",
+	HandlerType: (*gnmiServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Capabilities",
+			Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, _ grpc.UnaryServerInterceptor) (interface{}, error) {
+				in := &CapabilityRequest{}
+				if err := dec(in); err != nil {
+					return nil, err
+				}
+				return srv.(gnmiServer).Capabilities(ctx, in)
+			},
+		},
+		{
+			MethodName: "Get",
+			Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, _ grpc.UnaryServerInterceptor) (interface{}, error) {
+				in := &GetRequest{}
+				if err := dec(in); err != nil {
+					return nil, err
+				}
+				return srv.(gnmiServer).Get(ctx, in)
+			},
+		},
+		{
+			MethodName: "Set",
+			Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, _ grpc.UnaryServerInterceptor) (interface{}, error) {
+				in := &SetRequest{}
+				if err := dec(in); err != nil {
+					return nil, err
+				}
+				return srv.(gnmiServer).Set(ctx, in)
+			},
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName: "Subscribe",
+			Handler: func(srv interface{}, stream grpc.ServerStream) error {
+				return srv.(gnmiServer).Subscribe(stream)
+			},
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "github.com/openconfig/gnmi/proto/gnmi/gnmi.proto",
+}
+
+// leaf is a value of the data tree
+type leaf struct {
+	path Path
+	val  interface{}
+}
+
+// elem returns a path element with its keys, given as name, value pairs
+func elem(name string, keys ...string) PathElem {
+	e := PathElem{Name: name}
+	for i := 0; i+1 < len(keys); i += 2 {
+		if e.Key == nil {
+			e.Key = map[string]string{}
+		}
+		e.Key[keys[i]] = keys[i+1]
+	}
+	return e
+}
+
+// pathString formats a path as /interfaces/interface[name=eth0]/state, the keys sorted
+func pathString(p Path) string {
+	var b strings.Builder
+	for _, e := range p.Elem {
+		b.WriteString("/" + e.Name)
+		keys := make([]string, 0, len(e.Key))
+		for key := range e.Key {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			fmt.Fprintf(&b, "[%s=%s]", key, e.Key[key])
+		}
+	}
+	if b.Len() == 0 {
+		return "/"
+	}
+	return b.String()
+}
+
+// join appends the elements of the path to those of the prefix
+func join(prefix *Path, p Path) Path {
+	if prefix == nil {
+		return p
+	}
+	elems := append(append([]PathElem{}, prefix.Elem...), p.Elem...)
+	return Path{Origin: prefix.Origin, Elem: elems, Target: prefix.Target}
+}
+
+// match reports whether the leaf is under the subscribed path, * matching any name or key
+// value and ... any remaining elements
+func match(sub Path, p Path) bool {
+	for i, e := range sub.Elem {
+		if e.Name == "..." {
+			return true
+		}
+		if i >= len(p.Elem) {
+			return false
+		}
+		if e.Name != "*" && e.Name != p.Elem[i].Name {
+			return false
+		}
+		for key, value := range e.Key {
+			if value != "*" && p.Elem[i].Key[key] != value {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// roots returns the top elements of the paths, all of them for a wildcard or empty path
+func roots(paths []Path) map[string]bool {
+	result := map[string]bool{}
+	for _, p := range paths {
+		if len(p.Elem) == 0 || p.Elem[0].Name == "*" || p.Elem[0].Name == "..." {
+			return map[string]bool{"interfaces": true, "network-instances": true, "evpn": true}
+		}
+		result[p.Elem[0].Name] = true
+	}
+	return result
+}
+
+// collect returns the leaves under the roots, keyed by their path, the interfaces and the BGP
+// state being only seen without a tenant
+func (s *Server) collect(ctx context.Context, roots map[string]bool) (map[string]leaf, error) {
+	leaves := map[string]leaf{}
+	add := func(val interface{}, elems ...PathElem) {
+		p := Path{Elem: elems}
+		leaves[pathString(p)] = leaf{path: p, val: val}
+	}
+	admin := utils.TenantFromContext(ctx) == ""
+	if roots["interfaces"] && admin {
+		interfaces, err := s.opi.InterfaceStates(ctx)
+		if err != nil {
+			return nil, status.Errorf(codes.Unavailable, "unable to read the interfaces: %v", err)
+		}
+		for name, state := range interfaces {
+			operStatus := "DOWN"
+			if state.Up {
+				operStatus = "UP"
+			}
+			add(operStatus, elem("interfaces"), elem("interface", "name", name), elem("state"), elem("oper-status"))
+			for counter, value := range state.Counters {
+				add(value, elem("interfaces"), elem("interface", "name", name), elem("state"), elem("counters"), elem(counter))
+			}
+		}
+	}
+	if roots["network-instances"] && admin {
+		peers, err := s.opi.BgpPeerStates(ctx)
+		if err != nil {
+			return nil, status.Errorf(codes.Unavailable, "unable to read the BGP state: %v", err)
+		}
+		for key, state := range peers {
+			vrf, peer, _ := strings.Cut(key, "|")
+			add(strings.ToUpper(state), elem("network-instances"), elem("network-instance", "name", vrf),
+				elem("protocols"), elem("protocol", "identifier", "BGP", "name", "bgp"), elem("bgp"),
+				elem("neighbors"), elem("neighbor", "neighbor-address", peer), elem("state"), elem("session-state"))
+		}
+	}
+	if roots["evpn"] {
+		for name, state := range s.opi.ObjectStates(ctx) {
+			container := elem(state.Kind + "s")
+			item := elem(state.Kind, "name", name)
+			add(state.OperStatus, elem("evpn"), container, item, elem("state"), elem("oper-status"))
+			// protojson randomizes its spaces, the ON_CHANGE subscriptions compare the values
+			spec, err := protojson.Marshal(state.Spec)
+			var compact bytes.Buffer
+			if err == nil {
+				err = json.Compact(&compact, spec)
+			}
+			if err != nil {
+				return nil, status.Errorf(codes.Internal, "unable to encode %s: %v", name, err)
+			}
+			add(JSON(compact.String()), elem("evpn"), container, item, elem("config"))
+		}
+	}
+	return leaves, nil
+}
+
+// updates returns the leaves under the path, sorted by path
+func updates(leaves map[string]leaf, p Path) []Update {
+	var result []Update
+	for _, key := range sortedKeys(leaves) {
+		if match(p, leaves[key].path) {
+			result = append(result, Update{Path: leaves[key].path, Val: leaves[key].val})
+		}
+	}
+	return result
+}
+
+func sortedKeys(leaves map[string]leaf) []string {
+	keys := make([]string, 0, len(leaves))
+	for key := range leaves {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// checkEncoding fails with Unimplemented for the encodings of the values not supported
+func checkEncoding(encoding Encoding) error {
+	switch encoding {
+	case EncodingJSON, EncodingJSONIETF, EncodingProto:
+		return nil
+	}
+	err := status.Errorf(codes.Unimplemented, "unsupported encoding %d, expected JSON, JSON_IETF or PROTO", encoding)
+	return err
+}
+
+// Capabilities returns the models, the encodings and the version of gNMI supported
+func (s *Server) Capabilities(_ context.Context, _ *CapabilityRequest) (*CapabilityResponse, error) {
+	return &CapabilityResponse{
+		SupportedModels:    models,
+		SupportedEncodings: []Encoding{EncodingJSON, EncodingJSONIETF, EncodingProto},
+		GNMIVersion:        version,
+	}, nil
+}
+
+// Get returns the values under the paths, a notification for each, failing with NotFound when
+// a path has none
+func (s *Server) Get(ctx context.Context, in *GetRequest) (*GetResponse, error) {
+	if err := checkEncoding(in.Encoding); err != nil {
+		return nil, err
+	}
+	paths := make([]Path, 0, len(in.Path))
+	for _, p := range in.Path {
+		paths = append(paths, join(in.Prefix, p))
+	}
+	if len(paths) == 0 {
+		paths = append(paths, join(in.Prefix, Path{}))
+	}
+	leaves, err := s.collect(ctx, roots(paths))
+	if err != nil {
+		return nil, err
+	}
+	response := &GetResponse{}
+	now := time.Now().UnixNano()
+	for _, p := range paths {
+		found := updates(leaves, p)
+		if len(found) == 0 {
+			err := status.Errorf(codes.NotFound, "unable to find key %s", pathString(p))
+			return nil, err
+		}
+		response.Notification = append(response.Notification, Notification{Timestamp: now, Update: found})
+	}
+	return response, nil
+}
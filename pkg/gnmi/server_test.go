@@ -0,0 +1,277 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package gnmi serves the telemetry and the configured objects of the EVPN server over gNMI
+package gnmi
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/philippgille/gokv/gomap"
+	"github.com/stretchr/testify/mock"
+	"github.com/vishvananda/netlink"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/evpn"
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils"
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils/mocks"
+)
+
+const bgpSummary = `{"default": {"ipv4Unicast": {"peers": {"10.0.0.2": {"state": "Established"}}}}}`
+
+func testInterface(name string, up bool, rxBytes uint64) netlink.Link {
+	attrs := netlink.LinkAttrs{Name: name, OperState: netlink.OperDown, Statistics: &netlink.LinkStatistics{RxBytes: rxBytes}}
+	if up {
+		attrs.OperState = netlink.OperUp
+	}
+	return &netlink.Device{LinkAttrs: attrs}
+}
+
+func newTestServer(t *testing.T) (*Server, *mocks.Netlink, *mocks.Frr) {
+	mockNetlink := mocks.NewNetlink(t)
+	mockFrr := mocks.NewFrr(t)
+	opi := evpn.NewServerWithArgs(mockNetlink, mockFrr, gomap.NewStore(gomap.DefaultOptions))
+	opi.Bridges["//network.opiproject.org/bridges/blue"] = &pb.LogicalBridge{
+		Name:   "//network.opiproject.org/bridges/blue",
+		Spec:   &pb.LogicalBridgeSpec{VlanId: 10},
+		Status: &pb.LogicalBridgeStatus{},
+	}
+	return NewServer(opi), mockNetlink, mockFrr
+}
+
+func path(elems ...PathElem) Path {
+	return Path{Elem: elems}
+}
+
+func Test_Get(t *testing.T) {
+	tests := map[string]struct {
+		tenant  string
+		prefix  *Path
+		paths   []Path
+		on      func(mockNetlink *mocks.Netlink, mockFrr *mocks.Frr)
+		updates []Update
+		errCode codes.Code
+	}{
+		"interface counter": {
+			paths: []Path{path(elem("interfaces"), elem("interface", "name", "eth0"), elem("state"), elem("counters"), elem("in-octets"))},
+			on: func(mockNetlink *mocks.Netlink, _ *mocks.Frr) {
+				mockNetlink.EXPECT().LinkList(mock.Anything).Return([]netlink.Link{testInterface("eth0", true, 42), testInterface("eth1", false, 0)}, nil).Once()
+			},
+			updates: []Update{
+				{Path: path(elem("interfaces"), elem("interface", "name", "eth0"), elem("state"), elem("counters"), elem("in-octets")), Val: uint64(42)},
+			},
+		},
+		"oper-status of all the interfaces": {
+			paths: []Path{path(elem("interfaces"), elem("interface", "name", "*"), elem("state"), elem("oper-status"))},
+			on: func(mockNetlink *mocks.Netlink, _ *mocks.Frr) {
+				mockNetlink.EXPECT().LinkList(mock.Anything).Return([]netlink.Link{testInterface("eth0", true, 42), testInterface("eth1", false, 0)}, nil).Once()
+			},
+			updates: []Update{
+				{Path: path(elem("interfaces"), elem("interface", "name", "eth0"), elem("state"), elem("oper-status")), Val: "UP"},
+				{Path: path(elem("interfaces"), elem("interface", "name", "eth1"), elem("state"), elem("oper-status")), Val: "DOWN"},
+			},
+		},
+		"BGP session state": {
+			paths: []Path{path(elem("network-instances"), elem("..."))},
+			on: func(_ *mocks.Netlink, mockFrr *mocks.Frr) {
+				mockFrr.EXPECT().FrrBgpCmd(mock.Anything, "show bgp vrf all summary json").Return(bgpSummary, nil).Once()
+			},
+			updates: []Update{
+				{Path: path(elem("network-instances"), elem("network-instance", "name", "default"), elem("protocols"),
+					elem("protocol", "identifier", "BGP", "name", "bgp"), elem("bgp"), elem("neighbors"),
+					elem("neighbor", "neighbor-address", "10.0.0.2"), elem("state"), elem("session-state")), Val: "ESTABLISHED"},
+			},
+		},
+		"object under a prefix": {
+			prefix: &Path{Elem: []PathElem{elem("evpn"), elem("logical-bridges")}},
+			paths:  []Path{path(elem("logical-bridge", "name", "*"))},
+			updates: []Update{
+				{Path: path(elem("evpn"), elem("logical-bridges"), elem("logical-bridge", "name", "//network.opiproject.org/bridges/blue"), elem("config")), Val: JSON(`{"vlanId":10}`)},
+				{Path: path(elem("evpn"), elem("logical-bridges"), elem("logical-bridge", "name", "//network.opiproject.org/bridges/blue"), elem("state"), elem("oper-status")), Val: "LB_OPER_STATUS_UP"},
+			},
+		},
+		"unknown path": {
+			paths:   []Path{path(elem("evpn"), elem("vrfs"))},
+			errCode: codes.NotFound,
+		},
+		"interfaces of a tenant": {
+			tenant:  "blue",
+			paths:   []Path{path(elem("interfaces"))},
+			errCode: codes.NotFound,
+		},
+		"netlink failure": {
+			paths: []Path{path(elem("interfaces"))},
+			on: func(mockNetlink *mocks.Netlink, _ *mocks.Frr) {
+				mockNetlink.EXPECT().LinkList(mock.Anything).Return(nil, io.ErrUnexpectedEOF).Once()
+			},
+			errCode: codes.Unavailable,
+		},
+	}
+	for testName, tt := range tests {
+		t.Run(testName, func(t *testing.T) {
+			s, mockNetlink, mockFrr := newTestServer(t)
+			if tt.on != nil {
+				tt.on(mockNetlink, mockFrr)
+			}
+			ctx := context.Background()
+			if tt.tenant != "" {
+				ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(utils.TenantMetadataKey, tt.tenant))
+			}
+			response, err := s.Get(ctx, &GetRequest{Prefix: tt.prefix, Path: tt.paths})
+			if status.Code(err) != tt.errCode {
+				t.Fatal("error: expected", tt.errCode, "received", err)
+			}
+			if err != nil {
+				return
+			}
+			if len(response.Notification) != 1 || !reflect.DeepEqual(response.Notification[0].Update, tt.updates) {
+				t.Error("updates: expected", tt.updates, "received", response.Notification)
+			}
+		})
+	}
+}
+
+func Test_GetEncoding(t *testing.T) {
+	s, _, _ := newTestServer(t)
+	_, err := s.Get(context.Background(), &GetRequest{Encoding: EncodingASCII})
+	if status.Code(err) != codes.Unimplemented {
+		t.Error("error: expected", codes.Unimplemented, "received", err)
+	}
+}
+
+// testStream is a Subscribe stream receiving the requests and recording the responses
+type testStream struct {
+	grpc.ServerStream
+	ctx       context.Context
+	requests  chan *SubscribeRequest
+	responses chan *SubscribeResponse
+}
+
+func (s *testStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *testStream) SendMsg(m interface{}) error {
+	s.responses <- m.(*SubscribeResponse)
+	return nil
+}
+
+func (s *testStream) RecvMsg(m interface{}) error {
+	select {
+	case in, ok := <-s.requests:
+		if !ok {
+			return io.EOF
+		}
+		*m.(*SubscribeRequest) = *in
+		return nil
+	case <-s.ctx.Done():
+		return s.ctx.Err()
+	}
+}
+
+func (s *testStream) next(t *testing.T) *SubscribeResponse {
+	select {
+	case response := <-s.responses:
+		return response
+	case <-time.After(5 * time.Second):
+		t.Fatal("response: expected one, received none")
+		return nil
+	}
+}
+
+func Test_SubscribeOnce(t *testing.T) {
+	s, mockNetlink, _ := newTestServer(t)
+	mockNetlink.EXPECT().LinkList(mock.Anything).Return([]netlink.Link{testInterface("eth0", true, 42)}, nil).Once()
+	stream := &testStream{ctx: context.Background(), requests: make(chan *SubscribeRequest, 1), responses: make(chan *SubscribeResponse, 10)}
+	stream.requests <- &SubscribeRequest{Subscribe: &SubscriptionList{
+		Mode:         ListModeOnce,
+		Subscription: []Subscription{{Path: path(elem("interfaces"), elem("interface"), elem("state"), elem("oper-status"))}},
+	}}
+	if err := s.Subscribe(stream); err != nil {
+		t.Fatal("error: expected", nil, "received", err)
+	}
+	if response := stream.next(t); response.Update == nil || len(response.Update.Update) != 1 || response.Update.Update[0].Val != "UP" {
+		t.Error("update: expected eth0 UP, received", response)
+	}
+	if response := stream.next(t); !response.SyncResponse {
+		t.Error("sync response: expected", true, "received", response)
+	}
+}
+
+func Test_SubscribeOnChange(t *testing.T) {
+	s, mockNetlink, _ := newTestServer(t)
+	s.PollInterval = 10 * time.Millisecond
+	mockNetlink.EXPECT().LinkList(mock.Anything).Return([]netlink.Link{testInterface("eth0", true, 1), testInterface("eth1", true, 0)}, nil).Once()
+	mockNetlink.EXPECT().LinkList(mock.Anything).Return([]netlink.Link{testInterface("eth0", false, 2)}, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream := &testStream{ctx: ctx, requests: make(chan *SubscribeRequest, 1), responses: make(chan *SubscribeResponse, 10)}
+	stream.requests <- &SubscribeRequest{Subscribe: &SubscriptionList{
+		Mode:         ListModeStream,
+		UpdatesOnly:  true,
+		Subscription: []Subscription{{Path: path(elem("interfaces"), elem("interface"), elem("state"), elem("oper-status")), Mode: ModeOnChange}},
+	}}
+	done := make(chan error)
+	go func() { done <- s.Subscribe(stream) }()
+
+	if response := stream.next(t); !response.SyncResponse {
+		t.Error("sync response: expected", true, "received", response)
+	}
+	response := stream.next(t)
+	expected := &Notification{
+		Update: []Update{{Path: path(elem("interfaces"), elem("interface", "name", "eth0"), elem("state"), elem("oper-status")), Val: "DOWN"}},
+		Delete: []Path{path(elem("interfaces"), elem("interface", "name", "eth1"), elem("state"), elem("oper-status"))},
+	}
+	if response.Update == nil || !reflect.DeepEqual(response.Update.Update, expected.Update) || !reflect.DeepEqual(response.Update.Delete, expected.Delete) {
+		t.Error("notification: expected", expected, "received", response.Update)
+	}
+	// nothing changes anymore
+	select {
+	case response := <-stream.responses:
+		t.Error("response: expected none, received", response)
+	case <-time.After(50 * time.Millisecond):
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Error("error: expected", nil, "received", err)
+	}
+}
+
+func Test_SubscribeInvalid(t *testing.T) {
+	s, _, _ := newTestServer(t)
+	stream := &testStream{ctx: context.Background(), requests: make(chan *SubscribeRequest, 1)}
+	stream.requests <- &SubscribeRequest{Poll: true}
+	if err := s.Subscribe(stream); status.Code(err) != codes.InvalidArgument {
+		t.Error("error: expected", codes.InvalidArgument, "received", err)
+	}
+}
+
+func Test_Codec(t *testing.T) {
+	p := path(elem("interfaces"), elem("interface", "name", "eth0"), elem("state"))
+	decoded := Path{}
+	if err := decoded.unmarshal(p.marshal()); err != nil || !reflect.DeepEqual(decoded, p) {
+		t.Error("path: expected", p, "received", decoded, err)
+	}
+	data, err := codec{}.Marshal(&SubscribeResponse{SyncResponse: true})
+	if err != nil || !bytes.Equal(data, []byte{0x18, 0x01}) {
+		t.Error("sync response: expected", []byte{0x18, 0x01}, "received", data, err)
+	}
+	// field 1 of a TypedValue is string_val, 3 is uint_val
+	if data := marshalTypedValue(uint64(5)); !bytes.Equal(data, []byte{0x18, 0x05}) {
+		t.Error("typed value: expected", []byte{0x18, 0x05}, "received", data)
+	}
+	if err := (codec{}).Unmarshal(nil, &struct{}{}); err == nil {
+		t.Error("error: expected a non gNMI message to fail")
+	}
+}
@@ -0,0 +1,180 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package gnmi serves the telemetry and the configured objects of the EVPN server over gNMI
+package gnmi
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/openconfig"
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils"
+)
+
+// Set changes the OpenConfig configuration of the device and applies it, translated to
+// LogicalBridges, BridgePorts, Vrfs and Svis. The paths are /, /network-instances,
+// /interfaces and their entries, e.g. /interfaces/interface[name=irb10], their values being
+// JSON or JSON_IETF. The deletes, replaces and updates are applied at once, an update of a
+// container replacing the entries it lists
+func (s *Server) Set(ctx context.Context, in *SetRequest) (*SetResponse, error) {
+	if tenant := utils.TenantFromContext(ctx); tenant != "" {
+		return nil, status.Errorf(codes.PermissionDenied, "tenant %s cannot configure the device", tenant)
+	}
+	device, err := s.config.Device()
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	response := &SetResponse{Prefix: in.Prefix}
+	for _, p := range in.Delete {
+		if err := deletePath(device, join(in.Prefix, p)); err != nil {
+			return nil, err
+		}
+		response.Response = append(response.Response, UpdateResult{Path: p, Op: OperationDelete})
+	}
+	for _, u := range in.Replace {
+		if err := setPath(device, join(in.Prefix, u.Path), u.Val, true); err != nil {
+			return nil, err
+		}
+		response.Response = append(response.Response, UpdateResult{Path: u.Path, Op: OperationReplace})
+	}
+	for _, u := range in.Update {
+		if err := setPath(device, join(in.Prefix, u.Path), u.Val, false); err != nil {
+			return nil, err
+		}
+		response.Response = append(response.Response, UpdateResult{Path: u.Path, Op: OperationUpdate})
+	}
+	if err := s.config.Apply(ctx, device); err != nil {
+		return nil, err
+	}
+	response.Timestamp = time.Now().UnixNano()
+	return response, nil
+}
+
+// names returns the names of the elements of the path without their module, e.g.
+// openconfig-interfaces:interfaces is interfaces
+func names(p Path) string {
+	result := make([]string, 0, len(p.Elem))
+	for _, e := range p.Elem {
+		if _, local, ok := strings.Cut(e.Name, ":"); ok {
+			result = append(result, local)
+		} else {
+			result = append(result, e.Name)
+		}
+	}
+	return "/" + strings.Join(result, "/")
+}
+
+// unsupportedPath is the error of a path Set does not configure
+func unsupportedPath(p Path) error {
+	err := status.Errorf(codes.Unimplemented, "unsupported path %s, expected /, /network-instances, /interfaces or one of their entries", pathString(p))
+	return err
+}
+
+// deletePath removes the subtree of the path from the configuration, a missing entry being
+// already deleted
+func deletePath(d *openconfig.Device, p Path) error {
+	switch names(p) {
+	case "/":
+		*d = openconfig.Device{}
+	case "/network-instances":
+		d.NetworkInstances = nil
+	case "/interfaces":
+		d.Interfaces = nil
+	case "/network-instances/network-instance":
+		d.DeleteNetworkInstance(p.Elem[1].Key["name"])
+	case "/interfaces/interface":
+		d.DeleteInterface(p.Elem[1].Key["name"])
+	default:
+		return unsupportedPath(p)
+	}
+	return nil
+}
+
+// setPath replaces or merges the value of the path into the configuration
+func setPath(d *openconfig.Device, p Path, val interface{}, replace bool) error {
+	value, ok := val.(JSON)
+	if !ok {
+		return status.Errorf(codes.InvalidArgument, "unsupported value of %s, expected JSON or JSON_IETF", pathString(p))
+	}
+	invalid := func(err error) error {
+		return status.Errorf(codes.InvalidArgument, "invalid value of %s: %v", pathString(p), err)
+	}
+	switch names(p) {
+	case "/":
+		device := &openconfig.Device{}
+		if err := openconfig.Unmarshal([]byte(value), device); err != nil {
+			return invalid(err)
+		}
+		if replace {
+			*d = *device
+			return nil
+		}
+		merge(d, device.NetworkInstances, device.Interfaces)
+	case "/network-instances":
+		instances := &openconfig.NetworkInstances{}
+		if err := openconfig.Unmarshal([]byte(value), instances); err != nil {
+			return invalid(err)
+		}
+		if replace {
+			d.NetworkInstances = instances
+			return nil
+		}
+		merge(d, instances, nil)
+	case "/interfaces":
+		interfaces := &openconfig.Interfaces{}
+		if err := openconfig.Unmarshal([]byte(value), interfaces); err != nil {
+			return invalid(err)
+		}
+		if replace {
+			d.Interfaces = interfaces
+			return nil
+		}
+		merge(d, nil, interfaces)
+	case "/network-instances/network-instance":
+		ni := &openconfig.NetworkInstance{}
+		if err := openconfig.Unmarshal([]byte(value), ni); err != nil {
+			return invalid(err)
+		}
+		if ni.Name == "" {
+			ni.Name = p.Elem[1].Key["name"]
+		}
+		if ni.Name != p.Elem[1].Key["name"] {
+			return status.Errorf(codes.InvalidArgument, "the name %s of the value does not match the path %s", ni.Name, pathString(p))
+		}
+		d.SetNetworkInstance(ni)
+	case "/interfaces/interface":
+		intf := &openconfig.Interface{}
+		if err := openconfig.Unmarshal([]byte(value), intf); err != nil {
+			return invalid(err)
+		}
+		if intf.Name == "" {
+			intf.Name = p.Elem[1].Key["name"]
+		}
+		if intf.Name != p.Elem[1].Key["name"] {
+			return status.Errorf(codes.InvalidArgument, "the name %s of the value does not match the path %s", intf.Name, pathString(p))
+		}
+		d.SetInterface(intf)
+	default:
+		return unsupportedPath(p)
+	}
+	return nil
+}
+
+// merge adds or replaces the network instances and the interfaces listed
+func merge(d *openconfig.Device, instances *openconfig.NetworkInstances, interfaces *openconfig.Interfaces) {
+	if instances != nil {
+		for _, ni := range instances.NetworkInstance {
+			d.SetNetworkInstance(ni)
+		}
+	}
+	if interfaces != nil {
+		for _, intf := range interfaces.Interface {
+			d.SetInterface(intf)
+		}
+	}
+}
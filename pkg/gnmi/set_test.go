@@ -0,0 +1,92 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package gnmi serves the telemetry and the configured objects of the EVPN server over gNMI
+package gnmi
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils"
+)
+
+func Test_Set(t *testing.T) {
+	instance := path(elem("network-instances"), elem("network-instance", "name", "default"))
+	tests := map[string]struct {
+		tenant  string
+		in      *SetRequest
+		results []UpdateResult
+		errCode codes.Code
+	}{
+		"delete everything": {
+			in:      &SetRequest{Delete: []Path{path()}},
+			results: []UpdateResult{{Path: path(), Op: OperationDelete}},
+		},
+		"instance ignored by the translation": {
+			in: &SetRequest{Update: []Update{
+				{Path: instance, Val: JSON(`{"config": {"type": "openconfig-network-instance-types:DEFAULT_INSTANCE"}}`)},
+			}},
+			results: []UpdateResult{{Path: instance, Op: OperationUpdate}},
+		},
+		"tenant": {
+			tenant:  "tenant-a",
+			in:      &SetRequest{Delete: []Path{path()}},
+			errCode: codes.PermissionDenied,
+		},
+		"unsupported path": {
+			in:      &SetRequest{Replace: []Update{{Path: path(elem("evpn"), elem("vrfs")), Val: JSON(`{}`)}}},
+			errCode: codes.Unimplemented,
+		},
+		"not a JSON value": {
+			in:      &SetRequest{Update: []Update{{Path: instance, Val: "default"}}},
+			errCode: codes.InvalidArgument,
+		},
+		"name not matching the path": {
+			in:      &SetRequest{Update: []Update{{Path: instance, Val: JSON(`{"name": "blue"}`)}}},
+			errCode: codes.InvalidArgument,
+		},
+		"MAC_VRF without vlan": {
+			in: &SetRequest{Replace: []Update{
+				{Path: path(elem("network-instances")), Val: JSON(`{"network-instance": [{"name": "vlan10", "config": {"type": "MAC_VRF"}}]}`)},
+			}},
+			errCode: codes.InvalidArgument,
+		},
+	}
+	for testName, tt := range tests {
+		t.Run(testName, func(t *testing.T) {
+			s, _, _ := newTestServer(t)
+			ctx := context.Background()
+			if tt.tenant != "" {
+				ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(utils.TenantMetadataKey, tt.tenant))
+			}
+			response, err := s.Set(ctx, tt.in)
+			if status.Code(err) != tt.errCode {
+				t.Fatal("error: expected", tt.errCode, "received", err)
+			}
+			if err != nil {
+				return
+			}
+			if !reflect.DeepEqual(response.Response, tt.results) {
+				t.Error("results: expected", tt.results, "received", response.Response)
+			}
+		})
+	}
+}
+
+func Test_SetRequestCodec(t *testing.T) {
+	update := Update{Path: path(elem("interfaces")), Val: JSON(`{"interface": []}`)}
+	data := appendMessage(nil, 4, update.marshal())
+	in := &SetRequest{}
+	if err := (codec{}).Unmarshal(data, in); err != nil {
+		t.Fatal("error: expected nil received", err)
+	}
+	if len(in.Update) != 1 || !reflect.DeepEqual(in.Update[0], update) {
+		t.Error("update: expected", update, "received", in.Update)
+	}
+}
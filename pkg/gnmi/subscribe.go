@@ -0,0 +1,173 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package gnmi serves the telemetry and the configured objects of the EVPN server over gNMI
+package gnmi
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Subscribe sends the values of the subscribed paths once, on every poll, or as a stream:
+// SAMPLE subscriptions send all their values every sample interval, 1s at least, and ON_CHANGE
+// ones, TARGET_DEFINED included, the changed and deleted values only, their state being
+// compared every PollInterval. The initial values are followed by a sync response
+func (s *Server) Subscribe(stream grpc.ServerStream) error {
+	ctx := stream.Context()
+	in := &SubscribeRequest{}
+	if err := stream.RecvMsg(in); err != nil {
+		return err
+	}
+	list := in.Subscribe
+	if list == nil || len(list.Subscription) == 0 {
+		err := status.Error(codes.InvalidArgument, "the first request has to be a subscription list")
+		return err
+	}
+	if err := checkEncoding(list.Encoding); err != nil {
+		return err
+	}
+	paths := make([]Path, len(list.Subscription))
+	for i, sub := range list.Subscription {
+		paths[i] = join(list.Prefix, sub.Path)
+	}
+	var mu sync.Mutex
+	send := func(response *SubscribeResponse) error {
+		mu.Lock()
+		defer mu.Unlock()
+		return stream.SendMsg(response)
+	}
+	// last are the values last sent for each subscription, keyed by path
+	last := make([]map[string]leaf, len(paths))
+	sendAll := func(updatesOnly bool) error {
+		leaves, err := s.collect(ctx, roots(paths))
+		if err != nil {
+			return err
+		}
+		n := Notification{Timestamp: time.Now().UnixNano()}
+		for i, p := range paths {
+			last[i] = map[string]leaf{}
+			found := updates(leaves, p)
+			for _, update := range found {
+				last[i][pathString(update.Path)] = leaf{path: update.Path, val: update.Val}
+			}
+			n.Update = append(n.Update, found...)
+		}
+		if len(n.Update) > 0 && !updatesOnly {
+			if err := send(&SubscribeResponse{Update: &n}); err != nil {
+				return err
+			}
+		}
+		return send(&SubscribeResponse{SyncResponse: true})
+	}
+
+	switch list.Mode {
+	case ListModeOnce:
+		return sendAll(list.UpdatesOnly)
+	case ListModePoll:
+		if err := sendAll(list.UpdatesOnly); err != nil {
+			return err
+		}
+		for {
+			poll := &SubscribeRequest{}
+			if err := stream.RecvMsg(poll); err != nil {
+				if errors.Is(err, io.EOF) {
+					return nil
+				}
+				return err
+			}
+			if !poll.Poll {
+				err := status.Error(codes.InvalidArgument, "only polls can follow the subscription list of a POLL subscription")
+				return err
+			}
+			if err := sendAll(false); err != nil {
+				return err
+			}
+		}
+	case ListModeStream:
+	default:
+		err := status.Errorf(codes.InvalidArgument, "unknown subscription list mode %d", list.Mode)
+		return err
+	}
+
+	if err := sendAll(list.UpdatesOnly); err != nil {
+		return err
+	}
+	// the client has nothing more to send, but it may half-close its side of the stream
+	go func() {
+		for {
+			if err := stream.RecvMsg(&SubscribeRequest{}); err != nil {
+				return
+			}
+		}
+	}()
+	errs := make(chan error, len(paths))
+	for i, sub := range list.Subscription {
+		go func(p Path, sub Subscription, last map[string]leaf) {
+			errs <- s.stream(ctx, p, sub, last, send)
+		}(paths[i], sub, last[i])
+	}
+	return <-errs
+}
+
+// stream sends the updates of a STREAM subscription until ctx is done
+func (s *Server) stream(ctx context.Context, p Path, sub Subscription, last map[string]leaf, send func(*SubscribeResponse) error) error {
+	interval := s.PollInterval
+	if sub.Mode == ModeSample {
+		interval = time.Duration(sub.SampleInterval)
+		if interval < minSampleInterval {
+			interval = minSampleInterval
+		}
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			leaves, err := s.collect(ctx, roots([]Path{p}))
+			if err != nil {
+				log.Printf("Failed to collect %s: %v", pathString(p), err)
+				continue
+			}
+			n := changes(leaves, p, last, sub.Mode == ModeSample && !sub.SuppressRedundant)
+			if len(n.Update) == 0 && len(n.Delete) == 0 {
+				continue
+			}
+			if err := send(&SubscribeResponse{Update: n}); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// changes returns the values under the path that changed since last, all of them with all,
+// and the deleted ones, last being updated
+func changes(leaves map[string]leaf, p Path, last map[string]leaf, all bool) *Notification {
+	n := &Notification{Timestamp: time.Now().UnixNano()}
+	current := map[string]bool{}
+	for _, update := range updates(leaves, p) {
+		key := pathString(update.Path)
+		current[key] = true
+		if previous, ok := last[key]; all || !ok || previous.val != update.Val {
+			n.Update = append(n.Update, update)
+		}
+		last[key] = leaf{path: update.Path, val: update.Val}
+	}
+	for _, key := range sortedKeys(last) {
+		if !current[key] {
+			n.Delete = append(n.Delete, last[key].path)
+			delete(last, key)
+		}
+	}
+	return n
+}
@@ -0,0 +1,123 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Intel Corporation, or its subsidiaries.
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package ipu offloads the EVPN forwarding to the Intel IPU (Mount Evans) P4 pipeline
+package ipu
+
+import (
+	"fmt"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+	"github.com/opiproject/opi-evpn-bridge/pkg/evpn"
+	"github.com/opiproject/opi-evpn-bridge/pkg/p4rt"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// tables and actions of the EVPN P4 program loaded on the IPU, resolved to IDs through its P4Info
+const (
+	// vxlanEncapTable maps the vlan of a LogicalBridge to its vni, vlan_id -> set_vni(vni, src_ip)
+	vxlanEncapTable = "evpn.vxlan_encap_table"
+	// vxlanDecapTable maps the vni of a LogicalBridge back to its vlan, vni -> set_vlan(vlan_id)
+	vxlanDecapTable = "evpn.vxlan_decap_table"
+	// portVlanTable puts the traffic of a BridgePort in its vlans, port_mac, vlan_id -> access_port or trunk_port
+	portVlanTable = "evpn.port_vlan_table"
+	// l2FwdTable forwards to the BridgePort owning the destination, vlan_id, dst_mac -> fwd_to_port(port_mac)
+	l2FwdTable = "evpn.l2_fwd_table"
+	// vrfTable maps a Vrf to its L3 vni and router mac, vrf_id -> set_l3_vni(vni, rmac)
+	vrfTable = "evpn.vrf_table"
+	// sviTable routes the traffic sent to the gateway mac of a Svi in its Vrf, vlan_id, dst_mac -> route_in_vrf(vrf_id)
+	sviTable = "evpn.svi_table"
+)
+
+// program is the EVPN P4 program of the IPU, the Arm cores run the Linux dataplane
+// which stays the control plane view FRR learns from and the slow path
+type program struct{}
+
+// NewDataplane wraps the current dataplane of the server, to be set with server.SetDataplane
+func NewDataplane(server *evpn.Server, tables p4rt.Tables) *p4rt.Dataplane {
+	return p4rt.NewDataplane(server, program{}, tables)
+}
+
+// VrfEntries maps the L3 vni of the Vrf, a Vrf without vni is only routed locally
+func (program) VrfEntries(obj *pb.Vrf) []p4rt.Entry {
+	if obj.Spec.Vni == nil {
+		return nil
+	}
+	return []p4rt.Entry{{
+		Table:  vrfTable,
+		Match:  map[string][]byte{"vrf_id": p4rt.Uint32(obj.GetStatus().GetRoutingTable())},
+		Action: "set_l3_vni",
+		Params: map[string][]byte{"vni": p4rt.Uint32(*obj.Spec.Vni), "rmac": obj.GetStatus().GetRmac()},
+	}}
+}
+
+// LogicalBridgeEntries maps the vlan to the vni both ways, a LogicalBridge without vni
+// is not stretched over vxlan
+func (program) LogicalBridgeEntries(obj *pb.LogicalBridge) []p4rt.Entry {
+	if obj.Spec.Vni == nil {
+		return nil
+	}
+	vlan := p4rt.Uint16(obj.Spec.VlanId)
+	vni := p4rt.Uint32(*obj.Spec.Vni)
+	return []p4rt.Entry{{
+		Table:  vxlanEncapTable,
+		Match:  map[string][]byte{"vlan_id": vlan},
+		Action: "set_vni",
+		Params: map[string][]byte{"vni": vni, "src_ip": p4rt.Uint32(obj.Spec.VtepIpPrefix.GetAddr().GetV4Addr())},
+	}, {
+		Table:  vxlanDecapTable,
+		Match:  map[string][]byte{"vni": vni},
+		Action: "set_vlan",
+		Params: map[string][]byte{"vlan_id": vlan},
+	}}
+}
+
+// BridgePortEntries puts the port in the vlans and forwards to it, the IPU knows its ports
+// by mac, not by the interface name of the Arm cores
+func (program) BridgePortEntries(obj *pb.BridgePort, bridges []*pb.LogicalBridge) ([]p4rt.Entry, error) {
+	if len(obj.Spec.MacAddress) == 0 {
+		msg := fmt.Sprintf("BridgePort %s has no MacAddress, required to offload it", obj.Name)
+		return nil, status.Error(codes.InvalidArgument, msg)
+	}
+	action := "trunk_port"
+	switch obj.Spec.Ptype {
+	case pb.BridgePortType_ACCESS:
+		action = "access_port"
+	case pb.BridgePortType_TRUNK:
+	default:
+		msg := fmt.Sprintf("Only ACCESS or TRUNK supported and not (%d)", obj.Spec.Ptype)
+		return nil, status.Error(codes.InvalidArgument, msg)
+	}
+	var entries []p4rt.Entry
+	for _, bridgeObject := range bridges {
+		vlan := p4rt.Uint16(bridgeObject.Spec.VlanId)
+		entries = append(entries, p4rt.Entry{
+			Table:  portVlanTable,
+			Match:  map[string][]byte{"port_mac": obj.Spec.MacAddress, "vlan_id": vlan},
+			Action: action,
+		}, p4rt.Entry{
+			Table:  l2FwdTable,
+			Match:  map[string][]byte{"vlan_id": vlan, "dst_mac": obj.Spec.MacAddress},
+			Action: "fwd_to_port",
+			Params: map[string][]byte{"port_mac": obj.Spec.MacAddress},
+		})
+	}
+	return entries, nil
+}
+
+// SviEntries routes the gateway mac in the Vrf, without its own mac the Svi is only
+// reached through the kernel
+func (program) SviEntries(obj *pb.Svi, bridge *pb.LogicalBridge, vrf *pb.Vrf) []p4rt.Entry {
+	if len(obj.Spec.MacAddress) == 0 {
+		return nil
+	}
+	return []p4rt.Entry{{
+		Table:  sviTable,
+		Match:  map[string][]byte{"vlan_id": p4rt.Uint16(bridge.Spec.VlanId), "dst_mac": obj.Spec.MacAddress},
+		Action: "route_in_vrf",
+		Params: map[string][]byte{"vrf_id": p4rt.Uint32(vrf.GetStatus().GetRoutingTable())},
+	}}
+}
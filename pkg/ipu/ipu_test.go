@@ -0,0 +1,43 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Intel Corporation, or its subsidiaries.
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package ipu offloads the EVPN forwarding to the Intel IPU (Mount Evans) P4 pipeline
+package ipu
+
+import (
+	"testing"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+)
+
+func TestProgram_BridgePortEntries(t *testing.T) {
+	bridges := []*pb.LogicalBridge{{Spec: &pb.LogicalBridgeSpec{VlanId: 10}}, {Spec: &pb.LogicalBridgeSpec{VlanId: 20}}}
+	tests := map[string]struct {
+		spec    *pb.BridgePortSpec
+		entries int
+		wantErr bool
+	}{
+		"trunk": {
+			spec:    &pb.BridgePortSpec{Ptype: pb.BridgePortType_TRUNK, MacAddress: []byte{0xaa, 0xbb, 0xcc, 0, 0, 0x41}},
+			entries: 4,
+		},
+		"no mac": {
+			spec:    &pb.BridgePortSpec{Ptype: pb.BridgePortType_TRUNK},
+			wantErr: true,
+		},
+		"unknown type": {
+			spec:    &pb.BridgePortSpec{MacAddress: []byte{0xaa, 0xbb, 0xcc, 0, 0, 0x41}},
+			wantErr: true,
+		},
+	}
+
+	for testName, tt := range tests {
+		t.Run(testName, func(t *testing.T) {
+			entries, err := program{}.BridgePortEntries(&pb.BridgePort{Name: "//network.opiproject.org/ports/eth2", Spec: tt.spec}, bridges)
+			if (err != nil) != tt.wantErr || len(entries) != tt.entries {
+				t.Error("entries: expected", tt.entries, tt.wantErr, "received", entries, err)
+			}
+		})
+	}
+}
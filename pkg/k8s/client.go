@@ -0,0 +1,188 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package k8s drives the EVPN server from Kubernetes custom resources
+package k8s
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"time"
+)
+
+const (
+	// Group is the API group of the EVPN custom resources
+	Group = "evpn.opiproject.org"
+	// Version is the API version of the EVPN custom resources
+	Version = "v1alpha1"
+
+	serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+)
+
+// ObjectMeta is the subset of the Kubernetes object metadata the controller uses
+type ObjectMeta struct {
+	Name            string `json:"name"`
+	Namespace       string `json:"namespace,omitempty"`
+	ResourceVersion string `json:"resourceVersion,omitempty"`
+	Generation      int64  `json:"generation,omitempty"`
+}
+
+// ObjectStatus is written back to the custom resources once reconciled
+type ObjectStatus struct {
+	// State is Ready or Error
+	State              string          `json:"state"`
+	Message            string          `json:"message,omitempty"`
+	ObservedGeneration int64           `json:"observedGeneration,omitempty"`
+	Object             json.RawMessage `json:"object,omitempty"`
+}
+
+// Object is an EVPN custom resource, Spec holds the protojson encoded opi-api spec
+type Object struct {
+	APIVersion string          `json:"apiVersion,omitempty"`
+	Kind       string          `json:"kind,omitempty"`
+	Metadata   ObjectMeta      `json:"metadata"`
+	Spec       json.RawMessage `json:"spec,omitempty"`
+	Status     *ObjectStatus   `json:"status,omitempty"`
+}
+
+// ObjectList is the response of a list call
+type ObjectList struct {
+	Metadata struct {
+		ResourceVersion string `json:"resourceVersion"`
+	} `json:"metadata"`
+	Items []Object `json:"items"`
+}
+
+// WatchEvent is a single event of a watch stream, Type is ADDED, MODIFIED, DELETED, BOOKMARK or ERROR
+type WatchEvent struct {
+	Type   string `json:"type"`
+	Object Object `json:"object"`
+}
+
+// Client is a minimal client of the Kubernetes API limited to the EVPN custom resources
+type Client struct {
+	host  string
+	token string
+	http  *http.Client
+}
+
+// NewClient creates a client of the API server at host (e.g.: https://10.96.0.1:443)
+func NewClient(host string, token string, httpClient *http.Client) *Client {
+	return &Client{host: host, token: token, http: httpClient}
+}
+
+// NewInClusterClient creates a client from the service account mounted in the pod
+func NewInClusterClient() (*Client, error) {
+	host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+	if host == "" || port == "" {
+		return nil, fmt.Errorf("not running in a cluster, KUBERNETES_SERVICE_HOST and KUBERNETES_SERVICE_PORT are not set")
+	}
+	token, err := os.ReadFile(serviceAccountDir + "/token")
+	if err != nil {
+		return nil, err
+	}
+	ca, err := os.ReadFile(serviceAccountDir + "/ca.crt")
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return nil, fmt.Errorf("invalid service account CA")
+	}
+	transport := &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}}
+	return NewClient("https://"+net.JoinHostPort(host, port), string(bytes.TrimSpace(token)), &http.Client{Transport: transport}), nil
+}
+
+func (c *Client) resourcePath(namespace string, resource string) string {
+	return fmt.Sprintf("%s/apis/%s/%s/namespaces/%s/%s", c.host, Group, Version, namespace, resource)
+}
+
+func (c *Client) do(ctx context.Context, method string, url string, body interface{}) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return nil, fmt.Errorf("%s %s: %s: %s", method, url, resp.Status, bytes.TrimSpace(msg))
+	}
+	return resp, nil
+}
+
+// List lists the custom resources of the namespace
+func (c *Client) List(ctx context.Context, namespace string, resource string) (*ObjectList, error) {
+	resp, err := c.do(ctx, http.MethodGet, c.resourcePath(namespace, resource), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	list := &ObjectList{}
+	if err := json.NewDecoder(resp.Body).Decode(list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// Watch streams the changes made after resourceVersion to handle until the
+// server closes the stream, ctx is done or handle fails
+func (c *Client) Watch(ctx context.Context, namespace string, resource string, resourceVersion string, handle func(WatchEvent) error) error {
+	url := fmt.Sprintf("%s?watch=1&allowWatchBookmarks=true&resourceVersion=%s", c.resourcePath(namespace, resource), resourceVersion)
+	resp, err := c.do(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		ev := WatchEvent{}
+		if err := decoder.Decode(&ev); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		if err := handle(ev); err != nil {
+			return err
+		}
+	}
+}
+
+// UpdateStatus replaces the status subresource of the custom resource
+func (c *Client) UpdateStatus(ctx context.Context, namespace string, resource string, obj *Object) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	url := fmt.Sprintf("%s/%s/status", c.resourcePath(namespace, resource), obj.Metadata.Name)
+	resp, err := c.do(ctx, http.MethodPut, url, obj)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
@@ -0,0 +1,261 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package k8s drives the EVPN server from Kubernetes custom resources
+package k8s
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/evpn"
+)
+
+const (
+	// StateReady is the status state of a custom resource programmed into the dataplane
+	StateReady = "Ready"
+	// StateError is the status state of a custom resource the server rejected or failed to program
+	StateError = "Error"
+)
+
+// kind maps a custom resource to the server methods managing the matching object
+type kind struct {
+	// resource is the plural name of the custom resource
+	resource string
+	// container is the collection of the object in the resource names of the server
+	container string
+	apply     func(ctx context.Context, id string, spec []byte) (proto.Message, error)
+	remove    func(ctx context.Context, name string) error
+}
+
+// Controller watches the LogicalBridge, Vrf, Svi and BridgePort custom resources of
+// a namespace, drives the matching Create, Update and Delete calls of the server,
+// named after the custom resources, and writes the outcome back to their status
+type Controller struct {
+	client    *Client
+	server    *evpn.Server
+	namespace string
+	kinds     []kind
+	retry     time.Duration
+	// mu serializes the calls to the server, the watches run concurrently
+	mu sync.Mutex
+}
+
+// objectName is the resource name the server gives to the object with that ID
+func objectName(container string, id string) string {
+	return fmt.Sprintf("//network.opiproject.org/%s/%s", container, id)
+}
+
+// NewController creates a controller of the custom resources of namespace
+func NewController(client *Client, server *evpn.Server, namespace string) *Controller {
+	c := &Controller{client: client, server: server, namespace: namespace, retry: 5 * time.Second}
+	// parents first, so the initial sync resolves the references
+	c.kinds = []kind{
+		{resource: "vrfs", container: "vrfs", apply: c.applyVrf, remove: c.removeVrf},
+		{resource: "logicalbridges", container: "bridges", apply: c.applyLogicalBridge, remove: c.removeLogicalBridge},
+		{resource: "bridgeports", container: "ports", apply: c.applyBridgePort, remove: c.removeBridgePort},
+		{resource: "svis", container: "svis", apply: c.applySvi, remove: c.removeSvi},
+	}
+	return c
+}
+
+// Run reconciles all the custom resources once, then keeps watching them until ctx is done
+func (c *Controller) Run(ctx context.Context) error {
+	versions := make([]string, len(c.kinds))
+	for i := range c.kinds {
+		version, err := c.sync(ctx, &c.kinds[i])
+		if err != nil {
+			return err
+		}
+		versions[i] = version
+	}
+	var wg sync.WaitGroup
+	for i := range c.kinds {
+		wg.Add(1)
+		go func(k *kind, version string) {
+			defer wg.Done()
+			c.watch(ctx, k, version)
+		}(&c.kinds[i], versions[i])
+	}
+	wg.Wait()
+	return ctx.Err()
+}
+
+// sync reconciles every custom resource of the kind and returns the version to watch from
+func (c *Controller) sync(ctx context.Context, k *kind) (string, error) {
+	list, err := c.client.List(ctx, c.namespace, k.resource)
+	if err != nil {
+		fmt.Printf("Failed to list %s: %v", k.resource, err)
+		return "", err
+	}
+	for i := range list.Items {
+		c.reconcile(ctx, k, &list.Items[i])
+	}
+	return list.Metadata.ResourceVersion, nil
+}
+
+// watch follows the changes of the kind, relisting whenever the stream breaks
+func (c *Controller) watch(ctx context.Context, k *kind, version string) {
+	for ctx.Err() == nil {
+		err := c.client.Watch(ctx, c.namespace, k.resource, version, func(ev WatchEvent) error {
+			switch ev.Type {
+			case "ADDED", "MODIFIED":
+				// skip the events of our own status updates
+				if st := ev.Object.Status; st == nil || st.State != StateReady || st.ObservedGeneration != ev.Object.Metadata.Generation {
+					c.reconcile(ctx, k, &ev.Object)
+				}
+			case "DELETED":
+				c.delete(ctx, k, &ev.Object)
+			case "ERROR":
+				// e.g. 410 Gone once the version is compacted away
+				return fmt.Errorf("watch of %s failed: %s", k.resource, ev.Object.Status.GetMessage())
+			}
+			if ev.Object.Metadata.ResourceVersion != "" {
+				version = ev.Object.Metadata.ResourceVersion
+			}
+			return nil
+		})
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			fmt.Printf("Failed to watch %s: %v", k.resource, err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(c.retry):
+		}
+		if relisted, err := c.sync(ctx, k); err == nil {
+			version = relisted
+		}
+	}
+}
+
+// reconcile applies the custom resource to the server and records the outcome in its status
+func (c *Controller) reconcile(ctx context.Context, k *kind, obj *Object) {
+	if c.server.IsStandby() {
+		return
+	}
+	c.mu.Lock()
+	result, err := k.apply(ctx, obj.Metadata.Name, obj.Spec)
+	c.mu.Unlock()
+	st := &ObjectStatus{State: StateReady, ObservedGeneration: obj.Metadata.Generation}
+	if err != nil {
+		fmt.Printf("Failed to reconcile %s %s: %v", k.resource, obj.Metadata.Name, err)
+		st.State = StateError
+		st.Message = status.Convert(err).Message()
+	} else if data, merr := protojson.Marshal(result); merr == nil {
+		st.Object = data
+	}
+	if old := obj.Status; old != nil && old.State == st.State && old.Message == st.Message &&
+		old.ObservedGeneration == st.ObservedGeneration && bytes.Equal(old.Object, st.Object) {
+		return
+	}
+	obj.Status = st
+	if err := c.client.UpdateStatus(ctx, c.namespace, k.resource, obj); err != nil {
+		fmt.Printf("Failed to update status of %s %s: %v", k.resource, obj.Metadata.Name, err)
+	}
+}
+
+// delete removes the object of a deleted custom resource from the server
+func (c *Controller) delete(ctx context.Context, k *kind, obj *Object) {
+	if c.server.IsStandby() {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	log.Printf("Deleting %s %s", k.resource, obj.Metadata.Name)
+	if err := k.remove(ctx, objectName(k.container, obj.Metadata.Name)); err != nil {
+		fmt.Printf("Failed to delete %s %s: %v", k.resource, obj.Metadata.Name, err)
+	}
+}
+
+// GetMessage returns the message of the status, empty when nil
+func (st *ObjectStatus) GetMessage() string {
+	if st == nil {
+		return ""
+	}
+	return st.Message
+}
+
+// a changed spec under an existing name is applied with an Update
+
+func (c *Controller) applyVrf(ctx context.Context, id string, data []byte) (proto.Message, error) {
+	spec := &pb.VrfSpec{}
+	if err := protojson.Unmarshal(data, spec); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid spec: %v", err)
+	}
+	obj, err := c.server.CreateVrf(ctx, &pb.CreateVrfRequest{VrfId: id, Vrf: &pb.Vrf{Spec: spec}})
+	if status.Code(err) == codes.AlreadyExists {
+		obj, err = c.server.UpdateVrf(ctx, &pb.UpdateVrfRequest{Vrf: &pb.Vrf{Name: objectName("vrfs", id), Spec: spec}})
+	}
+	return obj, err
+}
+
+func (c *Controller) removeVrf(ctx context.Context, name string) error {
+	_, err := c.server.DeleteVrf(ctx, &pb.DeleteVrfRequest{Name: name, AllowMissing: true})
+	return err
+}
+
+func (c *Controller) applyLogicalBridge(ctx context.Context, id string, data []byte) (proto.Message, error) {
+	spec := &pb.LogicalBridgeSpec{}
+	if err := protojson.Unmarshal(data, spec); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid spec: %v", err)
+	}
+	obj, err := c.server.CreateLogicalBridge(ctx, &pb.CreateLogicalBridgeRequest{LogicalBridgeId: id, LogicalBridge: &pb.LogicalBridge{Spec: spec}})
+	if status.Code(err) == codes.AlreadyExists {
+		obj, err = c.server.UpdateLogicalBridge(ctx, &pb.UpdateLogicalBridgeRequest{LogicalBridge: &pb.LogicalBridge{Name: objectName("bridges", id), Spec: spec}})
+	}
+	return obj, err
+}
+
+func (c *Controller) removeLogicalBridge(ctx context.Context, name string) error {
+	_, err := c.server.DeleteLogicalBridge(ctx, &pb.DeleteLogicalBridgeRequest{Name: name, AllowMissing: true})
+	return err
+}
+
+func (c *Controller) applyBridgePort(ctx context.Context, id string, data []byte) (proto.Message, error) {
+	spec := &pb.BridgePortSpec{}
+	if err := protojson.Unmarshal(data, spec); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid spec: %v", err)
+	}
+	obj, err := c.server.CreateBridgePort(ctx, &pb.CreateBridgePortRequest{BridgePortId: id, BridgePort: &pb.BridgePort{Spec: spec}})
+	if status.Code(err) == codes.AlreadyExists {
+		obj, err = c.server.UpdateBridgePort(ctx, &pb.UpdateBridgePortRequest{BridgePort: &pb.BridgePort{Name: objectName("ports", id), Spec: spec}})
+	}
+	return obj, err
+}
+
+func (c *Controller) removeBridgePort(ctx context.Context, name string) error {
+	_, err := c.server.DeleteBridgePort(ctx, &pb.DeleteBridgePortRequest{Name: name, AllowMissing: true})
+	return err
+}
+
+func (c *Controller) applySvi(ctx context.Context, id string, data []byte) (proto.Message, error) {
+	spec := &pb.SviSpec{}
+	if err := protojson.Unmarshal(data, spec); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid spec: %v", err)
+	}
+	obj, err := c.server.CreateSvi(ctx, &pb.CreateSviRequest{SviId: id, Svi: &pb.Svi{Spec: spec}})
+	if status.Code(err) == codes.AlreadyExists {
+		obj, err = c.server.UpdateSvi(ctx, &pb.UpdateSviRequest{Svi: &pb.Svi{Name: objectName("svis", id), Spec: spec}})
+	}
+	return obj, err
+}
+
+func (c *Controller) removeSvi(ctx context.Context, name string) error {
+	_, err := c.server.DeleteSvi(ctx, &pb.DeleteSviRequest{Name: name, AllowMissing: true})
+	return err
+}
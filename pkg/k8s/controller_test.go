@@ -0,0 +1,114 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package k8s drives the EVPN server from Kubernetes custom resources
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/philippgille/gokv/gomap"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/evpn"
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils/mocks"
+)
+
+// apiServer fakes the custom resources endpoints of the Kubernetes API
+type apiServer struct {
+	mu       sync.Mutex
+	items    map[string][]Object
+	statuses map[string]*ObjectStatus
+}
+
+func (s *apiServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	prefix := "/apis/" + Group + "/" + Version + "/namespaces/default/"
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, prefix), "/")
+	switch {
+	case r.Method == http.MethodGet && r.URL.Query().Get("watch") != "":
+		// an empty stream, the server closes it right away
+		w.WriteHeader(http.StatusOK)
+	case r.Method == http.MethodGet:
+		list := ObjectList{Items: s.items[parts[0]]}
+		list.Metadata.ResourceVersion = "1"
+		_ = json.NewEncoder(w).Encode(list)
+	case r.Method == http.MethodPut && len(parts) == 3 && parts[2] == "status":
+		obj := Object{}
+		if err := json.NewDecoder(r.Body).Decode(&obj); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		s.statuses[parts[0]+"/"+parts[1]] = obj.Status
+		_ = json.NewEncoder(w).Encode(obj)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (s *apiServer) status(key string) *ObjectStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.statuses[key]
+}
+
+func TestController_Run(t *testing.T) {
+	tests := map[string]struct {
+		spec  string
+		state string
+		msg   string
+	}{
+		"valid spec": {
+			spec:  `{"vlanId": 22}`,
+			state: StateReady,
+		},
+		"invalid vlan": {
+			spec:  `{"vlanId": 4095}`,
+			state: StateError,
+			msg:   "VlanId value (4095) have to be between 1 and 4094",
+		},
+	}
+	for testName, tt := range tests {
+		t.Run(testName, func(t *testing.T) {
+			api := &apiServer{
+				items: map[string][]Object{
+					"logicalbridges": {{
+						Kind:     "LogicalBridge",
+						Metadata: ObjectMeta{Name: "blue-bridge", Namespace: "default", Generation: 1},
+						Spec:     json.RawMessage(tt.spec),
+					}},
+				},
+				statuses: map[string]*ObjectStatus{},
+			}
+			ts := httptest.NewServer(api)
+			defer ts.Close()
+
+			server := evpn.NewServerWithArgs(mocks.NewNetlink(t), mocks.NewFrr(t), gomap.NewStore(gomap.DefaultOptions))
+			c := NewController(NewClient(ts.URL, "", ts.Client()), server, "default")
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+			if err := c.Run(ctx); err != context.DeadlineExceeded {
+				t.Errorf("Run() error = %v, want %v", err, context.DeadlineExceeded)
+			}
+
+			st := api.status("logicalbridges/blue-bridge")
+			if st == nil {
+				t.Fatalf("status = nil, want one written")
+			}
+			if st.State != tt.state || st.Message != tt.msg || st.ObservedGeneration != 1 {
+				t.Errorf("status = %+v, want state %v message %v", st, tt.state, tt.msg)
+			}
+			_, stored := server.Bridges[objectName("bridges", "blue-bridge")]
+			if stored != (tt.state == StateReady) {
+				t.Errorf("stored = %v, want %v", stored, tt.state == StateReady)
+			}
+		})
+	}
+}
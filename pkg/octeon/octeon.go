@@ -0,0 +1,240 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package octeon offloads the EVPN forwarding to the Marvell OCTEON DPU through its SDK
+package octeon
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"log"
+	"net"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+	"github.com/opiproject/opi-evpn-bridge/pkg/evpn"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Dataplane programs the bridge domains, vxlan tunnels, Vrfs and Svis into the OCTEON switch
+// on top of the Linux dataplane of the Arm cores, which stays the control plane view FRR
+// learns from. VRF-lite handoffs and static routes are left to the Linux dataplane
+type Dataplane struct {
+	evpn.Dataplane
+	server *evpn.Server
+	sdk    SDK
+}
+
+// NewDataplane wraps the current dataplane of the server, to be set with server.SetDataplane
+func NewDataplane(server *evpn.Server, sdk SDK) *Dataplane {
+	return &Dataplane{Dataplane: server.Dataplane(), server: server, sdk: sdk}
+}
+
+func ipv4(addr uint32) net.IP {
+	ip := make(net.IP, 4)
+	binary.BigEndian.PutUint32(ip, addr)
+	return ip
+}
+
+// undo removes again from Linux an object the SDK rejected
+func undo(err error, remove func() error) error {
+	fmt.Printf("Failed to program the OCTEON switch: %v", err)
+	if err := remove(); err != nil {
+		log.Printf("Failed to clean up after the SDK failure: %v", err)
+	}
+	return err
+}
+
+func (d *Dataplane) sdkCreateVrf(ctx context.Context, obj *pb.Vrf) error {
+	return d.sdk.CreateVrf(ctx, obj.GetStatus().GetRoutingTable(), obj.Spec.GetVni(), obj.GetStatus().GetRmac())
+}
+
+// CreateVrf programs the Vrf in Linux and creates its routing instance
+func (d *Dataplane) CreateVrf(ctx context.Context, obj *pb.Vrf) error {
+	if err := d.Dataplane.CreateVrf(ctx, obj); err != nil {
+		return err
+	}
+	if err := d.sdkCreateVrf(ctx, obj); err != nil {
+		return undo(err, func() error { return d.Dataplane.DeleteVrf(ctx, obj) })
+	}
+	return nil
+}
+
+// DeleteVrf deletes the routing instance before the Linux devices
+func (d *Dataplane) DeleteVrf(ctx context.Context, obj *pb.Vrf) error {
+	if err := d.sdk.DeleteVrf(ctx, obj.GetStatus().GetRoutingTable()); err != nil {
+		fmt.Printf("Failed to delete OCTEON vrf: %v", err)
+		return err
+	}
+	return d.Dataplane.DeleteVrf(ctx, obj)
+}
+
+// ResyncVrf re-applies the Vrf to Linux and creates its routing instance again
+func (d *Dataplane) ResyncVrf(ctx context.Context, obj *pb.Vrf) (bool, error) {
+	recreated, err := d.Dataplane.ResyncVrf(ctx, obj)
+	if err != nil {
+		return recreated, err
+	}
+	return recreated, d.sdkCreateVrf(ctx, obj)
+}
+
+func (d *Dataplane) sdkCreateLogicalBridge(ctx context.Context, obj *pb.LogicalBridge) error {
+	if err := d.sdk.CreateBridgeDomain(ctx, obj.Spec.VlanId); err != nil {
+		return err
+	}
+	if obj.Spec.Vni == nil {
+		return nil
+	}
+	return d.sdk.CreateVxlanTunnel(ctx, *obj.Spec.Vni, obj.Spec.VlanId, ipv4(obj.Spec.VtepIpPrefix.GetAddr().GetV4Addr()))
+}
+
+// CreateLogicalBridge programs the LogicalBridge in Linux and creates its bridge domain and tunnel
+func (d *Dataplane) CreateLogicalBridge(ctx context.Context, obj *pb.LogicalBridge) error {
+	if err := d.Dataplane.CreateLogicalBridge(ctx, obj); err != nil {
+		return err
+	}
+	if err := d.sdkCreateLogicalBridge(ctx, obj); err != nil {
+		return undo(err, func() error { return d.Dataplane.DeleteLogicalBridge(ctx, obj) })
+	}
+	return nil
+}
+
+// DeleteLogicalBridge deletes the tunnel and bridge domain before the Linux devices
+func (d *Dataplane) DeleteLogicalBridge(ctx context.Context, obj *pb.LogicalBridge) error {
+	if obj.Spec.Vni != nil {
+		if err := d.sdk.DeleteVxlanTunnel(ctx, *obj.Spec.Vni); err != nil {
+			fmt.Printf("Failed to delete OCTEON vxlan tunnel: %v", err)
+			return err
+		}
+	}
+	if err := d.sdk.DeleteBridgeDomain(ctx, obj.Spec.VlanId); err != nil {
+		fmt.Printf("Failed to delete OCTEON bridge domain: %v", err)
+		return err
+	}
+	return d.Dataplane.DeleteLogicalBridge(ctx, obj)
+}
+
+// ResyncLogicalBridge re-applies the LogicalBridge to Linux and creates its bridge domain again
+func (d *Dataplane) ResyncLogicalBridge(ctx context.Context, obj *pb.LogicalBridge) error {
+	if err := d.Dataplane.ResyncLogicalBridge(ctx, obj); err != nil {
+		return err
+	}
+	return d.sdkCreateLogicalBridge(ctx, obj)
+}
+
+// bridgePortVlans returns the vlans of the LogicalBridges of the port
+func (d *Dataplane) bridgePortVlans(obj *pb.BridgePort) ([]uint32, error) {
+	var vlans []uint32
+	for _, bridgeRefName := range obj.Spec.LogicalBridges {
+		bridgeObject, ok := d.server.Bridges[bridgeRefName]
+		if !ok {
+			err := status.Errorf(codes.NotFound, "unable to find key %s", bridgeRefName)
+			return nil, err
+		}
+		vlans = append(vlans, bridgeObject.Spec.VlanId)
+	}
+	return vlans, nil
+}
+
+func (d *Dataplane) sdkBindBridgePort(ctx context.Context, obj *pb.BridgePort) error {
+	vlans, err := d.bridgePortVlans(obj)
+	if err != nil {
+		return err
+	}
+	for _, vlan := range vlans {
+		if err := d.sdk.AddBridgePort(ctx, d.server.PortKernelName(obj.Name), vlan, obj.Spec.Ptype == pb.BridgePortType_TRUNK); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *Dataplane) sdkUnbindBridgePort(ctx context.Context, obj *pb.BridgePort) error {
+	vlans, err := d.bridgePortVlans(obj)
+	if err != nil {
+		return err
+	}
+	for _, vlan := range vlans {
+		if err := d.sdk.RemoveBridgePort(ctx, d.server.PortKernelName(obj.Name), vlan); err != nil {
+			fmt.Printf("Failed to remove OCTEON bridge port: %v", err)
+			return err
+		}
+	}
+	return nil
+}
+
+// BindBridgePort binds the port in Linux and adds it to the bridge domains of its LogicalBridges
+func (d *Dataplane) BindBridgePort(ctx context.Context, obj *pb.BridgePort) error {
+	if err := d.Dataplane.BindBridgePort(ctx, obj); err != nil {
+		return err
+	}
+	if err := d.sdkBindBridgePort(ctx, obj); err != nil {
+		return undo(err, func() error { return d.Dataplane.UnbindBridgePort(ctx, obj) })
+	}
+	return nil
+}
+
+// UpdateBridgePort updates the port in Linux and moves it to its new bridge domains
+func (d *Dataplane) UpdateBridgePort(ctx context.Context, old *pb.BridgePort, obj *pb.BridgePort) error {
+	if err := d.Dataplane.UpdateBridgePort(ctx, old, obj); err != nil {
+		return err
+	}
+	if err := d.sdkUnbindBridgePort(ctx, old); err != nil {
+		return err
+	}
+	return d.sdkBindBridgePort(ctx, obj)
+}
+
+// UnbindBridgePort removes the port from its bridge domains before unbinding it in Linux
+func (d *Dataplane) UnbindBridgePort(ctx context.Context, obj *pb.BridgePort) error {
+	if err := d.sdkUnbindBridgePort(ctx, obj); err != nil {
+		return err
+	}
+	return d.Dataplane.UnbindBridgePort(ctx, obj)
+}
+
+// ResyncBridgePort re-applies the port to Linux and adds it to its bridge domains again
+func (d *Dataplane) ResyncBridgePort(ctx context.Context, obj *pb.BridgePort) error {
+	if err := d.Dataplane.ResyncBridgePort(ctx, obj); err != nil {
+		return err
+	}
+	return d.sdkBindBridgePort(ctx, obj)
+}
+
+func (d *Dataplane) sdkCreateSvi(ctx context.Context, obj *pb.Svi, bridge *pb.LogicalBridge, vrf *pb.Vrf) error {
+	var addrs []string
+	for _, gwip := range obj.Spec.GwIpPrefix {
+		addrs = append(addrs, fmt.Sprintf("%s/%d", ipv4(gwip.Addr.GetV4Addr()), gwip.Len))
+	}
+	return d.sdk.CreateL3Interface(ctx, bridge.Spec.VlanId, vrf.GetStatus().GetRoutingTable(), obj.Spec.MacAddress, addrs)
+}
+
+// CreateSvi programs the Svi in Linux and routes its bridge domain in the routing instance
+func (d *Dataplane) CreateSvi(ctx context.Context, obj *pb.Svi, bridge *pb.LogicalBridge, vrf *pb.Vrf) error {
+	if err := d.Dataplane.CreateSvi(ctx, obj, bridge, vrf); err != nil {
+		return err
+	}
+	if err := d.sdkCreateSvi(ctx, obj, bridge, vrf); err != nil {
+		return undo(err, func() error { return d.Dataplane.DeleteSvi(ctx, obj, bridge, vrf) })
+	}
+	return nil
+}
+
+// DeleteSvi deletes the L3 interface before the Linux devices
+func (d *Dataplane) DeleteSvi(ctx context.Context, obj *pb.Svi, bridge *pb.LogicalBridge, vrf *pb.Vrf) error {
+	if err := d.sdk.DeleteL3Interface(ctx, bridge.Spec.VlanId); err != nil {
+		fmt.Printf("Failed to delete OCTEON L3 interface: %v", err)
+		return err
+	}
+	return d.Dataplane.DeleteSvi(ctx, obj, bridge, vrf)
+}
+
+// ResyncSvi re-applies the Svi to Linux and creates its L3 interface again
+func (d *Dataplane) ResyncSvi(ctx context.Context, obj *pb.Svi, bridge *pb.LogicalBridge, vrf *pb.Vrf, force bool) error {
+	if err := d.Dataplane.ResyncSvi(ctx, obj, bridge, vrf, force); err != nil {
+		return err
+	}
+	return d.sdkCreateSvi(ctx, obj, bridge, vrf)
+}
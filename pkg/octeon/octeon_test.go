@@ -0,0 +1,126 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package octeon offloads the EVPN forwarding to the Marvell OCTEON DPU through its SDK
+package octeon
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+	"path/filepath"
+	"testing"
+
+	"github.com/philippgille/gokv/gomap"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+	pc "github.com/opiproject/opi-api/network/opinetcommon/v1alpha1/gen/go"
+	"github.com/opiproject/opi-evpn-bridge/pkg/evpn"
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils/mocks"
+)
+
+// fakeLinux stands for the Linux dataplane of the Arm cores
+type fakeLinux struct {
+	evpn.Dataplane
+	bridges map[string]bool
+}
+
+func (l *fakeLinux) CreateLogicalBridge(_ context.Context, obj *pb.LogicalBridge) error {
+	l.bridges[obj.Name] = true
+	return nil
+}
+
+func (l *fakeLinux) DeleteLogicalBridge(_ context.Context, obj *pb.LogicalBridge) error {
+	delete(l.bridges, obj.Name)
+	return nil
+}
+
+// Octeon is the service of a fake SDK agent
+type Octeon struct {
+	requests []string
+	err      error
+}
+
+func (o *Octeon) CreateBridgeDomain(_ *Request, _ *struct{}) error {
+	o.requests = append(o.requests, "CreateBridgeDomain")
+	return nil
+}
+
+func (o *Octeon) CreateVxlanTunnel(request *Request, _ *struct{}) error {
+	o.requests = append(o.requests, "CreateVxlanTunnel "+request.IP.String())
+	return o.err
+}
+
+func serveAgent(t *testing.T, service *Octeon) *AgentSDK {
+	socket := filepath.Join(t.TempDir(), "octeon-sdk.sock")
+	lis, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { lis.Close() })
+	server := rpc.NewServer()
+	if err := server.Register(service); err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		for {
+			conn, err := lis.Accept()
+			if err != nil {
+				return
+			}
+			go server.ServeCodec(jsonrpc.NewServerCodec(conn))
+		}
+	}()
+	sdk, err := DialAgent(socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sdk.Close() })
+	return sdk
+}
+
+func TestDataplane_CreateLogicalBridge(t *testing.T) {
+	vni := uint32(10)
+	tests := map[string]struct {
+		err      error
+		requests []string
+		bridges  int
+	}{
+		"bridge domain and tunnel": {
+			requests: []string{"CreateBridgeDomain", "CreateVxlanTunnel 10.0.0.1"},
+			bridges:  1,
+		},
+		"sdk failure removes the bridge": {
+			err:      errors.New("no free tunnel"),
+			requests: []string{"CreateBridgeDomain", "CreateVxlanTunnel 10.0.0.1"},
+		},
+	}
+
+	for testName, tt := range tests {
+		t.Run(testName, func(t *testing.T) {
+			service := &Octeon{err: tt.err}
+			server := evpn.NewServerWithArgs(mocks.NewNetlink(t), mocks.NewFrr(t), gomap.NewStore(gomap.DefaultOptions))
+			linux := &fakeLinux{bridges: map[string]bool{}}
+			server.SetDataplane(linux)
+			dataplane := NewDataplane(server, serveAgent(t, service))
+
+			obj := &pb.LogicalBridge{
+				Name: "//network.opiproject.org/bridges/vlan10",
+				Spec: &pb.LogicalBridgeSpec{
+					VlanId:       10,
+					Vni:          &vni,
+					VtepIpPrefix: &pc.IPPrefix{Addr: &pc.IPAddress{V4OrV6: &pc.IPAddress_V4Addr{V4Addr: 0x0a000001}}, Len: 24},
+				},
+			}
+			err := dataplane.CreateLogicalBridge(context.Background(), obj)
+			if (err != nil) != (tt.err != nil) {
+				t.Error("error: expected", tt.err, "received", err)
+			}
+			if len(service.requests) != len(tt.requests) || service.requests[1] != tt.requests[1] || len(linux.bridges) != tt.bridges {
+				t.Error("requests: expected", tt.requests, tt.bridges, "received", service.requests, linux.bridges)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package octeon offloads the EVPN forwarding to the Marvell OCTEON DPU through its SDK
+package octeon
+
+import (
+	"context"
+	"net"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+)
+
+// SDK is the subset of the OCTEON SDK programming the switch of the DPU, implemented by
+// AgentSDK. Creating an object which already exists is expected to succeed
+type SDK interface {
+	CreateBridgeDomain(ctx context.Context, vlan uint32) error
+	DeleteBridgeDomain(ctx context.Context, vlan uint32) error
+	CreateVxlanTunnel(ctx context.Context, vni uint32, vlan uint32, srcIP net.IP) error
+	DeleteVxlanTunnel(ctx context.Context, vni uint32) error
+	AddBridgePort(ctx context.Context, port string, vlan uint32, tagged bool) error
+	RemoveBridgePort(ctx context.Context, port string, vlan uint32) error
+	CreateVrf(ctx context.Context, vrfID uint32, vni uint32, rmac net.HardwareAddr) error
+	DeleteVrf(ctx context.Context, vrfID uint32) error
+	CreateL3Interface(ctx context.Context, vlan uint32, vrfID uint32, mac net.HardwareAddr, addrs []string) error
+	DeleteL3Interface(ctx context.Context, vlan uint32) error
+}
+
+// Request is the argument of every call to the SDK agent, the fields not used by a call are omitted
+type Request struct {
+	Vlan  uint32           `json:"vlan,omitempty"`
+	Vni   uint32           `json:"vni,omitempty"`
+	VrfID uint32           `json:"vrf_id,omitempty"`
+	Port  string           `json:"port,omitempty"`
+	Tag   bool             `json:"tagged,omitempty"`
+	IP    net.IP           `json:"ip,omitempty"`
+	Mac   net.HardwareAddr `json:"mac,omitempty"`
+	Addrs []string         `json:"addrs,omitempty"`
+}
+
+// AgentSDK calls the SDK agent of the DPU, the daemon linking the C SDK, with JSON-RPC
+// over its unix socket. The methods are served as Octeon.<method>
+type AgentSDK struct {
+	client *rpc.Client
+}
+
+// DialAgent connects to the unix socket of the SDK agent
+func DialAgent(socket string) (*AgentSDK, error) {
+	conn, err := net.Dial("unix", socket)
+	if err != nil {
+		return nil, err
+	}
+	return &AgentSDK{client: jsonrpc.NewClient(conn)}, nil
+}
+
+// Close closes the connection to the SDK agent
+func (a *AgentSDK) Close() error {
+	return a.client.Close()
+}
+
+func (a *AgentSDK) call(ctx context.Context, method string, request *Request) error {
+	call := a.client.Go("Octeon."+method, request, &struct{}{}, make(chan *rpc.Call, 1))
+	select {
+	case <-call.Done:
+		return call.Error
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// CreateBridgeDomain creates the bridge domain of a vlan
+func (a *AgentSDK) CreateBridgeDomain(ctx context.Context, vlan uint32) error {
+	return a.call(ctx, "CreateBridgeDomain", &Request{Vlan: vlan})
+}
+
+// DeleteBridgeDomain deletes the bridge domain of a vlan
+func (a *AgentSDK) DeleteBridgeDomain(ctx context.Context, vlan uint32) error {
+	return a.call(ctx, "DeleteBridgeDomain", &Request{Vlan: vlan})
+}
+
+// CreateVxlanTunnel maps a vni to the bridge domain of a vlan, sourced from the local VTEP
+func (a *AgentSDK) CreateVxlanTunnel(ctx context.Context, vni uint32, vlan uint32, srcIP net.IP) error {
+	return a.call(ctx, "CreateVxlanTunnel", &Request{Vni: vni, Vlan: vlan, IP: srcIP})
+}
+
+// DeleteVxlanTunnel deletes the tunnel of a vni
+func (a *AgentSDK) DeleteVxlanTunnel(ctx context.Context, vni uint32) error {
+	return a.call(ctx, "DeleteVxlanTunnel", &Request{Vni: vni})
+}
+
+// AddBridgePort adds a port to the bridge domain of a vlan, tagged for trunks
+func (a *AgentSDK) AddBridgePort(ctx context.Context, port string, vlan uint32, tagged bool) error {
+	return a.call(ctx, "AddBridgePort", &Request{Port: port, Vlan: vlan, Tag: tagged})
+}
+
+// RemoveBridgePort removes a port from the bridge domain of a vlan
+func (a *AgentSDK) RemoveBridgePort(ctx context.Context, port string, vlan uint32) error {
+	return a.call(ctx, "RemoveBridgePort", &Request{Port: port, Vlan: vlan})
+}
+
+// CreateVrf creates a routing instance, with its L3 vni and router mac when vni is not 0
+func (a *AgentSDK) CreateVrf(ctx context.Context, vrfID uint32, vni uint32, rmac net.HardwareAddr) error {
+	return a.call(ctx, "CreateVrf", &Request{VrfID: vrfID, Vni: vni, Mac: rmac})
+}
+
+// DeleteVrf deletes a routing instance
+func (a *AgentSDK) DeleteVrf(ctx context.Context, vrfID uint32) error {
+	return a.call(ctx, "DeleteVrf", &Request{VrfID: vrfID})
+}
+
+// CreateL3Interface routes the bridge domain of a vlan in a routing instance
+func (a *AgentSDK) CreateL3Interface(ctx context.Context, vlan uint32, vrfID uint32, mac net.HardwareAddr, addrs []string) error {
+	return a.call(ctx, "CreateL3Interface", &Request{Vlan: vlan, VrfID: vrfID, Mac: mac, Addrs: addrs})
+}
+
+// DeleteL3Interface deletes the L3 interface of a vlan
+func (a *AgentSDK) DeleteL3Interface(ctx context.Context, vlan uint32) error {
+	return a.call(ctx, "DeleteL3Interface", &Request{Vlan: vlan})
+}
@@ -0,0 +1,152 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+package openconfig
+
+import (
+	"context"
+	"log"
+	"sort"
+	"sync"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/opiproject/opi-evpn-bridge/pkg/evpn"
+)
+
+// Applier drives the EVPN server from the OpenConfig configuration of the device, creating,
+// updating and deleting the objects translated from it
+type Applier struct {
+	server *evpn.Server
+	// mu serializes the applies
+	mu     sync.Mutex
+	device *Device
+	// applied are the objects created by the applies, the stale ones being deleted
+	applied *Objects
+}
+
+// NewApplier creates an applier of an empty configuration
+func NewApplier(server *evpn.Server) *Applier {
+	return &Applier{server: server, device: &Device{}, applied: NewObjects()}
+}
+
+// Device returns a copy of the configuration last applied
+func (a *Applier) Device() (*Device, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.device.Clone()
+}
+
+// Apply translates the configuration and applies it: the objects no longer translated are
+// deleted, children first, then the others are created or updated, parents first. A failed
+// apply leaves the objects applied so far and keeps the previous configuration, a later apply
+// picking up from there
+func (a *Applier) Apply(ctx context.Context, d *Device) error {
+	objects, err := Translate(d)
+	if err != nil {
+		return err
+	}
+	if a.server.IsStandby() {
+		return status.Error(codes.Unavailable, "the server is a standby, apply the configuration to the active one")
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, id := range stale(a.applied.Svis, objects.Svis) {
+		if _, err := a.server.DeleteSvi(ctx, &pb.DeleteSviRequest{Name: objectName("svis", id), AllowMissing: true}); err != nil {
+			return err
+		}
+		delete(a.applied.Svis, id)
+	}
+	for _, id := range stale(a.applied.BridgePorts, objects.BridgePorts) {
+		if _, err := a.server.DeleteBridgePort(ctx, &pb.DeleteBridgePortRequest{Name: objectName("ports", id), AllowMissing: true}); err != nil {
+			return err
+		}
+		delete(a.applied.BridgePorts, id)
+	}
+	for _, id := range stale(a.applied.LogicalBridges, objects.LogicalBridges) {
+		if _, err := a.server.DeleteLogicalBridge(ctx, &pb.DeleteLogicalBridgeRequest{Name: objectName("bridges", id), AllowMissing: true}); err != nil {
+			return err
+		}
+		delete(a.applied.LogicalBridges, id)
+	}
+	for _, id := range stale(a.applied.Vrfs, objects.Vrfs) {
+		if _, err := a.server.DeleteVrf(ctx, &pb.DeleteVrfRequest{Name: objectName("vrfs", id), AllowMissing: true}); err != nil {
+			return err
+		}
+		delete(a.applied.Vrfs, id)
+	}
+	// a changed spec under an existing name is applied with an Update
+	for _, id := range sortedIDs(objects.Vrfs) {
+		spec := objects.Vrfs[id]
+		_, err := a.server.CreateVrf(ctx, &pb.CreateVrfRequest{VrfId: id, Vrf: &pb.Vrf{Spec: spec}})
+		if status.Code(err) == codes.AlreadyExists {
+			_, err = a.server.UpdateVrf(ctx, &pb.UpdateVrfRequest{Vrf: &pb.Vrf{Name: objectName("vrfs", id), Spec: spec}})
+		}
+		if err != nil {
+			return err
+		}
+		a.applied.Vrfs[id] = spec
+	}
+	for _, id := range sortedIDs(objects.LogicalBridges) {
+		spec := objects.LogicalBridges[id]
+		_, err := a.server.CreateLogicalBridge(ctx, &pb.CreateLogicalBridgeRequest{LogicalBridgeId: id, LogicalBridge: &pb.LogicalBridge{Spec: spec}})
+		if status.Code(err) == codes.AlreadyExists {
+			_, err = a.server.UpdateLogicalBridge(ctx, &pb.UpdateLogicalBridgeRequest{LogicalBridge: &pb.LogicalBridge{Name: objectName("bridges", id), Spec: spec}})
+		}
+		if err != nil {
+			return err
+		}
+		a.applied.LogicalBridges[id] = spec
+	}
+	for _, id := range sortedIDs(objects.BridgePorts) {
+		spec := objects.BridgePorts[id]
+		_, err := a.server.CreateBridgePort(ctx, &pb.CreateBridgePortRequest{BridgePortId: id, BridgePort: &pb.BridgePort{Spec: spec}})
+		if status.Code(err) == codes.AlreadyExists {
+			_, err = a.server.UpdateBridgePort(ctx, &pb.UpdateBridgePortRequest{BridgePort: &pb.BridgePort{Name: objectName("ports", id), Spec: spec}})
+		}
+		if err != nil {
+			return err
+		}
+		a.applied.BridgePorts[id] = spec
+	}
+	for _, id := range sortedIDs(objects.Svis) {
+		spec := objects.Svis[id]
+		_, err := a.server.CreateSvi(ctx, &pb.CreateSviRequest{SviId: id, Svi: &pb.Svi{Spec: spec}})
+		if status.Code(err) == codes.AlreadyExists {
+			_, err = a.server.UpdateSvi(ctx, &pb.UpdateSviRequest{Svi: &pb.Svi{Name: objectName("svis", id), Spec: spec}})
+		}
+		if err != nil {
+			return err
+		}
+		a.applied.Svis[id] = spec
+	}
+	log.Printf("Applied the OpenConfig configuration: %d vrfs, %d bridges, %d ports, %d svis",
+		len(objects.Vrfs), len(objects.LogicalBridges), len(objects.BridgePorts), len(objects.Svis))
+	a.device = d
+	return nil
+}
+
+// stale returns the sorted IDs applied and no longer translated
+func stale[T any](applied map[string]T, desired map[string]T) []string {
+	var ids []string
+	for id := range applied {
+		if _, ok := desired[id]; !ok {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// sortedIDs returns the IDs of the objects sorted, for the applies to be reproducible
+func sortedIDs[T any](objects map[string]T) []string {
+	ids := make([]string, 0, len(objects))
+	for id := range objects {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
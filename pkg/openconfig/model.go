@@ -0,0 +1,257 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package openconfig maps the OpenConfig network-instance and interfaces models to the
+// LogicalBridges, BridgePorts, Vrfs and Svis of the EVPN server
+package openconfig
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// The subset of the openconfig-network-instance, openconfig-evpn, openconfig-interfaces,
+// openconfig-if-ethernet, openconfig-vlan and openconfig-if-ip models translated, in their
+// RFC 7951 (JSON_IETF) encoding. The other leaves are ignored
+
+// Network instance types of openconfig-network-instance-types
+const (
+	TypeL3VRF  = "L3VRF"
+	TypeMACVRF = "MAC_VRF"
+)
+
+// Interface types of iana-if-type
+const (
+	TypeEthernet = "ethernetCsmacd"
+	TypeLoopback = "softwareLoopback"
+	TypeIrb      = "l3ipvlan"
+)
+
+// Interface modes of openconfig-vlan
+const (
+	ModeAccess = "ACCESS"
+	ModeTrunk  = "TRUNK"
+)
+
+// Device is the configuration of the gateway, the network instances and the interfaces
+type Device struct {
+	NetworkInstances *NetworkInstances `json:"network-instances,omitempty"`
+	Interfaces       *Interfaces       `json:"interfaces,omitempty"`
+}
+
+// NetworkInstances is the /network-instances container
+type NetworkInstances struct {
+	NetworkInstance []*NetworkInstance `json:"network-instance"`
+}
+
+// NetworkInstance is an L3VRF, translated to a Vrf, or a MAC_VRF, translated to a
+// LogicalBridge. The other types, e.g. DEFAULT_INSTANCE, are ignored
+type NetworkInstance struct {
+	Name   string `json:"name"`
+	Config struct {
+		Name string `json:"name,omitempty"`
+		Type string `json:"type,omitempty"`
+	} `json:"config"`
+	Evpn *struct {
+		EvpnInstances struct {
+			EvpnInstance []struct {
+				Vxlan *struct {
+					Config struct {
+						Vni uint32 `json:"vni,omitempty"`
+					} `json:"config"`
+				} `json:"vxlan,omitempty"`
+			} `json:"evpn-instance"`
+		} `json:"evpn-instances"`
+	} `json:"evpn,omitempty"`
+	Vlans *struct {
+		Vlan []struct {
+			VlanID uint16 `json:"vlan-id"`
+		} `json:"vlan"`
+	} `json:"vlans,omitempty"`
+	Interfaces *struct {
+		Interface []struct {
+			ID     string `json:"id"`
+			Config struct {
+				Interface string `json:"interface,omitempty"`
+			} `json:"config"`
+		} `json:"interface"`
+	} `json:"interfaces,omitempty"`
+}
+
+// Interfaces is the /interfaces container
+type Interfaces struct {
+	Interface []*Interface `json:"interface"`
+}
+
+// Interface is an ethernet port with a switched-vlan, translated to a BridgePort, a routed
+// vlan (irb), translated to a Svi, or the loopback of an L3VRF. The others are ignored
+type Interface struct {
+	Name   string `json:"name"`
+	Config struct {
+		Name string `json:"name,omitempty"`
+		Type string `json:"type,omitempty"`
+	} `json:"config"`
+	Ethernet *struct {
+		Config struct {
+			MacAddress string `json:"mac-address,omitempty"`
+		} `json:"config"`
+		SwitchedVlan *struct {
+			Config struct {
+				InterfaceMode string   `json:"interface-mode,omitempty"`
+				AccessVlan    uint16   `json:"access-vlan,omitempty"`
+				TrunkVlans    []uint16 `json:"trunk-vlans,omitempty"`
+			} `json:"config"`
+		} `json:"switched-vlan,omitempty"`
+	} `json:"ethernet,omitempty"`
+	RoutedVlan *struct {
+		Config struct {
+			Vlan uint16 `json:"vlan,omitempty"`
+		} `json:"config"`
+		IPv4 *IPAddresses `json:"ipv4,omitempty"`
+		IPv6 *IPAddresses `json:"ipv6,omitempty"`
+	} `json:"routed-vlan,omitempty"`
+	Subinterfaces *struct {
+		Subinterface []struct {
+			Index uint32       `json:"index"`
+			IPv4  *IPAddresses `json:"ipv4,omitempty"`
+		} `json:"subinterface"`
+	} `json:"subinterfaces,omitempty"`
+}
+
+// IPAddresses are the addresses of an interface
+type IPAddresses struct {
+	Addresses struct {
+		Address []struct {
+			IP     string `json:"ip"`
+			Config struct {
+				PrefixLength uint8 `json:"prefix-length"`
+			} `json:"config"`
+		} `json:"address"`
+	} `json:"addresses"`
+}
+
+// Unmarshal decodes an RFC 7951 document, the module names prefixing the members being
+// optional, e.g. both openconfig-interfaces:interfaces and interfaces are accepted
+func Unmarshal(data []byte, v interface{}) error {
+	var document interface{}
+	if err := json.Unmarshal(data, &document); err != nil {
+		return err
+	}
+	stripped, err := json.Marshal(stripModules(document))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(stripped, v)
+}
+
+// stripModules removes the module names of the members and of the identity values
+func stripModules(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			result[localName(key)] = stripModules(value)
+		}
+		return result
+	case []interface{}:
+		for i, value := range v {
+			v[i] = stripModules(value)
+		}
+		return v
+	case string:
+		// identities, e.g. openconfig-network-instance-types:L3VRF, and not the MAC or IP
+		// addresses or the route distinguishers
+		if module, local, ok := strings.Cut(v, ":"); ok && isModule(module) {
+			return local
+		}
+		return v
+	}
+	return v
+}
+
+// localName strips the module: prefix of a member
+func localName(name string) string {
+	if _, local, ok := strings.Cut(name, ":"); ok {
+		return local
+	}
+	return name
+}
+
+// isModule reports whether the prefix of an identity is the name of a module
+func isModule(name string) bool {
+	for _, prefix := range []string{"openconfig-", "iana-", "ietf-"} {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// SetNetworkInstance adds or replaces the network instance of that name
+func (d *Device) SetNetworkInstance(ni *NetworkInstance) {
+	if d.NetworkInstances == nil {
+		d.NetworkInstances = &NetworkInstances{}
+	}
+	d.DeleteNetworkInstance(ni.Name)
+	d.NetworkInstances.NetworkInstance = append(d.NetworkInstances.NetworkInstance, ni)
+	sort.Slice(d.NetworkInstances.NetworkInstance, func(i, j int) bool {
+		return d.NetworkInstances.NetworkInstance[i].Name < d.NetworkInstances.NetworkInstance[j].Name
+	})
+}
+
+// DeleteNetworkInstance removes the network instance of that name and reports whether it was there
+func (d *Device) DeleteNetworkInstance(name string) bool {
+	if d.NetworkInstances == nil {
+		return false
+	}
+	list := d.NetworkInstances.NetworkInstance
+	for i, ni := range list {
+		if ni.Name == name {
+			d.NetworkInstances.NetworkInstance = append(list[:i:i], list[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// SetInterface adds or replaces the interface of that name
+func (d *Device) SetInterface(intf *Interface) {
+	if d.Interfaces == nil {
+		d.Interfaces = &Interfaces{}
+	}
+	d.DeleteInterface(intf.Name)
+	d.Interfaces.Interface = append(d.Interfaces.Interface, intf)
+	sort.Slice(d.Interfaces.Interface, func(i, j int) bool {
+		return d.Interfaces.Interface[i].Name < d.Interfaces.Interface[j].Name
+	})
+}
+
+// DeleteInterface removes the interface of that name and reports whether it was there
+func (d *Device) DeleteInterface(name string) bool {
+	if d.Interfaces == nil {
+		return false
+	}
+	list := d.Interfaces.Interface
+	for i, intf := range list {
+		if intf.Name == name {
+			d.Interfaces.Interface = append(list[:i:i], list[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Clone returns a deep copy of the device, to change it without touching the applied one
+func (d *Device) Clone() (*Device, error) {
+	data, err := json.Marshal(d)
+	if err != nil {
+		return nil, err
+	}
+	clone := &Device{}
+	if err := json.Unmarshal(data, clone); err != nil {
+		return nil, fmt.Errorf("cannot copy the device: %v", err)
+	}
+	return clone, nil
+}
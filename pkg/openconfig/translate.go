@@ -0,0 +1,249 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package openconfig maps the OpenConfig network-instance and interfaces models to the
+// LogicalBridges, BridgePorts, Vrfs and Svis of the EVPN server
+package openconfig
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+	pc "github.com/opiproject/opi-api/network/opinetcommon/v1alpha1/gen/go"
+)
+
+// invalidResourceIDChars are the characters of the OpenConfig names not allowed in resource IDs
+var invalidResourceIDChars = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// Objects are the specs translated from a Device, keyed by resource ID
+type Objects struct {
+	Vrfs           map[string]*pb.VrfSpec
+	LogicalBridges map[string]*pb.LogicalBridgeSpec
+	BridgePorts    map[string]*pb.BridgePortSpec
+	Svis           map[string]*pb.SviSpec
+}
+
+// NewObjects returns empty Objects
+func NewObjects() *Objects {
+	return &Objects{
+		Vrfs:           map[string]*pb.VrfSpec{},
+		LogicalBridges: map[string]*pb.LogicalBridgeSpec{},
+		BridgePorts:    map[string]*pb.BridgePortSpec{},
+		Svis:           map[string]*pb.SviSpec{},
+	}
+}
+
+// ResourceID returns the resource ID of the object of an OpenConfig name, lower case with
+// hyphens instead of the other characters, e.g. Ethernet1/1 is ethernet1-1
+func ResourceID(name string) string {
+	return strings.Trim(invalidResourceIDChars.ReplaceAllString(strings.ToLower(name), "-"), "-")
+}
+
+// objectName returns the full name of the object of a resource ID
+func objectName(container string, id string) string {
+	return fmt.Sprintf("//network.opiproject.org/%s/%s", container, id)
+}
+
+// invalid returns the InvalidArgument error of an element of the device
+func invalid(kind string, name string, format string, args ...interface{}) error {
+	msg := fmt.Sprintf("%s %s: %s", kind, name, fmt.Sprintf(format, args...))
+	return status.Error(codes.InvalidArgument, msg)
+}
+
+// prefixes returns the addresses as prefixes
+func prefixes(addresses *IPAddresses) ([]*pc.IPPrefix, error) {
+	if addresses == nil {
+		return nil, nil
+	}
+	var result []*pc.IPPrefix
+	for _, address := range addresses.Addresses.Address {
+		ip := net.ParseIP(address.IP)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid address %q", address.IP)
+		}
+		prefix := &pc.IPPrefix{Len: int32(address.Config.PrefixLength), Addr: &pc.IPAddress{}}
+		if ip4 := ip.To4(); ip4 != nil {
+			prefix.Addr.Af = pc.IpAf_IP_AF_INET
+			prefix.Addr.V4OrV6 = &pc.IPAddress_V4Addr{V4Addr: binary.BigEndian.Uint32(ip4)}
+		} else {
+			prefix.Addr.Af = pc.IpAf_IP_AF_INET6
+			prefix.Addr.V4OrV6 = &pc.IPAddress_V6Addr{V6Addr: ip}
+		}
+		result = append(result, prefix)
+	}
+	return result, nil
+}
+
+// vni returns the vni of the vxlan of the EVPN instance, nil without one
+func (ni *NetworkInstance) vni() *uint32 {
+	if ni.Evpn == nil {
+		return nil
+	}
+	for _, instance := range ni.Evpn.EvpnInstances.EvpnInstance {
+		if instance.Vxlan != nil && instance.Vxlan.Config.Vni != 0 {
+			vni := instance.Vxlan.Config.Vni
+			return &vni
+		}
+	}
+	return nil
+}
+
+// interfaceNames returns the interfaces of the network instance
+func (ni *NetworkInstance) interfaceNames() []string {
+	if ni.Interfaces == nil {
+		return nil
+	}
+	var names []string
+	for _, intf := range ni.Interfaces.Interface {
+		name := intf.Config.Interface
+		if name == "" {
+			name = intf.ID
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// loopback returns the first IPv4 address of the loopback, on its subinterface 0
+func (intf *Interface) loopback() (*pc.IPPrefix, error) {
+	if intf.Subinterfaces == nil {
+		return nil, nil
+	}
+	for _, sub := range intf.Subinterfaces.Subinterface {
+		if sub.Index != 0 {
+			continue
+		}
+		addresses, err := prefixes(sub.IPv4)
+		if err != nil || len(addresses) == 0 {
+			return nil, err
+		}
+		return addresses[0], nil
+	}
+	return nil, nil
+}
+
+// macAddress returns the MAC address of the ethernet config of the interface
+func (intf *Interface) macAddress() (net.HardwareAddr, error) {
+	if intf.Ethernet == nil || intf.Ethernet.Config.MacAddress == "" {
+		return nil, fmt.Errorf("missing ethernet mac-address")
+	}
+	return net.ParseMAC(intf.Ethernet.Config.MacAddress)
+}
+
+// Translate translates the network instances and the interfaces of the device to objects:
+//
+//   - an L3VRF network instance is a Vrf, with the vni of the vxlan of its EVPN instance and
+//     the IPv4 address of its loopback interface
+//   - a MAC_VRF network instance is a LogicalBridge, of its single vlan and the vni of the
+//     vxlan of its EVPN instance
+//   - an interface with an ethernet switched-vlan is a BridgePort, ACCESS or TRUNK, of the
+//     LogicalBridges of its vlans
+//   - an interface with a routed-vlan is a Svi of the LogicalBridge of the vlan, in the Vrf
+//     of the L3VRF it is an interface of, its addresses being the gateway addresses
+func Translate(d *Device) (*Objects, error) {
+	objects := NewObjects()
+	interfaces := map[string]*Interface{}
+	if d.Interfaces != nil {
+		for _, intf := range d.Interfaces.Interface {
+			interfaces[intf.Name] = intf
+		}
+	}
+	bridgesByVlan := map[uint16]string{}
+	vrfOf := map[string]string{}
+	if d.NetworkInstances != nil {
+		for _, ni := range d.NetworkInstances.NetworkInstance {
+			id := ResourceID(ni.Name)
+			switch ni.Config.Type {
+			case TypeMACVRF:
+				if ni.Vlans == nil || len(ni.Vlans.Vlan) != 1 {
+					return nil, invalid("network-instance", ni.Name, "a MAC_VRF has to have a single vlan")
+				}
+				vlan := ni.Vlans.Vlan[0].VlanID
+				if other, ok := bridgesByVlan[vlan]; ok {
+					return nil, invalid("network-instance", ni.Name, "vlan %d is already the one of %s", vlan, other)
+				}
+				bridgesByVlan[vlan] = objectName("bridges", id)
+				objects.LogicalBridges[id] = &pb.LogicalBridgeSpec{VlanId: uint32(vlan), Vni: ni.vni()}
+			case TypeL3VRF:
+				spec := &pb.VrfSpec{Vni: ni.vni()}
+				for _, name := range ni.interfaceNames() {
+					vrfOf[name] = objectName("vrfs", id)
+					intf, ok := interfaces[name]
+					if !ok || intf.Config.Type != TypeLoopback {
+						continue
+					}
+					loopback, err := intf.loopback()
+					if err != nil {
+						return nil, invalid("interface", name, "%v", err)
+					}
+					spec.LoopbackIpPrefix = loopback
+				}
+				objects.Vrfs[id] = spec
+			}
+		}
+	}
+	if d.Interfaces == nil {
+		return objects, nil
+	}
+	for _, intf := range d.Interfaces.Interface {
+		id := ResourceID(intf.Name)
+		switch {
+		case intf.RoutedVlan != nil:
+			bridge, ok := bridgesByVlan[intf.RoutedVlan.Config.Vlan]
+			if !ok {
+				return nil, invalid("interface", intf.Name, "no MAC_VRF has vlan %d", intf.RoutedVlan.Config.Vlan)
+			}
+			vrf, ok := vrfOf[intf.Name]
+			if !ok {
+				return nil, invalid("interface", intf.Name, "the routed vlan is not an interface of an L3VRF")
+			}
+			mac, err := intf.macAddress()
+			if err != nil {
+				return nil, invalid("interface", intf.Name, "%v", err)
+			}
+			spec := &pb.SviSpec{Vrf: vrf, LogicalBridge: bridge, MacAddress: mac}
+			for _, addresses := range []*IPAddresses{intf.RoutedVlan.IPv4, intf.RoutedVlan.IPv6} {
+				gateways, err := prefixes(addresses)
+				if err != nil {
+					return nil, invalid("interface", intf.Name, "%v", err)
+				}
+				spec.GwIpPrefix = append(spec.GwIpPrefix, gateways...)
+			}
+			objects.Svis[id] = spec
+		case intf.Ethernet != nil && intf.Ethernet.SwitchedVlan != nil:
+			mac, err := intf.macAddress()
+			if err != nil {
+				return nil, invalid("interface", intf.Name, "%v", err)
+			}
+			config := intf.Ethernet.SwitchedVlan.Config
+			spec := &pb.BridgePortSpec{MacAddress: mac}
+			var vlans []uint16
+			switch config.InterfaceMode {
+			case ModeAccess:
+				spec.Ptype = pb.BridgePortType_ACCESS
+				vlans = []uint16{config.AccessVlan}
+			case ModeTrunk:
+				spec.Ptype = pb.BridgePortType_TRUNK
+				vlans = config.TrunkVlans
+			default:
+				return nil, invalid("interface", intf.Name, "unknown interface-mode %q, expected ACCESS or TRUNK", config.InterfaceMode)
+			}
+			for _, vlan := range vlans {
+				bridge, ok := bridgesByVlan[vlan]
+				if !ok {
+					return nil, invalid("interface", intf.Name, "no MAC_VRF has vlan %d", vlan)
+				}
+				spec.LogicalBridges = append(spec.LogicalBridges, bridge)
+			}
+			objects.BridgePorts[id] = spec
+		}
+	}
+	return objects, nil
+}
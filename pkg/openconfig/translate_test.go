@@ -0,0 +1,162 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package openconfig maps the OpenConfig network-instance and interfaces models to the
+// LogicalBridges, BridgePorts, Vrfs and Svis of the EVPN server
+package openconfig
+
+import (
+	"net"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+	pc "github.com/opiproject/opi-api/network/opinetcommon/v1alpha1/gen/go"
+)
+
+const testDevice = `{
+  "openconfig-network-instance:network-instances": {
+    "network-instance": [
+      {
+        "name": "Blue",
+        "config": {"name": "Blue", "type": "openconfig-network-instance-types:L3VRF"},
+        "evpn": {"evpn-instances": {"evpn-instance": [{"vxlan": {"config": {"vni": 1000}}}]}},
+        "interfaces": {"interface": [{"id": "irb10", "config": {"interface": "irb10"}}, {"id": "lo1", "config": {"interface": "lo1"}}]}
+      },
+      {
+        "name": "vlan10",
+        "config": {"name": "vlan10", "type": "openconfig-network-instance-types:MAC_VRF"},
+        "evpn": {"evpn-instances": {"evpn-instance": [{"vxlan": {"config": {"vni": 10}}}]}},
+        "vlans": {"vlan": [{"vlan-id": 10}]}
+      },
+      {
+        "name": "default",
+        "config": {"name": "default", "type": "openconfig-network-instance-types:DEFAULT_INSTANCE"}
+      }
+    ]
+  },
+  "openconfig-interfaces:interfaces": {
+    "interface": [
+      {
+        "name": "Ethernet1/1",
+        "config": {"name": "Ethernet1/1", "type": "iana-if-type:ethernetCsmacd"},
+        "openconfig-if-ethernet:ethernet": {
+          "config": {"mac-address": "aa:bb:cc:00:00:01"},
+          "openconfig-vlan:switched-vlan": {"config": {"interface-mode": "ACCESS", "access-vlan": 10}}
+        }
+      },
+      {
+        "name": "irb10",
+        "config": {"name": "irb10", "type": "iana-if-type:l3ipvlan"},
+        "openconfig-if-ethernet:ethernet": {"config": {"mac-address": "aa:bb:cc:00:00:10"}},
+        "openconfig-vlan:routed-vlan": {
+          "config": {"vlan": 10},
+          "openconfig-if-ip:ipv4": {"addresses": {"address": [{"ip": "10.0.10.1", "config": {"prefix-length": 24}}]}}
+        }
+      },
+      {
+        "name": "lo1",
+        "config": {"name": "lo1", "type": "iana-if-type:softwareLoopback"},
+        "subinterfaces": {"subinterface": [{"index": 0, "openconfig-if-ip:ipv4": {"addresses": {"address": [{"ip": "10.0.0.1", "config": {"prefix-length": 32}}]}}}]}
+      }
+    ]
+  }
+}`
+
+func testPrefix(ip string, length int32) *pc.IPPrefix {
+	addr := net.ParseIP(ip).To4()
+	return &pc.IPPrefix{
+		Addr: &pc.IPAddress{
+			Af:     pc.IpAf_IP_AF_INET,
+			V4OrV6: &pc.IPAddress_V4Addr{V4Addr: uint32(addr[0])<<24 | uint32(addr[1])<<16 | uint32(addr[2])<<8 | uint32(addr[3])},
+		},
+		Len: length,
+	}
+}
+
+func Test_Translate(t *testing.T) {
+	d := &Device{}
+	if err := Unmarshal([]byte(testDevice), d); err != nil {
+		t.Fatal("error: expected nil received", err)
+	}
+	objects, err := Translate(d)
+	if err != nil {
+		t.Fatal("error: expected nil received", err)
+	}
+	vni := uint32(1000)
+	vrf := &pb.VrfSpec{Vni: &vni, LoopbackIpPrefix: testPrefix("10.0.0.1", 32)}
+	if len(objects.Vrfs) != 1 || !proto.Equal(objects.Vrfs["blue"], vrf) {
+		t.Error("vrfs: expected", vrf, "received", objects.Vrfs)
+	}
+	bridgeVni := uint32(10)
+	bridge := &pb.LogicalBridgeSpec{VlanId: 10, Vni: &bridgeVni}
+	if len(objects.LogicalBridges) != 1 || !proto.Equal(objects.LogicalBridges["vlan10"], bridge) {
+		t.Error("bridges: expected", bridge, "received", objects.LogicalBridges)
+	}
+	port := &pb.BridgePortSpec{
+		Ptype:          pb.BridgePortType_ACCESS,
+		MacAddress:     []byte{0xaa, 0xbb, 0xcc, 0, 0, 1},
+		LogicalBridges: []string{"//network.opiproject.org/bridges/vlan10"},
+	}
+	if len(objects.BridgePorts) != 1 || !proto.Equal(objects.BridgePorts["ethernet1-1"], port) {
+		t.Error("ports: expected", port, "received", objects.BridgePorts)
+	}
+	svi := &pb.SviSpec{
+		Vrf:           "//network.opiproject.org/vrfs/blue",
+		LogicalBridge: "//network.opiproject.org/bridges/vlan10",
+		MacAddress:    []byte{0xaa, 0xbb, 0xcc, 0, 0, 0x10},
+		GwIpPrefix:    []*pc.IPPrefix{testPrefix("10.0.10.1", 24)},
+	}
+	if len(objects.Svis) != 1 || !proto.Equal(objects.Svis["irb10"], svi) {
+		t.Error("svis: expected", svi, "received", objects.Svis)
+	}
+}
+
+func Test_TranslateInvalid(t *testing.T) {
+	tests := map[string]string{
+		"MAC_VRF without vlan": `{"network-instances": {"network-instance": [
+			{"name": "vlan10", "config": {"type": "MAC_VRF"}}]}}`,
+		"duplicated vlan": `{"network-instances": {"network-instance": [
+			{"name": "a", "config": {"type": "MAC_VRF"}, "vlans": {"vlan": [{"vlan-id": 10}]}},
+			{"name": "b", "config": {"type": "MAC_VRF"}, "vlans": {"vlan": [{"vlan-id": 10}]}}]}}`,
+		"port of an unknown vlan": `{"interfaces": {"interface": [
+			{"name": "eth1", "ethernet": {"config": {"mac-address": "aa:bb:cc:00:00:01"},
+			"switched-vlan": {"config": {"interface-mode": "ACCESS", "access-vlan": 20}}}}]}}`,
+		"port without mac": `{"network-instances": {"network-instance": [
+			{"name": "vlan10", "config": {"type": "MAC_VRF"}, "vlans": {"vlan": [{"vlan-id": 10}]}}]},
+			"interfaces": {"interface": [{"name": "eth1", "ethernet": {
+			"switched-vlan": {"config": {"interface-mode": "ACCESS", "access-vlan": 10}}}}]}}`,
+		"routed vlan outside of a vrf": `{"network-instances": {"network-instance": [
+			{"name": "vlan10", "config": {"type": "MAC_VRF"}, "vlans": {"vlan": [{"vlan-id": 10}]}}]},
+			"interfaces": {"interface": [{"name": "irb10", "ethernet": {"config": {"mac-address": "aa:bb:cc:00:00:01"}},
+			"routed-vlan": {"config": {"vlan": 10}}}]}}`,
+	}
+	for testName, document := range tests {
+		t.Run(testName, func(t *testing.T) {
+			d := &Device{}
+			if err := Unmarshal([]byte(document), d); err != nil {
+				t.Fatal("error: expected nil received", err)
+			}
+			_, err := Translate(d)
+			if status.Code(err) != codes.InvalidArgument {
+				t.Error("error: expected", codes.InvalidArgument, "received", err)
+			}
+		})
+	}
+}
+
+func Test_ResourceID(t *testing.T) {
+	tests := map[string]string{
+		"Ethernet1/1": "ethernet1-1",
+		"irb10":       "irb10",
+		"VRF_Blue":    "vrf-blue",
+	}
+	for name, expected := range tests {
+		if id := ResourceID(name); id != expected {
+			t.Error("id: expected", expected, "received", id)
+		}
+	}
+}
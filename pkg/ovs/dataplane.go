@@ -0,0 +1,477 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package ovs plugs the EVPN objects into an Open vSwitch bridge instead of the kernel bridge
+package ovs
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"log"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/vishvananda/netlink"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+	"github.com/opiproject/opi-evpn-bridge/pkg/evpn"
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Bridge is the OVS bridge replacing the kernel tenant bridge
+const Bridge = "br-tenant"
+
+// Dataplane plugs the LogicalBridges, BridgePorts and Svis into an OVS bridge, FRR keeps
+// running the control plane over the vxlan and internal ports. Vrfs, VRF-lite handoffs and
+// routes stay in the kernel, programmed by the Linux dataplane
+type Dataplane struct {
+	evpn.Dataplane
+	server *evpn.Server
+	ovs    Switch
+	nLink  utils.Netlink
+	frr    utils.Frr
+}
+
+// NewDataplane wraps the current dataplane of the server, to be set with server.SetDataplane
+func NewDataplane(server *evpn.Server, ovs Switch, nLink utils.Netlink, frr utils.Frr) *Dataplane {
+	return &Dataplane{Dataplane: server.Dataplane(), server: server, ovs: ovs, nLink: nLink, frr: frr}
+}
+
+// checkBridge fails with NotFound when the OVS bridge was not set up
+func (d *Dataplane) checkBridge(ctx context.Context) error {
+	exists, err := d.ovs.BridgeExists(ctx, Bridge)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		err := status.Errorf(codes.NotFound, "unable to find key %s", Bridge)
+		return err
+	}
+	return nil
+}
+
+// checkPort fails with NotFound when the port is not in the OVS bridge
+func (d *Dataplane) checkPort(ctx context.Context, name string) error {
+	exists, err := d.ovs.PortExists(ctx, name)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		err := status.Errorf(codes.NotFound, "unable to find key %s", name)
+		return err
+	}
+	return nil
+}
+
+// checkPortAbsent fails with AlreadyExists when the port is already in the OVS bridge
+func (d *Dataplane) checkPortAbsent(ctx context.Context, name string) error {
+	exists, err := d.ovs.PortExists(ctx, name)
+	if err != nil {
+		return err
+	}
+	if exists {
+		err := status.Errorf(codes.AlreadyExists, "port %s already exists", name)
+		return err
+	}
+	return nil
+}
+
+func (d *Dataplane) addPort(ctx context.Context, port *Port) error {
+	if err := d.ovs.AddPort(ctx, Bridge, port); err != nil {
+		fmt.Printf("Failed to add OVS port: %v", err)
+		return err
+	}
+	return nil
+}
+
+func (d *Dataplane) delPort(ctx context.Context, name string) error {
+	if err := d.ovs.DelPort(ctx, Bridge, name); err != nil {
+		fmt.Printf("Failed to delete OVS port: %v", err)
+		return err
+	}
+	return nil
+}
+
+func vxlanName(obj *pb.LogicalBridge) string {
+	return fmt.Sprintf("vni%d", *obj.Spec.Vni)
+}
+
+func vlanName(bridge *pb.LogicalBridge) string {
+	return fmt.Sprintf("vlan%d", bridge.Spec.VlanId)
+}
+
+func ipv4(addr uint32) net.IP {
+	ip := make(net.IP, 4)
+	binary.BigEndian.PutUint32(ip, addr)
+	return ip
+}
+
+// CreateLogicalBridge adds the vxlan port of the LogicalBridge, the vlan itself only exists as
+// the tag of the ports. The remote VTEPs are left to the flows of the bridge (remote_ip=flow),
+// a geneve port goes to the remote TEP of its tunnel
+func (d *Dataplane) CreateLogicalBridge(ctx context.Context, obj *pb.LogicalBridge) error {
+	if obj.Spec.Vni == nil {
+		return nil
+	}
+	// Example: ovs-vsctl add-port br-tenant vni10 tag=10 -- set interface vni10 type=vxlan options:key=10
+	options := map[string]string{
+		"key":       strconv.Itoa(int(*obj.Spec.Vni)),
+		"local_ip":  ipv4(obj.Spec.VtepIpPrefix.GetAddr().GetV4Addr()).String(),
+		"remote_ip": "flow",
+		"dst_port":  strconv.Itoa(d.server.Vxlan.Port),
+	}
+	// MAC learning is left to the flows
+	if d.server.Vxlan.TTL != 0 {
+		options["ttl"] = strconv.Itoa(d.server.Vxlan.TTL)
+	}
+	portType := "vxlan"
+	// Example: ovs-vsctl set interface vni10 type=geneve options:remote_ip=10.0.0.9 options:dst_port=6081
+	if tunnel, ok := d.server.GeneveTunnels[obj.Name]; ok {
+		portType = string(evpn.TunnelGeneve)
+		options["remote_ip"] = tunnel.Remote
+		options["dst_port"] = strconv.Itoa(tunnel.Port)
+		delete(options, "ttl")
+		if tunnel.TTL != 0 {
+			options["ttl"] = strconv.Itoa(tunnel.TTL)
+		}
+	}
+	switch d.server.Vxlan.TOS {
+	case 0:
+	case 1:
+		options["tos"] = "inherit"
+	default:
+		options["tos"] = strconv.Itoa(d.server.Vxlan.TOS)
+	}
+	return d.addPort(ctx, &Port{
+		Name:    vxlanName(obj),
+		Tag:     int(obj.Spec.VlanId),
+		Type:    portType,
+		Options: options,
+	})
+}
+
+// UpdateLogicalBridge checks the vxlan port is still there
+func (d *Dataplane) UpdateLogicalBridge(ctx context.Context, old *pb.LogicalBridge, _ *pb.LogicalBridge) error {
+	return d.GetLogicalBridge(ctx, old)
+}
+
+// DeleteLogicalBridge removes the vxlan port
+func (d *Dataplane) DeleteLogicalBridge(ctx context.Context, obj *pb.LogicalBridge) error {
+	if obj.Spec.Vni == nil {
+		return nil
+	}
+	return d.delPort(ctx, vxlanName(obj))
+}
+
+// GetLogicalBridge fails with NotFound when the vxlan port is missing
+func (d *Dataplane) GetLogicalBridge(ctx context.Context, obj *pb.LogicalBridge) error {
+	if obj.Spec.Vni == nil {
+		return nil
+	}
+	return d.checkPort(ctx, vxlanName(obj))
+}
+
+// CheckLogicalBridge fails when the OVS bridge or the vxlan port is missing
+func (d *Dataplane) CheckLogicalBridge(ctx context.Context, obj *pb.LogicalBridge) error {
+	if err := d.checkBridge(ctx); err != nil {
+		return err
+	}
+	return d.GetLogicalBridge(ctx, obj)
+}
+
+// PrecheckCreateLogicalBridge fails when the OVS bridge is missing or the vxlan port exists
+func (d *Dataplane) PrecheckCreateLogicalBridge(ctx context.Context, obj *pb.LogicalBridge) error {
+	if obj.Spec.Vni == nil {
+		return nil
+	}
+	if err := d.checkBridge(ctx); err != nil {
+		return err
+	}
+	return d.checkPortAbsent(ctx, vxlanName(obj))
+}
+
+// ResyncLogicalBridge adds the vxlan port again when it is missing
+func (d *Dataplane) ResyncLogicalBridge(ctx context.Context, obj *pb.LogicalBridge) error {
+	return d.CreateLogicalBridge(ctx, obj)
+}
+
+// BindBridgePort adds the port to the OVS bridge, tagged with the vlans of its LogicalBridges
+func (d *Dataplane) BindBridgePort(ctx context.Context, obj *pb.BridgePort) error {
+	if !d.server.MacLearning(obj.Name) {
+		msg := fmt.Sprintf("Turning MAC learning off on BridgePort %s is not supported by the OVS dataplane", obj.Name)
+		return status.Error(codes.Unimplemented, msg)
+	}
+	trunk := d.server.PortTrunkVlans(obj.Name)
+	if len(trunk.Translations) > 0 {
+		msg := fmt.Sprintf("Translating the vlans of BridgePort %s is not supported by the OVS dataplane", obj.Name)
+		return status.Error(codes.Unimplemented, msg)
+	}
+	if trunk.ServiceVlan != 0 {
+		msg := fmt.Sprintf("Service-tagged BridgePort %s is not supported by the OVS dataplane", obj.Name)
+		return status.Error(codes.Unimplemented, msg)
+	}
+	if d.server.IsMacsecPort(obj) {
+		msg := fmt.Sprintf("MACsec on BridgePort %s is not supported by the OVS dataplane", obj.Name)
+		return status.Error(codes.Unimplemented, msg)
+	}
+	port := &Port{Name: d.server.PortKernelName(obj.Name)}
+	var vids []int
+	for _, bridgeRefName := range obj.Spec.LogicalBridges {
+		bridgeObject, ok := d.server.Bridges[bridgeRefName]
+		if !ok {
+			err := status.Errorf(codes.NotFound, "unable to find key %s", bridgeRefName)
+			return err
+		}
+		vids = append(vids, int(bridgeObject.Spec.VlanId))
+	}
+	if len(vids) > 0 {
+		switch obj.Spec.Ptype {
+		case pb.BridgePortType_ACCESS:
+			// Example: ovs-vsctl add-port br-tenant eth2 -- set port eth2 vlan_mode=access tag=20
+			port.VlanMode, port.Tag = "access", vids[0]
+		case pb.BridgePortType_TRUNK:
+			// Example: ovs-vsctl add-port br-tenant eth2 -- set port eth2 vlan_mode=trunk trunks=10,20
+			port.VlanMode, port.Trunks = "trunk", vids
+			if trunk.Native != 0 {
+				// Example: ovs-vsctl set port eth2 vlan_mode=native-untagged tag=10 trunks=10,20
+				port.VlanMode, port.Tag = "native-untagged", int(trunk.Native)
+			}
+		default:
+			msg := fmt.Sprintf("Only ACCESS or TRUNK supported and not (%d)", obj.Spec.Ptype)
+			return status.Error(codes.InvalidArgument, msg)
+		}
+	}
+	return d.addPort(ctx, port)
+}
+
+// UpdateBridgePort sets the vlans of the port again
+func (d *Dataplane) UpdateBridgePort(ctx context.Context, _ *pb.BridgePort, obj *pb.BridgePort) error {
+	if err := d.GetBridgePort(ctx, obj); err != nil {
+		return err
+	}
+	return d.BindBridgePort(ctx, obj)
+}
+
+// UnbindBridgePort removes the port from the OVS bridge
+func (d *Dataplane) UnbindBridgePort(ctx context.Context, obj *pb.BridgePort) error {
+	return d.delPort(ctx, d.server.PortKernelName(obj.Name))
+}
+
+// GetBridgePort fails with NotFound when the port is not in the OVS bridge
+func (d *Dataplane) GetBridgePort(ctx context.Context, obj *pb.BridgePort) error {
+	return d.checkPort(ctx, d.server.PortKernelName(obj.Name))
+}
+
+// CheckBridgePort fails when the port is not in the OVS bridge
+func (d *Dataplane) CheckBridgePort(ctx context.Context, obj *pb.BridgePort) error {
+	return d.GetBridgePort(ctx, obj)
+}
+
+// PrecheckBindBridgePort fails when the OVS bridge or the port device is missing
+func (d *Dataplane) PrecheckBindBridgePort(ctx context.Context, obj *pb.BridgePort) error {
+	if err := d.checkBridge(ctx); err != nil {
+		return err
+	}
+	resourceID := d.server.PortKernelName(obj.Name)
+	if _, err := d.nLink.LinkByName(ctx, resourceID); err != nil {
+		err := status.Errorf(codes.NotFound, "unable to find key %s", resourceID)
+		return err
+	}
+	return nil
+}
+
+// ResyncBridgePort adds the port and its vlans again
+func (d *Dataplane) ResyncBridgePort(ctx context.Context, obj *pb.BridgePort) error {
+	return d.BindBridgePort(ctx, obj)
+}
+
+// createSviDevice adds the internal port of the Svi to the OVS bridge, then configures
+// its kernel device like the Linux dataplane does with a vlan device
+func (d *Dataplane) createSviDevice(ctx context.Context, obj *pb.Svi, bridge *pb.LogicalBridge, vrf *pb.Vrf) error {
+	name := vlanName(bridge)
+	// Example: ovs-vsctl add-port br-tenant vlan10 tag=10 -- set interface vlan10 type=internal
+	if err := d.addPort(ctx, &Port{Name: name, Tag: int(bridge.Spec.VlanId), Type: "internal"}); err != nil {
+		return err
+	}
+	vlandev, err := d.nLink.LinkByName(ctx, name)
+	if err != nil {
+		err := status.Errorf(codes.NotFound, "unable to find key %s", name)
+		return err
+	}
+	// Example: ip link set <link_svi> addr aa:bb:cc:00:00:41
+	if len(obj.Spec.MacAddress) > 0 {
+		if err := d.nLink.LinkSetHardwareAddr(ctx, vlandev, obj.Spec.MacAddress); err != nil {
+			fmt.Printf("Failed to set MAC on link: %v", err)
+			return err
+		}
+	}
+	// Example: ip address add <svi-ip-with prefixlength> dev <link_svi>
+	for _, gwip := range obj.Spec.GwIpPrefix {
+		addr := &netlink.Addr{IPNet: &net.IPNet{IP: ipv4(gwip.Addr.GetV4Addr()), Mask: net.CIDRMask(int(gwip.Len), 32)}}
+		if err := d.nLink.AddrAdd(ctx, vlandev, addr); err != nil {
+			fmt.Printf("Failed to set IP on link: %v", err)
+			return err
+		}
+	}
+	vrfdev, err := d.nLink.LinkByName(ctx, d.server.VrfKernelName(vrf.Name))
+	if err != nil {
+		err := status.Errorf(codes.NotFound, "unable to find key %s", vrf.Name)
+		return err
+	}
+	// Example: ip link set <link_svi> master <vrf-name> up
+	if err := d.nLink.LinkSetMaster(ctx, vlandev, vrfdev); err != nil {
+		fmt.Printf("Failed to add vlandev to vrf: %v", err)
+		return err
+	}
+	if err := d.nLink.LinkSetUp(ctx, vlandev); err != nil {
+		fmt.Printf("Failed to up link: %v", err)
+		return err
+	}
+	return nil
+}
+
+// frrCreateSvi peers with the hosts behind the Svi and advertises its anycast gateway, as the
+// Linux dataplane does
+func (d *Dataplane) frrCreateSvi(ctx context.Context, obj *pb.Svi, bridge *pb.LogicalBridge, vrf *pb.Vrf) error {
+	if obj.Spec.EnableBgp {
+		data, err := d.frr.FrrBgpCmd(ctx, fmt.Sprintf(
+			`configure terminal
+			router bgp %[4]d vrf %[1]s
+			bgp disable-ebgp-connected-route-check
+			neighbor %[2]s peer-group
+			neighbor %[2]s remote-as %[3]d
+			neighbor %[2]s as-override
+			neighbor %[2]s soft-reconfiguration inbound
+			exit`, d.server.VrfKernelName(vrf.Name), vlanName(bridge), obj.Spec.RemoteAs, d.server.Gateway.LocalAs))
+		fmt.Printf("FrrBgpCmd: %v:%v", data, err)
+		if err != nil {
+			return err
+		}
+	}
+	if !d.server.IsAnycastGateway(obj.Name) {
+		return nil
+	}
+	return d.frrAdvertiseDefaultGw(ctx, bridge, "advertise-default-gw")
+}
+
+// frrAdvertiseDefaultGw applies the advertise-default-gw command, or its negation, to the vni
+// of the LogicalBridge of an anycast gateway
+func (d *Dataplane) frrAdvertiseDefaultGw(ctx context.Context, bridge *pb.LogicalBridge, command string) error {
+	data, err := d.frr.FrrBgpCmd(ctx, fmt.Sprintf(
+		`configure terminal
+		router bgp %d
+		address-family l2vpn evpn
+		vni %d
+		%s
+		exit-vni
+		exit-address-family
+		exit`, d.server.Gateway.LocalAs, *bridge.Spec.Vni, command))
+	fmt.Printf("FrrBgpCmd: %v:%v", data, err)
+	return err
+}
+
+// CreateSvi adds the internal port of the Svi and peers over it
+func (d *Dataplane) CreateSvi(ctx context.Context, obj *pb.Svi, bridge *pb.LogicalBridge, vrf *pb.Vrf) error {
+	if err := d.createSviDevice(ctx, obj, bridge, vrf); err != nil {
+		return err
+	}
+	return d.frrCreateSvi(ctx, obj, bridge, vrf)
+}
+
+// UpdateSvi checks the internal port is still there
+func (d *Dataplane) UpdateSvi(ctx context.Context, old *pb.Svi, _ *pb.Svi, bridge *pb.LogicalBridge) error {
+	return d.GetSvi(ctx, old, bridge)
+}
+
+// DeleteSvi removes the peering, the anycast gateway and the internal port, its addresses go with it
+func (d *Dataplane) DeleteSvi(ctx context.Context, obj *pb.Svi, bridge *pb.LogicalBridge, vrf *pb.Vrf) error {
+	if obj.Spec.EnableBgp {
+		data, err := d.frr.FrrBgpCmd(ctx, fmt.Sprintf(
+			`configure terminal
+			router bgp %d vrf %s
+			no neighbor %s peer-group
+			exit`, d.server.Gateway.LocalAs, d.server.VrfKernelName(vrf.Name), vlanName(bridge)))
+		fmt.Printf("FrrBgpCmd: %v:%v", data, err)
+		if err != nil {
+			return err
+		}
+	}
+	if d.server.IsAnycastGateway(obj.Name) {
+		if err := d.frrAdvertiseDefaultGw(ctx, bridge, "no advertise-default-gw"); err != nil {
+			return err
+		}
+	}
+	return d.delPort(ctx, vlanName(bridge))
+}
+
+// GetSvi fails with NotFound when the internal port is missing
+func (d *Dataplane) GetSvi(ctx context.Context, _ *pb.Svi, bridge *pb.LogicalBridge) error {
+	return d.checkPort(ctx, vlanName(bridge))
+}
+
+// CheckSvi fails when the internal port is missing
+func (d *Dataplane) CheckSvi(ctx context.Context, obj *pb.Svi) error {
+	bridge, ok := d.server.Bridges[obj.Spec.LogicalBridge]
+	if !ok {
+		err := status.Errorf(codes.NotFound, "unable to find key %s", obj.Spec.LogicalBridge)
+		return err
+	}
+	return d.GetSvi(ctx, obj, bridge)
+}
+
+// PrecheckCreateSvi fails when the OVS bridge or the vrf device is missing, or the internal port exists
+func (d *Dataplane) PrecheckCreateSvi(ctx context.Context, _ *pb.Svi, bridge *pb.LogicalBridge, vrf *pb.Vrf) error {
+	if err := d.checkBridge(ctx); err != nil {
+		return err
+	}
+	vrfName := d.server.VrfKernelName(vrf.Name)
+	if _, err := d.nLink.LinkByName(ctx, vrfName); err != nil {
+		err := status.Errorf(codes.NotFound, "unable to find key %s", vrfName)
+		return err
+	}
+	return d.checkPortAbsent(ctx, vlanName(bridge))
+}
+
+// ResyncSvi recreates the internal port when it is missing or its Vrf was recreated,
+// and always re-applies FRR
+func (d *Dataplane) ResyncSvi(ctx context.Context, obj *pb.Svi, bridge *pb.LogicalBridge, vrf *pb.Vrf, force bool) error {
+	if err := d.GetSvi(ctx, obj, bridge); err != nil || force {
+		log.Printf("Recreating Svi %v", obj.Name)
+		// best effort removal of the port left over
+		if err := d.delPort(ctx, vlanName(bridge)); err != nil {
+			log.Printf("Failed to clean up Svi %v: %v", obj.Name, err)
+		}
+		if err := d.createSviDevice(ctx, obj, bridge, vrf); err != nil {
+			return err
+		}
+	}
+	return d.frrCreateSvi(ctx, obj, bridge, vrf)
+}
+
+// SetMacAgeing is not supported, the MAC learning of the OVS bridge is left to the flows
+func (d *Dataplane) SetMacAgeing(_ context.Context, _ time.Duration) error {
+	return status.Error(codes.Unimplemented, "Setting the MAC ageing time is not supported by the OVS dataplane")
+}
+
+// FlushBridgeMacs is not supported, the MAC learning of the OVS bridge is left to the flows
+func (d *Dataplane) FlushBridgeMacs(_ context.Context, bridge *pb.LogicalBridge, _ *pb.BridgePort) (int, error) {
+	msg := fmt.Sprintf("Flushing the MAC addresses of %s is not supported by the OVS dataplane", bridge.Name)
+	return 0, status.Error(codes.Unimplemented, msg)
+}
+
+// CreateStaticFdbEntry is not supported, the OVS bridge forwards with its flows instead of an fdb
+func (d *Dataplane) CreateStaticFdbEntry(_ context.Context, obj *evpn.StaticFdbEntry, _ *pb.LogicalBridge) error {
+	msg := fmt.Sprintf("StaticFdbEntry %s is not supported by the OVS dataplane", obj.Name)
+	return status.Error(codes.Unimplemented, msg)
+}
+
+// DeleteStaticFdbEntry is not supported, no entry can be created
+func (d *Dataplane) DeleteStaticFdbEntry(_ context.Context, obj *evpn.StaticFdbEntry, _ *pb.LogicalBridge) error {
+	msg := fmt.Sprintf("StaticFdbEntry %s is not supported by the OVS dataplane", obj.Name)
+	return status.Error(codes.Unimplemented, msg)
+}
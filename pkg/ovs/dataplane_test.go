@@ -0,0 +1,145 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package ovs plugs the EVPN objects into an Open vSwitch bridge instead of the kernel bridge
+package ovs
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/philippgille/gokv/gomap"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+	pc "github.com/opiproject/opi-api/network/opinetcommon/v1alpha1/gen/go"
+	"github.com/opiproject/opi-evpn-bridge/pkg/evpn"
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils/mocks"
+)
+
+// fakeSwitch records the ports added to the bridge
+type fakeSwitch struct {
+	added []*Port
+	ports map[string]bool
+}
+
+func (s *fakeSwitch) BridgeExists(_ context.Context, bridge string) (bool, error) {
+	return bridge == Bridge, nil
+}
+
+func (s *fakeSwitch) PortExists(_ context.Context, name string) (bool, error) {
+	return s.ports[name], nil
+}
+
+func (s *fakeSwitch) AddPort(_ context.Context, _ string, port *Port) error {
+	s.added = append(s.added, port)
+	return nil
+}
+
+func (s *fakeSwitch) DelPort(_ context.Context, _ string, name string) error {
+	delete(s.ports, name)
+	return nil
+}
+
+func (s *fakeSwitch) OtherConfig(_ context.Context) (map[string]string, error) {
+	return map[string]string{}, nil
+}
+
+func newTestDataplane(t *testing.T, ovs *fakeSwitch) *Dataplane {
+	server := evpn.NewServerWithArgs(mocks.NewNetlink(t), mocks.NewFrr(t), gomap.NewStore(gomap.DefaultOptions))
+	server.Bridges["//network.opiproject.org/bridges/vlan10"] = &pb.LogicalBridge{Spec: &pb.LogicalBridgeSpec{VlanId: 10}}
+	server.Bridges["//network.opiproject.org/bridges/vlan20"] = &pb.LogicalBridge{Spec: &pb.LogicalBridgeSpec{VlanId: 20}}
+	return NewDataplane(server, ovs, mocks.NewNetlink(t), mocks.NewFrr(t))
+}
+
+func TestDataplane_BindBridgePort(t *testing.T) {
+	tests := map[string]struct {
+		ptype   pb.BridgePortType
+		port    *Port
+		wantErr bool
+	}{
+		"access": {
+			ptype: pb.BridgePortType_ACCESS,
+			port:  &Port{Name: "eth2", VlanMode: "access", Tag: 10},
+		},
+		"trunk": {
+			ptype: pb.BridgePortType_TRUNK,
+			port:  &Port{Name: "eth2", VlanMode: "trunk", Trunks: []int{10, 20}},
+		},
+		"unknown type": {
+			ptype:   pb.BridgePortType_UNKNOWN,
+			wantErr: true,
+		},
+	}
+
+	for testName, tt := range tests {
+		t.Run(testName, func(t *testing.T) {
+			ovs := &fakeSwitch{ports: map[string]bool{}}
+			dataplane := newTestDataplane(t, ovs)
+			obj := &pb.BridgePort{
+				Name: "//network.opiproject.org/ports/eth2",
+				Spec: &pb.BridgePortSpec{
+					Ptype:          tt.ptype,
+					LogicalBridges: []string{"//network.opiproject.org/bridges/vlan10", "//network.opiproject.org/bridges/vlan20"},
+				},
+			}
+			err := dataplane.BindBridgePort(context.Background(), obj)
+			if (err != nil) != tt.wantErr {
+				t.Error("error: expected", tt.wantErr, "received", err)
+			}
+			if tt.port != nil && (len(ovs.added) != 1 || !reflect.DeepEqual(ovs.added[0], tt.port)) {
+				t.Error("ports: expected", tt.port, "received", ovs.added)
+			}
+		})
+	}
+}
+
+func TestDataplane_PrecheckCreateLogicalBridge(t *testing.T) {
+	vni := uint32(10)
+	obj := &pb.LogicalBridge{Spec: &pb.LogicalBridgeSpec{VlanId: 10, Vni: &vni, VtepIpPrefix: &pc.IPPrefix{}}}
+	ovs := &fakeSwitch{ports: map[string]bool{}}
+	dataplane := newTestDataplane(t, ovs)
+
+	if err := dataplane.PrecheckCreateLogicalBridge(context.Background(), obj); err != nil {
+		t.Error("error: expected", nil, "received", err)
+	}
+	if err := dataplane.CreateLogicalBridge(context.Background(), obj); err != nil {
+		t.Fatal("error: expected", nil, "received", err)
+	}
+	ovs.ports["vni10"] = true
+	if err := dataplane.PrecheckCreateLogicalBridge(context.Background(), obj); err == nil {
+		t.Error("error: expected the vxlan port to exist")
+	}
+}
+
+func TestDataplane_CreateLogicalBridgeVxlanOptions(t *testing.T) {
+	vni := uint32(10)
+	obj := &pb.LogicalBridge{Spec: &pb.LogicalBridgeSpec{VlanId: 10, Vni: &vni, VtepIpPrefix: &pc.IPPrefix{}}}
+	ovs := &fakeSwitch{ports: map[string]bool{}}
+	dataplane := newTestDataplane(t, ovs)
+	dataplane.server.Vxlan = evpn.VxlanOptions{Port: 8472, TTL: 64, TOS: 1}
+
+	if err := dataplane.CreateLogicalBridge(context.Background(), obj); err != nil {
+		t.Fatal("error: expected", nil, "received", err)
+	}
+	expected := map[string]string{"key": "10", "local_ip": "0.0.0.0", "remote_ip": "flow", "dst_port": "8472", "ttl": "64", "tos": "inherit"}
+	if len(ovs.added) != 1 || !reflect.DeepEqual(ovs.added[0].Options, expected) {
+		t.Error("options: expected", expected, "received", ovs.added)
+	}
+}
+
+func TestDataplane_CreateLogicalBridgeGeneve(t *testing.T) {
+	vni := uint32(10)
+	obj := &pb.LogicalBridge{Name: "bridges/geneve", Spec: &pb.LogicalBridgeSpec{VlanId: 10, Vni: &vni, VtepIpPrefix: &pc.IPPrefix{}}}
+	ovs := &fakeSwitch{ports: map[string]bool{}}
+	dataplane := newTestDataplane(t, ovs)
+	dataplane.server.GeneveTunnels[obj.Name] = evpn.GeneveTunnel{Remote: "10.0.0.9", Port: 6081}
+
+	if err := dataplane.CreateLogicalBridge(context.Background(), obj); err != nil {
+		t.Fatal("error: expected", nil, "received", err)
+	}
+	expected := map[string]string{"key": "10", "local_ip": "0.0.0.0", "remote_ip": "10.0.0.9", "dst_port": "6081"}
+	if len(ovs.added) != 1 || ovs.added[0].Type != "geneve" || !reflect.DeepEqual(ovs.added[0].Options, expected) {
+		t.Error("options: expected", expected, "received", ovs.added)
+	}
+}
@@ -0,0 +1,218 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package ovs plugs the EVPN objects into an Open vSwitch bridge instead of the kernel bridge
+package ovs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ovn-org/libovsdb/client"
+	"github.com/ovn-org/libovsdb/model"
+	"github.com/ovn-org/libovsdb/ovsdb"
+)
+
+// Port is a port of an OVS bridge with its single interface
+type Port struct {
+	Name string
+	// Tag is the access vlan, 0 for none
+	Tag    int
+	Trunks []int
+	// VlanMode is access or trunk, empty for the OVS default
+	VlanMode string
+	// Type is the interface type, empty for a system device
+	Type    string
+	Options map[string]string
+}
+
+// Switch is the part of OVSDB the dataplane needs, the equivalent of a few ovs-vsctl
+// commands, implemented by OvsdbSwitch
+type Switch interface {
+	BridgeExists(ctx context.Context, bridge string) (bool, error)
+	PortExists(ctx context.Context, name string) (bool, error)
+	// AddPort adds the port, or updates the vlans of an existing one (ovs-vsctl --may-exist add-port)
+	AddPort(ctx context.Context, bridge string, port *Port) error
+	// DelPort deletes the port, a missing one is not an error (ovs-vsctl --if-exists del-port)
+	DelPort(ctx context.Context, bridge string, name string) error
+	// OtherConfig returns the other_config of the Open_vSwitch table, e.g. hw-offload
+	OtherConfig(ctx context.Context) (map[string]string, error)
+}
+
+// ovsBridgeRow, ovsPortRow, ovsInterfaceRow and openvSwitchRow are the columns used of the rows
+// of the Open_vSwitch database
+type ovsBridgeRow struct {
+	UUID  string   `ovsdb:"_uuid"`
+	Name  string   `ovsdb:"name"`
+	Ports []string `ovsdb:"ports"`
+}
+
+type ovsPortRow struct {
+	UUID       string   `ovsdb:"_uuid"`
+	Name       string   `ovsdb:"name"`
+	Interfaces []string `ovsdb:"interfaces"`
+	Tag        *int     `ovsdb:"tag"`
+	Trunks     []int    `ovsdb:"trunks"`
+	VlanMode   *string  `ovsdb:"vlan_mode"`
+}
+
+type ovsInterfaceRow struct {
+	UUID    string            `ovsdb:"_uuid"`
+	Name    string            `ovsdb:"name"`
+	Type    string            `ovsdb:"type"`
+	Options map[string]string `ovsdb:"options"`
+}
+
+type openvSwitchRow struct {
+	UUID        string            `ovsdb:"_uuid"`
+	OtherConfig map[string]string `ovsdb:"other_config"`
+}
+
+// OvsdbSwitch talks to ovsdb-server with libovsdb, its cache is kept up to date by a monitor
+type OvsdbSwitch struct {
+	client client.Client
+}
+
+// DialOvsdb connects to ovsdb-server, e.g. at unix:/var/run/openvswitch/db.sock
+func DialOvsdb(ctx context.Context, endpoint string) (*OvsdbSwitch, error) {
+	dbModel, err := model.NewClientDBModel("Open_vSwitch", map[string]model.Model{
+		"Open_vSwitch": &openvSwitchRow{},
+		"Bridge":       &ovsBridgeRow{},
+		"Port":         &ovsPortRow{},
+		"Interface":    &ovsInterfaceRow{},
+	})
+	if err != nil {
+		return nil, err
+	}
+	c, err := client.NewOVSDBClient(dbModel, client.WithEndpoint(endpoint))
+	if err != nil {
+		return nil, err
+	}
+	if err := c.Connect(ctx); err != nil {
+		return nil, err
+	}
+	if _, err := c.MonitorAll(ctx); err != nil {
+		c.Close()
+		return nil, err
+	}
+	return &OvsdbSwitch{client: c}, nil
+}
+
+// Close disconnects from ovsdb-server
+func (o *OvsdbSwitch) Close() {
+	o.client.Close()
+}
+
+func (o *OvsdbSwitch) transact(ctx context.Context, ops []ovsdb.Operation) error {
+	results, err := o.client.Transact(ctx, ops...)
+	if err != nil {
+		return err
+	}
+	_, err = ovsdb.CheckOperationResults(results, ops)
+	return err
+}
+
+func (o *OvsdbSwitch) bridge(ctx context.Context, name string) (*ovsBridgeRow, error) {
+	bridge := &ovsBridgeRow{Name: name}
+	if err := o.client.Get(ctx, bridge); err != nil {
+		return nil, err
+	}
+	return bridge, nil
+}
+
+// BridgeExists reports whether the bridge is in the database
+func (o *OvsdbSwitch) BridgeExists(ctx context.Context, bridge string) (bool, error) {
+	_, err := o.bridge(ctx, bridge)
+	if errors.Is(err, client.ErrNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// PortExists reports whether the port is in the database
+func (o *OvsdbSwitch) PortExists(ctx context.Context, name string) (bool, error) {
+	err := o.client.Get(ctx, &ovsPortRow{Name: name})
+	if errors.Is(err, client.ErrNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// AddPort creates the port and its interface in one transaction
+func (o *OvsdbSwitch) AddPort(ctx context.Context, bridgeName string, port *Port) error {
+	row := &ovsPortRow{Name: port.Name, Trunks: port.Trunks}
+	if port.Tag != 0 {
+		row.Tag = &port.Tag
+	}
+	if port.VlanMode != "" {
+		row.VlanMode = &port.VlanMode
+	}
+	existing := &ovsPortRow{Name: port.Name}
+	if err := o.client.Get(ctx, existing); err == nil {
+		// only the vlans of an existing port are updated, like ovs-vsctl set port
+		row.UUID = existing.UUID
+		ops, err := o.client.Where(row).Update(row, &row.Tag, &row.Trunks, &row.VlanMode)
+		if err != nil {
+			return err
+		}
+		return o.transact(ctx, ops)
+	} else if !errors.Is(err, client.ErrNotFound) {
+		return err
+	}
+	bridge, err := o.bridge(ctx, bridgeName)
+	if err != nil {
+		return fmt.Errorf("failed to find bridge %s: %w", bridgeName, err)
+	}
+	iface := &ovsInterfaceRow{UUID: "iface", Name: port.Name, Type: port.Type, Options: port.Options}
+	row.UUID = "port"
+	row.Interfaces = []string{iface.UUID}
+	ops, err := o.client.Create(iface, row)
+	if err != nil {
+		return err
+	}
+	mutate, err := o.client.Where(bridge).Mutate(bridge, model.Mutation{
+		Field:   &bridge.Ports,
+		Mutator: ovsdb.MutateOperationInsert,
+		Value:   []string{row.UUID},
+	})
+	if err != nil {
+		return err
+	}
+	return o.transact(ctx, append(ops, mutate...))
+}
+
+// DelPort removes the port from the bridge, ovsdb-server garbage collects it with its interface
+func (o *OvsdbSwitch) DelPort(ctx context.Context, bridgeName string, name string) error {
+	port := &ovsPortRow{Name: name}
+	if err := o.client.Get(ctx, port); errors.Is(err, client.ErrNotFound) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	bridge, err := o.bridge(ctx, bridgeName)
+	if err != nil {
+		return fmt.Errorf("failed to find bridge %s: %w", bridgeName, err)
+	}
+	ops, err := o.client.Where(bridge).Mutate(bridge, model.Mutation{
+		Field:   &bridge.Ports,
+		Mutator: ovsdb.MutateOperationDelete,
+		Value:   []string{port.UUID},
+	})
+	if err != nil {
+		return err
+	}
+	return o.transact(ctx, ops)
+}
+
+// OtherConfig returns the other_config of the single row of the Open_vSwitch table
+func (o *OvsdbSwitch) OtherConfig(ctx context.Context) (map[string]string, error) {
+	var rows []openvSwitchRow
+	if err := o.client.List(ctx, &rows); err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, errors.New("empty Open_vSwitch table")
+	}
+	return rows[0].OtherConfig, nil
+}
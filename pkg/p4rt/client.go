@@ -0,0 +1,141 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Intel Corporation, or its subsidiaries.
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package p4rt programs the EVPN objects as entries of the tables of a P4 pipeline
+package p4rt
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	protov1 "github.com/golang/protobuf/proto"
+	p4config "github.com/p4lang/p4runtime/go/p4/config/v1"
+	p4 "github.com/p4lang/p4runtime/go/p4/v1"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/encoding/prototext"
+)
+
+// Client writes the entries to the P4Runtime server of the device, as its primary
+// controller, with the names of the P4Info of the loaded program
+type Client struct {
+	client     p4.P4RuntimeClient
+	stream     p4.P4Runtime_StreamChannelClient
+	deviceID   uint64
+	electionID *p4.Uint128
+	tables     map[string]*p4config.Table
+	actions    map[string]*p4config.Action
+}
+
+// LoadP4Info reads the P4Info of the loaded program in text format
+func LoadP4Info(fileName string) (*p4config.P4Info, error) {
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	info := &p4config.P4Info{}
+	if err := prototext.Unmarshal(data, protov1.MessageV2(info)); err != nil {
+		return nil, fmt.Errorf("failed to parse P4Info %s: %w", fileName, err)
+	}
+	return info, nil
+}
+
+// NewClient becomes the primary controller of the device over conn, the stream
+// is kept open for as long as ctx to stay primary
+func NewClient(ctx context.Context, conn *grpc.ClientConn, deviceID uint64, info *p4config.P4Info) (*Client, error) {
+	t := &Client{
+		client:     p4.NewP4RuntimeClient(conn),
+		deviceID:   deviceID,
+		electionID: &p4.Uint128{High: 0, Low: 1},
+		tables:     map[string]*p4config.Table{},
+		actions:    map[string]*p4config.Action{},
+	}
+	for _, table := range info.Tables {
+		t.tables[table.Preamble.Name] = table
+	}
+	for _, action := range info.Actions {
+		t.actions[action.Preamble.Name] = action
+	}
+	stream, err := t.client.StreamChannel(ctx)
+	if err != nil {
+		return nil, err
+	}
+	arbitration := &p4.MasterArbitrationUpdate{DeviceId: deviceID, ElectionId: t.electionID}
+	if err := stream.Send(&p4.StreamMessageRequest{Update: &p4.StreamMessageRequest_Arbitration{Arbitration: arbitration}}); err != nil {
+		return nil, err
+	}
+	response, err := stream.Recv()
+	if err != nil {
+		return nil, err
+	}
+	if code := response.GetArbitration().GetStatus().GetCode(); code != 0 {
+		return nil, fmt.Errorf("not the primary controller of device %d: %s", deviceID, response.GetArbitration().GetStatus().GetMessage())
+	}
+	t.stream = stream
+	return t, nil
+}
+
+// Insert adds the entries in one write, all or none
+func (t *Client) Insert(ctx context.Context, entries []Entry) error {
+	return t.write(ctx, p4.Update_INSERT, entries)
+}
+
+// Delete removes the entries in one write, all or none
+func (t *Client) Delete(ctx context.Context, entries []Entry) error {
+	return t.write(ctx, p4.Update_DELETE, entries)
+}
+
+func (t *Client) write(ctx context.Context, updateType p4.Update_Type, entries []Entry) error {
+	request := &p4.WriteRequest{DeviceId: t.deviceID, ElectionId: t.electionID, Atomicity: p4.WriteRequest_DATAPLANE_ATOMIC}
+	for _, entry := range entries {
+		tableEntry, err := t.tableEntry(entry, updateType != p4.Update_DELETE)
+		if err != nil {
+			return err
+		}
+		request.Updates = append(request.Updates, &p4.Update{
+			Type:   updateType,
+			Entity: &p4.Entity{Entity: &p4.Entity_TableEntry{TableEntry: tableEntry}},
+		})
+	}
+	_, err := t.client.Write(ctx, request)
+	return err
+}
+
+// tableEntry translates the names of the entry to the IDs of the P4Info, the action is
+// only part of the entry when it is inserted
+func (t *Client) tableEntry(entry Entry, withAction bool) (*p4.TableEntry, error) {
+	table, ok := t.tables[entry.Table]
+	if !ok {
+		return nil, fmt.Errorf("table %s is not in the P4Info", entry.Table)
+	}
+	tableEntry := &p4.TableEntry{TableId: table.Preamble.Id}
+	for _, field := range table.MatchFields {
+		value, ok := entry.Match[field.Name]
+		if !ok {
+			return nil, fmt.Errorf("match field %s of table %s is missing", field.Name, entry.Table)
+		}
+		tableEntry.Match = append(tableEntry.Match, &p4.FieldMatch{
+			FieldId:        field.Id,
+			FieldMatchType: &p4.FieldMatch_Exact_{Exact: &p4.FieldMatch_Exact{Value: value}},
+		})
+	}
+	if !withAction {
+		return tableEntry, nil
+	}
+	action, ok := t.actions[entry.Action]
+	if !ok {
+		return nil, fmt.Errorf("action %s is not in the P4Info", entry.Action)
+	}
+	p4Action := &p4.Action{ActionId: action.Preamble.Id}
+	for _, param := range action.Params {
+		value, ok := entry.Params[param.Name]
+		if !ok {
+			return nil, fmt.Errorf("param %s of action %s is missing", param.Name, entry.Action)
+		}
+		p4Action.Params = append(p4Action.Params, &p4.Action_Param{ParamId: param.Id, Value: value})
+	}
+	tableEntry.Action = &p4.TableAction{Type: &p4.TableAction_Action{Action: p4Action}}
+	return tableEntry, nil
+}
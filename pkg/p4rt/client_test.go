@@ -0,0 +1,46 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Intel Corporation, or its subsidiaries.
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package p4rt programs the EVPN objects as entries of the tables of a P4 pipeline
+package p4rt
+
+import (
+	"testing"
+
+	p4config "github.com/p4lang/p4runtime/go/p4/config/v1"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+)
+
+func TestClient_tableEntry(t *testing.T) {
+	client := &Client{
+		tables: map[string]*p4config.Table{DefaultPipeline.VniTable: {
+			Preamble:    &p4config.Preamble{Id: 1, Name: DefaultPipeline.VniTable},
+			MatchFields: []*p4config.MatchField{{Id: 1, Name: "vni"}},
+		}},
+		actions: map[string]*p4config.Action{"set_bd": {
+			Preamble: &p4config.Preamble{Id: 2, Name: "set_bd"},
+			Params:   []*p4config.Action_Param{{Id: 1, Name: "bd"}, {Id: 2, Name: "untagged"}},
+		}},
+	}
+	vni := uint32(11)
+	entries := IRB{Pipeline: &DefaultPipeline}.LogicalBridgeEntries(&pb.LogicalBridge{Spec: &pb.LogicalBridgeSpec{VlanId: 11, Vni: &vni}})
+
+	entry, err := client.tableEntry(entries[0], true)
+	if err != nil {
+		t.Fatal("error: expected", nil, "received", err)
+	}
+	params := entry.GetAction().GetAction().GetParams()
+	if entry.TableId != 1 || len(entry.Match) != 1 || len(params) != 2 || string(params[0].Value) != "\x00\x0b" {
+		t.Error("entry: received", entry)
+	}
+	// the tunnel table is not in this P4Info
+	if _, err := client.tableEntry(entries[1], true); err == nil {
+		t.Error("error: expected a missing table")
+	}
+	// deletes only carry the match
+	if entry, err := client.tableEntry(entries[0], false); err != nil || entry.Action != nil {
+		t.Error("entry: expected no action, received", entry, err)
+	}
+}
@@ -0,0 +1,299 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Intel Corporation, or its subsidiaries.
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package p4rt programs the EVPN objects as entries of the tables of a P4 pipeline
+package p4rt
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"log"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+	"github.com/opiproject/opi-evpn-bridge/pkg/evpn"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Entry is an exact match entry of a P4 table, fields and params are named as in the P4Info
+// and their values are the P4Runtime canonical big endian bytestrings
+type Entry struct {
+	Table  string
+	Match  map[string][]byte
+	Action string
+	Params map[string][]byte
+}
+
+// Tables writes the entries of the P4 tables, implemented over P4Runtime by Client
+type Tables interface {
+	Insert(ctx context.Context, entries []Entry) error
+	Delete(ctx context.Context, entries []Entry) error
+}
+
+// Program translates the objects into the entries of the tables of a P4 program,
+// an object without entries is left to the Linux dataplane
+type Program interface {
+	VrfEntries(obj *pb.Vrf) []Entry
+	LogicalBridgeEntries(obj *pb.LogicalBridge) []Entry
+	// BridgePortEntries fails for the ports the program cannot forward to
+	BridgePortEntries(obj *pb.BridgePort, bridges []*pb.LogicalBridge) ([]Entry, error)
+	SviEntries(obj *pb.Svi, bridge *pb.LogicalBridge, vrf *pb.Vrf) []Entry
+}
+
+// Uint16 encodes a 16 bits field
+func Uint16(v uint32) []byte {
+	b := make([]byte, 2)
+	binary.BigEndian.PutUint16(b, uint16(v))
+	return b
+}
+
+// Uint32 encodes a 32 bits field
+func Uint32(v uint32) []byte {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, v)
+	return b
+}
+
+// Dataplane programs the entries of the P4 pipeline on top of the Linux dataplane of the
+// host CPU, which stays the control plane view FRR learns from and the slow path.
+// VRF-lite handoffs and static routes are left to the Linux dataplane
+type Dataplane struct {
+	evpn.Dataplane
+	server  *evpn.Server
+	program Program
+	tables  Tables
+}
+
+// NewDataplane wraps the current dataplane of the server, to be set with server.SetDataplane
+func NewDataplane(server *evpn.Server, program Program, tables Tables) *Dataplane {
+	return &Dataplane{Dataplane: server.Dataplane(), server: server, program: program, tables: tables}
+}
+
+func (d *Dataplane) bridgePortEntries(obj *pb.BridgePort) ([]Entry, error) {
+	bridges := make([]*pb.LogicalBridge, 0, len(obj.Spec.LogicalBridges))
+	for _, bridgeRefName := range obj.Spec.LogicalBridges {
+		bridgeObject, ok := d.server.Bridges[bridgeRefName]
+		if !ok {
+			err := status.Errorf(codes.NotFound, "unable to find key %s", bridgeRefName)
+			return nil, err
+		}
+		bridges = append(bridges, bridgeObject)
+	}
+	return d.program.BridgePortEntries(obj, bridges)
+}
+
+// insert writes the entries of an object already programmed in Linux, which is
+// removed again with undo when the pipeline rejects them
+func (d *Dataplane) insert(ctx context.Context, entries []Entry, undo func() error) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	if err := d.tables.Insert(ctx, entries); err != nil {
+		fmt.Printf("Failed to insert P4 entries: %v", err)
+		if err := undo(); err != nil {
+			log.Printf("Failed to clean up after the P4 failure: %v", err)
+		}
+		return err
+	}
+	return nil
+}
+
+func (d *Dataplane) delete(ctx context.Context, entries []Entry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	if err := d.tables.Delete(ctx, entries); err != nil {
+		fmt.Printf("Failed to delete P4 entries: %v", err)
+		return err
+	}
+	return nil
+}
+
+// replace swaps the entries of an updated object
+func (d *Dataplane) replace(ctx context.Context, old []Entry, entries []Entry) error {
+	if err := d.delete(ctx, old); err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+	return d.tables.Insert(ctx, entries)
+}
+
+// resync writes the entries again, the pipeline may have been reset with the kernel devices
+func (d *Dataplane) resync(ctx context.Context, entries []Entry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	if err := d.tables.Delete(ctx, entries); err != nil {
+		log.Printf("Failed to delete P4 entries before resync: %v", err)
+	}
+	return d.tables.Insert(ctx, entries)
+}
+
+// CreateVrf programs the Vrf in Linux and its entries in the pipeline
+func (d *Dataplane) CreateVrf(ctx context.Context, obj *pb.Vrf) error {
+	if err := d.Dataplane.CreateVrf(ctx, obj); err != nil {
+		return err
+	}
+	return d.insert(ctx, d.program.VrfEntries(obj), func() error { return d.Dataplane.DeleteVrf(ctx, obj) })
+}
+
+// UpdateVrf updates the Vrf in Linux and replaces its entries
+func (d *Dataplane) UpdateVrf(ctx context.Context, old *pb.Vrf, obj *pb.Vrf) error {
+	if err := d.Dataplane.UpdateVrf(ctx, old, obj); err != nil {
+		return err
+	}
+	// the status of obj is filled by the handler after the update
+	updated := &pb.Vrf{Name: obj.Name, Spec: obj.Spec, Status: old.Status}
+	return d.replace(ctx, d.program.VrfEntries(old), d.program.VrfEntries(updated))
+}
+
+// DeleteVrf removes the entries of the Vrf before its Linux devices
+func (d *Dataplane) DeleteVrf(ctx context.Context, obj *pb.Vrf) error {
+	if err := d.delete(ctx, d.program.VrfEntries(obj)); err != nil {
+		return err
+	}
+	return d.Dataplane.DeleteVrf(ctx, obj)
+}
+
+// ResyncVrf re-applies the Vrf to Linux and writes its entries again
+func (d *Dataplane) ResyncVrf(ctx context.Context, obj *pb.Vrf) (bool, error) {
+	recreated, err := d.Dataplane.ResyncVrf(ctx, obj)
+	if err != nil {
+		return recreated, err
+	}
+	return recreated, d.resync(ctx, d.program.VrfEntries(obj))
+}
+
+// CreateLogicalBridge programs the LogicalBridge in Linux and its entries in the pipeline
+func (d *Dataplane) CreateLogicalBridge(ctx context.Context, obj *pb.LogicalBridge) error {
+	if err := d.Dataplane.CreateLogicalBridge(ctx, obj); err != nil {
+		return err
+	}
+	return d.insert(ctx, d.program.LogicalBridgeEntries(obj), func() error { return d.Dataplane.DeleteLogicalBridge(ctx, obj) })
+}
+
+// UpdateLogicalBridge updates the LogicalBridge in Linux and replaces its entries
+func (d *Dataplane) UpdateLogicalBridge(ctx context.Context, old *pb.LogicalBridge, obj *pb.LogicalBridge) error {
+	if err := d.Dataplane.UpdateLogicalBridge(ctx, old, obj); err != nil {
+		return err
+	}
+	return d.replace(ctx, d.program.LogicalBridgeEntries(old), d.program.LogicalBridgeEntries(obj))
+}
+
+// DeleteLogicalBridge removes the entries of the LogicalBridge before its Linux devices
+func (d *Dataplane) DeleteLogicalBridge(ctx context.Context, obj *pb.LogicalBridge) error {
+	if err := d.delete(ctx, d.program.LogicalBridgeEntries(obj)); err != nil {
+		return err
+	}
+	return d.Dataplane.DeleteLogicalBridge(ctx, obj)
+}
+
+// ResyncLogicalBridge re-applies the LogicalBridge to Linux and writes its entries again
+func (d *Dataplane) ResyncLogicalBridge(ctx context.Context, obj *pb.LogicalBridge) error {
+	if err := d.Dataplane.ResyncLogicalBridge(ctx, obj); err != nil {
+		return err
+	}
+	return d.resync(ctx, d.program.LogicalBridgeEntries(obj))
+}
+
+// PrecheckBindBridgePort also fails for the ports the program cannot forward to
+func (d *Dataplane) PrecheckBindBridgePort(ctx context.Context, obj *pb.BridgePort) error {
+	if _, err := d.bridgePortEntries(obj); err != nil {
+		return err
+	}
+	return d.Dataplane.PrecheckBindBridgePort(ctx, obj)
+}
+
+// BindBridgePort binds the port in Linux and writes its entries in the pipeline
+func (d *Dataplane) BindBridgePort(ctx context.Context, obj *pb.BridgePort) error {
+	entries, err := d.bridgePortEntries(obj)
+	if err != nil {
+		return err
+	}
+	if err := d.Dataplane.BindBridgePort(ctx, obj); err != nil {
+		return err
+	}
+	return d.insert(ctx, entries, func() error { return d.Dataplane.UnbindBridgePort(ctx, obj) })
+}
+
+// UpdateBridgePort updates the port in Linux and replaces its entries
+func (d *Dataplane) UpdateBridgePort(ctx context.Context, old *pb.BridgePort, obj *pb.BridgePort) error {
+	oldEntries, err := d.bridgePortEntries(old)
+	if err != nil {
+		return err
+	}
+	entries, err := d.bridgePortEntries(obj)
+	if err != nil {
+		return err
+	}
+	if err := d.Dataplane.UpdateBridgePort(ctx, old, obj); err != nil {
+		return err
+	}
+	return d.replace(ctx, oldEntries, entries)
+}
+
+// UnbindBridgePort removes the entries of the port before unbinding it in Linux
+func (d *Dataplane) UnbindBridgePort(ctx context.Context, obj *pb.BridgePort) error {
+	entries, err := d.bridgePortEntries(obj)
+	if err != nil {
+		return err
+	}
+	if err := d.delete(ctx, entries); err != nil {
+		return err
+	}
+	return d.Dataplane.UnbindBridgePort(ctx, obj)
+}
+
+// ResyncBridgePort re-applies the port to Linux and writes its entries again
+func (d *Dataplane) ResyncBridgePort(ctx context.Context, obj *pb.BridgePort) error {
+	entries, err := d.bridgePortEntries(obj)
+	if err != nil {
+		return err
+	}
+	if err := d.Dataplane.ResyncBridgePort(ctx, obj); err != nil {
+		return err
+	}
+	return d.resync(ctx, entries)
+}
+
+// CreateSvi programs the Svi in Linux and its entries in the pipeline
+func (d *Dataplane) CreateSvi(ctx context.Context, obj *pb.Svi, bridge *pb.LogicalBridge, vrf *pb.Vrf) error {
+	if err := d.Dataplane.CreateSvi(ctx, obj, bridge, vrf); err != nil {
+		return err
+	}
+	return d.insert(ctx, d.program.SviEntries(obj, bridge, vrf), func() error { return d.Dataplane.DeleteSvi(ctx, obj, bridge, vrf) })
+}
+
+// UpdateSvi updates the Svi in Linux and replaces its entries
+func (d *Dataplane) UpdateSvi(ctx context.Context, old *pb.Svi, obj *pb.Svi, bridge *pb.LogicalBridge) error {
+	if err := d.Dataplane.UpdateSvi(ctx, old, obj, bridge); err != nil {
+		return err
+	}
+	vrf, ok := d.server.Vrfs[old.Spec.Vrf]
+	if !ok {
+		err := status.Errorf(codes.NotFound, "unable to find key %s", old.Spec.Vrf)
+		return err
+	}
+	return d.replace(ctx, d.program.SviEntries(old, bridge, vrf), d.program.SviEntries(obj, bridge, vrf))
+}
+
+// DeleteSvi removes the entries of the Svi before its Linux devices
+func (d *Dataplane) DeleteSvi(ctx context.Context, obj *pb.Svi, bridge *pb.LogicalBridge, vrf *pb.Vrf) error {
+	if err := d.delete(ctx, d.program.SviEntries(obj, bridge, vrf)); err != nil {
+		return err
+	}
+	return d.Dataplane.DeleteSvi(ctx, obj, bridge, vrf)
+}
+
+// ResyncSvi re-applies the Svi to Linux and writes its entries again
+func (d *Dataplane) ResyncSvi(ctx context.Context, obj *pb.Svi, bridge *pb.LogicalBridge, vrf *pb.Vrf, force bool) error {
+	if err := d.Dataplane.ResyncSvi(ctx, obj, bridge, vrf, force); err != nil {
+		return err
+	}
+	return d.resync(ctx, d.program.SviEntries(obj, bridge, vrf))
+}
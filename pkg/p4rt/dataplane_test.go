@@ -0,0 +1,102 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Intel Corporation, or its subsidiaries.
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package p4rt programs the EVPN objects as entries of the tables of a P4 pipeline
+package p4rt
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/philippgille/gokv/gomap"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+	pc "github.com/opiproject/opi-api/network/opinetcommon/v1alpha1/gen/go"
+	"github.com/opiproject/opi-evpn-bridge/pkg/evpn"
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils/mocks"
+)
+
+// fakeLinux stands for the Linux dataplane of the host CPU
+type fakeLinux struct {
+	evpn.Dataplane
+	bridges map[string]bool
+}
+
+func (l *fakeLinux) CreateLogicalBridge(_ context.Context, obj *pb.LogicalBridge) error {
+	l.bridges[obj.Name] = true
+	return nil
+}
+
+func (l *fakeLinux) DeleteLogicalBridge(_ context.Context, obj *pb.LogicalBridge) error {
+	delete(l.bridges, obj.Name)
+	return nil
+}
+
+type fakeTables struct {
+	entries map[string]Entry
+	err     error
+}
+
+func (t *fakeTables) Insert(_ context.Context, entries []Entry) error {
+	if t.err != nil {
+		return t.err
+	}
+	for _, entry := range entries {
+		t.entries[entry.Table] = entry
+	}
+	return nil
+}
+
+func (t *fakeTables) Delete(_ context.Context, entries []Entry) error {
+	for _, entry := range entries {
+		delete(t.entries, entry.Table)
+	}
+	return nil
+}
+
+func TestDataplane_CreateLogicalBridge(t *testing.T) {
+	vni := uint32(11)
+	tests := map[string]struct {
+		vni     *uint32
+		err     error
+		entries int
+		bridges int
+	}{
+		"vxlan mapping offloaded": {
+			vni:     &vni,
+			entries: 2,
+			bridges: 1,
+		},
+		"no vni, nothing to offload": {
+			bridges: 1,
+		},
+		"pipeline failure removes the bridge": {
+			vni: &vni,
+			err: errors.New("table full"),
+		},
+	}
+
+	for testName, tt := range tests {
+		t.Run(testName, func(t *testing.T) {
+			server := evpn.NewServerWithArgs(mocks.NewNetlink(t), mocks.NewFrr(t), gomap.NewStore(gomap.DefaultOptions))
+			linux := &fakeLinux{bridges: map[string]bool{}}
+			server.SetDataplane(linux)
+			tables := &fakeTables{entries: map[string]Entry{}, err: tt.err}
+			dataplane := NewDataplane(server, IRB{Pipeline: &DefaultPipeline}, tables)
+
+			obj := &pb.LogicalBridge{
+				Name: "//network.opiproject.org/bridges/opi-bridge9",
+				Spec: &pb.LogicalBridgeSpec{VlanId: 11, Vni: tt.vni, VtepIpPrefix: &pc.IPPrefix{}},
+			}
+			err := dataplane.CreateLogicalBridge(context.Background(), obj)
+			if !errors.Is(err, tt.err) {
+				t.Error("error: expected", tt.err, "received", err)
+			}
+			if len(tables.entries) != tt.entries || len(linux.bridges) != tt.bridges {
+				t.Error("entries: expected", tt.entries, tt.bridges, "received", tables.entries, linux.bridges)
+			}
+		})
+	}
+}
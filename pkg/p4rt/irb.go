@@ -0,0 +1,138 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package p4rt programs the EVPN objects as entries of the tables of a P4 pipeline
+package p4rt
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Pipeline names the tables of a P4 program implementing EVPN IRB, e.g. on a Tofino,
+// and numbers its ports. The actions, match fields and params are fixed:
+//   - BridgeDomainTable: port, vlan_id -> set_bd(bd, untagged), vlan_id 0 for access ports
+//   - VniTable: vni -> set_bd(bd, untagged), on vxlan decap
+//   - TunnelTable: bd -> set_tunnel(vni, src_ip), on vxlan encap
+//   - VrfTable: vrf_id -> set_l3_vni(vni, rmac), vni 0 for a Vrf routed locally
+//   - RmacTable: bd, dst_mac -> route(vrf_id), for the gateway macs of the Svis
+type Pipeline struct {
+	BridgeDomainTable string `json:"bridgeDomainTable"`
+	VniTable          string `json:"vniTable"`
+	TunnelTable       string `json:"tunnelTable"`
+	VrfTable          string `json:"vrfTable"`
+	RmacTable         string `json:"rmacTable"`
+	// Ports maps the interface names of the BridgePorts to the ports of the pipeline
+	Ports map[string]uint32 `json:"ports"`
+}
+
+// DefaultPipeline holds the table names of the reference EVPN IRB program
+var DefaultPipeline = Pipeline{
+	BridgeDomainTable: "ingress.bd_table",
+	VniTable:          "ingress.vni_table",
+	TunnelTable:       "egress.tunnel_table",
+	VrfTable:          "ingress.vrf_table",
+	RmacTable:         "ingress.rmac_table",
+}
+
+// LoadPipeline reads a Pipeline in JSON format, the missing table names are the default ones
+func LoadPipeline(fileName string) (*Pipeline, error) {
+	pipeline := DefaultPipeline
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &pipeline); err != nil {
+		return nil, fmt.Errorf("failed to parse pipeline %s: %w", fileName, err)
+	}
+	return &pipeline, nil
+}
+
+// IRB is the Program of a pipeline described by a Pipeline, the vlan of a LogicalBridge
+// is used as its bridge domain
+type IRB struct {
+	Pipeline *Pipeline
+}
+
+// VrfEntries maps the Vrf to its L3 vni and router mac
+func (p IRB) VrfEntries(obj *pb.Vrf) []Entry {
+	return []Entry{{
+		Table:  p.Pipeline.VrfTable,
+		Match:  map[string][]byte{"vrf_id": Uint32(obj.GetStatus().GetRoutingTable())},
+		Action: "set_l3_vni",
+		Params: map[string][]byte{"vni": Uint32(obj.Spec.GetVni()), "rmac": obj.GetStatus().GetRmac()},
+	}}
+}
+
+// LogicalBridgeEntries maps the vni to the bridge domain both ways, the bridge domain
+// itself only exists through the entries of its ports
+func (p IRB) LogicalBridgeEntries(obj *pb.LogicalBridge) []Entry {
+	if obj.Spec.Vni == nil {
+		return nil
+	}
+	bd := Uint16(obj.Spec.VlanId)
+	vni := Uint32(*obj.Spec.Vni)
+	return []Entry{{
+		Table:  p.Pipeline.VniTable,
+		Match:  map[string][]byte{"vni": vni},
+		Action: "set_bd",
+		Params: map[string][]byte{"bd": bd, "untagged": {0}},
+	}, {
+		Table:  p.Pipeline.TunnelTable,
+		Match:  map[string][]byte{"bd": bd},
+		Action: "set_tunnel",
+		Params: map[string][]byte{"vni": vni, "src_ip": Uint32(obj.Spec.VtepIpPrefix.GetAddr().GetV4Addr())},
+	}}
+}
+
+// BridgePortEntries puts the untagged traffic of an access port, or the tagged traffic
+// of a trunk port, in the bridge domains of its LogicalBridges
+func (p IRB) BridgePortEntries(obj *pb.BridgePort, bridges []*pb.LogicalBridge) ([]Entry, error) {
+	ifname := path.Base(obj.Name)
+	port, ok := p.Pipeline.Ports[ifname]
+	if !ok {
+		msg := fmt.Sprintf("Interface %s is not a port of the pipeline", ifname)
+		return nil, status.Error(codes.InvalidArgument, msg)
+	}
+	var entries []Entry
+	for _, bridgeObject := range bridges {
+		bd := Uint16(bridgeObject.Spec.VlanId)
+		var vlan, untagged []byte
+		switch obj.Spec.Ptype {
+		case pb.BridgePortType_ACCESS:
+			vlan, untagged = Uint16(0), []byte{1}
+		case pb.BridgePortType_TRUNK:
+			vlan, untagged = bd, []byte{0}
+		default:
+			msg := fmt.Sprintf("Only ACCESS or TRUNK supported and not (%d)", obj.Spec.Ptype)
+			return nil, status.Error(codes.InvalidArgument, msg)
+		}
+		entries = append(entries, Entry{
+			Table:  p.Pipeline.BridgeDomainTable,
+			Match:  map[string][]byte{"port": Uint16(port), "vlan_id": vlan},
+			Action: "set_bd",
+			Params: map[string][]byte{"bd": bd, "untagged": untagged},
+		})
+	}
+	return entries, nil
+}
+
+// SviEntries routes the traffic sent to the gateway mac in the Vrf
+func (p IRB) SviEntries(obj *pb.Svi, bridge *pb.LogicalBridge, vrf *pb.Vrf) []Entry {
+	if len(obj.Spec.MacAddress) == 0 {
+		return nil
+	}
+	return []Entry{{
+		Table:  p.Pipeline.RmacTable,
+		Match:  map[string][]byte{"bd": Uint16(bridge.Spec.VlanId), "dst_mac": obj.Spec.MacAddress},
+		Action: "route",
+		Params: map[string][]byte{"vrf_id": Uint32(vrf.GetStatus().GetRoutingTable())},
+	}}
+}
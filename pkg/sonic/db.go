@@ -0,0 +1,72 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package sonic writes the EVPN objects into the Redis databases of a SONiC switch or DPU
+package sonic
+
+import (
+	"context"
+
+	"github.com/go-redis/redis"
+)
+
+// numbers of the SONiC databases in the redis instance, see database_config.json
+const (
+	// ApplDB is read back to check the objects were applied by the SONiC daemons
+	ApplDB = 0
+	// ConfigDB is written, vlanmgrd, vxlanmgrd, vrfmgrd and intfmgrd program the kernel and SAI from it
+	ConfigDB = 4
+)
+
+// DB is the part of a SONiC database the dataplane needs, the keys are TABLE|key in CONFIG_DB
+// and TABLE:key in APPL_DB
+type DB interface {
+	// HSet writes the fields of the entry, creating it when missing
+	HSet(ctx context.Context, key string, fields map[string]string) error
+	// Del deletes the entries, the missing ones are ignored
+	Del(ctx context.Context, keys ...string) error
+	// Exists reports whether the entry exists
+	Exists(ctx context.Context, key string) (bool, error)
+}
+
+// RedisDB is a DB in the redis instance of SONiC
+type RedisDB struct {
+	client *redis.Client
+}
+
+// NewRedisDB creates initialized instance of RedisDB, db is ApplDB or ConfigDB
+func NewRedisDB(address string, db int) *RedisDB {
+	client := redis.NewClient(&redis.Options{Addr: address, DB: db})
+	return &RedisDB{client: client}
+}
+
+// build time check that struct implements interface
+var _ DB = (*RedisDB)(nil)
+
+// HSet writes the fields with HMSET, SONiC stores entries without fields as NULL: NULL
+func (r *RedisDB) HSet(ctx context.Context, key string, fields map[string]string) error {
+	values := map[string]interface{}{"NULL": "NULL"}
+	if len(fields) > 0 {
+		values = map[string]interface{}{}
+		for field, value := range fields {
+			values[field] = value
+		}
+	}
+	return r.client.WithContext(ctx).HMSet(key, values).Err()
+}
+
+// Del deletes the entries
+func (r *RedisDB) Del(ctx context.Context, keys ...string) error {
+	return r.client.WithContext(ctx).Del(keys...).Err()
+}
+
+// Exists reports whether the entry exists
+func (r *RedisDB) Exists(ctx context.Context, key string) (bool, error) {
+	n, err := r.client.WithContext(ctx).Exists(key).Result()
+	return n > 0, err
+}
+
+// Close closes the connection to redis
+func (r *RedisDB) Close() error {
+	return r.client.Close()
+}
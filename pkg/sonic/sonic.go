@@ -0,0 +1,737 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package sonic writes the EVPN objects into the Redis databases of a SONiC switch or DPU
+package sonic
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"log"
+	"net"
+	"path"
+	"strconv"
+	"time"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+	pc "github.com/opiproject/opi-api/network/opinetcommon/v1alpha1/gen/go"
+	"github.com/opiproject/opi-evpn-bridge/pkg/evpn"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// vtepName is the single VXLAN_TUNNEL of SONiC, its source is the VTEP of the LogicalBridges
+	vtepName = "vtep"
+	// nvoName is the VXLAN_EVPN_NVO using the vtep for EVPN
+	nvoName = "nvo"
+)
+
+// Dataplane writes the objects into CONFIG_DB instead of programming Linux and FRR, the
+// SONiC daemons own the kernel devices, the SAI objects and the FRR configuration, and the
+// objects are checked in APPL_DB, written by these daemons once applied
+type Dataplane struct {
+	server *evpn.Server
+	config DB
+	appl   DB
+}
+
+// NewDataplane creates a dataplane writing to config and reading back from appl, to be set
+// with server.SetDataplane
+func NewDataplane(server *evpn.Server, config DB, appl DB) *Dataplane {
+	return &Dataplane{server: server, config: config, appl: appl}
+}
+
+// build time check that struct implements interface
+var _ evpn.Dataplane = (*Dataplane)(nil)
+
+func ipv4(addr uint32) net.IP {
+	ip := make(net.IP, 4)
+	binary.BigEndian.PutUint32(ip, addr)
+	return ip
+}
+
+func ipPrefix(prefix *pc.IPPrefix) string {
+	return fmt.Sprintf("%s/%d", ipv4(prefix.GetAddr().GetV4Addr()), prefix.GetLen())
+}
+
+// VrfName is the SONiC name of a Vrf, SONiC only accepts VRF names starting with Vrf
+func VrfName(name string) string {
+	return "Vrf-" + path.Base(name)
+}
+
+func vlanName(bridge *pb.LogicalBridge) string {
+	return fmt.Sprintf("Vlan%d", bridge.Spec.VlanId)
+}
+
+func tunnelMapName(bridge *pb.LogicalBridge) string {
+	return fmt.Sprintf("map_%d_%s", *bridge.Spec.Vni, vlanName(bridge))
+}
+
+func (d *Dataplane) set(ctx context.Context, key string, fields map[string]string) error {
+	// Example: redis-cli -n 4 hset "VLAN|Vlan10" vlanid 10
+	if err := d.config.HSet(ctx, key, fields); err != nil {
+		fmt.Printf("Failed to write %s: %v", key, err)
+		return err
+	}
+	return nil
+}
+
+func (d *Dataplane) del(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	if err := d.config.Del(ctx, keys...); err != nil {
+		fmt.Printf("Failed to delete %v: %v", keys, err)
+		return err
+	}
+	return nil
+}
+
+// checkKeys fails with NotFound when any of the entries is missing from the database
+func checkKeys(ctx context.Context, db DB, keys ...string) error {
+	for _, key := range keys {
+		exists, err := db.Exists(ctx, key)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			err := status.Errorf(codes.NotFound, "unable to find key %s", key)
+			return err
+		}
+	}
+	return nil
+}
+
+// checkKeysAbsent fails with AlreadyExists when any of the entries is already in CONFIG_DB
+func (d *Dataplane) checkKeysAbsent(ctx context.Context, keys ...string) error {
+	for _, key := range keys {
+		exists, err := d.config.Exists(ctx, key)
+		if err != nil {
+			return err
+		}
+		if exists {
+			msg := fmt.Sprintf("entry %s already exists", key)
+			return status.Error(codes.AlreadyExists, msg)
+		}
+	}
+	return nil
+}
+
+// CreateVrf writes the VRF, its L3 vni is mapped by vrfmgrd
+func (d *Dataplane) CreateVrf(ctx context.Context, obj *pb.Vrf) error {
+	if d.server.IsSrv6Vrf(obj) {
+		msg := fmt.Sprintf("the srv6 overlay of Vrf %s is not supported by the SONiC dataplane", obj.Name)
+		return status.Error(codes.Unimplemented, msg)
+	}
+	fields := map[string]string{}
+	if obj.Spec.Vni != nil {
+		fields["vni"] = strconv.Itoa(int(*obj.Spec.Vni))
+	}
+	// Example: redis-cli -n 4 hset "VRF|Vrf-blue" vni 1000
+	return d.set(ctx, "VRF|"+VrfName(obj.Name), fields)
+}
+
+// UpdateVrf rewrites the VRF with the new vni
+func (d *Dataplane) UpdateVrf(ctx context.Context, _ *pb.Vrf, obj *pb.Vrf) error {
+	return d.CreateVrf(ctx, obj)
+}
+
+// DeleteVrf deletes the VRF, its Svis and handoffs are already gone
+func (d *Dataplane) DeleteVrf(ctx context.Context, obj *pb.Vrf) error {
+	return d.del(ctx, "VRF|"+VrfName(obj.Name))
+}
+
+// GetVrf fails with NotFound when the VRF is not in CONFIG_DB
+func (d *Dataplane) GetVrf(ctx context.Context, obj *pb.Vrf) error {
+	return checkKeys(ctx, d.config, "VRF|"+VrfName(obj.Name))
+}
+
+// CheckVrf fails when vrfmgrd did not apply the VRF
+func (d *Dataplane) CheckVrf(ctx context.Context, obj *pb.Vrf) error {
+	return checkKeys(ctx, d.appl, "VRF_TABLE:"+VrfName(obj.Name))
+}
+
+// PrecheckCreateVrf checks the VRF is not configured yet
+func (d *Dataplane) PrecheckCreateVrf(ctx context.Context, obj *pb.Vrf) error {
+	return d.checkKeysAbsent(ctx, "VRF|"+VrfName(obj.Name))
+}
+
+// ResyncVrf writes the VRF again, it is recreated when it was missing from CONFIG_DB
+func (d *Dataplane) ResyncVrf(ctx context.Context, obj *pb.Vrf) (bool, error) {
+	recreated := d.GetVrf(ctx, obj) != nil
+	if recreated {
+		log.Printf("Recreating Vrf %v", obj.Name)
+	}
+	return recreated, d.CreateVrf(ctx, obj)
+}
+
+// CreateLogicalBridge writes the VLAN and, with a vni, maps it on the EVPN vtep
+func (d *Dataplane) CreateLogicalBridge(ctx context.Context, obj *pb.LogicalBridge) error {
+	if d.server.LogicalBridgeTunnel(obj) == evpn.TunnelGeneve {
+		msg := fmt.Sprintf("the geneve tunnel of LogicalBridge %s is not supported by the SONiC dataplane", obj.Name)
+		return status.Error(codes.Unimplemented, msg)
+	}
+	name := vlanName(obj)
+	if err := d.set(ctx, "VLAN|"+name, map[string]string{"vlanid": strconv.Itoa(int(obj.Spec.VlanId))}); err != nil {
+		return err
+	}
+	if obj.Spec.Vni == nil {
+		return nil
+	}
+	// the vtep and nvo are shared by all the LogicalBridges and left in place
+	src := ipv4(obj.Spec.VtepIpPrefix.GetAddr().GetV4Addr()).String()
+	if err := d.set(ctx, "VXLAN_TUNNEL|"+vtepName, map[string]string{"src_ip": src}); err != nil {
+		return err
+	}
+	if err := d.set(ctx, "VXLAN_EVPN_NVO|"+nvoName, map[string]string{"source_vtep": vtepName}); err != nil {
+		return err
+	}
+	// Example: redis-cli -n 4 hset "VXLAN_TUNNEL_MAP|vtep|map_10_Vlan10" vlan Vlan10 vni 10
+	return d.set(ctx, "VXLAN_TUNNEL_MAP|"+vtepName+"|"+tunnelMapName(obj),
+		map[string]string{"vlan": name, "vni": strconv.Itoa(int(*obj.Spec.Vni))})
+}
+
+// UpdateLogicalBridge rewrites the VLAN and its vni mapping
+func (d *Dataplane) UpdateLogicalBridge(ctx context.Context, old *pb.LogicalBridge, obj *pb.LogicalBridge) error {
+	if old.Spec.Vni != nil && (obj.Spec.Vni == nil || *old.Spec.Vni != *obj.Spec.Vni) {
+		if err := d.del(ctx, "VXLAN_TUNNEL_MAP|"+vtepName+"|"+tunnelMapName(old)); err != nil {
+			return err
+		}
+	}
+	return d.CreateLogicalBridge(ctx, obj)
+}
+
+// DeleteLogicalBridge unmaps the vni before deleting the VLAN
+func (d *Dataplane) DeleteLogicalBridge(ctx context.Context, obj *pb.LogicalBridge) error {
+	if obj.Spec.Vni != nil {
+		if err := d.del(ctx, "VXLAN_TUNNEL_MAP|"+vtepName+"|"+tunnelMapName(obj)); err != nil {
+			return err
+		}
+	}
+	return d.del(ctx, "VLAN|"+vlanName(obj))
+}
+
+// GetLogicalBridge fails with NotFound when the VLAN is not in CONFIG_DB
+func (d *Dataplane) GetLogicalBridge(ctx context.Context, obj *pb.LogicalBridge) error {
+	return checkKeys(ctx, d.config, "VLAN|"+vlanName(obj))
+}
+
+// CheckLogicalBridge fails when vlanmgrd or vxlanmgrd did not apply the LogicalBridge
+func (d *Dataplane) CheckLogicalBridge(ctx context.Context, obj *pb.LogicalBridge) error {
+	keys := []string{"VLAN_TABLE:" + vlanName(obj)}
+	if obj.Spec.Vni != nil {
+		keys = append(keys, "VXLAN_TUNNEL_MAP_TABLE:"+vtepName+":"+tunnelMapName(obj))
+	}
+	return checkKeys(ctx, d.appl, keys...)
+}
+
+// PrecheckCreateLogicalBridge checks the VLAN is not configured yet
+func (d *Dataplane) PrecheckCreateLogicalBridge(ctx context.Context, obj *pb.LogicalBridge) error {
+	return d.checkKeysAbsent(ctx, "VLAN|"+vlanName(obj))
+}
+
+// ResyncLogicalBridge writes the LogicalBridge again
+func (d *Dataplane) ResyncLogicalBridge(ctx context.Context, obj *pb.LogicalBridge) error {
+	return d.CreateLogicalBridge(ctx, obj)
+}
+
+// bridgePortVlans returns the VLANs of the LogicalBridges of the port
+func (d *Dataplane) bridgePortVlans(obj *pb.BridgePort) ([]string, error) {
+	var vlans []string
+	for _, bridgeRefName := range obj.Spec.LogicalBridges {
+		bridgeObject, ok := d.server.Bridges[bridgeRefName]
+		if !ok {
+			err := status.Errorf(codes.NotFound, "unable to find key %s", bridgeRefName)
+			return nil, err
+		}
+		vlans = append(vlans, vlanName(bridgeObject))
+	}
+	return vlans, nil
+}
+
+// BindBridgePort makes the port an untagged member of the VLAN of an access port, or a
+// tagged member of the VLANs of a trunk port but its native VLAN
+func (d *Dataplane) BindBridgePort(ctx context.Context, obj *pb.BridgePort) error {
+	mode := "tagged"
+	switch obj.Spec.Ptype {
+	case pb.BridgePortType_ACCESS:
+		mode = "untagged"
+	case pb.BridgePortType_TRUNK:
+	default:
+		msg := fmt.Sprintf("Only ACCESS or TRUNK supported and not (%d)", obj.Spec.Ptype)
+		return status.Error(codes.InvalidArgument, msg)
+	}
+	trunk := d.server.PortTrunkVlans(obj.Name)
+	if len(trunk.Translations) > 0 {
+		msg := fmt.Sprintf("Translating the vlans of BridgePort %s is not supported by the SONiC dataplane", obj.Name)
+		return status.Error(codes.Unimplemented, msg)
+	}
+	if trunk.ServiceVlan != 0 {
+		msg := fmt.Sprintf("Service-tagged BridgePort %s is not supported by the SONiC dataplane", obj.Name)
+		return status.Error(codes.Unimplemented, msg)
+	}
+	// the MACsec of SONiC is configured by its macsec container, with MKA profiles
+	if d.server.IsMacsecPort(obj) {
+		msg := fmt.Sprintf("MACsec on BridgePort %s is not supported by the SONiC dataplane", obj.Name)
+		return status.Error(codes.Unimplemented, msg)
+	}
+	// the ports of SONiC are named after the front panel, not after a PCI function
+	if d.server.PortKernelName(obj.Name) != path.Base(obj.Name) {
+		msg := fmt.Sprintf("BridgePort %s on a PCI address or VF index is not supported by the SONiC dataplane", obj.Name)
+		return status.Error(codes.Unimplemented, msg)
+	}
+	vlans, err := d.bridgePortVlans(obj)
+	if err != nil {
+		return err
+	}
+	for _, vlan := range vlans {
+		member := mode
+		if trunk.Native != 0 && vlan == fmt.Sprintf("Vlan%d", trunk.Native) {
+			member = "untagged"
+		}
+		// Example: redis-cli -n 4 hset "VLAN_MEMBER|Vlan10|Ethernet0" tagging_mode untagged
+		if err := d.set(ctx, "VLAN_MEMBER|"+vlan+"|"+path.Base(obj.Name), map[string]string{"tagging_mode": member}); err != nil {
+			return err
+		}
+	}
+	if d.server.MacLearning(obj.Name) {
+		return nil
+	}
+	// Example: redis-cli -n 4 hset "PORT|Ethernet0" learn_mode disable
+	return d.set(ctx, "PORT|"+path.Base(obj.Name), map[string]string{"learn_mode": "disable"})
+}
+
+// UpdateBridgePort adds the port to its new VLANs and removes it from the VLANs it left, the
+// VLAN memberships it keeps are written again unchanged
+func (d *Dataplane) UpdateBridgePort(ctx context.Context, old *pb.BridgePort, obj *pb.BridgePort) error {
+	if err := d.BindBridgePort(ctx, obj); err != nil {
+		return err
+	}
+	keys, err := d.bridgePortKeys(obj, "VLAN_MEMBER", "|")
+	if err != nil {
+		return err
+	}
+	kept := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		kept[key] = true
+	}
+	oldKeys, err := d.bridgePortKeys(old, "VLAN_MEMBER", "|")
+	if err != nil {
+		return err
+	}
+	var left []string
+	for _, key := range oldKeys {
+		if !kept[key] {
+			left = append(left, key)
+		}
+	}
+	if len(left) == 0 {
+		return nil
+	}
+	return d.del(ctx, left...)
+}
+
+func (d *Dataplane) bridgePortKeys(obj *pb.BridgePort, table string, sep string) ([]string, error) {
+	vlans, err := d.bridgePortVlans(obj)
+	if err != nil {
+		return nil, err
+	}
+	var keys []string
+	for _, vlan := range vlans {
+		keys = append(keys, table+sep+vlan+sep+path.Base(obj.Name))
+	}
+	return keys, nil
+}
+
+// UnbindBridgePort removes the port from all its VLANs
+func (d *Dataplane) UnbindBridgePort(ctx context.Context, obj *pb.BridgePort) error {
+	keys, err := d.bridgePortKeys(obj, "VLAN_MEMBER", "|")
+	if err != nil {
+		return err
+	}
+	if err := d.del(ctx, keys...); err != nil {
+		return err
+	}
+	if d.server.MacLearning(obj.Name) {
+		return nil
+	}
+	// Example: redis-cli -n 4 hset "PORT|Ethernet0" learn_mode hardware
+	return d.set(ctx, "PORT|"+path.Base(obj.Name), map[string]string{"learn_mode": "hardware"})
+}
+
+// GetBridgePort fails with NotFound when the port is not a port of SONiC
+func (d *Dataplane) GetBridgePort(ctx context.Context, obj *pb.BridgePort) error {
+	return checkKeys(ctx, d.config, "PORT|"+path.Base(obj.Name))
+}
+
+// CheckBridgePort fails when the port or one of its VLAN memberships was not applied
+func (d *Dataplane) CheckBridgePort(ctx context.Context, obj *pb.BridgePort) error {
+	keys, err := d.bridgePortKeys(obj, "VLAN_MEMBER_TABLE", ":")
+	if err != nil {
+		return err
+	}
+	return checkKeys(ctx, d.appl, append([]string{"PORT_TABLE:" + path.Base(obj.Name)}, keys...)...)
+}
+
+// PrecheckBindBridgePort checks the port is a port of SONiC, ports are not created by the bridge
+func (d *Dataplane) PrecheckBindBridgePort(ctx context.Context, obj *pb.BridgePort) error {
+	return d.GetBridgePort(ctx, obj)
+}
+
+// ResyncBridgePort writes the VLAN memberships again
+func (d *Dataplane) ResyncBridgePort(ctx context.Context, obj *pb.BridgePort) error {
+	return d.BindBridgePort(ctx, obj)
+}
+
+func sviKeys(obj *pb.Svi, bridge *pb.LogicalBridge) []string {
+	keys := []string{"VLAN_INTERFACE|" + vlanName(bridge)}
+	for _, gwip := range obj.Spec.GwIpPrefix {
+		keys = append(keys, "VLAN_INTERFACE|"+vlanName(bridge)+"|"+ipPrefix(gwip))
+	}
+	return keys
+}
+
+// CreateSvi writes the VLAN_INTERFACE of the LogicalBridge in the VRF and its gateway addresses,
+// an anycast gateway uses the static anycast gateway MAC of SONiC, shared by all its Svis
+func (d *Dataplane) CreateSvi(ctx context.Context, obj *pb.Svi, bridge *pb.LogicalBridge, vrf *pb.Vrf) error {
+	if obj.Spec.EnableBgp {
+		msg := fmt.Sprintf("EnableBgp on Svi %s is not supported by the SONiC dataplane", obj.Name)
+		return status.Error(codes.Unimplemented, msg)
+	}
+	fields := map[string]string{"vrf_name": VrfName(vrf.Name)}
+	switch {
+	case d.server.IsAnycastGateway(obj.Name):
+		// Example: redis-cli -n 4 hset "SAG|GLOBAL" gateway_mac 00:00:5e:00:01:01
+		if err := d.set(ctx, "SAG|GLOBAL", map[string]string{"gateway_mac": net.HardwareAddr(obj.Spec.MacAddress).String()}); err != nil {
+			return err
+		}
+		fields["static_anycast_gateway"] = "true"
+	case len(obj.Spec.MacAddress) > 0:
+		fields["mac_addr"] = net.HardwareAddr(obj.Spec.MacAddress).String()
+	}
+	keys := sviKeys(obj, bridge)
+	// Example: redis-cli -n 4 hset "VLAN_INTERFACE|Vlan10" vrf_name Vrf-blue
+	if err := d.set(ctx, keys[0], fields); err != nil {
+		return err
+	}
+	// Example: redis-cli -n 4 hset "VLAN_INTERFACE|Vlan10|10.0.0.1/24" NULL NULL
+	for _, key := range keys[1:] {
+		if err := d.set(ctx, key, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UpdateSvi rewrites the gateway addresses, the Svi stays in its VRF
+func (d *Dataplane) UpdateSvi(ctx context.Context, old *pb.Svi, obj *pb.Svi, bridge *pb.LogicalBridge) error {
+	if err := d.del(ctx, sviKeys(old, bridge)[1:]...); err != nil {
+		return err
+	}
+	vrf, ok := d.server.Vrfs[obj.Spec.Vrf]
+	if !ok {
+		err := status.Errorf(codes.NotFound, "unable to find key %s", obj.Spec.Vrf)
+		return err
+	}
+	return d.CreateSvi(ctx, obj, bridge, vrf)
+}
+
+// DeleteSvi deletes the gateway addresses before the VLAN_INTERFACE itself
+func (d *Dataplane) DeleteSvi(ctx context.Context, obj *pb.Svi, bridge *pb.LogicalBridge, _ *pb.Vrf) error {
+	keys := sviKeys(obj, bridge)
+	if err := d.del(ctx, keys[1:]...); err != nil {
+		return err
+	}
+	return d.del(ctx, keys[0])
+}
+
+// GetSvi fails with NotFound when the VLAN_INTERFACE is not in CONFIG_DB
+func (d *Dataplane) GetSvi(ctx context.Context, _ *pb.Svi, bridge *pb.LogicalBridge) error {
+	return checkKeys(ctx, d.config, "VLAN_INTERFACE|"+vlanName(bridge))
+}
+
+// CheckSvi fails when intfmgrd did not apply the VLAN interface
+func (d *Dataplane) CheckSvi(ctx context.Context, obj *pb.Svi) error {
+	bridge, ok := d.server.Bridges[obj.Spec.LogicalBridge]
+	if !ok {
+		err := status.Errorf(codes.NotFound, "unable to find key %s", obj.Spec.LogicalBridge)
+		return err
+	}
+	return checkKeys(ctx, d.appl, "INTF_TABLE:"+vlanName(bridge))
+}
+
+// PrecheckCreateSvi checks the VLAN and VRF are configured and the VLAN_INTERFACE is not
+func (d *Dataplane) PrecheckCreateSvi(ctx context.Context, _ *pb.Svi, bridge *pb.LogicalBridge, vrf *pb.Vrf) error {
+	if err := checkKeys(ctx, d.config, "VLAN|"+vlanName(bridge), "VRF|"+VrfName(vrf.Name)); err != nil {
+		return err
+	}
+	return d.checkKeysAbsent(ctx, "VLAN_INTERFACE|"+vlanName(bridge))
+}
+
+// ResyncSvi writes the Svi again, there is nothing to recreate since intfmgrd reapplies it
+func (d *Dataplane) ResyncSvi(ctx context.Context, obj *pb.Svi, bridge *pb.LogicalBridge, vrf *pb.Vrf, _ bool) error {
+	return d.CreateSvi(ctx, obj, bridge, vrf)
+}
+
+func handoffName(obj *evpn.VrfLiteHandoff) string {
+	return fmt.Sprintf("%s.%d", obj.Spec.Uplink, obj.Spec.VlanID)
+}
+
+// CreateVrfLiteHandoff writes a VLAN_SUB_INTERFACE of the uplink in the VRF and peers with
+// the router on the other side through a BGP_NEIGHBOR of the VRF
+func (d *Dataplane) CreateVrfLiteHandoff(ctx context.Context, obj *evpn.VrfLiteHandoff, vrf *pb.Vrf) error {
+	name := handoffName(obj)
+	// Example: redis-cli -n 4 hset "VLAN_SUB_INTERFACE|Ethernet0.100" vlan 100 vrf_name Vrf-blue admin_status up
+	if err := d.set(ctx, "VLAN_SUB_INTERFACE|"+name, map[string]string{
+		"vlan":         strconv.Itoa(int(obj.Spec.VlanID)),
+		"vrf_name":     VrfName(vrf.Name),
+		"admin_status": "up",
+	}); err != nil {
+		return err
+	}
+	if err := d.set(ctx, "VLAN_SUB_INTERFACE|"+name+"|"+ipPrefix(obj.Spec.LocalIPPrefix), nil); err != nil {
+		return err
+	}
+	peer := ipv4(obj.Spec.PeerIPAddress.GetV4Addr()).String()
+	// Example: redis-cli -n 4 hset "BGP_NEIGHBOR|Vrf-blue|10.0.0.2" asn 65001
+	return d.set(ctx, "BGP_NEIGHBOR|"+VrfName(vrf.Name)+"|"+peer, map[string]string{
+		"asn":          strconv.Itoa(int(obj.Spec.RemoteAs)),
+		"name":         path.Base(obj.Name),
+		"admin_status": "up",
+	})
+}
+
+// DeleteVrfLiteHandoff removes the BGP peering before the sub interface
+func (d *Dataplane) DeleteVrfLiteHandoff(ctx context.Context, obj *evpn.VrfLiteHandoff, vrf *pb.Vrf) error {
+	name := handoffName(obj)
+	peer := ipv4(obj.Spec.PeerIPAddress.GetV4Addr()).String()
+	if err := d.del(ctx, "BGP_NEIGHBOR|"+VrfName(vrf.Name)+"|"+peer); err != nil {
+		return err
+	}
+	if err := d.del(ctx, "VLAN_SUB_INTERFACE|"+name+"|"+ipPrefix(obj.Spec.LocalIPPrefix)); err != nil {
+		return err
+	}
+	return d.del(ctx, "VLAN_SUB_INTERFACE|"+name)
+}
+
+// GetVrfLiteHandoff fails with NotFound when the sub interface is not in CONFIG_DB
+func (d *Dataplane) GetVrfLiteHandoff(ctx context.Context, obj *evpn.VrfLiteHandoff) error {
+	return checkKeys(ctx, d.config, "VLAN_SUB_INTERFACE|"+handoffName(obj))
+}
+
+func routeKey(vrf *pb.Vrf, prefix *pc.IPPrefix) string {
+	return "STATIC_ROUTE|" + VrfName(vrf.Name) + "|" + ipPrefix(prefix)
+}
+
+// CreateRoute writes the STATIC_ROUTE of the VRF, bgpcfgd configures it into FRR
+func (d *Dataplane) CreateRoute(ctx context.Context, obj *evpn.Route, vrf *pb.Vrf) error {
+	fields := map[string]string{}
+	if obj.Spec.NextHop != nil {
+		fields["nexthop"] = ipv4(obj.Spec.NextHop.GetV4Addr()).String()
+	}
+	if obj.Spec.Interface != "" {
+		fields["ifname"] = obj.Spec.Interface
+	}
+	if obj.Spec.Metric != 0 {
+		fields["distance"] = strconv.Itoa(int(obj.Spec.Metric))
+	}
+	// Example: redis-cli -n 4 hset "STATIC_ROUTE|Vrf-blue|10.1.0.0/16" nexthop 10.0.0.1 ifname Ethernet0
+	return d.set(ctx, routeKey(vrf, obj.Spec.Prefix), fields)
+}
+
+// DeleteRoute deletes the STATIC_ROUTE of the VRF
+func (d *Dataplane) DeleteRoute(ctx context.Context, obj *evpn.Route, vrf *pb.Vrf) error {
+	return d.del(ctx, routeKey(vrf, obj.Spec.Prefix))
+}
+
+// CreateRouteLeak writes a STATIC_ROUTE in the destination VRF, resolved in the source VRF,
+// for each of the leaked prefixes. SONiC has no CONFIG_DB table to import a whole VRF
+func (d *Dataplane) CreateRouteLeak(ctx context.Context, obj *evpn.RouteLeak, src *pb.Vrf, dst *pb.Vrf) error {
+	if len(obj.Spec.Prefixes) == 0 {
+		msg := fmt.Sprintf("RouteLeak %s without prefixes is not supported by the SONiC dataplane", obj.Name)
+		return status.Error(codes.Unimplemented, msg)
+	}
+	for _, prefix := range obj.Spec.Prefixes {
+		// Example: redis-cli -n 4 hset "STATIC_ROUTE|Vrf-red|10.1.0.0/16" nexthop-vrf Vrf-blue
+		if err := d.set(ctx, routeKey(dst, prefix), map[string]string{"nexthop-vrf": VrfName(src.Name)}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeleteRouteLeak deletes the leaked STATIC_ROUTEs, keyed by the destination VRF name only
+func (d *Dataplane) DeleteRouteLeak(ctx context.Context, obj *evpn.RouteLeak) error {
+	var keys []string
+	for _, prefix := range obj.Spec.Prefixes {
+		keys = append(keys, routeKey(&pb.Vrf{Name: obj.Spec.DestinationVrf}, prefix))
+	}
+	return d.del(ctx, keys...)
+}
+
+func bgpPeerKey(obj *evpn.BgpPeer) string {
+	return "BGP_NEIGHBOR|default|" + evpn.BgpPeerNeighbor(obj.Spec)
+}
+
+// bgpPeerFamilies maps the address families to the BGP_NEIGHBOR_AF names of bgpcfgd
+var bgpPeerFamilies = map[evpn.BgpAddressFamily]string{
+	evpn.BgpIPv4Unicast: "ipv4_unicast",
+	evpn.BgpL2vpnEvpn:   "l2vpn_evpn",
+	evpn.BgpIPv4Vpn:     "ipv4_vpn",
+	evpn.BgpIPv6Vpn:     "ipv6_vpn",
+}
+
+// CreateBgpPeer writes a BGP_NEIGHBOR of the default VRF, with a BGP_NEIGHBOR_AF per address family
+func (d *Dataplane) CreateBgpPeer(ctx context.Context, obj *evpn.BgpPeer) error {
+	if obj.Spec.Interface != "" {
+		msg := fmt.Sprintf("unnumbered BgpPeer %s is not supported by the SONiC dataplane", obj.Name)
+		return status.Error(codes.Unimplemented, msg)
+	}
+	// the asn of a BGP_NEIGHBOR is a number
+	if obj.Spec.PeerType != "" {
+		msg := fmt.Sprintf("BgpPeer %s with a %s peer type is not supported by the SONiC dataplane", obj.Name, obj.Spec.PeerType)
+		return status.Error(codes.Unimplemented, msg)
+	}
+	fields := map[string]string{
+		"asn":          strconv.Itoa(int(obj.Spec.RemoteAs)),
+		"name":         path.Base(obj.Name),
+		"admin_status": "up",
+	}
+	if obj.Spec.HoldTime != 0 {
+		fields["keepalive"] = strconv.Itoa(int(obj.Spec.KeepaliveTime))
+		fields["holdtime"] = strconv.Itoa(int(obj.Spec.HoldTime))
+	}
+	// Example: redis-cli -n 4 hset "BGP_NEIGHBOR|default|10.0.0.2" asn 65001
+	if err := d.set(ctx, bgpPeerKey(obj), fields); err != nil {
+		return err
+	}
+	for _, family := range evpn.BgpPeerAddressFamilies(obj.Spec) {
+		// Example: redis-cli -n 4 hset "BGP_NEIGHBOR_AF|default|10.0.0.2|l2vpn_evpn" admin_status true
+		key := "BGP_NEIGHBOR_AF|default|" + evpn.BgpPeerNeighbor(obj.Spec) + "|" + bgpPeerFamilies[family]
+		if err := d.set(ctx, key, map[string]string{"admin_status": "true"}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeleteBgpPeer deletes the address families before the BGP_NEIGHBOR
+func (d *Dataplane) DeleteBgpPeer(ctx context.Context, obj *evpn.BgpPeer) error {
+	var keys []string
+	for _, family := range evpn.BgpPeerAddressFamilies(obj.Spec) {
+		keys = append(keys, "BGP_NEIGHBOR_AF|default|"+evpn.BgpPeerNeighbor(obj.Spec)+"|"+bgpPeerFamilies[family])
+	}
+	keys = append(keys, bgpPeerKey(obj))
+	return d.del(ctx, keys...)
+}
+
+// SetMacAgeing writes the FDB ageing time of the switch, SONiC counts it in seconds
+func (d *Dataplane) SetMacAgeing(ctx context.Context, ageing time.Duration) error {
+	// Example: redis-cli -n 4 hset "SWITCH|switch" fdb_aging_time 600
+	return d.set(ctx, "SWITCH|switch", map[string]string{"fdb_aging_time": strconv.Itoa(int(ageing / time.Second))})
+}
+
+// FlushBridgeMacs is not supported, SONiC flushes the FDB with sonic-clear, not through CONFIG_DB
+func (d *Dataplane) FlushBridgeMacs(_ context.Context, bridge *pb.LogicalBridge, _ *pb.BridgePort) (int, error) {
+	msg := fmt.Sprintf("Flushing the MAC addresses of %s is not supported by the SONiC dataplane", bridge.Name)
+	return 0, status.Error(codes.Unimplemented, msg)
+}
+
+// CreateStaticFdbEntry is not supported, CONFIG_DB has no static FDB table
+func (d *Dataplane) CreateStaticFdbEntry(_ context.Context, obj *evpn.StaticFdbEntry, _ *pb.LogicalBridge) error {
+	msg := fmt.Sprintf("StaticFdbEntry %s is not supported by the SONiC dataplane", obj.Name)
+	return status.Error(codes.Unimplemented, msg)
+}
+
+// DeleteStaticFdbEntry is not supported, no entry can be created
+func (d *Dataplane) DeleteStaticFdbEntry(_ context.Context, obj *evpn.StaticFdbEntry, _ *pb.LogicalBridge) error {
+	msg := fmt.Sprintf("StaticFdbEntry %s is not supported by the SONiC dataplane", obj.Name)
+	return status.Error(codes.Unimplemented, msg)
+}
+
+// CreateSecurityPolicy is not supported, the ACL tables of CONFIG_DB are not managed yet
+func (d *Dataplane) CreateSecurityPolicy(_ context.Context, obj *evpn.SecurityPolicy, _ *pb.Vrf, _ []*pb.Svi) error {
+	msg := fmt.Sprintf("SecurityPolicy %s is not supported by the SONiC dataplane", obj.Name)
+	return status.Error(codes.Unimplemented, msg)
+}
+
+// DeleteSecurityPolicy is not supported, no policy can be created
+func (d *Dataplane) DeleteSecurityPolicy(_ context.Context, obj *evpn.SecurityPolicy, _ *pb.Vrf) error {
+	msg := fmt.Sprintf("SecurityPolicy %s is not supported by the SONiC dataplane", obj.Name)
+	return status.Error(codes.Unimplemented, msg)
+}
+
+// CreateNatRule is not supported, the NAT tables of CONFIG_DB are not managed yet
+func (d *Dataplane) CreateNatRule(_ context.Context, obj *evpn.NatRule, _ *pb.Vrf) error {
+	msg := fmt.Sprintf("NatRule %s is not supported by the SONiC dataplane", obj.Name)
+	return status.Error(codes.Unimplemented, msg)
+}
+
+// DeleteNatRule is not supported, no rule can be created
+func (d *Dataplane) DeleteNatRule(_ context.Context, obj *evpn.NatRule, _ *pb.Vrf) error {
+	msg := fmt.Sprintf("NatRule %s is not supported by the SONiC dataplane", obj.Name)
+	return status.Error(codes.Unimplemented, msg)
+}
+
+// CreatePbrRule is not supported, the PBR tables of CONFIG_DB are not managed yet
+func (d *Dataplane) CreatePbrRule(_ context.Context, obj *evpn.PbrRule, _ *pb.Vrf) error {
+	msg := fmt.Sprintf("PbrRule %s is not supported by the SONiC dataplane", obj.Name)
+	return status.Error(codes.Unimplemented, msg)
+}
+
+// DeletePbrRule is not supported, no rule can be created
+func (d *Dataplane) DeletePbrRule(_ context.Context, obj *evpn.PbrRule, _ *pb.Vrf) error {
+	msg := fmt.Sprintf("PbrRule %s is not supported by the SONiC dataplane", obj.Name)
+	return status.Error(codes.Unimplemented, msg)
+}
+
+// CreatePrefixList is not supported, the routing policies of CONFIG_DB are not managed yet
+func (d *Dataplane) CreatePrefixList(_ context.Context, obj *evpn.PrefixList) error {
+	msg := fmt.Sprintf("PrefixList %s is not supported by the SONiC dataplane", obj.Name)
+	return status.Error(codes.Unimplemented, msg)
+}
+
+// DeletePrefixList is not supported, no list can be created
+func (d *Dataplane) DeletePrefixList(_ context.Context, obj *evpn.PrefixList) error {
+	msg := fmt.Sprintf("PrefixList %s is not supported by the SONiC dataplane", obj.Name)
+	return status.Error(codes.Unimplemented, msg)
+}
+
+// CreateRouteMap is not supported, the routing policies of CONFIG_DB are not managed yet
+func (d *Dataplane) CreateRouteMap(_ context.Context, obj *evpn.RouteMap) error {
+	msg := fmt.Sprintf("RouteMap %s is not supported by the SONiC dataplane", obj.Name)
+	return status.Error(codes.Unimplemented, msg)
+}
+
+// DeleteRouteMap is not supported, no map can be created
+func (d *Dataplane) DeleteRouteMap(_ context.Context, obj *evpn.RouteMap) error {
+	msg := fmt.Sprintf("RouteMap %s is not supported by the SONiC dataplane", obj.Name)
+	return status.Error(codes.Unimplemented, msg)
+}
+
+// CreateTunnelSecurity is not supported, the ASIC encapsulates the tunnels without IPsec
+func (d *Dataplane) CreateTunnelSecurity(_ context.Context, obj *evpn.TunnelSecurity) error {
+	msg := fmt.Sprintf("TunnelSecurity %s is not supported by the SONiC dataplane", obj.Name)
+	return status.Error(codes.Unimplemented, msg)
+}
+
+// DeleteTunnelSecurity is not supported, no protection can be created
+func (d *Dataplane) DeleteTunnelSecurity(_ context.Context, obj *evpn.TunnelSecurity) error {
+	msg := fmt.Sprintf("TunnelSecurity %s is not supported by the SONiC dataplane", obj.Name)
+	return status.Error(codes.Unimplemented, msg)
+}
+
+// CreateUnderlayInterface is not supported, the interfaces of the switch are configured by SONiC
+func (d *Dataplane) CreateUnderlayInterface(_ context.Context, obj *evpn.UnderlayInterface) error {
+	msg := fmt.Sprintf("UnderlayInterface %s is not supported by the SONiC dataplane", obj.Name)
+	return status.Error(codes.Unimplemented, msg)
+}
+
+// DeleteUnderlayInterface is not supported, no underlay interface can be created
+func (d *Dataplane) DeleteUnderlayInterface(_ context.Context, obj *evpn.UnderlayInterface) error {
+	msg := fmt.Sprintf("UnderlayInterface %s is not supported by the SONiC dataplane", obj.Name)
+	return status.Error(codes.Unimplemented, msg)
+}
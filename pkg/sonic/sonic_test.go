@@ -0,0 +1,218 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package sonic writes the EVPN objects into the Redis databases of a SONiC switch or DPU
+package sonic
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/philippgille/gokv/gomap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+	pc "github.com/opiproject/opi-api/network/opinetcommon/v1alpha1/gen/go"
+	"github.com/opiproject/opi-evpn-bridge/pkg/evpn"
+	"github.com/opiproject/opi-evpn-bridge/pkg/utils/mocks"
+)
+
+// fakeDB keeps the entries in memory
+type fakeDB map[string]map[string]string
+
+func (db fakeDB) HSet(_ context.Context, key string, fields map[string]string) error {
+	db[key] = fields
+	return nil
+}
+
+func (db fakeDB) Del(_ context.Context, keys ...string) error {
+	for _, key := range keys {
+		delete(db, key)
+	}
+	return nil
+}
+
+func (db fakeDB) Exists(_ context.Context, key string) (bool, error) {
+	_, ok := db[key]
+	return ok, nil
+}
+
+func newTestDataplane(t *testing.T, config fakeDB) *Dataplane {
+	server := evpn.NewServerWithArgs(mocks.NewNetlink(t), mocks.NewFrr(t), gomap.NewStore(gomap.DefaultOptions))
+	server.Bridges["//network.opiproject.org/bridges/vlan10"] = &pb.LogicalBridge{Spec: &pb.LogicalBridgeSpec{VlanId: 10}}
+	server.Bridges["//network.opiproject.org/bridges/vlan20"] = &pb.LogicalBridge{Spec: &pb.LogicalBridgeSpec{VlanId: 20}}
+	return NewDataplane(server, config, fakeDB{})
+}
+
+func TestDataplane_LogicalBridge(t *testing.T) {
+	vni := uint32(10)
+	obj := &pb.LogicalBridge{
+		Name: "//network.opiproject.org/bridges/vlan10",
+		Spec: &pb.LogicalBridgeSpec{
+			VlanId:       10,
+			Vni:          &vni,
+			VtepIpPrefix: &pc.IPPrefix{Addr: &pc.IPAddress{V4OrV6: &pc.IPAddress_V4Addr{V4Addr: 0x0a000001}}, Len: 24},
+		},
+	}
+	config := fakeDB{}
+	dataplane := newTestDataplane(t, config)
+
+	if err := dataplane.CreateLogicalBridge(context.Background(), obj); err != nil {
+		t.Fatal("error: expected", nil, "received", err)
+	}
+	want := fakeDB{
+		"VLAN|Vlan10":                         {"vlanid": "10"},
+		"VXLAN_TUNNEL|vtep":                   {"src_ip": "10.0.0.1"},
+		"VXLAN_EVPN_NVO|nvo":                  {"source_vtep": "vtep"},
+		"VXLAN_TUNNEL_MAP|vtep|map_10_Vlan10": {"vlan": "Vlan10", "vni": "10"},
+	}
+	if !reflect.DeepEqual(config, want) {
+		t.Error("CONFIG_DB: expected", want, "received", config)
+	}
+	if err := dataplane.PrecheckCreateLogicalBridge(context.Background(), obj); err == nil {
+		t.Error("error: expected the VLAN to exist")
+	}
+	if err := dataplane.CheckLogicalBridge(context.Background(), obj); err == nil {
+		t.Error("error: expected the VLAN not to be applied yet")
+	}
+
+	if err := dataplane.DeleteLogicalBridge(context.Background(), obj); err != nil {
+		t.Fatal("error: expected", nil, "received", err)
+	}
+	if _, ok := config["VLAN|Vlan10"]; ok || len(config) != 2 {
+		t.Error("CONFIG_DB: expected only the vtep and nvo, received", config)
+	}
+}
+
+func TestDataplane_BindBridgePort(t *testing.T) {
+	tests := map[string]struct {
+		ptype   pb.BridgePortType
+		mode    string
+		wantErr bool
+	}{
+		"access": {
+			ptype: pb.BridgePortType_ACCESS,
+			mode:  "untagged",
+		},
+		"trunk": {
+			ptype: pb.BridgePortType_TRUNK,
+			mode:  "tagged",
+		},
+		"unknown type": {
+			ptype:   pb.BridgePortType_UNKNOWN,
+			wantErr: true,
+		},
+	}
+
+	for testName, tt := range tests {
+		t.Run(testName, func(t *testing.T) {
+			config := fakeDB{}
+			dataplane := newTestDataplane(t, config)
+			obj := &pb.BridgePort{
+				Name: "//network.opiproject.org/ports/Ethernet0",
+				Spec: &pb.BridgePortSpec{
+					Ptype:          tt.ptype,
+					LogicalBridges: []string{"//network.opiproject.org/bridges/vlan10", "//network.opiproject.org/bridges/vlan20"},
+				},
+			}
+			err := dataplane.BindBridgePort(context.Background(), obj)
+			if (err != nil) != tt.wantErr {
+				t.Error("error: expected", tt.wantErr, "received", err)
+			}
+			for _, key := range []string{"VLAN_MEMBER|Vlan10|Ethernet0", "VLAN_MEMBER|Vlan20|Ethernet0"} {
+				if tt.mode != "" && config[key]["tagging_mode"] != tt.mode {
+					t.Error("tagging_mode of", key, ": expected", tt.mode, "received", config[key])
+				}
+			}
+		})
+	}
+}
+
+func TestDataplane_BindBridgePort_NativeVlan(t *testing.T) {
+	config := fakeDB{}
+	dataplane := newTestDataplane(t, config)
+	obj := &pb.BridgePort{
+		Name: "//network.opiproject.org/ports/Ethernet0",
+		Spec: &pb.BridgePortSpec{
+			Ptype:          pb.BridgePortType_TRUNK,
+			LogicalBridges: []string{"//network.opiproject.org/bridges/vlan10", "//network.opiproject.org/bridges/vlan20"},
+		},
+	}
+	dataplane.server.TrunkVlans[obj.Name] = evpn.TrunkVlans{Native: 10}
+	if err := dataplane.BindBridgePort(context.Background(), obj); err != nil {
+		t.Fatal("error: expected", nil, "received", err)
+	}
+	if mode := config["VLAN_MEMBER|Vlan10|Ethernet0"]["tagging_mode"]; mode != "untagged" {
+		t.Error("tagging_mode of native vlan: expected untagged, received", mode)
+	}
+	if mode := config["VLAN_MEMBER|Vlan20|Ethernet0"]["tagging_mode"]; mode != "tagged" {
+		t.Error("tagging_mode: expected tagged, received", mode)
+	}
+
+	dataplane.server.TrunkVlans[obj.Name] = evpn.TrunkVlans{Translations: map[uint32]uint32{100: 20}}
+	if err := dataplane.BindBridgePort(context.Background(), obj); status.Code(err) != codes.Unimplemented {
+		t.Error("error: expected", codes.Unimplemented, "received", err)
+	}
+}
+
+func TestDataplane_BgpPeer(t *testing.T) {
+	obj := &evpn.BgpPeer{
+		Name: "//network.opiproject.org/bgppeers/spine1",
+		Spec: &evpn.BgpPeerSpec{
+			PeerIPAddress: &pc.IPAddress{V4OrV6: &pc.IPAddress_V4Addr{V4Addr: 0x0a000002}},
+			RemoteAs:      65001,
+			KeepaliveTime: 3,
+			HoldTime:      9,
+		},
+	}
+	config := fakeDB{}
+	dataplane := newTestDataplane(t, config)
+
+	if err := dataplane.CreateBgpPeer(context.Background(), obj); err != nil {
+		t.Fatal("error: expected", nil, "received", err)
+	}
+	want := fakeDB{
+		"BGP_NEIGHBOR|default|10.0.0.2":                 {"asn": "65001", "name": "spine1", "admin_status": "up", "keepalive": "3", "holdtime": "9"},
+		"BGP_NEIGHBOR_AF|default|10.0.0.2|ipv4_unicast": {"admin_status": "true"},
+		"BGP_NEIGHBOR_AF|default|10.0.0.2|l2vpn_evpn":   {"admin_status": "true"},
+	}
+	if !reflect.DeepEqual(config, want) {
+		t.Error("CONFIG_DB: expected", want, "received", config)
+	}
+
+	if err := dataplane.DeleteBgpPeer(context.Background(), obj); err != nil {
+		t.Fatal("error: expected", nil, "received", err)
+	}
+	if len(config) != 0 {
+		t.Error("CONFIG_DB: expected no entries, received", config)
+	}
+}
+
+func TestDataplane_AnycastSvi(t *testing.T) {
+	obj := &pb.Svi{
+		Name: "//network.opiproject.org/svis/svi10",
+		Spec: &pb.SviSpec{
+			MacAddress: []byte{0x00, 0x00, 0x5e, 0x00, 0x01, 0x01},
+			GwIpPrefix: []*pc.IPPrefix{{Addr: &pc.IPAddress{V4OrV6: &pc.IPAddress_V4Addr{V4Addr: 0x0a010001}}, Len: 24}},
+		},
+	}
+	bridge := &pb.LogicalBridge{Spec: &pb.LogicalBridgeSpec{VlanId: 10}}
+	vrf := &pb.Vrf{Name: "//network.opiproject.org/vrfs/blue"}
+	config := fakeDB{}
+	dataplane := newTestDataplane(t, config)
+	dataplane.server.AnycastGateways[obj.Name] = true
+
+	if err := dataplane.CreateSvi(context.Background(), obj, bridge, vrf); err != nil {
+		t.Fatal("error: expected", nil, "received", err)
+	}
+	want := fakeDB{
+		"SAG|GLOBAL":                        {"gateway_mac": "00:00:5e:00:01:01"},
+		"VLAN_INTERFACE|Vlan10":             {"vrf_name": "Vrf-blue", "static_anycast_gateway": "true"},
+		"VLAN_INTERFACE|Vlan10|10.1.0.1/24": nil,
+	}
+	if !reflect.DeepEqual(config, want) {
+		t.Error("CONFIG_DB: expected", want, "received", config)
+	}
+}
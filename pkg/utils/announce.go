@@ -0,0 +1,180 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package utils contails useful helper functions
+package utils
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/sys/unix"
+)
+
+const (
+	// etherTypeArp and etherTypeIPv6 are the ethertypes of the announcements
+	etherTypeArp  = 0x0806
+	etherTypeIPv6 = 0x86dd
+	// icmpv6NeighborAdvertisement is the ICMPv6 type of a neighbor advertisement
+	icmpv6NeighborAdvertisement = 136
+	// naRouterOverride are the router and override flags of the unsolicited advertisements of
+	// a gateway, the override one replacing the cached link-layer address
+	naRouterOverride = 0xa0000000
+)
+
+// Announcer represents limited subset of the neighbor announcements, sent from a device so the
+// hosts of its link update their ARP and ND caches without waiting for them to expire
+type Announcer interface {
+	Announce(ctx context.Context, dev string, ip net.IP, count int, interval time.Duration) error
+}
+
+// AnnouncerWrapper wrapper sending the gratuitous ARPs and unsolicited neighbor advertisements
+// on a raw packet socket of the device
+type AnnouncerWrapper struct {
+	tracer trace.Tracer
+}
+
+// NewAnnouncerWrapper creates initialized instance of AnnouncerWrapper
+func NewAnnouncerWrapper() *AnnouncerWrapper {
+	// default tracer name is good for now
+	return &AnnouncerWrapper{tracer: otel.Tracer("")}
+}
+
+// build time check that struct implements interface
+var _ Announcer = (*AnnouncerWrapper)(nil)
+
+// Announce sends count gratuitous ARPs for an IPv4 address, or unsolicited neighbor
+// advertisements for an IPv6 one, from the MAC address of the device dev, interval apart
+func (n *AnnouncerWrapper) Announce(ctx context.Context, dev string, ip net.IP, count int, interval time.Duration) error {
+	_, childSpan := n.tracer.Start(ctx, "announcer.Announce")
+	childSpan.SetAttributes(attribute.String("link.name", dev), attribute.String("ip", ip.String()))
+	defer childSpan.End()
+	iface, err := net.InterfaceByName(dev)
+	if err != nil {
+		return err
+	}
+	frame, dst, err := announcementFrame(iface.HardwareAddr, ip)
+	if err != nil {
+		return err
+	}
+	fd, err := unix.Socket(unix.AF_PACKET, unix.SOCK_RAW, 0)
+	if err != nil {
+		return err
+	}
+	defer unix.Close(fd)
+	addr := &unix.SockaddrLinklayer{
+		Protocol: htons(binary.BigEndian.Uint16(frame[12:14])),
+		Ifindex:  iface.Index,
+		Halen:    6,
+	}
+	copy(addr.Addr[:], dst)
+	for i := 0; i < count; i++ {
+		if i != 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(interval):
+			}
+		}
+		if err := unix.Sendto(fd, frame, 0, addr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// announcementFrame builds the ethernet frame announcing ip at mac and returns it with its
+// destination MAC address
+func announcementFrame(mac net.HardwareAddr, ip net.IP) ([]byte, net.HardwareAddr, error) {
+	if len(mac) != 6 {
+		return nil, nil, fmt.Errorf("invalid MAC address %v, expected an ethernet one", mac)
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		dst := net.HardwareAddr{0xff, 0xff, 0xff, 0xff, 0xff, 0xff}
+		return append(ethernetHeader(dst, mac, etherTypeArp), gratuitousArp(mac, ip4)...), dst, nil
+	}
+	if ip16 := ip.To16(); ip16 != nil {
+		// all-nodes multicast ff02::1
+		allNodes := net.IP{0xff, 0x02, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0x01}
+		dst := net.HardwareAddr{0x33, 0x33, 0, 0, 0, 0x01}
+		return append(ethernetHeader(dst, mac, etherTypeIPv6), unsolicitedNa(mac, ip16, allNodes)...), dst, nil
+	}
+	return nil, nil, fmt.Errorf("invalid IP address %v", ip)
+}
+
+func ethernetHeader(dst net.HardwareAddr, src net.HardwareAddr, etherType uint16) []byte {
+	header := make([]byte, 14)
+	copy(header[0:6], dst)
+	copy(header[6:12], src)
+	binary.BigEndian.PutUint16(header[12:14], etherType)
+	return header
+}
+
+// gratuitousArp is an ARP request for ip from ip itself, the sender being cached by the hosts
+func gratuitousArp(mac net.HardwareAddr, ip net.IP) []byte {
+	arp := make([]byte, 28)
+	binary.BigEndian.PutUint16(arp[0:2], 1)      // ethernet
+	binary.BigEndian.PutUint16(arp[2:4], 0x0800) // IPv4
+	arp[4] = 6
+	arp[5] = 4
+	binary.BigEndian.PutUint16(arp[6:8], 1) // request
+	copy(arp[8:14], mac)
+	copy(arp[14:18], ip)
+	copy(arp[24:28], ip)
+	return arp
+}
+
+// unsolicitedNa is a neighbor advertisement of ip with the router and override flags and the
+// target link-layer address option, from ip to dst
+func unsolicitedNa(mac net.HardwareAddr, ip net.IP, dst net.IP) []byte {
+	icmp := make([]byte, 32)
+	icmp[0] = icmpv6NeighborAdvertisement
+	binary.BigEndian.PutUint32(icmp[4:8], naRouterOverride)
+	copy(icmp[8:24], ip)
+	icmp[24] = 2 // target link-layer address
+	icmp[25] = 1 // in units of 8 bytes
+	copy(icmp[26:32], mac)
+
+	packet := make([]byte, 40, 40+len(icmp))
+	packet[0] = 0x60
+	binary.BigEndian.PutUint16(packet[4:6], uint16(len(icmp)))
+	packet[6] = unix.IPPROTO_ICMPV6
+	packet[7] = 255 // the hosts drop the ND messages with any other hop limit
+	copy(packet[8:24], ip)
+	copy(packet[24:40], dst)
+	binary.BigEndian.PutUint16(icmp[2:4], icmpv6Checksum(ip, dst, icmp))
+	return append(packet, icmp...)
+}
+
+// icmpv6Checksum is the checksum of the ICMPv6 message and its IPv6 pseudo-header
+func icmpv6Checksum(src net.IP, dst net.IP, icmp []byte) uint16 {
+	var sum uint32
+	add := func(data []byte) {
+		for i := 0; i+1 < len(data); i += 2 {
+			sum += uint32(binary.BigEndian.Uint16(data[i : i+2]))
+		}
+		if len(data)%2 == 1 {
+			sum += uint32(data[len(data)-1]) << 8
+		}
+	}
+	add(src)
+	add(dst)
+	sum += uint32(len(icmp))
+	sum += unix.IPPROTO_ICMPV6
+	add(icmp)
+	for sum > 0xffff {
+		sum = (sum >> 16) + (sum & 0xffff)
+	}
+	return ^uint16(sum)
+}
+
+// htons converts to the network byte order the packet sockets expect their protocol in
+func htons(v uint16) uint16 {
+	return v<<8 | v>>8
+}
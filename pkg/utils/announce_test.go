@@ -0,0 +1,54 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package utils contails useful helper functions
+package utils
+
+import (
+	"bytes"
+	"net"
+	"testing"
+)
+
+func TestAnnouncementFrame(t *testing.T) {
+	mac := net.HardwareAddr{0xaa, 0xbb, 0xcc, 0x00, 0x00, 0x01}
+
+	frame, dst, err := announcementFrame(mac, net.ParseIP("10.0.0.1"))
+	if err != nil {
+		t.Fatal("error: expected", nil, "received", err)
+	}
+	expected := []byte{
+		0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xaa, 0xbb, 0xcc, 0x00, 0x00, 0x01, 0x08, 0x06,
+		0x00, 0x01, 0x08, 0x00, 6, 4, 0x00, 0x01,
+		0xaa, 0xbb, 0xcc, 0x00, 0x00, 0x01, 10, 0, 0, 1,
+		0, 0, 0, 0, 0, 0, 10, 0, 0, 1,
+	}
+	if !bytes.Equal(frame, expected) || dst.String() != "ff:ff:ff:ff:ff:ff" {
+		t.Error("gratuitous ARP: expected", expected, "received", frame, dst)
+	}
+
+	ip := net.ParseIP("2001:db8::1")
+	frame, dst, err = announcementFrame(mac, ip)
+	if err != nil {
+		t.Fatal("error: expected", nil, "received", err)
+	}
+	if len(frame) != 14+40+32 || dst.String() != "33:33:00:00:00:01" {
+		t.Fatal("neighbor advertisement: unexpected frame", frame, dst)
+	}
+	packet := frame[14:]
+	if packet[6] != 58 || packet[7] != 255 || !net.IP(packet[8:24]).Equal(ip) || !net.IP(packet[24:40]).Equal(net.ParseIP("ff02::1")) {
+		t.Error("neighbor advertisement: unexpected IPv6 header", packet[:40])
+	}
+	icmp := packet[40:]
+	if icmp[0] != 136 || icmp[4] != 0xa0 || !net.IP(icmp[8:24]).Equal(ip) || !bytes.Equal(icmp[26:32], mac) {
+		t.Error("neighbor advertisement: unexpected message", icmp)
+	}
+	// the checksum of a message including its checksum is 0
+	if sum := icmpv6Checksum(packet[8:24], packet[24:40], icmp); sum != 0 {
+		t.Error("checksum: expected", 0, "received", sum)
+	}
+
+	if _, _, err := announcementFrame(net.HardwareAddr{0x01}, ip); err == nil {
+		t.Error("error: expected an invalid MAC address")
+	}
+}
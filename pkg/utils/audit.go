@@ -0,0 +1,316 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package utils has some utility functions and interfaces
+package utils
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"log/syslog"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// auditRetention is the number of most recent audit events kept for retrieval
+const auditRetention = 1000
+
+// AuditChange is a single field of the object changed by a mutating call
+type AuditChange struct {
+	Path string          `json:"path"`
+	Old  json.RawMessage `json:"old,omitempty"`
+	New  json.RawMessage `json:"new,omitempty"`
+}
+
+// AuditEvent records a single mutating call
+type AuditEvent struct {
+	Time time.Time `json:"time"`
+	// Identity is the common name of the client certificate, empty without mutual TLS
+	Identity string          `json:"identity,omitempty"`
+	Source   string          `json:"source"`
+	Method   string          `json:"method"`
+	Object   string          `json:"object,omitempty"`
+	Request  json.RawMessage `json:"request,omitempty"`
+	Diff     []AuditChange   `json:"diff,omitempty"`
+	Code     string          `json:"code"`
+	Message  string          `json:"message,omitempty"`
+	Duration time.Duration   `json:"duration"`
+}
+
+// AuditForwarder ships audit events to an external system, Forward must not block
+type AuditForwarder interface {
+	Forward(ev AuditEvent)
+}
+
+// AuditLog appends every mutating call as a JSON line to a sink and keeps the
+// most recent events in memory so they can be listed
+type AuditLog struct {
+	mu         sync.Mutex
+	sink       io.Writer
+	events     []AuditEvent
+	forwarders []AuditForwarder
+	lookup     func(name string) proto.Message
+	now        func() time.Time
+}
+
+// NewAuditLog creates initialized instance of AuditLog, a nil sink only keeps events in memory
+func NewAuditLog(sink io.Writer) *AuditLog {
+	return &AuditLog{sink: sink, now: time.Now}
+}
+
+var defaultAuditLog = NewAuditLog(nil)
+
+// DefaultAuditLog returns the process wide AuditLog
+func DefaultAuditLog() *AuditLog {
+	return defaultAuditLog
+}
+
+// SetSink replaces the sink events are appended to
+func (a *AuditLog) SetSink(sink io.Writer) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.sink = sink
+}
+
+// AddForwarder registers a forwarder receiving every recorded event
+func (a *AuditLog) AddForwarder(f AuditForwarder) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.forwarders = append(a.forwarders, f)
+}
+
+// SetObjectLookup sets the function fetching the stored object by name,
+// used to record what a call changed
+func (a *AuditLog) SetObjectLookup(lookup func(name string) proto.Message) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.lookup = lookup
+}
+
+// OpenAuditSink opens the sink described by spec, either file:<path> to
+// append to a file or syslog to send to the local syslog daemon
+func OpenAuditSink(spec string) (io.WriteCloser, error) {
+	switch {
+	case spec == "syslog":
+		return syslog.New(syslog.LOG_INFO|syslog.LOG_AUTH, "opi-evpn-bridge")
+	case strings.HasPrefix(spec, "file:"):
+		return os.OpenFile(strings.TrimPrefix(spec, "file:"), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	default:
+		return nil, fmt.Errorf("invalid audit sink %q, expected file:<path> or syslog", spec)
+	}
+}
+
+// Record appends the event to the sink and to the in-memory history
+func (a *AuditLog) Record(ev AuditEvent) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.sink != nil {
+		line, err := json.Marshal(ev)
+		if err == nil {
+			_, err = a.sink.Write(append(line, '\n'))
+		}
+		if err != nil {
+			log.Printf("Failed to write audit event: %v", err)
+		}
+	}
+	if len(a.events) == auditRetention {
+		a.events = a.events[1:]
+	}
+	a.events = append(a.events, ev)
+	for _, f := range a.forwarders {
+		f.Forward(ev)
+	}
+}
+
+// Events returns a copy of the retained events, oldest first
+func (a *AuditLog) Events() []AuditEvent {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return append([]AuditEvent(nil), a.events...)
+}
+
+// callerFromContext returns the source address of the caller and, with mutual TLS,
+// the common name of its client certificate
+func callerFromContext(ctx context.Context) (identity string, source string) {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return "", "unknown"
+	}
+	if tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo); ok && len(tlsInfo.State.PeerCertificates) > 0 {
+		identity = tlsInfo.State.PeerCertificates[0].Subject.CommonName
+	}
+	return identity, p.Addr.String()
+}
+
+// objectName returns the name field of the request, or of the object it carries
+func objectName(m proto.Message) string {
+	msg := m.ProtoReflect()
+	fields := msg.Descriptor().Fields()
+	if fd := fields.ByName("name"); fd != nil && fd.Kind() == protoreflect.StringKind {
+		return msg.Get(fd).String()
+	}
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		if fd.Kind() != protoreflect.MessageKind || fd.IsList() || fd.IsMap() || !msg.Has(fd) {
+			continue
+		}
+		inner := msg.Get(fd).Message()
+		if nd := inner.Descriptor().Fields().ByName("name"); nd != nil && nd.Kind() == protoreflect.StringKind {
+			return inner.Get(nd).String()
+		}
+	}
+	return ""
+}
+
+func (a *AuditLog) lookupObject(name string) proto.Message {
+	a.mu.Lock()
+	lookup := a.lookup
+	a.mu.Unlock()
+	if lookup == nil || name == "" {
+		return nil
+	}
+	return lookup(name)
+}
+
+// flattenJSON maps the JSON leaves of v to their dotted paths, lists are leaves
+func flattenJSON(prefix string, v interface{}, out map[string]json.RawMessage) {
+	if obj, ok := v.(map[string]interface{}); ok {
+		for key, value := range obj {
+			path := key
+			if prefix != "" {
+				path = prefix + "." + key
+			}
+			flattenJSON(path, value, out)
+		}
+		return
+	}
+	b, err := json.Marshal(v)
+	if err == nil {
+		out[prefix] = b
+	}
+}
+
+func flattenMessage(m proto.Message) map[string]json.RawMessage {
+	out := map[string]json.RawMessage{}
+	if m == nil {
+		return out
+	}
+	b, err := protojson.Marshal(m)
+	if err != nil {
+		return out
+	}
+	var v interface{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return out
+	}
+	flattenJSON("", v, out)
+	return out
+}
+
+// diffObjects lists the fields that differ between the two versions of an object
+func diffObjects(before proto.Message, after proto.Message) []AuditChange {
+	old := flattenMessage(before)
+	updated := flattenMessage(after)
+	paths := map[string]bool{}
+	for path := range old {
+		paths[path] = true
+	}
+	for path := range updated {
+		paths[path] = true
+	}
+	var diff []AuditChange
+	for path := range paths {
+		if !bytes.Equal(old[path], updated[path]) {
+			diff = append(diff, AuditChange{Path: path, Old: old[path], New: updated[path]})
+		}
+	}
+	sort.Slice(diff, func(i int, j int) bool {
+		return diff[i].Path < diff[j].Path
+	})
+	return diff
+}
+
+// UnaryServerInterceptor records every programming call (Create, Update and Delete)
+// with its caller, payload, changes and outcome, rejected calls included
+func (a *AuditLog) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if !isProgrammingMethod(info.FullMethod) {
+			return handler(ctx, req)
+		}
+		m, isProto := req.(proto.Message)
+		var before proto.Message
+		if isProto {
+			// cloned, handlers update the stored objects in place
+			if obj := a.lookupObject(objectName(m)); obj != nil {
+				before = proto.Clone(obj)
+			}
+		}
+		start := a.now()
+		resp, err := handler(ctx, req)
+		identity, source := callerFromContext(ctx)
+		ev := AuditEvent{
+			Time:     start,
+			Identity: identity,
+			Source:   source,
+			Method:   info.FullMethod,
+			Code:     status.Code(err).String(),
+			Duration: a.now().Sub(start),
+		}
+		if isProto {
+			// create handlers fill in the name of the new object
+			ev.Object = objectName(m)
+			if payload, merr := protojson.Marshal(m); merr == nil {
+				ev.Request = payload
+			}
+			if err == nil {
+				ev.Diff = diffObjects(before, a.lookupObject(ev.Object))
+			}
+		}
+		if err != nil {
+			ev.Message = status.Convert(err).Message()
+		}
+		a.Record(ev)
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor records every programming stream with its caller and outcome,
+// the messages of a stream being neither recorded nor diffed
+func (a *AuditLog) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if !isProgrammingMethod(info.FullMethod) {
+			return handler(srv, ss)
+		}
+		start := a.now()
+		err := handler(srv, ss)
+		identity, source := callerFromContext(ss.Context())
+		ev := AuditEvent{
+			Time:     start,
+			Identity: identity,
+			Source:   source,
+			Method:   info.FullMethod,
+			Code:     status.Code(err).String(),
+			Duration: a.now().Sub(start),
+		}
+		if err != nil {
+			ev.Message = status.Convert(err).Message()
+		}
+		a.Record(ev)
+		return err
+	}
+}
@@ -0,0 +1,151 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package utils contains utility functions
+package utils
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net"
+	"reflect"
+	"testing"
+
+	middleware "github.com/grpc-ecosystem/go-grpc-middleware/v2"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestAuditLog_UnaryServerInterceptor(t *testing.T) {
+	tests := map[string]struct {
+		method    string
+		err       error
+		wantCount int
+		wantCode  string
+	}{
+		"read calls are not audited": {
+			method:    "/opi_api.network.evpn_gw.v1alpha1.VrfService/GetVrf",
+			err:       nil,
+			wantCount: 0,
+		},
+		"successful programming call": {
+			method:    "/opi_api.network.evpn_gw.v1alpha1.VrfService/CreateVrf",
+			err:       nil,
+			wantCount: 1,
+			wantCode:  "OK",
+		},
+		"failed programming call": {
+			method:    "/opi_api.network.evpn_gw.v1alpha1.VrfService/DeleteVrf",
+			err:       status.Error(codes.NotFound, "unable to find key"),
+			wantCount: 1,
+			wantCode:  "NotFound",
+		},
+	}
+	for testName, tt := range tests {
+		t.Run(testName, func(t *testing.T) {
+			sink := &bytes.Buffer{}
+			audit := NewAuditLog(sink)
+			handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+				return nil, tt.err
+			}
+			addr := &net.TCPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 1234}
+			ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: addr})
+			_, err := audit.UnaryServerInterceptor()(ctx, wrapperspb.String("payload"), &grpc.UnaryServerInfo{FullMethod: tt.method}, handler)
+			if err != tt.err {
+				t.Errorf("interceptor() error = %v, want %v", err, tt.err)
+			}
+			events := audit.Events()
+			if len(events) != tt.wantCount {
+				t.Fatalf("Events() = %v, want %v events", events, tt.wantCount)
+			}
+			if tt.wantCount == 0 {
+				if sink.Len() != 0 {
+					t.Errorf("sink = %v, want empty", sink.String())
+				}
+				return
+			}
+			if events[0].Code != tt.wantCode || events[0].Source != addr.String() || string(events[0].Request) != `"payload"` {
+				t.Errorf("Events() = %v, want code %v from %v", events[0], tt.wantCode, addr)
+			}
+			line := AuditEvent{}
+			if err := json.Unmarshal(sink.Bytes(), &line); err != nil || line.Method != tt.method {
+				t.Errorf("sink = %v, want a JSON record of %v", sink.String(), tt.method)
+			}
+		})
+	}
+}
+
+func TestAuditLog_StreamServerInterceptor(t *testing.T) {
+	audit := NewAuditLog(nil)
+	handler := func(srv interface{}, stream grpc.ServerStream) error {
+		return status.Error(codes.Unavailable, "instance is in standby")
+	}
+	addr := &net.TCPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 1234}
+	stream := &middleware.WrappedServerStream{WrappedContext: peer.NewContext(context.Background(), &peer.Peer{Addr: addr})}
+	for _, method := range []string{
+		"/opi_evpn_bridge.v1alpha1.ListStreamService/ListLogicalBridgesStream",
+		"/opi_api.network.evpn_gw.v1alpha1.VrfService/CreateVrf",
+	} {
+		if err := audit.StreamServerInterceptor()(nil, stream, &grpc.StreamServerInfo{FullMethod: method}, handler); status.Code(err) != codes.Unavailable {
+			t.Errorf("interceptor() error = %v, want %v", err, codes.Unavailable)
+		}
+	}
+	events := audit.Events()
+	if len(events) != 1 || events[0].Code != "Unavailable" || events[0].Source != addr.String() {
+		t.Errorf("Events() = %v, want a single Unavailable event of the programming stream from %v", events, addr)
+	}
+}
+
+func TestAuditLog_Retention(t *testing.T) {
+	audit := NewAuditLog(nil)
+	for i := 0; i < auditRetention+10; i++ {
+		audit.Record(AuditEvent{Method: "/Svc/CreateX"})
+	}
+	if n := len(audit.Events()); n != auditRetention {
+		t.Errorf("len(Events()) = %v, want %v", n, auditRetention)
+	}
+}
+
+func TestDiffObjects(t *testing.T) {
+	before, _ := structpb.NewStruct(map[string]interface{}{"spec": map[string]interface{}{"vni": 10, "vlan": 20}})
+	after, _ := structpb.NewStruct(map[string]interface{}{"spec": map[string]interface{}{"vni": 11, "vlan": 20}})
+	tests := map[string]struct {
+		before proto.Message
+		after  proto.Message
+		want   []AuditChange
+	}{
+		"update": {
+			before: before,
+			after:  after,
+			want:   []AuditChange{{Path: "spec.vni", Old: json.RawMessage(`10`), New: json.RawMessage(`11`)}},
+		},
+		"create": {
+			before: nil,
+			after:  after,
+			want: []AuditChange{
+				{Path: "spec.vlan", New: json.RawMessage(`20`)},
+				{Path: "spec.vni", New: json.RawMessage(`11`)},
+			},
+		},
+		"unchanged": {
+			before: before,
+			after:  before,
+			want:   nil,
+		},
+	}
+	for testName, tt := range tests {
+		t.Run(testName, func(t *testing.T) {
+			got := diffObjects(tt.before, tt.after)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("diffObjects() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
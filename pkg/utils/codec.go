@@ -0,0 +1,37 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package utils has some utility functions and interfaces
+package utils
+
+import (
+	"fmt"
+
+	"github.com/philippgille/gokv/encoding"
+	"google.golang.org/protobuf/proto"
+)
+
+// ProtoCodec encodes/decodes protobuf messages for the gokv store, the JSON
+// codec cannot decode oneof fields (e.g.: IPAddress.V4OrV6) back
+type ProtoCodec struct{}
+
+// build time check that struct implements interface
+var _ encoding.Codec = ProtoCodec{}
+
+// Marshal encodes a protobuf message to a slice of bytes
+func (c ProtoCodec) Marshal(v interface{}) ([]byte, error) {
+	msg, ok := v.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("unable to marshal %T, not a protobuf message", v)
+	}
+	return proto.Marshal(msg)
+}
+
+// Unmarshal decodes a slice of bytes into a protobuf message
+func (c ProtoCodec) Unmarshal(data []byte, v interface{}) error {
+	msg, ok := v.(proto.Message)
+	if !ok {
+		return fmt.Errorf("unable to unmarshal into %T, not a protobuf message", v)
+	}
+	return proto.Unmarshal(data, msg)
+}
@@ -0,0 +1,155 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package utils contails useful helper functions
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ping and traceroute are run bound to a device with SO_BINDTODEVICE, i.e. inside its VRF,
+// tcpdump captures the packets of a device
+const (
+	ping       = "ping"
+	traceroute = "traceroute"
+	tcpdump    = "tcpdump"
+)
+
+// Diagnostics represents limited subset of the connectivity diagnostic tools, their output
+// being passed line by line to the output function as the tool prints it, or as written
+// for the packet captures
+type Diagnostics interface {
+	Ping(ctx context.Context, dev string, dst string, count int, output func(line string)) error
+	Traceroute(ctx context.Context, dev string, dst string, maxHops int, output func(line string)) error
+	Capture(ctx context.Context, dev string, filter string, count int, output func(data []byte)) error
+}
+
+// DiagnosticsWrapper wrapper for the ping, traceroute and tcpdump command line tools
+type DiagnosticsWrapper struct {
+	tracer trace.Tracer
+}
+
+// NewDiagnosticsWrapper creates initialized instance of DiagnosticsWrapper
+func NewDiagnosticsWrapper() *DiagnosticsWrapper {
+	// default tracer name is good for now
+	return &DiagnosticsWrapper{tracer: otel.Tracer("")}
+}
+
+// build time check that struct implements interface
+var _ Diagnostics = (*DiagnosticsWrapper)(nil)
+
+// Ping sends count echo requests to dst from the device or the source address dev, through
+// the main routing table when empty, a destination not answering is a result of the ping and
+// not an error
+func (n *DiagnosticsWrapper) Ping(ctx context.Context, dev string, dst string, count int, output func(line string)) error {
+	// Example: ping -n -I blue -c 5 -W 1 10.0.0.1
+	args := []string{"-n"}
+	if dev != "" {
+		args = append(args, "-I", dev)
+	}
+	args = append(args, "-c", strconv.Itoa(count), "-W", "1", dst)
+	err := n.run(ctx, "diagnostics.Ping", ping, args, output)
+	// ping exits with 1 when no reply was received, 2 on the other errors
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+		return nil
+	}
+	return err
+}
+
+// Traceroute prints the hops of the path to dst from the device dev, up to maxHops
+func (n *DiagnosticsWrapper) Traceroute(ctx context.Context, dev string, dst string, maxHops int, output func(line string)) error {
+	// Example: traceroute -n -i blue -m 30 -w 1 10.0.0.1
+	args := []string{"-n", "-i", dev, "-m", strconv.Itoa(maxHops), "-w", "1", dst}
+	return n.run(ctx, "diagnostics.Traceroute", traceroute, args, output)
+}
+
+// Capture writes up to count packets of the device dev matching the BPF filter, all of them
+// when empty, to output in the pcap format, as soon as captured. The data passed to output
+// is only valid until it returns
+func (n *DiagnosticsWrapper) Capture(ctx context.Context, dev string, filter string, count int, output func(data []byte)) error {
+	_, childSpan := n.tracer.Start(ctx, "diagnostics.Capture")
+	childSpan.SetAttributes(attribute.String("diagnostics.dev", dev), attribute.String("diagnostics.filter", filter))
+	defer childSpan.End()
+
+	// Example: tcpdump -n -i vni10 -U -w - -c 100 -- icmp
+	args := []string{"-n", "-i", dev, "-U", "-w", "-", "-c", strconv.Itoa(count)}
+	if filter != "" {
+		// getopt reorders the arguments, a filter after -- is never read as an option
+		args = append(args, "--", filter)
+	}
+	cmd := exec.CommandContext(ctx, tcpdump, args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	buf := make([]byte, 64*1024)
+	for {
+		size, err := stdout.Read(buf)
+		if size > 0 {
+			output(buf[:size])
+		}
+		if err != nil {
+			break
+		}
+	}
+	if err := cmd.Wait(); err != nil {
+		if stderr.Len() > 0 {
+			return fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+		}
+		return err
+	}
+	return nil
+}
+
+// run streams the standard output of the tool to output, what it printed on its standard
+// error being added to its error
+func (n *DiagnosticsWrapper) run(ctx context.Context, span string, name string, args []string, output func(line string)) error {
+	_, childSpan := n.tracer.Start(ctx, span)
+	defer childSpan.End()
+
+	if childSpan.IsRecording() {
+		childSpan.SetAttributes(
+			attribute.String("diagnostics.name", name+" "+strings.Join(args, " ")),
+		)
+	}
+
+	cmd := exec.CommandContext(ctx, name, args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	scanner := bufio.NewScanner(stdout)
+	for scanner.Scan() {
+		output(scanner.Text())
+	}
+	if err := cmd.Wait(); err != nil {
+		if stderr.Len() > 0 {
+			return fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package utils has some utility functions and interfaces
+package utils
+
+import (
+	"errors"
+	"strconv"
+	"syscall"
+
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ErrorDomain is the domain of the ErrorInfo details attached to the errors of this server
+const ErrorDomain = "network.opiproject.org"
+
+// NetlinkError is the failure of a netlink call, returned to gRPC clients with an ErrorInfo
+// detail telling the call and the kernel error, see https://google.aip.dev/193#errorinfo
+type NetlinkError struct {
+	// Operation is the failed call, e.g.: LinkAdd
+	Operation string
+	Err       error
+}
+
+// Error keeps the message of the kernel error
+func (e *NetlinkError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the kernel error, so errors.As still finds the errno
+func (e *NetlinkError) Unwrap() error {
+	return e.Err
+}
+
+// GRPCStatus keeps the Unknown code the bare kernel errors were returned with
+func (e *NetlinkError) GRPCStatus() *status.Status {
+	st := status.New(codes.Unknown, e.Err.Error())
+	info := &errdetails.ErrorInfo{
+		Reason:   "NETLINK_ERROR",
+		Domain:   ErrorDomain,
+		Metadata: map[string]string{"operation": e.Operation},
+	}
+	var notFound netlink.LinkNotFoundError
+	var errno syscall.Errno
+	switch {
+	case errors.As(e.Err, &notFound):
+		info.Reason = "LINK_NOT_FOUND"
+	case errors.As(e.Err, &errno):
+		if name := unix.ErrnoName(errno); name != "" {
+			info.Reason = name
+		}
+		info.Metadata["errno"] = strconv.Itoa(int(errno))
+	}
+	detailed, err := st.WithDetails(info)
+	if err != nil {
+		return st
+	}
+	return detailed
+}
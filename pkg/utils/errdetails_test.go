@@ -0,0 +1,61 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package utils has some utility functions and interfaces
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"syscall"
+	"testing"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestNetlinkError_GRPCStatus(t *testing.T) {
+	tests := map[string]struct {
+		err    error
+		reason string
+		errno  string
+	}{
+		"errno": {
+			err:    syscall.EEXIST,
+			reason: "EEXIST",
+			errno:  "17",
+		},
+		"wrapped errno": {
+			err:    fmt.Errorf("link add: %w", syscall.ENODEV),
+			reason: "ENODEV",
+			errno:  "19",
+		},
+		"other error": {
+			err:    errors.New("Failed to call LinkAdd"),
+			reason: "NETLINK_ERROR",
+		},
+	}
+	for testName, tt := range tests {
+		t.Run(testName, func(t *testing.T) {
+			var err error = &NetlinkError{Operation: "LinkAdd", Err: tt.err}
+			if !errors.Is(err, tt.err) {
+				t.Error("error: expected to wrap", tt.err, "received", err)
+			}
+			st := status.Convert(err)
+			if st.Code() != codes.Unknown || st.Message() != tt.err.Error() {
+				t.Error("status: expected", codes.Unknown, tt.err, "received", st)
+			}
+			if len(st.Details()) != 1 {
+				t.Fatal("details: expected 1, received", st.Details())
+			}
+			info, ok := st.Details()[0].(*errdetails.ErrorInfo)
+			if !ok {
+				t.Fatal("detail: expected ErrorInfo, received", st.Details()[0])
+			}
+			if info.Reason != tt.reason || info.Domain != ErrorDomain || info.Metadata["operation"] != "LinkAdd" || info.Metadata["errno"] != tt.errno {
+				t.Error("error info: expected", tt.reason, tt.errno, "received", info)
+			}
+		})
+	}
+}
@@ -0,0 +1,195 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package utils has some utility functions and interfaces
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/segmentio/kafka-go"
+)
+
+// BusEventType is the kind of change a BusEvent reports, it is also the last part of its topic
+type BusEventType string
+
+const (
+	// BusResourceCreated reports a created object
+	BusResourceCreated BusEventType = "resource.created"
+	// BusResourceModified reports an updated object
+	BusResourceModified BusEventType = "resource.modified"
+	// BusResourceDeleted reports a deleted object
+	BusResourceDeleted BusEventType = "resource.deleted"
+	// BusLinkUp reports a kernel interface going up
+	BusLinkUp BusEventType = "link.up"
+	// BusLinkDown reports a kernel interface going down
+	BusLinkDown BusEventType = "link.down"
+	// BusBgpPeerState reports a BGP peer changing state
+	BusBgpPeerState BusEventType = "bgp.peer"
+)
+
+const (
+	// busQueueLength is the number of events buffered in memory, the next ones are dropped
+	busQueueLength = 1024
+	// busTimeout bounds a single delivery
+	busTimeout = 5 * time.Second
+)
+
+// BusEvent is a structured event published on the event bus as JSON
+type BusEvent struct {
+	Type BusEventType `json:"type"`
+	Time time.Time    `json:"time"`
+	// Name is the name of the object, for resource events
+	Name string `json:"name,omitempty"`
+	// Interface is the kernel interface, for link events
+	Interface string `json:"interface,omitempty"`
+	// Vrf and Peer identify the BGP peer, for peer events
+	Vrf  string `json:"vrf,omitempty"`
+	Peer string `json:"peer,omitempty"`
+	// State is the new BGP state of the peer, e.g. Established
+	State string `json:"state,omitempty"`
+}
+
+// EventPublisher emits BusEvents, implemented by EventBus
+type EventPublisher interface {
+	Publish(ev BusEvent)
+}
+
+// busSender delivers the payload of a single event to a topic of the bus
+type busSender interface {
+	Send(ctx context.Context, topic string, payload []byte) error
+	Close() error
+}
+
+// EventBus publishes events to NATS subjects or Kafka topics in the background, named
+// <prefix>.<type>, e.g. opi.evpn.link.down. The events are dropped, not retried, when the
+// bus is unreachable: the consumers resync from the gRPC API
+type EventBus struct {
+	sender busSender
+	prefix string
+	queue  chan BusEvent
+	done   chan struct{}
+	// mu guards closed
+	mu     sync.Mutex
+	closed bool
+}
+
+// build time check that struct implements interface
+var _ EventPublisher = (*EventBus)(nil)
+
+// NewEventBus connects to target, either nats://host:port or kafka://broker[,broker...]
+func NewEventBus(target string, prefix string) (*EventBus, error) {
+	u, err := url.Parse(target)
+	if err != nil {
+		return nil, fmt.Errorf("invalid event bus target %q: %w", target, err)
+	}
+	var sender busSender
+	switch u.Scheme {
+	case "nats":
+		conn, err := nats.Connect(target, nats.Timeout(busTimeout))
+		if err != nil {
+			return nil, err
+		}
+		sender = &natsSender{conn: conn}
+	case "kafka":
+		writer := &kafka.Writer{
+			Addr:                   kafka.TCP(strings.Split(u.Host, ",")...),
+			AllowAutoTopicCreation: true,
+			WriteTimeout:           busTimeout,
+		}
+		sender = &kafkaSender{writer: writer}
+	default:
+		return nil, fmt.Errorf("invalid event bus target %q, expected nats:// or kafka://", target)
+	}
+	return newEventBus(sender, prefix), nil
+}
+
+func newEventBus(sender busSender, prefix string) *EventBus {
+	b := &EventBus{
+		sender: sender,
+		prefix: prefix,
+		queue:  make(chan BusEvent, busQueueLength),
+		done:   make(chan struct{}),
+	}
+	go b.run()
+	return b
+}
+
+// Publish queues the event without ever blocking, stamping it with the current time
+func (b *EventBus) Publish(ev BusEvent) {
+	if ev.Time.IsZero() {
+		ev.Time = time.Now().UTC()
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return
+	}
+	select {
+	case b.queue <- ev:
+	default:
+		log.Printf("Dropping %s event, the event bus queue is full", ev.Type)
+	}
+}
+
+// Close delivers the queued events and disconnects from the bus
+func (b *EventBus) Close() error {
+	b.mu.Lock()
+	if !b.closed {
+		b.closed = true
+		close(b.queue)
+	}
+	b.mu.Unlock()
+	<-b.done
+	return b.sender.Close()
+}
+
+func (b *EventBus) run() {
+	defer close(b.done)
+	for ev := range b.queue {
+		payload, err := json.Marshal(ev)
+		if err != nil {
+			log.Printf("Failed to encode %s event: %v", ev.Type, err)
+			continue
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), busTimeout)
+		if err := b.sender.Send(ctx, b.prefix+"."+string(ev.Type), payload); err != nil {
+			log.Printf("Failed to publish %s event: %v", ev.Type, err)
+		}
+		cancel()
+	}
+}
+
+// natsSender publishes each event on the subject named after its topic
+type natsSender struct {
+	conn *nats.Conn
+}
+
+func (n *natsSender) Send(_ context.Context, topic string, payload []byte) error {
+	return n.conn.Publish(topic, payload)
+}
+
+func (n *natsSender) Close() error {
+	return n.conn.Drain()
+}
+
+// kafkaSender writes each event to its topic, created on first use
+type kafkaSender struct {
+	writer *kafka.Writer
+}
+
+func (k *kafkaSender) Send(ctx context.Context, topic string, payload []byte) error {
+	return k.writer.WriteMessages(ctx, kafka.Message{Topic: topic, Value: payload})
+}
+
+func (k *kafkaSender) Close() error {
+	return k.writer.Close()
+}
@@ -0,0 +1,76 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package utils contains utility functions
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+type fakeBusSender struct {
+	topics []string
+	events []BusEvent
+	err    error
+	closed bool
+}
+
+func (s *fakeBusSender) Send(_ context.Context, topic string, payload []byte) error {
+	ev := BusEvent{}
+	if err := json.Unmarshal(payload, &ev); err != nil {
+		return err
+	}
+	s.topics = append(s.topics, topic)
+	s.events = append(s.events, ev)
+	return s.err
+}
+
+func (s *fakeBusSender) Close() error {
+	s.closed = true
+	return nil
+}
+
+func TestEventBus_Publish(t *testing.T) {
+	tests := map[string]struct {
+		err error
+	}{
+		"delivered": {},
+		"bus failure is not retried": {
+			err: errors.New("connection refused"),
+		},
+	}
+
+	for testName, tt := range tests {
+		t.Run(testName, func(t *testing.T) {
+			sender := &fakeBusSender{err: tt.err}
+			bus := newEventBus(sender, "opi.evpn")
+			bus.Publish(BusEvent{Type: BusResourceCreated, Name: "//network.opiproject.org/vrfs/blue"})
+			bus.Publish(BusEvent{Type: BusLinkDown, Interface: "eth0"})
+			if err := bus.Close(); err != nil {
+				t.Fatalf("Close() error = %v", err)
+			}
+			// published after Close, dropped
+			bus.Publish(BusEvent{Type: BusLinkUp, Interface: "eth0"})
+
+			want := []string{"opi.evpn.resource.created", "opi.evpn.link.down"}
+			if len(sender.topics) != len(want) || sender.topics[0] != want[0] || sender.topics[1] != want[1] {
+				t.Errorf("topics = %v, want %v", sender.topics, want)
+			}
+			if sender.events[1].Interface != "eth0" || sender.events[1].Time.IsZero() {
+				t.Errorf("event = %+v, want eth0 with a time", sender.events[1])
+			}
+			if !sender.closed {
+				t.Error("sender not closed")
+			}
+		})
+	}
+}
+
+func TestNewEventBus_InvalidTarget(t *testing.T) {
+	if _, err := NewEventBus("amqp://localhost", "opi.evpn"); err == nil {
+		t.Error("NewEventBus() expected an error for an unsupported scheme")
+	}
+}
@@ -0,0 +1,472 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package utils has some utility functions and interfaces
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"net"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netlink/nl"
+	"golang.org/x/sys/unix"
+)
+
+// FaultRule injects a fault into the netlink or FRR calls it matches, for testing only
+type FaultRule struct {
+	// Operation is the method of the Netlink or Frr interface, e.g. LinkAdd or FrrBgpCmd,
+	// all of them when "*"
+	Operation string `json:"operation"`
+	// Match restricts the rule to the calls whose link name, route destination or FRR
+	// command contains it
+	Match string `json:"match,omitempty"`
+	// Error is returned instead of calling the operation: an errno name, e.g. EEXIST,
+	// TIMEOUT for an expired deadline, or any other message
+	Error string `json:"error,omitempty"`
+	// Output replaces the output of the FRR commands, which complete, e.g. "% Unknown command"
+	Output string `json:"output,omitempty"`
+	// Delay is waited before the call, e.g. 2s
+	Delay string `json:"delay,omitempty"`
+	// After lets the first matching calls through, e.g. to fail the third step of a create
+	After int `json:"after,omitempty"`
+	// Count is the number of faults injected, unlimited when 0
+	Count int `json:"count,omitempty"`
+	// Probability is the chance of a matching call to fail, always when 0
+	Probability float64 `json:"probability,omitempty"`
+}
+
+// Validate checks the rule has an operation, a fault and a valid delay and probability
+func (r FaultRule) Validate() error {
+	switch {
+	case r.Operation == "":
+		return fmt.Errorf("invalid fault, has no operation")
+	case r.Error == "" && r.Output == "" && r.Delay == "":
+		return fmt.Errorf("invalid fault of %s, has no error, output or delay", r.Operation)
+	case r.Error != "" && r.Output != "":
+		return fmt.Errorf("invalid fault of %s, either an error or an output", r.Operation)
+	case r.Output != "" && r.Operation != "*" && !strings.HasPrefix(r.Operation, "Frr"):
+		return fmt.Errorf("invalid fault of %s, only the FRR commands have an output", r.Operation)
+	case r.After < 0 || r.Count < 0:
+		return fmt.Errorf("invalid fault of %s, after and count cannot be negative", r.Operation)
+	case r.Probability < 0 || r.Probability > 1:
+		return fmt.Errorf("invalid fault probability %v of %s, expected 0-1", r.Probability, r.Operation)
+	}
+	if r.Delay != "" {
+		if delay, err := time.ParseDuration(r.Delay); err != nil || delay < 0 {
+			return fmt.Errorf("invalid fault delay %q of %s", r.Delay, r.Operation)
+		}
+	}
+	return nil
+}
+
+// err is the error the rule injects
+func (r FaultRule) err() error {
+	if r.Error == "" {
+		return nil
+	}
+	if r.Error == "TIMEOUT" {
+		return context.DeadlineExceeded
+	}
+	for errno := syscall.Errno(1); errno < 256; errno++ {
+		if unix.ErrnoName(errno) == r.Error {
+			return errno
+		}
+	}
+	return errors.New(r.Error)
+}
+
+// faultState counts the calls a rule matched and the faults it injected
+type faultState struct {
+	rule     FaultRule
+	delay    time.Duration
+	matched  int
+	injected int
+}
+
+// FaultInjector holds the rules the FaultyNetlink and FaultyFrr wrappers apply
+type FaultInjector struct {
+	mu     sync.Mutex
+	states []*faultState
+}
+
+// NewFaultInjector creates an injector without rules, the calls going through
+func NewFaultInjector() *FaultInjector {
+	return &FaultInjector{}
+}
+
+// SetRules replaces the rules, their counts starting over
+func (f *FaultInjector) SetRules(rules []FaultRule) error {
+	states := make([]*faultState, 0, len(rules))
+	for _, rule := range rules {
+		if err := rule.Validate(); err != nil {
+			return err
+		}
+		delay, _ := time.ParseDuration(rule.Delay)
+		states = append(states, &faultState{rule: rule, delay: delay})
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.states = states
+	return nil
+}
+
+// Rules returns the rules in effect
+func (f *FaultInjector) Rules() []FaultRule {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	rules := make([]FaultRule, 0, len(f.states))
+	for _, state := range f.states {
+		rules = append(rules, state.rule)
+	}
+	return rules
+}
+
+// fault returns the first rule firing for the call, nil when the call goes through
+func (f *FaultInjector) fault(operation string, subject string) *faultState {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, state := range f.states {
+		rule := state.rule
+		if (rule.Operation != "*" && rule.Operation != operation) || !strings.Contains(subject, rule.Match) {
+			continue
+		}
+		state.matched++
+		if state.matched <= rule.After || (rule.Count != 0 && state.injected >= rule.Count) {
+			continue
+		}
+		// #nosec G404 the faults do not need a secure random source
+		if rule.Probability != 0 && rand.Float64() >= rule.Probability {
+			continue
+		}
+		state.injected++
+		return state
+	}
+	return nil
+}
+
+// inject waits the delay of the rule firing for the call and returns its output and error,
+// fired is false when no rule fired or the rule only delays the call
+func (f *FaultInjector) inject(ctx context.Context, operation string, subject string) (output string, fired bool, err error) {
+	state := f.fault(operation, subject)
+	if state == nil {
+		return "", false, nil
+	}
+	log.Printf("Injecting fault into %s %s: %+v", operation, subject, state.rule)
+	if state.delay > 0 {
+		select {
+		case <-time.After(state.delay):
+		case <-ctx.Done():
+			return "", true, ctx.Err()
+		}
+	}
+	if state.rule.Error == "" && state.rule.Output == "" {
+		return "", false, nil
+	}
+	return state.rule.Output, true, state.rule.err()
+}
+
+func faultLinkName(link netlink.Link) string {
+	if link == nil {
+		return ""
+	}
+	return link.Attrs().Name
+}
+
+func faultRouteDst(route *netlink.Route) string {
+	if route == nil || route.Dst == nil {
+		return ""
+	}
+	return route.Dst.String()
+}
+
+// FaultyNetlink injects the faults of its FaultInjector into the calls of a Netlink, but the
+// subscriptions and the devlink lookups
+type FaultyNetlink struct {
+	Netlink
+	faults *FaultInjector
+}
+
+// NewFaultyNetlink wraps the netlink calls with the faults of the injector
+func NewFaultyNetlink(inner Netlink, faults *FaultInjector) *FaultyNetlink {
+	return &FaultyNetlink{Netlink: inner, faults: faults}
+}
+
+// build time check that struct implements interface
+var _ Netlink = (*FaultyNetlink)(nil)
+
+// call injects the fault of the operation, if any, or runs it
+func (n *FaultyNetlink) call(ctx context.Context, operation string, subject string, run func() error) error {
+	if _, fired, err := n.faults.inject(ctx, operation, subject); fired {
+		return err
+	}
+	return run()
+}
+
+// LinkByName injects the faults of LinkByName
+func (n *FaultyNetlink) LinkByName(ctx context.Context, name string) (link netlink.Link, err error) {
+	err = n.call(ctx, "LinkByName", name, func() (err error) {
+		link, err = n.Netlink.LinkByName(ctx, name)
+		return err
+	})
+	return link, err
+}
+
+// LinkModify injects the faults of LinkModify
+func (n *FaultyNetlink) LinkModify(ctx context.Context, link netlink.Link) error {
+	return n.call(ctx, "LinkModify", faultLinkName(link), func() error { return n.Netlink.LinkModify(ctx, link) })
+}
+
+// LinkSetHardwareAddr injects the faults of LinkSetHardwareAddr
+func (n *FaultyNetlink) LinkSetHardwareAddr(ctx context.Context, link netlink.Link, hwaddr net.HardwareAddr) error {
+	return n.call(ctx, "LinkSetHardwareAddr", faultLinkName(link), func() error { return n.Netlink.LinkSetHardwareAddr(ctx, link, hwaddr) })
+}
+
+// AddrAdd injects the faults of AddrAdd
+func (n *FaultyNetlink) AddrAdd(ctx context.Context, link netlink.Link, addr *netlink.Addr) error {
+	return n.call(ctx, "AddrAdd", faultLinkName(link), func() error { return n.Netlink.AddrAdd(ctx, link, addr) })
+}
+
+// AddrDel injects the faults of AddrDel
+func (n *FaultyNetlink) AddrDel(ctx context.Context, link netlink.Link, addr *netlink.Addr) error {
+	return n.call(ctx, "AddrDel", faultLinkName(link), func() error { return n.Netlink.AddrDel(ctx, link, addr) })
+}
+
+// LinkAdd injects the faults of LinkAdd
+func (n *FaultyNetlink) LinkAdd(ctx context.Context, link netlink.Link) error {
+	return n.call(ctx, "LinkAdd", faultLinkName(link), func() error { return n.Netlink.LinkAdd(ctx, link) })
+}
+
+// LinkDel injects the faults of LinkDel
+func (n *FaultyNetlink) LinkDel(ctx context.Context, link netlink.Link) error {
+	return n.call(ctx, "LinkDel", faultLinkName(link), func() error { return n.Netlink.LinkDel(ctx, link) })
+}
+
+// LinkSetUp injects the faults of LinkSetUp
+func (n *FaultyNetlink) LinkSetUp(ctx context.Context, link netlink.Link) error {
+	return n.call(ctx, "LinkSetUp", faultLinkName(link), func() error { return n.Netlink.LinkSetUp(ctx, link) })
+}
+
+// LinkSetDown injects the faults of LinkSetDown
+func (n *FaultyNetlink) LinkSetDown(ctx context.Context, link netlink.Link) error {
+	return n.call(ctx, "LinkSetDown", faultLinkName(link), func() error { return n.Netlink.LinkSetDown(ctx, link) })
+}
+
+// LinkSetMaster injects the faults of LinkSetMaster
+func (n *FaultyNetlink) LinkSetMaster(ctx context.Context, link netlink.Link, master netlink.Link) error {
+	return n.call(ctx, "LinkSetMaster", faultLinkName(link), func() error { return n.Netlink.LinkSetMaster(ctx, link, master) })
+}
+
+// LinkSetNoMaster injects the faults of LinkSetNoMaster
+func (n *FaultyNetlink) LinkSetNoMaster(ctx context.Context, link netlink.Link) error {
+	return n.call(ctx, "LinkSetNoMaster", faultLinkName(link), func() error { return n.Netlink.LinkSetNoMaster(ctx, link) })
+}
+
+// LinkSetLearning injects the faults of LinkSetLearning
+func (n *FaultyNetlink) LinkSetLearning(ctx context.Context, link netlink.Link, mode bool) error {
+	return n.call(ctx, "LinkSetLearning", faultLinkName(link), func() error { return n.Netlink.LinkSetLearning(ctx, link, mode) })
+}
+
+// BridgeVlanAdd injects the faults of BridgeVlanAdd
+func (n *FaultyNetlink) BridgeVlanAdd(ctx context.Context, link netlink.Link, vid uint16, pvid, untagged, self, master bool) error {
+	return n.call(ctx, "BridgeVlanAdd", faultLinkName(link), func() error {
+		return n.Netlink.BridgeVlanAdd(ctx, link, vid, pvid, untagged, self, master)
+	})
+}
+
+// BridgeVlanDel injects the faults of BridgeVlanDel
+func (n *FaultyNetlink) BridgeVlanDel(ctx context.Context, link netlink.Link, vid uint16, pvid, untagged, self, master bool) error {
+	return n.call(ctx, "BridgeVlanDel", faultLinkName(link), func() error {
+		return n.Netlink.BridgeVlanDel(ctx, link, vid, pvid, untagged, self, master)
+	})
+}
+
+// RouteAdd injects the faults of RouteAdd
+func (n *FaultyNetlink) RouteAdd(ctx context.Context, route *netlink.Route) error {
+	return n.call(ctx, "RouteAdd", faultRouteDst(route), func() error { return n.Netlink.RouteAdd(ctx, route) })
+}
+
+// RouteDel injects the faults of RouteDel
+func (n *FaultyNetlink) RouteDel(ctx context.Context, route *netlink.Route) error {
+	return n.call(ctx, "RouteDel", faultRouteDst(route), func() error { return n.Netlink.RouteDel(ctx, route) })
+}
+
+// RouteListFiltered injects the faults of RouteListFiltered
+func (n *FaultyNetlink) RouteListFiltered(ctx context.Context, family int, filter *netlink.Route, filterMask uint64) (routes []netlink.Route, err error) {
+	err = n.call(ctx, "RouteListFiltered", faultRouteDst(filter), func() (err error) {
+		routes, err = n.Netlink.RouteListFiltered(ctx, family, filter, filterMask)
+		return err
+	})
+	return routes, err
+}
+
+// RuleAdd injects the faults of RuleAdd
+func (n *FaultyNetlink) RuleAdd(ctx context.Context, rule *netlink.Rule) error {
+	return n.call(ctx, "RuleAdd", "", func() error { return n.Netlink.RuleAdd(ctx, rule) })
+}
+
+// RuleDel injects the faults of RuleDel
+func (n *FaultyNetlink) RuleDel(ctx context.Context, rule *netlink.Rule) error {
+	return n.call(ctx, "RuleDel", "", func() error { return n.Netlink.RuleDel(ctx, rule) })
+}
+
+// XfrmStateAdd injects the faults of XfrmStateAdd
+func (n *FaultyNetlink) XfrmStateAdd(ctx context.Context, state *netlink.XfrmState) error {
+	return n.call(ctx, "XfrmStateAdd", "", func() error { return n.Netlink.XfrmStateAdd(ctx, state) })
+}
+
+// XfrmStateDel injects the faults of XfrmStateDel
+func (n *FaultyNetlink) XfrmStateDel(ctx context.Context, state *netlink.XfrmState) error {
+	return n.call(ctx, "XfrmStateDel", "", func() error { return n.Netlink.XfrmStateDel(ctx, state) })
+}
+
+// XfrmPolicyAdd injects the faults of XfrmPolicyAdd
+func (n *FaultyNetlink) XfrmPolicyAdd(ctx context.Context, policy *netlink.XfrmPolicy) error {
+	return n.call(ctx, "XfrmPolicyAdd", "", func() error { return n.Netlink.XfrmPolicyAdd(ctx, policy) })
+}
+
+// XfrmPolicyDel injects the faults of XfrmPolicyDel
+func (n *FaultyNetlink) XfrmPolicyDel(ctx context.Context, policy *netlink.XfrmPolicy) error {
+	return n.call(ctx, "XfrmPolicyDel", "", func() error { return n.Netlink.XfrmPolicyDel(ctx, policy) })
+}
+
+// LinkList injects the faults of LinkList
+func (n *FaultyNetlink) LinkList(ctx context.Context) (links []netlink.Link, err error) {
+	err = n.call(ctx, "LinkList", "", func() (err error) {
+		links, err = n.Netlink.LinkList(ctx)
+		return err
+	})
+	return links, err
+}
+
+// AddrList injects the faults of AddrList
+func (n *FaultyNetlink) AddrList(ctx context.Context, link netlink.Link, family int) (addrs []netlink.Addr, err error) {
+	err = n.call(ctx, "AddrList", faultLinkName(link), func() (err error) {
+		addrs, err = n.Netlink.AddrList(ctx, link, family)
+		return err
+	})
+	return addrs, err
+}
+
+// BridgeVlanList injects the faults of BridgeVlanList
+func (n *FaultyNetlink) BridgeVlanList(ctx context.Context) (vlans map[int32][]*nl.BridgeVlanInfo, err error) {
+	err = n.call(ctx, "BridgeVlanList", "", func() (err error) {
+		vlans, err = n.Netlink.BridgeVlanList(ctx)
+		return err
+	})
+	return vlans, err
+}
+
+// NeighList injects the faults of NeighList
+func (n *FaultyNetlink) NeighList(ctx context.Context, linkIndex, family int) (neighs []netlink.Neigh, err error) {
+	err = n.call(ctx, "NeighList", "", func() (err error) {
+		neighs, err = n.Netlink.NeighList(ctx, linkIndex, family)
+		return err
+	})
+	return neighs, err
+}
+
+// NeighDel injects the faults of NeighDel
+func (n *FaultyNetlink) NeighDel(ctx context.Context, neigh *netlink.Neigh) error {
+	return n.call(ctx, "NeighDel", neigh.HardwareAddr.String(), func() error { return n.Netlink.NeighDel(ctx, neigh) })
+}
+
+// NeighAppend injects the faults of NeighAppend
+func (n *FaultyNetlink) NeighAppend(ctx context.Context, neigh *netlink.Neigh) error {
+	return n.call(ctx, "NeighAppend", neigh.HardwareAddr.String(), func() error { return n.Netlink.NeighAppend(ctx, neigh) })
+}
+
+// NeighSet injects the faults of NeighSet
+func (n *FaultyNetlink) NeighSet(ctx context.Context, neigh *netlink.Neigh) error {
+	return n.call(ctx, "NeighSet", neigh.HardwareAddr.String(), func() error { return n.Netlink.NeighSet(ctx, neigh) })
+}
+
+// NetnsAdd injects the faults of NetnsAdd
+func (n *FaultyNetlink) NetnsAdd(ctx context.Context, name string) error {
+	return n.call(ctx, "NetnsAdd", name, func() error { return n.Netlink.NetnsAdd(ctx, name) })
+}
+
+// NetnsDel injects the faults of NetnsDel
+func (n *FaultyNetlink) NetnsDel(ctx context.Context, name string) error {
+	return n.call(ctx, "NetnsDel", name, func() error { return n.Netlink.NetnsDel(ctx, name) })
+}
+
+// LinkSetNs injects the faults of LinkSetNs
+func (n *FaultyNetlink) LinkSetNs(ctx context.Context, link netlink.Link, name string) error {
+	return n.call(ctx, "LinkSetNs", faultLinkName(link), func() error { return n.Netlink.LinkSetNs(ctx, link, name) })
+}
+
+// Netns wraps the calls into the named network namespace with the same faults
+func (n *FaultyNetlink) Netns(ctx context.Context, name string) (Netlink, error) {
+	inner, err := n.Netlink.Netns(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return NewFaultyNetlink(inner, n.faults), nil
+}
+
+// FaultyFrr injects the faults of its FaultInjector into the commands of an Frr, the telnet
+// login steps being part of the commands
+type FaultyFrr struct {
+	Frr
+	faults *FaultInjector
+}
+
+// NewFaultyFrr wraps the FRR commands with the faults of the injector
+func NewFaultyFrr(inner Frr, faults *FaultInjector) *FaultyFrr {
+	return &FaultyFrr{Frr: inner, faults: faults}
+}
+
+// build time check that struct implements interface
+var _ Frr = (*FaultyFrr)(nil)
+
+// command injects the fault of the operation, if any, or runs the command
+func (n *FaultyFrr) command(ctx context.Context, operation string, command string, run func(context.Context, string) (string, error)) (string, error) {
+	if output, fired, err := n.faults.inject(ctx, operation, command); fired {
+		return output, err
+	}
+	return run(ctx, command)
+}
+
+// TelnetDialAndCommunicate injects the faults of TelnetDialAndCommunicate
+func (n *FaultyFrr) TelnetDialAndCommunicate(ctx context.Context, command string, port int) (string, error) {
+	return n.command(ctx, "TelnetDialAndCommunicate", command, func(ctx context.Context, command string) (string, error) {
+		return n.Frr.TelnetDialAndCommunicate(ctx, command, port)
+	})
+}
+
+// FrrZebraCmd injects the faults of FrrZebraCmd
+func (n *FaultyFrr) FrrZebraCmd(ctx context.Context, command string) (string, error) {
+	return n.command(ctx, "FrrZebraCmd", command, n.Frr.FrrZebraCmd)
+}
+
+// FrrBgpCmd injects the faults of FrrBgpCmd
+func (n *FaultyFrr) FrrBgpCmd(ctx context.Context, command string) (string, error) {
+	return n.command(ctx, "FrrBgpCmd", command, n.Frr.FrrBgpCmd)
+}
+
+// FrrPimCmd injects the faults of FrrPimCmd
+func (n *FaultyFrr) FrrPimCmd(ctx context.Context, command string) (string, error) {
+	return n.command(ctx, "FrrPimCmd", command, n.Frr.FrrPimCmd)
+}
+
+// FrrOspfCmd injects the faults of FrrOspfCmd
+func (n *FaultyFrr) FrrOspfCmd(ctx context.Context, command string) (string, error) {
+	return n.command(ctx, "FrrOspfCmd", command, n.Frr.FrrOspfCmd)
+}
+
+// DaemonPid injects the faults of DaemonPid, their output being ignored
+func (n *FaultyFrr) DaemonPid(ctx context.Context, daemon string) (int, error) {
+	if _, fired, err := n.faults.inject(ctx, "DaemonPid", daemon); fired {
+		return 0, err
+	}
+	return n.Frr.DaemonPid(ctx, daemon)
+}
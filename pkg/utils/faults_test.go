@@ -0,0 +1,87 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package utils has some utility functions and interfaces
+package utils
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"syscall"
+	"testing"
+
+	"github.com/vishvananda/netlink"
+)
+
+// fakeFrr answers every command with ok, other methods are not implemented
+type fakeFrr struct {
+	Frr
+	commands int
+}
+
+func (f *fakeFrr) FrrBgpCmd(_ context.Context, _ string) (string, error) {
+	f.commands++
+	return "ok", nil
+}
+
+// fakeNetlink adds every link, other methods are not implemented
+type fakeNetlink struct {
+	Netlink
+	added []string
+}
+
+func (n *fakeNetlink) LinkAdd(_ context.Context, link netlink.Link) error {
+	n.added = append(n.added, link.Attrs().Name)
+	return nil
+}
+
+func Test_FaultInjector(t *testing.T) {
+	faults := NewFaultInjector()
+	err := faults.SetRules([]FaultRule{
+		{Operation: "LinkAdd", Match: "vni", Error: "EEXIST", After: 1, Count: 1},
+		{Operation: "FrrBgpCmd", Match: "router bgp", Output: "% Unknown command: router bgp"},
+		{Operation: "*", Match: "timeout", Error: "TIMEOUT"},
+	})
+	if err != nil {
+		t.Fatal("error: expected", nil, "received", err)
+	}
+	nLink := &fakeNetlink{}
+	faulty := NewFaultyNetlink(nLink, faults)
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		err  error
+	}{
+		{name: "vni1", err: nil},
+		{name: "vni2", err: syscall.EEXIST},
+		{name: "vni3", err: nil},
+		{name: "br1", err: nil},
+		{name: "timeout", err: context.DeadlineExceeded},
+	}
+	for _, tt := range tests {
+		err := faulty.LinkAdd(ctx, &netlink.Dummy{LinkAttrs: netlink.LinkAttrs{Name: tt.name}})
+		if !errors.Is(err, tt.err) {
+			t.Error(tt.name, "error: expected", tt.err, "received", err)
+		}
+	}
+	expected := []string{"vni1", "vni3", "br1"}
+	if !reflect.DeepEqual(nLink.added, expected) {
+		t.Error("added: expected", expected, "received", nLink.added)
+	}
+
+	frr := &fakeFrr{}
+	faultyFrr := NewFaultyFrr(frr, faults)
+	output, err := faultyFrr.FrrBgpCmd(ctx, "configure terminal\nrouter bgp 65000\nexit")
+	if err != nil || output != "% Unknown command: router bgp" || frr.commands != 0 {
+		t.Error("faulty command: expected the injected output, received", output, err, frr.commands)
+	}
+	if output, err := faultyFrr.FrrBgpCmd(ctx, "show version"); err != nil || output != "ok" || frr.commands != 1 {
+		t.Error("command: expected ok, received", output, err, frr.commands)
+	}
+
+	if err := faults.SetRules([]FaultRule{{Operation: "LinkAdd", Probability: 2, Error: "EEXIST"}}); err == nil {
+		t.Error("invalid probability: expected an error")
+	}
+}
@@ -7,10 +7,15 @@ package utils
 import (
 	"bufio"
 	"context"
-	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/ziutek/telnet"
 
 	"go.opentelemetry.io/otel"
@@ -21,10 +26,29 @@ import (
 const (
 	network  = "tcp"
 	password = "opi"
-	address  = "localhost"
 	timeout  = 10 * time.Second
+	// frrRunDir is where the FRR daemons write their pid files
+	frrRunDir = "/var/run/frr"
 )
 
+// frrAddress is the host the FRR daemons are reached at, changed at runtime with SetFrrAddress
+var frrAddress atomic.Value
+
+func init() {
+	frrAddress.Store("localhost")
+}
+
+// SetFrrAddress changes the host the FRR daemons are reached at, the next commands of every
+// FrrWrapper connecting to it
+func SetFrrAddress(address string) {
+	frrAddress.Store(address)
+}
+
+// FrrAddress returns the host the FRR daemons are reached at
+func FrrAddress() string {
+	return frrAddress.Load().(string)
+}
+
 // Ports defined here https://docs.frrouting.org/en/latest/setup.html#servicess
 const (
 	zebrasrv = iota + 2600
@@ -46,11 +70,80 @@ const (
 	vrrpd
 )
 
+var frrCommands = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: "evpn",
+		Subsystem: "frr",
+		Name:      "commands_total",
+		Help:      "Number of FRR vty commands by daemon, command and result.",
+	},
+	[]string{"daemon", "command", "result"},
+)
+
+// MustRegisterFrrMetrics registers the FRR command collectors in the given registry
+func MustRegisterFrrMetrics(reg prometheus.Registerer) {
+	reg.MustRegister(frrCommands)
+}
+
+// frrDaemons names the daemons by their vty port
+var frrDaemons = map[int]string{
+	zebra: "zebra",
+	ospfd: "ospfd",
+	bgpd:  "bgpd",
+	pimd:  "pimd",
+}
+
+// vtyErrorClasses classify the errors FRR prints in the output of the commands, which
+// complete anyway, the last one catching the other errors
+var vtyErrorClasses = []struct {
+	prefix string
+	class  string
+}{
+	{"% Unknown command", "UNKNOWN_COMMAND"},
+	{"% Command incomplete", "INCOMPLETE_COMMAND"},
+	{"% Ambiguous command", "AMBIGUOUS_COMMAND"},
+	{"% ", "VTY_ERROR"},
+}
+
+// FrrErrorClass returns the ErrorClass of a failed command, e.g. ECONNREFUSED or TIMEOUT,
+// or the class of the first error FRR printed in the output of a completed one,
+// e.g. UNKNOWN_COMMAND
+func FrrErrorClass(output string, err error) string {
+	if err != nil {
+		return ErrorClass(err)
+	}
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		for _, c := range vtyErrorClasses {
+			if strings.HasPrefix(line, c.prefix) {
+				return c.class
+			}
+		}
+	}
+	return sloNoError
+}
+
+// recordFrrCommand counts the command by daemon, its first word and its error class
+func recordFrrCommand(port int, command string, output string, err error) {
+	daemon, ok := frrDaemons[port]
+	if !ok {
+		daemon = strconv.Itoa(port)
+	}
+	name := "none"
+	if fields := strings.Fields(command); len(fields) != 0 {
+		name = fields[0]
+	}
+	frrCommands.WithLabelValues(daemon, name, FrrErrorClass(output, err)).Inc()
+}
+
 // Frr represents limited subset of functions from Frr package
 type Frr interface {
 	TelnetDialAndCommunicate(ctx context.Context, command string, port int) (string, error)
 	FrrZebraCmd(ctx context.Context, command string) (string, error)
 	FrrBgpCmd(ctx context.Context, command string) (string, error)
+	FrrPimCmd(ctx context.Context, command string) (string, error)
+	FrrOspfCmd(ctx context.Context, command string) (string, error)
+	DaemonPid(ctx context.Context, daemon string) (int, error)
 	Password(conn *telnet.Conn, delim string) error
 	EnterPrivileged(conn *telnet.Conn) error
 	ExitPrivileged(conn *telnet.Conn) error
@@ -113,6 +206,31 @@ func (n *FrrWrapper) FrrBgpCmd(ctx context.Context, command string) (string, err
 	return n.TelnetDialAndCommunicate(ctx, command, bgpd)
 }
 
+// FrrPimCmd connects to Pim telnet with password and runs command
+func (n *FrrWrapper) FrrPimCmd(ctx context.Context, command string) (string, error) {
+	// ports defined here https://docs.frrouting.org/en/latest/setup.html#services
+	return n.TelnetDialAndCommunicate(ctx, command, pimd)
+}
+
+// FrrOspfCmd connects to Ospf telnet with password and runs command
+func (n *FrrWrapper) FrrOspfCmd(ctx context.Context, command string) (string, error) {
+	// ports defined here https://docs.frrouting.org/en/latest/setup.html#services
+	return n.TelnetDialAndCommunicate(ctx, command, ospfd)
+}
+
+// DaemonPid reads the pid file of an FRR daemon, e.g. bgpd, only found when FRR runs on
+// the same host
+func (n *FrrWrapper) DaemonPid(ctx context.Context, daemon string) (int, error) {
+	_, childSpan := n.tracer.Start(ctx, "frr.DaemonPid")
+	childSpan.SetAttributes(attribute.String("frr.daemon", daemon))
+	defer childSpan.End()
+	data, err := os.ReadFile(filepath.Join(frrRunDir, daemon+".pid"))
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(string(data)))
+}
+
 // MultiLineCmd breaks command by lines, sends each and waits for output and returns combined output
 func (n *FrrWrapper) MultiLineCmd(conn *telnet.Conn, command string) (string, error) {
 	// multi-line command
@@ -133,9 +251,11 @@ func (n *FrrWrapper) MultiLineCmd(conn *telnet.Conn, command string) (string, er
 }
 
 // TelnetDialAndCommunicate connects to telnet with password and runs command
-func (n *FrrWrapper) TelnetDialAndCommunicate(ctx context.Context, command string, port int) (string, error) {
+func (n *FrrWrapper) TelnetDialAndCommunicate(ctx context.Context, command string, port int) (output string, err error) {
 	_, childSpan := n.tracer.Start(ctx, "frr.Command")
 	defer childSpan.End()
+	defer func() { recordFrrCommand(port, command, output, err) }()
+	address := FrrAddress()
 
 	if childSpan.IsRecording() {
 		childSpan.SetAttributes(
@@ -146,19 +266,54 @@ func (n *FrrWrapper) TelnetDialAndCommunicate(ctx context.Context, command strin
 		)
 	}
 
+	// a command cut short by the cancellation or the deadline of the call fails with its code
+	defer func() {
+		if err != nil && ctx.Err() != nil {
+			err = checkContext(ctx)
+		}
+	}()
+	if err := checkContext(ctx); err != nil {
+		return "", err
+	}
+
 	// new connection every time
-	conn, err := telnet.DialTimeout(network, fmt.Sprintf("%s:%d", address, port), timeout)
+	dialer := net.Dialer{Timeout: timeout}
+	raw, err := dialer.DialContext(ctx, network, net.JoinHostPort(address, strconv.Itoa(port)))
 	if err != nil {
 		return "", err
 	}
+	conn, err := telnet.NewConn(raw)
+	if err != nil {
+		_ = raw.Close()
+		return "", err
+	}
 	defer func(t *telnet.Conn) { _ = t.Close() }(conn)
 
 	conn.SetUnixWriteMode(true)
 
-	err = conn.SetWriteDeadline(time.Now().Add(timeout))
+	// a vtysh command does not outlive the call, the pending read or write fails when it ends
+	writeDeadline := time.Now().Add(timeout)
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetReadDeadline(deadline); err != nil {
+			return "", err
+		}
+		if deadline.Before(writeDeadline) {
+			writeDeadline = deadline
+		}
+	}
+	err = conn.SetWriteDeadline(writeDeadline)
 	if err != nil {
 		return "", err
 	}
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = conn.SetDeadline(time.Now())
+		case <-done:
+		}
+	}()
 
 	err = n.Password(conn, ">")
 	if err != nil {
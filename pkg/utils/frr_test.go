@@ -0,0 +1,52 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package utils contails useful helper functions
+package utils
+
+import (
+	"net"
+	"os"
+	"syscall"
+	"testing"
+)
+
+func TestFrrErrorClass(t *testing.T) {
+	tests := map[string]struct {
+		output string
+		err    error
+		want   string
+	}{
+		"completed": {
+			output: "router bgp 65000\nexit\n",
+			want:   "OK",
+		},
+		"unknown command": {
+			output: "frr(config)# vni 10\n% Unknown command: vni 10\n",
+			want:   "UNKNOWN_COMMAND",
+		},
+		"incomplete command": {
+			output: "% Command incomplete: router bgp\n",
+			want:   "INCOMPLETE_COMMAND",
+		},
+		"other vty error": {
+			output: "% Specify remote-as or peer-group commands first\n",
+			want:   "VTY_ERROR",
+		},
+		"daemon down": {
+			err:  &net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)},
+			want: "ECONNREFUSED",
+		},
+		"timeout": {
+			err:  &net.OpError{Op: "read", Net: "tcp", Err: os.ErrDeadlineExceeded},
+			want: "TIMEOUT",
+		},
+	}
+	for testName, tt := range tests {
+		t.Run(testName, func(t *testing.T) {
+			if got := FrrErrorClass(tt.output, tt.err); got != tt.want {
+				t.Errorf("FrrErrorClass() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -9,13 +9,31 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"runtime/debug"
 
+	"github.com/google/uuid"
+	middleware "github.com/grpc-ecosystem/go-grpc-middleware/v2"
 	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/logging"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 )
 
-// InterceptorLogger creates logger for interceptors based on default Go logger
+// RequestIDMetadataKey is the grpc metadata key carrying the ID of a call, taken from the
+// request when the client sets it, generated otherwise, and echoed in the response header
+const RequestIDMetadataKey = "x-request-id"
+
+type requestIDKey struct{}
+
+// InterceptorLogger creates logger for interceptors based on default Go logger, the
+// entries below the level set with SetLogLevel are dropped
 func InterceptorLogger(l *log.Logger) logging.Logger {
 	return logging.LoggerFunc(func(_ context.Context, lvl logging.Level, msg string, fields ...any) {
+		if lvl < CurrentLogLevel() {
+			return
+		}
 		switch lvl {
 		case logging.LevelDebug:
 			msg = fmt.Sprintf("DEBUG :%v", msg)
@@ -31,3 +49,87 @@ func InterceptorLogger(l *log.Logger) logging.Logger {
 		l.Println(append([]any{"msg", msg}, fields...))
 	})
 }
+
+// StandbyInterceptor rejects programming calls while the instance is the HA standby,
+// only the active instance of a pair is allowed to change state
+func StandbyInterceptor(isStandby func() bool) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if isStandby() && isProgrammingMethod(info.FullMethod) {
+			return nil, status.Error(codes.Unavailable, "instance is in standby, retry on the active one")
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StandbyStreamInterceptor rejects programming streams while the instance is the HA standby
+func StandbyStreamInterceptor(isStandby func() bool) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if isStandby() && isProgrammingMethod(info.FullMethod) {
+			return status.Error(codes.Unavailable, "instance is in standby, retry on the active one")
+		}
+		return handler(srv, ss)
+	}
+}
+
+// RequestIDInterceptor gives every call a request ID, available to the next interceptors
+// and the handlers with RequestIDFromContext, and sends it back in the response header
+func RequestIDInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		id := ""
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if values := md.Get(RequestIDMetadataKey); len(values) != 0 {
+				id = values[0]
+			}
+		}
+		if id == "" {
+			id = uuid.New().String()
+		}
+		if err := grpc.SetHeader(ctx, metadata.Pairs(RequestIDMetadataKey, id)); err != nil {
+			log.Printf("Failed to send request ID %v of %v: %v", id, info.FullMethod, err)
+		}
+		return handler(context.WithValue(ctx, requestIDKey{}, id), req)
+	}
+}
+
+// RequestIDStreamInterceptor gives every stream a request ID as RequestIDInterceptor does
+func RequestIDStreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		id := ""
+		if md, ok := metadata.FromIncomingContext(ss.Context()); ok {
+			if values := md.Get(RequestIDMetadataKey); len(values) != 0 {
+				id = values[0]
+			}
+		}
+		if id == "" {
+			id = uuid.New().String()
+		}
+		if err := ss.SetHeader(metadata.Pairs(RequestIDMetadataKey, id)); err != nil {
+			log.Printf("Failed to send request ID %v of %v: %v", id, info.FullMethod, err)
+		}
+		wrapped := middleware.WrapServerStream(ss)
+		wrapped.WrappedContext = context.WithValue(ss.Context(), requestIDKey{}, id)
+		return handler(srv, wrapped)
+	}
+}
+
+// RequestIDFromContext returns the request ID of the call, empty outside of RequestIDInterceptor
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// RequestIDFields adds the request ID to the fields logged by the logging interceptor
+func RequestIDFields(ctx context.Context) logging.Fields {
+	if id := RequestIDFromContext(ctx); id != "" {
+		return logging.Fields{"request_id", id}
+	}
+	return nil
+}
+
+// RecoverPanic is the handler of the recovery interceptor: a panicking call fails with
+// Internal instead of killing the daemon, the stack being logged under its request ID
+func RecoverPanic(ctx context.Context, p interface{}) error {
+	id := RequestIDFromContext(ctx)
+	log.Printf("Recovered from panic in request %v: %v\n%s", id, p, debug.Stack())
+	return status.Errorf(codes.Internal, "internal error, see the logs of request %s", id)
+}
@@ -0,0 +1,136 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package utils contains utility functions
+package utils
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"strings"
+	"testing"
+
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/logging"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestStandbyInterceptor(t *testing.T) {
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "done", nil
+	}
+	tests := map[string]struct {
+		standby  bool
+		method   string
+		wantCode codes.Code
+	}{
+		"active accepts programming calls": {
+			standby:  false,
+			method:   "/opi_api.network.evpn_gw.v1alpha1.VrfService/CreateVrf",
+			wantCode: codes.OK,
+		},
+		"standby accepts read calls": {
+			standby:  true,
+			method:   "/opi_api.network.evpn_gw.v1alpha1.VrfService/GetVrf",
+			wantCode: codes.OK,
+		},
+		"standby rejects programming calls": {
+			standby:  true,
+			method:   "/opi_api.network.evpn_gw.v1alpha1.VrfService/DeleteVrf",
+			wantCode: codes.Unavailable,
+		},
+	}
+	for testName, tt := range tests {
+		t.Run(testName, func(t *testing.T) {
+			interceptor := StandbyInterceptor(func() bool { return tt.standby })
+			_, err := interceptor(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: tt.method}, handler)
+			if status.Code(err) != tt.wantCode {
+				t.Errorf("interceptor() error = %v, want %v", err, tt.wantCode)
+			}
+		})
+	}
+}
+
+// headerStream captures the header sent by the interceptors outside of a real server
+type headerStream struct {
+	grpc.ServerTransportStream
+	header metadata.MD
+}
+
+func (s *headerStream) SetHeader(md metadata.MD) error {
+	s.header = metadata.Join(s.header, md)
+	return nil
+}
+
+func TestRequestIDInterceptor(t *testing.T) {
+	tests := map[string]struct {
+		incoming string
+	}{
+		"echoes the client request ID": {
+			incoming: "req-1",
+		},
+		"generates a missing request ID": {
+			incoming: "",
+		},
+	}
+	for testName, tt := range tests {
+		t.Run(testName, func(t *testing.T) {
+			stream := &headerStream{}
+			ctx := grpc.NewContextWithServerTransportStream(context.Background(), stream)
+			if tt.incoming != "" {
+				ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(RequestIDMetadataKey, tt.incoming))
+			}
+			var seen string
+			handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+				seen = RequestIDFromContext(ctx)
+				return "done", nil
+			}
+			interceptor := RequestIDInterceptor()
+			if _, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/test/Method"}, handler); err != nil {
+				t.Fatal("error: expected", nil, "received", err)
+			}
+			if seen == "" || (tt.incoming != "" && seen != tt.incoming) {
+				t.Error("request ID: expected", tt.incoming, "received", seen)
+			}
+			if header := stream.header.Get(RequestIDMetadataKey); len(header) != 1 || header[0] != seen {
+				t.Error("response header: expected", seen, "received", header)
+			}
+		})
+	}
+}
+
+func TestRecoverPanic(t *testing.T) {
+	ctx := context.WithValue(context.Background(), requestIDKey{}, "req-1")
+	err := RecoverPanic(ctx, "nil map")
+	if status.Code(err) != codes.Internal {
+		t.Error("error: expected", codes.Internal, "received", err)
+	}
+	if !strings.Contains(err.Error(), "req-1") {
+		t.Error("error: expected the request ID, received", err)
+	}
+}
+
+func TestInterceptorLoggerLevel(t *testing.T) {
+	defer SetLogLevel(CurrentLogLevel())
+	var buf bytes.Buffer
+	logger := InterceptorLogger(log.New(&buf, "", 0))
+
+	SetLogLevel(logging.LevelWarn)
+	logger.Log(context.Background(), logging.LevelInfo, "started call")
+	logger.Log(context.Background(), logging.LevelError, "finished call")
+	if strings.Contains(buf.String(), "started call") || !strings.Contains(buf.String(), "ERROR :finished call") {
+		t.Error("logs: expected the error only, received", buf.String())
+	}
+
+	level, err := ParseLogLevel("debug")
+	if err != nil || LogLevelName(level) != "debug" {
+		t.Error("level: expected debug, received", level, err)
+	}
+	if _, err := ParseLogLevel("trace"); err == nil {
+		t.Error("error: expected an invalid level")
+	}
+}
@@ -0,0 +1,96 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package utils contails useful helper functions
+package utils
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// swanctl is the command line tool of strongSwan
+const swanctl = "swanctl"
+
+// swanctlConfDir is where swanctl --load-all reads the connections and secrets from
+const swanctlConfDir = "/etc/swanctl/conf.d"
+
+// Ipsec represents limited subset of functions from strongSwan
+type Ipsec interface {
+	IpsecLoad(ctx context.Context, name string, conf string) error
+	IpsecUnload(ctx context.Context, name string) error
+}
+
+// IpsecWrapper wrapper for strongSwan, through its swanctl command line tool
+type IpsecWrapper struct {
+	tracer  trace.Tracer
+	confDir string
+}
+
+// NewIpsecWrapper creates initialized instance of IpsecWrapper
+func NewIpsecWrapper() *IpsecWrapper {
+	// default tracer name is good for now
+	return &IpsecWrapper{tracer: otel.Tracer(""), confDir: swanctlConfDir}
+}
+
+// build time check that struct implements interface
+var _ Ipsec = (*IpsecWrapper)(nil)
+
+// IpsecLoad writes the swanctl configuration of a connection and its secrets, and loads it into
+// the charon daemon, replacing the previous configuration of the connection
+func (n *IpsecWrapper) IpsecLoad(ctx context.Context, name string, conf string) error {
+	_, childSpan := n.tracer.Start(ctx, "swanctl.Load")
+	childSpan.SetAttributes(attribute.String("ipsec.connection", name))
+	defer childSpan.End()
+
+	// the file carries the pre-shared key, only readable by root
+	if err := os.WriteFile(n.confFile(name), []byte(conf), 0o600); err != nil {
+		return err
+	}
+	return n.run(ctx, "--load-all", "--noprompt")
+}
+
+// IpsecUnload removes the swanctl configuration of a connection, unloads it from the charon
+// daemon and terminates its security associations
+func (n *IpsecWrapper) IpsecUnload(ctx context.Context, name string) error {
+	_, childSpan := n.tracer.Start(ctx, "swanctl.Unload")
+	childSpan.SetAttributes(attribute.String("ipsec.connection", name))
+	defer childSpan.End()
+
+	if err := os.Remove(n.confFile(name)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	// the connections missing from the files are unloaded
+	if err := n.run(ctx, "--load-all", "--noprompt"); err != nil {
+		return err
+	}
+	// fails when the peer was never reached, nothing is left to terminate then
+	if err := n.run(ctx, "--terminate", "--ike", name, "--force"); err != nil {
+		fmt.Printf("swanctl terminate %s: %v", name, err)
+	}
+	return nil
+}
+
+func (n *IpsecWrapper) confFile(name string) string {
+	return filepath.Join(n.confDir, name+".conf")
+}
+
+func (n *IpsecWrapper) run(ctx context.Context, args ...string) error {
+	// Example: swanctl --load-all --noprompt
+	cmd := exec.CommandContext(ctx, swanctl, args...)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		if len(out) > 0 {
+			return fmt.Errorf("%s: %s", err, strings.TrimSpace(string(out)))
+		}
+		return err
+	}
+	return nil
+}
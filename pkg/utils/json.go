@@ -0,0 +1,254 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package utils has some utility functions and interfaces
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// the messages not in the opi-api yet are go structs holding protobuf messages, which
+// encoding/json cannot decode back (e.g.: the oneof IPAddress.V4OrV6), these functions
+// encode the go structs with encoding/json and the protobuf messages with protojson
+
+var (
+	protoMessageType    = reflect.TypeOf((*proto.Message)(nil)).Elem()
+	jsonMarshalerType   = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+)
+
+// MarshalJSON encodes v as encoding/json does, the protobuf messages it holds as protojson does
+func MarshalJSON(v interface{}) ([]byte, error) {
+	var b bytes.Buffer
+	if err := marshalJSONValue(&b, reflect.ValueOf(v)); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// UnmarshalJSON decodes the encoding of MarshalJSON into v, a pointer
+func UnmarshalJSON(data []byte, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return fmt.Errorf("unable to unmarshal into %T, not a pointer", v)
+	}
+	return unmarshalJSONValue(data, rv.Elem())
+}
+
+func marshalJSONValue(b *bytes.Buffer, v reflect.Value) error {
+	if !v.IsValid() {
+		b.WriteString("null")
+		return nil
+	}
+	switch {
+	case v.Type().Implements(protoMessageType):
+		if v.IsNil() {
+			b.WriteString("null")
+			return nil
+		}
+		data, err := protojson.Marshal(v.Interface().(proto.Message))
+		if err != nil {
+			return err
+		}
+		// protojson randomly adds spaces, its output is not meant to be stable
+		return json.Compact(b, data)
+	case v.Type().Implements(jsonMarshalerType):
+		data, err := json.Marshal(v.Interface())
+		if err != nil {
+			return err
+		}
+		b.Write(data)
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			b.WriteString("null")
+			return nil
+		}
+		return marshalJSONValue(b, v.Elem())
+	case reflect.Struct:
+		b.WriteByte('{')
+		first := true
+		for i := 0; i < v.NumField(); i++ {
+			key, omitEmpty, ok := jsonField(v.Type().Field(i))
+			if !ok || (omitEmpty && v.Field(i).IsZero()) {
+				continue
+			}
+			if !first {
+				b.WriteByte(',')
+			}
+			first = false
+			data, _ := json.Marshal(key)
+			b.Write(data)
+			b.WriteByte(':')
+			if err := marshalJSONValue(b, v.Field(i)); err != nil {
+				return err
+			}
+		}
+		b.WriteByte('}')
+		return nil
+	case reflect.Slice:
+		if v.IsNil() {
+			b.WriteString("null")
+			return nil
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			break
+		}
+		b.WriteByte('[')
+		for i := 0; i < v.Len(); i++ {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			if err := marshalJSONValue(b, v.Index(i)); err != nil {
+				return err
+			}
+		}
+		b.WriteByte(']')
+		return nil
+	case reflect.Map:
+		if v.IsNil() {
+			b.WriteString("null")
+			return nil
+		}
+		// encoding/json sorts the keys
+		values := make(map[string]json.RawMessage, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			var value bytes.Buffer
+			if err := marshalJSONValue(&value, iter.Value()); err != nil {
+				return err
+			}
+			values[fmt.Sprint(iter.Key().Interface())] = value.Bytes()
+		}
+		data, err := json.Marshal(values)
+		if err != nil {
+			return err
+		}
+		b.Write(data)
+		return nil
+	}
+	data, err := json.Marshal(v.Interface())
+	if err != nil {
+		return err
+	}
+	b.Write(data)
+	return nil
+}
+
+func unmarshalJSONValue(data []byte, v reflect.Value) error {
+	if v.Kind() == reflect.Pointer {
+		if string(bytes.TrimSpace(data)) == "null" {
+			v.Set(reflect.Zero(v.Type()))
+			return nil
+		}
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		if v.Type().Implements(protoMessageType) {
+			return protojson.Unmarshal(data, v.Interface().(proto.Message))
+		}
+		return unmarshalJSONValue(data, v.Elem())
+	}
+	if reflect.PtrTo(v.Type()).Implements(jsonUnmarshalerType) {
+		return json.Unmarshal(data, v.Addr().Interface())
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(data, &fields); err != nil {
+			return err
+		}
+		for i := 0; i < v.NumField(); i++ {
+			key, _, ok := jsonField(v.Type().Field(i))
+			if !ok {
+				continue
+			}
+			value, found := fields[key]
+			if !found {
+				// encoding/json matches the keys case-insensitively
+				for k, raw := range fields {
+					if strings.EqualFold(k, key) {
+						value, found = raw, true
+						break
+					}
+				}
+			}
+			if !found {
+				continue
+			}
+			if err := unmarshalJSONValue(value, v.Field(i)); err != nil {
+				return fmt.Errorf("%s: %w", key, err)
+			}
+		}
+		return nil
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			break
+		}
+		var items []json.RawMessage
+		if err := json.Unmarshal(data, &items); err != nil {
+			return err
+		}
+		if items == nil {
+			v.Set(reflect.Zero(v.Type()))
+			return nil
+		}
+		slice := reflect.MakeSlice(v.Type(), len(items), len(items))
+		for i, item := range items {
+			if err := unmarshalJSONValue(item, slice.Index(i)); err != nil {
+				return fmt.Errorf("[%d]: %w", i, err)
+			}
+		}
+		v.Set(slice)
+		return nil
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			break
+		}
+		var items map[string]json.RawMessage
+		if err := json.Unmarshal(data, &items); err != nil {
+			return err
+		}
+		if items == nil {
+			v.Set(reflect.Zero(v.Type()))
+			return nil
+		}
+		m := reflect.MakeMapWithSize(v.Type(), len(items))
+		for key, item := range items {
+			value := reflect.New(v.Type().Elem()).Elem()
+			if err := unmarshalJSONValue(item, value); err != nil {
+				return fmt.Errorf("%s: %w", key, err)
+			}
+			m.SetMapIndex(reflect.ValueOf(key).Convert(v.Type().Key()), value)
+		}
+		v.Set(m)
+		return nil
+	}
+	return json.Unmarshal(data, v.Addr().Interface())
+}
+
+// jsonField returns the key of the field as encoding/json does, skipping the unexported fields
+func jsonField(field reflect.StructField) (key string, omitEmpty bool, ok bool) {
+	if !field.IsExported() {
+		return "", false, false
+	}
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false, false
+	}
+	name, options, _ := strings.Cut(tag, ",")
+	if name == "" {
+		name = field.Name
+	}
+	return name, strings.Contains(options, "omitempty"), true
+}
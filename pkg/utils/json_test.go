@@ -0,0 +1,67 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package utils has some utility functions and interfaces
+package utils
+
+import (
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+
+	pc "github.com/opiproject/opi-api/network/opinetcommon/v1alpha1/gen/go"
+)
+
+type jsonTestSpec struct {
+	Prefix  *pc.IPPrefix
+	NextHop *pc.IPAddress
+	Hops    []*pc.IPAddress
+	Labels  map[string]string
+	Metric  uint32 `json:"metric,omitempty"`
+	secret  string
+}
+
+func TestJSON(t *testing.T) {
+	addr := &pc.IPAddress{Af: pc.IpAf_IP_AF_INET, V4OrV6: &pc.IPAddress_V4Addr{V4Addr: 0x0a000001}}
+	in := &jsonTestSpec{
+		Prefix:  &pc.IPPrefix{Addr: addr, Len: 24},
+		NextHop: addr,
+		Hops:    []*pc.IPAddress{addr, addr},
+		Labels:  map[string]string{"app": "web"},
+		secret:  "hidden",
+	}
+	data, err := MarshalJSON(in)
+	if err != nil {
+		t.Fatal("marshal: expected", nil, "received", err)
+	}
+	expected := `{"Prefix":{"addr":{"af":"IP_AF_INET","v4Addr":167772161},"len":24},` +
+		`"NextHop":{"af":"IP_AF_INET","v4Addr":167772161},` +
+		`"Hops":[{"af":"IP_AF_INET","v4Addr":167772161},{"af":"IP_AF_INET","v4Addr":167772161}],` +
+		`"Labels":{"app":"web"}}`
+	if string(data) != expected {
+		t.Error("marshal: expected", expected, "received", string(data))
+	}
+
+	out := &jsonTestSpec{}
+	// the keys match case-insensitively, as with encoding/json
+	if err := UnmarshalJSON([]byte(`{"prefix":{"addr":{"af":"IP_AF_INET","v4Addr":167772161},"len":24},"metric":5}`), out); err != nil {
+		t.Fatal("unmarshal: expected", nil, "received", err)
+	}
+	if !proto.Equal(out.Prefix, in.Prefix) || out.Metric != 5 || out.NextHop != nil {
+		t.Error("unmarshal: expected", in.Prefix, "received", out)
+	}
+	out = &jsonTestSpec{}
+	if err := UnmarshalJSON(data, out); err != nil {
+		t.Fatal("round trip: expected", nil, "received", err)
+	}
+	if !proto.Equal(out.NextHop, addr) || len(out.Hops) != 2 || !proto.Equal(out.Hops[1], addr) || out.Labels["app"] != "web" {
+		t.Error("round trip: expected", in, "received", out)
+	}
+
+	if err := UnmarshalJSON([]byte(`{"Prefix":{"addr":"10.0.0.1"}}`), out); err == nil {
+		t.Error("invalid prefix: expected an error, received", nil)
+	}
+	if err := UnmarshalJSON([]byte(`{}`), *out); err == nil {
+		t.Error("not a pointer: expected an error, received", nil)
+	}
+}
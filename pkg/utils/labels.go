@@ -0,0 +1,145 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package utils has some utility functions and interfaces
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// labelNameRegexp is the name part of a label key, and the format of a label value,
+// as in Kubernetes (see https://kubernetes.io/docs/concepts/overview/working-with-objects/labels/#syntax-and-character-set)
+var labelNameRegexp = regexp.MustCompile(`^[A-Za-z0-9]([-A-Za-z0-9_.]{0,61}[A-Za-z0-9])?$`)
+
+// labelPrefixRegexp is the optional DNS subdomain prefix of a label key, e.g.: example.com/
+var labelPrefixRegexp = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`)
+
+func validateLabelKey(key string) error {
+	name := key
+	if prefix, rest, ok := strings.Cut(key, "/"); ok {
+		if len(prefix) > 253 || !labelPrefixRegexp.MatchString(prefix) {
+			return fmt.Errorf("invalid label key %q, the prefix has to be a DNS subdomain", key)
+		}
+		name = rest
+	}
+	if !labelNameRegexp.MatchString(name) {
+		return fmt.Errorf("invalid label key %q, expected up to 63 alphanumeric characters, '-', '_' or '.'", key)
+	}
+	return nil
+}
+
+func validateLabelValue(key string, value string) error {
+	if value != "" && !labelNameRegexp.MatchString(value) {
+		return fmt.Errorf("invalid value %q of label %s, expected up to 63 alphanumeric characters, '-', '_' or '.'", value, key)
+	}
+	return nil
+}
+
+// ParseLabels parses labels in key=value,key=value format (e.g.: app=web,tier=frontend),
+// the values of annotations are not restricted to the label character set
+func ParseLabels(value string, annotations bool) (map[string]string, error) {
+	labels := make(map[string]string)
+	if value == "" {
+		return labels, nil
+	}
+	for _, item := range strings.Split(value, ",") {
+		key, val, ok := strings.Cut(item, "=")
+		key = strings.TrimSpace(key)
+		if !ok {
+			return nil, fmt.Errorf("invalid label %q, expected key=value", item)
+		}
+		if err := validateLabelKey(key); err != nil {
+			return nil, err
+		}
+		if !annotations {
+			val = strings.TrimSpace(val)
+			if err := validateLabelValue(key, val); err != nil {
+				return nil, err
+			}
+		}
+		if _, ok := labels[key]; ok {
+			return nil, fmt.Errorf("duplicate label %s", key)
+		}
+		labels[key] = val
+	}
+	return labels, nil
+}
+
+// labelRequirement is one comma separated term of a LabelSelector
+type labelRequirement struct {
+	key      string
+	value    string
+	operator string
+}
+
+// LabelSelector is an equality-based selector as in Kubernetes: key=value, key==value,
+// key!=value, key (the label is set) and !key (the label is not set), all of them matching
+type LabelSelector []labelRequirement
+
+// ParseLabelSelector parses a comma separated list of requirements, e.g.: app=web,tier!=db,!canary
+func ParseLabelSelector(value string) (LabelSelector, error) {
+	selector := LabelSelector{}
+	if strings.TrimSpace(value) == "" {
+		return selector, nil
+	}
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		var r labelRequirement
+		switch {
+		case strings.Contains(item, "!="):
+			r.key, r.value, _ = strings.Cut(item, "!=")
+			r.operator = "!="
+		case strings.Contains(item, "=="):
+			r.key, r.value, _ = strings.Cut(item, "==")
+			r.operator = "="
+		case strings.Contains(item, "="):
+			r.key, r.value, _ = strings.Cut(item, "=")
+			r.operator = "="
+		case strings.HasPrefix(item, "!"):
+			r.key = strings.TrimPrefix(item, "!")
+			r.operator = "!"
+		default:
+			r.key = item
+			r.operator = "exists"
+		}
+		r.key = strings.TrimSpace(r.key)
+		r.value = strings.TrimSpace(r.value)
+		if err := validateLabelKey(r.key); err != nil {
+			return nil, fmt.Errorf("invalid label selector %q: %w", item, err)
+		}
+		if err := validateLabelValue(r.key, r.value); err != nil {
+			return nil, fmt.Errorf("invalid label selector %q: %w", item, err)
+		}
+		selector = append(selector, r)
+	}
+	return selector, nil
+}
+
+// Matches reports whether the labels meet all the requirements, an empty selector matches everything
+func (l LabelSelector) Matches(labels map[string]string) bool {
+	for _, r := range l {
+		value, ok := labels[r.key]
+		switch r.operator {
+		case "=":
+			if !ok || value != r.value {
+				return false
+			}
+		case "!=":
+			if ok && value == r.value {
+				return false
+			}
+		case "!":
+			if ok {
+				return false
+			}
+		case "exists":
+			if !ok {
+				return false
+			}
+		}
+	}
+	return true
+}
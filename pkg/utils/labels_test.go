@@ -0,0 +1,92 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package utils has some utility functions and interfaces
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseLabels(t *testing.T) {
+	tests := map[string]struct {
+		in          string
+		annotations bool
+		out         map[string]string
+		wantErr     bool
+	}{
+		"empty": {
+			in:  "",
+			out: map[string]string{},
+		},
+		"labels": {
+			in:  "app=web, example.com/tier=frontend,canary=",
+			out: map[string]string{"app": "web", "example.com/tier": "frontend", "canary": ""},
+		},
+		"invalid value": {
+			in:      "app=web server",
+			wantErr: true,
+		},
+		"free-form annotation": {
+			in:          "owner=Jane Doe <jane@example.com>",
+			annotations: true,
+			out:         map[string]string{"owner": "Jane Doe <jane@example.com>"},
+		},
+		"invalid key": {
+			in:      "-app=web",
+			wantErr: true,
+		},
+		"missing value": {
+			in:      "app",
+			wantErr: true,
+		},
+		"duplicate key": {
+			in:      "app=web,app=db",
+			wantErr: true,
+		},
+	}
+	for testName, tt := range tests {
+		t.Run(testName, func(t *testing.T) {
+			labels, err := ParseLabels(tt.in, tt.annotations)
+			if (err != nil) != tt.wantErr {
+				t.Fatal("error: expected", tt.wantErr, "received", err)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(labels, tt.out) {
+				t.Error("labels: expected", tt.out, "received", labels)
+			}
+		})
+	}
+}
+
+func TestLabelSelector_Matches(t *testing.T) {
+	labels := map[string]string{"app": "web", "tier": "frontend"}
+	tests := map[string]struct {
+		selector string
+		matches  bool
+	}{
+		"empty":          {selector: "", matches: true},
+		"equal":          {selector: "app=web", matches: true},
+		"double equal":   {selector: "app==web", matches: true},
+		"not equal":      {selector: "app!=web", matches: false},
+		"missing equal":  {selector: "env=prod", matches: false},
+		"missing unset":  {selector: "env!=prod", matches: true},
+		"exists":         {selector: "tier", matches: true},
+		"does not exist": {selector: "!tier", matches: false},
+		"all terms":      {selector: "app=web,!canary,tier=backend", matches: false},
+	}
+	for testName, tt := range tests {
+		t.Run(testName, func(t *testing.T) {
+			selector, err := ParseLabelSelector(tt.selector)
+			if err != nil {
+				t.Fatal("error: expected", nil, "received", err)
+			}
+			if matches := selector.Matches(labels); matches != tt.matches {
+				t.Error("matches: expected", tt.matches, "received", matches)
+			}
+		})
+	}
+	if _, err := ParseLabelSelector("app=web server"); err == nil {
+		t.Error("error: expected invalid selector, received", nil)
+	}
+}
@@ -0,0 +1,72 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package utils has some utility functions and interfaces
+package utils
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-redis/redis"
+)
+
+// Lease represents a leadership lease shared by the instances of an HA pair
+type Lease interface {
+	// Acquire takes the lease if free or renews it if already held, reporting whether it is held
+	Acquire(context.Context) (bool, error)
+	// Release gives the lease up if held
+	Release(context.Context) error
+}
+
+const (
+	// take the lease if free, renew it if ours
+	leaseAcquireScript = `
+local holder = redis.call('GET', KEYS[1])
+if holder == ARGV[1] then
+	redis.call('PEXPIRE', KEYS[1], ARGV[2])
+	return 1
+end
+if not holder then
+	redis.call('SET', KEYS[1], ARGV[1], 'PX', ARGV[2])
+	return 1
+end
+return 0`
+	// delete the lease only if ours
+	leaseReleaseScript = `
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0`
+)
+
+// RedisLease is a Lease kept as an expiring key in the shared Redis store
+type RedisLease struct {
+	client *redis.Client
+	key    string
+	id     string
+	ttl    time.Duration
+}
+
+// NewRedisLease creates initialized instance of RedisLease, id identifies this instance
+func NewRedisLease(address string, key string, id string, ttl time.Duration) *RedisLease {
+	client := redis.NewClient(&redis.Options{Addr: address})
+	return &RedisLease{client: client, key: key, id: id, ttl: ttl}
+}
+
+// build time check that struct implements interface
+var _ Lease = (*RedisLease)(nil)
+
+// Acquire takes or renews the lease
+func (l *RedisLease) Acquire(ctx context.Context) (bool, error) {
+	held, err := l.client.WithContext(ctx).Eval(leaseAcquireScript, []string{l.key}, l.id, l.ttl.Milliseconds()).Int64()
+	if err != nil {
+		return false, err
+	}
+	return held == 1, nil
+}
+
+// Release gives up the lease
+func (l *RedisLease) Release(ctx context.Context) error {
+	return l.client.WithContext(ctx).Eval(leaseReleaseScript, []string{l.key}, l.id).Err()
+}
@@ -0,0 +1,160 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package utils has some utility functions and interfaces
+package utils
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	programmingQueued = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "evpn",
+			Subsystem: "programming",
+			Name:      "queued",
+			Help:      "Number of programming operations waiting for a concurrency slot by object type.",
+		},
+		[]string{"object"},
+	)
+	programmingInFlight = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "evpn",
+			Subsystem: "programming",
+			Name:      "in_flight",
+			Help:      "Number of programming operations currently running by object type.",
+		},
+		[]string{"object"},
+	)
+	programmingWait = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "evpn",
+			Subsystem: "programming",
+			Name:      "wait_seconds",
+			Help:      "Time programming operations spent queued for a concurrency slot by object type.",
+			Buckets:   prometheus.ExponentialBuckets(0.001, 4, 8),
+		},
+		[]string{"object"},
+	)
+)
+
+// MustRegisterLimiterMetrics registers the concurrency limiter collectors in the given registry
+func MustRegisterLimiterMetrics(reg prometheus.Registerer) {
+	reg.MustRegister(programmingQueued, programmingInFlight, programmingWait)
+}
+
+// ParseConcurrencyLimits parses limits in ObjectType=N,ObjectType=N format
+// (e.g.: LogicalBridge=2,Vrf=4)
+func ParseConcurrencyLimits(value string) (map[string]int, error) {
+	limits := make(map[string]int)
+	if value == "" {
+		return limits, nil
+	}
+	for _, item := range strings.Split(value, ",") {
+		object, count, ok := strings.Cut(item, "=")
+		if !ok || object == "" {
+			return nil, fmt.Errorf("invalid concurrency limit %q, expected ObjectType=N", item)
+		}
+		n, err := strconv.Atoi(count)
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("invalid concurrency limit %q, N has to be a positive number", item)
+		}
+		limits[object] = n
+	}
+	return limits, nil
+}
+
+// ConcurrencyLimiter bounds the number of concurrent programming operations
+// (Create, Update and Delete calls) per object type, queuing the extra ones,
+// to protect slow hardware offload paths from bursts
+type ConcurrencyLimiter struct {
+	slots map[string]chan struct{}
+}
+
+// NewConcurrencyLimiter creates initialized instance of ConcurrencyLimiter,
+// object types without a limit are not restricted
+func NewConcurrencyLimiter(limits map[string]int) *ConcurrencyLimiter {
+	l := &ConcurrencyLimiter{slots: make(map[string]chan struct{})}
+	for object, n := range limits {
+		l.slots[object] = make(chan struct{}, n)
+	}
+	return l
+}
+
+// programmingVerbs start the names of the methods changing objects, the batches, flushes,
+// imports, applies and resyncs being calls of the HTTP gateway
+var programmingVerbs = []string{"Create", "Update", "Delete", "Batch", "Flush", "Import", "Apply", "Resync"}
+
+func isProgrammingMethod(method string) bool {
+	name := path.Base(method)
+	for _, verb := range programmingVerbs {
+		if strings.HasPrefix(name, verb) {
+			return true
+		}
+	}
+	return false
+}
+
+// Acquire waits for a free slot for the object type, the returned func releases it
+func (l *ConcurrencyLimiter) Acquire(ctx context.Context, object string) (func(), error) {
+	slots, ok := l.slots[object]
+	if !ok {
+		return func() {}, nil
+	}
+	start := time.Now()
+	programmingQueued.WithLabelValues(object).Inc()
+	select {
+	case slots <- struct{}{}:
+		programmingQueued.WithLabelValues(object).Dec()
+	case <-ctx.Done():
+		programmingQueued.WithLabelValues(object).Dec()
+		return nil, status.FromContextError(ctx.Err()).Err()
+	}
+	programmingWait.WithLabelValues(object).Observe(time.Since(start).Seconds())
+	programmingInFlight.WithLabelValues(object).Inc()
+	return func() {
+		programmingInFlight.WithLabelValues(object).Dec()
+		<-slots
+	}, nil
+}
+
+// UnaryServerInterceptor limits concurrent programming calls per object type
+func (l *ConcurrencyLimiter) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if !isProgrammingMethod(info.FullMethod) {
+			return handler(ctx, req)
+		}
+		release, err := l.Acquire(ctx, objectTypeFromMethod(info.FullMethod))
+		if err != nil {
+			return nil, err
+		}
+		defer release()
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor limits concurrent programming streams per object type, for the
+// whole life of the stream
+func (l *ConcurrencyLimiter) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if !isProgrammingMethod(info.FullMethod) {
+			return handler(srv, ss)
+		}
+		release, err := l.Acquire(ss.Context(), objectTypeFromMethod(info.FullMethod))
+		if err != nil {
+			return err
+		}
+		defer release()
+		return handler(srv, ss)
+	}
+}
@@ -0,0 +1,170 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package utils has some utility functions and interfaces
+package utils
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	middleware "github.com/grpc-ecosystem/go-grpc-middleware/v2"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestParseConcurrencyLimits(t *testing.T) {
+	tests := map[string]struct {
+		value   string
+		want    map[string]int
+		wantErr bool
+	}{
+		"empty": {
+			value:   "",
+			want:    map[string]int{},
+			wantErr: false,
+		},
+		"valid": {
+			value:   "LogicalBridge=2,Vrf=4",
+			want:    map[string]int{"LogicalBridge": 2, "Vrf": 4},
+			wantErr: false,
+		},
+		"missing count": {
+			value:   "LogicalBridge",
+			want:    nil,
+			wantErr: true,
+		},
+		"zero count": {
+			value:   "Vrf=0",
+			want:    nil,
+			wantErr: true,
+		},
+	}
+	for testName, tt := range tests {
+		t.Run(testName, func(t *testing.T) {
+			got, err := ParseConcurrencyLimits(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseConcurrencyLimits() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseConcurrencyLimits() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConcurrencyLimiter_Acquire(t *testing.T) {
+	limiter := NewConcurrencyLimiter(map[string]int{"LogicalBridge": 1})
+
+	// unlimited object types never block
+	release, err := limiter.Acquire(context.Background(), "Vrf")
+	if err != nil {
+		t.Fatalf("Acquire() unexpected error = %v", err)
+	}
+	release()
+
+	release, err = limiter.Acquire(context.Background(), "LogicalBridge")
+	if err != nil {
+		t.Fatalf("Acquire() unexpected error = %v", err)
+	}
+
+	// second caller is queued until its deadline
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := limiter.Acquire(ctx, "LogicalBridge"); status.Code(err) != codes.DeadlineExceeded {
+		t.Errorf("Acquire() error = %v, want %v", err, codes.DeadlineExceeded)
+	}
+
+	// and gets the slot once released
+	release()
+	release, err = limiter.Acquire(context.Background(), "LogicalBridge")
+	if err != nil {
+		t.Fatalf("Acquire() unexpected error = %v", err)
+	}
+	release()
+}
+
+func TestConcurrencyLimiter_UnaryServerInterceptor(t *testing.T) {
+	limiter := NewConcurrencyLimiter(map[string]int{"LogicalBridge": 1})
+	interceptor := limiter.UnaryServerInterceptor()
+	release, err := limiter.Acquire(context.Background(), "LogicalBridge")
+	if err != nil {
+		t.Fatalf("Acquire() unexpected error = %v", err)
+	}
+	defer release()
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "done", nil
+	}
+
+	tests := map[string]struct {
+		method   string
+		wantCode codes.Code
+	}{
+		"read calls are not limited": {
+			method:   "/opi_api.network.evpn_gw.v1alpha1.LogicalBridgeService/GetLogicalBridge",
+			wantCode: codes.OK,
+		},
+		"other object types are not limited": {
+			method:   "/opi_api.network.evpn_gw.v1alpha1.VrfService/CreateVrf",
+			wantCode: codes.OK,
+		},
+		"programming calls wait for a slot": {
+			method:   "/opi_api.network.evpn_gw.v1alpha1.LogicalBridgeService/CreateLogicalBridge",
+			wantCode: codes.DeadlineExceeded,
+		},
+	}
+	for testName, tt := range tests {
+		t.Run(testName, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			defer cancel()
+			_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: tt.method}, handler)
+			if status.Code(err) != tt.wantCode {
+				t.Errorf("interceptor() error = %v, want %v", err, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestConcurrencyLimiter_StreamServerInterceptor(t *testing.T) {
+	limiter := NewConcurrencyLimiter(map[string]int{"LogicalBridge": 1, "ListStream": 1})
+	interceptor := limiter.StreamServerInterceptor()
+	for _, object := range []string{"LogicalBridge", "ListStream"} {
+		release, err := limiter.Acquire(context.Background(), object)
+		if err != nil {
+			t.Fatalf("Acquire() unexpected error = %v", err)
+		}
+		defer release()
+	}
+	handler := func(srv interface{}, stream grpc.ServerStream) error {
+		return nil
+	}
+
+	tests := map[string]struct {
+		method   string
+		wantCode codes.Code
+	}{
+		"streaming lists are not limited": {
+			method:   "/opi_evpn_bridge.v1alpha1.ListStreamService/ListLogicalBridgesStream",
+			wantCode: codes.OK,
+		},
+		"programming streams wait for a slot": {
+			method:   "/opi_api.network.evpn_gw.v1alpha1.LogicalBridgeService/CreateLogicalBridge",
+			wantCode: codes.DeadlineExceeded,
+		},
+	}
+	for testName, tt := range tests {
+		t.Run(testName, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			defer cancel()
+			stream := &middleware.WrappedServerStream{WrappedContext: ctx}
+			err := interceptor(nil, stream, &grpc.StreamServerInfo{FullMethod: tt.method}, handler)
+			if status.Code(err) != tt.wantCode {
+				t.Errorf("interceptor() error = %v, want %v", err, tt.wantCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package utils contails useful helper functions
+package utils
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// lldpcli is the client of the lldpd daemon running next to the bridge
+const lldpcli = "lldpcli"
+
+// Lldp represents limited subset of functions from lldpd
+type Lldp interface {
+	LldpNeighbors(ctx context.Context) (string, error)
+}
+
+// LldpWrapper wrapper for lldpd, through its lldpcli client
+type LldpWrapper struct {
+	tracer trace.Tracer
+}
+
+// NewLldpWrapper creates initialized instance of LldpWrapper
+func NewLldpWrapper() *LldpWrapper {
+	// default tracer name is good for now
+	return &LldpWrapper{tracer: otel.Tracer("")}
+}
+
+// build time check that struct implements interface
+var _ Lldp = (*LldpWrapper)(nil)
+
+// LldpNeighbors returns the neighbors lldpd discovered on all the interfaces, in the json0
+// format of lldpcli, every value being wrapped in an array whatever the number of values
+func (n *LldpWrapper) LldpNeighbors(ctx context.Context) (string, error) {
+	args := []string{"-f", "json0", "show", "neighbors", "details"}
+	_, childSpan := n.tracer.Start(ctx, "lldp.Command")
+	defer childSpan.End()
+
+	if childSpan.IsRecording() {
+		childSpan.SetAttributes(
+			attribute.String("lldp.name", strings.Join(args, " ")),
+		)
+	}
+
+	data, err := exec.CommandContext(ctx, lldpcli, args...).Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok && len(exitErr.Stderr) > 0 {
+			return "", fmt.Errorf("%s: %s", err, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", err
+	}
+	return string(data), nil
+}
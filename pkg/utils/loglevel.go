@@ -0,0 +1,57 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package utils contails useful helper functions
+package utils
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/logging"
+)
+
+// logLevel is the minimum level of the call logs of InterceptorLogger, every call being logged
+// by default, changed at runtime with SetLogLevel
+var logLevel atomic.Int64
+
+func init() {
+	logLevel.Store(int64(logging.LevelDebug))
+}
+
+// logLevelNames are the names of the levels, as given on the command line and the admin calls
+var logLevelNames = map[string]logging.Level{
+	"debug": logging.LevelDebug,
+	"info":  logging.LevelInfo,
+	"warn":  logging.LevelWarn,
+	"error": logging.LevelError,
+}
+
+// ParseLogLevel parses the debug, info, warn or error name of a level
+func ParseLogLevel(name string) (logging.Level, error) {
+	level, ok := logLevelNames[name]
+	if !ok {
+		return 0, fmt.Errorf("invalid log level %q, expected debug, info, warn or error", name)
+	}
+	return level, nil
+}
+
+// LogLevelName returns the name of the level
+func LogLevelName(level logging.Level) string {
+	for name, l := range logLevelNames {
+		if l == level {
+			return name
+		}
+	}
+	return fmt.Sprint(int(level))
+}
+
+// SetLogLevel changes the minimum level of the call logs, the calls in flight included
+func SetLogLevel(level logging.Level) {
+	logLevel.Store(int64(level))
+}
+
+// CurrentLogLevel returns the minimum level of the call logs
+func CurrentLogLevel() logging.Level {
+	return logging.Level(logLevel.Load())
+}
@@ -0,0 +1,130 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package utils contails useful helper functions
+package utils
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ip is the command line tool of iproute2, netlink has no binding of the macsec generic netlink family
+const ip = "ip"
+
+// MacsecStats are the counters of the secure channels of a macsec device, summed over its
+// receive channels
+type MacsecStats struct {
+	OutPktsProtected uint64 `json:"outPktsProtected"`
+	OutPktsEncrypted uint64 `json:"outPktsEncrypted"`
+	InPktsOK         uint64 `json:"inPktsOk"`
+	InPktsInvalid    uint64 `json:"inPktsInvalid"`
+	InPktsNotValid   uint64 `json:"inPktsNotValid"`
+}
+
+// Macsec represents limited subset of functions of the kernel macsec driver
+type Macsec interface {
+	MacsecApply(ctx context.Context, script string) error
+	MacsecStats(ctx context.Context, dev string) (MacsecStats, error)
+}
+
+// MacsecWrapper wrapper for the kernel macsec driver, through the ip command line tool
+type MacsecWrapper struct {
+	tracer trace.Tracer
+}
+
+// NewMacsecWrapper creates initialized instance of MacsecWrapper
+func NewMacsecWrapper() *MacsecWrapper {
+	// default tracer name is good for now
+	return &MacsecWrapper{tracer: otel.Tracer("")}
+}
+
+// build time check that struct implements interface
+var _ Macsec = (*MacsecWrapper)(nil)
+
+// MacsecApply runs an ip batch script, stopping at the first failed command
+func (n *MacsecWrapper) MacsecApply(ctx context.Context, script string) error {
+	_, childSpan := n.tracer.Start(ctx, "macsec.Apply")
+	defer childSpan.End()
+
+	// Example: ip -batch - <<< 'macsec add eth2s tx sa 0 pn 1 on key 00 ...'
+	cmd := exec.CommandContext(ctx, ip, "-batch", "-")
+	cmd.Stdin = strings.NewReader(script)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		if len(out) > 0 {
+			return fmt.Errorf("%s: %s", err, strings.TrimSpace(string(out)))
+		}
+		return err
+	}
+	return nil
+}
+
+// MacsecStats reads the counters of the secure channels of a macsec device
+func (n *MacsecWrapper) MacsecStats(ctx context.Context, dev string) (MacsecStats, error) {
+	_, childSpan := n.tracer.Start(ctx, "macsec.Stats")
+	childSpan.SetAttributes(attribute.String("macsec.dev", dev))
+	defer childSpan.End()
+
+	// Example: ip -s macsec show eth2s
+	out, err := exec.CommandContext(ctx, ip, "-s", "macsec", "show", dev).CombinedOutput()
+	if err != nil {
+		if len(out) > 0 {
+			return MacsecStats{}, fmt.Errorf("%s: %s", err, strings.TrimSpace(string(out)))
+		}
+		return MacsecStats{}, err
+	}
+	return ParseMacsecStats(string(out))
+}
+
+// ParseMacsecStats parses the output of ip -s macsec show, the counters of a secure channel are
+// the "stats:" header and values lines following its TXSC or RXSC line, up to the line of its
+// first association, the following ones are the counters of the associations
+func ParseMacsecStats(out string) (MacsecStats, error) {
+	var stats MacsecStats
+	counters := map[string]*uint64{
+		"OutPktsProtected": &stats.OutPktsProtected,
+		"OutPktsEncrypted": &stats.OutPktsEncrypted,
+		"InPktsOK":         &stats.InPktsOK,
+		"InPktsInvalid":    &stats.InPktsInvalid,
+		"InPktsNotValid":   &stats.InPktsNotValid,
+	}
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	channel := false
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case strings.HasPrefix(line, "TXSC:") || strings.HasPrefix(line, "RXSC:"):
+			channel = true
+			continue
+		case strings.Contains(line, ": PN "):
+			channel = false
+			continue
+		case !channel || !strings.HasPrefix(line, "stats:") || !scanner.Scan():
+			continue
+		}
+		names, values := strings.Fields(strings.TrimPrefix(line, "stats:")), strings.Fields(scanner.Text())
+		if len(names) != len(values) {
+			return stats, fmt.Errorf("unexpected macsec stats %q for %q", values, names)
+		}
+		for i, name := range names {
+			counter, ok := counters[name]
+			if !ok {
+				continue
+			}
+			value, err := strconv.ParseUint(values[i], 10, 64)
+			if err != nil {
+				return stats, fmt.Errorf("invalid macsec counter %s %q", name, values[i])
+			}
+			*counter += value
+		}
+	}
+	return stats, scanner.Err()
+}
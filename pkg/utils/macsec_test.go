@@ -0,0 +1,42 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package utils contails useful helper functions
+package utils
+
+import (
+	"testing"
+)
+
+const testMacsecShow = `7: eth2s: protect on validate strict sc off sa off encrypt on send_sci on end_station off scb off replay off
+    cipher suite: GCM-AES-128, using ICV length 16
+    TXSC: 0011223344550001 on SA 0
+    stats: OutPktsUntagged InPktsUntagged OutPktsTooLong InPktsNoTag InPktsBadTag InPktsUnknownSCI InPktsNoSCI InPktsOverrun
+                 0              3              0           0            0                0            0             0
+    stats: OutPktsProtected OutPktsEncrypted
+                 1             120
+        0: PN 121, state on, key 00000000000000000000000000000000
+    stats: OutPktsProtected OutPktsEncrypted
+                 1             120
+    RXSC: 66778899aabb0001, state on
+    stats: InOctetsValidated InOctetsDecrypted InPktsUnchecked InPktsDelayed InPktsOK InPktsInvalid InPktsLate InPktsNotValid InPktsNotUsingSA InPktsUnusedSA
+                 0              9000              0           0            100            2            0             1              0                0
+        0: PN 101, state on, key 01000000000000000000000000000000
+    stats: InPktsOK InPktsInvalid InPktsNotValid InPktsNotUsingSA InPktsUnusedSA
+                 100            2            1             0                0
+`
+
+func TestParseMacsecStats(t *testing.T) {
+	stats, err := ParseMacsecStats(testMacsecShow)
+	if err != nil {
+		t.Fatal("error: expected", nil, "received", err)
+	}
+	// the counters of the associations are not added to the ones of their channel
+	expected := MacsecStats{OutPktsProtected: 1, OutPktsEncrypted: 120, InPktsOK: 100, InPktsInvalid: 2, InPktsNotValid: 1}
+	if stats != expected {
+		t.Error("stats: expected", expected, "received", stats)
+	}
+	if _, err := ParseMacsecStats("    RXSC: 66778899aabb0001, state on\n    stats: InPktsOK InPktsInvalid\n 1\n"); err == nil {
+		t.Error("error: expected mismatched stats, received", nil)
+	}
+}
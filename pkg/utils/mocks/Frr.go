@@ -25,6 +25,59 @@ func (_m *Frr) EXPECT() *Frr_Expecter {
 	return &Frr_Expecter{mock: &_m.Mock}
 }
 
+// DaemonPid provides a mock function with given fields: _a0, _a1
+func (_m *Frr) DaemonPid(_a0 context.Context, _a1 string) (int, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (int, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) int); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Frr_DaemonPid_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DaemonPid'
+type Frr_DaemonPid_Call struct {
+	*mock.Call
+}
+
+// DaemonPid is a helper method to define mock.On call
+//   - _a0 context.Context
+//   - _a1 string
+func (_e *Frr_Expecter) DaemonPid(_a0 interface{}, _a1 interface{}) *Frr_DaemonPid_Call {
+	return &Frr_DaemonPid_Call{Call: _e.mock.On("DaemonPid", _a0, _a1)}
+}
+
+func (_c *Frr_DaemonPid_Call) Run(run func(_a0 context.Context, _a1 string)) *Frr_DaemonPid_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *Frr_DaemonPid_Call) Return(_a0 int, _a1 error) *Frr_DaemonPid_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Frr_DaemonPid_Call) RunAndReturn(run func(context.Context, string) (int, error)) *Frr_DaemonPid_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // EnterPrivileged provides a mock function with given fields: conn
 func (_m *Frr) EnterPrivileged(conn *telnet.Conn) error {
 	ret := _m.Called(conn)
@@ -162,6 +215,112 @@ func (_c *Frr_FrrBgpCmd_Call) RunAndReturn(run func(context.Context, string) (st
 	return _c
 }
 
+// FrrPimCmd provides a mock function with given fields: ctx, command
+func (_m *Frr) FrrPimCmd(ctx context.Context, command string) (string, error) {
+	ret := _m.Called(ctx, command)
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (string, error)); ok {
+		return rf(ctx, command)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) string); ok {
+		r0 = rf(ctx, command)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, command)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Frr_FrrPimCmd_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'FrrPimCmd'
+type Frr_FrrPimCmd_Call struct {
+	*mock.Call
+}
+
+// FrrPimCmd is a helper method to define mock.On call
+//   - ctx context.Context
+//   - command string
+func (_e *Frr_Expecter) FrrPimCmd(ctx interface{}, command interface{}) *Frr_FrrPimCmd_Call {
+	return &Frr_FrrPimCmd_Call{Call: _e.mock.On("FrrPimCmd", ctx, command)}
+}
+
+func (_c *Frr_FrrPimCmd_Call) Run(run func(ctx context.Context, command string)) *Frr_FrrPimCmd_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *Frr_FrrPimCmd_Call) Return(_a0 string, _a1 error) *Frr_FrrPimCmd_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Frr_FrrPimCmd_Call) RunAndReturn(run func(context.Context, string) (string, error)) *Frr_FrrPimCmd_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// FrrOspfCmd provides a mock function with given fields: ctx, command
+func (_m *Frr) FrrOspfCmd(ctx context.Context, command string) (string, error) {
+	ret := _m.Called(ctx, command)
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (string, error)); ok {
+		return rf(ctx, command)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) string); ok {
+		r0 = rf(ctx, command)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, command)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Frr_FrrOspfCmd_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'FrrOspfCmd'
+type Frr_FrrOspfCmd_Call struct {
+	*mock.Call
+}
+
+// FrrOspfCmd is a helper method to define mock.On call
+//   - ctx context.Context
+//   - command string
+func (_e *Frr_Expecter) FrrOspfCmd(ctx interface{}, command interface{}) *Frr_FrrOspfCmd_Call {
+	return &Frr_FrrOspfCmd_Call{Call: _e.mock.On("FrrOspfCmd", ctx, command)}
+}
+
+func (_c *Frr_FrrOspfCmd_Call) Run(run func(ctx context.Context, command string)) *Frr_FrrOspfCmd_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *Frr_FrrOspfCmd_Call) Return(_a0 string, _a1 error) *Frr_FrrOspfCmd_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Frr_FrrOspfCmd_Call) RunAndReturn(run func(context.Context, string) (string, error)) *Frr_FrrOspfCmd_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // FrrZebraCmd provides a mock function with given fields: ctx, command
 func (_m *Frr) FrrZebraCmd(ctx context.Context, command string) (string, error) {
 	ret := _m.Called(ctx, command)
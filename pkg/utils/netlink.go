@@ -6,13 +6,22 @@ package utils
 
 import (
 	"context"
+	"fmt"
+	"log"
 	"net"
+	"runtime"
+	"strings"
+	"sync"
 
 	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netlink/nl"
+	"github.com/vishvananda/netns"
 
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
+
+	"google.golang.org/grpc/status"
 )
 
 // Netlink represents limited subset of functions from netlink package
@@ -28,18 +37,122 @@ type Netlink interface {
 	LinkSetDown(context.Context, netlink.Link) error
 	LinkSetMaster(context.Context, netlink.Link, netlink.Link) error
 	LinkSetNoMaster(context.Context, netlink.Link) error
+	LinkSetLearning(context.Context, netlink.Link, bool) error
 	BridgeVlanAdd(context.Context, netlink.Link, uint16, bool, bool, bool, bool) error
 	BridgeVlanDel(context.Context, netlink.Link, uint16, bool, bool, bool, bool) error
+	RouteAdd(context.Context, *netlink.Route) error
+	RouteDel(context.Context, *netlink.Route) error
+	RouteListFiltered(context.Context, int, *netlink.Route, uint64) ([]netlink.Route, error)
+	RuleAdd(context.Context, *netlink.Rule) error
+	RuleDel(context.Context, *netlink.Rule) error
+	XfrmStateAdd(context.Context, *netlink.XfrmState) error
+	XfrmStateDel(context.Context, *netlink.XfrmState) error
+	XfrmPolicyAdd(context.Context, *netlink.XfrmPolicy) error
+	XfrmPolicyDel(context.Context, *netlink.XfrmPolicy) error
+	LinkList(context.Context) ([]netlink.Link, error)
+	AddrList(context.Context, netlink.Link, int) ([]netlink.Addr, error)
+	BridgeVlanList(context.Context) (map[int32][]*nl.BridgeVlanInfo, error)
+	NeighList(context.Context, int, int) ([]netlink.Neigh, error)
+	NeighDel(context.Context, *netlink.Neigh) error
+	NeighAppend(context.Context, *netlink.Neigh) error
+	NeighSet(context.Context, *netlink.Neigh) error
+	DevLinkGetDeviceByName(context.Context, string, string) (*netlink.DevlinkDevice, error)
+	LinkSubscribe(context.Context, chan<- netlink.LinkUpdate, bool) error
+	NeighSubscribe(context.Context, chan<- netlink.NeighUpdate, bool) error
+	RouteSubscribe(context.Context, chan<- netlink.RouteUpdate, bool) error
+	NetnsAdd(context.Context, string) error
+	NetnsDel(context.Context, string) error
+	LinkSetNs(context.Context, netlink.Link, string) error
+	Netns(context.Context, string) (Netlink, error)
 }
 
 // NetlinkWrapper wrapper for netlink package
 type NetlinkWrapper struct {
 	tracer trace.Tracer
+	slo    *SloTracker
+	// h and ns program a network namespace other than the one of the daemon, nil for its own
+	h  *netlink.Handle
+	ns *netns.NsHandle
+
+	mu         sync.Mutex
+	namespaces map[string]*NetlinkWrapper
 }
 
 // NewNetlinkWrapper creates initialized instance of NetlinkWrapper
 func NewNetlinkWrapper() *NetlinkWrapper {
-	return &NetlinkWrapper{tracer: otel.Tracer("")}
+	return &NetlinkWrapper{tracer: otel.Tracer(""), slo: DefaultSloTracker()}
+}
+
+// NewNetlinkWrapperAt creates an instance of NetlinkWrapper programming the network namespace
+// given by name, as in /var/run/netns, or by path, e.g. /proc/<pid>/ns/net, instead of the
+// one of the daemon
+func NewNetlinkWrapperAt(namespace string) (*NetlinkWrapper, error) {
+	n := NewNetlinkWrapper()
+	if err := n.open(namespace); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+// open points the wrapper at the network namespace given by name or path
+func (n *NetlinkWrapper) open(namespace string) error {
+	var ns netns.NsHandle
+	var err error
+	if strings.HasPrefix(namespace, "/") {
+		ns, err = netns.GetFromPath(namespace)
+	} else {
+		ns, err = netns.GetFromName(namespace)
+	}
+	if err != nil {
+		return fmt.Errorf("unable to open network namespace %s: %w", namespace, err)
+	}
+	h, err := netlink.NewHandleAt(ns)
+	if err != nil {
+		_ = ns.Close()
+		return fmt.Errorf("unable to open netlink in network namespace %s: %w", namespace, err)
+	}
+	n.h, n.ns = h, &ns
+	return nil
+}
+
+// close releases the network namespace the wrapper programs, if not the one of the daemon
+func (n *NetlinkWrapper) close() {
+	if n.h != nil {
+		n.h.Close()
+	}
+	if n.ns != nil {
+		_ = n.ns.Close()
+	}
+}
+
+// handle returns the netlink handle of the network namespace the wrapper programs
+func (n *NetlinkWrapper) handle() *netlink.Handle {
+	if n.h == nil {
+		// the zero handle is the one of the netlink package functions
+		return &netlink.Handle{}
+	}
+	return n.h
+}
+
+// record accounts the operation outcome for SLO reporting and tells the failed call in the error
+func (n *NetlinkWrapper) record(ctx context.Context, operation string, err error) error {
+	if n.slo != nil {
+		n.slo.Record(operation, ObjectTypeFromContext(ctx), err)
+	}
+	if err == nil {
+		return nil
+	}
+	return &NetlinkError{Operation: operation, Err: err}
+}
+
+// checkContext fails a call without making it once ctx is done, the client went away or the
+// deadline passed, so a create or delete stops at its next netlink or FRR call. The error has
+// the Canceled or DeadlineExceeded code
+func checkContext(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return status.FromContextError(err).Err()
+	}
+	return nil
 }
 
 // build time check that struct implements interface
@@ -50,7 +163,12 @@ func (n *NetlinkWrapper) LinkByName(ctx context.Context, name string) (netlink.L
 	_, childSpan := n.tracer.Start(ctx, "netlink.LinkByName")
 	childSpan.SetAttributes(attribute.String("link.name", name))
 	defer childSpan.End()
-	return netlink.LinkByName(name)
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+	link, err := n.handle().LinkByName(name)
+	err = n.record(ctx, "LinkByName", err)
+	return link, err
 }
 
 // LinkModify is a wrapper for netlink.LinkModify
@@ -58,7 +176,12 @@ func (n *NetlinkWrapper) LinkModify(ctx context.Context, link netlink.Link) erro
 	_, childSpan := n.tracer.Start(ctx, "netlink.LinkModify")
 	childSpan.SetAttributes(attribute.String("link.name", link.Attrs().Name))
 	defer childSpan.End()
-	return netlink.LinkModify(link)
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+	err := n.handle().LinkModify(link)
+	err = n.record(ctx, "LinkModify", err)
+	return err
 }
 
 // LinkSetHardwareAddr is a wrapper for netlink.LinkSetHardwareAddr
@@ -66,7 +189,12 @@ func (n *NetlinkWrapper) LinkSetHardwareAddr(ctx context.Context, link netlink.L
 	_, childSpan := n.tracer.Start(ctx, "netlink.LinkSetHardwareAddr")
 	childSpan.SetAttributes(attribute.String("link.name", link.Attrs().Name))
 	defer childSpan.End()
-	return netlink.LinkSetHardwareAddr(link, hwaddr)
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+	err := n.handle().LinkSetHardwareAddr(link, hwaddr)
+	err = n.record(ctx, "LinkSetHardwareAddr", err)
+	return err
 }
 
 // AddrAdd is a wrapper for netlink.AddrAdd
@@ -74,7 +202,12 @@ func (n *NetlinkWrapper) AddrAdd(ctx context.Context, link netlink.Link, addr *n
 	_, childSpan := n.tracer.Start(ctx, "netlink.AddrAdd")
 	childSpan.SetAttributes(attribute.String("link.name", link.Attrs().Name))
 	defer childSpan.End()
-	return netlink.AddrAdd(link, addr)
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+	err := n.handle().AddrAdd(link, addr)
+	err = n.record(ctx, "AddrAdd", err)
+	return err
 }
 
 // AddrDel is a wrapper for netlink.AddrDel
@@ -82,7 +215,12 @@ func (n *NetlinkWrapper) AddrDel(ctx context.Context, link netlink.Link, addr *n
 	_, childSpan := n.tracer.Start(ctx, "netlink.AddrDel")
 	childSpan.SetAttributes(attribute.String("link.name", link.Attrs().Name))
 	defer childSpan.End()
-	return netlink.AddrDel(link, addr)
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+	err := n.handle().AddrDel(link, addr)
+	err = n.record(ctx, "AddrDel", err)
+	return err
 }
 
 // LinkAdd is a wrapper for netlink.LinkAdd
@@ -90,7 +228,12 @@ func (n *NetlinkWrapper) LinkAdd(ctx context.Context, link netlink.Link) error {
 	_, childSpan := n.tracer.Start(ctx, "netlink.LinkAdd")
 	childSpan.SetAttributes(attribute.String("link.name", link.Attrs().Name))
 	defer childSpan.End()
-	return netlink.LinkAdd(link)
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+	err := n.handle().LinkAdd(link)
+	err = n.record(ctx, "LinkAdd", err)
+	return err
 }
 
 // LinkDel is a wrapper for netlink.LinkDel
@@ -98,7 +241,12 @@ func (n *NetlinkWrapper) LinkDel(ctx context.Context, link netlink.Link) error {
 	_, childSpan := n.tracer.Start(ctx, "netlink.LinkDel")
 	childSpan.SetAttributes(attribute.String("link.name", link.Attrs().Name))
 	defer childSpan.End()
-	return netlink.LinkDel(link)
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+	err := n.handle().LinkDel(link)
+	err = n.record(ctx, "LinkDel", err)
+	return err
 }
 
 // LinkSetUp is a wrapper for netlink.LinkSetUp
@@ -106,7 +254,12 @@ func (n *NetlinkWrapper) LinkSetUp(ctx context.Context, link netlink.Link) error
 	_, childSpan := n.tracer.Start(ctx, "netlink.LinkSetUp")
 	childSpan.SetAttributes(attribute.String("link.name", link.Attrs().Name))
 	defer childSpan.End()
-	return netlink.LinkSetUp(link)
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+	err := n.handle().LinkSetUp(link)
+	err = n.record(ctx, "LinkSetUp", err)
+	return err
 }
 
 // LinkSetDown is a wrapper for netlink.LinkSetDown
@@ -114,7 +267,12 @@ func (n *NetlinkWrapper) LinkSetDown(ctx context.Context, link netlink.Link) err
 	_, childSpan := n.tracer.Start(ctx, "netlink.LinkSetDown")
 	childSpan.SetAttributes(attribute.String("link.name", link.Attrs().Name))
 	defer childSpan.End()
-	return netlink.LinkSetDown(link)
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+	err := n.handle().LinkSetDown(link)
+	err = n.record(ctx, "LinkSetDown", err)
+	return err
 }
 
 // LinkSetMaster is a wrapper for netlink.LinkSetMaster
@@ -122,7 +280,12 @@ func (n *NetlinkWrapper) LinkSetMaster(ctx context.Context, link, master netlink
 	_, childSpan := n.tracer.Start(ctx, "netlink.LinkSetMaster")
 	childSpan.SetAttributes(attribute.String("link.name", link.Attrs().Name))
 	defer childSpan.End()
-	return netlink.LinkSetMaster(link, master)
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+	err := n.handle().LinkSetMaster(link, master)
+	err = n.record(ctx, "LinkSetMaster", err)
+	return err
 }
 
 // LinkSetNoMaster is a wrapper for netlink.LinkSetNoMaster
@@ -130,7 +293,25 @@ func (n *NetlinkWrapper) LinkSetNoMaster(ctx context.Context, link netlink.Link)
 	_, childSpan := n.tracer.Start(ctx, "netlink.LinkSetNoMaster")
 	childSpan.SetAttributes(attribute.String("link.name", link.Attrs().Name))
 	defer childSpan.End()
-	return netlink.LinkSetNoMaster(link)
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+	err := n.handle().LinkSetNoMaster(link)
+	err = n.record(ctx, "LinkSetNoMaster", err)
+	return err
+}
+
+// LinkSetLearning is a wrapper for netlink.LinkSetLearning
+func (n *NetlinkWrapper) LinkSetLearning(ctx context.Context, link netlink.Link, mode bool) error {
+	_, childSpan := n.tracer.Start(ctx, "netlink.LinkSetLearning")
+	childSpan.SetAttributes(attribute.String("link.name", link.Attrs().Name), attribute.Bool("link.learning", mode))
+	defer childSpan.End()
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+	err := n.handle().LinkSetLearning(link, mode)
+	err = n.record(ctx, "LinkSetLearning", err)
+	return err
 }
 
 // BridgeVlanAdd is a wrapper for netlink.BridgeVlanAdd
@@ -138,7 +319,12 @@ func (n *NetlinkWrapper) BridgeVlanAdd(ctx context.Context, link netlink.Link, v
 	_, childSpan := n.tracer.Start(ctx, "netlink.BridgeVlanAdd")
 	childSpan.SetAttributes(attribute.String("link.name", link.Attrs().Name))
 	defer childSpan.End()
-	return netlink.BridgeVlanAdd(link, vid, pvid, untagged, self, master)
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+	err := n.handle().BridgeVlanAdd(link, vid, pvid, untagged, self, master)
+	err = n.record(ctx, "BridgeVlanAdd", err)
+	return err
 }
 
 // BridgeVlanDel is a wrapper for netlink.BridgeVlanDel
@@ -146,5 +332,361 @@ func (n *NetlinkWrapper) BridgeVlanDel(ctx context.Context, link netlink.Link, v
 	_, childSpan := n.tracer.Start(ctx, "netlink.BridgeVlanDel")
 	childSpan.SetAttributes(attribute.String("link.name", link.Attrs().Name))
 	defer childSpan.End()
-	return netlink.BridgeVlanDel(link, vid, pvid, untagged, self, master)
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+	err := n.handle().BridgeVlanDel(link, vid, pvid, untagged, self, master)
+	err = n.record(ctx, "BridgeVlanDel", err)
+	return err
+}
+
+// RouteAdd is a wrapper for netlink.RouteAdd
+func (n *NetlinkWrapper) RouteAdd(ctx context.Context, route *netlink.Route) error {
+	_, childSpan := n.tracer.Start(ctx, "netlink.RouteAdd")
+	childSpan.SetAttributes(attribute.String("route.dst", route.Dst.String()), attribute.Int("route.table", route.Table))
+	defer childSpan.End()
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+	err := n.handle().RouteAdd(route)
+	err = n.record(ctx, "RouteAdd", err)
+	return err
+}
+
+// RouteDel is a wrapper for netlink.RouteDel
+func (n *NetlinkWrapper) RouteDel(ctx context.Context, route *netlink.Route) error {
+	_, childSpan := n.tracer.Start(ctx, "netlink.RouteDel")
+	childSpan.SetAttributes(attribute.String("route.dst", route.Dst.String()), attribute.Int("route.table", route.Table))
+	defer childSpan.End()
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+	err := n.handle().RouteDel(route)
+	err = n.record(ctx, "RouteDel", err)
+	return err
+}
+
+// RuleAdd is a wrapper for netlink.RuleAdd
+func (n *NetlinkWrapper) RuleAdd(ctx context.Context, rule *netlink.Rule) error {
+	_, childSpan := n.tracer.Start(ctx, "netlink.RuleAdd")
+	childSpan.SetAttributes(attribute.Int("rule.priority", rule.Priority), attribute.Int("rule.table", rule.Table))
+	defer childSpan.End()
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+	err := n.handle().RuleAdd(rule)
+	err = n.record(ctx, "RuleAdd", err)
+	return err
+}
+
+// RuleDel is a wrapper for netlink.RuleDel
+func (n *NetlinkWrapper) RuleDel(ctx context.Context, rule *netlink.Rule) error {
+	_, childSpan := n.tracer.Start(ctx, "netlink.RuleDel")
+	childSpan.SetAttributes(attribute.Int("rule.priority", rule.Priority), attribute.Int("rule.table", rule.Table))
+	defer childSpan.End()
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+	err := n.handle().RuleDel(rule)
+	err = n.record(ctx, "RuleDel", err)
+	return err
+}
+
+// XfrmStateAdd is a wrapper for netlink.XfrmStateAdd
+func (n *NetlinkWrapper) XfrmStateAdd(ctx context.Context, state *netlink.XfrmState) error {
+	_, childSpan := n.tracer.Start(ctx, "netlink.XfrmStateAdd")
+	childSpan.SetAttributes(attribute.String("xfrm.dst", state.Dst.String()), attribute.Int("xfrm.spi", state.Spi))
+	defer childSpan.End()
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+	err := n.handle().XfrmStateAdd(state)
+	err = n.record(ctx, "XfrmStateAdd", err)
+	return err
+}
+
+// XfrmStateDel is a wrapper for netlink.XfrmStateDel
+func (n *NetlinkWrapper) XfrmStateDel(ctx context.Context, state *netlink.XfrmState) error {
+	_, childSpan := n.tracer.Start(ctx, "netlink.XfrmStateDel")
+	childSpan.SetAttributes(attribute.String("xfrm.dst", state.Dst.String()), attribute.Int("xfrm.spi", state.Spi))
+	defer childSpan.End()
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+	err := n.handle().XfrmStateDel(state)
+	err = n.record(ctx, "XfrmStateDel", err)
+	return err
+}
+
+// XfrmPolicyAdd is a wrapper for netlink.XfrmPolicyAdd
+func (n *NetlinkWrapper) XfrmPolicyAdd(ctx context.Context, policy *netlink.XfrmPolicy) error {
+	_, childSpan := n.tracer.Start(ctx, "netlink.XfrmPolicyAdd")
+	childSpan.SetAttributes(attribute.String("xfrm.dst", policy.Dst.String()), attribute.String("xfrm.dir", policy.Dir.String()))
+	defer childSpan.End()
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+	err := n.handle().XfrmPolicyAdd(policy)
+	err = n.record(ctx, "XfrmPolicyAdd", err)
+	return err
+}
+
+// XfrmPolicyDel is a wrapper for netlink.XfrmPolicyDel
+func (n *NetlinkWrapper) XfrmPolicyDel(ctx context.Context, policy *netlink.XfrmPolicy) error {
+	_, childSpan := n.tracer.Start(ctx, "netlink.XfrmPolicyDel")
+	childSpan.SetAttributes(attribute.String("xfrm.dst", policy.Dst.String()), attribute.String("xfrm.dir", policy.Dir.String()))
+	defer childSpan.End()
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+	err := n.handle().XfrmPolicyDel(policy)
+	err = n.record(ctx, "XfrmPolicyDel", err)
+	return err
+}
+
+// LinkList is a wrapper for netlink.LinkList
+func (n *NetlinkWrapper) LinkList(ctx context.Context) ([]netlink.Link, error) {
+	_, childSpan := n.tracer.Start(ctx, "netlink.LinkList")
+	defer childSpan.End()
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+	links, err := n.handle().LinkList()
+	err = n.record(ctx, "LinkList", err)
+	return links, err
+}
+
+// AddrList is a wrapper for netlink.AddrList
+func (n *NetlinkWrapper) AddrList(ctx context.Context, link netlink.Link, family int) ([]netlink.Addr, error) {
+	_, childSpan := n.tracer.Start(ctx, "netlink.AddrList")
+	childSpan.SetAttributes(attribute.String("link.name", link.Attrs().Name), attribute.Int("addr.family", family))
+	defer childSpan.End()
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+	addrs, err := n.handle().AddrList(link, family)
+	err = n.record(ctx, "AddrList", err)
+	return addrs, err
+}
+
+// BridgeVlanList is a wrapper for netlink.BridgeVlanList
+func (n *NetlinkWrapper) BridgeVlanList(ctx context.Context) (map[int32][]*nl.BridgeVlanInfo, error) {
+	_, childSpan := n.tracer.Start(ctx, "netlink.BridgeVlanList")
+	defer childSpan.End()
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+	vlans, err := n.handle().BridgeVlanList()
+	err = n.record(ctx, "BridgeVlanList", err)
+	return vlans, err
+}
+
+// NeighList is a wrapper for netlink.NeighList
+func (n *NetlinkWrapper) NeighList(ctx context.Context, linkIndex, family int) ([]netlink.Neigh, error) {
+	_, childSpan := n.tracer.Start(ctx, "netlink.NeighList")
+	childSpan.SetAttributes(attribute.Int("link.index", linkIndex), attribute.Int("neigh.family", family))
+	defer childSpan.End()
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+	neighs, err := n.handle().NeighList(linkIndex, family)
+	err = n.record(ctx, "NeighList", err)
+	return neighs, err
+}
+
+// RouteListFiltered is a wrapper for netlink.RouteListFiltered
+func (n *NetlinkWrapper) RouteListFiltered(ctx context.Context, family int, filter *netlink.Route, filterMask uint64) ([]netlink.Route, error) {
+	_, childSpan := n.tracer.Start(ctx, "netlink.RouteListFiltered")
+	childSpan.SetAttributes(attribute.Int("route.family", family), attribute.Int("route.table", filter.Table))
+	defer childSpan.End()
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+	routes, err := n.handle().RouteListFiltered(family, filter, filterMask)
+	err = n.record(ctx, "RouteListFiltered", err)
+	return routes, err
+}
+
+// NeighDel is a wrapper for netlink.NeighDel
+func (n *NetlinkWrapper) NeighDel(ctx context.Context, neigh *netlink.Neigh) error {
+	_, childSpan := n.tracer.Start(ctx, "netlink.NeighDel")
+	childSpan.SetAttributes(attribute.String("neigh.mac", neigh.HardwareAddr.String()), attribute.Int("neigh.vlan", neigh.Vlan))
+	defer childSpan.End()
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+	err := n.handle().NeighDel(neigh)
+	err = n.record(ctx, "NeighDel", err)
+	return err
+}
+
+// NeighAppend is a wrapper for netlink.NeighAppend
+func (n *NetlinkWrapper) NeighAppend(ctx context.Context, neigh *netlink.Neigh) error {
+	_, childSpan := n.tracer.Start(ctx, "netlink.NeighAppend")
+	childSpan.SetAttributes(attribute.String("neigh.mac", neigh.HardwareAddr.String()), attribute.Int("neigh.vlan", neigh.Vlan))
+	defer childSpan.End()
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+	err := n.handle().NeighAppend(neigh)
+	err = n.record(ctx, "NeighAppend", err)
+	return err
+}
+
+// NeighSet is a wrapper for netlink.NeighSet
+func (n *NetlinkWrapper) NeighSet(ctx context.Context, neigh *netlink.Neigh) error {
+	_, childSpan := n.tracer.Start(ctx, "netlink.NeighSet")
+	childSpan.SetAttributes(attribute.String("neigh.mac", neigh.HardwareAddr.String()), attribute.Int("neigh.vlan", neigh.Vlan))
+	defer childSpan.End()
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+	err := n.handle().NeighSet(neigh)
+	err = n.record(ctx, "NeighSet", err)
+	return err
+}
+
+// DevLinkGetDeviceByName is a wrapper for netlink.DevLinkGetDeviceByName
+func (n *NetlinkWrapper) DevLinkGetDeviceByName(ctx context.Context, bus string, device string) (*netlink.DevlinkDevice, error) {
+	_, childSpan := n.tracer.Start(ctx, "netlink.DevLinkGetDeviceByName")
+	childSpan.SetAttributes(attribute.String("devlink.bus", bus), attribute.String("devlink.device", device))
+	defer childSpan.End()
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+	dev, err := n.handle().DevLinkGetDeviceByName(bus, device)
+	err = n.record(ctx, "DevLinkGetDeviceByName", err)
+	return dev, err
+}
+
+// LinkSubscribe is a wrapper for netlink.LinkSubscribeWithOptions, the updates stop when ctx is done
+// and ch is closed when they stop, also on error
+func (n *NetlinkWrapper) LinkSubscribe(ctx context.Context, ch chan<- netlink.LinkUpdate, listExisting bool) error {
+	_, childSpan := n.tracer.Start(ctx, "netlink.LinkSubscribe")
+	defer childSpan.End()
+	err := netlink.LinkSubscribeWithOptions(ch, ctx.Done(), netlink.LinkSubscribeOptions{
+		Namespace:    n.ns,
+		ListExisting: listExisting,
+		ErrorCallback: func(err error) {
+			log.Printf("Link subscription failed: %v", err)
+		},
+	})
+	err = n.record(ctx, "LinkSubscribe", err)
+	return err
+}
+
+// NeighSubscribe is a wrapper for netlink.NeighSubscribeWithOptions, the updates stop when ctx is done
+// and ch is closed when they stop, also on error
+func (n *NetlinkWrapper) NeighSubscribe(ctx context.Context, ch chan<- netlink.NeighUpdate, listExisting bool) error {
+	_, childSpan := n.tracer.Start(ctx, "netlink.NeighSubscribe")
+	defer childSpan.End()
+	err := netlink.NeighSubscribeWithOptions(ch, ctx.Done(), netlink.NeighSubscribeOptions{
+		Namespace:    n.ns,
+		ListExisting: listExisting,
+		ErrorCallback: func(err error) {
+			log.Printf("Neighbor subscription failed: %v", err)
+		},
+	})
+	err = n.record(ctx, "NeighSubscribe", err)
+	return err
+}
+
+// RouteSubscribe is a wrapper for netlink.RouteSubscribeWithOptions, the updates stop when ctx is done
+// and ch is closed when they stop, also on error
+func (n *NetlinkWrapper) RouteSubscribe(ctx context.Context, ch chan<- netlink.RouteUpdate, listExisting bool) error {
+	_, childSpan := n.tracer.Start(ctx, "netlink.RouteSubscribe")
+	defer childSpan.End()
+	err := netlink.RouteSubscribeWithOptions(ch, ctx.Done(), netlink.RouteSubscribeOptions{
+		Namespace:    n.ns,
+		ListExisting: listExisting,
+		ErrorCallback: func(err error) {
+			log.Printf("Route subscription failed: %v", err)
+		},
+	})
+	err = n.record(ctx, "RouteSubscribe", err)
+	return err
+}
+
+// NetnsAdd creates a named network namespace, as ip netns add does, the daemon staying in its own
+func (n *NetlinkWrapper) NetnsAdd(ctx context.Context, name string) error {
+	_, childSpan := n.tracer.Start(ctx, "netns.NewNamed")
+	childSpan.SetAttributes(attribute.String("netns.name", name))
+	defer childSpan.End()
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+	// netns.NewNamed moves the calling thread into the new namespace, move it back before
+	// another goroutine gets it
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+	origin, err := netns.Get()
+	if err == nil {
+		defer func() { _ = origin.Close() }()
+		var ns netns.NsHandle
+		ns, err = netns.NewNamed(name)
+		if err == nil {
+			_ = ns.Close()
+			err = netns.Set(origin)
+		}
+	}
+	err = n.record(ctx, "NetnsAdd", err)
+	return err
+}
+
+// NetnsDel deletes a named network namespace, as ip netns del does, its virtual links with it
+func (n *NetlinkWrapper) NetnsDel(ctx context.Context, name string) error {
+	_, childSpan := n.tracer.Start(ctx, "netns.DeleteNamed")
+	childSpan.SetAttributes(attribute.String("netns.name", name))
+	defer childSpan.End()
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+	n.mu.Lock()
+	if inner, ok := n.namespaces[name]; ok {
+		inner.close()
+		delete(n.namespaces, name)
+	}
+	n.mu.Unlock()
+	err := netns.DeleteNamed(name)
+	err = n.record(ctx, "NetnsDel", err)
+	return err
+}
+
+// LinkSetNs moves the link into the named network namespace, as ip link set netns does
+func (n *NetlinkWrapper) LinkSetNs(ctx context.Context, link netlink.Link, name string) error {
+	_, childSpan := n.tracer.Start(ctx, "netlink.LinkSetNsFd")
+	childSpan.SetAttributes(attribute.String("link.name", link.Attrs().Name), attribute.String("netns.name", name))
+	defer childSpan.End()
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+	ns, err := netns.GetFromName(name)
+	if err == nil {
+		err = n.handle().LinkSetNsFd(link, int(ns))
+		_ = ns.Close()
+	}
+	err = n.record(ctx, "LinkSetNs", err)
+	return err
+}
+
+// Netns returns the wrapper programming the named network namespace, opened once and kept
+// until NetnsDel deletes the namespace
+func (n *NetlinkWrapper) Netns(ctx context.Context, name string) (Netlink, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if inner, ok := n.namespaces[name]; ok {
+		return inner, nil
+	}
+	inner := &NetlinkWrapper{tracer: n.tracer, slo: n.slo}
+	if err := inner.open(name); err != nil {
+		return nil, n.record(ctx, "Netns", err)
+	}
+	if n.namespaces == nil {
+		n.namespaces = map[string]*NetlinkWrapper{}
+	}
+	n.namespaces[name] = inner
+	return inner, nil
 }
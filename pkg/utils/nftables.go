@@ -0,0 +1,55 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package utils contails useful helper functions
+package utils
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// nft is the command line tool of nftables
+const nft = "nft"
+
+// Nftables represents limited subset of functions from nftables
+type Nftables interface {
+	NftApply(ctx context.Context, script string) error
+}
+
+// NftablesWrapper wrapper for nftables, through its nft command line tool
+type NftablesWrapper struct {
+	tracer trace.Tracer
+}
+
+// NewNftablesWrapper creates initialized instance of NftablesWrapper
+func NewNftablesWrapper() *NftablesWrapper {
+	// default tracer name is good for now
+	return &NftablesWrapper{tracer: otel.Tracer("")}
+}
+
+// build time check that struct implements interface
+var _ Nftables = (*NftablesWrapper)(nil)
+
+// NftApply runs an nft script in a single transaction, either all of its commands are
+// applied or none of them
+func (n *NftablesWrapper) NftApply(ctx context.Context, script string) error {
+	_, childSpan := n.tracer.Start(ctx, "nft.Apply")
+	defer childSpan.End()
+
+	// Example: nft -f - <<< 'add table inet opi-blue'
+	cmd := exec.CommandContext(ctx, nft, "-f", "-")
+	cmd.Stdin = strings.NewReader(script)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		if len(out) > 0 {
+			return fmt.Errorf("%s: %s", err, strings.TrimSpace(string(out)))
+		}
+		return err
+	}
+	return nil
+}
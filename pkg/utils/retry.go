@@ -0,0 +1,141 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package utils has some utility functions and interfaces
+package utils
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	retryPending = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: "evpn",
+			Subsystem: "retry",
+			Name:      "pending",
+			Help:      "Number of failed operations waiting to be retried.",
+		},
+	)
+	retryAttempts = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "evpn",
+			Subsystem: "retry",
+			Name:      "attempts_total",
+			Help:      "Number of retries of failed operations by outcome.",
+		},
+		[]string{"outcome"},
+	)
+)
+
+// MustRegisterRetryMetrics registers the retry queue collectors in the given registry
+func MustRegisterRetryMetrics(reg prometheus.Registerer) {
+	reg.MustRegister(retryPending, retryAttempts)
+}
+
+// RetryFunc is an operation run again until it succeeds
+type RetryFunc func(ctx context.Context) error
+
+// RetryQueue runs failed operations again in the background, waiting twice as long after
+// every failure up to a maximum, until they succeed or are cancelled. There is at most one
+// pending operation per key, e.g. per object name
+type RetryQueue struct {
+	mu      sync.Mutex
+	pending map[string]*retryItem
+	initial time.Duration
+	max     time.Duration
+	// report is called after every retry with its outcome, it must not use the queue
+	report func(key string, attempt int, err error)
+}
+
+type retryItem struct {
+	op      RetryFunc
+	attempt int
+	delay   time.Duration
+	timer   *time.Timer
+}
+
+// NewRetryQueue creates a queue retrying after initial, then doubling the delay up to max,
+// report is called after every retry, if not nil, and must not use the queue
+func NewRetryQueue(initial time.Duration, max time.Duration, report func(key string, attempt int, err error)) *RetryQueue {
+	return &RetryQueue{
+		pending: map[string]*retryItem{},
+		initial: initial,
+		max:     max,
+		report:  report,
+	}
+}
+
+// Add schedules op to be retried for key, replacing the operation already pending for it
+func (q *RetryQueue) Add(key string, op RetryFunc) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if old, ok := q.pending[key]; ok {
+		old.timer.Stop()
+	} else {
+		retryPending.Inc()
+	}
+	item := &retryItem{op: op, delay: q.initial}
+	q.pending[key] = item
+	item.timer = time.AfterFunc(item.delay, func() { q.run(key, item) })
+}
+
+// Cancel drops the operation pending for key, e.g. when its object is deleted
+func (q *RetryQueue) Cancel(key string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if item, ok := q.pending[key]; ok {
+		item.timer.Stop()
+		delete(q.pending, key)
+		retryPending.Dec()
+	}
+}
+
+// Pending returns the keys of the operations waiting to be retried with their attempt count
+func (q *RetryQueue) Pending() map[string]int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	pending := make(map[string]int, len(q.pending))
+	for key, item := range q.pending {
+		pending[key] = item.attempt
+	}
+	return pending
+}
+
+func (q *RetryQueue) run(key string, item *retryItem) {
+	// the operation may have been cancelled or replaced while waiting
+	q.mu.Lock()
+	if q.pending[key] != item {
+		q.mu.Unlock()
+		return
+	}
+	item.attempt++
+	q.mu.Unlock()
+
+	err := item.op(context.Background())
+
+	q.mu.Lock()
+	if q.pending[key] == item {
+		if err == nil {
+			delete(q.pending, key)
+			retryPending.Dec()
+			retryAttempts.WithLabelValues("success").Inc()
+		} else {
+			item.delay *= 2
+			if item.delay > q.max {
+				item.delay = q.max
+			}
+			item.timer = time.AfterFunc(item.delay, func() { q.run(key, item) })
+			retryAttempts.WithLabelValues("failure").Inc()
+		}
+		// reported under the lock, so nothing is reported for a key once Cancel returned
+		if q.report != nil {
+			q.report(key, item.attempt, err)
+		}
+	}
+	q.mu.Unlock()
+}
@@ -0,0 +1,63 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package utils has some utility functions and interfaces
+package utils
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type retryOutcome struct {
+	key     string
+	attempt int
+	err     error
+}
+
+func TestRetryQueue_Add(t *testing.T) {
+	outcomes := make(chan retryOutcome, 10)
+	q := NewRetryQueue(time.Millisecond, 4*time.Millisecond, func(key string, attempt int, err error) {
+		outcomes <- retryOutcome{key: key, attempt: attempt, err: err}
+	})
+	failures := 2
+	q.Add("vrf1", func(context.Context) error {
+		if failures > 0 {
+			failures--
+			return errors.New("frr is restarting")
+		}
+		return nil
+	})
+	for attempt := 1; attempt <= 3; attempt++ {
+		select {
+		case outcome := <-outcomes:
+			if outcome.key != "vrf1" || outcome.attempt != attempt || (outcome.err == nil) != (attempt == 3) {
+				t.Errorf("RetryQueue outcome = %+v, want attempt %d", outcome, attempt)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("RetryQueue did not retry attempt %d", attempt)
+		}
+	}
+	if pending := q.Pending(); len(pending) != 0 {
+		t.Errorf("RetryQueue.Pending() = %v, want none after success", pending)
+	}
+}
+
+func TestRetryQueue_Cancel(t *testing.T) {
+	q := NewRetryQueue(10*time.Millisecond, time.Second, func(key string, attempt int, err error) {
+		t.Errorf("RetryQueue reported %s attempt %d after cancel: %v", key, attempt, err)
+	})
+	q.Add("vrf1", func(context.Context) error {
+		return errors.New("frr is restarting")
+	})
+	if pending := q.Pending(); pending["vrf1"] != 0 || len(pending) != 1 {
+		t.Errorf("RetryQueue.Pending() = %v, want vrf1 with no attempt", pending)
+	}
+	q.Cancel("vrf1")
+	time.Sleep(50 * time.Millisecond)
+	if pending := q.Pending(); len(pending) != 0 {
+		t.Errorf("RetryQueue.Pending() = %v, want none after cancel", pending)
+	}
+}
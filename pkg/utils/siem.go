@@ -0,0 +1,323 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package utils has some utility functions and interfaces
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"log/syslog"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// siemQueueLength is the number of events buffered in memory before spooling
+	siemQueueLength = 1024
+	// siemRetries is the number of delivery attempts of an event before spooling
+	siemRetries = 3
+	// siemTimeout bounds a single delivery attempt
+	siemTimeout = 5 * time.Second
+)
+
+// siemSender delivers a single event to the SIEM
+type siemSender interface {
+	Send(ev AuditEvent) error
+}
+
+// SiemForwarder ships audit events to a SIEM in the background, retrying failed
+// deliveries and spooling undelivered events to a local file, replayed in order
+// once the SIEM is reachable again
+type SiemForwarder struct {
+	sender  siemSender
+	queue   chan AuditEvent
+	done    chan struct{}
+	spool   string
+	backoff time.Duration
+	// mu guards the spool file and closed
+	mu     sync.Mutex
+	closed bool
+}
+
+// NewSiemForwarder starts a forwarder to target, either cef+udp://host:port or
+// cef+tcp://host:port for CEF over syslog, or an http(s):// URL receiving each
+// event as a JSON POST. Undelivered events are appended to the spool file, if any.
+func NewSiemForwarder(target string, spool string) (*SiemForwarder, error) {
+	u, err := url.Parse(target)
+	if err != nil {
+		return nil, fmt.Errorf("invalid siem target %q: %w", target, err)
+	}
+	var sender siemSender
+	switch u.Scheme {
+	case "cef+udp", "cef+tcp":
+		hostname, _ := os.Hostname()
+		sender = &cefSender{network: strings.TrimPrefix(u.Scheme, "cef+"), addr: u.Host, hostname: hostname}
+	case "http", "https":
+		sender = &httpSender{url: target, client: &http.Client{Timeout: siemTimeout}}
+	default:
+		return nil, fmt.Errorf("invalid siem target %q, expected cef+udp://, cef+tcp://, http:// or https://", target)
+	}
+	return newSiemForwarder(sender, spool, time.Second), nil
+}
+
+func newSiemForwarder(sender siemSender, spool string, backoff time.Duration) *SiemForwarder {
+	f := &SiemForwarder{
+		sender:  sender,
+		queue:   make(chan AuditEvent, siemQueueLength),
+		done:    make(chan struct{}),
+		spool:   spool,
+		backoff: backoff,
+	}
+	go f.run()
+	return f
+}
+
+// Forward queues the event for delivery, spooling it when the queue is full
+func (f *SiemForwarder) Forward(ev AuditEvent) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		f.spoolEvents(ev)
+		return
+	}
+	select {
+	case f.queue <- ev:
+	default:
+		f.spoolEvents(ev)
+	}
+}
+
+// Close delivers or spools the queued events and stops the forwarder
+func (f *SiemForwarder) Close() error {
+	f.mu.Lock()
+	if !f.closed {
+		f.closed = true
+		close(f.queue)
+	}
+	f.mu.Unlock()
+	<-f.done
+	return nil
+}
+
+func (f *SiemForwarder) run() {
+	defer close(f.done)
+	for ev := range f.queue {
+		// replay the spool first to keep the events in order
+		if !f.replaySpool() {
+			f.mu.Lock()
+			f.spoolEvents(ev)
+			f.mu.Unlock()
+			continue
+		}
+		if err := f.sendWithRetries(ev); err != nil {
+			log.Printf("Failed to forward audit event to siem: %v", err)
+			f.mu.Lock()
+			f.spoolEvents(ev)
+			f.mu.Unlock()
+		}
+	}
+}
+
+func (f *SiemForwarder) sendWithRetries(ev AuditEvent) error {
+	var err error
+	delay := f.backoff
+	for attempt := 0; attempt < siemRetries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(delay)
+			delay *= 2
+		}
+		if err = f.sender.Send(ev); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+// spoolEvents appends the events to the spool file, f.mu must be held
+func (f *SiemForwarder) spoolEvents(events ...AuditEvent) {
+	if f.spool == "" {
+		log.Printf("Dropping %d audit events, no siem spool configured", len(events))
+		return
+	}
+	if err := writeSpool(f.spool, os.O_APPEND, events); err != nil {
+		log.Printf("Failed to spool audit events: %v", err)
+	}
+}
+
+// writeSpool writes the events as JSON lines, flag is os.O_APPEND or os.O_TRUNC
+func writeSpool(name string, flag int, events []AuditEvent) error {
+	file, err := os.OpenFile(name, flag|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(file)
+	enc := json.NewEncoder(w)
+	for _, ev := range events {
+		if err := enc.Encode(ev); err != nil {
+			_ = file.Close()
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		_ = file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// parseSpool decodes the JSON lines of a spool file
+func parseSpool(data []byte) []AuditEvent {
+	var events []AuditEvent
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		ev := AuditEvent{}
+		if err := json.Unmarshal(scanner.Bytes(), &ev); err != nil {
+			log.Printf("Skipping corrupted siem spool entry: %v", err)
+			continue
+		}
+		events = append(events, ev)
+	}
+	return events
+}
+
+// replaySpool delivers the spooled events, keeping the undelivered ones,
+// and reports whether the spool is now empty
+func (f *SiemForwarder) replaySpool() bool {
+	if f.spool == "" {
+		return true
+	}
+	f.mu.Lock()
+	data, err := os.ReadFile(f.spool)
+	f.mu.Unlock()
+	if err != nil {
+		return os.IsNotExist(err)
+	}
+	// delivered without holding the lock, Forward keeps spooling meanwhile
+	events := parseSpool(data)
+	delivered := 0
+	for _, ev := range events {
+		if err := f.sender.Send(ev); err != nil {
+			log.Printf("Failed to replay siem spool: %v", err)
+			break
+		}
+		delivered++
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	current, err := os.ReadFile(f.spool)
+	if err != nil || len(current) < len(data) {
+		log.Printf("Failed to read siem spool: %v", err)
+		return false
+	}
+	rest := append(events[delivered:], parseSpool(current[len(data):])...)
+	if len(rest) == 0 {
+		if err := os.Remove(f.spool); err != nil {
+			log.Printf("Failed to remove siem spool: %v", err)
+		}
+		return true
+	}
+	if delivered > 0 {
+		// the replacement is renamed over the spool so a crash never loses events
+		tmp := f.spool + ".tmp"
+		err := writeSpool(tmp, os.O_TRUNC, rest)
+		if err == nil {
+			err = os.Rename(tmp, f.spool)
+		}
+		if err != nil {
+			log.Printf("Failed to rewrite siem spool: %v", err)
+		}
+	}
+	return false
+}
+
+// httpSender posts each event as JSON
+type httpSender struct {
+	url    string
+	client *http.Client
+}
+
+func (h *httpSender) Send(ev AuditEvent) error {
+	body, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	resp, err := h.client.Post(h.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("siem returned %s", resp.Status)
+	}
+	return nil
+}
+
+// cefSender sends each event as a CEF message in a syslog frame
+type cefSender struct {
+	network  string
+	addr     string
+	hostname string
+}
+
+func (c *cefSender) Send(ev AuditEvent) error {
+	conn, err := net.DialTimeout(c.network, c.addr, siemTimeout)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if err := conn.SetDeadline(time.Now().Add(siemTimeout)); err != nil {
+		return err
+	}
+	msg := fmt.Sprintf("<%d>%s %s opi-evpn-bridge: %s\n", syslog.LOG_AUTH|syslog.LOG_INFO, ev.Time.Format(time.Stamp), c.hostname, FormatCEF(ev))
+	_, err = conn.Write([]byte(msg))
+	return err
+}
+
+var (
+	cefHeaderEscaper    = strings.NewReplacer(`\`, `\\`, `|`, `\|`)
+	cefExtensionEscaper = strings.NewReplacer(`\`, `\\`, `=`, `\=`, "\n", `\n`, "\r", `\r`)
+)
+
+// FormatCEF renders the event in ArcSight Common Event Format
+func FormatCEF(ev AuditEvent) string {
+	severity := 3
+	if ev.Code != "OK" {
+		severity = 6
+	}
+	src := ev.Source
+	if host, _, err := net.SplitHostPort(ev.Source); err == nil {
+		src = host
+	}
+	ext := []string{
+		"rt=" + fmt.Sprint(ev.Time.UnixMilli()),
+		"src=" + cefExtensionEscaper.Replace(src),
+		"suser=" + cefExtensionEscaper.Replace(ev.Identity),
+		"outcome=" + cefExtensionEscaper.Replace(ev.Code),
+		"cs1Label=object",
+		"cs1=" + cefExtensionEscaper.Replace(ev.Object),
+	}
+	if len(ev.Diff) > 0 {
+		if diff, err := json.Marshal(ev.Diff); err == nil {
+			ext = append(ext, "cs2Label=diff", "cs2="+cefExtensionEscaper.Replace(string(diff)))
+		}
+	}
+	if ev.Message != "" {
+		ext = append(ext, "msg="+cefExtensionEscaper.Replace(ev.Message))
+	}
+	return fmt.Sprintf("CEF:0|OPI|opi-evpn-bridge|1.0|%s|%s|%d|%s",
+		cefHeaderEscaper.Replace(path.Base(ev.Method)),
+		cefHeaderEscaper.Replace(ev.Method),
+		severity,
+		strings.Join(ext, " "))
+}
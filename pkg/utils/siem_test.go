@@ -0,0 +1,122 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package utils contains utility functions
+package utils
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type siemServer struct {
+	mu       sync.Mutex
+	methods  []string
+	failures atomic.Bool
+}
+
+func (s *siemServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.failures.Load() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	ev := AuditEvent{}
+	if err := json.NewDecoder(r.Body).Decode(&ev); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.methods = append(s.methods, ev.Method)
+}
+
+func (s *siemServer) received() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string{}, s.methods...)
+}
+
+func TestSiemForwarder_Spool(t *testing.T) {
+	server := &siemServer{}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+	spool := filepath.Join(t.TempDir(), "siem.spool")
+	sender := &httpSender{url: ts.URL, client: ts.Client()}
+
+	// unreachable siem, the event ends up in the spool
+	server.failures.Store(true)
+	f := newSiemForwarder(sender, spool, time.Millisecond)
+	f.Forward(AuditEvent{Method: "/Svc/CreateX"})
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if got := server.received(); len(got) != 0 {
+		t.Errorf("received = %v, want none", got)
+	}
+	if data, err := os.ReadFile(spool); err != nil || !strings.Contains(string(data), "/Svc/CreateX") {
+		t.Errorf("spool = %s (%v), want the undelivered event", data, err)
+	}
+
+	// reachable again, the spool is replayed first
+	server.failures.Store(false)
+	f = newSiemForwarder(sender, spool, time.Millisecond)
+	f.Forward(AuditEvent{Method: "/Svc/DeleteX"})
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	want := []string{"/Svc/CreateX", "/Svc/DeleteX"}
+	if got := server.received(); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("received = %v, want %v", got, want)
+	}
+	if _, err := os.Stat(spool); !os.IsNotExist(err) {
+		t.Errorf("spool stat error = %v, want the spool removed", err)
+	}
+}
+
+func TestNewSiemForwarder(t *testing.T) {
+	tests := map[string]struct {
+		target  string
+		wantErr bool
+	}{
+		"cef over udp":   {target: "cef+udp://127.0.0.1:514", wantErr: false},
+		"cef over tcp":   {target: "cef+tcp://127.0.0.1:514", wantErr: false},
+		"https":          {target: "https://siem.example.com/events", wantErr: false},
+		"unknown scheme": {target: "ftp://siem.example.com", wantErr: true},
+	}
+	for testName, tt := range tests {
+		t.Run(testName, func(t *testing.T) {
+			f, err := NewSiemForwarder(tt.target, "")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewSiemForwarder() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if f != nil {
+				_ = f.Close()
+			}
+		})
+	}
+}
+
+func TestFormatCEF(t *testing.T) {
+	ev := AuditEvent{
+		Time:     time.UnixMilli(1700000000000),
+		Identity: "admin",
+		Source:   "10.0.0.1:1234",
+		Method:   "/opi_api.network.evpn_gw.v1alpha1.VrfService/CreateVrf",
+		Object:   "//network.opiproject.org/vrfs/blue",
+		Code:     "InvalidArgument",
+		Message:  "a=b|c",
+	}
+	want := `CEF:0|OPI|opi-evpn-bridge|1.0|CreateVrf|/opi_api.network.evpn_gw.v1alpha1.VrfService/CreateVrf|6|` +
+		`rt=1700000000000 src=10.0.0.1 suser=admin outcome=InvalidArgument cs1Label=object cs1=//network.opiproject.org/vrfs/blue msg=a\=b|c`
+	if got := FormatCEF(ev); got != want {
+		t.Errorf("FormatCEF() = %v, want %v", got, want)
+	}
+}
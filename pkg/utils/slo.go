@@ -0,0 +1,218 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package utils has some utility functions and interfaces
+package utils
+
+import (
+	"context"
+	"errors"
+	"net"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	sloBucketWidth = time.Minute
+	sloRetention   = 24 * time.Hour
+	sloNoError     = "OK"
+	sloOtherError  = "OTHER"
+)
+
+var netlinkOperations = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: "evpn",
+		Subsystem: "netlink",
+		Name:      "operations_total",
+		Help:      "Number of netlink operations by operation, object type and result.",
+	},
+	[]string{"operation", "object", "result"},
+)
+
+// MustRegisterSloMetrics registers the SLO related collectors in the given registry
+func MustRegisterSloMetrics(reg prometheus.Registerer) {
+	reg.MustRegister(netlinkOperations)
+}
+
+// SloEntry is the aggregated outcome of one operation kind on one object type
+type SloEntry struct {
+	Operation      string            `json:"operation"`
+	Object         string            `json:"object"`
+	Total          uint64            `json:"total"`
+	Errors         uint64            `json:"errors"`
+	SuccessRatio   float64           `json:"successRatio"`
+	ErrorBreakdown map[string]uint64 `json:"errorBreakdown,omitempty"`
+}
+
+// SloReport is a summary of netlink operation outcomes over a rolling window
+type SloReport struct {
+	Window  time.Duration `json:"window"`
+	Entries []*SloEntry   `json:"entries"`
+}
+
+type sloKey struct {
+	operation string
+	object    string
+}
+
+type sloBucket struct {
+	start  time.Time
+	counts map[sloKey]map[string]uint64
+}
+
+// SloTracker keeps per-minute buckets of operation outcomes, so success
+// ratios can be computed over arbitrary rolling windows up to the retention
+type SloTracker struct {
+	mu      sync.Mutex
+	buckets []*sloBucket
+	now     func() time.Time
+}
+
+// NewSloTracker creates initialized instance of SloTracker
+func NewSloTracker() *SloTracker {
+	return &SloTracker{now: time.Now}
+}
+
+var defaultSloTracker = NewSloTracker()
+
+// DefaultSloTracker returns the process wide SloTracker used by the wrappers
+func DefaultSloTracker() *SloTracker {
+	return defaultSloTracker
+}
+
+// ErrorClass returns a short, stable classification of an error,
+// typically the errno name (e.g.: EEXIST, ENODEV) returned by the kernel,
+// or TIMEOUT for the expired contexts and network deadlines.
+// A lookup of a missing link is an answer, not a failure: the prechecks
+// probe for absent devices before every create
+func ErrorClass(err error) string {
+	if err == nil {
+		return sloNoError
+	}
+	var notFound netlink.LinkNotFoundError
+	if errors.As(err, &notFound) {
+		return sloNoError
+	}
+	var errno syscall.Errno
+	if errors.As(err, &errno) {
+		if name := unix.ErrnoName(errno); name != "" {
+			return name
+		}
+	}
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		return "TIMEOUT"
+	}
+	// the calls made once the call they belong to ended, see checkContext
+	if code := status.Code(err); code == codes.DeadlineExceeded || code == codes.Canceled {
+		return "TIMEOUT"
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return "TIMEOUT"
+	}
+	return sloOtherError
+}
+
+// ObjectTypeFromContext derives the object type from the gRPC method being served,
+// e.g.: LogicalBridgeService.CreateLogicalBridge yields LogicalBridge
+func ObjectTypeFromContext(ctx context.Context) string {
+	method, ok := grpc.Method(ctx)
+	if !ok {
+		return "unknown"
+	}
+	return objectTypeFromMethod(method)
+}
+
+// objectTypeFromMethod is ObjectTypeFromContext for a full gRPC method name
+func objectTypeFromMethod(method string) string {
+	service := path.Base(path.Dir(method))
+	if i := strings.LastIndex(service, "."); i >= 0 {
+		service = service[i+1:]
+	}
+	return strings.TrimSuffix(service, "Service")
+}
+
+// Record accounts the outcome of a single operation
+func (t *SloTracker) Record(operation, object string, err error) {
+	class := ErrorClass(err)
+	netlinkOperations.WithLabelValues(operation, object, class).Inc()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	start := t.now().Truncate(sloBucketWidth)
+	if len(t.buckets) == 0 || !t.buckets[len(t.buckets)-1].start.Equal(start) {
+		t.buckets = append(t.buckets, &sloBucket{start: start, counts: map[sloKey]map[string]uint64{}})
+		t.expire(start)
+	}
+	bucket := t.buckets[len(t.buckets)-1]
+	key := sloKey{operation: operation, object: object}
+	if bucket.counts[key] == nil {
+		bucket.counts[key] = map[string]uint64{}
+	}
+	bucket.counts[key][class]++
+}
+
+func (t *SloTracker) expire(now time.Time) {
+	i := 0
+	for i < len(t.buckets) && now.Sub(t.buckets[i].start) >= sloRetention {
+		i++
+	}
+	t.buckets = t.buckets[i:]
+}
+
+// Report aggregates the outcomes recorded during the last window
+func (t *SloTracker) Report(window time.Duration) *SloReport {
+	if window <= 0 || window > sloRetention {
+		window = sloRetention
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	since := t.now().Add(-window)
+	entries := map[sloKey]*SloEntry{}
+	for _, bucket := range t.buckets {
+		if bucket.start.Add(sloBucketWidth).Before(since) {
+			continue
+		}
+		for key, classes := range bucket.counts {
+			entry, ok := entries[key]
+			if !ok {
+				entry = &SloEntry{Operation: key.operation, Object: key.object}
+				entries[key] = entry
+			}
+			for class, count := range classes {
+				entry.Total += count
+				if class == sloNoError {
+					continue
+				}
+				entry.Errors += count
+				if entry.ErrorBreakdown == nil {
+					entry.ErrorBreakdown = map[string]uint64{}
+				}
+				entry.ErrorBreakdown[class] += count
+			}
+		}
+	}
+	report := &SloReport{Window: window, Entries: []*SloEntry{}}
+	for _, entry := range entries {
+		entry.SuccessRatio = float64(entry.Total-entry.Errors) / float64(entry.Total)
+		report.Entries = append(report.Entries, entry)
+	}
+	sort.Slice(report.Entries, func(i int, j int) bool {
+		if report.Entries[i].Object != report.Entries[j].Object {
+			return report.Entries[i].Object < report.Entries[j].Object
+		}
+		return report.Entries[i].Operation < report.Entries[j].Operation
+	})
+	return report
+}
@@ -0,0 +1,120 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package utils has some utility functions and interfaces
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"reflect"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/vishvananda/netlink"
+	"google.golang.org/grpc/status"
+)
+
+func TestErrorClass(t *testing.T) {
+	tests := map[string]struct {
+		err  error
+		want string
+	}{
+		"no error": {
+			err:  nil,
+			want: "OK",
+		},
+		"plain errno": {
+			err:  syscall.EEXIST,
+			want: "EEXIST",
+		},
+		"wrapped errno": {
+			err:  fmt.Errorf("link add: %w", syscall.ENODEV),
+			want: "ENODEV",
+		},
+		"missing link": {
+			err:  netlink.LinkNotFoundError{},
+			want: "OK",
+		},
+		"deadline": {
+			err:  context.DeadlineExceeded,
+			want: "TIMEOUT",
+		},
+		"network deadline": {
+			err:  &net.OpError{Op: "dial", Net: "tcp", Err: os.ErrDeadlineExceeded},
+			want: "TIMEOUT",
+		},
+		"cancelled call": {
+			err:  status.FromContextError(context.Canceled).Err(),
+			want: "TIMEOUT",
+		},
+		"unclassified": {
+			err:  errors.New("something went wrong"),
+			want: "OTHER",
+		},
+	}
+	for testName, tt := range tests {
+		t.Run(testName, func(t *testing.T) {
+			if got := ErrorClass(tt.err); got != tt.want {
+				t.Errorf("ErrorClass() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSloTracker_Report(t *testing.T) {
+	now := time.Date(2023, 10, 1, 12, 0, 0, 0, time.UTC)
+	tracker := NewSloTracker()
+	tracker.now = func() time.Time { return now }
+
+	// an hour ago
+	now = now.Add(-time.Hour)
+	tracker.Record("LinkAdd", "LogicalBridge", syscall.EEXIST)
+	// now
+	now = now.Add(time.Hour)
+	tracker.Record("LinkAdd", "LogicalBridge", nil)
+	tracker.Record("LinkAdd", "LogicalBridge", nil)
+	tracker.Record("LinkAdd", "LogicalBridge", syscall.ENODEV)
+	tracker.Record("LinkByName", "Vrf", nil)
+
+	tests := map[string]struct {
+		window time.Duration
+		want   []*SloEntry
+	}{
+		"last five minutes": {
+			window: 5 * time.Minute,
+			want: []*SloEntry{
+				{Operation: "LinkAdd", Object: "LogicalBridge", Total: 3, Errors: 1, SuccessRatio: 2.0 / 3.0, ErrorBreakdown: map[string]uint64{"ENODEV": 1}},
+				{Operation: "LinkByName", Object: "Vrf", Total: 1, Errors: 0, SuccessRatio: 1},
+			},
+		},
+		"last two hours": {
+			window: 2 * time.Hour,
+			want: []*SloEntry{
+				{Operation: "LinkAdd", Object: "LogicalBridge", Total: 4, Errors: 2, SuccessRatio: 0.5, ErrorBreakdown: map[string]uint64{"ENODEV": 1, "EEXIST": 1}},
+				{Operation: "LinkByName", Object: "Vrf", Total: 1, Errors: 0, SuccessRatio: 1},
+			},
+		},
+	}
+	for testName, tt := range tests {
+		t.Run(testName, func(t *testing.T) {
+			report := tracker.Report(tt.window)
+			if report.Window != tt.window {
+				t.Errorf("Report() window = %v, want %v", report.Window, tt.window)
+			}
+			if !reflect.DeepEqual(report.Entries, tt.want) {
+				t.Errorf("Report() entries = %v, want %v", report.Entries, tt.want)
+			}
+		})
+	}
+}
+
+func TestObjectTypeFromContext(t *testing.T) {
+	if got := ObjectTypeFromContext(context.Background()); got != "unknown" {
+		t.Errorf("ObjectTypeFromContext() = %v, want %v", got, "unknown")
+	}
+}
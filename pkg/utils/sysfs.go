@@ -0,0 +1,64 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package utils has some utility functions and interfaces
+package utils
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// sysClassNet is where the kernel exposes the attributes of the network interfaces
+const sysClassNet = "/sys/class/net"
+
+// Sysfs represents limited subset of the network interface attributes of sysfs
+type Sysfs interface {
+	LinkAttr(ctx context.Context, name string, attr string) (string, error)
+}
+
+// SysfsWrapper wrapper for the sysfs attributes of the network interfaces
+type SysfsWrapper struct {
+	tracer trace.Tracer
+}
+
+// NewSysfsWrapper creates initialized instance of SysfsWrapper
+func NewSysfsWrapper() *SysfsWrapper {
+	// default tracer name is good for now
+	return &SysfsWrapper{tracer: otel.Tracer("")}
+}
+
+// build time check that struct implements interface
+var _ Sysfs = (*SysfsWrapper)(nil)
+
+// LinkAttr reads an attribute of a network interface, e.g.: speed or device/sriov_numvfs. The
+// attributes being links, e.g.: device or device/physfn, return the base name of their target,
+// the PCI address of the device
+func (n *SysfsWrapper) LinkAttr(ctx context.Context, name string, attr string) (string, error) {
+	_, childSpan := n.tracer.Start(ctx, "sysfs.LinkAttr")
+	childSpan.SetAttributes(attribute.String("link.name", name), attribute.String("sysfs.attr", attr))
+	defer childSpan.End()
+	file := filepath.Join(sysClassNet, name, attr)
+	info, err := os.Lstat(file)
+	if err != nil {
+		return "", err
+	}
+	if info.Mode()&os.ModeSymlink != 0 {
+		target, err := os.Readlink(file)
+		if err != nil {
+			return "", err
+		}
+		return filepath.Base(target), nil
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
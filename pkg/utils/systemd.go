@@ -0,0 +1,152 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package utils contails useful helper functions
+package utils
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// sdListenFdsStart is the first file descriptor systemd passes with socket activation
+const sdListenFdsStart = 3
+
+// SdNotify sends a state to systemd, e.g. READY=1 or WATCHDOG=1, when it started the server as
+// a Type=notify service, and reports whether it was sent, not being when NOTIFY_SOCKET is unset
+func SdNotify(state string) (bool, error) {
+	socket := os.Getenv("NOTIFY_SOCKET")
+	if socket == "" {
+		return false, nil
+	}
+	// a leading @ is an abstract socket
+	if socket[0] == '@' {
+		socket = "\x00" + socket[1:]
+	}
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte(state)); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// SdWatchdogInterval returns the WatchdogSec of the service, systemd restarting it when it does
+// not send WATCHDOG=1 in time, 0 when the watchdog is disabled or meant for another process
+func SdWatchdogInterval() (time.Duration, error) {
+	value := os.Getenv("WATCHDOG_USEC")
+	if value == "" {
+		return 0, nil
+	}
+	if pid := os.Getenv("WATCHDOG_PID"); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return 0, nil
+	}
+	usec, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || usec <= 0 {
+		return 0, fmt.Errorf("invalid WATCHDOG_USEC %q", value)
+	}
+	return time.Duration(usec) * time.Microsecond, nil
+}
+
+// RunSdWatchdog sends WATCHDOG=1 at half the interval as long as check succeeds, so systemd
+// restarts the server when check fails or hangs until the interval elapses, e.g. when a
+// netlink call is stuck. A check still running is not started again
+func RunSdWatchdog(ctx context.Context, interval time.Duration, check func(context.Context) error) {
+	ticker := time.NewTicker(interval / 2)
+	defer ticker.Stop()
+	done := make(chan error, 1)
+	running := false
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if running {
+				log.Printf("Watchdog check still running, not notifying systemd")
+				continue
+			}
+			running = true
+			go func() {
+				checkCtx, cancel := context.WithTimeout(ctx, interval/2)
+				defer cancel()
+				done <- check(checkCtx)
+			}()
+		case err := <-done:
+			running = false
+			if err != nil {
+				log.Printf("Watchdog check failed, not notifying systemd: %v", err)
+				continue
+			}
+			if _, err := SdNotify("WATCHDOG=1"); err != nil {
+				log.Printf("Failed to notify the systemd watchdog: %v", err)
+			}
+		}
+	}
+}
+
+// SdListeners returns the sockets systemd passed with socket activation by their
+// FileDescriptorName, those without one being named by their position in names, e.g. the
+// first one is grpc and the second one http. It returns nil when the server was not socket
+// activated, and unsets the LISTEN_ variables so the child processes do not use the sockets
+func SdListeners(names ...string) (map[string]net.Listener, error) {
+	defer func() {
+		_ = os.Unsetenv("LISTEN_PID")
+		_ = os.Unsetenv("LISTEN_FDS")
+		_ = os.Unsetenv("LISTEN_FDNAMES")
+	}()
+	if os.Getenv("LISTEN_PID") != strconv.Itoa(os.Getpid()) {
+		return nil, nil
+	}
+	count, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || count <= 0 {
+		return nil, fmt.Errorf("invalid LISTEN_FDS %q", os.Getenv("LISTEN_FDS"))
+	}
+	listeners := make(map[string]net.Listener, count)
+	for i, name := range sdListenerNames(os.Getenv("LISTEN_FDNAMES"), count, names) {
+		fd := sdListenFdsStart + i
+		syscall.CloseOnExec(fd)
+		file := os.NewFile(uintptr(fd), name)
+		listener, err := net.FileListener(file)
+		// the listener has its own copy of the descriptor
+		_ = file.Close()
+		if err != nil {
+			return nil, fmt.Errorf("socket %s is not a listening stream socket: %v", name, err)
+		}
+		if _, ok := listeners[name]; ok {
+			return nil, fmt.Errorf("duplicate socket %s", name)
+		}
+		listeners[name] = listener
+	}
+	return listeners, nil
+}
+
+// sdListenerNames names the count sockets from LISTEN_FDNAMES, the unnamed ones, of systemd
+// versions not setting it or sockets without a FileDescriptorName, by their position in names
+func sdListenerNames(fdnames string, count int, names []string) []string {
+	var given []string
+	if fdnames != "" {
+		given = strings.Split(fdnames, ":")
+	}
+	result := make([]string, count)
+	for i := range result {
+		switch {
+		case i < len(given) && given[i] != "" && given[i] != "unknown":
+			result[i] = given[i]
+		case i < len(names):
+			result[i] = names[i]
+		default:
+			result[i] = strconv.Itoa(sdListenFdsStart + i)
+		}
+	}
+	return result
+}
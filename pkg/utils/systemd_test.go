@@ -0,0 +1,166 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package utils contails useful helper functions
+package utils
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// listenNotifySocket listens for the states sent to systemd on a temporary socket
+func listenNotifySocket(t *testing.T) *net.UnixConn {
+	path := filepath.Join(t.TempDir(), "notify")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	t.Setenv("NOTIFY_SOCKET", path)
+	return conn
+}
+
+func readState(t *testing.T, conn *net.UnixConn, timeout time.Duration) (string, error) {
+	buf := make([]byte, 256)
+	if err := conn.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+		t.Fatal(err)
+	}
+	n, err := conn.Read(buf)
+	return string(buf[:n]), err
+}
+
+func TestSdNotify(t *testing.T) {
+	t.Setenv("NOTIFY_SOCKET", "")
+	if sent, err := SdNotify("READY=1"); sent || err != nil {
+		t.Error("sent: expected", false, "received", sent, err)
+	}
+
+	conn := listenNotifySocket(t)
+	if sent, err := SdNotify("READY=1"); !sent || err != nil {
+		t.Fatal("sent: expected", true, "received", sent, err)
+	}
+	if state, err := readState(t, conn, time.Second); state != "READY=1" {
+		t.Error("state: expected", "READY=1", "received", state, err)
+	}
+}
+
+func TestSdWatchdogInterval(t *testing.T) {
+	tests := map[string]struct {
+		usec     string
+		pid      string
+		interval time.Duration
+		valid    bool
+	}{
+		"disabled": {
+			valid: true,
+		},
+		"enabled": {
+			usec:     "30000000",
+			interval: 30 * time.Second,
+			valid:    true,
+		},
+		"enabled for this process": {
+			usec:     "2000000",
+			pid:      strconv.Itoa(os.Getpid()),
+			interval: 2 * time.Second,
+			valid:    true,
+		},
+		"enabled for another process": {
+			usec:  "2000000",
+			pid:   "1",
+			valid: true,
+		},
+		"invalid interval": {
+			usec: "30s",
+		},
+	}
+	for testName, tt := range tests {
+		t.Run(testName, func(t *testing.T) {
+			t.Setenv("WATCHDOG_USEC", tt.usec)
+			t.Setenv("WATCHDOG_PID", tt.pid)
+			interval, err := SdWatchdogInterval()
+			if (err == nil) != tt.valid {
+				t.Error("valid: expected", tt.valid, "received", err)
+			}
+			if interval != tt.interval {
+				t.Error("interval: expected", tt.interval, "received", interval)
+			}
+		})
+	}
+}
+
+func TestRunSdWatchdog(t *testing.T) {
+	conn := listenNotifySocket(t)
+	var healthy atomic.Bool
+	healthy.Store(true)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go RunSdWatchdog(ctx, 100*time.Millisecond, func(context.Context) error {
+		if !healthy.Load() {
+			return errors.New("stuck")
+		}
+		return nil
+	})
+
+	if state, err := readState(t, conn, time.Second); state != "WATCHDOG=1" {
+		t.Fatal("state: expected", "WATCHDOG=1", "received", state, err)
+	}
+	healthy.Store(false)
+	// drain a ping of a check started before the failure
+	_, _ = readState(t, conn, 100*time.Millisecond)
+	if state, err := readState(t, conn, 300*time.Millisecond); err == nil {
+		t.Error("state: expected none, received", state)
+	}
+}
+
+func TestSdListenerNames(t *testing.T) {
+	tests := map[string]struct {
+		fdnames string
+		count   int
+		out     []string
+	}{
+		"named sockets": {
+			fdnames: "http:grpc",
+			count:   2,
+			out:     []string{"http", "grpc"},
+		},
+		"unnamed sockets": {
+			count: 3,
+			out:   []string{"grpc", "http", "5"},
+		},
+		"default names": {
+			fdnames: "unknown:http",
+			count:   2,
+			out:     []string{"grpc", "http"},
+		},
+	}
+	for testName, tt := range tests {
+		t.Run(testName, func(t *testing.T) {
+			names := sdListenerNames(tt.fdnames, tt.count, []string{"grpc", "http"})
+			if !reflect.DeepEqual(names, tt.out) {
+				t.Error("names: expected", tt.out, "received", names)
+			}
+		})
+	}
+}
+
+func TestSdListenersNotActivated(t *testing.T) {
+	t.Setenv("LISTEN_PID", "1")
+	t.Setenv("LISTEN_FDS", "2")
+	listeners, err := SdListeners("grpc", "http")
+	if listeners != nil || err != nil {
+		t.Error("listeners: expected", nil, "received", listeners, err)
+	}
+	if _, ok := os.LookupEnv("LISTEN_FDS"); ok {
+		t.Error("environment: expected LISTEN_FDS unset")
+	}
+}
@@ -0,0 +1,88 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package utils contains utility functions
+package utils
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	middleware "github.com/grpc-ecosystem/go-grpc-middleware/v2"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Tenancy scopes the calls to the tenant of their client, the common name of its client
+// certificate, instead of trusting the TenantMetadataKey metadata sent by the client
+type Tenancy struct {
+	enabled bool
+	admins  map[string]bool
+}
+
+// NewTenancy creates initialized instance of Tenancy, admins are the comma separated common
+// names of the clients not scoped to a tenant, the calls are never scoped when not enabled
+func NewTenancy(enabled bool, admins string) *Tenancy {
+	t := &Tenancy{enabled: enabled, admins: make(map[string]bool)}
+	for _, admin := range strings.Split(admins, ",") {
+		if admin = strings.TrimSpace(admin); admin != "" {
+			t.admins[admin] = true
+		}
+	}
+	return t
+}
+
+// Scope replaces the tenant the client claims in ctx by the one of its identity, the calls
+// without an identity fail with Unauthenticated when tenancy is enabled
+func (t *Tenancy) Scope(ctx context.Context, identity string) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	md = md.Copy()
+	md.Delete(TenantMetadataKey)
+	if t.enabled {
+		if identity == "" {
+			return nil, status.Error(codes.Unauthenticated, "tenancy is enabled, the call needs a client certificate")
+		}
+		if !t.admins[identity] {
+			md.Set(TenantMetadataKey, identity)
+		}
+	}
+	return metadata.NewIncomingContext(ctx, md), nil
+}
+
+// UnaryServerInterceptor scopes the calls to the tenant of the client certificate
+func (t *Tenancy) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		identity, _ := callerFromContext(ctx)
+		ctx, err := t.Scope(ctx, identity)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor scopes the streams to the tenant of the client certificate
+func (t *Tenancy) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		identity, _ := callerFromContext(ss.Context())
+		ctx, err := t.Scope(ss.Context(), identity)
+		if err != nil {
+			return err
+		}
+		wrapped := middleware.WrapServerStream(ss)
+		wrapped.WrappedContext = ctx
+		return handler(srv, wrapped)
+	}
+}
+
+// HTTPIdentity returns the common name of the client certificate of r, empty without one
+func HTTPIdentity(r *http.Request) string {
+	if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+		return ""
+	}
+	return r.TLS.PeerCertificates[0].Subject.CommonName
+}
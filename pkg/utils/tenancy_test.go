@@ -0,0 +1,84 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package utils contains utility functions
+package utils
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+func TestTenancy_UnaryServerInterceptor(t *testing.T) {
+	tests := map[string]struct {
+		enabled    bool
+		identity   string
+		claimed    string
+		wantTenant string
+		wantCode   codes.Code
+	}{
+		"disabled drops the claimed tenant": {
+			enabled:    false,
+			identity:   "acme",
+			claimed:    "globex",
+			wantTenant: "",
+			wantCode:   codes.OK,
+		},
+		"tenant of the client certificate": {
+			enabled:    true,
+			identity:   "acme",
+			claimed:    "globex",
+			wantTenant: "acme",
+			wantCode:   codes.OK,
+		},
+		"admin is not scoped": {
+			enabled:    true,
+			identity:   "operator",
+			claimed:    "globex",
+			wantTenant: "",
+			wantCode:   codes.OK,
+		},
+		"no client certificate": {
+			enabled:    true,
+			identity:   "",
+			claimed:    "globex",
+			wantTenant: "",
+			wantCode:   codes.Unauthenticated,
+		},
+	}
+	for testName, tt := range tests {
+		t.Run(testName, func(t *testing.T) {
+			tenancy := NewTenancy(tt.enabled, "operator, admin")
+			tenant := ""
+			handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+				tenant = TenantFromContext(ctx)
+				return nil, nil
+			}
+			p := &peer.Peer{Addr: &net.TCPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 1234}}
+			if tt.identity != "" {
+				cert := &x509.Certificate{Subject: pkix.Name{CommonName: tt.identity}}
+				p.AuthInfo = credentials.TLSInfo{State: tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}}
+			}
+			ctx := peer.NewContext(context.Background(), p)
+			ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(TenantMetadataKey, tt.claimed))
+			_, err := tenancy.UnaryServerInterceptor()(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/opi_api.network.evpn_gw.v1alpha1.VrfService/ListVrfs"}, handler)
+			if status.Code(err) != tt.wantCode {
+				t.Errorf("interceptor() error = %v, want %v", err, tt.wantCode)
+			}
+			if tenant != tt.wantTenant {
+				t.Errorf("TenantFromContext() = %v, want %v", tenant, tt.wantTenant)
+			}
+		})
+	}
+}
@@ -61,10 +61,27 @@ func SetupTLSCredentials(config TLSConfig) (grpc.ServerOption, error) {
 	return setupTLSCredentials(config, tls.LoadX509KeyPair, os.ReadFile)
 }
 
+// ServerTLSConfig returns the TLS config of the servers, the clients needing a certificate
+// signed by the CA, e.g. for the HTTP gateway
+func ServerTLSConfig(config TLSConfig) (*tls.Config, error) {
+	return serverTLSConfig(config, tls.LoadX509KeyPair, os.ReadFile)
+}
+
 func setupTLSCredentials(config TLSConfig,
 	loadX509KeyPair func(string, string) (tls.Certificate, error),
 	readFile func(string) ([]byte, error),
 ) (grpc.ServerOption, error) {
+	c, err := serverTLSConfig(config, loadX509KeyPair, readFile)
+	if err != nil {
+		return nil, err
+	}
+	return grpc.Creds(credentials.NewTLS(c)), nil
+}
+
+func serverTLSConfig(config TLSConfig,
+	loadX509KeyPair func(string, string) (tls.Certificate, error),
+	readFile func(string) ([]byte, error),
+) (*tls.Config, error) {
 	serverCert, err := loadX509KeyPair(config.ServerCertPath, config.ServerKeyPath)
 	if err != nil {
 		return nil, err
@@ -97,5 +114,5 @@ func setupTLSCredentials(config TLSConfig,
 		return nil, fmt.Errorf("failed to add client CA's certificate: %v", config.CaCertPath)
 	}
 
-	return grpc.Creds(credentials.NewTLS(c)), nil
+	return c, nil
 }
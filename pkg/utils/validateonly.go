@@ -0,0 +1,217 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package utils has some utility functions and interfaces
+package utils
+
+import (
+	"context"
+	"strconv"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// ValidateOnlyMetadataKey is the grpc metadata key requesting a dry-run of a
+// mutating call (see https://google.aip.dev/163), the generated request
+// messages do not carry a validate_only field yet. Over HTTP it is sent as
+// the Grpc-Metadata-X-Opi-Validate-Only header
+// TODO: replace by validate_only request fields once they are added to opi-api
+const ValidateOnlyMetadataKey = "x-opi-validate-only"
+
+// CascadeMetadataKey is the grpc metadata key asking a Delete call to also
+// delete the objects still referencing the deleted one instead of failing.
+// Over HTTP it is sent as the Grpc-Metadata-X-Opi-Cascade header
+// TODO: replace by force request fields once they are added to opi-api
+const CascadeMetadataKey = "x-opi-cascade"
+
+// AsyncMetadataKey is the grpc metadata key asking a slow Create call to return as soon as the
+// request is validated and to program the object in the background (see https://google.aip.dev/151),
+// the generated services do not return google.longrunning.Operation yet. Over HTTP it is sent as
+// the Grpc-Metadata-X-Opi-Async header
+// TODO: replace by long-running methods once they are added to opi-api
+const AsyncMetadataKey = "x-opi-async"
+
+// TenantMetadataKey is the grpc metadata key scoping a call to a tenant: the objects it creates
+// are named //network.opiproject.org/tenants/{tenant}/{collection}/{id} and List only returns the
+// objects of the tenant. It is set by Tenancy from the client certificate, the value sent by the
+// clients is dropped
+const TenantMetadataKey = "x-opi-tenant"
+
+// LabelsMetadataKey is the grpc metadata key setting the labels of the object of a Create or
+// Update call, in key=value,key=value format, an Update without it keeps the labels. Over HTTP it is
+// sent as the Grpc-Metadata-X-Opi-Labels header
+// TODO: replace by labels fields once they are added to the opi-api messages
+const LabelsMetadataKey = "x-opi-labels"
+
+// AnnotationsMetadataKey is the grpc metadata key setting the annotations of the object of a
+// Create or Update call, in the format of LabelsMetadataKey but with free-form values
+// TODO: replace by annotations fields once they are added to the opi-api messages
+const AnnotationsMetadataKey = "x-opi-annotations"
+
+// LabelSelectorMetadataKey is the grpc metadata key restricting a List call to the objects whose
+// labels match the selector, e.g.: app=web,tier!=db. Over HTTP it is sent as the
+// Grpc-Metadata-X-Opi-Label-Selector header
+// TODO: replace by filter request fields once they are added to opi-api
+const LabelSelectorMetadataKey = "x-opi-label-selector"
+
+// MulticastGroupMetadataKey is the grpc metadata key making a new LogicalBridge flood its BUM
+// traffic to an IPv4 multicast group of the underlay instead of using BGP-EVPN ingress
+// replication. Over HTTP it is sent as the Grpc-Metadata-X-Opi-Multicast-Group header
+// TODO: replace by a LogicalBridgeSpec field once it is added to opi-api
+const MulticastGroupMetadataKey = "x-opi-multicast-group"
+
+// AnycastGatewayMetadataKey is the grpc metadata key making a new Svi a distributed anycast
+// gateway: its mac_address and gw_ip_prefix are the virtual MAC and gateway IP shared by every
+// VTEP of the fabric, advertised as the default gateway of the LogicalBridge. Over HTTP it is
+// sent as the Grpc-Metadata-X-Opi-Anycast-Gateway header
+// TODO: replace by a SviSpec field once it is added to opi-api
+const AnycastGatewayMetadataKey = "x-opi-anycast-gateway"
+
+// GratuitousArpMetadataKey is the grpc metadata key setting how a new or updated Svi announces
+// its gateway addresses, with gratuitous ARPs and unsolicited neighbor advertisements, in
+// count=N,interval=DURATION format (e.g.: count=5,interval=500ms), count=0 turning them off.
+// Over HTTP it is sent as the Grpc-Metadata-X-Opi-Gratuitous-Arp header
+// TODO: replace by SviSpec fields once they are added to opi-api
+const GratuitousArpMetadataKey = "x-opi-gratuitous-arp"
+
+// MacLearningMetadataKey is the grpc metadata key turning the MAC learning of a new BridgePort
+// off with false, e.g. for ports whose hosts are known from EVPN or static entries only. Over
+// HTTP it is sent as the Grpc-Metadata-X-Opi-Mac-Learning header
+// TODO: replace by a BridgePortSpec field once it is added to opi-api
+const MacLearningMetadataKey = "x-opi-mac-learning"
+
+// NativeVlanMetadataKey is the grpc metadata key making the vlan of one of the LogicalBridges of
+// a new TRUNK BridgePort untagged, for the hosts sending untagged frames on the trunk. Over HTTP
+// it is sent as the Grpc-Metadata-X-Opi-Native-Vlan header
+// TODO: replace by a BridgePortSpec field once it is added to opi-api
+const NativeVlanMetadataKey = "x-opi-native-vlan"
+
+// VlanTranslationMetadataKey is the grpc metadata key mapping the external vlans of a new TRUNK
+// BridgePort to the vlans of its LogicalBridges, as a comma separated list of external=internal
+// pairs (e.g.: 100=10,200=20). Over HTTP it is sent as the Grpc-Metadata-X-Opi-Vlan-Translation header
+// TODO: replace by a BridgePortSpec field once it is added to opi-api
+const VlanTranslationMetadataKey = "x-opi-vlan-translation"
+
+// ServiceVlanMetadataKey is the grpc metadata key making a new TRUNK BridgePort service-tagged,
+// the port carries the 802.1ad outer tag given and the vlans of its LogicalBridges are the inner
+// customer tags. Over HTTP it is sent as the Grpc-Metadata-X-Opi-Service-Vlan header
+// TODO: replace by a BridgePortSpec field once it is added to opi-api
+const ServiceVlanMetadataKey = "x-opi-service-vlan"
+
+// PciAddressMetadataKey is the grpc metadata key creating a new BridgePort on the interface of a
+// PCI address (e.g.: 0000:03:00.2), the representor of a SR-IOV virtual function or a port,
+// instead of the interface named after the BridgePort. Over HTTP it is sent as the
+// Grpc-Metadata-X-Opi-Pci-Address header
+// TODO: replace by a BridgePortSpec field once it is added to opi-api
+const PciAddressMetadataKey = "x-opi-pci-address"
+
+// VfIndexMetadataKey is the grpc metadata key creating a new BridgePort on the representor of a
+// SR-IOV virtual function, given by index as vf or pf/vf (e.g.: 3 for the vf 3 of the pf 0, 1/3
+// for the vf 3 of the pf 1). Over HTTP it is sent as the Grpc-Metadata-X-Opi-Vf-Index header
+// TODO: replace by a BridgePortSpec field once it is added to opi-api
+const VfIndexMetadataKey = "x-opi-vf-index"
+
+// MacsecMetadataKey is the grpc metadata key securing a new BridgePort with MACsec towards the
+// single peer of its link, with static keys in key=HEX,peer=MAC,peer-key=HEX,encrypt=BOOL format,
+// only encrypt is optional and true by default. Over HTTP it is sent as the
+// Grpc-Metadata-X-Opi-Macsec header
+// TODO: replace by BridgePortSpec fields once they are added to opi-api
+const MacsecMetadataKey = "x-opi-macsec"
+
+// TunnelTypeMetadataKey is the grpc metadata key choosing the tunnels of a new LogicalBridge with
+// a vni: vxlan, the default, advertised with BGP-EVPN, or geneve to a single remote TEP, for the
+// fabrics whose controller does not speak EVPN. Over HTTP it is sent as the
+// Grpc-Metadata-X-Opi-Tunnel-Type header
+// TODO: replace by a LogicalBridgeSpec field once it is added to opi-api
+const TunnelTypeMetadataKey = "x-opi-tunnel-type"
+
+// TunnelOptionsMetadataKey is the grpc metadata key setting the options of the geneve tunnel of a
+// new LogicalBridge, in remote=A.B.C.D,port=N,ttl=N format, only the remote TEP is required
+// TODO: replace by a LogicalBridgeSpec field once it is added to opi-api
+const TunnelOptionsMetadataKey = "x-opi-tunnel-options"
+
+// RouteDistinguisherMetadataKey is the grpc metadata key setting the route distinguisher of the
+// EVPN routes of a new Vrf, in ASN:NN or A.B.C.D:NN format, instead of the one FRR auto-derives
+// from its router-id. Over HTTP it is sent as the Grpc-Metadata-X-Opi-Route-Distinguisher header
+// TODO: replace by a VrfSpec field once it is added to opi-api
+const RouteDistinguisherMetadataKey = "x-opi-route-distinguisher"
+
+// ImportRouteTargetsMetadataKey is the grpc metadata key setting the comma separated route
+// targets a new Vrf imports, instead of the ASN:VNI one FRR auto-derives
+// TODO: replace by a VrfSpec field once it is added to opi-api
+const ImportRouteTargetsMetadataKey = "x-opi-import-route-targets"
+
+// ExportRouteTargetsMetadataKey is the grpc metadata key setting the comma separated route
+// targets a new Vrf exports, in the format of ImportRouteTargetsMetadataKey
+// TODO: replace by a VrfSpec field once it is added to opi-api
+const ExportRouteTargetsMetadataKey = "x-opi-export-route-targets"
+
+// IrbModeMetadataKey is the grpc metadata key choosing how a new Vrf routes between its Svis
+// across VTEPs: symmetric, the default of the Vrfs with a vni, routes over the L3 vni of the Vrf
+// and asymmetric, for the Vrfs without a vni, routes over the vnis of the LogicalBridges of the
+// Svis. Over HTTP it is sent as the Grpc-Metadata-X-Opi-Irb-Mode header
+// TODO: replace by a VrfSpec field once it is added to opi-api
+const IrbModeMetadataKey = "x-opi-irb-mode"
+
+// OverlayMetadataKey is the grpc metadata key choosing the overlay carrying the routes of a new
+// Vrf between the gateways: vxlan, the default, over the L3 vni of the Vrf, or srv6 over an
+// End.DT46 SID of the locator of the gateway. Over HTTP it is sent as the
+// Grpc-Metadata-X-Opi-Overlay header
+// TODO: replace by a VrfSpec field once it is added to opi-api
+const OverlayMetadataKey = "x-opi-overlay"
+
+// IfMatchMetadataKey is the grpc metadata key sending the etag an Update or Delete call of a
+// Vrf, LogicalBridge, BridgePort or Svi expects the object to still have, the call failing with
+// FailedPrecondition when the object changed since it was read. Over HTTP it is sent as the
+// Grpc-Metadata-X-Opi-If-Match header
+// TODO: replace by the etag field of the messages once it is added to opi-api
+const IfMatchMetadataKey = "x-opi-if-match"
+
+// OperationMetadataKey is the grpc response header carrying the name of the operation
+// programming the object of an asynchronous call, to be polled with the Operations service
+const OperationMetadataKey = "x-opi-operation"
+
+// IsValidateOnly reports whether the incoming call only asks for validation
+func IsValidateOnly(ctx context.Context) bool {
+	return metadataFlag(ctx, ValidateOnlyMetadataKey)
+}
+
+// IsCascade reports whether the incoming Delete call asks to delete the dependents too
+func IsCascade(ctx context.Context) bool {
+	return metadataFlag(ctx, CascadeMetadataKey)
+}
+
+// IsAsync reports whether the incoming Create call asks to program the object in the background
+func IsAsync(ctx context.Context) bool {
+	return metadataFlag(ctx, AsyncMetadataKey)
+}
+
+// TenantFromContext returns the tenant the incoming call is scoped to, empty when it is not
+func TenantFromContext(ctx context.Context) string {
+	tenant, _ := MetadataValue(ctx, TenantMetadataKey)
+	return tenant
+}
+
+// MetadataValue returns the first value of the metadata key of the incoming call,
+// and whether the call carries the key
+func MetadataValue(ctx context.Context, key string) (string, bool) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", false
+	}
+	values := md.Get(key)
+	if len(values) == 0 {
+		return "", false
+	}
+	return values[0], true
+}
+
+// metadataFlag reports whether the boolean metadata key of the incoming call is set
+func metadataFlag(ctx context.Context, key string) bool {
+	value, ok := MetadataValue(ctx, key)
+	if !ok {
+		return false
+	}
+	flag, err := strconv.ParseBool(value)
+	return err == nil && flag
+}
@@ -0,0 +1,194 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package utils has some utility functions and interfaces
+package utils
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// WatchEventType is the kind of change a WatchEvent reports
+type WatchEventType string
+
+const (
+	// WatchAdded reports a created object
+	WatchAdded WatchEventType = "ADDED"
+	// WatchModified reports an updated object
+	WatchModified WatchEventType = "MODIFIED"
+	// WatchDeleted reports a deleted object
+	WatchDeleted WatchEventType = "DELETED"
+	// WatchResync reports that events were dropped and the client has to list again
+	WatchResync WatchEventType = "RESYNC"
+)
+
+// ErrWatcherEvicted is returned to a watcher that was disconnected for being stuck
+var ErrWatcherEvicted = errors.New("watcher evicted for not consuming events")
+
+var (
+	watchClients = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: "evpn",
+			Subsystem: "watch",
+			Name:      "clients",
+			Help:      "Number of connected watch clients.",
+		},
+	)
+	watchDropped = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: "evpn",
+			Subsystem: "watch",
+			Name:      "dropped_events_total",
+			Help:      "Number of events dropped because a watch client buffer was full.",
+		},
+	)
+	watchResyncs = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: "evpn",
+			Subsystem: "watch",
+			Name:      "resyncs_total",
+			Help:      "Number of resyncs forced on slow watch clients.",
+		},
+	)
+	watchEvictions = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: "evpn",
+			Subsystem: "watch",
+			Name:      "evictions_total",
+			Help:      "Number of stuck watch clients disconnected.",
+		},
+	)
+)
+
+// MustRegisterWatchMetrics registers the watch related collectors in the given registry
+func MustRegisterWatchMetrics(reg prometheus.Registerer) {
+	reg.MustRegister(watchClients, watchDropped, watchResyncs, watchEvictions)
+}
+
+// WatchEvent is a change notification of a single object
+type WatchEvent struct {
+	Type WatchEventType `json:"type"`
+	Name string         `json:"name"`
+	// State is the lifecycle state the object went to, e.g. DELETING, when it is not ACTIVE
+	State string `json:"state,omitempty"`
+}
+
+// Watcher receives the events of a WatchBroker through a bounded buffer
+type Watcher struct {
+	events chan WatchEvent
+	// overflowSince is when the buffer first overflowed without the watcher taking
+	// an event since, in unix nanoseconds, zero while it keeps up
+	overflowSince atomic.Int64
+	evicted       chan struct{}
+}
+
+// Next blocks until the next event, ctx is done or the watcher is evicted
+func (w *Watcher) Next(ctx context.Context) (WatchEvent, error) {
+	// pending events of an evicted watcher are meaningless
+	select {
+	case <-w.evicted:
+		return WatchEvent{}, ErrWatcherEvicted
+	default:
+	}
+	select {
+	case ev := <-w.events:
+		w.overflowSince.Store(0)
+		return ev, nil
+	case <-w.evicted:
+		return WatchEvent{}, ErrWatcherEvicted
+	case <-ctx.Done():
+		return WatchEvent{}, ctx.Err()
+	}
+}
+
+// WatchBroker fans events out to watchers. A watcher whose buffer is full has
+// its pending events replaced by a single RESYNC, and a watcher that overflows
+// again after not taking any event for longer than the stale timeout since its
+// first overflow is evicted, so one stuck client cannot make the daemon memory
+// grow unbounded while an idle one survives a burst
+type WatchBroker struct {
+	mu           sync.Mutex
+	watchers     map[*Watcher]struct{}
+	bufferSize   int
+	staleTimeout time.Duration
+	now          func() time.Time
+}
+
+// NewWatchBroker creates initialized instance of WatchBroker
+func NewWatchBroker(bufferSize int, staleTimeout time.Duration) *WatchBroker {
+	// room for at least the RESYNC event
+	if bufferSize < 1 {
+		bufferSize = 1
+	}
+	return &WatchBroker{
+		watchers:     make(map[*Watcher]struct{}),
+		bufferSize:   bufferSize,
+		staleTimeout: staleTimeout,
+		now:          time.Now,
+	}
+}
+
+// Subscribe registers a new watcher
+func (b *WatchBroker) Subscribe() *Watcher {
+	w := &Watcher{events: make(chan WatchEvent, b.bufferSize), evicted: make(chan struct{})}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.watchers[w] = struct{}{}
+	watchClients.Inc()
+	return w
+}
+
+// Unsubscribe removes a watcher, it is safe to call for an evicted watcher
+func (b *WatchBroker) Unsubscribe(w *Watcher) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.remove(w)
+}
+
+func (b *WatchBroker) remove(w *Watcher) {
+	if _, ok := b.watchers[w]; ok {
+		delete(b.watchers, w)
+		watchClients.Dec()
+	}
+}
+
+// Publish delivers the event to every watcher without ever blocking
+func (b *WatchBroker) Publish(ev WatchEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for w := range b.watchers {
+		select {
+		case w.events <- ev:
+			continue
+		default:
+		}
+		since := w.overflowSince.Load()
+		if since == 0 {
+			w.overflowSince.Store(b.now().UnixNano())
+		} else if b.now().Sub(time.Unix(0, since)) > b.staleTimeout {
+			b.remove(w)
+			close(w.evicted)
+			watchDropped.Add(float64(len(w.events) + 1))
+			watchEvictions.Inc()
+			continue
+		}
+		// drop whatever is pending, the client has to list again anyway
+		dropped := 1
+		for len(w.events) > 0 {
+			select {
+			case <-w.events:
+				dropped++
+			default:
+			}
+		}
+		watchDropped.Add(float64(dropped))
+		w.events <- WatchEvent{Type: WatchResync}
+		watchResyncs.Inc()
+	}
+}
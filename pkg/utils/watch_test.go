@@ -0,0 +1,111 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2022-2023 Dell Inc, or its subsidiaries.
+
+// Package utils has some utility functions and interfaces
+package utils
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestWatchBroker_Publish(t *testing.T) {
+	added := WatchEvent{Type: WatchAdded, Name: "//network.opiproject.org/vrfs/blue"}
+	deleted := WatchEvent{Type: WatchDeleted, Name: "//network.opiproject.org/vrfs/blue"}
+	resync := WatchEvent{Type: WatchResync}
+	tests := map[string]struct {
+		publish []WatchEvent
+		want    []WatchEvent
+	}{
+		"events fit in buffer": {
+			publish: []WatchEvent{added, deleted},
+			want:    []WatchEvent{added, deleted},
+		},
+		"overflow replaces pending events with resync": {
+			publish: []WatchEvent{added, deleted, added},
+			want:    []WatchEvent{resync},
+		},
+		"events after resync are delivered": {
+			publish: []WatchEvent{added, deleted, added, deleted},
+			want:    []WatchEvent{resync, deleted},
+		},
+	}
+	for testName, tt := range tests {
+		t.Run(testName, func(t *testing.T) {
+			broker := NewWatchBroker(2, time.Minute)
+			w := broker.Subscribe()
+			defer broker.Unsubscribe(w)
+			for _, ev := range tt.publish {
+				broker.Publish(ev)
+			}
+			var got []WatchEvent
+			for len(w.events) > 0 {
+				ev, err := w.Next(context.Background())
+				if err != nil {
+					t.Fatalf("Next() unexpected error = %v", err)
+				}
+				got = append(got, ev)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Next() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWatchBroker_EvictStale(t *testing.T) {
+	now := time.Date(2023, 10, 1, 12, 0, 0, 0, time.UTC)
+	broker := NewWatchBroker(1, time.Minute)
+	broker.now = func() time.Time { return now }
+	stuck := broker.Subscribe()
+	healthy := broker.Subscribe()
+
+	broker.Publish(WatchEvent{Type: WatchAdded, Name: "a"})
+	if _, err := healthy.Next(context.Background()); err != nil {
+		t.Fatalf("Next() unexpected error = %v", err)
+	}
+	// the first overflow only forces a resync
+	broker.Publish(WatchEvent{Type: WatchAdded, Name: "b"})
+	if _, err := healthy.Next(context.Background()); err != nil {
+		t.Fatalf("Next() unexpected error = %v", err)
+	}
+	// stuck client did not take any event for longer than the stale timeout
+	now = now.Add(2 * time.Minute)
+	broker.Publish(WatchEvent{Type: WatchAdded, Name: "c"})
+
+	if _, err := stuck.Next(context.Background()); !errors.Is(err, ErrWatcherEvicted) {
+		t.Errorf("Next() error = %v, want %v", err, ErrWatcherEvicted)
+	}
+	if ev, err := healthy.Next(context.Background()); err != nil || ev.Name != "c" {
+		t.Errorf("Next() = %v, %v, want c", ev, err)
+	}
+	if len(broker.watchers) != 1 {
+		t.Errorf("watchers = %d, want 1", len(broker.watchers))
+	}
+}
+
+func TestWatchBroker_IdleSurvivesBurst(t *testing.T) {
+	now := time.Date(2023, 10, 1, 12, 0, 0, 0, time.UTC)
+	broker := NewWatchBroker(1, time.Minute)
+	broker.now = func() time.Time { return now }
+	idle := broker.Subscribe()
+
+	// no event for a long time, then a burst faster than the client reads
+	now = now.Add(time.Hour)
+	broker.Publish(WatchEvent{Type: WatchAdded, Name: "a"})
+	broker.Publish(WatchEvent{Type: WatchAdded, Name: "b"})
+
+	if ev, err := idle.Next(context.Background()); err != nil || ev.Type != WatchResync {
+		t.Errorf("Next() = %v, %v, want %v", ev, err, WatchResync)
+	}
+	// having taken an event, it overflows afresh later
+	now = now.Add(time.Hour)
+	broker.Publish(WatchEvent{Type: WatchAdded, Name: "c"})
+	broker.Publish(WatchEvent{Type: WatchAdded, Name: "d"})
+	if ev, err := idle.Next(context.Background()); err != nil || ev.Type != WatchResync {
+		t.Errorf("Next() = %v, %v, want %v", ev, err, WatchResync)
+	}
+}